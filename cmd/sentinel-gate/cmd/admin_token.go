@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+var (
+	adminTokenAddr    string
+	adminTokenScopes  []string
+	adminTokenExpires time.Duration
+	adminTokenJSON    bool
+)
+
+var adminTokenCmd = &cobra.Command{
+	Use:   "admin-token",
+	Short: "Manage scoped tokens for automating the admin API",
+	Long: `Create, list and revoke admin API tokens on the running server.
+
+Admin tokens let automation such as CI pipelines use the admin API from
+another host, limited to the scopes the token was granted:
+
+  read-only        read everything except audit data
+  policies:write   read and change policies and policy variables
+  upstreams:write  read and change upstreams
+  audit:read       read the audit log and session recordings
+
+Send the token as "Authorization: Bearer <token>". Admin tokens are not
+MCP API keys: they do not authenticate agents on /mcp.
+
+Like "status", these commands talk to the admin API of the running
+server, which only accepts token management from localhost.
+
+Examples:
+  # Token for a CI pipeline deploying policies, valid for 90 days
+  sentinel-gate admin-token create ci-policies --scope policies:write --expires 2160h
+
+  sentinel-gate admin-token list
+  sentinel-gate admin-token revoke <id>`,
+}
+
+var adminTokenCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create an admin token and print it once",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runAdminTokenCreate,
+}
+
+var adminTokenListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List admin tokens",
+	Args:  cobra.NoArgs,
+	RunE:  runAdminTokenList,
+}
+
+var adminTokenRevokeCmd = &cobra.Command{
+	Use:   "revoke <id>",
+	Short: "Revoke an admin token (a revoked token is deleted)",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runAdminTokenRevoke,
+}
+
+func init() {
+	adminTokenCmd.PersistentFlags().StringVar(&adminTokenAddr, "addr", "", "Server address or URL (default: server.http_addr from config)")
+	adminTokenCreateCmd.Flags().StringSliceVar(&adminTokenScopes, "scope", nil, "Scope to grant, repeatable ("+strings.Join(service.AdminTokenScopes, ", ")+")")
+	adminTokenCreateCmd.Flags().DurationVar(&adminTokenExpires, "expires", 0, "Lifetime of the token, e.g. 720h (default: never expires)")
+	adminTokenListCmd.Flags().BoolVar(&adminTokenJSON, "json", false, "Print the tokens as JSON")
+	_ = adminTokenCreateCmd.MarkFlagRequired("scope")
+	adminTokenCmd.AddCommand(adminTokenCreateCmd, adminTokenListCmd, adminTokenRevokeCmd)
+	rootCmd.AddCommand(adminTokenCmd)
+}
+
+func runAdminTokenCreate(cmd *cobra.Command, args []string) error {
+	client, err := newAdminAPIClient(adminTokenAddr)
+	if err != nil {
+		return err
+	}
+	body := map[string]any{"name": args[0], "scopes": adminTokenScopes}
+	if adminTokenExpires > 0 {
+		body["expires_at"] = time.Now().Add(adminTokenExpires).UTC()
+	}
+	var created struct {
+		service.AdminToken
+		Token string `json:"token"`
+	}
+	if err := client.do(cmd.Context(), http.MethodPost, "/admin/api/admin-tokens", body, &created); err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Created admin token %q (%s)\n", created.Name, created.ID)
+	fmt.Fprintf(out, "Scopes: %s\n", strings.Join(created.Scopes, ", "))
+	if created.ExpiresAt != nil {
+		fmt.Fprintf(out, "Expires: %s\n", created.ExpiresAt.Format(time.RFC3339))
+	}
+	fmt.Fprintf(out, "\n%s\n\nStore it now: it is not shown again.\n", created.Token)
+	return nil
+}
+
+func runAdminTokenList(cmd *cobra.Command, args []string) error {
+	client, err := newAdminAPIClient(adminTokenAddr)
+	if err != nil {
+		return err
+	}
+	var tokens []service.AdminToken
+	if err := client.do(cmd.Context(), http.MethodGet, "/admin/api/admin-tokens", nil, &tokens); err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+	if adminTokenJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(tokens)
+	}
+	if len(tokens) == 0 {
+		fmt.Fprintln(out, "No admin tokens.")
+		return nil
+	}
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tSCOPES\tEXPIRES\tSTATUS")
+	now := time.Now()
+	for _, t := range tokens {
+		expires := "never"
+		if t.ExpiresAt != nil {
+			expires = t.ExpiresAt.Format(time.RFC3339)
+		}
+		status := "active"
+		switch {
+		case t.Revoked:
+			status = "revoked"
+		case t.Expired(now):
+			status = "expired"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", t.ID, t.Name, strings.Join(t.Scopes, ","), expires, status)
+	}
+	return tw.Flush()
+}
+
+func runAdminTokenRevoke(cmd *cobra.Command, args []string) error {
+	client, err := newAdminAPIClient(adminTokenAddr)
+	if err != nil {
+		return err
+	}
+	if err := client.do(cmd.Context(), http.MethodDelete, "/admin/api/admin-tokens/"+url.PathEscape(args[0]), nil, nil); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Revoked admin token %s\n", args[0])
+	return nil
+}
+
+// adminAPIClient calls the admin API of the running server from localhost.
+// It keeps the CSRF cookie the server sets on reads and echoes it on
+// changes, like the admin UI does.
+type adminAPIClient struct {
+	base string
+	http *http.Client
+}
+
+func newAdminAPIClient(addr string) (*adminAPIClient, error) {
+	base, err := statusBaseURL(addr)
+	if err != nil {
+		return nil, err
+	}
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &adminAPIClient{base: base, http: &http.Client{Timeout: 10 * time.Second, Jar: jar}}, nil
+}
+
+// do sends a request with an optional JSON body and decodes the JSON
+// response into out when it is non-nil.
+func (c *adminAPIClient) do(ctx context.Context, method, path string, body, out any) error {
+	var csrfToken string
+	if method != http.MethodGet {
+		// A read sets the CSRF cookie.
+		if err := c.do(ctx, http.MethodGet, "/admin/api/auth/status", nil, nil); err != nil {
+			return err
+		}
+		u, _ := url.Parse(c.base)
+		for _, cookie := range c.http.Jar.Cookies(u) {
+			if cookie.Name == "sentinel_csrf_token" {
+				csrfToken = cookie.Value
+			}
+		}
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if csrfToken != "" {
+		req.Header.Set("X-CSRF-Token", csrfToken)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&apiErr)
+		if apiErr.Error != "" {
+			return fmt.Errorf("%s %s: %s (%d)", method, path, apiErr.Error, resp.StatusCode)
+		}
+		return fmt.Errorf("%s %s: HTTP %d", method, path, resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
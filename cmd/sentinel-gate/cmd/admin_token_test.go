@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/inbound/admin"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+func TestAdminAPIClient(t *testing.T) {
+	tokens := service.NewAdminTokenService(nil, slog.Default())
+	h := admin.NewAdminAPIHandler(admin.WithAdminTokenService(tokens), admin.WithAPILogger(slog.Default()))
+	srv := httptest.NewServer(h.Routes())
+	defer srv.Close()
+
+	client, err := newAdminAPIClient(srv.URL)
+	if err != nil {
+		t.Fatalf("newAdminAPIClient(): %v", err)
+	}
+	ctx := context.Background()
+
+	// Changes go through the CSRF check like the admin UI.
+	var created struct {
+		service.AdminToken
+		Token string `json:"token"`
+	}
+	body := map[string]any{"name": "ci", "scopes": []string{service.AdminScopeReadOnly}}
+	if err := client.do(ctx, http.MethodPost, "/admin/api/admin-tokens", body, &created); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if !strings.HasPrefix(created.Token, service.AdminTokenPrefix) {
+		t.Errorf("token = %q", created.Token)
+	}
+
+	var list []service.AdminToken
+	if err := client.do(ctx, http.MethodGet, "/admin/api/admin-tokens", nil, &list); err != nil || len(list) != 1 {
+		t.Fatalf("list = %+v, %v", list, err)
+	}
+	if err := client.do(ctx, http.MethodDelete, "/admin/api/admin-tokens/"+created.ID, nil, nil); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+
+	err = client.do(ctx, http.MethodPost, "/admin/api/admin-tokens", map[string]any{"name": "ci"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "at least one scope") {
+		t.Errorf("create without scope error = %v", err)
+	}
+}
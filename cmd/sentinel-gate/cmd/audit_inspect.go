@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	auditadapter "github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/audit"
+	evidence "github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/evidence"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+)
+
+var (
+	auditInspectKeyFile    string
+	auditInspectPubKeyFile string
+	auditInspectConvert    string
+	auditInspectOut        string
+	auditInspectTop        int
+)
+
+// errAuditInspectFailed makes the command exit non-zero after the report
+// has been printed.
+var errAuditInspectFailed = errors.New("audit file failed inspection")
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Inspect and verify audit files",
+	Long: `Work with audit files outside a running server: the daily audit logs
+(audit-*.log, optionally .zst compressed), evidence files and CSV exports
+from the admin API.`,
+}
+
+var auditInspectCmd = &cobra.Command{
+	Use:   "inspect <file>",
+	Short: "Validate, summarize and convert an exported audit file",
+	Long: `Check an exported audit file and print a summary, without a running server.
+
+Checks:
+  1. Every line is a valid audit record (JSON Lines) or CSV row
+  2. Timestamps are in order (ascending or descending, as the file starts)
+  3. For evidence files, the hash chain is unbroken and, when a key is
+     given with --key-file or --pub-key, every signature is valid
+
+The summary shows the time range and the records per decision, tool and
+identity. Files ending in .csv are read as admin API CSV exports, files
+ending in .zst are decompressed. The command exits non-zero when a check
+fails.
+
+With --convert the records are also written as jsonl, csv or parquet, to
+--out or stdout (Parquet needs --out); the report then goes to stderr.
+
+Examples:
+  sentinel-gate audit inspect audit-2026-01-14.log.zst
+  sentinel-gate audit inspect evidence.jsonl --pub-key public-key.pem
+  sentinel-gate audit inspect export.csv --convert parquet --out audit.parquet`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAuditInspect,
+}
+
+func init() {
+	auditInspectCmd.Flags().StringVar(&auditInspectKeyFile, "key-file", "", "Evidence signing key PEM file, to verify signatures")
+	auditInspectCmd.Flags().StringVar(&auditInspectPubKeyFile, "pub-key", "", "Evidence public key PEM file, to verify signatures")
+	auditInspectCmd.Flags().StringVar(&auditInspectConvert, "convert", "", "Also write the records in this format: jsonl, csv or parquet")
+	auditInspectCmd.Flags().StringVarP(&auditInspectOut, "out", "o", "", "Write the converted records to this file instead of stdout")
+	auditInspectCmd.Flags().IntVar(&auditInspectTop, "top", 10, "Number of tools and identities to list in the summary")
+	auditInspectCmd.MarkFlagsMutuallyExclusive("key-file", "pub-key")
+	auditCmd.AddCommand(auditInspectCmd)
+	rootCmd.AddCommand(auditCmd)
+}
+
+func runAuditInspect(cmd *cobra.Command, args []string) error {
+	switch auditInspectConvert {
+	case "", auditadapter.ExportFormatJSONL, auditadapter.ExportFormatCSV:
+	case auditadapter.ExportFormatParquet:
+		if auditInspectOut == "" {
+			return errors.New("--convert parquet needs --out")
+		}
+	default:
+		return fmt.Errorf("unknown --convert format %q (want jsonl, csv or parquet)", auditInspectConvert)
+	}
+
+	path := args[0]
+	r, err := auditadapter.OpenExport(path)
+	if err != nil {
+		return fmt.Errorf("open audit file: %w", err)
+	}
+	data, err := io.ReadAll(r)
+	_ = r.Close()
+	if err != nil {
+		return fmt.Errorf("read audit file: %w", err)
+	}
+
+	csvInput := strings.HasSuffix(strings.TrimSuffix(path, ".zst"), ".csv")
+	in, records, err := auditadapter.InspectExport(bytes.NewReader(data), csvInput)
+	if err != nil {
+		return err
+	}
+
+	var chain *evidence.VerifyResult
+	if in.Format == "evidence" {
+		if chain, err = verifyAuditInspectChain(data); err != nil {
+			return err
+		}
+	} else if auditInspectKeyFile != "" || auditInspectPubKeyFile != "" {
+		return fmt.Errorf("%s is not an evidence file: it has no signatures to verify", path)
+	}
+
+	// The report goes to stderr when the converted records go to stdout.
+	w := cmd.OutOrStdout()
+	if auditInspectConvert != "" {
+		w = cmd.ErrOrStderr()
+	}
+	ok := printAuditInspection(w, path, in, chain)
+
+	if auditInspectConvert != "" {
+		if err := writeAuditConversion(cmd.OutOrStdout(), records); err != nil {
+			return err
+		}
+		if auditInspectOut != "" {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Wrote %d records as %s to %s\n", len(records), auditInspectConvert, auditInspectOut)
+		}
+	}
+
+	if !ok {
+		cmd.SilenceUsage = true
+		return errAuditInspectFailed
+	}
+	return nil
+}
+
+// verifyAuditInspectChain checks the hash chain of an evidence file, and
+// its signatures when a key was given.
+func verifyAuditInspectChain(data []byte) (*evidence.VerifyResult, error) {
+	var verifier *evidence.ECDSAVerifier
+	switch {
+	case auditInspectPubKeyFile != "":
+		pubKeyPEM, err := os.ReadFile(auditInspectPubKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("read public key: %w", err)
+		}
+		if verifier, err = evidence.NewECDSAVerifier(pubKeyPEM); err != nil {
+			return nil, fmt.Errorf("parse public key: %w", err)
+		}
+	case auditInspectKeyFile != "":
+		var err error
+		if verifier, err = evidence.NewECDSAVerifierFromKeyFile(auditInspectKeyFile); err != nil {
+			return nil, fmt.Errorf("load verification key: %w", err)
+		}
+	}
+	return evidence.VerifyReader(bytes.NewReader(data), verifier)
+}
+
+// printAuditInspection writes the inspection report and reports whether
+// every check passed.
+func printAuditInspection(w io.Writer, path string, in *auditadapter.Inspection, chain *evidence.VerifyResult) bool {
+	fmt.Fprintf(w, "Inspecting %s (%s)\n\n", path, in.Format)
+	fmt.Fprintf(w, "Records:    %d\n", in.Records)
+	fmt.Fprintf(w, "Invalid:    %d\n", in.Invalid)
+	if in.Records > 0 {
+		fmt.Fprintf(w, "Time range: %s - %s\n", in.First.UTC().Format(time.RFC3339), in.Last.UTC().Format(time.RFC3339))
+	}
+	switch {
+	case in.OutOfOrder > 0:
+		fmt.Fprintf(w, "Order:      %d records out of %s order, first at line %d\n", in.OutOfOrder, in.Order, in.FirstOutOfOrder)
+	case in.Order != "":
+		fmt.Fprintf(w, "Order:      %s\n", in.Order)
+	}
+
+	ok := in.Valid()
+	if chain != nil {
+		switch {
+		case !chain.ChainValid:
+			fmt.Fprintf(w, "Hash chain: BROKEN at record %d\n", chain.ChainBreakAt)
+		case chain.PartialChain:
+			fmt.Fprintf(w, "Hash chain: VALID (partial — starts mid-chain)\n")
+		default:
+			fmt.Fprintf(w, "Hash chain: VALID (complete from genesis)\n")
+		}
+		if chain.SignaturesChecked {
+			fmt.Fprintf(w, "Signatures: %d valid, %d invalid\n", chain.ValidSignatures, chain.InvalidSigs)
+		} else {
+			fmt.Fprintf(w, "Signatures: not checked (use --key-file or --pub-key)\n")
+		}
+		ok = ok && chain.ChainValid && chain.InvalidSigs == 0
+	}
+
+	if in.Records > 0 {
+		fmt.Fprintf(w, "\nBy decision:\n")
+		for _, c := range auditadapter.TopCounts(in.ByDecision, 0) {
+			fmt.Fprintf(w, "  %-30s %d\n", auditInspectLabel(c.Key), c.Count)
+		}
+		fmt.Fprintf(w, "\nTop tools:\n")
+		for _, c := range auditadapter.TopCounts(in.ByTool, auditInspectTop) {
+			fmt.Fprintf(w, "  %-30s %d\n", auditInspectLabel(c.Key), c.Count)
+		}
+		fmt.Fprintf(w, "\nTop identities:\n")
+		for _, c := range auditadapter.TopCounts(in.ByIdentity, auditInspectTop) {
+			fmt.Fprintf(w, "  %-30s %d\n", auditInspectLabel(c.Key), c.Count)
+		}
+	}
+
+	if in.FirstInvalid != "" {
+		fmt.Fprintf(w, "\nFirst error: %s\n", in.FirstInvalid)
+	} else if chain != nil && chain.FirstError != "" {
+		fmt.Fprintf(w, "\nFirst error: %s\n", chain.FirstError)
+	}
+
+	fmt.Fprintln(w)
+	switch {
+	case in.Records == 0:
+		fmt.Fprintln(w, "FAIL - File contains no records.")
+	case ok:
+		fmt.Fprintln(w, "PASS - All checks passed.")
+	default:
+		fmt.Fprintln(w, "FAIL - File failed inspection.")
+	}
+	return ok
+}
+
+func auditInspectLabel(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
+
+// writeAuditConversion writes the records in the --convert format to --out,
+// or to stdout.
+func writeAuditConversion(stdout io.Writer, records []audit.AuditRecord) error {
+	if auditInspectOut == "" {
+		return auditadapter.WriteExport(stdout, auditInspectConvert, records)
+	}
+	f, err := os.OpenFile(auditInspectOut, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("create output file: %w", err)
+	}
+	if err := auditadapter.WriteExport(f, auditInspectConvert, records); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("write %s: %w", auditInspectOut, err)
+	}
+	return f.Close()
+}
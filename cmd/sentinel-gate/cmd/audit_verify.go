@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	auditadapter "github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/audit"
+	evidence "github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/evidence"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/config"
+)
+
+var (
+	auditVerifyKeyFile            string
+	auditVerifyPubKeyFile         string
+	auditVerifyCheckpointInterval time.Duration
+)
+
+// checkpointSlack is the delay tolerated between the checkpoint interval
+// and the checkpoints actually written.
+const checkpointSlack = time.Minute
+
+// errAuditVerifyFailed makes the command exit non-zero after the report
+// has been printed.
+var errAuditVerifyFailed = errors.New("audit hash chain verification failed")
+
+var auditVerifyCmd = &cobra.Command{
+	Use:   "verify [dir]",
+	Short: "Verify the hash chain and checkpoints of the audit files",
+	Long: `Check the audit files written with audit_file.chain enabled for tampering,
+without a running server. The directory defaults to audit_file.dir.
+
+Checks:
+  1. Every chained record matches its hash (no record was modified)
+  2. Every record links to the previous one (none was deleted, inserted
+     or duplicated)
+  3. Every checkpoint in audit-checkpoints.jsonl names a record still in
+     the chain (records removed from the end are found too) and, when a
+     key is given with --key-file or --pub-key, its signature is valid
+  4. Checkpoints cover the chain: at least one checkpoint names a record
+     still in the chain, and the last record is at most one checkpoint
+     interval newer than the newest checkpoint. Records after the newest
+     checkpoint could be rewritten together with their hashes.
+
+Records written before the chain was enabled are counted but not
+protected. When retention deleted the oldest files the chain is reported
+as partial. The checkpoint interval defaults to audit_file.checkpoint_interval
+("0s" skips check 4). The command exits non-zero when tampering is found or
+checkpoints do not cover the chain, and warns when signatures were not
+checked or the newest checkpoint is older than the interval.
+
+Examples:
+  sentinel-gate audit verify
+  sentinel-gate audit verify /var/lib/sentinel-gate/audit --pub-key public-key.pem`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runAuditVerify,
+}
+
+func init() {
+	auditVerifyCmd.Flags().StringVar(&auditVerifyKeyFile, "key-file", "", "Evidence signing key PEM file, to verify checkpoint signatures")
+	auditVerifyCmd.Flags().StringVar(&auditVerifyPubKeyFile, "pub-key", "", "Evidence public key PEM file, to verify checkpoint signatures")
+	auditVerifyCmd.Flags().DurationVar(&auditVerifyCheckpointInterval, "checkpoint-interval", time.Hour, "Expected time between checkpoints (default audit_file.checkpoint_interval)")
+	auditVerifyCmd.MarkFlagsMutuallyExclusive("key-file", "pub-key")
+	auditCmd.AddCommand(auditVerifyCmd)
+}
+
+func runAuditVerify(cmd *cobra.Command, args []string) error {
+	// The directory argument makes the config optional.
+	cfg, err := config.LoadConfigRaw()
+	if err != nil && len(args) == 0 {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	var dir string
+	if len(args) > 0 {
+		dir = args[0]
+	} else {
+		dir = cfg.AuditFile.Dir
+	}
+	interval := auditVerifyCheckpointInterval
+	if !cmd.Flags().Changed("checkpoint-interval") && cfg != nil && cfg.AuditFile.CheckpointInterval != "" {
+		if d, err := time.ParseDuration(cfg.AuditFile.CheckpointInterval); err == nil {
+			interval = d
+		}
+	}
+	if dir == "" {
+		return errors.New("no audit files: set audit_file.dir in the config or pass the directory")
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("audit directory: %w", err)
+	}
+
+	var verifier auditadapter.CheckpointVerifier
+	switch {
+	case auditVerifyPubKeyFile != "":
+		pubKeyPEM, err := os.ReadFile(auditVerifyPubKeyFile)
+		if err != nil {
+			return fmt.Errorf("read public key: %w", err)
+		}
+		v, err := evidence.NewECDSAVerifier(pubKeyPEM)
+		if err != nil {
+			return fmt.Errorf("parse public key: %w", err)
+		}
+		verifier = v
+	case auditVerifyKeyFile != "":
+		v, err := evidence.NewECDSAVerifierFromKeyFile(auditVerifyKeyFile)
+		if err != nil {
+			return fmt.Errorf("load verification key: %w", err)
+		}
+		verifier = v
+	}
+
+	result, err := auditadapter.VerifyChain(dir, verifier)
+	if err != nil {
+		return err
+	}
+	if !printAuditVerification(cmd.OutOrStdout(), dir, result, interval, time.Now()) {
+		cmd.SilenceUsage = true
+		return errAuditVerifyFailed
+	}
+	return nil
+}
+
+// printAuditVerification writes the verification report and reports
+// whether the chain is intact and covered by checkpoints written every
+// interval (0 when checkpoints are disabled).
+func printAuditVerification(w io.Writer, dir string, v *auditadapter.ChainVerification, interval time.Duration, now time.Time) bool {
+	fmt.Fprintf(w, "Verifying audit files in %s\n\n", dir)
+	fmt.Fprintf(w, "Files:       %d\n", v.Files)
+	fmt.Fprintf(w, "Records:     %d chained", v.Records)
+	if v.Unchained > 0 {
+		fmt.Fprintf(w, ", %d written before the chain was enabled", v.Unchained)
+	}
+	fmt.Fprintln(w)
+	switch {
+	case v.Records == 0:
+		fmt.Fprintf(w, "Hash chain:  none (audit_file.chain is not enabled)\n")
+	case !v.Valid():
+		fmt.Fprintf(w, "Hash chain:  BROKEN\n")
+	case v.Partial:
+		fmt.Fprintf(w, "Hash chain:  VALID (partial — older files deleted by retention)\n")
+	default:
+		fmt.Fprintf(w, "Hash chain:  VALID (complete from genesis)\n")
+	}
+	if v.HeadFile != "" {
+		fmt.Fprintf(w, "Last record: %s:%d", v.HeadFile, v.HeadLine)
+		if !v.HeadTime.IsZero() {
+			fmt.Fprintf(w, " (%s)", v.HeadTime.UTC().Format(time.RFC3339))
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "Checkpoints: %d, %d matched, %d of deleted files\n", v.Checkpoints, v.CheckpointsMatched, v.CheckpointsExpired)
+	if !v.LastCheckpoint.IsZero() {
+		fmt.Fprintf(w, "Newest:      %s\n", v.LastCheckpoint.UTC().Format(time.RFC3339))
+	}
+	if v.SignaturesChecked {
+		fmt.Fprintf(w, "Signatures:  %d valid, %d invalid\n", v.Checkpoints-v.InvalidSignatures, v.InvalidSignatures)
+	} else {
+		fmt.Fprintf(w, "Signatures:  not checked (use --key-file or --pub-key)\n")
+	}
+
+	if v.FirstProblem != "" {
+		fmt.Fprintf(w, "\nFirst problem (%d total): %s\n", v.Problems, v.FirstProblem)
+	}
+
+	// Without a checkpoint after them, records can be rewritten together
+	// with their hashes, so the chain alone proves nothing about them.
+	var uncovered string
+	var warnings []string
+	if v.Records > 0 && v.Valid() {
+		switch {
+		case interval <= 0:
+			warnings = append(warnings, "checkpoints are disabled (audit_file.checkpoint_interval is 0s): the records could be rewritten together with their hashes")
+		case v.CheckpointsMatched == 0:
+			uncovered = "No checkpoint covers the chained records; they could be rewritten together with their hashes."
+		case !v.HeadTime.IsZero() && v.HeadTime.After(v.LastCheckpoint.Add(interval+checkpointSlack)):
+			uncovered = fmt.Sprintf("No checkpoint since %s, but records continue until %s; checkpoints are expected every %s.",
+				v.LastCheckpoint.UTC().Format(time.RFC3339), v.HeadTime.UTC().Format(time.RFC3339), interval)
+		case now.After(v.LastCheckpoint.Add(interval + checkpointSlack)):
+			warnings = append(warnings, fmt.Sprintf("the newest checkpoint is %s old, more than the %s interval: records written since then may have been removed (expected when the gateway is stopped)",
+				now.Sub(v.LastCheckpoint).Round(time.Minute), interval))
+		}
+		if !v.SignaturesChecked && v.Checkpoints > 0 {
+			warnings = append(warnings, "checkpoint signatures were not checked: without --key-file or --pub-key, forged checkpoints are not detected")
+		}
+	}
+	if len(warnings) > 0 {
+		fmt.Fprintln(w)
+		for _, warning := range warnings {
+			fmt.Fprintf(w, "WARNING: %s\n", warning)
+		}
+	}
+
+	ok := v.Valid() && v.Records > 0 && uncovered == ""
+	fmt.Fprintln(w)
+	switch {
+	case v.Records == 0 && v.Valid():
+		fmt.Fprintln(w, "FAIL - No chained records to verify.")
+	case !v.Valid():
+		fmt.Fprintln(w, "FAIL - Audit files were tampered with.")
+	case uncovered != "":
+		fmt.Fprintf(w, "FAIL - %s\n", uncovered)
+	default:
+		fmt.Fprintln(w, "PASS - No tampering found.")
+	}
+	return ok
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	auditadapter "github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/audit"
+)
+
+func TestPrintAuditVerification(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	covered := func() *auditadapter.ChainVerification {
+		return &auditadapter.ChainVerification{
+			Files: 1, Records: 10, HeadFile: "audit-2026-03-02.log", HeadLine: 10,
+			HeadTime:    now.Add(-40 * time.Minute),
+			Checkpoints: 3, CheckpointsMatched: 3,
+			LastCheckpoint:    now.Add(-30 * time.Minute),
+			SignaturesChecked: true,
+		}
+	}
+
+	tests := []struct {
+		name     string
+		edit     func(v *auditadapter.ChainVerification)
+		interval time.Duration
+		wantOK   bool
+		want     []string
+		notWant  []string
+	}{
+		{"covered and signed", func(v *auditadapter.ChainVerification) {}, time.Hour, true,
+			[]string{"PASS"}, []string{"WARNING"}},
+		{"no checkpoints", func(v *auditadapter.ChainVerification) {
+			v.Checkpoints, v.CheckpointsMatched, v.LastCheckpoint = 0, 0, time.Time{}
+		}, time.Hour, false, []string{"FAIL - No checkpoint covers"}, nil},
+		{"only checkpoints of deleted files", func(v *auditadapter.ChainVerification) {
+			v.CheckpointsMatched, v.CheckpointsExpired, v.LastCheckpoint = 0, 3, time.Time{}
+		}, time.Hour, false, []string{"FAIL - No checkpoint covers"}, nil},
+		{"records after the newest checkpoint", func(v *auditadapter.ChainVerification) {
+			v.LastCheckpoint = now.Add(-5 * time.Hour)
+		}, time.Hour, false, []string{"FAIL - No checkpoint since"}, nil},
+		{"newest checkpoint older than the interval", func(v *auditadapter.ChainVerification) {
+			v.HeadTime = now.Add(-3 * time.Hour)
+			v.LastCheckpoint = now.Add(-3 * time.Hour)
+		}, time.Hour, true, []string{"WARNING: the newest checkpoint is 3h0m0s old", "PASS"}, nil},
+		{"signatures not checked", func(v *auditadapter.ChainVerification) {
+			v.SignaturesChecked = false
+		}, time.Hour, true, []string{"WARNING: checkpoint signatures were not checked", "PASS"}, nil},
+		{"checkpoints disabled", func(v *auditadapter.ChainVerification) {
+			v.Checkpoints, v.CheckpointsMatched, v.LastCheckpoint = 0, 0, time.Time{}
+		}, 0, true, []string{"WARNING: checkpoints are disabled", "PASS"}, nil},
+		{"tampered", func(v *auditadapter.ChainVerification) {
+			v.Problems, v.FirstProblem = 1, "audit-2026-03-02.log:4: record does not match its hash (modified)"
+		}, time.Hour, false, []string{"FAIL - Audit files were tampered with."}, []string{"WARNING"}},
+		{"no chained records", func(v *auditadapter.ChainVerification) {
+			*v = auditadapter.ChainVerification{Files: 1, Unchained: 5}
+		}, time.Hour, false, []string{"FAIL - No chained records to verify."}, []string{"WARNING"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := covered()
+			tt.edit(v)
+			var buf bytes.Buffer
+			ok := printAuditVerification(&buf, "/var/audit", v, tt.interval, now)
+			out := buf.String()
+			if ok != tt.wantOK {
+				t.Errorf("printAuditVerification() = %v, want %v\n%s", ok, tt.wantOK, out)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(out, s) {
+					t.Errorf("output does not contain %q:\n%s", s, out)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(out, s) {
+					t.Errorf("output contains %q:\n%s", s, out)
+				}
+			}
+		})
+	}
+}
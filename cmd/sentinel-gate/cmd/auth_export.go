@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/config"
+)
+
+// authPassphraseEnv holds the export passphrase when --passphrase-file is not given.
+const authPassphraseEnv = "SENTINEL_GATE_EXPORT_PASSPHRASE"
+
+var (
+	authPassphraseFile string
+	authExportOut      string
+)
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Export and import identities, API keys and policies",
+	Long: `Move the authentication setup of one SentinelGate host to another, e.g. to
+prepare a cold standby for disaster recovery.
+
+The export holds the identities, the API key hashes (never the keys
+themselves) and the policies created through the admin API. It is encrypted
+with AES-256-GCM under a key derived from a passphrase with Argon2id. Entries
+that come from the YAML config are not exported; copy the config instead.
+
+The passphrase is read from --passphrase-file or from the
+SENTINEL_GATE_EXPORT_PASSPHRASE environment variable.`,
+}
+
+var authExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Write an encrypted export of identities, API keys and policies",
+	Long: `Write an encrypted export of the identities, API key hashes and policies
+in state.json. The export can be taken while the server is running.
+
+Examples:
+  sentinel-gate auth export --passphrase-file /run/secrets/dr-passphrase --out auth.sgx
+  SENTINEL_GATE_EXPORT_PASSPHRASE=... sentinel-gate auth export > auth.sgx`,
+	Args: cobra.NoArgs,
+	RunE: runAuthExport,
+}
+
+var authImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Merge an encrypted export into state.json",
+	Long: `Merge an export written by "auth export" into state.json.
+
+Local entries are never clobbered by older ones: an identity or policy that
+exists on both hosts is only replaced when the exported copy was updated more
+recently, entries from the YAML config are never replaced, and existing API
+keys are only ever revoked. Importing into a host without a state file also
+restores the default policy.
+
+Run the import while the server is stopped, or restart it afterwards: a
+running server does not reload state.json.
+
+Example:
+  sentinel-gate auth import --passphrase-file /run/secrets/dr-passphrase auth.sgx`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAuthImport,
+}
+
+func init() {
+	authCmd.PersistentFlags().StringVar(&authPassphraseFile, "passphrase-file", "", "File holding the export passphrase (default: $"+authPassphraseEnv+")")
+	authExportCmd.Flags().StringVarP(&authExportOut, "out", "o", "", "Write the export to this file instead of stdout")
+	authCmd.AddCommand(authExportCmd, authImportCmd)
+	rootCmd.AddCommand(authCmd)
+}
+
+func runAuthExport(cmd *cobra.Command, args []string) error {
+	passphrase, err := readAuthPassphrase()
+	if err != nil {
+		return err
+	}
+	store, closeStore, err := authStateStore(false)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+	if !store.Exists() {
+		return fmt.Errorf("no state at %s", store.Path())
+	}
+	appState, err := store.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	bundle := state.ExportAuth(appState)
+	for _, k := range bundle.APIKeys {
+		if state.IsEncrypted(k.KeyHash) {
+			return fmt.Errorf("API key hashes in %s are encrypted and the credentials key was not found", store.Path())
+		}
+	}
+	data, err := state.SealAuthBundle(bundle, passphrase)
+	if err != nil {
+		return err
+	}
+
+	if authExportOut == "" {
+		_, err = cmd.OutOrStdout().Write(append(data, '\n'))
+		return err
+	}
+	if err := os.WriteFile(authExportOut, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("write export: %w", err)
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "Exported %d identities, %d API keys and %d policies to %s\n",
+		len(bundle.Identities), len(bundle.APIKeys), len(bundle.Policies), authExportOut)
+	return nil
+}
+
+func runAuthImport(cmd *cobra.Command, args []string) error {
+	passphrase, err := readAuthPassphrase()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("read export: %w", err)
+	}
+	bundle, err := state.OpenAuthBundle(data, passphrase)
+	if err != nil {
+		return err
+	}
+
+	store, closeStore, err := authStateStore(true)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+	fresh := !store.Exists()
+	var res state.AuthMergeResult
+	if err := store.Mutate(func(appState *state.AppState) error {
+		res = state.MergeAuth(appState, bundle)
+		if fresh && bundle.DefaultPolicy != "" {
+			appState.DefaultPolicy = bundle.DefaultPolicy
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+
+	w := cmd.OutOrStdout()
+	fmt.Fprintf(w, "Imported export from %s into %s\n", bundle.ExportedAt.Format("2006-01-02 15:04:05 UTC"), store.Path())
+	fmt.Fprintf(w, "  identities: %d added, %d updated, %d kept\n", res.IdentitiesAdded, res.IdentitiesUpdated, res.IdentitiesKept)
+	fmt.Fprintf(w, "  API keys:   %d added, %d revoked, %d kept, %d skipped (unknown identity)\n", res.KeysAdded, res.KeysRevoked, res.KeysKept, res.KeysSkipped)
+	fmt.Fprintf(w, "  policies:   %d added, %d updated, %d kept\n", res.PoliciesAdded, res.PoliciesUpdated, res.PoliciesKept)
+	fmt.Fprintln(w, "Restart SentinelGate if it is running to load the imported entries.")
+	return nil
+}
+
+// readAuthPassphrase returns the passphrase from --passphrase-file or the
+// environment. A single trailing newline in the file is ignored.
+func readAuthPassphrase() (string, error) {
+	if authPassphraseFile != "" {
+		data, err := os.ReadFile(authPassphraseFile)
+		if err != nil {
+			return "", fmt.Errorf("read passphrase file: %w", err)
+		}
+		passphrase := strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r")
+		if passphrase == "" {
+			return "", errors.New("passphrase file is empty")
+		}
+		return passphrase, nil
+	}
+	if passphrase := os.Getenv(authPassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+	return "", fmt.Errorf("no passphrase: use --passphrase-file or set %s", authPassphraseEnv)
+}
+
+// authStateStore opens the state store the start command uses, with the
+// key that encrypts its secrets. With create, a missing key is generated;
+// otherwise the store reads secrets as they are stored. The returned
+// function closes the store.
+func authStateStore(create bool) (state.Store, func(), error) {
+	statePath := stateFilePath
+	if statePath == "" {
+		statePath = os.Getenv("SENTINEL_GATE_STATE_PATH")
+	}
+	if statePath == "" {
+		statePath = "./state.json"
+	}
+
+	cfg, err := config.LoadConfigRaw()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	cfg.SetDefaults()
+	store, closeStore, err := openStateStore(context.Background(), cfg, statePath, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		return nil, nil, err
+	}
+	key, _, _, err := loadCredentialsKey(cfg, statePath, create)
+	if err != nil {
+		if !create && errors.Is(err, fs.ErrNotExist) {
+			return store, closeStore, nil
+		}
+		closeStore()
+		return nil, nil, fmt.Errorf("failed to load credentials key: %w", err)
+	}
+	c, err := state.NewSecretCipher(key)
+	if err != nil {
+		closeStore()
+		return nil, nil, fmt.Errorf("invalid credentials key: %w", err)
+	}
+	store.SetSecretCipher(c)
+	return store, closeStore, nil
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/inbound/admin"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
 )
 
 // bootAdminAPI creates the AdminAPIHandler with all dependencies.
@@ -10,16 +11,29 @@ func (bc *bootContext) bootAdminAPI() {
 		admin.WithUpstreamService(bc.upstreamService),
 		admin.WithUpstreamManager(bc.upstreamManager),
 		admin.WithDiscoveryService(bc.discoveryService),
+		admin.WithReverseUpstreamHub(bc.reverseHub),
 		admin.WithToolCache(bc.toolCache),
 		admin.WithPolicyService(bc.policyService),
 		admin.WithPolicyStore(bc.policyStore),
 		admin.WithPolicyEvalService(bc.policyEvalService),
 		admin.WithPolicyAdminService(bc.policyAdminService),
+		admin.WithPolicyVariableService(bc.policyVariableService),
+		admin.WithPolicyDirectory(bc.policyDirectory),
+		admin.WithRegoEngine(bc.regoEngine),
+		admin.WithRateLimitOverrideService(bc.rateLimitOverrides),
+		admin.WithRateLimitExemptionService(bc.rateLimitExemptions),
+		admin.WithAdminTokenService(bc.adminTokens),
+		admin.WithNoticeService(bc.noticeService),
 		admin.WithTemplateService(bc.templateService),
 		admin.WithIdentityService(bc.identityService),
 		admin.WithAuditService(bc.auditService),
-		admin.WithAuditReader(bc.auditStore),
+		admin.WithAuditReader(bc.auditReader()),
+		admin.WithAuditStorage(bc.auditStorage()),
+		admin.WithPayloadStore(bc.payloadStoreOrNil()),
 		admin.WithStatsService(bc.statsService),
+		admin.WithToolStatsService(bc.toolStatsService),
+		admin.WithOutboundLearningService(bc.outboundLearningService),
+		admin.WithUpstreamSecretDetection(bc.cfg.Upstream.SecretDetection),
 		admin.WithStateStore(bc.stateStore),
 		admin.WithToolSecurityService(bc.toolSecurityService),
 		admin.WithNotificationService(bc.notificationService),
@@ -32,3 +46,34 @@ func (bc *bootContext) bootAdminAPI() {
 		admin.WithStartTime(bc.startTime),
 	)
 }
+
+// auditReader returns the reader for admin audit queries: the SQLite audit
+// store when audit_sqlite.path is set, otherwise the audit files when
+// audit_file.dir is set, otherwise the in-memory ring buffer.
+func (bc *bootContext) auditReader() admin.AuditReader {
+	if bc.auditSQLiteStore != nil {
+		return &indexedAuditReader{MemoryAuditStore: bc.auditStore, db: bc.auditSQLiteStore}
+	}
+	if bc.auditFileStore == nil {
+		return bc.auditStore
+	}
+	return &historyAuditReader{MemoryAuditStore: bc.auditStore, files: bc.auditFileStore}
+}
+
+// auditStorage returns the audit file storage reporter, or nil when audit
+// files are not configured.
+func (bc *bootContext) auditStorage() audit.StorageReporter {
+	if bc.auditFileStore == nil {
+		return nil
+	}
+	return bc.auditFileStore
+}
+
+// payloadStoreOrNil returns the payload store, or a nil interface when
+// payload sampling is off.
+func (bc *bootContext) payloadStoreOrNil() audit.PayloadStore {
+	if bc.payloadStore == nil {
+		return nil
+	}
+	return bc.payloadStore
+}
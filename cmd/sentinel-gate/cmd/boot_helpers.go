@@ -3,33 +3,63 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
+	"net"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	auditadapter "github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/audit"
+	celeval "github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/cel"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/dns"
+	evidenceAdapter "github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/evidence"
+	mcpclient "github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/mcp"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/memory"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/config"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/denyloop"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/oidc"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/proxy"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/ratelimit"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/tokenexchange"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/validation"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
 )
 
-// createAuditStore creates an audit store based on configuration.
-func createAuditStore(cfg *config.OSSConfig, logger *slog.Logger) (*memory.MemoryAuditStore, error) {
+// createAuditStore creates an audit store based on configuration. For a
+// syslog output the in-memory store only keeps the recent records and the
+// returned syslog store does the writing.
+func createAuditStore(cfg *config.OSSConfig, logger *slog.Logger) (*memory.MemoryAuditStore, *auditadapter.SyslogAuditStore, error) {
 	switch {
 	case cfg.Audit.Output == "stdout":
 		logger.Debug("audit output: stdout", "buffer_size", cfg.Audit.BufferSize)
-		return memory.NewAuditStore(cfg.Audit.BufferSize), nil
+		return memory.NewAuditStore(cfg.Audit.BufferSize), nil, nil
+
+	case strings.HasPrefix(cfg.Audit.Output, "syslog://"), strings.HasPrefix(cfg.Audit.Output, "tcp+tls://"):
+		syslogCfg, err := auditadapter.ParseSyslogURL(cfg.Audit.Output)
+		if err != nil {
+			return nil, nil, err
+		}
+		syslog, err := auditadapter.NewSyslogAuditStore(syslogCfg, logger)
+		if err != nil {
+			return nil, nil, err
+		}
+		logger.Debug("audit output: syslog", "address", syslogCfg.Address, "tls", syslogCfg.TLS, "buffer_size", cfg.Audit.BufferSize)
+		return memory.NewAuditStoreWithWriter(io.Discard, cfg.Audit.BufferSize), syslog, nil
 
 	case strings.HasPrefix(cfg.Audit.Output, "file://"):
 		path := parseFileURI(cfg.Audit.Output)
 		if path == "" {
-			return nil, fmt.Errorf("invalid audit file URI: %s", cfg.Audit.Output)
+			return nil, nil, fmt.Errorf("invalid audit file URI: %s", cfg.Audit.Output)
 		}
 		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
-			return nil, fmt.Errorf("failed to create audit file directory %s: %w", filepath.Dir(path), err)
+			return nil, nil, fmt.Errorf("failed to create audit file directory %s: %w", filepath.Dir(path), err)
 		}
 		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
 		if err != nil {
-			return nil, fmt.Errorf("failed to open audit file %s: %w", path, err)
+			return nil, nil, fmt.Errorf("failed to open audit file %s: %w", path, err)
 		}
 		// Ownership of the fd transfers to the audit store from this point.
 		// MemoryAuditStore.Close() will Sync+Close the file (L-8).
@@ -38,14 +68,333 @@ func createAuditStore(cfg *config.OSSConfig, logger *slog.Logger) (*memory.Memor
 		store := memory.NewAuditStoreWithWriter(f, cfg.Audit.BufferSize)
 		if store == nil {
 			_ = f.Close()
-			return nil, fmt.Errorf("failed to create audit store for file %s", path)
+			return nil, nil, fmt.Errorf("failed to create audit store for file %s", path)
 		}
 		logger.Debug("audit output: file", "path", path, "buffer_size", cfg.Audit.BufferSize)
-		return store, nil
+		return store, nil, nil
 
 	default:
-		return nil, fmt.Errorf("invalid audit output: %s (must be 'stdout' or 'file://path')", cfg.Audit.Output)
+		return nil, nil, fmt.Errorf("invalid audit output: %s (must be 'stdout', 'file://path', 'syslog://host:port' or 'tcp+tls://host:port')", cfg.Audit.Output)
+	}
+}
+
+// createAuditFileStore creates the rotating audit file store configured by
+// the audit_file section, or returns nil when audit_file.dir is not set.
+func createAuditFileStore(cfg *config.OSSConfig, logger *slog.Logger) (*auditadapter.FileAuditStore, error) {
+	if cfg.AuditFile.Dir == "" {
+		return nil, nil
+	}
+	fileCfg := auditadapter.AuditFileConfig{
+		Dir:            cfg.AuditFile.Dir,
+		RetentionDays:  cfg.AuditFile.RetentionDays,
+		MaxFileSizeMB:  cfg.AuditFile.MaxFileSizeMB,
+		CacheSize:      cfg.AuditFile.CacheSize,
+		Compress:       cfg.AuditFile.Compress,
+		MaxTotalSizeMB: cfg.AuditFile.MaxTotalSizeMB,
+		Chain:          cfg.AuditFile.Chain,
+	}
+	if cfg.AuditFile.Chain {
+		// Checkpoints are signed with the evidence key, so one public key
+		// verifies both the evidence file and the audit files.
+		interval := time.Hour
+		if cfg.AuditFile.CheckpointInterval != "" {
+			interval, _ = time.ParseDuration(cfg.AuditFile.CheckpointInterval)
+		}
+		if interval > 0 {
+			signer, err := evidenceAdapter.NewECDSASigner(evidenceKeyPath(cfg), evidenceSignerID(cfg))
+			if err != nil {
+				return nil, fmt.Errorf("create audit checkpoint signer: %w", err)
+			}
+			fileCfg.CheckpointSigner = signer
+			fileCfg.CheckpointInterval = interval
+		}
+	}
+	store, err := auditadapter.NewFileAuditStore(fileCfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	logger.Debug("audit files enabled", "dir", cfg.AuditFile.Dir, "compress", cfg.AuditFile.Compress,
+		"chain", cfg.AuditFile.Chain)
+	return store, nil
+}
+
+// evidenceKeyPath returns the evidence signing key file, defaulting to
+// evidence-key.pem.
+func evidenceKeyPath(cfg *config.OSSConfig) string {
+	if cfg.Evidence.KeyPath == "" {
+		return "evidence-key.pem"
+	}
+	return cfg.Evidence.KeyPath
+}
+
+// evidenceSignerID returns the configured signer ID, or the hostname.
+func evidenceSignerID(cfg *config.OSSConfig) string {
+	if cfg.Evidence.SignerID != "" {
+		return cfg.Evidence.SignerID
+	}
+	if host, _ := os.Hostname(); host != "" {
+		return host
+	}
+	return "sentinel-gate"
+}
+
+// createAuditSQLiteStore creates the SQLite audit store configured by the
+// audit_sqlite section, or returns nil when audit_sqlite.path is not set.
+func createAuditSQLiteStore(cfg *config.OSSConfig, logger *slog.Logger) (*auditadapter.SQLiteAuditStore, error) {
+	if cfg.AuditSQLite.Path == "" {
+		return nil, nil
+	}
+	store, err := auditadapter.NewSQLiteAuditStore(auditadapter.SQLiteAuditStoreConfig{
+		Path:          cfg.AuditSQLite.Path,
+		RetentionDays: cfg.AuditSQLite.RetentionDays,
+	}, logger)
+	if err != nil {
+		return nil, err
+	}
+	logger.Debug("audit database enabled", "path", cfg.AuditSQLite.Path, "retention_days", cfg.AuditSQLite.RetentionDays)
+	return store, nil
+}
+
+// teeAuditStore appends every record to the in-memory store and to the
+// persistent audit stores (audit files, SQLite). A failure of a persistent
+// store does not prevent the records from reaching the other stores.
+type teeAuditStore struct {
+	primary *memory.MemoryAuditStore
+	others  []audit.AuditStore
+}
+
+func (t *teeAuditStore) Append(ctx context.Context, records ...audit.AuditRecord) error {
+	err := t.primary.Append(ctx, records...)
+	for _, o := range t.others {
+		if oerr := o.Append(ctx, records...); oerr != nil && err == nil {
+			err = oerr
+		}
+	}
+	return err
+}
+
+func (t *teeAuditStore) Flush(ctx context.Context) error {
+	err := t.primary.Flush(ctx)
+	for _, o := range t.others {
+		if oerr := o.Flush(ctx); oerr != nil && err == nil {
+			err = oerr
+		}
+	}
+	return err
+}
+
+// Close is a no-op: every store is closed by its own lifecycle hook.
+func (t *teeAuditStore) Close() error { return nil }
+
+// historyAuditReader serves audit queries from the audit files, including
+// compressed ones, with cursor pagination; the file store answers recent
+// pages from its own cache. Recent records come from the in-memory ring
+// buffer.
+type historyAuditReader struct {
+	*memory.MemoryAuditStore
+	files *auditadapter.FileAuditStore
+}
+
+func (r *historyAuditReader) Query(ctx context.Context, filter audit.AuditFilter) ([]audit.AuditRecord, string, error) {
+	return r.files.Query(ctx, filter)
+}
+
+// indexedAuditReader serves audit queries from the SQLite audit store and
+// recent records from the in-memory ring buffer.
+type indexedAuditReader struct {
+	*memory.MemoryAuditStore
+	db *auditadapter.SQLiteAuditStore
+}
+
+func (r *indexedAuditReader) Query(ctx context.Context, filter audit.AuditFilter) ([]audit.AuditRecord, string, error) {
+	return r.db.Query(ctx, filter)
+}
+
+// newTokenPassthroughService builds the upstream token passthrough service
+// from the token_exchange config section. With jwt.issuer set, user tokens
+// are verified against the provider's keys by an OIDC verifier.
+func newTokenPassthroughService(cfg *config.OSSConfig, upstreams *service.UpstreamService, logger *slog.Logger) (*service.TokenPassthroughService, error) {
+	te := cfg.TokenExchange
+	cacheTTL, err := time.ParseDuration(te.CacheTTL)
+	if err != nil {
+		cacheTTL = 5 * time.Minute
+	}
+	brokerCfg := tokenexchange.Config{
+		IntrospectionURL: te.IntrospectionURL,
+		TokenURL:         te.TokenURL,
+		ClientID:         te.ClientID,
+		ClientSecret:     te.ClientSecret,
+		CacheTTL:         cacheTTL,
+	}
+	if te.JWT.Issuer != "" {
+		verifier, err := oidc.NewVerifier(oidc.Config{
+			Issuer:    te.JWT.Issuer,
+			Audiences: te.JWT.Audiences,
+			JWKSURL:   te.JWT.JWKSURL,
+		})
+		if err != nil {
+			return nil, err
+		}
+		brokerCfg.Verifier = verifier
+	}
+	broker := tokenexchange.NewBroker(brokerCfg)
+	bindings := make([]tokenexchange.Binding, 0, len(te.Upstreams))
+	for _, u := range te.Upstreams {
+		bindings = append(bindings, tokenexchange.Binding{
+			Upstream: u.Upstream,
+			Mode:     tokenexchange.Mode(u.Mode),
+			Audience: u.Audience,
+			Scope:    u.Scope,
+			Resource: u.Resource,
+		})
+	}
+	return service.NewTokenPassthroughService(broker, bindings, upstreams, logger), nil
+}
+
+// newOIDCVerifier builds the bearer token verifier from the auth.oidc
+// config section.
+func newOIDCVerifier(cfg config.OIDCConfig) (*oidc.Verifier, error) {
+	skew, err := time.ParseDuration(cfg.ClockSkew)
+	if err != nil {
+		skew = time.Minute
+	}
+	return oidc.NewVerifier(oidc.Config{
+		Issuer:        cfg.Issuer,
+		Audiences:     cfg.Audiences,
+		JWKSURL:       cfg.JWKSURL,
+		IdentityClaim: cfg.IdentityClaim,
+		NameClaim:     cfg.NameClaim,
+		RolesClaim:    cfg.RolesClaim,
+		RoleMappings:  cfg.RoleMappings,
+		DefaultRoles:  cfg.DefaultRoles,
+		ClockSkew:     skew,
+	})
+}
+
+// rateLimitOverrides converts the rate_limit.overrides config section.
+func rateLimitOverrides(cfg []config.RateLimitOverrideConfig) []ratelimit.Override {
+	list := make([]ratelimit.Override, 0, len(cfg))
+	for _, o := range cfg {
+		list = append(list, ratelimit.Override{Identity: o.Identity, Tool: o.Tool, Rate: o.Rate, Burst: o.Burst})
+	}
+	return list
+}
+
+// denyLoopConfig converts the deny_loop config section. Durations are
+// validated at config load; zero values take the detector defaults.
+func denyLoopConfig(cfg config.DenyLoopConfig) denyloop.Config {
+	window, _ := time.ParseDuration(cfg.Window)
+	base, _ := time.ParseDuration(cfg.BaseBackoff)
+	maxBackoff, _ := time.ParseDuration(cfg.MaxBackoff)
+	return denyloop.Config{Threshold: cfg.Threshold, Window: window, BaseBackoff: base, MaxBackoff: maxBackoff}
+}
+
+// newMethodPolicy builds the MCP method policy from the mcp_methods config
+// section.
+func newMethodPolicy(cfg config.MCPMethodsConfig) (*validation.MethodPolicy, error) {
+	rules := make([]validation.MethodRule, 0, len(cfg.Rules))
+	for _, r := range cfg.Rules {
+		rules = append(rules, validation.MethodRule{
+			Method:   r.Method,
+			Action:   validation.MethodAction(r.Action),
+			Upstream: r.Upstream,
+		})
+	}
+	return validation.NewMethodPolicy(rules, validation.MethodRule{
+		Action:   validation.MethodAction(cfg.UnknownAction),
+		Upstream: cfg.UnknownUpstream,
+	})
+}
+
+// newNotificationPolicy builds the upstream notification policy from the
+// upstream_notifications config section.
+func newNotificationPolicy(cfg config.UpstreamNotificationsConfig) (*proxy.NotificationPolicy, error) {
+	rules := make([]proxy.NotificationRule, 0, len(cfg.Rules))
+	for _, r := range cfg.Rules {
+		rules = append(rules, proxy.NotificationRule{
+			Upstream: r.Upstream,
+			Method:   r.Method,
+			Action:   proxy.NotificationAction(r.Action),
+		})
+	}
+	return proxy.NewNotificationPolicy(rules)
+}
+
+// celLimits builds the policy condition limits from the cel config section.
+func celLimits(cfg config.CELConfig) celeval.Limits {
+	// Duration validated at config load; zero keeps the default.
+	timeout, _ := time.ParseDuration(cfg.EvalTimeout)
+	return celeval.Limits{
+		CostLimit:       uint64(cfg.CostLimit),
+		EvalTimeout:     timeout,
+		MaxComplexity:   uint64(cfg.MaxComplexity),
+		BannedFunctions: cfg.BannedFunctions,
+		BannedMacros:    cfg.BannedMacros,
+	}
+}
+
+// bootDNS sets up host name resolution before anything dials out: the
+// gateway resolver (see useDNS) and the DNS rebinding checks of long-lived
+// upstream connections.
+func (bc *bootContext) bootDNS() error {
+	resolver, restore, err := useDNS(bc.cfg.DNS, bc.logger)
+	if err != nil {
+		return err
+	}
+	bc.cleanups = append(bc.cleanups, restore)
+	bc.dnsResolver = resolver
+	// Duration validated at config load.
+	if interval, _ := time.ParseDuration(bc.cfg.DNS.RevalidateInterval); interval > 0 {
+		bc.dnsWatcher = dns.NewWatcher(net.DefaultResolver, interval, bc.logger)
+	}
+	return nil
+}
+
+// connWatcher returns the watcher of upstream connections, nil when DNS
+// rebinding checks are disabled.
+func (bc *bootContext) connWatcher() mcpclient.ConnWatcher {
+	if bc.dnsWatcher == nil {
+		return nil
+	}
+	return bc.dnsWatcher
+}
+
+// useDNS makes the process resolve host names through the gateway resolver,
+// which sends queries to the DNS-over-HTTPS or DNS-over-TLS servers in cfg
+// and caches answers when the cache is enabled, by replacing
+// net.DefaultResolver, which every outbound dialer of the gateway uses. It
+// returns the resolver and a function restoring the previous one; without
+// servers or cache it returns a nil resolver and changes nothing.
+func useDNS(cfg config.DNSConfig, logger *slog.Logger) (*dns.Resolver, func(), error) {
+	if len(cfg.Servers) == 0 && !cfg.Cache.Enabled {
+		return nil, func() {}, nil
+	}
+	opts := dns.Options{Servers: make([]dns.Server, len(cfg.Servers))}
+	urls := make([]string, len(cfg.Servers))
+	for i, s := range cfg.Servers {
+		opts.Servers[i] = dns.Server{URL: s.URL, Bootstrap: s.Bootstrap}
+		urls[i] = s.URL
+	}
+	if cfg.Cache.Enabled {
+		// Durations validated at config load.
+		opts.Cache.MaxTTL, _ = time.ParseDuration(cfg.Cache.MaxTTL)
+		opts.Cache.NegativeTTL, _ = time.ParseDuration(cfg.Cache.NegativeTTL)
+	}
+	resolver, err := dns.New(opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid dns configuration: %w", err)
+	}
+	prev := net.DefaultResolver
+	net.DefaultResolver = resolver.Resolver
+	if logger != nil {
+		if len(urls) > 0 {
+			logger.Info("resolving host names through secure DNS", "servers", urls)
+		}
+		if cfg.Cache.Enabled {
+			logger.Info("DNS cache enabled", "max_ttl", opts.Cache.MaxTTL, "negative_ttl", opts.Cache.NegativeTTL)
+		}
 	}
+	return resolver, func() { net.DefaultResolver = prev }, nil
 }
 
 // parseLogLevel converts a string log level to slog.Level.
@@ -75,8 +424,9 @@ func countRules(ctx context.Context, policyStore *memory.MemoryPolicyStore) int
 	return count
 }
 
-// printBanner prints a formatted startup banner to stderr.
-func printBanner(version, httpAddr string, upstreamCount, connectedCount, toolCount, ruleCount int) {
+// printBanner prints a formatted startup banner to stderr. scheme is
+// "http" or "https".
+func printBanner(version, scheme, httpAddr string, upstreamCount, connectedCount, toolCount, ruleCount int) {
 	const (
 		reset = "\033[0m"
 		bold  = "\033[1m"
@@ -84,14 +434,14 @@ func printBanner(version, httpAddr string, upstreamCount, connectedCount, toolCo
 		dim   = "\033[2m"
 	)
 
-	adminURL := fmt.Sprintf("http://localhost%s/admin", httpAddr)
+	adminURL := fmt.Sprintf("%s://localhost%s/admin", scheme, httpAddr)
 	if !strings.HasPrefix(httpAddr, ":") {
-		adminURL = fmt.Sprintf("http://%s/admin", httpAddr)
+		adminURL = fmt.Sprintf("%s://%s/admin", scheme, httpAddr)
 	}
 
-	proxyURL := fmt.Sprintf("http://localhost%s/mcp", httpAddr)
+	proxyURL := fmt.Sprintf("%s://localhost%s/mcp", scheme, httpAddr)
 	if !strings.HasPrefix(httpAddr, ":") {
-		proxyURL = fmt.Sprintf("http://%s/mcp", httpAddr)
+		proxyURL = fmt.Sprintf("%s://%s/mcp", scheme, httpAddr)
 	}
 
 	fmt.Fprintf(os.Stderr, "\n")
@@ -3,17 +3,24 @@ package cmd
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"net"
 	"path/filepath"
 	"time"
 
+	auditadapter "github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/audit"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/memory"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/action"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/denyloop"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/policy"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/proxy"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/quota"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/ratelimit"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/recording"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/session"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/transform"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/watchdog"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/lifecycle"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
 )
@@ -31,11 +38,58 @@ func (bc *bootContext) bootInterceptorChain(ctx context.Context) error {
 	// Clean up per-upstream I/O mutexes when an upstream is stopped/removed.
 	bc.upstreamManager.SetOnStopCallback(router.CleanupUpstream)
 
+	// Resource subscriptions: per-identity limit, released upstream on session end.
+	router.SetSubscriptionTracker(proxy.NewSubscriptionTracker(bc.cfg.Server.MaxSubscriptionsPerIdentity))
+
+	// MCP method handling: ping, completion/complete, experimental methods.
+	methodPolicy, err := newMethodPolicy(bc.cfg.MCPMethods)
+	if err != nil {
+		return fmt.Errorf("mcp_methods: %w", err)
+	}
+	router.SetMethodPolicy(methodPolicy)
+	router.SetUpstreamResolver(bc.upstreamService)
+	if bc.noticeService != nil {
+		router.SetNoticeProvider(bc.noticeService)
+	}
+	bc.apiHandler.SetMCPMethodPolicy(methodPolicy)
+
+	// Upstream notification delivery: forward, latest connection only, drop.
+	notificationPolicy, err := newNotificationPolicy(bc.cfg.UpstreamNotifications)
+	if err != nil {
+		return fmt.Errorf("upstream_notifications: %w", err)
+	}
+	router.SetNotificationPolicy(notificationPolicy)
+	bc.apiHandler.SetNotificationPolicy(notificationPolicy)
+
 	// Namespace isolation (Upgrade 8): filter tools/list by role.
 	if bc.namespaceService != nil {
 		router.SetNamespaceFilter(bc.namespaceService)
 	}
 
+	// Token passthrough: attach end-user OAuth tokens to bound HTTP upstreams.
+	if bc.cfg.TokenExchange.Enabled {
+		tokenPassthrough, err := newTokenPassthroughService(bc.cfg, bc.upstreamService, bc.logger)
+		if err != nil {
+			return fmt.Errorf("token_exchange: %w", err)
+		}
+		router.SetCredentialInjector(tokenPassthrough)
+		bc.logger.Info("upstream token passthrough enabled",
+			"header", bc.cfg.TokenExchange.Header,
+			"upstreams", len(bc.cfg.TokenExchange.Upstreams))
+	}
+
+	// Correlation IDs: passed on to upstreams in a header and/or params._meta.
+	if cc := bc.cfg.Server.Correlation; cc.Propagate != "off" {
+		var header, metaKey string
+		if cc.Propagate == "header" || cc.Propagate == "both" {
+			header = cc.Header
+		}
+		if cc.Propagate == "meta" || cc.Propagate == "both" {
+			metaKey = cc.MetaKey
+		}
+		router.SetCorrelationPropagation(header, metaKey)
+	}
+
 	routerAdapter := action.NewLegacyAdapter(router, "upstream-router")
 
 	// Response scanning (output direction — IPI defense)
@@ -45,7 +99,7 @@ func (bc *bootContext) bootInterceptorChain(ctx context.Context) error {
 		// M-30: Validate scan mode from state.json; fallback to "monitor" if unrecognized.
 		m := action.ScanMode(bc.appState.ContentScanningConfig.Mode)
 		switch m {
-		case action.ScanModeMonitor, action.ScanModeEnforce:
+		case action.ScanModeMonitor, action.ScanModeEnforce, action.ScanModeRedact:
 			scanMode = m
 		default:
 			bc.logger.Warn("invalid content scanning mode in state, defaulting to monitor", "mode", bc.appState.ContentScanningConfig.Mode)
@@ -53,27 +107,101 @@ func (bc *bootContext) bootInterceptorChain(ctx context.Context) error {
 		scanEnabled = bc.appState.ContentScanningConfig.Enabled
 	}
 	bc.responseScanner = action.NewResponseScanner()
+	if bc.appState.ContentScanningConfig != nil && len(bc.appState.ContentScanningConfig.Detectors) > 0 {
+		// Detectors are loaded one by one so that an entry edited into an
+		// invalid state does not disable the others.
+		var detectors []action.CustomDetector
+		for _, d := range bc.appState.ContentScanningConfig.Detectors {
+			detector := action.CustomDetector{
+				ID: d.ID, Name: d.Name, Pattern: d.Pattern, Keywords: d.Keywords,
+				Severity: d.Severity, Category: d.Category,
+			}
+			if err := bc.responseScanner.SetCustomDetectors(append(detectors, detector)); err != nil {
+				bc.logger.Warn("skipping invalid custom scan detector", "name", d.Name, "error", err)
+				continue
+			}
+			detectors = append(detectors, detector)
+		}
+		bc.logger.Info("custom scan detectors loaded", "count", len(detectors))
+	}
+	scanLimits := action.StreamLimits{
+		ChunkSize: bc.cfg.ContentInspection.ChunkSize,
+		Overlap:   bc.cfg.ContentInspection.Overlap,
+		MaxBytes:  bc.cfg.ContentInspection.MaxScanBytes,
+	}
+	bc.responseScanner.SetStreamLimits(scanLimits)
 	bc.responseScanInterceptor = action.NewResponseScanInterceptor(
 		bc.responseScanner, routerAdapter, scanMode, scanEnabled, bc.logger,
 	)
 	bc.logger.Info("response scanning configured", "mode", scanMode, "enabled", scanEnabled)
 	bc.apiHandler.SetResponseScanController(bc.responseScanInterceptor)
+	bc.apiHandler.SetResponseScanner(bc.responseScanner)
 	if bc.eventBus != nil {
 		bc.responseScanInterceptor.SetEventBus(bc.eventBus)
 	}
 
+	// Taint tracking: flagged response content reused in later arguments.
+	if tc := bc.cfg.TaintTracking; tc.Enabled {
+		idleTTL, err := time.ParseDuration(bc.cfg.Server.SessionTimeout)
+		if err != nil {
+			idleTTL = 30 * time.Minute
+		}
+		bc.taintTracker = action.NewTaintTracker(action.TaintConfig{
+			WindowSize:      tc.WindowSize,
+			MaxFingerprints: tc.MaxFingerprintsPerSession,
+			IdleTTL:         idleTTL,
+		})
+		if bc.eventBus != nil {
+			bc.taintTracker.SetEventBus(bc.eventBus)
+		}
+		bc.responseScanInterceptor.SetTaintTracker(bc.taintTracker)
+		bc.logger.Info("taint tracking enabled", "window_size", tc.WindowSize)
+	}
+
+	// Argument scanning: secrets, internal hostnames and prompt injection
+	// sent to upstreams. Disabled unless turned on from the admin API.
+	argScanMode := action.ScanModeMonitor
+	argScanEnabled := false
+	argScanner := action.NewArgumentScanner(bc.responseScanner)
+	argScanner.SetStreamLimits(scanLimits)
+	if bc.appState.ContentScanningConfig != nil && bc.appState.ContentScanningConfig.ArgumentScan != nil {
+		ac := bc.appState.ContentScanningConfig.ArgumentScan
+		switch m := action.ScanMode(ac.Mode); m {
+		case action.ScanModeMonitor, action.ScanModeEnforce, action.ScanModeRedact:
+			argScanMode = m
+		default:
+			bc.logger.Warn("invalid argument scanning mode in state, defaulting to monitor", "mode", ac.Mode)
+		}
+		argScanEnabled = ac.Enabled
+		if err := argScanner.SetInternalDomains(ac.InternalDomains); err != nil {
+			bc.logger.Warn("invalid internal domains in state, using defaults", "error", err)
+		}
+	}
+	bc.argumentScanInterceptor = action.NewArgumentScanInterceptor(
+		argScanner, bc.responseScanInterceptor, argScanMode, argScanEnabled, bc.logger,
+	)
+	if bc.eventBus != nil {
+		bc.argumentScanInterceptor.SetEventBus(bc.eventBus)
+	}
+	bc.logger.Info("argument scanning configured", "mode", argScanMode, "enabled", argScanEnabled)
+	bc.apiHandler.SetArgumentScanInterceptor(bc.argumentScanInterceptor)
+
 	// Content scanning (input direction — PII/secrets in arguments)
 	inputScanEnabled := true
 	if bc.appState.ContentScanningConfig != nil {
 		inputScanEnabled = bc.appState.ContentScanningConfig.InputScanEnabled
 	}
 	bc.contentScanner = action.NewContentScanner()
+	bc.contentScanner.SetStreamLimits(scanLimits)
 	bc.contentScanInterceptor = action.NewContentScanInterceptor(
-		bc.contentScanner, bc.responseScanInterceptor, inputScanEnabled, bc.logger,
+		bc.contentScanner, bc.argumentScanInterceptor, inputScanEnabled, bc.logger,
 	)
 	if bc.eventBus != nil {
 		bc.contentScanInterceptor.SetEventBus(bc.eventBus)
 	}
+	// Redact mode also masks PII and secrets in results, using the input
+	// scanner's pattern settings.
+	bc.responseScanInterceptor.SetContentScanner(bc.contentScanner)
 	// Load whitelist from state.
 	if bc.appState.ContentScanningConfig != nil && len(bc.appState.ContentScanningConfig.Whitelist) > 0 {
 		entries := make([]action.WhitelistEntry, 0, len(bc.appState.ContentScanningConfig.Whitelist))
@@ -138,7 +266,12 @@ func (bc *bootContext) bootInterceptorChain(ctx context.Context) error {
 	})
 
 	// Approval (HITL)
-	bc.approvalStore = action.NewApprovalStore(100)
+	bc.approvalStore = action.NewApprovalStore(bc.cfg.Approval.MaxPending)
+	// The durations were validated with the config.
+	approvalTimeout, _ := time.ParseDuration(bc.cfg.Approval.DefaultTimeout)
+	grantDuration, _ := time.ParseDuration(bc.cfg.Approval.GrantDuration)
+	maxGrantDuration, _ := time.ParseDuration(bc.cfg.Approval.MaxGrantDuration)
+	bc.approvalStore.SetTimeouts(approvalTimeout, grantDuration, maxGrantDuration)
 	if bc.eventBus != nil {
 		bc.approvalStore.SetEventBus(bc.eventBus)
 	}
@@ -152,10 +285,44 @@ func (bc *bootContext) bootInterceptorChain(ctx context.Context) error {
 	})
 
 	// Policy + quarantine
-	nativePolicyInterceptor := action.NewPolicyActionInterceptor(bc.policyService, approvalInterceptor, bc.logger,
+	policyOpts := []action.PolicyActionOption{
 		action.WithSessionUsage(&sessionUsageAdapter{tracker: bc.sessionTracker}),
-	)
+	}
+	if bc.taintTracker != nil {
+		policyOpts = append(policyOpts, action.WithTaintTracker(bc.taintTracker))
+	}
+	if bc.argumentAnomalyService != nil {
+		policyOpts = append(policyOpts, action.WithAnomalyDetector(bc.argumentAnomalyService))
+	}
+	if bc.cfg.DNS.ResolveDestinations {
+		// net.DefaultResolver is the secure DNS resolver when one is configured.
+		policyOpts = append(policyOpts, action.WithDestinationResolver(net.DefaultResolver))
+	}
+	if bc.geoLocator != nil {
+		policyOpts = append(policyOpts, action.WithGeoLocator(bc.geoLocator))
+	}
+	if ec := bc.cfg.Policy.Enrichment; ec.URL != "" {
+		// The durations were validated with the config.
+		timeout, _ := time.ParseDuration(ec.Timeout)
+		cacheTTL, _ := time.ParseDuration(ec.CacheTTL)
+		enricher := service.NewPolicyEnrichmentService(service.PolicyEnrichmentConfig{
+			URL:        ec.URL,
+			Token:      ec.Token,
+			Timeout:    timeout,
+			CacheTTL:   cacheTTL,
+			Arguments:  ec.Arguments,
+			FailClosed: ec.FailClosed,
+		}, bc.logger)
+		policyOpts = append(policyOpts, action.WithContextEnricher(enricher))
+		bc.logger.Info("policy enrichment enabled", "url", ec.URL, "cache_ttl", ec.CacheTTL, "fail_closed", ec.FailClosed)
+	}
+	var policyEngine policy.PolicyEngine = bc.policyService
+	if bc.regoEngine != nil {
+		policyEngine = bc.regoEngine
+	}
+	nativePolicyInterceptor := action.NewPolicyActionInterceptor(policyEngine, approvalInterceptor, bc.logger, policyOpts...)
 	bc.policyActionInterceptor = nativePolicyInterceptor // store for late health metrics binding
+	nativePolicyInterceptor.SetDestinationObserver(bc.outboundLearningService)
 	quarantineInterceptor := action.NewQuarantineInterceptor(bc.toolSecurityService, nativePolicyInterceptor, bc.logger)
 
 	// Rate limiting
@@ -175,18 +342,44 @@ func (bc *bootContext) bootInterceptorChain(ctx context.Context) error {
 			bc.logger.Warn("invalid rate_limit.max_ttl, using default",
 				"value", bc.cfg.RateLimit.MaxTTL, "default", "1h")
 		}
-		bc.rateLimiter = memory.NewRateLimiterWithConfig(cleanupInterval, maxTTL)
+		if err := bc.openRateLimiter(ctx, cleanupInterval, maxTTL); err != nil {
+			return err
+		}
 		ipConfig = ratelimit.RateLimitConfig{Rate: bc.cfg.RateLimit.IPRate, Burst: bc.cfg.RateLimit.IPBurst, Period: time.Minute}
 		userConfig = ratelimit.RateLimitConfig{Rate: bc.cfg.RateLimit.UserRate, Burst: bc.cfg.RateLimit.UserBurst, Period: time.Minute}
 		userRateLimiter := action.NewActionUserRateLimitInterceptor(bc.rateLimiter, userConfig, quarantineInterceptor, bc.logger)
+		bc.userRateLimiter = userRateLimiter
+		bc.rateLimitOverrides.OnChange(userRateLimiter.SetOverrides)
+		userRateLimiter.SetExempter(bc.rateLimitExemptions)
+		bc.apiHandler.SetRateLimitReporter(userRateLimiter)
 		preQuotaChain = userRateLimiter
+		// Per-session tier runs before the user tier so one busy agent is
+		// throttled on its own bucket instead of draining the shared user bucket.
+		if bc.cfg.RateLimit.SessionRate > 0 {
+			sessionConfig := ratelimit.RateLimitConfig{Rate: bc.cfg.RateLimit.SessionRate, Burst: bc.cfg.RateLimit.SessionBurst, Period: time.Minute}
+			bc.sessionRateLimiter = action.NewActionSessionRateLimitInterceptor(bc.rateLimiter, sessionConfig, userRateLimiter, bc.logger)
+			preQuotaChain = bc.sessionRateLimiter
+		}
 		bc.logger.Debug("rate limiting enabled",
 			"ip_rate", bc.cfg.RateLimit.IPRate, "user_rate", bc.cfg.RateLimit.UserRate,
+			"session_rate", bc.cfg.RateLimit.SessionRate,
 			"cleanup_interval", cleanupInterval, "max_ttl", maxTTL)
 	} else {
 		bc.rateLimiter = memory.NewRateLimiter()
 	}
 
+	// Deny loop detection (before the rate limiters, so calls rejected by the
+	// backoff don't use up rate limits)
+	if bc.cfg.DenyLoop.Enabled {
+		bc.denyLoops = denyloop.NewDetector(denyLoopConfig(bc.cfg.DenyLoop))
+		denyLoopInterceptor := action.NewDenyLoopInterceptor(bc.denyLoops, preQuotaChain, bc.logger)
+		if bc.eventBus != nil {
+			denyLoopInterceptor.SetEventBus(bc.eventBus)
+		}
+		preQuotaChain = denyLoopInterceptor
+		bc.apiHandler.SetDenyLoopDetector(bc.denyLoops)
+	}
+
 	// Quota enforcement
 	bc.quotaStore = quota.NewMemoryQuotaStore()
 	for _, qe := range bc.appState.Quotas {
@@ -194,7 +387,8 @@ func (bc *bootContext) bootInterceptorChain(ctx context.Context) error {
 			IdentityID: qe.IdentityID, MaxCallsPerSession: qe.MaxCallsPerSession,
 			MaxWritesPerSession: qe.MaxWritesPerSession, MaxDeletesPerSession: qe.MaxDeletesPerSession,
 			MaxCallsPerMinute: qe.MaxCallsPerMinute, MaxCallsPerDay: qe.MaxCallsPerDay,
-			ToolLimits: qe.ToolLimits, Action: quota.QuotaAction(qe.Action), Enabled: qe.Enabled,
+			ToolLimits: qe.ToolLimits, ToolWeights: qe.ToolWeights,
+			Action: quota.QuotaAction(qe.Action), Enabled: qe.Enabled,
 		}
 		// M-29: Validate quota config loaded from state.json before storing.
 		if vErr := qcfg.Validate(); vErr != nil {
@@ -211,6 +405,7 @@ func (bc *bootContext) bootInterceptorChain(ctx context.Context) error {
 		bc.logger.Info("loaded quota configurations", "count", len(bc.appState.Quotas))
 	}
 	quotaService := quota.NewQuotaService(bc.quotaStore, bc.sessionTracker)
+	quotaService.SetDailyCounter(bc.bootDailyQuota(ctx))
 	actionQuotaInterceptor := quota.NewActionQuotaInterceptor(quotaService, bc.sessionTracker, preQuotaChain, bc.logger)
 	if bc.finopsService != nil {
 		actionQuotaInterceptor.SetCostEstimator(bc.finopsService)
@@ -218,6 +413,7 @@ func (bc *bootContext) bootInterceptorChain(ctx context.Context) error {
 
 	// Wire quota/session/transform into admin API
 	bc.apiHandler.SetQuotaStore(bc.quotaStore)
+	bc.apiHandler.SetQuotaDailyCounter(quotaService.DailyCounter())
 	bc.apiHandler.SetSessionTracker(bc.sessionTracker)
 	bc.apiHandler.SetTransformStore(bc.transformStore)
 	bc.apiHandler.SetTransformExecutor(bc.transformExecutor)
@@ -240,14 +436,73 @@ func (bc *bootContext) bootInterceptorChain(ctx context.Context) error {
 	if bc.evidenceService != nil {
 		auditRecorder = service.NewEvidenceRecorder(bc.auditService, bc.evidenceService)
 	}
+	if bc.noticeService != nil {
+		bc.noticeService.SetAuditRecorder(auditRecorder)
+	}
+	if bc.policyAdminService != nil {
+		bc.policyAdminService.SetAuditRecorder(auditRecorder)
+	}
 	actionAuditInterceptor := action.NewActionAuditInterceptor(auditRecorder, bc.statsService, postQuotaChain, bc.logger)
 	actionAuditInterceptor.SetFrameworkGetter(router.ClientFrameworkForSession)
+	actionAuditInterceptor.SetToolStatsRecorder(bc.toolStatsService)
+	if bc.costAccounting != nil {
+		actionAuditInterceptor.SetUsageRecorder(bc.costAccounting)
+		bc.apiHandler.SetCostAccountingService(bc.costAccounting)
+	}
 	if bc.recordingObserver != nil {
 		actionAuditInterceptor.SetRecordingCallback(bc.recordingObserver.OnAuditRecord)
 	}
+	// Sensitive tool arguments are encrypted in audit records and recordings
+	argEncryptor, err := bc.newArgumentEncryptor()
+	if err != nil {
+		return err
+	}
+	if argEncryptor != nil {
+		actionAuditInterceptor.SetArgumentEncryptor(argEncryptor)
+	}
+	// Full payloads of sampled calls go to the payload store
+	if bc.payloadStore != nil {
+		rules := make([]audit.PayloadSampleRule, 0, len(bc.cfg.AuditPayloads.Tools))
+		for _, t := range bc.cfg.AuditPayloads.Tools {
+			rules = append(rules, audit.PayloadSampleRule{Tool: t.Tool, Rate: t.Rate})
+		}
+		sampler, err := audit.NewPayloadSampler(rules)
+		if err != nil {
+			return fmt.Errorf("invalid audit_payloads: %w", err)
+		}
+		actionAuditInterceptor.SetPayloadSampling(sampler, bc.payloadStore, bc.cfg.AuditPayloads.MaxPayloadBytes)
+	}
+	// Server-initiated requests (sampling, elicitation) pass policy and
+	// approval before they reach the client; the HTTP transport is bound
+	// later as the client channel.
+	bc.clientRequestInterceptor = action.NewClientRequestInterceptor()
+	serverReqApproval := action.NewApprovalInterceptor(bc.approvalStore, bc.clientRequestInterceptor, bc.logger)
+	serverReqPolicy := action.NewPolicyActionInterceptor(policyEngine, serverReqApproval, bc.logger, policyOpts...)
+	router.SetServerRequestHandler(action.NewServerRequestGate(serverReqPolicy, auditRecorder, bc.logger))
+
+	// Tool result provenance in result._meta (headers are set by the HTTP transport)
+	switch bc.cfg.Server.ToolProvenance {
+	case "meta", "both":
+		actionAuditInterceptor.SetProvenanceMeta(true)
+	}
 
 	// Auth interceptor
 	bc.actionAuthInterceptor = action.NewActionAuthInterceptor(bc.apiKeyService, bc.sessionService, actionAuditInterceptor, bc.logger, bc.sessionTracker)
+	// Identity access restriction denials happen before the audit interceptor.
+	bc.actionAuthInterceptor.SetAuditRecorder(auditRecorder)
+	if bc.geoResolver != nil {
+		bc.actionAuthInterceptor.SetCountryResolver(bc.geoResolver)
+	}
+	if bc.cfg.Auth.OIDC.Enabled {
+		verifier, err := newOIDCVerifier(bc.cfg.Auth.OIDC)
+		if err != nil {
+			return err
+		}
+		bc.actionAuthInterceptor.SetTokenVerifier(verifier)
+		bc.logger.Info("oidc authentication enabled",
+			"issuer", bc.cfg.Auth.OIDC.Issuer,
+			"audiences", bc.cfg.Auth.OIDC.Audiences)
+	}
 	// BUG-6 FIX: Wire the auth interceptor as session cache invalidator so
 	// admin Terminate/Revoke/Delete can flush cached sessions immediately.
 	bc.apiHandler.SetSessionCacheInvalidator(bc.actionAuthInterceptor)
@@ -272,7 +527,8 @@ func (bc *bootContext) bootInterceptorChain(ctx context.Context) error {
 	// IP rate limit (optional, before auth)
 	var preValidation action.ActionInterceptor = bc.actionAuthInterceptor
 	if bc.cfg.RateLimit.Enabled {
-		preValidation = action.NewActionIPRateLimitInterceptor(bc.rateLimiter, ipConfig, bc.actionAuthInterceptor, bc.logger)
+		bc.ipRateLimiter = action.NewActionIPRateLimitInterceptor(bc.rateLimiter, ipConfig, bc.actionAuthInterceptor, bc.logger)
+		preValidation = bc.ipRateLimiter
 	}
 	// L-36: Pass context.Background() so the cleanup goroutine stays alive
 	// until the explicit Stop() lifecycle hook, rather than exiting early
@@ -286,14 +542,115 @@ func (bc *bootContext) bootInterceptorChain(ctx context.Context) error {
 
 	// Validation (outermost)
 	actionValidationInterceptor := action.NewActionValidationInterceptor(preValidation, bc.logger)
+	actionValidationInterceptor.SetMethodPolicy(methodPolicy)
 
 	// Single InterceptorChain
 	mcpNormalizer := action.NewMCPNormalizer()
-	bc.interceptorChain = action.NewInterceptorChain(mcpNormalizer, actionValidationInterceptor, bc.logger)
+	mcpNormalizer.SetCorrelationMetaKey(bc.cfg.Server.Correlation.MetaKey)
+	if ue := bc.cfg.URLExtraction; ue.Enabled {
+		hints := make(map[string]action.ToolURLHint, len(ue.Tools))
+		for name, t := range ue.Tools {
+			hints[name] = action.ToolURLHint{Fields: t.Fields, Ignore: t.Ignore, Disabled: t.Disabled}
+		}
+		mcpNormalizer.SetURLExtractor(action.NewURLExtractor(action.URLExtractionConfig{
+			MaxDepth:       ue.MaxDepth,
+			BareDomains:    ue.BareDomains,
+			CommandStrings: ue.CommandStrings,
+			Tools:          hints,
+		}))
+	}
+	chain := action.NewInterceptorChain(mcpNormalizer, actionValidationInterceptor, bc.logger)
+	bc.interceptorChain = chain
+
+	// Stall watchdog (per-stage processing time of in-flight requests)
+	if bc.cfg.Watchdog.Enabled {
+		bc.bootWatchdog(chain)
+	}
+
+	// Latency breakdown (gateway vs upstream time) reported to clients
+	if lb := bc.cfg.Server.LatencyBreakdown; lb.Mode != "off" {
+		chain.SetTimingReport(&action.TimingReport{
+			Meta:       lb.Mode == "meta" || lb.Mode == "both",
+			Identities: lb.Identities,
+		})
+		bc.logger.Info("latency breakdown enabled", "mode", lb.Mode, "identities", len(lb.Identities))
+	}
+
+	// SLO tracking (burn rates for the gateway and individual upstreams)
+	if len(bc.cfg.SLO.Objectives) > 0 {
+		bc.bootSLO(chain, router)
+	}
 
 	return nil
 }
 
+// bootSLO starts SLO tracking and burn-rate alerting. The chain reports
+// gateway requests, the router reports tool calls per upstream.
+func (bc *bootContext) bootSLO(chain *action.InterceptorChain, router *proxy.UpstreamRouter) {
+	defs := make([]service.SLODefinition, 0, len(bc.cfg.SLO.Objectives))
+	for _, o := range bc.cfg.SLO.Objectives {
+		def := service.SLODefinition{Name: o.Name, Target: o.Target, Type: o.Type, Objective: o.Objective}
+		if o.LatencyThreshold != "" {
+			// Validated at config load.
+			def.LatencyThreshold, _ = time.ParseDuration(o.LatencyThreshold)
+		}
+		defs = append(defs, def)
+	}
+	interval, err := time.ParseDuration(bc.cfg.SLO.EvaluationInterval)
+	if err != nil {
+		interval = service.DefaultSLOEvaluationInterval
+	}
+
+	bc.sloService = service.NewSLOService(defs, bc.cfg.SLO.FastBurnThreshold, bc.cfg.SLO.SlowBurnThreshold, bc.logger)
+	if bc.eventBus != nil {
+		bc.sloService.SetEventBus(bc.eventBus)
+	}
+	chain.SetCallObserver(bc.sloService)
+	router.SetCallObserver(bc.sloService)
+	bc.apiHandler.SetSLOService(bc.sloService)
+
+	sloCtx, cancel := context.WithCancel(context.Background())
+	go bc.sloService.Run(sloCtx, interval)
+	bc.lifecycle.Register(lifecycle.Hook{
+		Name: "slo-stop", Phase: lifecycle.PhaseFlushBuffers,
+		Timeout: time.Second,
+		Fn:      func(ctx context.Context) error { cancel(); return nil },
+	})
+	bc.logger.Info("SLO tracking enabled", "objectives", len(defs), "evaluation_interval", interval)
+}
+
+// bootWatchdog starts the interceptor chain stall watchdog.
+func (bc *bootContext) bootWatchdog(chain *action.InterceptorChain) {
+	thresholds := make(map[watchdog.Stage]time.Duration, len(bc.cfg.Watchdog.Thresholds))
+	for stage, value := range bc.cfg.Watchdog.Thresholds {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			bc.logger.Warn("invalid watchdog threshold, using default", "stage", stage, "value", value)
+			continue
+		}
+		thresholds[watchdog.Stage(stage)] = d
+	}
+	interval, err := time.ParseDuration(bc.cfg.Watchdog.CheckInterval)
+	if err != nil {
+		interval = watchdog.DefaultCheckInterval
+	}
+
+	wd := watchdog.New(thresholds, interval, bc.logger)
+	if bc.eventBus != nil {
+		wd.SetEventBus(bc.eventBus)
+	}
+	chain.SetWatchdog(wd)
+
+	wdCtx, cancel := context.WithCancel(context.Background())
+	go wd.Run(wdCtx)
+	bc.lifecycle.Register(lifecycle.Hook{
+		Name: "watchdog-stop", Phase: lifecycle.PhaseFlushBuffers,
+		Timeout: time.Second,
+		Fn:      func(ctx context.Context) error { cancel(); return nil },
+	})
+	bc.logger.Debug("stall watchdog enabled", "check_interval", interval)
+}
+
 // bootRecording sets up session recording (passive observer).
 func (bc *bootContext) bootRecording(ctx context.Context, _ action.ActionInterceptor) {
 	var recordingCfg recording.RecordingConfig
@@ -433,6 +790,32 @@ func (a *recordingSessionAdapter) GetUsage(sessionID string) (recording.SessionU
 	}, true
 }
 
+// bootDailyQuota creates the counter enforcing max_calls_per_day. Usage is
+// persisted in the time series store, when there is one, so daily quotas
+// survive restarts.
+func (bc *bootContext) bootDailyQuota(ctx context.Context) *quota.DailyCounter {
+	daily := quota.NewDailyCounter(bc.logger)
+	if bc.timeSeriesStore == nil {
+		return daily
+	}
+	daily.SetTimeSeriesStore(bc.timeSeriesStore)
+	if err := daily.Load(ctx); err != nil {
+		bc.logger.Warn("failed to load daily quota usage, counting from zero", "error", err)
+	}
+
+	flushCtx, cancel := context.WithCancel(context.Background())
+	go daily.Run(flushCtx, quota.DefaultDailyFlushInterval)
+	bc.lifecycle.Register(lifecycle.Hook{
+		Name: "quota-daily-flush", Phase: lifecycle.PhaseFlushBuffers,
+		Timeout: 5 * time.Second,
+		Fn: func(ctx context.Context) error {
+			cancel()
+			return daily.Flush(ctx)
+		},
+	})
+	return daily
+}
+
 // quotaLimitAdapter bridges quota.QuotaStore to recording.QuotaLimitProvider.
 type quotaLimitAdapter struct {
 	store quota.QuotaStore
@@ -450,3 +833,35 @@ func (a *quotaLimitAdapter) GetLimits(identityID string) (recording.QuotaLimitsS
 		MaxCallsPerMinute:    cfg.MaxCallsPerMinute,
 	}, true
 }
+
+// newArgumentEncryptor builds the encryptor for sensitive tool arguments, or
+// returns nil when no sensitive arguments are configured. The key is
+// generated on first use.
+func (bc *bootContext) newArgumentEncryptor() (*audit.ArgumentEncryptor, error) {
+	cfg := bc.cfg.SensitiveArguments
+	if len(cfg.Tools) == 0 {
+		return nil, nil
+	}
+	keyPath := cfg.KeyPath
+	if keyPath == "" {
+		keyPath = filepath.Join(filepath.Dir(bc.statePath), "argument-key")
+	}
+	key, created, err := auditadapter.LoadOrCreateArgumentKey(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load argument key: %w", err)
+	}
+	if created {
+		bc.logger.Warn("generated new argument encryption key; back it up, encrypted arguments cannot be read without it", "path", keyPath)
+	}
+
+	rules := make([]audit.SensitiveArgRule, 0, len(cfg.Tools))
+	for _, t := range cfg.Tools {
+		rules = append(rules, audit.SensitiveArgRule{Tool: t.Tool, Paths: t.Paths})
+	}
+	enc, err := audit.NewArgumentEncryptor(key, rules)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sensitive_arguments: %w", err)
+	}
+	bc.logger.Info("sensitive argument encryption enabled", "tools", len(rules), "key_id", enc.KeyID())
+	return enc, nil
+}
@@ -26,7 +26,7 @@ func (a *toolCacheToolLister) GetAllToolNames() []string {
 // PermissionHealthIdentityLister, merging identities from both sources
 // so that YAML-configured identities are also visible.
 type stateIdentityLister struct {
-	stateStore *state.FileStateStore
+	stateStore state.Store
 	authStore  *memory.AuthStore
 }
 
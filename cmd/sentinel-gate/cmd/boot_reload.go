@@ -0,0 +1,393 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/inbound/admin"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/config"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/policy"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/ratelimit"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+// Reload triggers, recorded in the audit log.
+const (
+	reloadTriggerSignal = "sighup"
+	reloadTriggerAdmin  = "admin_api"
+)
+
+// yamlConfig returns the config file as last loaded: bc.cfg until the first
+// reload. bc.cfg itself keeps the config the process booted with, which the
+// running components were built from.
+func (bc *bootContext) yamlConfig() *config.OSSConfig {
+	bc.reloadMu.Lock()
+	defer bc.reloadMu.Unlock()
+	if bc.reloadedCfg != nil {
+		return bc.reloadedCfg
+	}
+	return bc.cfg
+}
+
+// bootConfigReload reloads the config file on POST /admin/api/system/reload
+// and, until ctx is done, on SIGHUP. Windows has no such signal.
+func (bc *bootContext) bootConfigReload(ctx context.Context) {
+	bc.apiHandler.SetConfigReloader(func(ctx context.Context) (admin.ConfigReloadResult, error) {
+		return bc.reloadConfig(ctx, reloadTriggerAdmin)
+	})
+	signals := reloadSignals()
+	if len(signals) == 0 {
+		return
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+	go func() {
+		defer signal.Stop(ch)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ch:
+				// Errors are logged and audited by reloadConfig.
+				_, _ = bc.reloadConfig(ctx, reloadTriggerSignal)
+			}
+		}
+	}()
+}
+
+// reloadConfig re-reads the config file and applies the changed settings
+// that can change at runtime: rate limits, YAML policies, YAML identities
+// and API keys, and the audit send timeout and warning threshold. Other
+// changed settings are reported as requiring a restart. An invalid file
+// changes nothing.
+func (bc *bootContext) reloadConfig(ctx context.Context, trigger string) (admin.ConfigReloadResult, error) {
+	result, err := bc.reload(ctx)
+	if err != nil {
+		bc.logger.Error("config reload failed, keeping the running config", "trigger", trigger, "error", err)
+		bc.auditReload(trigger, result, err)
+		return result, err
+	}
+	if len(result.RestartRequired) > 0 {
+		bc.logger.Warn("config reloaded, some changes require a restart",
+			"trigger", trigger, "applied", result.Applied, "restart_required", result.RestartRequired)
+	} else {
+		bc.logger.Info("config reloaded", "trigger", trigger, "applied", result.Applied)
+	}
+	bc.auditReload(trigger, result, nil)
+	return result, nil
+}
+
+func (bc *bootContext) reload(ctx context.Context) (admin.ConfigReloadResult, error) {
+	bc.reloadMu.Lock()
+	defer bc.reloadMu.Unlock()
+
+	result := admin.ConfigReloadResult{Applied: []string{}, RestartRequired: []string{}, ReloadedAt: time.Now().UTC()}
+	cur := bc.reloadedCfg
+	if cur == nil {
+		cur = bc.cfg
+	}
+	next, err := config.LoadConfigRaw()
+	if err != nil {
+		return result, fmt.Errorf("failed to load config: %w", err)
+	}
+	if bc.stdioTransport {
+		// The upstream was given on the command line, not read from the file.
+		next.Upstream = cur.Upstream
+	}
+	if err := next.Validate(); err != nil {
+		return result, fmt.Errorf("config validation failed: %w", err)
+	}
+
+	var hot []string
+	for _, path := range configChanges(cur, next) {
+		if bc.reloadable(path, next) {
+			hot = append(hot, path)
+		} else {
+			result.RestartRequired = append(result.RestartRequired, path)
+		}
+	}
+	if err := bc.applyConfig(ctx, cur, next, hot); err != nil {
+		result.RestartRequired = []string{}
+		return result, err
+	}
+	result.Applied = append(result.Applied, hot...)
+	bc.reloadedCfg = next
+	return result, nil
+}
+
+// reloadable reports whether the setting at the YAML path can be changed
+// to its value in next without a restart.
+func (bc *bootContext) reloadable(path string, next *config.OSSConfig) bool {
+	switch path {
+	case "rate_limit.ip_rate", "rate_limit.ip_burst", "rate_limit.user_rate", "rate_limit.user_burst":
+		// The tiers exist only when rate limiting was enabled at boot.
+		return bc.userRateLimiter != nil
+	case "rate_limit.session_rate", "rate_limit.session_burst":
+		// Turning the session tier on or off changes the interceptor chain.
+		return bc.sessionRateLimiter != nil && next.RateLimit.SessionRate > 0
+	case "rate_limit.overrides":
+		return bc.rateLimitOverrides != nil
+	case "policies", "auth.identities", "auth.api_keys",
+		"audit.send_timeout", "audit.warning_threshold":
+		return true
+	}
+	return false
+}
+
+// applyConfig applies the changed reloadable settings of next. The steps
+// that can fail run first, so that a failure leaves the running config as
+// it was.
+func (bc *bootContext) applyConfig(ctx context.Context, cur, next *config.OSSConfig, changed []string) error {
+	has := func(prefix string) bool {
+		for _, path := range changed {
+			if path == prefix || strings.HasPrefix(path, prefix+".") {
+				return true
+			}
+		}
+		return false
+	}
+
+	var appState *state.AppState
+	if has("auth") {
+		// Checked up front: seedAuthFromConfig stops at the first error.
+		for _, identityCfg := range next.Auth.Identities {
+			if _, err := service.AccessRestrictionsFromEntry(accessEntryFromConfig(identityCfg.Access)); err != nil {
+				return fmt.Errorf("identity %q: access: %w", identityCfg.ID, err)
+			}
+		}
+		var err error
+		if appState, err = bc.stateStore.Load(); err != nil {
+			return fmt.Errorf("load state: %w", err)
+		}
+	}
+	if has("rate_limit.overrides") {
+		if err := bc.rateLimitOverrides.SetConfigOverrides(rateLimitOverrides(next.RateLimit.Overrides)); err != nil {
+			return fmt.Errorf("rate_limit.overrides: %w", err)
+		}
+	}
+	if has("policies") {
+		if err := bc.reloadPolicies(ctx, cur, next); err != nil {
+			if has("rate_limit.overrides") {
+				_ = bc.rateLimitOverrides.SetConfigOverrides(rateLimitOverrides(cur.RateLimit.Overrides))
+			}
+			return err
+		}
+	}
+	if has("auth") {
+		bc.reloadAuth(appState, cur, next)
+	}
+	if has("rate_limit") {
+		bc.reloadRateLimits(next)
+	}
+	if has("audit") {
+		sendTimeout, err := time.ParseDuration(next.Audit.SendTimeout)
+		if err != nil {
+			sendTimeout = 100 * time.Millisecond
+		}
+		bc.auditService.SetBackpressure(sendTimeout, next.Audit.WarningThreshold)
+	}
+	return nil
+}
+
+// reloadPolicies swaps the YAML policies of cur for those of next and
+// recompiles the rules. If recompiling fails the policies of cur are
+// restored.
+func (bc *bootContext) reloadPolicies(ctx context.Context, cur, next *config.OSSConfig) error {
+	policies, err := policiesFromConfig(next)
+	if err != nil {
+		return err
+	}
+	old, err := policiesFromConfig(cur)
+	if err != nil {
+		return err
+	}
+	oldIDs := policyNames(old)
+	yamlIDs := make(map[string]bool, len(oldIDs))
+	for _, id := range oldIDs {
+		yamlIDs[id] = true
+	}
+
+	// At boot, state policies named like a YAML policy are skipped; a policy
+	// added to the file cannot take over one that is already loaded.
+	existing, err := bc.policyStore.GetAllPolicies(ctx)
+	if err != nil {
+		return fmt.Errorf("list policies: %w", err)
+	}
+	taken := make(map[string]bool, len(existing))
+	for _, p := range existing {
+		if !yamlIDs[p.ID] {
+			taken[p.Name] = true
+		}
+	}
+	for _, p := range policies {
+		if taken[p.Name] {
+			return fmt.Errorf("policy %q is already defined in the admin API or the policy directory", p.Name)
+		}
+		if err := bc.policyService.ValidateRules(p.Rules); err != nil {
+			return fmt.Errorf("policy %q: %w", p.Name, err)
+		}
+	}
+
+	bc.policyStore.ReplacePolicies(oldIDs, policies)
+	if err := bc.policyService.Reload(ctx); err != nil {
+		bc.policyStore.ReplacePolicies(policyNames(policies), old)
+		if rbErr := bc.policyService.Reload(ctx); rbErr != nil {
+			bc.logger.Error("CRITICAL: failed to restore policies after config reload error", "error", rbErr)
+		}
+		return fmt.Errorf("reload policies: %w", err)
+	}
+	return nil
+}
+
+// policyNames returns the IDs of policies seeded from the config, which are
+// their names.
+func policyNames(policies []*policy.Policy) []string {
+	names := make([]string, len(policies))
+	for i, p := range policies {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// reloadAuth replaces the YAML identities and API keys of cur with those of
+// next. state.json entries win over the file, as at boot. Sessions of the
+// identities whose YAML entries changed are invalidated.
+func (bc *bootContext) reloadAuth(appState *state.AppState, cur, next *config.OSSConfig) {
+	inState := make(map[string]bool, len(appState.Identities))
+	for _, identity := range appState.Identities {
+		inState[identity.ID] = true
+	}
+
+	changed := make(map[string]bool)
+	nextIdentities := make(map[string]config.IdentityConfig, len(next.Auth.Identities))
+	for _, identityCfg := range next.Auth.Identities {
+		nextIdentities[identityCfg.ID] = identityCfg
+	}
+	for _, identityCfg := range cur.Auth.Identities {
+		n, ok := nextIdentities[identityCfg.ID]
+		if !ok {
+			changed[identityCfg.ID] = true
+			if !inState[identityCfg.ID] {
+				bc.authStore.RemoveIdentity(identityCfg.ID)
+			}
+		} else if !reflect.DeepEqual(n, identityCfg) {
+			changed[identityCfg.ID] = true
+		}
+	}
+	nextKeys := make(map[string]config.APIKeyConfig, len(next.Auth.APIKeys))
+	for _, keyCfg := range next.Auth.APIKeys {
+		nextKeys[keyCfg.KeyHash] = keyCfg
+	}
+	for _, keyCfg := range cur.Auth.APIKeys {
+		if n, ok := nextKeys[keyCfg.KeyHash]; !ok || !reflect.DeepEqual(n, keyCfg) {
+			changed[keyCfg.IdentityID] = true
+		}
+	}
+
+	if err := seedAuthFromConfig(next, bc.authStore); err != nil {
+		// Unreachable: access restrictions are checked by applyConfig.
+		bc.logger.Error("failed to seed auth from reloaded config", "error", err)
+	}
+	// Removes the YAML keys of cur that are gone, then re-applies the state.
+	seedAuthFromState(appState, bc.authStore, next, bc.logger)
+
+	if bc.actionAuthInterceptor != nil {
+		for id := range changed {
+			bc.actionAuthInterceptor.InvalidateByIdentity(id)
+		}
+	}
+}
+
+// reloadRateLimits sets the rates and bursts of next on the running tiers.
+// Buckets in use keep their state.
+func (bc *bootContext) reloadRateLimits(next *config.OSSConfig) {
+	rl := next.RateLimit
+	if bc.ipRateLimiter != nil {
+		bc.ipRateLimiter.SetConfig(ratelimit.RateLimitConfig{Rate: rl.IPRate, Burst: rl.IPBurst, Period: time.Minute})
+	}
+	if bc.userRateLimiter != nil {
+		bc.userRateLimiter.SetConfig(ratelimit.RateLimitConfig{Rate: rl.UserRate, Burst: rl.UserBurst, Period: time.Minute})
+	}
+	if bc.sessionRateLimiter != nil && rl.SessionRate > 0 {
+		bc.sessionRateLimiter.SetConfig(ratelimit.RateLimitConfig{Rate: rl.SessionRate, Burst: rl.SessionBurst, Period: time.Minute})
+	}
+}
+
+// auditReload records a config reload in the audit log.
+func (bc *bootContext) auditReload(trigger string, result admin.ConfigReloadResult, err error) {
+	if bc.auditService == nil {
+		return
+	}
+	record := audit.AuditRecord{
+		Timestamp:    time.Now().UTC(),
+		IdentityID:   "system",
+		IdentityName: "system",
+		ToolName:     "config.reload",
+		ToolArguments: map[string]interface{}{
+			"trigger":          trigger,
+			"applied":          result.Applied,
+			"restart_required": result.RestartRequired,
+		},
+		Decision: audit.DecisionAllow,
+		Reason:   fmt.Sprintf("config reloaded: %d change(s) applied, %d require a restart", len(result.Applied), len(result.RestartRequired)),
+		Source:   "config_reload",
+	}
+	if err != nil {
+		record.Decision = audit.DecisionWarn
+		record.Reason = "config reload failed: " + err.Error()
+	}
+	bc.auditService.Record(record)
+}
+
+// configChanges returns the YAML paths of the settings that differ between
+// a and b, sorted. Lists and maps are compared as a whole.
+func configChanges(a, b *config.OSSConfig) []string {
+	var paths []string
+	diffValues(reflect.ValueOf(*a), reflect.ValueOf(*b), "", &paths)
+	sort.Strings(paths)
+	return paths
+}
+
+func diffValues(a, b reflect.Value, path string, paths *[]string) {
+	switch a.Kind() {
+	case reflect.Struct:
+		t := a.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(f.Name)
+			}
+			if path != "" {
+				name = path + "." + name
+			}
+			diffValues(a.Field(i), b.Field(i), name, paths)
+		}
+	case reflect.Pointer:
+		if !a.IsNil() && !b.IsNil() && a.Elem().Kind() == reflect.Struct {
+			diffValues(a.Elem(), b.Elem(), path, paths)
+			return
+		}
+		if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+			*paths = append(*paths, path)
+		}
+	default:
+		if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+			*paths = append(*paths, path)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/config"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/action"
+)
+
+func TestConfigChanges(t *testing.T) {
+	a := &config.OSSConfig{}
+	a.RateLimit.UserRate = 100
+	a.Server.HTTPAddr = ":8080"
+	a.Policies = []config.PolicyConfig{{Name: "base"}}
+
+	b := &config.OSSConfig{}
+	b.RateLimit.UserRate = 200
+	b.Server.HTTPAddr = ":8080"
+	b.Policies = []config.PolicyConfig{{Name: "base"}, {Name: "extra"}}
+	b.Audit.SendTimeout = "1s"
+
+	want := []string{"audit.send_timeout", "policies", "rate_limit.user_rate"}
+	if got := configChanges(a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("configChanges() = %v, want %v", got, want)
+	}
+	if got := configChanges(a, a); len(got) != 0 {
+		t.Errorf("configChanges() of the same config = %v, want none", got)
+	}
+}
+
+func TestBootContext_Reloadable(t *testing.T) {
+	next := &config.OSSConfig{}
+	bc := &bootContext{}
+
+	for _, path := range []string{"policies", "auth.identities", "audit.warning_threshold"} {
+		if !bc.reloadable(path, next) {
+			t.Errorf("reloadable(%q) = false, want true", path)
+		}
+	}
+	for _, path := range []string{"server.http_addr", "rate_limit.enabled", "audit.output"} {
+		if bc.reloadable(path, next) {
+			t.Errorf("reloadable(%q) = true, want false", path)
+		}
+	}
+	// Rates need the tiers built at boot.
+	if bc.reloadable("rate_limit.user_rate", next) {
+		t.Error("reloadable(rate_limit.user_rate) without rate limiting = true, want false")
+	}
+	bc.userRateLimiter = &action.ActionUserRateLimitInterceptor{}
+	if !bc.reloadable("rate_limit.user_rate", next) {
+		t.Error("reloadable(rate_limit.user_rate) = false, want true")
+	}
+	// The session tier cannot be turned on without a restart.
+	next.RateLimit.SessionRate = 10
+	if bc.reloadable("rate_limit.session_rate", next) {
+		t.Error("reloadable(rate_limit.session_rate) without the session tier = true, want false")
+	}
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/scheduler"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/lifecycle"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+// bootScheduler registers the built-in recurring jobs and starts the
+// scheduler. Job schedules come from the job defaults, overridden by
+// scheduler.jobs in the YAML config, overridden in turn by changes made
+// from the admin API (persisted in state.json).
+func (bc *bootContext) bootScheduler() error {
+	if !bc.cfg.Scheduler.Enabled {
+		bc.logger.Info("scheduler disabled")
+		if len(bc.cfg.ResponseGuard.Tools) > 0 {
+			bc.logger.Warn("response_guard needs the scheduler, canary calls are disabled")
+		}
+		return nil
+	}
+
+	warning, err := time.ParseDuration(bc.cfg.Scheduler.KeyExpiryWarning)
+	if err != nil || warning <= 0 {
+		return fmt.Errorf("invalid scheduler.key_expiry_warning %q", bc.cfg.Scheduler.KeyExpiryWarning)
+	}
+	stateDir := filepath.Dir(bc.statePath)
+	snapshotDir := bc.cfg.Scheduler.SnapshotDir
+	if snapshotDir == "" {
+		snapshotDir = filepath.Join(stateDir, "snapshots")
+	}
+	reportDir := bc.cfg.Scheduler.ReportDir
+	if reportDir == "" {
+		reportDir = filepath.Join(stateDir, "reports")
+	}
+
+	var jobs []scheduler.Job
+	if bc.auditFileStore != nil {
+		jobs = append(jobs, service.AuditArchivalJob(bc.auditFileStore))
+	}
+	if bc.identityService != nil {
+		jobs = append(jobs, service.KeyExpiryJob(bc.identityService, bc.eventBus, warning))
+	}
+	jobs = append(jobs, service.StateSnapshotJob(bc.stateStore, snapshotDir, bc.cfg.Scheduler.SnapshotKeep))
+	if bc.complianceService != nil {
+		jobs = append(jobs, service.ComplianceReportJob(bc.complianceService, bc.complianceContext,
+			"sentinelgate-"+Version, reportDir, bc.cfg.Scheduler.ReportKeep))
+	}
+	if bc.upstreamService != nil {
+		jobs = append(jobs, service.UpstreamConformanceJob(bc.upstreamService, defaultClientFactory(bc.cfg, bc.reverseHub, bc.egressTLS, bc.connWatcher()), bc.eventBus))
+	}
+	if guard := bc.newResponseGuard(); guard != nil {
+		jobs = append(jobs, service.ResponseGuardJob(guard))
+	}
+
+	sched := scheduler.New(bc.logger)
+	known := make(map[string]bool, len(jobs))
+	for _, job := range jobs {
+		known[job.Name] = true
+		if override, ok := bc.cfg.Scheduler.Jobs[job.Name]; ok {
+			if override.Schedule != "" {
+				job.Schedule = override.Schedule
+			}
+			if override.Enabled != nil {
+				job.Enabled = *override.Enabled
+			}
+			if _, err := scheduler.Parse(job.Schedule); err != nil {
+				return fmt.Errorf("scheduler.jobs.%s: invalid schedule %q: %w", job.Name, job.Schedule, err)
+			}
+		}
+		if saved, ok := bc.appState.JobSchedules[job.Name]; ok {
+			if _, err := scheduler.Parse(saved.Schedule); err != nil {
+				bc.logger.Warn("ignoring invalid saved job schedule", "job", job.Name, "schedule", saved.Schedule, "error", err)
+			} else {
+				job.Schedule = saved.Schedule
+				job.Enabled = saved.Enabled
+			}
+		}
+		if err := sched.Register(job); err != nil {
+			return fmt.Errorf("failed to register job: %w", err)
+		}
+	}
+
+	var unknown []string
+	for name := range bc.cfg.Scheduler.Jobs {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		bc.logger.Warn("scheduler.jobs references unknown jobs", "jobs", unknown)
+	}
+
+	bc.jobService = service.NewJobService(sched, bc.stateStore, bc.logger)
+	if bc.eventBus != nil {
+		bc.jobService.SetEventBus(bc.eventBus)
+	}
+	bc.apiHandler.SetJobService(bc.jobService)
+
+	schedCtx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sched.Run(schedCtx)
+	}()
+	// Stop before buffers are flushed so a job never writes into a store
+	// that is shutting down. Running jobs see their context cancelled.
+	bc.lifecycle.Register(lifecycle.Hook{
+		Name: "scheduler-stop", Phase: lifecycle.PhaseDrainRequests,
+		Timeout: 10 * time.Second,
+		Fn: func(ctx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+
+	bc.logger.Info("scheduler started", "jobs", len(jobs))
+	return nil
+}
+
+// newResponseGuard builds the response guard from the YAML config, or
+// returns nil when no tools are guarded.
+func (bc *bootContext) newResponseGuard() *service.ResponseGuardService {
+	if len(bc.cfg.ResponseGuard.Tools) == 0 || bc.upstreamRouter == nil {
+		return nil
+	}
+	canaries := make([]service.ResponseCanary, 0, len(bc.cfg.ResponseGuard.Tools))
+	for _, t := range bc.cfg.ResponseGuard.Tools {
+		var args map[string]interface{}
+		if t.Arguments != "" {
+			_ = json.Unmarshal([]byte(t.Arguments), &args) // validated at config load
+		}
+		canaries = append(canaries, service.ResponseCanary{
+			Tool:       t.Tool,
+			Arguments:  args,
+			Compare:    t.Compare,
+			Threshold:  t.Threshold,
+			Quarantine: t.Quarantine,
+		})
+	}
+	guard := service.NewResponseGuardService(canaries, bc.upstreamRouter, bc.stateStore, bc.logger)
+	guard.LoadFromState(bc.appState)
+	if bc.eventBus != nil {
+		guard.SetEventBus(bc.eventBus)
+	}
+	if bc.toolSecurityService != nil {
+		guard.SetQuarantiner(bc.toolSecurityService)
+	}
+	bc.apiHandler.SetResponseGuardService(guard)
+	bc.logger.Info("response guard enabled", "tools", len(canaries))
+	return guard
+}
@@ -7,11 +7,12 @@ import (
 	"log/slog"
 	"net"
 	"net/url"
-	"os"
 	"time"
 
 	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/inbound/admin"
+	auditadapter "github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/audit"
 	evidenceAdapter "github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/evidence"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/opa"
 	storageAdapter "github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/storage"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/action"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
@@ -38,7 +39,8 @@ func (bc *bootContext) bootServices(ctx context.Context) error {
 	bc.sessionService = session.NewSessionService(bc.sessionStore, session.Config{
 		Timeout: sessionTimeout,
 	})
-	bc.policyService, err = service.NewPolicyService(ctx, bc.policyStore, bc.logger)
+	bc.policyService, err = service.NewPolicyService(ctx, bc.policyStore, bc.logger,
+		service.WithCELLimits(celLimits(bc.cfg.CEL)))
 	if err != nil {
 		return fmt.Errorf("failed to create policy service: %w", err)
 	}
@@ -47,8 +49,30 @@ func (bc *bootContext) bootServices(ctx context.Context) error {
 	// H-10: Restore persisted pending evaluations (approval_required, etc.) from state.json.
 	bc.policyEvalService.LoadFromState(bc.appState)
 
+	// Policy variables (vars in rule conditions) set from the admin API.
+	bc.policyVariableService = service.NewPolicyVariableService(bc.policyService, bc.stateStore, bc.logger)
+	bc.policyVariableService.Load(bc.appState.PolicyVariables)
+
+	// Rate limit overrides: read-only ones from the YAML config, the others
+	// from the admin API.
+	bc.rateLimitOverrides = service.NewRateLimitOverrideService(bc.stateStore, bc.logger)
+	if err := bc.rateLimitOverrides.SetConfigOverrides(rateLimitOverrides(bc.cfg.RateLimit.Overrides)); err != nil {
+		return err
+	}
+	bc.rateLimitOverrides.Load(bc.appState.RateLimitOverrides)
+	bc.rateLimitExemptions = service.NewRateLimitExemptionService(bc.policyService, bc.logger)
+
+	// Scoped tokens for automating the admin API.
+	bc.adminTokens = service.NewAdminTokenService(bc.stateStore, bc.logger)
+	bc.adminTokens.Load(bc.appState.AdminTokens)
+
+	// Banner and terms returned to agents with initialize.
+	bc.noticeService = service.NewNoticeService(bc.stateStore, bc.logger)
+	bc.noticeService.Load(bc.appState.Notice)
+
 	// Audit store + service
-	bc.auditStore, err = createAuditStore(bc.cfg, bc.logger)
+	var auditSyslogStore *auditadapter.SyslogAuditStore
+	bc.auditStore, auditSyslogStore, err = createAuditStore(bc.cfg, bc.logger)
 	if err != nil {
 		return fmt.Errorf("failed to create audit store: %w", err)
 	}
@@ -58,6 +82,43 @@ func (bc *bootContext) bootServices(ctx context.Context) error {
 		Timeout: 3 * time.Second,
 		Fn:      func(ctx context.Context) error { return bc.auditStore.Close() },
 	})
+	var persistent []audit.AuditStore
+	if auditSyslogStore != nil {
+		persistent = append(persistent, auditSyslogStore)
+		bc.lifecycle.Register(lifecycle.Hook{
+			Name: "audit-syslog-store-close", Phase: lifecycle.PhaseCleanup,
+			Timeout: 15 * time.Second,
+			Fn:      func(ctx context.Context) error { return auditSyslogStore.Close() },
+		})
+	}
+	bc.auditFileStore, err = createAuditFileStore(bc.cfg, bc.logger)
+	if err != nil {
+		return fmt.Errorf("failed to create audit file store: %w", err)
+	}
+	if bc.auditFileStore != nil {
+		persistent = append(persistent, bc.auditFileStore)
+		bc.lifecycle.Register(lifecycle.Hook{
+			Name: "audit-file-store-close", Phase: lifecycle.PhaseCleanup,
+			Timeout: 5 * time.Second,
+			Fn:      func(ctx context.Context) error { return bc.auditFileStore.Close() },
+		})
+	}
+	bc.auditSQLiteStore, err = createAuditSQLiteStore(bc.cfg, bc.logger)
+	if err != nil {
+		return fmt.Errorf("failed to create audit database: %w", err)
+	}
+	if bc.auditSQLiteStore != nil {
+		persistent = append(persistent, bc.auditSQLiteStore)
+		bc.lifecycle.Register(lifecycle.Hook{
+			Name: "audit-sqlite-store-close", Phase: lifecycle.PhaseCleanup,
+			Timeout: 5 * time.Second,
+			Fn:      func(ctx context.Context) error { return bc.auditSQLiteStore.Close() },
+		})
+	}
+	var auditSink audit.AuditStore = bc.auditStore
+	if len(persistent) > 0 {
+		auditSink = &teeAuditStore{primary: bc.auditStore, others: persistent}
+	}
 
 	flushInterval, err := time.ParseDuration(bc.cfg.Audit.FlushInterval)
 	if err != nil {
@@ -70,13 +131,28 @@ func (bc *bootContext) bootServices(ctx context.Context) error {
 		bc.logger.Warn("invalid send_timeout, using default", "value", bc.cfg.Audit.SendTimeout, "default", "100ms")
 	}
 
-	bc.auditService = service.NewAuditService(bc.auditStore, bc.logger,
+	auditOpts := []service.AuditOption{
 		service.WithChannelSize(bc.cfg.Audit.ChannelSize),
 		service.WithBatchSize(bc.cfg.Audit.BatchSize),
 		service.WithFlushInterval(flushInterval),
 		service.WithSendTimeout(sendTimeout),
 		service.WithWarningThreshold(bc.cfg.Audit.WarningThreshold),
-	)
+	}
+	if path := bc.cfg.Audit.JournalPath; path != "" {
+		journal, err := auditadapter.OpenJournal(path, bc.logger)
+		if err != nil {
+			return fmt.Errorf("failed to open audit journal: %w", err)
+		}
+		// Closed after the final flush (audit-flush runs in an earlier phase).
+		bc.lifecycle.Register(lifecycle.Hook{
+			Name: "audit-journal-close", Phase: lifecycle.PhaseCleanup,
+			Timeout: time.Second,
+			Fn:      func(ctx context.Context) error { return journal.Close() },
+		})
+		auditOpts = append(auditOpts, service.WithJournal(journal))
+		bc.logger.Info("audit journal enabled", "path", path, "backlog", journal.Backlog())
+	}
+	bc.auditService = service.NewAuditService(auditSink, bc.logger, auditOpts...)
 	bc.auditService.Start(context.Background())
 
 	// Register lifecycle hooks (A6: ordered shutdown)
@@ -89,19 +165,35 @@ func (bc *bootContext) bootServices(ctx context.Context) error {
 	// Event Bus (A4: Internal Event Bus)
 	bc.eventBus = event.NewBus(1000)
 	bc.eventBus.Start()
+	bc.auditService.SetEventBus(bc.eventBus)
+	bc.policyVariableService.SetEventBus(bc.eventBus)
+	bc.rateLimitOverrides.SetEventBus(bc.eventBus)
+	bc.noticeService.SetEventBus(bc.eventBus)
+
+	// Event sinks: every sink gets its own filter and queue from the
+	// dispatcher. The dispatcher stops before the sinks and before the event
+	// bus is drained (hooks in the same phase run in registration order).
+	bc.eventDispatcher = event.NewDispatcher(bc.eventBus, bc.logger)
+	bc.lifecycle.Register(lifecycle.Hook{
+		Name: "event-dispatcher-stop", Phase: lifecycle.PhaseFlushBuffers,
+		Timeout: 5 * time.Second,
+		Fn:      func(ctx context.Context) error { bc.eventDispatcher.Stop(); return nil },
+	})
 
-	// Notification Center (UX-F3: subscribes to all events)
+	// Notification Center (UX-F3: the admin UI sink receives all events)
 	bc.notificationService = service.NewNotificationService(500)
-	bc.notificationService.SubscribeToBus(bc.eventBus)
-	// Unsubscribe BEFORE the event bus is drained (both in PhaseFlushBuffers,
-	// but notification-stop sorts first because hooks in the same phase run
-	// in registration order and this is registered before event-bus-drain).
+	bc.eventDispatcher.AddSink(bc.notificationService, event.SinkOptions{QueueSize: bc.cfg.Events.SinkQueueSize})
 	bc.lifecycle.Register(lifecycle.Hook{
 		Name: "notification-stop", Phase: lifecycle.PhaseFlushBuffers,
 		Timeout: 3 * time.Second,
 		Fn:      func(ctx context.Context) error { bc.notificationService.Stop(); return nil },
 	})
 
+	// Webhooks are added before upstreams start so lifecycle events from the
+	// first connection attempts are delivered too.
+	bc.bootWebhooks()
+	bc.bootEventLog()
+
 	// Storage abstraction (A5: TimeSeriesStore + VersionedStore)
 	if err := bc.bootStorage(ctx); err != nil {
 		return fmt.Errorf("boot storage: %w", err)
@@ -110,17 +202,8 @@ func (bc *bootContext) bootServices(ctx context.Context) error {
 	// Evidence service (Upgrade 1: Cryptographic Evidence)
 	// Honor state.json override: same override-when-present pattern as other toggles.
 	if bc.evidenceEnabled() {
-		keyPath := bc.cfg.Evidence.KeyPath
-		if keyPath == "" {
-			keyPath = "evidence-key.pem"
-		}
-		signerID := bc.cfg.Evidence.SignerID
-		if signerID == "" {
-			signerID, _ = os.Hostname()
-			if signerID == "" {
-				signerID = "sentinel-gate"
-			}
-		}
+		keyPath := evidenceKeyPath(bc.cfg)
+		signerID := evidenceSignerID(bc.cfg)
 
 		signer, signerErr := evidenceAdapter.NewECDSASigner(keyPath, signerID)
 		if signerErr != nil {
@@ -200,6 +283,12 @@ func (bc *bootContext) bootServices(ctx context.Context) error {
 	if err := bc.policyAdminService.LoadPoliciesFromState(ctx, bc.appState); err != nil {
 		bc.logger.Error("failed to load policies from state", "error", err)
 	}
+	if err := bc.bootPolicyDirectory(ctx); err != nil {
+		return err
+	}
+	if err := bc.bootRegoEngine(ctx); err != nil {
+		return err
+	}
 
 	bc.identityService = service.NewIdentityService(bc.stateStore, bc.logger)
 	if err := bc.identityService.Init(); err != nil {
@@ -213,7 +302,7 @@ func (bc *bootContext) bootServices(ctx context.Context) error {
 		}
 		// M-11: Pass cfg so seedAuthFromState can distinguish YAML-seeded
 		// entries from state-sourced ones and remove revoked/deleted keys.
-		seedAuthFromState(hookState, bc.authStore, bc.cfg, bc.logger)
+		seedAuthFromState(hookState, bc.authStore, bc.yamlConfig(), bc.logger)
 	})
 	// H-1: Invalidate cached sessions when identity roles change.
 	bc.identityService.SetSessionInvalidator(func(identityID string) {
@@ -224,6 +313,12 @@ func (bc *bootContext) bootServices(ctx context.Context) error {
 
 	bc.templateService = service.NewTemplateService(bc.policyAdminService, bc.logger)
 	bc.statsService = service.NewStatsService()
+	bc.bootToolStats()
+	if err := bc.bootCostAccounting(); err != nil {
+		return err
+	}
+	bc.bootOutboundLearning()
+	bc.bootAnomalyDetection()
 
 	// Namespace isolation (Upgrade 8): config from state.json.
 	bc.namespaceService = service.NewNamespaceService(bc.logger)
@@ -247,6 +342,226 @@ func (bc *bootContext) bootServices(ctx context.Context) error {
 	return nil
 }
 
+// bootToolStats creates the per-tool statistics service. Rollups are flushed
+// to the time series store periodically and once more on shutdown, before
+// the store is closed.
+func (bc *bootContext) bootToolStats() {
+	bc.toolStatsService = service.NewToolStatsService(bc.logger)
+	if bc.timeSeriesStore == nil {
+		return
+	}
+	bc.toolStatsService.SetTimeSeriesStore(bc.timeSeriesStore)
+
+	statsCtx, cancel := context.WithCancel(context.Background())
+	go bc.toolStatsService.Run(statsCtx, service.DefaultToolStatsFlushInterval)
+	bc.lifecycle.Register(lifecycle.Hook{
+		Name: "tool-stats-flush", Phase: lifecycle.PhaseFlushBuffers,
+		Timeout: 5 * time.Second,
+		Fn: func(ctx context.Context) error {
+			cancel()
+			return bc.toolStatsService.Flush(ctx)
+		},
+	})
+}
+
+// bootCostAccounting creates the cost accounting service when enabled. Calls
+// are priced by the cost table and the cost webhook; aggregates are flushed
+// to the time series store periodically and once more on shutdown.
+func (bc *bootContext) bootCostAccounting() error {
+	ca := bc.cfg.CostAccounting
+	if !ca.Enabled {
+		return nil
+	}
+
+	var hooks []service.CostHook
+	if len(ca.Table) > 0 {
+		rates := make([]service.CostRate, 0, len(ca.Table))
+		for _, r := range ca.Table {
+			rates = append(rates, service.CostRate{
+				Tool: r.Tool, Upstream: r.Upstream,
+				PerCall: r.PerCall, PerSecond: r.PerSecond, PerMB: r.PerMB,
+			})
+		}
+		table, err := service.NewStaticCostTable(rates)
+		if err != nil {
+			return fmt.Errorf("cost_accounting.table: %w", err)
+		}
+		hooks = append(hooks, table)
+	}
+	if ca.Webhook.URL != "" {
+		// Same SSRF checks as the event webhooks.
+		if msg := validateWebhookURL(ca.Webhook.URL); msg != "" {
+			bc.logger.Error("cost webhook URL rejected, webhook disabled", "url", ca.Webhook.URL, "reason", msg)
+		} else {
+			timeout, _ := time.ParseDuration(ca.Webhook.Timeout) // validated at config load
+			hooks = append(hooks, service.NewWebhookCostHook(ca.Webhook.URL, ca.Webhook.Secret, timeout))
+		}
+	}
+
+	bc.costAccounting = service.NewCostAccountingService(hooks, bc.logger)
+	bc.costAccounting.SetCurrency(ca.Currency)
+	if bc.timeSeriesStore != nil {
+		bc.costAccounting.SetTimeSeriesStore(bc.timeSeriesStore)
+	}
+
+	costCtx, cancel := context.WithCancel(context.Background())
+	go bc.costAccounting.Run(costCtx, service.DefaultCostFlushInterval)
+	bc.lifecycle.Register(lifecycle.Hook{
+		Name: "cost-accounting-flush", Phase: lifecycle.PhaseFlushBuffers,
+		Timeout: 5 * time.Second,
+		Fn: func(ctx context.Context) error {
+			cancel()
+			return bc.costAccounting.Flush(ctx)
+		},
+	})
+	bc.logger.Info("cost accounting enabled", "rates", len(ca.Table), "hooks", len(hooks), "currency", ca.Currency)
+	return nil
+}
+
+// bootPolicyDirectory loads the policies of the policy directory, if one is
+// configured, and watches it for changes. An invalid directory at startup
+// stops the boot, like an invalid config file.
+func (bc *bootContext) bootPolicyDirectory(ctx context.Context) error {
+	pd := bc.cfg.PolicyDirectory
+	if pd.Path == "" {
+		return nil
+	}
+	bc.policyDirectory = service.NewPolicyDirectory(pd.Path, bc.policyStore, bc.policyService, bc.logger)
+	if err := bc.policyDirectory.Load(ctx); err != nil {
+		return fmt.Errorf("policy directory: %w", err)
+	}
+	interval, err := time.ParseDuration(pd.ReloadInterval)
+	if err != nil {
+		interval = service.DefaultPolicyDirectoryReloadInterval
+	}
+
+	watchCtx, cancel := context.WithCancel(context.Background())
+	go bc.policyDirectory.Run(watchCtx, interval)
+	bc.lifecycle.Register(lifecycle.Hook{
+		Name: "policy-directory-stop", Phase: lifecycle.PhaseDrainRequests,
+		Timeout: time.Second,
+		Fn:      func(ctx context.Context) error { cancel(); return nil },
+	})
+	status := bc.policyDirectory.Status()
+	bc.logger.Info("policy directory loaded", "dir", pd.Path,
+		"files", len(status.Files), "policies", status.Policies, "reload_interval", pd.ReloadInterval)
+	return nil
+}
+
+// bootRegoEngine creates the Rego policy engine when policy.engine is
+// "rego" and pushes the bundle directory, if one is configured, to the OPA
+// server. An unreachable server does not stop the boot: the directory is
+// pushed again by the watcher, and calls are denied until it answers.
+func (bc *bootContext) bootRegoEngine(ctx context.Context) error {
+	if bc.cfg.Policy.Engine != "rego" {
+		return nil
+	}
+	rc := bc.cfg.Policy.Rego
+	timeout, _ := time.ParseDuration(rc.Timeout) // validated; 0 uses the default
+	var err error
+	bc.regoEngine, err = opa.New(opa.Config{
+		URL:       rc.URL,
+		Decision:  rc.Decision,
+		Token:     rc.Token,
+		Timeout:   timeout,
+		BundleDir: rc.BundleDir,
+	}, bc.policyService.Variables, bc.logger)
+	if err != nil {
+		return fmt.Errorf("rego engine: %w", err)
+	}
+	bc.logger.Info("rego policy engine enabled", "url", rc.URL, "decision", rc.Decision)
+	if rc.BundleDir == "" {
+		return nil
+	}
+
+	if _, err := bc.regoEngine.SyncBundle(ctx); err != nil {
+		bc.logger.Warn("rego bundle push failed", "dir", rc.BundleDir, "error", err)
+	}
+	interval, err := time.ParseDuration(rc.ReloadInterval)
+	if err != nil {
+		interval = opa.DefaultReloadInterval
+	}
+	watchCtx, cancel := context.WithCancel(context.Background())
+	go bc.regoEngine.Run(watchCtx, interval)
+	bc.lifecycle.Register(lifecycle.Hook{
+		Name: "rego-bundle-stop", Phase: lifecycle.PhaseDrainRequests,
+		Timeout: time.Second,
+		Fn:      func(ctx context.Context) error { cancel(); return nil },
+	})
+	status := bc.regoEngine.Status()
+	bc.logger.Info("rego bundle directory watched", "dir", rc.BundleDir,
+		"files", len(status.Files), "reload_interval", rc.ReloadInterval)
+	return nil
+}
+
+// bootOutboundLearning creates the outbound allowlist learning service and
+// restores any window persisted by a previous run. Observations are flushed
+// to state.json periodically and once more on shutdown.
+func (bc *bootContext) bootOutboundLearning() {
+	bc.outboundLearningService = service.NewOutboundLearningService(bc.stateStore, bc.policyAdminService, bc.logger)
+	bc.outboundLearningService.LoadFromState(bc.appState)
+	if st := bc.outboundLearningService.Status(); st.Active {
+		bc.logger.Info("outbound learning resumed", "ends_at", st.EndsAt, "observations", st.Observations)
+	}
+
+	learnCtx, cancel := context.WithCancel(context.Background())
+	go bc.outboundLearningService.Run(learnCtx, service.DefaultOutboundLearningFlushInterval)
+	bc.lifecycle.Register(lifecycle.Hook{
+		Name: "outbound-learning-flush", Phase: lifecycle.PhaseFlushBuffers,
+		Timeout: 5 * time.Second,
+		Fn: func(ctx context.Context) error {
+			cancel()
+			return bc.outboundLearningService.Flush(ctx)
+		},
+	})
+}
+
+// bootAnomalyDetection creates the argument anomaly detection service when
+// enabled and restores the profiles of a previous run. Profiles are flushed
+// to state.json periodically and once more on shutdown.
+func (bc *bootContext) bootAnomalyDetection() {
+	ac := bc.cfg.AnomalyDetection
+	if !ac.Enabled {
+		return
+	}
+	// The durations were validated with the config.
+	warmupPeriod, _ := time.ParseDuration(ac.WarmupPeriod)
+	cfg := service.ArgumentAnomalyConfig{
+		WarmupCalls:  int64(ac.WarmupCalls),
+		WarmupPeriod: warmupPeriod,
+		Threshold:    ac.Threshold,
+	}
+	for _, t := range ac.Tools {
+		period, _ := time.ParseDuration(t.WarmupPeriod)
+		cfg.Tools = append(cfg.Tools, service.ArgumentAnomalyToolConfig{
+			Pattern:      t.Tool,
+			Disabled:     t.Disabled,
+			WarmupCalls:  int64(t.WarmupCalls),
+			WarmupPeriod: period,
+			Threshold:    t.Threshold,
+		})
+	}
+	bc.argumentAnomalyService = service.NewArgumentAnomalyService(cfg, bc.stateStore, bc.logger)
+	bc.argumentAnomalyService.LoadFromState(bc.appState)
+	if bc.eventBus != nil {
+		bc.argumentAnomalyService.SetEventBus(bc.eventBus)
+	}
+	bc.logger.Info("anomaly detection enabled",
+		"warmup_calls", ac.WarmupCalls, "warmup_period", ac.WarmupPeriod,
+		"threshold", ac.Threshold, "profiled_tools", bc.argumentAnomalyService.Tools())
+
+	flushCtx, cancel := context.WithCancel(context.Background())
+	go bc.argumentAnomalyService.Run(flushCtx, service.DefaultArgumentProfileFlushInterval)
+	bc.lifecycle.Register(lifecycle.Hook{
+		Name: "argument-profile-flush", Phase: lifecycle.PhaseFlushBuffers,
+		Timeout: 5 * time.Second,
+		Fn: func(ctx context.Context) error {
+			cancel()
+			return bc.argumentAnomalyService.Flush(ctx)
+		},
+	})
+}
+
 // bootComplianceAndSimulation wires Compliance (Upgrade 2) and Simulation (UX-F1)
 // services. Called after bootAdminAPI + bootInterceptorChain since it references
 // apiHandler, interceptor, and approval store fields.
@@ -273,7 +588,7 @@ func (bc *bootContext) bootComplianceAndSimulation() {
 	}
 	bc.complianceService = service.NewComplianceService(complianceReader, bc.logger)
 	bc.apiHandler.SetComplianceService(bc.complianceService)
-	bc.apiHandler.SetComplianceContextProvider(func() service.ComplianceContext {
+	bc.complianceContext = func() service.ComplianceContext {
 		// Read live counts from services, not the stale boot-time appState snapshot.
 		// appState is loaded once at boot and never updated when identities/policies
 		// are created via the admin API.
@@ -315,13 +630,15 @@ func (bc *bootContext) bootComplianceAndSimulation() {
 			DenyRuleCount:       denyRuleCount,
 			HITLAvailable:       bc.approvalStore != nil,
 		}
-	})
+	}
+	bc.apiHandler.SetComplianceContextProvider(bc.complianceContext)
 
 	// Simulation service
 	simReader := func(n int) []audit.AuditRecord {
 		return bc.auditStore.GetRecent(n)
 	}
 	bc.simulationService = service.NewSimulationService(bc.policyService, simReader, bc.logger)
+	bc.simulationService.SetAuditQuery(bc.auditReader())
 	bc.apiHandler.SetSimulationService(bc.simulationService)
 
 	bc.logger.Info("compliance and simulation services wired")
@@ -499,38 +816,16 @@ func (bc *bootContext) bootComplianceAndSimulation() {
 		})
 	}
 	bc.apiHandler.SetHealthService(bc.healthService)
+	if bc.webhookService != nil {
+		bc.apiHandler.SetWebhookService(bc.webhookService)
+	}
+	bc.apiHandler.SetEventDispatcher(bc.eventDispatcher)
 	// Late-bind health metrics to policy interceptor for CEL variables
 	if bc.policyActionInterceptor != nil {
 		bc.policyActionInterceptor.SetHealthMetrics(&healthMetricsAdapter{svc: bc.healthService})
 	}
 	bc.logger.Info("agent health dashboard service wired")
 
-	// Webhook notifications (M-29: validate URL to prevent SSRF)
-	if bc.cfg.Webhook.URL != "" && bc.eventBus != nil {
-		if msg := validateWebhookURL(bc.cfg.Webhook.URL); msg != "" {
-			bc.logger.Error("webhook URL rejected, webhook disabled",
-				"url", bc.cfg.Webhook.URL, "reason", msg)
-		} else if s := bc.cfg.Webhook.Secret; s != "" && len(s) < 32 {
-			// M-32: Reject webhook secret shorter than 32 chars to ensure HMAC strength.
-			bc.logger.Error("webhook secret too short, webhook disabled",
-				"length", len(s), "minimum", 32)
-		} else {
-			bc.webhookService = service.NewWebhookService(
-				bc.cfg.Webhook.URL, bc.cfg.Webhook.Secret,
-				bc.cfg.Webhook.Events, bc.logger,
-			)
-			bc.webhookService.SubscribeToBus(bc.eventBus)
-			// Stop webhook before event bus drain so in-flight deliveries complete
-			// while the transport is still open.
-			bc.lifecycle.Register(lifecycle.Hook{
-				Name: "webhook-stop", Phase: lifecycle.PhaseFlushBuffers,
-				Timeout: 5 * time.Second,
-				Fn:      func(ctx context.Context) error { bc.webhookService.Stop(); return nil },
-			})
-			bc.logger.Info("webhook notifications enabled", "url", bc.cfg.Webhook.URL, "events", len(bc.cfg.Webhook.Events))
-		}
-	}
-
 	bc.lifecycle.Register(lifecycle.Hook{
 		Name: "event-bus-drain", Phase: lifecycle.PhaseFlushBuffers,
 		Timeout: 3 * time.Second,
@@ -549,6 +844,87 @@ func (bc *bootContext) bootComplianceAndSimulation() {
 	}
 }
 
+// bootWebhooks validates the configured webhook endpoints and registers
+// the webhook service as an event sink. Rejected endpoints are logged and
+// skipped; the others still receive events.
+func (bc *bootContext) bootWebhooks() {
+	wc := bc.cfg.Webhook
+	endpoints := make([]service.WebhookEndpoint, 0, len(wc.Endpoints)+1)
+	if wc.URL != "" {
+		f := eventFilter(wc.Events, wc.Categories, wc.MinSeverity)
+		endpoints = append(endpoints, service.WebhookEndpoint{Name: "default", URL: wc.URL, Secret: wc.Secret,
+			Events: f.Types, Categories: f.Categories, MinSeverity: f.MinSeverity})
+	}
+	for _, ep := range wc.Endpoints {
+		f := eventFilter(ep.Events, ep.Categories, ep.MinSeverity)
+		endpoints = append(endpoints, service.WebhookEndpoint{Name: ep.Name, URL: ep.URL, Secret: ep.Secret,
+			Events: f.Types, Categories: f.Categories, MinSeverity: f.MinSeverity})
+	}
+	if len(endpoints) == 0 || bc.eventDispatcher == nil {
+		return
+	}
+
+	svc := service.NewWebhookService("", "", nil, bc.logger)
+	added := 0
+	for _, ep := range endpoints {
+		// M-29: validate URL to prevent SSRF
+		if msg := validateWebhookURL(ep.URL); msg != "" {
+			bc.logger.Error("webhook URL rejected, endpoint disabled",
+				"endpoint", ep.Name, "url", ep.URL, "reason", msg)
+			continue
+		}
+		// M-32: Reject webhook secret shorter than 32 chars to ensure HMAC strength.
+		if s := ep.Secret; s != "" && len(s) < 32 {
+			bc.logger.Error("webhook secret too short, endpoint disabled",
+				"endpoint", ep.Name, "length", len(s), "minimum", 32)
+			continue
+		}
+		svc.AddEndpoint(ep)
+		added++
+		bc.logger.Info("webhook notifications enabled", "endpoint", ep.Name, "url", ep.URL, "events", len(ep.Events))
+	}
+	if added == 0 {
+		return
+	}
+
+	backoff, _ := time.ParseDuration(wc.RetryBackoff)
+	svc.SetRetryPolicy(wc.Retries, backoff)
+	// Retries are per endpoint inside the webhook service.
+	bc.eventDispatcher.AddSink(svc, event.SinkOptions{QueueSize: bc.cfg.Events.SinkQueueSize})
+	bc.webhookService = svc
+	// Stop webhook before event bus drain so in-flight deliveries complete
+	// while the transport is still open.
+	bc.lifecycle.Register(lifecycle.Hook{
+		Name: "webhook-stop", Phase: lifecycle.PhaseFlushBuffers,
+		Timeout: 5 * time.Second,
+		Fn:      func(ctx context.Context) error { svc.Stop(); return nil },
+	})
+}
+
+// bootEventLog adds the log sink when events.log is enabled.
+func (bc *bootContext) bootEventLog() {
+	lc := bc.cfg.Events.Log
+	if !lc.Enabled {
+		return
+	}
+	bc.eventDispatcher.AddSink(event.NewLogSink(bc.logger), event.SinkOptions{
+		Filter:    eventFilter(lc.Events, lc.Categories, lc.MinSeverity),
+		QueueSize: bc.cfg.Events.SinkQueueSize,
+	})
+	bc.logger.Info("event log sink enabled", "events", len(lc.Events), "categories", lc.Categories, "min_severity", lc.MinSeverity)
+}
+
+// eventFilter builds a sink filter from config values, which were
+// validated at config load.
+func eventFilter(types, categories []string, minSeverity string) event.Filter {
+	f := event.Filter{Types: types}
+	for _, c := range categories {
+		f.Categories = append(f.Categories, event.Category(c))
+	}
+	f.MinSeverity, _ = event.ParseSeverity(minSeverity)
+	return f
+}
+
 // healthMetricsAdapter adapts service.HealthService to action.HealthMetricsProvider.
 type healthMetricsAdapter struct {
 	svc *service.HealthService
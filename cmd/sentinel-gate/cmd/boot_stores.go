@@ -2,17 +2,22 @@ package cmd
 
 import (
 	"context"
-	"errors"
+	"crypto/tls"
 	"fmt"
-	"io/fs"
 	"log/slog"
+	"net"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 
+	auditadapter "github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/audit"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/geoip"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/memory"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/postgres"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/redis"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/config"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/auth"
@@ -25,14 +30,25 @@ import (
 // bootStores initializes all in-memory stores, loads state.json, seeds
 // config data, and creates the upstream service (BOOT-03 + BOOT-04).
 func (bc *bootContext) bootStores(ctx context.Context) error {
-	// BOOT-03: Load/create state.json
-	bc.stateStore = state.NewFileStateStore(bc.statePath, bc.logger)
+	// BOOT-03: Load/create state.json, or the PostgreSQL state table
+	stateStore, closeState, err := openStateStore(ctx, bc.cfg, bc.statePath, bc.logger)
+	if err != nil {
+		return err
+	}
+	bc.stateStore = stateStore
+	bc.lifecycle.Register(lifecycle.Hook{
+		Name: "state-store-close", Phase: lifecycle.PhaseCleanup,
+		Timeout: 3 * time.Second,
+		Fn:      func(ctx context.Context) error { closeState(); return nil },
+	})
+	if err := bc.openSecretCipher(); err != nil {
+		return err
+	}
 
-	// L-20: Check whether state.json exists before loading. Only save on
-	// first boot (file missing) to avoid unconditionally overwriting the
+	// L-20: Check whether the state exists before loading. Only save on
+	// first boot (state missing) to avoid unconditionally overwriting the
 	// .bak file when no migrations have been applied.
-	_, statErr := os.Stat(bc.statePath)
-	isFirstBoot := errors.Is(statErr, fs.ErrNotExist)
+	isFirstBoot := !bc.stateStore.Exists()
 
 	appState, err := bc.stateStore.Load()
 	if err != nil {
@@ -45,7 +61,7 @@ func (bc *bootContext) bootStores(ctx context.Context) error {
 	}
 	bc.appState = appState
 	bc.logger.Info("state loaded",
-		"path", bc.statePath,
+		"path", bc.stateStore.Path(),
 		"upstreams", len(appState.Upstreams),
 		"policies", len(appState.Policies),
 		"default_policy", appState.DefaultPolicy,
@@ -53,7 +69,9 @@ func (bc *bootContext) bootStores(ctx context.Context) error {
 
 	// BOOT-04: Populate in-memory stores
 	bc.authStore = memory.NewAuthStore()
-	bc.sessionStore = memory.NewSessionStore()
+	if err := bc.openSessionStore(ctx); err != nil {
+		return err
+	}
 	// L-37: Pass context.Background() so the cleanup goroutine stays alive
 	// until the explicit Stop() lifecycle hook, rather than exiting early
 	// when the signal context is cancelled.
@@ -78,6 +96,14 @@ func (bc *bootContext) bootStores(ctx context.Context) error {
 	// Seed state.json identities and API keys
 	seedAuthFromState(appState, bc.authStore, bc.cfg, bc.logger)
 
+	if err := bc.openGeoIP(); err != nil {
+		return err
+	}
+
+	if err := bc.openPayloadStore(); err != nil {
+		return err
+	}
+
 	if err := seedPoliciesFromConfig(bc.cfg, bc.policyStore); err != nil {
 		return fmt.Errorf("failed to seed policies: %w", err)
 	}
@@ -105,6 +131,244 @@ func (bc *bootContext) bootStores(ctx context.Context) error {
 	return nil
 }
 
+// openStateStore opens the state store selected by state.backend: the file
+// at statePath, or the PostgreSQL table, which must be reachable so that a
+// misconfigured replica fails fast. The returned function closes it.
+func openStateStore(ctx context.Context, cfg *config.OSSConfig, statePath string, logger *slog.Logger) (state.Store, func(), error) {
+	if cfg.State.Backend != "postgres" {
+		return state.NewFileStateStore(statePath, logger), func() {}, nil
+	}
+	pc := cfg.State.Postgres
+	client := newPostgresClient(pc)
+	store, err := state.NewPostgresStateStore(client, pc.Table, logger)
+	if err != nil {
+		_ = client.Close()
+		return nil, nil, fmt.Errorf("invalid state store: %w", err)
+	}
+	if err := store.EnsureSchema(ctx); err != nil {
+		_ = client.Close()
+		return nil, nil, fmt.Errorf("failed to connect to state store: %w", err)
+	}
+	logger.Info("state stored in PostgreSQL", "address", pc.Address, "database", pc.Database, "table", pc.Table)
+	return store, func() { _ = client.Close() }, nil
+}
+
+// newPostgresClient creates a client for the PostgreSQL server configured
+// by pc.
+func newPostgresClient(pc config.PostgresConfig) *postgres.Client {
+	timeout, err := time.ParseDuration(pc.Timeout)
+	if err != nil {
+		timeout = postgres.DefaultTimeout
+	}
+	opts := postgres.Options{
+		Address:  pc.Address,
+		User:     pc.User,
+		Password: pc.Password,
+		Database: pc.Database,
+		Timeout:  timeout,
+		PoolSize: pc.PoolSize,
+	}
+	if pc.TLS {
+		host, _, _ := net.SplitHostPort(pc.Address)
+		opts.TLSConfig = &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+	}
+	return postgres.NewClient(opts)
+}
+
+// credentialsKeyEnv holds the base64-encoded key that encrypts the secrets
+// in state.json. When set, it is used instead of the key file.
+const credentialsKeyEnv = "SENTINEL_GATE_CREDENTIALS_KEY"
+
+// openSecretCipher loads the key that encrypts the secrets in state.json,
+// generating it on first use, and encrypts the secrets still stored in
+// plaintext.
+func (bc *bootContext) openSecretCipher() error {
+	key, source, created, err := loadCredentialsKey(bc.cfg, bc.statePath, true)
+	if err != nil {
+		return fmt.Errorf("failed to load credentials key: %w", err)
+	}
+	if created {
+		bc.logger.Warn("generated new credentials encryption key; back it up, secrets in state.json cannot be read without it", "path", source)
+	}
+	c, err := state.NewSecretCipher(key)
+	if err != nil {
+		return fmt.Errorf("invalid credentials key: %w", err)
+	}
+	bc.stateStore.SetSecretCipher(c)
+
+	n, err := bc.stateStore.EncryptPlaintextSecrets()
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+	if n > 0 {
+		bc.logger.Info("encrypted plaintext secrets in state.json", "secrets", n, "key", source)
+	}
+	return nil
+}
+
+// loadCredentialsKey returns the key that encrypts the secrets in
+// state.json, from $SENTINEL_GATE_CREDENTIALS_KEY or else the key file, and
+// where it was read from. With create, a missing key file is generated and
+// created reports it; otherwise the fs.ErrNotExist error is returned.
+func loadCredentialsKey(cfg *config.OSSConfig, statePath string, create bool) (key []byte, source string, created bool, err error) {
+	if v := os.Getenv(credentialsKeyEnv); v != "" {
+		key, err := state.ParseSecretKey([]byte(v))
+		if err != nil {
+			return nil, "", false, fmt.Errorf("$%s: %w", credentialsKeyEnv, err)
+		}
+		return key, "$" + credentialsKeyEnv, false, nil
+	}
+	keyPath := credentialsKeyPath(cfg, statePath)
+	if create {
+		key, created, err = state.LoadOrCreateSecretKey(keyPath)
+	} else {
+		key, err = state.LoadSecretKey(keyPath)
+	}
+	return key, keyPath, created, err
+}
+
+// credentialsKeyPath returns the path of the state secrets key file.
+func credentialsKeyPath(cfg *config.OSSConfig, statePath string) string {
+	if cfg.Upstream.CredentialsKeyPath != "" {
+		return cfg.Upstream.CredentialsKeyPath
+	}
+	return filepath.Join(filepath.Dir(statePath), "credentials-key")
+}
+
+// openSessionStore creates the session store selected by session.store.
+// A Redis store must answer at startup so a misconfigured replica fails
+// fast instead of rejecting every session.
+func (bc *bootContext) openSessionStore(ctx context.Context) error {
+	if bc.cfg.Session.Store != "redis" {
+		bc.sessionStore = memory.NewSessionStore()
+		return nil
+	}
+	rc := bc.cfg.Session.Redis
+	store := redis.NewSessionStore(newRedisClient(rc), rc.KeyPrefix)
+	if err := store.Ping(ctx); err != nil {
+		store.Stop()
+		return fmt.Errorf("failed to connect to session store: %w", err)
+	}
+	bc.sessionStore = store
+	bc.logger.Info("sessions stored in Redis", "address", rc.Address, "db", rc.DB, "key_prefix", rc.KeyPrefix)
+	return nil
+}
+
+// openRateLimiter creates the rate limiter selected by rate_limit.store.
+// Like the session store, a Redis limiter must answer at startup.
+func (bc *bootContext) openRateLimiter(ctx context.Context, cleanupInterval, maxTTL time.Duration) error {
+	if bc.cfg.RateLimit.Store != "redis" {
+		bc.rateLimiter = memory.NewRateLimiterWithConfig(cleanupInterval, maxTTL)
+		return nil
+	}
+	rc := bc.cfg.RateLimit.Redis
+	limiter := redis.NewRateLimiter(newRedisClient(rc), rc.KeyPrefix)
+	if err := limiter.Ping(ctx); err != nil {
+		limiter.Stop()
+		return fmt.Errorf("failed to connect to rate limit store: %w", err)
+	}
+	bc.rateLimiter = limiter
+	bc.logger.Info("rate limits stored in Redis", "address", rc.Address, "db", rc.DB, "key_prefix", rc.KeyPrefix)
+	return nil
+}
+
+// newRedisClient creates a client for the Redis server configured by rc.
+func newRedisClient(rc config.RedisConfig) *redis.Client {
+	timeout, err := time.ParseDuration(rc.Timeout)
+	if err != nil {
+		timeout = redis.DefaultTimeout
+	}
+	opts := redis.Options{
+		Address:  rc.Address,
+		Username: rc.Username,
+		Password: rc.Password,
+		DB:       rc.DB,
+		Timeout:  timeout,
+		PoolSize: rc.PoolSize,
+	}
+	if rc.TLS {
+		host, _, _ := net.SplitHostPort(rc.Address)
+		opts.TLSConfig = &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+	}
+	return redis.NewClient(opts)
+}
+
+// openPayloadStore opens the store of sampled tool call payloads, when
+// audit_payloads.tools is set.
+func (bc *bootContext) openPayloadStore() error {
+	cfg := bc.cfg.AuditPayloads
+	if len(cfg.Tools) == 0 {
+		return nil
+	}
+	dir := cfg.Dir
+	if dir == "" {
+		dir = filepath.Join(filepath.Dir(bc.statePath), "payloads")
+	}
+	store, err := auditadapter.NewPayloadFileStore(auditadapter.PayloadFileConfig{
+		Dir:           dir,
+		RetentionDays: cfg.RetentionDays,
+	}, bc.logger)
+	if err != nil {
+		return fmt.Errorf("failed to open payload store: %w", err)
+	}
+	bc.payloadStore = store
+	bc.lifecycle.Register(lifecycle.Hook{
+		Name: "payload-store-close", Phase: lifecycle.PhaseCleanup,
+		Timeout: 5 * time.Second,
+		Fn:      func(ctx context.Context) error { return store.Close() },
+	})
+	bc.logger.Info("payload sampling enabled", "dir", dir, "tools", len(cfg.Tools))
+	return nil
+}
+
+// openGeoIP opens the country database used by identity country
+// restrictions, when geoip.database is set, and locates outbound
+// destinations with it and geoip.asn_database.
+func (bc *bootContext) openGeoIP() error {
+	if bc.cfg.GeoIP.Database == "" {
+		for _, identity := range bc.authStore.ListAllIdentities() {
+			if identity.Access.HasCountryRules() {
+				bc.logger.Warn("identity has country restrictions but geoip.database is not set: every client country is unknown",
+					"identity_id", identity.ID)
+			}
+		}
+	} else {
+		resolver, err := bc.openGeoIPDatabase("geoip-close", bc.cfg.GeoIP.Database)
+		if err != nil {
+			return err
+		}
+		bc.geoResolver = resolver
+	}
+
+	var asns *geoip.Resolver
+	if bc.cfg.GeoIP.ASNDatabase != "" {
+		var err error
+		if asns, err = bc.openGeoIPDatabase("geoip-asn-close", bc.cfg.GeoIP.ASNDatabase); err != nil {
+			return err
+		}
+	}
+	if bc.geoResolver != nil || asns != nil {
+		bc.geoLocator = geoip.NewLocator(bc.geoResolver, asns)
+	}
+	return nil
+}
+
+// openGeoIPDatabase opens the MMDB database at path and closes it on
+// shutdown with the named hook.
+func (bc *bootContext) openGeoIPDatabase(hook, path string) (*geoip.Resolver, error) {
+	resolver, err := geoip.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	bc.lifecycle.Register(lifecycle.Hook{
+		Name: hook, Phase: lifecycle.PhaseCleanup,
+		Timeout: time.Second,
+		Fn:      func(ctx context.Context) error { return resolver.Close() },
+	})
+	bc.logger.Info("GeoIP database loaded", "path", path)
+	return resolver, nil
+}
+
 // seedAuthFromConfig seeds identities and API keys from configuration.
 func seedAuthFromConfig(cfg *config.OSSConfig, authStore *memory.AuthStore) error {
 	// L-66: Detect duplicate identity IDs in YAML config.
@@ -119,10 +383,15 @@ func seedAuthFromConfig(cfg *config.OSSConfig, authStore *memory.AuthStore) erro
 		for i, role := range identityCfg.Roles {
 			roles[i] = auth.Role(role)
 		}
+		access, err := service.AccessRestrictionsFromEntry(accessEntryFromConfig(identityCfg.Access))
+		if err != nil {
+			return fmt.Errorf("identity %q: access: %w", identityCfg.ID, err)
+		}
 		authStore.AddIdentity(&auth.Identity{
-			ID:    identityCfg.ID,
-			Name:  identityCfg.Name,
-			Roles: roles,
+			ID:     identityCfg.ID,
+			Name:   identityCfg.Name,
+			Roles:  roles,
+			Access: access,
 		})
 	}
 
@@ -134,15 +403,33 @@ func seedAuthFromConfig(cfg *config.OSSConfig, authStore *memory.AuthStore) erro
 				"identity_id", keyCfg.IdentityID)
 		}
 		authStore.AddKey(&auth.APIKey{
-			Key:        hash,
-			IdentityID: keyCfg.IdentityID,
-			CreatedAt:  time.Now(),
+			Key:            hash,
+			IdentityID:     keyCfg.IdentityID,
+			CreatedAt:      time.Now(),
+			AllowedOrigins: keyCfg.AllowedOrigins,
 		})
 	}
 
 	return nil
 }
 
+// accessEntryFromConfig converts YAML access restrictions to their state form
+// so both sources share one validation path.
+func accessEntryFromConfig(cfg *config.IdentityAccessConfig) *state.IdentityAccessEntry {
+	if cfg == nil {
+		return nil
+	}
+	entry := &state.IdentityAccessEntry{
+		Timezone:       cfg.Timezone,
+		AllowCountries: cfg.AllowCountries,
+		DenyCountries:  cfg.DenyCountries,
+	}
+	for _, w := range cfg.Windows {
+		entry.Windows = append(entry.Windows, state.AccessWindowEntry{Days: w.Days, Start: w.Start, End: w.End})
+	}
+	return entry
+}
+
 // seedAuthFromState loads identities and API keys from state.json.
 // M-11: Before adding, it removes API keys that were previously loaded from
 // state but are no longer present (revoked/deleted). YAML-seeded entries are
@@ -182,10 +469,20 @@ func seedAuthFromState(appState *state.AppState, authStore *memory.AuthStore, cf
 		for i, role := range identity.Roles {
 			roles[i] = auth.Role(role)
 		}
+		access, err := service.AccessRestrictionsFromEntry(identity.Access)
+		if err != nil {
+			// Fail closed: an identity whose restrictions cannot be enforced
+			// must not authenticate unrestricted.
+			logger.Error("skipping identity with invalid access restrictions",
+				"id", identity.ID, "error", err)
+			authStore.RemoveIdentity(identity.ID)
+			continue
+		}
 		authStore.AddIdentity(&auth.Identity{
-			ID:    identity.ID,
-			Name:  identity.Name,
-			Roles: roles,
+			ID:     identity.ID,
+			Name:   identity.Name,
+			Roles:  roles,
+			Access: access,
 		})
 	}
 
@@ -195,13 +492,14 @@ func seedAuthFromState(appState *state.AppState, authStore *memory.AuthStore, cf
 			continue
 		}
 		authStore.AddKey(&auth.APIKey{
-			Key:        key.KeyHash,
-			Prefix:     key.KeyPrefix,
-			IdentityID: key.IdentityID,
-			Name:       key.Name,
-			CreatedAt:  key.CreatedAt,
-			ExpiresAt:  key.ExpiresAt,
-			Revoked:    key.Revoked,
+			Key:            key.KeyHash,
+			Prefix:         key.KeyPrefix,
+			IdentityID:     key.IdentityID,
+			Name:           key.Name,
+			CreatedAt:      key.CreatedAt,
+			ExpiresAt:      key.ExpiresAt,
+			Revoked:        key.Revoked,
+			AllowedOrigins: key.AllowedOrigins,
 		})
 	}
 
@@ -213,7 +511,21 @@ func seedAuthFromState(appState *state.AppState, authStore *memory.AuthStore, cf
 
 // seedPoliciesFromConfig seeds policies from configuration.
 func seedPoliciesFromConfig(cfg *config.OSSConfig, policyStore *memory.MemoryPolicyStore) error {
+	policies, err := policiesFromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	for _, p := range policies {
+		policyStore.AddPolicy(p)
+	}
+	return nil
+}
+
+// policiesFromConfig builds the policies of the configuration, identified by
+// their names. A later definition of a name replaces an earlier one.
+func policiesFromConfig(cfg *config.OSSConfig) ([]*policy.Policy, error) {
 	now := time.Now()
+	var policies []*policy.Policy
 	// L-67: Detect duplicate policy names in YAML config.
 	seenPolicies := make(map[string]bool, len(cfg.Policies))
 	for _, policyCfg := range cfg.Policies {
@@ -228,6 +540,12 @@ func seedPoliciesFromConfig(cfg *config.OSSConfig, policyStore *memory.MemoryPol
 			if cond == "" {
 				cond = "true" // default: match all calls
 			}
+			if err := policy.ValidateHelpTemplate(ruleCfg.HelpText); err != nil {
+				return nil, fmt.Errorf("policy %q rule %q help_text: %w", policyCfg.Name, ruleCfg.Name, err)
+			}
+			if err := policy.ValidateHelpTemplate(ruleCfg.HelpURL); err != nil {
+				return nil, fmt.Errorf("policy %q rule %q help_url: %w", policyCfg.Name, ruleCfg.Name, err)
+			}
 			rules[i] = policy.Rule{
 				ID:        fmt.Sprintf("%s-rule-%d", policyCfg.Name, i),
 				Name:      ruleCfg.Name,
@@ -235,9 +553,11 @@ func seedPoliciesFromConfig(cfg *config.OSSConfig, policyStore *memory.MemoryPol
 				Action:    policy.Action(ruleCfg.Action),
 				ToolMatch: "*",
 				Priority:  len(policyCfg.Rules) - i,
+				HelpText:  ruleCfg.HelpText,
+				HelpURL:   ruleCfg.HelpURL,
 			}
 		}
-		policyStore.AddPolicy(&policy.Policy{
+		policies = append(policies, &policy.Policy{
 			ID:        policyCfg.Name,
 			Name:      policyCfg.Name,
 			Enabled:   true,
@@ -246,7 +566,7 @@ func seedPoliciesFromConfig(cfg *config.OSSConfig, policyStore *memory.MemoryPol
 			UpdatedAt: now,
 		})
 	}
-	return nil
+	return policies, nil
 }
 
 // migrateYAMLUpstream creates a state.json entry from the YAML single upstream.
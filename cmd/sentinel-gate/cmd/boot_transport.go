@@ -2,15 +2,20 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	stdhttp "net/http"
+	"sort"
 	"time"
 
 	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/inbound/admin"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/inbound/http"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/inbound/stdio"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/dns"
 	mcpclient "github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/mcp"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/lifecycle"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
 )
 
 // bootTransport creates the proxy service and MCP client (BOOT-08).
@@ -27,11 +32,16 @@ func (bc *bootContext) bootTransport() {
 		}
 		if bc.cfg.Upstream.HTTP != "" {
 			mcpClient = mcpclient.NewHTTPClient(bc.cfg.Upstream.HTTP,
-				mcpclient.WithTimeout(httpTimeout))
+				mcpclient.WithTimeout(httpTimeout), mcpclient.WithEgressTLS(bc.egressTLS, "default"))
 			bc.logger.Info("upstream mode: HTTP", "endpoint", bc.cfg.Upstream.HTTP, "timeout", httpTimeout)
 		} else {
-			mcpClient = mcpclient.NewStdioClient(bc.cfg.Upstream.Command, bc.cfg.Upstream.Args...)
-			bc.logger.Info("upstream mode: stdio", "command", bc.cfg.Upstream.Command, "args", bc.cfg.Upstream.Args)
+			stdioClient := mcpclient.NewStdioClient(bc.cfg.Upstream.Command, bc.cfg.Upstream.Args...)
+			if framing, err := mcp.ParseFraming(bc.cfg.Upstream.Framing); err == nil {
+				stdioClient.SetFraming(framing)
+			}
+			mcpClient = stdioClient
+			bc.logger.Info("upstream mode: stdio", "command", bc.cfg.Upstream.Command, "args", bc.cfg.Upstream.Args,
+				"framing", bc.cfg.Upstream.Framing)
 		}
 	}
 
@@ -60,18 +70,25 @@ func (bc *bootContext) startTransport(ctx context.Context, stdioTransport bool)
 	// shutdown. Stdin is closed by the parent process; no explicit draining is needed.
 	if stdioTransport {
 		transport := stdio.NewStdioTransport(bc.proxyService)
+		if framing, err := mcp.ParseFraming(bc.cfg.Server.StdioFraming); err == nil {
+			transport.SetFraming(framing)
+		}
 
 		// Tool change notifier for stdio clients
 		toolChangeNotifier := stdio.NewStdioToolChangeNotifier(transport)
 		bc.discoveryService.SetNotifier(toolChangeNotifier)
 		bc.apiHandler.SetToolChangeNotifier(toolChangeNotifier)
 
-		bc.logger.Info("transport mode: stdio")
+		bc.logger.Info("transport mode: stdio", "framing", bc.cfg.Server.StdioFraming)
 		return transport.Start(ctx)
 	}
 
 	// Print banner (HTTP mode only)
-	printBanner(Version, bc.cfg.Server.HTTPAddr,
+	scheme := "http"
+	if bc.cfg.Server.TLS.CertFile != "" {
+		scheme = "https"
+	}
+	printBanner(Version, scheme, bc.cfg.Server.HTTPAddr,
 		len(bc.statusAll), bc.connectedCount, bc.toolCount, ruleCount)
 
 	// Admin handler
@@ -81,11 +98,107 @@ func (bc *bootContext) startTransport(ctx context.Context, stdioTransport bool)
 	}
 
 	healthChecker := http.NewHealthChecker(bc.sessionStore, bc.rateLimiter, bc.auditService, Version)
+	healthChecker.SetDetailPolicy(http.HealthDetail(bc.cfg.Server.Health.PublicDetail),
+		http.HealthDetail(bc.cfg.Server.Health.AuthenticatedDetail))
+	healthChecker.SetKeyValidator(bc.apiKeyService)
+	if bc.upstreamManager != nil {
+		healthChecker.SetUpstreamLister(&upstreamHealthLister{upstreams: bc.upstreamService, manager: bc.upstreamManager})
+	}
 
 	transportOpts := []http.Option{
 		http.WithAddr(bc.cfg.Server.HTTPAddr),
+		http.WithExtraAddrs(bc.cfg.Server.ExtraHTTPAddrs...),
+		http.WithReusePort(bc.cfg.Server.ReusePort),
 		http.WithLogger(bc.logger),
 		http.WithHealthChecker(healthChecker),
+		http.WithMaxRequestBodySize(bc.cfg.Server.MaxRequestBodySize),
+		http.WithAllowedOrigins(bc.cfg.Server.AllowedOrigins),
+	}
+	sseCfg := bc.cfg.Server.SSE
+	overflowTTL, _ := time.ParseDuration(sseCfg.OverflowTTL) // validated at config load
+	transportOpts = append(transportOpts, http.WithSSEConfig(http.SSEConfig{
+		MaxLineSize:      sseCfg.MaxLineSize,
+		MaxEventSize:     sseCfg.MaxEventSize,
+		OverflowTTL:      overflowTTL,
+		OverflowMaxBytes: sseCfg.OverflowMaxBytes,
+		Compression:      sseCfg.Compression,
+		ReplayBufferSize: sseCfg.ReplayBufferSize,
+		QueueSize:        sseCfg.Queue.MaxMessages,
+		QueueMaxBytes:    sseCfg.Queue.MaxBytes,
+		DropPolicy:       sseCfg.Queue.DropPolicy,
+	}))
+	if bc.auditService != nil {
+		transportOpts = append(transportOpts, http.WithQueueDropCallback(bc.auditQueueDrop))
+	}
+	if wsCfg := bc.cfg.Server.WebSocket; wsCfg.Enabled {
+		pingInterval, _ := time.ParseDuration(wsCfg.PingInterval) // validated at config load
+		transportOpts = append(transportOpts, http.WithWebSocket(http.WebSocketConfig{
+			Enabled:      true,
+			PingInterval: pingInterval,
+			MaxInFlight:  wsCfg.MaxInFlight,
+		}))
+	}
+	if bc.cfg.TokenExchange.Enabled {
+		transportOpts = append(transportOpts, http.WithUpstreamTokenHeader(bc.cfg.TokenExchange.Header))
+	}
+	if bc.reverseHub != nil {
+		transportOpts = append(transportOpts, http.WithUpstreamRegistry(bc.reverseHub))
+	}
+	transportOpts = append(transportOpts, http.WithCorrelationHeader(bc.cfg.Server.Correlation.Header))
+	switch bc.cfg.Server.ToolProvenance {
+	case "headers", "both":
+		transportOpts = append(transportOpts, http.WithProvenanceHeaders(true))
+	}
+	switch bc.cfg.Server.LatencyBreakdown.Mode {
+	case "headers", "both":
+		transportOpts = append(transportOpts, http.WithServerTiming(true))
+	}
+	if bc.sloService != nil {
+		transportOpts = append(transportOpts, http.WithSLOStatus(bc.sloService))
+	}
+	if bc.dnsResolver != nil || bc.dnsWatcher != nil {
+		transportOpts = append(transportOpts, http.WithDNSStats(dnsMetrics{resolver: bc.dnsResolver, watcher: bc.dnsWatcher}))
+	}
+	if ac := bc.cfg.Admission; ac.Enabled {
+		// Durations validated at config load.
+		checkInterval, _ := time.ParseDuration(ac.CheckInterval)
+		retryAfter, _ := time.ParseDuration(ac.RetryAfter)
+		admission := service.NewAdmissionController(service.AdmissionConfig{
+			MemoryThreshold:    uint64(ac.MemoryThresholdMB) * 1024 * 1024,
+			GoroutineThreshold: ac.GoroutineThreshold,
+			RetryAfter:         retryAfter,
+		}, bc.logger)
+		go admission.Run(ctx, checkInterval)
+		transportOpts = append(transportOpts, http.WithLoadShedder(admission))
+		bc.apiHandler.SetAdmissionController(admission)
+		bc.logger.Info("admission control enabled", "memory_threshold_mb", ac.MemoryThresholdMB,
+			"goroutine_threshold", ac.GoroutineThreshold, "check_interval", ac.CheckInterval)
+	}
+	if tc := bc.cfg.Server.TLS; tc.CertFile != "" {
+		certs, err := http.NewCertReloader(tc.CertFile, tc.KeyFile, bc.logger)
+		if err != nil {
+			return err
+		}
+		reloadInterval, _ := time.ParseDuration(tc.ReloadInterval) // validated at config load
+		transportOpts = append(transportOpts, http.WithCertReloader(certs, reloadInterval))
+		bc.apiHandler.SetTLSCertificateInfo(func() admin.TLSCertificateInfo {
+			info := certs.Info()
+			return admin.TLSCertificateInfo{
+				CertFile:          info.CertFile,
+				Subject:           info.Subject,
+				Issuer:            info.Issuer,
+				DNSNames:          info.DNSNames,
+				NotBefore:         info.NotBefore,
+				NotAfter:          info.NotAfter,
+				FingerprintSHA256: info.FingerprintSHA256,
+				LoadedAt:          info.LoadedAt,
+				LastReloadError:   info.LastError,
+				LastReloadErrorAt: info.LastErrorAt,
+			}
+		})
+		info := certs.Info()
+		bc.logger.Info("TLS enabled", "cert_file", tc.CertFile, "subject", info.Subject,
+			"not_after", info.NotAfter, "reload_interval", tc.ReloadInterval)
 	}
 
 	// Composite admin mux
@@ -97,12 +210,26 @@ func (bc *bootContext) startTransport(ctx context.Context, stdioTransport bool)
 	}
 	transportOpts = append(transportOpts, http.WithExtraHandler(compositeMux))
 
-	// Clean up per-session framework tracking when sessions are terminated.
+	// Clean up per-session framework tracking, resource subscriptions and
+	// taint fingerprints when sessions are terminated.
 	if bc.upstreamRouter != nil {
-		transportOpts = append(transportOpts, http.WithSessionTerminateCallback(bc.upstreamRouter.CleanupSession))
+		router, taint := bc.upstreamRouter, bc.taintTracker
+		transportOpts = append(transportOpts, http.WithSessionTerminateCallback(func(sessionID string) {
+			router.CleanupSession(sessionID)
+			if taint != nil {
+				taint.ClearSession(sessionID)
+			}
+		}))
 	}
 
-	bc.logger.Info("admin enabled", "api", "/admin/api/", "ui", "/admin/")
+	switch ui := bc.cfg.Server.AdminUI; ui.Mode {
+	case "disabled":
+		bc.logger.Info("admin enabled (API-only mode)", "api", "/admin/api/")
+	case "directory":
+		bc.logger.Info("admin enabled", "api", "/admin/api/", "ui", "/admin/", "ui_directory", ui.Directory)
+	default:
+		bc.logger.Info("admin enabled", "api", "/admin/api/", "ui", "/admin/")
+	}
 
 	transport := http.NewHTTPTransport(bc.proxyService, transportOpts...)
 
@@ -123,7 +250,79 @@ func (bc *bootContext) startTransport(ctx context.Context, stdioTransport bool)
 		notifForwarder := http.NewHTTPNotificationForwarder(transport)
 		bc.upstreamRouter.SetNotificationForwarder(notifForwarder)
 	}
+	// Upstream sampling/elicitation requests reach clients over their SSE streams.
+	if bc.clientRequestInterceptor != nil {
+		bc.clientRequestInterceptor.SetClientRequester(transport)
+	}
 
-	bc.logger.Info("transport mode: HTTP", "addr", bc.cfg.Server.HTTPAddr)
+	bc.logger.Info("transport mode: HTTP", "addr", bc.cfg.Server.HTTPAddr,
+		"extra_addrs", bc.cfg.Server.ExtraHTTPAddrs, "reuse_port", bc.cfg.Server.ReusePort)
 	return transport.Start(ctx)
 }
+
+// upstreamHealthLister reports configured upstreams and their connection
+// status to the health endpoint.
+type upstreamHealthLister struct {
+	upstreams *service.UpstreamService
+	manager   *service.UpstreamManager
+}
+
+func (l *upstreamHealthLister) UpstreamHealth(ctx context.Context) []http.UpstreamHealth {
+	list, err := l.upstreams.List(ctx)
+	if err != nil {
+		return nil
+	}
+	result := make([]http.UpstreamHealth, 0, len(list))
+	for _, u := range list {
+		status, lastErr := l.manager.Status(u.ID)
+		result = append(result, http.UpstreamHealth{Name: u.Name, Status: string(status), Error: lastErr})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// dnsMetrics reports the counters of the gateway resolver and of the DNS
+// rebinding checks on /metrics.
+type dnsMetrics struct {
+	resolver *dns.Resolver
+	watcher  *dns.Watcher
+}
+
+func (m dnsMetrics) DNSStats() (dns.Stats, bool) {
+	if m.resolver == nil {
+		return dns.Stats{}, false
+	}
+	return m.resolver.Stats(), true
+}
+
+func (m dnsMetrics) DNSRebindings() uint64 {
+	if m.watcher == nil {
+		return 0
+	}
+	return m.watcher.Rebindings()
+}
+
+// auditQueueDrop records a warning in the audit log when server-initiated
+// messages of a session were dropped because its SSE stream fell behind.
+func (bc *bootContext) auditQueueDrop(d http.QueueDrop) {
+	reason := fmt.Sprintf("%d message(s) dropped, SSE stream queue full (drop policy %s)", d.Dropped, d.Policy)
+	if d.Closed {
+		reason = fmt.Sprintf("SSE stream closed, client too slow: %d message(s) dropped", d.Dropped)
+	}
+	bc.auditService.Record(audit.AuditRecord{
+		Timestamp:    time.Now().UTC(),
+		SessionID:    d.SessionID,
+		IdentityID:   "system",
+		IdentityName: "system",
+		ToolName:     "sse.messages_dropped",
+		ToolArguments: map[string]interface{}{
+			"dropped":     d.Dropped,
+			"drop_policy": d.Policy,
+			"closed":      d.Closed,
+		},
+		Decision: audit.DecisionWarn,
+		Reason:   reason,
+		Source:   "sse_backpressure",
+		Protocol: "mcp",
+	})
+}
@@ -4,23 +4,46 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"os"
 	"strings"
 	"time"
 
 	mcpclient "github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/mcp"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/config"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/event"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/upstream"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/lifecycle"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/port/outbound"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
 )
 
 // bootUpstreams starts the upstream manager, runs tool discovery, and
 // sets up tool security (BOOT-05 + BOOT-06).
 func (bc *bootContext) bootUpstreams(ctx context.Context) error {
 	// BOOT-05: Start Upstream Manager
-	clientFactory := defaultClientFactory(bc.cfg)
+	// Servers of reverse upstreams register over /upstream/register; the hub
+	// holds their connections for the client factory.
+	bc.reverseHub = mcpclient.NewReverseHub(bc.upstreamService.VerifyRegistrationToken, bc.logger)
+	bc.lifecycle.Register(lifecycle.Hook{
+		Name: "reverse-upstream-hub-close", Phase: lifecycle.PhaseCloseConnections,
+		Timeout: 5 * time.Second,
+		Fn:      func(ctx context.Context) error { return bc.reverseHub.Close() },
+	})
+	egressTLS, err := newEgressTLSPolicy(bc.cfg, bc.recordEgressTLSFailure)
+	if err != nil {
+		return err
+	}
+	bc.egressTLS = egressTLS
+	clientFactory := defaultClientFactory(bc.cfg, bc.reverseHub, bc.egressTLS, bc.connWatcher())
 	bc.upstreamManager = service.NewUpstreamManager(bc.upstreamService, clientFactory, bc.logger)
+	lazyStart, _ := time.ParseDuration(bc.cfg.Upstream.LazyStartTimeout)
+	lazyIdle, _ := time.ParseDuration(bc.cfg.Upstream.LazyIdleTimeout)
+	bc.upstreamManager.SetLazyConfig(lazyStart, lazyIdle)
+	if bc.eventBus != nil {
+		bc.upstreamManager.SetEventBus(bc.eventBus)
+	}
 	bc.lifecycle.Register(lifecycle.Hook{
 		Name: "upstream-close", Phase: lifecycle.PhaseCloseConnections,
 		Timeout: 10 * time.Second,
@@ -32,19 +55,27 @@ func (bc *bootContext) bootUpstreams(ctx context.Context) error {
 	}
 
 	bc.statusAll = bc.upstreamManager.StatusAll()
+	idleCount := 0
 	for _, status := range bc.statusAll {
-		if status == upstream.StatusConnected {
+		switch status {
+		case upstream.StatusConnected:
 			bc.connectedCount++
+		case upstream.StatusIdle:
+			idleCount++
 		}
 	}
 	bc.logger.Info("upstream manager started",
 		"total", len(bc.statusAll),
 		"connected", bc.connectedCount,
+		"idle", idleCount,
 	)
 
 	// BOOT-06: Run tool discovery
 	bc.toolCache = upstream.NewToolCache()
+	bc.toolCache.SetSchemaCompression(bc.cfg.Upstream.SchemaCompressMin)
+	bc.toolCache.SetNamespacing(upstream.ToolNamespacing(bc.cfg.Upstream.ToolNamespacing))
 	bc.discoveryService = service.NewToolDiscoveryService(bc.upstreamService, bc.toolCache, clientFactory, bc.logger)
+	bc.discoveryService.SetMaxPages(bc.cfg.Upstream.DiscoveryMaxPages)
 	bc.lifecycle.Register(lifecycle.Hook{
 		Name: "discovery-service-stop", Phase: lifecycle.PhaseDrainRequests,
 		Timeout: 5 * time.Second,
@@ -66,6 +97,22 @@ func (bc *bootContext) bootUpstreams(ctx context.Context) error {
 	bc.discoveryService.StartPeriodicRetry(context.Background())
 	bc.discoveryService.StartPeriodicFullRediscovery(context.Background())
 
+	// A registering reverse upstream server starts its upstream, and its
+	// tools are discovered the first time it is reachable.
+	bc.reverseHub.SetOnAttach(func(upstreamID string) {
+		ctx := context.Background()
+		if status, _ := bc.upstreamManager.Status(upstreamID); status != upstream.StatusConnected && status != upstream.StatusConnecting {
+			if err := bc.upstreamManager.Restart(ctx, upstreamID); err != nil {
+				bc.logger.Warn("failed to start reverse upstream", "id", upstreamID, "error", err)
+			}
+		}
+		if len(bc.toolCache.GetToolsByUpstream(upstreamID)) == 0 {
+			if _, err := bc.discoveryService.DiscoverFromUpstream(ctx, upstreamID); err != nil {
+				bc.logger.Warn("failed to discover tools of reverse upstream", "id", upstreamID, "error", err)
+			}
+		}
+	})
+
 	bc.toolCount = bc.toolCache.Count()
 	bc.logger.Info("tool discovery complete", "tools", bc.toolCount)
 
@@ -73,12 +120,26 @@ func (bc *bootContext) bootUpstreams(ctx context.Context) error {
 }
 
 // defaultClientFactory returns a ClientFactory that creates MCPClient instances
-// based on the upstream type.
-func defaultClientFactory(cfg *config.OSSConfig) service.ClientFactory {
+// based on the upstream type. Secret references (${env:NAME}) in the upstream
+// configuration are resolved from the gateway environment here, so the
+// secrets themselves never reach state.json. Upstream credentials are sent
+// with every request of HTTP, SSE, OpenAPI and shim upstreams. Reverse
+// upstreams use the connections held by hub; without a hub (outside a
+// running gateway) they cannot be reached. Certificates of HTTP, OpenAPI and shim upstreams are
+// verified by egressTLS.
+func defaultClientFactory(cfg *config.OSSConfig, hub *mcpclient.ReverseHub, egressTLS *mcpclient.EgressTLSPolicy, watcher mcpclient.ConnWatcher) service.ClientFactory {
 	return func(u *upstream.Upstream) (outbound.MCPClient, error) {
+		resolved, err := u.WithResolvedSecrets(os.LookupEnv)
+		if err != nil {
+			return nil, fmt.Errorf("upstream %s: %w", u.Name, err)
+		}
+		u = resolved
 		switch u.Type {
 		case upstream.UpstreamTypeStdio:
 			client := mcpclient.NewStdioClient(u.Command, u.Args...)
+			if framing, err := mcp.ParseFraming(u.Framing); err == nil {
+				client.SetFraming(framing)
+			}
 			if len(u.Env) > 0 {
 				// L-68: Filter out dangerous env vars that could compromise the subprocess.
 				filtered := make(map[string]string, len(u.Env))
@@ -99,9 +160,111 @@ func defaultClientFactory(cfg *config.OSSConfig) service.ClientFactory {
 				httpTimeout = 30 * time.Second
 			}
 			// H-1: Enable SSRF protection to prevent DNS rebinding attacks at connect time.
-			return mcpclient.NewHTTPClient(u.URL, mcpclient.WithTimeout(httpTimeout), mcpclient.WithSSRFProtection()), nil
+			return mcpclient.NewHTTPClient(u.URL, mcpclient.WithTimeout(httpTimeout), mcpclient.WithSSRFProtection(),
+				mcpclient.WithEgressTLS(egressTLS, u.Name), mcpclient.WithServerStream(), mcpclient.WithConnWatcher(watcher),
+				mcpclient.WithCredentials(clientCredentials(u.Credentials))), nil
+		case upstream.UpstreamTypeSSE:
+			httpTimeout, err := time.ParseDuration(cfg.Upstream.HTTPTimeout)
+			if err != nil {
+				httpTimeout = 30 * time.Second
+			}
+			return mcpclient.NewSSEClient(u.URL, mcpclient.WithSSETimeout(httpTimeout), mcpclient.WithSSESSRFProtection(),
+				mcpclient.WithSSEEgressTLS(egressTLS, u.Name), mcpclient.WithSSEConnWatcher(watcher),
+				mcpclient.WithSSECredentials(clientCredentials(u.Credentials))), nil
+		case upstream.UpstreamTypeOpenAPI:
+			httpTimeout, err := time.ParseDuration(cfg.Upstream.HTTPTimeout)
+			if err != nil {
+				httpTimeout = 30 * time.Second
+			}
+			return mcpclient.NewOpenAPIClient(u.Spec, u.URL, u.Headers,
+				mcpclient.WithOpenAPITimeout(httpTimeout), mcpclient.WithOpenAPISSRFProtection(),
+				mcpclient.WithOpenAPIEgressTLS(egressTLS, u.Name), mcpclient.WithOpenAPICredentials(clientCredentials(u.Credentials))), nil
+		case upstream.UpstreamTypeShim:
+			httpTimeout, err := time.ParseDuration(cfg.Upstream.HTTPTimeout)
+			if err != nil {
+				httpTimeout = 30 * time.Second
+			}
+			return mcpclient.NewOpenAPIClient(u.Spec, u.URL, u.Headers, mcpclient.WithOpenAPIToolMapping(),
+				mcpclient.WithOpenAPITimeout(httpTimeout), mcpclient.WithOpenAPISSRFProtection(),
+				mcpclient.WithOpenAPIEgressTLS(egressTLS, u.Name), mcpclient.WithOpenAPICredentials(clientCredentials(u.Credentials))), nil
+		case upstream.UpstreamTypeReverse:
+			if hub == nil {
+				return nil, fmt.Errorf("reverse upstream %s is only reachable through a running gateway", u.Name)
+			}
+			return mcpclient.NewReverseClient(hub, u.ID), nil
 		default:
 			return nil, fmt.Errorf("unsupported upstream type: %s", u.Type)
 		}
 	}
 }
+
+// clientCredentials converts the resolved credentials of an upstream for
+// the MCP clients. Returns nil for nil.
+func clientCredentials(c *upstream.Credentials) *mcpclient.Credentials {
+	if c == nil {
+		return nil
+	}
+	return &mcpclient.Credentials{
+		Type:         string(c.Type),
+		Token:        c.Token,
+		Username:     c.Username,
+		Password:     c.Password,
+		HeaderName:   c.HeaderName,
+		HeaderValue:  c.HeaderValue,
+		TokenURL:     c.TokenURL,
+		ClientID:     c.ClientID,
+		ClientSecret: c.ClientSecret,
+		Scopes:       c.Scopes,
+	}
+}
+
+// newEgressTLSPolicy builds the certificate verification of upstream
+// connections from the egress_tls configuration.
+func newEgressTLSPolicy(cfg *config.OSSConfig, onFailure func(*mcpclient.TLSVerificationError)) (*mcpclient.EgressTLSPolicy, error) {
+	dests := make([]mcpclient.EgressTLSDestination, 0, len(cfg.EgressTLS.Destinations))
+	for _, d := range cfg.EgressTLS.Destinations {
+		dests = append(dests, mcpclient.EgressTLSDestination{Domain: d.Domain, CAFile: d.CAFile, SPKIPins: d.SPKIPins})
+	}
+	return mcpclient.NewEgressTLSPolicy(dests, onFailure)
+}
+
+// recordEgressTLSFailure reports an upstream certificate the gateway
+// rejected: a possible interception of its traffic. It is logged, recorded
+// in the audit log and published as a security event.
+func (bc *bootContext) recordEgressTLSFailure(verr *mcpclient.TLSVerificationError) {
+	bc.logger.Error("upstream TLS verification failed",
+		"upstream", verr.Upstream,
+		"host", verr.Host,
+		"domain", verr.Domain,
+		"reason", verr.Reason,
+		"error", verr.Err,
+	)
+	details := map[string]interface{}{
+		"upstream_name": verr.Upstream,
+		"host":          verr.Host,
+		"reason":        verr.Reason,
+	}
+	if verr.Domain != "" {
+		details["domain"] = verr.Domain
+	}
+	if bc.auditService != nil {
+		bc.auditService.Record(audit.AuditRecord{
+			Timestamp:     time.Now().UTC(),
+			IdentityID:    "system",
+			IdentityName:  "system",
+			ToolName:      service.EventUpstreamTLSVerificationFailed,
+			ToolArguments: details,
+			Decision:      audit.DecisionDeny,
+			Reason:        verr.Error(),
+			Source:        "egress_tls",
+		})
+	}
+	if bc.eventBus != nil {
+		bc.eventBus.Publish(context.Background(), event.Event{
+			Type:     service.EventUpstreamTLSVerificationFailed,
+			Source:   "egress-tls",
+			Severity: event.SeverityCritical,
+			Payload:  details,
+		})
+	}
+}
@@ -1,19 +1,28 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/inbound/admin"
+	auditadapter "github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/audit"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/dns"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/geoip"
+	mcpclient "github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/mcp"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/memory"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/opa"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/config"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/action"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/auth"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/denyloop"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/event"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/proxy"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/quota"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/ratelimit"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/recording"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/session"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/storage"
@@ -24,6 +33,22 @@ import (
 	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
 )
 
+// sessionBackend is a session store with the cleanup lifecycle shared by
+// the memory and Redis implementations.
+type sessionBackend interface {
+	session.SessionStore
+	StartCleanup(ctx context.Context)
+	Stop()
+}
+
+// rateLimitBackend is a rate limiter with the cleanup lifecycle shared by
+// the memory and Redis implementations.
+type rateLimitBackend interface {
+	ratelimit.RateLimiter
+	StartCleanup(ctx context.Context)
+	Stop()
+}
+
 // bootContext accumulates all components created during the boot sequence.
 // Each boot phase is a method that populates fields. The validate() method
 // checks that all required components are wired before starting the transport.
@@ -36,30 +61,61 @@ type bootContext struct {
 	logger    *slog.Logger
 	startTime time.Time
 
+	// --- DNS ---
+	dnsResolver *dns.Resolver // nil when the system resolver is used
+	dnsWatcher  *dns.Watcher  // nil when rebinding checks are disabled
+
 	// --- BOOT-03/04: Stores ---
-	stateStore    *state.FileStateStore
+	stateStore    state.Store
 	appState      *state.AppState
 	authStore     *memory.AuthStore
-	sessionStore  *memory.MemorySessionStore
+	sessionStore  sessionBackend
 	policyStore   *memory.MemoryPolicyStore
 	upstreamStore *memory.MemoryUpstreamStore
-	rateLimiter   *memory.MemoryRateLimiter
+	rateLimiter   rateLimitBackend
 
 	// --- Services ---
-	apiKeyService      *auth.APIKeyService
-	sessionService     *session.SessionService
-	policyService      *service.PolicyService
-	policyEvalService  *service.PolicyEvaluationService
-	policyAdminService *service.PolicyAdminService
-	auditService       *service.AuditService
-	auditStore         *memory.MemoryAuditStore
-	statsService       *service.StatsService
-	identityService    *service.IdentityService
-	templateService    *service.TemplateService
-	upstreamService    *service.UpstreamService
+	apiKeyService         *auth.APIKeyService
+	sessionService        *session.SessionService
+	policyService         *service.PolicyService
+	policyEvalService     *service.PolicyEvaluationService
+	policyAdminService    *service.PolicyAdminService
+	policyVariableService *service.PolicyVariableService
+	policyDirectory       *service.PolicyDirectory
+	regoEngine            *opa.Engine // nil unless policy.engine is "rego"
+	rateLimitOverrides    *service.RateLimitOverrideService
+	rateLimitExemptions   *service.RateLimitExemptionService
+	adminTokens           *service.AdminTokenService
+	noticeService         *service.NoticeService
+	auditService          *service.AuditService
+	auditStore            *memory.MemoryAuditStore
+	auditFileStore        *auditadapter.FileAuditStore
+	auditSQLiteStore      *auditadapter.SQLiteAuditStore
+	payloadStore          *auditadapter.PayloadFileStore // nil unless audit_payloads.tools is set
+	statsService          *service.StatsService
+	toolStatsService      *service.ToolStatsService
+	costAccounting        *service.CostAccountingService
+	identityService       *service.IdentityService
+	templateService       *service.TemplateService
+	upstreamService       *service.UpstreamService
+
+	// --- Outbound allowlist learning ---
+	outboundLearningService *service.OutboundLearningService
+
+	// --- Argument anomaly detection ---
+	argumentAnomalyService *service.ArgumentAnomalyService // nil when disabled
+
+	// --- Recurring jobs ---
+	jobService *service.JobService
+
+	// --- GeoIP (identity country restrictions) ---
+	geoResolver *geoip.Resolver
+	geoLocator  *geoip.Locator
 
 	// --- Event Bus (A4) ---
 	eventBus *event.InProcessBus
+	// eventDispatcher routes bus events to the sinks (admin UI, webhooks, log).
+	eventDispatcher *event.Dispatcher
 
 	// --- Notifications (UX-F3) ---
 	notificationService *service.NotificationService
@@ -73,6 +129,7 @@ type bootContext struct {
 
 	// --- Compliance (Upgrade 2) ---
 	complianceService *service.ComplianceService
+	complianceContext func() service.ComplianceContext
 
 	// --- Simulation (UX-F1) ---
 	simulationService *service.SimulationService
@@ -107,6 +164,8 @@ type bootContext struct {
 	// --- BOOT-05/06: Upstreams ---
 	upstreamManager     *service.UpstreamManager
 	discoveryService    *service.ToolDiscoveryService
+	reverseHub          *mcpclient.ReverseHub
+	egressTLS           *mcpclient.EgressTLSPolicy
 	toolCache           *upstream.ToolCache
 	toolSecurityService *service.ToolSecurityService
 	connectedCount      int
@@ -119,17 +178,27 @@ type bootContext struct {
 	// --- BOOT-07: Interceptor chain ---
 	interceptorChain        proxy.MessageInterceptor
 	upstreamRouter          *proxy.UpstreamRouter
+	clientRequestInterceptor *action.ClientRequestInterceptor // bound to the HTTP transport for sampling/elicitation
 	actionAuthInterceptor   *action.ActionAuthInterceptor
 	sessionTracker          *session.SessionTracker
 	responseScanner         *action.ResponseScanner
 	responseScanInterceptor *action.ResponseScanInterceptor
+	taintTracker            *action.TaintTracker // nil when taint tracking is disabled
 	contentScanner          *action.ContentScanner
 	contentScanInterceptor  *action.ContentScanInterceptor
+	argumentScanInterceptor *action.ArgumentScanInterceptor
 	approvalStore           *action.ApprovalStore
 	transformStore          *transform.MemoryTransformStore
 	transformExecutor       *transform.TransformExecutor
 	quotaStore              *quota.MemoryQuotaStore
+	denyLoops               *denyloop.Detector // nil when deny loop detection is disabled
 	recordingObserver       *recording.RecordingObserver
+	sloService              *service.SLOService
+	// Rate limit tiers, kept so a config reload can change their rates; nil
+	// when rate limiting (or the session tier) is disabled.
+	ipRateLimiter      *action.ActionIPRateLimitInterceptor
+	userRateLimiter    *action.ActionUserRateLimitInterceptor
+	sessionRateLimiter *action.ActionSessionRateLimitInterceptor
 
 	// --- Transport ---
 	mcpClient    outbound.MCPClient
@@ -138,6 +207,11 @@ type bootContext struct {
 	// --- Lifecycle (A6) ---
 	lifecycle *lifecycle.Manager
 
+	// --- Config reload ---
+	stdioTransport bool // upstream given on the command line
+	reloadMu       sync.Mutex
+	reloadedCfg    *config.OSSConfig // config file as last reloaded; guarded by reloadMu
+
 	// --- Cleanup (legacy, used alongside lifecycle) ---
 	cleanups []func()
 }
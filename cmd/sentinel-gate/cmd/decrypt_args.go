@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	auditadapter "github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/audit"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+)
+
+var decryptArgsKeyFile string
+
+var decryptArgsCmd = &cobra.Command{
+	Use:   "decrypt-args [file...]",
+	Short: "Decrypt sensitive tool arguments in audit files and recordings",
+	Long: `Decrypt the tool arguments that were encrypted because they are listed
+under sensitive_arguments in the config.
+
+Reads JSON values (audit file lines, session recording files) from the given
+files or from standard input and writes each one back as a JSON line with
+every encrypted value replaced by its plaintext. Compressed audit files must
+be decompressed first.
+
+Examples:
+  sentinel-gate decrypt-args --key-file argument-key audit-2026-01-15.log
+  zstd -dc audit-2026-01-14.log.zst | sentinel-gate decrypt-args --key-file argument-key`,
+	RunE: runDecryptArgs,
+}
+
+func init() {
+	decryptArgsCmd.Flags().StringVar(&decryptArgsKeyFile, "key-file", "", "Path to the argument encryption key (required)")
+	decryptArgsCmd.MarkFlagRequired("key-file")
+	rootCmd.AddCommand(decryptArgsCmd)
+}
+
+func runDecryptArgs(cmd *cobra.Command, args []string) error {
+	key, err := auditadapter.LoadArgumentKey(decryptArgsKeyFile)
+	if err != nil {
+		return err
+	}
+	enc, err := audit.NewArgumentEncryptor(key, nil)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if len(args) == 0 {
+		return decryptArgsStream(enc, cmd.InOrStdin(), out)
+	}
+	for _, path := range args {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		err = decryptArgsStream(enc, f, out)
+		_ = f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// decryptArgsStream decrypts every JSON value read from r and writes it to w
+// as one line.
+func decryptArgsStream(enc *audit.ArgumentEncryptor, r io.Reader, w io.Writer) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	out := json.NewEncoder(w)
+	out.SetEscapeHTML(false)
+	for {
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		opened, _, err := enc.Open(v)
+		if err != nil {
+			return err
+		}
+		if err := out.Encode(opened); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/inbound/migrate"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+)
+
+var (
+	importFrom   string
+	importDryRun bool
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import --from <mcp-proxy|litellm|custom-json> <file>",
+	Short: "Convert another MCP gateway's config into upstreams, keys and policies",
+	Long: `Convert the configuration of another MCP gateway into SentinelGate
+upstreams, identities, API keys and a starter policy, and merge them into
+state.json. A report lists what was converted and every construct that has
+no SentinelGate equivalent.
+
+Formats:
+  mcp-proxy     mcp-proxy JSON config, or any "mcpServers" JSON file
+  litellm       LiteLLM proxy YAML config (mcp_servers, general_settings.master_key)
+  custom-json   {"upstreams": [...], "keys": [...], "allow": [...], "deny": [...]}
+
+Upstreams whose name already exists are skipped, keys of an existing
+identity are attached to it, and the starter policy is skipped when a policy
+of the same name exists. Only the hashes of imported keys are stored.
+
+Run the import while the server is stopped, or restart it afterwards: a
+running server does not reload state.json.
+
+Examples:
+  sentinel-gate import --from mcp-proxy config.json
+  sentinel-gate import --from litellm --dry-run litellm_config.yaml`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImport,
+}
+
+func init() {
+	importCmd.Flags().StringVar(&importFrom, "from", "", "Source format: mcp-proxy, litellm or custom-json")
+	importCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Print the conversion report without changing state.json")
+	_ = importCmd.MarkFlagRequired("from")
+	rootCmd.AddCommand(importCmd)
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("read %s: %w", args[0], err)
+	}
+	plan, err := migrate.Convert(migrate.Format(importFrom), data)
+	if err != nil {
+		return err
+	}
+
+	w := cmd.OutOrStdout()
+	printImportReport(w, plan)
+	if importDryRun {
+		fmt.Fprintln(w, "Dry run: state.json not changed.")
+		return nil
+	}
+
+	// Imported upstream credentials are encrypted like those added through
+	// the admin API.
+	store, closeStore, err := authStateStore(true)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	var res migrate.MergeResult
+	if err := store.Mutate(func(appState *state.AppState) error {
+		res = migrate.Merge(appState, plan)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+
+	fmt.Fprintf(w, "Imported into %s\n", store.Path())
+	fmt.Fprintf(w, "  upstreams:    %d added, %d skipped (name exists)\n", res.UpstreamsAdded, res.UpstreamsSkipped)
+	fmt.Fprintf(w, "  identities:   %d added, %d existing\n", res.IdentitiesAdded, res.IdentitiesReused)
+	fmt.Fprintf(w, "  API keys:     %d added\n", res.KeysAdded)
+	fmt.Fprintf(w, "  policy rules: %d added, %d skipped (policy exists)\n", res.RulesAdded, res.RulesSkipped)
+	fmt.Fprintln(w, "Restart SentinelGate if it is running to load the imported entries.")
+	return nil
+}
+
+// printImportReport writes the conversion report: converted constructs
+// first, then the ones without an equivalent.
+func printImportReport(w io.Writer, plan *migrate.Plan) {
+	fmt.Fprintf(w, "Converted from %s:\n", plan.Format)
+	for _, n := range plan.Notes {
+		if !n.Unsupported {
+			fmt.Fprintf(w, "  %s: %s\n", n.Path, n.Message)
+		}
+	}
+	unsupported := plan.Unsupported()
+	if len(unsupported) == 0 {
+		fmt.Fprintln(w, "All constructs were converted.")
+		return
+	}
+	fmt.Fprintf(w, "Not converted (%d):\n", len(unsupported))
+	for _, n := range unsupported {
+		fmt.Fprintf(w, "  %s: %s\n", n.Path, n.Message)
+	}
+}
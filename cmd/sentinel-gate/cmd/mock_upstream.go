@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/mockupstream"
+)
+
+var (
+	mockUpstreamHTTP string
+	mockUpstreamPath string
+	mockUpstreamSeed int64
+)
+
+var mockUpstreamCmd = &cobra.Command{
+	Use:   "mock-upstream <tools.yaml>",
+	Short: "Run a fake MCP server with canned tool responses",
+	Long: `Run a fake MCP server whose tools, responses, latency and failures are
+described in a YAML file, so policies and integrations can be tested without
+real upstream servers.
+
+By default the server speaks MCP over stdin/stdout and can be added as a
+stdio upstream. With --http it listens for Streamable HTTP requests instead.
+
+Example tools.yaml:
+
+  server:
+    name: mock-files
+    version: 1.0.0
+  tools:
+    - name: read_file
+      description: Read a file
+      input_schema:
+        type: object
+        properties:
+          path: {type: string}
+      latency: 50ms
+      jitter: 20ms
+      responses:
+        - match: {path: /etc/passwd}
+          text: "permission denied"
+          is_error: true
+        - text: "contents of {{.Arguments.path}}"
+    - name: flaky_search
+      responses:
+        - json: {results: []}
+      failure:
+        rate: 0.2          # or every: 5
+        mode: error        # error, tool_error, timeout or crash
+        message: backend unavailable
+
+Examples:
+  sentinel-gate mock-upstream tools.yaml
+  sentinel-gate mock-upstream --http 127.0.0.1:9000 tools.yaml`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMockUpstream,
+}
+
+func init() {
+	mockUpstreamCmd.Flags().StringVar(&mockUpstreamHTTP, "http", "", "Listen address for Streamable HTTP (default: serve on stdio)")
+	mockUpstreamCmd.Flags().StringVar(&mockUpstreamPath, "path", "/mcp", "HTTP endpoint path")
+	mockUpstreamCmd.Flags().Int64Var(&mockUpstreamSeed, "seed", 0, "Seed for jitter and failure injection (default: random)")
+	rootCmd.AddCommand(mockUpstreamCmd)
+}
+
+func runMockUpstream(cmd *cobra.Command, args []string) error {
+	spec, err := mockupstream.LoadSpec(args[0])
+	if err != nil {
+		return err
+	}
+
+	// Logs go to stderr: in stdio mode stdout carries the protocol.
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	opts := []mockupstream.Option{mockupstream.WithLogger(logger)}
+	if cmd.Flags().Changed("seed") {
+		opts = append(opts, mockupstream.WithSeed(mockUpstreamSeed))
+	}
+	server, err := mockupstream.NewServer(spec, opts...)
+	if err != nil {
+		return err
+	}
+
+	// In stdio mode the server stops when stdin closes; signals keep their
+	// default behaviour.
+	if mockUpstreamHTTP == "" {
+		return server.ServeStdio(context.Background(), os.Stdin, os.Stdout)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	mux := http.NewServeMux()
+	mux.Handle(mockUpstreamPath, server)
+	srv := &http.Server{
+		Addr:              mockUpstreamHTTP,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.ListenAndServe() }()
+	logger.Info("mock upstream listening", "addr", mockUpstreamHTTP, "path", mockUpstreamPath, "tools", len(spec.Tools))
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("mock upstream: %w", err)
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -13,6 +13,12 @@ func gracefulSignals() []os.Signal {
 	return []os.Signal{syscall.SIGINT, syscall.SIGTERM}
 }
 
+// reloadSignals returns the OS signals that reload the config file.
+// On Unix: SIGHUP.
+func reloadSignals() []os.Signal {
+	return []os.Signal{syscall.SIGHUP}
+}
+
 // processIsAlive checks if a process is still running using Signal(0).
 func processIsAlive(proc *os.Process) bool {
 	return proc.Signal(syscall.Signal(0)) == nil
@@ -15,6 +15,12 @@ func gracefulSignals() []os.Signal {
 	return []os.Signal{os.Interrupt}
 }
 
+// reloadSignals returns the OS signals that reload the config file. Windows
+// has no SIGHUP; use POST /admin/api/system/reload instead.
+func reloadSignals() []os.Signal {
+	return nil
+}
+
 // processIsAlive checks if a process is still running on Windows
 // by opening a handle and checking the exit code.
 func processIsAlive(proc *os.Process) bool {
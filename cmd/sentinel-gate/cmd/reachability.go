@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/memory"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/config"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+var (
+	reachabilityTool              string
+	reachabilityRoles             []string
+	reachabilityIdentityID        string
+	reachabilityIdentityName      string
+	reachabilityJSON              bool
+	reachabilityAssertUnreachable bool
+)
+
+var reachabilityCmd = &cobra.Command{
+	Use:   "reachability",
+	Short: "Check whether a tool can ever be reached under the current policies",
+	Long: `Check whether a tool call can be let through by the current policy set
+(YAML config + state.json) for the given roles.
+
+Rule conditions are evaluated with the roles and identity fixed and every
+other input (arguments, session state, destinations) unknown. A condition is
+only considered always true or never true when that holds for every possible
+input, so an "unreachable" verdict is a proof. Otherwise every rule chain that
+could allow the call is printed.
+
+Examples:
+  # Prove contractors can never delete the database
+  sentinel-gate reachability --tool delete_database --role contractor --assert-unreachable
+
+  # Full report as JSON
+  sentinel-gate reachability --tool delete_database --role contractor --json`,
+	RunE: runReachability,
+}
+
+func init() {
+	reachabilityCmd.Flags().StringVar(&reachabilityTool, "tool", "", "Tool name to check (required)")
+	reachabilityCmd.Flags().StringSliceVar(&reachabilityRoles, "role", nil, "Role of the caller (repeatable)")
+	reachabilityCmd.Flags().StringVar(&reachabilityIdentityID, "identity-id", "", "Fix identity_id for the check")
+	reachabilityCmd.Flags().StringVar(&reachabilityIdentityName, "identity-name", "", "Fix identity_name for the check")
+	reachabilityCmd.Flags().BoolVar(&reachabilityJSON, "json", false, "Print the full result as JSON")
+	reachabilityCmd.Flags().BoolVar(&reachabilityAssertUnreachable, "assert-unreachable", false, "Exit with status 1 unless the tool is unreachable")
+	reachabilityCmd.MarkFlagRequired("tool")
+	rootCmd.AddCommand(reachabilityCmd)
+}
+
+func runReachability(cmd *cobra.Command, args []string) error {
+	result, err := checkReachability(context.Background(), service.ReachabilityQuery{
+		ToolName:     reachabilityTool,
+		Roles:        reachabilityRoles,
+		IdentityID:   reachabilityIdentityID,
+		IdentityName: reachabilityIdentityName,
+	})
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if reachabilityJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			return err
+		}
+	} else {
+		printReachability(out, result)
+	}
+
+	if reachabilityAssertUnreachable && result.Verdict != service.ReachabilityUnreachable {
+		os.Exit(1)
+	}
+	return nil
+}
+
+// checkReachability loads policies the same way start does (YAML first, then
+// state.json) and runs the reachability check against them.
+func checkReachability(ctx context.Context, q service.ReachabilityQuery) (*service.ReachabilityResult, error) {
+	cfg, err := config.LoadConfigRaw()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	cfg.SetDefaults()
+
+	statePath := stateFilePath
+	if statePath == "" {
+		statePath = os.Getenv("SENTINEL_GATE_STATE_PATH")
+	}
+	if statePath == "" {
+		statePath = "./state.json"
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	stateStore, closeState, err := openStateStore(ctx, cfg, statePath, logger)
+	if err != nil {
+		return nil, err
+	}
+	defer closeState()
+	appState, err := stateStore.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load state: %w", err)
+	}
+
+	policyStore := memory.NewPolicyStore()
+	if err := seedPoliciesFromConfig(cfg, policyStore); err != nil {
+		return nil, fmt.Errorf("failed to seed policies: %w", err)
+	}
+	policyService, err := service.NewPolicyService(ctx, policyStore, logger,
+		service.WithCELLimits(celLimits(cfg.CEL)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create policy service: %w", err)
+	}
+	policyAdmin := service.NewPolicyAdminService(policyStore, stateStore, policyService, logger)
+	if err := policyAdmin.LoadPoliciesFromState(ctx, appState); err != nil {
+		return nil, fmt.Errorf("failed to load policies from state: %w", err)
+	}
+	if cfg.PolicyDirectory.Path != "" {
+		if err := service.NewPolicyDirectory(cfg.PolicyDirectory.Path, policyStore, policyService, logger).Load(ctx); err != nil {
+			return nil, fmt.Errorf("failed to load policy directory: %w", err)
+		}
+	}
+
+	return policyService.CheckReachability(ctx, q)
+}
+
+// printReachability writes a human-readable reachability report.
+func printReachability(w io.Writer, r *service.ReachabilityResult) {
+	roles := "(none)"
+	if len(r.Roles) > 0 {
+		roles = strings.Join(r.Roles, ", ")
+	}
+	fmt.Fprintf(w, "Tool:    %s\n", r.ToolName)
+	fmt.Fprintf(w, "Roles:   %s\n", roles)
+	fmt.Fprintf(w, "Verdict: %s\n", strings.ToUpper(r.Verdict))
+
+	if r.Verdict == service.ReachabilityUnreachable {
+		if r.BlockedBy != nil {
+			fmt.Fprintf(w, "\nBlocked by rule %q (priority %d, tool_match %q).\n",
+				r.BlockedBy.Name, r.BlockedBy.Priority, r.BlockedBy.ToolMatch)
+		}
+		return
+	}
+
+	fmt.Fprintln(w, "\nRule chains that could allow the call:")
+	for i, p := range r.Paths {
+		if p.DefaultAllow {
+			fmt.Fprintf(w, "  %d. default allow (no rule matches)\n", i+1)
+		} else {
+			fmt.Fprintf(w, "  %d. %s by rule %q (priority %d, condition %s: %s)\n",
+				i+1, p.Decision, p.Rule.Name, p.Rule.Priority, p.Rule.Outcome, displayCondition(p.Rule.Condition))
+		}
+		for _, g := range p.MustNotMatch {
+			fmt.Fprintf(w, "     provided %s rule %q does not match: %s\n",
+				g.Action, g.Name, displayCondition(g.Condition))
+		}
+	}
+}
+
+// displayCondition renders an empty condition as "true".
+func displayCondition(cond string) string {
+	if cond == "" {
+		return "true"
+	}
+	return cond
+}
@@ -35,6 +35,7 @@ Configuration:
 Commands:
   start       Start the proxy server
   stop        Stop the running server
+  status      Show upstreams, sessions, rates and recent denials
   reset       Reset to clean state (remove state.json)
   hash-key    Generate SHA256 hash for an API key
   trust-ca    Add/remove the CA certificate to the OS trust store
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	auditadapter "github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/audit"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/config"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+var (
+	simulatePolicies   string
+	simulateAuditDir   string
+	simulateSince      time.Duration
+	simulateFrom       string
+	simulateTo         string
+	simulateMaxRecords int
+	simulateTool       string
+	simulateIdentity   string
+	simulateJSON       bool
+)
+
+// simulateShownDetails is how many changed calls the text report lists.
+const simulateShownDetails = 20
+
+var simulateCmd = &cobra.Command{
+	Use:   "simulate",
+	Short: "Replay audit history through a candidate policy set",
+	Long: `Replay a window of recorded tool calls through a candidate policy set and
+report which decisions would change: newly denied, newly allowed and
+unchanged calls, attributed to the candidate rule that made each decision.
+
+The candidate set is a JSON file holding an array of policies (or an object
+with a "policies" array) in the layout of GET /admin/api/policies, so the
+active policies can be exported, edited and replayed. It replaces the active
+policies for the replay and is never activated.
+
+The audit files are read from audit_file.dir in the config or --audit-dir.
+The server does not need to be running.
+
+Examples:
+  curl -s http://localhost:8080/admin/api/policies > candidate.json
+  sentinel-gate simulate --policies candidate.json
+  sentinel-gate simulate --policies candidate.json --since 168h --tool 'write_*'
+  sentinel-gate simulate --policies candidate.json --from 2026-03-01T00:00:00Z --to 2026-03-02T00:00:00Z --json`,
+	Args: cobra.NoArgs,
+	RunE: runSimulate,
+}
+
+func init() {
+	simulateCmd.Flags().StringVar(&simulatePolicies, "policies", "", "JSON file with the candidate policy set (required)")
+	simulateCmd.Flags().StringVar(&simulateAuditDir, "audit-dir", "", "Audit file directory (default: audit_file.dir from config)")
+	simulateCmd.Flags().DurationVar(&simulateSince, "since", 24*time.Hour, "Replay this much history before --to")
+	simulateCmd.Flags().StringVar(&simulateFrom, "from", "", "Window start (RFC 3339); overrides --since")
+	simulateCmd.Flags().StringVar(&simulateTo, "to", "", "Window end (RFC 3339, default: now)")
+	simulateCmd.Flags().IntVar(&simulateMaxRecords, "max-records", 10000, "Replay at most this many records, newest first")
+	simulateCmd.Flags().StringVar(&simulateTool, "tool", "", "Only replay tools matching this glob pattern")
+	simulateCmd.Flags().StringVar(&simulateIdentity, "identity", "", "Only replay calls of this identity ID")
+	simulateCmd.Flags().BoolVar(&simulateJSON, "json", false, "Print the result as JSON")
+	simulateCmd.MarkFlagRequired("policies")
+	rootCmd.AddCommand(simulateCmd)
+}
+
+func runSimulate(cmd *cobra.Command, args []string) error {
+	policies, err := readCandidatePolicies(simulatePolicies)
+	if err != nil {
+		return err
+	}
+
+	dir := simulateAuditDir
+	if dir == "" {
+		cfg, err := config.LoadConfigRaw()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		dir = cfg.AuditFile.Dir
+	}
+	if dir == "" {
+		return errors.New("no audit files: set audit_file.dir in the config or pass --audit-dir")
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("audit directory: %w", err)
+	}
+
+	req := service.ReplayRequest{
+		Policies:   policies,
+		MaxRecords: simulateMaxRecords,
+		ToolMatch:  simulateTool,
+		IdentityID: simulateIdentity,
+	}
+	req.EndTime = time.Now().UTC()
+	if simulateTo != "" {
+		if req.EndTime, err = time.Parse(time.RFC3339, simulateTo); err != nil {
+			return fmt.Errorf("invalid --to: %w", err)
+		}
+	}
+	req.StartTime = req.EndTime.Add(-simulateSince)
+	if simulateFrom != "" {
+		if req.StartTime, err = time.Parse(time.RFC3339, simulateFrom); err != nil {
+			return fmt.Errorf("invalid --from: %w", err)
+		}
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	reader := auditadapter.NewAuditFileReader(dir, logger)
+	defer func() { _ = reader.Close() }()
+	sim := service.NewSimulationService(nil, nil, logger)
+	sim.SetAuditQuery(reader)
+
+	result, err := sim.Replay(context.Background(), req)
+	if err != nil {
+		return err
+	}
+	if simulateJSON {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+	printReplay(cmd.OutOrStdout(), result)
+	return nil
+}
+
+// readCandidatePolicies reads a candidate policy set: a JSON array of
+// policies or an object with a "policies" array.
+func readCandidatePolicies(path string) ([]service.CandidatePolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read candidate policies: %w", err)
+	}
+	var policies []service.CandidatePolicy
+	if err := json.Unmarshal(data, &policies); err != nil {
+		var wrapped struct {
+			Policies []service.CandidatePolicy `json:"policies"`
+		}
+		if err2 := json.Unmarshal(data, &wrapped); err2 != nil {
+			return nil, fmt.Errorf("parse candidate policies: %w", err)
+		}
+		policies = wrapped.Policies
+	}
+	return policies, nil
+}
+
+// printReplay writes a human-readable replay report.
+func printReplay(w io.Writer, r *service.ReplayResult) {
+	fmt.Fprintf(w, "Window:   %s .. %s\n", r.StartTime.UTC().Format(time.RFC3339), r.EndTime.UTC().Format(time.RFC3339))
+	fmt.Fprintf(w, "Replayed: %d of %d records (%d skipped)", r.TotalAnalyzed, r.TotalProcessed, r.Skipped)
+	if r.Truncated {
+		fmt.Fprint(w, ", older records not replayed (--max-records)")
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Newly denied: %d   Newly allowed: %d   Other changes: %d   Unchanged: %d\n",
+		r.NewlyDenied, r.NewlyAllowed, r.Changed-r.NewlyDenied-r.NewlyAllowed, r.Unchanged)
+
+	if len(r.Rules) > 0 {
+		fmt.Fprintln(w, "\nBy rule:")
+		fmt.Fprintf(w, "  %-32s %-18s %9s %7s %8s %9s\n", "RULE", "ACTION", "DECISIONS", "DENIED", "ALLOWED", "UNCHANGED")
+		for _, rule := range r.Rules {
+			name := rule.RuleName
+			if rule.PolicyName != "" {
+				name = rule.PolicyName + "/" + name
+			}
+			fmt.Fprintf(w, "  %-32s %-18s %9d %7d %8d %9d\n", truncateStatus(name, 32), rule.Action,
+				rule.Decisions, rule.NewlyDenied, rule.NewlyAllowed, rule.Unchanged)
+		}
+	}
+
+	if len(r.Details) > 0 {
+		fmt.Fprintln(w, "\nChanged calls:")
+		for i, d := range r.Details {
+			if i == simulateShownDetails {
+				fmt.Fprintf(w, "  ... %d more (use --json for up to 100)\n", len(r.Details)-i)
+				break
+			}
+			identity := d.IdentityName
+			if identity == "" {
+				identity = d.IdentityID
+			}
+			fmt.Fprintf(w, "  %s  %-24s %-16s %s -> %s  %s\n", d.Timestamp, truncateStatus(d.ToolName, 24),
+				truncateStatus(identity, 16), d.OriginalDecision, d.NewDecision, d.NewRuleName)
+		}
+	}
+}
@@ -127,11 +127,12 @@ func runStart(cmd *cobra.Command, args []string) error {
 // Boot sequence: BOOT-00 through BOOT-09.
 func run(ctx context.Context, cfg *config.OSSConfig, statePath string, stdioTransport bool, logger *slog.Logger) error {
 	bc := &bootContext{
-		cfg:       cfg,
-		statePath: statePath,
-		logger:    logger,
-		startTime: time.Now().UTC(),
-		lifecycle: lifecycle.NewManager(logger),
+		cfg:            cfg,
+		statePath:      statePath,
+		logger:         logger,
+		startTime:      time.Now().UTC(),
+		lifecycle:      lifecycle.NewManager(logger),
+		stdioTransport: stdioTransport,
 	}
 	defer bc.runCleanups()
 	defer func() {
@@ -143,6 +144,11 @@ func run(ctx context.Context, cfg *config.OSSConfig, statePath string, stdioTran
 		}
 	}()
 
+	// DNS before anything dials out.
+	if err := bc.bootDNS(); err != nil {
+		return err
+	}
+
 	// BOOT-03/04: Stores + seeding
 	if err := bc.bootStores(ctx); err != nil {
 		return err
@@ -177,6 +183,11 @@ func run(ctx context.Context, cfg *config.OSSConfig, statePath string, stdioTran
 		bc.finopsService.StartPeriodicBudgetCheck(ctx, 2*time.Minute)
 	}
 
+	// Recurring admin jobs. Runs last so every job dependency is wired.
+	if err := bc.bootScheduler(); err != nil {
+		return err
+	}
+
 	// Validate all critical components are wired
 	if err := bc.validate(); err != nil {
 		return err
@@ -185,6 +196,9 @@ func run(ctx context.Context, cfg *config.OSSConfig, statePath string, stdioTran
 	// BOOT-08: Create proxy service
 	bc.bootTransport()
 
+	// Config reload on SIGHUP and from the admin API
+	bc.bootConfigReload(ctx)
+
 	// BOOT-09: Start transport
 	return bc.startTransport(ctx, stdioTransport)
 }
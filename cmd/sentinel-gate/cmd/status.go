@@ -0,0 +1,422 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/config"
+)
+
+var (
+	statusWatch    bool
+	statusInterval time.Duration
+	statusJSON     bool
+	statusAddr     string
+)
+
+// statusRecentWindow is how far back the audit log is read for rates,
+// denials and scan detections.
+const statusRecentWindow = 15 * time.Minute
+
+// statusRecentLimit is how many denials and detections are shown.
+const statusRecentLimit = 5
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show what the running server is doing right now",
+	Long: `Query the admin API of the running server and print upstream states,
+active sessions, request rates, recent denials and scan detections.
+
+The server address is taken from server.http_addr in the config file (a
+wildcard listen address is reached on 127.0.0.1), over HTTPS when
+server.tls is configured. The admin API only
+accepts localhost connections, so run this on the gateway host.
+
+Examples:
+  # One-shot summary
+  sentinel-gate status
+
+  # Live view, refreshed every 2 seconds (Ctrl+C to exit)
+  sentinel-gate status --watch
+
+  # Machine-readable; with --watch prints one JSON object per refresh
+  sentinel-gate status --json`,
+	RunE: runStatus,
+}
+
+func init() {
+	statusCmd.Flags().BoolVarP(&statusWatch, "watch", "w", false, "Refresh the view until interrupted")
+	statusCmd.Flags().DurationVar(&statusInterval, "interval", 2*time.Second, "Refresh interval for --watch")
+	statusCmd.Flags().BoolVar(&statusJSON, "json", false, "Print the status as JSON")
+	statusCmd.Flags().StringVar(&statusAddr, "addr", "", "Server address or URL (default: server.http_addr from config)")
+	rootCmd.AddCommand(statusCmd)
+}
+
+// statusSnapshot is the status of the server at one point in time.
+type statusSnapshot struct {
+	Server           string            `json:"server"`
+	CheckedAt        time.Time         `json:"checked_at"`
+	Health           string            `json:"health"`
+	Version          string            `json:"version,omitempty"`
+	Checks           map[string]string `json:"checks,omitempty"`
+	Upstreams        []statusUpstream  `json:"upstreams"`
+	Sessions         []statusSession   `json:"sessions"`
+	Totals           statusTotals      `json:"totals"`
+	LastMinute       statusRates       `json:"last_minute"`
+	RecentDenials    []statusEvent     `json:"recent_denials"`
+	RecentDetections []statusEvent     `json:"recent_detections"`
+}
+
+type statusUpstream struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Enabled   bool   `json:"enabled"`
+	Status    string `json:"status"`
+	LastError string `json:"last_error,omitempty"`
+	ToolCount int    `json:"tool_count"`
+}
+
+type statusSession struct {
+	SessionID    string `json:"session_id"`
+	IdentityID   string `json:"identity_id"`
+	IdentityName string `json:"identity_name"`
+	TotalCalls   int64  `json:"total_calls"`
+	StartedAt    string `json:"started_at"`
+	LastCallAt   string `json:"last_call_at,omitempty"`
+}
+
+// statusTotals are the decision counters since the server started.
+type statusTotals struct {
+	Allowed     int64 `json:"allowed"`
+	Denied      int64 `json:"denied"`
+	Blocked     int64 `json:"blocked"`
+	RateLimited int64 `json:"rate_limited"`
+	Warned      int64 `json:"warned"`
+	Errors      int64 `json:"errors"`
+}
+
+// statusRates counts audited requests over the last minute.
+type statusRates struct {
+	Requests  int     `json:"requests"`
+	Denied    int     `json:"denied"`
+	PerSecond float64 `json:"per_second"`
+}
+
+// statusEvent is an audit record shown as a recent denial or detection.
+type statusEvent struct {
+	Timestamp  string `json:"timestamp"`
+	Identity   string `json:"identity"`
+	Tool       string `json:"tool"`
+	Decision   string `json:"decision"`
+	Reason     string `json:"reason,omitempty"`
+	ScanAction string `json:"scan_action,omitempty"`
+	ScanTypes  string `json:"scan_types,omitempty"`
+}
+
+func runStatus(cmd *cobra.Command, args []string) error {
+	base, err := statusBaseURL(statusAddr)
+	if err != nil {
+		return err
+	}
+	client := &statusClient{base: base, http: &http.Client{Timeout: 5 * time.Second}}
+	out := cmd.OutOrStdout()
+
+	if !statusWatch {
+		snap, err := client.collect(cmd.Context())
+		if err != nil {
+			return err
+		}
+		return writeStatus(out, snap, statusJSON)
+	}
+
+	if statusInterval < 500*time.Millisecond {
+		statusInterval = 500 * time.Millisecond
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ticker := time.NewTicker(statusInterval)
+	defer ticker.Stop()
+	for {
+		snap, err := client.collect(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if !statusJSON {
+			// Clear the screen and move the cursor home before each frame.
+			fmt.Fprint(out, "\033[H\033[2J")
+		}
+		switch {
+		case err != nil && statusJSON:
+			_ = json.NewEncoder(out).Encode(map[string]string{"server": base, "error": err.Error()})
+		case err != nil:
+			fmt.Fprintf(out, "SentinelGate status  %s  %s\n\n%v\n", base, time.Now().Format("15:04:05"), err)
+		default:
+			if err := writeStatus(out, snap, statusJSON); err != nil {
+				return err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// statusBaseURL resolves the admin API base URL from --addr or the config.
+// A server with server.tls configured is reached over HTTPS.
+func statusBaseURL(addr string) (string, error) {
+	scheme := "http"
+	if addr == "" {
+		cfg, err := config.LoadConfigRaw()
+		if err != nil {
+			return "", fmt.Errorf("failed to load config: %w", err)
+		}
+		cfg.SetDefaults()
+		addr = cfg.Server.HTTPAddr
+		if cfg.Server.TLS.CertFile != "" {
+			scheme = "https"
+		}
+	}
+	if strings.Contains(addr, "://") {
+		u, err := url.Parse(addr)
+		if err != nil || u.Host == "" {
+			return "", fmt.Errorf("invalid server URL %q", addr)
+		}
+		return strings.TrimRight(u.Scheme+"://"+u.Host, "/"), nil
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid server address %q: %w", addr, err)
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+	}
+	return scheme + "://" + net.JoinHostPort(host, port), nil
+}
+
+// statusClient reads the admin API of a running server.
+type statusClient struct {
+	base string
+	http *http.Client
+	now  func() time.Time
+}
+
+func (c *statusClient) getJSON(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.base+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// /health answers 503 with a body when a component is unhealthy.
+	if resp.StatusCode != http.StatusOK && !(path == "/health" && resp.StatusCode == http.StatusServiceUnavailable) {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&apiErr)
+		if apiErr.Error != "" {
+			return fmt.Errorf("GET %s: %s (%d)", path, apiErr.Error, resp.StatusCode)
+		}
+		return fmt.Errorf("GET %s: HTTP %d", path, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// collect builds a snapshot. Failing to reach the server is an error;
+// sections whose endpoint is unavailable (e.g. no audit store) are left empty.
+func (c *statusClient) collect(ctx context.Context) (*statusSnapshot, error) {
+	now := time.Now()
+	if c.now != nil {
+		now = c.now()
+	}
+	snap := &statusSnapshot{
+		Server:           c.base,
+		CheckedAt:        now.UTC(),
+		Upstreams:        []statusUpstream{},
+		Sessions:         []statusSession{},
+		RecentDenials:    []statusEvent{},
+		RecentDetections: []statusEvent{},
+	}
+
+	var health struct {
+		Status  string            `json:"status"`
+		Checks  map[string]string `json:"checks"`
+		Version string            `json:"version"`
+	}
+	if err := c.getJSON(ctx, "/health", &health); err != nil {
+		return nil, fmt.Errorf("cannot reach SentinelGate at %s: %w\nIs the server running?", c.base, err)
+	}
+	snap.Health, snap.Checks, snap.Version = health.Status, health.Checks, health.Version
+
+	if err := c.getJSON(ctx, "/admin/api/upstreams", &snap.Upstreams); err != nil {
+		return nil, err
+	}
+	sort.Slice(snap.Upstreams, func(i, j int) bool { return snap.Upstreams[i].Name < snap.Upstreams[j].Name })
+
+	_ = c.getJSON(ctx, "/admin/api/v1/sessions/active", &snap.Sessions)
+	if err := c.getJSON(ctx, "/admin/api/stats", &snap.Totals); err != nil {
+		return nil, err
+	}
+
+	var auditResp struct {
+		Records []struct {
+			Timestamp      string `json:"timestamp"`
+			IdentityID     string `json:"identity_id"`
+			IdentityName   string `json:"identity_name"`
+			ToolName       string `json:"tool_name"`
+			Decision       string `json:"decision"`
+			Reason         string `json:"reason"`
+			ScanDetections int    `json:"scan_detections"`
+			ScanAction     string `json:"scan_action"`
+			ScanTypes      string `json:"scan_types"`
+		} `json:"records"`
+	}
+	q := url.Values{
+		"start": {now.Add(-statusRecentWindow).UTC().Format(time.RFC3339)},
+		"end":   {now.UTC().Format(time.RFC3339)},
+		"limit": {"1000"},
+	}
+	if err := c.getJSON(ctx, "/admin/api/audit?"+q.Encode(), &auditResp); err != nil {
+		return snap, nil
+	}
+	records := auditResp.Records
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Timestamp > records[j].Timestamp })
+	minuteAgo := now.Add(-time.Minute)
+	for _, r := range records {
+		ts, _ := time.Parse(time.RFC3339, r.Timestamp)
+		denied := r.Decision == "deny" || r.Decision == "blocked"
+		if !ts.Before(minuteAgo) {
+			snap.LastMinute.Requests++
+			if denied {
+				snap.LastMinute.Denied++
+			}
+		}
+		identity := r.IdentityName
+		if identity == "" {
+			identity = r.IdentityID
+		}
+		ev := statusEvent{Timestamp: r.Timestamp, Identity: identity, Tool: r.ToolName, Decision: r.Decision, Reason: r.Reason}
+		if denied && len(snap.RecentDenials) < statusRecentLimit {
+			snap.RecentDenials = append(snap.RecentDenials, ev)
+		}
+		if r.ScanDetections > 0 && len(snap.RecentDetections) < statusRecentLimit {
+			ev.ScanAction, ev.ScanTypes = r.ScanAction, r.ScanTypes
+			snap.RecentDetections = append(snap.RecentDetections, ev)
+		}
+	}
+	snap.LastMinute.PerSecond = float64(snap.LastMinute.Requests) / 60
+	return snap, nil
+}
+
+func writeStatus(w io.Writer, snap *statusSnapshot, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(w)
+		if !statusWatch {
+			enc.SetIndent("", "  ")
+		}
+		return enc.Encode(snap)
+	}
+	printStatus(w, snap)
+	return nil
+}
+
+func printStatus(w io.Writer, s *statusSnapshot) {
+	version := ""
+	if s.Version != "" {
+		version = "  v" + strings.TrimPrefix(s.Version, "v")
+	}
+	fmt.Fprintf(w, "SentinelGate %s%s  %s  %s\n", s.Server, version, strings.ToUpper(s.Health), s.CheckedAt.Local().Format("15:04:05"))
+	if s.Health != "healthy" {
+		keys := make([]string, 0, len(s.Checks))
+		for k := range s.Checks {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(w, "  %-12s %s\n", k, s.Checks[k])
+		}
+	}
+
+	fmt.Fprintf(w, "\nUPSTREAMS (%d)\n", len(s.Upstreams))
+	for _, u := range s.Upstreams {
+		st := u.Status
+		if !u.Enabled {
+			st = "disabled"
+		}
+		line := fmt.Sprintf("  %-24s %-6s %-12s %3d tools", u.Name, u.Type, st, u.ToolCount)
+		if u.LastError != "" {
+			line += "  " + truncateStatus(u.LastError, 60)
+		}
+		fmt.Fprintln(w, line)
+	}
+
+	fmt.Fprintf(w, "\nSESSIONS (%d)\n", len(s.Sessions))
+	for _, sess := range s.Sessions {
+		name := sess.IdentityName
+		if name == "" {
+			name = sess.IdentityID
+		}
+		last := "-"
+		if sess.LastCallAt != "" {
+			last = sess.LastCallAt
+		}
+		fmt.Fprintf(w, "  %-24s %-20s %6d calls  last %s\n", truncateStatus(sess.SessionID, 24), truncateStatus(name, 20), sess.TotalCalls, last)
+	}
+
+	fmt.Fprintf(w, "\nREQUESTS\n")
+	fmt.Fprintf(w, "  last minute  %d (%.1f/s), %d denied\n", s.LastMinute.Requests, s.LastMinute.PerSecond, s.LastMinute.Denied)
+	t := s.Totals
+	fmt.Fprintf(w, "  since start  allowed %d  denied %d  blocked %d  rate-limited %d  warned %d  errors %d\n",
+		t.Allowed, t.Denied, t.Blocked, t.RateLimited, t.Warned, t.Errors)
+
+	fmt.Fprintf(w, "\nRECENT DENIALS\n")
+	if len(s.RecentDenials) == 0 {
+		fmt.Fprintf(w, "  none in the last %s\n", statusRecentWindow)
+	}
+	for _, e := range s.RecentDenials {
+		fmt.Fprintf(w, "  %s  %-20s %-24s %s\n", statusClock(e.Timestamp), truncateStatus(e.Identity, 20), truncateStatus(e.Tool, 24), truncateStatus(e.Reason, 60))
+	}
+
+	fmt.Fprintf(w, "\nSCAN DETECTIONS\n")
+	if len(s.RecentDetections) == 0 {
+		fmt.Fprintf(w, "  none in the last %s\n", statusRecentWindow)
+	}
+	for _, e := range s.RecentDetections {
+		fmt.Fprintf(w, "  %s  %-20s %-24s %s (%s)\n", statusClock(e.Timestamp), truncateStatus(e.Identity, 20), truncateStatus(e.Tool, 24), e.ScanTypes, e.ScanAction)
+	}
+}
+
+// statusClock formats an RFC3339 timestamp as local wall-clock time.
+func statusClock(ts string) string {
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return ts
+	}
+	return t.Local().Format("15:04:05")
+}
+
+func truncateStatus(s string, n int) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-3] + "..."
+}
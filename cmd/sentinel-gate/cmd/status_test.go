@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatusBaseURL(t *testing.T) {
+	tests := []struct {
+		addr string
+		want string
+	}{
+		{"127.0.0.1:8080", "http://127.0.0.1:8080"},
+		{":9090", "http://127.0.0.1:9090"},
+		{"0.0.0.0:8080", "http://127.0.0.1:8080"},
+		{"[::]:8080", "http://127.0.0.1:8080"},
+		{"localhost:8080", "http://localhost:8080"},
+		{"https://gate.local:8443/", "https://gate.local:8443"},
+	}
+	for _, tt := range tests {
+		got, err := statusBaseURL(tt.addr)
+		if err != nil {
+			t.Errorf("statusBaseURL(%q): %v", tt.addr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("statusBaseURL(%q) = %q, want %q", tt.addr, got, tt.want)
+		}
+	}
+	if _, err := statusBaseURL("no-port"); err == nil {
+		t.Error("statusBaseURL(no-port) succeeded, want error")
+	}
+}
+
+func TestStatusClient_Collect(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	responses := map[string]string{
+		"/health":                       `{"status":"healthy","checks":{"audit":"ok"},"version":"1.4.0"}`,
+		"/admin/api/upstreams":          `[{"id":"2","name":"github","type":"http","enabled":true,"status":"error","last_error":"connection refused","tool_count":0},{"id":"1","name":"fs","type":"stdio","enabled":true,"status":"connected","tool_count":12}]`,
+		"/admin/api/v1/sessions/active": `[{"session_id":"s-1","identity_id":"id-1","identity_name":"claude","total_calls":42,"started_at":"2026-03-02T09:00:00Z","last_call_at":"2026-03-02T09:59:50Z"}]`,
+		"/admin/api/stats":              `{"upstreams":2,"allowed":100,"denied":7,"blocked":1,"rate_limited":2,"warned":0,"errors":3}`,
+		"/admin/api/audit": `{"records":[
+			{"timestamp":"2026-03-02T09:59:30Z","identity_name":"claude","tool_name":"read_file","decision":"allow"},
+			{"timestamp":"2026-03-02T09:59:40Z","identity_name":"claude","tool_name":"delete_file","decision":"deny","reason":"blocked by rule"},
+			{"timestamp":"2026-03-02T09:50:00Z","identity_id":"id-2","tool_name":"fetch","decision":"allow","scan_detections":1,"scan_action":"mask","scan_types":"email"}
+		],"count":3}`,
+	}
+	var auditQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Path == "/admin/api/audit" {
+			auditQuery = r.URL.RawQuery
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	client := &statusClient{base: srv.URL, http: srv.Client(), now: func() time.Time { return now }}
+	snap, err := client.collect(context.Background())
+	if err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+
+	if snap.Health != "healthy" || snap.Version != "1.4.0" {
+		t.Errorf("health = %q version = %q", snap.Health, snap.Version)
+	}
+	if len(snap.Upstreams) != 2 || snap.Upstreams[0].Name != "fs" {
+		t.Errorf("upstreams = %+v, want sorted by name", snap.Upstreams)
+	}
+	if len(snap.Sessions) != 1 || snap.Sessions[0].TotalCalls != 42 {
+		t.Errorf("sessions = %+v", snap.Sessions)
+	}
+	if snap.Totals.Denied != 7 || snap.Totals.Allowed != 100 {
+		t.Errorf("totals = %+v", snap.Totals)
+	}
+	if snap.LastMinute.Requests != 2 || snap.LastMinute.Denied != 1 {
+		t.Errorf("last minute = %+v, want 2 requests, 1 denied", snap.LastMinute)
+	}
+	if len(snap.RecentDenials) != 1 || snap.RecentDenials[0].Tool != "delete_file" {
+		t.Errorf("recent denials = %+v", snap.RecentDenials)
+	}
+	if len(snap.RecentDetections) != 1 || snap.RecentDetections[0].Identity != "id-2" || snap.RecentDetections[0].ScanTypes != "email" {
+		t.Errorf("recent detections = %+v", snap.RecentDetections)
+	}
+	if !strings.Contains(auditQuery, "start=2026-03-02T09%3A45%3A00Z") {
+		t.Errorf("audit query = %q, want start 15 minutes back", auditQuery)
+	}
+
+	var out bytes.Buffer
+	printStatus(&out, snap)
+	for _, want := range []string{"HEALTHY", "UPSTREAMS (2)", "connection refused", "SESSIONS (1)", "2 (0.0/s), 1 denied", "blocked by rule", "email (mask)"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestStatusClient_Unreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	base := srv.URL
+	srv.Close()
+
+	client := &statusClient{base: base, http: &http.Client{Timeout: time.Second}}
+	if _, err := client.collect(context.Background()); err == nil || !strings.Contains(err.Error(), "Is the server running?") {
+		t.Errorf("collect error = %v, want unreachable error", err)
+	}
+}
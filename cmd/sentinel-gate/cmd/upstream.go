@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/config"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/upstream"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+var (
+	upstreamCheckJSON             bool
+	upstreamCheckTimeout          time.Duration
+	upstreamCheckLatencyThreshold time.Duration
+)
+
+var upstreamCmd = &cobra.Command{
+	Use:   "upstream",
+	Short: "Inspect and connect upstream MCP servers",
+}
+
+var upstreamCheckCmd = &cobra.Command{
+	Use:   "check <id|name|url|command> [args...]",
+	Short: "Run a protocol conformance suite against an upstream MCP server",
+	Long: `Connect to an upstream MCP server and check that it follows the protocol
+before putting it behind the gate.
+
+The target is an upstream ID or name from state.json, an http(s) URL, or a
+command (with its arguments) to launch as a stdio server. Flags must come
+before the target; everything after it is passed to the command.
+
+Checks:
+  initialize       handshake result, protocol version, server info
+  tools/list       listing succeeds (following pagination)
+  tool schemas     names and inputSchema are valid
+  ping, latency    ping support and round-trip time
+  unknown method   answered with -32601
+  invalid params   tools/call without a name answered with -32602
+  unknown tool     calling a nonexistent tool is an error
+  malformed input  invalid JSON is rejected and the server keeps running
+
+No real tool is ever called. Exits with status 1 when any check fails.
+
+Examples:
+  sentinel-gate upstream check filesystem
+  sentinel-gate upstream check https://mcp.example.com/mcp
+  sentinel-gate upstream check --json npx -y @modelcontextprotocol/server-filesystem /tmp`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runUpstreamCheck,
+}
+
+func init() {
+	upstreamCheckCmd.Flags().BoolVar(&upstreamCheckJSON, "json", false, "Print the report as JSON")
+	upstreamCheckCmd.Flags().DurationVar(&upstreamCheckTimeout, "timeout", 10*time.Second, "Timeout for each request")
+	upstreamCheckCmd.Flags().DurationVar(&upstreamCheckLatencyThreshold, "latency-threshold", time.Second, "Warn when a round trip takes longer than this")
+	// Flags after the target belong to the upstream command.
+	upstreamCheckCmd.Flags().SetInterspersed(false)
+	upstreamCmd.AddCommand(upstreamCheckCmd)
+	rootCmd.AddCommand(upstreamCmd)
+}
+
+func runUpstreamCheck(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfigRaw()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	cfg.SetDefaults()
+
+	u, err := resolveCheckTarget(cfg, args)
+	if err != nil {
+		return err
+	}
+	_, restoreDNS, err := useDNS(cfg.DNS, nil)
+	if err != nil {
+		return err
+	}
+	defer restoreDNS()
+	egressTLS, err := newEgressTLSPolicy(cfg, nil)
+	if err != nil {
+		return err
+	}
+	client, err := defaultClientFactory(cfg, nil, egressTLS, nil)(u)
+	if err != nil {
+		return fmt.Errorf("create client for %s: %w", u.Name, err)
+	}
+
+	report := service.RunUpstreamConformance(context.Background(), client, u.Name, service.ConformanceOptions{
+		RequestTimeout:   upstreamCheckTimeout,
+		LatencyThreshold: upstreamCheckLatencyThreshold,
+	})
+
+	out := cmd.OutOrStdout()
+	if upstreamCheckJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		printConformanceReport(out, report)
+	}
+
+	if !report.OK() {
+		os.Exit(1)
+	}
+	return nil
+}
+
+// resolveCheckTarget turns the check arguments into an upstream: a single
+// argument matching an upstream ID or name in state.json selects it, a URL is
+// checked over HTTP, anything else is launched as a stdio command.
+func resolveCheckTarget(cfg *config.OSSConfig, args []string) (*upstream.Upstream, error) {
+	if len(args) == 1 {
+		statePath := stateFilePath
+		if statePath == "" {
+			statePath = os.Getenv("SENTINEL_GATE_STATE_PATH")
+		}
+		if statePath == "" {
+			statePath = "./state.json"
+		}
+		logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+		store, closeStore, err := openStateStore(context.Background(), cfg, statePath, logger)
+		if err != nil {
+			return nil, err
+		}
+		defer closeStore()
+		// Credentials can only be decrypted with the gateway's key; without
+		// one the upstream is checked without them.
+		withCredentials := false
+		if key, _, _, err := loadCredentialsKey(cfg, statePath, false); err == nil {
+			if c, err := state.NewSecretCipher(key); err == nil {
+				store.SetSecretCipher(c)
+				withCredentials = true
+			}
+		}
+		appState, err := store.Load()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load state: %w", err)
+		}
+		entries := appState.Upstreams
+		if len(entries) == 0 && cfg.HasYAMLUpstream() {
+			entries = append(entries, migrateYAMLUpstream(cfg))
+		}
+		for _, e := range entries {
+			if e.ID == args[0] || e.Name == args[0] {
+				if !withCredentials {
+					e.Credentials = nil
+				}
+				return &upstream.Upstream{
+					ID:          e.ID,
+					Name:        e.Name,
+					Type:        upstream.UpstreamType(e.Type),
+					Command:     e.Command,
+					Args:        e.Args,
+					URL:         e.URL,
+					Spec:        e.Spec,
+					Headers:     e.Headers,
+					Env:         e.Env,
+					Credentials: service.UpstreamCredentialsFromEntry(e.Credentials),
+				}, nil
+			}
+		}
+		if strings.HasPrefix(args[0], "http://") || strings.HasPrefix(args[0], "https://") {
+			return &upstream.Upstream{Name: args[0], Type: upstream.UpstreamTypeHTTP, URL: args[0]}, nil
+		}
+	}
+	return &upstream.Upstream{
+		Name:    strings.Join(args, " "),
+		Type:    upstream.UpstreamTypeStdio,
+		Command: args[0],
+		Args:    args[1:],
+	}, nil
+}
+
+// printConformanceReport writes a human-readable conformance report.
+func printConformanceReport(w io.Writer, r *service.ConformanceReport) {
+	fmt.Fprintf(w, "Upstream: %s\n", r.Target)
+	if r.ServerName != "" {
+		fmt.Fprintf(w, "Server:   %s %s\n", r.ServerName, r.ServerVersion)
+	}
+	if r.ProtocolVersion != "" {
+		fmt.Fprintf(w, "Protocol: %s\n", r.ProtocolVersion)
+	}
+	fmt.Fprintln(w)
+
+	for _, c := range r.Checks {
+		line := fmt.Sprintf("  %-5s %-16s", strings.ToUpper(c.Status), c.Name)
+		if c.LatencyMs > 0 {
+			line += fmt.Sprintf(" %5dms", c.LatencyMs)
+		} else {
+			line += "        "
+		}
+		if c.Detail != "" {
+			line += "  " + c.Detail
+		}
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+
+	verdict := "PASS"
+	if !r.OK() {
+		verdict = "FAIL"
+	}
+	fmt.Fprintf(w, "\n%s: %d passed, %d warnings, %d failed (%dms)\n",
+		verdict, r.Passed, r.Warnings, r.Failed, r.DurationMs)
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	mcpclient "github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/mcp"
+	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
+)
+
+var (
+	upstreamConnectGateway     string
+	upstreamConnectToken       string
+	upstreamConnectConnections int
+	upstreamConnectCAFile      string
+	upstreamConnectFraming     string
+)
+
+var upstreamConnectCmd = &cobra.Command{
+	Use:   "connect --gateway <url> -- <command> [args...]",
+	Short: "Register a local MCP server with a gateway as a reverse upstream",
+	Long: `Run next to an MCP server the gateway cannot reach (behind NAT or a
+firewall) and register it as a reverse upstream. The connector dials out to
+the gateway over WebSocket with the upstream's registration token, so no
+inbound port has to be opened.
+
+Create the upstream first with type "reverse" in the admin UI or API; the
+registration token is shown once. Pass it with --token or the
+SENTINEL_GATE_REGISTRATION_TOKEN environment variable.
+
+The server command is started each time the gateway starts using a
+connection, and stopped when the connection ends. The connector reconnects
+with backoff while the gateway is unreachable and exits when the gateway
+rejects the token.
+
+Examples:
+  sentinel-gate upstream connect --gateway wss://gate.example.com/upstream/register \
+    -- npx -y @modelcontextprotocol/server-filesystem /srv/data`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runUpstreamConnect,
+}
+
+func init() {
+	upstreamConnectCmd.Flags().StringVar(&upstreamConnectGateway, "gateway", "", "Gateway registration URL (ws:// or wss://.../upstream/register)")
+	upstreamConnectCmd.Flags().StringVar(&upstreamConnectToken, "token", "", "Registration token (default: $SENTINEL_GATE_REGISTRATION_TOKEN)")
+	upstreamConnectCmd.Flags().IntVar(&upstreamConnectConnections, "connections", 2, "Connections to keep registered with the gateway")
+	upstreamConnectCmd.Flags().StringVar(&upstreamConnectCAFile, "ca-file", "", "CA certificate PEM file to verify a wss:// gateway")
+	upstreamConnectCmd.Flags().StringVar(&upstreamConnectFraming, "framing", "", "Server stdio framing: auto, newline or content-length")
+	_ = upstreamConnectCmd.MarkFlagRequired("gateway")
+	// Flags after the command belong to the server.
+	upstreamConnectCmd.Flags().SetInterspersed(false)
+	upstreamCmd.AddCommand(upstreamConnectCmd)
+}
+
+func runUpstreamConnect(cmd *cobra.Command, args []string) error {
+	token := upstreamConnectToken
+	if token == "" {
+		token = os.Getenv("SENTINEL_GATE_REGISTRATION_TOKEN")
+	}
+	framing, err := mcp.ParseFraming(upstreamConnectFraming)
+	if err != nil {
+		return err
+	}
+	cfg := mcpclient.ReverseConnectorConfig{
+		GatewayURL:  upstreamConnectGateway,
+		Token:       token,
+		Connections: upstreamConnectConnections,
+		Command:     args[0],
+		Args:        args[1:],
+		Framing:     framing,
+	}
+	if upstreamConnectCAFile != "" {
+		pem, err := os.ReadFile(upstreamConnectCAFile)
+		if err != nil {
+			return fmt.Errorf("read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates in CA file %s", upstreamConnectCAFile)
+		}
+		cfg.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12, RootCAs: pool}
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	connector, err := mcpclient.NewReverseConnector(cfg, logger)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	logger.Info("registering with the gateway", "gateway", upstreamConnectGateway, "connections", upstreamConnectConnections)
+	if err := connector.Run(ctx); err != nil {
+		if errors.Is(err, mcpclient.ErrRegistrationRejected) {
+			cmd.SilenceUsage = true
+		}
+		return err
+	}
+	return nil
+}
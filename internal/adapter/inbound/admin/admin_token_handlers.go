@@ -0,0 +1,256 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+// WithAdminTokenService sets the service managing the scoped admin API tokens.
+func WithAdminTokenService(s *service.AdminTokenService) AdminAPIOption {
+	return func(h *AdminAPIHandler) { h.adminTokens = s }
+}
+
+// createAdminTokenRequest is the JSON body of POST /admin/api/admin-tokens.
+type createAdminTokenRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// createAdminTokenResponse is returned on creation. The cleartext token is
+// returned exactly once and never stored.
+type createAdminTokenResponse struct {
+	service.AdminToken
+	Token string `json:"token"`
+}
+
+// handleListAdminTokens returns all admin tokens.
+// GET /admin/api/admin-tokens
+func (h *AdminAPIHandler) handleListAdminTokens(w http.ResponseWriter, r *http.Request) {
+	if h.adminTokens == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "admin tokens not configured")
+		return
+	}
+	h.respondJSON(w, http.StatusOK, h.adminTokens.List())
+}
+
+// handleCreateAdminToken creates an admin token.
+// POST /admin/api/admin-tokens
+func (h *AdminAPIHandler) handleCreateAdminToken(w http.ResponseWriter, r *http.Request) {
+	if h.adminTokens == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "admin tokens not configured")
+		return
+	}
+	var req createAdminTokenRequest
+	if err := h.readJSON(r, &req); err != nil {
+		h.handleReadJSONErr(w, err)
+		return
+	}
+
+	token, cleartext, err := h.adminTokens.Create(r.Context(), service.CreateAdminTokenInput{
+		Name:      req.Name,
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidAdminTokenInput) {
+			h.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		// Only log the error, never the cleartext token.
+		h.logger.Error("failed to create admin token", "error", err)
+		h.respondError(w, http.StatusInternalServerError, "failed to create admin token")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	h.respondJSON(w, http.StatusCreated, createAdminTokenResponse{AdminToken: *token, Token: cleartext})
+}
+
+// handleRevokeAdminToken revokes an admin token, or deletes it when it is
+// already revoked.
+// DELETE /admin/api/admin-tokens/{id}
+func (h *AdminAPIHandler) handleRevokeAdminToken(w http.ResponseWriter, r *http.Request) {
+	if h.adminTokens == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "admin tokens not configured")
+		return
+	}
+	id := h.pathParam(r, "id")
+	if err := h.adminTokens.Revoke(r.Context(), id); err != nil {
+		if errors.Is(err, service.ErrAdminTokenNotFound) {
+			h.respondError(w, http.StatusNotFound, "admin token not found")
+			return
+		}
+		h.logger.Error("failed to revoke admin token", "error", err, "id", id)
+		h.respondError(w, http.StatusInternalServerError, "failed to revoke admin token")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// bearerAdminToken returns the admin token in the Authorization header, or
+// "" when the request carries none. MCP API keys are not admin tokens.
+func bearerAdminToken(r *http.Request) string {
+	value := r.Header.Get("Authorization")
+	if len(value) < len("Bearer ") || !strings.EqualFold(value[:len("Bearer ")], "Bearer ") {
+		return ""
+	}
+	token := strings.TrimSpace(value[len("Bearer "):])
+	if !strings.HasPrefix(token, service.AdminTokenPrefix) {
+		return ""
+	}
+	return token
+}
+
+// Admin API paths that the write scopes may read and change.
+var (
+	policyPathPrefixes   = []string{"/admin/api/policies", "/admin/api/policy-variables"}
+	upstreamPathPrefixes = []string{"/admin/api/upstreams"}
+)
+
+// adminTokenReadScopes is the scope an admin token needs for each GET route
+// of the admin API. Routes returning tool calls, their arguments, sessions or
+// denials need audit:read; routes not listed are refused to admin tokens, so
+// a new route stays closed until it is classified here.
+var adminTokenReadScopes = map[string]string{
+	"GET /admin/api/upstreams":                                service.AdminScopeReadOnly,
+	"GET /admin/api/tools":                                    service.AdminScopeReadOnly,
+	"GET /admin/api/tools/memory":                             service.AdminScopeReadOnly,
+	"GET /admin/api/tools/stats/top":                          service.AdminScopeReadOnly,
+	"GET /admin/api/tools/{name}/stats":                       service.AdminScopeReadOnly,
+	"GET /admin/api/jobs":                                     service.AdminScopeReadOnly,
+	"GET /admin/api/jobs/{name}":                              service.AdminScopeReadOnly,
+	"GET /admin/api/policies":                                 service.AdminScopeReadOnly,
+	"GET /admin/api/policies/schema":                          service.AdminScopeReadOnly,
+	"GET /admin/api/policies/{id}/versions":                   service.AdminScopeReadOnly,
+	"GET /admin/api/policy-variables":                         service.AdminScopeReadOnly,
+	"GET /admin/api/policy-variables/{name}":                  service.AdminScopeReadOnly,
+	"GET /admin/api/rate-limits/overrides":                    service.AdminScopeReadOnly,
+	"GET /admin/api/rate-limits/exemptions":                   service.AdminScopeReadOnly,
+	"GET /admin/api/notice":                                   service.AdminScopeReadOnly,
+	"GET /admin/api/identities":                               service.AdminScopeReadOnly,
+	"GET /admin/api/keys":                                     service.AdminScopeReadOnly,
+	"GET /admin/api/v1/policy/evaluate/{request_id}/status":   service.AdminScopeReadOnly,
+	"GET /admin/api/v1/approvals":                             service.AdminScopeReadOnly,
+	"GET /admin/api/v1/approvals/{id}/context":                service.AdminScopeAuditRead,
+	"GET /admin/api/v1/approvals/grants":                      service.AdminScopeReadOnly,
+	"GET /admin/api/v1/security/content-scanning":             service.AdminScopeReadOnly,
+	"GET /admin/api/v1/security/detectors":                    service.AdminScopeReadOnly,
+	"GET /admin/api/v1/security/input-scanning":               service.AdminScopeReadOnly,
+	"GET /admin/api/v1/security/argument-scanning":            service.AdminScopeReadOnly,
+	"GET /admin/api/v1/tools/baseline":                        service.AdminScopeReadOnly,
+	"GET /admin/api/v1/tools/drift":                           service.AdminScopeReadOnly,
+	"GET /admin/api/v1/tools/quarantine":                      service.AdminScopeReadOnly,
+	"GET /admin/api/v1/tools/response-guard":                  service.AdminScopeReadOnly,
+	"GET /admin/api/v1/templates":                             service.AdminScopeReadOnly,
+	"GET /admin/api/v1/templates/{id}":                        service.AdminScopeReadOnly,
+	"GET /admin/api/v1/quotas":                                service.AdminScopeReadOnly,
+	"GET /admin/api/v1/quotas/{identity_id}":                  service.AdminScopeReadOnly,
+	"GET /admin/api/quotas":                                   service.AdminScopeReadOnly,
+	"GET /admin/api/v1/sessions/active":                       service.AdminScopeAuditRead,
+	"GET /admin/api/v1/deny-loops":                            service.AdminScopeAuditRead,
+	"GET /admin/api/v1/agents/{identity_id}/summary":          service.AdminScopeAuditRead,
+	"GET /admin/api/v1/compliance/packs":                      service.AdminScopeReadOnly,
+	"GET /admin/api/v1/compliance/packs/{id}":                 service.AdminScopeReadOnly,
+	"GET /admin/api/v1/compliance/evidence":                   service.AdminScopeReadOnly,
+	"GET /admin/api/v1/drift/reports":                         service.AdminScopeReadOnly,
+	"GET /admin/api/v1/drift/config":                          service.AdminScopeReadOnly,
+	"GET /admin/api/v1/drift/profiles/{identity_id}":          service.AdminScopeReadOnly,
+	"GET /admin/api/v1/permissions/health":                    service.AdminScopeReadOnly,
+	"GET /admin/api/v1/permissions/health/{identity_id}":      service.AdminScopeReadOnly,
+	"GET /admin/api/v1/permissions/suggestions/{identity_id}": service.AdminScopeReadOnly,
+	"GET /admin/api/v1/permissions/config":                    service.AdminScopeReadOnly,
+	"GET /admin/api/v1/outbound/learning":                     service.AdminScopeReadOnly,
+	"GET /admin/api/v1/telemetry/config":                      service.AdminScopeReadOnly,
+	"GET /admin/api/v1/namespaces/config":                     service.AdminScopeReadOnly,
+	"GET /admin/api/v1/transforms":                            service.AdminScopeReadOnly,
+	"GET /admin/api/v1/transforms/{id}":                       service.AdminScopeReadOnly,
+	"GET /admin/api/v1/recordings/config":                     service.AdminScopeAuditRead,
+	"GET /admin/api/v1/recordings/{id}/events":                service.AdminScopeAuditRead,
+	"GET /admin/api/v1/recordings/{id}/export":                service.AdminScopeAuditRead,
+	"GET /admin/api/v1/recordings/{id}":                       service.AdminScopeAuditRead,
+	"GET /admin/api/v1/recordings":                            service.AdminScopeAuditRead,
+	"GET /admin/api/v1/notifications":                         service.AdminScopeAuditRead,
+	"GET /admin/api/v1/notifications/count":                   service.AdminScopeReadOnly,
+	"GET /admin/api/v1/notifications/stream":                  service.AdminScopeAuditRead,
+	"GET /admin/api/v1/redteam/corpus":                        service.AdminScopeReadOnly,
+	"GET /admin/api/v1/redteam/reports":                       service.AdminScopeAuditRead,
+	"GET /admin/api/v1/redteam/reports/{id}":                  service.AdminScopeAuditRead,
+	"GET /admin/api/v1/agents/{identity_id}/health":           service.AdminScopeAuditRead,
+	"GET /admin/api/v1/health/overview":                       service.AdminScopeReadOnly,
+	"GET /admin/api/v1/health/config":                         service.AdminScopeReadOnly,
+	"GET /admin/api/v1/finops/costs":                          service.AdminScopeReadOnly,
+	"GET /admin/api/v1/finops/costs/{identity_id}":            service.AdminScopeReadOnly,
+	"GET /admin/api/v1/finops/budgets":                        service.AdminScopeReadOnly,
+	"GET /admin/api/v1/finops/config":                         service.AdminScopeReadOnly,
+	"GET /admin/api/costs":                                    service.AdminScopeReadOnly,
+	"GET /admin/api/mcp-methods":                              service.AdminScopeReadOnly,
+	"GET /admin/api/upstream-notifications":                   service.AdminScopeReadOnly,
+	"GET /admin/api/stats":                                    service.AdminScopeReadOnly,
+	"GET /admin/api/slo":                                      service.AdminScopeReadOnly,
+	"GET /admin/api/slo/alerts":                               service.AdminScopeReadOnly,
+	"GET /admin/api/webhooks":                                 service.AdminScopeReadOnly,
+	"GET /admin/api/webhooks/{name}/deliveries":               service.AdminScopeReadOnly,
+	"GET /admin/api/events/sinks":                             service.AdminScopeReadOnly,
+	"GET /admin/api/system":                                   service.AdminScopeReadOnly,
+	"GET /admin/api/audit":                                    service.AdminScopeAuditRead,
+	"GET /admin/api/audit/stream":                             service.AdminScopeAuditRead,
+	"GET /admin/api/audit/export":                             service.AdminScopeAuditRead,
+	"GET /admin/api/audit/storage":                            service.AdminScopeAuditRead,
+	"GET /admin/api/audit/schema":                             service.AdminScopeAuditRead,
+	"GET /admin/api/audit/payloads":                           service.AdminScopeAuditRead,
+}
+
+// adminTokenReadMux matches a request to its pattern in adminTokenReadScopes
+// with the routing rules of the admin API.
+var adminTokenReadMux = sync.OnceValue(func() *http.ServeMux {
+	mux := http.NewServeMux()
+	for pattern := range adminTokenReadScopes {
+		mux.Handle(pattern, http.NotFoundHandler())
+	}
+	return mux
+})
+
+// adminTokenAllows reports whether token may make a request with method to
+// path:
+//   - read-only and audit:read read the routes adminTokenReadScopes gives
+//     them; audit:read covers the audit log, recordings, sessions, denials
+//     and the agent activity built from them;
+//   - policies:write and upstreams:write read and change their own paths.
+//
+// Admin tokens are managed from localhost only, and every other change
+// (identities, keys, system settings, factory reset) needs localhost too.
+func adminTokenAllows(token *service.AdminToken, method, path string) bool {
+	if hasPathPrefix(path, "/admin/api/admin-tokens") {
+		return false
+	}
+	if hasPathPrefix(path, policyPathPrefixes...) && token.HasScope(service.AdminScopePoliciesWrite) {
+		return true
+	}
+	if hasPathPrefix(path, upstreamPathPrefixes...) && token.HasScope(service.AdminScopeUpstreamsWrite) {
+		return true
+	}
+	if method != http.MethodGet && method != http.MethodHead {
+		return false
+	}
+	_, pattern := adminTokenReadMux().Handler(&http.Request{Method: method, Host: "localhost", URL: &url.URL{Path: path}})
+	scope, ok := adminTokenReadScopes[pattern]
+	return ok && token.HasScope(scope)
+}
+
+// hasPathPrefix reports whether path is one of prefixes or below one.
+func hasPathPrefix(path string, prefixes ...string) bool {
+	for _, p := range prefixes {
+		if path == p || strings.HasPrefix(path, p+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,178 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"go/ast"
+	"go/parser"
+	gotoken "go/token"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+func TestAdminTokenAllows(t *testing.T) {
+	tests := []struct {
+		scopes []string
+		method string
+		path   string
+		want   bool
+	}{
+		{[]string{service.AdminScopeReadOnly}, http.MethodGet, "/admin/api/upstreams", true},
+		{[]string{service.AdminScopeReadOnly}, http.MethodDelete, "/admin/api/upstreams/u1", false},
+		{[]string{service.AdminScopeReadOnly}, http.MethodGet, "/admin/api/audit", false},
+		{[]string{service.AdminScopeReadOnly}, http.MethodGet, "/admin/api/v1/recordings/r1", false},
+		{[]string{service.AdminScopeReadOnly}, http.MethodGet, "/admin/api/v1/approvals/a1/context", false},
+		{[]string{service.AdminScopeReadOnly}, http.MethodGet, "/admin/api/v1/deny-loops", false},
+		{[]string{service.AdminScopeReadOnly}, http.MethodGet, "/admin/api/v1/sessions/active", false},
+		{[]string{service.AdminScopeReadOnly}, http.MethodGet, "/admin/api/v1/notifications/stream", false},
+		{[]string{service.AdminScopeReadOnly}, http.MethodGet, "/admin/api/v1/redteam/reports", false},
+		{[]string{service.AdminScopeReadOnly}, http.MethodHead, "/admin/api/v1/approvals", true},
+		{[]string{service.AdminScopeReadOnly}, http.MethodGet, "/admin/api/v1/unknown", false},
+		{[]string{service.AdminScopeAuditRead}, http.MethodGet, "/admin/api/v1/approvals/a1/context", true},
+		{[]string{service.AdminScopeAuditRead}, http.MethodGet, "/admin/api/v1/sessions/active", true},
+		{[]string{service.AdminScopeAuditRead}, http.MethodGet, "/admin/api/audit/export", true},
+		{[]string{service.AdminScopeAuditRead}, http.MethodGet, "/admin/api/policies", false},
+		{[]string{service.AdminScopePoliciesWrite}, http.MethodGet, "/admin/api/policies", true},
+		{[]string{service.AdminScopePoliciesWrite}, http.MethodPut, "/admin/api/policies/p1", true},
+		{[]string{service.AdminScopePoliciesWrite}, http.MethodPut, "/admin/api/policy-variables/env", true},
+		{[]string{service.AdminScopePoliciesWrite}, http.MethodDelete, "/admin/api/upstreams/u1", false},
+		{[]string{service.AdminScopePoliciesWrite}, http.MethodGet, "/admin/api/policiesx", false},
+		{[]string{service.AdminScopeUpstreamsWrite}, http.MethodDelete, "/admin/api/upstreams/u1", true},
+		{[]string{service.AdminScopeReadOnly, service.AdminScopeUpstreamsWrite}, http.MethodPost, "/admin/api/identities", false},
+		{service.AdminTokenScopes, http.MethodGet, "/admin/api/admin-tokens", false},
+		{service.AdminTokenScopes, http.MethodPost, "/admin/api/system/factory-reset", false},
+	}
+	for _, tt := range tests {
+		token := &service.AdminToken{Scopes: tt.scopes}
+		if got := adminTokenAllows(token, tt.method, tt.path); got != tt.want {
+			t.Errorf("adminTokenAllows(%v, %s %s) = %v, want %v", tt.scopes, tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+// TestAdminTokenReadScopes checks that every GET route of the admin API has
+// a scope for admin tokens, and that the table lists no removed route.
+func TestAdminTokenReadScopes(t *testing.T) {
+	f, err := parser.ParseFile(gotoken.NewFileSet(), "api_handler.go", nil, 0)
+	if err != nil {
+		t.Fatalf("parse api_handler.go: %v", err)
+	}
+	registered := map[string]bool{}
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "HandleFunc" {
+			return true
+		}
+		if recv, ok := sel.X.(*ast.Ident); !ok || recv.Name != "protectedMux" {
+			return true
+		}
+		lit, ok := call.Args[0].(*ast.BasicLit)
+		if !ok || lit.Kind != gotoken.STRING {
+			return true
+		}
+		pattern, _ := strconv.Unquote(lit.Value)
+		if strings.HasPrefix(pattern, "GET ") && !hasPathPrefix(strings.TrimPrefix(pattern, "GET "), "/admin/api/admin-tokens") {
+			registered[pattern] = true
+		}
+		return true
+	})
+	if len(registered) == 0 {
+		t.Fatal("found no GET routes in api_handler.go")
+	}
+	for pattern := range registered {
+		scope, ok := adminTokenReadScopes[pattern]
+		if !ok {
+			t.Errorf("route %q has no admin token scope in adminTokenReadScopes", pattern)
+			continue
+		}
+		if scope != service.AdminScopeReadOnly && scope != service.AdminScopeAuditRead {
+			t.Errorf("route %q needs scope %q, want read-only or audit:read", pattern, scope)
+		}
+	}
+	for pattern := range adminTokenReadScopes {
+		if !registered[pattern] {
+			t.Errorf("adminTokenReadScopes lists %q, which is not a GET route", pattern)
+		}
+	}
+}
+
+func TestAdminTokenAuth(t *testing.T) {
+	_, adminSvc := testPolicyHandlerEnv(t)
+	tokens := service.NewAdminTokenService(nil, slog.Default())
+	h := NewAdminAPIHandler(
+		WithPolicyAdminService(adminSvc),
+		WithAdminTokenService(tokens),
+		WithAPILogger(slog.Default()),
+	)
+	routes := h.Routes()
+	_, cleartext, err := tokens.Create(context.Background(), service.CreateAdminTokenInput{
+		Name:   "ci",
+		Scopes: []string{service.AdminScopePoliciesWrite},
+	})
+	if err != nil {
+		t.Fatalf("Create(): %v", err)
+	}
+
+	send := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.RemoteAddr = "203.0.113.7:40000"
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		routes.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := send(http.MethodGet, "/admin/api/policies", "", ""); w.Code != http.StatusForbidden {
+		t.Errorf("remote request without token: status = %d, want 403", w.Code)
+	}
+	if w := send(http.MethodGet, "/admin/api/policies", service.AdminTokenPrefix+"0000000000", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("unknown token: status = %d, want 401", w.Code)
+	}
+	if w := send(http.MethodGet, "/admin/api/policies", cleartext, ""); w.Code != http.StatusOK {
+		t.Errorf("list policies: status = %d, body: %s", w.Code, w.Body.String())
+	}
+
+	// Token requests need no CSRF token, and changes are attributed to it.
+	body := `{"name":"From CI","enabled":true,"rules":[{"name":"allow-read","priority":100,"tool_match":"read_*","condition":"true","action":"allow"}]}`
+	w := send(http.MethodPost, "/admin/api/policies", cleartext, body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create policy: status = %d, body: %s", w.Code, w.Body.String())
+	}
+	var created policyResponse
+	decodePolicyJSON(t, w.Body, &created)
+	versions, err := adminSvc.Versions(context.Background(), created.ID)
+	if err != nil || len(versions) != 1 || versions[0].ChangedBy != "token:ci" {
+		t.Errorf("Versions() = %+v, %v; want a version by token:ci", versions, err)
+	}
+
+	for _, path := range []string{"/admin/api/upstreams/u1", "/admin/api/admin-tokens/x"} {
+		if w := send(http.MethodDelete, path, cleartext, ""); w.Code != http.StatusForbidden {
+			t.Errorf("DELETE %s: status = %d, want 403", path, w.Code)
+		}
+	}
+	if w := send(http.MethodGet, "/admin/api/audit", cleartext, ""); w.Code != http.StatusForbidden {
+		t.Errorf("read audit: status = %d, want 403", w.Code)
+	}
+
+	// Without a token, localhost changes still need the CSRF token.
+	req := httptest.NewRequest(http.MethodPost, "/admin/api/admin-tokens", bytes.NewBufferString(`{}`))
+	req.RemoteAddr = "127.0.0.1:40000"
+	rec := httptest.NewRecorder()
+	routes.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("localhost POST without CSRF token: status = %d, want 403", rec.Code)
+	}
+}
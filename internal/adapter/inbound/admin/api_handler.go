@@ -11,16 +11,20 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/opa"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/action"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/denyloop"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/event"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/policy"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/proxy"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/quota"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/recording"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/session"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/transform"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/upstream"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/validation"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
 )
 
@@ -41,29 +45,44 @@ type SessionCacheInvalidator interface {
 	InvalidateByIdentity(identityID string)
 }
 
+// ReverseUpstreamHub holds the connections registered by servers of reverse
+// upstreams.
+type ReverseUpstreamHub interface {
+	// Disconnect closes every connection of an upstream.
+	Disconnect(upstreamID string)
+	// Connections returns how many connections an upstream has registered.
+	Connections(upstreamID string) int
+}
+
 // AdminAPIHandler provides JSON API endpoints for the admin interface.
 // It coexists with the legacy AdminHandler which serves the template-based UI.
 type AdminAPIHandler struct {
 	upstreamService         *service.UpstreamService
 	upstreamManager         *service.UpstreamManager
 	discoveryService        *service.ToolDiscoveryService
+	reverseHub              ReverseUpstreamHub
 	toolCache               *upstream.ToolCache
 	policyService           *service.PolicyService
 	policyStore             policy.PolicyStore
 	auditService            *service.AuditService
 	auditReader             AuditReader
+	auditStorage            audit.StorageReporter
+	payloadStore            audit.PayloadStore
 	statsService            *service.StatsService
 	identityService         *service.IdentityService
 	policyEvalService       *service.PolicyEvaluationService
 	policyAdminService      *service.PolicyAdminService
-	stateStore              *state.FileStateStore
+	stateStore              state.Store
 	approvalStore           *action.ApprovalStore
 	responseScanCtrl        ResponseScanController
 	additionalScanCtrls     []ResponseScanController
+	responseScanner         *action.ResponseScanner
 	toolSecurityService     *service.ToolSecurityService
 	templateService         *service.TemplateService
 	quotaStore              quota.QuotaStore
+	quotaDaily              *quota.DailyCounter
 	sessionTracker          *session.SessionTracker
+	denyLoops               *denyloop.Detector
 	transformStore          transform.TransformStore
 	transformExecutor       *transform.TransformExecutor
 	recordingService        *recording.FileRecorder
@@ -71,6 +90,7 @@ type AdminAPIHandler struct {
 	retentionCleaner        *recording.RetentionCleaner
 	notificationService     *service.NotificationService
 	contentScanInterceptor  *action.ContentScanInterceptor
+	argumentScanInterceptor *action.ArgumentScanInterceptor
 	complianceService       *service.ComplianceService
 	complianceCtxFn         func() service.ComplianceContext
 	simulationService       *service.SimulationService
@@ -81,6 +101,28 @@ type AdminAPIHandler struct {
 	redteamService          *service.RedTeamService
 	finopsService           *service.FinOpsService
 	healthService           *service.HealthService
+	sloService              *service.SLOService
+	webhookService          *service.WebhookService
+	eventDispatcher         *event.Dispatcher
+	toolStatsService        *service.ToolStatsService
+	outboundLearning        *service.OutboundLearningService
+	jobService              *service.JobService
+	policyVariableService   *service.PolicyVariableService
+	policyDirectory         *service.PolicyDirectory
+	regoEngine              *opa.Engine
+	rateLimitOverrides      *service.RateLimitOverrideService
+	rateLimitExemptions     *service.RateLimitExemptionService
+	rateLimitReporter       RateLimitReporter
+	adminTokens             *service.AdminTokenService
+	noticeService           *service.NoticeService
+	responseGuard           *service.ResponseGuardService
+	costAccountingService   *service.CostAccountingService
+	tlsCertInfo             func() TLSCertificateInfo // nil when serving plain HTTP
+	configReloader          func(ctx context.Context) (ConfigReloadResult, error)
+	mcpMethodPolicy         *validation.MethodPolicy
+	notificationPolicy      *proxy.NotificationPolicy
+	admission               *service.AdmissionController
+	secretDetection         string // upstream secret detection mode
 	sessionCacheInvalidator SessionCacheInvalidator
 	sessionService          *session.SessionService
 	eventBus                event.Bus
@@ -114,6 +156,11 @@ func WithDiscoveryService(s *service.ToolDiscoveryService) AdminAPIOption {
 	return func(h *AdminAPIHandler) { h.discoveryService = s }
 }
 
+// WithReverseUpstreamHub sets the hub of reverse upstream connections.
+func WithReverseUpstreamHub(hub ReverseUpstreamHub) AdminAPIOption {
+	return func(h *AdminAPIHandler) { h.reverseHub = hub }
+}
+
 // WithToolCache sets the shared tool cache.
 func WithToolCache(c *upstream.ToolCache) AdminAPIOption {
 	return func(h *AdminAPIHandler) { h.toolCache = c }
@@ -140,7 +187,7 @@ func WithAuditReader(r AuditReader) AdminAPIOption {
 }
 
 // WithStateStore sets the file state store.
-func WithStateStore(s *state.FileStateStore) AdminAPIOption {
+func WithStateStore(s state.Store) AdminAPIOption {
 	return func(h *AdminAPIHandler) { h.stateStore = s }
 }
 
@@ -264,19 +311,50 @@ func (h *AdminAPIHandler) Routes() http.Handler {
 	protectedMux.HandleFunc("PUT /admin/api/upstreams/{id}", h.handleUpdateUpstream)
 	protectedMux.HandleFunc("DELETE /admin/api/upstreams/{id}", h.handleDeleteUpstream)
 	protectedMux.HandleFunc("POST /admin/api/upstreams/{id}/restart", h.handleRestartUpstream)
+	protectedMux.HandleFunc("POST /admin/api/upstreams/{id}/registration-token", h.handleRotateRegistrationToken)
 
 	// Tool discovery.
 	protectedMux.HandleFunc("GET /admin/api/tools", h.handleListTools)
 	protectedMux.HandleFunc("POST /admin/api/tools/refresh", h.handleRefreshTools)
+	protectedMux.HandleFunc("GET /admin/api/tools/memory", h.handleGetToolCacheMemory)
+	protectedMux.HandleFunc("GET /admin/api/tools/stats/top", h.handleGetToolStatsTop)
+	protectedMux.HandleFunc("GET /admin/api/tools/{name}/stats", h.handleGetToolStats)
+
+	// Recurring jobs.
+	protectedMux.HandleFunc("GET /admin/api/jobs", h.handleListJobs)
+	protectedMux.HandleFunc("GET /admin/api/jobs/{name}", h.handleGetJob)
+	protectedMux.HandleFunc("PUT /admin/api/jobs/{name}", h.handleUpdateJob)
+	protectedMux.HandleFunc("POST /admin/api/jobs/{name}/run", h.handleRunJob)
 
 	// Policy CRUD.
 	protectedMux.HandleFunc("GET /admin/api/policies", h.handleListPolicies)
 	protectedMux.HandleFunc("POST /admin/api/policies", h.handleCreatePolicy)
 	protectedMux.HandleFunc("POST /admin/api/policies/test", h.handleTestPolicy)
 	protectedMux.HandleFunc("POST /admin/api/policies/lint", h.handleLintPolicy)
+	protectedMux.HandleFunc("POST /admin/api/policies/reachability", h.handlePolicyReachability)
+	protectedMux.HandleFunc("GET /admin/api/policies/schema", h.handlePolicySchema)
 	protectedMux.HandleFunc("PUT /admin/api/policies/{id}", h.handleUpdatePolicy)
 	protectedMux.HandleFunc("DELETE /admin/api/policies/{id}", h.handleDeletePolicy)
 	protectedMux.HandleFunc("DELETE /admin/api/policies/{id}/rules/{ruleId}", h.handleDeleteRule)
+	protectedMux.HandleFunc("GET /admin/api/policies/{id}/versions", h.handleListPolicyVersions)
+	protectedMux.HandleFunc("POST /admin/api/policies/{id}/rollback/{version}", h.handleRollbackPolicy)
+
+	// Policy variables (vars in rule conditions).
+	protectedMux.HandleFunc("GET /admin/api/policy-variables", h.handleListPolicyVariables)
+	protectedMux.HandleFunc("GET /admin/api/policy-variables/{name}", h.handleGetPolicyVariable)
+	protectedMux.HandleFunc("PUT /admin/api/policy-variables/{name}", h.handleSetPolicyVariable)
+	protectedMux.HandleFunc("DELETE /admin/api/policy-variables/{name}", h.handleDeletePolicyVariable)
+
+	// Rate limit overrides (per identity and per tool).
+	protectedMux.HandleFunc("GET /admin/api/rate-limits/overrides", h.handleListRateLimitOverrides)
+	protectedMux.HandleFunc("POST /admin/api/rate-limits/overrides", h.handleCreateRateLimitOverride)
+	protectedMux.HandleFunc("PUT /admin/api/rate-limits/overrides/{id}", h.handleUpdateRateLimitOverride)
+	protectedMux.HandleFunc("DELETE /admin/api/rate-limits/overrides/{id}", h.handleDeleteRateLimitOverride)
+	protectedMux.HandleFunc("GET /admin/api/rate-limits/exemptions", h.handleListRateLimitExemptions)
+
+	// Notice (banner and terms returned with initialize).
+	protectedMux.HandleFunc("GET /admin/api/notice", h.handleGetNotice)
+	protectedMux.HandleFunc("PUT /admin/api/notice", h.handleSetNotice)
 
 	// Identity CRUD.
 	protectedMux.HandleFunc("GET /admin/api/identities", h.handleListIdentities)
@@ -289,6 +367,11 @@ func (h *AdminAPIHandler) Routes() http.Handler {
 	protectedMux.HandleFunc("POST /admin/api/keys", h.handleGenerateKey)
 	protectedMux.HandleFunc("DELETE /admin/api/keys/{id}", h.handleRevokeKey)
 
+	// Admin API tokens (scoped automation credentials, localhost only).
+	protectedMux.HandleFunc("GET /admin/api/admin-tokens", h.handleListAdminTokens)
+	protectedMux.HandleFunc("POST /admin/api/admin-tokens", h.handleCreateAdminToken)
+	protectedMux.HandleFunc("DELETE /admin/api/admin-tokens/{id}", h.handleRevokeAdminToken)
+
 	// Policy evaluation API (SDK / runtime agent access).
 	protectedMux.HandleFunc("POST /admin/api/v1/policy/evaluate", h.handlePolicyEvaluate)
 	protectedMux.HandleFunc("GET /admin/api/v1/policy/evaluate/{request_id}/status", h.handlePolicyEvaluateStatus)
@@ -298,16 +381,28 @@ func (h *AdminAPIHandler) Routes() http.Handler {
 	protectedMux.HandleFunc("GET /admin/api/v1/approvals/{id}/context", h.handleGetApprovalContext)
 	protectedMux.HandleFunc("POST /admin/api/v1/approvals/{id}/approve", h.handleApproveRequest)
 	protectedMux.HandleFunc("POST /admin/api/v1/approvals/{id}/deny", h.handleDenyRequest)
+	protectedMux.HandleFunc("POST /admin/api/v1/approvals/{id}/delegate", h.handleDelegateApproval)
+	protectedMux.HandleFunc("GET /admin/api/v1/approvals/grants", h.handleListApprovalGrants)
+	protectedMux.HandleFunc("DELETE /admin/api/v1/approvals/grants/{id}", h.handleRevokeApprovalGrant)
 
 	// Content scanning configuration (response/output direction).
 	protectedMux.HandleFunc("GET /admin/api/v1/security/content-scanning", h.handleGetContentScanning)
 	protectedMux.HandleFunc("PUT /admin/api/v1/security/content-scanning", h.handleUpdateContentScanning)
 
+	// Custom response scanning detectors.
+	protectedMux.HandleFunc("GET /admin/api/v1/security/detectors", h.handleListScanDetectors)
+	protectedMux.HandleFunc("POST /admin/api/v1/security/detectors", h.handleCreateScanDetector)
+	protectedMux.HandleFunc("POST /admin/api/v1/security/detectors/test", h.handleTestScanDetector)
+	protectedMux.HandleFunc("PUT /admin/api/v1/security/detectors/{id}", h.handleUpdateScanDetector)
+	protectedMux.HandleFunc("DELETE /admin/api/v1/security/detectors/{id}", h.handleDeleteScanDetector)
+
 	// Input content scanning (PII/secrets in arguments — Upgrade 3).
 	protectedMux.HandleFunc("GET /admin/api/v1/security/input-scanning", h.handleGetInputScanning)
 	protectedMux.HandleFunc("PUT /admin/api/v1/security/input-scanning", h.handleUpdateInputScanning)
 	protectedMux.HandleFunc("POST /admin/api/v1/security/input-scanning/whitelist", h.handleAddWhitelist)
 	protectedMux.HandleFunc("DELETE /admin/api/v1/security/input-scanning/whitelist/{id}", h.handleRemoveWhitelist)
+	protectedMux.HandleFunc("GET /admin/api/v1/security/argument-scanning", h.handleGetArgumentScanning)
+	protectedMux.HandleFunc("PUT /admin/api/v1/security/argument-scanning", h.handleUpdateArgumentScanning)
 
 	// Tool security: baseline, drift, quarantine.
 	protectedMux.HandleFunc("POST /admin/api/v1/tools/baseline", h.handleCaptureBaseline)
@@ -317,6 +412,8 @@ func (h *AdminAPIHandler) Routes() http.Handler {
 	protectedMux.HandleFunc("DELETE /admin/api/v1/tools/quarantine/{tool_name}", h.handleUnquarantineTool)
 	protectedMux.HandleFunc("GET /admin/api/v1/tools/quarantine", h.handleListQuarantined)
 	protectedMux.HandleFunc("POST /admin/api/v1/tools/accept-change", h.handleAcceptToolChange)
+	protectedMux.HandleFunc("GET /admin/api/v1/tools/response-guard", h.handleGetResponseGuard)
+	protectedMux.HandleFunc("POST /admin/api/v1/tools/response-guard/{tool_name}/accept", h.handleAcceptResponseChange)
 
 	// Policy templates (TMPL-01 through TMPL-04).
 	protectedMux.HandleFunc("GET /admin/api/v1/templates", h.handleListTemplates)
@@ -328,10 +425,16 @@ func (h *AdminAPIHandler) Routes() http.Handler {
 	protectedMux.HandleFunc("GET /admin/api/v1/quotas/{identity_id}", h.handleGetQuota)
 	protectedMux.HandleFunc("PUT /admin/api/v1/quotas/{identity_id}", h.handlePutQuota)
 	protectedMux.HandleFunc("DELETE /admin/api/v1/quotas/{identity_id}", h.handleDeleteQuota)
+	protectedMux.HandleFunc("GET /admin/api/quotas", h.handleQuotaStatus)
 
 	// Active sessions (QUOT-06).
 	protectedMux.HandleFunc("GET /admin/api/v1/sessions/active", h.handleListActiveSessions)
 	protectedMux.HandleFunc("DELETE /admin/api/v1/sessions/{id}", h.handleTerminateSession)
+	protectedMux.HandleFunc("PUT /admin/api/v1/sessions/{id}/labels", h.handleSetSessionLabels)
+
+	// Deny loops (identities retrying the same denied call).
+	protectedMux.HandleFunc("GET /admin/api/v1/deny-loops", h.handleListDenyLoops)
+	protectedMux.HandleFunc("DELETE /admin/api/v1/deny-loops/{id}", h.handleClearDenyLoop)
 
 	// Unified Agent View (UX-F2).
 	protectedMux.HandleFunc("GET /admin/api/v1/agents/{identity_id}/summary", h.handleGetAgentSummary)
@@ -347,6 +450,7 @@ func (h *AdminAPIHandler) Routes() http.Handler {
 
 	// Policy Simulation (UX-F1).
 	protectedMux.HandleFunc("POST /admin/api/v1/simulation/run", h.handleRunSimulation)
+	protectedMux.HandleFunc("POST /admin/api/v1/simulation/replay", h.handleReplayPolicies)
 
 	// Behavioral Drift Detection (Upgrade 5).
 	protectedMux.HandleFunc("GET /admin/api/v1/drift/reports", h.handleListDriftReports)
@@ -363,6 +467,13 @@ func (h *AdminAPIHandler) Routes() http.Handler {
 	protectedMux.HandleFunc("GET /admin/api/v1/permissions/config", h.handleGetPermissionHealthConfig)
 	protectedMux.HandleFunc("PUT /admin/api/v1/permissions/config", h.handleUpdatePermissionHealthConfig)
 
+	// Outbound allowlist learning.
+	protectedMux.HandleFunc("GET /admin/api/v1/outbound/learning", h.handleGetOutboundLearning)
+	protectedMux.HandleFunc("DELETE /admin/api/v1/outbound/learning", h.handleResetOutboundLearning)
+	protectedMux.HandleFunc("POST /admin/api/v1/outbound/learning/start", h.handleStartOutboundLearning)
+	protectedMux.HandleFunc("POST /admin/api/v1/outbound/learning/stop", h.handleStopOutboundLearning)
+	protectedMux.HandleFunc("POST /admin/api/v1/outbound/learning/apply", h.handleApplyOutboundLearning)
+
 	// Telemetry / OpenTelemetry (Upgrade 9).
 	protectedMux.HandleFunc("GET /admin/api/v1/telemetry/config", h.handleGetTelemetryConfig)
 	protectedMux.HandleFunc("PUT /admin/api/v1/telemetry/config", h.handlePutTelemetryConfig)
@@ -417,19 +528,49 @@ func (h *AdminAPIHandler) Routes() http.Handler {
 	protectedMux.HandleFunc("GET /admin/api/v1/finops/config", h.handleGetFinOpsConfig)
 	protectedMux.HandleFunc("PUT /admin/api/v1/finops/config", h.handleUpdateFinOpsConfig)
 
+	// Cost accounting (per-call cost hooks, aggregated per identity and day).
+	protectedMux.HandleFunc("GET /admin/api/costs", h.handleGetCosts)
+
+	// MCP method handling (ping, completion/complete, unknown methods).
+	protectedMux.HandleFunc("GET /admin/api/mcp-methods", h.handleGetMCPMethods)
+
+	// Upstream notification delivery (forward, latest connection, drop).
+	protectedMux.HandleFunc("GET /admin/api/upstream-notifications", h.handleGetUpstreamNotifications)
+
 	// Stats, system info, and audit endpoints.
 	protectedMux.HandleFunc("GET /admin/api/stats", h.handleGetStats)
+	protectedMux.HandleFunc("GET /admin/api/slo", h.handleGetSLO)
+	protectedMux.HandleFunc("GET /admin/api/slo/alerts", h.handleGetSLOAlertRules)
+	protectedMux.HandleFunc("GET /admin/api/webhooks", h.handleListWebhooks)
+	protectedMux.HandleFunc("GET /admin/api/webhooks/{name}/deliveries", h.handleWebhookDeliveries)
+	protectedMux.HandleFunc("GET /admin/api/events/sinks", h.handleListEventSinks)
 	protectedMux.HandleFunc("GET /admin/api/system", h.handleSystemInfo)
 	protectedMux.HandleFunc("GET /admin/api/audit", h.handleQueryAudit)
 	protectedMux.HandleFunc("GET /admin/api/audit/stream", h.handleAuditStream)
 	protectedMux.HandleFunc("GET /admin/api/audit/export", h.handleAuditExport)
+	protectedMux.HandleFunc("GET /admin/api/audit/storage", h.handleAuditStorage)
+	protectedMux.HandleFunc("GET /admin/api/audit/schema", h.handleAuditSchema)
+	protectedMux.HandleFunc("GET /admin/api/audit/payloads", h.handleQueryPayloads)
 
 	// System management.
 	protectedMux.HandleFunc("POST /admin/api/system/factory-reset", h.handleFactoryReset)
+	protectedMux.HandleFunc("POST /admin/api/system/reload", h.handleReloadConfig)
 
 	// Wrap protected routes with auth middleware.
 	mux.Handle("/admin/api/", h.adminAuthMiddleware(protectedMux))
 
+	// Routes an identity calls with its own MCP API key.
+	meMux := http.NewServeMux()
+	meMux.HandleFunc("GET /admin/api/me", h.handleGetMe)
+	meMux.HandleFunc("GET /admin/api/me/rate-limits", h.handleGetMyRateLimits)
+	meMux.HandleFunc("GET /admin/api/me/denials", h.handleListMyDenials)
+	meMux.HandleFunc("POST /admin/api/me/key/rotate", h.handleRotateMyKey)
+	meMux.HandleFunc("GET /admin/api/me/approvals", h.handleListDelegatedApprovals)
+	meMux.HandleFunc("POST /admin/api/me/approvals/{id}/approve", h.handleDelegatedApprove)
+	meMux.HandleFunc("POST /admin/api/me/approvals/{id}/deny", h.handleDelegatedDeny)
+	mux.Handle("/admin/api/me", h.identityAuthMiddleware(meMux))
+	mux.Handle("/admin/api/me/", h.identityAuthMiddleware(meMux))
+
 	// SECU-09: Wrap with API rate limiter (3000 req/min/IP).
 	// M-15: All connections including localhost are rate-limited to prevent CPU
 	// exhaustion via compute-intensive operations (e.g. Argon2id hashing).
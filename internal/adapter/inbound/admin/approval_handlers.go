@@ -10,6 +10,7 @@ import (
 
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/action"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
 )
 
 // WithApprovalStore sets the approval store on the AdminAPIHandler.
@@ -39,6 +40,34 @@ type approvalResponse struct {
 	CreatedAt    string `json:"created_at"`
 	TimeoutSecs  int    `json:"timeout_secs"`
 	AuditNote    string `json:"audit_note,omitempty"`
+	// ExpiresAt is when the timeout action applies.
+	ExpiresAt     string                     `json:"expires_at"`
+	TimeoutAction string                     `json:"timeout_action"`
+	Delegation    *action.ApprovalDelegation `json:"delegation,omitempty"`
+}
+
+// newApprovalResponse converts a pending approval for the API.
+func newApprovalResponse(p *action.PendingApproval) approvalResponse {
+	resp := approvalResponse{
+		ID:            p.ID,
+		ToolName:      p.ToolName,
+		IdentityName:  p.IdentityName,
+		IdentityID:    p.IdentityID,
+		SessionID:     p.SessionID,
+		RuleID:        p.RuleID,
+		RuleName:      p.RuleName,
+		Condition:     p.Condition,
+		Status:        p.Status,
+		CreatedAt:     p.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		TimeoutSecs:   int(p.Timeout.Seconds()),
+		ExpiresAt:     p.ExpiresAt().UTC().Format(time.RFC3339),
+		TimeoutAction: string(p.TimeoutAction),
+	}
+	if !p.Delegation.Empty() {
+		d := p.Delegation
+		resp.Delegation = &d
+	}
+	return resp
 }
 
 // handleListApprovals returns all pending approvals as a JSON array.
@@ -52,19 +81,7 @@ func (h *AdminAPIHandler) handleListApprovals(w http.ResponseWriter, r *http.Req
 	pending := h.approvalStore.List()
 	result := make([]approvalResponse, len(pending))
 	for i, p := range pending {
-		result[i] = approvalResponse{
-			ID:           p.ID,
-			ToolName:     p.ToolName,
-			IdentityName: p.IdentityName,
-			IdentityID:   p.IdentityID,
-			SessionID:    p.SessionID,
-			RuleID:       p.RuleID,
-			RuleName:     p.RuleName,
-			Condition:    p.Condition,
-			Status:       p.Status,
-			CreatedAt:    p.CreatedAt.Format("2006-01-02T15:04:05Z"),
-			TimeoutSecs:  int(p.Timeout.Seconds()),
-		}
+		result[i] = newApprovalResponse(p)
 	}
 
 	h.respondJSON(w, http.StatusOK, result)
@@ -73,6 +90,11 @@ func (h *AdminAPIHandler) handleListApprovals(w http.ResponseWriter, r *http.Req
 // approveRequest is the JSON request body for approving an approval.
 type approveRequest struct {
 	Note string `json:"note"`
+	// Scope is "once" (default), "session" or "identity".
+	Scope string `json:"scope"`
+	// Duration is how long a session or identity approval lasts, e.g.
+	// "24h"; empty uses approval.grant_duration.
+	Duration string `json:"duration"`
 }
 
 // handleApproveRequest approves a pending approval request.
@@ -89,28 +111,58 @@ func (h *AdminAPIHandler) handleApproveRequest(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	h.approve(w, r, id)
+}
+
+// approve decides a pending approval for the admin API or a delegate, the
+// actor of the request context.
+func (h *AdminAPIHandler) approve(w http.ResponseWriter, r *http.Request, id string) {
 	var req approveRequest
 	if err := h.readJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
 		h.respondError(w, http.StatusBadRequest, "invalid JSON body")
 		return
 	}
+	opts := action.ApproveOptions{
+		Note:  req.Note,
+		Scope: action.ApprovalScope(req.Scope),
+		By:    service.ActorFromContext(r.Context()),
+	}
+	if req.Duration != "" {
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil || d <= 0 {
+			h.respondError(w, http.StatusBadRequest, "duration must be a positive duration such as \"24h\"")
+			return
+		}
+		opts.Duration = d
+	}
 
-	if err := h.approvalStore.Approve(id, req.Note); err != nil {
+	grant, err := h.approvalStore.ApproveWith(id, opts)
+	if err != nil {
 		if errors.Is(err, action.ErrAlreadyResolved) {
 			h.respondError(w, http.StatusConflict, "approval already resolved")
 		} else if errors.Is(err, action.ErrApprovalNotFound) {
 			h.respondError(w, http.StatusNotFound, "approval not found")
+		} else if errors.Is(err, action.ErrInvalidApprovalScope) {
+			h.respondError(w, http.StatusBadRequest, err.Error())
 		} else {
 			h.internalError(w, "failed to approve request", err)
 		}
 		return
 	}
 
-	h.respondJSON(w, http.StatusOK, map[string]string{
+	resp := map[string]string{
 		"status":  "approved",
 		"id":      id,
 		"message": "approval granted",
-	})
+		"scope":   string(action.ApprovalScopeOnce),
+	}
+	if grant != nil {
+		resp["scope"] = string(grant.Scope)
+		resp["grant_id"] = grant.ID
+		resp["expires_at"] = grant.ExpiresAt.Format(time.RFC3339)
+		resp["message"] = fmt.Sprintf("approval granted for the %s until %s", grant.Scope, grant.ExpiresAt.Format(time.RFC3339))
+	}
+	h.respondJSON(w, http.StatusOK, resp)
 }
 
 // denyRequest is the JSON request body for denying an approval.
@@ -133,6 +185,11 @@ func (h *AdminAPIHandler) handleDenyRequest(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	h.deny(w, r, id)
+}
+
+// deny denies a pending approval for the admin API or a delegate.
+func (h *AdminAPIHandler) deny(w http.ResponseWriter, r *http.Request, id string) {
 	// Read optional reason and note from body (M-47: check errors, allow empty body)
 	var req denyRequest
 	if err := h.readJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
@@ -145,7 +202,7 @@ func (h *AdminAPIHandler) handleDenyRequest(w http.ResponseWriter, r *http.Reque
 		reason = "denied by admin"
 	}
 
-	if err := h.approvalStore.Deny(id, reason, req.Note); err != nil {
+	if err := h.approvalStore.DenyBy(id, service.ActorFromContext(r.Context()), reason, req.Note); err != nil {
 		if errors.Is(err, action.ErrAlreadyResolved) {
 			h.respondError(w, http.StatusConflict, "approval already resolved")
 		} else if errors.Is(err, action.ErrApprovalNotFound) {
@@ -163,6 +220,141 @@ func (h *AdminAPIHandler) handleDenyRequest(w http.ResponseWriter, r *http.Reque
 	})
 }
 
+// delegateRequest is the JSON request body for delegating an approval.
+type delegateRequest struct {
+	Identities []string `json:"identities"`
+	Roles      []string `json:"roles"`
+}
+
+// handleDelegateApproval sets the identities and roles that may decide a
+// pending approval besides the admins. An empty body takes the delegation
+// back.
+// POST /admin/api/v1/approvals/{id}/delegate
+func (h *AdminAPIHandler) handleDelegateApproval(w http.ResponseWriter, r *http.Request) {
+	if h.approvalStore == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "approval store not configured")
+		return
+	}
+	id := h.pathParam(r, "id")
+	var req delegateRequest
+	if err := h.readJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+		h.respondError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	d := action.ApprovalDelegation{}
+	for _, v := range req.Identities {
+		if v = strings.TrimSpace(v); v != "" {
+			d.Identities = append(d.Identities, v)
+		}
+	}
+	for _, v := range req.Roles {
+		if v = strings.TrimSpace(v); v != "" {
+			d.Roles = append(d.Roles, v)
+		}
+	}
+
+	if err := h.approvalStore.Delegate(id, d, service.ActorFromContext(r.Context())); err != nil {
+		if errors.Is(err, action.ErrAlreadyResolved) {
+			h.respondError(w, http.StatusConflict, "approval already resolved")
+		} else if errors.Is(err, action.ErrApprovalNotFound) {
+			h.respondError(w, http.StatusNotFound, "approval not found")
+		} else {
+			h.internalError(w, "failed to delegate approval", err)
+		}
+		return
+	}
+	h.respondJSON(w, http.StatusOK, newApprovalResponse(h.approvalStore.Get(id)))
+}
+
+// handleListApprovalGrants lists the unexpired session and identity
+// approvals.
+// GET /admin/api/v1/approvals/grants
+func (h *AdminAPIHandler) handleListApprovalGrants(w http.ResponseWriter, r *http.Request) {
+	if h.approvalStore == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "approval store not configured")
+		return
+	}
+	h.respondJSON(w, http.StatusOK, h.approvalStore.Grants())
+}
+
+// handleRevokeApprovalGrant ends a session or identity approval.
+// DELETE /admin/api/v1/approvals/grants/{id}
+func (h *AdminAPIHandler) handleRevokeApprovalGrant(w http.ResponseWriter, r *http.Request) {
+	if h.approvalStore == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "approval store not configured")
+		return
+	}
+	if err := h.approvalStore.RevokeGrant(h.pathParam(r, "id")); err != nil {
+		if errors.Is(err, action.ErrApprovalGrantNotFound) {
+			h.respondError(w, http.StatusNotFound, "approval grant not found")
+		} else {
+			h.internalError(w, "failed to revoke approval grant", err)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// --- Delegated approvals (authenticated by an MCP API key) ---
+
+// handleListDelegatedApprovals lists the pending approvals delegated to the
+// calling identity.
+// GET /admin/api/me/approvals
+func (h *AdminAPIHandler) handleListDelegatedApprovals(w http.ResponseWriter, r *http.Request) {
+	if h.approvalStore == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "approval store not configured")
+		return
+	}
+	caller := keyIdentityFromContext(r.Context())
+	pending := h.approvalStore.ListDelegated(caller.ID, caller.Name, caller.Roles)
+	result := make([]approvalResponse, len(pending))
+	for i, p := range pending {
+		result[i] = newApprovalResponse(p)
+	}
+	h.respondJSON(w, http.StatusOK, result)
+}
+
+// handleDelegatedApprove approves a pending approval delegated to the
+// calling identity.
+// POST /admin/api/me/approvals/{id}/approve
+func (h *AdminAPIHandler) handleDelegatedApprove(w http.ResponseWriter, r *http.Request) {
+	if id, ok := h.checkDelegate(w, r); ok {
+		h.approve(w, r, id)
+	}
+}
+
+// handleDelegatedDeny denies a pending approval delegated to the calling
+// identity.
+// POST /admin/api/me/approvals/{id}/deny
+func (h *AdminAPIHandler) handleDelegatedDeny(w http.ResponseWriter, r *http.Request) {
+	if id, ok := h.checkDelegate(w, r); ok {
+		h.deny(w, r, id)
+	}
+}
+
+// checkDelegate responds with an error unless the calling identity may
+// decide the approval in the path.
+func (h *AdminAPIHandler) checkDelegate(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if h.approvalStore == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "approval store not configured")
+		return "", false
+	}
+	id := h.pathParam(r, "id")
+	caller := keyIdentityFromContext(r.Context())
+	err := h.approvalStore.CheckDelegate(id, caller.ID, caller.Name, caller.Roles)
+	switch {
+	case err == nil:
+		return id, true
+	case errors.Is(err, action.ErrApprovalNotFound):
+		h.respondError(w, http.StatusNotFound, "approval not found")
+	case errors.Is(err, action.ErrSelfApproval), errors.Is(err, action.ErrNotDelegated):
+		h.respondError(w, http.StatusForbidden, err.Error())
+	default:
+		h.internalError(w, "failed to check approval delegation", err)
+	}
+	return "", false
+}
+
 // --- Decision Context (Delta 2.3) ---
 
 // approvalContextResponse provides rich decision context for an approval request.
@@ -2,16 +2,20 @@ package admin
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/action"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
 )
 
 type approvalTestEnv struct {
@@ -298,3 +302,171 @@ func TestHandleGetApprovalContext_NotFound(t *testing.T) {
 		t.Fatalf("GET context nonexistent status = %d, want %d", rec.Code, http.StatusNotFound)
 	}
 }
+
+// --- Scopes, grants and delegation ---
+
+func TestHandleApproveRequest_Scope(t *testing.T) {
+	env := setupApprovalTestEnv(t)
+	addTestApproval(t, env.approvalStore, "appr-s1")
+
+	rec := env.doRequest(t, "POST", "/admin/api/v1/approvals/appr-s1/approve", map[string]string{"scope": "forever"})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("POST approve with unknown scope status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	rec = env.doRequest(t, "POST", "/admin/api/v1/approvals/appr-s1/approve", map[string]string{"scope": "session", "duration": "soon"})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("POST approve with invalid duration status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	rec = env.doRequest(t, "POST", "/admin/api/v1/approvals/appr-s1/approve", map[string]string{"scope": "session", "duration": "2h"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST approve with session scope status = %d (body=%s)", rec.Code, rec.Body.String())
+	}
+	var result map[string]string
+	decodeApprovalJSON(t, rec, &result)
+	if result["scope"] != "session" || result["grant_id"] == "" || result["expires_at"] == "" {
+		t.Fatalf("response = %v", result)
+	}
+	if p := env.approvalStore.Get("appr-s1"); p.ResolvedBy != "admin@127.0.0.1" {
+		t.Errorf("ResolvedBy = %q, want admin@127.0.0.1", p.ResolvedBy)
+	}
+
+	rec = env.doRequest(t, "GET", "/admin/api/v1/approvals/grants", nil)
+	var grants []action.ApprovalGrant
+	decodeApprovalJSON(t, rec, &grants)
+	if len(grants) != 1 || grants[0].ID != result["grant_id"] || grants[0].SessionID != "session-abc" {
+		t.Fatalf("GET grants = %+v", grants)
+	}
+
+	rec = env.doRequest(t, "DELETE", "/admin/api/v1/approvals/grants/"+grants[0].ID, nil)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("DELETE grant status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	rec = env.doRequest(t, "DELETE", "/admin/api/v1/approvals/grants/"+grants[0].ID, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("DELETE revoked grant status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+// setupDelegationTestEnv returns an approval test environment with an
+// identity service, and the API keys of an "oncall" identity and of the
+// identity making the test approvals (identity-001 is replaced by it).
+func setupDelegationTestEnv(t *testing.T) (env *approvalTestEnv, oncallKey, agentID, agentKey string) {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	stateStore := state.NewFileStateStore(filepath.Join(t.TempDir(), "state.json"), logger)
+	if err := stateStore.Save(stateStore.DefaultState()); err != nil {
+		t.Fatalf("save default state: %v", err)
+	}
+	identitySvc := service.NewIdentityService(stateStore, logger)
+	newKey := func(name string, roles []string) (string, string) {
+		ctx := context.Background()
+		identity, err := identitySvc.CreateIdentity(ctx, service.CreateIdentityInput{Name: name, Roles: roles})
+		if err != nil {
+			t.Fatalf("CreateIdentity(%s): %v", name, err)
+		}
+		key, err := identitySvc.GenerateKey(ctx, service.GenerateKeyInput{IdentityID: identity.ID, Name: name + "-key"})
+		if err != nil {
+			t.Fatalf("GenerateKey(%s): %v", name, err)
+		}
+		return identity.ID, key.CleartextKey
+	}
+	_, oncallKey = newKey("oncall-lead", []string{"oncall"})
+	agentID, agentKey = newKey("agent-1", []string{"oncall"})
+
+	store := action.NewApprovalStore(100)
+	handler := NewAdminAPIHandler(
+		WithApprovalStore(store),
+		WithIdentityService(identitySvc),
+		WithAPILogger(logger),
+	)
+	env = &approvalTestEnv{handler: handler, approvalStore: store, mux: handler.Routes()}
+	return env, oncallKey, agentID, agentKey
+}
+
+// doKeyRequest makes a request authenticated by an MCP API key, without a
+// CSRF token.
+func (e *approvalTestEnv) doKeyRequest(t *testing.T, method, path, key string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	var bodyReader io.Reader
+	if body != nil {
+		data, _ := json.Marshal(body)
+		bodyReader = bytes.NewReader(data)
+	}
+	req := httptest.NewRequest(method, path, bodyReader)
+	req.RemoteAddr = "203.0.113.7:4321"
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	rec := httptest.NewRecorder()
+	e.mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestDelegatedApprovals(t *testing.T) {
+	env, oncallKey, agentID, agentKey := setupDelegationTestEnv(t)
+	p := action.NewTestPendingApproval("appr-d1", "deploy", "agent-1", agentID, "session-abc", "rule-42", "deploys", 5*time.Minute)
+	if err := env.approvalStore.Add(p); err != nil {
+		t.Fatalf("store.Add: %v", err)
+	}
+
+	// Without an API key, or with an invalid one.
+	if rec := env.doKeyRequest(t, "GET", "/admin/api/me/approvals", "", nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("GET without key status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if rec := env.doKeyRequest(t, "GET", "/admin/api/me/approvals", "sg_invalid", nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("GET with invalid key status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+
+	// Nothing is delegated yet.
+	rec := env.doKeyRequest(t, "POST", "/admin/api/me/approvals/appr-d1/approve", oncallKey, nil)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("POST approve before delegation status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	rec = env.doRequest(t, "POST", "/admin/api/v1/approvals/appr-d1/delegate", map[string][]string{"roles": {"oncall"}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST delegate status = %d (body=%s)", rec.Code, rec.Body.String())
+	}
+	var delegated approvalResponse
+	decodeApprovalJSON(t, rec, &delegated)
+	if delegated.Delegation == nil || len(delegated.Delegation.Roles) != 1 || delegated.ExpiresAt == "" {
+		t.Fatalf("delegate response = %+v", delegated)
+	}
+
+	rec = env.doKeyRequest(t, "GET", "/admin/api/me/approvals", oncallKey, nil)
+	var list []approvalResponse
+	decodeApprovalJSON(t, rec, &list)
+	if len(list) != 1 || list[0].ID != "appr-d1" {
+		t.Fatalf("GET me/approvals = %+v", list)
+	}
+
+	// The agent has the role, but cannot approve its own call.
+	rec = env.doKeyRequest(t, "POST", "/admin/api/me/approvals/appr-d1/approve", agentKey, nil)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("POST self-approve status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	rec = env.doKeyRequest(t, "GET", "/admin/api/me/approvals", agentKey, nil)
+	decodeApprovalJSON(t, rec, &list)
+	if len(list) != 0 {
+		t.Errorf("GET me/approvals for the caller of the approval = %+v", list)
+	}
+
+	// API keys do not reach the admin API.
+	rec = env.doKeyRequest(t, "POST", "/admin/api/v1/approvals/appr-d1/approve", oncallKey, nil)
+	if rec.Code == http.StatusOK {
+		t.Fatal("POST admin approve with an API key succeeded")
+	}
+
+	rec = env.doKeyRequest(t, "POST", "/admin/api/me/approvals/appr-d1/approve", oncallKey, map[string]string{"note": "ok"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST delegated approve status = %d (body=%s)", rec.Code, rec.Body.String())
+	}
+	if got := env.approvalStore.Get("appr-d1"); got.Status != "approved" || got.ResolvedBy != "identity:oncall-lead" {
+		t.Errorf("approval = %s by %q, want approved by identity:oncall-lead", got.Status, got.ResolvedBy)
+	}
+	rec = env.doKeyRequest(t, "POST", "/admin/api/me/approvals/appr-d1/deny", oncallKey, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("POST delegated deny of a resolved approval status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
@@ -3,6 +3,7 @@ package admin
 import (
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/session"
 )
 
 // AuditQueryResponse is the JSON response for GET /admin/api/audit.
@@ -21,6 +23,7 @@ type AuditQueryResponse struct {
 
 // AuditRecordDTO is the JSON representation of an audit record.
 type AuditRecordDTO struct {
+	SchemaVersion  int                    `json:"schema_version,omitempty"`
 	Timestamp      string                 `json:"timestamp"`
 	SessionID      string                 `json:"session_id"`
 	IdentityID     string                 `json:"identity_id"`
@@ -31,6 +34,7 @@ type AuditRecordDTO struct {
 	Reason         string                 `json:"reason"`
 	RuleID         string                 `json:"rule_id"`
 	RequestID      string                 `json:"request_id"`
+	CorrelationID  string                 `json:"correlation_id,omitempty"`
 	LatencyMicros  int64                  `json:"latency_micros"`
 	Protocol       string                 `json:"protocol,omitempty"`
 	Framework      string                 `json:"framework,omitempty"`
@@ -38,6 +42,14 @@ type AuditRecordDTO struct {
 	ScanAction     string                 `json:"scan_action,omitempty"`
 	ScanTypes      string                 `json:"scan_types,omitempty"`
 	TransformCount int                    `json:"transform_count"`
+	// SourceCountry and AccessRestriction are set on identity access
+	// restriction denials.
+	SourceCountry     string `json:"source_country,omitempty"`
+	AccessRestriction string `json:"access_restriction,omitempty"`
+	// DestIP, DestCountry and DestASN locate the destination of the call.
+	DestIP      string `json:"dest_ip,omitempty"`
+	DestCountry string `json:"dest_country,omitempty"`
+	DestASN     uint   `json:"dest_asn,omitempty"`
 }
 
 // csvSafe prefixes values that could trigger formula injection in spreadsheets (L-16).
@@ -50,16 +62,18 @@ func csvSafe(s string) string {
 
 func toDTO(r audit.AuditRecord) AuditRecordDTO {
 	return AuditRecordDTO{
+		SchemaVersion:  r.SchemaVersion,
 		Timestamp:      r.Timestamp.UTC().Format(time.RFC3339),
 		SessionID:      r.SessionID,
 		IdentityID:     r.IdentityID,
 		IdentityName:   r.IdentityName,
 		ToolName:       r.ToolName,
-		ToolArguments:  r.ToolArguments,
+		ToolArguments:  audit.MaskEncryptedArgs(r.ToolArguments),
 		Decision:       r.Decision,
 		Reason:         r.Reason,
 		RuleID:         r.RuleID,
 		RequestID:      r.RequestID,
+		CorrelationID:  r.CorrelationID,
 		LatencyMicros:  r.LatencyMicros,
 		Protocol:       r.Protocol,
 		Framework:      r.Framework,
@@ -67,6 +81,12 @@ func toDTO(r audit.AuditRecord) AuditRecordDTO {
 		ScanAction:     r.ScanAction,
 		ScanTypes:      r.ScanTypes,
 		TransformCount: len(r.TransformResults),
+
+		SourceCountry:     r.SourceCountry,
+		AccessRestriction: r.AccessRestriction,
+		DestIP:            r.DestIP,
+		DestCountry:       r.DestCountry,
+		DestASN:           r.DestASN,
 	}
 }
 
@@ -81,6 +101,10 @@ func (h *AdminAPIHandler) handleQueryAudit(w http.ResponseWriter, r *http.Reques
 		return
 	}
 	records, nextCursor, err := h.auditReader.Query(r.Context(), filter)
+	if errors.Is(err, audit.ErrInvalidCursor) {
+		h.respondError(w, http.StatusBadRequest, "invalid cursor")
+		return
+	}
 	if err != nil {
 		h.logger.Error("audit query failed", "error", err)
 		h.respondError(w, http.StatusInternalServerError, "audit query failed")
@@ -224,7 +248,7 @@ func (h *AdminAPIHandler) handleAuditExport(w http.ResponseWriter, r *http.Reque
 		// Serialize tool arguments to JSON for CSV column
 		argsStr := ""
 		if len(rec.ToolArguments) > 0 {
-			if ab, err := json.Marshal(rec.ToolArguments); err == nil {
+			if ab, err := json.Marshal(audit.MaskEncryptedArgs(rec.ToolArguments)); err == nil {
 				argsStr = string(ab)
 			}
 		}
@@ -250,6 +274,13 @@ func (h *AdminAPIHandler) handleAuditExport(w http.ResponseWriter, r *http.Reque
 	}
 }
 
+// handleAuditSchema returns the audit record schema descriptor: the current
+// and oldest readable versions, the field evolution between versions and the
+// fields of the current layout.
+func (h *AdminAPIHandler) handleAuditSchema(w http.ResponseWriter, _ *http.Request) {
+	h.respondJSON(w, http.StatusOK, audit.Schema())
+}
+
 func parseAuditFilter(r *http.Request) (audit.AuditFilter, error) {
 	q := r.URL.Query()
 	filter := audit.AuditFilter{}
@@ -267,6 +298,25 @@ func parseAuditFilter(r *http.Request) (audit.AuditFilter, error) {
 	}
 	filter.ToolName = q.Get("tool")
 	filter.UserID = q.Get("user")
+	if identity := q.Get("identity"); identity != "" {
+		filter.UserID = identity
+	}
+	filter.Upstream = q.Get("upstream")
+	filter.RuleID = q.Get("rule_id")
+	filter.Reason = q.Get("reason")
+	filter.CorrelationID = q.Get("correlation_id")
+	switch order := q.Get("order"); order {
+	case "", "desc":
+	case "asc":
+		filter.Ascending = true
+	default:
+		return filter, fmt.Errorf("invalid order: must be 'asc' or 'desc'")
+	}
+	labels, err := session.ParseLabelSelector(q["label"])
+	if err != nil {
+		return filter, err
+	}
+	filter.Labels = labels
 	if startStr := q.Get("start"); startStr != "" {
 		t, err := time.Parse(time.RFC3339, startStr)
 		if err != nil {
@@ -288,6 +288,25 @@ func TestHandleAuditStream_NoReader(t *testing.T) {
 	}
 }
 
+func TestHandleAuditSchema(t *testing.T) {
+	h := NewAdminAPIHandler()
+	req := httptest.NewRequest(http.MethodGet, "/admin/api/audit/schema", nil)
+	req.RemoteAddr = "127.0.0.1:1234"
+	rec := httptest.NewRecorder()
+	h.Routes().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d (body=%s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var desc audit.SchemaDescriptor
+	if err := json.NewDecoder(rec.Body).Decode(&desc); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if desc.CurrentVersion != audit.CurrentSchemaVersion || len(desc.Versions) != audit.CurrentSchemaVersion {
+		t.Errorf("descriptor = %+v, want version %d with its full history", desc, audit.CurrentSchemaVersion)
+	}
+}
+
 func TestParseAuditFilter_Defaults(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/admin/api/audit", nil)
 	filter, err := parseAuditFilter(req)
@@ -316,6 +335,24 @@ func TestParseAuditFilter_LimitClamp(t *testing.T) {
 	}
 }
 
+func TestParseAuditFilter_QueryParams(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet,
+		"/admin/api/audit?identity=alice&upstream=desktop&rule_id=block-writes&reason=denied&order=asc&cursor=2026-01-14.0.12", nil)
+	filter, err := parseAuditFilter(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if filter.UserID != "alice" || filter.Upstream != "desktop" || filter.RuleID != "block-writes" ||
+		filter.Reason != "denied" || !filter.Ascending || filter.Cursor != "2026-01-14.0.12" {
+		t.Errorf("filter = %+v, want every parameter set", filter)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/admin/api/audit?order=newest", nil)
+	if _, err := parseAuditFilter(req); err == nil {
+		t.Error("expected error for invalid order")
+	}
+}
+
 func TestHandleQueryAudit_ProtocolFilter(t *testing.T) {
 	reader := &mockAuditReader{records: testAuditRecords()}
 	h := NewAdminAPIHandler(WithAuditReader(reader))
@@ -0,0 +1,102 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+)
+
+// WithPayloadStore sets the store of sampled tool call payloads.
+func WithPayloadStore(s audit.PayloadStore) AdminAPIOption {
+	return func(h *AdminAPIHandler) { h.payloadStore = s }
+}
+
+// payloadSampleDTO is the JSON representation of a payload sample.
+type payloadSampleDTO struct {
+	Timestamp    string                 `json:"timestamp"`
+	RequestID    string                 `json:"request_id,omitempty"`
+	SessionID    string                 `json:"session_id"`
+	IdentityID   string                 `json:"identity_id"`
+	IdentityName string                 `json:"identity_name,omitempty"`
+	ToolName     string                 `json:"tool_name"`
+	Decision     string                 `json:"decision"`
+	Arguments    map[string]interface{} `json:"arguments,omitempty"`
+	Result       json.RawMessage        `json:"result,omitempty"`
+	Truncated    bool                   `json:"truncated,omitempty"`
+}
+
+// payloadQueryResponse is the JSON response for GET /admin/api/audit/payloads.
+type payloadQueryResponse struct {
+	Samples []payloadSampleDTO `json:"samples"`
+	Count   int                `json:"count"`
+}
+
+// handleQueryPayloads returns sampled tool call payloads, newest first,
+// filtered by request_id, tool, start and end (RFC3339). Encrypted
+// arguments are masked as in audit records.
+// GET /admin/api/audit/payloads
+func (h *AdminAPIHandler) handleQueryPayloads(w http.ResponseWriter, r *http.Request) {
+	if h.payloadStore == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "payload sampling not configured")
+		return
+	}
+	filter, err := parsePayloadFilter(r)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	samples, err := h.payloadStore.Query(r.Context(), filter)
+	if err != nil {
+		h.logger.Error("payload query failed", "error", err)
+		h.respondError(w, http.StatusInternalServerError, "payload query failed")
+		return
+	}
+	dtos := make([]payloadSampleDTO, len(samples))
+	for i, s := range samples {
+		dtos[i] = payloadSampleDTO{
+			Timestamp:    s.Timestamp.UTC().Format(time.RFC3339),
+			RequestID:    s.RequestID,
+			SessionID:    s.SessionID,
+			IdentityID:   s.IdentityID,
+			IdentityName: s.IdentityName,
+			ToolName:     s.ToolName,
+			Decision:     s.Decision,
+			Arguments:    audit.MaskEncryptedArgs(s.Arguments),
+			Result:       s.Result,
+			Truncated:    s.Truncated,
+		}
+	}
+	h.respondJSON(w, http.StatusOK, payloadQueryResponse{Samples: dtos, Count: len(dtos)})
+}
+
+// parsePayloadFilter reads the payload query parameters. The limit defaults
+// to 50 and is capped at 500.
+func parsePayloadFilter(r *http.Request) (audit.PayloadFilter, error) {
+	q := r.URL.Query()
+	filter := audit.PayloadFilter{
+		RequestID: q.Get("request_id"),
+		ToolName:  q.Get("tool"),
+		Limit:     50,
+	}
+	for name, dst := range map[string]*time.Time{"start": &filter.StartTime, "end": &filter.EndTime} {
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s time: expected RFC3339 format", name)
+			}
+			*dst = t
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return filter, fmt.Errorf("invalid limit: must be a positive integer")
+		}
+		filter.Limit = min(limit, 500)
+	}
+	return filter, nil
+}
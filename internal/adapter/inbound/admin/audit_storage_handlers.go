@@ -0,0 +1,61 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+)
+
+// WithAuditStorage sets the reporter of the audit file storage footprint.
+func WithAuditStorage(s audit.StorageReporter) AdminAPIOption {
+	return func(h *AdminAPIHandler) { h.auditStorage = s }
+}
+
+// auditStorageResponse is the response body of GET /admin/api/audit/storage.
+type auditStorageResponse struct {
+	Enabled           bool    `json:"enabled"`
+	Dir               string  `json:"dir,omitempty"`
+	Compression       bool    `json:"compression"`
+	Files             int     `json:"files"`
+	CompressedFiles   int     `json:"compressed_files"`
+	DiskBytes         int64   `json:"disk_bytes"`
+	UncompressedBytes int64   `json:"uncompressed_bytes"`
+	SavedBytes        int64   `json:"saved_bytes"`
+	SavingsPercent    float64 `json:"savings_percent"`
+	MaxTotalBytes     int64   `json:"max_total_bytes,omitempty"`
+	OldestDate        string  `json:"oldest_date,omitempty"`
+	NewestDate        string  `json:"newest_date,omitempty"`
+}
+
+// handleAuditStorage reports the size of the audit files on disk and the
+// space saved by compressing rotated files. Enabled is false when audit
+// files are not configured (audit_file.dir unset).
+func (h *AdminAPIHandler) handleAuditStorage(w http.ResponseWriter, r *http.Request) {
+	if h.auditStorage == nil {
+		h.respondJSON(w, http.StatusOK, auditStorageResponse{})
+		return
+	}
+	stats, err := h.auditStorage.StorageStats()
+	if err != nil {
+		h.logger.Error("audit storage stats failed", "error", err)
+		h.respondError(w, http.StatusInternalServerError, "failed to read audit storage")
+		return
+	}
+	resp := auditStorageResponse{
+		Enabled:           true,
+		Dir:               stats.Dir,
+		Compression:       stats.Compression,
+		Files:             stats.Files,
+		CompressedFiles:   stats.CompressedFiles,
+		DiskBytes:         stats.DiskBytes,
+		UncompressedBytes: stats.UncompressedBytes,
+		MaxTotalBytes:     stats.MaxTotalBytes,
+		OldestDate:        stats.OldestDate,
+		NewestDate:        stats.NewestDate,
+	}
+	if stats.UncompressedBytes > stats.DiskBytes {
+		resp.SavedBytes = stats.UncompressedBytes - stats.DiskBytes
+		resp.SavingsPercent = float64(resp.SavedBytes) * 100 / float64(stats.UncompressedBytes)
+	}
+	h.respondJSON(w, http.StatusOK, resp)
+}
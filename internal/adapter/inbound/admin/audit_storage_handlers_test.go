@@ -0,0 +1,47 @@
+package admin
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+)
+
+type fakeStorageReporter struct{ stats audit.StorageStats }
+
+func (f fakeStorageReporter) StorageStats() (audit.StorageStats, error) { return f.stats, nil }
+
+func TestHandleAuditStorage(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	rec := sloTestRequest(t, NewAdminAPIHandler(WithAPILogger(logger)), "/admin/api/audit/storage")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body=%s)", rec.Code, rec.Body.String())
+	}
+	var off auditStorageResponse
+	if err := json.NewDecoder(rec.Body).Decode(&off); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if off.Enabled {
+		t.Errorf("response = %+v, want enabled=false without audit files", off)
+	}
+
+	h := NewAdminAPIHandler(WithAPILogger(logger), WithAuditStorage(fakeStorageReporter{audit.StorageStats{
+		Dir: "/var/audit", Compression: true, Files: 3, CompressedFiles: 2,
+		DiskBytes: 250, UncompressedBytes: 1000,
+	}}))
+	rec = sloTestRequest(t, h, "/admin/api/audit/storage")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body=%s)", rec.Code, rec.Body.String())
+	}
+	var on auditStorageResponse
+	if err := json.NewDecoder(rec.Body).Decode(&on); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !on.Enabled || on.SavedBytes != 750 || on.SavingsPercent != 75 || on.CompressedFiles != 2 {
+		t.Errorf("response = %+v, want 750 bytes (75%%) saved", on)
+	}
+}
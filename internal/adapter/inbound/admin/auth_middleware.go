@@ -4,6 +4,8 @@ import (
 	"net"
 	"net/http"
 	"strings"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
 )
 
 // isLocalhostIP reports whether the given IP string is a loopback address.
@@ -88,15 +90,44 @@ func (h *AdminAPIHandler) isTrustedProxy(ip net.IP) bool {
 // Localhost requests (AUTH-01) bypass auth entirely. Remote requests are
 // rejected with 403 — use SSH tunnel for remote access.
 //
+// Requests carrying an admin token (Authorization: Bearer sgat_...) are
+// authenticated by the token instead, from any address, and limited to its
+// scopes. A token is never ignored in favour of localhost access, so
+// automation running on the gateway host gets the same limits.
+//
 // When trusted proxies are configured (HARD-11), the effective client IP is
 // resolved via X-Forwarded-For before checking for loopback.
 func (h *AdminAPIHandler) adminAuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cleartext := bearerAdminToken(r); cleartext != "" {
+			h.serveWithAdminToken(w, r, cleartext, next)
+			return
+		}
 		ip := h.clientIP(r)
 		if isLocalhostIP(ip) {
-			next.ServeHTTP(w, r)
+			// Changes made through the API are attributed to the caller.
+			next.ServeHTTP(w, r.WithContext(service.WithActor(r.Context(), "admin@"+ip)))
 			return
 		}
 		h.respondError(w, http.StatusForbidden, "admin API requires localhost access")
 	})
 }
+
+// serveWithAdminToken serves a request authenticated by an admin token.
+func (h *AdminAPIHandler) serveWithAdminToken(w http.ResponseWriter, r *http.Request, cleartext string, next http.Handler) {
+	if h.adminTokens == nil {
+		h.respondError(w, http.StatusUnauthorized, "invalid admin token")
+		return
+	}
+	token, err := h.adminTokens.Authenticate(cleartext)
+	if err != nil {
+		h.logger.Warn("admin API request with invalid token", "ip", h.clientIP(r), "path", r.URL.Path)
+		h.respondError(w, http.StatusUnauthorized, "invalid admin token")
+		return
+	}
+	if !adminTokenAllows(token, r.Method, r.URL.Path) {
+		h.respondError(w, http.StatusForbidden, "admin token scopes do not allow "+r.Method+" "+r.URL.Path)
+		return
+	}
+	next.ServeHTTP(w, r.WithContext(service.WithActor(r.Context(), "token:"+token.Name)))
+}
@@ -0,0 +1,136 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/action"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/event"
+)
+
+// SetArgumentScanInterceptor sets the argument scan interceptor after construction.
+func (h *AdminAPIHandler) SetArgumentScanInterceptor(i *action.ArgumentScanInterceptor) {
+	h.argumentScanInterceptor = i
+}
+
+// argumentScanConfigResponse is the JSON response for GET /admin/api/v1/security/argument-scanning.
+type argumentScanConfigResponse struct {
+	Enabled         bool     `json:"enabled"`
+	Mode            string   `json:"mode"`
+	InternalDomains []string `json:"internal_domains"`
+}
+
+// argumentScanConfigRequest is the JSON body for PUT /admin/api/v1/security/argument-scanning.
+// Omitted fields are left unchanged; an empty internal_domains list disables
+// internal hostname detection.
+type argumentScanConfigRequest struct {
+	Enabled         *bool     `json:"enabled"`
+	Mode            *string   `json:"mode"`
+	InternalDomains *[]string `json:"internal_domains"`
+}
+
+// handleGetArgumentScanning returns the current argument scanning configuration.
+// GET /admin/api/v1/security/argument-scanning
+func (h *AdminAPIHandler) handleGetArgumentScanning(w http.ResponseWriter, r *http.Request) {
+	if h.argumentScanInterceptor == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "argument scanning not available")
+		return
+	}
+	h.respondJSON(w, http.StatusOK, h.argumentScanConfig())
+}
+
+// handleUpdateArgumentScanning updates the argument scanning configuration.
+// PUT /admin/api/v1/security/argument-scanning
+func (h *AdminAPIHandler) handleUpdateArgumentScanning(w http.ResponseWriter, r *http.Request) {
+	if h.argumentScanInterceptor == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "argument scanning not available")
+		return
+	}
+
+	var req argumentScanConfigRequest
+	if !h.readJSONBody(w, r, &req) {
+		return
+	}
+	if req.Mode != nil {
+		switch action.ScanMode(*req.Mode) {
+		case action.ScanModeMonitor, action.ScanModeEnforce, action.ScanModeRedact:
+		default:
+			h.respondError(w, http.StatusBadRequest, "mode must be 'monitor', 'enforce' or 'redact'")
+			return
+		}
+	}
+
+	// Capture old state for rollback on persist failure.
+	scanner := h.argumentScanInterceptor.Scanner()
+	oldEnabled := h.argumentScanInterceptor.Enabled()
+	oldMode := h.argumentScanInterceptor.Mode()
+	oldDomains := scanner.InternalDomains()
+
+	if req.InternalDomains != nil {
+		if err := scanner.SetInternalDomains(*req.InternalDomains); err != nil {
+			h.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if req.Enabled != nil {
+		h.argumentScanInterceptor.SetEnabled(*req.Enabled)
+	}
+	if req.Mode != nil {
+		h.argumentScanInterceptor.SetMode(action.ScanMode(*req.Mode))
+	}
+
+	if h.stateStore != nil {
+		if err := h.persistArgumentScanConfig(); err != nil {
+			// Rollback in-memory state.
+			h.argumentScanInterceptor.SetEnabled(oldEnabled)
+			h.argumentScanInterceptor.SetMode(oldMode)
+			_ = scanner.SetInternalDomains(oldDomains)
+			h.logger.Error("failed to persist argument scan config", "error", err)
+			h.respondError(w, http.StatusInternalServerError, "failed to persist argument scan config")
+			return
+		}
+	}
+
+	cfg := h.argumentScanConfig()
+	if h.eventBus != nil {
+		h.eventBus.Publish(context.Background(), event.Event{
+			Type:     "content.argument_scan_updated",
+			Source:   "content-scanning",
+			Severity: event.SeverityInfo,
+			Payload: map[string]interface{}{
+				"enabled":          cfg.Enabled,
+				"mode":             cfg.Mode,
+				"internal_domains": cfg.InternalDomains,
+			},
+		})
+	}
+	h.respondJSON(w, http.StatusOK, cfg)
+}
+
+// argumentScanConfig returns the current argument scanning configuration.
+func (h *AdminAPIHandler) argumentScanConfig() argumentScanConfigResponse {
+	return argumentScanConfigResponse{
+		Enabled:         h.argumentScanInterceptor.Enabled(),
+		Mode:            string(h.argumentScanInterceptor.Mode()),
+		InternalDomains: h.argumentScanInterceptor.Scanner().InternalDomains(),
+	}
+}
+
+// persistArgumentScanConfig saves the argument scanning configuration to state.json.
+func (h *AdminAPIHandler) persistArgumentScanConfig() error {
+	cfg := h.argumentScanConfig()
+	return h.stateStore.Mutate(func(appState *state.AppState) error {
+		if appState.ContentScanningConfig == nil {
+			appState.ContentScanningConfig = &state.ContentScanningConfig{}
+		}
+		appState.ContentScanningConfig.ArgumentScan = &state.ArgumentScanConfig{
+			Enabled:         cfg.Enabled,
+			Mode:            cfg.Mode,
+			InternalDomains: cfg.InternalDomains,
+		}
+		appState.ContentScanningConfig.UpdatedAt = time.Now().UTC()
+		return nil
+	})
+}
@@ -0,0 +1,70 @@
+package admin
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/action"
+)
+
+func TestArgumentScanningHandlers(t *testing.T) {
+	h, store := newTestHandlerWithDetectors(t)
+	h.SetArgumentScanInterceptor(action.NewArgumentScanInterceptor(
+		action.NewArgumentScanner(h.responseScanner), nil, action.ScanModeMonitor, false, nil,
+	))
+
+	w := httptest.NewRecorder()
+	h.handleGetArgumentScanning(w, httptest.NewRequest(http.MethodGet, "/admin/api/v1/security/argument-scanning", nil))
+	var cfg argumentScanConfigResponse
+	if err := json.NewDecoder(w.Body).Decode(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Enabled || cfg.Mode != "monitor" || len(cfg.InternalDomains) != len(action.DefaultInternalDomains) {
+		t.Errorf("initial config = %+v", cfg)
+	}
+
+	body := `{"enabled": true, "mode": "redact", "internal_domains": ["corp.example.com"]}`
+	w = httptest.NewRecorder()
+	h.handleUpdateArgumentScanning(w, httptest.NewRequest(http.MethodPut, "/admin/api/v1/security/argument-scanning", bytes.NewBufferString(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("update: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !h.argumentScanInterceptor.Enabled() || h.argumentScanInterceptor.Mode() != action.ScanModeRedact {
+		t.Error("update not applied")
+	}
+	appState, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ac := appState.ContentScanningConfig.ArgumentScan
+	if ac == nil || !ac.Enabled || ac.Mode != "redact" || len(ac.InternalDomains) != 1 {
+		t.Errorf("persisted config = %+v", ac)
+	}
+
+	// Invalid values are rejected without changing the config.
+	for _, body := range []string{
+		`{"mode": "block"}`,
+		`{"enabled": false, "internal_domains": ["not a domain"]}`,
+	} {
+		w = httptest.NewRecorder()
+		h.handleUpdateArgumentScanning(w, httptest.NewRequest(http.MethodPut, "/admin/api/v1/security/argument-scanning", bytes.NewBufferString(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, w.Code)
+		}
+	}
+	if !h.argumentScanInterceptor.Enabled() {
+		t.Error("rejected update disabled argument scanning")
+	}
+}
+
+func TestArgumentScanningHandlers_Unavailable(t *testing.T) {
+	h := NewAdminAPIHandler()
+	w := httptest.NewRecorder()
+	h.handleGetArgumentScanning(w, httptest.NewRequest(http.MethodGet, "/admin/api/v1/security/argument-scanning", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", w.Code)
+	}
+}
@@ -87,8 +87,10 @@ func (h *AdminAPIHandler) handleUpdateContentScanning(w http.ResponseWriter, r *
 	}
 
 	// Validate mode.
-	if req.Mode != string(action.ScanModeMonitor) && req.Mode != string(action.ScanModeEnforce) {
-		h.respondError(w, http.StatusBadRequest, "invalid mode: must be \"monitor\" or \"enforce\"")
+	switch action.ScanMode(req.Mode) {
+	case action.ScanModeMonitor, action.ScanModeEnforce, action.ScanModeRedact:
+	default:
+		h.respondError(w, http.StatusBadRequest, "invalid mode: must be \"monitor\", \"enforce\" or \"redact\"")
 		return
 	}
 
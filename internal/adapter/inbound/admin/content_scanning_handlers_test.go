@@ -167,6 +167,25 @@ func TestContentScanning_UpdateInvalidMode_Returns400(t *testing.T) {
 	}
 }
 
+func TestContentScanning_UpdateRedactMode(t *testing.T) {
+	ctrl := &mockScanController{mode: action.ScanModeMonitor, enabled: true}
+	h := testContentScanEnv(t, ctrl)
+
+	body := `{"mode":"redact"}`
+	req := httptest.NewRequest(http.MethodPut, "/admin/api/v1/security/content-scanning", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	h.handleUpdateContentScanning(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d (body=%s)", w.Code, http.StatusOK, w.Body.String())
+	}
+	if ctrl.mode != action.ScanModeRedact {
+		t.Errorf("controller mode = %q, want %q", ctrl.mode, action.ScanModeRedact)
+	}
+}
+
 func TestContentScanning_UpdateInvalidJSON_Returns400(t *testing.T) {
 	ctrl := &mockScanController{mode: action.ScanModeMonitor, enabled: true}
 	h := testContentScanEnv(t, ctrl)
@@ -0,0 +1,90 @@
+package admin
+
+import (
+	"encoding/csv"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+// defaultCostReportDays is the number of days reported when no range is given.
+const defaultCostReportDays = 30
+
+// costDayLayout is the day format of the from/to query parameters.
+const costDayLayout = "2006-01-02"
+
+// SetCostAccountingService wires the cost accounting service.
+func (h *AdminAPIHandler) SetCostAccountingService(s *service.CostAccountingService) {
+	h.costAccountingService = s
+}
+
+// handleGetCosts returns usage and cost per identity and day.
+// GET /admin/api/costs?from=YYYY-MM-DD&to=YYYY-MM-DD&identity_id=...&format=csv
+func (h *AdminAPIHandler) handleGetCosts(w http.ResponseWriter, r *http.Request) {
+	if h.costAccountingService == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "cost accounting not enabled")
+		return
+	}
+
+	q := r.URL.Query()
+	to := time.Now().UTC()
+	if v := q.Get("to"); v != "" {
+		t, err := time.Parse(costDayLayout, v)
+		if err != nil {
+			h.respondError(w, http.StatusBadRequest, "invalid to: use YYYY-MM-DD")
+			return
+		}
+		to = t
+	}
+	from := to.AddDate(0, 0, -(defaultCostReportDays - 1))
+	if v := q.Get("from"); v != "" {
+		t, err := time.Parse(costDayLayout, v)
+		if err != nil {
+			h.respondError(w, http.StatusBadRequest, "invalid from: use YYYY-MM-DD")
+			return
+		}
+		from = t
+	}
+
+	report, err := h.costAccountingService.Report(r.Context(), from, to, q.Get("identity_id"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCostRange) {
+			h.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.logger.Error("failed to get cost usage", "error", err)
+		h.respondError(w, http.StatusInternalServerError, "failed to get cost usage")
+		return
+	}
+
+	if q.Get("format") != "csv" {
+		h.respondJSON(w, http.StatusOK, report)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", "attachment; filename=costs-"+report.From+"-"+report.To+".csv")
+	w.WriteHeader(http.StatusOK)
+	writer := csv.NewWriter(w)
+	_ = writer.Write([]string{
+		"day", "identity_id", "identity_name", "calls", "errors",
+		"duration_ms", "bytes_in", "bytes_out", "cost", "currency",
+	})
+	for _, d := range report.Days {
+		_ = writer.Write([]string{
+			d.Day,
+			csvSafe(d.IdentityID),
+			csvSafe(d.IdentityName),
+			strconv.FormatInt(d.Calls, 10),
+			strconv.FormatInt(d.Errors, 10),
+			strconv.FormatInt(d.DurationMs, 10),
+			strconv.FormatInt(d.BytesIn, 10),
+			strconv.FormatInt(d.BytesOut, 10),
+			strconv.FormatFloat(d.Cost, 'f', -1, 64),
+			report.Currency,
+		})
+	}
+	writer.Flush()
+}
@@ -0,0 +1,66 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/action"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+func TestHandleGetCosts(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	h := NewAdminAPIHandler(WithAPILogger(logger))
+	if rec := sloTestRequest(t, h, "/admin/api/costs"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("without service: status = %d, want 503", rec.Code)
+	}
+
+	table, _ := service.NewStaticCostTable([]service.CostRate{{PerCall: 0.25}})
+	costs := service.NewCostAccountingService([]service.CostHook{table}, logger)
+	h.SetCostAccountingService(costs)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go costs.Run(ctx, time.Hour)
+	costs.RecordUsage(action.CallUsage{Timestamp: time.Now(), IdentityID: "ci-bot", IdentityName: "=cmd", Tool: "search"})
+
+	var report service.CostUsageReport
+	deadline := time.Now().Add(2 * time.Second)
+	for report.Total.Calls == 0 && time.Now().Before(deadline) {
+		rec := sloTestRequest(t, h, "/admin/api/costs")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200 (body=%s)", rec.Code, rec.Body.String())
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if report.Total.Cost != 0.25 || len(report.Identities) != 1 || report.Identities[0].IdentityID != "ci-bot" {
+		t.Errorf("report = %+v", report)
+	}
+
+	rec := sloTestRequest(t, h, "/admin/api/costs?format=csv")
+	if ct := rec.Header().Get("Content-Type"); ct != "text/csv" {
+		t.Errorf("csv Content-Type = %q", ct)
+	}
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], ",ci-bot,'=cmd,1,0,") {
+		t.Errorf("csv = %q", rec.Body.String())
+	}
+
+	for _, path := range []string{
+		"/admin/api/costs?from=yesterday",
+		"/admin/api/costs?from=2026-03-02&to=2026-03-01",
+		"/admin/api/costs?from=2020-01-01&to=2026-01-01",
+	} {
+		if rec := sloTestRequest(t, h, path); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", path, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/denyloop"
+)
+
+// SetDenyLoopDetector sets the deny loop detector after construction, since
+// it is created with the interceptor chain.
+func (h *AdminAPIHandler) SetDenyLoopDetector(d *denyloop.Detector) {
+	h.denyLoops = d
+}
+
+// denyLoopsResponse is the JSON response of GET /admin/api/v1/deny-loops.
+type denyLoopsResponse struct {
+	Enabled       bool            `json:"enabled"`
+	Threshold     int             `json:"threshold,omitempty"`
+	WindowSeconds float64         `json:"window_seconds,omitempty"`
+	Loops         []denyloop.Loop `json:"loops"`
+}
+
+// handleListDenyLoops lists the calls currently in a deny loop.
+// GET /admin/api/v1/deny-loops
+func (h *AdminAPIHandler) handleListDenyLoops(w http.ResponseWriter, r *http.Request) {
+	if h.denyLoops == nil {
+		h.respondJSON(w, http.StatusOK, denyLoopsResponse{Loops: []denyloop.Loop{}})
+		return
+	}
+	cfg := h.denyLoops.Config()
+	h.respondJSON(w, http.StatusOK, denyLoopsResponse{
+		Enabled:       true,
+		Threshold:     cfg.Threshold,
+		WindowSeconds: cfg.Window.Seconds(),
+		Loops:         h.denyLoops.Loops(),
+	})
+}
+
+// handleClearDenyLoop ends a deny loop, lifting its backoff.
+// DELETE /admin/api/v1/deny-loops/{id}
+func (h *AdminAPIHandler) handleClearDenyLoop(w http.ResponseWriter, r *http.Request) {
+	if h.denyLoops == nil || !h.denyLoops.Clear(h.pathParam(r, "id")) {
+		h.respondError(w, http.StatusNotFound, "deny loop not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
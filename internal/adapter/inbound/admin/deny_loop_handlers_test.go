@@ -0,0 +1,59 @@
+package admin
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/denyloop"
+)
+
+func TestHandleDenyLoops(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	h := NewAdminAPIHandler(WithAPILogger(logger))
+
+	rec := outboundLearningRequest(t, h, http.MethodGet, "/admin/api/v1/deny-loops", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body=%s)", rec.Code, rec.Body.String())
+	}
+	var resp denyLoopsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Enabled || resp.Loops == nil || len(resp.Loops) != 0 {
+		t.Fatalf("response without detector = %+v", resp)
+	}
+
+	detector := denyloop.NewDetector(denyloop.Config{Threshold: 2})
+	h.SetDenyLoopDetector(detector)
+	key := denyloop.Key("id-1", "delete_file", nil)
+	for i := 0; i < 2; i++ {
+		detector.RecordDenial(key, denyloop.Denial{IdentityID: "id-1", IdentityName: "alice", Tool: "delete_file", Rule: "no-delete"})
+	}
+
+	rec = outboundLearningRequest(t, h, http.MethodGet, "/admin/api/v1/deny-loops", "")
+	resp = denyLoopsResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Enabled || resp.Threshold != 2 || len(resp.Loops) != 1 {
+		t.Fatalf("response = %+v", resp)
+	}
+	if l := resp.Loops[0]; l.ID != key || l.IdentityName != "alice" || l.Tool != "delete_file" || l.Denials != 2 || l.Rule != "no-delete" {
+		t.Errorf("loop = %+v", l)
+	}
+
+	rec = outboundLearningRequest(t, h, http.MethodDelete, "/admin/api/v1/deny-loops/"+key, "")
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("clear status = %d, want 204 (body=%s)", rec.Code, rec.Body.String())
+	}
+	rec = outboundLearningRequest(t, h, http.MethodDelete, "/admin/api/v1/deny-loops/"+key, "")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("second clear status = %d, want 404", rec.Code)
+	}
+	if len(detector.Loops()) != 0 {
+		t.Error("loop still listed after clear")
+	}
+}
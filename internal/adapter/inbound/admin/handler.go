@@ -114,26 +114,42 @@ func (h *AdminHandler) AddLogEntry(entry AuditEntry) {
 	}
 }
 
-// Handler returns an http.Handler with all admin routes.
+// Handler returns an http.Handler with all admin routes. What is served at
+// /admin/ follows server.admin_ui: the embedded UI, a bundle from a
+// directory, or nothing in API-only mode.
 func (h *AdminHandler) Handler() http.Handler {
 	mux := http.NewServeMux()
+	ui := h.cfg.Server.AdminUI
+	csp := defaultContentSecurityPolicy
+
+	switch ui.Mode {
+	case "disabled":
+		// API-only mode: /admin and /admin/ answer 404.
+	case "directory":
+		bundle := h.requireAuth(newBundleHandler(ui.Directory).ServeHTTP)
+		mux.HandleFunc("GET /admin", bundle)
+		mux.HandleFunc("GET /admin/", bundle)
+		if ui.ContentSecurityPolicy != "" {
+			csp = ui.ContentSecurityPolicy
+		}
+	default:
+		// Static files (no auth, no-cache for JS to prevent stale code)
+		staticSub, err := fs.Sub(staticFS, "static")
+		if err != nil {
+			// M-49: fs.Sub on an embedded FS with a valid path must not fail at init time.
+			// If it does, the binary is malformed — panic immediately rather than silently serve nothing.
+			panic("admin: failed to sub static filesystem: " + err.Error())
+		}
+		staticHandler := http.StripPrefix("/admin/static/", http.FileServer(http.FS(staticSub)))
+		mux.Handle("GET /admin/static/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", "no-cache, must-revalidate")
+			staticHandler.ServeHTTP(w, r)
+		}))
 
-	// Static files (no auth, no-cache for JS to prevent stale code)
-	staticSub, err := fs.Sub(staticFS, "static")
-	if err != nil {
-		// M-49: fs.Sub on an embedded FS with a valid path must not fail at init time.
-		// If it does, the binary is malformed — panic immediately rather than silently serve nothing.
-		panic("admin: failed to sub static filesystem: " + err.Error())
+		// SPA shell (auth required)
+		mux.HandleFunc("GET /admin", h.requireAuth(h.spaPage))
+		mux.HandleFunc("GET /admin/", h.requireAuth(h.spaPage))
 	}
-	staticHandler := http.StripPrefix("/admin/static/", http.FileServer(http.FS(staticSub)))
-	mux.Handle("GET /admin/static/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Cache-Control", "no-cache, must-revalidate")
-		staticHandler.ServeHTTP(w, r)
-	}))
-
-	// SPA shell (auth required)
-	mux.HandleFunc("GET /admin", h.requireAuth(h.spaPage))
-	mux.HandleFunc("GET /admin/", h.requireAuth(h.spaPage))
 
 	// API endpoints (auth required)
 	mux.HandleFunc("GET /admin/api/rules", h.requireAuth(h.listRules))
@@ -146,7 +162,7 @@ func (h *AdminHandler) Handler() http.Handler {
 	// (non-API template UI) while Routes() serves /admin/api/*. They are
 	// mounted on separate compositeMux paths in boot_transport.go and never
 	// overlap, so each route tree gets exactly one CSRF check.
-	return cspMiddleware(csrfMiddleware(mux))
+	return cspMiddlewareWithPolicy(csrfMiddleware(mux), csp, nil)
 }
 
 // requireAuth wraps a handler with authentication check.
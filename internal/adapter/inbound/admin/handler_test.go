@@ -1,6 +1,7 @@
 package admin
 
 import (
+	"bytes"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
@@ -86,3 +87,19 @@ func TestLegacyHandler_StaticFiles_AlwaysServed(t *testing.T) {
 		t.Errorf("GET /admin/static/... from remote: got %d, static files should not require auth", rec.Code)
 	}
 }
+
+// TestEmbeddedGuide_MatchesDocs verifies that the Guide served by the admin
+// UI is the one in docs/. Run `make docs` after editing docs/Guide.md.
+func TestEmbeddedGuide_MatchesDocs(t *testing.T) {
+	want, err := os.ReadFile("../../../../docs/Guide.md")
+	if err != nil {
+		t.Fatalf("read docs/Guide.md: %v", err)
+	}
+	got, err := staticFS.ReadFile("static/docs/Guide.md")
+	if err != nil {
+		t.Fatalf("read embedded Guide: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("static/docs/Guide.md differs from docs/Guide.md; run `make docs`")
+	}
+}
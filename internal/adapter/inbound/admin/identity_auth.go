@@ -0,0 +1,81 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+// keyIdentity is the identity an MCP API key authenticated on the
+// /admin/api/me/ routes.
+type keyIdentity struct {
+	ID    string
+	Name  string
+	Roles []string
+	// Key is the API key of the request.
+	Key state.APIKeyEntry
+}
+
+type keyIdentityCtxKey struct{}
+
+// keyIdentityFromContext returns the identity set by identityAuthMiddleware.
+func keyIdentityFromContext(ctx context.Context) keyIdentity {
+	id, _ := ctx.Value(keyIdentityCtxKey{}).(keyIdentity)
+	return id
+}
+
+// bearerAPIKey returns the MCP API key in the Authorization header, or ""
+// when the request carries none. Admin tokens are not API keys.
+func bearerAPIKey(r *http.Request) string {
+	value := r.Header.Get("Authorization")
+	if len(value) < len("Bearer ") || !strings.EqualFold(value[:len("Bearer ")], "Bearer ") {
+		return ""
+	}
+	key := strings.TrimSpace(value[len("Bearer "):])
+	if strings.HasPrefix(key, service.AdminTokenPrefix) {
+		return ""
+	}
+	return key
+}
+
+// identityAuthMiddleware authenticates the /admin/api/me/ routes by the MCP
+// API key of an identity, from any address: they only expose what concerns
+// that identity. Changes made through them are attributed to it.
+func (h *AdminAPIHandler) identityAuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cleartext := bearerAPIKey(r)
+		if cleartext == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="sentinelgate"`)
+			h.respondError(w, http.StatusUnauthorized, "an API key is required")
+			return
+		}
+		if h.identityService == nil {
+			h.respondError(w, http.StatusServiceUnavailable, "identity service not configured")
+			return
+		}
+		key, err := h.identityService.VerifyKey(r.Context(), cleartext)
+		if err != nil || (key.ExpiresAt != nil && !time.Now().Before(*key.ExpiresAt)) {
+			h.logger.Warn("identity API request with invalid key", "ip", h.clientIP(r), "path", r.URL.Path)
+			h.respondError(w, http.StatusUnauthorized, "invalid API key")
+			return
+		}
+		identity, err := h.identityService.GetIdentity(r.Context(), key.IdentityID)
+		if err != nil {
+			h.respondError(w, http.StatusUnauthorized, "invalid API key")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), keyIdentityCtxKey{}, keyIdentity{
+			ID:    identity.ID,
+			Name:  identity.Name,
+			Roles: identity.Roles,
+			Key:   *key,
+		})
+		ctx = service.WithActor(ctx, "identity:"+identity.Name)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
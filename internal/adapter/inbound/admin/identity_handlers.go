@@ -5,23 +5,28 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/auth"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
 )
 
 // identityRequest is the JSON body for create and update identity endpoints.
+// On update, a present "access" object replaces the restrictions and an
+// empty one removes them.
 type identityRequest struct {
-	Name  string   `json:"name"`
-	Roles []string `json:"roles"`
+	Name   string                     `json:"name"`
+	Roles  []string                   `json:"roles"`
+	Access *state.IdentityAccessEntry `json:"access,omitempty"`
 }
 
 // identityResponse is the JSON representation of an identity returned by the API.
 type identityResponse struct {
-	ID        string   `json:"id"`
-	Name      string   `json:"name"`
-	Roles     []string `json:"roles"`
-	ReadOnly  bool     `json:"read_only"`
-	CreatedAt string   `json:"created_at"`
+	ID        string                     `json:"id"`
+	Name      string                     `json:"name"`
+	Roles     []string                   `json:"roles"`
+	Access    *state.IdentityAccessEntry `json:"access,omitempty"`
+	ReadOnly  bool                       `json:"read_only"`
+	CreatedAt string                     `json:"created_at"`
 }
 
 // WithIdentityService sets the identity and API key management service.
@@ -51,6 +56,7 @@ func (h *AdminAPIHandler) handleListIdentities(w http.ResponseWriter, r *http.Re
 			ID:        identity.ID,
 			Name:      identity.Name,
 			Roles:     identity.Roles,
+			Access:    identity.Access,
 			ReadOnly:  identity.ReadOnly,
 			CreatedAt: identity.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"),
 		})
@@ -88,8 +94,9 @@ func (h *AdminAPIHandler) handleCreateIdentity(w http.ResponseWriter, r *http.Re
 	}
 
 	input := service.CreateIdentityInput{
-		Name:  req.Name,
-		Roles: req.Roles,
+		Name:   req.Name,
+		Roles:  req.Roles,
+		Access: req.Access,
 	}
 
 	identity, err := h.identityService.CreateIdentity(ctx, input)
@@ -98,6 +105,10 @@ func (h *AdminAPIHandler) handleCreateIdentity(w http.ResponseWriter, r *http.Re
 			h.respondError(w, http.StatusConflict, "identity name already exists")
 			return
 		}
+		if errors.Is(err, service.ErrInvalidAccess) {
+			h.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		h.internalError(w, "failed to create identity", err)
 		return
 	}
@@ -109,6 +120,7 @@ func (h *AdminAPIHandler) handleCreateIdentity(w http.ResponseWriter, r *http.Re
 		ID:        identity.ID,
 		Name:      identity.Name,
 		Roles:     identity.Roles,
+		Access:    identity.Access,
 		ReadOnly:  identity.ReadOnly,
 		CreatedAt: identity.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"),
 	})
@@ -139,7 +151,8 @@ func (h *AdminAPIHandler) handleUpdateIdentity(w http.ResponseWriter, r *http.Re
 	}
 
 	input := service.UpdateIdentityInput{
-		Roles: req.Roles,
+		Roles:  req.Roles,
+		Access: req.Access,
 	}
 	if req.Name != "" {
 		input.Name = &req.Name
@@ -159,6 +172,10 @@ func (h *AdminAPIHandler) handleUpdateIdentity(w http.ResponseWriter, r *http.Re
 			h.respondError(w, http.StatusForbidden, "cannot modify read-only identity")
 			return
 		}
+		if errors.Is(err, service.ErrInvalidAccess) {
+			h.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		h.internalError(w, "failed to update identity", err)
 		return
 	}
@@ -167,6 +184,7 @@ func (h *AdminAPIHandler) handleUpdateIdentity(w http.ResponseWriter, r *http.Re
 		ID:        identity.ID,
 		Name:      identity.Name,
 		Roles:     identity.Roles,
+		Access:    identity.Access,
 		ReadOnly:  identity.ReadOnly,
 		CreatedAt: identity.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"),
 	})
@@ -0,0 +1,114 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/scheduler"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+// WithJobService sets the recurring job service.
+func WithJobService(s *service.JobService) AdminAPIOption {
+	return func(h *AdminAPIHandler) { h.jobService = s }
+}
+
+// SetJobService sets the recurring job service after construction.
+func (h *AdminAPIHandler) SetJobService(s *service.JobService) {
+	h.jobService = s
+}
+
+// updateJobRequest is the request body of PUT /admin/api/jobs/{name}.
+type updateJobRequest struct {
+	Schedule string `json:"schedule"`
+	Enabled  bool   `json:"enabled"`
+}
+
+// handleListJobs returns every recurring job with its schedule, next run
+// and last run status.
+// GET /admin/api/jobs
+func (h *AdminAPIHandler) handleListJobs(w http.ResponseWriter, r *http.Request) {
+	if h.jobService == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "scheduler not available")
+		return
+	}
+	h.respondJSON(w, http.StatusOK, h.jobService.List())
+}
+
+// handleGetJob returns one job with its recent run history.
+// GET /admin/api/jobs/{name}
+func (h *AdminAPIHandler) handleGetJob(w http.ResponseWriter, r *http.Request) {
+	if h.jobService == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "scheduler not available")
+		return
+	}
+	status, err := h.jobService.Get(h.pathParam(r, "name"))
+	if err != nil {
+		h.respondJobError(w, err)
+		return
+	}
+	h.respondJSON(w, http.StatusOK, status)
+}
+
+// handleUpdateJob changes a job's schedule and whether it runs on it.
+// PUT /admin/api/jobs/{name}
+func (h *AdminAPIHandler) handleUpdateJob(w http.ResponseWriter, r *http.Request) {
+	if h.jobService == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "scheduler not available")
+		return
+	}
+	var req updateJobRequest
+	if err := h.readJSON(r, &req); err != nil {
+		h.handleReadJSONErr(w, err)
+		return
+	}
+	name := h.pathParam(r, "name")
+	if _, err := h.jobService.Get(name); err != nil {
+		h.respondJobError(w, err)
+		return
+	}
+	if _, err := scheduler.Parse(req.Schedule); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid schedule: "+err.Error())
+		return
+	}
+
+	status, err := h.jobService.UpdateSchedule(r.Context(), name, req.Schedule, req.Enabled)
+	if err != nil {
+		h.respondJobError(w, err)
+		return
+	}
+	h.respondJSON(w, http.StatusOK, status)
+}
+
+// handleRunJob starts a job now, outside its schedule. The run happens in
+// the background; poll GET /admin/api/jobs/{name} for its outcome.
+// POST /admin/api/jobs/{name}/run
+func (h *AdminAPIHandler) handleRunJob(w http.ResponseWriter, r *http.Request) {
+	if h.jobService == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "scheduler not available")
+		return
+	}
+	name := h.pathParam(r, "name")
+	if err := h.jobService.Trigger(name); err != nil {
+		h.respondJobError(w, err)
+		return
+	}
+	status, err := h.jobService.Get(name)
+	if err != nil {
+		h.respondJobError(w, err)
+		return
+	}
+	h.logger.Info("job triggered manually", "job", name)
+	h.respondJSON(w, http.StatusAccepted, status)
+}
+
+func (h *AdminAPIHandler) respondJobError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, scheduler.ErrJobNotFound):
+		h.respondError(w, http.StatusNotFound, "job not found")
+	case errors.Is(err, scheduler.ErrJobRunning):
+		h.respondError(w, http.StatusConflict, err.Error())
+	default:
+		h.internalError(w, "job request failed", err)
+	}
+}
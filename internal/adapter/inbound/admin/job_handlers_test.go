@@ -0,0 +1,99 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/scheduler"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+func TestHandleJobs(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	sched := scheduler.New(logger)
+	release := make(chan struct{})
+	finished := make(chan struct{})
+	if err := sched.Register(scheduler.Job{
+		Name:     "cleanup",
+		Schedule: "@daily",
+		Enabled:  true,
+		Run: func(ctx context.Context) (string, error) {
+			defer close(finished)
+			<-release
+			return "done", nil
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	h := NewAdminAPIHandler(WithJobService(service.NewJobService(sched, nil, logger)), WithAPILogger(logger))
+
+	rec := sloTestRequest(t, h, "/admin/api/jobs")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("list status = %d, want 200", rec.Code)
+	}
+	var list []scheduler.JobStatus
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(list) != 1 || list[0].Name != "cleanup" || list[0].NextRun == nil {
+		t.Fatalf("list = %+v, want cleanup with a next run", list)
+	}
+
+	if rec := sloTestRequest(t, h, "/admin/api/jobs/missing"); rec.Code != http.StatusNotFound {
+		t.Errorf("get unknown status = %d, want 404", rec.Code)
+	}
+	if rec := outboundLearningRequest(t, h, http.MethodPost, "/admin/api/jobs/missing/run", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("run unknown status = %d, want 404", rec.Code)
+	}
+
+	rec = outboundLearningRequest(t, h, http.MethodPut, "/admin/api/jobs/cleanup", `{"schedule":"61 * * * *","enabled":true}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid schedule status = %d, want 400", rec.Code)
+	}
+	rec = outboundLearningRequest(t, h, http.MethodPut, "/admin/api/jobs/cleanup", `{"schedule":"*/15 * * * *","enabled":false}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("update status = %d, want 200 (body=%s)", rec.Code, rec.Body.String())
+	}
+	var updated scheduler.JobStatus
+	if err := json.NewDecoder(rec.Body).Decode(&updated); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if updated.Schedule != "*/15 * * * *" || updated.Enabled || updated.NextRun != nil {
+		t.Errorf("updated = %+v, want disabled */15 schedule", updated)
+	}
+
+	rec = outboundLearningRequest(t, h, http.MethodPost, "/admin/api/jobs/cleanup/run", "")
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("run status = %d, want 202 (body=%s)", rec.Code, rec.Body.String())
+	}
+	rec = outboundLearningRequest(t, h, http.MethodPost, "/admin/api/jobs/cleanup/run", "")
+	if rec.Code != http.StatusConflict {
+		t.Errorf("second run status = %d, want 409", rec.Code)
+	}
+	close(release)
+	<-finished
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		rec = sloTestRequest(t, h, "/admin/api/jobs/cleanup")
+		var status scheduler.JobStatus
+		if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if status.LastRun != nil {
+			if status.LastRun.Trigger != scheduler.TriggerManual || status.LastRun.Message != "done" || len(status.History) != 1 {
+				t.Errorf("status = %+v, want one manual run", status)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("manual run never recorded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -9,28 +9,32 @@ import (
 
 // generateKeyRequest is the JSON body for the generate key endpoint.
 type generateKeyRequest struct {
-	IdentityID string `json:"identity_id"`
-	Name       string `json:"name"`
+	IdentityID     string   `json:"identity_id"`
+	Name           string   `json:"name"`
+	AllowedOrigins []string `json:"allowed_origins,omitempty"`
 }
 
 // generateKeyResponse is the JSON response for key generation.
 // The CleartextKey is returned exactly once and never stored.
 type generateKeyResponse struct {
-	ID           string `json:"id"`
-	IdentityID   string `json:"identity_id"`
-	Name         string `json:"name"`
-	CleartextKey string `json:"cleartext_key"`
-	CreatedAt    string `json:"created_at"`
+	ID             string   `json:"id"`
+	IdentityID     string   `json:"identity_id"`
+	Name           string   `json:"name"`
+	CleartextKey   string   `json:"cleartext_key"`
+	CreatedAt      string   `json:"created_at"`
+	AllowedOrigins []string `json:"allowed_origins"`
 }
 
 // keyResponse is the JSON representation of an API key (without cleartext).
+// AllowedOrigins is empty when the key is not bound to any origin.
 type keyResponse struct {
-	ID         string `json:"id"`
-	IdentityID string `json:"identity_id"`
-	Name       string `json:"name"`
-	Revoked    bool   `json:"revoked"`
-	ReadOnly   bool   `json:"read_only"`
-	CreatedAt  string `json:"created_at"`
+	ID             string   `json:"id"`
+	IdentityID     string   `json:"identity_id"`
+	Name           string   `json:"name"`
+	Revoked        bool     `json:"revoked"`
+	ReadOnly       bool     `json:"read_only"`
+	CreatedAt      string   `json:"created_at"`
+	AllowedOrigins []string `json:"allowed_origins"`
 }
 
 // handleListKeys returns all API keys across all identities.
@@ -52,12 +56,13 @@ func (h *AdminAPIHandler) handleListKeys(w http.ResponseWriter, r *http.Request)
 	result := make([]keyResponse, 0, len(keys))
 	for _, k := range keys {
 		result = append(result, keyResponse{
-			ID:         k.ID,
-			IdentityID: k.IdentityID,
-			Name:       k.Name,
-			Revoked:    k.Revoked,
-			ReadOnly:   k.ReadOnly,
-			CreatedAt:  k.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"),
+			ID:             k.ID,
+			IdentityID:     k.IdentityID,
+			Name:           k.Name,
+			Revoked:        k.Revoked,
+			ReadOnly:       k.ReadOnly,
+			CreatedAt:      k.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"),
+			AllowedOrigins: nonNilOrigins(k.AllowedOrigins),
 		})
 	}
 
@@ -89,8 +94,9 @@ func (h *AdminAPIHandler) handleGenerateKey(w http.ResponseWriter, r *http.Reque
 	}
 
 	input := service.GenerateKeyInput{
-		IdentityID: req.IdentityID,
-		Name:       req.Name,
+		IdentityID:     req.IdentityID,
+		Name:           req.Name,
+		AllowedOrigins: req.AllowedOrigins,
 	}
 
 	result, err := h.identityService.GenerateKey(ctx, input)
@@ -99,6 +105,10 @@ func (h *AdminAPIHandler) handleGenerateKey(w http.ResponseWriter, r *http.Reque
 			h.respondError(w, http.StatusNotFound, "identity not found")
 			return
 		}
+		if errors.Is(err, service.ErrInvalidOrigin) {
+			h.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		// SECU-06: Only log the error, never the cleartext key.
 		h.logger.Error("failed to generate key", "error", err)
 		h.respondError(w, http.StatusInternalServerError, "failed to generate key")
@@ -112,14 +122,24 @@ func (h *AdminAPIHandler) handleGenerateKey(w http.ResponseWriter, r *http.Reque
 	w.Header().Set("Cache-Control", "no-store")
 	w.Header().Set("Pragma", "no-cache")
 	h.respondJSON(w, http.StatusCreated, generateKeyResponse{
-		ID:           result.KeyEntry.ID,
-		IdentityID:   result.KeyEntry.IdentityID,
-		Name:         result.KeyEntry.Name,
-		CleartextKey: result.CleartextKey,
-		CreatedAt:    result.KeyEntry.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"),
+		ID:             result.KeyEntry.ID,
+		IdentityID:     result.KeyEntry.IdentityID,
+		Name:           result.KeyEntry.Name,
+		CleartextKey:   result.CleartextKey,
+		CreatedAt:      result.KeyEntry.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"),
+		AllowedOrigins: nonNilOrigins(result.KeyEntry.AllowedOrigins),
 	})
 }
 
+// nonNilOrigins returns origins, or an empty slice so unbound keys
+// serialize as [] rather than null.
+func nonNilOrigins(origins []string) []string {
+	if origins == nil {
+		return []string{}
+	}
+	return origins
+}
+
 // handleRevokeKey revokes an API key.
 // DELETE /admin/api/keys/{id}
 // BUG-6 FIX: After revoking, invalidates all cached sessions for the key's
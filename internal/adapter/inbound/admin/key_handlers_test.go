@@ -94,6 +94,54 @@ func TestHandleGenerateKey_MissingName(t *testing.T) {
 	}
 }
 
+func TestHandleGenerateKey_AllowedOrigins(t *testing.T) {
+	env := setupIdentityTestEnv(t)
+	ctx := context.Background()
+
+	identity, err := env.identityService.CreateIdentity(ctx, service.CreateIdentityInput{
+		Name: "browser-user",
+	})
+	if err != nil {
+		t.Fatalf("CreateIdentity: %v", err)
+	}
+
+	rec := env.doRequest(t, "POST", "/admin/api/keys", generateKeyRequest{
+		IdentityID:     identity.ID,
+		Name:           "web-key",
+		AllowedOrigins: []string{"https://App.Example.com/"},
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST /admin/api/keys status = %d, want %d (body=%s)", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+	var created generateKeyResponse
+	if err := json.NewDecoder(rec.Body).Decode(&created); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(created.AllowedOrigins) != 1 || created.AllowedOrigins[0] != "https://app.example.com" {
+		t.Errorf("AllowedOrigins = %v, want normalized [https://app.example.com]", created.AllowedOrigins)
+	}
+
+	// The key list shows the binding.
+	rec = env.doRequest(t, "GET", "/admin/api/keys", nil)
+	var keys []keyResponse
+	if err := json.NewDecoder(rec.Body).Decode(&keys); err != nil {
+		t.Fatalf("decode list: %v", err)
+	}
+	if len(keys) != 1 || len(keys[0].AllowedOrigins) != 1 {
+		t.Fatalf("listed keys = %+v, want one key with one origin", keys)
+	}
+
+	// Malformed origins are rejected.
+	rec = env.doRequest(t, "POST", "/admin/api/keys", generateKeyRequest{
+		IdentityID:     identity.ID,
+		Name:           "bad-key",
+		AllowedOrigins: []string{"https://app.example.com/path"},
+	})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("POST /admin/api/keys bad origin status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
 // --- Revoke Key ---
 
 func TestHandleRevokeKey(t *testing.T) {
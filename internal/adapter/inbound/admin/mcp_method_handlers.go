@@ -0,0 +1,35 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/validation"
+)
+
+// mcpMethodsResponse is the JSON body of GET /admin/api/mcp-methods.
+type mcpMethodsResponse struct {
+	Rules   []validation.MethodRule  `json:"rules"`
+	Unknown validation.MethodRule    `json:"unknown"`
+	Methods []validation.MethodStats `json:"methods"`
+}
+
+// SetMCPMethodPolicy wires the policy handling non-tool MCP methods.
+func (h *AdminAPIHandler) SetMCPMethodPolicy(p *validation.MethodPolicy) {
+	h.mcpMethodPolicy = p
+}
+
+// handleGetMCPMethods returns the configured method rules and how often
+// each method was forwarded, routed, denied or answered locally.
+// GET /admin/api/mcp-methods
+func (h *AdminAPIHandler) handleGetMCPMethods(w http.ResponseWriter, r *http.Request) {
+	if h.mcpMethodPolicy == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "method policy not available")
+		return
+	}
+	rules, unknown := h.mcpMethodPolicy.Rules()
+	h.respondJSON(w, http.StatusOK, mcpMethodsResponse{
+		Rules:   rules,
+		Unknown: unknown,
+		Methods: h.mcpMethodPolicy.Stats(),
+	})
+}
@@ -0,0 +1,73 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/proxy"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/validation"
+)
+
+func TestHandleGetMCPMethods(t *testing.T) {
+	h := NewAdminAPIHandler()
+	if rec := sloTestRequest(t, h, "/admin/api/mcp-methods"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("without policy: status = %d, want 503", rec.Code)
+	}
+
+	policy, err := validation.NewMethodPolicy([]validation.MethodRule{
+		{Method: "completion/complete", Action: validation.MethodRoute, Upstream: "search"},
+	}, validation.MethodRule{Action: validation.MethodForward})
+	if err != nil {
+		t.Fatalf("NewMethodPolicy: %v", err)
+	}
+	policy.Record("completion/complete", validation.MethodRoute)
+	policy.Record("ping", validation.MethodLocal)
+	h.SetMCPMethodPolicy(policy)
+
+	rec := sloTestRequest(t, h, "/admin/api/mcp-methods")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body=%s)", rec.Code, rec.Body.String())
+	}
+	var resp mcpMethodsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Rules) != 1 || resp.Rules[0].Upstream != "search" || resp.Unknown.Action != validation.MethodForward {
+		t.Errorf("rules = %+v, unknown = %+v", resp.Rules, resp.Unknown)
+	}
+	if len(resp.Methods) != 2 || resp.Methods[0].Method != "completion/complete" || resp.Methods[0].Routed != 1 || resp.Methods[1].Local != 1 {
+		t.Errorf("methods = %+v", resp.Methods)
+	}
+}
+
+func TestHandleGetUpstreamNotifications(t *testing.T) {
+	h := NewAdminAPIHandler()
+	if rec := sloTestRequest(t, h, "/admin/api/upstream-notifications"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("without policy: status = %d, want 503", rec.Code)
+	}
+
+	policy, err := proxy.NewNotificationPolicy([]proxy.NotificationRule{
+		{Upstream: "chatty", Method: "notifications/message", Action: proxy.NotificationDrop},
+	})
+	if err != nil {
+		t.Fatalf("NewNotificationPolicy: %v", err)
+	}
+	policy.Decide("chatty", "notifications/message")
+	h.SetNotificationPolicy(policy)
+
+	rec := sloTestRequest(t, h, "/admin/api/upstream-notifications")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body=%s)", rec.Code, rec.Body.String())
+	}
+	var resp upstreamNotificationsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Rules) != 1 || resp.Rules[0].Upstream != "chatty" {
+		t.Errorf("rules = %+v", resp.Rules)
+	}
+	if len(resp.Notifications) != 1 || resp.Notifications[0].Dropped != 1 {
+		t.Errorf("notifications = %+v", resp.Notifications)
+	}
+}
@@ -0,0 +1,56 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+// WithNoticeService sets the notice service.
+func WithNoticeService(s *service.NoticeService) AdminAPIOption {
+	return func(h *AdminAPIHandler) { h.noticeService = s }
+}
+
+// handleGetNotice returns the notice with its identity overrides.
+// GET /admin/api/notice
+func (h *AdminAPIHandler) handleGetNotice(w http.ResponseWriter, r *http.Request) {
+	if h.noticeService == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "notice not available")
+		return
+	}
+	h.respondJSON(w, http.StatusOK, h.noticeService.Get())
+}
+
+// handleSetNotice replaces the notice. It applies to sessions initialized
+// afterwards; an empty banner and terms turn the notice off.
+// PUT /admin/api/notice
+func (h *AdminAPIHandler) handleSetNotice(w http.ResponseWriter, r *http.Request) {
+	if h.noticeService == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "notice not available")
+		return
+	}
+	var req service.NoticeConfig
+	if err := h.readJSON(r, &req); err != nil {
+		h.handleReadJSONErr(w, err)
+		return
+	}
+	if h.identityService != nil {
+		for id := range req.Identities {
+			if _, err := h.identityService.GetIdentity(r.Context(), id); err != nil {
+				h.respondError(w, http.StatusBadRequest, "unknown identity: "+id)
+				return
+			}
+		}
+	}
+	cfg, err := h.noticeService.Set(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidNotice) {
+			h.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.internalError(w, "notice update failed", err)
+		return
+	}
+	h.respondJSON(w, http.StatusOK, cfg)
+}
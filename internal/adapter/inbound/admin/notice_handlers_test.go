@@ -0,0 +1,59 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/session"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+func TestHandleNotice(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	stateStore := state.NewFileStateStore(filepath.Join(t.TempDir(), "state.json"), logger)
+	if err := stateStore.Save(stateStore.DefaultState()); err != nil {
+		t.Fatalf("save default state: %v", err)
+	}
+	identitySvc := service.NewIdentityService(stateStore, logger)
+	ci, err := identitySvc.CreateIdentity(context.Background(), service.CreateIdentityInput{Name: "ci-bot"})
+	if err != nil {
+		t.Fatalf("CreateIdentity: %v", err)
+	}
+	noticeSvc := service.NewNoticeService(stateStore, logger)
+	h := NewAdminAPIHandler(
+		WithNoticeService(noticeSvc),
+		WithIdentityService(identitySvc),
+		WithAPILogger(logger),
+	)
+
+	body := `{"banner":"PRODUCTION — all actions audited","terms":"Acceptable use applies.","identities":{"` + ci.ID + `":{"banner":"CI"}}}`
+	rec := outboundLearningRequest(t, h, http.MethodPut, "/admin/api/notice", body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("put status = %d, want 200 (body=%s)", rec.Code, rec.Body.String())
+	}
+	if n := noticeSvc.NoticeFor(&session.Session{IdentityID: ci.ID}); n == nil || n.Banner != "CI" {
+		t.Errorf("override notice = %+v, want CI", n)
+	}
+
+	if rec := outboundLearningRequest(t, h, http.MethodPut, "/admin/api/notice", `{"identities":{"nobody":{"banner":"x"}}}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown identity status = %d, want 400", rec.Code)
+	}
+	if rec := outboundLearningRequest(t, h, http.MethodPut, "/admin/api/notice", `{"banner":"two\nlines"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("multi-line banner status = %d, want 400", rec.Code)
+	}
+
+	rec = sloTestRequest(t, h, "/admin/api/notice")
+	var got service.NoticeConfig
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.Banner != "PRODUCTION — all actions audited" || len(got.Identities) != 1 {
+		t.Errorf("get = %+v", got)
+	}
+}
@@ -0,0 +1,144 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+// maxOutboundLearningDuration bounds a learning window.
+const maxOutboundLearningDuration = 90 * 24 * time.Hour
+
+// WithOutboundLearningService sets the outbound allowlist learning service.
+func WithOutboundLearningService(s *service.OutboundLearningService) AdminAPIOption {
+	return func(h *AdminAPIHandler) { h.outboundLearning = s }
+}
+
+// SetOutboundLearningService sets the outbound allowlist learning service after construction.
+func (h *AdminAPIHandler) SetOutboundLearningService(s *service.OutboundLearningService) {
+	h.outboundLearning = s
+}
+
+// outboundLearningResponse is the response body of the learning endpoints.
+type outboundLearningResponse struct {
+	service.OutboundLearningStatus
+	Proposal []service.OutboundAllowlistProposal `json:"proposal"`
+}
+
+// startOutboundLearningRequest is the request body of POST .../learning/start.
+type startOutboundLearningRequest struct {
+	// Duration is a Go duration such as "72h".
+	Duration string `json:"duration"`
+}
+
+func (h *AdminAPIHandler) outboundLearningResponse() outboundLearningResponse {
+	return outboundLearningResponse{
+		OutboundLearningStatus: h.outboundLearning.Status(),
+		Proposal:               h.outboundLearning.Proposal(),
+	}
+}
+
+// handleGetOutboundLearning returns the learning window and the proposed
+// allowlist grouped by identity and tool.
+// GET /admin/api/v1/outbound/learning
+func (h *AdminAPIHandler) handleGetOutboundLearning(w http.ResponseWriter, r *http.Request) {
+	if h.outboundLearning == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "outbound learning not available")
+		return
+	}
+	h.respondJSON(w, http.StatusOK, h.outboundLearningResponse())
+}
+
+// handleStartOutboundLearning opens a learning window, discarding what a
+// previous window learned.
+// POST /admin/api/v1/outbound/learning/start
+func (h *AdminAPIHandler) handleStartOutboundLearning(w http.ResponseWriter, r *http.Request) {
+	if h.outboundLearning == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "outbound learning not available")
+		return
+	}
+	var req startOutboundLearningRequest
+	if err := h.readJSON(r, &req); err != nil {
+		h.handleReadJSONErr(w, err)
+		return
+	}
+	duration, err := time.ParseDuration(req.Duration)
+	if err != nil || duration <= 0 || duration > maxOutboundLearningDuration {
+		h.respondError(w, http.StatusBadRequest, "invalid 'duration': expected a duration up to 2160h")
+		return
+	}
+
+	if _, err := h.outboundLearning.Start(r.Context(), duration); err != nil {
+		if errors.Is(err, service.ErrLearningActive) {
+			h.respondError(w, http.StatusConflict, err.Error())
+			return
+		}
+		h.internalError(w, "failed to start outbound learning", err)
+		return
+	}
+	h.respondJSON(w, http.StatusOK, h.outboundLearningResponse())
+}
+
+// handleStopOutboundLearning closes the learning window early. What was
+// learned is kept for review.
+// POST /admin/api/v1/outbound/learning/stop
+func (h *AdminAPIHandler) handleStopOutboundLearning(w http.ResponseWriter, r *http.Request) {
+	if h.outboundLearning == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "outbound learning not available")
+		return
+	}
+	if _, err := h.outboundLearning.Stop(r.Context()); err != nil {
+		h.internalError(w, "failed to stop outbound learning", err)
+		return
+	}
+	h.respondJSON(w, http.StatusOK, h.outboundLearningResponse())
+}
+
+// handleResetOutboundLearning discards all learned destinations.
+// DELETE /admin/api/v1/outbound/learning
+func (h *AdminAPIHandler) handleResetOutboundLearning(w http.ResponseWriter, r *http.Request) {
+	if h.outboundLearning == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "outbound learning not available")
+		return
+	}
+	if err := h.outboundLearning.Reset(r.Context()); err != nil {
+		h.internalError(w, "failed to reset outbound learning", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleApplyOutboundLearning converts the proposed allowlist into an
+// enforced policy.
+// POST /admin/api/v1/outbound/learning/apply
+func (h *AdminAPIHandler) handleApplyOutboundLearning(w http.ResponseWriter, r *http.Request) {
+	if h.outboundLearning == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "outbound learning not available")
+		return
+	}
+	var opts service.OutboundApplyOptions
+	if r.ContentLength != 0 {
+		if err := h.readJSON(r, &opts); err != nil {
+			h.handleReadJSONErr(w, err)
+			return
+		}
+	}
+	if opts.Priority < 0 {
+		h.respondError(w, http.StatusBadRequest, "priority must not be negative")
+		return
+	}
+
+	created, err := h.outboundLearning.ApplyProposal(r.Context(), opts)
+	if err != nil {
+		if errors.Is(err, service.ErrNoLearnedDestinations) {
+			h.respondError(w, http.StatusConflict, err.Error())
+			return
+		}
+		h.internalError(w, "failed to apply learned allowlist", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, toPolicyResponse(created))
+}
@@ -0,0 +1,80 @@
+package admin
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/action"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+// outboundLearningCSRFToken is a fixed CSRF token for state-changing requests.
+const outboundLearningCSRFToken = "test-csrf-token-for-outbound-learning-tests"
+
+func outboundLearningRequest(t *testing.T, h *AdminAPIHandler, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.RemoteAddr = "127.0.0.1:12345"
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if method != http.MethodGet {
+		req.AddCookie(&http.Cookie{Name: "sentinel_csrf_token", Value: outboundLearningCSRFToken})
+		req.Header.Set("X-CSRF-Token", outboundLearningCSRFToken)
+	}
+	rec := httptest.NewRecorder()
+	h.Routes().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandleOutboundLearning(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	learning := service.NewOutboundLearningService(nil, nil, logger)
+	h := NewAdminAPIHandler(WithOutboundLearningService(learning), WithAPILogger(logger))
+
+	rec := outboundLearningRequest(t, h, http.MethodPost, "/admin/api/v1/outbound/learning/start", `{"duration":"forever"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad duration status = %d, want 400", rec.Code)
+	}
+
+	rec = outboundLearningRequest(t, h, http.MethodPost, "/admin/api/v1/outbound/learning/start", `{"duration":"72h"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("start status = %d, want 200 (body=%s)", rec.Code, rec.Body.String())
+	}
+	rec = outboundLearningRequest(t, h, http.MethodPost, "/admin/api/v1/outbound/learning/start", `{"duration":"72h"}`)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("second start status = %d, want 409", rec.Code)
+	}
+
+	learning.ObserveDestination("id-1", "alice", "fetch", action.Destination{Domain: "api.github.com"})
+
+	rec = sloTestRequest(t, h, "/admin/api/v1/outbound/learning")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("get status = %d, want 200", rec.Code)
+	}
+	var resp outboundLearningResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !resp.Active || resp.Observations != 1 || len(resp.Proposal) != 1 || resp.Proposal[0].Destinations[0].Host != "api.github.com" {
+		t.Errorf("response = %+v, want active with one learned host", resp)
+	}
+
+	rec = outboundLearningRequest(t, h, http.MethodPost, "/admin/api/v1/outbound/learning/stop", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("stop status = %d, want 200", rec.Code)
+	}
+	rec = outboundLearningRequest(t, h, http.MethodDelete, "/admin/api/v1/outbound/learning", "")
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("reset status = %d, want 204", rec.Code)
+	}
+	rec = outboundLearningRequest(t, h, http.MethodPost, "/admin/api/v1/outbound/learning/apply", `{"default_deny":true}`)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("apply after reset status = %d, want 409 (body=%s)", rec.Code, rec.Body.String())
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"time"
 
+	celAdapter "github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/cel"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/policy"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
 )
@@ -34,6 +35,10 @@ type ruleRequest struct {
 	Action          string `json:"action"`
 	ApprovalTimeout string `json:"approval_timeout,omitempty"`
 	TimeoutAction   string `json:"timeout_action,omitempty"`
+	ExemptScope     string `json:"exempt_scope,omitempty"`
+	ExemptDuration  string `json:"exempt_duration,omitempty"`
+	HelpText        string `json:"help_text,omitempty"`
+	HelpURL         string `json:"help_url,omitempty"`
 	Source          string `json:"source,omitempty"`
 }
 
@@ -59,6 +64,10 @@ type ruleResponse struct {
 	Action          string    `json:"action"`
 	ApprovalTimeout string    `json:"approval_timeout,omitempty"`
 	TimeoutAction   string    `json:"timeout_action,omitempty"`
+	ExemptScope     string    `json:"exempt_scope,omitempty"`
+	ExemptDuration  string    `json:"exempt_duration,omitempty"`
+	HelpText        string    `json:"help_text,omitempty"`
+	HelpURL         string    `json:"help_url,omitempty"`
 	Source          string    `json:"source,omitempty"`
 	CreatedAt       time.Time `json:"created_at"`
 }
@@ -74,6 +83,8 @@ func toPolicyResponse(p *policy.Policy) policyResponse {
 			ToolMatch: r.ToolMatch,
 			Condition: r.Condition,
 			Action:    string(r.Action),
+			HelpText:  r.HelpText,
+			HelpURL:   r.HelpURL,
 			Source:    r.Source,
 			CreatedAt: r.CreatedAt,
 		}
@@ -83,6 +94,10 @@ func toPolicyResponse(p *policy.Policy) policyResponse {
 		if r.TimeoutAction != "" {
 			rules[i].TimeoutAction = string(r.TimeoutAction)
 		}
+		rules[i].ExemptScope = string(r.ExemptScope)
+		if r.ExemptDuration > 0 {
+			rules[i].ExemptDuration = r.ExemptDuration.String()
+		}
 	}
 	return policyResponse{
 		ID:          p.ID,
@@ -116,6 +131,8 @@ func toDomainPolicy(req policyRequest) (*policy.Policy, error) {
 			ToolMatch: toolMatch,
 			Condition: cond,
 			Action:    policy.Action(r.Action),
+			HelpText:  r.HelpText,
+			HelpURL:   r.HelpURL,
 			Source:    r.Source,
 		}
 		if r.ApprovalTimeout != "" {
@@ -129,6 +146,14 @@ func toDomainPolicy(req policyRequest) (*policy.Policy, error) {
 		if r.TimeoutAction != "" {
 			rules[i].TimeoutAction = policy.Action(r.TimeoutAction)
 		}
+		rules[i].ExemptScope = policy.ExemptScope(r.ExemptScope)
+		if r.ExemptDuration != "" {
+			d, parseErr := time.ParseDuration(r.ExemptDuration)
+			if parseErr != nil {
+				return nil, fmt.Errorf("rule %q: invalid exempt_duration %q: %w", r.Name, r.ExemptDuration, parseErr)
+			}
+			rules[i].ExemptDuration = d
+		}
 	}
 	// If no rules were provided but top-level rule fields exist,
 	// create a single rule from the top-level fields.
@@ -189,6 +214,17 @@ func (h *AdminAPIHandler) handleListPolicies(w http.ResponseWriter, r *http.Requ
 	h.respondJSON(w, http.StatusOK, result)
 }
 
+// invalidPolicyMessage returns the client message for an invalid policy.
+// Only CEL limit violations (banned functions, complexity) are detailed, so
+// the admin can rework the condition.
+func invalidPolicyMessage(err error) string {
+	var limitErr *celAdapter.LimitError
+	if errors.As(err, &limitErr) {
+		return "invalid policy configuration: " + limitErr.Error()
+	}
+	return "invalid policy configuration"
+}
+
 // handleCreatePolicy creates a new policy from the request body.
 // POST /admin/api/policies
 func (h *AdminAPIHandler) handleCreatePolicy(w http.ResponseWriter, r *http.Request) {
@@ -216,6 +252,12 @@ func (h *AdminAPIHandler) handleCreatePolicy(w http.ResponseWriter, r *http.Requ
 				return
 			}
 		}
+		if r.ExemptDuration != "" {
+			if _, err := time.ParseDuration(r.ExemptDuration); err != nil {
+				h.respondError(w, http.StatusBadRequest, "invalid exempt_duration: "+r.ExemptDuration)
+				return
+			}
+		}
 	}
 
 	p, convErr := toDomainPolicy(req)
@@ -226,7 +268,7 @@ func (h *AdminAPIHandler) handleCreatePolicy(w http.ResponseWriter, r *http.Requ
 	created, err := h.policyAdminService.Create(r.Context(), p)
 	if err != nil {
 		if errors.Is(err, service.ErrInvalidPolicy) {
-			h.respondError(w, http.StatusBadRequest, "invalid policy configuration")
+			h.respondError(w, http.StatusBadRequest, invalidPolicyMessage(err))
 			return
 		}
 		h.logger.Error("failed to create policy", "error", err)
@@ -270,6 +312,12 @@ func (h *AdminAPIHandler) handleUpdatePolicy(w http.ResponseWriter, r *http.Requ
 				return
 			}
 		}
+		if r.ExemptDuration != "" {
+			if _, err := time.ParseDuration(r.ExemptDuration); err != nil {
+				h.respondError(w, http.StatusBadRequest, "invalid exempt_duration: "+r.ExemptDuration)
+				return
+			}
+		}
 	}
 
 	p, convErr := toDomainPolicy(req)
@@ -283,8 +331,12 @@ func (h *AdminAPIHandler) handleUpdatePolicy(w http.ResponseWriter, r *http.Requ
 			h.respondError(w, http.StatusNotFound, "policy not found")
 			return
 		}
+		if errors.Is(err, service.ErrFilePolicy) {
+			h.respondError(w, http.StatusForbidden, "policy is managed by a file in the policy directory")
+			return
+		}
 		if errors.Is(err, service.ErrInvalidPolicy) {
-			h.respondError(w, http.StatusBadRequest, "invalid policy configuration")
+			h.respondError(w, http.StatusBadRequest, invalidPolicyMessage(err))
 			return
 		}
 		h.logger.Error("failed to update policy", "error", err, "id", id)
@@ -315,6 +367,10 @@ func (h *AdminAPIHandler) handleDeletePolicy(w http.ResponseWriter, r *http.Requ
 			h.respondError(w, http.StatusForbidden, "cannot delete the default policy")
 			return
 		}
+		if errors.Is(err, service.ErrFilePolicy) {
+			h.respondError(w, http.StatusForbidden, "policy is managed by a file in the policy directory")
+			return
+		}
 		if errors.Is(err, service.ErrPolicyNotFound) {
 			h.respondError(w, http.StatusNotFound, "policy not found")
 			return
@@ -348,6 +404,10 @@ func (h *AdminAPIHandler) handleDeleteRule(w http.ResponseWriter, r *http.Reques
 			h.respondError(w, http.StatusForbidden, "cannot modify the default policy")
 			return
 		}
+		if errors.Is(err, service.ErrFilePolicy) {
+			h.respondError(w, http.StatusForbidden, "policy is managed by a file in the policy directory")
+			return
+		}
 		if errors.Is(err, service.ErrPolicyNotFound) {
 			h.respondError(w, http.StatusNotFound, "policy not found")
 			return
@@ -20,6 +20,12 @@ import (
 
 // testPolicyHandlerEnv creates a complete test environment for policy handler tests.
 func testPolicyHandlerEnv(t *testing.T) (*AdminAPIHandler, *service.PolicyAdminService) {
+	t.Helper()
+	return testPolicyHandlerEnvWith(t)
+}
+
+// testPolicyHandlerEnvWith is testPolicyHandlerEnv with policy service options.
+func testPolicyHandlerEnvWith(t *testing.T, opts ...service.PolicyServiceOption) (*AdminAPIHandler, *service.PolicyAdminService) {
 	t.Helper()
 	tmpDir := t.TempDir()
 	statePath := filepath.Join(tmpDir, "state.json")
@@ -41,7 +47,7 @@ func testPolicyHandlerEnv(t *testing.T) (*AdminAPIHandler, *service.PolicyAdminS
 	policyStore.AddPolicy(defaultPolicy)
 
 	// Create policy service.
-	policySvc, err := service.NewPolicyService(context.Background(), policyStore, logger)
+	policySvc, err := service.NewPolicyService(context.Background(), policyStore, logger, opts...)
 	if err != nil {
 		t.Fatalf("NewPolicyService: %v", err)
 	}
@@ -52,6 +58,7 @@ func testPolicyHandlerEnv(t *testing.T) (*AdminAPIHandler, *service.PolicyAdminS
 	// Create API handler with the admin service.
 	h := NewAdminAPIHandler(
 		WithPolicyAdminService(adminSvc),
+		WithPolicyService(policySvc),
 		WithAPILogger(logger),
 	)
 
@@ -1,6 +1,7 @@
 package admin
 
 import (
+	"errors"
 	"net/http"
 	"path/filepath"
 	"strings"
@@ -19,7 +20,7 @@ type lintRequest struct {
 
 // lintWarning represents a single lint finding.
 type lintWarning struct {
-	Type     string `json:"type"`     // "syntax", "permissive", "shadowed", "conflict"
+	Type     string `json:"type"`     // "syntax", "limit", "permissive", "shadowed", "conflict"
 	Severity string `json:"severity"` // "error", "warning", "info"
 	Message  string `json:"message"`
 }
@@ -28,6 +29,10 @@ type lintWarning struct {
 type lintResponse struct {
 	Valid    bool          `json:"valid"`
 	Warnings []lintWarning `json:"warnings"`
+	// Complexity is the estimated worst-case cost of the condition, checked
+	// against MaxComplexity when the policy is saved.
+	Complexity    *uint64 `json:"complexity,omitempty"`
+	MaxComplexity uint64  `json:"max_complexity,omitempty"`
 }
 
 // handleLintPolicy validates a policy rule and checks for common issues.
@@ -48,30 +53,45 @@ func (h *AdminAPIHandler) handleLintPolicy(w http.ResponseWriter, r *http.Reques
 	}
 
 	var warnings []lintWarning
+	var complexity *uint64
+	var maxComplexity uint64
 
-	// 1. Syntax check: compile the CEL expression
+	// 1. Syntax check: compile the CEL expression with the limits of the
+	// active policies
 	if req.Condition != "true" {
-		evaluator, err := celAdapter.NewEvaluator()
+		evaluator, err := h.lintEvaluator()
 		if err != nil {
 			h.respondError(w, http.StatusInternalServerError, "failed to create CEL evaluator")
 			return
 		}
-		if err := evaluator.ValidateExpression(req.Condition); err != nil {
-			errMsg := err.Error()
-			// Strip the "invalid CEL expression: " prefix for cleaner messages
-			errMsg = strings.TrimPrefix(errMsg, "invalid CEL expression: ")
-			errMsg = strings.TrimPrefix(errMsg, "compilation failed: ")
-			warnings = append(warnings, lintWarning{
-				Type:     "syntax",
-				Severity: "error",
-				Message:  errMsg,
-			})
+		maxComplexity = evaluator.Limits().MaxComplexity
+		score, err := evaluator.Complexity(req.Condition)
+		if err != nil {
+			warning := lintWarning{Type: "syntax", Severity: "error"}
+			var limitErr *celAdapter.LimitError
+			if errors.As(err, &limitErr) {
+				warning.Type = "limit"
+				warning.Message = limitErr.Error()
+				if limitErr.Complexity > 0 {
+					complexity = &limitErr.Complexity
+				}
+			} else {
+				errMsg := err.Error()
+				// Strip the "invalid CEL expression: " prefix for cleaner messages
+				errMsg = strings.TrimPrefix(errMsg, "invalid CEL expression: ")
+				errMsg = strings.TrimPrefix(errMsg, "compilation failed: ")
+				warning.Message = errMsg
+			}
+			warnings = append(warnings, warning)
 			h.respondJSON(w, http.StatusOK, lintResponse{
-				Valid:    false,
-				Warnings: warnings,
+				Valid:         false,
+				Warnings:      warnings,
+				Complexity:    complexity,
+				MaxComplexity: maxComplexity,
 			})
 			return
 		}
+		complexity = &score
 	}
 
 	// 2. Permissiveness check: wildcard tool_match + trivial condition + allow
@@ -137,11 +157,22 @@ func (h *AdminAPIHandler) handleLintPolicy(w http.ResponseWriter, r *http.Reques
 	}
 
 	h.respondJSON(w, http.StatusOK, lintResponse{
-		Valid:    len(warnings) == 0 || !hasSeverity(warnings, "error"),
-		Warnings: warnings,
+		Valid:         len(warnings) == 0 || !hasSeverity(warnings, "error"),
+		Warnings:      warnings,
+		Complexity:    complexity,
+		MaxComplexity: maxComplexity,
 	})
 }
 
+// lintEvaluator returns the evaluator of the policy service, so conditions
+// are linted against the configured CEL limits.
+func (h *AdminAPIHandler) lintEvaluator() (*celAdapter.Evaluator, error) {
+	if h.policyService != nil {
+		return h.policyService.CELEvaluator(), nil
+	}
+	return celAdapter.NewEvaluator()
+}
+
 // containsIdentityCheck returns true if the CEL expression references identity/role variables.
 func containsIdentityCheck(cel string) bool {
 	return strings.Contains(cel, "identity_name") ||
@@ -6,7 +6,11 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+
+	celAdapter "github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/cel"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
 )
 
 func TestHandleLintPolicy(t *testing.T) {
@@ -134,3 +138,49 @@ func TestHandleLintPolicy_InvalidJSON(t *testing.T) {
 		t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
 	}
 }
+
+func TestHandleLintPolicy_CELLimits(t *testing.T) {
+	h, _ := testPolicyHandlerEnvWith(t, service.WithCELLimits(celAdapter.Limits{
+		BannedFunctions: []string{"matches"},
+		MaxComplexity:   10_000,
+	}))
+
+	lint := func(condition string) lintResponse {
+		t.Helper()
+		body, _ := json.Marshal(lintRequest{Condition: condition, ToolMatch: "bash", Action: "deny", Priority: 50})
+		req := httptest.NewRequest(http.MethodPost, "/admin/api/policies/lint", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		h.handleLintPolicy(w, req)
+		var resp lintResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("unmarshal: %v (body: %s)", err, w.Body.String())
+		}
+		return resp
+	}
+
+	resp := lint(`tool_name == "bash"`)
+	if !resp.Valid || resp.Complexity == nil || *resp.Complexity == 0 || resp.MaxComplexity != 10_000 {
+		t.Errorf("simple condition: %+v", resp)
+	}
+
+	resp = lint(`tool_name.matches("^ba")`)
+	if resp.Valid || len(resp.Warnings) != 1 || resp.Warnings[0].Type != "limit" {
+		t.Errorf("banned function: %+v", resp)
+	}
+
+	resp = lint(`user_roles.all(a, user_roles.all(b, a != b))`)
+	if resp.Valid || resp.Complexity == nil || *resp.Complexity <= 10_000 {
+		t.Errorf("complex condition: %+v", resp)
+	}
+
+	// Saving a policy reports the violated limit.
+	body := `{"name":"Regex","priority":10,"enabled":true,"rules":[{"name":"r","priority":100,"tool_match":"*","condition":"tool_name.matches(\"x\")","action":"deny"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/admin/api/policies", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.handleCreatePolicy(w, req)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "function matches is not allowed") {
+		t.Errorf("create = %d %s, want 400 naming the banned function", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,49 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+// handlePolicyReachability reports whether a tool can ever be reached under
+// the current policy set for the given roles or identity.
+// POST /admin/api/policies/reachability
+func (h *AdminAPIHandler) handlePolicyReachability(w http.ResponseWriter, r *http.Request) {
+	if h.policyService == nil {
+		h.respondError(w, http.StatusInternalServerError, "policy service not configured")
+		return
+	}
+
+	var req service.ReachabilityQuery
+	if err := h.readJSON(r, &req); err != nil {
+		h.handleReadJSONErr(w, err)
+		return
+	}
+
+	if req.ToolName == "" {
+		h.respondError(w, http.StatusBadRequest, "tool_name is required")
+		return
+	}
+
+	// Resolve identity_name and roles from identity_id if not provided.
+	if req.IdentityID != "" && h.identityService != nil {
+		if identity, err := h.identityService.GetIdentity(r.Context(), req.IdentityID); err == nil {
+			if req.IdentityName == "" {
+				req.IdentityName = identity.Name
+			}
+			if len(req.Roles) == 0 {
+				req.Roles = identity.Roles
+			}
+		}
+	}
+
+	result, err := h.policyService.CheckReachability(r.Context(), req)
+	if err != nil {
+		h.logger.Error("policy reachability check failed", "error", err, "tool", req.ToolName)
+		h.respondError(w, http.StatusInternalServerError, "policy reachability check failed")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, result)
+}
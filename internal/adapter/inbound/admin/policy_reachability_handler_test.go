@@ -0,0 +1,78 @@
+package admin
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+func TestHandlePolicyReachability(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantStatus  int
+		wantVerdict string
+		wantBlocked string
+	}{
+		{
+			name:        "delete is unreachable under default policy",
+			body:        `{"tool_name":"delete_database","roles":["contractor"]}`,
+			wantStatus:  http.StatusOK,
+			wantVerdict: service.ReachabilityUnreachable,
+			wantBlocked: "block-delete",
+		},
+		{
+			name:        "read is reachable through default allow",
+			body:        `{"tool_name":"read_file","roles":["user"]}`,
+			wantStatus:  http.StatusOK,
+			wantVerdict: service.ReachabilityReachable,
+		},
+		{
+			name:       "missing tool_name returns 400",
+			body:       `{"roles":["user"]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid JSON returns 400",
+			body:       `not json`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := testPolicyTestEnv(t)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/api/policies/reachability", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			h.handlePolicyReachability(w, req)
+
+			resp := w.Result()
+			if resp.StatusCode != tt.wantStatus {
+				bodyBytes, _ := io.ReadAll(resp.Body)
+				t.Fatalf("status = %d, want %d, body: %s", resp.StatusCode, tt.wantStatus, string(bodyBytes))
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var result service.ReachabilityResult
+			if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if result.Verdict != tt.wantVerdict {
+				t.Errorf("verdict = %q, want %q", result.Verdict, tt.wantVerdict)
+			}
+			if tt.wantBlocked != "" && (result.BlockedBy == nil || result.BlockedBy.ID != tt.wantBlocked) {
+				t.Errorf("blocked_by = %+v, want %q", result.BlockedBy, tt.wantBlocked)
+			}
+		})
+	}
+}
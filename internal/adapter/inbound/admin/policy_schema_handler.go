@@ -0,0 +1,21 @@
+package admin
+
+import (
+	"net/http"
+
+	celAdapter "github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/cel"
+)
+
+// handlePolicySchema returns the variables and functions available to rule
+// conditions, with their types and example expressions, for the policy
+// editor's autocomplete and for policy authors.
+// GET /admin/api/policies/schema
+func (h *AdminAPIHandler) handlePolicySchema(w http.ResponseWriter, r *http.Request) {
+	schema, err := celAdapter.PolicySchema()
+	if err != nil {
+		h.logger.Error("failed to build policy schema", "error", err)
+		h.respondError(w, http.StatusInternalServerError, "failed to build policy schema")
+		return
+	}
+	h.respondJSON(w, http.StatusOK, schema)
+}
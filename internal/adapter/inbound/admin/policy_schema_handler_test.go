@@ -0,0 +1,36 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	celAdapter "github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/cel"
+)
+
+func TestHandlePolicySchema(t *testing.T) {
+	h := NewAdminAPIHandler()
+	w := httptest.NewRecorder()
+	h.handlePolicySchema(w, httptest.NewRequest(http.MethodGet, "/admin/api/policies/schema", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var schema celAdapter.Schema
+	if err := json.NewDecoder(w.Body).Decode(&schema); err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, v := range schema.Variables {
+		if v.Name == "dest_domain" {
+			found = v.Type == "string" && v.Group == "destination" && v.Example != ""
+		}
+	}
+	if !found {
+		t.Error("dest_domain missing or incomplete in the schema")
+	}
+	if len(schema.Functions) == 0 {
+		t.Error("schema has no functions")
+	}
+}
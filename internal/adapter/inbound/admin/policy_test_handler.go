@@ -32,14 +32,26 @@ type PolicyTestRequest struct {
 	DestURL string `json:"dest_url,omitempty"`
 	// DestDomain is the destination domain.
 	DestDomain string `json:"dest_domain,omitempty"`
+	// DestIP is the destination IP address.
+	DestIP string `json:"dest_ip,omitempty"`
+	// DestPort is the destination port.
+	DestPort int `json:"dest_port,omitempty"`
+	// DestCountry is the ISO country code of the destination, e.g. "DE".
+	DestCountry string `json:"dest_country,omitempty"`
+	// DestASN is the autonomous system number of the destination.
+	DestASN int64 `json:"dest_asn,omitempty"`
 	// DestCommand is the command being executed.
 	DestCommand string `json:"dest_command,omitempty"`
+	// PayloadBytes is a simulated request size in bytes.
+	PayloadBytes int64 `json:"payload_bytes,omitempty"`
 	// SessionCumulativeCost is a simulated cumulative cost for the current session.
 	SessionCumulativeCost float64 `json:"session_cumulative_cost,omitempty"`
 	// SessionContext is an optional list of prior actions to simulate a session history.
 	// Each entry has tool_name (required), call_type (optional, defaults to "other"),
 	// seconds_ago (optional, defaults to 0), and arg_keys (optional).
 	SessionContext []SessionContextEntry `json:"session_context,omitempty"`
+	// Enrichment simulates the attributes of the policy enrichment endpoint.
+	Enrichment map[string]interface{} `json:"enrichment,omitempty"`
 }
 
 // SessionContextEntry represents a single prior action in the simulated session history.
@@ -123,8 +135,14 @@ func (h *AdminAPIHandler) handleTestPolicy(w http.ResponseWriter, r *http.Reques
 		Gateway:       req.Gateway,
 		DestURL:       req.DestURL,
 		DestDomain:    req.DestDomain,
+		DestIP:                req.DestIP,
+		DestPort:              req.DestPort,
+		DestCountry:           req.DestCountry,
+		DestASN:               req.DestASN,
 		DestCommand:           req.DestCommand,
+		PayloadBytes:          req.PayloadBytes,
 		SessionCumulativeCost: req.SessionCumulativeCost,
+		Enrichment:            req.Enrichment,
 		SkipCache:             true,
 	}
 
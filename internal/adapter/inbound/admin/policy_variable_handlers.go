@@ -0,0 +1,101 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+// WithPolicyVariableService sets the policy variable service.
+func WithPolicyVariableService(s *service.PolicyVariableService) AdminAPIOption {
+	return func(h *AdminAPIHandler) { h.policyVariableService = s }
+}
+
+// SetPolicyVariableService sets the policy variable service after construction.
+func (h *AdminAPIHandler) SetPolicyVariableService(s *service.PolicyVariableService) {
+	h.policyVariableService = s
+}
+
+// setPolicyVariableRequest is the request body of
+// PUT /admin/api/policy-variables/{name}.
+type setPolicyVariableRequest struct {
+	Value       json.RawMessage `json:"value"`
+	Description string          `json:"description,omitempty"`
+}
+
+// handleListPolicyVariables returns every policy variable.
+// GET /admin/api/policy-variables
+func (h *AdminAPIHandler) handleListPolicyVariables(w http.ResponseWriter, r *http.Request) {
+	if h.policyVariableService == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "policy variables not available")
+		return
+	}
+	h.respondJSON(w, http.StatusOK, h.policyVariableService.List())
+}
+
+// handleGetPolicyVariable returns one policy variable.
+// GET /admin/api/policy-variables/{name}
+func (h *AdminAPIHandler) handleGetPolicyVariable(w http.ResponseWriter, r *http.Request) {
+	if h.policyVariableService == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "policy variables not available")
+		return
+	}
+	v, err := h.policyVariableService.Get(h.pathParam(r, "name"))
+	if err != nil {
+		h.respondPolicyVariableError(w, err)
+		return
+	}
+	h.respondJSON(w, http.StatusOK, v)
+}
+
+// handleSetPolicyVariable creates or replaces a policy variable. The new
+// value applies to the next evaluation.
+// PUT /admin/api/policy-variables/{name}
+func (h *AdminAPIHandler) handleSetPolicyVariable(w http.ResponseWriter, r *http.Request) {
+	if h.policyVariableService == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "policy variables not available")
+		return
+	}
+	var req setPolicyVariableRequest
+	if err := h.readJSON(r, &req); err != nil {
+		h.handleReadJSONErr(w, err)
+		return
+	}
+	if len(req.Value) == 0 {
+		h.respondError(w, http.StatusBadRequest, "value is required")
+		return
+	}
+	v, err := h.policyVariableService.Set(r.Context(), h.pathParam(r, "name"), req.Value, req.Description)
+	if err != nil {
+		h.respondPolicyVariableError(w, err)
+		return
+	}
+	h.respondJSON(w, http.StatusOK, v)
+}
+
+// handleDeletePolicyVariable removes a policy variable.
+// DELETE /admin/api/policy-variables/{name}
+func (h *AdminAPIHandler) handleDeletePolicyVariable(w http.ResponseWriter, r *http.Request) {
+	if h.policyVariableService == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "policy variables not available")
+		return
+	}
+	if err := h.policyVariableService.Delete(r.Context(), h.pathParam(r, "name")); err != nil {
+		h.respondPolicyVariableError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AdminAPIHandler) respondPolicyVariableError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrPolicyVariableNotFound):
+		h.respondError(w, http.StatusNotFound, "policy variable not found")
+	case errors.Is(err, service.ErrInvalidPolicyVariable):
+		h.respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		h.internalError(w, "policy variable request failed", err)
+	}
+}
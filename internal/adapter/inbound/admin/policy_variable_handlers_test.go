@@ -0,0 +1,56 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/memory"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+func TestHandlePolicyVariables(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	policySvc, err := service.NewPolicyService(context.Background(), memory.NewPolicyStore(), logger)
+	if err != nil {
+		t.Fatalf("NewPolicyService: %v", err)
+	}
+	h := NewAdminAPIHandler(
+		WithPolicyVariableService(service.NewPolicyVariableService(policySvc, nil, logger)),
+		WithAPILogger(logger),
+	)
+
+	rec := outboundLearningRequest(t, h, http.MethodPut, "/admin/api/policy-variables/allowed_buckets", `{"value":["a","b"],"description":"Writable buckets"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("put status = %d, want 200 (body=%s)", rec.Code, rec.Body.String())
+	}
+	if got := policySvc.Variables()["allowed_buckets"]; len(got.([]interface{})) != 2 {
+		t.Errorf("policy service variable = %v", got)
+	}
+
+	if rec := outboundLearningRequest(t, h, http.MethodPut, "/admin/api/policy-variables/Bad-Name", `{"value":1}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid name status = %d, want 400", rec.Code)
+	}
+	if rec := outboundLearningRequest(t, h, http.MethodPut, "/admin/api/policy-variables/empty", `{}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("missing value status = %d, want 400", rec.Code)
+	}
+
+	rec = sloTestRequest(t, h, "/admin/api/policy-variables")
+	var list []service.PolicyVariable
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(list) != 1 || list[0].Name != "allowed_buckets" || list[0].Description != "Writable buckets" {
+		t.Errorf("list = %+v", list)
+	}
+
+	if rec := outboundLearningRequest(t, h, http.MethodDelete, "/admin/api/policy-variables/allowed_buckets", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("delete status = %d, want 204", rec.Code)
+	}
+	if rec := sloTestRequest(t, h, "/admin/api/policy-variables/allowed_buckets"); rec.Code != http.StatusNotFound {
+		t.Errorf("get deleted status = %d, want 404", rec.Code)
+	}
+}
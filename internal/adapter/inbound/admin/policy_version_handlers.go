@@ -0,0 +1,92 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+// policyVersionResponse is the JSON response for one version of a policy.
+type policyVersionResponse struct {
+	Version         int            `json:"version"`
+	Change          string         `json:"change"`
+	RestoredVersion int            `json:"restored_version,omitempty"`
+	ChangedBy       string         `json:"changed_by,omitempty"`
+	ChangedAt       time.Time      `json:"changed_at"`
+	Policy          policyResponse `json:"policy"`
+}
+
+// handleListPolicyVersions returns the change history of a policy, oldest first.
+// GET /admin/api/policies/{id}/versions
+func (h *AdminAPIHandler) handleListPolicyVersions(w http.ResponseWriter, r *http.Request) {
+	if h.policyAdminService == nil {
+		h.respondError(w, http.StatusInternalServerError, "policy service not configured")
+		return
+	}
+
+	id := h.pathParam(r, "id")
+	versions, err := h.policyAdminService.Versions(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrPolicyNotFound) {
+			h.respondError(w, http.StatusNotFound, "policy not found")
+			return
+		}
+		h.logger.Error("failed to list policy versions", "error", err, "id", id)
+		h.respondError(w, http.StatusInternalServerError, "failed to list policy versions")
+		return
+	}
+
+	result := make([]policyVersionResponse, len(versions))
+	for i := range versions {
+		v := &versions[i]
+		result[i] = policyVersionResponse{
+			Version:         v.Version,
+			Change:          v.Change,
+			RestoredVersion: v.RestoredVersion,
+			ChangedBy:       v.ChangedBy,
+			ChangedAt:       v.ChangedAt,
+			Policy:          toPolicyResponse(&v.Policy),
+		}
+	}
+	h.respondJSON(w, http.StatusOK, result)
+}
+
+// handleRollbackPolicy restores a policy as it was at a version of its history.
+// POST /admin/api/policies/{id}/rollback/{version}
+func (h *AdminAPIHandler) handleRollbackPolicy(w http.ResponseWriter, r *http.Request) {
+	if h.policyAdminService == nil {
+		h.respondError(w, http.StatusInternalServerError, "policy service not configured")
+		return
+	}
+
+	id := h.pathParam(r, "id")
+	version, err := strconv.Atoi(h.pathParam(r, "version"))
+	if err != nil || version < 1 {
+		h.respondError(w, http.StatusBadRequest, "version must be a positive integer")
+		return
+	}
+
+	restored, err := h.policyAdminService.Rollback(r.Context(), id, version)
+	if err != nil {
+		if errors.Is(err, service.ErrPolicyVersionNotFound) {
+			h.respondError(w, http.StatusNotFound, "policy version not found")
+			return
+		}
+		if errors.Is(err, service.ErrFilePolicy) {
+			h.respondError(w, http.StatusForbidden, "policy is managed by a file in the policy directory")
+			return
+		}
+		if errors.Is(err, service.ErrInvalidPolicy) {
+			h.respondError(w, http.StatusBadRequest, invalidPolicyMessage(err))
+			return
+		}
+		h.logger.Error("failed to roll back policy", "error", err, "id", id, "version", version)
+		h.respondError(w, http.StatusInternalServerError, "failed to roll back policy")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, toPolicyResponse(restored))
+}
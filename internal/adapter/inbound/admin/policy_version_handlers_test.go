@@ -0,0 +1,97 @@
+package admin
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/policy"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+func TestHandlePolicyVersions(t *testing.T) {
+	h, adminSvc := testPolicyHandlerEnv(t)
+	ctx := service.WithActor(context.Background(), "admin@127.0.0.1")
+
+	created, err := adminSvc.Create(ctx, &policy.Policy{
+		Name:    "Original",
+		Enabled: true,
+		Rules: []policy.Rule{
+			{Name: "rule-1", Priority: 100, ToolMatch: "*", Condition: "true", Action: policy.ActionAllow},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Create(): %v", err)
+	}
+	if _, err := adminSvc.Update(ctx, created.ID, &policy.Policy{
+		Name:    "Updated",
+		Enabled: true,
+		Rules: []policy.Rule{
+			{Name: "rule-1", Priority: 100, ToolMatch: "*", Condition: "true", Action: policy.ActionDeny},
+		},
+	}); err != nil {
+		t.Fatalf("Update(): %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/api/policies/"+created.ID+"/versions", nil)
+	req.SetPathValue("id", created.ID)
+	w := httptest.NewRecorder()
+	h.handleListPolicyVersions(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("handleListPolicyVersions status = %d, body: %s", w.Code, w.Body.String())
+	}
+	var versions []policyVersionResponse
+	decodePolicyJSON(t, w.Body, &versions)
+	if len(versions) != 2 || versions[0].Policy.Name != "Original" || versions[1].ChangedBy != "admin@127.0.0.1" {
+		t.Fatalf("versions = %+v", versions)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/admin/api/policies/"+created.ID+"/rollback/1", nil)
+	req.SetPathValue("id", created.ID)
+	req.SetPathValue("version", "1")
+	w = httptest.NewRecorder()
+	h.handleRollbackPolicy(w, req)
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("handleRollbackPolicy status = %d, body: %s", resp.StatusCode, body)
+	}
+	var restored policyResponse
+	decodePolicyJSON(t, resp.Body, &restored)
+	if restored.Name != "Original" {
+		t.Errorf("restored Name = %q, want %q", restored.Name, "Original")
+	}
+}
+
+func TestHandleRollbackPolicy_Errors(t *testing.T) {
+	h, _ := testPolicyHandlerEnv(t)
+
+	tests := []struct {
+		id, version string
+		want        int
+	}{
+		{"default-policy-id", "abc", http.StatusBadRequest},
+		{"default-policy-id", "0", http.StatusBadRequest},
+		{"default-policy-id", "7", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/admin/api/policies/"+tt.id+"/rollback/"+tt.version, nil)
+		req.SetPathValue("id", tt.id)
+		req.SetPathValue("version", tt.version)
+		w := httptest.NewRecorder()
+		h.handleRollbackPolicy(w, req)
+		if w.Code != tt.want {
+			t.Errorf("rollback to %q: status = %d, want %d", tt.version, w.Code, tt.want)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/api/policies/missing/versions", nil)
+	req.SetPathValue("id", "missing")
+	w := httptest.NewRecorder()
+	h.handleListPolicyVersions(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("versions of a missing policy: status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
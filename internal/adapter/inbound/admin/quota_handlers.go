@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"sort"
+	"time"
 
 	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/quota"
@@ -21,6 +23,12 @@ func (h *AdminAPIHandler) SetQuotaStore(s quota.QuotaStore) {
 	h.quotaStore = s
 }
 
+// SetQuotaDailyCounter sets the counter of daily quota usage shown by the
+// quota status endpoint.
+func (h *AdminAPIHandler) SetQuotaDailyCounter(c *quota.DailyCounter) {
+	h.quotaDaily = c
+}
+
 // quotaRequest is the JSON body for create/update quota endpoints.
 type quotaRequest struct {
 	MaxCallsPerSession   int64            `json:"max_calls_per_session,omitempty"`
@@ -29,6 +37,7 @@ type quotaRequest struct {
 	MaxCallsPerMinute    int64            `json:"max_calls_per_minute,omitempty"`
 	MaxCallsPerDay       int64            `json:"max_calls_per_day,omitempty"`
 	ToolLimits           map[string]int64 `json:"tool_limits,omitempty"`
+	ToolWeights          map[string]int64 `json:"tool_weights,omitempty"`
 	Action               string           `json:"action"`
 	Enabled              bool             `json:"enabled"`
 }
@@ -42,10 +51,40 @@ type quotaResponse struct {
 	MaxCallsPerMinute    int64            `json:"max_calls_per_minute,omitempty"`
 	MaxCallsPerDay       int64            `json:"max_calls_per_day,omitempty"`
 	ToolLimits           map[string]int64 `json:"tool_limits,omitempty"`
+	ToolWeights          map[string]int64 `json:"tool_weights,omitempty"`
 	Action               string           `json:"action"`
 	Enabled              bool             `json:"enabled"`
 }
 
+// quotaStatusResponse is the JSON body of the quota status endpoint.
+type quotaStatusResponse struct {
+	// Day is the current UTC day daily usage is counted for.
+	Day string `json:"day"`
+	// ResetsAt is when daily usage resets (next midnight UTC).
+	ResetsAt time.Time          `json:"resets_at"`
+	Quotas   []quotaStatusEntry `json:"quotas"`
+}
+
+// quotaStatusEntry is the usage of one identity against its quota.
+type quotaStatusEntry struct {
+	Quota quotaResponse `json:"quota"`
+	// UsedToday is the units of max_calls_per_day used today.
+	UsedToday int64 `json:"used_today"`
+	// RemainingToday is omitted when there is no daily limit.
+	RemainingToday *int64               `json:"remaining_today,omitempty"`
+	Sessions       []quotaSessionStatus `json:"sessions"`
+}
+
+// quotaSessionStatus is the usage of one active session of the identity.
+type quotaSessionStatus struct {
+	SessionID   string           `json:"session_id"`
+	TotalCalls  int64            `json:"total_calls"`
+	WriteCalls  int64            `json:"write_calls"`
+	DeleteCalls int64            `json:"delete_calls"`
+	WindowCalls int64            `json:"window_calls"`
+	CallsByTool map[string]int64 `json:"calls_by_tool,omitempty"`
+}
+
 // handleListQuotas returns all configured quotas.
 // GET /admin/api/v1/quotas
 func (h *AdminAPIHandler) handleListQuotas(w http.ResponseWriter, r *http.Request) {
@@ -128,6 +167,12 @@ func (h *AdminAPIHandler) handlePutQuota(w http.ResponseWriter, r *http.Request)
 			return
 		}
 	}
+	for toolName, weight := range req.ToolWeights {
+		if weight < 0 {
+			h.respondError(w, http.StatusBadRequest, "tool weight for "+toolName+" must be non-negative")
+			return
+		}
+	}
 
 	cfg := &quota.QuotaConfig{
 		IdentityID:           identityID,
@@ -137,6 +182,7 @@ func (h *AdminAPIHandler) handlePutQuota(w http.ResponseWriter, r *http.Request)
 		MaxCallsPerMinute:    req.MaxCallsPerMinute,
 		MaxCallsPerDay:       req.MaxCallsPerDay,
 		ToolLimits:           req.ToolLimits,
+		ToolWeights:          req.ToolWeights,
 		Action:               quota.QuotaAction(req.Action),
 		Enabled:              req.Enabled,
 	}
@@ -206,6 +252,74 @@ func (h *AdminAPIHandler) handleDeleteQuota(w http.ResponseWriter, r *http.Reque
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// handleQuotaStatus returns the usage of every identity with a quota, or
+// of one identity, against its limits: units used today and the usage of
+// its active sessions.
+// GET /admin/api/quotas?identity_id=...
+func (h *AdminAPIHandler) handleQuotaStatus(w http.ResponseWriter, r *http.Request) {
+	if h.quotaStore == nil {
+		h.respondError(w, http.StatusInternalServerError, "quota store not configured")
+		return
+	}
+
+	configs, err := h.quotaStore.List(r.Context())
+	if err != nil {
+		h.logger.Error("failed to list quotas", "error", err)
+		h.respondError(w, http.StatusInternalServerError, "failed to list quotas")
+		return
+	}
+	identityID := r.URL.Query().Get("identity_id")
+
+	sessions := make(map[string][]quotaSessionStatus)
+	if h.sessionTracker != nil {
+		for _, s := range h.sessionTracker.ActiveSessions() {
+			sessions[s.IdentityID] = append(sessions[s.IdentityID], quotaSessionStatus{
+				SessionID:   s.SessionID,
+				TotalCalls:  s.Usage.TotalCalls,
+				WriteCalls:  s.Usage.WriteCalls,
+				DeleteCalls: s.Usage.DeleteCalls,
+				WindowCalls: s.Usage.WindowCalls,
+				CallsByTool: s.Usage.CallsByToolName,
+			})
+		}
+	}
+
+	day := time.Now().UTC().Format(time.DateOnly)
+	if h.quotaDaily != nil {
+		day = h.quotaDaily.Day()
+	}
+	start, _ := time.Parse(time.DateOnly, day)
+	resp := quotaStatusResponse{
+		Day:      day,
+		ResetsAt: start.AddDate(0, 0, 1),
+		Quotas:   make([]quotaStatusEntry, 0, len(configs)),
+	}
+	for _, c := range configs {
+		if identityID != "" && c.IdentityID != identityID {
+			continue
+		}
+		entry := quotaStatusEntry{
+			Quota:    toQuotaResponse(c),
+			Sessions: sessions[c.IdentityID],
+		}
+		if entry.Sessions == nil {
+			entry.Sessions = []quotaSessionStatus{}
+		}
+		sort.Slice(entry.Sessions, func(i, j int) bool { return entry.Sessions[i].SessionID < entry.Sessions[j].SessionID })
+		if h.quotaDaily != nil {
+			entry.UsedToday = h.quotaDaily.Used(c.IdentityID)
+		}
+		if c.MaxCallsPerDay > 0 {
+			remaining := max(c.MaxCallsPerDay-entry.UsedToday, 0)
+			entry.RemainingToday = &remaining
+		}
+		resp.Quotas = append(resp.Quotas, entry)
+	}
+	sort.Slice(resp.Quotas, func(i, j int) bool { return resp.Quotas[i].Quota.IdentityID < resp.Quotas[j].Quota.IdentityID })
+
+	h.respondJSON(w, http.StatusOK, resp)
+}
+
 // persistQuotas rebuilds the Quotas slice in state.json from the quota store.
 func (h *AdminAPIHandler) persistQuotas(ctx context.Context) error {
 	configs, err := h.quotaStore.List(ctx)
@@ -222,6 +336,7 @@ func (h *AdminAPIHandler) persistQuotas(ctx context.Context) error {
 			MaxCallsPerMinute:    c.MaxCallsPerMinute,
 			MaxCallsPerDay:       c.MaxCallsPerDay,
 			ToolLimits:           c.ToolLimits,
+			ToolWeights:          c.ToolWeights,
 			Action:               string(c.Action),
 			Enabled:              c.Enabled,
 		})
@@ -242,6 +357,7 @@ func toQuotaResponse(c *quota.QuotaConfig) quotaResponse {
 		MaxCallsPerMinute:    c.MaxCallsPerMinute,
 		MaxCallsPerDay:       c.MaxCallsPerDay,
 		ToolLimits:           c.ToolLimits,
+		ToolWeights:          c.ToolWeights,
 		Action:               string(c.Action),
 		Enabled:              c.Enabled,
 	}
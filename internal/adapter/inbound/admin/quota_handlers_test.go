@@ -3,15 +3,18 @@ package admin
 import (
 	"bytes"
 	"encoding/json"
+	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/quota"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/session"
 )
 
 // quotaTestEnv holds test dependencies for quota handler tests.
@@ -411,3 +414,67 @@ func TestHandleDeleteQuota(t *testing.T) {
 		t.Fatalf("state quotas count = %d, want 0", len(appState.Quotas))
 	}
 }
+
+func TestHandleQuotaStatus(t *testing.T) {
+	env := setupQuotaTestEnv(t)
+	ctx := httptest.NewRequest(http.MethodGet, "/", nil).Context()
+
+	_ = env.quotaStore.Put(ctx, &quota.QuotaConfig{
+		IdentityID: "id-b", MaxCallsPerDay: 10, ToolWeights: map[string]int64{"web_search": 3},
+		Action: quota.QuotaActionDeny, Enabled: true,
+	})
+	_ = env.quotaStore.Put(ctx, &quota.QuotaConfig{
+		IdentityID: "id-a", MaxCallsPerSession: 5, Action: quota.QuotaActionWarn, Enabled: true,
+	})
+
+	tracker := session.NewSessionTracker(time.Minute, session.DefaultClassifier())
+	defer tracker.Stop()
+	tracker.RecordCall("sess-1", "web_search", "id-b", "bot", nil)
+	env.handler.SetSessionTracker(tracker)
+
+	daily := quota.NewDailyCounter(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	daily.Reserve("id-b", 3, 10)
+	env.handler.SetQuotaDailyCounter(daily)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/api/quotas", nil)
+	w := httptest.NewRecorder()
+	env.handler.handleQuotaStatus(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	var resp quotaStatusResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Day != daily.Day() {
+		t.Errorf("day = %q, want %q", resp.Day, daily.Day())
+	}
+	if len(resp.Quotas) != 2 || resp.Quotas[0].Quota.IdentityID != "id-a" {
+		t.Fatalf("quotas = %+v, want id-a then id-b", resp.Quotas)
+	}
+	if q := resp.Quotas[0]; q.RemainingToday != nil || len(q.Sessions) != 0 {
+		t.Errorf("id-a = %+v, want no daily limit and no sessions", q)
+	}
+	b := resp.Quotas[1]
+	if b.UsedToday != 3 || b.RemainingToday == nil || *b.RemainingToday != 7 {
+		t.Errorf("id-b daily usage = %d (remaining %v), want 3 (7)", b.UsedToday, b.RemainingToday)
+	}
+	if b.Quota.ToolWeights["web_search"] != 3 {
+		t.Errorf("id-b tool weights = %v", b.Quota.ToolWeights)
+	}
+	if len(b.Sessions) != 1 || b.Sessions[0].SessionID != "sess-1" || b.Sessions[0].TotalCalls != 1 {
+		t.Errorf("id-b sessions = %+v, want sess-1 with 1 call", b.Sessions)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/admin/api/quotas?identity_id=id-a", nil)
+	w = httptest.NewRecorder()
+	env.handler.handleQuotaStatus(w, req)
+	resp = quotaStatusResponse{}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Quotas) != 1 || resp.Quotas[0].Quota.IdentityID != "id-a" {
+		t.Errorf("filtered quotas = %+v, want only id-a", resp.Quotas)
+	}
+}
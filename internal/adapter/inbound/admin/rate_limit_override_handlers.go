@@ -0,0 +1,126 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/ratelimit"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+// WithRateLimitOverrideService sets the rate limit override service.
+func WithRateLimitOverrideService(s *service.RateLimitOverrideService) AdminAPIOption {
+	return func(h *AdminAPIHandler) { h.rateLimitOverrides = s }
+}
+
+// WithRateLimitExemptionService sets the rate limit exemption service.
+func WithRateLimitExemptionService(s *service.RateLimitExemptionService) AdminAPIOption {
+	return func(h *AdminAPIHandler) { h.rateLimitExemptions = s }
+}
+
+// rateLimitOverrideRequest is the request body of
+// POST /admin/api/rate-limits/overrides and
+// PUT /admin/api/rate-limits/overrides/{id}.
+type rateLimitOverrideRequest struct {
+	Identity string `json:"identity"`
+	Tool     string `json:"tool"`
+	Rate     int    `json:"rate"`
+	Burst    int    `json:"burst"`
+}
+
+func (r rateLimitOverrideRequest) override() ratelimit.Override {
+	return ratelimit.Override{Identity: r.Identity, Tool: r.Tool, Rate: r.Rate, Burst: r.Burst}
+}
+
+// handleListRateLimitOverrides returns every rate limit override, config
+// overrides first.
+// GET /admin/api/rate-limits/overrides
+func (h *AdminAPIHandler) handleListRateLimitOverrides(w http.ResponseWriter, r *http.Request) {
+	if h.rateLimitOverrides == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "rate limit overrides not available")
+		return
+	}
+	h.respondJSON(w, http.StatusOK, h.rateLimitOverrides.List())
+}
+
+// handleCreateRateLimitOverride adds a rate limit override.
+// POST /admin/api/rate-limits/overrides
+func (h *AdminAPIHandler) handleCreateRateLimitOverride(w http.ResponseWriter, r *http.Request) {
+	if h.rateLimitOverrides == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "rate limit overrides not available")
+		return
+	}
+	var req rateLimitOverrideRequest
+	if err := h.readJSON(r, &req); err != nil {
+		h.handleReadJSONErr(w, err)
+		return
+	}
+	o, err := h.rateLimitOverrides.Create(r.Context(), req.override())
+	if err != nil {
+		h.respondRateLimitOverrideError(w, err)
+		return
+	}
+	h.respondJSON(w, http.StatusCreated, o)
+}
+
+// handleUpdateRateLimitOverride replaces a rate limit override created from
+// the admin API.
+// PUT /admin/api/rate-limits/overrides/{id}
+func (h *AdminAPIHandler) handleUpdateRateLimitOverride(w http.ResponseWriter, r *http.Request) {
+	if h.rateLimitOverrides == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "rate limit overrides not available")
+		return
+	}
+	var req rateLimitOverrideRequest
+	if err := h.readJSON(r, &req); err != nil {
+		h.handleReadJSONErr(w, err)
+		return
+	}
+	o, err := h.rateLimitOverrides.Update(r.Context(), h.pathParam(r, "id"), req.override())
+	if err != nil {
+		h.respondRateLimitOverrideError(w, err)
+		return
+	}
+	h.respondJSON(w, http.StatusOK, o)
+}
+
+// handleDeleteRateLimitOverride removes a rate limit override created from
+// the admin API.
+// DELETE /admin/api/rate-limits/overrides/{id}
+func (h *AdminAPIHandler) handleDeleteRateLimitOverride(w http.ResponseWriter, r *http.Request) {
+	if h.rateLimitOverrides == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "rate limit overrides not available")
+		return
+	}
+	if err := h.rateLimitOverrides.Delete(r.Context(), h.pathParam(r, "id")); err != nil {
+		h.respondRateLimitOverrideError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleListRateLimitExemptions returns the exempt_rate_limit policy rules
+// with their usage and active session and identity exemptions.
+// GET /admin/api/rate-limits/exemptions
+func (h *AdminAPIHandler) handleListRateLimitExemptions(w http.ResponseWriter, r *http.Request) {
+	if h.rateLimitExemptions == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "rate limit exemptions not available")
+		return
+	}
+	h.respondJSON(w, http.StatusOK, h.rateLimitExemptions.List())
+}
+
+func (h *AdminAPIHandler) respondRateLimitOverrideError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrRateLimitOverrideNotFound):
+		h.respondError(w, http.StatusNotFound, "rate limit override not found")
+	case errors.Is(err, service.ErrReadOnly):
+		h.respondError(w, http.StatusForbidden, "rate limit override is defined in the config file and cannot be changed")
+	case errors.Is(err, service.ErrRateLimitOverrideConflict):
+		h.respondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, ratelimit.ErrInvalidOverride):
+		h.respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		h.internalError(w, "rate limit override request failed", err)
+	}
+}
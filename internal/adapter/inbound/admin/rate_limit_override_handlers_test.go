@@ -0,0 +1,62 @@
+package admin
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/ratelimit"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+func TestHandleRateLimitOverrides(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	svc := service.NewRateLimitOverrideService(nil, logger)
+	if err := svc.SetConfigOverrides([]ratelimit.Override{{Identity: "ci-bot", Rate: 1000}}); err != nil {
+		t.Fatalf("SetConfigOverrides: %v", err)
+	}
+	h := NewAdminAPIHandler(
+		WithRateLimitOverrideService(svc),
+		WithAPILogger(logger),
+	)
+
+	rec := outboundLearningRequest(t, h, http.MethodPost, "/admin/api/rate-limits/overrides", `{"tool":"send_email","rate":10}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, want 201 (body=%s)", rec.Code, rec.Body.String())
+	}
+	var created service.RateLimitOverride
+	if err := json.NewDecoder(rec.Body).Decode(&created); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	if rec := outboundLearningRequest(t, h, http.MethodPost, "/admin/api/rate-limits/overrides", `{"rate":10}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid create status = %d, want 400", rec.Code)
+	}
+	if rec := outboundLearningRequest(t, h, http.MethodPost, "/admin/api/rate-limits/overrides", `{"tool":"send_email","rate":20}`); rec.Code != http.StatusConflict {
+		t.Errorf("duplicate create status = %d, want 409", rec.Code)
+	}
+	if rec := outboundLearningRequest(t, h, http.MethodPut, "/admin/api/rate-limits/overrides/"+created.ID, `{"tool":"send_email","rate":5}`); rec.Code != http.StatusOK {
+		t.Errorf("update status = %d, want 200 (body=%s)", rec.Code, rec.Body.String())
+	}
+	if rec := outboundLearningRequest(t, h, http.MethodDelete, "/admin/api/rate-limits/overrides/config-1", ""); rec.Code != http.StatusForbidden {
+		t.Errorf("delete config override status = %d, want 403", rec.Code)
+	}
+
+	rec = sloTestRequest(t, h, "/admin/api/rate-limits/overrides")
+	var list []service.RateLimitOverride
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(list) != 2 || !list[0].ReadOnly || list[1].Rate != 5 {
+		t.Errorf("list = %+v", list)
+	}
+
+	if rec := outboundLearningRequest(t, h, http.MethodDelete, "/admin/api/rate-limits/overrides/"+created.ID, ""); rec.Code != http.StatusNoContent {
+		t.Errorf("delete status = %d, want 204", rec.Code)
+	}
+	if rec := outboundLearningRequest(t, h, http.MethodDelete, "/admin/api/rate-limits/overrides/"+created.ID, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("second delete status = %d, want 404", rec.Code)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/recording"
 )
 
@@ -307,7 +308,7 @@ func (h *AdminAPIHandler) handleExportRecording(w http.ResponseWriter, r *http.R
 			}
 			argsStr := ""
 			if len(e.RequestArgs) > 0 {
-				if ab, err := json.Marshal(e.RequestArgs); err == nil {
+				if ab, err := json.Marshal(audit.MaskEncryptedArgs(e.RequestArgs)); err == nil {
 					argsStr = string(ab)
 				}
 			}
@@ -497,7 +498,7 @@ func toRecordingEventResponse(e recording.RecordingEvent) recordingEventResponse
 		Decision:          e.Decision,
 		Reason:            e.Reason,
 		RuleID:            e.RuleID,
-		RequestArgs:       e.RequestArgs,
+		RequestArgs:       audit.MaskEncryptedArgs(e.RequestArgs),
 		ResponseBody:      e.ResponseBody,
 		TransformsApplied: e.TransformsApplied,
 		LatencyMicros:     e.LatencyMicros,
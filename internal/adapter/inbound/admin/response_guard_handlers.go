@@ -0,0 +1,49 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+// SetResponseGuardService sets the response guard after construction.
+func (h *AdminAPIHandler) SetResponseGuardService(s *service.ResponseGuardService) {
+	h.responseGuard = s
+}
+
+// handleGetResponseGuard returns the canary state of every guarded tool.
+// GET /admin/api/v1/tools/response-guard
+func (h *AdminAPIHandler) handleGetResponseGuard(w http.ResponseWriter, r *http.Request) {
+	if h.responseGuard == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "response guard not configured")
+		return
+	}
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"tools": h.responseGuard.Status(),
+	})
+}
+
+// handleAcceptResponseChange makes a tool's last canary result its baseline.
+// POST /admin/api/v1/tools/response-guard/{tool_name}/accept
+func (h *AdminAPIHandler) handleAcceptResponseChange(w http.ResponseWriter, r *http.Request) {
+	if h.responseGuard == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "response guard not configured")
+		return
+	}
+	toolName := h.pathParam(r, "tool_name")
+	if err := h.responseGuard.Accept(toolName); err != nil {
+		switch {
+		case errors.Is(err, service.ErrResponseGuardUnknownTool):
+			h.respondError(w, http.StatusNotFound, "tool is not guarded")
+		case errors.Is(err, service.ErrResponseGuardNoResult):
+			h.respondError(w, http.StatusConflict, "no successful canary result to accept yet")
+		default:
+			h.internalError(w, "failed to accept response change", err)
+		}
+		return
+	}
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"accepted": toolName,
+	})
+}
@@ -0,0 +1,286 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/action"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/event"
+	"github.com/google/uuid"
+)
+
+// SetResponseScanner sets the response scanner whose custom detectors are
+// managed by the detector endpoints.
+func (h *AdminAPIHandler) SetResponseScanner(s *action.ResponseScanner) {
+	h.responseScanner = s
+}
+
+// scanDetectorListResponse is the JSON response for GET /admin/api/v1/security/detectors.
+type scanDetectorListResponse struct {
+	Builtin []action.BuiltinPattern `json:"builtin"`
+	Custom  []action.CustomDetector `json:"custom"`
+}
+
+// scanDetectorRequest is the JSON body for creating, updating and testing a detector.
+type scanDetectorRequest struct {
+	Name     string   `json:"name"`
+	Pattern  string   `json:"pattern"`
+	Keywords []string `json:"keywords"`
+	Severity string   `json:"severity"`
+	Category string   `json:"category"`
+}
+
+func (r scanDetectorRequest) detector(id string) action.CustomDetector {
+	return action.CustomDetector{
+		ID:       id,
+		Name:     r.Name,
+		Pattern:  r.Pattern,
+		Keywords: r.Keywords,
+		Severity: r.Severity,
+		Category: r.Category,
+	}
+}
+
+// handleListScanDetectors returns the built-in patterns and the custom detectors.
+// GET /admin/api/v1/security/detectors
+func (h *AdminAPIHandler) handleListScanDetectors(w http.ResponseWriter, r *http.Request) {
+	if h.responseScanner == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "response scanning not available")
+		return
+	}
+	h.respondJSON(w, http.StatusOK, scanDetectorListResponse{
+		Builtin: h.responseScanner.BuiltinPatterns(),
+		Custom:  h.responseScanner.CustomDetectors(),
+	})
+}
+
+// handleCreateScanDetector adds a custom detector to the response scanner.
+// POST /admin/api/v1/security/detectors
+func (h *AdminAPIHandler) handleCreateScanDetector(w http.ResponseWriter, r *http.Request) {
+	if h.responseScanner == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "response scanning not available")
+		return
+	}
+
+	var req scanDetectorRequest
+	if !h.readJSONBody(w, r, &req) {
+		return
+	}
+
+	detector := req.detector("det_" + uuid.New().String())
+	if err := detector.Normalize(); err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	old := h.responseScanner.CustomDetectors()
+	if err := h.applyScanDetectors(append(old, detector), old); err != nil {
+		h.respondDetectorError(w, err)
+		return
+	}
+
+	h.publishDetectorEvent("content.detector_added", detector)
+	h.respondJSON(w, http.StatusCreated, detector)
+}
+
+// handleUpdateScanDetector replaces a custom detector.
+// PUT /admin/api/v1/security/detectors/{id}
+func (h *AdminAPIHandler) handleUpdateScanDetector(w http.ResponseWriter, r *http.Request) {
+	if h.responseScanner == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "response scanning not available")
+		return
+	}
+
+	id := r.PathValue("id")
+	var req scanDetectorRequest
+	if !h.readJSONBody(w, r, &req) {
+		return
+	}
+
+	detector := req.detector(id)
+	if err := detector.Normalize(); err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	old := h.responseScanner.CustomDetectors()
+	updated := make([]action.CustomDetector, len(old))
+	copy(updated, old)
+	found := false
+	for i := range updated {
+		if updated[i].ID == id {
+			updated[i] = detector
+			found = true
+			break
+		}
+	}
+	if !found {
+		h.respondError(w, http.StatusNotFound, action.ErrDetectorNotFound.Error())
+		return
+	}
+	if err := h.applyScanDetectors(updated, old); err != nil {
+		h.respondDetectorError(w, err)
+		return
+	}
+
+	h.publishDetectorEvent("content.detector_updated", detector)
+	h.respondJSON(w, http.StatusOK, detector)
+}
+
+// handleDeleteScanDetector removes a custom detector.
+// DELETE /admin/api/v1/security/detectors/{id}
+func (h *AdminAPIHandler) handleDeleteScanDetector(w http.ResponseWriter, r *http.Request) {
+	if h.responseScanner == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "response scanning not available")
+		return
+	}
+
+	id := r.PathValue("id")
+	old := h.responseScanner.CustomDetectors()
+	remaining := make([]action.CustomDetector, 0, len(old))
+	var removed *action.CustomDetector
+	for i := range old {
+		if old[i].ID == id {
+			removed = &old[i]
+			continue
+		}
+		remaining = append(remaining, old[i])
+	}
+	if removed == nil {
+		h.respondError(w, http.StatusNotFound, action.ErrDetectorNotFound.Error())
+		return
+	}
+	if err := h.applyScanDetectors(remaining, old); err != nil {
+		h.respondDetectorError(w, err)
+		return
+	}
+
+	h.publishDetectorEvent("content.detector_removed", *removed)
+	h.respondJSON(w, http.StatusOK, map[string]string{"status": "removed"})
+}
+
+// scanDetectorTestRequest is the JSON body for POST /admin/api/v1/security/detectors/test.
+type scanDetectorTestRequest struct {
+	scanDetectorRequest
+	Text string `json:"text"`
+}
+
+// handleTestScanDetector runs a detector against sample text without adding it.
+// POST /admin/api/v1/security/detectors/test
+func (h *AdminAPIHandler) handleTestScanDetector(w http.ResponseWriter, r *http.Request) {
+	var req scanDetectorTestRequest
+	if !h.readJSONBody(w, r, &req) {
+		return
+	}
+	findings, err := req.detector("").Match(req.Text)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if findings == nil {
+		findings = []action.ScanFinding{}
+	}
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"detected": len(findings) > 0,
+		"findings": findings,
+	})
+}
+
+// errDetectorPersist marks a failure to save detectors after they were applied.
+var errDetectorPersist = errors.New("failed to persist detectors")
+
+// applyScanDetectors loads detectors into the scanner and persists them,
+// restoring old if the state cannot be saved.
+func (h *AdminAPIHandler) applyScanDetectors(detectors, old []action.CustomDetector) error {
+	if err := h.responseScanner.SetCustomDetectors(detectors); err != nil {
+		return err
+	}
+	if h.stateStore != nil {
+		if err := h.persistScanDetectors(); err != nil {
+			if rbErr := h.responseScanner.SetCustomDetectors(old); rbErr != nil {
+				h.logger.Error("failed to restore detectors", "error", rbErr)
+			}
+			h.logger.Error("failed to persist detectors", "error", err)
+			return errDetectorPersist
+		}
+	}
+	return nil
+}
+
+func (h *AdminAPIHandler) respondDetectorError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errDetectorPersist) {
+		h.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.respondError(w, http.StatusBadRequest, err.Error())
+}
+
+// persistScanDetectors saves the custom detectors to state.json, keeping the
+// creation time of existing entries.
+func (h *AdminAPIHandler) persistScanDetectors() error {
+	detectors := h.responseScanner.CustomDetectors()
+	return h.stateStore.Mutate(func(appState *state.AppState) error {
+		if appState.ContentScanningConfig == nil {
+			appState.ContentScanningConfig = &state.ContentScanningConfig{}
+		}
+		now := time.Now().UTC()
+		previous := make(map[string]state.ScanDetectorEntry, len(appState.ContentScanningConfig.Detectors))
+		for _, e := range appState.ContentScanningConfig.Detectors {
+			previous[e.ID] = e
+		}
+		entries := make([]state.ScanDetectorEntry, 0, len(detectors))
+		for _, d := range detectors {
+			entry := state.ScanDetectorEntry{
+				ID:        d.ID,
+				Name:      d.Name,
+				Pattern:   d.Pattern,
+				Keywords:  d.Keywords,
+				Severity:  d.Severity,
+				Category:  d.Category,
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			if p, ok := previous[d.ID]; ok {
+				entry.CreatedAt = p.CreatedAt
+				if sameDetector(p, entry) {
+					entry.UpdatedAt = p.UpdatedAt
+				}
+			}
+			entries = append(entries, entry)
+		}
+		appState.ContentScanningConfig.Detectors = entries
+		appState.ContentScanningConfig.UpdatedAt = now
+		return nil
+	})
+}
+
+func sameDetector(a, b state.ScanDetectorEntry) bool {
+	if a.Name != b.Name || a.Pattern != b.Pattern || a.Severity != b.Severity ||
+		a.Category != b.Category || len(a.Keywords) != len(b.Keywords) {
+		return false
+	}
+	for i := range a.Keywords {
+		if a.Keywords[i] != b.Keywords[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (h *AdminAPIHandler) publishDetectorEvent(eventType string, d action.CustomDetector) {
+	if h.eventBus == nil {
+		return
+	}
+	h.eventBus.Publish(context.Background(), event.Event{
+		Type:     eventType,
+		Source:   "content-scanning",
+		Severity: event.SeverityInfo,
+		Payload: map[string]string{
+			"id":       d.ID,
+			"name":     d.Name,
+			"category": d.Category,
+			"severity": d.Severity,
+		},
+	})
+}
@@ -0,0 +1,158 @@
+package admin
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/action"
+)
+
+func newTestHandlerWithDetectors(t *testing.T) (*AdminAPIHandler, *state.FileStateStore) {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	store := state.NewFileStateStore(filepath.Join(t.TempDir(), "state.json"), logger)
+	h := NewAdminAPIHandler(WithStateStore(store), WithAPILogger(logger))
+	h.SetResponseScanner(action.NewResponseScanner())
+	return h, store
+}
+
+func TestScanDetectorHandlers_Lifecycle(t *testing.T) {
+	h, store := newTestHandlerWithDetectors(t)
+
+	body := `{"name": "acme_token", "pattern": "acme_[a-f0-9]{32}", "severity": "critical", "category": "secret"}`
+	w := httptest.NewRecorder()
+	h.handleCreateScanDetector(w, httptest.NewRequest(http.MethodPost, "/admin/api/v1/security/detectors", bytes.NewBufferString(body)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created action.CustomDetector
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	if created.ID == "" || created.Severity != "critical" {
+		t.Fatalf("created = %+v", created)
+	}
+	if f := h.responseScanner.Scan("acme_0123456789abcdef0123456789abcdef").Findings; len(f) != 1 {
+		t.Errorf("findings after create = %+v, want the new detector to be active", f)
+	}
+
+	// Duplicate names are rejected.
+	w = httptest.NewRecorder()
+	h.handleCreateScanDetector(w, httptest.NewRequest(http.MethodPost, "/admin/api/v1/security/detectors", bytes.NewBufferString(body)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("duplicate create: expected 400, got %d", w.Code)
+	}
+
+	appState, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if appState.ContentScanningConfig == nil || len(appState.ContentScanningConfig.Detectors) != 1 {
+		t.Fatalf("persisted detectors = %+v, want 1", appState.ContentScanningConfig)
+	}
+	createdAt := appState.ContentScanningConfig.Detectors[0].CreatedAt
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/admin/api/v1/security/detectors/"+created.ID,
+		bytes.NewBufferString(`{"name": "acme_token", "keywords": ["acme-internal"]}`))
+	req.SetPathValue("id", created.ID)
+	h.handleUpdateScanDetector(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	appState, _ = store.Load()
+	if e := appState.ContentScanningConfig.Detectors[0]; len(e.Keywords) != 1 || e.Pattern != "" || !e.CreatedAt.Equal(createdAt) {
+		t.Errorf("persisted detector after update = %+v", e)
+	}
+
+	w = httptest.NewRecorder()
+	h.handleListScanDetectors(w, httptest.NewRequest(http.MethodGet, "/admin/api/v1/security/detectors", nil))
+	var list scanDetectorListResponse
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Builtin) == 0 || len(list.Custom) != 1 || list.Custom[0].Category != "custom" {
+		t.Errorf("list = %+v", list)
+	}
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodDelete, "/admin/api/v1/security/detectors/"+created.ID, nil)
+	req.SetPathValue("id", created.ID)
+	h.handleDeleteScanDetector(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("delete: expected 200, got %d", w.Code)
+	}
+	if len(h.responseScanner.CustomDetectors()) != 0 {
+		t.Error("detector still active after delete")
+	}
+	appState, _ = store.Load()
+	if len(appState.ContentScanningConfig.Detectors) != 0 {
+		t.Errorf("persisted detectors after delete = %+v", appState.ContentScanningConfig.Detectors)
+	}
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodDelete, "/admin/api/v1/security/detectors/det_missing", nil)
+	req.SetPathValue("id", "det_missing")
+	h.handleDeleteScanDetector(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("delete missing: expected 404, got %d", w.Code)
+	}
+}
+
+func TestScanDetectorHandlers_InvalidDetector(t *testing.T) {
+	h, _ := newTestHandlerWithDetectors(t)
+	for _, body := range []string{
+		`{"name": "x", "pattern": "(unclosed"}`,
+		`{"name": "x", "pattern": ".*"}`,
+		`{"name": "Bad Name", "pattern": "x"}`,
+		`{"name": "role_hijack", "pattern": "x"}`,
+	} {
+		w := httptest.NewRecorder()
+		h.handleCreateScanDetector(w, httptest.NewRequest(http.MethodPost, "/admin/api/v1/security/detectors", bytes.NewBufferString(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, w.Code)
+		}
+	}
+	if len(h.responseScanner.CustomDetectors()) != 0 {
+		t.Error("invalid detectors were added")
+	}
+}
+
+func TestScanDetectorHandlers_Test(t *testing.T) {
+	h, _ := newTestHandlerWithDetectors(t)
+	body := `{"name": "ticket", "pattern": "INC-\\d{6}", "text": "see INC-123456"}`
+	w := httptest.NewRecorder()
+	h.handleTestScanDetector(w, httptest.NewRequest(http.MethodPost, "/admin/api/v1/security/detectors/test", bytes.NewBufferString(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Detected bool                 `json:"detected"`
+		Findings []action.ScanFinding `json:"findings"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Detected || len(resp.Findings) != 1 || resp.Findings[0].MatchedText != "INC-123456" {
+		t.Errorf("response = %+v", resp)
+	}
+	if len(h.responseScanner.CustomDetectors()) != 0 {
+		t.Error("testing a detector added it")
+	}
+}
+
+func TestScanDetectorHandlers_Unavailable(t *testing.T) {
+	h := NewAdminAPIHandler()
+	w := httptest.NewRecorder()
+	h.handleListScanDetectors(w, httptest.NewRequest(http.MethodGet, "/admin/api/v1/security/detectors", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", w.Code)
+	}
+}
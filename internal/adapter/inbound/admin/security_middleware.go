@@ -35,6 +35,13 @@ func isTLSRequest(r *http.Request, isTrustedProxy func(net.IP) bool) bool {
 	return r.Header.Get("X-Forwarded-Proto") == "https"
 }
 
+// defaultContentSecurityPolicy only allows same-origin resources. It is sent
+// with the admin API, the embedded UI and, unless replaced in the config, a
+// UI bundle served from a directory.
+const defaultContentSecurityPolicy = "default-src 'self'; script-src 'self'; style-src 'self' 'unsafe-inline'; " +
+	"img-src 'self' data:; font-src 'self'; connect-src 'self'; " +
+	"frame-ancestors 'none'; form-action 'self'"
+
 // cspMiddleware sets Content Security Policy and related security headers on all responses.
 // CSP restricts resource loading to mitigate XSS and data injection attacks (SECU-03).
 func cspMiddleware(next http.Handler) http.Handler {
@@ -42,11 +49,14 @@ func cspMiddleware(next http.Handler) http.Handler {
 }
 
 func cspMiddlewareWithTLS(next http.Handler, isTrustedProxy func(net.IP) bool) http.Handler {
+	return cspMiddlewareWithPolicy(next, defaultContentSecurityPolicy, isTrustedProxy)
+}
+
+// cspMiddlewareWithPolicy is cspMiddlewareWithTLS with a custom
+// Content-Security-Policy.
+func cspMiddlewareWithPolicy(next http.Handler, policy string, isTrustedProxy func(net.IP) bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Security-Policy",
-			"default-src 'self'; script-src 'self'; style-src 'self' 'unsafe-inline'; "+
-				"img-src 'self' data:; font-src 'self'; connect-src 'self'; "+
-				"frame-ancestors 'none'; form-action 'self'")
+		w.Header().Set("Content-Security-Policy", policy)
 		w.Header().Set("X-Content-Type-Options", "nosniff")
 		w.Header().Set("X-Frame-Options", "DENY")
 		w.Header().Set("Referrer-Policy", "strict-origin-when-cross-origin")
@@ -154,7 +164,12 @@ func (h *AdminAPIHandler) csrfMiddlewareWithProxyTrust(next http.Handler) http.H
 			return
 		}
 
-		if strings.HasPrefix(r.URL.Path, "/admin/api/auth/") {
+		// Admin tokens are sent explicitly in a header a browser never adds
+		// cross-site, so they need no CSRF token. adminAuthMiddleware rejects
+		// the request unless the token is valid. The same holds for the API
+		// keys identityAuthMiddleware checks on /admin/api/me/.
+		if strings.HasPrefix(r.URL.Path, "/admin/api/auth/") || bearerAdminToken(r) != "" ||
+			(strings.HasPrefix(r.URL.Path, "/admin/api/me/") && bearerAPIKey(r) != "") {
 			next.ServeHTTP(w, r)
 			return
 		}
@@ -0,0 +1,217 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/action"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+// RateLimitReporter reports the rate limit buckets of an identity.
+// Implemented by action.ActionUserRateLimitInterceptor.
+type RateLimitReporter interface {
+	Status(ctx context.Context, identityID, identityName string) ([]action.RateLimitStatus, error)
+}
+
+// SetRateLimitReporter sets the reporter of the identity rate limits after
+// construction, since it is created with the interceptor chain. Nil when
+// rate limiting is disabled.
+func (h *AdminAPIHandler) SetRateLimitReporter(r RateLimitReporter) {
+	h.rateLimitReporter = r
+}
+
+// myDenialsWindow is how far back GET /admin/api/me/denials looks.
+const myDenialsWindow = 7 * 24 * time.Hour
+
+// meKeyResponse describes the API key of a self-service request.
+type meKeyResponse struct {
+	ID             string   `json:"id"`
+	Name           string   `json:"name"`
+	CreatedAt      string   `json:"created_at"`
+	ExpiresAt      string   `json:"expires_at,omitempty"`
+	ReadOnly       bool     `json:"read_only"`
+	AllowedOrigins []string `json:"allowed_origins"`
+}
+
+// meResponse is the JSON response of GET /admin/api/me.
+type meResponse struct {
+	ID    string        `json:"id"`
+	Name  string        `json:"name"`
+	Roles []string      `json:"roles"`
+	Key   meKeyResponse `json:"key"`
+}
+
+// handleGetMe returns the identity of the API key and the key itself.
+// GET /admin/api/me
+func (h *AdminAPIHandler) handleGetMe(w http.ResponseWriter, r *http.Request) {
+	caller := keyIdentityFromContext(r.Context())
+	resp := meResponse{
+		ID:    caller.ID,
+		Name:  caller.Name,
+		Roles: caller.Roles,
+		Key: meKeyResponse{
+			ID:             caller.Key.ID,
+			Name:           caller.Key.Name,
+			CreatedAt:      caller.Key.CreatedAt.UTC().Format(time.RFC3339),
+			ReadOnly:       caller.Key.ReadOnly,
+			AllowedOrigins: nonNilOrigins(caller.Key.AllowedOrigins),
+		},
+	}
+	if resp.Roles == nil {
+		resp.Roles = []string{}
+	}
+	if caller.Key.ExpiresAt != nil {
+		resp.Key.ExpiresAt = caller.Key.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	h.respondJSON(w, http.StatusOK, resp)
+}
+
+// meRateLimitBucket is one rate limit bucket in GET /admin/api/me/rate-limits.
+type meRateLimitBucket struct {
+	action.RateLimitStatus
+	RetryAfterSecs float64 `json:"retry_after_seconds,omitempty"`
+}
+
+// meRateLimitsResponse is the JSON response of GET /admin/api/me/rate-limits.
+type meRateLimitsResponse struct {
+	Enabled bool                `json:"enabled"`
+	Buckets []meRateLimitBucket `json:"buckets"`
+}
+
+// handleGetMyRateLimits returns the state of the caller's user rate limit
+// and of the tool overrides applying to it, without counting a request.
+// GET /admin/api/me/rate-limits
+func (h *AdminAPIHandler) handleGetMyRateLimits(w http.ResponseWriter, r *http.Request) {
+	resp := meRateLimitsResponse{Buckets: []meRateLimitBucket{}}
+	if h.rateLimitReporter == nil {
+		h.respondJSON(w, http.StatusOK, resp)
+		return
+	}
+	caller := keyIdentityFromContext(r.Context())
+	statuses, err := h.rateLimitReporter.Status(r.Context(), caller.ID, caller.Name)
+	if err != nil {
+		h.internalError(w, "failed to read rate limits", err)
+		return
+	}
+	resp.Enabled = true
+	for _, st := range statuses {
+		resp.Buckets = append(resp.Buckets, meRateLimitBucket{
+			RateLimitStatus: st,
+			RetryAfterSecs:  st.RetryAfter.Seconds(),
+		})
+	}
+	h.respondJSON(w, http.StatusOK, resp)
+}
+
+// meDenial is one denied call in GET /admin/api/me/denials.
+type meDenial struct {
+	Timestamp string `json:"timestamp"`
+	ToolName  string `json:"tool_name"`
+	Reason    string `json:"reason,omitempty"`
+	RuleID    string `json:"rule_id,omitempty"`
+	SessionID string `json:"session_id,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// handleListMyDenials returns the caller's denied calls of the last 7 days,
+// most recent first, with the reason of each.
+// GET /admin/api/me/denials?limit=20
+func (h *AdminAPIHandler) handleListMyDenials(w http.ResponseWriter, r *http.Request) {
+	limit := 20
+	if s := r.URL.Query().Get("limit"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 1 {
+			h.respondError(w, http.StatusBadRequest, "invalid 'limit' parameter")
+			return
+		}
+		if v > 100 {
+			v = 100
+		}
+		limit = v
+	}
+	if h.auditReader == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "audit log not available")
+		return
+	}
+
+	caller := keyIdentityFromContext(r.Context())
+	now := time.Now().UTC()
+	records, _, err := h.auditReader.Query(r.Context(), audit.AuditFilter{
+		StartTime:     now.Add(-myDenialsWindow),
+		EndTime:       now,
+		UserID:        caller.ID,
+		Decision:      "deny",
+		Limit:         limit,
+		LimitExplicit: true,
+	})
+	if err != nil {
+		h.internalError(w, "failed to query denials", err)
+		return
+	}
+	denials := make([]meDenial, 0, len(records))
+	for _, rec := range records {
+		denials = append(denials, meDenial{
+			Timestamp: rec.Timestamp.UTC().Format(time.RFC3339),
+			ToolName:  rec.ToolName,
+			Reason:    rec.Reason,
+			RuleID:    rec.RuleID,
+			SessionID: rec.SessionID,
+			RequestID: rec.RequestID,
+		})
+	}
+	h.respondJSON(w, http.StatusOK, denials)
+}
+
+// handleRotateMyKey replaces the API key of the request with a new one of
+// the same name and origins, and revokes it. The sessions of the identity
+// are closed; clients reconnect with the new key.
+// POST /admin/api/me/key/rotate
+func (h *AdminAPIHandler) handleRotateMyKey(w http.ResponseWriter, r *http.Request) {
+	caller := keyIdentityFromContext(r.Context())
+	if caller.Key.ReadOnly {
+		h.respondError(w, http.StatusForbidden, "keys from the config file cannot be rotated")
+		return
+	}
+	ctx := r.Context()
+	result, err := h.identityService.GenerateKey(ctx, service.GenerateKeyInput{
+		IdentityID:     caller.ID,
+		Name:           caller.Key.Name,
+		AllowedOrigins: caller.Key.AllowedOrigins,
+	})
+	if err != nil {
+		// SECU-06: Only log the error, never the cleartext key.
+		h.internalError(w, "failed to rotate key", err)
+		return
+	}
+	if _, err := h.identityService.RevokeKey(ctx, caller.Key.ID); err != nil {
+		// Keep the old key working rather than leave two.
+		if _, rerr := h.identityService.RevokeKey(ctx, result.KeyEntry.ID); rerr != nil && !errors.Is(rerr, service.ErrAPIKeyNotFound) {
+			h.logger.Error("failed to revoke new key after failed rotation", "key_id", result.KeyEntry.ID, "error", rerr)
+		}
+		h.internalError(w, "failed to rotate key", err)
+		return
+	}
+	h.logger.Info("api key rotated by its identity",
+		"identity_id", caller.ID, "old_key_id", caller.Key.ID, "new_key_id", result.KeyEntry.ID)
+
+	// BUG-6: Sessions opened with the revoked key must not outlive it.
+	if h.sessionCacheInvalidator != nil {
+		h.sessionCacheInvalidator.InvalidateByIdentity(caller.ID)
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	h.respondJSON(w, http.StatusCreated, generateKeyResponse{
+		ID:             result.KeyEntry.ID,
+		IdentityID:     result.KeyEntry.IdentityID,
+		Name:           result.KeyEntry.Name,
+		CleartextKey:   result.CleartextKey,
+		CreatedAt:      result.KeyEntry.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"),
+		AllowedOrigins: nonNilOrigins(result.KeyEntry.AllowedOrigins),
+	})
+}
@@ -0,0 +1,184 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/action"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+type stubRateLimitReporter struct {
+	identityID string
+}
+
+func (s *stubRateLimitReporter) Status(_ context.Context, identityID, _ string) ([]action.RateLimitStatus, error) {
+	s.identityID = identityID
+	return []action.RateLimitStatus{
+		{Scope: "user", Rate: 60, Burst: 60, Limited: true, RetryAfter: 1500 * time.Millisecond},
+	}, nil
+}
+
+type recordingInvalidator struct {
+	identities []string
+}
+
+func (r *recordingInvalidator) InvalidateBySessionID(string) {}
+func (r *recordingInvalidator) InvalidateByIdentity(identityID string) {
+	r.identities = append(r.identities, identityID)
+}
+
+type selfServiceTestEnv struct {
+	*approvalTestEnv
+	identityService *service.IdentityService
+	identityID      string
+	key             string
+	keyID           string
+	reporter        *stubRateLimitReporter
+	invalidator     *recordingInvalidator
+}
+
+func setupSelfServiceTestEnv(t *testing.T) *selfServiceTestEnv {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	stateStore := state.NewFileStateStore(filepath.Join(t.TempDir(), "state.json"), logger)
+	if err := stateStore.Save(stateStore.DefaultState()); err != nil {
+		t.Fatalf("save default state: %v", err)
+	}
+	identitySvc := service.NewIdentityService(stateStore, logger)
+	ctx := context.Background()
+	identity, err := identitySvc.CreateIdentity(ctx, service.CreateIdentityInput{Name: "ci-bot", Roles: []string{"ci"}})
+	if err != nil {
+		t.Fatalf("CreateIdentity: %v", err)
+	}
+	key, err := identitySvc.GenerateKey(ctx, service.GenerateKeyInput{IdentityID: identity.ID, Name: "ci-key"})
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	now := time.Now().UTC()
+	reader := &mockAuditReader{records: []audit.AuditRecord{
+		{Timestamp: now.Add(-time.Minute), IdentityID: identity.ID, ToolName: "delete_file", Decision: "deny", Reason: "deletes are blocked", RuleID: "no-deletes"},
+		{Timestamp: now.Add(-2 * time.Minute), IdentityID: identity.ID, ToolName: "read_file", Decision: "allow"},
+		{Timestamp: now.Add(-3 * time.Minute), IdentityID: "someone-else", ToolName: "delete_file", Decision: "deny"},
+	}}
+	reporter := &stubRateLimitReporter{}
+	invalidator := &recordingInvalidator{}
+	handler := NewAdminAPIHandler(
+		WithIdentityService(identitySvc),
+		WithAuditReader(reader),
+		WithAPILogger(logger),
+	)
+	handler.SetRateLimitReporter(reporter)
+	handler.SetSessionCacheInvalidator(invalidator)
+	return &selfServiceTestEnv{
+		approvalTestEnv: &approvalTestEnv{handler: handler, mux: handler.Routes()},
+		identityService: identitySvc,
+		identityID:      identity.ID,
+		key:             key.CleartextKey,
+		keyID:           key.KeyEntry.ID,
+		reporter:        reporter,
+		invalidator:     invalidator,
+	}
+}
+
+func TestHandleGetMe(t *testing.T) {
+	env := setupSelfServiceTestEnv(t)
+
+	rec := env.doKeyRequest(t, "GET", "/admin/api/me", env.key, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /admin/api/me status = %d (body=%s)", rec.Code, rec.Body.String())
+	}
+	var me meResponse
+	decodeApprovalJSON(t, rec, &me)
+	if me.ID != env.identityID || me.Name != "ci-bot" || len(me.Roles) != 1 || me.Key.ID != env.keyID || me.Key.Name != "ci-key" {
+		t.Errorf("GET /admin/api/me = %+v", me)
+	}
+
+	// Admin access from localhost does not reach the self-service routes.
+	rec = env.doRequest(t, "GET", "/admin/api/me", nil)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("GET /admin/api/me without key status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestHandleGetMyRateLimits(t *testing.T) {
+	env := setupSelfServiceTestEnv(t)
+
+	rec := env.doKeyRequest(t, "GET", "/admin/api/me/rate-limits", env.key, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET rate-limits status = %d (body=%s)", rec.Code, rec.Body.String())
+	}
+	var resp map[string]any
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	buckets, _ := resp["buckets"].([]any)
+	if resp["enabled"] != true || len(buckets) != 1 || env.reporter.identityID != env.identityID {
+		t.Fatalf("GET rate-limits = %v", resp)
+	}
+	if b := buckets[0].(map[string]any); b["limited"] != true || b["retry_after_seconds"] != 1.5 || b["scope"] != "user" {
+		t.Errorf("bucket = %v", b)
+	}
+}
+
+func TestHandleListMyDenials(t *testing.T) {
+	env := setupSelfServiceTestEnv(t)
+
+	rec := env.doKeyRequest(t, "GET", "/admin/api/me/denials", env.key, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET denials status = %d (body=%s)", rec.Code, rec.Body.String())
+	}
+	var denials []meDenial
+	decodeApprovalJSON(t, rec, &denials)
+	if len(denials) != 1 || denials[0].ToolName != "delete_file" || denials[0].Reason != "deletes are blocked" || denials[0].RuleID != "no-deletes" {
+		t.Errorf("GET denials = %+v", denials)
+	}
+
+	if rec := env.doKeyRequest(t, "GET", "/admin/api/me/denials?limit=x", env.key, nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("GET denials with invalid limit status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandleRotateMyKey(t *testing.T) {
+	env := setupSelfServiceTestEnv(t)
+
+	rec := env.doKeyRequest(t, "POST", "/admin/api/me/key/rotate", env.key, nil)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST rotate status = %d (body=%s)", rec.Code, rec.Body.String())
+	}
+	var resp generateKeyResponse
+	decodeApprovalJSON(t, rec, &resp)
+	if resp.CleartextKey == "" || resp.ID == env.keyID || resp.Name != "ci-key" || resp.IdentityID != env.identityID {
+		t.Fatalf("POST rotate = %+v", resp)
+	}
+	if len(env.invalidator.identities) != 1 || env.invalidator.identities[0] != env.identityID {
+		t.Errorf("invalidated sessions of %v, want the identity", env.invalidator.identities)
+	}
+
+	// The old key is revoked, the new one works.
+	if rec := env.doKeyRequest(t, "GET", "/admin/api/me", env.key, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("GET with the old key status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if rec := env.doKeyRequest(t, "GET", "/admin/api/me", resp.CleartextKey, nil); rec.Code != http.StatusOK {
+		t.Errorf("GET with the new key status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	keys, _ := env.identityService.ListKeys(context.Background(), env.identityID)
+	active := 0
+	for _, k := range keys {
+		if !k.Revoked {
+			active++
+		}
+	}
+	if active != 1 {
+		t.Errorf("identity has %d active keys after rotation, want 1", active)
+	}
+}
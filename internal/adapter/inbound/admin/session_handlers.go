@@ -1,6 +1,7 @@
 package admin
 
 import (
+	"errors"
 	"net/http"
 	"sort"
 
@@ -43,9 +44,13 @@ type activeSessionResponse struct {
 	WindowCalls  int64  `json:"window_calls"`
 	StartedAt    string `json:"started_at"`
 	LastCallAt   string `json:"last_call_at,omitempty"`
+	// Labels are the session labels; omitted when the session has none.
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
 // handleListActiveSessions returns all active sessions with usage data.
+// Repeated label=key=value (or label=key) parameters keep only the sessions
+// carrying all of those labels.
 // GET /admin/api/v1/sessions/active
 func (h *AdminAPIHandler) handleListActiveSessions(w http.ResponseWriter, r *http.Request) {
 	if h.sessionTracker == nil {
@@ -53,6 +58,12 @@ func (h *AdminAPIHandler) handleListActiveSessions(w http.ResponseWriter, r *htt
 		return
 	}
 
+	selector, err := session.ParseLabelSelector(r.URL.Query()["label"])
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	sessions := h.sessionTracker.ActiveSessions()
 
 	// Sort by LastCallAt descending (most recent first).
@@ -62,6 +73,10 @@ func (h *AdminAPIHandler) handleListActiveSessions(w http.ResponseWriter, r *htt
 
 	result := make([]activeSessionResponse, 0, len(sessions))
 	for _, s := range sessions {
+		labels := h.sessionLabels(r, s.SessionID)
+		if !session.MatchLabels(labels, selector) {
+			continue
+		}
 		resp := activeSessionResponse{
 			SessionID:    s.SessionID,
 			IdentityID:   s.IdentityID,
@@ -72,6 +87,7 @@ func (h *AdminAPIHandler) handleListActiveSessions(w http.ResponseWriter, r *htt
 			DeleteCalls:  s.Usage.DeleteCalls,
 			WindowCalls:  s.Usage.WindowCalls,
 			StartedAt:    s.Usage.StartedAt.UTC().Format("2006-01-02T15:04:05Z"),
+			Labels:       labels,
 		}
 		if !s.Usage.LastCallAt.IsZero() {
 			resp.LastCallAt = s.Usage.LastCallAt.UTC().Format("2006-01-02T15:04:05Z")
@@ -82,6 +98,68 @@ func (h *AdminAPIHandler) handleListActiveSessions(w http.ResponseWriter, r *htt
 	h.respondJSON(w, http.StatusOK, result)
 }
 
+// sessionLabels returns the labels of a session, or nil when it has none or
+// the session service is not configured.
+func (h *AdminAPIHandler) sessionLabels(r *http.Request, sessionID string) map[string]string {
+	if h.sessionService == nil {
+		return nil
+	}
+	sess, err := h.sessionService.Get(r.Context(), sessionID)
+	if err != nil {
+		return nil
+	}
+	return sess.Labels
+}
+
+// setSessionLabelsRequest is the JSON body for PUT /admin/api/v1/sessions/{id}/labels.
+type setSessionLabelsRequest struct {
+	Labels map[string]string `json:"labels"`
+}
+
+// handleSetSessionLabels replaces the labels of an active session. Calls
+// made after the update carry the new labels in their audit records.
+// PUT /admin/api/v1/sessions/{id}/labels
+func (h *AdminAPIHandler) handleSetSessionLabels(w http.ResponseWriter, r *http.Request) {
+	if h.sessionService == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "session service not configured")
+		return
+	}
+
+	sessionID := h.pathParam(r, "id")
+	if sessionID == "" {
+		h.respondError(w, http.StatusBadRequest, "session ID required")
+		return
+	}
+
+	var req setSessionLabelsRequest
+	if err := h.readJSON(r, &req); err != nil {
+		h.handleReadJSONErr(w, err)
+		return
+	}
+
+	if err := session.ValidateLabels(req.Labels); err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sess, err := h.sessionService.SetLabels(r.Context(), sessionID, req.Labels)
+	if err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			h.respondError(w, http.StatusNotFound, "session not found")
+			return
+		}
+		h.logger.Error("failed to set session labels", "session_id", sessionID, "error", err)
+		h.respondError(w, http.StatusInternalServerError, "failed to set session labels")
+		return
+	}
+
+	h.logger.Info("session labels set via admin API", "session_id", sessionID, "labels", len(sess.Labels))
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"session_id": sess.ID,
+		"labels":     sess.Labels,
+	})
+}
+
 // handleTerminateSession removes an active session.
 // DELETE /admin/api/v1/sessions/{id}
 // BUG-6 FIX: Also invalidates the auth interceptor cache and deletes the
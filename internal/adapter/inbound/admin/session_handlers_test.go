@@ -1,14 +1,18 @@
 package admin
 
 import (
+	"context"
 	"encoding/json"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/memory"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/auth"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/session"
 )
 
@@ -108,3 +112,73 @@ func TestHandleListActiveSessions_WithData(t *testing.T) {
 		t.Error("LastCallAt is empty")
 	}
 }
+
+func TestHandleSetSessionLabels_AndFilter(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	tracker := session.NewSessionTracker(1*time.Minute, session.DefaultClassifier())
+	store := memory.NewSessionStore()
+	sessions := session.NewSessionService(store, session.Config{})
+
+	checkout, err := sessions.Create(context.Background(), &auth.Identity{ID: "identity-1", Name: "Alice"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	other, err := sessions.Create(context.Background(), &auth.Identity{ID: "identity-2", Name: "Bob"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	tracker.RecordCall(checkout.ID, "read_file", "identity-1", "Alice", nil)
+	tracker.RecordCall(other.ID, "read_file", "identity-2", "Bob", nil)
+
+	h := NewAdminAPIHandler(
+		WithSessionTracker(tracker),
+		WithAPILogger(logger),
+	)
+	h.SetSessionService(sessions)
+
+	setLabels := func(id, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/admin/api/v1/sessions/"+id+"/labels", strings.NewReader(body))
+		req.SetPathValue("id", id)
+		w := httptest.NewRecorder()
+		h.handleSetSessionLabels(w, req)
+		return w
+	}
+
+	if w := setLabels(checkout.ID, `{"labels":{"project":"checkout-bot","run-id":"1234"}}`); w.Code != http.StatusOK {
+		t.Fatalf("set labels status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if w := setLabels(other.ID, `{"labels":{"project":"search"}}`); w.Code != http.StatusOK {
+		t.Fatalf("set labels status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if w := setLabels("missing", `{"labels":{"project":"x"}}`); w.Code != http.StatusNotFound {
+		t.Errorf("unknown session status = %d, want 404", w.Code)
+	}
+	if w := setLabels(checkout.ID, `{"labels":{"bad key":"x"}}`); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid label status = %d, want 400", w.Code)
+	}
+
+	list := func(query string) []activeSessionResponse {
+		req := httptest.NewRequest(http.MethodGet, "/admin/api/v1/sessions/active"+query, nil)
+		w := httptest.NewRecorder()
+		h.handleListActiveSessions(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("list %q status = %d", query, w.Code)
+		}
+		var items []activeSessionResponse
+		if err := json.NewDecoder(w.Body).Decode(&items); err != nil {
+			t.Fatalf("decode JSON: %v", err)
+		}
+		return items
+	}
+
+	if items := list(""); len(items) != 2 {
+		t.Errorf("unfiltered count = %d, want 2", len(items))
+	}
+	items := list("?label=project=checkout-bot&label=run-id")
+	if len(items) != 1 || items[0].SessionID != checkout.ID || items[0].Labels["run-id"] != "1234" {
+		t.Errorf("filtered = %+v, want only the checkout session", items)
+	}
+	if items := list("?label=project=billing"); len(items) != 0 {
+		t.Errorf("non-matching filter count = %d, want 0", len(items))
+	}
+}
@@ -1,6 +1,7 @@
 package admin
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
@@ -53,3 +54,35 @@ func (h *AdminAPIHandler) handleRunSimulation(w http.ResponseWriter, r *http.Req
 
 	h.respondJSON(w, http.StatusOK, result)
 }
+
+// handleReplayPolicies replays a window of audit history through a candidate
+// policy set and reports the decision changes per rule. The candidate set is
+// never activated.
+// POST /admin/api/v1/simulation/replay
+func (h *AdminAPIHandler) handleReplayPolicies(w http.ResponseWriter, r *http.Request) {
+	if h.simulationService == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "simulation service not available")
+		return
+	}
+
+	var req service.ReplayRequest
+	if !h.readJSONBody(w, r, &req) {
+		return
+	}
+
+	result, err := h.simulationService.Replay(r.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidCandidatePolicy), errors.Is(err, service.ErrInvalidReplayWindow):
+			h.respondError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrReplayUnavailable):
+			h.respondError(w, http.StatusServiceUnavailable, err.Error())
+		default:
+			h.logger.Error("policy replay failed", "error", err)
+			h.respondError(w, http.StatusInternalServerError, "policy replay failed")
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, result)
+}
@@ -172,3 +172,42 @@ func TestHandleRunSimulation_WithMaxRecords(t *testing.T) {
 		t.Errorf("DurationMs = %d, want >= 0", result.DurationMs)
 	}
 }
+
+func TestHandleReplayPolicies(t *testing.T) {
+	env := setupSimulationTestEnv(t)
+
+	body := map[string]interface{}{
+		"policies": []map[string]interface{}{
+			{"name": "candidate", "rules": []map[string]interface{}{{"name": "deny all", "action": "deny"}}},
+		},
+	}
+	rec := env.doRequest(t, "POST", "/admin/api/v1/simulation/replay", body)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("without audit history: status = %d, want 503 (body=%s)", rec.Code, rec.Body.String())
+	}
+
+	env.simulationService.SetAuditQuery(memory.NewAuditStore())
+	rec = env.doRequest(t, "POST", "/admin/api/v1/simulation/replay", body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body=%s)", rec.Code, rec.Body.String())
+	}
+	var result service.ReplayResult
+	decodeSimulationJSON(t, rec, &result)
+	if result.TotalProcessed != 0 || result.StartTime.IsZero() {
+		t.Errorf("result = %+v", result)
+	}
+
+	bad := map[string]interface{}{
+		"policies": []map[string]interface{}{
+			{"name": "candidate", "rules": []map[string]interface{}{{"action": "deny", "condition": "tool_name =="}}},
+		},
+	}
+	if rec := env.doRequest(t, "POST", "/admin/api/v1/simulation/replay", bad); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid CEL: status = %d, want 400", rec.Code)
+	}
+	body["start_time"] = "2026-01-02T00:00:00Z"
+	body["end_time"] = "2026-01-01T00:00:00Z"
+	if rec := env.doRequest(t, "POST", "/admin/api/v1/simulation/replay", body); rec.Code != http.StatusBadRequest {
+		t.Errorf("reversed window: status = %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,61 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+// WithSLOService sets the SLO tracking service.
+func WithSLOService(s *service.SLOService) AdminAPIOption {
+	return func(h *AdminAPIHandler) { h.sloService = s }
+}
+
+// SetSLOService sets the SLO tracking service after construction.
+func (h *AdminAPIHandler) SetSLOService(s *service.SLOService) {
+	h.sloService = s
+}
+
+// sloResponse is the response body of GET /admin/api/slo.
+type sloResponse struct {
+	Enabled           bool                `json:"enabled"`
+	FastBurnThreshold float64             `json:"fast_burn_threshold,omitempty"`
+	SlowBurnThreshold float64             `json:"slow_burn_threshold,omitempty"`
+	SLOs              []service.SLOStatus `json:"slos"`
+}
+
+// handleGetSLO returns the burn rates and alert state of every configured SLO.
+// GET /admin/api/slo
+func (h *AdminAPIHandler) handleGetSLO(w http.ResponseWriter, r *http.Request) {
+	if h.sloService == nil {
+		h.respondJSON(w, http.StatusOK, sloResponse{SLOs: []service.SLOStatus{}})
+		return
+	}
+	fast, slow := h.sloService.Thresholds()
+	h.respondJSON(w, http.StatusOK, sloResponse{
+		Enabled:           true,
+		FastBurnThreshold: fast,
+		SlowBurnThreshold: slow,
+		SLOs:              h.sloService.Status(),
+	})
+}
+
+// handleGetSLOAlertRules returns a Prometheus alerting rules file for the
+// configured SLOs.
+// GET /admin/api/slo/alerts
+func (h *AdminAPIHandler) handleGetSLOAlertRules(w http.ResponseWriter, r *http.Request) {
+	if h.sloService == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "SLO tracking not configured")
+		return
+	}
+	rules, err := h.sloService.PrometheusAlertRules()
+	if err != nil {
+		h.internalError(w, "failed to generate SLO alert rules", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Header().Set("Content-Disposition", `attachment; filename="sentinelgate-slo-alerts.yml"`)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(rules)
+}
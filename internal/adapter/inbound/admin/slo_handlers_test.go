@@ -0,0 +1,79 @@
+package admin
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+func sloTestRequest(t *testing.T, h *AdminAPIHandler, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = "127.0.0.1:1234"
+	rec := httptest.NewRecorder()
+	h.Routes().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandleGetSLO(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	slo := service.NewSLOService([]service.SLODefinition{
+		{Name: "gw", Target: service.SLOTargetGateway, Type: service.SLOTypeAvailability, Objective: 99.9},
+	}, 0, 0, logger)
+	slo.ObserveGatewayCall(time.Millisecond, false)
+	h := NewAdminAPIHandler(WithSLOService(slo), WithAPILogger(logger))
+
+	rec := sloTestRequest(t, h, "/admin/api/slo")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body=%s)", rec.Code, rec.Body.String())
+	}
+	var resp sloResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !resp.Enabled || resp.FastBurnThreshold != service.DefaultSLOFastBurnThreshold {
+		t.Errorf("response = %+v, want enabled with default thresholds", resp)
+	}
+	if len(resp.SLOs) != 1 || resp.SLOs[0].Name != "gw" || resp.SLOs[0].Windows[0].Total != 1 {
+		t.Errorf("SLOs = %+v, want gw with one request", resp.SLOs)
+	}
+
+	rec = sloTestRequest(t, h, "/admin/api/slo/alerts")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("alerts status = %d, want 200", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/yaml" {
+		t.Errorf("alerts Content-Type = %q, want application/yaml", ct)
+	}
+	if !strings.Contains(rec.Body.String(), "SentinelGateSLOFastBurn") {
+		t.Errorf("alerts body missing fast burn rule:\n%s", rec.Body.String())
+	}
+}
+
+func TestHandleGetSLO_NotConfigured(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	h := NewAdminAPIHandler(WithAPILogger(logger))
+
+	rec := sloTestRequest(t, h, "/admin/api/slo")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	var resp sloResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Enabled || resp.SLOs == nil || len(resp.SLOs) != 0 {
+		t.Errorf("response = %+v, want disabled with empty list", resp)
+	}
+
+	if rec := sloTestRequest(t, h, "/admin/api/slo/alerts"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("alerts status = %d, want 503", rec.Code)
+	}
+}
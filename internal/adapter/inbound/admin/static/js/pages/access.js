@@ -13,6 +13,9 @@
  *
  * Connect Your Agent: tabbed config snippets for 7 agents with Copy button.
  *
+ * Admin API tokens: scoped tokens for automating the admin API (CI
+ * pipelines), created with one-time cleartext display and revoked here.
+ *
  * Data sources:
  *   GET    /admin/api/keys              -> all API keys
  *   GET    /admin/api/identities        -> identity list for dropdown + name resolution
@@ -24,6 +27,9 @@
  *   GET    /admin/api/v1/quotas         -> all quota configs
  *   PUT    /admin/api/v1/quotas/{id}    -> create/update quota
  *   DELETE /admin/api/v1/quotas/{id}    -> remove quota
+ *   GET    /admin/api/admin-tokens      -> admin API tokens
+ *   POST   /admin/api/admin-tokens      -> create admin API token
+ *   DELETE /admin/api/admin-tokens/{id} -> revoke (or delete revoked) token
  *
  * Design features:
  *   - API Keys table with name, identity, created, status, actions
@@ -63,6 +69,7 @@
 
   var styleInjected = false;
   var keys = [];
+  var adminTokens = [];
   var identities = [];
   var identityMap = {};
   var identitiesCollapsed = false;
@@ -610,6 +617,25 @@
     }
   }
 
+  // Summarizes identity access restrictions (time windows and countries).
+  function describeAccess(access) {
+    var parts = [];
+    var windows = access.windows || [];
+    for (var i = 0; i < windows.length; i++) {
+      var w = windows[i];
+      var days = (w.days && w.days.length) ? w.days.join(',') : 'every day';
+      parts.push(days + ' ' + w.start + '-' + w.end);
+    }
+    if (parts.length) parts = ['Hours: ' + parts.join('; ') + ' ' + (access.timezone || 'UTC')];
+    if (access.allow_countries && access.allow_countries.length) {
+      parts.push('Allowed countries: ' + access.allow_countries.join(', '));
+    }
+    if (access.deny_countries && access.deny_countries.length) {
+      parts.push('Denied countries: ' + access.deny_countries.join(', '));
+    }
+    return parts.join('\n');
+  }
+
   function resolveIdentityName(identityId) {
     if (!identityId) return 'Unknown';
     return identityMap[identityId] || identityId.substring(0, 8) + '...';
@@ -1034,6 +1060,32 @@
     root.appendChild(configSection);
     root.appendChild(section); // API Keys after Connect Your Agent
 
+    // Admin API tokens section
+    var tokenSection = mk('div', 'access-section access-enter access-enter-4');
+    var tokenCard = mk('div', 'card');
+    var tokenCardHeader = mk('div', 'card-header');
+    var tokenCardTitle = mk('span', 'card-title');
+    tokenCardTitle.innerHTML = SG.icon('lock', 16) + ' ';
+    tokenCardTitle.appendChild(document.createTextNode('Admin API Tokens'));
+    tokenCardHeader.appendChild(tokenCardTitle);
+
+    var createTokenBtn = mk('button', 'btn btn-primary btn-sm', { 'aria-label': 'Create a new admin API token' });
+    createTokenBtn.innerHTML = SG.icon('plus', 14) + ' ';
+    createTokenBtn.appendChild(document.createTextNode('Create Token'));
+    createTokenBtn.addEventListener('click', function () {
+      openCreateAdminTokenModal();
+    });
+    tokenCardHeader.appendChild(createTokenBtn);
+    tokenCard.appendChild(tokenCardHeader);
+
+    var tokenCardBody = mk('div', 'card-body');
+    tokenCardBody.id = 'admin-tokens-table-container';
+    // Skeleton loading — 5 columns: Name, Scopes, Expires, Status, Actions
+    tokenCardBody.innerHTML = skeletonTableRows(2, 5);
+    tokenCard.appendChild(tokenCardBody);
+    tokenSection.appendChild(tokenCard);
+    root.appendChild(tokenSection);
+
     container.appendChild(root);
   }
 
@@ -1064,7 +1116,7 @@
     // Table head
     var thead = mk('thead', '');
     var headRow = mk('tr', '');
-    var cols = ['Name', 'Identity', 'Created', 'Status', 'Actions'];
+    var cols = ['Name', 'Identity', 'Origins', 'Created', 'Status', 'Actions'];
     for (var c = 0; c < cols.length; c++) {
       var th = mk('th', '');
       th.textContent = cols[c];
@@ -1092,6 +1144,24 @@
       tdIdentity.textContent = resolveIdentityName(key.identity_id);
       row.appendChild(tdIdentity);
 
+      // Origins (browser origin binding)
+      var tdOrigins = mk('td', '');
+      var origins = key.allowed_origins || [];
+      if (origins.length === 0) {
+        var anyLabel = mk('span', '', {
+          style: 'font-size: var(--text-xs); color: var(--text-muted);'
+        });
+        anyLabel.textContent = 'Any';
+        tdOrigins.appendChild(anyLabel);
+      } else {
+        for (var oi = 0; oi < origins.length; oi++) {
+          var originCode = mk('code', '', { style: 'display: block; font-size: var(--text-xs);' });
+          originCode.textContent = origins[oi];
+          tdOrigins.appendChild(originCode);
+        }
+      }
+      row.appendChild(tdOrigins);
+
       // Created
       var tdCreated = mk('td', '');
       tdCreated.textContent = formatDate(key.created_at);
@@ -1143,6 +1213,102 @@
     container.appendChild(table);
   }
 
+  // -- Render admin tokens table ----------------------------------------------
+
+  function renderAdminTokensTable() {
+    var container = document.getElementById('admin-tokens-table-container');
+    if (!container) return;
+    container.innerHTML = '';
+
+    var intro = mk('p', '', {
+      style: 'font-size: var(--text-sm); color: var(--text-secondary); margin: 0 0 var(--space-4) 0;'
+    });
+    intro.textContent = 'Tokens for automating the admin API, e.g. from CI. Send them as "Authorization: Bearer <token>". They only allow what their scopes grant and do not work as MCP API keys.';
+    container.appendChild(intro);
+
+    if (adminTokens.length === 0) {
+      var empty = mk('div', 'empty-state');
+      var emptyTitle = mk('p', 'empty-state-title');
+      emptyTitle.textContent = 'No admin API tokens';
+      empty.appendChild(emptyTitle);
+      container.appendChild(empty);
+      return;
+    }
+
+    var table = mk('table', 'table');
+    var thead = mk('thead', '');
+    var headRow = mk('tr', '');
+    var cols = ['Name', 'Scopes', 'Expires', 'Status', 'Actions'];
+    for (var c = 0; c < cols.length; c++) {
+      var th = mk('th', '');
+      th.textContent = cols[c];
+      if (cols[c] === 'Actions') { th.style.textAlign = 'right'; th.style.width = '1%'; th.style.whiteSpace = 'nowrap'; }
+      headRow.appendChild(th);
+    }
+    thead.appendChild(headRow);
+    table.appendChild(thead);
+
+    var tbody = mk('tbody', '');
+    var now = Date.now();
+    for (var i = 0; i < adminTokens.length; i++) {
+      var token = adminTokens[i];
+      var row = mk('tr', '');
+
+      var tdName = mk('td', '');
+      var nameSpan = mk('span', '', { style: 'font-weight: var(--font-medium);' });
+      nameSpan.textContent = token.name || '-';
+      tdName.appendChild(nameSpan);
+      var prefix = mk('code', '', { style: 'display: block; font-size: var(--text-xs); color: var(--text-muted);' });
+      prefix.textContent = (token.prefix || '') + '\u2026';
+      tdName.appendChild(prefix);
+      row.appendChild(tdName);
+
+      var tdScopes = mk('td', '');
+      var scopes = token.scopes || [];
+      for (var si = 0; si < scopes.length; si++) {
+        var scopeBadge = mk('span', 'badge badge-neutral', { style: 'margin-right: var(--space-1);' });
+        scopeBadge.textContent = scopes[si];
+        tdScopes.appendChild(scopeBadge);
+      }
+      row.appendChild(tdScopes);
+
+      var tdExpires = mk('td', '');
+      tdExpires.textContent = token.expires_at ? formatDate(token.expires_at) : 'Never';
+      row.appendChild(tdExpires);
+
+      var tdStatus = mk('td', '');
+      var expired = token.expires_at && new Date(token.expires_at).getTime() <= now;
+      var statusBadge;
+      if (token.revoked) {
+        statusBadge = mk('span', 'badge badge-danger');
+        statusBadge.textContent = 'Revoked';
+      } else if (expired) {
+        statusBadge = mk('span', 'badge badge-warning');
+        statusBadge.textContent = 'Expired';
+      } else {
+        statusBadge = mk('span', 'badge badge-success');
+        statusBadge.textContent = 'Active';
+      }
+      tdStatus.appendChild(statusBadge);
+      row.appendChild(tdStatus);
+
+      var tdActions = mk('td', '', { style: 'text-align: right; padding-right: 15px;' });
+      var actionBtn = mk('button', 'btn btn-danger btn-sm', { 'aria-label': (token.revoked ? 'Delete' : 'Revoke') + ' admin token ' + (token.name || '') });
+      actionBtn.textContent = token.revoked ? 'Delete' : 'Revoke';
+      (function (t) {
+        actionBtn.addEventListener('click', function () {
+          revokeAdminToken(t);
+        });
+      })(token);
+      tdActions.appendChild(actionBtn);
+      row.appendChild(tdActions);
+
+      tbody.appendChild(row);
+    }
+    table.appendChild(tbody);
+    container.appendChild(table);
+  }
+
   // -- Render identities table ------------------------------------------------
 
   function renderIdentitiesTable() {
@@ -1263,6 +1429,14 @@
           rolesContainer.appendChild(roleBadge);
         }
       }
+      if (identity.access) {
+        var accessBadge = mk('span', 'role-badge', {
+          style: 'border-style: dashed;',
+          title: describeAccess(identity.access)
+        });
+        accessBadge.textContent = 'restricted';
+        rolesContainer.appendChild(accessBadge);
+      }
       tdRoles.appendChild(rolesContainer);
       row.appendChild(tdRoles);
 
@@ -1788,6 +1962,11 @@
       // Always send tool_limits (even empty) so the backend replaces them atomically
       payload.tool_limits = toolLimits;
 
+      // Tool weights are set through the API; keep them when saving here
+      if (existing && existing.tool_weights) {
+        payload.tool_weights = existing.tool_weights;
+      }
+
       saveBtn.disabled = true;
       saveBtn.textContent = 'Saving...';
 
@@ -2145,6 +2324,143 @@
     }).catch(function (err) {
       SG.toast.show('Failed to load data: ' + (err.message || 'Unknown error'), 'error');
     });
+    loadAdminTokens();
+  }
+
+  function loadAdminTokens() {
+    SG.api.get('/admin-tokens').then(function (data) {
+      adminTokens = data || [];
+      renderAdminTokensTable();
+    }).catch(function () {
+      adminTokens = [];
+      renderAdminTokensTable();
+    });
+  }
+
+  // -- Create admin token modal -----------------------------------------------
+
+  var ADMIN_TOKEN_SCOPES = [
+    { value: 'read-only', label: 'read-only', help: 'Read everything except audit data' },
+    { value: 'policies:write', label: 'policies:write', help: 'Read and change policies and policy variables' },
+    { value: 'upstreams:write', label: 'upstreams:write', help: 'Read and change MCP servers' },
+    { value: 'audit:read', label: 'audit:read', help: 'Read the audit log and session recordings' }
+  ];
+
+  function openCreateAdminTokenModal() {
+    var form = mk('form', '');
+    form.addEventListener('submit', function (e) { e.preventDefault(); });
+
+    var nameGroup = mk('div', 'form-group');
+    var nameLabel = mk('label', 'form-label');
+    nameLabel.textContent = 'Name';
+    nameGroup.appendChild(nameLabel);
+    var nameInput = mk('input', 'form-input', { type: 'text', placeholder: 'e.g. ci-policy-deploy', required: 'required' });
+    nameGroup.appendChild(nameInput);
+    form.appendChild(nameGroup);
+
+    var scopeGroup = mk('div', 'form-group');
+    var scopeLabel = mk('label', 'form-label');
+    scopeLabel.textContent = 'Scopes';
+    scopeGroup.appendChild(scopeLabel);
+    var scopeInputs = [];
+    for (var i = 0; i < ADMIN_TOKEN_SCOPES.length; i++) {
+      var scope = ADMIN_TOKEN_SCOPES[i];
+      var scopeRow = mk('label', '', { style: 'display: flex; align-items: center; gap: var(--space-2); margin-bottom: var(--space-1); font-size: var(--text-sm);' });
+      var checkbox = mk('input', '', { type: 'checkbox', value: scope.value });
+      scopeInputs.push(checkbox);
+      scopeRow.appendChild(checkbox);
+      var scopeCode = mk('code', '');
+      scopeCode.textContent = scope.label;
+      scopeRow.appendChild(scopeCode);
+      var scopeHelp = mk('span', '', { style: 'color: var(--text-muted);' });
+      scopeHelp.textContent = scope.help;
+      scopeRow.appendChild(scopeHelp);
+      scopeGroup.appendChild(scopeRow);
+    }
+    form.appendChild(scopeGroup);
+
+    var expiryGroup = mk('div', 'form-group');
+    var expiryLabel = mk('label', 'form-label');
+    expiryLabel.textContent = 'Expires';
+    expiryGroup.appendChild(expiryLabel);
+    var expirySelect = mk('select', 'form-select');
+    [['30', 'In 30 days'], ['90', 'In 90 days'], ['365', 'In 1 year'], ['', 'Never']].forEach(function (o) {
+      var opt = mk('option', '', { value: o[0] });
+      opt.textContent = o[1];
+      expirySelect.appendChild(opt);
+    });
+    expirySelect.value = '90';
+    expiryGroup.appendChild(expirySelect);
+    form.appendChild(expiryGroup);
+
+    var footer = mk('div', '', { style: 'display: contents;' });
+    var cancelBtn = mk('button', 'btn btn-secondary');
+    cancelBtn.textContent = 'Cancel';
+    cancelBtn.type = 'button';
+    cancelBtn.addEventListener('click', function () {
+      SG.modal.close();
+    });
+    footer.appendChild(cancelBtn);
+    var submitBtn = mk('button', 'btn btn-primary');
+    submitBtn.textContent = 'Create Token';
+    submitBtn.type = 'submit';
+    footer.appendChild(submitBtn);
+
+    var modalBody = SG.modal.open({
+      title: 'Create Admin API Token',
+      body: form,
+      footer: footer,
+      width: '520px'
+    });
+
+    submitBtn.addEventListener('click', function () {
+      var name = nameInput.value.trim();
+      if (!name) {
+        nameInput.focus();
+        return;
+      }
+      var scopes = scopeInputs.filter(function (cb) { return cb.checked; }).map(function (cb) { return cb.value; });
+      if (scopes.length === 0) {
+        SG.toast.show('Select at least one scope', 'warning');
+        return;
+      }
+      var payload = { name: name, scopes: scopes };
+      if (expirySelect.value) {
+        payload.expires_at = new Date(Date.now() + parseInt(expirySelect.value, 10) * 86400000).toISOString();
+      }
+
+      submitBtn.disabled = true;
+      submitBtn.textContent = 'Creating...';
+      SG.api.post('/admin-tokens', payload).then(function (result) {
+        showKeyResult(modalBody, { cleartext_key: result.token, name: result.name, id: result.id });
+      }).catch(function (err) {
+        submitBtn.disabled = false;
+        submitBtn.textContent = 'Create Token';
+        SG.toast.show(err.message || 'Failed to create token', 'error');
+      });
+    });
+
+    setTimeout(function () { nameInput.focus(); }, 100);
+  }
+
+  function revokeAdminToken(token) {
+    var deleting = token.revoked;
+    SG.modal.confirm({
+      title: (deleting ? 'Delete' : 'Revoke') + ' admin token "' + token.name + '"?',
+      message: deleting
+        ? 'The revoked token is removed from the list.'
+        : 'This action cannot be undone. Automation using this token will lose access.',
+      confirmText: deleting ? 'Delete' : 'Revoke',
+      confirmClass: 'btn-danger',
+      onConfirm: function () {
+        SG.api.del('/admin-tokens/' + encodeURIComponent(token.id)).then(function () {
+          SG.toast.show(deleting ? 'Token deleted' : 'Token revoked', 'success');
+          loadAdminTokens();
+        }).catch(function (err) {
+          SG.toast.show(err.message || 'Failed to revoke token', 'error');
+        });
+      }
+    });
   }
 
   // -- Create Key modal -------------------------------------------------------
@@ -2192,6 +2508,21 @@
     identityGroup.appendChild(identityHelp);
     form.appendChild(identityGroup);
 
+    // Allowed origins field (optional)
+    var originsGroup = mk('div', 'form-group');
+    var originsLabel = mk('label', 'form-label');
+    originsLabel.textContent = 'Allowed origins (optional)';
+    originsGroup.appendChild(originsLabel);
+    var originsInput = mk('input', 'form-input', {
+      type: 'text',
+      placeholder: 'e.g. https://app.example.com'
+    });
+    originsGroup.appendChild(originsInput);
+    var originsHelp = mk('span', 'form-help');
+    originsHelp.textContent = 'Comma-separated browser origins allowed to use this key. Leave empty for no binding.';
+    originsGroup.appendChild(originsHelp);
+    form.appendChild(originsGroup);
+
     // Footer with buttons
     var footer = mk('div', '', { style: 'display: contents;' });
     var cancelBtn = mk('button', 'btn btn-secondary');
@@ -2231,9 +2562,14 @@
       submitBtn.disabled = true;
       submitBtn.textContent = 'Creating...';
 
+      var allowedOrigins = originsInput.value.split(',').map(function (o) {
+        return o.trim();
+      }).filter(function (o) { return o !== ''; });
+
       SG.api.post('/keys', {
         name: name,
-        identity_id: identityId
+        identity_id: identityId,
+        allowed_origins: allowedOrigins
       }).then(function (result) {
 
         showKeyResult(modalBody, result);
@@ -2405,6 +2741,7 @@
 
   function cleanup() {
     keys = [];
+    adminTokens = [];
     identities = [];
     identityMap = {};
     quotaMap = {};
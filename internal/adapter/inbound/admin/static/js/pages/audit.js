@@ -1091,6 +1091,11 @@
     count.textContent = '0 entries';
     root.appendChild(count);
 
+    // ── Audit file storage footer (hidden unless audit_file.dir is set) ──
+    var storage = mk('div', 'audit-count', { style: 'display:none;' });
+    storage.id = 'audit-storage';
+    root.appendChild(storage);
+
     // (Distribution widgets moved above filters)
 
     container.appendChild(root);
@@ -1552,6 +1557,34 @@
     });
   }
 
+  // ── Audit file storage ─────────────────────────────────────────────
+
+  function formatBytes(n) {
+    if (n < 1024) return n + ' B';
+    var units = ['KB', 'MB', 'GB', 'TB'];
+    var i = -1;
+    do { n /= 1024; i++; } while (n >= 1024 && i < units.length - 1);
+    return n.toFixed(1) + ' ' + units[i];
+  }
+
+  function loadAuditStorage() {
+    SG.api.get('/audit/storage').then(function (data) {
+      var el = document.getElementById('audit-storage');
+      if (!el || !data || !data.enabled) return;
+      var text = 'Audit files: ' + data.files + ' (' + formatBytes(data.disk_bytes) + ' on disk';
+      if (data.compressed_files > 0) {
+        text += ', ' + data.compressed_files + ' compressed, ' + formatBytes(data.saved_bytes) +
+          ' saved (' + data.savings_percent.toFixed(0) + '%)';
+      }
+      text += ')';
+      if (data.max_total_bytes) text += ' \u00B7 cap ' + formatBytes(data.max_total_bytes);
+      el.textContent = text;
+      el.style.display = '';
+    }).catch(function () {
+      // Non-fatal
+    });
+  }
+
   // ── Lifecycle ──────────────────────────────────────────────────────
 
   function render(container) {
@@ -1561,6 +1594,7 @@
     showAuditSkeleton();
     startSSE();
     loadAuditStats();
+    loadAuditStorage();
   }
 
   function cleanup() {
@@ -821,6 +821,23 @@
     sessCard.appendChild(sessWrap);
     root.appendChild(sessCard);
 
+    // Deny loops card — identities retrying the same denied call
+    var loopCard = mk('div', 'card dash-enter dash-enter-8', { style: 'margin-top: var(--space-6)' });
+    var loopHeader = mk('div', 'card-header');
+    var loopTitle = mk('span', 'card-title');
+    loopTitle.innerHTML = SG.icon('refreshCw', 16) + ' ';
+    loopTitle.appendChild(document.createTextNode('Deny Loops'));
+    loopHeader.appendChild(loopTitle);
+    var loopBadge = mk('span', 'badge badge-neutral');
+    loopBadge.id = 'deny-loop-count';
+    loopBadge.textContent = '0';
+    loopHeader.appendChild(loopBadge);
+    loopCard.appendChild(loopHeader);
+    var loopBody = mk('div', 'card-body');
+    loopBody.id = 'deny-loops-container';
+    loopCard.appendChild(loopBody);
+    root.appendChild(loopCard);
+
     container.appendChild(root);
   }
 
@@ -1168,6 +1185,70 @@
     });
   }
 
+  // -- Data: Deny loops --------------------------------------------------------
+
+  function loadDenyLoops(opts) {
+    SG.api.get('/v1/deny-loops', opts).then(function (data) {
+      renderDenyLoops(data || {});
+    }).catch(function () {
+      // Non-fatal -- deny loops widget retains last state
+    });
+  }
+
+  function renderDenyLoops(data) {
+    var container = document.getElementById('deny-loops-container');
+    if (!container) return;
+    var loops = data.loops || [];
+
+    var countBadge = document.getElementById('deny-loop-count');
+    if (countBadge) {
+      countBadge.textContent = String(loops.length);
+      countBadge.className = 'badge ' + (loops.length > 0 ? 'badge-warning' : 'badge-neutral');
+    }
+
+    container.innerHTML = '';
+    if (loops.length === 0) {
+      var empty = mk('div', 'dist-empty-state');
+      empty.textContent = data.enabled === false ? 'Deny loop detection is disabled' : 'No identity is retrying a denied call';
+      container.appendChild(empty);
+      return;
+    }
+
+    for (var i = 0; i < loops.length; i++) {
+      container.appendChild(buildDenyLoopRow(loops[i]));
+    }
+  }
+
+  function buildDenyLoopRow(loop) {
+    var row = mk('div', 'upstream-item');
+
+    var info = mk('div', '');
+    var name = mk('strong', '');
+    name.textContent = (loop.identity_name || loop.identity_id) + ' \u2192 ' + loop.tool;
+    info.appendChild(name);
+    var detail = mk('div', 'session-meta');
+    var text = loop.denials + ' denials';
+    if (loop.rule) text += ' by ' + loop.rule;
+    var remaining = Math.ceil((new Date(loop.blocked_until).getTime() - Date.now()) / 1000);
+    text += remaining > 0 ? ' \u2014 backed off for ' + remaining + 's' : ' \u2014 ' + formatRelativeTime(loop.last_denied_at);
+    detail.textContent = text;
+    info.appendChild(detail);
+    row.appendChild(info);
+
+    var clearBtn = mk('button', 'btn btn-sm btn-secondary', { type: 'button' });
+    clearBtn.textContent = 'Clear';
+    clearBtn.title = 'Lift the backoff of this call';
+    clearBtn.addEventListener('click', function () {
+      SG.api.del('/v1/deny-loops/' + encodeURIComponent(loop.id)).then(function () {
+        loadDenyLoops();
+      }).catch(function (err) {
+        SG.toast.error('Clear failed: ' + (err.message || 'Unknown error'));
+      });
+    });
+    row.appendChild(clearBtn);
+    return row;
+  }
+
   function loadQuotaConfigs() {
     SG.api.get('/v1/quotas').then(function (quotas) {
       cachedQuotas = {};
@@ -2019,6 +2100,7 @@
     loadQuotaConfigs();
     loadSupplementaryData();
     loadActiveSessions(); // BUG-4 FIX: initial load for active sessions widget
+    loadDenyLoops();
     startSSE();
 
     // UX-13: check scroll overflow after initial data loads
@@ -2036,6 +2118,7 @@
     // BUG-4 FIX: poll active sessions every 2s (matches stats frequency)
     sessionsInterval = setInterval(function () {
       loadActiveSessions(bg);
+      loadDenyLoops(bg);
     }, 2000);
     // Refresh supplementary data every 30s (not on every 2s stats poll)
     supplementaryInterval = setInterval(function () {
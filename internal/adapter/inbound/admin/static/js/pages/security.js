@@ -2,7 +2,7 @@
  * security.js -- Security page for SentinelGate admin UI.
  *
  * Content Scanning configuration: toggle enable/disable, switch between
- * monitor and enforce modes. Changes take effect immediately and persist
+ * monitor, enforce and redact modes. Changes take effect immediately and persist
  * to state.json.
  *
  * Data sources:
@@ -207,6 +207,10 @@
     '  background: rgba(251, 146, 60, 0.1);',
     '  color: var(--warning);',
     '}',
+    '.security-status-redact {',
+    '  background: rgba(59, 130, 246, 0.1);',
+    '  color: var(--info);',
+    '}',
     '.security-status-disabled {',
     '  background: var(--bg-elevated);',
     '  color: var(--text-muted);',
@@ -416,6 +420,9 @@
     if (config.mode === 'enforce') {
       return '<span class="security-status security-status-enforce">Enforce</span>';
     }
+    if (config.mode === 'redact') {
+      return '<span class="security-status security-status-redact">Redact</span>';
+    }
     return '<span class="security-status security-status-monitor">Monitor</span>';
   }
 
@@ -590,6 +597,14 @@
                 '<span class="mode-option-desc">Block responses containing detected prompt injection patterns. Use after validating detections in monitor mode.</span>' +
               '</div>' +
             '</label>' +
+
+            '<label class="mode-option' + (mode === 'redact' ? ' selected' : '') + '" id="mode-redact">' +
+              '<input type="radio" name="scan-mode" value="redact" ' + (mode === 'redact' ? 'checked' : '') + '>' +
+              '<div class="mode-option-text">' +
+                '<span class="mode-option-label">Redact</span>' +
+                '<span class="mode-option-desc">Replace detected prompt injection snippets, PII and secrets in responses with placeholders and forward the rest.</span>' +
+              '</div>' +
+            '</label>' +
           '</div>' +
 
           /* Save button */
@@ -636,22 +651,21 @@
     var enabledCheckbox = document.getElementById('scan-enabled');
     var monitorRadio = document.querySelector('input[name="scan-mode"][value="monitor"]');
     var enforceRadio = document.querySelector('input[name="scan-mode"][value="enforce"]');
+    var redactRadio = document.querySelector('input[name="scan-mode"][value="redact"]');
     var saveBtn = document.getElementById('save-scan-config');
     var monitorOption = document.getElementById('mode-monitor');
     var enforceOption = document.getElementById('mode-enforce');
+    var redactOption = document.getElementById('mode-redact');
 
     function updateModeSelection() {
-      if (monitorRadio && monitorRadio.checked) {
-        monitorOption.classList.add('selected');
-        enforceOption.classList.remove('selected');
-      } else {
-        monitorOption.classList.remove('selected');
-        enforceOption.classList.add('selected');
-      }
+      monitorOption.classList.toggle('selected', !!(monitorRadio && monitorRadio.checked));
+      enforceOption.classList.toggle('selected', !!(enforceRadio && enforceRadio.checked));
+      redactOption.classList.toggle('selected', !!(redactRadio && redactRadio.checked));
     }
 
     if (monitorRadio) monitorRadio.addEventListener('change', updateModeSelection);
     if (enforceRadio) enforceRadio.addEventListener('change', updateModeSelection);
+    if (redactRadio) redactRadio.addEventListener('change', updateModeSelection);
 
     if (saveBtn) {
       saveBtn.addEventListener('click', function () {
@@ -659,6 +673,8 @@
         var newMode = 'monitor';
         if (enforceRadio && enforceRadio.checked) {
           newMode = 'enforce';
+        } else if (redactRadio && redactRadio.checked) {
+          newMode = 'redact';
         }
 
         saveBtn.disabled = true;
@@ -31,7 +31,7 @@
  *   TOOL-04  Filter tabs (All + per upstream)
  *   TOOL-05  Query string pre-filter (?upstream=)
  *   TOOL-06  Empty state for no tools
- *   TRUL-07  Add Upstream modal (stdio/HTTP, validation, API)
+ *   TRUL-07  Add Upstream modal (stdio/HTTP/OpenAPI, validation, API)
  *   TRUL-08  Policy Rules section with rule list
  *   TRUL-03  Click tool opens rule modal
  *   TRUL-09  Rule CRUD (add/edit/delete with confirmation)
@@ -1775,6 +1775,18 @@
     optHttp.value = 'http';
     optHttp.textContent = 'http';
     typeSelect.appendChild(optHttp);
+    var optSSE = mk('option');
+    optSSE.value = 'sse';
+    optSSE.textContent = 'sse (legacy HTTP+SSE)';
+    typeSelect.appendChild(optSSE);
+    var optOpenAPI = mk('option');
+    optOpenAPI.value = 'openapi';
+    optOpenAPI.textContent = 'openapi (REST API)';
+    typeSelect.appendChild(optOpenAPI);
+    var optShim = mk('option');
+    optShim.value = 'shim';
+    optShim.textContent = 'shim (HTTP tool mapping)';
+    typeSelect.appendChild(optShim);
     typeGroup.appendChild(typeSelect);
     form.appendChild(typeGroup);
 
@@ -1809,9 +1821,27 @@
     argsGroup.appendChild(argsHelp);
     form.appendChild(argsGroup);
 
-    // 5. URL field (http) - initially hidden
+    // 5. OpenAPI document (openapi) or tool mapping (shim) - initially hidden
+    var specGroup = mk('div', 'form-group');
+    specGroup.setAttribute('data-field', 'openapi shim');
+    specGroup.style.display = 'none';
+    var specLabel = mk('label', 'form-label');
+    specLabel.textContent = 'OpenAPI Document';
+    specLabel.setAttribute('for', 'upstream-spec');
+    specGroup.appendChild(specLabel);
+    var specInput = mk('input', 'form-input', {
+      type: 'text', id: 'upstream-spec', name: 'spec',
+      placeholder: 'e.g. https://api.example.com/openapi.json or /etc/sentinelgate/api.yaml'
+    });
+    specGroup.appendChild(specInput);
+    var specHelp = mk('div', 'form-help');
+    specHelp.textContent = 'Each operation in the document becomes a tool';
+    specGroup.appendChild(specHelp);
+    form.appendChild(specGroup);
+
+    // 6. URL field (http, sse, openapi, shim) - initially hidden
     var urlGroup = mk('div', 'form-group');
-    urlGroup.setAttribute('data-field', 'http');
+    urlGroup.setAttribute('data-field', 'http sse openapi shim');
     urlGroup.style.display = 'none';
     var urlLabel = mk('label', 'form-label');
     urlLabel.textContent = 'URL';
@@ -1824,7 +1854,25 @@
     urlGroup.appendChild(urlInput);
     form.appendChild(urlGroup);
 
-    // 6. Environment Variables (stdio)
+    // 7. Request headers (openapi, shim) - initially hidden
+    var headersGroup = mk('div', 'form-group');
+    headersGroup.setAttribute('data-field', 'openapi shim');
+    headersGroup.style.display = 'none';
+    var headersLabel = mk('label', 'form-label');
+    headersLabel.textContent = 'Request Headers';
+    headersLabel.setAttribute('for', 'upstream-headers');
+    headersGroup.appendChild(headersLabel);
+    var headersTextarea = mk('textarea', 'form-textarea', {
+      id: 'upstream-headers', name: 'headers',
+      placeholder: 'Authorization=Bearer ${env:API_TOKEN} (one per line)'
+    });
+    headersGroup.appendChild(headersTextarea);
+    var headersHelp = mk('div', 'form-help');
+    headersHelp.textContent = 'Headers sent with every API request, one NAME=VALUE per line';
+    headersGroup.appendChild(headersHelp);
+    form.appendChild(headersGroup);
+
+    // 8. Environment Variables (stdio)
     var envGroup = mk('div', 'form-group');
     envGroup.setAttribute('data-field', 'stdio');
     var envLabel = mk('label', 'form-label');
@@ -1841,6 +1889,35 @@
     envGroup.appendChild(envHelp);
     form.appendChild(envGroup);
 
+    // Shows the fields of the selected type. data-field lists the types a
+    // field belongs to, separated by spaces.
+    function showFieldsFor(selected) {
+      var fields = form.querySelectorAll('[data-field]');
+      for (var i = 0; i < fields.length; i++) {
+        var types = fields[i].getAttribute('data-field').split(' ');
+        fields[i].style.display = types.indexOf(selected) >= 0 ? 'block' : 'none';
+      }
+      var bridge = selected === 'openapi' || selected === 'shim';
+      urlLabel.textContent = bridge ? 'Base URL (optional)' : 'URL';
+      specLabel.textContent = selected === 'shim' ? 'Tool Mapping' : 'OpenAPI Document';
+      specInput.placeholder = selected === 'shim'
+        ? 'e.g. /etc/sentinelgate/tools.yaml'
+        : 'e.g. https://api.example.com/openapi.json or /etc/sentinelgate/api.yaml';
+      specHelp.textContent = selected === 'shim'
+        ? 'Mapping file listing each tool\'s HTTP endpoint or OpenAPI operation'
+        : 'Each operation in the document becomes a tool';
+      urlInput.placeholder = selected === 'openapi'
+        ? 'Overrides the servers listed in the document'
+        : selected === 'shim' ? 'Overrides the base_url of the mapping'
+        : selected === 'sse' ? 'e.g. http://localhost:3001/sse' : 'e.g. http://localhost:3001/mcp';
+    }
+
+    // Formats a map as NAME=VALUE lines.
+    function formatPairs(obj) {
+      if (!obj || typeof obj !== 'object') return '';
+      return Object.keys(obj).map(function (k) { return k + '=' + obj[k]; }).join('\n');
+    }
+
     // -- Pre-fill for edit mode ---------------------------------------------
     if (isEdit) {
       nameInput.value = existing.name || '';
@@ -1848,41 +1925,28 @@
       // that would cause the wrong fields to be sent in the payload.
       typeSelect.disabled = true;
       typeSelect.style.opacity = '0.6';
-      if (existing.type === 'http') {
-        typeSelect.value = 'http';
+      if (existing.type === 'http' || existing.type === 'sse') {
+        typeSelect.value = existing.type;
+        urlInput.value = existing.url || '';
+        showFieldsFor(existing.type);
+      } else if (existing.type === 'openapi' || existing.type === 'shim') {
+        typeSelect.value = existing.type;
+        specInput.value = existing.spec || '';
         urlInput.value = existing.url || '';
-        // Show HTTP fields, hide stdio fields
-        var stdioInit = form.querySelectorAll('[data-field="stdio"]');
-        var httpInit = form.querySelectorAll('[data-field="http"]');
-        for (var si = 0; si < stdioInit.length; si++) stdioInit[si].style.display = 'none';
-        for (var hi = 0; hi < httpInit.length; hi++) httpInit[hi].style.display = 'block';
+        headersTextarea.value = formatPairs(existing.headers);
+        showFieldsFor(existing.type);
       } else {
         typeSelect.value = 'stdio';
         cmdInput.value = existing.command || '';
         argsInput.value = (existing.args && existing.args.length) ? existing.args.join(' ') : '';
         // Format env as KEY=VALUE lines
-        if (existing.env && typeof existing.env === 'object') {
-          var envLines = [];
-          var envKeys = Object.keys(existing.env);
-          for (var ei = 0; ei < envKeys.length; ei++) {
-            envLines.push(envKeys[ei] + '=' + existing.env[envKeys[ei]]);
-          }
-          envTextarea.value = envLines.join('\n');
-        }
+        envTextarea.value = formatPairs(existing.env);
       }
     }
 
     // -- Type toggle logic --------------------------------------------------
     typeSelect.addEventListener('change', function () {
-      var selected = typeSelect.value;
-      var stdioFields = form.querySelectorAll('[data-field="stdio"]');
-      var httpFields = form.querySelectorAll('[data-field="http"]');
-      for (var i = 0; i < stdioFields.length; i++) {
-        stdioFields[i].style.display = (selected === 'stdio') ? 'block' : 'none';
-      }
-      for (var j = 0; j < httpFields.length; j++) {
-        httpFields[j].style.display = (selected === 'http') ? 'block' : 'none';
-      }
+      showFieldsFor(typeSelect.value);
       // Clear any validation errors when switching type
       clearFormErrors(form);
     });
@@ -1940,12 +2004,20 @@
           valid = false;
           if (!firstInvalid) firstInvalid = cmdInput;
         }
-      } else if (selectedType === 'http') {
+      } else if (selectedType === 'http' || selectedType === 'sse') {
         if (!urlInput.value.trim()) {
-          setFieldError(urlGroup, 'URL is required for HTTP type');
+          setFieldError(urlGroup, 'URL is required for ' + selectedType + ' type');
           valid = false;
           if (!firstInvalid) firstInvalid = urlInput;
         }
+      } else if (selectedType === 'openapi' || selectedType === 'shim') {
+        if (!specInput.value.trim()) {
+          setFieldError(specGroup, selectedType === 'shim'
+            ? 'Tool mapping is required for shim type'
+            : 'OpenAPI document is required for openapi type');
+          valid = false;
+          if (!firstInvalid) firstInvalid = specInput;
+        }
       }
 
       if (firstInvalid) {
@@ -1979,6 +2051,14 @@
           // "preserve existing" and {} as "clear all".
           payload.env = envObj;
         }
+      } else if (selectedType === 'openapi' || selectedType === 'shim') {
+        payload.spec = specInput.value.trim();
+        payload.url = urlInput.value.trim();
+        var headersObj = parseEnvVars(headersTextarea.value);
+        if (isEdit || Object.keys(headersObj).length > 0) {
+          // As with env, an empty object clears the headers on edit.
+          payload.headers = headersObj;
+        }
       } else {
         payload.url = urlInput.value.trim();
       }
@@ -1987,13 +2067,30 @@
       saveBtn.disabled = true;
       saveBtn.textContent = isEdit ? 'Updating\u2026' : 'Saving\u2026';
 
+      submitUpstream(payload).catch(function (err) {
+        var findings = err.body && err.body.secret_findings;
+        if (err.status === 400 && findings && findings.length) {
+          // Blocked: plaintext secrets. Offer to convert them to references.
+          offerSecretConversion(payload, findings);
+          return;
+        }
+        SG.toast.error(err.message || ('Failed to ' + (isEdit ? 'update' : 'create') + ' upstream'));
+      }).finally(function () {
+        saveBtn.disabled = false;
+        saveBtn.textContent = isEdit ? 'Update' : 'Save';
+      });
+    });
+
+    // Saves the upstream and refreshes the page. Rejects on API errors.
+    function submitUpstream(payload) {
       var apiCall = isEdit
         ? SG.api.put('/upstreams/' + existing.id, payload)
         : SG.api.post('/upstreams', payload);
 
-      apiCall.then(function () {
+      return apiCall.then(function (saved) {
         SG.modal.close();
         SG.toast.success('Upstream "' + payload.name + '" ' + (isEdit ? 'updated' : 'created'));
+        reportSecretFindings(saved && saved.secret_findings);
         if (typeof SG.refreshSidebarUpstreams === 'function') SG.refreshSidebarUpstreams();
         // Refresh the tool list or current page
         var contentContainer = document.querySelector('.tools-content');
@@ -2006,13 +2103,50 @@
           // Modal was opened from another page (e.g., Connections) — reload to refresh
           SG.router.reload();
         }
-      }).catch(function (err) {
-        SG.toast.error(err.message || ('Failed to ' + (isEdit ? 'update' : 'create') + ' upstream'));
-      }).finally(function () {
-        saveBtn.disabled = false;
-        saveBtn.textContent = isEdit ? 'Update' : 'Save';
       });
-    });
+    }
+
+    // Describes where a secret was found, e.g. "env GITHUB_TOKEN" or "args[3]".
+    function describeFinding(f) {
+      return f.field === 'args' ? 'args[' + f.key + ']' : f.field + ' ' + f.key;
+    }
+
+    // Warns about plaintext secrets that were saved, and lists the
+    // environment variables to set for converted ones.
+    function reportSecretFindings(findings) {
+      if (!findings || !findings.length) return;
+      var converted = findings.filter(function (f) { return f.converted; });
+      var plaintext = findings.filter(function (f) { return !f.converted; });
+      if (converted.length) {
+        SG.toast.info('Set these environment variables on the gateway before the upstream starts: ' +
+          converted.map(function (f) { return f.env_var; }).join(', '));
+      }
+      if (plaintext.length) {
+        SG.toast.warning('Possible plaintext secrets saved in ' +
+          plaintext.map(describeFinding).join(', ') +
+          '. Use ${env:NAME} references to keep them out of state.json.');
+      }
+    }
+
+    // Asks whether to replace blocked plaintext secrets with ${env:NAME}
+    // references and save again.
+    function offerSecretConversion(payload, findings) {
+      var lines = findings.map(function (f) {
+        return describeFinding(f) + ' (' + f.kind + ')' + (f.reference ? ' \u2192 ' + f.reference : ' \u2014 move it manually');
+      });
+      SG.modal.confirm({
+        title: 'Plaintext secrets detected',
+        message: 'This upstream contains values that look like credentials: ' + lines.join('; ') +
+          '. Convert them to environment variable references? You will need to set the variables on the gateway.',
+        confirmText: 'Convert and save',
+        onConfirm: function () {
+          payload.convert_secrets = true;
+          submitUpstream(payload).catch(function (err) {
+            SG.toast.error(err.message || 'Failed to save upstream');
+          });
+        }
+      });
+    }
 
     // Prevent form submit on Enter causing page reload
     form.addEventListener('submit', function (e) {
@@ -3021,7 +3155,9 @@
       { name: 'dest_url', type: 'string', label: 'URL', example: 'https://api.example.com' },
       { name: 'dest_path', type: 'string', label: 'Path', example: '/data/secrets' },
       { name: 'dest_scheme', type: 'string', label: 'Scheme', example: 'https', suggestions: ['http', 'https', 'ws', 'wss'] },
-      { name: 'dest_command', type: 'string', label: 'Command', example: 'rm' }
+      { name: 'dest_command', type: 'string', label: 'Command', example: 'rm' },
+      { name: 'dest_urls', type: 'list', label: 'All URLs', example: 'https://api.example.com' },
+      { name: 'dest_domains', type: 'list', label: 'All Domains', example: 'api.example.com' }
     ]},
     { category: 'Arguments', variables: [
       { name: 'arguments', type: 'map', label: 'Tool Arguments', example: '{"path": "/data"}' }
@@ -3978,6 +4114,12 @@
       ['dest_domain_matches(domain, "*.evil.com")', 'domain wildcard match'],
       ['action_arg(arguments, "key")', 'get argument by key'],
       ['action_arg_contains(arguments, "pat")', 'search all argument values'],
+      ['arg("a.b")', 'argument at a dotted path (null if missing)'],
+      ['matchesGlob(s, "/home/**/*.pem")', 'path glob, ** crosses /'],
+      ['cidrContains(ip, "10.0.0.0/8")', 'any IP in CIDR range'],
+      ['isWeekend()', 'request on Saturday or Sunday'],
+      ['argsSize()', 'arguments size in JSON bytes'],
+      ['argsDepth()', 'arguments nesting depth'],
       ['session_count(history, "read")', 'count by call type'],
       ['session_count_for(history, "tool")', 'count by tool name'],
       ['session_count_window(history, "tool", 60)', 'count in last N seconds'],
@@ -46,7 +46,7 @@ type AuditSubHandler struct {
 	recordingService  *recording.FileRecorder
 	recordingObserver *recording.RecordingObserver
 	retentionCleaner  *recording.RetentionCleaner
-	stateStore        *state.FileStateStore
+	stateStore        state.Store
 }
 
 // UpstreamSubHandler handles upstream management and tool discovery.
@@ -59,7 +59,7 @@ type UpstreamSubHandler struct {
 	toolCache          *upstream.ToolCache
 	toolChangeNotifier service.ToolChangeNotifier
 	policyService      *service.PolicyService
-	stateStore         *state.FileStateStore
+	stateStore         state.Store
 }
 
 // AccessSubHandler handles identities, API keys, approvals, quotas, and sessions.
@@ -70,7 +70,7 @@ type AccessSubHandler struct {
 	approvalStore   *action.ApprovalStore
 	quotaStore      quota.QuotaStore
 	sessionTracker  *session.SessionTracker
-	stateStore      *state.FileStateStore
+	stateStore      state.Store
 }
 
 // SecuritySubHandler handles content scanning and tool security.
@@ -80,7 +80,7 @@ type SecuritySubHandler struct {
 	additionalScanCtrls  []ResponseScanController
 	toolSecurityService  *service.ToolSecurityService
 	toolCache            *upstream.ToolCache
-	stateStore           *state.FileStateStore
+	stateStore           state.Store
 }
 
 // TransformSubHandler handles response transformation rules.
@@ -89,7 +89,7 @@ type TransformSubHandler struct {
 	baseHandler
 	transformStore    transform.TransformStore
 	transformExecutor *transform.TransformExecutor
-	stateStore        *state.FileStateStore
+	stateStore        state.Store
 }
 
 // --- Factory methods on AdminAPIHandler ---
@@ -1,8 +1,12 @@
 package admin
 
 import (
+	"context"
 	"net/http"
 	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/opa"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
 )
 
 // BuildInfo holds build-time version information.
@@ -24,6 +28,71 @@ type SystemInfoResponse struct {
 	BuildDate string `json:"build_date"`
 	Uptime    string `json:"uptime"`
 	UptimeSec int64  `json:"uptime_seconds"`
+	// TLS describes the served certificate; omitted when serving plain HTTP.
+	TLS *TLSCertificateInfo `json:"tls,omitempty"`
+	// Admission is the admission control state; omitted when disabled.
+	Admission *service.AdmissionStatus `json:"admission,omitempty"`
+	// PolicyDirectory describes the policy directory; omitted when none is
+	// configured.
+	PolicyDirectory *service.PolicyDirectoryStatus `json:"policy_directory,omitempty"`
+	// Rego describes the Rego policy engine; omitted when policies are
+	// evaluated with CEL.
+	Rego *opa.Status `json:"rego,omitempty"`
+}
+
+// TLSCertificateInfo describes the certificate served by the HTTP server.
+type TLSCertificateInfo struct {
+	CertFile          string     `json:"cert_file"`
+	Subject           string     `json:"subject"`
+	Issuer            string     `json:"issuer"`
+	DNSNames          []string   `json:"dns_names,omitempty"`
+	NotBefore         time.Time  `json:"not_before"`
+	NotAfter          time.Time  `json:"not_after"`
+	DaysRemaining     int        `json:"days_remaining"`
+	FingerprintSHA256 string     `json:"fingerprint_sha256"`
+	LoadedAt          time.Time  `json:"loaded_at"`
+	LastReloadError   string     `json:"last_reload_error,omitempty"`
+	LastReloadErrorAt *time.Time `json:"last_reload_error_at,omitempty"`
+}
+
+// SetTLSCertificateInfo sets the function reporting the served certificate.
+// The certificate can change at runtime when its files are rotated.
+func (h *AdminAPIHandler) SetTLSCertificateInfo(fn func() TLSCertificateInfo) {
+	h.tlsCertInfo = fn
+}
+
+// WithPolicyDirectory sets the policy directory reported by the system info
+// endpoint.
+func WithPolicyDirectory(d *service.PolicyDirectory) AdminAPIOption {
+	return func(h *AdminAPIHandler) { h.policyDirectory = d }
+}
+
+// WithRegoEngine sets the Rego policy engine reported by the system info
+// endpoint.
+func WithRegoEngine(e *opa.Engine) AdminAPIOption {
+	return func(h *AdminAPIHandler) { h.regoEngine = e }
+}
+
+// ConfigReloadResult is the JSON response for POST /admin/api/system/reload.
+type ConfigReloadResult struct {
+	// Applied lists the changed settings now in effect, by YAML path.
+	Applied []string `json:"applied"`
+	// RestartRequired lists the changed settings that take effect only after
+	// a restart.
+	RestartRequired []string  `json:"restart_required"`
+	ReloadedAt      time.Time `json:"reloaded_at"`
+}
+
+// SetConfigReloader sets the function re-reading the YAML config file. The
+// reload endpoint answers 503 until it is set.
+func (h *AdminAPIHandler) SetConfigReloader(fn func(ctx context.Context) (ConfigReloadResult, error)) {
+	h.configReloader = fn
+}
+
+// SetAdmissionController sets the admission controller reported by the
+// system info endpoint.
+func (h *AdminAPIHandler) SetAdmissionController(a *service.AdmissionController) {
+	h.admission = a
 }
 
 // handleSystemInfo returns system information including version, uptime,
@@ -48,6 +117,40 @@ func (h *AdminAPIHandler) handleSystemInfo(w http.ResponseWriter, r *http.Reques
 		Uptime:    uptime.Truncate(time.Second).String(),
 		UptimeSec: int64(uptime.Seconds()),
 	}
+	if h.tlsCertInfo != nil {
+		info := h.tlsCertInfo()
+		info.DaysRemaining = int(time.Until(info.NotAfter).Hours() / 24)
+		resp.TLS = &info
+	}
+	if h.admission != nil {
+		st := h.admission.Status()
+		resp.Admission = &st
+	}
+	if h.policyDirectory != nil {
+		st := h.policyDirectory.Status()
+		resp.PolicyDirectory = &st
+	}
+	if h.regoEngine != nil {
+		st := h.regoEngine.Status()
+		resp.Rego = &st
+	}
 
 	h.respondJSON(w, http.StatusOK, resp)
 }
+
+// handleReloadConfig re-reads the YAML config file and applies the settings
+// that can change without a restart. An invalid file changes nothing.
+//
+// POST /admin/api/system/reload
+func (h *AdminAPIHandler) handleReloadConfig(w http.ResponseWriter, r *http.Request) {
+	if h.configReloader == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "config reload not available")
+		return
+	}
+	result, err := h.configReloader(r.Context())
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "config reload failed: "+err.Error())
+		return
+	}
+	h.respondJSON(w, http.StatusOK, result)
+}
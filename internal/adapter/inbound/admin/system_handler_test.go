@@ -1,11 +1,17 @@
 package admin
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
 )
 
 func TestHandleSystemInfo_Fields(t *testing.T) {
@@ -91,3 +97,83 @@ func TestHandleSystemInfo_ContentType(t *testing.T) {
 		t.Errorf("Content-Type = %q, want application/json", ct)
 	}
 }
+
+func TestHandleSystemInfo_TLSCertificate(t *testing.T) {
+	h := NewAdminAPIHandler()
+	rec := httptest.NewRecorder()
+	h.handleSystemInfo(rec, httptest.NewRequest(http.MethodGet, "/admin/api/system", nil))
+	if strings.Contains(rec.Body.String(), `"tls"`) {
+		t.Errorf("plain HTTP response should omit tls: %s", rec.Body.String())
+	}
+
+	notAfter := time.Now().Add(10*24*time.Hour + time.Hour)
+	h.SetTLSCertificateInfo(func() TLSCertificateInfo {
+		return TLSCertificateInfo{Subject: "CN=gate.example.com", NotAfter: notAfter, FingerprintSHA256: "ab12"}
+	})
+	rec = httptest.NewRecorder()
+	h.handleSystemInfo(rec, httptest.NewRequest(http.MethodGet, "/admin/api/system", nil))
+
+	var resp SystemInfoResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.TLS == nil {
+		t.Fatal("TLS should be reported")
+	}
+	if resp.TLS.Subject != "CN=gate.example.com" || resp.TLS.FingerprintSHA256 != "ab12" || resp.TLS.DaysRemaining != 10 {
+		t.Errorf("TLS = %+v", resp.TLS)
+	}
+}
+
+func TestHandleSystemInfo_Admission(t *testing.T) {
+	h := NewAdminAPIHandler()
+	h.SetAdmissionController(service.NewAdmissionController(service.AdmissionConfig{GoroutineThreshold: 1 << 20}, slog.Default()))
+	rec := httptest.NewRecorder()
+	h.handleSystemInfo(rec, httptest.NewRequest(http.MethodGet, "/admin/api/system", nil))
+
+	var resp SystemInfoResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Admission == nil {
+		t.Fatal("admission should be reported")
+	}
+	if resp.Admission.Shedding || resp.Admission.GoroutineThreshold != 1<<20 {
+		t.Errorf("admission = %+v", resp.Admission)
+	}
+}
+
+func TestHandleReloadConfig(t *testing.T) {
+	h := NewAdminAPIHandler()
+	rec := httptest.NewRecorder()
+	h.handleReloadConfig(rec, httptest.NewRequest(http.MethodPost, "/admin/api/system/reload", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status without reloader = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	h.SetConfigReloader(func(ctx context.Context) (ConfigReloadResult, error) {
+		return ConfigReloadResult{Applied: []string{"rate_limit.user_rate"}, RestartRequired: []string{"server.http_addr"}}, nil
+	})
+	rec = httptest.NewRecorder()
+	h.handleReloadConfig(rec, httptest.NewRequest(http.MethodPost, "/admin/api/system/reload", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var resp ConfigReloadResult
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(resp.Applied) != 1 || resp.Applied[0] != "rate_limit.user_rate" ||
+		len(resp.RestartRequired) != 1 || resp.RestartRequired[0] != "server.http_addr" {
+		t.Errorf("result = %+v", resp)
+	}
+
+	h.SetConfigReloader(func(ctx context.Context) (ConfigReloadResult, error) {
+		return ConfigReloadResult{}, errors.New("rate_limit.ip_rate: must be at least 1")
+	})
+	rec = httptest.NewRecorder()
+	h.handleReloadConfig(rec, httptest.NewRequest(http.MethodPost, "/admin/api/system/reload", nil))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "ip_rate") {
+		t.Errorf("invalid config: status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+}
@@ -72,6 +72,7 @@ func (h *AdminAPIHandler) handleFactoryReset(w http.ResponseWriter, r *http.Requ
 		pending := h.approvalStore.List()
 		result.ApprovalsCancelled = len(pending)
 		h.approvalStore.CancelAll()
+		h.approvalStore.ClearGrants()
 	}
 
 	// ── Phase 2: Stop upstreams and clear tool cache ──────────────────
@@ -111,7 +112,7 @@ func (h *AdminAPIHandler) handleFactoryReset(w http.ResponseWriter, r *http.Requ
 		}
 		for _, p := range policies {
 			if err := h.policyAdminService.Delete(ctx, p.ID); err != nil {
-				if errors.Is(err, service.ErrDefaultPolicyDelete) {
+				if errors.Is(err, service.ErrDefaultPolicyDelete) || errors.Is(err, service.ErrFilePolicy) {
 					result.SkippedReadOnly = append(result.SkippedReadOnly, "policy:"+p.Name)
 				} else {
 					h.logger.Warn("factory reset: failed to delete policy", "id", p.ID, "error", err)
@@ -208,12 +209,22 @@ func (h *AdminAPIHandler) handleFactoryReset(w http.ResponseWriter, r *http.Requ
 		h.contentScanInterceptor.SetEnabled(false)
 		h.contentScanInterceptor.SetWhitelist(nil)
 	}
+	if h.argumentScanInterceptor != nil {
+		h.argumentScanInterceptor.SetEnabled(false)
+		h.argumentScanInterceptor.SetMode(action.ScanModeMonitor)
+		_ = h.argumentScanInterceptor.Scanner().SetInternalDomains(nil)
+	}
 
 	// ── Phase 8c: Reset response scanning to defaults ─────────────────
 	if h.responseScanCtrl != nil {
 		h.responseScanCtrl.SetEnabled(false)
 		h.responseScanCtrl.SetMode(action.ScanModeMonitor)
 	}
+	if h.responseScanner != nil {
+		if err := h.responseScanner.SetCustomDetectors(nil); err != nil {
+			h.logger.Warn("factory reset: failed to clear custom scan detectors", "error", err)
+		}
+	}
 	for _, ctrl := range h.additionalScanCtrls {
 		ctrl.SetEnabled(false)
 		ctrl.SetMode(action.ScanModeMonitor)
@@ -234,6 +245,13 @@ func (h *AdminAPIHandler) handleFactoryReset(w http.ResponseWriter, r *http.Requ
 		}
 	}
 
+	// Outbound learning observations are kept in memory between flushes.
+	if h.outboundLearning != nil {
+		if err := h.outboundLearning.Reset(ctx); err != nil {
+			h.logger.Warn("factory reset: failed to reset outbound learning", "error", err)
+		}
+	}
+
 	// ── Phase 9: Reset state.json config fields ───────────────────────
 	// Clears configs that don't have dedicated in-memory stores,
 	// and ensures in-memory deletions (quotas, transforms) are persisted.
@@ -254,6 +272,12 @@ func (h *AdminAPIHandler) handleFactoryReset(w http.ResponseWriter, r *http.Requ
 			s.DriftConfig = nil
 			s.EvidenceConfig = nil
 			s.PolicyEvaluations = nil
+			s.OutboundLearning = nil
+			s.JobSchedules = nil
+			s.PolicyVariables = nil
+			s.RateLimitOverrides = nil
+			s.AdminTokens = nil
+			s.Notice = nil
 			s.UpdatedAt = time.Now().UTC()
 			return nil
 		}); err != nil {
@@ -293,6 +317,21 @@ func (h *AdminAPIHandler) handleFactoryReset(w http.ResponseWriter, r *http.Requ
 	if h.policyEvalService != nil {
 		h.policyEvalService.ClearEvaluations()
 	}
+	if h.policyVariableService != nil {
+		h.policyVariableService.Reset()
+	}
+	if h.rateLimitOverrides != nil {
+		h.rateLimitOverrides.Reset()
+	}
+	if h.rateLimitExemptions != nil {
+		h.rateLimitExemptions.Reset()
+	}
+	if h.adminTokens != nil {
+		h.adminTokens.Reset()
+	}
+	if h.noticeService != nil {
+		h.noticeService.Reset()
+	}
 	if h.driftService != nil {
 		h.driftService.ClearCache()
 		h.driftService.SetConfig(service.DefaultDriftConfig())
@@ -317,6 +356,11 @@ func (h *AdminAPIHandler) handleFactoryReset(w http.ResponseWriter, r *http.Requ
 		h.statsService.Reset()
 		result.StatsReset = true
 	}
+	if h.toolStatsService != nil {
+		if err := h.toolStatsService.Reset(r.Context()); err != nil {
+			h.logger.Warn("factory reset: failed to reset tool stats", "error", err)
+		}
+	}
 
 	// ── Phase 13: Notify clients about tool list change ───────────────
 	if h.toolChangeNotifier != nil {
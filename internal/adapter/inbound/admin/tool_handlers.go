@@ -180,6 +180,39 @@ func (h *AdminAPIHandler) handleRefreshTools(w http.ResponseWriter, r *http.Requ
 	})
 }
 
+// toolCacheMemoryResponse is the JSON response for the tool cache memory endpoint.
+type toolCacheMemoryResponse struct {
+	Tools             int   `json:"tools"`
+	UniqueSchemas     int   `json:"unique_schemas"`
+	CompressedSchemas int   `json:"compressed_schemas"`
+	SchemaBytes       int64 `json:"schema_bytes"`
+	StoredBytes       int64 `json:"stored_bytes"`
+	MetadataBytes     int64 `json:"metadata_bytes"`
+	SnapshotRetained  bool  `json:"snapshot_retained"`
+	CompressMinBytes  int   `json:"compress_min_bytes"`
+}
+
+// handleGetToolCacheMemory reports how much memory the tool cache holds.
+// GET /admin/api/tools/memory
+func (h *AdminAPIHandler) handleGetToolCacheMemory(w http.ResponseWriter, r *http.Request) {
+	if h.toolCache == nil {
+		h.respondJSON(w, http.StatusOK, toolCacheMemoryResponse{})
+		return
+	}
+
+	stats := h.toolCache.MemoryStats()
+	h.respondJSON(w, http.StatusOK, toolCacheMemoryResponse{
+		Tools:             stats.Tools,
+		UniqueSchemas:     stats.UniqueSchemas,
+		CompressedSchemas: stats.CompressedSchemas,
+		SchemaBytes:       stats.SchemaBytes,
+		StoredBytes:       stats.StoredBytes,
+		MetadataBytes:     stats.MetadataBytes,
+		SnapshotRetained:  stats.SnapshotRetained,
+		CompressMinBytes:  stats.CompressMinBytes,
+	})
+}
+
 // evaluateToolPolicy determines the policy status of a tool by examining
 // all rules whose tool_match pattern matches, without evaluating CEL conditions.
 // Returns status ("allow", "deny", "conditional", "no_rule", "unknown") and
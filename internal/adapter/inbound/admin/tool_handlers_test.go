@@ -373,6 +373,28 @@ func TestHandleRefreshTools_NilDiscoveryService(t *testing.T) {
 	}
 }
 
+func TestHandleGetToolCacheMemory(t *testing.T) {
+	cache := upstream.NewToolCache()
+	schema := json.RawMessage(`{"type":"object"}`)
+	cache.SetToolsForUpstream("upstream-a", []*upstream.DiscoveredTool{
+		{Name: "read_file", InputSchema: schema, UpstreamID: "upstream-a", UpstreamName: "files"},
+		{Name: "write_file", InputSchema: schema, UpstreamID: "upstream-a", UpstreamName: "files"},
+	})
+	h := newTestToolHandler(t, cache)
+
+	rec := serveToolRequest(t, h.handleGetToolCacheMemory, http.MethodGet, "/admin/api/tools/memory")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var resp toolCacheMemoryResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Tools != 2 || resp.UniqueSchemas != 1 || resp.SchemaBytes != int64(2*len(schema)) || resp.StoredBytes != int64(len(schema)) {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
 // --- Tests for evaluateToolPolicy ---
 
 func TestHandleListTools_PolicyStatusEvaluation(t *testing.T) {
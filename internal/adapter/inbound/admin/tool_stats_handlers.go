@@ -0,0 +1,140 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+const (
+	defaultToolStatsWindow = 24 * time.Hour
+	maxToolStatsWindow     = 30 * 24 * time.Hour
+	defaultToolStatsTop    = 10
+	maxToolStatsTop        = 100
+)
+
+// WithToolStatsService sets the per-tool statistics service.
+func WithToolStatsService(s *service.ToolStatsService) AdminAPIOption {
+	return func(h *AdminAPIHandler) { h.toolStatsService = s }
+}
+
+// SetToolStatsService sets the per-tool statistics service after construction.
+func (h *AdminAPIHandler) SetToolStatsService(s *service.ToolStatsService) {
+	h.toolStatsService = s
+}
+
+// toolStatsResponse is the response body of GET /admin/api/tools/{name}/stats.
+type toolStatsResponse struct {
+	service.ToolStatsSummary
+	Window     string    `json:"window"`
+	Persistent bool      `json:"persistent"`
+	Since      time.Time `json:"since"`
+}
+
+// toolStatsTopResponse is the response body of GET /admin/api/tools/stats/top.
+type toolStatsTopResponse struct {
+	By         string                     `json:"by"`
+	Window     string                     `json:"window"`
+	Persistent bool                       `json:"persistent"`
+	Since      time.Time                  `json:"since"`
+	Tools      []service.ToolStatsSummary `json:"tools"`
+}
+
+// parseToolStatsWindow reads the window query parameter (a Go duration such
+// as "1h" or "168h"). Defaults to 24h.
+func parseToolStatsWindow(r *http.Request) (time.Duration, bool) {
+	s := r.URL.Query().Get("window")
+	if s == "" {
+		return defaultToolStatsWindow, true
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 || d > maxToolStatsWindow {
+		return 0, false
+	}
+	return d, true
+}
+
+// handleGetToolStats returns the call counts, error rate, latency
+// percentiles and latency histogram of one tool.
+// GET /admin/api/tools/{name}/stats?window=24h
+func (h *AdminAPIHandler) handleGetToolStats(w http.ResponseWriter, r *http.Request) {
+	if h.toolStatsService == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "tool statistics not available")
+		return
+	}
+	name := r.PathValue("name")
+	if name == "" {
+		h.respondError(w, http.StatusBadRequest, "tool name is required")
+		return
+	}
+	window, ok := parseToolStatsWindow(r)
+	if !ok {
+		h.respondError(w, http.StatusBadRequest, "invalid 'window' parameter: expected a duration up to 720h")
+		return
+	}
+
+	summary, _, err := h.toolStatsService.ToolStats(r.Context(), name, window)
+	if err != nil {
+		h.internalError(w, "failed to get tool stats", err)
+		return
+	}
+	h.respondJSON(w, http.StatusOK, toolStatsResponse{
+		ToolStatsSummary: summary,
+		Window:           window.String(),
+		Persistent:       h.toolStatsService.Persistent(),
+		Since:            h.toolStatsService.Since(),
+	})
+}
+
+// handleGetToolStatsTop returns the tools ranked by calls, errors, error
+// rate or latency.
+// GET /admin/api/tools/stats/top?by=p99&limit=10&window=24h
+func (h *AdminAPIHandler) handleGetToolStatsTop(w http.ResponseWriter, r *http.Request) {
+	if h.toolStatsService == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "tool statistics not available")
+		return
+	}
+	q := r.URL.Query()
+	by := q.Get("by")
+	switch by {
+	case "":
+		by = service.ToolStatsByCalls
+	case service.ToolStatsByCalls, service.ToolStatsByErrors, service.ToolStatsByErrorRate,
+		service.ToolStatsByP50, service.ToolStatsByP99:
+	default:
+		h.respondError(w, http.StatusBadRequest, "invalid 'by' parameter: expected calls, errors, error_rate, p50 or p99")
+		return
+	}
+	limit := defaultToolStatsTop
+	if s := q.Get("limit"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 1 {
+			h.respondError(w, http.StatusBadRequest, "invalid 'limit' parameter")
+			return
+		}
+		if v > maxToolStatsTop {
+			v = maxToolStatsTop
+		}
+		limit = v
+	}
+	window, ok := parseToolStatsWindow(r)
+	if !ok {
+		h.respondError(w, http.StatusBadRequest, "invalid 'window' parameter: expected a duration up to 720h")
+		return
+	}
+
+	tools, err := h.toolStatsService.Top(r.Context(), by, limit, window)
+	if err != nil {
+		h.internalError(w, "failed to get top tools", err)
+		return
+	}
+	h.respondJSON(w, http.StatusOK, toolStatsTopResponse{
+		By:         by,
+		Window:     window.String(),
+		Persistent: h.toolStatsService.Persistent(),
+		Since:      h.toolStatsService.Since(),
+		Tools:      tools,
+	})
+}
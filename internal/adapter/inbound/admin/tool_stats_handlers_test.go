@@ -0,0 +1,56 @@
+package admin
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/toolstats"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+func TestHandleToolStats(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	stats := service.NewToolStatsService(logger)
+	stats.RecordToolCall("read_file", toolstats.OutcomeOK, 5*time.Millisecond)
+	stats.RecordToolCall("read_file", toolstats.OutcomeOK, 7*time.Millisecond)
+	stats.RecordToolCall("slow_query", toolstats.OutcomeOK, 2*time.Second)
+	h := NewAdminAPIHandler(WithToolStatsService(stats), WithAPILogger(logger))
+
+	rec := sloTestRequest(t, h, "/admin/api/tools/read_file/stats?window=1h")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body=%s)", rec.Code, rec.Body.String())
+	}
+	var one toolStatsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&one); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if one.Tool != "read_file" || one.Calls != 2 || len(one.Histogram) == 0 || one.Window != "1h0m0s" {
+		t.Errorf("response = %+v, want read_file with 2 calls and a histogram", one)
+	}
+
+	rec = sloTestRequest(t, h, "/admin/api/tools/stats/top?by=p99&limit=1")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("top status = %d, want 200 (body=%s)", rec.Code, rec.Body.String())
+	}
+	var top toolStatsTopResponse
+	if err := json.NewDecoder(rec.Body).Decode(&top); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(top.Tools) != 1 || top.Tools[0].Tool != "slow_query" {
+		t.Errorf("top = %+v, want slow_query first by p99", top.Tools)
+	}
+
+	for _, path := range []string{
+		"/admin/api/tools/stats/top?by=median",
+		"/admin/api/tools/stats/top?limit=0",
+		"/admin/api/tools/read_file/stats?window=forever",
+	} {
+		if rec := sloTestRequest(t, h, path); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", path, rec.Code)
+		}
+	}
+}
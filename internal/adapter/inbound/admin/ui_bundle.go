@@ -0,0 +1,72 @@
+package admin
+
+import (
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+// bundleHandler serves an admin UI bundle from a directory under /admin/.
+// Paths matching no file get the bundle's index.html, so client-side routes
+// load the app. Directories are never listed and files whose name starts
+// with a dot are not served.
+type bundleHandler struct {
+	root fs.FS
+}
+
+// newBundleHandler creates a bundleHandler for dir.
+func newBundleHandler(dir string) *bundleHandler {
+	return &bundleHandler{root: os.DirFS(dir)}
+}
+
+func (b *bundleHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(path.Clean("/"+strings.TrimPrefix(r.URL.Path, "/admin")), "/")
+	if name == "" {
+		name = "."
+	}
+
+	w.Header().Set("Cache-Control", "no-cache, must-revalidate")
+	if !hiddenPath(name) && b.serveFile(w, r, name) {
+		return
+	}
+	if !b.serveFile(w, r, "index.html") {
+		http.NotFound(w, r)
+	}
+}
+
+// serveFile serves name, or name/index.html when name is a directory. It
+// reports false when there is no such file.
+func (b *bundleHandler) serveFile(w http.ResponseWriter, r *http.Request, name string) bool {
+	info, err := fs.Stat(b.root, name)
+	if err == nil && info.IsDir() {
+		name = path.Join(name, "index.html")
+		info, err = fs.Stat(b.root, name)
+	}
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	f, err := b.root.Open(name)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		return false
+	}
+	http.ServeContent(w, r, path.Base(name), info.ModTime(), content)
+	return true
+}
+
+// hiddenPath reports whether any element of name starts with a dot.
+func hiddenPath(name string) bool {
+	for _, elem := range strings.Split(name, "/") {
+		if strings.HasPrefix(elem, ".") && elem != "." {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package admin
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/config"
+)
+
+// newTestUIHandler creates an AdminHandler with the given admin UI config.
+func newTestUIHandler(t *testing.T, ui config.AdminUIConfig) http.Handler {
+	t.Helper()
+	cfg := &config.OSSConfig{}
+	cfg.Server.AdminUI = ui
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	h, err := NewAdminHandler(cfg, logger)
+	if err != nil {
+		t.Fatalf("NewAdminHandler: %v", err)
+	}
+	return h.Handler()
+}
+
+func getAdminUI(handler http.Handler, path, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = remoteAddr
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdminUI_DirectoryBundle(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"index.html":        "<html>console</html>",
+		"assets/app.js":     "console.log('app')",
+		".env":              "SECRET=1",
+		"assets/.git/HEAD":  "ref",
+		"reports/list.html": "<html>reports</html>",
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	csp := "default-src 'self'; connect-src 'self' https://console.example.com"
+	handler := newTestUIHandler(t, config.AdminUIConfig{Mode: "directory", Directory: dir, ContentSecurityPolicy: csp})
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/admin", "<html>console</html>"},
+		{"/admin/", "<html>console</html>"},
+		{"/admin/assets/app.js", "console.log('app')"},
+		{"/admin/reports/list.html", "<html>reports</html>"},
+		// Client-side routes, directories and hidden files get index.html.
+		{"/admin/settings/keys", "<html>console</html>"},
+		{"/admin/assets/", "<html>console</html>"},
+		{"/admin/.env", "<html>console</html>"},
+		{"/admin/assets/.git/HEAD", "<html>console</html>"},
+		// The embedded UI is not served.
+		{"/admin/static/css/variables.css", "<html>console</html>"},
+	}
+	for _, tt := range tests {
+		rec := getAdminUI(handler, tt.path, "127.0.0.1:12345")
+		if rec.Code != http.StatusOK || rec.Body.String() != tt.want {
+			t.Errorf("GET %s = %d %q, want 200 %q", tt.path, rec.Code, rec.Body.String(), tt.want)
+		}
+		if got := rec.Header().Get("Content-Security-Policy"); got != csp {
+			t.Errorf("GET %s CSP = %q, want %q", tt.path, got, csp)
+		}
+	}
+
+	if rec := getAdminUI(handler, "/admin/assets/app.js", "127.0.0.1:1"); !strings.Contains(rec.Header().Get("Content-Type"), "javascript") {
+		t.Errorf("app.js Content-Type = %q", rec.Header().Get("Content-Type"))
+	}
+	if rec := getAdminUI(handler, "/admin/", "192.168.1.100:5555"); rec.Code != http.StatusForbidden {
+		t.Errorf("GET /admin/ from remote: got %d, want 403", rec.Code)
+	}
+}
+
+func TestAdminUI_DirectoryBundleDefaultCSP(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "index.html"), []byte("<html></html>"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	handler := newTestUIHandler(t, config.AdminUIConfig{Mode: "directory", Directory: dir})
+
+	rec := getAdminUI(handler, "/admin/", "127.0.0.1:12345")
+	if got := rec.Header().Get("Content-Security-Policy"); got != defaultContentSecurityPolicy {
+		t.Errorf("CSP = %q, want the default policy", got)
+	}
+	if rec.Header().Get("X-Frame-Options") != "DENY" {
+		t.Error("X-Frame-Options should be set for a directory bundle")
+	}
+}
+
+func TestAdminUI_Disabled(t *testing.T) {
+	handler := newTestUIHandler(t, config.AdminUIConfig{Mode: "disabled"})
+
+	for _, path := range []string{"/admin", "/admin/", "/admin/static/css/variables.css"} {
+		if rec := getAdminUI(handler, path, "127.0.0.1:12345"); rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s in API-only mode: got %d, want 404", path, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package admin
+
+import (
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/upstream"
+)
+
+// upstreamCredentialsJSON is the JSON form of upstream credentials in
+// requests and responses. Responses mask token, password, header_value and
+// client_secret as "***" unless they are ${env:NAME} references; sending
+// "***" back on update keeps the stored value.
+type upstreamCredentialsJSON struct {
+	Type         string   `json:"type"`
+	Token        string   `json:"token,omitempty"`
+	Username     string   `json:"username,omitempty"`
+	Password     string   `json:"password,omitempty"`
+	HeaderName   string   `json:"header_name,omitempty"`
+	HeaderValue  string   `json:"header_value,omitempty"`
+	TokenURL     string   `json:"token_url,omitempty"`
+	ClientID     string   `json:"client_id,omitempty"`
+	ClientSecret string   `json:"client_secret,omitempty"`
+	Scopes       []string `json:"scopes,omitempty"`
+}
+
+// redactSecret masks a credential secret for API responses.
+func redactSecret(v string) string {
+	if v == "" || upstream.IsSecretRef(v) {
+		return v
+	}
+	return "***"
+}
+
+// toCredentialsResponse returns the masked credentials of an upstream, or
+// nil when it has none.
+func toCredentialsResponse(c *upstream.Credentials) *upstreamCredentialsJSON {
+	if c == nil {
+		return nil
+	}
+	return &upstreamCredentialsJSON{
+		Type:         string(c.Type),
+		Token:        redactSecret(c.Token),
+		Username:     c.Username,
+		Password:     redactSecret(c.Password),
+		HeaderName:   c.HeaderName,
+		HeaderValue:  redactSecret(c.HeaderValue),
+		TokenURL:     c.TokenURL,
+		ClientID:     c.ClientID,
+		ClientSecret: redactSecret(c.ClientSecret),
+		Scopes:       c.Scopes,
+	}
+}
+
+// restoreRedactedSecret returns existing when v is the "***" mask.
+func restoreRedactedSecret(v, existing string) string {
+	if v == "***" {
+		return existing
+	}
+	return v
+}
+
+// credentialsFromRequest builds the credentials of an upstream of type t
+// from the request. When req is nil the existing credentials are kept; an
+// empty type removes them. Masked secrets are restored from existing when
+// the credential type is unchanged. Returns an error message for a 400
+// response when the credentials are invalid.
+func credentialsFromRequest(req *upstreamCredentialsJSON, existing *upstream.Credentials, t upstream.UpstreamType) (*upstream.Credentials, string) {
+	if req == nil {
+		return existing, ""
+	}
+	if req.Type == "" {
+		return nil, ""
+	}
+	switch t {
+	case upstream.UpstreamTypeHTTP, upstream.UpstreamTypeSSE, upstream.UpstreamTypeOpenAPI, upstream.UpstreamTypeShim:
+	default:
+		return nil, "credentials are only supported for http, sse, openapi and shim upstreams"
+	}
+	c := &upstream.Credentials{
+		Type:         upstream.CredentialType(req.Type),
+		Token:        req.Token,
+		Username:     req.Username,
+		Password:     req.Password,
+		HeaderName:   req.HeaderName,
+		HeaderValue:  req.HeaderValue,
+		TokenURL:     req.TokenURL,
+		ClientID:     req.ClientID,
+		ClientSecret: req.ClientSecret,
+		Scopes:       req.Scopes,
+	}
+	if existing != nil && existing.Type == c.Type {
+		c.Token = restoreRedactedSecret(c.Token, existing.Token)
+		c.Password = restoreRedactedSecret(c.Password, existing.Password)
+		c.HeaderValue = restoreRedactedSecret(c.HeaderValue, existing.HeaderValue)
+		c.ClientSecret = restoreRedactedSecret(c.ClientSecret, existing.ClientSecret)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err.Error()
+	}
+	return c, ""
+}
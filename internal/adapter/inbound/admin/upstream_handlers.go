@@ -7,11 +7,15 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/upstream"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
 )
 
 // dangerousEnvVars is a blocklist of environment variables that could be used
@@ -132,7 +136,8 @@ func rejectCloudMetadata(host string) string {
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
-	resolver := &net.Resolver{}
+	// The default resolver, so the check sees what the upstream dialer will.
+	resolver := net.DefaultResolver
 	addrs, err := resolver.LookupHost(ctx, host)
 	if err != nil {
 		return fmt.Sprintf("DNS resolution failed for %s: cannot verify safety", host)
@@ -160,6 +165,92 @@ func validateEnvVars(env map[string]string) string {
 	return ""
 }
 
+// normalizeFraming validates a requested stdio framing and returns its
+// canonical name. An empty value keeps the default (auto) and is stored empty.
+func normalizeFraming(framing string, t upstream.UpstreamType) (string, string) {
+	if strings.TrimSpace(framing) == "" {
+		return "", ""
+	}
+	if t != upstream.UpstreamTypeStdio {
+		return "", "framing is only supported for stdio upstreams"
+	}
+	f, err := mcp.ParseFraming(framing)
+	if err != nil {
+		return "", err.Error()
+	}
+	return string(f), ""
+}
+
+// headerNamePattern matches valid HTTP header names (RFC 9110 tokens).
+var headerNamePattern = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$")
+
+// reservedOpenAPIHeaders are set by the bridge for every request and cannot
+// be configured on an openapi or shim upstream.
+var reservedOpenAPIHeaders = map[string]bool{
+	"Host":              true,
+	"Content-Length":    true,
+	"Content-Type":      true,
+	"Transfer-Encoding": true,
+	"Connection":        true,
+}
+
+// validateOpenAPISpec checks the OpenAPI document location of an openapi
+// upstream, or the tool mapping location of a shim upstream: an http(s) URL (with the same SSRF checks as upstream URLs) or
+// a file path without traversal.
+func validateOpenAPISpec(spec string) string {
+	if strings.TrimSpace(spec) == "" {
+		return "spec is required for openapi and shim upstreams"
+	}
+	if scheme, _, ok := strings.Cut(spec, "://"); ok {
+		if scheme != "http" && scheme != "https" {
+			return "spec must be an http(s) URL or a file path"
+		}
+		return validateUpstreamURL(spec)
+	}
+	if containsPathTraversal(spec) {
+		return "path traversal detected in spec"
+	}
+	return ""
+}
+
+// validateOpenAPIHeaders checks the header names configured on an openapi
+// or shim upstream.
+func validateOpenAPIHeaders(headers map[string]string) string {
+	for name := range headers {
+		if !headerNamePattern.MatchString(name) {
+			return "invalid header name " + strconv.Quote(name)
+		}
+		if reservedOpenAPIHeaders[http.CanonicalHeaderKey(name)] {
+			return "header " + name + " cannot be configured"
+		}
+	}
+	return ""
+}
+
+// restoreRedacted returns values with every "***" replaced by the existing
+// value under the same key. The API returns "***" for env and header values
+// (see redactEnvValues); when the frontend sends them back on edit, the
+// original value is preserved to prevent data corruption.
+func restoreRedacted(values, existing map[string]string) map[string]string {
+	if values == nil {
+		return existing
+	}
+	if existing == nil {
+		return values
+	}
+	merged := make(map[string]string, len(values))
+	for k, v := range values {
+		if v == "***" {
+			if orig, ok := existing[k]; ok {
+				merged[k] = orig
+				continue
+			}
+		}
+		merged[k] = v
+	}
+	return merged
+}
+
 // upstreamRequest is the JSON body for create and update upstream endpoints.
 type upstreamRequest struct {
 	Name    string            `json:"name"`
@@ -167,8 +258,30 @@ type upstreamRequest struct {
 	Command string            `json:"command"`
 	Args    []string          `json:"args"`
 	URL     string            `json:"url"`
+	Spec    string            `json:"spec"`    // openapi: OpenAPI document, shim: tool mapping (URL or file path)
+	Headers map[string]string `json:"headers"` // openapi and shim: added to every API request
 	Env     map[string]string `json:"env"`
 	Enabled *bool             `json:"enabled"` // pointer to distinguish missing from false
+	Lazy    *bool             `json:"lazy"`    // stdio only: start on first request, stop when idle
+	Framing *string           `json:"framing"` // stdio only: auto, newline or content-length
+	// Credentials authenticate the gateway to http, sse, openapi and shim
+	// upstreams. Omitted on update keeps them; an empty type removes them.
+	Credentials *upstreamCredentialsJSON `json:"credentials"`
+	// ToolPrefix is prepended to the names of the upstream's tools. Omitted
+	// on update keeps it; an empty string removes it.
+	ToolPrefix *string `json:"tool_prefix"`
+	// ToolInclude and ToolExclude are glob patterns selecting the tools
+	// exposed to clients. Omitted on update keeps them; [] removes them.
+	ToolInclude *[]string `json:"tool_include"`
+	ToolExclude *[]string `json:"tool_exclude"`
+	// ConvertSecrets replaces detected plaintext secrets with ${env:NAME}
+	// references before saving.
+	ConvertSecrets bool `json:"convert_secrets"`
+	// Drain applies an update once the upstream's in-flight calls have
+	// finished instead of restarting it right away. DrainTimeout bounds the
+	// wait (default 30s).
+	Drain        bool   `json:"drain"`
+	DrainTimeout string `json:"drain_timeout"`
 }
 
 // upstreamResponse is the JSON representation of an upstream returned by the API.
@@ -179,30 +292,60 @@ type upstreamResponse struct {
 	Command   string            `json:"command,omitempty"`
 	Args      []string          `json:"args,omitempty"`
 	URL       string            `json:"url,omitempty"`
+	Spec      string            `json:"spec,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
 	Env       map[string]string `json:"env,omitempty"`
 	Enabled   bool              `json:"enabled"`
+	Lazy      bool              `json:"lazy,omitempty"`
+	Framing   string            `json:"framing,omitempty"`
 	Status    string            `json:"status"`
 	LastError string            `json:"last_error,omitempty"`
 	ToolCount int               `json:"tool_count"`
 	CreatedAt string            `json:"created_at"`
 	UpdatedAt string            `json:"updated_at"`
+	// Credentials are returned with their secrets masked.
+	Credentials *upstreamCredentialsJSON `json:"credentials,omitempty"`
+	// ToolPrefix is prepended to the names of the upstream's tools.
+	ToolPrefix string `json:"tool_prefix,omitempty"`
+	// ToolInclude and ToolExclude select the tools exposed to clients.
+	ToolInclude []string `json:"tool_include,omitempty"`
+	ToolExclude []string `json:"tool_exclude,omitempty"`
+	// SecretFindings lists plaintext secrets detected (or converted) on save.
+	SecretFindings []secretFindingResponse `json:"secret_findings,omitempty"`
+	// Discovery is the last tool discovery: pages, durations and whether it
+	// was complete.
+	Discovery *service.DiscoveryStatus `json:"discovery,omitempty"`
+	// Connections is the number of connections the server of a reverse
+	// upstream has registered.
+	Connections int `json:"connections,omitempty"`
+	// RegistrationToken is returned once, when a reverse upstream is created
+	// or its token rotated. Only its hash is stored.
+	RegistrationToken string `json:"registration_token,omitempty"`
+	// Drain is the progress of a drain before a configuration update.
+	Drain *service.DrainStatus `json:"drain,omitempty"`
 }
 
 // redactEnvValues returns a copy of env with all values masked.
 // Keys are preserved so the admin UI can show which variables are set.
+// Values that are only a secret reference (${env:NAME}) hold no secret and
+// are shown as is.
 func redactEnvValues(env map[string]string) map[string]string {
 	if len(env) == 0 {
 		return env
 	}
 	redacted := make(map[string]string, len(env))
-	for k := range env {
+	for k, v := range env {
+		if upstream.IsSecretRef(v) {
+			redacted[k] = v
+			continue
+		}
 		redacted[k] = "***"
 	}
 	return redacted
 }
 
 // toUpstreamResponse converts a domain Upstream plus runtime info into an API response.
-// SECURITY: Env var values are redacted — only keys are visible in API responses.
+// SECURITY: Env var, header and credential secret values are redacted — only keys are visible in API responses.
 func toUpstreamResponse(u *upstream.Upstream, status upstream.ConnectionStatus, lastError string, toolCount int) upstreamResponse {
 	return upstreamResponse{
 		ID:        u.ID,
@@ -211,13 +354,22 @@ func toUpstreamResponse(u *upstream.Upstream, status upstream.ConnectionStatus,
 		Command:   u.Command,
 		Args:      u.Args,
 		URL:       u.URL,
+		Spec:      u.Spec,
+		Headers:   redactEnvValues(u.Headers),
 		Env:       redactEnvValues(u.Env),
 		Enabled:   u.Enabled,
+		Lazy:      u.Lazy,
+		Framing:   u.Framing,
 		Status:    string(status),
 		LastError: lastError,
 		ToolCount: toolCount,
 		CreatedAt: u.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"),
 		UpdatedAt: u.UpdatedAt.UTC().Format("2006-01-02T15:04:05Z"),
+
+		Credentials: toCredentialsResponse(u.Credentials),
+		ToolPrefix:  u.ToolPrefix,
+		ToolInclude: u.ToolInclude,
+		ToolExclude: u.ToolExclude,
 	}
 }
 
@@ -251,7 +403,19 @@ func (h *AdminAPIHandler) handleListUpstreams(w http.ResponseWriter, r *http.Req
 			toolCount = len(tools)
 		}
 
-		result = append(result, toUpstreamResponse(u, status, lastError, toolCount))
+		resp := toUpstreamResponse(u, status, lastError, toolCount)
+		if h.discoveryService != nil {
+			if st, ok := h.discoveryService.DiscoveryStatus(u.ID); ok {
+				resp.Discovery = &st
+			}
+		}
+		if u.Type == upstream.UpstreamTypeReverse && h.reverseHub != nil {
+			resp.Connections = h.reverseHub.Connections(u.ID)
+		}
+		if d, ok := h.upstreamManager.DrainStatus(u.ID); ok {
+			resp.Drain = &d
+		}
+		result = append(result, resp)
 	}
 
 	h.respondJSON(w, http.StatusOK, result)
@@ -288,6 +452,15 @@ func validateCommandSafety(upstreamType upstream.UpstreamType, command string, a
 	return ""
 }
 
+// validateReverseRequest rejects the fields a reverse upstream has no use
+// for: its server registers itself, so there is nothing to start or dial.
+func validateReverseRequest(req *upstreamRequest) string {
+	if req.Command != "" || len(req.Args) > 0 || req.URL != "" || len(req.Env) > 0 {
+		return "command, args, url and env are not supported for reverse upstreams"
+	}
+	return ""
+}
+
 // handleCreateUpstream creates a new upstream, optionally starts it and discovers tools.
 // POST /admin/api/upstreams
 func (h *AdminAPIHandler) handleCreateUpstream(w http.ResponseWriter, r *http.Request) {
@@ -305,10 +478,18 @@ func (h *AdminAPIHandler) handleCreateUpstream(w http.ResponseWriter, r *http.Re
 	}
 
 	upstreamType := upstream.UpstreamType(req.Type)
-	if upstreamType != upstream.UpstreamTypeStdio && upstreamType != upstream.UpstreamTypeHTTP {
-		h.respondError(w, http.StatusBadRequest, "type must be \"stdio\" or \"http\"")
+	if upstreamType != upstream.UpstreamTypeStdio && upstreamType != upstream.UpstreamTypeHTTP &&
+		upstreamType != upstream.UpstreamTypeSSE && upstreamType != upstream.UpstreamTypeOpenAPI &&
+		upstreamType != upstream.UpstreamTypeShim && upstreamType != upstream.UpstreamTypeReverse {
+		h.respondError(w, http.StatusBadRequest, "type must be \"stdio\", \"http\", \"sse\", \"openapi\", \"shim\" or \"reverse\"")
 		return
 	}
+	if upstreamType == upstream.UpstreamTypeReverse {
+		if msg := validateReverseRequest(&req); msg != "" {
+			h.respondError(w, http.StatusBadRequest, msg)
+			return
+		}
+	}
 
 	// SECU-08: Validate command and args for path traversal.
 	if msg := validateCommandSafety(upstreamType, req.Command, req.Args); msg != "" {
@@ -317,13 +498,28 @@ func (h *AdminAPIHandler) handleCreateUpstream(w http.ResponseWriter, r *http.Re
 	}
 
 	// SECU-09: Validate URL scheme (http/https only, prevents SSRF).
-	if upstreamType == upstream.UpstreamTypeHTTP {
+	if upstreamType == upstream.UpstreamTypeHTTP || upstreamType == upstream.UpstreamTypeSSE ||
+		upstreamType == upstream.UpstreamTypeOpenAPI || upstreamType == upstream.UpstreamTypeShim {
 		if msg := validateUpstreamURL(req.URL); msg != "" {
 			h.respondError(w, http.StatusBadRequest, msg)
 			return
 		}
 	}
 
+	if upstreamType == upstream.UpstreamTypeOpenAPI || upstreamType == upstream.UpstreamTypeShim {
+		if msg := validateOpenAPISpec(req.Spec); msg != "" {
+			h.respondError(w, http.StatusBadRequest, msg)
+			return
+		}
+		if msg := validateOpenAPIHeaders(req.Headers); msg != "" {
+			h.respondError(w, http.StatusBadRequest, msg)
+			return
+		}
+	} else if req.Spec != "" || len(req.Headers) > 0 {
+		h.respondError(w, http.StatusBadRequest, "spec and headers are only supported for openapi and shim upstreams")
+		return
+	}
+
 	// SECU-10: Block dangerous environment variables.
 	if msg := validateEnvVars(req.Env); msg != "" {
 		h.respondError(w, http.StatusBadRequest, msg)
@@ -336,14 +532,76 @@ func (h *AdminAPIHandler) handleCreateUpstream(w http.ResponseWriter, r *http.Re
 		enabled = *req.Enabled
 	}
 
+	lazy := req.Lazy != nil && *req.Lazy
+	if lazy && upstreamType != upstream.UpstreamTypeStdio {
+		h.respondError(w, http.StatusBadRequest, "lazy is only supported for stdio upstreams")
+		return
+	}
+
+	framing := ""
+	if req.Framing != nil {
+		var msg string
+		if framing, msg = normalizeFraming(*req.Framing, upstreamType); msg != "" {
+			h.respondError(w, http.StatusBadRequest, msg)
+			return
+		}
+	}
+
+	credentials, msg := credentialsFromRequest(req.Credentials, nil, upstreamType)
+	if msg != "" {
+		h.respondError(w, http.StatusBadRequest, msg)
+		return
+	}
+
 	u := &upstream.Upstream{
 		Name:    strings.TrimSpace(req.Name),
 		Type:    upstreamType,
 		Command: req.Command,
 		Args:    req.Args,
 		URL:     req.URL,
+		Spec:    req.Spec,
+		Headers: req.Headers,
 		Env:     req.Env,
 		Enabled: enabled,
+		Lazy:    lazy,
+		Framing: framing,
+
+		Credentials: credentials,
+	}
+	if req.ToolPrefix != nil {
+		if *req.ToolPrefix != "" {
+			if err := upstream.ValidateToolPrefix(*req.ToolPrefix); err != nil {
+				h.respondError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+		u.ToolPrefix = *req.ToolPrefix
+	}
+	if req.ToolInclude != nil {
+		u.ToolInclude = *req.ToolInclude
+	}
+	if req.ToolExclude != nil {
+		u.ToolExclude = *req.ToolExclude
+	}
+	if err := upstream.ValidateToolFilters(u.ToolInclude, u.ToolExclude); err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Reverse upstreams get a registration token, shown once in the response.
+	var registrationToken string
+	if upstreamType == upstream.UpstreamTypeReverse {
+		token, hash, err := upstream.NewRegistrationToken()
+		if err != nil {
+			h.internalError(w, "failed to generate registration token", err)
+			return
+		}
+		registrationToken, u.RegistrationTokenHash = token, hash
+	}
+
+	findings, ok := h.checkUpstreamSecrets(w, u, req.ConvertSecrets)
+	if !ok {
+		return
 	}
 
 	created, err := h.upstreamService.Add(ctx, u)
@@ -387,7 +645,10 @@ func (h *AdminAPIHandler) handleCreateUpstream(w http.ResponseWriter, r *http.Re
 		toolCount = len(h.toolCache.GetToolsByUpstream(created.ID))
 	}
 
-	h.respondJSON(w, http.StatusCreated, toUpstreamResponse(created, status, lastError, toolCount))
+	resp := toUpstreamResponse(created, status, lastError, toolCount)
+	resp.SecretFindings = findings
+	resp.RegistrationToken = registrationToken
+	h.respondJSON(w, http.StatusCreated, resp)
 }
 
 // handleUpdateUpstream updates an existing upstream's configuration.
@@ -413,6 +674,29 @@ func (h *AdminAPIHandler) handleUpdateUpstream(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	drainTimeout := defaultDrainTimeout
+	if req.DrainTimeout != "" {
+		d, err := time.ParseDuration(req.DrainTimeout)
+		if err != nil || d <= 0 || d > maxDrainTimeout {
+			h.respondError(w, http.StatusBadRequest, "drain_timeout must be a duration between 0 and "+maxDrainTimeout.String())
+			return
+		}
+		drainTimeout = d
+	}
+	if req.Drain && h.upstreamManager != nil {
+		if _, draining := h.upstreamManager.DrainStatus(id); draining {
+			h.respondError(w, http.StatusConflict, "upstream is already draining")
+			return
+		}
+	}
+
+	if existing.Type == upstream.UpstreamTypeReverse {
+		if msg := validateReverseRequest(&req); msg != "" {
+			h.respondError(w, http.StatusBadRequest, msg)
+			return
+		}
+	}
+
 	// Build updated upstream, preserving type (immutable).
 	name := strings.TrimSpace(req.Name)
 	if name == "" {
@@ -436,13 +720,33 @@ func (h *AdminAPIHandler) handleUpdateUpstream(w http.ResponseWriter, r *http.Re
 	}
 
 	// SECU-09: Validate URL scheme on update too.
-	if existing.Type == upstream.UpstreamTypeHTTP && req.URL != "" {
+	if (existing.Type == upstream.UpstreamTypeHTTP || existing.Type == upstream.UpstreamTypeSSE ||
+		existing.Type == upstream.UpstreamTypeOpenAPI || existing.Type == upstream.UpstreamTypeShim) && req.URL != "" {
 		if msg := validateUpstreamURL(req.URL); msg != "" {
 			h.respondError(w, http.StatusBadRequest, msg)
 			return
 		}
 	}
 
+	spec := req.Spec
+	if spec == "" {
+		spec = existing.Spec
+	}
+	headers := restoreRedacted(req.Headers, existing.Headers)
+	if existing.Type == upstream.UpstreamTypeOpenAPI || existing.Type == upstream.UpstreamTypeShim {
+		if msg := validateOpenAPISpec(spec); msg != "" {
+			h.respondError(w, http.StatusBadRequest, msg)
+			return
+		}
+		if msg := validateOpenAPIHeaders(headers); msg != "" {
+			h.respondError(w, http.StatusBadRequest, msg)
+			return
+		}
+	} else if spec != "" || len(headers) > 0 {
+		h.respondError(w, http.StatusBadRequest, "spec and headers are only supported for openapi and shim upstreams")
+		return
+	}
+
 	// SECU-10: Block dangerous environment variables on update.
 	if req.Env != nil {
 		if msg := validateEnvVars(req.Env); msg != "" {
@@ -456,24 +760,30 @@ func (h *AdminAPIHandler) handleUpdateUpstream(w http.ResponseWriter, r *http.Re
 		enabled = *req.Enabled
 	}
 
-	env := req.Env
-	if env == nil {
-		env = existing.Env
-	} else if existing.Env != nil {
-		// Restore redacted values: the API returns "***" for env values
-		// (see redactEnvValues). When the frontend sends "***" back on edit,
-		// preserve the original value to prevent data corruption.
-		merged := make(map[string]string, len(env))
-		for k, v := range env {
-			if v == "***" {
-				if orig, ok := existing.Env[k]; ok {
-					merged[k] = orig
-					continue
-				}
-			}
-			merged[k] = v
+	env := restoreRedacted(req.Env, existing.Env)
+
+	lazy := existing.Lazy
+	if req.Lazy != nil {
+		lazy = *req.Lazy
+	}
+	if lazy && existing.Type != upstream.UpstreamTypeStdio {
+		h.respondError(w, http.StatusBadRequest, "lazy is only supported for stdio upstreams")
+		return
+	}
+
+	framing := existing.Framing
+	if req.Framing != nil {
+		var msg string
+		if framing, msg = normalizeFraming(*req.Framing, existing.Type); msg != "" {
+			h.respondError(w, http.StatusBadRequest, msg)
+			return
 		}
-		env = merged
+	}
+
+	credentials, msg := credentialsFromRequest(req.Credentials, existing.Credentials, existing.Type)
+	if msg != "" {
+		h.respondError(w, http.StatusBadRequest, msg)
+		return
 	}
 
 	u := &upstream.Upstream{
@@ -482,8 +792,37 @@ func (h *AdminAPIHandler) handleUpdateUpstream(w http.ResponseWriter, r *http.Re
 		Command: command,
 		Args:    args,
 		URL:     req.URL,
+		Spec:    spec,
+		Headers: headers,
 		Env:     env,
 		Enabled: enabled,
+		Lazy:    lazy,
+		Framing: framing,
+		// The registration token is changed only by rotation.
+		RegistrationTokenHash: existing.RegistrationTokenHash,
+		Credentials:           credentials,
+		ToolPrefix:            existing.ToolPrefix,
+		ToolInclude:           existing.ToolInclude,
+		ToolExclude:           existing.ToolExclude,
+	}
+	if req.ToolPrefix != nil {
+		if *req.ToolPrefix != "" {
+			if err := upstream.ValidateToolPrefix(*req.ToolPrefix); err != nil {
+				h.respondError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+		u.ToolPrefix = *req.ToolPrefix
+	}
+	if req.ToolInclude != nil {
+		u.ToolInclude = *req.ToolInclude
+	}
+	if req.ToolExclude != nil {
+		u.ToolExclude = *req.ToolExclude
+	}
+	if err := upstream.ValidateToolFilters(u.ToolInclude, u.ToolExclude); err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
 	// If url not provided, preserve existing value.
@@ -491,6 +830,11 @@ func (h *AdminAPIHandler) handleUpdateUpstream(w http.ResponseWriter, r *http.Re
 		u.URL = existing.URL
 	}
 
+	findings, ok := h.checkUpstreamSecrets(w, u, req.ConvertSecrets)
+	if !ok {
+		return
+	}
+
 	updated, err := h.upstreamService.Update(ctx, id, u)
 	if err != nil {
 		if errors.Is(err, upstream.ErrUpstreamNotFound) {
@@ -506,20 +850,37 @@ func (h *AdminAPIHandler) handleUpdateUpstream(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	// Restart the upstream so the new config takes effect immediately.
-	if h.upstreamManager != nil {
-		if err := h.upstreamManager.Restart(ctx, id); err != nil {
-			h.logger.Warn("failed to restart upstream after update", "id", id, "error", err)
-		}
+	// A disabled reverse upstream no longer accepts its server.
+	if !enabled && h.reverseHub != nil {
+		h.reverseHub.Disconnect(id)
 	}
 
-	// Re-discover tools after restart (non-fatal).
-	if h.discoveryService != nil {
-		if _, discoverErr := h.discoveryService.DiscoverFromUpstream(ctx, id); discoverErr != nil {
-			h.logger.Warn("failed to re-discover tools after update", "id", id, "error", discoverErr)
+	// Drain the upstream in the background when asked to; otherwise restart
+	// it so the new config takes effect immediately.
+	var drained <-chan error
+	if req.Drain && h.upstreamManager != nil {
+		drained, err = h.upstreamManager.DrainAndRestart(id, drainTimeout)
+		if err != nil {
+			h.logger.Warn("failed to drain upstream after update, restarting", "id", id, "error", err)
 		}
-		if h.toolSecurityService != nil {
-			h.toolSecurityService.CheckIntegrityAndEmit(ctx)
+	}
+	if drained != nil {
+		go h.rediscoverAfterDrain(id, drained)
+	} else {
+		if h.upstreamManager != nil {
+			if err := h.upstreamManager.Restart(ctx, id); err != nil {
+				h.logger.Warn("failed to restart upstream after update", "id", id, "error", err)
+			}
+		}
+
+		// Re-discover tools after restart (non-fatal).
+		if h.discoveryService != nil {
+			if _, discoverErr := h.discoveryService.DiscoverFromUpstream(ctx, id); discoverErr != nil {
+				h.logger.Warn("failed to re-discover tools after update", "id", id, "error", discoverErr)
+			}
+			if h.toolSecurityService != nil {
+				h.toolSecurityService.CheckIntegrityAndEmit(ctx)
+			}
 		}
 	}
 
@@ -533,7 +894,40 @@ func (h *AdminAPIHandler) handleUpdateUpstream(w http.ResponseWriter, r *http.Re
 		toolCount = len(h.toolCache.GetToolsByUpstream(id))
 	}
 
-	h.respondJSON(w, http.StatusOK, toUpstreamResponse(updated, status, lastError, toolCount))
+	resp := toUpstreamResponse(updated, status, lastError, toolCount)
+	resp.SecretFindings = findings
+	if drained != nil {
+		if d, ok := h.upstreamManager.DrainStatus(id); ok {
+			resp.Drain = &d
+		}
+		h.respondJSON(w, http.StatusAccepted, resp)
+		return
+	}
+	h.respondJSON(w, http.StatusOK, resp)
+}
+
+// Drain timeouts of upstream updates.
+const (
+	defaultDrainTimeout = 30 * time.Second
+	maxDrainTimeout     = 10 * time.Minute
+)
+
+// rediscoverAfterDrain waits for a drained upstream to restart, then
+// re-discovers its tools like an immediate update does.
+func (h *AdminAPIHandler) rediscoverAfterDrain(id string, drained <-chan error) {
+	if err := <-drained; err != nil {
+		h.logger.Warn("failed to restart upstream after drain", "id", id, "error", err)
+		return
+	}
+	if h.discoveryService != nil {
+		ctx := context.Background()
+		if _, err := h.discoveryService.DiscoverFromUpstream(ctx, id); err != nil {
+			h.logger.Warn("failed to re-discover tools after drain", "id", id, "error", err)
+		}
+		if h.toolSecurityService != nil {
+			h.toolSecurityService.CheckIntegrityAndEmit(ctx)
+		}
+	}
 }
 
 // handleDeleteUpstream stops and removes an upstream.
@@ -582,6 +976,9 @@ func (h *AdminAPIHandler) handleDeleteUpstream(w http.ResponseWriter, r *http.Re
 		h.respondError(w, http.StatusInternalServerError, "failed to delete upstream")
 		return
 	}
+	if h.reverseHub != nil {
+		h.reverseHub.Disconnect(id)
+	}
 
 	// Auto-update baseline to exclude the removed upstream's tools.
 	if h.toolSecurityService != nil {
@@ -652,3 +1049,41 @@ func (h *AdminAPIHandler) handleRestartUpstream(w http.ResponseWriter, r *http.R
 		"message":    "upstream restarted",
 	})
 }
+
+// handleRotateRegistrationToken replaces the registration token of a
+// reverse upstream and closes the connections registered with the old one.
+// The new token is returned once.
+// POST /admin/api/upstreams/{id}/registration-token
+func (h *AdminAPIHandler) handleRotateRegistrationToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := h.pathParam(r, "id")
+
+	existing, err := h.upstreamService.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, upstream.ErrUpstreamNotFound) {
+			h.respondError(w, http.StatusNotFound, "upstream not found")
+			return
+		}
+		h.logger.Error("failed to get upstream for token rotation", "id", id, "error", err)
+		h.respondError(w, http.StatusInternalServerError, "failed to get upstream")
+		return
+	}
+	if existing.Type != upstream.UpstreamTypeReverse {
+		h.respondError(w, http.StatusBadRequest, "registration tokens are only supported for reverse upstreams")
+		return
+	}
+
+	token, err := h.upstreamService.RotateRegistrationToken(ctx, id)
+	if err != nil {
+		h.internalError(w, "failed to rotate registration token", err)
+		return
+	}
+	if h.reverseHub != nil {
+		h.reverseHub.Disconnect(id)
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]string{
+		"id":                 id,
+		"registration_token": token,
+	})
+}
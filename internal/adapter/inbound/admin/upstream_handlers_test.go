@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -11,7 +12,9 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/memory"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
@@ -241,6 +244,213 @@ func TestHandleCreateUpstream_HTTP(t *testing.T) {
 	}
 }
 
+func TestHandleCreateUpstream_OpenAPI(t *testing.T) {
+	env := setupUpstreamTestEnv(t)
+
+	rec := env.doRequest(t, "POST", "/admin/api/upstreams", upstreamRequest{
+		Name:    "petstore",
+		Type:    "openapi",
+		Spec:    "/etc/sentinelgate/petstore.yaml",
+		Headers: map[string]string{"X-Api-Key": "${env:PETSTORE_KEY}", "X-Tenant": "acme"},
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST openapi upstream status = %d, want %d (body=%s)", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+	var result upstreamResponse
+	decodeUpstreamJSON(t, rec, &result)
+	if result.Type != "openapi" || result.Spec != "/etc/sentinelgate/petstore.yaml" {
+		t.Errorf("response = %+v", result)
+	}
+	// Header values are redacted like env values; references are shown.
+	if result.Headers["X-Tenant"] != "***" || result.Headers["X-Api-Key"] != "${env:PETSTORE_KEY}" {
+		t.Errorf("response Headers = %v", result.Headers)
+	}
+
+	// Sending a redacted value back on update keeps the stored value.
+	rec = env.doRequest(t, "PUT", "/admin/api/upstreams/"+result.ID, upstreamRequest{
+		Headers: map[string]string{"X-Tenant": "***", "X-Region": "eu"},
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT openapi upstream status = %d, want %d (body=%s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	stored, err := env.upstreamService.Get(context.Background(), result.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Headers sent on update replace the stored set.
+	if stored.Headers["X-Tenant"] != "acme" || stored.Headers["X-Region"] != "eu" || len(stored.Headers) != 2 {
+		t.Errorf("stored Headers = %v", stored.Headers)
+	}
+	if stored.Spec != "/etc/sentinelgate/petstore.yaml" {
+		t.Errorf("stored Spec = %q, want it preserved", stored.Spec)
+	}
+
+	bad := []struct {
+		name string
+		req  upstreamRequest
+	}{
+		{"missing spec", upstreamRequest{Name: "a", Type: "openapi"}},
+		{"spec scheme", upstreamRequest{Name: "b", Type: "openapi", Spec: "file:///etc/passwd"}},
+		{"spec traversal", upstreamRequest{Name: "c", Type: "openapi", Spec: "../../secret.yaml"}},
+		{"reserved header", upstreamRequest{Name: "d", Type: "openapi", Spec: "api.yaml", Headers: map[string]string{"Host": "evil"}}},
+		{"invalid header", upstreamRequest{Name: "e", Type: "openapi", Spec: "api.yaml", Headers: map[string]string{"Bad Header": "x"}}},
+		{"spec on stdio", upstreamRequest{Name: "f", Type: "stdio", Command: "/usr/bin/echo", Spec: "api.yaml"}},
+	}
+	for _, tt := range bad {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := env.doRequest(t, "POST", "/admin/api/upstreams", tt.req)
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d (body=%s)", rec.Code, http.StatusBadRequest, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandleCreateUpstream_Credentials(t *testing.T) {
+	env := setupUpstreamTestEnv(t)
+
+	rec := env.doRequest(t, "POST", "/admin/api/upstreams", upstreamRequest{
+		Name: "billing",
+		Type: "openapi",
+		Spec: "/etc/sentinelgate/billing.yaml",
+		Credentials: &upstreamCredentialsJSON{
+			Type: "oauth2_client_credentials", TokenURL: "https://auth.example.com/token",
+			ClientID: "gateway", ClientSecret: "plain-secret", Scopes: []string{"billing:read"},
+		},
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST upstream status = %d, want %d (body=%s)", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+	var result upstreamResponse
+	decodeUpstreamJSON(t, rec, &result)
+	if result.Credentials == nil || result.Credentials.ClientSecret != "***" || result.Credentials.ClientID != "gateway" {
+		t.Errorf("response Credentials = %+v, want masked secret", result.Credentials)
+	}
+
+	// The masked secret sent back keeps the stored one.
+	rec = env.doRequest(t, "PUT", "/admin/api/upstreams/"+result.ID, upstreamRequest{
+		Credentials: &upstreamCredentialsJSON{
+			Type: "oauth2_client_credentials", TokenURL: "https://auth.example.com/token",
+			ClientID: "gateway-2", ClientSecret: "***",
+		},
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT upstream status = %d, want %d (body=%s)", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	stored, err := env.upstreamService.Get(context.Background(), result.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Credentials == nil || stored.Credentials.ClientSecret != "plain-secret" || stored.Credentials.ClientID != "gateway-2" {
+		t.Errorf("stored Credentials = %+v", stored.Credentials)
+	}
+
+	// Omitted credentials are kept; an empty type removes them.
+	rec = env.doRequest(t, "PUT", "/admin/api/upstreams/"+result.ID, upstreamRequest{Name: "billing-api"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT upstream status = %d (body=%s)", rec.Code, rec.Body.String())
+	}
+	if stored, _ = env.upstreamService.Get(context.Background(), result.ID); stored.Credentials == nil {
+		t.Error("credentials were dropped by an update without them")
+	}
+	rec = env.doRequest(t, "PUT", "/admin/api/upstreams/"+result.ID, upstreamRequest{Credentials: &upstreamCredentialsJSON{}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT upstream status = %d (body=%s)", rec.Code, rec.Body.String())
+	}
+	if stored, _ = env.upstreamService.Get(context.Background(), result.ID); stored.Credentials != nil {
+		t.Errorf("stored Credentials = %+v, want removed", stored.Credentials)
+	}
+
+	bad := []struct {
+		name string
+		req  upstreamRequest
+	}{
+		{"stdio", upstreamRequest{Name: "a", Type: "stdio", Command: "/usr/bin/echo",
+			Credentials: &upstreamCredentialsJSON{Type: "bearer", Token: "t"}}},
+		{"missing token", upstreamRequest{Name: "b", Type: "openapi", Spec: "api.yaml",
+			Credentials: &upstreamCredentialsJSON{Type: "bearer"}}},
+		{"unknown type", upstreamRequest{Name: "c", Type: "openapi", Spec: "api.yaml",
+			Credentials: &upstreamCredentialsJSON{Type: "kerberos"}}},
+	}
+	for _, tt := range bad {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := env.doRequest(t, "POST", "/admin/api/upstreams", tt.req)
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d (body=%s)", rec.Code, http.StatusBadRequest, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandleCreateUpstream_Reverse(t *testing.T) {
+	env := setupUpstreamTestEnv(t)
+	ctx := context.Background()
+
+	rec := env.doRequest(t, "POST", "/admin/api/upstreams", upstreamRequest{Name: "on-prem", Type: "reverse"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST reverse upstream status = %d, want %d (body=%s)", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+	var result upstreamResponse
+	decodeUpstreamJSON(t, rec, &result)
+	if !strings.HasPrefix(result.RegistrationToken, "sgr_") {
+		t.Fatalf("registration_token = %q, want a sgr_ token", result.RegistrationToken)
+	}
+	if id, err := env.upstreamService.VerifyRegistrationToken(ctx, result.RegistrationToken); err != nil || id != result.ID {
+		t.Errorf("VerifyRegistrationToken = %q, %v; want %q", id, err, result.ID)
+	}
+
+	// The token is never shown again and survives updates.
+	rec = env.doRequest(t, "PUT", "/admin/api/upstreams/"+result.ID, upstreamRequest{Name: "on-prem-2"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT reverse upstream status = %d (body=%s)", rec.Code, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "registration_token") {
+		t.Errorf("update response exposes the token: %s", rec.Body.String())
+	}
+	if _, err := env.upstreamService.VerifyRegistrationToken(ctx, result.RegistrationToken); err != nil {
+		t.Errorf("token rejected after update: %v", err)
+	}
+
+	// Rotation replaces the token.
+	rec = env.doRequest(t, "POST", "/admin/api/upstreams/"+result.ID+"/registration-token", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("rotate status = %d (body=%s)", rec.Code, rec.Body.String())
+	}
+	var rotated map[string]string
+	decodeUpstreamJSON(t, rec, &rotated)
+	if _, err := env.upstreamService.VerifyRegistrationToken(ctx, result.RegistrationToken); !errors.Is(err, upstream.ErrInvalidRegistrationToken) {
+		t.Errorf("old token after rotation: err = %v, want ErrInvalidRegistrationToken", err)
+	}
+	if id, err := env.upstreamService.VerifyRegistrationToken(ctx, rotated["registration_token"]); err != nil || id != result.ID {
+		t.Errorf("new token: VerifyRegistrationToken = %q, %v", id, err)
+	}
+
+	// A disabled upstream no longer accepts its server.
+	rec = env.doRequest(t, "PUT", "/admin/api/upstreams/"+result.ID, map[string]interface{}{"enabled": false})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("disable status = %d (body=%s)", rec.Code, rec.Body.String())
+	}
+	if _, err := env.upstreamService.VerifyRegistrationToken(ctx, rotated["registration_token"]); !errors.Is(err, upstream.ErrInvalidRegistrationToken) {
+		t.Errorf("token of disabled upstream: err = %v, want ErrInvalidRegistrationToken", err)
+	}
+
+	stdio := env.addTestUpstream(t, "local")
+	rec = env.doRequest(t, "POST", "/admin/api/upstreams/"+stdio.ID+"/registration-token", nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("rotate on stdio upstream status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	for _, req := range []upstreamRequest{
+		{Name: "a", Type: "reverse", Command: "/usr/bin/echo"},
+		{Name: "b", Type: "reverse", URL: "https://example.com/mcp"},
+		{Name: "c", Type: "reverse", Env: map[string]string{"A": "b"}},
+	} {
+		if rec := env.doRequest(t, "POST", "/admin/api/upstreams", req); rec.Code != http.StatusBadRequest {
+			t.Errorf("POST %+v status = %d, want %d", req, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
+
 func TestHandleCreateUpstream_MissingName(t *testing.T) {
 	env := setupUpstreamTestEnv(t)
 
@@ -308,6 +518,89 @@ func TestHandleCreateUpstream_DangerousEnv(t *testing.T) {
 	}
 }
 
+func TestHandleCreateUpstream_Framing(t *testing.T) {
+	env := setupUpstreamTestEnv(t)
+
+	framing := "Content-Length"
+	rec := env.doRequest(t, "POST", "/admin/api/upstreams", upstreamRequest{
+		Name:    "lsp-style",
+		Type:    "stdio",
+		Command: "/usr/bin/echo",
+		Framing: &framing,
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST framed upstream status = %d, want %d (body=%s)", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+	var result upstreamResponse
+	decodeUpstreamJSON(t, rec, &result)
+	if result.Framing != "content-length" {
+		t.Errorf("response Framing = %q, want %q", result.Framing, "content-length")
+	}
+
+	bad := "xml"
+	rec = env.doRequest(t, "POST", "/admin/api/upstreams", upstreamRequest{
+		Name:    "bad-framing",
+		Type:    "stdio",
+		Command: "/usr/bin/echo",
+		Framing: &bad,
+	})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("POST bad framing status = %d, want %d (body=%s)", rec.Code, http.StatusBadRequest, rec.Body.String())
+	}
+}
+
+func TestHandleCreateUpstream_SecretDetection(t *testing.T) {
+	env := setupUpstreamTestEnv(t)
+	req := upstreamRequest{
+		Name:    "github",
+		Type:    "stdio",
+		Command: "/usr/bin/echo",
+		Env:     map[string]string{"GITHUB_TOKEN": "ghp_" + strings.Repeat("a1B2", 9)},
+	}
+
+	// Default mode (warn): saved, findings reported.
+	rec := env.doRequest(t, "POST", "/admin/api/upstreams", req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("warn mode status = %d, want 201 (body=%s)", rec.Code, rec.Body.String())
+	}
+	var warned upstreamResponse
+	decodeUpstreamJSON(t, rec, &warned)
+	if len(warned.SecretFindings) != 1 || warned.SecretFindings[0].Converted || warned.SecretFindings[0].EnvVar != "GITHUB_TOKEN" {
+		t.Errorf("findings = %+v, want one unconverted GITHUB_TOKEN finding", warned.SecretFindings)
+	}
+
+	// Block mode: rejected unless converted.
+	WithUpstreamSecretDetection(SecretDetectionBlock)(env.handler)
+	req.Name = "github-2"
+	rec = env.doRequest(t, "POST", "/admin/api/upstreams", req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("block mode status = %d, want 400 (body=%s)", rec.Code, rec.Body.String())
+	}
+	var blocked secretsBlockedResponse
+	decodeUpstreamJSON(t, rec, &blocked)
+	if len(blocked.SecretFindings) != 1 || strings.Contains(rec.Body.String(), "ghp_") {
+		t.Errorf("blocked response = %+v, want one finding without the secret value", blocked)
+	}
+
+	req.ConvertSecrets = true
+	rec = env.doRequest(t, "POST", "/admin/api/upstreams", req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("convert status = %d, want 201 (body=%s)", rec.Code, rec.Body.String())
+	}
+	var converted upstreamResponse
+	decodeUpstreamJSON(t, rec, &converted)
+	if converted.Env["GITHUB_TOKEN"] != "${env:GITHUB_2_GITHUB_TOKEN}" || !converted.SecretFindings[0].Converted {
+		t.Errorf("converted = env %v findings %+v, want a reference", converted.Env, converted.SecretFindings)
+	}
+	stored, err := env.upstreamService.Get(context.Background(), converted.ID)
+	if err != nil {
+		t.Fatalf("get upstream: %v", err)
+	}
+	if strings.Contains(stored.Env["GITHUB_TOKEN"], "ghp_") {
+		t.Error("plaintext secret was stored after conversion")
+	}
+}
+
 func TestHandleCreateUpstream_DuplicateName(t *testing.T) {
 	env := setupUpstreamTestEnv(t)
 
@@ -422,6 +715,67 @@ func TestHandleUpdateUpstream_PathTraversalInCommand(t *testing.T) {
 	}
 }
 
+func TestHandleUpdateUpstream_Drain(t *testing.T) {
+	env := setupUpstreamTestEnv(t)
+	created := env.addTestUpstream(t, "drained")
+	ctx := context.Background()
+	_ = env.upstreamManager.Start(ctx, created.ID)
+
+	end, err := env.upstreamManager.BeginCall(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("BeginCall: %v", err)
+	}
+	defer end()
+
+	rec := env.doRequest(t, "PUT", "/admin/api/upstreams/"+created.ID, upstreamRequest{
+		Command: "/usr/bin/cat", Drain: true, DrainTimeout: "1m",
+	})
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("PUT with drain status = %d, want %d (body=%s)", rec.Code, http.StatusAccepted, rec.Body.String())
+	}
+	var result upstreamResponse
+	decodeUpstreamJSON(t, rec, &result)
+	if result.Drain == nil || result.Drain.InFlight != 1 || result.Command != "/usr/bin/cat" {
+		t.Fatalf("response = %+v, want the new command and one call in flight", result)
+	}
+
+	rec = env.doRequest(t, "GET", "/admin/api/upstreams", nil)
+	var list []upstreamResponse
+	decodeUpstreamJSON(t, rec, &list)
+	if len(list) != 1 || list[0].Drain == nil {
+		t.Fatalf("list = %+v, want the drain progress", list)
+	}
+
+	rec = env.doRequest(t, "PUT", "/admin/api/upstreams/"+created.ID, upstreamRequest{Drain: true})
+	if rec.Code != http.StatusConflict {
+		t.Errorf("PUT while draining status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+
+	end()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, draining := env.upstreamManager.DrainStatus(created.ID); !draining {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("drain did not finish after the last call ended")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestHandleUpdateUpstream_InvalidDrainTimeout(t *testing.T) {
+	env := setupUpstreamTestEnv(t)
+	created := env.addTestUpstream(t, "drained")
+
+	for _, timeout := range []string{"soon", "-1s", "1h"} {
+		rec := env.doRequest(t, "PUT", "/admin/api/upstreams/"+created.ID, upstreamRequest{Drain: true, DrainTimeout: timeout})
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("drain_timeout %q status = %d, want %d", timeout, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
+
 // --- Delete Upstream ---
 
 func TestHandleDeleteUpstream(t *testing.T) {
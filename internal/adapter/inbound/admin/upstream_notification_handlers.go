@@ -0,0 +1,34 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/proxy"
+)
+
+// upstreamNotificationsResponse is the JSON body of
+// GET /admin/api/upstream-notifications.
+type upstreamNotificationsResponse struct {
+	Rules         []proxy.NotificationRule  `json:"rules"`
+	Notifications []proxy.NotificationStats `json:"notifications"`
+}
+
+// SetNotificationPolicy wires the policy delivering upstream notifications.
+func (h *AdminAPIHandler) SetNotificationPolicy(p *proxy.NotificationPolicy) {
+	h.notificationPolicy = p
+}
+
+// handleGetUpstreamNotifications returns the configured notification rules
+// and how often each upstream's notifications were forwarded, sent to the
+// latest connection or dropped.
+// GET /admin/api/upstream-notifications
+func (h *AdminAPIHandler) handleGetUpstreamNotifications(w http.ResponseWriter, r *http.Request) {
+	if h.notificationPolicy == nil {
+		h.respondError(w, http.StatusServiceUnavailable, "notification policy not available")
+		return
+	}
+	h.respondJSON(w, http.StatusOK, upstreamNotificationsResponse{
+		Rules:         h.notificationPolicy.Rules(),
+		Notifications: h.notificationPolicy.Stats(),
+	})
+}
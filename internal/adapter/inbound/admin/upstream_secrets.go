@@ -0,0 +1,94 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/upstream"
+)
+
+// Secret detection modes for upstreams saved through the admin API
+// (upstream.secret_detection).
+const (
+	SecretDetectionOff   = "off"
+	SecretDetectionWarn  = "warn"
+	SecretDetectionBlock = "block"
+)
+
+// WithUpstreamSecretDetection sets how plaintext secrets in upstream args,
+// env values and URLs are handled on save: off, warn (default) or block.
+func WithUpstreamSecretDetection(mode string) AdminAPIOption {
+	return func(h *AdminAPIHandler) { h.secretDetection = mode }
+}
+
+// secretFindingResponse describes a plaintext secret found in an upstream.
+// The secret value itself is never returned.
+type secretFindingResponse struct {
+	Field string `json:"field"`
+	Key   string `json:"key"`
+	Kind  string `json:"kind"`
+	// EnvVar and Reference are the suggested (or, when Converted, applied)
+	// gateway environment variable and the reference that replaces the value.
+	EnvVar    string `json:"env_var,omitempty"`
+	Reference string `json:"reference,omitempty"`
+	Converted bool   `json:"converted"`
+}
+
+// secretsBlockedResponse is the 400 body returned when block mode rejects an
+// upstream with plaintext secrets.
+type secretsBlockedResponse struct {
+	Error          string                  `json:"error"`
+	SecretFindings []secretFindingResponse `json:"secret_findings"`
+}
+
+// checkUpstreamSecrets runs secret detection on u before it is saved. With
+// convert, detected secrets are replaced in u with ${env:NAME} references.
+// Returns the findings to report, or false after writing a 400 response when
+// block mode rejects the upstream.
+func (h *AdminAPIHandler) checkUpstreamSecrets(w http.ResponseWriter, u *upstream.Upstream, convert bool) ([]secretFindingResponse, bool) {
+	mode := h.secretDetection
+	if mode == "" {
+		mode = SecretDetectionWarn
+	}
+	if mode == SecretDetectionOff && !convert {
+		return nil, true
+	}
+
+	var findings []upstream.SecretFinding
+	if convert {
+		findings = upstream.ConvertSecrets(u)
+	} else {
+		findings = upstream.DetectSecrets(u)
+	}
+	if len(findings) == 0 {
+		return nil, true
+	}
+
+	resp := make([]secretFindingResponse, 0, len(findings))
+	unconverted := 0
+	for _, f := range findings {
+		converted := convert && f.EnvVar != ""
+		if !converted {
+			unconverted++
+		}
+		resp = append(resp, secretFindingResponse{
+			Field:     f.Field,
+			Key:       f.Key,
+			Kind:      f.Kind,
+			EnvVar:    f.EnvVar,
+			Reference: f.Reference(),
+			Converted: converted,
+		})
+		// SECURITY: log where the secret is, never its value.
+		h.logger.Warn("plaintext secret in upstream configuration",
+			"upstream", u.Name, "field", f.Field, "key", f.Key, "kind", f.Kind, "converted", converted)
+	}
+
+	if mode == SecretDetectionBlock && unconverted > 0 {
+		h.respondJSON(w, http.StatusBadRequest, secretsBlockedResponse{
+			Error:          "upstream configuration contains plaintext secrets; set convert_secrets to replace them with ${env:NAME} references",
+			SecretFindings: resp,
+		})
+		return nil, false
+	}
+	return resp, true
+}
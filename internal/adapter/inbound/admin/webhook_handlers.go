@@ -0,0 +1,58 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/event"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+// SetWebhookService sets the webhook service whose endpoints and delivery
+// logs are exposed by the admin API.
+func (h *AdminAPIHandler) SetWebhookService(s *service.WebhookService) {
+	h.webhookService = s
+}
+
+// handleListWebhooks returns the configured webhook endpoints with their
+// delivery counters. Secrets are never returned.
+// GET /admin/api/webhooks
+func (h *AdminAPIHandler) handleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	if h.webhookService == nil {
+		h.respondJSON(w, http.StatusOK, []service.WebhookEndpointStatus{})
+		return
+	}
+	h.respondJSON(w, http.StatusOK, h.webhookService.Endpoints())
+}
+
+// handleWebhookDeliveries returns the recent deliveries of one endpoint,
+// newest first, including failed attempts and retry counts.
+// GET /admin/api/webhooks/{name}/deliveries
+func (h *AdminAPIHandler) handleWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	if h.webhookService == nil {
+		h.respondError(w, http.StatusNotFound, "webhook endpoint not found")
+		return
+	}
+	deliveries, ok := h.webhookService.Deliveries(r.PathValue("name"))
+	if !ok {
+		h.respondError(w, http.StatusNotFound, "webhook endpoint not found")
+		return
+	}
+	h.respondJSON(w, http.StatusOK, deliveries)
+}
+
+// SetEventDispatcher sets the dispatcher whose sinks are exposed by the
+// admin API.
+func (h *AdminAPIHandler) SetEventDispatcher(d *event.Dispatcher) {
+	h.eventDispatcher = d
+}
+
+// handleListEventSinks returns the event sinks with their filters and
+// delivery counters.
+// GET /admin/api/events/sinks
+func (h *AdminAPIHandler) handleListEventSinks(w http.ResponseWriter, r *http.Request) {
+	if h.eventDispatcher == nil {
+		h.respondJSON(w, http.StatusOK, []event.SinkStatus{})
+		return
+	}
+	h.respondJSON(w, http.StatusOK, h.eventDispatcher.Sinks())
+}
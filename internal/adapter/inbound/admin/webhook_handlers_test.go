@@ -0,0 +1,47 @@
+package admin
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+func TestHandleWebhooks(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	h := NewAdminAPIHandler(WithAPILogger(logger))
+
+	rec := sloTestRequest(t, h, "/admin/api/webhooks")
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Errorf("without service: status = %d body = %s, want 200 []", rec.Code, rec.Body.String())
+	}
+
+	svc := service.NewWebhookService("https://user:pw@hooks.example.com/x", "0123456789abcdef0123456789abcdef", []string{"upstream.*"}, logger)
+	h.SetWebhookService(svc)
+
+	rec = sloTestRequest(t, h, "/admin/api/webhooks")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "0123456789abcdef") || strings.Contains(rec.Body.String(), "pw@") {
+		t.Errorf("response leaks secret or URL credentials: %s", rec.Body.String())
+	}
+	var endpoints []service.WebhookEndpointStatus
+	if err := json.NewDecoder(rec.Body).Decode(&endpoints); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(endpoints) != 1 || endpoints[0].Name != "default" || !endpoints[0].Signed {
+		t.Errorf("endpoints = %+v", endpoints)
+	}
+
+	if rec := sloTestRequest(t, h, "/admin/api/webhooks/default/deliveries"); rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Errorf("deliveries: status = %d body = %s", rec.Code, rec.Body.String())
+	}
+	if rec := sloTestRequest(t, h, "/admin/api/webhooks/nope/deliveries"); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown endpoint status = %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,132 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Classes of low-priority traffic shed under load.
+const (
+	ShedClassList         = "list"
+	ShedClassNotification = "notification"
+)
+
+// LoadShedder reports when the process is overloaded and low-priority
+// traffic should be refused. service.AdmissionController implements it.
+type LoadShedder interface {
+	Overloaded() bool
+	RetryAfter() time.Duration
+	RecordShed(class string)
+}
+
+// admissionContextKey carries the admission state in the request context.
+type admissionContextKey struct{}
+
+// admission is the load shedder and the metrics of one transport.
+type admission struct {
+	shedder LoadShedder
+	// metrics is set when the transport starts; server notifications can be
+	// forwarded before that.
+	metrics atomic.Pointer[Metrics]
+}
+
+// newAdmission creates the admission state for shedder.
+func newAdmission(shedder LoadShedder, metrics *Metrics) *admission {
+	a := &admission{shedder: shedder}
+	a.metrics.Store(metrics)
+	return a
+}
+
+// AdmissionMiddleware makes the shedder available to the MCP handler, which
+// refuses low-priority requests with 503 and Retry-After while the shedder
+// reports overload. Tool calls and other requests are never shed.
+func AdmissionMiddleware(shedder LoadShedder, metrics *Metrics) func(http.Handler) http.Handler {
+	return newAdmission(shedder, metrics).middleware
+}
+
+// middleware places a in the request context.
+func (a *admission) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), admissionContextKey{}, a)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// shedClass returns the shedding class of a JSON-RPC message, or "" when it
+// is never shed.
+func shedClass(method string, isNotification bool) string {
+	switch {
+	case isNotification && isNonEssentialNotification(method):
+		return ShedClassNotification
+	case isNotification:
+		return ""
+	}
+	switch method {
+	case "tools/list", "resources/list", "resources/templates/list", "prompts/list":
+		return ShedClassList
+	}
+	return ""
+}
+
+// isNonEssentialNotification reports whether a notification can be dropped
+// without breaking the session: progress and log messages. Cancellation,
+// initialization and list changes are always delivered.
+func isNonEssentialNotification(method string) bool {
+	return method == "notifications/progress" || method == "notifications/message"
+}
+
+// shedIfOverloaded answers 503 with Retry-After and reports true when the
+// request is low-priority and the process is overloaded.
+func shedIfOverloaded(w http.ResponseWriter, r *http.Request, method string, isNotification bool) bool {
+	retryAfter, shed := shedMessage(r.Context(), method, isNotification)
+	if !shed {
+		return false
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	writeJSONError(w, http.StatusServiceUnavailable, "Server overloaded, retry later")
+	return true
+}
+
+// shedMessage reports whether a message is low-priority and the process is
+// overloaded, counting it if so, with the seconds the client should wait.
+func shedMessage(ctx context.Context, method string, isNotification bool) (retryAfter int, shed bool) {
+	a, _ := ctx.Value(admissionContextKey{}).(*admission)
+	if a == nil || !a.shedder.Overloaded() {
+		return 0, false
+	}
+	class := shedClass(method, isNotification)
+	if class == "" {
+		return 0, false
+	}
+	a.record(class)
+	return max(int(math.Ceil(a.shedder.RetryAfter().Seconds())), 1), true
+}
+
+// record counts one shed message.
+func (a *admission) record(class string) {
+	a.shedder.RecordShed(class)
+	if m := a.metrics.Load(); m != nil {
+		m.RequestsShed.WithLabelValues(class).Inc()
+	}
+}
+
+// shedNotification reports whether a server-initiated notification should
+// be dropped instead of sent to clients, and counts it.
+func (a *admission) shedNotification(data []byte) bool {
+	if a == nil || !a.shedder.Overloaded() {
+		return false
+	}
+	var msg struct {
+		Method string `json:"method"`
+	}
+	if json.Unmarshal(data, &msg) != nil || !isNonEssentialNotification(msg.Method) {
+		return false
+	}
+	a.record(ShedClassNotification)
+	return true
+}
@@ -0,0 +1,118 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// fakeShedder is a LoadShedder with a fixed state.
+type fakeShedder struct {
+	overloaded bool
+	shed       map[string]int
+}
+
+func (f *fakeShedder) Overloaded() bool          { return f.overloaded }
+func (f *fakeShedder) RetryAfter() time.Duration { return 1500 * time.Millisecond }
+func (f *fakeShedder) RecordShed(class string) {
+	if f.shed == nil {
+		f.shed = make(map[string]int)
+	}
+	f.shed[class]++
+}
+
+func postThroughAdmission(t *testing.T, shedder LoadShedder, metrics *Metrics, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	handler := AdmissionMiddleware(shedder, metrics)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlePost(w, r, nil, nil, nil)
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdmission_ShedsListRequests(t *testing.T) {
+	shedder := &fakeShedder{overloaded: true}
+	metrics := NewMetrics(prometheus.NewRegistry())
+
+	rec := postThroughAdmission(t, shedder, metrics, `{"jsonrpc":"2.0","method":"tools/list","id":1}`)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, want 2", got)
+	}
+	if shedder.shed[ShedClassList] != 1 {
+		t.Errorf("shed = %v, want one list", shedder.shed)
+	}
+	if got := testutil.ToFloat64(metrics.RequestsShed.WithLabelValues(ShedClassList)); got != 1 {
+		t.Errorf("sentinelgate_requests_shed_total{class=list} = %v, want 1", got)
+	}
+
+	rec = postThroughAdmission(t, shedder, metrics, `{"jsonrpc":"2.0","method":"notifications/progress","params":{"progressToken":1,"progress":1}}`)
+	if rec.Code != http.StatusServiceUnavailable || shedder.shed[ShedClassNotification] != 1 {
+		t.Errorf("progress notification: status = %d, shed = %v", rec.Code, shedder.shed)
+	}
+}
+
+func TestAdmission_KeepsEssentialTraffic(t *testing.T) {
+	admitted := func(shedder LoadShedder, method string, isNotification bool) bool {
+		req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+		rec := httptest.NewRecorder()
+		var shed bool
+		AdmissionMiddleware(shedder, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			shed = shedIfOverloaded(w, r, method, isNotification)
+		})).ServeHTTP(rec, req)
+		return !shed && rec.Code == http.StatusOK
+	}
+
+	overloaded := &fakeShedder{overloaded: true}
+	for _, tc := range []struct {
+		method         string
+		isNotification bool
+	}{
+		{"tools/call", false},
+		{"initialize", false},
+		{"resources/read", false},
+		{"notifications/cancelled", true},
+		{"notifications/initialized", true},
+	} {
+		if !admitted(overloaded, tc.method, tc.isNotification) {
+			t.Errorf("%s should be admitted while overloaded", tc.method)
+		}
+	}
+	if len(overloaded.shed) != 0 {
+		t.Errorf("shed = %v, want none", overloaded.shed)
+	}
+
+	if !admitted(&fakeShedder{overloaded: false}, "tools/list", false) {
+		t.Error("tools/list should be admitted without overload")
+	}
+}
+
+func TestAdmission_ShedNotification(t *testing.T) {
+	shedder := &fakeShedder{overloaded: true}
+	a := newAdmission(shedder, nil)
+
+	if !a.shedNotification([]byte(`{"jsonrpc":"2.0","method":"notifications/message","params":{}}`)) {
+		t.Error("log message notification should be shed")
+	}
+	if a.shedNotification([]byte(`{"jsonrpc":"2.0","method":"notifications/tools/list_changed"}`)) {
+		t.Error("list_changed notification should be delivered")
+	}
+	shedder.overloaded = false
+	if a.shedNotification([]byte(`{"jsonrpc":"2.0","method":"notifications/progress"}`)) {
+		t.Error("nothing should be shed without overload")
+	}
+	var none *admission
+	if none.shedNotification([]byte(`{"method":"notifications/progress"}`)) {
+		t.Error("nil admission should never shed")
+	}
+}
@@ -0,0 +1,183 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Sentinel errors returned by bodyReader.read.
+var (
+	// errBodyTooLarge is returned when the body exceeds the configured limit,
+	// either up front (Content-Length) or while streaming (chunked bodies).
+	errBodyTooLarge = errors.New("request body too large")
+	// errBodyEmpty is returned when the body contains no JSON value at all.
+	errBodyEmpty = errors.New("empty request body")
+	// errBodyInvalidJSON is returned when the body is not exactly one JSON value.
+	errBodyInvalidJSON = errors.New("invalid JSON")
+	// errBodyRead is returned for transport-level read failures.
+	errBodyRead = errors.New("failed to read request body")
+)
+
+// bodyReader reads MCP POST bodies incrementally. The body is fed through a
+// streaming JSON tokenizer while it arrives, so oversized or malformed
+// payloads are rejected as soon as the offending byte is seen instead of
+// after the whole body has been read. Accepted bodies are still buffered in
+// full, because policies and scanners inspect the whole message: the memory
+// a request holds is bounded by the limit, not independent of the body size.
+// A nil *bodyReader uses the default limit and records no metrics.
+type bodyReader struct {
+	maxSize int64
+	metrics *Metrics
+}
+
+// limit returns the effective maximum body size in bytes.
+func (b *bodyReader) limit() int64 {
+	if b == nil || b.maxSize <= 0 {
+		return maxRequestBodySize
+	}
+	return b.maxSize
+}
+
+// reject increments the rejection counter for the given reason.
+func (b *bodyReader) reject(reason string) {
+	if b != nil && b.metrics != nil {
+		b.metrics.RequestBodyRejected.WithLabelValues(reason).Inc()
+	}
+}
+
+// read buffers the body, up to the limit, and returns it after validating
+// that it contains exactly one JSON value. The returned release func must be called once the caller
+// no longer holds the buffer; it keeps the in-flight memory gauge accurate.
+func (b *bodyReader) read(w http.ResponseWriter, r *http.Request) ([]byte, func(), error) {
+	release := func() {}
+	maxSize := b.limit()
+
+	// Early rejection: a declared Content-Length over the limit never needs
+	// to be read at all. Chunked bodies (ContentLength == -1) fall through
+	// to the streaming check below.
+	if r.ContentLength > maxSize {
+		b.reject("content_length")
+		return nil, release, errBodyTooLarge
+	}
+
+	buf := &meteredBuffer{}
+	if b != nil && b.metrics != nil {
+		buf.gauge = b.metrics.InflightBodyBytes
+	}
+	if r.ContentLength > 0 {
+		buf.Grow(int(r.ContentLength))
+	}
+	release = buf.release
+
+	limited := http.MaxBytesReader(w, r.Body, maxSize)
+	dec := json.NewDecoder(io.TeeReader(limited, buf))
+
+	depth := 0
+	values := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) && depth == 0 {
+				break
+			}
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				b.reject("streaming")
+				return nil, release, errBodyTooLarge
+			}
+			var syntaxErr *json.SyntaxError
+			if errors.As(err, &syntaxErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				b.reject("invalid_json")
+				return nil, release, errBodyInvalidJSON
+			}
+			return nil, release, errBodyRead
+		}
+		// A second top-level value (e.g. `{}{}`) is not valid single-message JSON.
+		if depth == 0 && values > 0 {
+			b.reject("invalid_json")
+			return nil, release, errBodyInvalidJSON
+		}
+		if delim, ok := tok.(json.Delim); ok {
+			switch delim {
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+			}
+		}
+		if depth == 0 {
+			values++
+		}
+	}
+
+	// Drain any trailing whitespace the decoder left unread so the size
+	// limit also covers it and Content-Length framing stays intact.
+	if _, err := io.Copy(io.Discard, limited); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			b.reject("streaming")
+			return nil, release, errBodyTooLarge
+		}
+		return nil, release, errBodyRead
+	}
+
+	if values == 0 {
+		if len(bytes.TrimSpace(buf.Bytes())) == 0 {
+			return nil, release, errBodyEmpty
+		}
+		b.reject("invalid_json")
+		return nil, release, errBodyInvalidJSON
+	}
+
+	if b != nil && b.metrics != nil {
+		b.metrics.RequestBodyBytes.Observe(float64(buf.Len()))
+	}
+	return buf.Bytes(), release, nil
+}
+
+// formatByteSize renders a byte limit for client-facing error messages.
+func formatByteSize(n int64) string {
+	switch {
+	case n >= 1<<20 && n%(1<<20) == 0:
+		return fmt.Sprintf("%dMB", n>>20)
+	case n >= 1<<10 && n%(1<<10) == 0:
+		return fmt.Sprintf("%dKB", n>>10)
+	default:
+		return fmt.Sprintf("%d bytes", n)
+	}
+}
+
+// meteredBuffer is a bytes.Buffer that mirrors its size into a gauge so the
+// memory held by in-flight POST bodies is observable.
+type meteredBuffer struct {
+	bytes.Buffer
+	gauge     gaugeAdder
+	accounted int
+}
+
+// gaugeAdder is the subset of prometheus.Gauge used by meteredBuffer.
+type gaugeAdder interface {
+	Add(float64)
+}
+
+// Write appends p to the buffer and updates the gauge.
+func (m *meteredBuffer) Write(p []byte) (int, error) {
+	n, err := m.Buffer.Write(p)
+	if m.gauge != nil && n > 0 {
+		m.gauge.Add(float64(n))
+		m.accounted += n
+	}
+	return n, err
+}
+
+// release subtracts everything this buffer added to the gauge.
+func (m *meteredBuffer) release() {
+	if m.gauge != nil && m.accounted > 0 {
+		m.gauge.Add(-float64(m.accounted))
+		m.accounted = 0
+	}
+}
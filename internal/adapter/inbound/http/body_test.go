@@ -0,0 +1,126 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// chunkedReader hides the length of the underlying reader so the request
+// is treated like a chunked body (ContentLength == -1).
+type chunkedReader struct{ r io.Reader }
+
+func (c chunkedReader) Read(p []byte) (int, error) { return c.r.Read(p) }
+
+func TestBodyReader_Valid(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := NewMetrics(reg)
+	b := &bodyReader{metrics: m}
+
+	payload := `{"jsonrpc":"2.0","method":"tools/list","id":1}` + "\n"
+	req := httptest.NewRequest("POST", "/mcp", strings.NewReader(payload))
+	rec := httptest.NewRecorder()
+
+	body, release, err := b.read(rec, req)
+	if err != nil {
+		t.Fatalf("read() error = %v", err)
+	}
+	if string(body) != payload {
+		t.Errorf("body = %q, want %q", body, payload)
+	}
+	if got := testutil.ToFloat64(m.InflightBodyBytes); got != float64(len(payload)) {
+		t.Errorf("inflight bytes = %v, want %d", got, len(payload))
+	}
+	release()
+	if got := testutil.ToFloat64(m.InflightBodyBytes); got != 0 {
+		t.Errorf("inflight bytes after release = %v, want 0", got)
+	}
+}
+
+func TestBodyReader_ContentLengthEarlyReject(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := NewMetrics(reg)
+	b := &bodyReader{maxSize: 1024, metrics: m}
+
+	req := httptest.NewRequest("POST", "/mcp", strings.NewReader(strings.Repeat("x", 2048)))
+	rec := httptest.NewRecorder()
+
+	_, release, err := b.read(rec, req)
+	defer release()
+	if !errors.Is(err, errBodyTooLarge) {
+		t.Fatalf("read() error = %v, want errBodyTooLarge", err)
+	}
+	if got := testutil.ToFloat64(m.RequestBodyRejected.WithLabelValues("content_length")); got != 1 {
+		t.Errorf("content_length rejections = %v, want 1", got)
+	}
+}
+
+func TestBodyReader_ChunkedOversized(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := NewMetrics(reg)
+	b := &bodyReader{maxSize: 1024, metrics: m}
+
+	payload := `{"jsonrpc":"2.0","method":"tools/call","params":{"arguments":{"data":"` +
+		strings.Repeat("a", 4096) + `"}},"id":1}`
+	req := httptest.NewRequest("POST", "/mcp", chunkedReader{strings.NewReader(payload)})
+	req.ContentLength = -1
+	rec := httptest.NewRecorder()
+
+	_, release, err := b.read(rec, req)
+	defer release()
+	if !errors.Is(err, errBodyTooLarge) {
+		t.Fatalf("read() error = %v, want errBodyTooLarge", err)
+	}
+	if got := testutil.ToFloat64(m.RequestBodyRejected.WithLabelValues("streaming")); got != 1 {
+		t.Errorf("streaming rejections = %v, want 1", got)
+	}
+}
+
+func TestBodyReader_InvalidBodies(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		wantErr error
+	}{
+		{"empty", "", errBodyEmpty},
+		{"whitespace", "  \n", errBodyEmpty},
+		{"malformed", `{"jsonrpc":`, errBodyInvalidJSON},
+		{"garbage", `not json`, errBodyInvalidJSON},
+		{"two values", `{}{}`, errBodyInvalidJSON},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/mcp", strings.NewReader(tt.payload))
+			rec := httptest.NewRecorder()
+
+			var b *bodyReader
+			_, release, err := b.read(rec, req)
+			defer release()
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("read() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFormatByteSize(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{1 << 20, "1MB"},
+		{10 << 20, "10MB"},
+		{4096, "4KB"},
+		{1500, "1500 bytes"},
+	}
+	for _, tt := range tests {
+		if got := formatByteSize(tt.n); got != tt.want {
+			t.Errorf("formatByteSize(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
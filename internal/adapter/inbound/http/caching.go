@@ -0,0 +1,64 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// catalogCacheControl makes clients revalidate every time: the catalog can
+// change at any moment and depends on the caller's roles.
+const catalogCacheControl = "private, no-cache"
+
+// isCatalogMethod reports whether method lists a catalog that clients poll
+// and can revalidate with If-None-Match.
+func isCatalogMethod(method string) bool {
+	switch method {
+	case "tools/list", "resources/list", "resources/templates/list", "prompts/list":
+		return true
+	}
+	return false
+}
+
+// catalogETag returns the entity tag of a successful catalog response, or ""
+// for errors. The tag is a hash of the result exactly as it is sent, after
+// every interceptor has filtered it and added _meta, so it never depends on
+// the request ID but changes with anything else in the body.
+func catalogETag(response []byte) string {
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+		Error  json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(response, &resp) != nil || len(resp.Error) > 0 || len(resp.Result) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(resp.Result)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header value matches etag,
+// using the weak comparison of RFC 9110.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// writeNotModified sets the caching headers of a catalog response and, when
+// the client already has it, answers 304 Not Modified. It reports whether
+// the response was written.
+func writeNotModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", catalogCacheControl)
+	if inm := r.Header.Get("If-None-Match"); inm == "" || !etagMatches(inm, etag) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
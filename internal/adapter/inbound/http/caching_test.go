@@ -0,0 +1,61 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCatalogETag(t *testing.T) {
+	a := catalogETag([]byte(`{"jsonrpc":"2.0","id":1,"result":{"resources":[]}}`))
+	b := catalogETag([]byte(`{"jsonrpc":"2.0","id":2,"result":{"resources":[]}}`))
+	if a == "" || a != b {
+		t.Errorf("ETags %q and %q should match for the same result", a, b)
+	}
+	if c := catalogETag([]byte(`{"jsonrpc":"2.0","id":1,"result":{"resources":[{"uri":"file:///a"}]}}`)); c == a {
+		t.Error("ETag unchanged for a different result")
+	}
+	tools := catalogETag([]byte(`{"jsonrpc":"2.0","id":1,"result":{"tools":[]}}`))
+	if withMeta := catalogETag([]byte(`{"jsonrpc":"2.0","id":1,"result":{"tools":[],"_meta":{"x":1}}}`)); withMeta == tools {
+		t.Error("ETag unchanged when _meta is added to the result")
+	}
+	if got := catalogETag([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32603,"message":"x"}}`)); got != "" {
+		t.Errorf("ETag = %q for an error response", got)
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{`"abc"`, true},
+		{`W/"abc"`, true},
+		{`"x", "abc"`, true},
+		{`*`, true},
+		{`"abcd"`, false},
+		{`abc`, false},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, `"abc"`); got != tt.want {
+			t.Errorf("etagMatches(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestWriteNotModified(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	rec := httptest.NewRecorder()
+	if writeNotModified(rec, req, `"abc"`) {
+		t.Fatal("304 written without If-None-Match")
+	}
+	if rec.Header().Get("ETag") != `"abc"` || rec.Header().Get("Cache-Control") != catalogCacheControl {
+		t.Errorf("headers = %v", rec.Header())
+	}
+
+	req.Header.Set("If-None-Match", `"abc"`)
+	rec = httptest.NewRecorder()
+	if !writeNotModified(rec, req, `"abc"`) || rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("status = %d, body = %q, want an empty 304", rec.Code, rec.Body.String())
+	}
+}
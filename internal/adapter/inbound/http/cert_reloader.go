@@ -0,0 +1,184 @@
+package http
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultCertReloadInterval is how often the certificate files are checked
+// for changes.
+const DefaultCertReloadInterval = time.Minute
+
+// CertInfo describes the certificate currently served.
+type CertInfo struct {
+	CertFile          string    `json:"cert_file"`
+	Subject           string    `json:"subject"`
+	Issuer            string    `json:"issuer"`
+	DNSNames          []string  `json:"dns_names,omitempty"`
+	NotBefore         time.Time `json:"not_before"`
+	NotAfter          time.Time `json:"not_after"`
+	FingerprintSHA256 string    `json:"fingerprint_sha256"`
+	LoadedAt          time.Time `json:"loaded_at"`
+	// LastError is the last failed reload, cleared by the next successful
+	// one. The previous certificate stays in use while it is set.
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+// fileStamp identifies a version of a file on disk.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// CertReloader serves a TLS certificate and key pair from disk and reloads
+// it when either file changes, e.g. after rotation by cert-manager or
+// certbot. Changes are found by comparing modification time and size, which
+// also covers files replaced through a symlink swap. A pair that fails to
+// load (e.g. the certificate was replaced but the key not yet) is retried on
+// the next check; until then the previous certificate is served.
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	logger   *slog.Logger
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	info      CertInfo
+	certStamp fileStamp
+	keyStamp  fileStamp
+}
+
+// NewCertReloader loads the certificate and key pair. It fails when the
+// initial pair cannot be loaded.
+func NewCertReloader(certFile, keyFile string, logger *slog.Logger) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile, logger: logger}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate returns the current certificate, for tls.Config.
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// Info returns a description of the current certificate.
+func (r *CertReloader) Info() CertInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	info := r.info
+	info.DNSNames = append([]string(nil), r.info.DNSNames...)
+	return info
+}
+
+// Reload loads the certificate and key pair from disk. On failure the
+// previous pair stays in use and the error is reported by Info.
+func (r *CertReloader) Reload() error {
+	certStamp, keyStamp, err := r.stamps()
+	if err == nil {
+		err = r.load(certStamp, keyStamp)
+	}
+	if err != nil {
+		r.mu.Lock()
+		now := time.Now().UTC()
+		r.info.LastError = err.Error()
+		r.info.LastErrorAt = &now
+		r.mu.Unlock()
+	}
+	return err
+}
+
+// load parses the pair and swaps it in.
+func (r *CertReloader) load(certStamp, keyStamp fileStamp) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load TLS certificate: %w", err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("parse TLS certificate: %w", err)
+	}
+	cert.Leaf = leaf
+	sum := sha256.Sum256(leaf.Raw)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.certStamp = certStamp
+	r.keyStamp = keyStamp
+	r.info = CertInfo{
+		CertFile:          r.certFile,
+		Subject:           leaf.Subject.String(),
+		Issuer:            leaf.Issuer.String(),
+		DNSNames:          leaf.DNSNames,
+		NotBefore:         leaf.NotBefore.UTC(),
+		NotAfter:          leaf.NotAfter.UTC(),
+		FingerprintSHA256: hex.EncodeToString(sum[:]),
+		LoadedAt:          time.Now().UTC(),
+	}
+	return nil
+}
+
+// stamps returns the current version of both files.
+func (r *CertReloader) stamps() (fileStamp, fileStamp, error) {
+	cs, err := os.Stat(r.certFile)
+	if err != nil {
+		return fileStamp{}, fileStamp{}, fmt.Errorf("stat TLS certificate: %w", err)
+	}
+	ks, err := os.Stat(r.keyFile)
+	if err != nil {
+		return fileStamp{}, fileStamp{}, fmt.Errorf("stat TLS key: %w", err)
+	}
+	return fileStamp{cs.ModTime(), cs.Size()}, fileStamp{ks.ModTime(), ks.Size()}, nil
+}
+
+// changed reports whether either file differs from the loaded pair.
+func (r *CertReloader) changed() bool {
+	certStamp, keyStamp, err := r.stamps()
+	if err != nil {
+		// Missing mid-rotation; try again on the next check.
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return certStamp != r.certStamp || keyStamp != r.keyStamp
+}
+
+// Run checks the files every interval and reloads the pair when either
+// changed, until ctx is cancelled.
+func (r *CertReloader) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultCertReloadInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !r.changed() {
+				continue
+			}
+			if err := r.Reload(); err != nil {
+				r.logger.Warn("TLS certificate reload failed, keeping the current certificate", "error", err)
+				continue
+			}
+			info := r.Info()
+			r.logger.Info("TLS certificate reloaded", "subject", info.Subject,
+				"not_after", info.NotAfter, "fingerprint_sha256", info.FingerprintSHA256)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package http
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"log/slog"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestCert writes a self-signed certificate and key for cn, with the
+// given modification time.
+func writeTestCert(t *testing.T, certFile, keyFile, cn string, mtime time.Time) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     []string{cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{certFile, keyFile} {
+		if err := os.Chtimes(f, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCertReloader_ReloadsRotatedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	start := time.Now().Add(-time.Hour)
+	writeTestCert(t, certFile, keyFile, "old.example.com", start)
+
+	r, err := NewCertReloader(certFile, keyFile, slog.Default())
+	if err != nil {
+		t.Fatalf("NewCertReloader: %v", err)
+	}
+	before := r.Info()
+	if before.Subject != "CN=old.example.com" || before.FingerprintSHA256 == "" {
+		t.Fatalf("info = %+v", before)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Run(ctx, 10*time.Millisecond)
+
+	writeTestCert(t, certFile, keyFile, "new.example.com", start.Add(time.Minute))
+	deadline := time.Now().Add(2 * time.Second)
+	for r.Info().Subject != "CN=new.example.com" {
+		if time.Now().After(deadline) {
+			t.Fatal("rotated certificate was not picked up")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	after := r.Info()
+	if after.FingerprintSHA256 == before.FingerprintSHA256 {
+		t.Error("fingerprint should change after rotation")
+	}
+	cert, err := r.GetCertificate(nil)
+	if err != nil || cert.Leaf.Subject.CommonName != "new.example.com" {
+		t.Errorf("GetCertificate = %v, %v", cert, err)
+	}
+}
+
+func TestCertReloader_KeepsCertificateOnBadPair(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	writeTestCert(t, certFile, keyFile, "good.example.com", time.Now().Add(-time.Hour))
+
+	r, err := NewCertReloader(certFile, keyFile, slog.Default())
+	if err != nil {
+		t.Fatalf("NewCertReloader: %v", err)
+	}
+
+	// Certificate replaced but key not yet: the pair no longer matches.
+	otherDir := t.TempDir()
+	writeTestCert(t, certFile, filepath.Join(otherDir, "key.pem"), "next.example.com", time.Now())
+	if err := r.Reload(); err == nil {
+		t.Fatal("Reload should fail for a mismatched pair")
+	}
+
+	info := r.Info()
+	if info.Subject != "CN=good.example.com" || info.LastError == "" || info.LastErrorAt == nil {
+		t.Errorf("info = %+v", info)
+	}
+	cert, _ := r.GetCertificate(nil)
+	if cert.Leaf.Subject.CommonName != "good.example.com" {
+		t.Errorf("served certificate = %q, want the previous one", cert.Leaf.Subject.CommonName)
+	}
+
+	if _, err := NewCertReloader(certFile, filepath.Join(dir, "missing.pem"), slog.Default()); err == nil {
+		t.Error("NewCertReloader should fail when the initial pair cannot be loaded")
+	}
+}
@@ -0,0 +1,186 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// clientRequestTimeout bounds the wait for the client's response to a
+// request from an upstream; sampling may involve the user, so it is long.
+const clientRequestTimeout = 5 * time.Minute
+
+// clientRequestIDPrefix marks the IDs the gateway gives to requests it
+// sends to clients, so they cannot collide with the upstream's own IDs.
+const clientRequestIDPrefix = "sg-"
+
+var (
+	// errClientUnreachable is returned when the session has no open stream.
+	errClientUnreachable = errors.New("client session has no open stream")
+	// errClientGone is returned when the session ends before the response.
+	errClientGone = errors.New("client session terminated")
+	// errClientTimeout is returned when the client does not respond in time.
+	errClientTimeout = errors.New("timeout waiting for client response")
+)
+
+// pendingClientRequest is a request sent to a client awaiting its response.
+type pendingClientRequest struct {
+	sessionID  string
+	originalID json.RawMessage
+	done       chan []byte // receives the response; closed when the session ends
+}
+
+// clientRequests tracks the requests the gateway sent to clients over their
+// SSE streams (sampling/createMessage, elicitation/create) until the
+// clients POST the responses.
+type clientRequests struct {
+	seq     atomic.Uint64
+	mu      sync.Mutex
+	pending map[string]*pendingClientRequest // gateway request ID → request
+}
+
+func newClientRequests() *clientRequests {
+	return &clientRequests{pending: make(map[string]*pendingClientRequest)}
+}
+
+// add registers a request and returns its gateway ID.
+func (c *clientRequests) add(sessionID string, originalID json.RawMessage) (string, *pendingClientRequest) {
+	id := clientRequestIDPrefix + strconv.FormatUint(c.seq.Add(1), 10)
+	p := &pendingClientRequest{sessionID: sessionID, originalID: originalID, done: make(chan []byte, 1)}
+	c.mu.Lock()
+	c.pending[id] = p
+	c.mu.Unlock()
+	return id, p
+}
+
+// remove forgets a request.
+func (c *clientRequests) remove(id string) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+// resolve hands a response of sessionID to the request waiting for it and
+// reports whether there was one.
+func (c *clientRequests) resolve(sessionID, id string, resp []byte) bool {
+	c.mu.Lock()
+	p, ok := c.pending[id]
+	if ok && p.sessionID == sessionID {
+		delete(c.pending, id)
+	}
+	c.mu.Unlock()
+	if !ok || p.sessionID != sessionID {
+		return false
+	}
+	p.done <- resp
+	return true
+}
+
+// dropSession fails the requests waiting for a terminated session.
+func (c *clientRequests) dropSession(sessionID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, p := range c.pending {
+		if p.sessionID == sessionID {
+			delete(c.pending, id)
+			close(p.done)
+		}
+	}
+}
+
+// RequestClient sends a JSON-RPC request to the most recently opened stream
+// of a session and waits for the client to POST the response. The request
+// travels under a gateway ID; the response gets the original ID back.
+// Implements proxy.ClientRequester.
+func (t *HTTPTransport) RequestClient(ctx context.Context, sessionID string, req []byte) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(req, &fields); err != nil {
+		return nil, err
+	}
+	reqs := t.sessions.requests
+	id, p := reqs.add(sessionID, fields["id"])
+	defer reqs.remove(id)
+	fields["id"], _ = json.Marshal(id)
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	if !t.sessions.sendLatest(sessionID, data) {
+		return nil, errClientUnreachable
+	}
+
+	timer := time.NewTimer(clientRequestTimeout)
+	defer timer.Stop()
+	var resp []byte
+	select {
+	case r, ok := <-p.done:
+		if !ok {
+			return nil, errClientGone
+		}
+		resp = r
+	case <-timer.C:
+		return nil, errClientTimeout
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	var out map[string]json.RawMessage
+	if err := json.Unmarshal(resp, &out); err != nil {
+		return nil, err
+	}
+	out["id"] = p.originalID
+	if p.originalID == nil {
+		out["id"] = json.RawMessage("null")
+	}
+	return json.Marshal(out)
+}
+
+// clientResponseID returns the ID of a JSON-RPC response (an object with an
+// id, a result or error, and no method), or false for other messages.
+func clientResponseID(body []byte) (string, bool) {
+	var probe struct {
+		Method *string          `json:"method"`
+		ID     json.RawMessage  `json:"id"`
+		Result json.RawMessage  `json:"result"`
+		Error  *json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(body, &probe) != nil || probe.Method != nil || probe.ID == nil {
+		return "", false
+	}
+	if probe.Result == nil && probe.Error == nil {
+		return "", false
+	}
+	var id string
+	if json.Unmarshal(bytes.TrimSpace(probe.ID), &id) != nil {
+		// Gateway IDs are strings; any other ID matches no request.
+		return "", true
+	}
+	return id, true
+}
+
+// handleClientResponse delivers a response POSTed by a client to the
+// upstream request waiting for it. Streamable HTTP answers accepted
+// responses with 202 Accepted.
+func handleClientResponse(w http.ResponseWriter, r *http.Request, registry *sessionRegistry, id string, body []byte) {
+	sessionID := r.Header.Get(MCPSessionIDHeader)
+	if sessionID == "" {
+		writeJSONError(w, http.StatusBadRequest, "Mcp-Session-Id header required for responses")
+		return
+	}
+	if !registry.verifyOwner(sessionID, ownerHashFromRequest(r)) {
+		writeJSONError(w, http.StatusNotFound, "Session not found")
+		return
+	}
+	// The body buffer is pooled and reused once this handler returns.
+	if !registry.requests.resolve(sessionID, id, bytes.Clone(body)) {
+		writeJSONError(w, http.StatusBadRequest, "No pending request for this response")
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,134 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// postClientResponse POSTs a client's JSON-RPC response to the MCP endpoint.
+func postClientResponse(t *testing.T, registry *sessionRegistry, sessionID, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if sessionID != "" {
+		req.Header.Set(MCPSessionIDHeader, sessionID)
+	}
+	rec := httptest.NewRecorder()
+	handlePost(rec, req, nil, registry, nil)
+	return rec
+}
+
+func TestRequestClient_RoundTrip(t *testing.T) {
+	transport := NewHTTPTransport(nil)
+	reg := transport.sessions
+	reg.preRegisterOwner("sess-1", "")
+	ch := make(chan []byte, 1)
+	reg.register("sess-1", ch, "")
+
+	type result struct {
+		resp []byte
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		resp, err := transport.RequestClient(context.Background(), "sess-1",
+			[]byte(`{"jsonrpc":"2.0","id":42,"method":"sampling/createMessage","params":{}}`))
+		done <- result{resp, err}
+	}()
+
+	var sent struct {
+		ID     string `json:"id"`
+		Method string `json:"method"`
+	}
+	select {
+	case data := <-ch:
+		if err := json.Unmarshal(data, &sent); err != nil {
+			t.Fatalf("invalid request on stream: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("request not sent to the session stream")
+	}
+	if !strings.HasPrefix(sent.ID, clientRequestIDPrefix) || sent.Method != "sampling/createMessage" {
+		t.Fatalf("sent id=%q method=%q, want a gateway ID", sent.ID, sent.Method)
+	}
+
+	// A response from another session does not resolve the request.
+	reg.preRegisterOwner("sess-2", "")
+	if rec := postClientResponse(t, reg, "sess-2", `{"jsonrpc":"2.0","id":"`+sent.ID+`","result":{}}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("foreign session status = %d, want 400", rec.Code)
+	}
+
+	rec := postClientResponse(t, reg, "sess-1", `{"jsonrpc":"2.0","id":"`+sent.ID+`","result":{"role":"assistant"}}`)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202 (body %s)", rec.Code, rec.Body.String())
+	}
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			t.Fatalf("RequestClient() error = %v", r.err)
+		}
+		var got struct {
+			ID     int             `json:"id"`
+			Result json.RawMessage `json:"result"`
+		}
+		if err := json.Unmarshal(r.resp, &got); err != nil || got.ID != 42 || got.Result == nil {
+			t.Errorf("response = %s, want the result with the original id 42", r.resp)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("RequestClient() did not return")
+	}
+
+	// The request is resolved; a repeated response has nothing to match.
+	if rec := postClientResponse(t, reg, "sess-1", `{"jsonrpc":"2.0","id":"`+sent.ID+`","result":{}}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("repeated response status = %d, want 400", rec.Code)
+	}
+}
+
+func TestRequestClient_NoStream(t *testing.T) {
+	transport := NewHTTPTransport(nil)
+	_, err := transport.RequestClient(context.Background(), "missing",
+		[]byte(`{"jsonrpc":"2.0","id":1,"method":"sampling/createMessage"}`))
+	if !errors.Is(err, errClientUnreachable) {
+		t.Errorf("RequestClient() error = %v, want errClientUnreachable", err)
+	}
+}
+
+func TestRequestClient_SessionTerminated(t *testing.T) {
+	transport := NewHTTPTransport(nil)
+	reg := transport.sessions
+	reg.preRegisterOwner("sess-1", "")
+	ch := make(chan []byte, 1)
+	reg.register("sess-1", ch, "")
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := transport.RequestClient(context.Background(), "sess-1",
+			[]byte(`{"jsonrpc":"2.0","id":1,"method":"elicitation/create"}`))
+		done <- err
+	}()
+	<-ch
+	reg.terminate("sess-1")
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, errClientGone) {
+			t.Errorf("RequestClient() error = %v, want errClientGone", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("RequestClient() did not return after terminate")
+	}
+}
+
+func TestHandlePost_ClientResponseRequiresSession(t *testing.T) {
+	rec := postClientResponse(t, newSessionRegistry(), "", `{"jsonrpc":"2.0","id":"sg-1","result":{}}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", rec.Code)
+	}
+}
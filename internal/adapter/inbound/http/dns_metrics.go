@@ -0,0 +1,69 @@
+package http
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/dns"
+)
+
+// DNSStatsProvider supplies DNS resolution counters for /metrics.
+type DNSStatsProvider interface {
+	// DNSStats returns the counters of the gateway resolver, and false when
+	// host names are resolved by the system resolver.
+	DNSStats() (dns.Stats, bool)
+	// DNSRebindings returns how many upstream connections were closed
+	// because their host rebound to an internal address.
+	DNSRebindings() uint64
+}
+
+// dnsCollector exports DNS cache, failure and rebinding counters, read at
+// scrape time.
+type dnsCollector struct {
+	provider   DNSStatsProvider
+	lookups    *prometheus.Desc
+	entries    *prometheus.Desc
+	failures   *prometheus.Desc
+	rebindings *prometheus.Desc
+}
+
+func newDNSCollector(provider DNSStatsProvider) *dnsCollector {
+	return &dnsCollector{
+		provider: provider,
+		lookups: prometheus.NewDesc("sentinelgate_dns_cache_lookups_total",
+			"DNS queries by cache result (hit, negative_hit, miss)",
+			[]string{"result"}, nil),
+		entries: prometheus.NewDesc("sentinelgate_dns_cache_entries",
+			"DNS answers in the cache",
+			nil, nil),
+		failures: prometheus.NewDesc("sentinelgate_dns_failures_total",
+			"Failed DNS queries by reason (timeout, unreachable, servfail, refused, nxdomain)",
+			[]string{"reason"}, nil),
+		rebindings: prometheus.NewDesc("sentinelgate_dns_rebindings_total",
+			"Upstream connections closed because their host rebound to an internal address",
+			nil, nil),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *dnsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.lookups
+	ch <- c.entries
+	ch <- c.failures
+	ch <- c.rebindings
+}
+
+// Collect implements prometheus.Collector.
+func (c *dnsCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(c.rebindings, prometheus.CounterValue, float64(c.provider.DNSRebindings()))
+	s, ok := c.provider.DNSStats()
+	if !ok {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.lookups, prometheus.CounterValue, float64(s.CacheHits), "hit")
+	ch <- prometheus.MustNewConstMetric(c.lookups, prometheus.CounterValue, float64(s.NegativeCacheHits), "negative_hit")
+	ch <- prometheus.MustNewConstMetric(c.lookups, prometheus.CounterValue, float64(s.CacheMisses), "miss")
+	ch <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(s.CacheEntries))
+	for reason, n := range s.Failures {
+		ch <- prometheus.MustNewConstMetric(c.failures, prometheus.CounterValue, float64(n), reason)
+	}
+}
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/dns"
+)
+
+type staticDNSStats struct {
+	stats      dns.Stats
+	ok         bool
+	rebindings uint64
+}
+
+func (s staticDNSStats) DNSStats() (dns.Stats, bool) { return s.stats, s.ok }
+func (s staticDNSStats) DNSRebindings() uint64       { return s.rebindings }
+
+func TestDNSCollector(t *testing.T) {
+	provider := staticDNSStats{
+		stats: dns.Stats{
+			CacheHits: 7, NegativeCacheHits: 2, CacheMisses: 3, CacheEntries: 4,
+			Failures: map[string]uint64{dns.FailureTimeout: 1, dns.FailureNXDomain: 2},
+		},
+		ok:         true,
+		rebindings: 1,
+	}
+
+	expected := `
+# HELP sentinelgate_dns_cache_lookups_total DNS queries by cache result (hit, negative_hit, miss)
+# TYPE sentinelgate_dns_cache_lookups_total counter
+sentinelgate_dns_cache_lookups_total{result="hit"} 7
+sentinelgate_dns_cache_lookups_total{result="miss"} 3
+sentinelgate_dns_cache_lookups_total{result="negative_hit"} 2
+# HELP sentinelgate_dns_failures_total Failed DNS queries by reason (timeout, unreachable, servfail, refused, nxdomain)
+# TYPE sentinelgate_dns_failures_total counter
+sentinelgate_dns_failures_total{reason="nxdomain"} 2
+sentinelgate_dns_failures_total{reason="timeout"} 1
+# HELP sentinelgate_dns_rebindings_total Upstream connections closed because their host rebound to an internal address
+# TYPE sentinelgate_dns_rebindings_total counter
+sentinelgate_dns_rebindings_total 1
+`
+	if err := testutil.CollectAndCompare(newDNSCollector(provider), strings.NewReader(expected),
+		"sentinelgate_dns_cache_lookups_total", "sentinelgate_dns_failures_total", "sentinelgate_dns_rebindings_total"); err != nil {
+		t.Error(err)
+	}
+
+	// With the system resolver only the rebinding counter is exported.
+	if n := testutil.CollectAndCount(newDNSCollector(staticDNSStats{})); n != 1 {
+		t.Errorf("collected %d metrics without a gateway resolver, want 1", n)
+	}
+}
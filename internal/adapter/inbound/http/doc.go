@@ -21,8 +21,9 @@
 // The transport exposes a single endpoint at the root path:
 //
 //	POST /  - Send JSON-RPC request, receive JSON-RPC response
-//	GET /   - Open SSE stream for server-initiated messages
-//	DELETE / - Terminate session and close SSE connections
+//	GET /   - Open SSE stream for server-initiated messages, or upgrade
+//	          to a WebSocket connection when enabled via WithWebSocket
+//	DELETE / - Terminate session and close SSE and WebSocket connections
 //	OPTIONS / - CORS preflight handling
 //
 // # Request Headers
@@ -42,7 +43,13 @@
 // The transport implements several security measures:
 //
 //   - TLS 1.2 minimum: When HTTPS enabled via WithTLS, TLS 1.2 is enforced
+//   - Certificate rotation: The certificate and key files are re-read when
+//     they change on disk (CertReloader, see WithCertReloader), so renewals
+//     by cert-manager or certbot apply without a restart
 //   - DNS rebinding protection: Origin header validation via WithAllowedOrigins
+//   - Load shedding: With WithLoadShedder, list requests and progress/log
+//     notifications are refused with 503 and Retry-After while the process
+//     is overloaded; tool calls are always admitted
 //   - Rate limiting: Applied via split interceptor chain (IPRateLimitInterceptor pre-auth, UserRateLimitInterceptor post-auth)
 //   - API key authentication: Extracted from Authorization header for AuthInterceptor
 //   - Real IP extraction: From X-Forwarded-For/X-Real-IP for rate limiting
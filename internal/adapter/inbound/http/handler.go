@@ -9,8 +9,6 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"errors"
-	"fmt"
-	"io"
 	"log/slog"
 	"mime"
 	"net/http"
@@ -30,7 +28,8 @@ import (
 // MCPProtocolVersion is the MCP protocol version this handler supports.
 const MCPProtocolVersion = "2025-11-25"
 
-// maxRequestBodySize is the maximum allowed request body size (1 MB).
+// maxRequestBodySize is the default maximum request body size (1 MB).
+// Override with WithMaxRequestBodySize.
 const maxRequestBodySize = 1 << 20
 
 // MCPSessionIDHeader is the header for session identification.
@@ -64,6 +63,16 @@ type sessionRegistry struct {
 	cleanDone  chan struct{}             // closed when cleanup goroutine exits (L-19)
 	stopOnce   sync.Once                 // prevents double-close panic on concurrent StopCleanup() calls
 	onTerminate func(sessionID string)   // optional callback when a session is terminated
+	sse         SSEConfig                // SSE framing, overflow and compression settings
+	overflow    *overflowStore           // spilled oversized messages (nil = never spill)
+	ws          WebSocketConfig          // WebSocket upgrade settings (disabled by default)
+	closing     atomic.Bool              // set by closeAll so WebSocket streams close with 1001
+	polls       map[string]*pollQueue    // long-polling queues by session ID
+	replays     map[string]*replayBuffer // events kept for Last-Event-ID resumption
+	queues      map[chan []byte]*streamQueue // backlog of the SSE streams
+	onQueueDrop func(QueueDrop)              // optional callback when SSE messages are dropped
+	metrics     atomic.Pointer[Metrics]      // set when the transport starts
+	requests    *clientRequests          // requests sent to clients awaiting their responses
 }
 
 // newSessionRegistry creates a new session registry.
@@ -74,8 +83,14 @@ func newSessionRegistry() *sessionRegistry {
 		sessions:    make(map[string][]chan []byte),
 		owners:      make(map[string]*ownerEntry),
 		sseCounters: make(map[string]*atomic.Uint64),
+		polls:       make(map[string]*pollQueue),
+		replays:     make(map[string]*replayBuffer),
+		queues:      make(map[chan []byte]*streamQueue),
+		requests:    newClientRequests(),
 		stopClean:   make(chan struct{}),
 		cleanDone:   make(chan struct{}),
+		sse:         SSEConfig{}.withDefaults(),
+		ws:          WebSocketConfig{}.withDefaults(),
 	}
 }
 
@@ -128,6 +143,7 @@ func (r *sessionRegistry) cleanupStaleOwners() {
 			if len(r.sessions[id]) == 0 {
 				delete(r.owners, id)
 				delete(r.sseCounters, id) // M-21: clean up per-session SSE counter
+				delete(r.replays, id)
 				reaped = append(reaped, id)
 			}
 		}
@@ -160,6 +176,11 @@ func (r *sessionRegistry) register(sessionID string, ch chan []byte, ownerHash s
 func (r *sessionRegistry) unregister(sessionID string, ch chan []byte) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	r.unregisterLocked(sessionID, ch)
+}
+
+// unregisterLocked removes an SSE channel from a session. r.mu must be held.
+func (r *sessionRegistry) unregisterLocked(sessionID string, ch chan []byte) {
 	channels := r.sessions[sessionID]
 	for i, c := range channels {
 		if c == ch {
@@ -190,8 +211,13 @@ func (r *sessionRegistry) terminate(sessionID string) bool {
 	delete(r.sessions, sessionID)
 	delete(r.owners, sessionID)
 	delete(r.sseCounters, sessionID) // M-21: clean up per-session SSE counter
+	delete(r.replays, sessionID)
 	cb := r.onTerminate
 	r.mu.Unlock()
+	if r.overflow != nil {
+		r.overflow.dropSession(sessionID)
+	}
+	r.requests.dropSession(sessionID)
 	// Call cleanup callback outside the lock to avoid potential deadlocks.
 	if cb != nil {
 		cb(sessionID)
@@ -202,6 +228,7 @@ func (r *sessionRegistry) terminate(sessionID string) bool {
 // closeAll closes all SSE channels for all sessions and stops the cleanup goroutine.
 func (r *sessionRegistry) closeAll() {
 	r.StopCleanup()
+	r.closing.Store(true)
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	for _, channels := range r.sessions {
@@ -212,36 +239,66 @@ func (r *sessionRegistry) closeAll() {
 	r.sessions = make(map[string][]chan []byte)
 	r.owners = make(map[string]*ownerEntry)
 	r.sseCounters = make(map[string]*atomic.Uint64) // M-21: reset per-session SSE counters
+	r.polls = make(map[string]*pollQueue)
+	r.replays = make(map[string]*replayBuffer)
+	r.queues = make(map[chan []byte]*streamQueue)
 }
 
 // broadcast sends a message to ONE SSE channel per session.
 // MCP spec: "MUST send each JSON-RPC message on only one of the
 // connected streams" — pick the first available channel per session.
+// Sessions whose GET stream is reconnecting keep it for replay.
 func (r *sessionRegistry) broadcast(data []byte) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
+	for sid := range r.replays {
+		if len(r.sessions[sid]) == 0 {
+			r.keepMissedLocked(sid, data)
+		}
+	}
 	for sid, channels := range r.sessions {
 		if len(channels) == 0 {
 			continue
 		}
 		sent := false
 		for _, ch := range channels {
-			select {
-			case ch <- data:
+			if r.offerLocked(ch, data) {
 				sent = true
-			default:
-				continue
-			}
-			if sent {
 				break
 			}
 		}
+		if !sent {
+			sent = r.overflowLocked(sid, channels[0], data)
+		}
 		if !sent {
 			slog.Debug("broadcast: notification dropped, all channels full", "session_id", sid)
 		}
 	}
 }
 
+// sendLatest sends a message to the most recently opened SSE channel of one
+// session and reports whether it was queued. The message is dropped when
+// that channel is full or the session has no open channel, unless the
+// session's GET stream is reconnecting: it is then kept for replay.
+func (r *sessionRegistry) sendLatest(sessionID string, data []byte) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	channels := r.sessions[sessionID]
+	if len(channels) == 0 {
+		if r.keepMissedLocked(sessionID, data) {
+			return true
+		}
+		slog.Debug("message dropped, session has no open channel", "session_id", sessionID)
+		return false
+	}
+	latest := channels[len(channels)-1]
+	if r.offerLocked(latest, data) || r.overflowLocked(sessionID, latest, data) {
+		return true
+	}
+	slog.Debug("message dropped, latest channel full", "session_id", sessionID)
+	return false
+}
+
 // nextSSEEventID returns the next monotonically increasing SSE event ID for
 // a session. Creates the counter if it doesn't exist yet (M-21).
 func (r *sessionRegistry) nextSSEEventID(sessionID string) uint64 {
@@ -303,12 +360,20 @@ func ownerHashFromRequest(r *http.Request) string {
 
 // mcpHandler creates the main HTTP handler for MCP Streamable HTTP transport.
 // It routes requests by HTTP method to the appropriate handler.
-func mcpHandler(proxyService *service.ProxyService, registry *sessionRegistry) http.Handler {
+func mcpHandler(proxyService *service.ProxyService, registry *sessionRegistry, body *bodyReader) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodPost:
-			handlePost(w, r, proxyService, registry)
+			handlePost(w, r, proxyService, registry, body)
 		case http.MethodGet:
+			if strings.HasPrefix(r.URL.Path, overflowPathPrefix) {
+				handleOverflowGet(w, r, registry)
+				return
+			}
+			if registry.wsConfig().Enabled && isWebSocketUpgrade(r) {
+				handleWebSocket(w, r, proxyService, registry, body)
+				return
+			}
 			handleGet(w, r, registry)
 		case http.MethodDelete:
 			handleDelete(w, r, registry)
@@ -323,9 +388,9 @@ func mcpHandler(proxyService *service.ProxyService, registry *sessionRegistry) h
 }
 
 // handlePost processes JSON-RPC messages from the client.
-// It reads the request body, passes it through the proxy service,
-// and returns the response.
-func handlePost(w http.ResponseWriter, r *http.Request, proxyService *service.ProxyService, registry *sessionRegistry) {
+// It reads the request body through bodyReader (size limit + JSON syntax),
+// passes it through the proxy service, and returns the response.
+func handlePost(w http.ResponseWriter, r *http.Request, proxyService *service.ProxyService, registry *sessionRegistry, bodyLimits *bodyReader) {
 	setCORSHeaders(w, r)
 
 	// MCP quality: log warning if Accept header is present but doesn't include
@@ -366,85 +431,53 @@ func handlePost(w http.ResponseWriter, r *http.Request, proxyService *service.Pr
 		return
 	}
 
-	// Apply payload size limit before reading body
-	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
+	// Read the body: oversized Content-Length is rejected before any byte is
+	// read, chunked bodies are cut off as soon as they cross the limit, and
+	// malformed JSON fails at the first bad token. Accepted bodies are
+	// buffered whole for the interceptors.
 	defer func() { _ = r.Body.Close() }()
-
-	// Read request body
-	body, err := io.ReadAll(r.Body)
+	body, release, err := bodyLimits.read(w, r)
+	defer release()
 	if err != nil {
-		// Check if the error is due to exceeding the size limit
-		var maxBytesErr *http.MaxBytesError
-		if errors.As(err, &maxBytesErr) {
-			writeJSONRPCError(w, nil, -32700, "Parse error: request body too large (max 1MB)")
-			return
+		switch {
+		case errors.Is(err, errBodyTooLarge):
+			writeJSONRPCError(w, nil, -32700, "Parse error: request body too large (max "+formatByteSize(bodyLimits.limit())+")")
+		case errors.Is(err, errBodyEmpty):
+			writeJSONRPCError(w, nil, -32700, "Parse error: empty request body")
+		case errors.Is(err, errBodyInvalidJSON):
+			writeJSONRPCError(w, nil, -32700, "Parse error: invalid JSON")
+		default:
+			writeJSONRPCError(w, nil, -32700, "Parse error: failed to read request body")
 		}
-		writeJSONRPCError(w, nil, -32700, "Parse error: failed to read request body")
-		return
-	}
-
-	// Check for empty body
-	if len(body) == 0 {
-		writeJSONRPCError(w, nil, -32700, "Parse error: empty request body")
 		return
 	}
 
-	// Validate JSON syntax
-	if !json.Valid(body) {
-		writeJSONRPCError(w, nil, -32700, "Parse error: invalid JSON")
+	// Responses to requests the gateway sent over SSE (sampling,
+	// elicitation) go to the upstream request waiting for them.
+	if id, ok := clientResponseID(body); ok {
+		handleClientResponse(w, r, registry, id, body)
 		return
 	}
 
 	// Validate JSON-RPC required fields
-	var rpcRequest struct {
-		JSONRPC string `json:"jsonrpc"`
-		Method  string `json:"method"`
-	}
-	if err := json.Unmarshal(body, &rpcRequest); err != nil {
-		// JSON is valid (passed json.Valid above) but not an object -
-		// e.g., array, string, number, boolean
-		writeJSONRPCError(w, nil, -32600, "Invalid Request: request must be a JSON object")
-		return
-	}
-	if rpcRequest.JSONRPC != "2.0" {
-		writeJSONRPCError(w, nil, -32600, "Invalid Request: missing or invalid jsonrpc version (must be \"2.0\")")
+	env, errMsg := parseJSONRPCEnvelope(body)
+	if errMsg != "" {
+		writeJSONRPCError(w, env.ID, -32600, errMsg)
 		return
 	}
-	if rpcRequest.Method == "" {
-		writeJSONRPCError(w, nil, -32600, "Invalid Request: missing method field")
-		return
-	}
-
-	// Determine if this is a notification (no "id" field) per JSON-RPC 2.0.
 	// Notifications don't expect a response; Streamable HTTP requires 202 Accepted.
-	var idCheck struct {
-		ID json.RawMessage `json:"id"`
-	}
-	_ = json.Unmarshal(body, &idCheck)
-	isNotification := idCheck.ID == nil
+	isNotification := env.IsNotification
 
-	// Validate id type: per JSON-RPC 2.0, id MUST be string, number, or null.
-	// M-20: Also reject arrays ([) and objects ({) which are not valid id types.
-	if idCheck.ID != nil {
-		trimmed := bytes.TrimSpace(idCheck.ID)
-		if len(trimmed) > 0 {
-			first := trimmed[0]
-			if first != '"' && first != 'n' && (first < '0' || first > '9') && first != '-' {
-				writeJSONRPCError(w, idCheck.ID, -32600, "Invalid Request: id must be a string, number, or null")
-				return
-			}
-			if first == '[' || first == '{' {
-				writeJSONRPCError(w, idCheck.ID, -32600, "Invalid Request: id must be a string, number, or null")
-				return
-			}
-		}
+	// Admission control: refuse low-priority messages while overloaded.
+	if shedIfOverloaded(w, r, env.Method, isNotification) {
+		return
 	}
 
 	// M-18: Validate session ID BEFORE proxy execution to reject invalid
 	// sessions early and avoid wasted work.
 	if sessionID := r.Header.Get(MCPSessionIDHeader); sessionID != "" {
 		if len(sessionID) > 128 || !validSessionIDRegexp.MatchString(sessionID) {
-			writeJSONRPCError(w, idCheck.ID, -32600, "Invalid Request: invalid session ID")
+			writeJSONRPCError(w, env.ID, -32600, "Invalid Request: invalid session ID")
 			return
 		}
 	}
@@ -489,6 +522,8 @@ func handlePost(w http.ResponseWriter, r *http.Request, proxyService *service.Pr
 
 	// Common response headers
 	w.Header().Set(MCPProtocolVersionHeader, MCPProtocolVersion)
+	setProvenanceHeaders(w, ctx)
+	setServerTimingHeader(w, ctx)
 
 	// M-4: Echo session ID only after verifying ownership.
 	if sessionID := r.Header.Get(MCPSessionIDHeader); sessionID != "" {
@@ -511,8 +546,8 @@ func handlePost(w http.ResponseWriter, r *http.Request, proxyService *service.Pr
 	// Defensive: if the buffer contains multiple JSON-RPC messages
 	// (e.g. progress notifications + final result), extract only the
 	// response that matches the request ID.
-	if len(idCheck.ID) > 0 {
-		response = filterResponseByID(response, idCheck.ID)
+	if len(env.ID) > 0 {
+		response = filterResponseByID(response, env.ID)
 	}
 
 	// MCP spec compliance: promote auth errors to HTTP 401.
@@ -529,11 +564,30 @@ func handlePost(w http.ResponseWriter, r *http.Request, proxyService *service.Pr
 		_, _ = w.Write(response)
 		return
 	}
+	// The key is valid but bound to other origins, or the identity is
+	// outside its access restrictions: re-authenticating would not help,
+	// so this is a 403 rather than a 401.
+	if isForbiddenErrorResponse(response) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set(MCPProtocolVersionHeader, MCPProtocolVersion)
+		w.Header().Set("Content-Length", strconv.Itoa(len(response)))
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write(response)
+		return
+	}
+
+	// Catalog lists carry an ETag, so a client polling them gets 304 Not
+	// Modified instead of the full list while nothing changed.
+	if isCatalogMethod(env.Method) {
+		if etag := catalogETag(response); etag != "" && writeNotModified(w, r, etag) {
+			return
+		}
+	}
 
 	// For initialize requests, generate and return a session ID only when
 	// the response is a success (has "result", no "error"). This prevents
 	// leaking a valid session ID alongside a JSON-RPC error body (H-6).
-	if rpcRequest.Method == "initialize" {
+	if env.Method == "initialize" {
 		var respCheck struct {
 			Error json.RawMessage `json:"error"`
 		}
@@ -555,11 +609,13 @@ func handlePost(w http.ResponseWriter, r *http.Request, proxyService *service.Pr
 		}
 	}
 
-	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+	if wantsSSEResponse(r.Header.Get("Accept"), registry.sseConfig(), response) {
 		w.Header().Set("Content-Type", "text/event-stream")
 		w.Header().Set("Cache-Control", "no-cache")
 		w.Header().Set("Connection", "keep-alive")
 		w.Header().Set("X-Accel-Buffering", "no")
+		sw := newSSEWriter(w, r, registry.sseConfig())
+		defer sw.close()
 		w.WriteHeader(http.StatusOK)
 		// M-21: Use per-session monotonic counter for SSE event ID instead of hardcoded "id: 1".
 		// M-3: For initialize, session ID is in response header, not request header.
@@ -572,15 +628,14 @@ func handlePost(w http.ResponseWriter, r *http.Request, proxyService *service.Pr
 		if sessionID != "" {
 			eventID = registry.nextSSEEventID(sessionID)
 		}
-		// L-11: Use w.Write instead of fmt.Fprintf to avoid %-verb interpretation in SSE data.
-		normalized := sseNormalize(response)
-		sseFrame := fmt.Appendf(nil, "id: %d\nevent: message\ndata: ", eventID)
-		sseFrame = append(sseFrame, normalized...)
-		sseFrame = append(sseFrame, '\n', '\n')
-		_, _ = w.Write(sseFrame)
-		if f, ok := w.(http.Flusher); ok {
-			f.Flush()
+		// L-11: Build the frame as bytes to avoid %-verb interpretation in SSE data.
+		var frame []byte
+		if registry != nil {
+			frame = registry.sseFrame(eventID, sessionID, ownerHashFromRequest(r), response)
+		} else {
+			frame = appendSSEEvent(nil, eventID, "message", response, defaultSSEMaxLineSize)
 		}
+		_ = sw.write(frame)
 		return
 	}
 
@@ -589,6 +644,54 @@ func handlePost(w http.ResponseWriter, r *http.Request, proxyService *service.Pr
 	_, _ = w.Write(response)
 }
 
+// jsonRPCEnvelope is the routing information of a JSON-RPC 2.0 message.
+type jsonRPCEnvelope struct {
+	Method string
+	// ID is nil for notifications (no "id" member).
+	ID             json.RawMessage
+	IsNotification bool
+}
+
+// parseJSONRPCEnvelope checks the envelope of a message that is already
+// known to be valid JSON. On failure it returns an "Invalid Request" error
+// message; the envelope then carries the id to echo, if it was read.
+func parseJSONRPCEnvelope(body []byte) (jsonRPCEnvelope, string) {
+	var rpcRequest struct {
+		JSONRPC string `json:"jsonrpc"`
+		Method  string `json:"method"`
+	}
+	if err := json.Unmarshal(body, &rpcRequest); err != nil {
+		// JSON is valid but not an object - e.g., array, string, number, boolean
+		return jsonRPCEnvelope{}, "Invalid Request: request must be a JSON object"
+	}
+	if rpcRequest.JSONRPC != "2.0" {
+		return jsonRPCEnvelope{}, "Invalid Request: missing or invalid jsonrpc version (must be \"2.0\")"
+	}
+	if rpcRequest.Method == "" {
+		return jsonRPCEnvelope{}, "Invalid Request: missing method field"
+	}
+
+	// Determine if this is a notification (no "id" field) per JSON-RPC 2.0.
+	var idCheck struct {
+		ID json.RawMessage `json:"id"`
+	}
+	_ = json.Unmarshal(body, &idCheck)
+	env := jsonRPCEnvelope{Method: rpcRequest.Method, ID: idCheck.ID, IsNotification: idCheck.ID == nil}
+
+	// Validate id type: per JSON-RPC 2.0, id MUST be string, number, or null.
+	// M-20: Also reject arrays ([) and objects ({) which are not valid id types.
+	if idCheck.ID != nil {
+		trimmed := bytes.TrimSpace(idCheck.ID)
+		if len(trimmed) > 0 {
+			first := trimmed[0]
+			if first != '"' && first != 'n' && (first < '0' || first > '9') && first != '-' {
+				return env, "Invalid Request: id must be a string, number, or null"
+			}
+		}
+	}
+	return env, ""
+}
+
 // filterResponseByID extracts the JSON-RPC response matching expectedID from buffer.
 // If the buffer is a single JSON object with the right ID, it is returned as-is.
 // Otherwise, it uses json.Decoder to split multiple concatenated JSON objects
@@ -637,26 +740,18 @@ func filterResponseByID(buffer []byte, expectedID json.RawMessage) []byte {
 	return buffer
 }
 
-// sseNormalize sanitizes SSE data to prevent newline injection.
-// Order: CRLF → LF, bare CR → LF, then fold multi-line into separate data: fields.
-func sseNormalize(msg []byte) []byte {
-	var buf bytes.Buffer
-	if err := json.Compact(&buf, msg); err == nil {
-		return buf.Bytes()
-	}
-	s := string(msg)
-	s = strings.ReplaceAll(s, "\r\n", " ")
-	s = strings.ReplaceAll(s, "\r", " ")
-	s = strings.ReplaceAll(s, "\n", " ")
-	return []byte(s)
-}
-
 // handleGet opens an SSE stream for server-initiated messages.
 // This is used for long-running connections where the server needs to
 // push messages to the client (e.g., progress notifications).
 func handleGet(w http.ResponseWriter, r *http.Request, registry *sessionRegistry) {
 	setCORSHeaders(w, r)
 
+	// Clients that cannot keep an event stream open fall back to polling.
+	if wantsLongPoll(r) {
+		handleLongPoll(w, r, registry)
+		return
+	}
+
 	// MCP spec: validate MCP-Protocol-Version header.
 	if protoVer := r.Header.Get(MCPProtocolVersionHeader); protoVer != "" {
 		if protoVer != MCPProtocolVersion {
@@ -667,17 +762,6 @@ func handleGet(w http.ResponseWriter, r *http.Request, registry *sessionRegistry
 		}
 	}
 
-	// M-21: Log warning if client sends Last-Event-ID (SSE reconnection).
-	// Event replay is not supported; the client will receive new events only.
-	// L-8: Truncate Last-Event-ID before logging to prevent log pollution (max 128 chars).
-	if lastID := r.Header.Get("Last-Event-ID"); lastID != "" {
-		logID := lastID
-		if len(logID) > 128 {
-			logID = logID[:128] + "...(truncated)"
-		}
-		slog.Warn("SSE reconnection detected but replay not supported", "last_event_id", logID)
-	}
-
 	// L-16: Validate Accept header. Allow text/event-stream, empty (curl-style),
 	// and wildcard (*/*). Reject explicit non-SSE accept types with 406.
 	accept := r.Header.Get("Accept")
@@ -688,8 +772,7 @@ func handleGet(w http.ResponseWriter, r *http.Request, registry *sessionRegistry
 	}
 
 	// SSE requires Flusher support
-	flusher, ok := w.(http.Flusher)
-	if !ok {
+	if _, ok := w.(http.Flusher); !ok {
 		// L-26: Use writeJSONError for consistent JSON error responses.
 		writeJSONError(w, http.StatusInternalServerError, "SSE not supported")
 		return
@@ -729,18 +812,32 @@ func handleGet(w http.ResponseWriter, r *http.Request, registry *sessionRegistry
 	w.Header().Set("X-Accel-Buffering", "no")
 	w.Header().Set(MCPProtocolVersionHeader, MCPProtocolVersion)
 	w.Header().Set(MCPSessionIDHeader, sessionID)
+	sw := newSSEWriter(w, r, registry.sseConfig())
+	defer sw.close()
 
 	// Create channel for messages
-	msgChan := make(chan []byte, 100) // Buffer for some messages
+	msgChan := registry.newStreamChan()
+	queue := registry.openQueue(msgChan)
+	replay := registry.openReplay(sessionID)
+	stream := replay.newStream()
 	registry.register(sessionID, msgChan, ownerHash)
-	defer registry.unregister(sessionID, msgChan)
+	// Messages still queued when the stream ends are kept for replay.
+	defer registry.closeStream(sessionID, msgChan, replay)
 
 	// Get request context for cancellation
 	ctx := r.Context()
 
 	// Write initial comment to establish connection
-	_, _ = fmt.Fprintf(w, ": connected\n\n")
-	flusher.Flush()
+	_ = sw.write([]byte(": connected\n\n"))
+
+	// MCP resumability: a client reconnecting with Last-Event-ID gets the
+	// events this session's stream sent after it, and those produced while
+	// no stream was open, before any new event.
+	if lastID := r.Header.Get("Last-Event-ID"); lastID != "" {
+		if !replayEvents(sw, replay, sessionID, lastID, stream) {
+			return
+		}
+	}
 
 	// M-19: Add 30s heartbeat/keepalive to prevent reverse proxies from
 	// closing idle SSE connections, matching admin SSE endpoints.
@@ -755,29 +852,30 @@ func handleGet(w http.ResponseWriter, r *http.Request, registry *sessionRegistry
 			return
 		case <-keepalive.C:
 			// M-47: Check write errors — client disconnect means stop.
-			if _, writeErr := fmt.Fprintf(w, ": keepalive\n\n"); writeErr != nil {
+			if writeErr := sw.write([]byte(": keepalive\n\n")); writeErr != nil {
 				return
 			}
-			flusher.Flush()
 			keepalive.Reset(30 * time.Second)
+		case <-queue.kicked():
+			// Drop policy "close": the client fell behind. It reconnects
+			// and gets the queued messages replayed.
+			return
 		case msg, ok := <-msgChan:
 			if !ok {
 				// Channel closed (session terminated)
 				return
 			}
+			queue.received(msg)
 			// M-21/M-36/M-37: Use per-session monotonic SSE event ID counter
 			// shared between GET and POST paths.
 			id := registry.nextSSEEventID(sessionID)
-			// L-11: Use w.Write instead of fmt.Fprintf to avoid %-verb interpretation in SSE data.
-			normalized := sseNormalize(msg)
-			sseFrame := fmt.Appendf(nil, "id: %d\nevent: message\ndata: ", id)
-			sseFrame = append(sseFrame, normalized...)
-			sseFrame = append(sseFrame, '\n', '\n')
+			// L-11: Build the frame as bytes to avoid %-verb interpretation in SSE data.
+			frame := registry.sseFrame(id, sessionID, ownerHash, msg)
+			replay.add(id, stream, frame)
 			// M-47: Check write errors.
-			if _, writeErr := w.Write(sseFrame); writeErr != nil {
+			if writeErr := sw.write(frame); writeErr != nil {
 				return
 			}
-			flusher.Flush()
 			// M-19: Reset keepalive timer since we just sent data.
 			if !keepalive.Stop() {
 				select {
@@ -824,7 +922,8 @@ func handleDelete(w http.ResponseWriter, r *http.Request, registry *sessionRegis
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// setCORSHeaders sets CORS headers only for allowed local origins.
+// setCORSHeaders sets CORS headers only for local origins and origins that
+// passed the global allowlist (see DNSRebindingProtection).
 func setCORSHeaders(w http.ResponseWriter, r *http.Request) {
 	origin := r.Header.Get("Origin")
 	if origin == "" {
@@ -832,21 +931,23 @@ func setCORSHeaders(w http.ResponseWriter, r *http.Request) {
 	}
 	w.Header().Add("Vary", "Origin")
 
-	u, err := url.Parse(origin)
-	if err != nil {
-		return
-	}
-	host := u.Hostname() // strips port and IPv6 brackets
-	switch host {
-	case "localhost", "127.0.0.1", "::1":
-		// Local origin allowed
-	default:
-		return
+	if allowed, _ := r.Context().Value(proxy.OriginContextKey).(string); allowed != origin {
+		u, err := url.Parse(origin)
+		if err != nil {
+			return
+		}
+		host := u.Hostname() // strips port and IPv6 brackets
+		switch host {
+		case "localhost", "127.0.0.1", "::1":
+			// Local origin allowed
+		default:
+			return
+		}
 	}
 
 	w.Header().Set("Access-Control-Allow-Origin", origin)
 	w.Header().Set("Access-Control-Allow-Credentials", "true")
-	w.Header().Set("Access-Control-Expose-Headers", "Mcp-Session-Id, MCP-Protocol-Version")
+	w.Header().Set("Access-Control-Expose-Headers", "Mcp-Session-Id, MCP-Protocol-Version, ETag, "+provenanceExposedHeaderList)
 }
 
 // handleOptions handles CORS preflight requests.
@@ -854,7 +955,7 @@ func handleOptions(w http.ResponseWriter, r *http.Request) {
 	setCORSHeaders(w, r)
 	// Allow common headers
 	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Mcp-Session-Id, MCP-Protocol-Version")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Mcp-Session-Id, MCP-Protocol-Version, If-None-Match")
 	w.Header().Set("Access-Control-Max-Age", "86400") // 24 hours
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -875,7 +976,14 @@ type jsonRPCErrorField struct {
 func writeJSONRPCError(w http.ResponseWriter, id interface{}, code int, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK) // JSON-RPC errors still return 200 OK
+	if data := marshalJSONRPCError(id, code, message); data != nil {
+		_, _ = w.Write(data)
+	}
+}
 
+// marshalJSONRPCError encodes a JSON-RPC error response, or returns nil if
+// encoding fails.
+func marshalJSONRPCError(id interface{}, code int, message string) []byte {
 	// Explicitly serialize nil as JSON null via json.RawMessage to avoid
 	// relying on the implicit Go nil-interface-to-null behavior.
 	actualID := id
@@ -895,9 +1003,9 @@ func writeJSONRPCError(w http.ResponseWriter, id interface{}, code int, message
 	data, err := json.Marshal(errResp)
 	if err != nil {
 		slog.Error("failed to encode JSON-RPC error response", "error", err)
-		return
+		return nil
 	}
-	_, _ = w.Write(data)
+	return data
 }
 
 // writeJSONError writes a JSON error response with the given status code and message.
@@ -921,19 +1029,33 @@ func writeJSONError(w http.ResponseWriter, statusCode int, message string) {
 // for ErrUnauthenticated, ErrInvalidAPIKey, and ErrSessionExpired
 // (defined in proxy/auth_interceptor.go:62-67).
 func isAuthErrorResponse(response []byte) bool {
+	switch jsonRPCErrorMessage(response) {
+	case "Authentication required", "Invalid API key", "Session expired":
+		return true
+	}
+	return false
+}
+
+// isForbiddenErrorResponse reports whether the response is the
+// proxy.SafeErrorMessage() output for proxy.ErrOriginNotAllowed or
+// proxy.ErrAccessRestricted.
+func isForbiddenErrorResponse(response []byte) bool {
+	msg := jsonRPCErrorMessage(response)
+	return msg == "Origin not allowed for this API key" || strings.HasPrefix(msg, "Access restricted")
+}
+
+// jsonRPCErrorMessage returns the error message of a JSON-RPC error
+// response, or "" if the response is not an error.
+func jsonRPCErrorMessage(response []byte) string {
 	var parsed struct {
 		Error *struct {
 			Message string `json:"message"`
 		} `json:"error"`
 	}
 	if err := json.Unmarshal(response, &parsed); err != nil || parsed.Error == nil {
-		return false
-	}
-	switch parsed.Error.Message {
-	case "Authentication required", "Invalid API key", "Session expired":
-		return true
+		return ""
 	}
-	return false
+	return parsed.Error.Message
 }
 
 // healthHandler returns an HTTP handler that responds with 200 OK for health checks.
@@ -33,7 +33,7 @@ func TestHandlePost_InvalidContentType(t *testing.T) {
 	req.Header.Set("Content-Type", "text/plain")
 	rec := httptest.NewRecorder()
 
-	handlePost(rec, req, nil, nil)
+	handlePost(rec, req, nil, nil, nil)
 
 	// M-17: Content-Type errors now return HTTP 200 with JSON-RPC error for consistency
 	if rec.Code != http.StatusOK {
@@ -56,7 +56,7 @@ func TestHandlePost_EmptyBody(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
-	handlePost(rec, req, nil, nil)
+	handlePost(rec, req, nil, nil, nil)
 
 	if rec.Code != http.StatusOK {
 		t.Errorf("status code = %d, want %d", rec.Code, http.StatusOK)
@@ -78,7 +78,7 @@ func TestHandlePost_InvalidJSON(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
-	handlePost(rec, req, nil, nil)
+	handlePost(rec, req, nil, nil, nil)
 
 	if rec.Code != http.StatusOK {
 		t.Errorf("status code = %d, want %d", rec.Code, http.StatusOK)
@@ -102,7 +102,7 @@ func TestHandlePost_OversizedPayload(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
-	handlePost(rec, req, nil, nil)
+	handlePost(rec, req, nil, nil, nil)
 
 	if rec.Code != http.StatusOK {
 		t.Errorf("status code = %d, want %d", rec.Code, http.StatusOK)
@@ -125,7 +125,7 @@ func TestHandlePost_MissingJsonrpcVersion(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
-	handlePost(rec, req, nil, nil)
+	handlePost(rec, req, nil, nil, nil)
 
 	if rec.Code != http.StatusOK {
 		t.Errorf("status code = %d, want %d", rec.Code, http.StatusOK)
@@ -148,7 +148,7 @@ func TestHandlePost_MissingMethod(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
-	handlePost(rec, req, nil, nil)
+	handlePost(rec, req, nil, nil, nil)
 
 	if rec.Code != http.StatusOK {
 		t.Errorf("status code = %d, want %d", rec.Code, http.StatusOK)
@@ -171,7 +171,7 @@ func TestHandlePost_WrongJsonrpcVersion(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
-	handlePost(rec, req, nil, nil)
+	handlePost(rec, req, nil, nil, nil)
 
 	if rec.Code != http.StatusOK {
 		t.Errorf("status code = %d, want %d", rec.Code, http.StatusOK)
@@ -194,7 +194,7 @@ func TestHandlePost_NoContentType(t *testing.T) {
 	// No Content-Type header set
 	rec := httptest.NewRecorder()
 
-	handlePost(rec, req, nil, nil)
+	handlePost(rec, req, nil, nil, nil)
 
 	// M-17: Missing Content-Type now returns HTTP 200 with JSON-RPC error for consistency
 	if rec.Code != http.StatusOK {
@@ -271,7 +271,7 @@ func TestMCPHandler_UnsupportedMethod(t *testing.T) {
 	for _, method := range methods {
 		t.Run(method, func(t *testing.T) {
 			registry := newSessionRegistry()
-			handler := mcpHandler(nil, registry)
+			handler := mcpHandler(nil, registry, nil)
 
 			req := httptest.NewRequest(method, "/mcp", nil)
 			rec := httptest.NewRecorder()
@@ -306,7 +306,7 @@ func TestHandlePost_ValidJSONNotObject(t *testing.T) {
 			req.Header.Set("Content-Type", "application/json")
 			rec := httptest.NewRecorder()
 
-			handlePost(rec, req, nil, nil)
+			handlePost(rec, req, nil, nil, nil)
 
 			code, _ := parseJSONRPCError(t, rec.Body.Bytes())
 			// Should get -32600 (Invalid Request) since jsonrpc/method are missing
@@ -336,7 +336,7 @@ func TestHandlePost_MultipleContentTypes(t *testing.T) {
 			req.Header.Set("Content-Type", ct)
 			rec := httptest.NewRecorder()
 
-			handlePost(rec, req, nil, nil)
+			handlePost(rec, req, nil, nil, nil)
 
 			// M-17: Content-Type errors now return HTTP 200 with JSON-RPC error
 			if rec.Code != http.StatusOK {
@@ -562,7 +562,7 @@ func TestSessionRegistry_CloseAll(t *testing.T) {
 
 func TestMCPHandler_OptionsRoute(t *testing.T) {
 	registry := newSessionRegistry()
-	handler := mcpHandler(nil, registry)
+	handler := mcpHandler(nil, registry, nil)
 
 	req := httptest.NewRequest(http.MethodOptions, "/mcp", nil)
 	rec := httptest.NewRecorder()
@@ -699,6 +699,33 @@ func TestSessionRegistryBroadcastMultipleChannelsPerSession(t *testing.T) {
 	reg.unregister("session-1", ch2)
 }
 
+func TestSessionRegistrySendLatest(t *testing.T) {
+	reg := newSessionRegistry()
+	ch1 := make(chan []byte, 10)
+	ch2 := make(chan []byte, 10)
+	other := make(chan []byte, 10)
+	reg.register("session-1", ch1, "")
+	reg.register("session-1", ch2, "")
+	reg.register("session-2", other, "")
+
+	msg := []byte(`{"jsonrpc":"2.0","method":"notifications/message"}`)
+	if !reg.sendLatest("session-1", msg) {
+		t.Error("sendLatest() = false, want true")
+	}
+
+	if len(ch2) != 1 {
+		t.Errorf("latest channel got %d messages, want 1", len(ch2))
+	}
+	if len(ch1) != 0 || len(other) != 0 {
+		t.Errorf("other channels got messages: session-1 first=%d, session-2=%d", len(ch1), len(other))
+	}
+
+	// Unknown sessions are ignored.
+	if reg.sendLatest("missing", msg) {
+		t.Error("sendLatest() to an unknown session = true, want false")
+	}
+}
+
 // --- handleGet SSE tests ---
 
 func TestHandleGet_SSEHeaders(t *testing.T) {
@@ -786,6 +813,7 @@ func TestIsAuthErrorResponse(t *testing.T) {
 		{"invalid key", `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid API key"},"id":1}`, true},
 		{"session expired", `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Session expired"},"id":1}`, true},
 		{"policy denied", `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Access denied by policy"},"id":1}`, false},
+		{"origin not allowed", `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Origin not allowed for this API key"},"id":1}`, false},
 		{"success response", `{"jsonrpc":"2.0","result":{},"id":1}`, false},
 		{"empty", ``, false},
 		{"invalid json", `not json`, false},
@@ -1003,7 +1031,7 @@ func TestHandlePost_UnsupportedProtocolVersion_Returns400(t *testing.T) {
 	req.Header.Set(MCPProtocolVersionHeader, "9999-01-01")
 	rec := httptest.NewRecorder()
 
-	handlePost(rec, req, nil, nil)
+	handlePost(rec, req, nil, nil, nil)
 
 	if rec.Code != http.StatusBadRequest {
 		t.Errorf("status = %d, want 400 for unsupported protocol version", rec.Code)
@@ -1024,7 +1052,7 @@ func TestHandlePost_SupportedProtocolVersion_Continues(t *testing.T) {
 	rec := httptest.NewRecorder()
 	registry := newSessionRegistry()
 
-	handlePost(rec, req, nil, registry)
+	handlePost(rec, req, nil, registry, nil)
 
 	// If version were rejected, we'd get 400. We expect 404 (unknown session)
 	// which proves the version check passed.
@@ -1045,7 +1073,7 @@ func TestHandlePost_NoProtocolVersion_Continues(t *testing.T) {
 	rec := httptest.NewRecorder()
 	registry := newSessionRegistry()
 
-	handlePost(rec, req, nil, registry)
+	handlePost(rec, req, nil, registry, nil)
 
 	if rec.Code == http.StatusBadRequest {
 		t.Errorf("missing version should not return 400 (backward compat)")
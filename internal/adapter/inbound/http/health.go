@@ -1,35 +1,87 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"runtime"
+	"strings"
+	"time"
 
-	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/memory"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/auth"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/ratelimit"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/session"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
 )
 
+// healthStoreTimeout bounds the session store and rate limiter checks.
+const healthStoreTimeout = 2 * time.Second
+
 // HealthResponse is the JSON response from the /health endpoint.
 type HealthResponse struct {
-	Status  string            `json:"status"`            // "healthy" or "unhealthy"
-	Checks  map[string]string `json:"checks"`            // Component check results
-	Version string            `json:"version,omitempty"` // Optional version info
+	Status    string            `json:"status"`               // "healthy" or "unhealthy"
+	Checks    map[string]string `json:"checks,omitempty"`     // Component check results
+	Version   string            `json:"version,omitempty"`    // Optional version info
+	GoVersion string            `json:"go_version,omitempty"` // Go runtime version
+	Upstreams []UpstreamHealth  `json:"upstreams,omitempty"`  // Per-upstream status
+}
+
+// UpstreamHealth is the status of one upstream in a full health response.
+type UpstreamHealth struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
 }
 
+// HealthDetail is how much a health response reveals.
+type HealthDetail string
+
+const (
+	// HealthDetailStatus returns only "healthy" or "unhealthy".
+	HealthDetailStatus HealthDetail = "status"
+	// HealthDetailSummary adds the component checks, without versions or
+	// upstream names.
+	HealthDetailSummary HealthDetail = "summary"
+	// HealthDetailFull returns everything, including versions and upstreams.
+	HealthDetailFull HealthDetail = "full"
+)
+
 // UpstreamChecker reports upstream connectivity status.
 type UpstreamChecker interface {
 	AllConnected() bool
 }
 
+// UpstreamStatusLister lists upstreams and their connection status for full
+// health responses. It does not affect the overall health verdict.
+type UpstreamStatusLister interface {
+	UpstreamHealth(ctx context.Context) []UpstreamHealth
+}
+
+// HealthKeyValidator validates API keys presented to the health endpoint.
+// auth.APIKeyService satisfies it.
+type HealthKeyValidator interface {
+	Validate(ctx context.Context, rawKey string) (*auth.Identity, error)
+}
+
 // HealthChecker verifies component health.
 type HealthChecker struct {
-	sessionStore    *memory.MemorySessionStore
-	rateLimiter     *memory.MemoryRateLimiter
+	sessionStore    session.SessionStore
+	rateLimiter     ratelimit.RateLimiter
 	auditService    *service.AuditService
 	upstreamChecker UpstreamChecker
+	upstreamLister  UpstreamStatusLister
+	keyValidator    HealthKeyValidator
 	version         string
+
+	// publicDetail and authDetail are the detail levels for anonymous and
+	// authenticated callers. Empty means full, so a checker built without
+	// a policy behaves as before.
+	publicDetail HealthDetail
+	authDetail   HealthDetail
 }
 
 // SetUpstreamChecker sets the optional upstream connectivity checker (M-39).
@@ -37,11 +89,38 @@ func (h *HealthChecker) SetUpstreamChecker(uc UpstreamChecker) {
 	h.upstreamChecker = uc
 }
 
+// SetUpstreamLister sets the source of per-upstream status reported at full detail.
+func (h *HealthChecker) SetUpstreamLister(l UpstreamStatusLister) {
+	h.upstreamLister = l
+}
+
+// SetKeyValidator sets the validator used to recognise authenticated callers
+// by their Bearer API key. When nil, only localhost callers are authenticated.
+func (h *HealthChecker) SetKeyValidator(v HealthKeyValidator) {
+	h.keyValidator = v
+}
+
+// SetDetailPolicy sets the detail levels for unauthenticated and
+// authenticated callers. Unknown levels fall back to status for public
+// callers and full for authenticated ones.
+func (h *HealthChecker) SetDetailPolicy(public, authenticated HealthDetail) {
+	h.publicDetail = normalizeHealthDetail(public, HealthDetailStatus)
+	h.authDetail = normalizeHealthDetail(authenticated, HealthDetailFull)
+}
+
+func normalizeHealthDetail(d, fallback HealthDetail) HealthDetail {
+	switch d {
+	case HealthDetailStatus, HealthDetailSummary, HealthDetailFull:
+		return d
+	}
+	return fallback
+}
+
 // NewHealthChecker creates a HealthChecker with optional components.
 // Pass nil for components that aren't available.
 func NewHealthChecker(
-	sessionStore *memory.MemorySessionStore,
-	rateLimiter *memory.MemoryRateLimiter,
+	sessionStore session.SessionStore,
+	rateLimiter ratelimit.RateLimiter,
 	auditService *service.AuditService,
 	version string,
 ) *HealthChecker {
@@ -60,17 +139,38 @@ func (h *HealthChecker) Check() HealthResponse {
 
 	// Check session store accessibility
 	if h.sessionStore != nil {
-		// Size() acquires lock - if this hangs, we have a problem
-		_ = h.sessionStore.Size()
-		checks["session_store"] = "ok"
+		// Looking up a missing session takes the memory store lock or
+		// round-trips to Redis - if this hangs or fails, we have a problem
+		ctx, cancel := context.WithTimeout(context.Background(), healthStoreTimeout)
+		_, err := h.sessionStore.Get(ctx, "health-check")
+		cancel()
+		if err != nil && !errors.Is(err, session.ErrSessionNotFound) {
+			checks["session_store"] = "error: " + err.Error()
+			healthy = false
+		} else {
+			checks["session_store"] = "ok"
+		}
 	} else {
 		checks["session_store"] = "not configured"
 	}
 
 	// Check rate limiter accessibility
 	if h.rateLimiter != nil {
-		_ = h.rateLimiter.Size()
-		checks["rate_limiter"] = "ok"
+		// A Redis limiter is shared by all replicas - report it when it
+		// stops answering, since limits then fail open
+		if p, ok := h.rateLimiter.(interface{ Ping(context.Context) error }); ok {
+			ctx, cancel := context.WithTimeout(context.Background(), healthStoreTimeout)
+			err := p.Ping(ctx)
+			cancel()
+			if err != nil {
+				checks["rate_limiter"] = "error: " + err.Error()
+				healthy = false
+			} else {
+				checks["rate_limiter"] = "ok"
+			}
+		} else {
+			checks["rate_limiter"] = "ok"
+		}
 	} else {
 		checks["rate_limiter"] = "not configured"
 	}
@@ -126,10 +226,60 @@ func (h *HealthChecker) Check() HealthResponse {
 	}
 }
 
-// Handler returns an HTTP handler for the health endpoint.
+// checkDetail runs the health checks and trims the result to detail.
+func (h *HealthChecker) checkDetail(ctx context.Context, detail HealthDetail) HealthResponse {
+	health := h.Check()
+	switch detail {
+	case HealthDetailStatus:
+		return HealthResponse{Status: health.Status}
+	case HealthDetailSummary:
+		health.Version = ""
+		return health
+	}
+	health.GoVersion = runtime.Version()
+	if h.upstreamLister != nil {
+		health.Upstreams = h.upstreamLister.UpstreamHealth(ctx)
+	}
+	return health
+}
+
+// detailFor returns the detail level for r. Callers are authenticated by a
+// valid Bearer API key or by connecting from localhost, which is where the
+// admin UI and the status command run.
+func (h *HealthChecker) detailFor(r *http.Request) HealthDetail {
+	if h.publicDetail == "" && h.authDetail == "" {
+		return HealthDetailFull
+	}
+	if h.isAuthenticated(r) {
+		return h.authDetail
+	}
+	return h.publicDetail
+}
+
+func (h *HealthChecker) isAuthenticated(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return true
+	}
+	if h.keyValidator == nil {
+		return false
+	}
+	key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || key == "" {
+		return false
+	}
+	identity, err := h.keyValidator.Validate(r.Context(), key)
+	return err == nil && identity != nil
+}
+
+// Handler returns an HTTP handler for the health endpoint. The status code
+// reflects health at every detail level, so load balancers need no key.
 func (h *HealthChecker) Handler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		health := h.Check()
+		health := h.checkDetail(r.Context(), h.detailFor(r))
 
 		w.Header().Set("Content-Type", "application/json")
 		if health.Status != "healthy" {
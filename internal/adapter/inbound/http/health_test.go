@@ -1,15 +1,19 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/memory"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/auth"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
 )
 
@@ -67,6 +71,23 @@ func TestHealthChecker_NilComponents(t *testing.T) {
 	}
 }
 
+// unreachableLimiter is a rate limiter whose shared store is down.
+type unreachableLimiter struct{ *memory.MemoryRateLimiter }
+
+func (unreachableLimiter) Ping(context.Context) error { return errors.New("connection refused") }
+
+func TestHealthChecker_RateLimiterUnreachable(t *testing.T) {
+	hc := NewHealthChecker(nil, unreachableLimiter{memory.NewRateLimiter()}, nil, "")
+	health := hc.Check()
+
+	if health.Status != "unhealthy" {
+		t.Errorf("Status = %q, want unhealthy", health.Status)
+	}
+	if health.Checks["rate_limiter"] != "error: connection refused" {
+		t.Errorf("rate_limiter = %q, want the ping error", health.Checks["rate_limiter"])
+	}
+}
+
 func TestHealthChecker_Handler_HTTP(t *testing.T) {
 	sessionStore := memory.NewSessionStore()
 	hc := NewHealthChecker(sessionStore, nil, nil, "1.0.0")
@@ -166,3 +187,90 @@ func TestHealthChecker_GoroutineCount(t *testing.T) {
 		t.Error("goroutines count should be > 0")
 	}
 }
+
+type staticKeyValidator struct{ key string }
+
+func (v staticKeyValidator) Validate(_ context.Context, rawKey string) (*auth.Identity, error) {
+	if rawKey != v.key {
+		return nil, auth.ErrInvalidKey
+	}
+	return &auth.Identity{ID: "id-1", Name: "ops"}, nil
+}
+
+type staticUpstreamLister []UpstreamHealth
+
+func (l staticUpstreamLister) UpstreamHealth(context.Context) []UpstreamHealth { return l }
+
+func TestHealthChecker_DetailLevels(t *testing.T) {
+	hc := NewHealthChecker(memory.NewSessionStore(), nil, nil, "1.2.3")
+	hc.SetDetailPolicy(HealthDetailStatus, HealthDetailFull)
+	hc.SetKeyValidator(staticKeyValidator{key: "sg_secret"})
+	hc.SetUpstreamLister(staticUpstreamLister{{Name: "github", Status: "connected"}})
+
+	get := func(remoteAddr, authHeader string) map[string]interface{} {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/healthz", nil)
+		req.RemoteAddr = remoteAddr
+		if authHeader != "" {
+			req.Header.Set("Authorization", authHeader)
+		}
+		rec := httptest.NewRecorder()
+		hc.Handler().ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status code = %d, want 200", rec.Code)
+		}
+		var body map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return body
+	}
+
+	for name, authHeader := range map[string]string{"anonymous": "", "wrong key": "Bearer nope"} {
+		body := get("203.0.113.5:4000", authHeader)
+		if len(body) != 1 || body["status"] != "healthy" {
+			t.Errorf("%s: body = %v, want status only", name, body)
+		}
+	}
+
+	for name, tc := range map[string][2]string{
+		"api key":   {"203.0.113.5:4000", "Bearer sg_secret"},
+		"localhost": {"127.0.0.1:4000", ""},
+	} {
+		body := get(tc[0], tc[1])
+		if body["version"] != "1.2.3" || body["go_version"] == nil || body["checks"] == nil {
+			t.Errorf("%s: body = %v, want full detail", name, body)
+		}
+		ups, _ := body["upstreams"].([]interface{})
+		if len(ups) != 1 || ups[0].(map[string]interface{})["name"] != "github" {
+			t.Errorf("%s: upstreams = %v", name, body["upstreams"])
+		}
+	}
+
+	hc.SetDetailPolicy(HealthDetailSummary, HealthDetailFull)
+	body := get("203.0.113.5:4000", "")
+	if body["checks"] == nil || body["version"] != nil || body["upstreams"] != nil {
+		t.Errorf("summary body = %v, want checks without version or upstreams", body)
+	}
+}
+
+func TestHealthChecker_StatusDetail_Unhealthy503(t *testing.T) {
+	auditService := service.NewAuditService(memory.NewAuditStore(), discardLogger(),
+		service.WithChannelSize(10),
+		service.WithSendTimeout(0),
+	)
+	for i := 0; i < 10; i++ {
+		auditService.Record(audit.AuditRecord{ToolName: "test"})
+	}
+	hc := NewHealthChecker(nil, nil, auditService, "")
+	hc.SetDetailPolicy(HealthDetailStatus, HealthDetailFull)
+
+	rec := httptest.NewRecorder()
+	hc.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status code = %d, want 503", rec.Code)
+	}
+	if got := strings.TrimSpace(rec.Body.String()); got != `{"status":"unhealthy"}` {
+		t.Errorf("body = %s", got)
+	}
+}
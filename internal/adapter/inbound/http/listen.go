@@ -0,0 +1,50 @@
+package http
+
+import (
+	"context"
+	"net"
+	"net/netip"
+)
+
+// listenNetwork picks the network for addr so that its host selects the
+// address family: an IPv4 address listens on IPv4 only, an IPv6 address on
+// IPv6 only (Go sets IPV6_V6ONLY for "tcp6"), and an empty host or a host
+// name on both.
+func listenNetwork(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "tcp"
+	}
+	ip, err := netip.ParseAddr(host)
+	switch {
+	case err != nil:
+		return "tcp"
+	case ip.Is4():
+		return "tcp4"
+	default:
+		return "tcp6"
+	}
+}
+
+// listen opens a listener for the main address and every extra address.
+// If any bind fails, the listeners opened so far are closed.
+func (t *HTTPTransport) listen(ctx context.Context) ([]net.Listener, error) {
+	var lc net.ListenConfig
+	if t.reusePort {
+		lc.Control = reusePortControl
+	}
+
+	addrs := append([]string{t.addr}, t.extraAddrs...)
+	listeners := make([]net.Listener, 0, len(addrs))
+	for _, addr := range addrs {
+		ln, err := lc.Listen(ctx, listenNetwork(addr), addr)
+		if err != nil {
+			for _, l := range listeners {
+				_ = l.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, ln)
+	}
+	return listeners, nil
+}
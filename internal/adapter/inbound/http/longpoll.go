@@ -0,0 +1,315 @@
+package http
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultLongPollWait is how long a poll waits for messages when the
+	// client sends no wait parameter.
+	defaultLongPollWait = 25 * time.Second
+	// maxLongPollWait caps the wait parameter, below common proxy timeouts.
+	maxLongPollWait = 60 * time.Second
+	// longPollQueueSize caps the messages kept for a polling client; the
+	// oldest are dropped first.
+	longPollQueueSize = 1000
+	// longPollIdleTimeout is how long a queue outlives the last poll before
+	// its messages are discarded and its session stream is released.
+	longPollIdleTimeout = 2 * time.Minute
+)
+
+// polledMessage is a server message waiting in a poll queue.
+type polledMessage struct {
+	id   uint64
+	data []byte
+}
+
+// pollQueue buffers the server-initiated messages of a session for
+// long-polling clients. It is registered with the session like an SSE
+// stream; messages stay queued until the client acknowledges them, so a
+// poll lost in transit is delivered again by the next one.
+type pollQueue struct {
+	sessionID string
+	ch        chan []byte
+
+	mu       sync.Mutex
+	msgs     []polledMessage
+	dropped  uint64        // messages evicted since the last response
+	notify   chan struct{} // closed and replaced when a message arrives
+	closed   bool          // session terminated
+	waiters  int           // polls in progress
+	lastPoll time.Time
+}
+
+// longPollResponse is the body returned to a poll.
+type longPollResponse struct {
+	// Messages are the queued JSON-RPC messages, oldest first.
+	Messages []json.RawMessage `json:"messages"`
+	// LastEventID is the ID of the last message returned, or the
+	// acknowledged ID when there are none. Sent back as "after" (or
+	// Last-Event-ID) it acknowledges every message up to it.
+	LastEventID uint64 `json:"last_event_id"`
+	// Dropped counts messages evicted from a full queue since the previous
+	// response.
+	Dropped uint64 `json:"dropped,omitempty"`
+}
+
+// wantsLongPoll reports whether a GET asks for long-polling instead of an
+// SSE stream: either it carries a wait parameter, or its Accept header
+// takes JSON but not event streams.
+func wantsLongPoll(r *http.Request) bool {
+	if r.URL.Query().Has("wait") {
+		return true
+	}
+	accept := r.Header.Get("Accept")
+	return strings.Contains(accept, "application/json") &&
+		!strings.Contains(accept, "text/event-stream") &&
+		!strings.Contains(accept, "*/*")
+}
+
+// acquirePollQueue returns the poll queue of a session, creating and
+// registering it on first use, and counts the caller as a waiter until it
+// calls release.
+func (r *sessionRegistry) acquirePollQueue(sessionID, ownerHash string) *pollQueue {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	q, ok := r.polls[sessionID]
+	if !ok {
+		q = &pollQueue{
+			sessionID: sessionID,
+			ch:        make(chan []byte, 100),
+			notify:    make(chan struct{}),
+		}
+		r.polls[sessionID] = q
+		r.sessions[sessionID] = append(r.sessions[sessionID], q.ch)
+		if entry, exists := r.owners[sessionID]; exists {
+			entry.createdAt = time.Now()
+		} else {
+			r.owners[sessionID] = &ownerEntry{hash: ownerHash, createdAt: time.Now()}
+		}
+		go r.pumpPollQueue(q)
+	}
+	q.mu.Lock()
+	q.waiters++
+	q.lastPoll = time.Now()
+	q.mu.Unlock()
+	return q
+}
+
+// pumpPollQueue moves the messages delivered to the session stream of q
+// into its queue until the session is terminated or no client has polled
+// for longPollIdleTimeout.
+func (r *sessionRegistry) pumpPollQueue(q *pollQueue) {
+	idle := time.NewTicker(longPollIdleTimeout / 4)
+	defer idle.Stop()
+	for {
+		select {
+		case msg, ok := <-q.ch:
+			if !ok {
+				// Session terminated: wake the waiting polls.
+				r.mu.Lock()
+				if r.polls[q.sessionID] == q {
+					delete(r.polls, q.sessionID)
+				}
+				r.mu.Unlock()
+				q.close()
+				return
+			}
+			if !json.Valid(msg) {
+				slog.Debug("long-poll: dropping non-JSON message", "session_id", q.sessionID)
+				continue
+			}
+			q.push(r.nextSSEEventID(q.sessionID), sseCompact(msg))
+		case <-idle.C:
+			if r.releaseIdlePollQueue(q) {
+				return
+			}
+		}
+	}
+}
+
+// releaseIdlePollQueue removes q and its session stream when no poll is in
+// progress and none arrived for longPollIdleTimeout.
+func (r *sessionRegistry) releaseIdlePollQueue(q *pollQueue) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.waiters > 0 || time.Since(q.lastPoll) < longPollIdleTimeout {
+		return false
+	}
+	if r.polls[q.sessionID] == q {
+		delete(r.polls, q.sessionID)
+	}
+	r.unregisterLocked(q.sessionID, q.ch)
+	q.closed = true
+	close(q.notify)
+	return true
+}
+
+// push appends a message, evicting the oldest when the queue is full.
+func (q *pollQueue) push(id uint64, data []byte) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.msgs) >= longPollQueueSize {
+		q.msgs = q.msgs[1:]
+		q.dropped++
+	}
+	q.msgs = append(q.msgs, polledMessage{id: id, data: data})
+	close(q.notify)
+	q.notify = make(chan struct{})
+}
+
+// close marks the session terminated and wakes the waiting polls.
+func (q *pollQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.closed {
+		q.closed = true
+		close(q.notify)
+	}
+}
+
+// release ends a poll started by acquirePollQueue.
+func (q *pollQueue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.waiters--
+	q.lastPoll = time.Now()
+}
+
+// ack discards the messages with an ID up to after.
+func (q *pollQueue) ack(after uint64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	i := 0
+	for i < len(q.msgs) && q.msgs[i].id <= after {
+		i++
+	}
+	q.msgs = q.msgs[i:]
+}
+
+// take returns the queued messages and the dropped count, which it resets,
+// or the channel to wait on when the queue is empty.
+func (q *pollQueue) take() (msgs []polledMessage, dropped uint64, notify <-chan struct{}, closed bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.msgs) == 0 {
+		return nil, 0, q.notify, q.closed
+	}
+	msgs = append([]polledMessage(nil), q.msgs...)
+	dropped = q.dropped
+	q.dropped = 0
+	return msgs, dropped, nil, q.closed
+}
+
+// handleLongPoll returns the queued server-initiated messages of a session
+// as JSON, waiting up to the wait parameter (seconds) for one to arrive.
+// Messages stay queued until acknowledged with the after parameter or the
+// Last-Event-ID header, which carry the last_event_id of a previous
+// response.
+func handleLongPoll(w http.ResponseWriter, r *http.Request, registry *sessionRegistry) {
+	if protoVer := r.Header.Get(MCPProtocolVersionHeader); protoVer != "" && protoVer != MCPProtocolVersion {
+		writeJSONError(w, http.StatusBadRequest,
+			"Unsupported MCP protocol version: "+protoVer+
+				" (supported: "+MCPProtocolVersion+")")
+		return
+	}
+
+	wait := defaultLongPollWait
+	query := r.URL.Query()
+	if v := query.Get("wait"); v != "" {
+		secs, err := strconv.Atoi(v)
+		if err != nil || secs < 0 {
+			writeJSONError(w, http.StatusBadRequest, "wait must be a non-negative number of seconds")
+			return
+		}
+		wait = min(time.Duration(secs)*time.Second, maxLongPollWait)
+	}
+	var after uint64
+	afterParam := query.Get("after")
+	if afterParam == "" {
+		afterParam = r.Header.Get("Last-Event-ID")
+	}
+	if afterParam != "" {
+		v, err := strconv.ParseUint(afterParam, 10, 64)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "after must be an event ID")
+			return
+		}
+		after = v
+	}
+
+	sessionID := r.Header.Get(MCPSessionIDHeader)
+	if sessionID == "" {
+		writeJSONError(w, http.StatusBadRequest, "Mcp-Session-Id header required for polling")
+		return
+	}
+	if len(sessionID) > 128 || !validSessionIDRegexp.MatchString(sessionID) {
+		writeJSONError(w, http.StatusBadRequest, "invalid session ID")
+		return
+	}
+	if !registry.sessionExists(sessionID) {
+		writeJSONError(w, http.StatusNotFound, "Session not found")
+		return
+	}
+	ownerHash := ownerHashFromRequest(r)
+	if !registry.verifyOwner(sessionID, ownerHash) {
+		writeJSONError(w, http.StatusForbidden, "Forbidden: session not owned by caller")
+		return
+	}
+
+	q := registry.acquirePollQueue(sessionID, ownerHash)
+	defer q.release()
+	q.ack(after)
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	resp := longPollResponse{Messages: []json.RawMessage{}, LastEventID: after}
+	for {
+		msgs, dropped, notify, closed := q.take()
+		if len(msgs) > 0 {
+			for _, m := range msgs {
+				resp.Messages = append(resp.Messages, m.data)
+			}
+			resp.LastEventID = msgs[len(msgs)-1].id
+			resp.Dropped = dropped
+			break
+		}
+		if closed {
+			writeJSONError(w, http.StatusNotFound, "Session not found")
+			return
+		}
+		select {
+		case <-notify:
+			continue
+		case <-timer.C:
+		case <-r.Context().Done():
+			return
+		}
+		break
+	}
+
+	body, err := json.Marshal(resp)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Internal error")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set(MCPProtocolVersionHeader, MCPProtocolVersion)
+	w.Header().Set(MCPSessionIDHeader, sessionID)
+	sw := newSSEWriter(w, r, registry.sseConfig())
+	defer sw.close()
+	if sw.enc == nil {
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	}
+	w.WriteHeader(http.StatusOK)
+	_ = sw.write(body)
+}
@@ -0,0 +1,127 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestWantsLongPoll(t *testing.T) {
+	tests := []struct {
+		target string
+		accept string
+		want   bool
+	}{
+		{"/mcp", "text/event-stream", false},
+		{"/mcp", "", false},
+		{"/mcp", "*/*", false},
+		{"/mcp", "application/json", true},
+		{"/mcp", "application/json, text/event-stream", false},
+		{"/mcp?wait=10", "text/event-stream", true},
+		{"/mcp?wait=", "", true},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		if got := wantsLongPoll(req); got != tt.want {
+			t.Errorf("wantsLongPoll(%q, Accept %q) = %v, want %v", tt.target, tt.accept, got, tt.want)
+		}
+	}
+}
+
+// poll runs one long-poll request and decodes its response.
+func poll(t *testing.T, registry *sessionRegistry, target, sessionID string) (int, longPollResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.Header.Set(MCPSessionIDHeader, sessionID)
+	rec := httptest.NewRecorder()
+	handleGet(rec, req, registry)
+	var resp longPollResponse
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode response: %v\nbody: %s", err, rec.Body.String())
+		}
+	}
+	return rec.Code, resp
+}
+
+func TestHandleLongPoll_DeliversAndReplaysUntilAcked(t *testing.T) {
+	registry := newSessionRegistry()
+	registry.preRegisterOwner("poll-session", "")
+
+	// The first poll creates the queue and times out empty.
+	code, resp := poll(t, registry, "/mcp?wait=0", "poll-session")
+	if code != http.StatusOK || len(resp.Messages) != 0 {
+		t.Fatalf("first poll = %d with %d messages, want 200 with none", code, len(resp.Messages))
+	}
+
+	done := make(chan longPollResponse)
+	go func() {
+		_, resp := poll(t, registry, "/mcp?wait=5", "poll-session")
+		done <- resp
+	}()
+	time.Sleep(50 * time.Millisecond)
+	registry.broadcast([]byte(`{"jsonrpc":"2.0","method":"notifications/progress"}`))
+
+	var first longPollResponse
+	select {
+	case first = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("poll did not return after a message was broadcast")
+	}
+	if len(first.Messages) != 1 || first.LastEventID == 0 {
+		t.Fatalf("poll response = %+v, want one message with an event ID", first)
+	}
+
+	// Without an acknowledgement the message is delivered again.
+	_, again := poll(t, registry, "/mcp?wait=0", "poll-session")
+	if len(again.Messages) != 1 || again.LastEventID != first.LastEventID {
+		t.Fatalf("replay = %+v, want the same message", again)
+	}
+
+	// Acknowledging it empties the queue.
+	_, acked := poll(t, registry, "/mcp?wait=0&after="+strconv.FormatUint(first.LastEventID, 10), "poll-session")
+	if len(acked.Messages) != 0 || acked.LastEventID != first.LastEventID {
+		t.Fatalf("after ack = %+v, want no messages and the acknowledged ID", acked)
+	}
+}
+
+func TestHandleLongPoll_TerminatedSession(t *testing.T) {
+	registry := newSessionRegistry()
+	registry.preRegisterOwner("poll-term", "")
+
+	done := make(chan int)
+	go func() {
+		code, _ := poll(t, registry, "/mcp?wait=5", "poll-term")
+		done <- code
+	}()
+	time.Sleep(50 * time.Millisecond)
+	registry.terminate("poll-term")
+
+	select {
+	case code := <-done:
+		if code != http.StatusNotFound {
+			t.Errorf("status = %d, want 404", code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("poll did not return after the session was terminated")
+	}
+}
+
+func TestHandleLongPoll_InvalidParameters(t *testing.T) {
+	registry := newSessionRegistry()
+	registry.preRegisterOwner("poll-bad", "")
+	for _, target := range []string{"/mcp?wait=-1", "/mcp?wait=soon", "/mcp?wait=1&after=x"} {
+		if code, _ := poll(t, registry, target, "poll-bad"); code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", target, code)
+		}
+	}
+	if code, _ := poll(t, registry, "/mcp?wait=0", "unknown"); code != http.StatusNotFound {
+		t.Errorf("unknown session: status = %d, want 404", code)
+	}
+}
@@ -15,6 +15,18 @@ type Metrics struct {
 	PolicyEvaluations *prometheus.CounterVec
 	AuditDropsTotal   prometheus.Counter
 	RateLimitKeys     prometheus.Gauge
+
+	// POST body handling (streaming reader).
+	RequestBodyBytes    prometheus.Histogram
+	InflightBodyBytes   prometheus.Gauge
+	RequestBodyRejected *prometheus.CounterVec
+
+	// Admission control.
+	RequestsShed *prometheus.CounterVec
+
+	// SSE stream backpressure.
+	SSEMessagesDropped *prometheus.CounterVec
+	SSEStreamsClosed   prometheus.Counter
 }
 
 // NewMetrics creates and registers all metrics with the given registry.
@@ -66,5 +78,51 @@ func NewMetrics(reg prometheus.Registerer) *Metrics {
 				Help:      "Number of active rate limit keys",
 			},
 		),
+		RequestBodyBytes: promauto.With(reg).NewHistogram(
+			prometheus.HistogramOpts{
+				Namespace: "sentinelgate",
+				Name:      "request_body_bytes",
+				Help:      "Size of accepted MCP POST bodies in bytes",
+				Buckets:   prometheus.ExponentialBuckets(256, 4, 8), // 256B to 4MB
+			},
+		),
+		InflightBodyBytes: promauto.With(reg).NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: "sentinelgate",
+				Name:      "request_body_inflight_bytes",
+				Help:      "Bytes of MCP POST bodies currently buffered by the handler",
+			},
+		),
+		RequestBodyRejected: promauto.With(reg).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "sentinelgate",
+				Name:      "request_body_rejected_total",
+				Help:      "MCP POST bodies rejected while reading",
+			},
+			[]string{"reason"}, // reason=content_length/streaming/invalid_json
+		),
+		RequestsShed: promauto.With(reg).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "sentinelgate",
+				Name:      "requests_shed_total",
+				Help:      "Low-priority MCP messages refused or dropped under memory or goroutine pressure",
+			},
+			[]string{"class"}, // class=list/notification
+		),
+		SSEMessagesDropped: promauto.With(reg).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "sentinelgate",
+				Name:      "sse_messages_dropped_total",
+				Help:      "Server-initiated messages dropped because an SSE stream queue was full",
+			},
+			[]string{"policy"}, // policy=newest/oldest/close
+		),
+		SSEStreamsClosed: promauto.With(reg).NewCounter(
+			prometheus.CounterOpts{
+				Namespace: "sentinelgate",
+				Name:      "sse_streams_closed_total",
+				Help:      "SSE streams closed because the client fell behind (drop policy close)",
+			},
+		),
 	}
 }
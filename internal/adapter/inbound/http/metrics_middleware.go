@@ -13,8 +13,8 @@ import (
 func MetricsMiddleware(metrics *Metrics) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Skip metrics for /metrics and /health endpoints
-			if r.URL.Path == "/metrics" || r.URL.Path == "/health" {
+			// Skip metrics for /metrics and the health endpoints
+			if r.URL.Path == "/metrics" || r.URL.Path == "/health" || r.URL.Path == "/healthz" {
 				next.ServeHTTP(w, r)
 				return
 			}
@@ -12,7 +12,9 @@ import (
 	"strings"
 
 	"github.com/Sentinel-Gate/Sentinelgate/internal/ctxkey"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/proxy"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/tokenexchange"
 	"github.com/google/uuid"
 )
 
@@ -52,6 +54,20 @@ func RequestIDMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
 	}
 }
 
+// CorrelationIDMiddleware stores the correlation ID sent by the client in
+// the named header in the request context. Invalid values are ignored, and
+// the interceptor chain then generates one.
+func CorrelationIDMiddleware(header string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if id := r.Header.Get(header); audit.ValidCorrelationID(id) {
+				r = r.WithContext(audit.WithCorrelationID(r.Context(), id))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // LoggerFromContext retrieves the enriched logger from context.
 // Returns slog.Default() if no logger is in context.
 func LoggerFromContext(ctx context.Context) *slog.Logger {
@@ -64,6 +80,7 @@ func LoggerFromContext(ctx context.Context) *slog.Logger {
 // DNSRebindingProtection validates Origin and Host headers against allowlists.
 // This prevents DNS rebinding attacks by ensuring requests come from allowed origins.
 // If allowedOrigins is empty, all requests with an Origin header are blocked (local-only mode).
+// An accepted Origin is stored in context under proxy.OriginContextKey.
 //
 // When no Origin header is present, the Host header is validated against allowedHosts.
 // If allowedHosts is empty, Host validation defaults to allowing only localhost variants.
@@ -95,7 +112,9 @@ func DNSRebindingProtection(allowedOrigins []string, allowedHosts ...string) fun
 					_ = json.NewEncoder(w).Encode(map[string]string{"error": "Forbidden: origin not allowed"})
 					return
 				}
-				next.ServeHTTP(w, r)
+				// Record the accepted origin for CORS and per-key origin binding.
+				ctx := context.WithValue(r.Context(), proxy.OriginContextKey, r.Header.Get("Origin"))
+				next.ServeHTTP(w, r.WithContext(ctx))
 				return
 			}
 
@@ -164,6 +183,23 @@ func APIKeyMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// UpstreamTokenMiddleware extracts the end user's OAuth token from the given
+// header and stores it in context for upstream token passthrough/exchange.
+// A "Bearer " prefix is optional. The token is kept separate from the
+// SentinelGate API key in the Authorization header.
+func UpstreamTokenMiddleware(header string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := strings.TrimSpace(r.Header.Get(header))
+			token = strings.TrimSpace(strings.TrimPrefix(token, "Bearer "))
+			if token != "" {
+				r = r.WithContext(tokenexchange.WithSubjectToken(r.Context(), token))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // apiKeyConnectionID generates a deterministic connection ID from an API key.
 // Uses a prefix of the SHA-256 hash to avoid storing the raw key in the cache map.
 func apiKeyConnectionID(apiKey string) string {
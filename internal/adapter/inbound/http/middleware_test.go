@@ -7,7 +7,9 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/proxy"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/tokenexchange"
 )
 
 // --- RequestIDMiddleware tests ---
@@ -79,6 +81,38 @@ func TestRequestIDMiddleware_EnrichesLogger(t *testing.T) {
 
 // --- LoggerFromContext tests ---
 
+// --- CorrelationIDMiddleware tests ---
+
+func TestCorrelationIDMiddleware(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{"valid", "trace-1:hop.2", "trace-1:hop.2"},
+		{"absent", "", ""},
+		{"invalid", "bad value", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = audit.CorrelationIDFromContext(r.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+			if tt.header != "" {
+				req.Header.Set("X-Correlation-ID", tt.header)
+			}
+			CorrelationIDMiddleware("X-Correlation-ID")(inner).ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Errorf("correlation ID = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestLoggerFromContext_WithLogger(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 	mw := RequestIDMiddleware(logger)
@@ -194,6 +228,28 @@ func TestDNSRebindingProtection_AllowedOrigin(t *testing.T) {
 	}
 }
 
+func TestDNSRebindingProtection_AllowedOrigin_SetsContext(t *testing.T) {
+	mw := DNSRebindingProtection([]string{"https://example.com"})
+
+	var got string
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = r.Context().Value(proxy.OriginContextKey).(string)
+		setCORSHeaders(w, r)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("Origin", "https://example.com")
+	rec := httptest.NewRecorder()
+	mw(inner).ServeHTTP(rec, req)
+
+	if got != "https://example.com" {
+		t.Errorf("origin in context = %q, want %q", got, "https://example.com")
+	}
+	if acao := rec.Header().Get("Access-Control-Allow-Origin"); acao != "https://example.com" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want allowlisted origin", acao)
+	}
+}
+
 func TestDNSRebindingProtection_BlockedOrigin(t *testing.T) {
 	mw := DNSRebindingProtection([]string{"http://localhost:8080"})
 
@@ -439,3 +495,31 @@ func TestRealIPMiddleware_SetsContext(t *testing.T) {
 		t.Errorf("captured IP = %q, want %q", capturedIP, "10.20.30.40")
 	}
 }
+
+func TestUpstreamTokenMiddleware(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{"bearer prefix", "Bearer user-token", "user-token"},
+		{"raw token", "user-token", "user-token"},
+		{"absent", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			h := UpstreamTokenMiddleware("X-Upstream-Token")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = tokenexchange.SubjectTokenFromContext(r.Context())
+			}))
+			req := httptest.NewRequest("POST", "/mcp", nil)
+			if tt.header != "" {
+				req.Header.Set("X-Upstream-Token", tt.header)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), req)
+			if got != tt.want {
+				t.Errorf("token = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -28,6 +28,20 @@ func NewHTTPNotificationForwarder(t *HTTPTransport) *HTTPNotificationForwarder {
 }
 
 // ForwardNotification broadcasts a raw JSON-RPC notification to all SSE clients.
+// Progress and log notifications are dropped while the transport sheds load.
 func (f *HTTPNotificationForwarder) ForwardNotification(data []byte) {
+	if f.transport.admission.shedNotification(data) {
+		return
+	}
 	f.transport.sessions.broadcast(data)
 }
+
+// ForwardNotificationToLatest sends a raw JSON-RPC notification to the most
+// recently opened SSE connection of one session. Shedding applies as in
+// ForwardNotification.
+func (f *HTTPNotificationForwarder) ForwardNotificationToLatest(sessionID string, data []byte) {
+	if f.transport.admission.shedNotification(data) {
+		return
+	}
+	f.transport.sessions.sendLatest(sessionID, data)
+}
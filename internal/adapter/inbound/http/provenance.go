@@ -0,0 +1,48 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+)
+
+// Response headers carrying tool result provenance.
+const (
+	ProvenanceUpstreamHeader    = "X-SentinelGate-Upstream"
+	ProvenanceScanHeader        = "X-SentinelGate-Scan-Verdict"
+	ProvenanceRuleHeader        = "X-SentinelGate-Policy-Rule"
+	ProvenanceLatencyHeader     = "X-SentinelGate-Latency-Ms"
+	provenanceExposedHeaderList = "X-Request-ID, " + ProvenanceUpstreamHeader + ", " + ProvenanceScanHeader + ", " +
+		ProvenanceRuleHeader + ", " + ProvenanceLatencyHeader + ", " + ServerTimingHeader
+)
+
+// ProvenanceMiddleware places a ProvenanceHolder in the request context so the
+// interceptor chain can report where a tool result came from. The handler
+// turns it into response headers. Must run inside RequestIDMiddleware.
+func ProvenanceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID, _ := r.Context().Value(RequestIDKey).(string)
+		ctx, _ := audit.NewProvenanceContext(r.Context(), requestID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// setProvenanceHeaders writes the provenance recorded for this request, if
+// any, as response headers. X-Request-ID is already set by RequestIDMiddleware.
+func setProvenanceHeaders(w http.ResponseWriter, ctx context.Context) {
+	holder := audit.ProvenanceFromContext(ctx)
+	if holder == nil || holder.Provenance == nil {
+		return
+	}
+	p := holder.Provenance
+	if p.Upstream != "" {
+		w.Header().Set(ProvenanceUpstreamHeader, p.Upstream)
+	}
+	w.Header().Set(ProvenanceScanHeader, p.ScanVerdict)
+	if p.RuleID != "" {
+		w.Header().Set(ProvenanceRuleHeader, p.RuleID)
+	}
+	w.Header().Set(ProvenanceLatencyHeader, strconv.FormatFloat(p.LatencyMs, 'f', 3, 64))
+}
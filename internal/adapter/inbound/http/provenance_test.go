@@ -0,0 +1,94 @@
+package http
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/timing"
+)
+
+func TestProvenanceMiddleware_SetsHeaders(t *testing.T) {
+	handler := RequestIDMiddleware(slog.New(slog.NewTextHandler(io.Discard, nil)))(ProvenanceMiddleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			holder := audit.ProvenanceFromContext(r.Context())
+			if holder == nil {
+				t.Fatal("expected provenance holder in context")
+			}
+			if holder.RequestID != "req-123" {
+				t.Errorf("holder.RequestID = %q, want %q", holder.RequestID, "req-123")
+			}
+			holder.Provenance = &audit.Provenance{
+				Upstream:    "filesystem",
+				RequestID:   holder.RequestID,
+				ScanVerdict: audit.ProvenanceScanClean,
+				RuleID:      "allow-read",
+				LatencyMs:   12.5,
+			}
+			setProvenanceHeaders(w, r.Context())
+			w.WriteHeader(http.StatusOK)
+		})))
+
+	req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	req.Header.Set("X-Request-ID", "req-123")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	want := map[string]string{
+		"X-Request-ID":           "req-123",
+		ProvenanceUpstreamHeader: "filesystem",
+		ProvenanceScanHeader:     "clean",
+		ProvenanceRuleHeader:     "allow-read",
+		ProvenanceLatencyHeader:  "12.500",
+	}
+	for header, value := range want {
+		if got := rec.Header().Get(header); got != value {
+			t.Errorf("%s = %q, want %q", header, got, value)
+		}
+	}
+}
+
+func TestSetProvenanceHeaders_NoProvenance(t *testing.T) {
+	rec := httptest.NewRecorder()
+	setProvenanceHeaders(rec, context.Background())
+
+	ctx, _ := audit.NewProvenanceContext(context.Background(), "req-1")
+	setProvenanceHeaders(rec, ctx)
+
+	if got := rec.Header().Get(ProvenanceScanHeader); got != "" {
+		t.Errorf("expected no provenance headers, got scan verdict %q", got)
+	}
+}
+
+func TestServerTimingMiddleware_SetsHeaderWhenReported(t *testing.T) {
+	for _, reported := range []bool{true, false} {
+		handler := ServerTimingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b := timing.FromContext(r.Context())
+			if b == nil {
+				t.Fatal("expected latency breakdown in context")
+			}
+			b.Add(timing.PhaseUpstream, 12*time.Millisecond)
+			b.Finish()
+			b.SetReported(reported)
+			setServerTimingHeader(w, r.Context())
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mcp", nil))
+
+		got := rec.Header().Get(ServerTimingHeader)
+		if reported && !strings.Contains(got, "upstream;dur=12.000") {
+			t.Errorf("Server-Timing = %q, want an upstream metric", got)
+		}
+		if !reported && got != "" {
+			t.Errorf("Server-Timing = %q for an unreported breakdown", got)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package http
+
+import (
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Drop policies for a full SSE stream queue (SSEConfig.DropPolicy).
+const (
+	// DropNewest drops the message that does not fit.
+	DropNewest = "newest"
+	// DropOldest drops queued messages, oldest first, to make room.
+	DropOldest = "oldest"
+	// DropClose closes the stream; the client reconnects and resumes with
+	// Last-Event-ID.
+	DropClose = "close"
+)
+
+const (
+	// defaultQueueSize is the number of messages an SSE stream queues when
+	// SSEConfig.QueueSize is zero.
+	defaultQueueSize = 100
+	// queueDropReportInterval is how often drops of one stream are reported.
+	queueDropReportInterval = time.Minute
+)
+
+// QueueDrop reports messages dropped because an SSE stream of a session
+// fell behind.
+type QueueDrop struct {
+	SessionID string
+	// Policy is the drop policy applied (see the Drop constants).
+	Policy string
+	// Dropped is the number of messages dropped since the previous report
+	// for the stream.
+	Dropped uint64
+	// Closed is set when the stream was closed (DropClose).
+	Closed bool
+}
+
+// streamQueue tracks the backlog of one SSE stream, whose messages are
+// queued on its channel.
+type streamQueue struct {
+	bytes      atomic.Int64  // bytes of the queued messages
+	dropped    atomic.Uint64 // drops not reported yet
+	lastReport atomic.Int64  // UnixNano of the last report
+	kick       chan struct{} // closed to make the stream close (DropClose)
+	kickOnce   sync.Once
+}
+
+// received accounts for msg taken off the queue; safe on a nil queue.
+func (q *streamQueue) received(msg []byte) {
+	if q != nil {
+		q.bytes.Add(-int64(len(msg)))
+	}
+}
+
+// kicked returns a channel closed when the stream must close; nil (never
+// ready) on a nil queue.
+func (q *streamQueue) kicked() <-chan struct{} {
+	if q == nil {
+		return nil
+	}
+	return q.kick
+}
+
+// fits reports whether a message of size bytes can be queued on ch without
+// dropping anything. A message larger than maxBytes fits an empty queue, so
+// that it is not dropped forever.
+func (q *streamQueue) fits(ch chan []byte, size, maxBytes int64) bool {
+	if len(ch) >= cap(ch) {
+		return false
+	}
+	return maxBytes <= 0 || len(ch) == 0 || q.bytes.Load()+size <= maxBytes
+}
+
+// newStreamChan returns the channel of a new SSE stream, sized by the
+// queue limit.
+func (r *sessionRegistry) newStreamChan() chan []byte {
+	return make(chan []byte, r.sse.QueueSize)
+}
+
+// openQueue starts tracking the backlog of the SSE stream ch. Call it
+// before registering ch.
+func (r *sessionRegistry) openQueue(ch chan []byte) *streamQueue {
+	q := &streamQueue{kick: make(chan struct{})}
+	r.mu.Lock()
+	r.queues[ch] = q
+	r.mu.Unlock()
+	return q
+}
+
+// offerLocked queues data on ch if it fits within the stream's limits and
+// reports whether it did. r.mu must be held (a read lock is enough).
+func (r *sessionRegistry) offerLocked(ch chan []byte, data []byte) bool {
+	q := r.queues[ch]
+	if q == nil {
+		// Not an SSE stream: only the channel capacity applies.
+		select {
+		case ch <- data:
+			return true
+		default:
+			return false
+		}
+	}
+	size := int64(len(data))
+	if !q.fits(ch, size, r.sse.QueueMaxBytes) {
+		return false
+	}
+	q.bytes.Add(size)
+	select {
+	case ch <- data:
+		return true
+	default:
+		q.bytes.Add(-size)
+		return false
+	}
+}
+
+// overflowLocked applies the drop policy to data, which did not fit on
+// the SSE stream ch, and reports whether data was queued in the end. r.mu
+// must be held (a read lock is enough).
+func (r *sessionRegistry) overflowLocked(sessionID string, ch chan []byte, data []byte) bool {
+	q := r.queues[ch]
+	if q == nil {
+		return false
+	}
+	switch r.sse.DropPolicy {
+	case DropOldest:
+		size := int64(len(data))
+		for !q.fits(ch, size, r.sse.QueueMaxBytes) {
+			select {
+			case old := <-ch:
+				q.received(old)
+				r.dropped(sessionID, q, DropOldest, false)
+			default:
+				// The stream caught up meanwhile.
+			}
+		}
+		if r.offerLocked(ch, data) {
+			return true
+		}
+		r.dropped(sessionID, q, DropOldest, false)
+	case DropClose:
+		first := false
+		q.kickOnce.Do(func() {
+			close(q.kick)
+			first = true
+		})
+		r.dropped(sessionID, q, DropClose, first)
+	default:
+		r.dropped(sessionID, q, DropNewest, false)
+	}
+	return false
+}
+
+// dropped counts a message dropped from q and reports the drops of the
+// stream at most once per queueDropReportInterval, and whenever it is
+// closed.
+func (r *sessionRegistry) dropped(sessionID string, q *streamQueue, policy string, closed bool) {
+	if m := r.metrics.Load(); m != nil {
+		m.SSEMessagesDropped.WithLabelValues(policy).Inc()
+		if closed {
+			m.SSEStreamsClosed.Inc()
+		}
+	}
+	q.dropped.Add(1)
+	now := time.Now().UnixNano()
+	last := q.lastReport.Load()
+	if !closed && (now-last < int64(queueDropReportInterval) || !q.lastReport.CompareAndSwap(last, now)) {
+		return
+	}
+	r.reportDrop(QueueDrop{SessionID: sessionID, Policy: policy, Dropped: q.dropped.Swap(0), Closed: closed})
+}
+
+// reportDrop logs d and hands it to the drop callback.
+func (r *sessionRegistry) reportDrop(d QueueDrop) {
+	if d.Dropped == 0 {
+		return
+	}
+	if d.Closed {
+		slog.Warn("SSE stream closed: client too slow, queue full",
+			"session_id", d.SessionID, "dropped", d.Dropped)
+	} else {
+		slog.Warn("SSE messages dropped: client too slow, queue full",
+			"session_id", d.SessionID, "policy", d.Policy, "dropped", d.Dropped)
+	}
+	if r.onQueueDrop != nil {
+		r.onQueueDrop(d)
+	}
+}
+
+// closeQueueLocked stops tracking the SSE stream ch and reports its drops not
+// reported yet. r.mu must be held.
+func (r *sessionRegistry) closeQueueLocked(sessionID string, ch chan []byte) {
+	q := r.queues[ch]
+	if q == nil {
+		return
+	}
+	delete(r.queues, ch)
+	if n := q.dropped.Swap(0); n > 0 {
+		r.reportDrop(QueueDrop{SessionID: sessionID, Policy: r.sse.DropPolicy, Dropped: n})
+	}
+}
@@ -0,0 +1,110 @@
+package http
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// newQueuedStream registers an SSE stream with queue tracking for a session.
+func newQueuedStream(registry *sessionRegistry, sessionID string) (chan []byte, *streamQueue) {
+	ch := registry.newStreamChan()
+	q := registry.openQueue(ch)
+	registry.register(sessionID, ch, "")
+	return ch, q
+}
+
+// drain returns the messages queued on ch.
+func drain(ch chan []byte, q *streamQueue) []string {
+	var msgs []string
+	for len(ch) > 0 {
+		msg := <-ch
+		q.received(msg)
+		msgs = append(msgs, string(msg))
+	}
+	return msgs
+}
+
+func TestSessionRegistry_DropPolicies(t *testing.T) {
+	tests := []struct {
+		policy string
+		want   string
+	}{
+		{DropNewest, "a,b"},
+		{DropOldest, "b,c"},
+		{DropClose, "a,b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			registry := newSessionRegistry()
+			registry.sse = SSEConfig{QueueSize: 2, DropPolicy: tt.policy}.withDefaults()
+			var drops []QueueDrop
+			registry.onQueueDrop = func(d QueueDrop) { drops = append(drops, d) }
+			ch, q := newQueuedStream(registry, "slow-session")
+
+			for _, msg := range []string{"a", "b", "c"} {
+				registry.broadcast([]byte(msg))
+			}
+			if got := strings.Join(drain(ch, q), ","); got != tt.want {
+				t.Errorf("queued = %q, want %q", got, tt.want)
+			}
+			if len(drops) != 1 || drops[0].Dropped != 1 || drops[0].Policy != tt.policy {
+				t.Errorf("drops = %+v, want one drop with policy %s", drops, tt.policy)
+			}
+
+			select {
+			case <-q.kicked():
+				if tt.policy != DropClose {
+					t.Error("stream closed with policy", tt.policy)
+				}
+			default:
+				if tt.policy == DropClose {
+					t.Error("stream not closed with policy close")
+				}
+			}
+		})
+	}
+}
+
+func TestSessionRegistry_QueueMaxBytes(t *testing.T) {
+	registry := newSessionRegistry()
+	registry.sse = SSEConfig{QueueMaxBytes: 10, DropPolicy: DropOldest}.withDefaults()
+	ch, q := newQueuedStream(registry, "bytes-session")
+
+	// A message larger than the limit is queued when nothing else is.
+	registry.sendLatest("bytes-session", []byte(strings.Repeat("x", 20)))
+	registry.sendLatest("bytes-session", []byte("12345"))
+	registry.sendLatest("bytes-session", []byte("67890"))
+	if got := strings.Join(drain(ch, q), ","); got != "12345,67890" {
+		t.Errorf("queued = %q, want the two small messages", got)
+	}
+	if got := q.bytes.Load(); got != 0 {
+		t.Errorf("queued bytes after draining = %d, want 0", got)
+	}
+}
+
+func TestSessionRegistry_DropMetricsAndReports(t *testing.T) {
+	registry := newSessionRegistry()
+	registry.sse = SSEConfig{QueueSize: 1}.withDefaults()
+	registry.metrics.Store(NewMetrics(prometheus.NewRegistry()))
+	var drops []QueueDrop
+	registry.onQueueDrop = func(d QueueDrop) { drops = append(drops, d) }
+	ch, _ := newQueuedStream(registry, "metrics-session")
+
+	for i := 0; i < 4; i++ {
+		registry.sendLatest("metrics-session", []byte("m"))
+	}
+	if got := testutil.ToFloat64(registry.metrics.Load().SSEMessagesDropped.WithLabelValues(DropNewest)); got != 3 {
+		t.Errorf("sse_messages_dropped_total{policy=newest} = %v, want 3", got)
+	}
+	// The first drop is reported at once, the others when the stream closes.
+	if len(drops) != 1 || drops[0].Dropped != 1 {
+		t.Fatalf("drops before close = %+v, want one report of 1", drops)
+	}
+	registry.closeStream("metrics-session", ch, nil)
+	if len(drops) != 2 || drops[1].Dropped != 2 {
+		t.Errorf("drops after close = %+v, want a second report of 2", drops)
+	}
+}
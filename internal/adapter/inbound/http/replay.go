@@ -0,0 +1,175 @@
+package http
+
+import (
+	"log/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// defaultReplayBufferSize is how many events of a session are kept for
+// replay when SSEConfig.ReplayBufferSize is zero.
+const defaultReplayBufferSize = 100
+
+// missedStream marks events that were not sent on any stream because the
+// session had none open when they were produced.
+const missedStream = 0
+
+// replayEvent is one event kept for replay, framed with its ID.
+type replayEvent struct {
+	id     uint64
+	stream uint64 // stream the event was sent on, or missedStream
+	frame  []byte
+}
+
+// replayBuffer keeps the last server-initiated events of a session's GET
+// streams, so that a client reconnecting with Last-Event-ID receives the
+// events it lost (MCP Streamable HTTP resumability). Events are kept in ID
+// order; the oldest are evicted beyond limit.
+type replayBuffer struct {
+	mu      sync.Mutex
+	limit   int
+	events  []replayEvent
+	evicted uint64 // highest ID evicted so far
+	streams atomic.Uint64
+}
+
+func newReplayBuffer(limit int) *replayBuffer {
+	return &replayBuffer{limit: limit}
+}
+
+// newStream returns the ID of a new GET stream of the session.
+func (b *replayBuffer) newStream() uint64 {
+	return b.streams.Add(1)
+}
+
+// add keeps the event id, framed as frame, sent on stream.
+func (b *replayBuffer) add(id, stream uint64, frame []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	// IDs are assigned before the frame is built, so concurrent senders can
+	// add slightly out of order.
+	i := len(b.events)
+	for i > 0 && b.events[i-1].id > id {
+		i--
+	}
+	b.events = append(b.events, replayEvent{})
+	copy(b.events[i+1:], b.events[i:])
+	b.events[i] = replayEvent{id: id, stream: stream, frame: frame}
+	if over := len(b.events) - b.limit; over > 0 {
+		if last := b.events[over-1].id; last > b.evicted {
+			b.evicted = last
+		}
+		b.events = append(b.events[:0], b.events[over:]...)
+	}
+}
+
+// resume returns, in order, the frames a stream must replay after reconnecting
+// with Last-Event-ID lastID: the later events of the stream that delivered
+// lastID and the events no stream received. They are moved to stream, the new
+// stream, so that a later reconnect from it replays them only once more if
+// needed. complete is false when events after lastID may have been evicted.
+func (b *replayBuffer) resume(lastID, stream uint64) (frames [][]byte, complete bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	from := uint64(missedStream)
+	for _, e := range b.events {
+		if e.id == lastID {
+			from = e.stream
+			break
+		}
+	}
+	for i := range b.events {
+		e := &b.events[i]
+		if e.id <= lastID || (e.stream != from && e.stream != missedStream) {
+			continue
+		}
+		frames = append(frames, e.frame)
+		e.stream = stream
+	}
+	return frames, lastID >= b.evicted
+}
+
+// openReplay returns the replay buffer of a session, creating it with the
+// session's event counter when its first GET stream opens.
+func (r *sessionRegistry) openReplay(sessionID string) *replayBuffer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.replays[sessionID]
+	if !ok {
+		b = newReplayBuffer(r.sse.ReplayBufferSize)
+		r.replays[sessionID] = b
+	}
+	if _, ok := r.sseCounters[sessionID]; !ok {
+		r.sseCounters[sessionID] = &atomic.Uint64{}
+	}
+	return b
+}
+
+// keepMissedLocked keeps data for replay when a session with a replay buffer
+// has no open stream, so that the client gets it when it reconnects with
+// Last-Event-ID. It reports whether data was kept. r.mu must be held (a read
+// lock is enough).
+func (r *sessionRegistry) keepMissedLocked(sessionID string, data []byte) bool {
+	b, ok := r.replays[sessionID]
+	counter := r.sseCounters[sessionID]
+	if !ok || counter == nil {
+		return false
+	}
+	var ownerHash string
+	if entry := r.owners[sessionID]; entry != nil {
+		ownerHash = entry.hash
+	}
+	id := counter.Add(1)
+	b.add(id, missedStream, r.sseFrame(id, sessionID, ownerHash, data))
+	return true
+}
+
+// closeStream unregisters the channel of a GET stream that ended and keeps
+// the messages still queued on it for replay.
+func (r *sessionRegistry) closeStream(sessionID string, ch chan []byte, b *replayBuffer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.unregisterLocked(sessionID, ch)
+	r.closeQueueLocked(sessionID, ch)
+	if r.replays[sessionID] != b {
+		return // session terminated
+	}
+	for {
+		select {
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			r.keepMissedLocked(sessionID, msg)
+		default:
+			return
+		}
+	}
+}
+
+// replayEvents writes to sw the events a stream reconnecting with the
+// Last-Event-ID header value lastID has missed. It returns false when the
+// client is gone.
+func replayEvents(sw *sseWriter, b *replayBuffer, sessionID, lastID string, stream uint64) bool {
+	id, err := strconv.ParseUint(lastID, 10, 64)
+	if err != nil {
+		// L-8: Truncate Last-Event-ID before logging to prevent log pollution (max 128 chars).
+		if len(lastID) > 128 {
+			lastID = lastID[:128] + "...(truncated)"
+		}
+		slog.Debug("SSE reconnection with an unknown Last-Event-ID, not replaying", "session_id", sessionID, "last_event_id", lastID)
+		return true
+	}
+	frames, complete := b.resume(id, stream)
+	if !complete {
+		slog.Warn("SSE reconnection: events after Last-Event-ID were evicted from the replay buffer",
+			"session_id", sessionID, "last_event_id", id)
+	}
+	for _, frame := range frames {
+		if err := sw.write(frame); err != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,153 @@
+package http
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReplayBuffer_Resume(t *testing.T) {
+	b := newReplayBuffer(4)
+	b.add(1, 1, []byte("one"))
+	b.add(3, 1, []byte("three"))
+	b.add(2, 2, []byte("two")) // another stream of the session, added late
+	b.add(4, missedStream, []byte("four"))
+
+	// Resuming stream 1 after event 1 replays its later events and the
+	// missed ones, not those of stream 2.
+	frames, complete := b.resume(1, 3)
+	if got := joinFrames(frames); got != "three,four" || !complete {
+		t.Fatalf("resume(1) = %q, %v; want \"three,four\", true", got, complete)
+	}
+	// The replayed events now belong to the new stream.
+	frames, _ = b.resume(3, 4)
+	if got := joinFrames(frames); got != "four" {
+		t.Errorf("resume(3) from the new stream = %q, want \"four\"", got)
+	}
+
+	// Evicting events after the Last-Event-ID is reported.
+	b.add(5, 4, []byte("five"))
+	b.add(6, 4, []byte("six"))
+	if frames, complete := b.resume(1, 5); complete {
+		t.Errorf("resume(1) after eviction = %q, complete; want incomplete", joinFrames(frames))
+	}
+	if _, complete := b.resume(6, 5); !complete {
+		t.Error("resume(6) incomplete, want complete")
+	}
+}
+
+func joinFrames(frames [][]byte) string {
+	parts := make([]string, len(frames))
+	for i, f := range frames {
+		parts[i] = string(f)
+	}
+	return strings.Join(parts, ",")
+}
+
+// openStream opens the SSE stream of a session, resuming after lastID when
+// it is not empty, and waits until it is registered.
+func openStream(t *testing.T, srv *httptest.Server, registry *sessionRegistry, sessionID, lastID string) (*http.Response, *bufio.Reader) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set(MCPSessionIDHeader, sessionID)
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	waitStreams(t, registry, sessionID, 1)
+	return resp, bufio.NewReader(resp.Body)
+}
+
+// waitStreams waits until a session has n open channels.
+func waitStreams(t *testing.T, registry *sessionRegistry, sessionID string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		registry.mu.RLock()
+		got := len(registry.sessions[sessionID])
+		registry.mu.RUnlock()
+		if got == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("session has %d streams, want %d", got, n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// readEvent reads the next event of an SSE stream, skipping comments.
+func readEvent(t *testing.T, r *bufio.Reader) (id uint64, data string) {
+	t.Helper()
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read event: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			id, _ = strconv.ParseUint(strings.TrimPrefix(line, "id: "), 10, 64)
+		case strings.HasPrefix(line, "data: "):
+			data += strings.TrimPrefix(line, "data: ")
+		case line == "" && data != "":
+			return id, data
+		}
+	}
+}
+
+func TestHandleGet_ResumesWithLastEventID(t *testing.T) {
+	registry := newSessionRegistry()
+	registry.preRegisterOwner("resume-session", "")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handleGet(w, r, registry)
+	}))
+	t.Cleanup(srv.Close) // after the streams are closed
+
+	first, events := openStream(t, srv, registry, "resume-session", "")
+	registry.broadcast([]byte(`{"n":1}`))
+	registry.broadcast([]byte(`{"n":2}`))
+	id1, _ := readEvent(t, events)
+	id2, data := readEvent(t, events)
+	if data != `{"n":2}` {
+		t.Fatalf("second event = %s", data)
+	}
+
+	// The connection drops; a message sent meanwhile is kept.
+	_ = first.Body.Close()
+	waitStreams(t, registry, "resume-session", 0)
+	if !registry.sendLatest("resume-session", []byte(`{"n":3}`)) {
+		t.Fatal("sendLatest() while reconnecting = false, want true")
+	}
+
+	_, events = openStream(t, srv, registry, "resume-session", strconv.FormatUint(id1, 10))
+	if id, data := readEvent(t, events); id != id2 || data != `{"n":2}` {
+		t.Errorf("first replayed event = %d %s, want %d {\"n\":2}", id, data, id2)
+	}
+	if id, data := readEvent(t, events); id <= id2 || data != `{"n":3}` {
+		t.Errorf("second replayed event = %d %s, want {\"n\":3} after %d", id, data, id2)
+	}
+	registry.broadcast([]byte(`{"n":4}`))
+	if _, data := readEvent(t, events); data != `{"n":4}` {
+		t.Errorf("event after replay = %s, want {\"n\":4}", data)
+	}
+}
+
+func TestSessionRegistry_TerminateDropsReplay(t *testing.T) {
+	registry := newSessionRegistry()
+	registry.preRegisterOwner("gone-session", "")
+	registry.openReplay("gone-session")
+	registry.terminate("gone-session")
+	if registry.sendLatest("gone-session", []byte(`{}`)) {
+		t.Error("sendLatest() to a terminated session = true, want false")
+	}
+}
@@ -0,0 +1,14 @@
+//go:build windows
+
+package http
+
+import (
+	"errors"
+	"syscall"
+)
+
+// reusePortControl fails on Windows, which has no SO_REUSEPORT equivalent
+// (SO_REUSEADDR there allows port hijacking and is deliberately not used).
+func reusePortControl(_, _ string, _ syscall.RawConn) error {
+	return errors.New("reuse_port is not supported on Windows")
+}
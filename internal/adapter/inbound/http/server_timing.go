@@ -0,0 +1,31 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/timing"
+)
+
+// ServerTimingHeader carries the latency breakdown of a request
+// (W3C Server Timing).
+const ServerTimingHeader = "Server-Timing"
+
+// ServerTimingMiddleware places a latency breakdown in the request context
+// for the interceptor chain to fill. The handler turns it into a
+// Server-Timing response header when the chain reports it for the
+// request's identity.
+func ServerTimingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, _ := timing.NewContext(r.Context())
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// setServerTimingHeader writes the latency breakdown recorded for this
+// request, if it is reported.
+func setServerTimingHeader(w http.ResponseWriter, ctx context.Context) {
+	if b := timing.FromContext(ctx); b != nil && b.Reported() {
+		w.Header().Set(ServerTimingHeader, b.ServerTiming())
+	}
+}
@@ -0,0 +1,66 @@
+package http
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+// SLOStatusProvider supplies the current SLO state for /metrics.
+type SLOStatusProvider interface {
+	Status() []service.SLOStatus
+}
+
+// sloCollector exports SLO burn rates, SLIs and alert states. Values are
+// computed at scrape time so they always reflect the current windows.
+type sloCollector struct {
+	provider  SLOStatusProvider
+	burnRate  *prometheus.Desc
+	sli       *prometheus.Desc
+	objective *prometheus.Desc
+	alert     *prometheus.Desc
+}
+
+func newSLOCollector(provider SLOStatusProvider) *sloCollector {
+	return &sloCollector{
+		provider: provider,
+		burnRate: prometheus.NewDesc("sentinelgate_slo_burn_rate",
+			"Error budget burn rate per SLO and window (1 = budget spent exactly over the SLO period)",
+			[]string{"slo", "target", "window"}, nil),
+		sli: prometheus.NewDesc("sentinelgate_slo_sli_percent",
+			"Percentage of good requests per SLO and window",
+			[]string{"slo", "target", "window"}, nil),
+		objective: prometheus.NewDesc("sentinelgate_slo_objective_percent",
+			"SLO objective as a percentage of good requests",
+			[]string{"slo", "target", "type"}, nil),
+		alert: prometheus.NewDesc("sentinelgate_slo_alert",
+			"1 while the SLO burn-rate alert of the given severity is firing",
+			[]string{"slo", "target", "alert"}, nil),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *sloCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.burnRate
+	ch <- c.sli
+	ch <- c.objective
+	ch <- c.alert
+}
+
+// Collect implements prometheus.Collector.
+func (c *sloCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range c.provider.Status() {
+		ch <- prometheus.MustNewConstMetric(c.objective, prometheus.GaugeValue, s.Objective, s.Name, s.Target, s.Type)
+		for _, w := range s.Windows {
+			ch <- prometheus.MustNewConstMetric(c.burnRate, prometheus.GaugeValue, w.BurnRate, s.Name, s.Target, w.Window)
+			ch <- prometheus.MustNewConstMetric(c.sli, prometheus.GaugeValue, w.SLI, s.Name, s.Target, w.Window)
+		}
+		for _, alert := range []string{service.SLOAlertFastBurn, service.SLOAlertSlowBurn} {
+			v := 0.0
+			if s.Alert == alert {
+				v = 1
+			}
+			ch <- prometheus.MustNewConstMetric(c.alert, prometheus.GaugeValue, v, s.Name, s.Target, alert)
+		}
+	}
+}
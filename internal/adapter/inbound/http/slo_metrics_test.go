@@ -0,0 +1,41 @@
+package http
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+type staticSLOStatus []service.SLOStatus
+
+func (s staticSLOStatus) Status() []service.SLOStatus { return s }
+
+func TestSLOCollector(t *testing.T) {
+	provider := staticSLOStatus{{
+		Name: "gw", Target: "gateway", Type: service.SLOTypeAvailability, Objective: 99.9,
+		Windows: []service.SLOWindowStatus{
+			{Window: "5m", Total: 10, Good: 9, SLI: 90, BurnRate: 100},
+		},
+		Alert: service.SLOAlertFastBurn,
+	}}
+
+	expected := `
+# HELP sentinelgate_slo_alert 1 while the SLO burn-rate alert of the given severity is firing
+# TYPE sentinelgate_slo_alert gauge
+sentinelgate_slo_alert{alert="fast_burn",slo="gw",target="gateway"} 1
+sentinelgate_slo_alert{alert="slow_burn",slo="gw",target="gateway"} 0
+# HELP sentinelgate_slo_burn_rate Error budget burn rate per SLO and window (1 = budget spent exactly over the SLO period)
+# TYPE sentinelgate_slo_burn_rate gauge
+sentinelgate_slo_burn_rate{slo="gw",target="gateway",window="5m"} 100
+`
+	if err := testutil.CollectAndCompare(newSLOCollector(provider), strings.NewReader(expected),
+		"sentinelgate_slo_alert", "sentinelgate_slo_burn_rate"); err != nil {
+		t.Error(err)
+	}
+	if n := testutil.CollectAndCount(newSLOCollector(provider)); n != 5 {
+		t.Errorf("collected %d metrics, want 5", n)
+	}
+}
@@ -0,0 +1,446 @@
+package http
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// defaultSSEMaxLineSize is the default longest "data:" line, in bytes.
+	defaultSSEMaxLineSize = 8192
+	// defaultOverflowTTL is how long a spilled message stays fetchable.
+	defaultOverflowTTL = 5 * time.Minute
+	// defaultOverflowMaxBytes caps the memory held by spilled messages.
+	defaultOverflowMaxBytes = 64 << 20
+	// overflowPathPrefix is the path under which spilled messages are served.
+	overflowPathPrefix = "/mcp/overflow/"
+)
+
+// SSEConfig controls how JSON-RPC messages are framed as server-sent events.
+type SSEConfig struct {
+	// MaxLineSize is the longest "data:" line written, in bytes. JSON is
+	// split into several data lines at token boundaries; a single string
+	// token longer than this is never split. Zero uses 8192.
+	MaxLineSize int
+	// MaxEventSize is the largest message sent inline in one event, in bytes.
+	// Larger messages are spilled to the overflow store. Zero means unlimited.
+	MaxEventSize int64
+	// OverflowTTL is how long spilled messages can be fetched. Zero uses 5m.
+	OverflowTTL time.Duration
+	// OverflowMaxBytes caps the total size of spilled messages; the oldest
+	// entries are evicted first. Zero uses 64MB.
+	OverflowMaxBytes int64
+	// Compression enables zstd/gzip encoding of SSE streams and overflow
+	// fetches when the client advertises support in Accept-Encoding.
+	Compression bool
+	// ReplayBufferSize is how many server-initiated events per session are
+	// kept for clients resuming their GET stream with Last-Event-ID. Zero
+	// uses 100.
+	ReplayBufferSize int
+	// QueueSize is how many messages an SSE stream of a session queues for
+	// a client that reads slower than they are produced. Zero uses 100.
+	QueueSize int
+	// QueueMaxBytes caps the bytes queued on one SSE stream. Zero means
+	// no limit besides QueueSize.
+	QueueMaxBytes int64
+	// DropPolicy is what happens when a message does not fit the queue:
+	// DropNewest (the default), DropOldest or DropClose.
+	DropPolicy string
+}
+
+// withDefaults returns the config with zero values replaced by defaults.
+func (c SSEConfig) withDefaults() SSEConfig {
+	if c.MaxLineSize <= 0 {
+		c.MaxLineSize = defaultSSEMaxLineSize
+	}
+	if c.OverflowTTL <= 0 {
+		c.OverflowTTL = defaultOverflowTTL
+	}
+	if c.OverflowMaxBytes <= 0 {
+		c.OverflowMaxBytes = defaultOverflowMaxBytes
+	}
+	if c.ReplayBufferSize <= 0 {
+		c.ReplayBufferSize = defaultReplayBufferSize
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = defaultQueueSize
+	}
+	if c.DropPolicy == "" {
+		c.DropPolicy = DropNewest
+	}
+	return c
+}
+
+// exceedsEventSize reports whether data is too large to send inline.
+func (c SSEConfig) exceedsEventSize(data []byte) bool {
+	return c.MaxEventSize > 0 && int64(len(data)) > c.MaxEventSize
+}
+
+// sseCompact returns msg as compact JSON, or msg unchanged if it is not JSON.
+func sseCompact(msg []byte) []byte {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, msg); err == nil {
+		return buf.Bytes()
+	}
+	return msg
+}
+
+// sseDataLines splits an SSE payload into the lines of its "data:" fields.
+//
+// JSON is compacted and then broken only between tokens (never inside a
+// string), where the newline the client inserts when joining data lines is
+// insignificant whitespace. Anything else is split on its own line breaks
+// (CRLF and bare CR count as LF), so the client reassembles the original
+// text and no line can inject SSE fields.
+func sseDataLines(msg []byte, maxLine int) [][]byte {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, msg); err == nil {
+		return splitJSONLines(buf.Bytes(), maxLine)
+	}
+	s := strings.ReplaceAll(string(msg), "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	parts := strings.Split(s, "\n")
+	lines := make([][]byte, len(parts))
+	for i, p := range parts {
+		lines[i] = []byte(p)
+	}
+	return lines
+}
+
+// splitJSONLines breaks compact JSON into lines of at most maxLine bytes,
+// cutting only after a structural ',', ':', '{' or '['. A line is longer
+// than maxLine only when no such cut point exists in it.
+func splitJSONLines(data []byte, maxLine int) [][]byte {
+	if maxLine <= 0 || len(data) <= maxLine {
+		return [][]byte{data}
+	}
+	var lines [][]byte
+	start, cut := 0, -1
+	inString, escaped := false, false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case inString:
+			if escaped {
+				escaped = false
+			} else if c == '\\' {
+				escaped = true
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == ',' || c == ':' || c == '{' || c == '[':
+			cut = i + 1
+		}
+		if i+1-start > maxLine && cut > start {
+			lines = append(lines, data[start:cut])
+			start = cut
+		}
+	}
+	return append(lines, data[start:])
+}
+
+// appendSSEEvent appends one complete event (id, event type, data lines and
+// the terminating blank line) to dst.
+func appendSSEEvent(dst []byte, id uint64, event string, data []byte, maxLine int) []byte {
+	dst = append(dst, "id: "...)
+	dst = strconv.AppendUint(dst, id, 10)
+	dst = append(dst, "\nevent: "...)
+	dst = append(dst, event...)
+	dst = append(dst, '\n')
+	for _, line := range sseDataLines(data, maxLine) {
+		dst = append(dst, "data: "...)
+		dst = append(dst, line...)
+		dst = append(dst, '\n')
+	}
+	return append(dst, '\n')
+}
+
+// negotiateEncoding picks a content coding from Accept-Encoding, preferring
+// zstd over gzip. Codings with q=0 are refused. Returns "" for identity.
+func negotiateEncoding(acceptEncoding string) string {
+	var zstdOK, gzipOK bool
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err != nil || v <= 0 {
+				continue
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "zstd":
+			zstdOK = true
+		case "gzip", "x-gzip":
+			gzipOK = true
+		}
+	}
+	switch {
+	case zstdOK:
+		return "zstd"
+	case gzipOK:
+		return "gzip"
+	}
+	return ""
+}
+
+// streamEncoder is a compressing writer that can emit everything written so
+// far as a complete block.
+type streamEncoder interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// sseWriter writes SSE frames, optionally through a compressor, and pushes
+// each one to the client immediately.
+type sseWriter struct {
+	w       io.Writer
+	enc     streamEncoder
+	flusher http.Flusher
+}
+
+// newSSEWriter negotiates compression (when enabled) and sets the
+// Content-Encoding and Vary headers. It must run before WriteHeader.
+func newSSEWriter(w http.ResponseWriter, r *http.Request, cfg SSEConfig) *sseWriter {
+	sw := &sseWriter{w: w}
+	sw.flusher, _ = w.(http.Flusher)
+	if !cfg.Compression {
+		return sw
+	}
+	w.Header().Add("Vary", "Accept-Encoding")
+	switch negotiateEncoding(r.Header.Get("Accept-Encoding")) {
+	case "zstd":
+		enc, err := zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.SpeedFastest), zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			return sw
+		}
+		sw.enc = enc
+		w.Header().Set("Content-Encoding", "zstd")
+	case "gzip":
+		sw.enc, _ = gzip.NewWriterLevel(w, gzip.BestSpeed)
+		w.Header().Set("Content-Encoding", "gzip")
+	default:
+		return sw
+	}
+	w.Header().Del("Content-Length")
+	sw.w = sw.enc
+	return sw
+}
+
+// write sends p and flushes the encoder and the connection.
+func (s *sseWriter) write(p []byte) error {
+	if _, err := s.w.Write(p); err != nil {
+		return err
+	}
+	if s.enc != nil {
+		if err := s.enc.Flush(); err != nil {
+			return err
+		}
+	}
+	if s.flusher != nil {
+		s.flusher.Flush()
+	}
+	return nil
+}
+
+// close finishes the compressed stream, if any.
+func (s *sseWriter) close() {
+	if s.enc != nil {
+		_ = s.enc.Close()
+	}
+}
+
+// overflowEntry is a message too large to send inline.
+type overflowEntry struct {
+	data      []byte
+	sessionID string
+	ownerHash string
+	expiresAt time.Time
+}
+
+// overflowStore keeps spilled messages until they expire or are evicted to
+// stay under maxBytes. Expired entries are purged on every put.
+type overflowStore struct {
+	mu       sync.Mutex
+	entries  map[string]*overflowEntry
+	order    []string // insertion order, oldest first
+	size     int64
+	maxBytes int64
+	ttl      time.Duration
+	now      func() time.Time
+}
+
+func newOverflowStore(maxBytes int64, ttl time.Duration) *overflowStore {
+	return &overflowStore{
+		entries:  make(map[string]*overflowEntry),
+		maxBytes: maxBytes,
+		ttl:      ttl,
+		now:      time.Now,
+	}
+}
+
+// put stores data for the given session and returns its ID and expiry.
+// Returns ok=false when data alone exceeds the store capacity.
+func (s *overflowStore) put(data []byte, sessionID, ownerHash string) (id string, expiresAt time.Time, ok bool) {
+	if int64(len(data)) > s.maxBytes {
+		return "", time.Time{}, false
+	}
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", time.Time{}, false
+	}
+	id = hex.EncodeToString(b[:])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.purgeLocked(now)
+	for len(s.order) > 0 && s.size+int64(len(data)) > s.maxBytes {
+		s.removeLocked(s.order[0])
+	}
+	expiresAt = now.Add(s.ttl)
+	s.entries[id] = &overflowEntry{data: data, sessionID: sessionID, ownerHash: ownerHash, expiresAt: expiresAt}
+	s.order = append(s.order, id)
+	s.size += int64(len(data))
+	return id, expiresAt, true
+}
+
+// get returns a live entry by ID.
+func (s *overflowStore) get(id string) (*overflowEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[id]
+	if !ok || !s.now().Before(e.expiresAt) {
+		return nil, false
+	}
+	return e, true
+}
+
+// dropSession removes every entry belonging to a terminated session.
+func (s *overflowStore) dropSession(sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, e := range s.entries {
+		if e.sessionID == sessionID {
+			s.removeLocked(id)
+		}
+	}
+}
+
+func (s *overflowStore) purgeLocked(now time.Time) {
+	for len(s.order) > 0 {
+		e, ok := s.entries[s.order[0]]
+		if ok && now.Before(e.expiresAt) {
+			return
+		}
+		s.removeLocked(s.order[0])
+	}
+}
+
+func (s *overflowStore) removeLocked(id string) {
+	if e, ok := s.entries[id]; ok {
+		s.size -= int64(len(e.data))
+		delete(s.entries, id)
+	}
+	for i, v := range s.order {
+		if v == id {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// overflowNotice is the data of an "overflow" event.
+type overflowNotice struct {
+	OverflowID string    `json:"overflow_id"`
+	URL        string    `json:"url"`
+	Size       int       `json:"size"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// sseFrame builds the event for msg. Messages over MaxEventSize are spilled
+// and replaced by an "overflow" event pointing at the fetch URL; when they
+// cannot be spilled (no session, store full) they are sent inline.
+func (r *sessionRegistry) sseFrame(id uint64, sessionID, ownerHash string, msg []byte) []byte {
+	cfg := r.sseConfig()
+	data := sseCompact(msg)
+	if cfg.exceedsEventSize(data) && sessionID != "" && r.overflow != nil {
+		if oid, exp, ok := r.overflow.put(data, sessionID, ownerHash); ok {
+			notice, _ := json.Marshal(overflowNotice{
+				OverflowID: oid,
+				URL:        overflowPathPrefix + oid,
+				Size:       len(data),
+				ExpiresAt:  exp.UTC(),
+			})
+			return appendSSEEvent(nil, id, "overflow", notice, cfg.MaxLineSize)
+		}
+	}
+	return appendSSEEvent(nil, id, "message", data, cfg.MaxLineSize)
+}
+
+// wantsSSEResponse reports whether a POST response should be streamed as an
+// SSE event. A response too large for one event goes back as plain JSON
+// when the client accepts both; otherwise it is spilled (see sseFrame).
+func wantsSSEResponse(accept string, cfg SSEConfig, response []byte) bool {
+	if !strings.Contains(accept, "text/event-stream") {
+		return false
+	}
+	return !strings.Contains(accept, "application/json") || !cfg.exceedsEventSize(sseCompact(response))
+}
+
+// sseConfig returns the registry's SSE settings; safe on a nil registry.
+func (r *sessionRegistry) sseConfig() SSEConfig {
+	if r == nil {
+		return SSEConfig{}.withDefaults()
+	}
+	return r.sse
+}
+
+// handleOverflowGet serves a spilled message to the session that owns it.
+func handleOverflowGet(w http.ResponseWriter, r *http.Request, registry *sessionRegistry) {
+	setCORSHeaders(w, r)
+
+	id := strings.TrimPrefix(r.URL.Path, overflowPathPrefix)
+	sessionID := r.Header.Get(MCPSessionIDHeader)
+	if sessionID == "" {
+		writeJSONError(w, http.StatusBadRequest, "Mcp-Session-Id header required")
+		return
+	}
+	if registry == nil || registry.overflow == nil {
+		writeJSONError(w, http.StatusNotFound, "Overflow message not found")
+		return
+	}
+	entry, ok := registry.overflow.get(id)
+	// An entry of another session is reported as missing so IDs cannot be
+	// probed across sessions.
+	if !ok || entry.sessionID != sessionID {
+		writeJSONError(w, http.StatusNotFound, "Overflow message not found")
+		return
+	}
+	if entry.ownerHash != ownerHashFromRequest(r) {
+		writeJSONError(w, http.StatusForbidden, "Forbidden: session not owned by caller")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set(MCPProtocolVersionHeader, MCPProtocolVersion)
+	sw := newSSEWriter(w, r, registry.sse)
+	defer sw.close()
+	if sw.enc == nil {
+		w.Header().Set("Content-Length", strconv.Itoa(len(entry.data)))
+	}
+	w.WriteHeader(http.StatusOK)
+	_ = sw.write(entry.data)
+}
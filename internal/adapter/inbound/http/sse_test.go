@@ -0,0 +1,257 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+// parseSSEData joins the data lines of the first event in stream the way an
+// EventSource does, returning the event type and the reassembled data.
+func parseSSEData(t *testing.T, stream string) (string, string) {
+	t.Helper()
+	event := "message"
+	var data []string
+	for _, line := range strings.Split(stream, "\n") {
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = append(data, strings.TrimPrefix(line, "data: "))
+		case line == "" && len(data) > 0:
+			return event, strings.Join(data, "\n")
+		}
+	}
+	t.Fatalf("no complete event in %q", stream)
+	return "", ""
+}
+
+func TestAppendSSEEvent_SplitsJSONAtTokenBoundaries(t *testing.T) {
+	msg := []byte(`{"jsonrpc":"2.0","id":1,"result":{"items":["aaaaaaaaaa","bbbbbbbbbb","cc,{[:cc"],"n":12345}}`)
+	frame := string(appendSSEEvent(nil, 7, "message", msg, 16))
+
+	if !strings.HasPrefix(frame, "id: 7\nevent: message\ndata: ") || !strings.HasSuffix(frame, "\n\n") {
+		t.Fatalf("frame = %q, want id/event header and blank-line terminator", frame)
+	}
+	lines := strings.Count(frame, "data: ")
+	if lines < 3 {
+		t.Errorf("frame has %d data lines, want the message split", lines)
+	}
+	_, data := parseSSEData(t, frame)
+	var got, want any
+	if err := json.Unmarshal([]byte(data), &got); err != nil {
+		t.Fatalf("reassembled data is not JSON: %v\n%s", err, data)
+	}
+	_ = json.Unmarshal(msg, &want)
+	gotJSON, _ := json.Marshal(got)
+	wantJSON, _ := json.Marshal(want)
+	if !bytes.Equal(gotJSON, wantJSON) {
+		t.Errorf("reassembled = %s, want %s", gotJSON, wantJSON)
+	}
+	if !strings.Contains(data, `"cc,{[:cc"`) {
+		t.Errorf("string token was split: %q", data)
+	}
+}
+
+func TestAppendSSEEvent_SmallJSONSingleLine(t *testing.T) {
+	frame := string(appendSSEEvent(nil, 1, "message", []byte("{\n  \"a\": 1\n}"), 8192))
+	if frame != "id: 1\nevent: message\ndata: {\"a\":1}\n\n" {
+		t.Errorf("frame = %q", frame)
+	}
+}
+
+func TestAppendSSEEvent_NonJSONMultiLine(t *testing.T) {
+	frame := string(appendSSEEvent(nil, 2, "message", []byte("line one\r\nline two\revent: injected\nid: 99"), 8192))
+	want := "id: 2\nevent: message\ndata: line one\ndata: line two\ndata: event: injected\ndata: id: 99\n\n"
+	if frame != want {
+		t.Errorf("frame = %q, want %q", frame, want)
+	}
+	_, data := parseSSEData(t, frame)
+	if data != "line one\nline two\nevent: injected\nid: 99" {
+		t.Errorf("reassembled = %q", data)
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"gzip", "gzip"},
+		{"gzip, deflate, br, zstd", "zstd"},
+		{"zstd;q=0, gzip;q=0.5", "gzip"},
+		{"gzip;q=0", ""},
+		{"identity", ""},
+	}
+	for _, tt := range tests {
+		if got := negotiateEncoding(tt.header); got != tt.want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func newOverflowRegistry(maxEvent int64) *sessionRegistry {
+	r := newSessionRegistry()
+	r.sse = SSEConfig{MaxEventSize: maxEvent}.withDefaults()
+	r.overflow = newOverflowStore(r.sse.OverflowMaxBytes, r.sse.OverflowTTL)
+	return r
+}
+
+func TestSSEFrame_SpillsOversizedMessage(t *testing.T) {
+	registry := newOverflowRegistry(64)
+	registry.preRegisterOwner("spill-session", "")
+	big := []byte(`{"jsonrpc":"2.0","id":1,"result":{"text":"` + strings.Repeat("x", 200) + `"}}`)
+
+	event, data := parseSSEData(t, string(registry.sseFrame(3, "spill-session", "", big)))
+	if event != "overflow" {
+		t.Fatalf("event = %q, want overflow", event)
+	}
+	var notice overflowNotice
+	if err := json.Unmarshal([]byte(data), &notice); err != nil {
+		t.Fatalf("overflow data: %v", err)
+	}
+	if notice.Size != len(big) || notice.URL != overflowPathPrefix+notice.OverflowID {
+		t.Errorf("notice = %+v", notice)
+	}
+
+	fetch := func(sessionID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, notice.URL, nil)
+		req.Header.Set(MCPSessionIDHeader, sessionID)
+		rec := httptest.NewRecorder()
+		mcpHandler(nil, registry, nil).ServeHTTP(rec, req)
+		return rec
+	}
+	rec := fetch("spill-session")
+	if rec.Code != http.StatusOK || rec.Body.String() != string(big) {
+		t.Errorf("fetch = %d %q, want 200 with the original message", rec.Code, rec.Body.String())
+	}
+	if rec := fetch("other-session"); rec.Code != http.StatusNotFound {
+		t.Errorf("fetch from another session = %d, want 404", rec.Code)
+	}
+
+	registry.terminate("spill-session")
+	if rec := fetch("spill-session"); rec.Code != http.StatusNotFound {
+		t.Errorf("fetch after terminate = %d, want 404", rec.Code)
+	}
+}
+
+func TestSSEFrame_InlineWithoutSession(t *testing.T) {
+	registry := newOverflowRegistry(8)
+	event, _ := parseSSEData(t, string(registry.sseFrame(1, "", "", []byte(`{"a":"0123456789"}`))))
+	if event != "message" {
+		t.Errorf("event = %q, want message sent inline when there is no session", event)
+	}
+}
+
+func TestOverflowStore_ExpiryAndEviction(t *testing.T) {
+	s := newOverflowStore(10, time.Minute)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	a, _, _ := s.put([]byte("aaaaaa"), "s", "")
+	b, _, _ := s.put([]byte("bbbbbb"), "s", "")
+	if _, ok := s.get(a); ok {
+		t.Error("oldest entry should be evicted when the store is full")
+	}
+	if _, ok := s.get(b); !ok {
+		t.Error("newest entry should be retained")
+	}
+	if _, _, ok := s.put(make([]byte, 11), "s", ""); ok {
+		t.Error("put larger than the store should fail")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := s.get(b); ok {
+		t.Error("expired entry should not be returned")
+	}
+	s.put([]byte("c"), "s", "")
+	if s.size != 1 || len(s.order) != 1 {
+		t.Errorf("size = %d, entries = %d after purge, want 1/1", s.size, len(s.order))
+	}
+}
+
+func TestWantsSSEResponse(t *testing.T) {
+	cfg := SSEConfig{MaxEventSize: 16}.withDefaults()
+	small := []byte(`{"result":1}`)
+	big := []byte(`{"result":"0123456789abcdef"}`)
+	tests := []struct {
+		accept string
+		msg    []byte
+		want   bool
+	}{
+		{"application/json, text/event-stream", small, true},
+		{"application/json, text/event-stream", big, false},
+		{"text/event-stream", big, true},
+		{"application/json", small, false},
+	}
+	for _, tt := range tests {
+		if got := wantsSSEResponse(tt.accept, cfg, tt.msg); got != tt.want {
+			t.Errorf("wantsSSEResponse(%q, %d bytes) = %v, want %v", tt.accept, len(tt.msg), got, tt.want)
+		}
+	}
+}
+
+func TestHandleGet_CompressedStream(t *testing.T) {
+	for _, enc := range []string{"gzip", "zstd"} {
+		t.Run(enc, func(t *testing.T) {
+			registry := newSessionRegistry()
+			registry.sse.Compression = true
+			registry.preRegisterOwner("gz-session", "")
+
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			req := httptest.NewRequest(http.MethodGet, "/mcp", nil).WithContext(ctx)
+			req.Header.Set(MCPSessionIDHeader, "gz-session")
+			req.Header.Set("Accept-Encoding", enc)
+			rec := httptest.NewRecorder()
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				handleGet(rec, req, registry)
+			}()
+			time.Sleep(50 * time.Millisecond)
+			registry.broadcast([]byte(`{"jsonrpc":"2.0","method":"notifications/progress"}`))
+			time.Sleep(50 * time.Millisecond)
+			cancel()
+			<-done
+
+			if ce := rec.Header().Get("Content-Encoding"); ce != enc {
+				t.Fatalf("Content-Encoding = %q, want %s", ce, enc)
+			}
+			var r io.Reader
+			if enc == "gzip" {
+				zr, err := gzip.NewReader(rec.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				r = zr
+			} else {
+				zr, err := zstd.NewReader(rec.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer zr.Close()
+				r = zr
+			}
+			body, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("decompress: %v", err)
+			}
+			if !strings.Contains(string(body), ": connected\n\n") ||
+				!strings.Contains(string(body), `data: {"jsonrpc":"2.0","method":"notifications/progress"}`) {
+				t.Errorf("decompressed stream = %q", body)
+			}
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"net"
 	"net/http"
 	"runtime/debug"
+	"sync"
 	"time"
 
 	"github.com/Sentinel-Gate/Sentinelgate/internal/port/inbound"
@@ -26,16 +27,29 @@ type HTTPTransport struct {
 	proxyService       *service.ProxyService
 	server             *http.Server
 	addr               string
+	extraAddrs         []string // Additional listen addresses
+	reusePort          bool     // Set SO_REUSEPORT on listening sockets
 	allowedOrigins     []string
 	allowedHosts       []string       // Allowed Host header values for DNS rebinding protection
 	metricsToken       string         // Bearer token for /metrics endpoint (empty = localhost only)
 	certFile           string
 	keyFile            string
+	certs              *CertReloader  // Serves and reloads the TLS certificate (nil = plain HTTP)
+	certReloadInterval time.Duration  // How often certificate files are checked for changes
 	sessions           *sessionRegistry
 	logger             *slog.Logger
 	extraHandler       http.Handler   // Optional extra handler (e.g., admin UI)
 	metrics            *Metrics       // Prometheus metrics
 	healthChecker      *HealthChecker // Health check handler
+	maxBodySize        int64          // Max MCP POST body size in bytes (0 = default 1MB)
+	upstreamTokenHeader string        // Inbound header carrying the end-user OAuth token (empty = disabled)
+	correlationHeader  string         // Inbound header carrying the correlation ID (empty = not read)
+	provenanceHeaders  bool           // Expose tool result provenance as response headers
+	serverTiming       bool           // Expose the latency breakdown as a Server-Timing header
+	sloStatus          SLOStatusProvider // Optional SLO state exported on /metrics
+	dnsStats           DNSStatsProvider  // Optional DNS counters exported on /metrics
+	admission          *admission        // Load shedding under memory pressure (nil = disabled)
+	upstreamRegistry   UpstreamRegistry  // Accepts reverse upstream registrations (nil = disabled)
 }
 
 // Option is a functional option for configuring HTTPTransport.
@@ -43,12 +57,31 @@ type Option func(*HTTPTransport)
 
 // WithAddr sets the listen address for the HTTP server.
 // Default is "127.0.0.1:8080" (localhost only).
+// The host selects the address family: "0.0.0.0:8080" listens on IPv4 only,
+// "[::]:8080" on IPv6 only and ":8080" on both.
 func WithAddr(addr string) Option {
 	return func(t *HTTPTransport) {
 		t.addr = addr
 	}
 }
 
+// WithExtraAddrs adds listen addresses served alongside the main one, e.g.
+// one per network interface. As with WithAddr, an IPv4 host listens on IPv4
+// only, an IPv6 host on IPv6 only and an empty host on both.
+func WithExtraAddrs(addrs ...string) Option {
+	return func(t *HTTPTransport) {
+		t.extraAddrs = addrs
+	}
+}
+
+// WithReusePort sets SO_REUSEPORT on the listening sockets so that several
+// processes can bind the same addresses. Not supported on Windows.
+func WithReusePort(enabled bool) Option {
+	return func(t *HTTPTransport) {
+		t.reusePort = enabled
+	}
+}
+
 // WithTLS enables TLS with the provided certificate and key files.
 // If not set, the server runs without TLS (plain HTTP).
 func WithTLS(certFile, keyFile string) Option {
@@ -58,6 +91,16 @@ func WithTLS(certFile, keyFile string) Option {
 	}
 }
 
+// WithCertReloader enables TLS with a certificate served by r, checking
+// its files for changes every interval (0 = DefaultCertReloadInterval).
+// It takes precedence over WithTLS.
+func WithCertReloader(r *CertReloader, interval time.Duration) Option {
+	return func(t *HTTPTransport) {
+		t.certs = r
+		t.certReloadInterval = interval
+	}
+}
+
 // WithAllowedOrigins sets the allowed origins for DNS rebinding protection.
 // If empty, all requests with an Origin header are blocked (local-only mode).
 // Example: []string{"https://example.com", "http://localhost:3000"}
@@ -106,6 +149,101 @@ func WithHealthChecker(hc *HealthChecker) Option {
 	}
 }
 
+// WithMaxRequestBodySize sets the maximum MCP POST body size in bytes.
+// Larger bodies are rejected while streaming, before being fully buffered.
+// Zero or negative keeps the 1MB default.
+func WithMaxRequestBodySize(n int64) Option {
+	return func(t *HTTPTransport) {
+		t.maxBodySize = n
+	}
+}
+
+// WithUpstreamTokenHeader enables extraction of the end user's OAuth token
+// from the named request header for upstream token passthrough/exchange.
+func WithUpstreamTokenHeader(header string) Option {
+	return func(t *HTTPTransport) {
+		t.upstreamTokenHeader = header
+	}
+}
+
+// WithCorrelationHeader takes the correlation ID of requests from the named
+// request header.
+func WithCorrelationHeader(header string) Option {
+	return func(t *HTTPTransport) {
+		t.correlationHeader = header
+	}
+}
+
+// WithProvenanceHeaders exposes tool result provenance (serving upstream,
+// scan verdict, policy rule, latency) as X-SentinelGate-* response headers.
+func WithProvenanceHeaders(enabled bool) Option {
+	return func(t *HTTPTransport) {
+		t.provenanceHeaders = enabled
+	}
+}
+
+// WithServerTiming exposes the latency breakdown of requests (auth, policy,
+// scan, upstream) as a Server-Timing response header. The interceptor chain
+// decides which identities get it.
+func WithServerTiming(enabled bool) Option {
+	return func(t *HTTPTransport) {
+		t.serverTiming = enabled
+	}
+}
+
+// WithSLOStatus exports SLO burn rates, SLIs and alert states on /metrics.
+func WithSLOStatus(p SLOStatusProvider) Option {
+	return func(t *HTTPTransport) {
+		t.sloStatus = p
+	}
+}
+
+// WithDNSStats exports DNS cache, failure and rebinding counters on /metrics.
+func WithDNSStats(p DNSStatsProvider) Option {
+	return func(t *HTTPTransport) {
+		t.dnsStats = p
+	}
+}
+
+// WithLoadShedder enables admission control: while the shedder reports
+// overload, list requests and progress/log notifications are refused with
+// 503 and Retry-After, and progress/log notifications to clients are
+// dropped. Tool calls and the admin API are not affected.
+func WithLoadShedder(s LoadShedder) Option {
+	return func(t *HTTPTransport) {
+		t.admission = newAdmission(s, nil)
+	}
+}
+
+// WithSSEConfig sets SSE framing, oversized message spilling and stream
+// compression. Zero fields keep their defaults.
+func WithSSEConfig(cfg SSEConfig) Option {
+	return func(t *HTTPTransport) {
+		t.sessions.sse = cfg.withDefaults()
+		t.sessions.overflow = nil
+		if cfg.MaxEventSize > 0 {
+			t.sessions.overflow = newOverflowStore(t.sessions.sse.OverflowMaxBytes, t.sessions.sse.OverflowTTL)
+		}
+	}
+}
+
+// WithWebSocket enables WebSocket upgrades on the MCP endpoint.
+func WithWebSocket(cfg WebSocketConfig) Option {
+	return func(t *HTTPTransport) {
+		t.sessions.ws = cfg.withDefaults()
+	}
+}
+
+// WithQueueDropCallback sets a callback invoked when server-initiated
+// messages are dropped because an SSE stream fell behind; drops of one
+// stream are reported at most once a minute and when it closes. cb must not
+// block or call into the transport.
+func WithQueueDropCallback(cb func(QueueDrop)) Option {
+	return func(t *HTTPTransport) {
+		t.sessions.onQueueDrop = cb
+	}
+}
+
 // WithSessionTerminateCallback sets a callback invoked when a session is terminated.
 // Used to clean up per-session state in other components (e.g., framework tracking).
 func WithSessionTerminateCallback(cb func(sessionID string)) Option {
@@ -144,6 +282,13 @@ func (t *HTTPTransport) Start(ctx context.Context) error {
 		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
 	)
 	t.metrics = NewMetrics(reg)
+	t.sessions.metrics.Store(t.metrics)
+	if t.sloStatus != nil {
+		reg.MustRegister(newSLOCollector(t.sloStatus))
+	}
+	if t.dnsStats != nil {
+		reg.MustRegister(newDNSCollector(t.dnsStats))
+	}
 
 	// Build middleware chain: Metrics -> RequestID -> RealIP -> DNSRebinding -> APIKey -> Handler
 	// Middleware order (outermost first):
@@ -152,8 +297,29 @@ func (t *HTTPTransport) Start(ctx context.Context) error {
 	// 3. RealIP - Extract client IP from X-Forwarded-For
 	// 4. DNSRebinding - Security check for Origin header
 	// 5. APIKey - Extract API key and identity
-	// 6. Handler - MCP request handling
-	mcpHandler := mcpHandler(t.proxyService, t.sessions)
+	// 6. Admission - Load shedding state for the handler (only if enabled)
+	// 7. UpstreamToken - Extract end-user OAuth token (only if token exchange is enabled)
+	// 8. Correlation - Extract the client's correlation ID (only if a header is set)
+	// 9. Provenance - Collect tool result provenance for response headers (only if enabled)
+	// 10. ServerTiming - Collect the latency breakdown for the Server-Timing header (only if enabled)
+	// 11. Handler - MCP request handling
+	mcpHandler := mcpHandler(t.proxyService, t.sessions, &bodyReader{maxSize: t.maxBodySize, metrics: t.metrics})
+	if t.serverTiming {
+		mcpHandler = ServerTimingMiddleware(mcpHandler)
+	}
+	if t.provenanceHeaders {
+		mcpHandler = ProvenanceMiddleware(mcpHandler)
+	}
+	if t.correlationHeader != "" {
+		mcpHandler = CorrelationIDMiddleware(t.correlationHeader)(mcpHandler)
+	}
+	if t.upstreamTokenHeader != "" {
+		mcpHandler = UpstreamTokenMiddleware(t.upstreamTokenHeader)(mcpHandler)
+	}
+	if t.admission != nil {
+		t.admission.metrics.Store(t.metrics)
+		mcpHandler = t.admission.middleware(mcpHandler)
+	}
 	mcpHandler = APIKeyMiddleware(mcpHandler)
 	mcpHandler = DNSRebindingProtection(t.allowedOrigins, t.allowedHosts...)(mcpHandler)
 	mcpHandler = RealIPMiddleware(mcpHandler)
@@ -170,9 +336,11 @@ func (t *HTTPTransport) Start(ctx context.Context) error {
 	}
 	if t.healthChecker != nil {
 		mux.Handle("/health", t.healthChecker.Handler())
+		mux.Handle("/healthz", t.healthChecker.Handler())
 	} else {
 		// Fallback to simple handler if no checker configured
 		mux.Handle("/health", healthHandler())
+		mux.Handle("/healthz", healthHandler())
 	}
 	mux.Handle("/metrics", t.metricsAuthHandler(promhttp.HandlerFor(reg, promhttp.HandlerOpts{
 		Registry: reg,
@@ -207,6 +375,16 @@ func (t *HTTPTransport) Start(ctx context.Context) error {
 	}))
 	// All other .well-known paths return 404 (prevents catch-all from returning 400).
 	mux.Handle("/.well-known/", http.NotFoundHandler())
+	// Reverse upstream registration authenticates with its own token, not
+	// an API key.
+	if t.upstreamRegistry != nil {
+		var register http.Handler = upstreamRegisterHandler(t.upstreamRegistry, t.sessions.wsConfig().PingInterval)
+		register = DNSRebindingProtection(t.allowedOrigins, t.allowedHosts...)(register)
+		register = RealIPMiddleware(register)
+		register = RequestIDMiddleware(t.logger)(register)
+		register = MetricsMiddleware(t.metrics)(register)
+		mux.Handle(upstreamRegisterPath, register)
+	}
 	// MCP on explicit paths (takes priority over catch-all in Go's ServeMux)
 	mux.Handle("/mcp", mcpHandler)
 	mux.Handle("/mcp/", mcpHandler)
@@ -225,12 +403,21 @@ func (t *HTTPTransport) Start(ctx context.Context) error {
 		ReadTimeout:       30 * time.Second,
 	}
 
-	// Configure TLS if certificates provided
+	// Configure TLS if certificates provided. The certificate is served
+	// through GetCertificate so rotated files are picked up without restart.
 	// L-9: Prefer AEAD cipher suites (GCM, ChaCha20) and exclude CBC mode ciphers.
 	// Go 1.22+ defaults are already secure, but explicit preference improves defense in depth.
-	if t.certFile != "" && t.keyFile != "" {
+	if t.certs == nil && t.certFile != "" && t.keyFile != "" {
+		certs, err := NewCertReloader(t.certFile, t.keyFile, t.logger)
+		if err != nil {
+			return err
+		}
+		t.certs = certs
+	}
+	if t.certs != nil {
 		t.server.TLSConfig = &tls.Config{
-			MinVersion: tls.VersionTLS12,
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: t.certs.GetCertificate,
 			CipherSuites: []uint16{
 				tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
 				tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
@@ -242,24 +429,42 @@ func (t *HTTPTransport) Start(ctx context.Context) error {
 		}
 	}
 
-	// Channel for server errors
-	errCh := make(chan error, 1)
+	// Bind every address before serving so a bad address fails Start
+	// instead of leaving the server half up.
+	listeners, err := t.listen(ctx)
+	if err != nil {
+		return err
+	}
 
-	// Start server in goroutine
+	// Channel for server errors, closed once every listener has stopped
+	errCh := make(chan error, len(listeners))
+	var wg sync.WaitGroup
+
+	// Serve each listener in its own goroutine
+	for _, ln := range listeners {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var err error
+			if t.certs != nil {
+				t.logger.Info("starting HTTPS server", "addr", ln.Addr().String())
+				err = t.server.ServeTLS(ln, "", "")
+			} else {
+				t.logger.Info("starting HTTP server", "addr", ln.Addr().String())
+				err = t.server.Serve(ln)
+			}
+			if err != nil && err != http.ErrServerClosed {
+				errCh <- err
+			}
+		}()
+	}
 	go func() {
-		var err error
-		if t.certFile != "" && t.keyFile != "" {
-			t.logger.Info("starting HTTPS server", "addr", t.addr)
-			err = t.server.ListenAndServeTLS(t.certFile, t.keyFile)
-		} else {
-			t.logger.Info("starting HTTP server", "addr", t.addr)
-			err = t.server.ListenAndServe()
-		}
-		if err != nil && err != http.ErrServerClosed {
-			errCh <- err
-		}
+		wg.Wait()
 		close(errCh)
 	}()
+	if t.certs != nil {
+		go t.certs.Run(ctx, t.certReloadInterval)
+	}
 
 	// Wait for context cancellation or server error.
 	// On context cancel, return immediately — the lifecycle manager
@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"runtime"
 	"testing"
 	"time"
 
@@ -236,3 +238,71 @@ func TestTransport_StartAndShutdown(t *testing.T) {
 		t.Fatal("Start() did not return within 5 seconds after cancel")
 	}
 }
+
+func TestListenNetwork(t *testing.T) {
+	tests := map[string]string{
+		"127.0.0.1:8080":    "tcp4",
+		"0.0.0.0:8080":      "tcp4",
+		"[::]:8080":         "tcp6",
+		"[fd00::5]:8080":    "tcp6",
+		"[fe80::1%eth0]:80": "tcp6",
+		":8080":             "tcp",
+		"localhost:8080":    "tcp",
+	}
+	for addr, want := range tests {
+		if got := listenNetwork(addr); got != want {
+			t.Errorf("listenNetwork(%q) = %q, want %q", addr, got, want)
+		}
+	}
+}
+
+func TestTransport_ListenExtraAddrs(t *testing.T) {
+	transport := &HTTPTransport{addr: "127.0.0.1:0", extraAddrs: []string{"127.0.0.1:0"}}
+	listeners, err := transport.listen(context.Background())
+	if err != nil {
+		t.Fatalf("listen() error: %v", err)
+	}
+	defer func() {
+		for _, ln := range listeners {
+			_ = ln.Close()
+		}
+	}()
+	if len(listeners) != 2 {
+		t.Fatalf("got %d listeners, want 2", len(listeners))
+	}
+	if listeners[0].Addr().String() == listeners[1].Addr().String() {
+		t.Errorf("listeners share address %s", listeners[0].Addr())
+	}
+}
+
+func TestTransport_ListenFailureClosesOpened(t *testing.T) {
+	busy, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = busy.Close() }()
+
+	transport := &HTTPTransport{addr: "127.0.0.1:0", extraAddrs: []string{busy.Addr().String()}}
+	if _, err := transport.listen(context.Background()); err == nil {
+		t.Fatal("listen() should fail when an address is in use")
+	}
+}
+
+func TestTransport_ListenReusePort(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SO_REUSEPORT is not supported on Windows")
+	}
+	first := &HTTPTransport{addr: "127.0.0.1:0", reusePort: true}
+	listeners, err := first.listen(context.Background())
+	if err != nil {
+		t.Fatalf("listen() error: %v", err)
+	}
+	defer func() { _ = listeners[0].Close() }()
+
+	second := &HTTPTransport{addr: listeners[0].Addr().String(), reusePort: true}
+	shared, err := second.listen(context.Background())
+	if err != nil {
+		t.Fatalf("second listen() with reuse_port error: %v", err)
+	}
+	_ = shared[0].Close()
+}
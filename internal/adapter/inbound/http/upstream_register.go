@@ -0,0 +1,157 @@
+package http
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/port/outbound"
+)
+
+// upstreamRegisterPath is where servers of reverse upstreams register.
+const upstreamRegisterPath = "/upstream/register"
+
+// reverseMaxMessage bounds a message from a reverse upstream server, which
+// can carry large tool results.
+const reverseMaxMessage = 32 << 20
+
+// UpstreamRegistry accepts connections from servers of reverse upstreams.
+type UpstreamRegistry interface {
+	// Authenticate returns the upstream ID a registration token belongs to.
+	Authenticate(ctx context.Context, token string) (string, error)
+	// Register adds a connection for the upstream. The returned channel is
+	// closed when the connection ends.
+	Register(upstreamID string, conn outbound.ReverseConn) (<-chan struct{}, error)
+}
+
+// WithUpstreamRegistry enables reverse upstreams: MCP servers the gateway
+// cannot reach dial in to /upstream/register over WebSocket with their
+// upstream's registration token, and the connection is used as the
+// upstream.
+func WithUpstreamRegistry(r UpstreamRegistry) Option {
+	return func(t *HTTPTransport) {
+		t.upstreamRegistry = r
+	}
+}
+
+// upstreamRegisterHandler upgrades an authenticated registration to a
+// WebSocket connection and hands it to the registry. The handler returns
+// when the connection ends.
+func upstreamRegisterHandler(registry UpstreamRegistry, pingInterval time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || !isWebSocketUpgrade(r) {
+			writeJSONError(w, http.StatusBadRequest, "Bad Request: WebSocket upgrade required")
+			return
+		}
+		if r.Header.Get("Sec-WebSocket-Version") != "13" {
+			w.Header().Set("Sec-WebSocket-Version", "13")
+			writeJSONError(w, http.StatusUpgradeRequired, "Upgrade Required: unsupported WebSocket version")
+			return
+		}
+		key := r.Header.Get("Sec-WebSocket-Key")
+		if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+			writeJSONError(w, http.StatusBadRequest, "invalid Sec-WebSocket-Key")
+			return
+		}
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			writeJSONError(w, http.StatusUnauthorized, "Unauthorized: registration token required")
+			return
+		}
+		upstreamID, err := registry.Authenticate(r.Context(), token)
+		if err != nil {
+			slog.Warn("reverse upstream registration rejected", "remote_addr", r.RemoteAddr, "error", err)
+			writeJSONError(w, http.StatusUnauthorized, "Unauthorized: invalid registration token")
+			return
+		}
+
+		conn, rw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "WebSocket not supported")
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		_ = conn.SetDeadline(time.Time{})
+
+		h := w.Header()
+		h.Set("Upgrade", "websocket")
+		h.Set("Connection", "Upgrade")
+		h.Set("Sec-WebSocket-Accept", wsAcceptKey(key))
+		if headerHasToken(r.Header, "Sec-WebSocket-Protocol", wsSubprotocol) {
+			h.Set("Sec-WebSocket-Protocol", wsSubprotocol)
+		}
+		_ = conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+		_ = h.Write(rw)
+		_, _ = rw.WriteString("\r\n")
+		if err := rw.Flush(); err != nil {
+			return
+		}
+		_ = conn.SetWriteDeadline(time.Time{})
+
+		rc := &reverseConn{ws: &wsConn{conn: conn, br: rw.Reader}}
+		done, err := registry.Register(upstreamID, rc)
+		if err != nil {
+			rc.ws.close(wsCloseGoingAway, err.Error())
+			return
+		}
+
+		// Ping the idle connection so NAT and proxies in between keep it open.
+		ticker := time.NewTicker(pingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := rc.ws.writeFrame(wsOpPing, nil); err != nil {
+					_ = rc.Close()
+				}
+			}
+		}
+	})
+}
+
+// reverseConn adapts a server-side WebSocket connection to
+// outbound.ReverseConn.
+type reverseConn struct {
+	ws *wsConn
+}
+
+// ReadMessage returns the next text message. Close frames and protocol
+// errors end the connection.
+func (c *reverseConn) ReadMessage() ([]byte, error) {
+	op, data, err := c.ws.readMessage(reverseMaxMessage)
+	var closeErr *wsCloseError
+	switch {
+	case errors.As(err, &closeErr):
+		code := closeErr.code
+		if closeErr.peer && code == wsCloseNoStatus {
+			code = wsCloseNormal
+		}
+		c.ws.close(code, closeErr.reason)
+		return nil, err
+	case err != nil:
+		return nil, err
+	case op == wsOpBinary:
+		c.ws.close(wsCloseUnsupportedData, "binary messages are not supported")
+		return nil, errors.New("binary message from reverse upstream")
+	}
+	return data, nil
+}
+
+// WriteMessage sends msg as a text message.
+func (c *reverseConn) WriteMessage(msg []byte) error {
+	return c.ws.writeFrame(wsOpText, msg)
+}
+
+// Close sends a close frame and closes the connection.
+func (c *reverseConn) Close() error {
+	c.ws.close(wsCloseNormal, "")
+	return c.ws.conn.Close()
+}
@@ -0,0 +1,124 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	mcpclient "github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/mcp"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/upstream"
+)
+
+// waitFor polls cond until it holds or the deadline passes.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestUpstreamRegister_ReverseUpstream(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	hub := mcpclient.NewReverseHub(func(_ context.Context, token string) (string, error) {
+		if token != "sgr_good" {
+			return "", upstream.ErrInvalidRegistrationToken
+		}
+		return "on-prem", nil
+	}, logger)
+	defer func() { _ = hub.Close() }()
+	attached := make(chan string, 10)
+	hub.SetOnAttach(func(id string) { attached <- id })
+
+	srv := httptest.NewServer(upstreamRegisterHandler(hub, time.Minute))
+	defer srv.Close()
+	gateway := "ws" + strings.TrimPrefix(srv.URL, "http") + upstreamRegisterPath
+
+	// Nothing registered yet.
+	if _, _, err := mcpclient.NewReverseClient(hub, "on-prem").Start(context.Background()); !errors.Is(err, mcpclient.ErrReverseNotConnected) {
+		t.Fatalf("Start before registration: err = %v, want ErrReverseNotConnected", err)
+	}
+
+	// A wrong token stops the connector.
+	bad, err := mcpclient.NewReverseConnector(mcpclient.ReverseConnectorConfig{
+		GatewayURL: gateway, Token: "sgr_bad", Command: "/bin/cat",
+	}, logger)
+	if err != nil {
+		t.Fatalf("NewReverseConnector: %v", err)
+	}
+	if err := bad.Run(context.Background()); !errors.Is(err, mcpclient.ErrRegistrationRejected) {
+		t.Fatalf("Run with bad token: err = %v, want ErrRegistrationRejected", err)
+	}
+
+	// /bin/cat stands in for the MCP server: it echoes every message.
+	connector, err := mcpclient.NewReverseConnector(mcpclient.ReverseConnectorConfig{
+		GatewayURL: gateway, Token: "sgr_good", Connections: 2, Command: "/bin/cat",
+	}, logger)
+	if err != nil {
+		t.Fatalf("NewReverseConnector: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	runDone := make(chan error, 1)
+	go func() { runDone <- connector.Run(ctx) }()
+	defer func() {
+		cancel()
+		<-runDone
+	}()
+
+	waitFor(t, "two registered connections", func() bool { return hub.Connections("on-prem") == 2 })
+	select {
+	case id := <-attached:
+		if id != "on-prem" {
+			t.Errorf("onAttach upstream = %q, want on-prem", id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("onAttach not called")
+	}
+
+	client := mcpclient.NewReverseClient(hub, "on-prem")
+	stdin, stdout, err := client.Start(context.Background())
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	msg := `{"jsonrpc":"2.0","id":1,"method":"ping"}`
+	if _, err := stdin.Write([]byte(msg + "\n")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if strings.TrimSpace(line) != msg {
+		t.Errorf("echoed message = %q, want %q", line, msg)
+	}
+
+	// A closed connection is replaced by the connector.
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	waitFor(t, "the used connection to be replaced", func() bool { return hub.Connections("on-prem") == 2 })
+	if _, _, err := client.Start(context.Background()); err != nil {
+		t.Fatalf("restart client: %v", err)
+	}
+	_ = client.Close()
+
+	// Disconnect drops every connection; the connector registers again.
+	hub.Disconnect("on-prem")
+	waitFor(t, "reconnection after Disconnect", func() bool {
+		c := mcpclient.NewReverseClient(hub, "on-prem")
+		if _, _, err := c.Start(context.Background()); err != nil {
+			return false
+		}
+		_ = c.Close()
+		return true
+	})
+}
@@ -0,0 +1,622 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/proxy"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/session"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+)
+
+// WebSocket defaults.
+const (
+	defaultWSPingInterval = 30 * time.Second
+	defaultWSMaxInFlight  = 16
+	// wsWriteTimeout bounds a single frame write to a stalled client.
+	wsWriteTimeout = 10 * time.Second
+	// wsCloseTimeout is how long the peer has to answer a close frame.
+	wsCloseTimeout = 5 * time.Second
+	// wsSubprotocol is selected when the client offers it.
+	wsSubprotocol = "mcp"
+	// wsGUID is the RFC 6455 key suffix for Sec-WebSocket-Accept.
+	wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+)
+
+// WebSocket opcodes (RFC 6455 section 5.2).
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+)
+
+// WebSocket close codes (RFC 6455 section 7.4.1).
+const (
+	wsCloseNormal          = 1000
+	wsCloseGoingAway       = 1001
+	wsCloseProtocolError   = 1002
+	wsCloseUnsupportedData = 1003
+	wsCloseNoStatus        = 1005
+	wsCloseInvalidPayload  = 1007
+	wsCloseTooBig          = 1009
+)
+
+// WebSocketConfig enables the WebSocket variant of the MCP endpoint: a GET
+// with "Upgrade: websocket" opens one connection carrying JSON-RPC messages
+// in both directions instead of POST requests plus an SSE stream.
+type WebSocketConfig struct {
+	Enabled bool
+	// PingInterval is how often the server pings an idle connection. A
+	// connection that sends nothing, not even a pong, for two intervals is
+	// closed. Zero uses 30s.
+	PingInterval time.Duration
+	// MaxInFlight caps the messages of one connection processed
+	// concurrently; further messages wait to be read. Zero uses 16.
+	MaxInFlight int
+}
+
+// withDefaults returns the config with zero values replaced by defaults.
+func (c WebSocketConfig) withDefaults() WebSocketConfig {
+	if c.PingInterval <= 0 {
+		c.PingInterval = defaultWSPingInterval
+	}
+	if c.MaxInFlight <= 0 {
+		c.MaxInFlight = defaultWSMaxInFlight
+	}
+	return c
+}
+
+// wsConfig returns the WebSocket settings of the registry.
+func (r *sessionRegistry) wsConfig() WebSocketConfig {
+	if r == nil {
+		return WebSocketConfig{}.withDefaults()
+	}
+	return r.ws
+}
+
+// isWebSocketUpgrade reports whether r asks to switch to the WebSocket protocol.
+func isWebSocketUpgrade(r *http.Request) bool {
+	return headerHasToken(r.Header, "Upgrade", "websocket") && headerHasToken(r.Header, "Connection", "upgrade")
+}
+
+// headerHasToken reports whether a comma-separated header contains token,
+// ignoring case.
+func headerHasToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// wsAcceptKey computes Sec-WebSocket-Accept for a client key.
+func wsAcceptKey(key string) string {
+	h := sha1.New() // fixed by RFC 6455, not a security use
+	h.Write([]byte(key + wsGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// handleWebSocket upgrades a GET request to a WebSocket connection. Every
+// text message is one JSON-RPC message run through the same proxy pipeline
+// as a POST; responses and server-initiated messages for the session are
+// written back on the connection.
+//
+// With Mcp-Session-Id the connection attaches to an existing session, the
+// same checks as an SSE stream apply, and it is closed with 1000 when the
+// session is terminated. Without it, a successful initialize over the
+// connection creates a session that lives as long as the connection.
+func handleWebSocket(w http.ResponseWriter, r *http.Request, proxyService *service.ProxyService, registry *sessionRegistry, bodyLimits *bodyReader) {
+	// MCP spec: validate MCP-Protocol-Version header.
+	if protoVer := r.Header.Get(MCPProtocolVersionHeader); protoVer != "" && protoVer != MCPProtocolVersion {
+		writeJSONError(w, http.StatusBadRequest,
+			"Unsupported MCP protocol version: "+protoVer+
+				" (supported: "+MCPProtocolVersion+")")
+		return
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		writeJSONError(w, http.StatusUpgradeRequired, "Upgrade Required: unsupported WebSocket version")
+		return
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		writeJSONError(w, http.StatusBadRequest, "invalid Sec-WebSocket-Key")
+		return
+	}
+
+	ownerHash := ownerHashFromRequest(r)
+	sessionID := r.Header.Get(MCPSessionIDHeader)
+	if sessionID != "" {
+		if len(sessionID) > 128 || !validSessionIDRegexp.MatchString(sessionID) {
+			writeJSONError(w, http.StatusBadRequest, "invalid session ID")
+			return
+		}
+		// MCP spec: unknown session → 404
+		if !registry.sessionExists(sessionID) {
+			writeJSONError(w, http.StatusNotFound, "Session not found")
+			return
+		}
+		if !registry.verifyOwner(sessionID, ownerHash) {
+			writeJSONError(w, http.StatusForbidden, "Forbidden: session not owned by caller")
+			return
+		}
+	}
+
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "WebSocket not supported")
+		return
+	}
+	defer func() { _ = conn.Close() }()
+	// The server read/write timeouts apply to the handshake only.
+	_ = conn.SetDeadline(time.Time{})
+
+	h := w.Header()
+	h.Set("Upgrade", "websocket")
+	h.Set("Connection", "Upgrade")
+	h.Set("Sec-WebSocket-Accept", wsAcceptKey(key))
+	if headerHasToken(r.Header, "Sec-WebSocket-Protocol", wsSubprotocol) {
+		h.Set("Sec-WebSocket-Protocol", wsSubprotocol)
+	}
+	h.Set(MCPProtocolVersionHeader, MCPProtocolVersion)
+	if sessionID != "" {
+		h.Set(MCPSessionIDHeader, sessionID)
+	}
+
+	s := &wsSession{
+		ws:           &wsConn{conn: conn, br: rw.Reader},
+		proxyService: proxyService,
+		registry:     registry,
+		bodyLimits:   bodyLimits,
+		cfg:          registry.wsConfig(),
+		ownerHash:    ownerHash,
+		msgChan:      make(chan []byte, 100),
+	}
+	// Attach before the handshake completes so no message sent to the
+	// session after the client sees 101 is missed.
+	if sessionID != "" {
+		s.attach(sessionID, false)
+	}
+	_ = conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	_ = h.Write(rw)
+	_, _ = rw.WriteString("\r\n")
+	if err := rw.Flush(); err != nil {
+		s.detach()
+		return
+	}
+	_ = conn.SetWriteDeadline(time.Time{})
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	s.serve(ctx, cancel)
+}
+
+// wsSession is one WebSocket connection and the MCP session it carries.
+type wsSession struct {
+	ws           *wsConn
+	proxyService *service.ProxyService
+	registry     *sessionRegistry
+	bodyLimits   *bodyReader
+	cfg          WebSocketConfig
+	ownerHash    string
+	// msgChan receives the server-initiated messages of the session once
+	// the connection is attached to it.
+	msgChan chan []byte
+
+	mu        sync.Mutex
+	sessionID string
+	owned     bool // the session was created by an initialize on this connection
+}
+
+// attach registers the connection as a stream of sessionID. owned sessions
+// are terminated when the connection ends.
+func (s *wsSession) attach(sessionID string, owned bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sessionID != "" {
+		return
+	}
+	s.sessionID, s.owned = sessionID, owned
+	s.registry.register(sessionID, s.msgChan, s.ownerHash)
+}
+
+// detach removes the connection from its session.
+func (s *wsSession) detach() {
+	s.mu.Lock()
+	sid, owned := s.sessionID, s.owned
+	s.mu.Unlock()
+	if sid == "" {
+		return
+	}
+	s.registry.unregister(sid, s.msgChan)
+	if owned {
+		s.registry.terminate(sid)
+	}
+}
+
+// serve runs the connection until either side closes it.
+func (s *wsSession) serve(ctx context.Context, cancel context.CancelFunc) {
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		s.writeLoop(ctx)
+	}()
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, s.cfg.MaxInFlight)
+	s.readLoop(func(data []byte) {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			s.handleMessage(ctx, data)
+		}()
+	})
+
+	cancel()
+	wg.Wait()
+	<-writerDone
+	s.detach()
+}
+
+// readLoop reads messages until the connection fails or is closed, passing
+// each text message to handle.
+func (s *wsSession) readLoop(handle func([]byte)) {
+	limit := s.bodyLimits.limit()
+	for {
+		s.ws.extendReadDeadline(2 * s.cfg.PingInterval)
+		op, data, err := s.ws.readMessage(limit)
+		var closeErr *wsCloseError
+		switch {
+		case errors.As(err, &closeErr):
+			if closeErr.peer {
+				// Echo the peer's status code to complete the closing handshake.
+				code := closeErr.code
+				if code == wsCloseNoStatus {
+					code = wsCloseNormal
+				}
+				s.ws.close(code, "")
+			} else {
+				if closeErr.code == wsCloseTooBig {
+					s.bodyLimits.reject("websocket")
+				}
+				s.ws.close(closeErr.code, closeErr.reason)
+			}
+			return
+		case err != nil:
+			return
+		case op == wsOpBinary:
+			s.ws.close(wsCloseUnsupportedData, "binary messages are not supported")
+			return
+		}
+		handle(data)
+	}
+}
+
+// writeLoop forwards server-initiated messages of the session and pings the
+// client until ctx is done. When the session is terminated the connection
+// is closed with 1000, or 1001 when the server is shutting down.
+func (s *wsSession) writeLoop(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.ws.writeFrame(wsOpPing, nil); err != nil {
+				_ = s.ws.conn.Close()
+				return
+			}
+		case msg, ok := <-s.msgChan:
+			if !ok {
+				if s.registry.closing.Load() {
+					s.ws.close(wsCloseGoingAway, "server shutting down")
+				} else {
+					s.ws.close(wsCloseNormal, "session terminated")
+				}
+				return
+			}
+			if err := s.ws.writeFrame(wsOpText, msg); err != nil {
+				_ = s.ws.conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// handleMessage runs one JSON-RPC message through the proxy and writes the
+// response, if any, back to the client.
+func (s *wsSession) handleMessage(ctx context.Context, data []byte) {
+	if !json.Valid(data) {
+		s.reply(marshalJSONRPCError(nil, -32700, "Parse error: invalid JSON"))
+		return
+	}
+	env, errMsg := parseJSONRPCEnvelope(data)
+	if errMsg != "" {
+		s.reply(marshalJSONRPCError(env.ID, -32600, errMsg))
+		return
+	}
+	// Admission control: refuse low-priority messages while overloaded.
+	if retryAfter, shed := shedMessage(ctx, env.Method, env.IsNotification); shed {
+		if !env.IsNotification {
+			s.reply(marshalJSONRPCError(env.ID, -32000,
+				"Server overloaded, retry after "+strconv.Itoa(retryAfter)+"s"))
+		}
+		return
+	}
+
+	var domainSessionID string
+	runCtx := context.WithValue(ctx, proxy.SessionIDSlotKey, &domainSessionID)
+	responseBuffer := &bytes.Buffer{}
+	if err := s.proxyService.Run(runCtx, bytes.NewReader(append(data, '\n')), responseBuffer); err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Error("proxy service error", "error", err, "transport", "websocket")
+		if !env.IsNotification {
+			s.reply(marshalJSONRPCError(env.ID, -32603, "Internal error"))
+		}
+		return
+	}
+	if env.IsNotification {
+		return
+	}
+
+	response := bytes.TrimSuffix(responseBuffer.Bytes(), []byte("\n"))
+	if len(env.ID) > 0 {
+		response = filterResponseByID(response, env.ID)
+	}
+	if len(response) == 0 {
+		return
+	}
+
+	// A successful initialize creates the session of the connection (H-6:
+	// never for an error response).
+	if env.Method == "initialize" {
+		var respCheck struct {
+			Error json.RawMessage `json:"error"`
+		}
+		if json.Unmarshal(response, &respCheck) != nil || len(respCheck.Error) == 0 {
+			sid := domainSessionID
+			if sid == "" {
+				sid, _ = session.GenerateSessionID()
+			}
+			if sid != "" {
+				s.registry.preRegisterOwner(sid, s.ownerHash)
+				s.attach(sid, true)
+			}
+		}
+	}
+	s.reply(response)
+}
+
+// reply writes a JSON-RPC message to the client.
+func (s *wsSession) reply(msg []byte) {
+	if msg == nil {
+		return
+	}
+	if err := s.ws.writeFrame(wsOpText, msg); err != nil {
+		_ = s.ws.conn.Close()
+	}
+}
+
+// wsCloseError ends the read loop with a close code, either received from
+// the peer or caused by a protocol violation.
+type wsCloseError struct {
+	code   int
+	reason string
+	peer   bool
+}
+
+func (e *wsCloseError) Error() string {
+	return fmt.Sprintf("websocket closed: %d %s", e.code, e.reason)
+}
+
+// wsConn reads and writes RFC 6455 frames on a hijacked connection. Reads
+// happen on one goroutine; writes are serialized.
+type wsConn struct {
+	conn net.Conn
+	br   *bufio.Reader
+
+	wmu       sync.Mutex
+	closeSent bool
+}
+
+// readMessage returns the next text or binary message, reassembling
+// fragments and answering pings. Messages larger than limit end the
+// connection with 1009.
+func (c *wsConn) readMessage(limit int64) (int, []byte, error) {
+	var (
+		msgOp int
+		msg   []byte
+	)
+	for {
+		fin, op, payload, err := c.readFrame(limit - int64(len(msg)))
+		if err != nil {
+			return 0, nil, err
+		}
+		switch op {
+		case wsOpPing:
+			if err := c.writeFrame(wsOpPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case wsOpPong:
+			continue
+		case wsOpClose:
+			return 0, nil, parseClosePayload(payload)
+		case wsOpText, wsOpBinary:
+			if msgOp != 0 {
+				return 0, nil, &wsCloseError{code: wsCloseProtocolError, reason: "expected continuation frame"}
+			}
+			msgOp = op
+		case wsOpContinuation:
+			if msgOp == 0 {
+				return 0, nil, &wsCloseError{code: wsCloseProtocolError, reason: "unexpected continuation frame"}
+			}
+		default:
+			return 0, nil, &wsCloseError{code: wsCloseProtocolError, reason: "unknown opcode"}
+		}
+		msg = append(msg, payload...)
+		if fin {
+			if msgOp == wsOpText && !utf8.Valid(msg) {
+				return 0, nil, &wsCloseError{code: wsCloseInvalidPayload, reason: "invalid UTF-8"}
+			}
+			return msgOp, msg, nil
+		}
+	}
+}
+
+// readFrame reads one masked client frame whose payload may not exceed
+// remaining bytes.
+func (c *wsConn) readFrame(remaining int64) (fin bool, op int, payload []byte, err error) {
+	var hdr [2]byte
+	if _, err = io.ReadFull(c.br, hdr[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin = hdr[0]&0x80 != 0
+	op = int(hdr[0] & 0x0f)
+	if hdr[0]&0x70 != 0 {
+		return false, 0, nil, &wsCloseError{code: wsCloseProtocolError, reason: "reserved bits set"}
+	}
+	if hdr[1]&0x80 == 0 {
+		return false, 0, nil, &wsCloseError{code: wsCloseProtocolError, reason: "client frames must be masked"}
+	}
+	length := uint64(hdr[1] & 0x7f)
+	control := op >= wsOpClose
+	if control && (!fin || length > 125) {
+		return false, 0, nil, &wsCloseError{code: wsCloseProtocolError, reason: "invalid control frame"}
+	}
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+		if length>>63 != 0 {
+			return false, 0, nil, &wsCloseError{code: wsCloseProtocolError, reason: "invalid frame length"}
+		}
+	}
+	if !control && (remaining < 0 || length > uint64(remaining)) {
+		return false, 0, nil, &wsCloseError{code: wsCloseTooBig, reason: "message too large"}
+	}
+	var mask [4]byte
+	if _, err = io.ReadFull(c.br, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, op, payload, nil
+}
+
+// parseClosePayload turns a received close frame into a wsCloseError.
+func parseClosePayload(payload []byte) error {
+	switch {
+	case len(payload) == 0:
+		return &wsCloseError{code: wsCloseNoStatus, peer: true}
+	case len(payload) == 1:
+		return &wsCloseError{code: wsCloseProtocolError, reason: "invalid close frame"}
+	}
+	return &wsCloseError{
+		code:   int(binary.BigEndian.Uint16(payload)),
+		reason: string(payload[2:]),
+		peer:   true,
+	}
+}
+
+// writeFrame writes one unmasked, unfragmented frame. Nothing is written
+// after a close frame.
+func (c *wsConn) writeFrame(op int, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closeSent {
+		return net.ErrClosed
+	}
+	return c.writeFrameLocked(op, payload)
+}
+
+func (c *wsConn) writeFrameLocked(op int, payload []byte) error {
+	frame := make([]byte, 0, len(payload)+10)
+	frame = append(frame, 0x80|byte(op))
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, byte(n))
+	case n <= 0xffff:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	frame = append(frame, payload...)
+	_ = c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// extendReadDeadline lets the next read wait d, unless a close frame was
+// sent and the peer is already on its shorter close deadline.
+func (c *wsConn) extendReadDeadline(d time.Duration) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if !c.closeSent {
+		_ = c.conn.SetReadDeadline(time.Now().Add(d))
+	}
+}
+
+// close sends a close frame once and gives the peer wsCloseTimeout to
+// answer before reads fail.
+func (c *wsConn) close(code int, reason string) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closeSent {
+		return
+	}
+	c.closeSent = true
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	payload = append(payload, reason...)
+	_ = c.writeFrameLocked(wsOpClose, payload)
+	_ = c.conn.SetReadDeadline(time.Now().Add(wsCloseTimeout))
+}
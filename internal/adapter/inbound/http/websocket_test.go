@@ -0,0 +1,235 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
+)
+
+// echoInterceptor answers every request with its method as the result.
+type echoInterceptor struct{}
+
+func (echoInterceptor) Intercept(ctx context.Context, msg *mcp.Message) (*mcp.Message, error) {
+	if msg.Direction != mcp.ClientToServer || len(msg.RawID()) == 0 {
+		return nil, nil
+	}
+	result, _ := json.Marshal(map[string]string{"method": msg.Method()})
+	raw := `{"jsonrpc":"2.0","id":` + string(msg.RawID()) + `,"result":` + string(result) + `}`
+	return &mcp.Message{Raw: []byte(raw), Direction: mcp.ServerToClient}, nil
+}
+
+// wsTestClient is a minimal RFC 6455 client sending masked frames.
+type wsTestClient struct {
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+// newWebSocketServer serves the MCP handler with WebSocket upgrades enabled.
+func newWebSocketServer(t *testing.T, registry *sessionRegistry) *httptest.Server {
+	t.Helper()
+	registry.ws = WebSocketConfig{Enabled: true}.withDefaults()
+	ps := service.NewProxyService(nil, echoInterceptor{}, slog.Default())
+	srv := httptest.NewServer(mcpHandler(ps, registry, nil))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// dialWebSocket performs the handshake and returns the client and the
+// handshake response.
+func dialWebSocket(t *testing.T, srv *httptest.Server, header http.Header) (*wsTestClient, *http.Response) {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	key := make([]byte, 16)
+	_, _ = rand.Read(key)
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/mcp", nil)
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", base64.StdEncoding.EncodeToString(key))
+	req.Header.Set("Sec-WebSocket-Protocol", "mcp")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if err := req.Write(conn); err != nil {
+		t.Fatalf("write handshake: %v", err)
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		t.Fatalf("read handshake: %v", err)
+	}
+	if resp.StatusCode == http.StatusSwitchingProtocols {
+		if got, want := resp.Header.Get("Sec-WebSocket-Accept"), wsAcceptKey(req.Header.Get("Sec-WebSocket-Key")); got != want {
+			t.Fatalf("Sec-WebSocket-Accept = %q, want %q", got, want)
+		}
+	}
+	return &wsTestClient{conn: conn, br: br}, resp
+}
+
+func (c *wsTestClient) send(t *testing.T, op byte, payload []byte) {
+	t.Helper()
+	frame := []byte{0x80 | op}
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, 0x80|byte(n))
+	default:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	}
+	mask := []byte{1, 2, 3, 4}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	if _, err := c.conn.Write(frame); err != nil {
+		t.Fatalf("write frame: %v", err)
+	}
+}
+
+// read returns the next frame, skipping pings.
+func (c *wsTestClient) read(t *testing.T) (byte, []byte) {
+	t.Helper()
+	for {
+		var hdr [2]byte
+		if _, err := io.ReadFull(c.br, hdr[:]); err != nil {
+			t.Fatalf("read frame: %v", err)
+		}
+		n := int(hdr[1] & 0x7f)
+		switch n {
+		case 126:
+			var ext [2]byte
+			_, _ = io.ReadFull(c.br, ext[:])
+			n = int(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			_, _ = io.ReadFull(c.br, ext[:])
+			n = int(binary.BigEndian.Uint64(ext[:]))
+		}
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(c.br, payload); err != nil {
+			t.Fatalf("read payload: %v", err)
+		}
+		if op := hdr[0] & 0x0f; op != wsOpPing {
+			return op, payload
+		}
+	}
+}
+
+func TestWebSocket_RequestResponse(t *testing.T) {
+	srv := newWebSocketServer(t, newSessionRegistry())
+	c, resp := dialWebSocket(t, srv, nil)
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("status = %d, want 101", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Sec-WebSocket-Protocol"); got != "mcp" {
+		t.Errorf("Sec-WebSocket-Protocol = %q, want mcp", got)
+	}
+
+	c.send(t, wsOpText, []byte(`{"jsonrpc":"2.0","id":7,"method":"tools/list"}`))
+	op, payload := c.read(t)
+	if op != wsOpText {
+		t.Fatalf("opcode = %d, want text", op)
+	}
+	var got struct {
+		ID     int               `json:"id"`
+		Result map[string]string `json:"result"`
+	}
+	if err := json.Unmarshal(payload, &got); err != nil || got.ID != 7 || got.Result["method"] != "tools/list" {
+		t.Errorf("response = %s", payload)
+	}
+
+	c.send(t, wsOpText, []byte(`{"jsonrpc":"1.0","id":8,"method":"x"}`))
+	_, payload = c.read(t)
+	if code, _ := parseJSONRPCError(t, payload); code != -32600 {
+		t.Errorf("invalid request code = %d, want -32600", code)
+	}
+
+	c.send(t, wsOpClose, binary.BigEndian.AppendUint16(nil, wsCloseNormal))
+	op, payload = c.read(t)
+	if op != wsOpClose || binary.BigEndian.Uint16(payload) != wsCloseNormal {
+		t.Errorf("close reply = %d %v, want echoed 1000", op, payload)
+	}
+}
+
+func TestWebSocket_SessionTerminationSendsClose(t *testing.T) {
+	registry := newSessionRegistry()
+	registry.preRegisterOwner("sess-1", "")
+	srv := newWebSocketServer(t, registry)
+
+	c, resp := dialWebSocket(t, srv, http.Header{MCPSessionIDHeader: {"sess-1"}})
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("status = %d, want 101", resp.StatusCode)
+	}
+	if got := resp.Header.Get(MCPSessionIDHeader); got != "sess-1" {
+		t.Errorf("Mcp-Session-Id = %q, want sess-1", got)
+	}
+
+	// Server-initiated messages reach the connection.
+	registry.broadcast([]byte(`{"jsonrpc":"2.0","method":"notifications/tools/list_changed"}`))
+	if _, payload := c.read(t); !strings.Contains(string(payload), "list_changed") {
+		t.Errorf("notification = %s", payload)
+	}
+
+	registry.terminate("sess-1")
+	op, payload := c.read(t)
+	if op != wsOpClose || binary.BigEndian.Uint16(payload) != wsCloseNormal || string(payload[2:]) != "session terminated" {
+		t.Errorf("close frame = %d %q, want 1000 session terminated", op, payload)
+	}
+}
+
+func TestWebSocket_HandshakeRejections(t *testing.T) {
+	registry := newSessionRegistry()
+	registry.preRegisterOwner("owned", "other-owner")
+	srv := newWebSocketServer(t, registry)
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   int
+	}{
+		{name: "unknown session", header: http.Header{MCPSessionIDHeader: {"missing"}}, want: http.StatusNotFound},
+		{name: "foreign session", header: http.Header{MCPSessionIDHeader: {"owned"}}, want: http.StatusForbidden},
+		{name: "invalid session ID", header: http.Header{MCPSessionIDHeader: {"bad id"}}, want: http.StatusBadRequest},
+		{name: "old version", header: http.Header{"Sec-Websocket-Version": {"8"}}, want: http.StatusUpgradeRequired},
+		{name: "protocol version", header: http.Header{MCPProtocolVersionHeader: {"1999-01-01"}}, want: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, resp := dialWebSocket(t, srv, tt.header)
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebSocket_DisabledFallsBackToSSE(t *testing.T) {
+	ps := service.NewProxyService(nil, echoInterceptor{}, slog.Default())
+	srv := httptest.NewServer(mcpHandler(ps, newSessionRegistry(), nil))
+	defer srv.Close()
+
+	_, resp := dialWebSocket(t, srv, nil)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400 from the SSE handler (missing session)", resp.StatusCode)
+	}
+}
@@ -0,0 +1,108 @@
+package migrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// customConfig is the gateway-neutral import format: a list of servers,
+// a list of API keys and global tool allow and deny lists.
+type customConfig struct {
+	Upstreams []customUpstream `json:"upstreams"`
+	Keys      []customKey      `json:"keys"`
+	Allow     []string         `json:"allow"`
+	Deny      []string         `json:"deny"`
+}
+
+type customUpstream struct {
+	Name         string            `json:"name"`
+	Transport    string            `json:"transport"`
+	Command      string            `json:"command"`
+	Args         []string          `json:"args"`
+	Env          map[string]string `json:"env"`
+	URL          string            `json:"url"`
+	Headers      map[string]string `json:"headers"`
+	IncludeTools []string          `json:"include_tools"`
+	ExcludeTools []string          `json:"exclude_tools"`
+	Enabled      *bool             `json:"enabled"`
+}
+
+type customKey struct {
+	Name     string   `json:"name"`
+	Key      string   `json:"key"`
+	Identity string   `json:"identity"`
+	Roles    []string `json:"roles"`
+}
+
+// customKnownFields are the top-level fields of the custom format; others
+// are reported as unsupported.
+var customKnownFields = map[string]bool{"upstreams": true, "keys": true, "allow": true, "deny": true}
+
+func convertCustomJSON(b *builder, data []byte) error {
+	var cfg customConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("parse custom-json config: %w", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("parse custom-json config: %w", err)
+	}
+	unknown := make([]string, 0)
+	for k := range fields {
+		if !customKnownFields[k] {
+			unknown = append(unknown, k)
+		}
+	}
+	sort.Strings(unknown)
+	for _, k := range unknown {
+		b.unsupported(k, "unknown field not imported")
+	}
+
+	for i, u := range cfg.Upstreams {
+		path := fmt.Sprintf("upstreams[%d]", i)
+		if u.Name == "" {
+			b.unsupported(path, "server without a name not imported")
+			continue
+		}
+		spec := upstreamSpec{
+			Name:     u.Name,
+			Type:     u.Transport,
+			Command:  u.Command,
+			Args:     u.Args,
+			Env:      u.Env,
+			URL:      u.URL,
+			Include:  u.IncludeTools,
+			Exclude:  u.ExcludeTools,
+			Disabled: u.Enabled != nil && !*u.Enabled,
+		}
+		if u.Command == "" {
+			spec.Credentials = b.headerCredentials(path+".headers", u.Headers)
+		} else if len(u.Headers) > 0 {
+			b.unsupported(path+".headers", "headers are not supported for stdio servers")
+		}
+		b.addUpstream(path, spec)
+	}
+
+	for i, k := range cfg.Keys {
+		path := fmt.Sprintf("keys[%d]", i)
+		identity := k.Identity
+		if identity == "" {
+			identity = k.Name
+		}
+		if identity == "" {
+			b.unsupported(path, "key without a name or identity not imported")
+			continue
+		}
+		name := k.Name
+		if name == "" {
+			name = identity
+		}
+		if err := b.addKey(path, identity, k.Roles, name, k.Key); err != nil {
+			return err
+		}
+	}
+
+	b.addAllowDeny("", cfg.Allow, cfg.Deny)
+	return nil
+}
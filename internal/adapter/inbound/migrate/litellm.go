@@ -0,0 +1,156 @@
+package migrate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+)
+
+// litellmConfig is the part of a LiteLLM proxy config that concerns MCP.
+type litellmConfig struct {
+	MCPServers      map[string]litellmServer `yaml:"mcp_servers"`
+	GeneralSettings struct {
+		MasterKey string `yaml:"master_key"`
+	} `yaml:"general_settings"`
+	ModelList       yaml.Node `yaml:"model_list"`
+	LiteLLMSettings yaml.Node `yaml:"litellm_settings"`
+	RouterSettings  yaml.Node `yaml:"router_settings"`
+}
+
+type litellmServer struct {
+	URL             string            `yaml:"url"`
+	Transport       string            `yaml:"transport"`
+	Command         string            `yaml:"command"`
+	Args            []string          `yaml:"args"`
+	Env             map[string]string `yaml:"env"`
+	SpecPath        string            `yaml:"spec_path"`
+	AuthType        string            `yaml:"auth_type"`
+	AuthValue       string            `yaml:"auth_value"`
+	StaticHeaders   map[string]string `yaml:"static_headers"`
+	ExtraHeaders    []string          `yaml:"extra_headers"`
+	AllowedTools    []string          `yaml:"allowed_tools"`
+	DisallowedTools []string          `yaml:"disallowed_tools"`
+	AccessGroups    []string          `yaml:"access_groups"`
+}
+
+func convertLiteLLM(b *builder, data []byte) error {
+	var cfg litellmConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("parse litellm config: %w", err)
+	}
+	if len(cfg.MCPServers) == 0 {
+		return fmt.Errorf("parse litellm config: no mcp_servers")
+	}
+
+	names := make([]string, 0, len(cfg.MCPServers))
+	for name := range cfg.MCPServers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s := cfg.MCPServers[name]
+		path := "mcp_servers." + name
+		spec := upstreamSpec{
+			Name:    name,
+			Command: s.Command,
+			Args:    s.Args,
+			Env:     litellmEnvRefs(s.Env),
+			URL:     s.URL,
+			Include: s.AllowedTools,
+			Exclude: s.DisallowedTools,
+		}
+		switch s.Transport {
+		case "sse":
+			spec.Type = "sse"
+		case "http":
+			spec.Type = "http"
+		case "", "stdio":
+		default:
+			b.unsupported(path+".transport", "unknown transport %q; inferred from the server fields", s.Transport)
+		}
+		if s.SpecPath != "" {
+			spec.Type = "openapi"
+			spec.Spec = s.SpecPath
+		}
+		if s.AuthType != "" {
+			spec.Credentials = litellmCredentials(b, path, s.AuthType, litellmEnvRef(s.AuthValue))
+		}
+		if len(s.StaticHeaders) > 0 {
+			if spec.Credentials != nil {
+				b.unsupported(path+".static_headers", "not imported: the upstream already sends auth_value")
+			} else {
+				headers := make(map[string]string, len(s.StaticHeaders))
+				for k, v := range s.StaticHeaders {
+					headers[k] = litellmEnvRef(v)
+				}
+				spec.Credentials = b.headerCredentials(path+".static_headers", headers)
+			}
+		}
+		if len(s.ExtraHeaders) > 0 {
+			b.unsupported(path+".extra_headers", "client headers are not forwarded to upstreams; use token passthrough (token_exchange) for per-client credentials")
+		}
+		if len(s.AccessGroups) > 0 {
+			b.unsupported(path+".access_groups", "access groups not imported; restrict identities with policies on this upstream's tools")
+		}
+		b.addUpstream(path, spec)
+	}
+
+	if key := cfg.GeneralSettings.MasterKey; key != "" {
+		if strings.HasPrefix(key, "os.environ/") {
+			b.unsupported("general_settings.master_key", "read from the environment at runtime; create an admin identity and key by hand")
+		} else if err := b.addKey("general_settings.master_key", "litellm-admin", []string{"admin"}, "litellm master key", key); err != nil {
+			return err
+		}
+	}
+	if !cfg.ModelList.IsZero() {
+		b.unsupported("model_list", "LLM model routing is outside the scope of an MCP gateway")
+	}
+	if !cfg.LiteLLMSettings.IsZero() {
+		b.unsupported("litellm_settings", "not imported")
+	}
+	if !cfg.RouterSettings.IsZero() {
+		b.unsupported("router_settings", "not imported")
+	}
+	return nil
+}
+
+// litellmCredentials converts LiteLLM's auth_type and auth_value.
+func litellmCredentials(b *builder, path, authType, value string) *state.UpstreamCredentialsEntry {
+	switch authType {
+	case "bearer_token":
+		return &state.UpstreamCredentialsEntry{Type: "bearer", Token: value}
+	case "api_key":
+		return &state.UpstreamCredentialsEntry{Type: "header", HeaderName: "X-API-Key", HeaderValue: value}
+	case "basic":
+		return &state.UpstreamCredentialsEntry{Type: "header", HeaderName: "Authorization", HeaderValue: "Basic " + value}
+	case "authorization":
+		return &state.UpstreamCredentialsEntry{Type: "header", HeaderName: "Authorization", HeaderValue: value}
+	default:
+		b.unsupported(path+".auth_type", "unknown auth type %q; credentials not imported", authType)
+		return nil
+	}
+}
+
+// litellmEnvRef turns LiteLLM's "os.environ/NAME" into a ${env:NAME}
+// reference. Other values are returned as is.
+func litellmEnvRef(v string) string {
+	if name, ok := strings.CutPrefix(v, "os.environ/"); ok {
+		return "${env:" + name + "}"
+	}
+	return v
+}
+
+func litellmEnvRefs(env map[string]string) map[string]string {
+	if len(env) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(env))
+	for k, v := range env {
+		out[k] = litellmEnvRef(v)
+	}
+	return out
+}
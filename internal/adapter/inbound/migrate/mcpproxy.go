@@ -0,0 +1,142 @@
+package migrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// mcpProxyConfig is the config of mcp-proxy and of the "mcpServers" files
+// shared by MCP clients. Fields other tools add are ignored.
+type mcpProxyConfig struct {
+	MCPProxy *struct {
+		Addr    string           `json:"addr"`
+		BaseURL string           `json:"baseURL"`
+		Options *mcpProxyOptions `json:"options"`
+	} `json:"mcpProxy"`
+	MCPServers map[string]mcpProxyServer `json:"mcpServers"`
+}
+
+type mcpProxyServer struct {
+	Command       string            `json:"command"`
+	Args          []string          `json:"args"`
+	Env           map[string]string `json:"env"`
+	URL           string            `json:"url"`
+	Headers       map[string]string `json:"headers"`
+	TransportType string            `json:"transportType"`
+	Type          string            `json:"type"`
+	Enabled       *bool             `json:"enabled"`
+	Disabled      bool              `json:"disabled"`
+	Timeout       json.RawMessage   `json:"timeout"`
+	Options       *mcpProxyOptions  `json:"options"`
+}
+
+type mcpProxyOptions struct {
+	AuthTokens     []string `json:"authTokens"`
+	PanicIfInvalid *bool    `json:"panicIfInvalid"`
+	LogEnabled     *bool    `json:"logEnabled"`
+	Disabled       bool     `json:"disabled"`
+	ToolFilter     *struct {
+		Mode string   `json:"mode"`
+		List []string `json:"list"`
+	} `json:"toolFilter"`
+}
+
+func convertMCPProxy(b *builder, data []byte) error {
+	var cfg mcpProxyConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("parse mcp-proxy config: %w", err)
+	}
+	if len(cfg.MCPServers) == 0 {
+		return fmt.Errorf("parse mcp-proxy config: no mcpServers")
+	}
+
+	if p := cfg.MCPProxy; p != nil {
+		if p.Addr != "" || p.BaseURL != "" {
+			b.unsupported("mcpProxy.addr", "listen address not imported; set server.http_addr in the YAML config")
+		}
+		if p.Options != nil {
+			for i, token := range p.Options.AuthTokens {
+				if err := b.addKey(fmt.Sprintf("mcpProxy.options.authTokens[%d]", i), "mcp-proxy", nil, fmt.Sprintf("mcp-proxy token %d", i+1), token); err != nil {
+					return err
+				}
+			}
+			mcpProxyIgnoredOptions(b, "mcpProxy.options", p.Options)
+		}
+	}
+
+	names := make([]string, 0, len(cfg.MCPServers))
+	for name := range cfg.MCPServers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s := cfg.MCPServers[name]
+		path := "mcpServers." + name
+		spec := upstreamSpec{
+			Name:     name,
+			Command:  s.Command,
+			Args:     s.Args,
+			Env:      s.Env,
+			URL:      s.URL,
+			Disabled: s.Disabled || (s.Enabled != nil && !*s.Enabled),
+		}
+		switch t := firstNonEmpty(s.TransportType, s.Type); t {
+		case "sse":
+			spec.Type = "sse"
+		case "streamable-http", "streamableHttp", "http":
+			spec.Type = "http"
+		case "", "stdio":
+		default:
+			b.unsupported(path+".transportType", "unknown transport %q; inferred from the server fields", t)
+		}
+		if s.Command == "" {
+			spec.Credentials = b.headerCredentials(path+".headers", s.Headers)
+		} else if len(s.Headers) > 0 {
+			b.unsupported(path+".headers", "headers are not supported for stdio servers")
+		}
+		if len(s.Timeout) > 0 {
+			b.unsupported(path+".timeout", "per-server timeout not imported; use the upstream timeouts of the YAML config")
+		}
+		if o := s.Options; o != nil {
+			if o.Disabled {
+				spec.Disabled = true
+			}
+			if f := o.ToolFilter; f != nil && len(f.List) > 0 {
+				switch f.Mode {
+				case "allow":
+					spec.Include = f.List
+				case "block":
+					spec.Exclude = f.List
+				default:
+					b.unsupported(path+".options.toolFilter", "unknown tool filter mode %q", f.Mode)
+				}
+			}
+			if len(o.AuthTokens) > 0 {
+				b.unsupported(path+".options.authTokens", "per-server tokens not imported: SentinelGate keys reach every upstream; create keys and a policy restricting them to this upstream's tools")
+			}
+			mcpProxyIgnoredOptions(b, path+".options", o)
+		}
+		b.addUpstream(path, spec)
+	}
+	return nil
+}
+
+// mcpProxyIgnoredOptions reports options without a SentinelGate equivalent.
+func mcpProxyIgnoredOptions(b *builder, path string, o *mcpProxyOptions) {
+	if o.PanicIfInvalid != nil {
+		b.unsupported(path+".panicIfInvalid", "not imported; an upstream that fails to start is reported as unhealthy")
+	}
+	if o.LogEnabled != nil {
+		b.unsupported(path+".logEnabled", "not imported; every call is recorded in the audit log")
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
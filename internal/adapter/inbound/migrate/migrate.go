@@ -0,0 +1,413 @@
+// Package migrate converts the configuration of other MCP gateways into
+// SentinelGate upstreams, identities, API keys and starter policies, with a
+// report of what could not be converted.
+package migrate
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/auth"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/upstream"
+)
+
+// Format identifies the gateway a configuration file comes from.
+type Format string
+
+const (
+	// FormatMCPProxy is an mcp-proxy JSON config ("mcpServers" map).
+	FormatMCPProxy Format = "mcp-proxy"
+	// FormatLiteLLM is a LiteLLM proxy YAML config ("mcp_servers" map).
+	FormatLiteLLM Format = "litellm"
+	// FormatCustomJSON is the gateway-neutral JSON format described in the guide.
+	FormatCustomJSON Format = "custom-json"
+)
+
+// Formats lists the supported formats.
+func Formats() []Format {
+	return []Format{FormatMCPProxy, FormatLiteLLM, FormatCustomJSON}
+}
+
+// Note is one line of the conversion report.
+type Note struct {
+	// Path locates the construct in the source file, e.g. "mcpServers.github".
+	Path string `json:"path"`
+	// Message describes what was done with it.
+	Message string `json:"message"`
+	// Unsupported is true when the construct was not converted.
+	Unsupported bool `json:"unsupported"`
+}
+
+// Plan is the result of a conversion: state entries ready to be merged into
+// state.json, and the report.
+type Plan struct {
+	Format     Format
+	Upstreams  []state.UpstreamEntry
+	Identities []state.IdentityEntry
+	APIKeys    []state.APIKeyEntry
+	Policies   []state.PolicyEntry
+	Notes      []Note
+}
+
+// Unsupported returns the notes of constructs that were not converted.
+func (p *Plan) Unsupported() []Note {
+	var out []Note
+	for _, n := range p.Notes {
+		if n.Unsupported {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// Convert parses data in the given format.
+func Convert(format Format, data []byte) (*Plan, error) {
+	b := newBuilder(format)
+	var err error
+	switch format {
+	case FormatMCPProxy:
+		err = convertMCPProxy(b, data)
+	case FormatLiteLLM:
+		err = convertLiteLLM(b, data)
+	case FormatCustomJSON:
+		err = convertCustomJSON(b, data)
+	default:
+		return nil, fmt.Errorf("unknown format %q (supported: %s)", format, formatList())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return b.plan, nil
+}
+
+func formatList() string {
+	names := make([]string, 0, len(Formats()))
+	for _, f := range Formats() {
+		names = append(names, string(f))
+	}
+	return strings.Join(names, ", ")
+}
+
+// upstreamSpec is the gateway-independent description of a server that
+// the converters fill in.
+type upstreamSpec struct {
+	Name        string
+	Type        string // empty: stdio with a command, otherwise inferred from the URL
+	Command     string
+	Args        []string
+	Env         map[string]string
+	URL         string
+	Spec        string
+	Credentials *state.UpstreamCredentialsEntry
+	Include     []string
+	Exclude     []string
+	Disabled    bool
+}
+
+// builder accumulates a Plan.
+type builder struct {
+	plan       *Plan
+	now        time.Time
+	identities map[string]int // identity name → index in plan.Identities
+	names      map[string]bool
+	policyID   string
+	rules      int
+}
+
+func newBuilder(format Format) *builder {
+	return &builder{
+		plan:       &Plan{Format: format},
+		now:        time.Now().UTC(),
+		identities: make(map[string]int),
+		names:      make(map[string]bool),
+		policyID:   uuid.New().String(),
+	}
+}
+
+// converted records a construct that was converted.
+func (b *builder) converted(path, format string, args ...interface{}) {
+	b.plan.Notes = append(b.plan.Notes, Note{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// unsupported records a construct that was not converted.
+func (b *builder) unsupported(path, format string, args ...interface{}) {
+	b.plan.Notes = append(b.plan.Notes, Note{Path: path, Message: fmt.Sprintf(format, args...), Unsupported: true})
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9 _-]+`)
+
+// upstreamName turns a server name of another gateway into a valid and
+// unique upstream name.
+func (b *builder) upstreamName(name string) string {
+	n := strings.Trim(invalidNameChars.ReplaceAllString(name, "-"), "-")
+	if n == "" {
+		n = "upstream"
+	}
+	if len(n) > 90 {
+		n = n[:90]
+	}
+	base := n
+	for i := 2; b.names[n]; i++ {
+		n = fmt.Sprintf("%s-%d", base, i)
+	}
+	return n
+}
+
+// addUpstream validates s and adds it to the plan.
+func (b *builder) addUpstream(path string, s upstreamSpec) {
+	typ := s.Type
+	if typ == "" {
+		switch {
+		case s.Command != "":
+			typ = string(upstream.UpstreamTypeStdio)
+		case strings.HasSuffix(strings.SplitN(s.URL, "?", 2)[0], "/sse"):
+			typ = string(upstream.UpstreamTypeSSE)
+		default:
+			typ = string(upstream.UpstreamTypeHTTP)
+		}
+	}
+	name := b.upstreamName(s.Name)
+	u := upstream.Upstream{
+		Name:    name,
+		Type:    upstream.UpstreamType(typ),
+		Command: s.Command,
+		Args:    s.Args,
+		URL:     s.URL,
+		Spec:    s.Spec,
+	}
+	if err := u.Validate(); err != nil {
+		b.unsupported(path, "server not imported: %v", err)
+		return
+	}
+	u.Env = s.Env
+	for _, f := range upstream.DetectSecrets(&u) {
+		if ref := f.Reference(); ref != "" {
+			b.converted(path+"."+f.Field, "plaintext secret in %s %s imported as is; consider %s", f.Field, f.Key, ref)
+		} else {
+			b.converted(path+"."+f.Field, "plaintext secret in %s %s imported as is", f.Field, f.Key)
+		}
+	}
+	b.names[name] = true
+	b.plan.Upstreams = append(b.plan.Upstreams, state.UpstreamEntry{
+		ID:          uuid.New().String(),
+		Name:        name,
+		Type:        typ,
+		Enabled:     !s.Disabled,
+		Command:     s.Command,
+		Args:        s.Args,
+		URL:         s.URL,
+		Spec:        s.Spec,
+		Env:         s.Env,
+		Credentials: s.Credentials,
+		ToolInclude: s.Include,
+		ToolExclude: s.Exclude,
+		CreatedAt:   b.now,
+		UpdatedAt:   b.now,
+	})
+	msg := fmt.Sprintf("imported as %s upstream %q", typ, name)
+	if s.Disabled {
+		msg += " (disabled)"
+	}
+	b.converted(path, "%s", msg)
+}
+
+// addKey adds an API key for the named identity, creating the identity on
+// first use. Only the Argon2id hash of the key is kept.
+func (b *builder) addKey(path, identity string, roles []string, keyName, key string) error {
+	if key == "" {
+		b.unsupported(path, "empty key not imported")
+		return nil
+	}
+	i, ok := b.identities[identity]
+	if !ok {
+		if len(roles) == 0 {
+			roles = []string{string(auth.RoleUser)}
+		}
+		i = len(b.plan.Identities)
+		b.identities[identity] = i
+		b.plan.Identities = append(b.plan.Identities, state.IdentityEntry{
+			ID:        uuid.New().String(),
+			Name:      identity,
+			Roles:     roles,
+			CreatedAt: b.now,
+			UpdatedAt: b.now,
+		})
+	}
+	hash, err := auth.HashKeyArgon2id(key)
+	if err != nil {
+		return fmt.Errorf("hash key: %w", err)
+	}
+	prefix := ""
+	if len(key) >= 8 {
+		prefix = key[:8]
+	}
+	b.plan.APIKeys = append(b.plan.APIKeys, state.APIKeyEntry{
+		ID:         uuid.New().String(),
+		KeyHash:    hash,
+		KeyPrefix:  prefix,
+		IdentityID: b.plan.Identities[i].ID,
+		Name:       keyName,
+		CreatedAt:  b.now,
+	})
+	b.converted(path, "imported as API key %q of identity %q", keyName, identity)
+	return nil
+}
+
+// Priorities of the starter policy rules: denies win over allows, and the
+// catch-all deny of an allow list comes last.
+const (
+	denyRulePriority     = 200
+	allowRulePriority    = 100
+	catchAllRulePriority = 1
+)
+
+// addRule adds a rule to the starter policy of the import.
+func (b *builder) addRule(path, ruleName, toolPattern, action string, priority int) {
+	b.rules++
+	b.plan.Policies = append(b.plan.Policies, state.PolicyEntry{
+		ID:          uuid.New().String(),
+		PolicyID:    b.policyID,
+		Name:        b.policyName() + ": " + ruleName,
+		Description: "Starter policy converted from a " + string(b.plan.Format) + " configuration",
+		Priority:    priority,
+		ToolPattern: toolPattern,
+		Action:      action,
+		Enabled:     true,
+		Source:      "import:" + string(b.plan.Format),
+		CreatedAt:   b.now,
+		UpdatedAt:   b.now,
+	})
+	b.converted(path, "imported as %s rule for %q in policy %q", action, toolPattern, b.policyName())
+}
+
+// addAllowDeny converts simple tool allow and deny lists into rules. An
+// allow list also gets a catch-all deny, as it does in the source gateway.
+func (b *builder) addAllowDeny(path string, allow, deny []string) {
+	for i, p := range deny {
+		b.addRule(fmt.Sprintf("%s[%d]", joinPath(path, "deny"), i), "deny "+p, p, "deny", denyRulePriority)
+	}
+	for i, p := range allow {
+		b.addRule(fmt.Sprintf("%s[%d]", joinPath(path, "allow"), i), "allow "+p, p, "allow", allowRulePriority)
+	}
+	if len(allow) > 0 {
+		b.addRule(joinPath(path, "allow"), "deny other tools", "*", "deny", catchAllRulePriority)
+	}
+}
+
+func joinPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+func (b *builder) policyName() string {
+	return "Imported from " + string(b.plan.Format)
+}
+
+// envRefPattern matches the ${env:NAME} references SentinelGate resolves in
+// upstream credentials.
+var envRefPattern = regexp.MustCompile(`^\$\{env:[A-Za-z_][A-Za-z0-9_]*\}$`)
+
+// headerCredentials converts static headers into upstream credentials.
+// SentinelGate sends one credential header per upstream; an Authorization
+// bearer header becomes a bearer credential.
+func (b *builder) headerCredentials(path string, headers map[string]string) *state.UpstreamCredentialsEntry {
+	if len(headers) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	name, value := names[0], headers[names[0]]
+	for _, n := range names[1:] {
+		b.unsupported(path+"."+n, "only one header per upstream is supported; header not imported")
+	}
+	if strings.EqualFold(name, "Authorization") && len(value) > 7 && strings.EqualFold(value[:7], "bearer ") {
+		return &state.UpstreamCredentialsEntry{Type: "bearer", Token: strings.TrimSpace(value[7:])}
+	}
+	return &state.UpstreamCredentialsEntry{Type: "header", HeaderName: name, HeaderValue: value}
+}
+
+// MergeResult counts what Merge did.
+type MergeResult struct {
+	UpstreamsAdded, UpstreamsSkipped  int
+	IdentitiesAdded, IdentitiesReused int
+	KeysAdded                         int
+	RulesAdded, RulesSkipped          int
+}
+
+// Merge adds the entries of p to st. Upstreams whose name is taken are
+// skipped, keys of an identity that already exists are attached to it, and
+// the starter policy is skipped when a policy of the same name exists, so
+// importing the same file twice only adds its keys again.
+func Merge(st *state.AppState, p *Plan) MergeResult {
+	var res MergeResult
+
+	upstreams := make(map[string]bool, len(st.Upstreams))
+	for _, u := range st.Upstreams {
+		upstreams[u.Name] = true
+	}
+	for _, u := range p.Upstreams {
+		if upstreams[u.Name] {
+			res.UpstreamsSkipped++
+			continue
+		}
+		upstreams[u.Name] = true
+		st.Upstreams = append(st.Upstreams, u)
+		res.UpstreamsAdded++
+	}
+
+	byName := make(map[string]string, len(st.Identities))
+	for _, e := range st.Identities {
+		byName[e.Name] = e.ID
+	}
+	remap := make(map[string]string, len(p.Identities))
+	for _, e := range p.Identities {
+		if id, ok := byName[e.Name]; ok {
+			remap[e.ID] = id
+			res.IdentitiesReused++
+			continue
+		}
+		byName[e.Name] = e.ID
+		st.Identities = append(st.Identities, e)
+		res.IdentitiesAdded++
+	}
+	for _, k := range p.APIKeys {
+		if id, ok := remap[k.IdentityID]; ok {
+			k.IdentityID = id
+		}
+		st.APIKeys = append(st.APIKeys, k)
+		res.KeysAdded++
+	}
+
+	policies := make(map[string]bool)
+	for _, e := range st.Policies {
+		policies[policyNameOf(e.Name)] = true
+	}
+	for _, e := range p.Policies {
+		if policies[policyNameOf(e.Name)] {
+			res.RulesSkipped++
+			continue
+		}
+		st.Policies = append(st.Policies, e)
+		res.RulesAdded++
+	}
+	return res
+}
+
+// policyNameOf returns the policy part of a "Policy: Rule" entry name.
+func policyNameOf(entryName string) string {
+	if i := strings.Index(entryName, ": "); i > 0 {
+		return entryName[:i]
+	}
+	return entryName
+}
@@ -0,0 +1,224 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/auth"
+)
+
+const mcpProxySample = `{
+  "mcpProxy": {
+    "baseURL": "https://mcp.example.com",
+    "addr": ":9090",
+    "options": {"authTokens": ["team-token-123456"], "logEnabled": true}
+  },
+  "mcpServers": {
+    "github": {
+      "command": "npx",
+      "args": ["-y", "@modelcontextprotocol/server-github"],
+      "env": {"GITHUB_PERSONAL_ACCESS_TOKEN": "<YOUR_TOKEN>"},
+      "options": {"toolFilter": {"mode": "block", "list": ["create_or_update_file"]}}
+    },
+    "fetch": {
+      "command": "uvx",
+      "args": ["mcp-server-fetch"],
+      "options": {"authTokens": ["fetch-only"]}
+    },
+    "amap": {"url": "https://mcp.amap.com/sse?key=abc"},
+    "docs": {
+      "url": "https://docs.example.com/mcp",
+      "transportType": "streamable-http",
+      "headers": {"Authorization": "Bearer s3cr3t"}
+    },
+    "broken": {"url": "ftp://nope"}
+  }
+}`
+
+func findUpstream(p *Plan, name string) *state.UpstreamEntry {
+	for i := range p.Upstreams {
+		if p.Upstreams[i].Name == name {
+			return &p.Upstreams[i]
+		}
+	}
+	return nil
+}
+
+func hasNote(notes []Note, path string) bool {
+	for _, n := range notes {
+		if n.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+func TestConvertMCPProxy(t *testing.T) {
+	plan, err := Convert(FormatMCPProxy, []byte(mcpProxySample))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if len(plan.Upstreams) != 4 {
+		t.Fatalf("got %d upstreams, want 4 (broken one skipped)", len(plan.Upstreams))
+	}
+
+	gh := findUpstream(plan, "github")
+	if gh == nil || gh.Type != "stdio" || gh.Command != "npx" || len(gh.ToolExclude) != 1 {
+		t.Errorf("github = %+v, want stdio with one excluded tool", gh)
+	}
+	if amap := findUpstream(plan, "amap"); amap == nil || amap.Type != "sse" {
+		t.Errorf("amap = %+v, want sse inferred from the URL", amap)
+	}
+	docs := findUpstream(plan, "docs")
+	if docs == nil || docs.Type != "http" || docs.Credentials == nil || docs.Credentials.Type != "bearer" || docs.Credentials.Token != "s3cr3t" {
+		t.Errorf("docs = %+v, want http with a bearer credential", docs)
+	}
+
+	if len(plan.Identities) != 1 || plan.Identities[0].Name != "mcp-proxy" {
+		t.Fatalf("identities = %+v, want one mcp-proxy identity", plan.Identities)
+	}
+	if len(plan.APIKeys) != 1 {
+		t.Fatalf("got %d keys, want 1 (per-server tokens are not imported)", len(plan.APIKeys))
+	}
+	key := plan.APIKeys[0]
+	if strings.Contains(key.KeyHash, "team-token") || auth.DetectHashType(key.KeyHash) != "argon2id" {
+		t.Errorf("key hash = %q, want an Argon2id hash", key.KeyHash)
+	}
+
+	unsupported := plan.Unsupported()
+	for _, path := range []string{"mcpProxy.addr", "mcpProxy.options.logEnabled", "mcpServers.fetch.options.authTokens", "mcpServers.broken"} {
+		if !hasNote(unsupported, path) {
+			t.Errorf("report lacks unsupported %s: %+v", path, unsupported)
+		}
+	}
+}
+
+func TestConvertLiteLLM(t *testing.T) {
+	data := `
+model_list:
+  - model_name: gpt-4o
+mcp_servers:
+  zapier:
+    url: "https://actions.zapier.com/mcp/sse"
+    transport: sse
+    auth_type: bearer_token
+    auth_value: os.environ/ZAPIER_TOKEN
+    allowed_tools: ["send_email"]
+    access_groups: ["marketing"]
+  local_files:
+    transport: stdio
+    command: python
+    args: ["server.py"]
+general_settings:
+  master_key: sk-1234567890
+`
+	plan, err := Convert(FormatLiteLLM, []byte(data))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	z := findUpstream(plan, "zapier")
+	if z == nil || z.Type != "sse" || z.Credentials == nil || z.Credentials.Token != "${env:ZAPIER_TOKEN}" {
+		t.Errorf("zapier = %+v, want sse with an env bearer reference", z)
+	}
+	if len(z.ToolInclude) != 1 {
+		t.Errorf("zapier include = %v, want [send_email]", z.ToolInclude)
+	}
+	if l := findUpstream(plan, "local_files"); l == nil || l.Type != "stdio" {
+		t.Errorf("local_files = %+v, want stdio", l)
+	}
+	if len(plan.Identities) != 1 || plan.Identities[0].Roles[0] != "admin" {
+		t.Errorf("identities = %+v, want the master key as admin", plan.Identities)
+	}
+	for _, path := range []string{"model_list", "mcp_servers.zapier.access_groups"} {
+		if !hasNote(plan.Unsupported(), path) {
+			t.Errorf("report lacks unsupported %s", path)
+		}
+	}
+}
+
+func TestConvertCustomJSON(t *testing.T) {
+	data := `{
+  "upstreams": [{"name": "files/main", "command": "mcp-files", "include_tools": ["read_*"]}],
+  "keys": [{"name": "ci", "key": "ci-key-0000000", "roles": ["read-only"]}],
+  "allow": ["read_*"],
+  "deny": ["delete_*"],
+  "rate_limits": {"per_minute": 10}
+}`
+	plan, err := Convert(FormatCustomJSON, []byte(data))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if findUpstream(plan, "files-main") == nil {
+		t.Errorf("upstreams = %+v, want the name sanitized to files-main", plan.Upstreams)
+	}
+	if len(plan.Identities) != 1 || plan.Identities[0].Roles[0] != "read-only" {
+		t.Errorf("identities = %+v", plan.Identities)
+	}
+	// deny, allow and the catch-all deny of the allow list
+	if len(plan.Policies) != 3 {
+		t.Fatalf("got %d rules, want 3", len(plan.Policies))
+	}
+	byPattern := map[string]state.PolicyEntry{}
+	for _, r := range plan.Policies {
+		byPattern[r.ToolPattern+"/"+r.Action] = r
+		if !strings.HasPrefix(r.Name, "Imported from custom-json: ") {
+			t.Errorf("rule name = %q, want it in the import policy", r.Name)
+		}
+	}
+	if byPattern["delete_*/deny"].Priority <= byPattern["read_*/allow"].Priority ||
+		byPattern["read_*/allow"].Priority <= byPattern["*/deny"].Priority {
+		t.Errorf("rule priorities = %+v, want deny > allow > catch-all", byPattern)
+	}
+	if !hasNote(plan.Unsupported(), "rate_limits") {
+		t.Error("unknown field not reported")
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	if _, err := Convert("kong", []byte(`{}`)); err == nil {
+		t.Error("unknown format accepted")
+	}
+	if _, err := Convert(FormatMCPProxy, []byte(`{"mcpServers": {}}`)); err == nil {
+		t.Error("config without servers accepted")
+	}
+	if _, err := Convert(FormatLiteLLM, []byte("mcp_servers: [")); err == nil {
+		t.Error("invalid YAML accepted")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	data := `{
+  "upstreams": [{"name": "files", "command": "mcp-files"}, {"name": "web", "url": "https://web.example.com/mcp"}],
+  "keys": [{"name": "ci", "key": "ci-key-0000000"}],
+  "deny": ["delete_*"]
+}`
+	plan, err := Convert(FormatCustomJSON, []byte(data))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	st := &state.AppState{
+		Upstreams:  []state.UpstreamEntry{{ID: "u1", Name: "files", Type: "stdio", Command: "other"}},
+		Identities: []state.IdentityEntry{{ID: "id-ci", Name: "ci", Roles: []string{"user"}}},
+	}
+
+	res := Merge(st, plan)
+	if res.UpstreamsAdded != 1 || res.UpstreamsSkipped != 1 {
+		t.Errorf("upstreams: %+v, want 1 added and 1 skipped", res)
+	}
+	if res.IdentitiesReused != 1 || res.IdentitiesAdded != 0 || len(st.Identities) != 1 {
+		t.Errorf("identities: %+v, want the existing identity reused", res)
+	}
+	if len(st.APIKeys) != 1 || st.APIKeys[0].IdentityID != "id-ci" {
+		t.Errorf("keys = %+v, want one key of id-ci", st.APIKeys)
+	}
+	if res.RulesAdded != 1 {
+		t.Errorf("rules added = %d, want 1", res.RulesAdded)
+	}
+
+	// A second import keeps the existing starter policy.
+	res = Merge(st, plan)
+	if res.RulesSkipped != 1 || res.RulesAdded != 0 || len(st.Policies) != 1 {
+		t.Errorf("second merge: %+v, want the policy kept", res)
+	}
+}
@@ -4,28 +4,42 @@ package stdio
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"os"
 	"sync"
 
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/proxy"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/port/inbound"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/service"
+	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
 )
 
 // StdioTransport is the inbound adapter that connects the proxy to stdin/stdout.
 // It implements the inbound.ProxyService interface.
 type StdioTransport struct {
 	proxyService *service.ProxyService
-	mu           sync.Mutex
+	framing      mcp.Framing
+	// out is stdout wrapped for the configured framing, set by Start.
+	// Before that, notifications go to os.Stdout directly.
+	out io.Writer
+	mu  sync.Mutex
 }
 
 // NewStdioTransport creates a stdio transport adapter wrapping the given proxy service.
 func NewStdioTransport(proxyService *service.ProxyService) *StdioTransport {
 	return &StdioTransport{
 		proxyService: proxyService,
+		framing:      mcp.FramingAuto,
 	}
 }
 
+// SetFraming sets how messages are delimited on stdin/stdout. With
+// FramingAuto (default) the framing of the client's first message is used
+// for the rest of the session. Must be called before Start.
+func (t *StdioTransport) SetFraming(f mcp.Framing) {
+	t.framing = f
+}
+
 // Start begins proxying between stdin/stdout and the upstream server.
 // It blocks until the context is cancelled or an error occurs.
 // This method reads from os.Stdin and writes to os.Stdout.
@@ -33,12 +47,28 @@ func (t *StdioTransport) Start(ctx context.Context) error {
 	// For stdio transport, use "local" as IP address (no real remote IP).
 	// This ensures all stdio connections share one rate limit bucket.
 	ctx = context.WithValue(ctx, proxy.IPAddressKey, "local")
-	return t.proxyService.Run(ctx, os.Stdin, os.Stdout)
+	in, out := t.streams(os.Stdin, os.Stdout)
+	return t.proxyService.Run(ctx, in, out)
+}
+
+// streams wraps the raw stdio streams for the configured framing. Both the
+// proxy and SendNotification write through the returned writer.
+func (t *StdioTransport) streams(stdin io.Reader, stdout io.Writer) (io.Reader, io.Writer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.framing == mcp.FramingNewline {
+		t.out = stdout
+		return stdin, stdout
+	}
+	reader := mcp.NewFrameReader(stdin, t.framing)
+	t.out = mcp.NewFrameWriter(stdout, t.framing, reader)
+	return reader, t.out
 }
 
 // SendNotification writes a JSON-RPC notification to stdout.
 // The entire message (including trailing newline) is written in a single
-// Write call to ensure atomicity on POSIX pipes (payload < PIPE_BUF).
+// Write call to ensure atomicity on POSIX pipes (payload < PIPE_BUF). When
+// the session uses Content-Length framing it is sent as one frame.
 func (t *StdioTransport) SendNotification(method string) {
 	notification := map[string]any{
 		"jsonrpc": "2.0",
@@ -51,7 +81,11 @@ func (t *StdioTransport) SendNotification(method string) {
 	// Append newline for line-delimited JSON-RPC and write atomically.
 	data = append(data, '\n')
 	t.mu.Lock()
-	_, _ = os.Stdout.Write(data)
+	out := t.out
+	if out == nil {
+		out = os.Stdout
+	}
+	_, _ = out.Write(data)
 	t.mu.Unlock()
 }
 
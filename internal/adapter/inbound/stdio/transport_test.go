@@ -746,3 +746,27 @@ func (r *rejectingInterceptor) Intercept(ctx context.Context, msg *mcp.Message)
 	}
 	return msg, nil
 }
+
+func TestStdioTransport_ContentLengthFraming(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	proxyService := service.NewProxyService(nil, proxy.NewPassthroughInterceptor(), logger)
+	transport := NewStdioTransport(proxyService)
+
+	// The client speaks Content-Length framing; in auto mode replies and
+	// notifications must use it too.
+	var out bytes.Buffer
+	in, w := transport.streams(strings.NewReader("Content-Length: 17\r\n\r\n{\"jsonrpc\":\"2.0\"}"), &out)
+	line, err := io.ReadAll(in)
+	if err != nil || string(line) != "{\"jsonrpc\":\"2.0\"}\n" {
+		t.Fatalf("read = %q, %v", line, err)
+	}
+
+	_, _ = w.Write([]byte("{\"id\":1}\n"))
+	transport.SendNotification("notifications/tools/list_changed")
+
+	want := "Content-Length: 8\r\n\r\n{\"id\":1}" +
+		"Content-Length: 61\r\n\r\n{\"jsonrpc\":\"2.0\",\"method\":\"notifications/tools/list_changed\"}"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
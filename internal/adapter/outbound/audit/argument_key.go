@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+)
+
+// LoadArgumentKey reads the base64-encoded AES-256 key used to encrypt
+// sensitive tool arguments.
+func LoadArgumentKey(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(data)))
+	if err != nil {
+		return nil, fmt.Errorf("decode argument key %s: %w", path, err)
+	}
+	if len(key) != audit.ArgumentKeySize {
+		return nil, fmt.Errorf("argument key %s must be %d bytes, got %d", path, audit.ArgumentKeySize, len(key))
+	}
+	return key, nil
+}
+
+// LoadOrCreateArgumentKey reads the argument key at path, generating and
+// saving a new one (mode 0600) if the file does not exist. created reports
+// whether a new key was written.
+func LoadOrCreateArgumentKey(path string) (key []byte, created bool, err error) {
+	key, err = LoadArgumentKey(path)
+	if err == nil || !errors.Is(err, fs.ErrNotExist) {
+		return key, false, err
+	}
+
+	key = make([]byte, audit.ArgumentKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, false, fmt.Errorf("generate argument key: %w", err)
+	}
+	if dir := filepath.Dir(path); dir != "." && dir != "" {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, false, fmt.Errorf("create key directory: %w", err)
+		}
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, false, fmt.Errorf("create argument key file: %w", err)
+	}
+	_, werr := f.WriteString(base64.StdEncoding.EncodeToString(key) + "\n")
+	if werr == nil {
+		werr = f.Sync()
+	}
+	if cerr := f.Close(); werr == nil {
+		werr = cerr
+	}
+	if werr != nil {
+		_ = os.Remove(path)
+		return nil, false, fmt.Errorf("write argument key file: %w", werr)
+	}
+	return key, true, nil
+}
@@ -0,0 +1,39 @@
+package audit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadOrCreateArgumentKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys", "argument-key")
+
+	key, created, err := LoadOrCreateArgumentKey(path)
+	if err != nil || !created {
+		t.Fatalf("first call: created=%v err=%v, want a new key", created, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("key file mode = %o, want 600", perm)
+	}
+
+	again, created, err := LoadOrCreateArgumentKey(path)
+	if err != nil || created {
+		t.Fatalf("second call: created=%v err=%v, want the existing key", created, err)
+	}
+	if !bytes.Equal(key, again) {
+		t.Error("second call returned a different key")
+	}
+
+	if err := os.WriteFile(path, []byte("c2hvcnQ=\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := LoadOrCreateArgumentKey(path); err == nil {
+		t.Error("short key accepted")
+	}
+}
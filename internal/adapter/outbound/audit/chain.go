@@ -0,0 +1,263 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// CheckpointFile is the file in the audit directory that holds the signed
+// chain checkpoints, one JSON object per line. Its name does not match the
+// audit file pattern, so rotation, compression and retention leave it alone.
+const CheckpointFile = "audit-checkpoints.jsonl"
+
+// chainGenesis is the prev_hash of the first record of a chain.
+var chainGenesis = strings.Repeat("0", sha256.Size*2)
+
+// CheckpointSigner signs chain checkpoints. The evidence ECDSA signer
+// implements it.
+type CheckpointSigner interface {
+	Sign(data []byte) ([]byte, error)
+	SignerID() string
+	Algorithm() string
+}
+
+// ChainCheckpoint records the head of the hash chain at a point in time:
+// the hash of the last record written and where it was written. Signed
+// checkpoints pin the chain, so records removed from its end (which leave
+// a shorter but consistent chain) are detected too.
+type ChainCheckpoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	// File is the uncompressed name of the audit file holding the record.
+	File string `json:"file"`
+	// Line is the line of the record in File, starting at 1.
+	Line      int    `json:"line"`
+	Hash      string `json:"hash"`
+	SignerID  string `json:"signer_id,omitempty"`
+	Algorithm string `json:"algorithm,omitempty"`
+	// Signature is the base64 signature of the checkpoint encoded as JSON
+	// without this field.
+	Signature string `json:"signature,omitempty"`
+}
+
+// signedPayload returns the bytes the checkpoint signature covers.
+func (c ChainCheckpoint) signedPayload() ([]byte, error) {
+	c.Signature = ""
+	return json.Marshal(c)
+}
+
+// chainRecord appends prev_hash and hash to the JSON object data. The hash
+// is the SHA-256 of the object with prev_hash, so it covers the link to
+// the previous record as well as the record itself.
+func chainRecord(data []byte, prev string) (line []byte, hash string) {
+	body := appendJSONString(data, "prev_hash", prev)
+	sum := sha256.Sum256(body)
+	hash = hex.EncodeToString(sum[:])
+	return appendJSONString(body, "hash", hash), hash
+}
+
+// appendJSONString adds a string field at the end of the JSON object obj.
+func appendJSONString(obj []byte, key, value string) []byte {
+	out := make([]byte, 0, len(obj)+len(key)+len(value)+7)
+	out = append(out, obj[:len(obj)-1]...)
+	if len(obj) > 2 {
+		out = append(out, ',')
+	}
+	out = append(out, '"')
+	out = append(out, key...)
+	out = append(out, `":"`...)
+	out = append(out, value...)
+	return append(out, `"}`...)
+}
+
+// cutChainField removes the trailing `,"key":"<hash>"}` written by
+// chainRecord from obj and returns the object without it and the hash.
+// ok is false when obj does not end with that field.
+func cutChainField(obj []byte, key string) (rest []byte, value string, ok bool) {
+	prefix := `,"` + key + `":"`
+	n := len(prefix) + sha256.Size*2 + len(`"}`)
+	if len(obj) < n+1 {
+		return nil, "", false
+	}
+	tail := obj[len(obj)-n:]
+	if !bytes.HasPrefix(tail, []byte(prefix)) || !bytes.HasSuffix(tail, []byte(`"}`)) {
+		return nil, "", false
+	}
+	value = string(tail[len(prefix) : len(prefix)+sha256.Size*2])
+	if _, err := hex.DecodeString(value); err != nil {
+		return nil, "", false
+	}
+	rest = make([]byte, 0, len(obj)-n+1)
+	rest = append(rest, obj[:len(obj)-n]...)
+	return append(rest, '}'), value, true
+}
+
+// splitChainedLine returns the hashed part of a chained audit line (the
+// record with its prev_hash) with the prev_hash and hash it carries. ok is
+// false for lines written without the chain.
+func splitChainedLine(line []byte) (body []byte, prev, hash string, ok bool) {
+	body, hash, ok = cutChainField(line, "hash")
+	if !ok {
+		return nil, "", "", false
+	}
+	if _, prev, ok = cutChainField(body, "prev_hash"); !ok {
+		return nil, "", "", false
+	}
+	return body, prev, hash, true
+}
+
+// loadChainHead finds the last record written by a previous run, so the
+// chain continues across restarts. Records are appended to the file of
+// their date, so the last one is in the most recently modified
+// uncompressed file; when every file is compressed it is in the newest.
+// Without chained records the chain starts from the genesis hash.
+func (s *FileAuditStore) loadChainHead() {
+	s.lastHash = chainGenesis
+
+	var head *auditFileInfo
+	var headMod time.Time
+	files := s.findSortedAuditFiles()
+	for i := range files {
+		if files[i].compressed {
+			continue
+		}
+		fi, err := os.Stat(filepath.Join(s.dir, files[i].name))
+		if err != nil {
+			continue
+		}
+		if head == nil || !fi.ModTime().Before(headMod) {
+			head, headMod = &files[i], fi.ModTime()
+		}
+	}
+	if head == nil && len(files) > 0 {
+		head = &files[len(files)-1]
+	}
+	if head == nil {
+		return
+	}
+
+	r, err := s.openAuditFile(*head)
+	if err != nil {
+		s.logger.Error("audit chain: failed to open last audit file", "file", head.name, "error", err)
+		return
+	}
+	defer func() { _ = r.Close() }()
+	var last []byte
+	lineNo, lastNo := 0, 0
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		lineNo++
+		if len(scanner.Bytes()) > 0 {
+			last = append(last[:0], scanner.Bytes()...)
+			lastNo = lineNo
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		s.logger.Warn("audit chain: failed to read last audit file", "file", head.name, "error", err)
+	}
+	if _, _, hash, ok := splitChainedLine(last); ok {
+		s.lastHash = hash
+		s.headFile = strings.TrimSuffix(head.name, compressedExt)
+		s.headLine = lastNo
+		return
+	}
+	s.logger.Info("audit hash chain starts", "dir", s.dir)
+}
+
+// startCheckpointLoop writes a checkpoint every checkpointInterval until
+// the context is cancelled. Close writes the last one.
+func (s *FileAuditStore) startCheckpointLoop(ctx context.Context) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.checkpointInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.writeCheckpoint(); err != nil {
+				s.logger.Error("audit chain: failed to write checkpoint", "error", err)
+			}
+		}
+	}
+}
+
+// writeCheckpoint signs the current head of the chain and appends it to
+// CheckpointFile. Nothing is written when no record was appended since the
+// last checkpoint.
+func (s *FileAuditStore) writeCheckpoint() error {
+	s.mu.Lock()
+	cp := ChainCheckpoint{File: s.headFile, Line: s.headLine, Hash: s.lastHash}
+	s.mu.Unlock()
+	if cp.File == "" || cp.Hash == s.checkpointedHash {
+		return nil
+	}
+
+	cp.Timestamp = time.Now().UTC()
+	cp.SignerID = s.checkpointSigner.SignerID()
+	cp.Algorithm = s.checkpointSigner.Algorithm()
+	payload, err := cp.signedPayload()
+	if err != nil {
+		return err
+	}
+	sig, err := s.checkpointSigner.Sign(payload)
+	if err != nil {
+		return fmt.Errorf("sign checkpoint: %w", err)
+	}
+	cp.Signature = base64.StdEncoding.EncodeToString(sig)
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(filepath.Join(s.dir, CheckpointFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	s.checkpointedHash = cp.Hash
+	return nil
+}
+
+// readCheckpoints reads the checkpoints in dir. A missing file yields none.
+func readCheckpoints(dir string) ([]ChainCheckpoint, error) {
+	data, err := os.ReadFile(filepath.Join(dir, CheckpointFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cps []ChainCheckpoint
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var cp ChainCheckpoint
+		if err := dec.Decode(&cp); err == io.EOF {
+			return cps, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("%s: checkpoint %d: %w", CheckpointFile, len(cps)+1, err)
+		}
+		cps = append(cps, cp)
+	}
+}
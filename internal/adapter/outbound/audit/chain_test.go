@@ -0,0 +1,279 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+)
+
+// hmacSigner signs and verifies checkpoints with a shared key.
+type hmacSigner struct{ key []byte }
+
+func (h hmacSigner) Sign(data []byte) ([]byte, error) {
+	m := hmac.New(sha256.New, h.key)
+	m.Write(data)
+	return m.Sum(nil), nil
+}
+func (h hmacSigner) SignerID() string  { return "test" }
+func (h hmacSigner) Algorithm() string { return "HMAC-SHA256" }
+func (h hmacSigner) Verify(data, sig []byte) (bool, error) {
+	want, _ := h.Sign(data)
+	return hmac.Equal(want, sig), nil
+}
+
+// writeChained appends n records, one per second from ts, to a chained
+// store in dir and closes it, which writes a checkpoint.
+func writeChained(t *testing.T, dir string, ts time.Time, n int) {
+	t.Helper()
+	store, err := NewFileAuditStore(AuditFileConfig{
+		Dir: dir, Chain: true, CheckpointSigner: hmacSigner{key: []byte("k")},
+	}, testLogger())
+	if err != nil {
+		t.Fatalf("NewFileAuditStore() error: %v", err)
+	}
+	for i := 0; i < n; i++ {
+		rec := makeRecord(ts.Add(time.Duration(i)*time.Second), "req-"+ts.Format("0102")+"-"+string(rune('a'+i)))
+		if err := store.Append(context.Background(), rec); err != nil {
+			t.Fatalf("Append() error: %v", err)
+		}
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+}
+
+func verifyChain(t *testing.T, dir string) *ChainVerification {
+	t.Helper()
+	v, err := VerifyChain(dir, hmacSigner{key: []byte("k")})
+	if err != nil {
+		t.Fatalf("VerifyChain() error: %v", err)
+	}
+	return v
+}
+
+// editAuditFile rewrites the lines of the audit file of day.
+func editAuditFile(t *testing.T, dir string, day time.Time, edit func(lines [][]byte) [][]byte) {
+	t.Helper()
+	path := filepath.Join(dir, "audit-"+day.Format("2006-01-02")+".log")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n"))
+	lines = edit(lines)
+	if err := os.WriteFile(path, append(bytes.Join(lines, []byte("\n")), '\n'), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChainRecord_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	data, err := json.Marshal(makeRecord(time.Now().UTC(), "req-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	line, hash := chainRecord(data, chainGenesis)
+	body, prev, got, ok := splitChainedLine(line)
+	if !ok {
+		t.Fatalf("splitChainedLine(%s) not ok", line)
+	}
+	if prev != chainGenesis || got != hash {
+		t.Errorf("prev, hash = %s, %s; want %s, %s", prev, got, chainGenesis, hash)
+	}
+	if sum := sha256.Sum256(body); hex.EncodeToString(sum[:]) != hash {
+		t.Errorf("hash does not cover the record with its prev_hash")
+	}
+	rec, err := audit.DecodeRecord(line)
+	if err != nil || rec.RequestID != "req-1" {
+		t.Errorf("DecodeRecord(chained line) = %q, %v; want req-1", rec.RequestID, err)
+	}
+
+	if _, _, _, ok := splitChainedLine(data); ok {
+		t.Error("splitChainedLine() ok for an unchained line")
+	}
+}
+
+func TestFileAuditStore_ChainVerifies(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	day1 := yesterdayNoon()
+	day2 := todayMidnight()
+	writeChained(t, dir, day1, 3)
+	// A restart continues the chain from the last record on disk.
+	writeChained(t, dir, day2, 2)
+
+	v := verifyChain(t, dir)
+	if !v.Valid() {
+		t.Fatalf("VerifyChain() problem: %s", v.FirstProblem)
+	}
+	if v.Records != 5 || v.Files != 2 || v.Partial {
+		t.Errorf("Records = %d, Files = %d, Partial = %v; want 5, 2, false", v.Records, v.Files, v.Partial)
+	}
+	if v.Checkpoints != 2 || v.CheckpointsMatched != 2 {
+		t.Errorf("Checkpoints = %d, matched %d; want 2, 2", v.Checkpoints, v.CheckpointsMatched)
+	}
+	if want := "audit-" + day2.Format("2006-01-02") + ".log"; v.HeadFile != want || v.HeadLine != 2 {
+		t.Errorf("head = %s:%d, want %s:2", v.HeadFile, v.HeadLine, want)
+	}
+	if want := day2.Add(time.Second); !v.HeadTime.Equal(want) {
+		t.Errorf("HeadTime = %v, want %v", v.HeadTime, want)
+	}
+	// Checkpoints are written on Close, after the records.
+	if v.LastCheckpoint.Before(v.HeadTime) {
+		t.Errorf("LastCheckpoint = %v, want at or after the head record %v", v.LastCheckpoint, v.HeadTime)
+	}
+
+	// Records stay readable by the store.
+	store, err := NewFileAuditStore(AuditFileConfig{Dir: dir}, testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = store.Close() }()
+	if got := store.GetRecent(10); len(got) != 5 {
+		t.Errorf("GetRecent() = %d records, want 5", len(got))
+	}
+}
+
+func TestVerifyChain_DetectsTampering(t *testing.T) {
+	t.Parallel()
+
+	day1 := yesterdayNoon()
+	day2 := todayMidnight()
+	tests := []struct {
+		name string
+		edit func(dir string)
+		want string
+	}{
+		{"modified record", func(dir string) {
+			editAuditFile(t, dir, day1, func(l [][]byte) [][]byte {
+				l[1] = bytes.Replace(l[1], []byte(`"decision":"allow"`), []byte(`"decision":"deny"`), 1)
+				return l
+			})
+		}, "does not match its hash"},
+		{"deleted record", func(dir string) {
+			editAuditFile(t, dir, day1, func(l [][]byte) [][]byte { return append(l[:1], l[2:]...) })
+		}, "is missing"},
+		{"duplicated record", func(dir string) {
+			editAuditFile(t, dir, day1, func(l [][]byte) [][]byte { return append(l, l[0]) })
+		}, "inserted or duplicated"},
+		{"stripped hash", func(dir string) {
+			editAuditFile(t, dir, day2, func(l [][]byte) [][]byte {
+				l[0], _, _ = bytes.Cut(l[0], []byte(`,"prev_hash"`))
+				l[0] = append(l[0], '}')
+				return l
+			})
+		}, "without hash"},
+		{"truncated tail", func(dir string) {
+			editAuditFile(t, dir, day2, func(l [][]byte) [][]byte { return l[:1] })
+		}, "checkpoint 2: record"},
+		{"deleted newest file", func(dir string) {
+			_ = os.Remove(filepath.Join(dir, "audit-"+day2.Format("2006-01-02")+".log"))
+		}, "checkpoint 2: record"},
+		{"forged checkpoint", func(dir string) {
+			path := filepath.Join(dir, CheckpointFile)
+			data, _ := os.ReadFile(path)
+			_ = os.WriteFile(path, bytes.Replace(data, []byte(`"line":3`), []byte(`"line":2`), 1), 0600)
+		}, "invalid signature"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			writeChained(t, dir, day1, 3)
+			writeChained(t, dir, day2, 2)
+			tt.edit(dir)
+
+			v := verifyChain(t, dir)
+			if v.Valid() || !strings.Contains(v.FirstProblem, tt.want) {
+				t.Errorf("FirstProblem = %q, want it to contain %q", v.FirstProblem, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyChain_PartialAfterRetention(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	day1 := yesterdayNoon()
+	writeChained(t, dir, day1, 3)
+	writeChained(t, dir, todayMidnight(), 2)
+	if err := os.Remove(filepath.Join(dir, "audit-"+day1.Format("2006-01-02")+".log")); err != nil {
+		t.Fatal(err)
+	}
+
+	v := verifyChain(t, dir)
+	if !v.Valid() {
+		t.Fatalf("VerifyChain() problem: %s", v.FirstProblem)
+	}
+	if !v.Partial || v.Records != 2 || v.CheckpointsExpired != 1 || v.CheckpointsMatched != 1 {
+		t.Errorf("Partial = %v, Records = %d, expired %d, matched %d; want true, 2, 1, 1",
+			v.Partial, v.Records, v.CheckpointsExpired, v.CheckpointsMatched)
+	}
+}
+
+func TestVerifyChain_RecordsBeforeChain(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	ts := todayMidnight()
+	store, err := NewFileAuditStore(AuditFileConfig{Dir: dir}, testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Append(context.Background(), makeRecord(ts, "plain")); err != nil {
+		t.Fatal(err)
+	}
+	_ = store.Close()
+	writeChained(t, dir, ts, 2)
+
+	v := verifyChain(t, dir)
+	if !v.Valid() || v.Unchained != 1 || v.Records != 2 || v.Partial {
+		t.Errorf("Valid = %v (%s), Unchained = %d, Records = %d, Partial = %v; want true, 1, 2, false",
+			v.Valid(), v.FirstProblem, v.Unchained, v.Records, v.Partial)
+	}
+	if v.HeadLine != 3 {
+		t.Errorf("HeadLine = %d, want 3", v.HeadLine)
+	}
+}
+
+func TestFileAuditStore_ChainSurvivesCompression(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeChained(t, dir, yesterdayNoon(), 2)
+	store, err := NewFileAuditStore(AuditFileConfig{Dir: dir, Compress: true, Chain: true}, testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := store.Archive(context.Background()); c != 1 {
+		t.Fatalf("Archive() compressed %d files, want 1", c)
+	}
+	if err := store.Append(context.Background(), makeRecord(time.Now().UTC(), "after")); err != nil {
+		t.Fatal(err)
+	}
+	_ = store.Close()
+
+	v := verifyChain(t, dir)
+	if !v.Valid() || v.Records != 3 || v.CheckpointsMatched != 1 {
+		t.Errorf("Valid = %v (%s), Records = %d, matched %d; want true, 3, 1",
+			v.Valid(), v.FirstProblem, v.Records, v.CheckpointsMatched)
+	}
+}
+
+// Test records are timestamped away from midnight so a run near it does
+// not split them across dates.
+func yesterdayNoon() time.Time { return todayMidnight().Add(-12 * time.Hour) }
+func todayMidnight() time.Time { return time.Now().UTC().Truncate(24 * time.Hour) }
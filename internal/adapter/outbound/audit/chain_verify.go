@@ -0,0 +1,274 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CheckpointVerifier checks checkpoint signatures. The evidence ECDSA
+// verifier implements it.
+type CheckpointVerifier interface {
+	Verify(data []byte, signature []byte) (bool, error)
+}
+
+// ChainVerification is the result of VerifyChain.
+type ChainVerification struct {
+	Files int
+	// Records is the number of chained records.
+	Records int
+	// Unchained is the number of records written before the chain was
+	// enabled. They are not protected.
+	Unchained int
+	// Partial is set when the chain does not start at the genesis hash:
+	// its oldest files were deleted by retention.
+	Partial bool
+	// HeadFile and HeadLine locate the last record of the chain, and
+	// HeadTime is its timestamp.
+	HeadFile string
+	HeadLine int
+	HeadTime time.Time
+
+	Checkpoints int
+	// CheckpointsMatched is the number of checkpoints whose record is in
+	// the chain, unchanged and at its recorded position.
+	CheckpointsMatched int
+	// CheckpointsExpired is the number of checkpoints of files deleted by
+	// retention.
+	CheckpointsExpired int
+	// LastCheckpoint is the time of the newest matched checkpoint.
+	LastCheckpoint    time.Time
+	SignaturesChecked bool
+	InvalidSignatures int
+
+	// Problems counts the tampering findings; FirstProblem describes the
+	// first one.
+	Problems     int
+	FirstProblem string
+}
+
+// Valid reports whether no tampering was found.
+func (v *ChainVerification) Valid() bool { return v.Problems == 0 }
+
+func (v *ChainVerification) problem(format string, args ...any) {
+	v.Problems++
+	if v.FirstProblem == "" {
+		v.FirstProblem = fmt.Sprintf(format, args...)
+	}
+}
+
+// chainNode is a chained record seen by VerifyChain.
+type chainNode struct {
+	file  int // index in the chronological file list
+	line  int
+	prev  string
+	child bool
+}
+
+// VerifyChain checks the hash chain of the audit files in dir and the
+// checkpoints in CheckpointFile. Every chained record must match its hash
+// and link to exactly one other record, except the first; each checkpoint
+// must name a record of the chain at its recorded position. Signatures are
+// checked when verifier is not nil.
+//
+// A record is appended to the file of its date, so a late record can
+// continue the chain in an older file; the chain is followed by its links,
+// not by file order. Only I/O errors are returned: tampering is reported in
+// the result.
+func VerifyChain(dir string, verifier CheckpointVerifier) (*ChainVerification, error) {
+	files := listAuditDir(dir)
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no audit files in %s", dir)
+	}
+	v := &ChainVerification{Files: len(files), SignaturesChecked: verifier != nil}
+	names := make([]string, len(files))
+	for i, f := range files {
+		names[i] = strings.TrimSuffix(f.name, compressedExt)
+	}
+
+	nodes := make(map[string]*chainNode)
+	linked := make(map[string]string) // prev_hash -> first record linking to it, as file:line
+	for i, f := range files {
+		if err := verifyChainFile(dir, f, i, names[i], v, nodes, linked); err != nil {
+			return nil, err
+		}
+	}
+
+	// Exactly one record may link outside the chain: the first one.
+	var starts []*chainNode
+	for _, n := range nodes {
+		if _, ok := nodes[n.prev]; !ok {
+			starts = append(starts, n)
+		}
+		if p, ok := nodes[n.prev]; ok {
+			p.child = true
+		}
+	}
+	sort.Slice(starts, func(i, j int) bool { return nodeBefore(starts[i], starts[j]) })
+	for i, n := range starts {
+		switch {
+		case i > 0:
+			v.problem("%s:%d: previous record %.12s is missing (records deleted)", names[n.file], n.line, n.prev)
+		case n.prev != chainGenesis:
+			v.Partial = true
+		}
+	}
+	var head *chainNode
+	for _, n := range nodes {
+		if !n.child && (head == nil || nodeBefore(head, n)) {
+			head = n
+		}
+	}
+	if head != nil {
+		v.HeadFile, v.HeadLine = names[head.file], head.line
+		v.HeadTime = recordTime(dir, files[head.file], head.line)
+	}
+
+	verifyCheckpoints(dir, verifier, v, names, nodes)
+	return v, nil
+}
+
+// verifyChainFile checks the records of one audit file and adds them to
+// nodes.
+func verifyChainFile(dir string, f auditFileInfo, idx int, name string, v *ChainVerification,
+	nodes map[string]*chainNode, linked map[string]string) error {
+	r, err := openAuditFileIn(dir, f)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = r.Close() }()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		raw := scanner.Bytes()
+		if len(raw) == 0 {
+			continue
+		}
+		body, prev, hash, ok := splitChainedLine(raw)
+		if !ok {
+			if v.Records > 0 {
+				v.problem("%s:%d: record without hash after the chain started", name, line)
+			} else {
+				v.Unchained++
+			}
+			continue
+		}
+		v.Records++
+		sum := sha256.Sum256(body)
+		if hex.EncodeToString(sum[:]) != hash {
+			v.problem("%s:%d: record does not match its hash (modified)", name, line)
+			continue
+		}
+		if at, dup := linked[prev]; dup {
+			v.problem("%s:%d: links to the same record as %s (inserted or duplicated)", name, line, at)
+			continue
+		}
+		linked[prev] = fmt.Sprintf("%s:%d", name, line)
+		nodes[hash] = &chainNode{file: idx, line: line, prev: prev}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read %s: %w", f.name, err)
+	}
+	return nil
+}
+
+// verifyCheckpoints checks the signature of every checkpoint and that its
+// record is still in the chain.
+func verifyCheckpoints(dir string, verifier CheckpointVerifier, v *ChainVerification,
+	names []string, nodes map[string]*chainNode) {
+	cps, err := readCheckpoints(dir)
+	if err != nil {
+		v.problem("%v", err)
+		return
+	}
+	oldest, _ := parseAuditFilename(names[0])
+	for i, cp := range cps {
+		v.Checkpoints++
+		if verifier != nil {
+			if !verifyCheckpointSignature(verifier, cp) {
+				v.InvalidSignatures++
+				v.problem("checkpoint %d: invalid signature", i+1)
+				continue
+			}
+		}
+		if n, ok := nodes[cp.Hash]; ok {
+			if names[n.file] != cp.File || n.line != cp.Line {
+				v.problem("checkpoint %d: record %s:%d was moved to %s:%d", i+1, cp.File, cp.Line, names[n.file], n.line)
+				continue
+			}
+			v.CheckpointsMatched++
+			if cp.Timestamp.After(v.LastCheckpoint) {
+				v.LastCheckpoint = cp.Timestamp
+			}
+			continue
+		}
+		// A checkpoint older than every remaining file points into files
+		// removed by retention; any other missing record was tampered with.
+		if info, ok := parseAuditFilename(cp.File); ok && auditFileBefore(info, oldest) {
+			v.CheckpointsExpired++
+			continue
+		}
+		v.problem("checkpoint %d: record %s:%d is missing or modified", i+1, cp.File, cp.Line)
+	}
+}
+
+// recordTime returns the timestamp of the record at line of f, or the zero
+// time when it cannot be read.
+func recordTime(dir string, f auditFileInfo, line int) time.Time {
+	r, err := openAuditFileIn(dir, f)
+	if err != nil {
+		return time.Time{}
+	}
+	defer func() { _ = r.Close() }()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		if n < line {
+			continue
+		}
+		var rec struct {
+			Timestamp time.Time `json:"timestamp"`
+		}
+		_ = json.Unmarshal(scanner.Bytes(), &rec)
+		return rec.Timestamp
+	}
+	return time.Time{}
+}
+
+func verifyCheckpointSignature(verifier CheckpointVerifier, cp ChainCheckpoint) bool {
+	sig, err := base64.StdEncoding.DecodeString(cp.Signature)
+	if err != nil || len(sig) == 0 {
+		return false
+	}
+	payload, err := cp.signedPayload()
+	if err != nil {
+		return false
+	}
+	ok, err := verifier.Verify(payload, sig)
+	return err == nil && ok
+}
+
+func nodeBefore(a, b *chainNode) bool {
+	if a.file != b.file {
+		return a.file < b.file
+	}
+	return a.line < b.line
+}
+
+// auditFileBefore reports whether a sorts before b chronologically.
+func auditFileBefore(a, b auditFileInfo) bool {
+	if a.date != b.date {
+		return a.date < b.date
+	}
+	return a.suffix < b.suffix
+}
@@ -0,0 +1,242 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+)
+
+// compressedExt is appended to the name of a compressed audit file.
+const compressedExt = ".zst"
+
+// compressTmpExt marks a compressed file that is still being written.
+const compressTmpExt = compressedExt + ".tmp"
+
+// scheduleCompressionLocked compresses rotated files in the background after
+// a rotation. Must be called with s.mu held.
+func (s *FileAuditStore) scheduleCompressionLocked() {
+	if !s.compress {
+		return
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.compressRotated(s.ctx)
+	}()
+}
+
+// compressRotated compresses every uncompressed audit file except the one
+// currently written to. Leftovers of interrupted passes are removed first.
+func (s *FileAuditStore) compressRotated(ctx context.Context) int {
+	s.compressMu.Lock()
+	defer s.compressMu.Unlock()
+
+	if entries, err := os.ReadDir(s.dir); err == nil {
+		for _, e := range entries {
+			if strings.HasPrefix(e.Name(), "audit-") && strings.HasSuffix(e.Name(), compressTmpExt) {
+				_ = os.Remove(filepath.Join(s.dir, e.Name()))
+			}
+		}
+	}
+
+	current := s.currentFilename()
+	compressed := 0
+	for _, f := range s.listAuditFiles() {
+		if f.compressed || f.name == current {
+			continue
+		}
+		if ctx.Err() != nil {
+			return compressed
+		}
+		if err := s.compressFile(f.name); err != nil {
+			s.logger.Error("audit compression: failed to compress file", "file", f.name, "error", err)
+			continue
+		}
+		compressed++
+	}
+	if compressed > 0 {
+		s.logger.Info("audit compression completed", "compressed", compressed)
+	}
+	return compressed
+}
+
+// compressFile replaces name with name.zst. The compressed file records the
+// original size in its frame header, which StorageStats reports without
+// decompressing. If the file was written to while being compressed (a late
+// record reopened it), the original is kept.
+func (s *FileAuditStore) compressFile(name string) error {
+	src := filepath.Join(s.dir, name)
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+	fi, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	if fi.Size() == 0 {
+		return nil
+	}
+
+	tmp := src + compressTmpExt
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	keep := false
+	defer func() {
+		if !keep {
+			_ = os.Remove(tmp)
+		}
+	}()
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		_ = out.Close()
+		return err
+	}
+	enc.ResetContentSize(out, fi.Size())
+	if _, err := io.Copy(enc, io.LimitReader(in, fi.Size())); err != nil {
+		_ = out.Close()
+		return fmt.Errorf("compress: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		_ = out.Close()
+		return fmt.Errorf("compress: %w", err)
+	}
+	if err := out.Sync(); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	// Swap under s.mu so no append can reach the original in between.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if name == s.buildFilename(s.currentDate, s.currentSuffix) {
+		return nil
+	}
+	if now, err := os.Stat(src); err != nil || now.Size() != fi.Size() {
+		return nil
+	}
+	if err := os.Rename(tmp, src+compressedExt); err != nil {
+		return err
+	}
+	keep = true
+	return os.Remove(src)
+}
+
+// zstdFileReader decompresses an open audit file.
+type zstdFileReader struct {
+	dec *zstd.Decoder
+	f   *os.File
+}
+
+func (r *zstdFileReader) Read(p []byte) (int, error) { return r.dec.Read(p) }
+
+func (r *zstdFileReader) Close() error {
+	r.dec.Close()
+	return r.f.Close()
+}
+
+// openAuditFile opens an audit file for reading, decompressing it when it
+// is compressed. A file compressed since it was listed is opened under its
+// new name.
+func (s *FileAuditStore) openAuditFile(file auditFileInfo) (io.ReadCloser, error) {
+	return openAuditFileIn(s.dir, file)
+}
+
+// openAuditFileIn is openAuditFile for a file in dir.
+func openAuditFileIn(dir string, file auditFileInfo) (io.ReadCloser, error) {
+	path := filepath.Join(dir, file.name)
+	compressed := file.compressed
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) && !compressed {
+		compressed = true
+		f, err = os.Open(path + compressedExt)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !compressed {
+		return f, nil
+	}
+	dec, err := zstd.NewReader(f, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &zstdFileReader{dec: dec, f: f}, nil
+}
+
+// uncompressedSize returns the original size recorded in the header of a
+// compressed audit file, or its size on disk when it is not compressed.
+func (s *FileAuditStore) uncompressedSize(file auditFileInfo, diskSize int64) int64 {
+	if !file.compressed {
+		return diskSize
+	}
+	f, err := os.Open(filepath.Join(s.dir, file.name))
+	if err != nil {
+		return diskSize
+	}
+	defer func() { _ = f.Close() }()
+	buf := make([]byte, zstd.HeaderMaxSize)
+	n, _ := io.ReadFull(f, buf)
+	var h zstd.Header
+	if err := h.Decode(buf[:n]); err != nil || !h.HasFCS {
+		return diskSize
+	}
+	return int64(h.FrameContentSize)
+}
+
+// StorageStats reports the number and size of the audit files, on disk and
+// decompressed, so the savings of compression can be shown.
+func (s *FileAuditStore) StorageStats() (audit.StorageStats, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return audit.StorageStats{}, fmt.Errorf("read audit directory: %w", err)
+	}
+	stats := audit.StorageStats{
+		Dir:           s.dir,
+		Compression:   s.compress,
+		MaxTotalBytes: s.maxTotalSize,
+	}
+	for _, e := range entries {
+		file, ok := parseAuditFilename(e.Name())
+		if !ok {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			continue
+		}
+		stats.Files++
+		stats.DiskBytes += fi.Size()
+		stats.UncompressedBytes += s.uncompressedSize(file, fi.Size())
+		if file.compressed {
+			stats.CompressedFiles++
+		}
+		if stats.OldestDate == "" || file.date < stats.OldestDate {
+			stats.OldestDate = file.date
+		}
+		if file.date > stats.NewestDate {
+			stats.NewestDate = file.date
+		}
+	}
+	return stats, nil
+}
+
+// Compile-time interface verification.
+var _ audit.StorageReporter = (*FileAuditStore)(nil)
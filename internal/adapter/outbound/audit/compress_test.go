@@ -0,0 +1,242 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+)
+
+// writeAuditFile writes n records for date into dir as an uncompressed file.
+func writeAuditFile(t *testing.T, dir string, date time.Time, n int) string {
+	t.Helper()
+	name := fmt.Sprintf("audit-%s.log", date.Format("2006-01-02"))
+	f, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("create %s: %v", name, err)
+	}
+	defer func() { _ = f.Close() }()
+	enc := json.NewEncoder(f)
+	for i := 0; i < n; i++ {
+		rec := makeRecord(date.Add(time.Duration(i)*time.Second), fmt.Sprintf("%s-req-%d", date.Format("0102"), i))
+		if err := enc.Encode(rec); err != nil {
+			t.Fatalf("write record: %v", err)
+		}
+	}
+	return name
+}
+
+// waitForFile polls until path exists.
+func waitForFile(t *testing.T, path string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s was not created", filepath.Base(path))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestFileAuditStore_CompressesRotatedFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	yesterday := time.Now().UTC().AddDate(0, 0, -1).Truncate(24 * time.Hour).Add(time.Hour)
+	old := writeAuditFile(t, dir, yesterday, 200)
+
+	store, err := NewFileAuditStore(AuditFileConfig{Dir: dir, Compress: true}, testLogger())
+	if err != nil {
+		t.Fatalf("NewFileAuditStore() error: %v", err)
+	}
+	waitForFile(t, filepath.Join(dir, old+compressedExt))
+	if _, err := os.Stat(filepath.Join(dir, old)); !os.IsNotExist(err) {
+		t.Errorf("uncompressed %s still exists after compression (err=%v)", old, err)
+	}
+
+	today := time.Now().UTC()
+	if err := store.Append(context.Background(), makeRecord(today, "today-req")); err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+	if err := store.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error: %v", err)
+	}
+	current := filepath.Join(dir, fmt.Sprintf("audit-%s.log", today.Format("2006-01-02")))
+	if _, err := os.Stat(current); err != nil {
+		t.Errorf("current file must stay uncompressed: %v", err)
+	}
+
+	// Queries read the compressed file transparently.
+	records, _, err := store.Query(context.Background(), audit.AuditFilter{
+		StartTime: yesterday,
+		EndTime:   yesterday.Add(time.Hour),
+		Limit:     1000,
+	})
+	if err != nil {
+		t.Fatalf("Query() error: %v", err)
+	}
+	if len(records) != 200 {
+		t.Fatalf("Query() returned %d records from the compressed file, want 200", len(records))
+	}
+	if records[0].RequestID != yesterday.Format("0102")+"-req-199" {
+		t.Errorf("Query()[0] = %q, want the newest record first", records[0].RequestID)
+	}
+
+	stats, err := store.StorageStats()
+	if err != nil {
+		t.Fatalf("StorageStats() error: %v", err)
+	}
+	if stats.Files != 2 || stats.CompressedFiles != 1 || !stats.Compression {
+		t.Errorf("StorageStats() = %+v, want 2 files with 1 compressed", stats)
+	}
+	if stats.UncompressedBytes <= stats.DiskBytes {
+		t.Errorf("StorageStats() uncompressed=%d disk=%d, want savings", stats.UncompressedBytes, stats.DiskBytes)
+	}
+	_ = store.Close()
+
+	// The boot cache is populated from the compressed file too.
+	reopened, err := NewFileAuditStore(AuditFileConfig{Dir: dir, CacheSize: 50}, testLogger())
+	if err != nil {
+		t.Fatalf("NewFileAuditStore() reopen error: %v", err)
+	}
+	defer func() { _ = reopened.Close() }()
+	recent := reopened.GetRecent(50)
+	if len(recent) != 50 || recent[0].RequestID != "today-req" || recent[1].RequestID != yesterday.Format("0102")+"-req-199" {
+		t.Errorf("GetRecent() after reopen = %d records starting %v, want today's then yesterday's", len(recent), recent[:min(2, len(recent))])
+	}
+}
+
+func TestFileAuditStore_QueryFilters(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	store, err := NewFileAuditStore(AuditFileConfig{Dir: dir}, testLogger())
+	if err != nil {
+		t.Fatalf("NewFileAuditStore() error: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	now := time.Now().UTC()
+	allowed := makeRecord(now, "allowed")
+	allowed.ToolName = "desktop/read_file"
+	denied := makeRecord(now.Add(time.Second), "denied")
+	denied.Decision = audit.DecisionDeny
+	denied.RuleID = "block-writes"
+	denied.Reason = "Denied by policy"
+	if err := store.Append(context.Background(), allowed, denied); err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+
+	for _, tt := range []struct {
+		filter audit.AuditFilter
+		want   string
+	}{
+		{audit.AuditFilter{Decision: "DENY"}, "denied"},
+		{audit.AuditFilter{ToolName: "read_file"}, "allowed"},
+		{audit.AuditFilter{Limit: 1}, "denied"},
+		{audit.AuditFilter{Upstream: "desktop"}, "allowed"},
+		{audit.AuditFilter{RuleID: "block-writes"}, "denied"},
+		{audit.AuditFilter{Reason: "POLICY"}, "denied"},
+		{audit.AuditFilter{Limit: 1, Ascending: true}, "allowed"},
+	} {
+		records, _, err := store.Query(context.Background(), tt.filter)
+		if err != nil {
+			t.Fatalf("Query(%+v) error: %v", tt.filter, err)
+		}
+		if len(records) != 1 || records[0].RequestID != tt.want {
+			t.Errorf("Query(%+v) = %v, want only %q", tt.filter, records, tt.want)
+		}
+	}
+}
+
+func TestFileAuditStore_MaxTotalSizeDeletesOldest(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	now := time.Now().UTC()
+	var names []string
+	for days := 3; days >= 1; days-- {
+		name := fmt.Sprintf("audit-%s.log", now.AddDate(0, 0, -days).Format("2006-01-02"))
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(strings.Repeat("x", 600*1024)+"\n"), 0600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		names = append(names, name)
+	}
+
+	store, err := NewFileAuditStore(AuditFileConfig{Dir: dir, MaxTotalSizeMB: 1}, testLogger())
+	if err != nil {
+		t.Fatalf("NewFileAuditStore() error: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	for i, name := range names {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if exists := err == nil; exists != (i == 2) {
+			t.Errorf("%s exists = %v, want only the newest rotated file kept", name, exists)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, store.currentFilename())); err != nil {
+		t.Errorf("current file deleted by size cap: %v", err)
+	}
+}
+
+func TestFileAuditStore_ArchiveDeletesExpiredFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	store, err := NewFileAuditStore(AuditFileConfig{Dir: dir, RetentionDays: 7}, testLogger())
+	if err != nil {
+		t.Fatalf("NewFileAuditStore() error: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	expired := writeAuditFile(t, dir, time.Now().UTC().AddDate(0, 0, -30), 3)
+	recent := writeAuditFile(t, dir, time.Now().UTC().AddDate(0, 0, -2), 3)
+
+	compressed, deleted := store.Archive(context.Background())
+	if compressed != 0 || deleted != 1 {
+		t.Errorf("Archive() = (%d compressed, %d deleted), want (0, 1)", compressed, deleted)
+	}
+	if _, err := os.Stat(filepath.Join(dir, expired)); !os.IsNotExist(err) {
+		t.Errorf("%s should have been deleted", expired)
+	}
+	if _, err := os.Stat(filepath.Join(dir, recent)); err != nil {
+		t.Errorf("%s should have been kept: %v", recent, err)
+	}
+}
+
+func TestNewAuditFileReader_QueriesWithoutWriting(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	day := time.Now().UTC().AddDate(0, 0, -30).Truncate(24 * time.Hour).Add(time.Hour)
+	writeAuditFile(t, dir, day, 10)
+
+	reader := NewAuditFileReader(dir, testLogger())
+	defer func() { _ = reader.Close() }()
+	records, _, err := reader.Query(context.Background(), audit.AuditFilter{StartTime: day, EndTime: day.Add(time.Hour), Limit: 100})
+	if err != nil {
+		t.Fatalf("Query() error: %v", err)
+	}
+	if len(records) != 10 {
+		t.Errorf("Query() returned %d records, want 10", len(records))
+	}
+
+	// The old file is neither removed by retention nor joined by a new file.
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("reader changed the directory: %d entries", len(entries))
+	}
+}
@@ -1,12 +1,16 @@
 // Package audit provides file-based audit persistence with JSON Lines format,
-// daily rotation, size caps, retention cleanup, and an in-memory cache.
+// daily rotation, size caps, optional zstd compression of rotated files,
+// an optional hash chain with signed checkpoints, retention cleanup, and an
+// in-memory cache.
 package audit
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
@@ -21,9 +25,10 @@ import (
 
 // auditFileInfo holds parsed information about an audit file.
 type auditFileInfo struct {
-	name   string
-	date   string
-	suffix int
+	name       string
+	date       string
+	suffix     int
+	compressed bool
 }
 
 // parseAuditFilename parses an audit filename and returns its components.
@@ -34,8 +39,9 @@ func parseAuditFilename(name string) (auditFileInfo, bool) {
 	}
 
 	info := auditFileInfo{
-		name: name,
-		date: matches[1],
+		name:       name,
+		date:       matches[1],
+		compressed: matches[3] != "",
 	}
 
 	if matches[2] != "" {
@@ -69,13 +75,30 @@ type AuditFileConfig struct {
 	MaxFileSizeMB int
 	// CacheSize is the number of recent entries to keep in memory (default 1000).
 	CacheSize int
+	// Compress enables zstd compression of rotated (non-current) files.
+	Compress bool
+	// MaxTotalSizeMB caps the on-disk size of all audit files; the oldest
+	// files are deleted first when it is exceeded (0 = no cap). Compressed
+	// files count with their compressed size.
+	MaxTotalSizeMB int
+	// Chain adds prev_hash and hash fields to every record, linking the
+	// records in a SHA-256 hash chain that VerifyChain checks.
+	Chain bool
+	// CheckpointSigner, when set with Chain, signs the head of the chain
+	// every CheckpointInterval and on Close, appending it to CheckpointFile.
+	CheckpointSigner CheckpointSigner
+	// CheckpointInterval is the time between checkpoints (default 1h).
+	CheckpointInterval time.Duration
 }
 
 // FileAuditStore implements audit.AuditStore with file rotation, retention, and cache.
 type FileAuditStore struct {
 	dir           string
 	maxFileSize   int64
+	maxTotalSize  int64
 	retentionDays int
+	compress      bool
+	compressMu    sync.Mutex // serializes compression passes
 	currentFile   *os.File
 	currentDate   string
 	currentSize   int64
@@ -83,19 +106,38 @@ type FileAuditStore struct {
 	cache         *auditCache
 	mu            sync.Mutex
 	logger        *slog.Logger
+	ctx           context.Context // cancelled by Close
 	cancel        context.CancelFunc
 	wg            sync.WaitGroup // L-33: tracks cleanup goroutine for graceful shutdown
 	closeOnce     sync.Once
 	closeErr      error
+
+	// currentLines is the number of lines in the current file; highWater
+	// is the highest position written. Both are guarded by mu.
+	currentLines int
+	highWater    recordPos
+
+	// Hash chain state, guarded by mu. headFile and headLine locate the
+	// record whose hash is lastHash.
+	chain    bool
+	lastHash string
+	headFile string
+	headLine int
+
+	checkpointSigner   CheckpointSigner
+	checkpointInterval time.Duration
+	checkpointedHash   string // only used by the checkpoint loop and Close
 }
 
-// auditFilePattern matches audit log filenames: audit-YYYY-MM-DD.log or audit-YYYY-MM-DD-N.log
-var auditFilePattern = regexp.MustCompile(`^audit-(\d{4}-\d{2}-\d{2})(?:-(\d+))?\.log$`)
+// auditFilePattern matches audit log filenames: audit-YYYY-MM-DD.log or
+// audit-YYYY-MM-DD-N.log, with a .zst extension once compressed.
+var auditFilePattern = regexp.MustCompile(`^audit-(\d{4}-\d{2}-\d{2})(?:-(\d+))?\.log(\.zst)?$`)
 
 // NewFileAuditStore creates a new file-based audit store.
 // It creates the directory if it does not exist, opens today's log file,
-// runs retention cleanup, populates the cache from the most recent file,
-// and starts the hourly cleanup goroutine.
+// runs retention cleanup, populates the cache from the most recent files,
+// and starts the hourly cleanup goroutine (which also compresses rotated
+// files when compression is enabled).
 func NewFileAuditStore(cfg AuditFileConfig, logger *slog.Logger) (*FileAuditStore, error) {
 	// Apply defaults
 	if cfg.RetentionDays <= 0 {
@@ -107,6 +149,9 @@ func NewFileAuditStore(cfg AuditFileConfig, logger *slog.Logger) (*FileAuditStor
 	if cfg.CacheSize <= 0 {
 		cfg.CacheSize = 1000
 	}
+	if cfg.CheckpointInterval <= 0 {
+		cfg.CheckpointInterval = time.Hour
+	}
 
 	// Create directory with restricted permissions
 	if err := os.MkdirAll(cfg.Dir, 0700); err != nil {
@@ -118,10 +163,21 @@ func NewFileAuditStore(cfg AuditFileConfig, logger *slog.Logger) (*FileAuditStor
 	s := &FileAuditStore{
 		dir:           cfg.Dir,
 		maxFileSize:   int64(cfg.MaxFileSizeMB) * 1024 * 1024,
+		maxTotalSize:  int64(cfg.MaxTotalSizeMB) * 1024 * 1024,
 		retentionDays: cfg.RetentionDays,
+		compress:      cfg.Compress,
 		cache:         newAuditCache(cfg.CacheSize),
 		logger:        logger,
+		ctx:           ctx,
 		cancel:        cancel,
+		chain:         cfg.Chain,
+	}
+	if cfg.Chain && cfg.CheckpointSigner != nil {
+		s.checkpointSigner = cfg.CheckpointSigner
+		s.checkpointInterval = cfg.CheckpointInterval
+	}
+	if s.chain {
+		s.loadChainHead()
 	}
 
 	// Open today's log file
@@ -140,6 +196,10 @@ func NewFileAuditStore(cfg AuditFileConfig, logger *slog.Logger) (*FileAuditStor
 	// L-33: Track cleanup goroutine with WaitGroup for graceful shutdown.
 	s.wg.Add(1)
 	go s.startCleanupLoop(ctx)
+	if s.checkpointSigner != nil {
+		s.wg.Add(1)
+		go s.startCheckpointLoop(ctx)
+	}
 
 	return s, nil
 }
@@ -155,6 +215,9 @@ func (s *FileAuditStore) Append(ctx context.Context, records ...audit.AuditRecor
 	defer s.mu.Unlock()
 
 	for _, rec := range records {
+		if rec.SchemaVersion == 0 {
+			rec.SchemaVersion = audit.CurrentSchemaVersion
+		}
 		dateStr := rec.Timestamp.UTC().Format("2006-01-02")
 
 		// Check if date rotation is needed
@@ -178,6 +241,10 @@ func (s *FileAuditStore) Append(ctx context.Context, records ...audit.AuditRecor
 		}
 
 		// Write JSON line
+		var hash string
+		if s.chain {
+			data, hash = chainRecord(data, s.lastHash)
+		}
 		line := make([]byte, len(data)+1)
 		copy(line, data)
 		line[len(data)] = '\n'
@@ -186,9 +253,20 @@ func (s *FileAuditStore) Append(ctx context.Context, records ...audit.AuditRecor
 			return fmt.Errorf("write audit record: %w", err)
 		}
 		s.currentSize += int64(n)
+		s.currentLines++
+		if s.chain {
+			s.lastHash = hash
+			s.headFile = s.buildFilename(s.currentDate, s.currentSuffix)
+			s.headLine = s.currentLines
+		}
 
 		// Add to cache
-		s.cache.Add(rec)
+		pos := recordPos{date: s.currentDate, suffix: s.currentSuffix, line: s.currentLines}
+		late := pos.before(s.highWater)
+		if !late {
+			s.highWater = pos
+		}
+		s.cache.AddAt(rec, pos, late)
 	}
 
 	// Fsync to ensure all records are persisted to disk.
@@ -223,6 +301,12 @@ func (s *FileAuditStore) Close() error {
 		// close the file.
 		s.wg.Wait()
 
+		if s.checkpointSigner != nil {
+			if err := s.writeCheckpoint(); err != nil {
+				s.logger.Error("audit chain: failed to write checkpoint", "error", err)
+			}
+		}
+
 		s.mu.Lock()
 		defer s.mu.Unlock()
 
@@ -244,7 +328,7 @@ func (s *FileAuditStore) GetRecent(n int) []audit.AuditRecord {
 // It determines the correct suffix by checking existing files on disk.
 func (s *FileAuditStore) openCurrentFile(dateStr string) error {
 	// Find the highest existing suffix for this date
-	suffix := s.findHighestSuffix(dateStr)
+	suffix := s.writableSuffix(dateStr, s.findHighestSuffix(dateStr))
 
 	f, size, err := s.openFile(dateStr, suffix)
 	if err != nil {
@@ -255,10 +339,35 @@ func (s *FileAuditStore) openCurrentFile(dateStr string) error {
 	s.currentDate = dateStr
 	s.currentSize = size
 	s.currentSuffix = suffix
+	s.countCurrentLinesLocked()
 
 	return nil
 }
 
+// countCurrentLinesLocked sets currentLines to the number of lines in the
+// file just opened for writing, so appended records know their position.
+// Must be called with s.mu held.
+func (s *FileAuditStore) countCurrentLinesLocked() {
+	s.currentLines = 0
+	if s.currentSize == 0 {
+		return
+	}
+	f, err := os.Open(filepath.Join(s.dir, s.buildFilename(s.currentDate, s.currentSuffix)))
+	if err != nil {
+		s.logger.Error("audit: failed to count lines", "error", err)
+		return
+	}
+	defer func() { _ = f.Close() }()
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := f.Read(buf)
+		s.currentLines += bytes.Count(buf[:n], []byte{'\n'})
+		if err != nil {
+			return
+		}
+	}
+}
+
 // findHighestSuffix returns the highest existing suffix for a date, or 0 if none.
 func (s *FileAuditStore) findHighestSuffix(dateStr string) int {
 	entries, err := os.ReadDir(s.dir)
@@ -280,6 +389,17 @@ func (s *FileAuditStore) findHighestSuffix(dateStr string) int {
 	return highest
 }
 
+// writableSuffix returns suffix, or the next free suffix for the date when
+// the file with that suffix was already compressed: compressed files are
+// never appended to.
+func (s *FileAuditStore) writableSuffix(dateStr string, suffix int) int {
+	name := s.buildFilename(dateStr, suffix) + compressedExt
+	if _, err := os.Stat(filepath.Join(s.dir, name)); err == nil {
+		return s.findHighestSuffix(dateStr) + 1
+	}
+	return suffix
+}
+
 // openFile opens an audit file with the given date and suffix.
 // Returns the file handle and its current size.
 func (s *FileAuditStore) openFile(dateStr string, suffix int) (*os.File, int64, error) {
@@ -315,7 +435,8 @@ func (s *FileAuditStore) buildFilename(dateStr string, suffix int) string {
 func (s *FileAuditStore) rotateDateLocked(dateStr string) error {
 	// L-17: Open new file first, before closing the old one.
 	// If this fails, s.currentFile remains valid for subsequent writes.
-	f, size, err := s.openFile(dateStr, 0)
+	suffix := s.writableSuffix(dateStr, 0)
+	f, size, err := s.openFile(dateStr, suffix)
 	if err != nil {
 		return err
 	}
@@ -332,8 +453,10 @@ func (s *FileAuditStore) rotateDateLocked(dateStr string) error {
 
 	s.currentFile = f
 	s.currentDate = dateStr
-	s.currentSuffix = 0
+	s.currentSuffix = suffix
 	s.currentSize = size
+	s.countCurrentLinesLocked()
+	s.scheduleCompressionLocked()
 
 	return nil
 }
@@ -362,16 +485,20 @@ func (s *FileAuditStore) rotateSizeLocked() error {
 	s.currentFile = f
 	s.currentSuffix = nextSuffix
 	s.currentSize = size
+	s.countCurrentLinesLocked()
+	s.scheduleCompressionLocked()
 
 	return nil
 }
 
-// runCleanup deletes audit files older than the retention period.
-func (s *FileAuditStore) runCleanup() {
+// runCleanup deletes audit files older than the retention period, then the
+// oldest files while the total size exceeds MaxTotalSizeMB. Returns the
+// number of files deleted.
+func (s *FileAuditStore) runCleanup() int {
 	entries, err := os.ReadDir(s.dir)
 	if err != nil {
 		s.logger.Error("audit cleanup: failed to read directory", "dir", s.dir, "error", err)
-		return
+		return 0
 	}
 
 	cutoff := time.Now().UTC().AddDate(0, 0, -s.retentionDays)
@@ -402,6 +529,54 @@ func (s *FileAuditStore) runCleanup() {
 	if deleted > 0 {
 		s.logger.Info("audit cleanup completed", "deleted", deleted)
 	}
+
+	if s.maxTotalSize > 0 {
+		deleted += s.enforceTotalSize()
+	}
+	return deleted
+}
+
+// enforceTotalSize deletes the oldest audit files (never the current one)
+// until the on-disk total is within maxTotalSize. Returns the number of
+// files deleted.
+func (s *FileAuditStore) enforceTotalSize() int {
+	files := s.listAuditFiles()
+	sizes := make([]int64, len(files))
+	var total int64
+	for i, f := range files {
+		if fi, err := os.Stat(filepath.Join(s.dir, f.name)); err == nil {
+			sizes[i] = fi.Size()
+			total += sizes[i]
+		}
+	}
+
+	current := s.currentFilename()
+	deleted := 0
+	for i, f := range files {
+		if total <= s.maxTotalSize {
+			break
+		}
+		if f.name == current {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, f.name)); err != nil {
+			s.logger.Error("audit cleanup: failed to delete file", "file", f.name, "error", err)
+			continue
+		}
+		total -= sizes[i]
+		deleted++
+	}
+	if deleted > 0 {
+		s.logger.Info("audit size cap enforced", "deleted", deleted, "total_bytes", total, "max_bytes", s.maxTotalSize)
+	}
+	return deleted
+}
+
+// currentFilename returns the name of the file currently written to.
+func (s *FileAuditStore) currentFilename() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buildFilename(s.currentDate, s.currentSuffix)
 }
 
 // startCleanupLoop runs retention cleanup every hour until the context is cancelled.
@@ -411,16 +586,37 @@ func (s *FileAuditStore) startCleanupLoop(ctx context.Context) {
 	ticker := time.NewTicker(1 * time.Hour)
 	defer ticker.Stop()
 
+	// Compress files rotated before this start (or left over by an older
+	// version without compression).
+	if s.compress {
+		s.compressRotated(ctx)
+		s.runCleanup()
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
+			if s.compress {
+				s.compressRotated(ctx)
+			}
 			s.runCleanup()
 		}
 	}
 }
 
+// Archive runs audit file maintenance now instead of waiting for the hourly
+// pass: rotated files are compressed (when compression is enabled), then
+// files past the retention period or over the size cap are deleted.
+// Returns how many files were compressed and deleted.
+func (s *FileAuditStore) Archive(ctx context.Context) (compressed, deleted int) {
+	if s.compress {
+		compressed = s.compressRotated(ctx)
+	}
+	return compressed, s.runCleanup()
+}
+
 // populateCache reads recent audit files (newest first, scanning backwards)
 // and fills the cache up to its configured capacity.
 // L-18: Scans multiple files instead of just the most recent one, so the cache
@@ -439,10 +635,10 @@ func (s *FileAuditStore) populateCache() {
 	// stopping once we have enough to fill the cache.
 	// We use a ring buffer to keep only the last cacheSize records per file,
 	// then accumulate across files.
-	var allRecords []audit.AuditRecord
+	var allRecords []cacheEntry
 
 	for i := len(sortedFiles) - 1; i >= 0 && len(allRecords) < cacheSize; i-- {
-		records := s.readRecordsFromFile(sortedFiles[i].name, cacheSize-len(allRecords))
+		records := s.readRecordsFromFile(sortedFiles[i], cacheSize-len(allRecords))
 		if len(records) > 0 {
 			// Prepend: older files go before newer files in chronological order.
 			allRecords = append(records, allRecords...)
@@ -454,17 +650,26 @@ func (s *FileAuditStore) populateCache() {
 		allRecords = allRecords[len(allRecords)-cacheSize:]
 	}
 
-	// Add records to cache in chronological order (oldest first).
-	for _, rec := range allRecords {
-		s.cache.Add(rec)
+	// Add records to cache in chronological order (oldest first). They are
+	// the newest records on disk, so none is late.
+	for _, e := range allRecords {
+		s.cache.AddAt(e.rec, e.pos, false)
+	}
+	if len(allRecords) > 0 {
+		s.mu.Lock()
+		if last := allRecords[len(allRecords)-1].pos; s.highWater.before(last) {
+			s.highWater = last
+		}
+		s.mu.Unlock()
 	}
 }
 
 // readRecordsFromFile reads up to maxRecords from a single audit file,
-// keeping only the last maxRecords entries (most recent in the file).
-func (s *FileAuditStore) readRecordsFromFile(filename string, maxRecords int) []audit.AuditRecord {
-	path := filepath.Join(s.dir, filename)
-	f, err := os.Open(path)
+// keeping only the last maxRecords entries (most recent in the file), with
+// their positions. Compressed files are decompressed transparently.
+func (s *FileAuditStore) readRecordsFromFile(file auditFileInfo, maxRecords int) []cacheEntry {
+	filename := file.name
+	f, err := s.openAuditFile(file)
 	if err != nil {
 		s.logger.Error("audit cache: failed to open file for population",
 			"file", filename, "error", err)
@@ -472,29 +677,18 @@ func (s *FileAuditStore) readRecordsFromFile(filename string, maxRecords int) []
 	}
 	defer func() { _ = f.Close() }()
 
-	ring := make([]audit.AuditRecord, maxRecords)
+	ring := make([]cacheEntry, maxRecords)
 	ringIdx := 0
 	count := 0
 
-	scanner := bufio.NewScanner(f)
-	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024) // L-7: allow up to 10MB lines
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		if len(line) == 0 {
-			continue
-		}
-		var rec audit.AuditRecord
-		if err := json.Unmarshal(line, &rec); err != nil {
-			s.logger.Warn("audit cache: skipping malformed record",
-				"file", filename, "error", err)
-			continue
-		}
-		ring[ringIdx%maxRecords] = rec
+	err = s.scanRecordLines(f, filename, func(line int, rec audit.AuditRecord) bool {
+		ring[ringIdx%maxRecords] = cacheEntry{rec: rec, pos: file.pos(line)}
 		ringIdx++
 		count++
-	}
+		return true
+	})
 	// L-32: Check scanner error after loop to detect truncated/corrupt reads.
-	if err := scanner.Err(); err != nil {
+	if err != nil {
 		s.logger.Warn("audit cache: scanner error, cache may be incomplete",
 			"file", filename, "error", err)
 	}
@@ -508,7 +702,7 @@ func (s *FileAuditStore) readRecordsFromFile(filename string, maxRecords int) []
 		n = maxRecords
 	}
 
-	result := make([]audit.AuditRecord, n)
+	result := make([]cacheEntry, n)
 	if count <= maxRecords {
 		copy(result, ring[:n])
 	} else {
@@ -521,6 +715,65 @@ func (s *FileAuditStore) readRecordsFromFile(filename string, maxRecords int) []
 	return result
 }
 
+// scanRecords decodes the JSON Lines in r and calls fn for each record,
+// rolled forward to the current schema version. Malformed lines and records
+// of unsupported versions are skipped. Uses bufio.Scanner with a generous buffer
+// (L-7: allows up to 10MB lines).
+func (s *FileAuditStore) scanRecords(r io.Reader, filename string, fn func(audit.AuditRecord)) error {
+	return s.scanRecordLines(r, filename, func(_ int, rec audit.AuditRecord) bool {
+		fn(rec)
+		return true
+	})
+}
+
+// scanRecordLines is scanRecords that also passes the line of each record,
+// starting at 1. Scanning stops when fn returns false.
+func (s *FileAuditStore) scanRecordLines(r io.Reader, filename string, fn func(int, audit.AuditRecord) bool) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	n := 0
+	for scanner.Scan() {
+		n++
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		rec, err := audit.DecodeRecord(line)
+		if err != nil {
+			s.logger.Warn("audit: skipping malformed record",
+				"file", filename, "error", err)
+			continue
+		}
+		if !fn(n, rec) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// listAuditFiles returns all audit files, including empty ones, sorted
+// chronologically (oldest first).
+func (s *FileAuditStore) listAuditFiles() []auditFileInfo {
+	return listAuditDir(s.dir)
+}
+
+// listAuditDir returns the audit files in dir, including empty ones,
+// sorted chronologically (oldest first).
+func listAuditDir(dir string) []auditFileInfo {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var files []auditFileInfo
+	for _, e := range entries {
+		if info, ok := parseAuditFilename(e.Name()); ok {
+			files = append(files, info)
+		}
+	}
+	sortAuditFiles(files)
+	return files
+}
+
 // findSortedAuditFiles returns all non-empty audit files sorted chronologically
 // (oldest first). L-18: Used by populateCache to scan multiple files.
 func (s *FileAuditStore) findSortedAuditFiles() []auditFileInfo {
@@ -551,31 +804,47 @@ func (s *FileAuditStore) findSortedAuditFiles() []auditFileInfo {
 var _ audit.AuditStore = (*FileAuditStore)(nil)
 
 // auditCache is a ring buffer of recent audit entries for fast UI access.
+// Entries added by the store carry their position in the audit files, so
+// queries can page through the cache and continue in the files.
 type auditCache struct {
-	entries []audit.AuditRecord
+	entries []cacheEntry
 	size    int
 	head    int
 	count   int
 	mu      sync.RWMutex
 }
 
+// cacheEntry is a cached record with its position. late is set when the
+// record was written below a position written before it (a late record
+// appended to the file of an earlier date).
+type cacheEntry struct {
+	rec  audit.AuditRecord
+	pos  recordPos
+	late bool
+}
+
 // newAuditCache creates a new cache with the given capacity.
 func newAuditCache(size int) *auditCache {
 	if size <= 0 {
 		size = 1000
 	}
 	return &auditCache{
-		entries: make([]audit.AuditRecord, size),
+		entries: make([]cacheEntry, size),
 		size:    size,
 	}
 }
 
 // Add adds a record to the ring buffer, overwriting the oldest entry if full.
 func (c *auditCache) Add(rec audit.AuditRecord) {
+	c.AddAt(rec, recordPos{}, false)
+}
+
+// AddAt adds a record written at pos.
+func (c *auditCache) AddAt(rec audit.AuditRecord, pos recordPos, late bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.entries[c.head] = rec
+	c.entries[c.head] = cacheEntry{rec: rec, pos: pos, late: late}
 	c.head = (c.head + 1) % c.size
 	if c.count < c.size {
 		c.count++
@@ -600,12 +869,31 @@ func (c *auditCache) Recent(n int) []audit.AuditRecord {
 	for i := 0; i < n; i++ {
 		// head points to next write position, so head-1 is most recent
 		idx := (c.head - 1 - i + c.size) % c.size
-		result[i] = c.entries[idx]
+		result[i] = c.entries[idx].rec
 	}
 
 	return result
 }
 
+// ordered returns the cached entries oldest first when they hold every
+// record at or after the position of the oldest one, in position order:
+// each entry has a position and none was written late. ok is false
+// otherwise, and the audit files must be read instead.
+func (c *auditCache) ordered() (entries []cacheEntry, ok bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entries = make([]cacheEntry, c.count)
+	for i := range entries {
+		e := c.entries[(c.head-c.count+i+c.size)%c.size]
+		if e.pos.line == 0 || e.late {
+			return nil, false
+		}
+		entries[i] = e
+	}
+	return entries, true
+}
+
 // Len returns the number of entries currently in the cache.
 func (c *auditCache) Len() int {
 	c.mu.RLock()
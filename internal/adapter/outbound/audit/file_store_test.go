@@ -1208,6 +1208,56 @@ func TestFileAuditStore_PopulateCacheHandlesMalformedLines(t *testing.T) {
 	}
 }
 
+func TestFileAuditStore_ReadsEarlierSchemaVersions(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	now := time.Now().UTC().Truncate(time.Second)
+	filename := filepath.Join(dir, fmt.Sprintf("audit-%s.log", now.Format("2006-01-02")))
+	ts := now.Format(time.RFC3339)
+	lines := []string{
+		// Written before schema versioning (versions 1 and 2).
+		fmt.Sprintf(`{"timestamp":%q,"session_id":"s","identity_id":"u","tool_name":"t","decision":"allow","request_id":"v1"}`, ts),
+		fmt.Sprintf(`{"timestamp":%q,"session_id":"s","identity_id":"u","tool_name":"t","decision":"deny","request_id":"v2","source_country":"IT"}`, ts),
+	}
+	if err := os.WriteFile(filename, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewFileAuditStore(AuditFileConfig{Dir: dir, RetentionDays: 7, MaxFileSizeMB: 100, CacheSize: 100}, testLogger())
+	if err != nil {
+		t.Fatalf("NewFileAuditStore() error: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	if err := store.Append(context.Background(), makeRecord(now, "v3")); err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+
+	// Boot cache and query path both roll the old records forward.
+	for _, rec := range store.GetRecent(10) {
+		if rec.SchemaVersion != audit.CurrentSchemaVersion {
+			t.Errorf("cached %s: schema_version = %d, want %d", rec.RequestID, rec.SchemaVersion, audit.CurrentSchemaVersion)
+		}
+	}
+	records, _, err := store.Query(context.Background(), audit.AuditFilter{Limit: 10})
+	if err != nil {
+		t.Fatalf("Query() error: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("Query() returned %d records, want 3", len(records))
+	}
+	for _, rec := range records {
+		if rec.SchemaVersion != audit.CurrentSchemaVersion {
+			t.Errorf("queried %s: schema_version = %d, want %d", rec.RequestID, rec.SchemaVersion, audit.CurrentSchemaVersion)
+		}
+	}
+
+	data, _ := os.ReadFile(filename)
+	if !strings.Contains(string(data), fmt.Sprintf(`"schema_version":%d`, audit.CurrentSchemaVersion)) {
+		t.Error("appended record was written without schema_version")
+	}
+}
+
 func TestFileAuditStore_AllFieldsSerialized(t *testing.T) {
 	t.Parallel()
 
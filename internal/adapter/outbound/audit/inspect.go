@@ -0,0 +1,392 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+	ev "github.com/Sentinel-Gate/Sentinelgate/internal/domain/evidence"
+)
+
+// Export formats read and written by the audit inspection.
+const (
+	ExportFormatJSONL   = "jsonl"
+	ExportFormatCSV     = "csv"
+	ExportFormatParquet = "parquet"
+)
+
+// exportCSVHeader is the column layout of the admin CSV export, also used
+// when converting to CSV.
+var exportCSVHeader = []string{
+	"timestamp", "session_id", "identity_id", "identity_name", "tool_name",
+	"decision", "reason", "rule_id", "request_id", "latency_micros",
+	"protocol", "framework", "request_args",
+}
+
+// Inspection is the result of checking an exported audit file: audit
+// JSON lines, an evidence file or an admin CSV export.
+type Inspection struct {
+	// Format is "audit" (JSON lines), "evidence" (signed JSON lines) or "csv".
+	Format string
+	// Lines is the number of non-empty lines (data rows for CSV).
+	Lines int
+	// Records is the number of valid records.
+	Records int
+	// Invalid counts the lines that are not valid records; FirstInvalid
+	// describes the first of them.
+	Invalid      int
+	FirstInvalid string
+	// Order is "ascending" or "descending" after the first two distinct
+	// timestamps. OutOfOrder counts the records breaking that order and
+	// FirstOutOfOrder is the line of the first of them.
+	Order           string
+	OutOfOrder      int
+	FirstOutOfOrder int
+	// First and Last are the earliest and latest record timestamps.
+	First, Last time.Time
+	// ByDecision, ByTool and ByIdentity count the records per value.
+	ByDecision map[string]int
+	ByTool     map[string]int
+	ByIdentity map[string]int
+}
+
+// Valid reports whether every line is a record and the timestamps are
+// ordered.
+func (in *Inspection) Valid() bool {
+	return in.Records > 0 && in.Invalid == 0 && in.OutOfOrder == 0
+}
+
+// OpenExport opens an exported audit file for reading, decompressing it
+// when its name ends in .zst.
+func OpenExport(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, compressedExt) {
+		return f, nil
+	}
+	dec, err := zstd.NewReader(f, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &zstdFileReader{dec: dec, f: f}, nil
+}
+
+// InspectExport reads an exported audit file and returns its inspection and
+// its records, in file order. CSV input is read by column name, so exports
+// with a subset of the columns are accepted.
+func InspectExport(r io.Reader, csvInput bool) (*Inspection, []audit.AuditRecord, error) {
+	in := &Inspection{
+		ByDecision: make(map[string]int),
+		ByTool:     make(map[string]int),
+		ByIdentity: make(map[string]int),
+	}
+	var records []audit.AuditRecord
+	var prev time.Time
+	add := func(line int, rec audit.AuditRecord) {
+		in.Records++
+		records = append(records, rec)
+		in.ByDecision[rec.Decision]++
+		in.ByTool[rec.ToolName]++
+		identity := rec.IdentityName
+		if identity == "" {
+			identity = rec.IdentityID
+		}
+		in.ByIdentity[identity]++
+
+		ts := rec.Timestamp
+		if in.First.IsZero() || ts.Before(in.First) {
+			in.First = ts
+		}
+		if ts.After(in.Last) {
+			in.Last = ts
+		}
+		if !prev.IsZero() && !ts.Equal(prev) {
+			switch {
+			case in.Order == "" && ts.After(prev):
+				in.Order = "ascending"
+			case in.Order == "":
+				in.Order = "descending"
+			case (in.Order == "ascending") != ts.After(prev):
+				in.OutOfOrder++
+				if in.FirstOutOfOrder == 0 {
+					in.FirstOutOfOrder = line
+				}
+			}
+		}
+		prev = ts
+	}
+	invalid := func(line int, format string, args ...interface{}) {
+		in.Invalid++
+		if in.FirstInvalid == "" {
+			in.FirstInvalid = fmt.Sprintf("line %d: ", line) + fmt.Sprintf(format, args...)
+		}
+	}
+
+	if csvInput {
+		in.Format = "csv"
+		return in, records, inspectCSV(r, in, add, invalid)
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		in.Lines++
+
+		var probe struct {
+			ChainHash *string         `json:"chain_hash"`
+			Timestamp json.RawMessage `json:"timestamp"`
+		}
+		if err := json.Unmarshal(raw, &probe); err != nil {
+			invalid(line, "invalid JSON: %v", err)
+			continue
+		}
+		if len(probe.Timestamp) == 0 {
+			invalid(line, "missing timestamp")
+			continue
+		}
+		format := "audit"
+		if probe.ChainHash != nil {
+			format = "evidence"
+		}
+		if in.Format == "" {
+			in.Format = format
+		} else if in.Format != format {
+			invalid(line, "%s record in a file of %s records", format, in.Format)
+			continue
+		}
+
+		var rec audit.AuditRecord
+		if format == "evidence" {
+			var er ev.Record
+			if err := json.Unmarshal(raw, &er); err != nil {
+				invalid(line, "invalid evidence record: %v", err)
+				continue
+			}
+			rec = auditFromEvidence(er)
+		} else {
+			if err := json.Unmarshal(raw, &rec); err != nil {
+				invalid(line, "invalid audit record: %v", err)
+				continue
+			}
+		}
+		add(line, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return in, records, fmt.Errorf("read line %d: %w", line+1, err)
+	}
+	if in.Format == "" {
+		in.Format = "audit"
+	}
+	return in, records, nil
+}
+
+func inspectCSV(r io.Reader, in *Inspection, add func(int, audit.AuditRecord), invalid func(int, string, ...interface{})) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read CSV header: %w", err)
+	}
+	col := make(map[string]int, len(header))
+	for i, name := range header {
+		col[strings.TrimSpace(name)] = i
+	}
+	if _, ok := col["timestamp"]; !ok {
+		return fmt.Errorf("CSV header has no timestamp column")
+	}
+
+	line := 1
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		line++
+		if err != nil {
+			return fmt.Errorf("read CSV line %d: %w", line, err)
+		}
+		in.Lines++
+		get := func(name string) string {
+			if i, ok := col[name]; ok && i < len(row) {
+				return csvUnsafe(row[i])
+			}
+			return ""
+		}
+		ts, err := time.Parse(time.RFC3339Nano, get("timestamp"))
+		if err != nil {
+			invalid(line, "invalid timestamp %q", get("timestamp"))
+			continue
+		}
+		rec := audit.AuditRecord{
+			Timestamp:    ts,
+			SessionID:    get("session_id"),
+			IdentityID:   get("identity_id"),
+			IdentityName: get("identity_name"),
+			ToolName:     get("tool_name"),
+			Decision:     get("decision"),
+			Reason:       get("reason"),
+			RuleID:       get("rule_id"),
+			RequestID:    get("request_id"),
+			Protocol:     get("protocol"),
+			Framework:    get("framework"),
+		}
+		if v := get("latency_micros"); v != "" {
+			if rec.LatencyMicros, err = strconv.ParseInt(v, 10, 64); err != nil {
+				invalid(line, "invalid latency_micros %q", v)
+				continue
+			}
+		}
+		if v := get("request_args"); v != "" {
+			if err := json.Unmarshal([]byte(v), &rec.ToolArguments); err != nil {
+				invalid(line, "invalid request_args: %v", err)
+				continue
+			}
+		}
+		add(line, rec)
+	}
+}
+
+// csvSafe prefixes values that spreadsheets would read as formulas, like
+// the admin CSV export.
+func csvSafe(s string) string {
+	if len(s) > 0 && strings.ContainsAny(s[:1], "=+\t-@") {
+		return "'" + s
+	}
+	return s
+}
+
+// csvUnsafe removes the quote added by csvSafe.
+func csvUnsafe(s string) string {
+	if len(s) > 1 && s[0] == '\'' && strings.ContainsAny(s[1:2], "=+\t-@") {
+		return s[1:]
+	}
+	return s
+}
+
+// auditFromEvidence maps an evidence record back to the audit fields it
+// carries. The evidence user ID is the identity name.
+func auditFromEvidence(r ev.Record) audit.AuditRecord {
+	return audit.AuditRecord{
+		Timestamp:     r.Timestamp,
+		IdentityName:  r.Identity.UserID,
+		Roles:         r.Identity.Roles,
+		Protocol:      r.Identity.Protocol,
+		ToolName:      r.Action.Tool,
+		ToolArguments: r.Action.Arguments,
+		Decision:      r.Action.Decision,
+		RuleID:        r.Action.PolicyMatched,
+		LatencyMicros: r.Result.LatencyMicros,
+		Reason:        r.Result.Reason,
+	}
+}
+
+// WriteExport writes records to w in format: JSON lines, CSV with the
+// admin export columns, or Parquet.
+func WriteExport(w io.Writer, format string, records []audit.AuditRecord) error {
+	switch format {
+	case ExportFormatJSONL:
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		for _, rec := range records {
+			if err := enc.Encode(rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	case ExportFormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(exportCSVHeader); err != nil {
+			return err
+		}
+		for _, rec := range records {
+			if err := cw.Write(exportCSVRow(rec)); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case ExportFormatParquet:
+		return writeParquet(w, records)
+	}
+	return fmt.Errorf("unknown export format %q (want jsonl, csv or parquet)", format)
+}
+
+// exportCSVRow returns the exportCSVHeader columns of rec.
+func exportCSVRow(rec audit.AuditRecord) []string {
+	return []string{
+		rec.Timestamp.UTC().Format(time.RFC3339Nano),
+		csvSafe(rec.SessionID),
+		csvSafe(rec.IdentityID),
+		csvSafe(rec.IdentityName),
+		csvSafe(rec.ToolName),
+		rec.Decision,
+		csvSafe(rec.Reason),
+		csvSafe(rec.RuleID),
+		csvSafe(rec.RequestID),
+		strconv.FormatInt(rec.LatencyMicros, 10),
+		rec.Protocol,
+		rec.Framework,
+		csvSafe(exportArgs(rec)),
+	}
+}
+
+// KeyCount is one entry of a TopCounts result.
+type KeyCount struct {
+	Key   string
+	Count int
+}
+
+// exportArgs returns the tool arguments of rec as JSON, with encrypted
+// values masked, or "" when there are none.
+func exportArgs(rec audit.AuditRecord) string {
+	if len(rec.ToolArguments) == 0 {
+		return ""
+	}
+	b, err := json.Marshal(audit.MaskEncryptedArgs(rec.ToolArguments))
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// TopCounts returns the n largest counts of m, largest first and by key on
+// ties. n <= 0 returns all of them.
+func TopCounts(m map[string]int, n int) []KeyCount {
+	out := make([]KeyCount, 0, len(m))
+	for k, c := range m {
+		out = append(out, KeyCount{Key: k, Count: c})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Key < out[j].Key
+	})
+	if n > 0 && len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
@@ -0,0 +1,206 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+)
+
+func TestInspectExport_JSONL(t *testing.T) {
+	t.Parallel()
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for i, rec := range []audit.AuditRecord{
+		{Timestamp: base, IdentityName: "alice", ToolName: "read_file", Decision: audit.DecisionAllow},
+		{Timestamp: base.Add(time.Second), IdentityName: "alice", ToolName: "write_file", Decision: audit.DecisionDeny},
+		{Timestamp: base.Add(2 * time.Second), IdentityName: "bob", ToolName: "read_file", Decision: audit.DecisionAllow},
+	} {
+		_ = enc.Encode(rec)
+		if i == 1 {
+			buf.WriteString("\n{not json\n")
+		}
+	}
+	// Out of order after an ascending start.
+	_ = enc.Encode(audit.AuditRecord{Timestamp: base.Add(-time.Hour), IdentityName: "bob", ToolName: "read_file", Decision: audit.DecisionAllow})
+
+	in, records, err := InspectExport(&buf, false)
+	if err != nil {
+		t.Fatalf("InspectExport() error: %v", err)
+	}
+	if in.Format != "audit" || in.Lines != 5 || in.Records != 4 || len(records) != 4 {
+		t.Errorf("format/lines/records = %s/%d/%d", in.Format, in.Lines, in.Records)
+	}
+	if in.Invalid != 1 || !strings.HasPrefix(in.FirstInvalid, "line 4: invalid JSON") {
+		t.Errorf("Invalid = %d, FirstInvalid = %q", in.Invalid, in.FirstInvalid)
+	}
+	if in.Order != "ascending" || in.OutOfOrder != 1 || in.FirstOutOfOrder != 6 {
+		t.Errorf("order = %s, out of order = %d at line %d", in.Order, in.OutOfOrder, in.FirstOutOfOrder)
+	}
+	if !in.First.Equal(base.Add(-time.Hour)) || !in.Last.Equal(base.Add(2*time.Second)) {
+		t.Errorf("range = %v - %v", in.First, in.Last)
+	}
+	if in.ByDecision[audit.DecisionAllow] != 3 || in.ByIdentity["bob"] != 2 {
+		t.Errorf("ByDecision = %v, ByIdentity = %v", in.ByDecision, in.ByIdentity)
+	}
+	if top := TopCounts(in.ByTool, 1); len(top) != 1 || top[0] != (KeyCount{"read_file", 3}) {
+		t.Errorf("TopCounts() = %v", top)
+	}
+	if in.Valid() {
+		t.Error("Valid() = true, want false")
+	}
+}
+
+func TestInspectExport_CSVRoundTrip(t *testing.T) {
+	t.Parallel()
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	records := []audit.AuditRecord{
+		{Timestamp: base.Add(time.Second), IdentityID: "id-1", IdentityName: "alice", ToolName: "run", Decision: audit.DecisionDeny,
+			Reason: "-rm blocked", LatencyMicros: 42, ToolArguments: map[string]interface{}{"cmd": "=SUM(A1)"}},
+		{Timestamp: base, IdentityID: "id-1", IdentityName: "alice", ToolName: "read_file", Decision: audit.DecisionAllow},
+	}
+	var buf bytes.Buffer
+	if err := WriteExport(&buf, ExportFormatCSV, records); err != nil {
+		t.Fatalf("WriteExport() error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "'-rm blocked") {
+		t.Errorf("CSV output is not formula-safe:\n%s", buf.String())
+	}
+
+	in, got, err := InspectExport(&buf, true)
+	if err != nil {
+		t.Fatalf("InspectExport() error: %v", err)
+	}
+	if !in.Valid() || in.Order != "descending" || len(got) != 2 {
+		t.Fatalf("inspection = %+v", in)
+	}
+	if got[0].Reason != "-rm blocked" || got[0].LatencyMicros != 42 || got[0].ToolArguments["cmd"] != "=SUM(A1)" ||
+		!got[0].Timestamp.Equal(records[0].Timestamp) {
+		t.Errorf("record after round trip = %+v", got[0])
+	}
+}
+
+func TestWriteExport_Parquet(t *testing.T) {
+	t.Parallel()
+	records := []audit.AuditRecord{
+		{Timestamp: time.Now(), IdentityName: "alice", ToolName: "read_file", Decision: audit.DecisionAllow},
+		{Timestamp: time.Now(), IdentityName: "bob", ToolName: "write_file", Decision: audit.DecisionDeny},
+	}
+	var buf bytes.Buffer
+	if err := WriteExport(&buf, ExportFormatParquet, records); err != nil {
+		t.Fatalf("WriteExport() error: %v", err)
+	}
+	b := buf.Bytes()
+	if !bytes.HasPrefix(b, []byte(parquetMagic)) || !bytes.HasSuffix(b, []byte(parquetMagic)) {
+		t.Fatal("missing PAR1 magic")
+	}
+	n := int(binary.LittleEndian.Uint32(b[len(b)-8:]))
+	footer := b[len(b)-8-n : len(b)-8]
+
+	// FileMetaData: version (1), schema (2), num_rows (3), row_groups (4).
+	d := &compactDecoder{b: footer}
+	fields := d.readStruct()
+	if d.err != "" {
+		t.Fatalf("decode footer: %s", d.err)
+	}
+	if fields[3] != int64(2) {
+		t.Errorf("num_rows = %v, want 2", fields[3])
+	}
+	if schema, _ := fields[2].([]interface{}); len(schema) != len(parquetColumns)+1 {
+		t.Errorf("schema elements = %d, want %d", len(schema), len(parquetColumns)+1)
+	}
+	groups, _ := fields[4].([]interface{})
+	if len(groups) != 1 {
+		t.Fatalf("row groups = %d, want 1", len(groups))
+	}
+	columns, _ := groups[0].(map[int16]interface{})[1].([]interface{})
+	if len(columns) != len(parquetColumns) {
+		t.Fatalf("column chunks = %d, want %d", len(columns), len(parquetColumns))
+	}
+	// The first column's page header is right after the leading magic.
+	meta := columns[0].(map[int16]interface{})[3].(map[int16]interface{})
+	if meta[9] != int64(len(parquetMagic)) || meta[5] != int64(2) {
+		t.Errorf("timestamp column metadata = %v", meta)
+	}
+}
+
+// compactDecoder reads the Thrift compact structs written by thriftCompact.
+type compactDecoder struct {
+	b   []byte
+	err string
+}
+
+func (d *compactDecoder) uvarint() uint64 {
+	v, n := binary.Uvarint(d.b)
+	if n <= 0 {
+		d.err = "bad varint"
+		d.b = nil
+		return 0
+	}
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *compactDecoder) int() int64 {
+	v := d.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (d *compactDecoder) value(typ byte) interface{} {
+	switch typ {
+	case thriftI32, thriftI64:
+		return d.int()
+	case thriftBinary:
+		n := int(d.uvarint())
+		if n > len(d.b) {
+			d.err = "short binary"
+			return nil
+		}
+		s := string(d.b[:n])
+		d.b = d.b[n:]
+		return s
+	case thriftList:
+		h := d.b[0]
+		d.b = d.b[1:]
+		n := int(h >> 4)
+		if n == 15 {
+			n = int(d.uvarint())
+		}
+		items := make([]interface{}, 0, n)
+		for i := 0; i < n && d.err == ""; i++ {
+			items = append(items, d.value(h&0x0f))
+		}
+		return items
+	case thriftStruct:
+		return d.readStruct()
+	}
+	d.err = "unexpected type"
+	return nil
+}
+
+func (d *compactDecoder) readStruct() map[int16]interface{} {
+	fields := make(map[int16]interface{})
+	var last int16
+	for d.err == "" && len(d.b) > 0 {
+		h := d.b[0]
+		d.b = d.b[1:]
+		if h == 0 {
+			return fields
+		}
+		id := last + int16(h>>4)
+		if h>>4 == 0 {
+			id = int16(d.int())
+		}
+		last = id
+		fields[id] = d.value(h & 0x0f)
+	}
+	if d.err == "" {
+		d.err = "unterminated struct"
+	}
+	return fields
+}
@@ -0,0 +1,274 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+)
+
+// ErrJournalClosed is returned by Append once the journal is closed.
+var ErrJournalClosed = errors.New("audit journal closed")
+
+// journalCompactSize is the journal size from which it is emptied once every
+// record in it has been read and committed.
+const journalCompactSize = 4 << 20
+
+// Journal is a write-ahead log of audit records: a JSON Lines file every
+// record is appended and fsynced to before it is handed to the audit stores.
+// The offset of the first record not yet written to the stores is kept in a
+// checkpoint file next to it (<path>.offset), so records journaled before a
+// crash are read again after a restart.
+//
+// Append is safe for concurrent use; concurrent appends share one fsync.
+// Read and Commit must be called from a single goroutine.
+type Journal struct {
+	path   string
+	logger *slog.Logger
+
+	mu       sync.Mutex
+	file     *os.File
+	writeOff int64  // end of the last complete record
+	readOff  int64  // first record not committed
+	backlog  int    // records after readOff
+	seq      uint64 // appends written
+	closed   bool
+
+	syncMu sync.Mutex
+	synced uint64 // appends fsynced
+
+	// Set by Read, applied by Commit.
+	pendingOff   int64
+	pendingCount int
+}
+
+// OpenJournal opens or creates the journal at path. A record cut short by a
+// crash at the end of the file is discarded; the records after the
+// checkpoint are counted in Backlog and returned by the next Reads.
+func OpenJournal(path string, logger *slog.Logger) (*Journal, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("create audit journal directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("open audit journal: %w", err)
+	}
+	j := &Journal{path: path, logger: logger, file: f}
+	if err := j.recover(); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	j.pendingOff = j.readOff
+	if j.backlog > 0 {
+		logger.Info("audit journal replaying records", "path", path, "records", j.backlog)
+	}
+	return j, nil
+}
+
+// recover reads the checkpoint, truncates a torn record at the end of the
+// file and counts the records after the checkpoint.
+func (j *Journal) recover() error {
+	info, err := j.file.Stat()
+	if err != nil {
+		return fmt.Errorf("stat audit journal: %w", err)
+	}
+	size := info.Size()
+	j.readOff = j.loadCheckpoint()
+	if j.readOff > size {
+		// The journal was emptied after its records were committed, but the
+		// checkpoint was not reset before the crash.
+		j.readOff = 0
+	}
+
+	r := bufio.NewReader(io.NewSectionReader(j.file, j.readOff, size-j.readOff))
+	off := j.readOff
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read audit journal: %w", err)
+		}
+		off += int64(len(line))
+		j.backlog++
+	}
+	if off < size {
+		j.logger.Warn("audit journal: discarding a record cut short by a crash",
+			"path", j.path, "bytes", size-off)
+		if err := j.file.Truncate(off); err != nil {
+			return fmt.Errorf("truncate audit journal: %w", err)
+		}
+	}
+	j.writeOff = off
+	return nil
+}
+
+// Append writes records to the journal and fsyncs it.
+func (j *Journal) Append(records ...audit.AuditRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	for i := range records {
+		line, err := json.Marshal(&records[i])
+		if err != nil {
+			return fmt.Errorf("encode audit record: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	j.mu.Lock()
+	if j.closed {
+		j.mu.Unlock()
+		return ErrJournalClosed
+	}
+	n, err := j.file.Write(buf.Bytes())
+	if err != nil {
+		// Drop a partial write so the next record starts on its own line.
+		if n > 0 {
+			_ = j.file.Truncate(j.writeOff)
+		}
+		j.mu.Unlock()
+		return fmt.Errorf("write audit journal: %w", err)
+	}
+	j.writeOff += int64(n)
+	j.backlog += len(records)
+	j.seq++
+	seq := j.seq
+	j.mu.Unlock()
+
+	return j.sync(seq)
+}
+
+// sync fsyncs the journal unless an fsync started after append seq was
+// written already covered it.
+func (j *Journal) sync(seq uint64) error {
+	j.syncMu.Lock()
+	defer j.syncMu.Unlock()
+	if j.synced >= seq {
+		return nil
+	}
+	j.mu.Lock()
+	target := j.seq
+	j.mu.Unlock()
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("sync audit journal: %w", err)
+	}
+	j.synced = target
+	return nil
+}
+
+// Backlog returns the number of records not committed yet.
+func (j *Journal) Backlog() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.backlog
+}
+
+// Read returns up to max records after the last committed one. Calling Read
+// again without Commit returns the same records. A record that cannot be
+// decoded is logged and skipped.
+func (j *Journal) Read(max int) ([]audit.AuditRecord, error) {
+	j.mu.Lock()
+	start, end := j.readOff, j.writeOff
+	j.mu.Unlock()
+
+	var records []audit.AuditRecord
+	r := bufio.NewReader(io.NewSectionReader(j.file, start, end-start))
+	off, count := start, 0
+	for len(records) < max {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read audit journal: %w", err)
+		}
+		off += int64(len(line))
+		count++
+		var rec audit.AuditRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			j.logger.Warn("audit journal: skipping an unreadable record",
+				"path", j.path, "offset", off-int64(len(line)), "error", err)
+			continue
+		}
+		records = append(records, rec)
+	}
+	j.pendingOff, j.pendingCount = off, count
+	return records, nil
+}
+
+// Commit marks the records returned by the last Read as written to the audit
+// stores. The journal is emptied when it is large and every record in it is
+// committed. The checkpoint is not fsynced: after a crash the last records
+// may be written to the stores a second time, never lost.
+func (j *Journal) Commit() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.pendingOff == j.readOff {
+		return nil
+	}
+	j.readOff = j.pendingOff
+	j.backlog -= j.pendingCount
+	j.pendingCount = 0
+	if j.readOff == j.writeOff && j.writeOff >= journalCompactSize && !j.closed {
+		if err := j.file.Truncate(0); err != nil {
+			return fmt.Errorf("truncate audit journal: %w", err)
+		}
+		j.readOff, j.writeOff, j.pendingOff = 0, 0, 0
+	}
+	return j.saveCheckpoint(j.readOff)
+}
+
+// Close closes the journal. Records not committed are kept for the next
+// OpenJournal.
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.closed {
+		return nil
+	}
+	j.closed = true
+	return j.file.Close()
+}
+
+func (j *Journal) checkpointPath() string {
+	return j.path + ".offset"
+}
+
+// loadCheckpoint returns the committed offset, 0 when there is none.
+func (j *Journal) loadCheckpoint() int64 {
+	data, err := os.ReadFile(j.checkpointPath())
+	if err != nil {
+		return 0
+	}
+	off, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil || off < 0 {
+		j.logger.Warn("audit journal: ignoring an invalid checkpoint", "path", j.checkpointPath())
+		return 0
+	}
+	return off
+}
+
+func (j *Journal) saveCheckpoint(off int64) error {
+	tmp := j.checkpointPath() + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.FormatInt(off, 10)+"\n"), 0600); err != nil {
+		return fmt.Errorf("write audit journal checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, j.checkpointPath()); err != nil {
+		return fmt.Errorf("write audit journal checkpoint: %w", err)
+	}
+	return nil
+}
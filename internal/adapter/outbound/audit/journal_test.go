@@ -0,0 +1,163 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestJournal_ReadCommitReopen(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "wal", "audit.wal")
+	j, err := OpenJournal(path, testLogger())
+	if err != nil {
+		t.Fatalf("OpenJournal() error: %v", err)
+	}
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	for i := 0; i < 5; i++ {
+		if err := j.Append(makeRecord(now, fmt.Sprintf("req-%d", i))); err != nil {
+			t.Fatalf("Append() error: %v", err)
+		}
+	}
+	if got := j.Backlog(); got != 5 {
+		t.Fatalf("Backlog() = %d, want 5", got)
+	}
+
+	records, err := j.Read(3)
+	if err != nil || len(records) != 3 || records[0].RequestID != "req-0" || !records[0].Timestamp.Equal(now) {
+		t.Fatalf("Read(3) = %+v, %v", records, err)
+	}
+	// Without Commit, Read returns the same records.
+	if again, _ := j.Read(3); len(again) != 3 || again[0].RequestID != "req-0" {
+		t.Fatalf("second Read(3) = %+v", again)
+	}
+	if err := j.Commit(); err != nil {
+		t.Fatalf("Commit() error: %v", err)
+	}
+	if got := j.Backlog(); got != 2 {
+		t.Errorf("Backlog() after Commit = %d, want 2", got)
+	}
+	if err := j.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+	if err := j.Append(makeRecord(now, "late")); err != ErrJournalClosed {
+		t.Errorf("Append() after Close error = %v, want ErrJournalClosed", err)
+	}
+
+	// The uncommitted records are read again after a restart.
+	j, err = OpenJournal(path, testLogger())
+	if err != nil {
+		t.Fatalf("OpenJournal() error: %v", err)
+	}
+	defer func() { _ = j.Close() }()
+	if got := j.Backlog(); got != 2 {
+		t.Fatalf("Backlog() after reopen = %d, want 2", got)
+	}
+	records, err = j.Read(10)
+	if err != nil || len(records) != 2 || records[0].RequestID != "req-3" || records[1].RequestID != "req-4" {
+		t.Fatalf("Read(10) after reopen = %+v, %v", records, err)
+	}
+}
+
+func TestJournal_TornRecord(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "audit.wal")
+	j, err := OpenJournal(path, testLogger())
+	if err != nil {
+		t.Fatalf("OpenJournal() error: %v", err)
+	}
+	if err := j.Append(makeRecord(time.Now(), "req-1"), makeRecord(time.Now(), "req-2")); err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+	_ = j.Close()
+
+	// A crash in the middle of a write leaves a partial line.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(`{"timestamp":"2026-`)
+	_ = f.Close()
+
+	j, err = OpenJournal(path, testLogger())
+	if err != nil {
+		t.Fatalf("OpenJournal() error: %v", err)
+	}
+	defer func() { _ = j.Close() }()
+	if got := j.Backlog(); got != 2 {
+		t.Errorf("Backlog() = %d, want 2", got)
+	}
+	if err := j.Append(makeRecord(time.Now(), "req-3")); err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+	records, err := j.Read(10)
+	if err != nil || len(records) != 3 || records[2].RequestID != "req-3" {
+		t.Fatalf("Read() = %+v, %v", records, err)
+	}
+}
+
+func TestJournal_Compaction(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "audit.wal")
+	j, err := OpenJournal(path, testLogger())
+	if err != nil {
+		t.Fatalf("OpenJournal() error: %v", err)
+	}
+	defer func() { _ = j.Close() }()
+
+	// Concurrent appends until the journal is over the compaction size.
+	rec := makeRecord(time.Now(), "req")
+	rec.ToolArguments = map[string]interface{}{"blob": string(make([]byte, 4096))}
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 150; i++ {
+				if err := j.Append(rec); err != nil {
+					t.Errorf("Append() error: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	total := 0
+	for {
+		records, err := j.Read(100)
+		if err != nil {
+			t.Fatalf("Read() error: %v", err)
+		}
+		if err := j.Commit(); err != nil {
+			t.Fatalf("Commit() error: %v", err)
+		}
+		total += len(records)
+		if len(records) == 0 {
+			break
+		}
+	}
+	if total != 1200 {
+		t.Errorf("read %d records, want 1200", total)
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Size() != 0 {
+		t.Errorf("journal size after compaction = %v, %v; want 0", info.Size(), err)
+	}
+
+	// Appends after compaction are read, also after a reopen.
+	if err := j.Append(makeRecord(time.Now(), "after")); err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+	j2, err := OpenJournal(path, testLogger())
+	if err != nil {
+		t.Fatalf("OpenJournal() error: %v", err)
+	}
+	defer func() { _ = j2.Close() }()
+	if records, _ := j2.Read(10); len(records) != 1 || records[0].RequestID != "after" {
+		t.Errorf("Read() after reopen = %+v", records)
+	}
+}
@@ -0,0 +1,245 @@
+package audit
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+)
+
+// Parquet output for audit conversion. The file has one row group with one
+// uncompressed PLAIN data page per column, which every Parquet reader
+// accepts; it is meant for loading exports into analytics tools, not for
+// large archives.
+
+const parquetMagic = "PAR1"
+
+// Parquet physical and converted types, encodings and repetition used by
+// the writer (parquet.thrift).
+const (
+	parquetTypeInt64     = 2
+	parquetTypeByteArray = 6
+
+	parquetConvertedUTF8            = 0
+	parquetConvertedTimestampMillis = 9
+
+	parquetRequired = 0
+
+	parquetEncodingPlain = 0
+	parquetEncodingRLE   = 3
+
+	parquetPageData = 0
+)
+
+// parquetColumn is one column of the export: an INT64 or a UTF8 string.
+type parquetColumn struct {
+	name      string
+	int64Of   func(audit.AuditRecord) int64
+	stringOf  func(audit.AuditRecord) string
+	converted int32
+}
+
+// parquetColumns follows the CSV export columns, with the timestamp in
+// milliseconds and the arguments as a JSON string.
+var parquetColumns = []parquetColumn{
+	{name: "timestamp", converted: parquetConvertedTimestampMillis, int64Of: func(r audit.AuditRecord) int64 { return r.Timestamp.UnixMilli() }},
+	{name: "session_id", stringOf: func(r audit.AuditRecord) string { return r.SessionID }},
+	{name: "identity_id", stringOf: func(r audit.AuditRecord) string { return r.IdentityID }},
+	{name: "identity_name", stringOf: func(r audit.AuditRecord) string { return r.IdentityName }},
+	{name: "tool_name", stringOf: func(r audit.AuditRecord) string { return r.ToolName }},
+	{name: "decision", stringOf: func(r audit.AuditRecord) string { return r.Decision }},
+	{name: "reason", stringOf: func(r audit.AuditRecord) string { return r.Reason }},
+	{name: "rule_id", stringOf: func(r audit.AuditRecord) string { return r.RuleID }},
+	{name: "request_id", stringOf: func(r audit.AuditRecord) string { return r.RequestID }},
+	{name: "latency_micros", int64Of: func(r audit.AuditRecord) int64 { return r.LatencyMicros }},
+	{name: "protocol", stringOf: func(r audit.AuditRecord) string { return r.Protocol }},
+	{name: "framework", stringOf: func(r audit.AuditRecord) string { return r.Framework }},
+	{name: "request_args", stringOf: func(r audit.AuditRecord) string { return exportArgs(r) }},
+}
+
+// writeParquet writes records as a Parquet file.
+func writeParquet(w io.Writer, records []audit.AuditRecord) error {
+	out := []byte(parquetMagic)
+
+	type chunk struct {
+		offset, size int64
+	}
+	chunks := make([]chunk, len(parquetColumns))
+	for i, col := range parquetColumns {
+		var data []byte
+		for _, rec := range records {
+			if col.int64Of != nil {
+				data = binary.LittleEndian.AppendUint64(data, uint64(col.int64Of(rec)))
+				continue
+			}
+			v := col.stringOf(rec)
+			data = binary.LittleEndian.AppendUint32(data, uint32(len(v)))
+			data = append(data, v...)
+		}
+
+		var h thriftCompact
+		h.i32(1, parquetPageData)
+		h.i32(2, int32(len(data)))
+		h.i32(3, int32(len(data)))
+		h.beginStruct(5)
+		h.i32(1, int32(len(records)))
+		h.i32(2, parquetEncodingPlain)
+		h.i32(3, parquetEncodingRLE)
+		h.i32(4, parquetEncodingRLE)
+		h.endStruct()
+		h.stop()
+
+		chunks[i] = chunk{offset: int64(len(out)), size: int64(len(h.b) + len(data))}
+		out = append(out, h.b...)
+		out = append(out, data...)
+	}
+
+	var m thriftCompact
+	m.i32(1, 1)
+	m.beginList(2, thriftStruct, len(parquetColumns)+1)
+	m.beginElem()
+	m.binary(4, "audit_record")
+	m.i32(5, int32(len(parquetColumns)))
+	m.endElem()
+	for _, col := range parquetColumns {
+		m.beginElem()
+		if col.int64Of != nil {
+			m.i32(1, parquetTypeInt64)
+		} else {
+			m.i32(1, parquetTypeByteArray)
+		}
+		m.i32(3, parquetRequired)
+		m.binary(4, col.name)
+		if col.int64Of == nil || col.converted != 0 {
+			m.i32(6, col.converted)
+		}
+		m.endElem()
+	}
+	m.i64(3, int64(len(records)))
+
+	var total int64
+	for _, c := range chunks {
+		total += c.size
+	}
+	m.beginList(4, thriftStruct, 1)
+	m.beginElem()
+	m.beginList(1, thriftStruct, len(parquetColumns))
+	for i, col := range parquetColumns {
+		m.beginElem()
+		m.i64(2, chunks[i].offset)
+		m.beginStruct(3)
+		if col.int64Of != nil {
+			m.i32(1, parquetTypeInt64)
+		} else {
+			m.i32(1, parquetTypeByteArray)
+		}
+		m.beginList(2, thriftI32, 2)
+		m.listI32(parquetEncodingPlain)
+		m.listI32(parquetEncodingRLE)
+		m.beginList(3, thriftBinary, 1)
+		m.listBinary(col.name)
+		m.i32(4, 0) // UNCOMPRESSED
+		m.i64(5, int64(len(records)))
+		m.i64(6, chunks[i].size)
+		m.i64(7, chunks[i].size)
+		m.i64(9, chunks[i].offset)
+		m.endStruct()
+		m.endElem()
+	}
+	m.i64(2, total)
+	m.i64(3, int64(len(records)))
+	m.endElem()
+	m.binary(6, "sentinel-gate audit inspect")
+	m.stop()
+
+	out = append(out, m.b...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(m.b)))
+	out = append(out, parquetMagic...)
+	_, err := w.Write(out)
+	return err
+}
+
+// Thrift compact protocol field types.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftCompact encodes the Thrift compact protocol structs of the Parquet
+// page headers and footer. Fields must be written in increasing ID order.
+type thriftCompact struct {
+	b     []byte
+	last  int16
+	stack []int16
+}
+
+func (t *thriftCompact) field(id int16, typ byte) {
+	if delta := id - t.last; delta > 0 && delta <= 15 {
+		t.b = append(t.b, byte(delta)<<4|typ)
+	} else {
+		t.b = append(t.b, typ)
+		t.b = binary.AppendUvarint(t.b, zigzag(int64(id)))
+	}
+	t.last = id
+}
+
+func (t *thriftCompact) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.b = binary.AppendUvarint(t.b, zigzag(int64(v)))
+}
+
+func (t *thriftCompact) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.b = binary.AppendUvarint(t.b, zigzag(v))
+}
+
+func (t *thriftCompact) binary(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.listBinary(s)
+}
+
+func (t *thriftCompact) beginStruct(id int16) {
+	t.field(id, thriftStruct)
+	t.beginElem()
+}
+
+func (t *thriftCompact) endStruct() { t.endElem() }
+
+func (t *thriftCompact) beginList(id int16, elem byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.b = append(t.b, byte(n)<<4|elem)
+		return
+	}
+	t.b = append(t.b, 0xf0|elem)
+	t.b = binary.AppendUvarint(t.b, uint64(n))
+}
+
+// beginElem and endElem bracket a struct that is a list element.
+func (t *thriftCompact) beginElem() {
+	t.stack = append(t.stack, t.last)
+	t.last = 0
+}
+
+func (t *thriftCompact) endElem() {
+	t.stop()
+	t.last = t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+}
+
+func (t *thriftCompact) listI32(v int32) {
+	t.b = binary.AppendUvarint(t.b, zigzag(int64(v)))
+}
+
+func (t *thriftCompact) listBinary(s string) {
+	t.b = binary.AppendUvarint(t.b, uint64(len(s)))
+	t.b = append(t.b, s...)
+}
+
+// stop ends the current struct.
+func (t *thriftCompact) stop() { t.b = append(t.b, 0) }
+
+func zigzag(v int64) uint64 { return uint64((v << 1) ^ (v >> 63)) }
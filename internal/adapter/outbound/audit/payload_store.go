@@ -0,0 +1,253 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+)
+
+// payloadFilePattern matches payload sample filenames: payloads-YYYY-MM-DD.jsonl.
+var payloadFilePattern = regexp.MustCompile(`^payloads-(\d{4}-\d{2}-\d{2})\.jsonl$`)
+
+// defaultPayloadRetentionDays is how long payload samples are kept when no
+// retention is configured.
+const defaultPayloadRetentionDays = 30
+
+// PayloadFileConfig holds configuration for the payload sample store.
+type PayloadFileConfig struct {
+	// Dir is the directory where payload files are stored.
+	Dir string
+	// RetentionDays is the number of days to keep payload files (default 30).
+	RetentionDays int
+}
+
+// PayloadFileStore implements audit.PayloadStore with one JSON Lines file
+// per day and its own retention, separate from the audit files.
+type PayloadFileStore struct {
+	dir           string
+	retentionDays int
+	logger        *slog.Logger
+
+	mu          sync.Mutex
+	currentFile *os.File
+	currentDate string
+
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+	closeErr  error
+}
+
+var _ audit.PayloadStore = (*PayloadFileStore)(nil)
+
+// NewPayloadFileStore creates the payload store, deletes files past the
+// retention period and starts the hourly cleanup.
+func NewPayloadFileStore(cfg PayloadFileConfig, logger *slog.Logger) (*PayloadFileStore, error) {
+	if cfg.RetentionDays <= 0 {
+		cfg.RetentionDays = defaultPayloadRetentionDays
+	}
+	if err := os.MkdirAll(cfg.Dir, 0700); err != nil {
+		return nil, fmt.Errorf("create payload directory: %w", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &PayloadFileStore{
+		dir:           cfg.Dir,
+		retentionDays: cfg.RetentionDays,
+		logger:        logger,
+		cancel:        cancel,
+	}
+	s.runCleanup()
+	s.wg.Add(1)
+	go s.cleanupLoop(ctx)
+	return s, nil
+}
+
+// Append writes one sample to the file of the current day.
+func (s *PayloadFileStore) Append(_ context.Context, sample audit.PayloadSample) error {
+	line, err := json.Marshal(sample)
+	if err != nil {
+		return fmt.Errorf("marshal payload sample: %w", err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	date := time.Now().UTC().Format("2006-01-02")
+	if s.currentFile == nil || s.currentDate != date {
+		if s.currentFile != nil {
+			_ = s.currentFile.Close()
+			s.currentFile = nil
+		}
+		f, err := os.OpenFile(filepath.Join(s.dir, "payloads-"+date+".jsonl"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return fmt.Errorf("open payload file: %w", err)
+		}
+		s.currentFile, s.currentDate = f, date
+	}
+	if _, err := s.currentFile.Write(line); err != nil {
+		return fmt.Errorf("write payload sample: %w", err)
+	}
+	return nil
+}
+
+// Query returns the samples matching filter, newest first.
+func (s *PayloadFileStore) Query(ctx context.Context, filter audit.PayloadFilter) ([]audit.PayloadSample, error) {
+	dates, err := s.listDates()
+	if err != nil {
+		return nil, err
+	}
+	var result []audit.PayloadSample
+	for i := len(dates) - 1; i >= 0; i-- {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		day, _ := time.Parse("2006-01-02", dates[i])
+		if !filter.StartTime.IsZero() && day.Add(24*time.Hour).Before(filter.StartTime) {
+			break
+		}
+		if !filter.EndTime.IsZero() && day.After(filter.EndTime) {
+			continue
+		}
+		samples, err := s.readFile(dates[i], filter)
+		if err != nil {
+			return nil, err
+		}
+		for j := len(samples) - 1; j >= 0; j-- {
+			result = append(result, samples[j])
+			if filter.Limit > 0 && len(result) >= filter.Limit {
+				return result, nil
+			}
+		}
+	}
+	return result, nil
+}
+
+// readFile returns the samples of one day matching filter, oldest first.
+func (s *PayloadFileStore) readFile(date string, filter audit.PayloadFilter) ([]audit.PayloadSample, error) {
+	f, err := os.Open(filepath.Join(s.dir, "payloads-"+date+".jsonl"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil // deleted by retention since listed
+		}
+		return nil, fmt.Errorf("open payload file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var samples []audit.PayloadSample
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var sample audit.PayloadSample
+		if err := json.Unmarshal(scanner.Bytes(), &sample); err != nil {
+			s.logger.Warn("skipping malformed payload sample", "date", date, "error", err)
+			continue
+		}
+		if payloadMatches(sample, filter) {
+			samples = append(samples, sample)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read payload file: %w", err)
+	}
+	return samples, nil
+}
+
+// payloadMatches reports whether sample passes filter.
+func payloadMatches(sample audit.PayloadSample, filter audit.PayloadFilter) bool {
+	if filter.RequestID != "" && sample.RequestID != filter.RequestID {
+		return false
+	}
+	if filter.ToolName != "" && sample.ToolName != filter.ToolName {
+		return false
+	}
+	if !filter.StartTime.IsZero() && sample.Timestamp.Before(filter.StartTime) {
+		return false
+	}
+	if !filter.EndTime.IsZero() && sample.Timestamp.After(filter.EndTime) {
+		return false
+	}
+	return true
+}
+
+// listDates returns the dates of the payload files, oldest first.
+func (s *PayloadFileStore) listDates() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("read payload directory: %w", err)
+	}
+	var dates []string
+	for _, e := range entries {
+		if m := payloadFilePattern.FindStringSubmatch(e.Name()); m != nil {
+			dates = append(dates, m[1])
+		}
+	}
+	sort.Strings(dates)
+	return dates, nil
+}
+
+// runCleanup deletes payload files older than the retention period and
+// returns how many were deleted.
+func (s *PayloadFileStore) runCleanup() int {
+	dates, err := s.listDates()
+	if err != nil {
+		s.logger.Error("payload cleanup failed", "error", err)
+		return 0
+	}
+	cutoff := time.Now().UTC().AddDate(0, 0, -s.retentionDays)
+	deleted := 0
+	for _, date := range dates {
+		day, err := time.Parse("2006-01-02", date)
+		if err != nil || !day.Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, "payloads-"+date+".jsonl")); err != nil {
+			s.logger.Error("payload cleanup: failed to delete file", "date", date, "error", err)
+			continue
+		}
+		deleted++
+	}
+	if deleted > 0 {
+		s.logger.Info("payload cleanup completed", "deleted", deleted)
+	}
+	return deleted
+}
+
+// cleanupLoop runs retention cleanup every hour until Close.
+func (s *PayloadFileStore) cleanupLoop(ctx context.Context) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.runCleanup()
+		}
+	}
+}
+
+// Close stops the cleanup and closes the current file.
+func (s *PayloadFileStore) Close() error {
+	s.closeOnce.Do(func() {
+		s.cancel()
+		s.wg.Wait()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.currentFile != nil {
+			s.closeErr = s.currentFile.Close()
+			s.currentFile = nil
+		}
+	})
+	return s.closeErr
+}
@@ -0,0 +1,87 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+)
+
+func TestPayloadFileStore_AppendQuery(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewPayloadFileStore(PayloadFileConfig{Dir: dir}, slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = store.Close() }()
+
+	now := time.Now().UTC()
+	for i, id := range []string{"req-1", "req-2", "req-3"} {
+		sample := audit.PayloadSample{
+			Timestamp: now.Add(time.Duration(i) * time.Second),
+			RequestID: id,
+			ToolName:  "payments_refund",
+			Result:    json.RawMessage(`{"ok":true}`),
+		}
+		if id == "req-2" {
+			sample.ToolName = "read_file"
+		}
+		if err := store.Append(context.Background(), sample); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all, err := store.Query(context.Background(), audit.PayloadFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 || all[0].RequestID != "req-3" || all[2].RequestID != "req-1" {
+		t.Fatalf("query = %+v, want 3 samples newest first", all)
+	}
+
+	refunds, _ := store.Query(context.Background(), audit.PayloadFilter{ToolName: "payments_refund", Limit: 1})
+	if len(refunds) != 1 || refunds[0].RequestID != "req-3" {
+		t.Errorf("tool query = %+v, want req-3", refunds)
+	}
+	byID, _ := store.Query(context.Background(), audit.PayloadFilter{RequestID: "req-2"})
+	if len(byID) != 1 || string(byID[0].Result) != `{"ok":true}` {
+		t.Errorf("request ID query = %+v, want req-2 with its result", byID)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "payloads-"+now.Format("2006-01-02")+".jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("file mode = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestPayloadFileStore_Retention(t *testing.T) {
+	dir := t.TempDir()
+	old := filepath.Join(dir, "payloads-"+time.Now().UTC().AddDate(0, 0, -10).Format("2006-01-02")+".jsonl")
+	recent := filepath.Join(dir, "payloads-"+time.Now().UTC().AddDate(0, 0, -2).Format("2006-01-02")+".jsonl")
+	for _, p := range []string{old, recent} {
+		if err := os.WriteFile(p, []byte(`{"tool_name":"x"}`+"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store, err := NewPayloadFileStore(PayloadFileConfig{Dir: dir, RetentionDays: 5}, slog.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = store.Close() }()
+
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Error("file past retention was not deleted")
+	}
+	if _, err := os.Stat(recent); err != nil {
+		t.Errorf("recent file was deleted: %v", err)
+	}
+}
@@ -0,0 +1,280 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+)
+
+// maxQueryLimit caps the records returned by one Query.
+const maxQueryLimit = 1000
+
+// NewAuditFileReader returns a store that only answers Query from the audit
+// files in dir. Unlike NewFileAuditStore it opens no file for writing and
+// deletes nothing, so it is safe to use next to a running server.
+func NewAuditFileReader(dir string, logger *slog.Logger) *FileAuditStore {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &FileAuditStore{
+		dir:    dir,
+		cache:  newAuditCache(1),
+		logger: logger,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// recordPos is the position of a record in the audit files: the date and
+// suffix of its file and its line, starting at 1. Records are ordered by
+// position, which is the order they were written in except for late
+// records appended to the file of an earlier date.
+type recordPos struct {
+	date   string
+	suffix int
+	line   int
+}
+
+func (p recordPos) before(q recordPos) bool {
+	if p.date != q.date {
+		return p.date < q.date
+	}
+	if p.suffix != q.suffix {
+		return p.suffix < q.suffix
+	}
+	return p.line < q.line
+}
+
+// String returns the position as a query cursor, e.g. "2026-01-14.0.812".
+func (p recordPos) String() string {
+	return fmt.Sprintf("%s.%d.%d", p.date, p.suffix, p.line)
+}
+
+// parseRecordCursor parses a cursor returned by Query.
+func parseRecordCursor(cursor string) (recordPos, error) {
+	parts := strings.Split(cursor, ".")
+	if len(parts) == 3 {
+		suffix, err1 := strconv.Atoi(parts[1])
+		line, err2 := strconv.Atoi(parts[2])
+		if _, err := time.Parse("2006-01-02", parts[0]); err == nil &&
+			err1 == nil && err2 == nil && suffix >= 0 && line >= 1 {
+			return recordPos{date: parts[0], suffix: suffix, line: line}, nil
+		}
+	}
+	return recordPos{}, fmt.Errorf("%w: %q", audit.ErrInvalidCursor, cursor)
+}
+
+// pos returns the position of line in the file.
+func (f auditFileInfo) pos(line int) recordPos {
+	return recordPos{date: f.date, suffix: f.suffix, line: line}
+}
+
+// Query returns audit records matching filter, newest first or, with
+// filter.Ascending, oldest first. Only the audit files whose date falls in
+// the filter's time range are read, and compressed files are decompressed
+// transparently. Newest-first pages are served from the cache as far as it
+// reaches.
+//
+// The returned cursor is the position of the last record of the page in
+// the audit files; passing it back continues after that record, across
+// rotated and daily files. It is empty on the last page.
+func (s *FileAuditStore) Query(ctx context.Context, filter audit.AuditFilter) ([]audit.AuditRecord, string, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	if limit > maxQueryLimit {
+		limit = maxQueryLimit
+	}
+	var cursor *recordPos
+	if filter.Cursor != "" {
+		pos, err := parseRecordCursor(filter.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		cursor = &pos
+	}
+
+	// One record past the page tells whether another page follows.
+	var found []cacheEntry
+	var err error
+	if filter.Ascending {
+		found, err = s.queryAscending(ctx, filter, cursor, limit+1)
+	} else {
+		found, err = s.queryDescending(ctx, filter, cursor, limit+1)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	var next string
+	if len(found) > limit {
+		found = found[:limit]
+		next = found[limit-1].pos.String()
+	}
+	result := make([]audit.AuditRecord, len(found))
+	for i, e := range found {
+		result[i] = e.rec
+	}
+	return result, next, nil
+}
+
+// queryDescending returns up to want matching records before upper (all
+// records when nil), newest first. The cache answers while it holds every
+// record from its oldest position on; older records are read from the
+// files.
+func (s *FileAuditStore) queryDescending(ctx context.Context, filter audit.AuditFilter, upper *recordPos, want int) ([]cacheEntry, error) {
+	var found []cacheEntry
+	if entries, ok := s.cache.ordered(); ok && len(entries) > 0 {
+		floor := entries[0].pos
+		if upper == nil || floor.before(*upper) {
+			for i := len(entries) - 1; i >= 0 && len(found) < want; i-- {
+				e := entries[i]
+				if (upper == nil || e.pos.before(*upper)) && matchesFilter(e.rec, filter) {
+					found = append(found, e)
+				}
+			}
+			if len(found) == want {
+				return found, nil
+			}
+			upper = &floor
+		}
+	}
+
+	startDate, endDate := filterDates(filter)
+	files := s.findSortedAuditFiles()
+	for i := len(files) - 1; i >= 0 && len(found) < want; i-- {
+		file := files[i]
+		if (startDate != "" && file.date < startDate) || (endDate != "" && file.date > endDate) {
+			continue
+		}
+		// Skip files that start at or after the bound.
+		if upper != nil && !file.pos(1).before(*upper) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		// Records are stored oldest first: keep the newest matches below
+		// the bound in a ring and return them newest first.
+		need := want - len(found)
+		ring := make([]cacheEntry, need)
+		count := 0
+		s.scanQueryFile(file, func(line int, rec audit.AuditRecord) bool {
+			pos := file.pos(line)
+			if upper != nil && !pos.before(*upper) {
+				return false
+			}
+			if matchesFilter(rec, filter) {
+				ring[count%need] = cacheEntry{rec: rec, pos: pos}
+				count++
+			}
+			return true
+		})
+		for j := 1; j <= count && j <= need; j++ {
+			found = append(found, ring[(count-j)%need])
+		}
+	}
+	return found, nil
+}
+
+// queryAscending returns up to want matching records after lower (all
+// records when nil), oldest first, reading the files.
+func (s *FileAuditStore) queryAscending(ctx context.Context, filter audit.AuditFilter, lower *recordPos, want int) ([]cacheEntry, error) {
+	var found []cacheEntry
+	startDate, endDate := filterDates(filter)
+	for _, file := range s.findSortedAuditFiles() {
+		if len(found) >= want {
+			break
+		}
+		if (startDate != "" && file.date < startDate) || (endDate != "" && file.date > endDate) {
+			continue
+		}
+		// Skip files before the cursor's file.
+		if lower != nil && file.pos(0).before(recordPos{date: lower.date, suffix: lower.suffix}) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		s.scanQueryFile(file, func(line int, rec audit.AuditRecord) bool {
+			pos := file.pos(line)
+			if (lower == nil || lower.before(pos)) && matchesFilter(rec, filter) {
+				found = append(found, cacheEntry{rec: rec, pos: pos})
+			}
+			return len(found) < want
+		})
+	}
+	return found, nil
+}
+
+// scanQueryFile calls fn for each record of file until it returns false.
+// Read errors are logged: the results may be incomplete.
+func (s *FileAuditStore) scanQueryFile(file auditFileInfo, fn func(int, audit.AuditRecord) bool) {
+	r, err := s.openAuditFile(file)
+	if err != nil {
+		s.logger.Warn("audit query: failed to open file", "file", file.name, "error", err)
+		return
+	}
+	defer func() { _ = r.Close() }()
+	if err := s.scanRecordLines(r, file.name, fn); err != nil {
+		s.logger.Warn("audit query: scanner error, results may be incomplete", "file", file.name, "error", err)
+	}
+}
+
+// filterDates returns the dates of the filter's time range, empty when
+// unbounded, to select the audit files to read.
+func filterDates(filter audit.AuditFilter) (startDate, endDate string) {
+	if !filter.StartTime.IsZero() {
+		startDate = filter.StartTime.UTC().Format("2006-01-02")
+	}
+	if !filter.EndTime.IsZero() {
+		endDate = filter.EndTime.UTC().Format("2006-01-02")
+	}
+	return startDate, endDate
+}
+
+// matchesFilter reports whether rec matches every set field of filter. It
+// follows the matching rules of the in-memory audit store and additionally
+// honours SessionID.
+func matchesFilter(rec audit.AuditRecord, filter audit.AuditFilter) bool {
+	if !filter.StartTime.IsZero() && rec.Timestamp.Before(filter.StartTime) {
+		return false
+	}
+	if !filter.EndTime.IsZero() && rec.Timestamp.After(filter.EndTime) {
+		return false
+	}
+	if filter.Decision != "" && !strings.EqualFold(rec.Decision, filter.Decision) {
+		return false
+	}
+	// A bare tool name also matches the namespaced form ("read_file"
+	// matches "desktop/read_file").
+	if filter.ToolName != "" && rec.ToolName != filter.ToolName {
+		bare := rec.ToolName
+		if idx := strings.Index(rec.ToolName, "/"); idx >= 0 {
+			bare = rec.ToolName[idx+1:]
+		}
+		if bare != filter.ToolName {
+			return false
+		}
+	}
+	if filter.UserID != "" && rec.IdentityID != filter.UserID &&
+		!strings.Contains(strings.ToLower(rec.IdentityName), strings.ToLower(filter.UserID)) {
+		return false
+	}
+	if filter.SessionID != "" && rec.SessionID != filter.SessionID {
+		return false
+	}
+	if filter.Protocol != "" && !strings.EqualFold(rec.Protocol, filter.Protocol) {
+		return false
+	}
+	if !filter.MatchesLabels(rec.SessionLabels) {
+		return false
+	}
+	return filter.MatchesDetails(rec)
+}
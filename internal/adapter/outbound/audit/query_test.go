@@ -0,0 +1,111 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+)
+
+// queryAll pages through Query with limit records per page and returns the
+// request IDs in order.
+func queryAll(t *testing.T, store *FileAuditStore, filter audit.AuditFilter, limit int) []string {
+	t.Helper()
+	var ids []string
+	filter.Limit = limit
+	for pages := 0; ; pages++ {
+		if pages > 100 {
+			t.Fatal("Query() keeps returning a cursor")
+		}
+		records, next, err := store.Query(context.Background(), filter)
+		if err != nil {
+			t.Fatalf("Query(%+v) error: %v", filter, err)
+		}
+		if len(records) > limit {
+			t.Fatalf("Query() returned %d records, limit %d", len(records), limit)
+		}
+		for _, r := range records {
+			ids = append(ids, r.RequestID)
+		}
+		if next == "" {
+			return ids
+		}
+		filter.Cursor = next
+	}
+}
+
+func TestFileAuditStore_QueryPagination(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	day1 := yesterdayNoon().AddDate(0, 0, -1)
+	day2 := yesterdayNoon()
+	writeAuditFile(t, dir, day1, 5)
+	writeAuditFile(t, dir, day2, 4)
+	// A small cache serves the newest records and the files the rest.
+	store, err := NewFileAuditStore(AuditFileConfig{Dir: dir, CacheSize: 3}, testLogger())
+	if err != nil {
+		t.Fatalf("NewFileAuditStore() error: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	today := todayMidnight().Add(time.Hour)
+	if err := store.Append(context.Background(), makeRecord(today, "today-0"), makeRecord(today.Add(time.Second), "today-1")); err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+
+	var want []string
+	for i := 0; i < 5; i++ {
+		want = append(want, fmt.Sprintf("%s-req-%d", day1.Format("0102"), i))
+	}
+	for i := 0; i < 4; i++ {
+		want = append(want, fmt.Sprintf("%s-req-%d", day2.Format("0102"), i))
+	}
+	want = append(want, "today-0", "today-1")
+	reversed := slices.Clone(want)
+	slices.Reverse(reversed)
+
+	reader := NewAuditFileReader(dir, testLogger())
+	for _, limit := range []int{1, 2, 4, 100} {
+		if got := queryAll(t, store, audit.AuditFilter{}, limit); !slices.Equal(got, reversed) {
+			t.Errorf("newest first, limit %d = %v, want %v", limit, got, reversed)
+		}
+		if got := queryAll(t, store, audit.AuditFilter{Ascending: true}, limit); !slices.Equal(got, want) {
+			t.Errorf("oldest first, limit %d = %v, want %v", limit, got, want)
+		}
+		if got := queryAll(t, reader, audit.AuditFilter{}, limit); !slices.Equal(got, reversed) {
+			t.Errorf("reader newest first, limit %d = %v, want %v", limit, got, reversed)
+		}
+	}
+
+	// Filters apply across pages.
+	filter := audit.AuditFilter{StartTime: day2, EndTime: day2.Add(time.Hour)}
+	if got := queryAll(t, store, filter, 3); !slices.Equal(got, reversed[2:6]) {
+		t.Errorf("time range = %v, want %v", got, reversed[2:6])
+	}
+
+	// A late record goes to the file of its date, after the records in it.
+	late := makeRecord(day2.Add(time.Minute), "late")
+	if err := store.Append(context.Background(), late); err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+	withLate := append(slices.Clone(reversed[:2]), append([]string{"late"}, reversed[2:]...)...)
+	if got := queryAll(t, store, audit.AuditFilter{}, 2); !slices.Equal(got, withLate) {
+		t.Errorf("with late record = %v, want %v", got, withLate)
+	}
+}
+
+func TestFileAuditStore_QueryInvalidCursor(t *testing.T) {
+	t.Parallel()
+
+	store := NewAuditFileReader(t.TempDir(), testLogger())
+	for _, cursor := range []string{"bogus", "2026-01-14.0.0", "2026-13-01.0.1", "2026-01-14.-1.5", "2026-01-14.0"} {
+		_, _, err := store.Query(context.Background(), audit.AuditFilter{Cursor: cursor})
+		if !errors.Is(err, audit.ErrInvalidCursor) {
+			t.Errorf("Query(cursor %q) error = %v, want ErrInvalidCursor", cursor, err)
+		}
+	}
+}
@@ -0,0 +1,409 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+)
+
+// Compile-time check.
+var _ audit.AuditStore = (*SQLiteAuditStore)(nil)
+
+// SQLiteAuditStoreConfig holds configuration for the SQLite audit store.
+type SQLiteAuditStoreConfig struct {
+	// Path is the database file; ":memory:" is accepted for testing.
+	Path string
+	// RetentionDays deletes records older than this many days in an hourly
+	// pass. 0 keeps every record.
+	RetentionDays int
+}
+
+// SQLiteAuditStore implements audit.AuditStore in an SQLite database.
+// Filtered columns (timestamp, identity, session, tool, decision,
+// protocol) are indexed so queries over months of records avoid scanning
+// every record; the full record is stored as JSON.
+type SQLiteAuditStore struct {
+	db            *sql.DB
+	path          string
+	retentionDays int
+	logger        *slog.Logger
+	cancel        context.CancelFunc
+	wg            sync.WaitGroup
+	closeOnce     sync.Once
+	closeErr      error
+}
+
+// NewSQLiteAuditStore opens (or creates) the audit database, deletes
+// records past the retention period and starts the hourly retention pass.
+func NewSQLiteAuditStore(cfg SQLiteAuditStoreConfig, logger *slog.Logger) (*SQLiteAuditStore, error) {
+	if cfg.Path != ":memory:" {
+		if err := os.MkdirAll(filepath.Dir(cfg.Path), 0700); err != nil {
+			return nil, fmt.Errorf("create audit database directory: %w", err)
+		}
+	}
+	dsn := cfg.Path + "?_journal_mode=WAL&_busy_timeout=5000&_synchronous=FULL"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite: %w", err)
+	}
+
+	// SQLite allows only one writer at a time; serializing all access via a
+	// single connection avoids SQLITE_BUSY under concurrent write load.
+	db.SetMaxOpenConns(1)
+
+	if err := initAuditSchema(db); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("init schema: %w", err)
+	}
+
+	// Audit records can hold tool arguments: owner-only permissions, also
+	// for the WAL/SHM files created by initAuditSchema.
+	if cfg.Path != ":memory:" {
+		if chmodErr := os.Chmod(cfg.Path, 0600); chmodErr != nil {
+			logger.Warn("failed to set sqlite file permissions", "path", cfg.Path, "error", chmodErr)
+		}
+		for _, suffix := range []string{"-wal", "-shm"} {
+			_ = os.Chmod(cfg.Path+suffix, 0600)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &SQLiteAuditStore{
+		db:            db,
+		path:          cfg.Path,
+		retentionDays: cfg.RetentionDays,
+		logger:        logger,
+		cancel:        cancel,
+	}
+	if s.retentionDays > 0 {
+		s.runRetention(ctx)
+		s.wg.Add(1)
+		go s.retentionLoop(ctx)
+	}
+	return s, nil
+}
+
+func initAuditSchema(db *sql.DB) error {
+	_, err := db.ExecContext(context.Background(), `
+		CREATE TABLE IF NOT EXISTS audit_records (
+			id            INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp     INTEGER NOT NULL,
+			identity_id   TEXT    NOT NULL DEFAULT '',
+			identity_name TEXT    NOT NULL DEFAULT '',
+			session_id    TEXT    NOT NULL DEFAULT '',
+			tool_name     TEXT    NOT NULL DEFAULT '',
+			tool_bare     TEXT    NOT NULL DEFAULT '',
+			decision      TEXT    NOT NULL DEFAULT '',
+			protocol      TEXT    NOT NULL DEFAULT '',
+			record        TEXT    NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_audit_time ON audit_records(timestamp);
+		CREATE INDEX IF NOT EXISTS idx_audit_identity ON audit_records(identity_id, timestamp);
+		CREATE INDEX IF NOT EXISTS idx_audit_session ON audit_records(session_id, timestamp);
+		CREATE INDEX IF NOT EXISTS idx_audit_tool ON audit_records(tool_name, timestamp);
+		CREATE INDEX IF NOT EXISTS idx_audit_tool_bare ON audit_records(tool_bare, timestamp);
+		CREATE INDEX IF NOT EXISTS idx_audit_decision ON audit_records(decision, timestamp);
+	`)
+	return err
+}
+
+// Append inserts audit records in one transaction.
+func (s *SQLiteAuditStore) Append(ctx context.Context, records ...audit.AuditRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin audit insert: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO audit_records
+		(timestamp, identity_id, identity_name, session_id, tool_name, tool_bare, decision, protocol, record)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("prepare audit insert: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for _, rec := range records {
+		data, err := json.Marshal(rec)
+		if err != nil {
+			s.logger.Error("failed to marshal audit record", "error", err)
+			continue
+		}
+		ts := rec.Timestamp
+		if ts.IsZero() {
+			ts = time.Now()
+		}
+		if _, err := stmt.ExecContext(ctx,
+			ts.UnixNano(), rec.IdentityID, rec.IdentityName, rec.SessionID,
+			rec.ToolName, bareToolName(rec.ToolName),
+			strings.ToLower(rec.Decision), strings.ToLower(rec.Protocol), string(data),
+		); err != nil {
+			return fmt.Errorf("insert audit record: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Flush is a no-op: Append commits before returning.
+func (s *SQLiteAuditStore) Flush(_ context.Context) error {
+	return nil
+}
+
+// Close stops the retention pass and closes the database.
+func (s *SQLiteAuditStore) Close() error {
+	s.closeOnce.Do(func() {
+		s.cancel()
+		s.wg.Wait()
+		s.closeErr = s.db.Close()
+	})
+	return s.closeErr
+}
+
+// GetRecent returns the last n audit records, newest first.
+func (s *SQLiteAuditStore) GetRecent(n int) []audit.AuditRecord {
+	if n <= 0 {
+		return nil
+	}
+	rows, err := s.db.QueryContext(context.Background(),
+		`SELECT id, timestamp, record FROM audit_records ORDER BY timestamp DESC, id DESC LIMIT ?`, n)
+	if err != nil {
+		s.logger.Warn("audit recent query failed", "error", err)
+		return nil
+	}
+	defer func() { _ = rows.Close() }()
+
+	result := make([]audit.AuditRecord, 0, n)
+	for rows.Next() {
+		rec, _, err := s.scanRecord(rows)
+		if err != nil {
+			continue
+		}
+		result = append(result, rec)
+	}
+	return result
+}
+
+// Query returns audit records matching filter, newest first or, with
+// Ascending, oldest first. Time range, identity, session, tool, upstream,
+// decision and protocol are answered from an index; rule ID and reason
+// are matched by SQLite on the rows the indexes select, and label
+// selectors afterwards. When more records match, the returned cursor
+// continues after the last record of the page.
+func (s *SQLiteAuditStore) Query(ctx context.Context, filter audit.AuditFilter) ([]audit.AuditRecord, string, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	if limit > maxQueryLimit {
+		limit = maxQueryLimit
+	}
+
+	var where []string
+	var args []interface{}
+	if !filter.StartTime.IsZero() {
+		where = append(where, "timestamp >= ?")
+		args = append(args, filter.StartTime.UnixNano())
+	}
+	if !filter.EndTime.IsZero() {
+		where = append(where, "timestamp <= ?")
+		args = append(args, filter.EndTime.UnixNano())
+	}
+	if filter.Decision != "" {
+		where = append(where, "decision = ?")
+		args = append(args, strings.ToLower(filter.Decision))
+	}
+	if filter.Protocol != "" {
+		where = append(where, "protocol = ?")
+		args = append(args, strings.ToLower(filter.Protocol))
+	}
+	// A bare tool name also matches the namespaced form ("read_file"
+	// matches "desktop/read_file").
+	if filter.ToolName != "" {
+		where = append(where, "(tool_name = ? OR tool_bare = ?)")
+		args = append(args, filter.ToolName, filter.ToolName)
+	}
+	if filter.UserID != "" {
+		where = append(where, "(identity_id = ? OR instr(lower(identity_name), ?) > 0)")
+		args = append(args, filter.UserID, strings.ToLower(filter.UserID))
+	}
+	if filter.SessionID != "" {
+		where = append(where, "session_id = ?")
+		args = append(args, filter.SessionID)
+	}
+	// Namespaced tool names sort between "<upstream>/" and "<upstream>0",
+	// so the tool index answers the upstream filter as a range.
+	if filter.Upstream != "" {
+		where = append(where, "tool_name >= ? AND tool_name < ?")
+		args = append(args, filter.Upstream+"/", filter.Upstream+"0")
+	}
+	if filter.RuleID != "" {
+		where = append(where, "json_extract(record, '$.rule_id') = ?")
+		args = append(args, filter.RuleID)
+	}
+	if filter.Reason != "" {
+		where = append(where, "instr(lower(json_extract(record, '$.reason')), ?) > 0")
+		args = append(args, strings.ToLower(filter.Reason))
+	}
+	if filter.CorrelationID != "" {
+		where = append(where, "json_extract(record, '$.correlation_id') = ?")
+		args = append(args, filter.CorrelationID)
+	}
+	if filter.Cursor != "" {
+		ts, id, err := parseAuditCursor(filter.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		if filter.Ascending {
+			where = append(where, "(timestamp > ? OR (timestamp = ? AND id > ?))")
+		} else {
+			where = append(where, "(timestamp < ? OR (timestamp = ? AND id < ?))")
+		}
+		args = append(args, ts, ts, id)
+	}
+
+	query := "SELECT id, timestamp, record FROM audit_records"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	if filter.Ascending {
+		query += " ORDER BY timestamp ASC, id ASC"
+	} else {
+		query += " ORDER BY timestamp DESC, id DESC"
+	}
+	// Without label selectors every selected row is returned, so the
+	// database can stop after one row more than the page.
+	if len(filter.Labels) == 0 {
+		query += " LIMIT " + strconv.Itoa(limit+1)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("query audit records: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	result := make([]audit.AuditRecord, 0)
+	var lastTS, lastID int64
+	more := false
+	for rows.Next() {
+		rec, pos, err := s.scanRecord(rows)
+		if err != nil {
+			continue
+		}
+		if !filter.MatchesLabels(rec.SessionLabels) {
+			continue
+		}
+		if len(result) == limit {
+			more = true
+			break
+		}
+		result = append(result, rec)
+		lastTS, lastID = pos.ts, pos.id
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("query audit records: %w", err)
+	}
+	if !more {
+		return result, "", nil
+	}
+	return result, formatAuditCursor(lastTS, lastID), nil
+}
+
+// PurgeOlderThan deletes records older than before and returns how many
+// were deleted.
+func (s *SQLiteAuditStore) PurgeOlderThan(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM audit_records WHERE timestamp < ?`, before.UnixNano())
+	if err != nil {
+		return 0, fmt.Errorf("purge audit records: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// auditRowPosition is the sort key of a row, used for cursors.
+type auditRowPosition struct {
+	ts, id int64
+}
+
+func (s *SQLiteAuditStore) scanRecord(rows *sql.Rows) (audit.AuditRecord, auditRowPosition, error) {
+	var pos auditRowPosition
+	var data string
+	if err := rows.Scan(&pos.id, &pos.ts, &data); err != nil {
+		return audit.AuditRecord{}, pos, err
+	}
+	var rec audit.AuditRecord
+	if err := json.Unmarshal([]byte(data), &rec); err != nil {
+		s.logger.Warn("skipping malformed audit record", "id", pos.id, "error", err)
+		return audit.AuditRecord{}, pos, err
+	}
+	return rec, pos, nil
+}
+
+func (s *SQLiteAuditStore) retentionLoop(ctx context.Context) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(1 * time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.runRetention(ctx)
+		}
+	}
+}
+
+// runRetention deletes the records past the retention period.
+func (s *SQLiteAuditStore) runRetention(ctx context.Context) {
+	cutoff := time.Now().UTC().AddDate(0, 0, -s.retentionDays)
+	n, err := s.PurgeOlderThan(ctx, cutoff)
+	if err != nil {
+		s.logger.Warn("audit database retention failed", "error", err)
+		return
+	}
+	if n > 0 {
+		s.logger.Info("deleted expired audit records", "count", n, "retention_days", s.retentionDays)
+	}
+}
+
+// bareToolName strips the namespace prefix of a tool name
+// ("desktop/read_file" → "read_file").
+func bareToolName(name string) string {
+	if idx := strings.Index(name, "/"); idx >= 0 {
+		return name[idx+1:]
+	}
+	return name
+}
+
+// formatAuditCursor encodes the position of the last record of a page.
+func formatAuditCursor(ts, id int64) string {
+	return strconv.FormatInt(ts, 10) + ":" + strconv.FormatInt(id, 10)
+}
+
+func parseAuditCursor(cursor string) (ts, id int64, err error) {
+	tsStr, idStr, ok := strings.Cut(cursor, ":")
+	if ok {
+		ts, err = strconv.ParseInt(tsStr, 10, 64)
+		if err == nil {
+			id, err = strconv.ParseInt(idStr, 10, 64)
+		}
+	}
+	if !ok || err != nil {
+		return 0, 0, fmt.Errorf("%w: %q", audit.ErrInvalidCursor, cursor)
+	}
+	return ts, id, nil
+}
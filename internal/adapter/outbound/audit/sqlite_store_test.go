@@ -0,0 +1,176 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+)
+
+func newTestSQLiteStore(t *testing.T, retentionDays int) (*SQLiteAuditStore, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "audit", "audit.db")
+	store, err := NewSQLiteAuditStore(SQLiteAuditStoreConfig{Path: path, RetentionDays: retentionDays}, testLogger())
+	if err != nil {
+		t.Fatalf("NewSQLiteAuditStore() error: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	return store, path
+}
+
+func TestSQLiteAuditStore_QueryFilters(t *testing.T) {
+	t.Parallel()
+	store, _ := newTestSQLiteStore(t, 0)
+	ctx := context.Background()
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	records := []audit.AuditRecord{
+		{Timestamp: base, IdentityID: "id-1", IdentityName: "Alice", SessionID: "s1", ToolName: "desktop/read_file", Decision: audit.DecisionAllow, Protocol: "mcp", RequestID: "r1",
+			RuleID: "allow-reads", Reason: "Matched rule allow-reads"},
+		{Timestamp: base.Add(time.Minute), IdentityID: "id-2", IdentityName: "Bob", SessionID: "s2", ToolName: "write_file", Decision: audit.DecisionDeny, Protocol: "mcp", RequestID: "r2",
+			SessionLabels: map[string]string{"project": "checkout"}, RuleID: "block-writes", Reason: "Deny by rule block-writes"},
+		{Timestamp: base.AddDate(0, 2, 0), IdentityID: "id-1", IdentityName: "Alice", SessionID: "s3", ToolName: "read_file", Decision: audit.DecisionDeny, Protocol: "http", RequestID: "r3",
+			Reason: "Default deny"},
+	}
+	if err := store.Append(ctx, records...); err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		filter audit.AuditFilter
+		want   []string
+	}{
+		{"all, newest first", audit.AuditFilter{}, []string{"r3", "r2", "r1"}},
+		{"time range", audit.AuditFilter{StartTime: base, EndTime: base.Add(time.Hour)}, []string{"r2", "r1"}},
+		{"bare tool name", audit.AuditFilter{ToolName: "read_file"}, []string{"r3", "r1"}},
+		{"namespaced tool name", audit.AuditFilter{ToolName: "desktop/read_file"}, []string{"r1"}},
+		{"decision", audit.AuditFilter{Decision: "DENY"}, []string{"r3", "r2"}},
+		{"identity ID", audit.AuditFilter{UserID: "id-2"}, []string{"r2"}},
+		{"identity name substring", audit.AuditFilter{UserID: "ali"}, []string{"r3", "r1"}},
+		{"session", audit.AuditFilter{SessionID: "s3"}, []string{"r3"}},
+		{"protocol", audit.AuditFilter{Protocol: "http"}, []string{"r3"}},
+		{"labels", audit.AuditFilter{Labels: map[string]string{"project": ""}}, []string{"r2"}},
+		{"upstream", audit.AuditFilter{Upstream: "desktop"}, []string{"r1"}},
+		{"rule ID", audit.AuditFilter{RuleID: "block-writes"}, []string{"r2"}},
+		{"reason substring", audit.AuditFilter{Reason: "DENY"}, []string{"r3", "r2"}},
+		{"oldest first", audit.AuditFilter{Ascending: true}, []string{"r1", "r2", "r3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, cursor, err := store.Query(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("Query() error: %v", err)
+			}
+			if cursor != "" {
+				t.Errorf("cursor = %q, want none", cursor)
+			}
+			var ids []string
+			for _, r := range got {
+				ids = append(ids, r.RequestID)
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.want) {
+				t.Errorf("Query() = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+
+	got, _, _ := store.Query(ctx, audit.AuditFilter{SessionID: "s2"})
+	if len(got) != 1 || got[0].IdentityName != "Bob" || got[0].SessionLabels["project"] != "checkout" {
+		t.Errorf("stored record = %+v", got)
+	}
+}
+
+func TestSQLiteAuditStore_Pagination(t *testing.T) {
+	t.Parallel()
+	store, _ := newTestSQLiteStore(t, 0)
+	ctx := context.Background()
+
+	// Records sharing a timestamp are still paged without gaps or repeats.
+	base := time.Now().UTC().Add(-time.Hour)
+	var records []audit.AuditRecord
+	for i := 0; i < 7; i++ {
+		records = append(records, makeRecord(base.Add(time.Duration(i/2)*time.Second), fmt.Sprintf("r%d", i)))
+	}
+	if err := store.Append(ctx, records...); err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+
+	var seen []string
+	filter := audit.AuditFilter{Limit: 3}
+	for page := 0; page < 5; page++ {
+		got, cursor, err := store.Query(ctx, filter)
+		if err != nil {
+			t.Fatalf("Query() error: %v", err)
+		}
+		for _, r := range got {
+			seen = append(seen, r.RequestID)
+		}
+		if cursor == "" {
+			break
+		}
+		filter.Cursor = cursor
+	}
+	if want := "[r6 r5 r4 r3 r2 r1 r0]"; fmt.Sprint(seen) != want {
+		t.Errorf("paged records = %v, want %s", seen, want)
+	}
+
+	seen = nil
+	filter = audit.AuditFilter{Limit: 3, Ascending: true}
+	for page := 0; page < 5; page++ {
+		got, cursor, err := store.Query(ctx, filter)
+		if err != nil {
+			t.Fatalf("Query() error: %v", err)
+		}
+		for _, r := range got {
+			seen = append(seen, r.RequestID)
+		}
+		if cursor == "" {
+			break
+		}
+		filter.Cursor = cursor
+	}
+	if want := "[r0 r1 r2 r3 r4 r5 r6]"; fmt.Sprint(seen) != want {
+		t.Errorf("ascending paged records = %v, want %s", seen, want)
+	}
+
+	if _, _, err := store.Query(ctx, audit.AuditFilter{Cursor: "bogus"}); !errors.Is(err, audit.ErrInvalidCursor) {
+		t.Errorf("Query() with bad cursor error = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestSQLiteAuditStore_RetentionAndReopen(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "audit.db")
+
+	store, err := NewSQLiteAuditStore(SQLiteAuditStoreConfig{Path: path}, testLogger())
+	if err != nil {
+		t.Fatalf("NewSQLiteAuditStore() error: %v", err)
+	}
+	now := time.Now().UTC()
+	if err := store.Append(ctx, makeRecord(now.AddDate(0, 0, -40), "old"), makeRecord(now, "new")); err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+	_ = store.Close()
+
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("database file mode = %v, %v, want 0600", info.Mode().Perm(), err)
+	}
+
+	// Reopening with a retention period deletes the expired record.
+	store, err = NewSQLiteAuditStore(SQLiteAuditStoreConfig{Path: path, RetentionDays: 30}, testLogger())
+	if err != nil {
+		t.Fatalf("NewSQLiteAuditStore() error: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	recent := store.GetRecent(10)
+	if len(recent) != 1 || recent[0].RequestID != "new" {
+		t.Errorf("GetRecent() after retention = %+v, want only the new record", recent)
+	}
+}
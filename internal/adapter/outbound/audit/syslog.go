@@ -0,0 +1,328 @@
+package audit
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+)
+
+// Syslog forwarding defaults.
+const (
+	syslogDialTimeout  = 5 * time.Second
+	syslogWriteTimeout = 5 * time.Second
+	syslogMinBackoff   = time.Second
+	syslogMaxBackoff   = time.Minute
+	// syslogMaxPending bounds the messages held while the collector is
+	// unreachable; the oldest are dropped beyond it.
+	syslogMaxPending = 10000
+	// syslogFacility is local0.
+	syslogFacility = 16
+	syslogAppName  = "sentinel-gate"
+)
+
+// SyslogConfig configures forwarding of audit records to a syslog collector.
+type SyslogConfig struct {
+	// Address is the collector host:port.
+	Address string
+	// TLS enables TLS to the collector, verified against the system roots
+	// or against CAFile when set.
+	TLS    bool
+	CAFile string
+}
+
+// ParseSyslogURL parses an audit output of the form "syslog://host:port"
+// (plain TCP) or "tcp+tls://host:port[?ca_file=/path/ca.pem]".
+func ParseSyslogURL(raw string) (SyslogConfig, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return SyslogConfig{}, fmt.Errorf("invalid syslog URL: %w", err)
+	}
+	var cfg SyslogConfig
+	switch u.Scheme {
+	case "syslog":
+	case "tcp+tls":
+		cfg.TLS = true
+		cfg.CAFile = u.Query().Get("ca_file")
+	default:
+		return SyslogConfig{}, fmt.Errorf("unsupported syslog scheme %q (want syslog or tcp+tls)", u.Scheme)
+	}
+	if u.Hostname() == "" || u.Port() == "" {
+		return SyslogConfig{}, fmt.Errorf("syslog URL %q must have a host and a port", raw)
+	}
+	cfg.Address = u.Host
+	return cfg, nil
+}
+
+// SyslogAuditStore streams audit records to a syslog collector over TCP as
+// RFC 5424 messages with octet-counting framing (RFC 6587). Each Append
+// batch from the AuditService goes out in one write. While the collector is
+// unreachable the messages are kept, up to syslogMaxPending, and the store
+// reconnects with exponential backoff.
+type SyslogAuditStore struct {
+	cfg       SyslogConfig
+	tlsConfig *tls.Config
+	hostname  string
+	procID    string
+	logger    *slog.Logger
+
+	mu      sync.Mutex
+	pending [][]byte
+	dropped int
+	closed  bool
+
+	conn    net.Conn // owned by run
+	wake    chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// NewSyslogAuditStore creates the store and starts its sender. The first
+// connection is made in the background, so an unreachable collector does
+// not prevent startup.
+func NewSyslogAuditStore(cfg SyslogConfig, logger *slog.Logger) (*SyslogAuditStore, error) {
+	if _, _, err := net.SplitHostPort(cfg.Address); err != nil {
+		return nil, fmt.Errorf("invalid syslog address %q: %w", cfg.Address, err)
+	}
+	s := &SyslogAuditStore{
+		cfg:     cfg,
+		procID:  strconv.Itoa(os.Getpid()),
+		logger:  logger,
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	if cfg.TLS {
+		host, _, _ := net.SplitHostPort(cfg.Address)
+		s.tlsConfig = &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+		if cfg.CAFile != "" {
+			pem, err := os.ReadFile(cfg.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("read syslog CA file: %w", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates in syslog CA file %s", cfg.CAFile)
+			}
+			s.tlsConfig.RootCAs = pool
+		}
+	}
+	s.hostname, _ = os.Hostname()
+	if s.hostname == "" {
+		s.hostname = "-"
+	}
+	go s.run()
+	return s, nil
+}
+
+// Append queues the records for the sender. It does not wait for the
+// collector.
+func (s *SyslogAuditStore) Append(ctx context.Context, records ...audit.AuditRecord) error {
+	msgs := make([][]byte, 0, len(records))
+	for _, r := range records {
+		msg, err := s.format(r)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, msg)
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return errors.New("syslog audit store is closed")
+	}
+	s.pending = append(s.pending, msgs...)
+	if over := len(s.pending) - syslogMaxPending; over > 0 {
+		s.pending = s.pending[over:]
+		s.dropped += over
+	}
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Flush is a no-op: records are sent as soon as they are appended.
+func (s *SyslogAuditStore) Flush(ctx context.Context) error {
+	return nil
+}
+
+// Close makes a last attempt to send the pending records and closes the
+// connection.
+func (s *SyslogAuditStore) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+	close(s.done)
+	<-s.stopped
+	return nil
+}
+
+// format renders a record as an octet-counted RFC 5424 message. Denials
+// have severity warning, everything else informational.
+func (s *SyslogAuditStore) format(r audit.AuditRecord) ([]byte, error) {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	severity := 6
+	if r.Decision == audit.DecisionDeny {
+		severity = 4
+	}
+	ts := r.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	msg := fmt.Sprintf("<%d>1 %s %s %s %s audit - ", syslogFacility*8+severity,
+		ts.UTC().Format(time.RFC3339Nano), s.hostname, syslogAppName, s.procID)
+	framed := strconv.AppendInt(nil, int64(len(msg)+len(body)), 10)
+	framed = append(framed, ' ')
+	framed = append(framed, msg...)
+	return append(framed, body...), nil
+}
+
+// run sends the pending messages whenever records are appended, retrying
+// with exponential backoff while the collector is unreachable.
+func (s *SyslogAuditStore) run() {
+	defer close(s.stopped)
+	backoff := syslogMinBackoff
+	var retry <-chan time.Time
+	down := false
+	for {
+		select {
+		case <-s.wake:
+			if retry != nil {
+				continue // wait for the backoff
+			}
+		case <-retry:
+			retry = nil
+		case <-s.done:
+			if err := s.send(); err != nil && !down {
+				s.logger.Warn("syslog audit: final send failed", "address", s.cfg.Address, "error", err)
+			}
+			s.mu.Lock()
+			lost := len(s.pending) + s.dropped
+			s.mu.Unlock()
+			if lost > 0 {
+				s.logger.Error("syslog audit: records not delivered", "address", s.cfg.Address, "count", lost)
+			}
+			if s.conn != nil {
+				_ = s.conn.Close()
+			}
+			return
+		}
+
+		if err := s.send(); err != nil {
+			if !down {
+				s.logger.Warn("syslog audit: collector unreachable, retrying", "address", s.cfg.Address, "error", err)
+				down = true
+			}
+			retry = time.After(backoff)
+			backoff = min(backoff*2, syslogMaxBackoff)
+			continue
+		}
+		if down {
+			s.logger.Info("syslog audit: reconnected", "address", s.cfg.Address)
+			down = false
+		}
+		backoff = syslogMinBackoff
+	}
+}
+
+// send writes all pending messages in one write, connecting first when
+// needed. On failure the messages stay pending and the connection is
+// dropped.
+func (s *SyslogAuditStore) send() error {
+	s.mu.Lock()
+	batch := s.pending
+	s.pending = nil
+	dropped := s.dropped
+	s.dropped = 0
+	s.mu.Unlock()
+	if dropped > 0 {
+		s.logger.Warn("syslog audit: dropped records while the collector was unreachable", "count", dropped)
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+
+	err := s.write(batch)
+	if err == nil {
+		return nil
+	}
+	if s.conn != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+	s.mu.Lock()
+	s.pending = append(batch, s.pending...)
+	if over := len(s.pending) - syslogMaxPending; over > 0 {
+		s.pending = s.pending[over:]
+		s.dropped += over
+	}
+	s.mu.Unlock()
+	return err
+}
+
+func (s *SyslogAuditStore) write(batch [][]byte) error {
+	if s.conn != nil && !connAlive(s.conn) {
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+	if s.conn == nil {
+		dialer := &net.Dialer{Timeout: syslogDialTimeout}
+		var conn net.Conn
+		var err error
+		if s.tlsConfig != nil {
+			conn, err = tls.DialWithDialer(dialer, "tcp", s.cfg.Address, s.tlsConfig)
+		} else {
+			conn, err = dialer.Dial("tcp", s.cfg.Address)
+		}
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+	var buf []byte
+	for _, msg := range batch {
+		buf = append(buf, msg...)
+	}
+	if err := s.conn.SetWriteDeadline(time.Now().Add(syslogWriteTimeout)); err != nil {
+		return err
+	}
+	_, err := s.conn.Write(buf)
+	return err
+}
+
+// connAlive reports whether the collector still has the connection open.
+// Collectors never send, so anything but a read timeout means the peer
+// closed it; checking first keeps the next batch from being written into a
+// dead connection.
+func connAlive(conn net.Conn) bool {
+	if err := conn.SetReadDeadline(time.Now().Add(time.Millisecond)); err != nil {
+		return false
+	}
+	var b [1]byte
+	_, err := conn.Read(b[:])
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
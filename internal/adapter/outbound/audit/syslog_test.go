@@ -0,0 +1,186 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+)
+
+// syslogCollector accepts connections on ln and sends every octet-counted
+// message it reads to msgs. With closeAfterFirst it drops each connection
+// after its first message.
+func syslogCollector(t *testing.T, ln net.Listener, closeAfterFirst bool) <-chan string {
+	t.Helper()
+	msgs := make(chan string, 100)
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = conn.Close() }()
+				br := bufio.NewReader(conn)
+				for {
+					size, err := br.ReadString(' ')
+					if err != nil {
+						return
+					}
+					n, err := strconv.Atoi(strings.TrimSpace(size))
+					if err != nil {
+						t.Errorf("bad frame length %q", size)
+						return
+					}
+					buf := make([]byte, n)
+					if _, err := io.ReadFull(br, buf); err != nil {
+						return
+					}
+					if closeAfterFirst {
+						_ = conn.Close()
+						msgs <- string(buf)
+						return
+					}
+					msgs <- string(buf)
+				}
+			}()
+		}
+	}()
+	return msgs
+}
+
+func receive(t *testing.T, msgs <-chan string) string {
+	t.Helper()
+	select {
+	case m := <-msgs:
+		return m
+	case <-time.After(5 * time.Second):
+		t.Fatal("no syslog message received")
+		return ""
+	}
+}
+
+func TestParseSyslogURL(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		raw     string
+		want    SyslogConfig
+		wantErr bool
+	}{
+		{raw: "syslog://siem.internal:514", want: SyslogConfig{Address: "siem.internal:514"}},
+		{raw: "tcp+tls://siem.internal:6514?ca_file=/etc/ssl/siem.pem", want: SyslogConfig{Address: "siem.internal:6514", TLS: true, CAFile: "/etc/ssl/siem.pem"}},
+		{raw: "syslog://siem.internal", wantErr: true},
+		{raw: "udp://siem.internal:514", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSyslogURL(tt.raw)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSyslogURL(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSyslogURL(%q) = %+v, want %+v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestSyslogAuditStore_Messages(t *testing.T) {
+	t.Parallel()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	msgs := syslogCollector(t, ln, false)
+
+	store, err := NewSyslogAuditStore(SyslogConfig{Address: ln.Addr().String()}, testLogger())
+	if err != nil {
+		t.Fatalf("NewSyslogAuditStore() error: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	ts := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	deny := makeRecord(ts.Add(time.Second), "r2")
+	deny.Decision = audit.DecisionDeny
+	if err := store.Append(context.Background(), makeRecord(ts, "r1"), deny); err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+
+	for _, want := range []struct{ pri, id string }{{"<134>1 ", "r1"}, {"<132>1 ", "r2"}} {
+		m := receive(t, msgs)
+		if !strings.HasPrefix(m, want.pri) || !strings.Contains(m, " sentinel-gate ") {
+			t.Errorf("message header = %q, want priority %s", m, want.pri)
+		}
+		body := m[strings.Index(m, " - ")+3:]
+		var rec audit.AuditRecord
+		if err := json.Unmarshal([]byte(body), &rec); err != nil || rec.RequestID != want.id {
+			t.Errorf("message body = %q (%v), want record %s", body, err, want.id)
+		}
+	}
+}
+
+func TestSyslogAuditStore_Reconnects(t *testing.T) {
+	t.Parallel()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	msgs := syslogCollector(t, ln, true)
+
+	store, err := NewSyslogAuditStore(SyslogConfig{Address: ln.Addr().String()}, testLogger())
+	if err != nil {
+		t.Fatalf("NewSyslogAuditStore() error: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+
+	// The collector drops the connection after each message; every record
+	// still arrives over a new connection.
+	for i := 0; i < 3; i++ {
+		id := "r" + strconv.Itoa(i)
+		if err := store.Append(ctx, makeRecord(time.Now(), id)); err != nil {
+			t.Fatalf("Append() error: %v", err)
+		}
+		if m := receive(t, msgs); !strings.Contains(m, `"request_id":"`+id+`"`) {
+			t.Errorf("message %d = %q", i, m)
+		}
+	}
+}
+
+func TestSyslogAuditStore_CollectorDownAtStart(t *testing.T) {
+	t.Parallel()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+
+	store, err := NewSyslogAuditStore(SyslogConfig{Address: addr}, testLogger())
+	if err != nil {
+		t.Fatalf("NewSyslogAuditStore() error: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	if err := store.Append(context.Background(), makeRecord(time.Now(), "queued")); err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+
+	// Records appended while the collector is down are sent after the
+	// backoff once it comes up.
+	time.Sleep(100 * time.Millisecond)
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("cannot listen on %s again: %v", addr, err)
+	}
+	msgs := syslogCollector(t, ln, false)
+	if m := receive(t, msgs); !strings.Contains(m, `"request_id":"queued"`) {
+		t.Errorf("message = %q", m)
+	}
+}
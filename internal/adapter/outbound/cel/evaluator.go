@@ -8,6 +8,9 @@ import (
 	"time"
 
 	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker"
+	celast "github.com/google/cel-go/common/ast"
+	"github.com/google/cel-go/common/types"
 
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/policy"
 )
@@ -24,12 +27,86 @@ const maxNestingDepth = 50
 // evalTimeout is the maximum time allowed for a single CEL evaluation (HARDEN-02).
 const evalTimeout = 5 * time.Second
 
+// defaultMaxComplexity is the highest estimated worst-case cost accepted for
+// a submitted expression. A comprehension over one variable costs a few
+// thousand; three nested comprehensions exceed it.
+const defaultMaxComplexity = 1_000_000
+
 // interruptCheckFreq is how often (in comprehension iterations) context cancellation is checked.
 const interruptCheckFreq = 100
 
+// Limits bounds the cost of untrusted policy conditions. Zero values use the
+// defaults.
+type Limits struct {
+	// CostLimit is the runtime cost budget of one evaluation; evaluations
+	// exceeding it fail.
+	CostLimit uint64
+	// EvalTimeout bounds the wall time of one evaluation.
+	EvalTimeout time.Duration
+	// MaxComplexity rejects submitted expressions whose estimated worst-case
+	// cost (see Complexity) is higher.
+	MaxComplexity uint64
+	// BannedFunctions are functions submitted expressions may not call, e.g.
+	// "matches" or "session_sequence".
+	BannedFunctions []string
+	// BannedMacros are macros submitted expressions may not use: has, all,
+	// exists, exists_one, map, filter.
+	BannedMacros []string
+}
+
+// DefaultLimits returns the built-in limits.
+func DefaultLimits() Limits {
+	return Limits{
+		CostLimit:     maxCostBudget,
+		EvalTimeout:   evalTimeout,
+		MaxComplexity: defaultMaxComplexity,
+	}
+}
+
+// LimitError reports an expression rejected by the configured limits. Its
+// message is safe to return to the admin who submitted the expression.
+type LimitError struct {
+	Reason string
+	// Complexity is the estimated cost of the expression, when computed.
+	Complexity uint64
+}
+
+func (e *LimitError) Error() string {
+	return e.Reason
+}
+
 // Evaluator compiles and evaluates CEL expressions for policy rules.
 type Evaluator struct {
-	env *cel.Env
+	env    *cel.Env
+	limits Limits
+	banned map[string]bool // functions
+	macros map[string]bool // banned macros
+}
+
+// EvaluatorOption configures an Evaluator.
+type EvaluatorOption func(*Evaluator)
+
+// WithLimits sets the limits applied to expressions. Zero fields keep their
+// defaults.
+func WithLimits(l Limits) EvaluatorOption {
+	return func(e *Evaluator) {
+		if l.CostLimit > 0 {
+			e.limits.CostLimit = l.CostLimit
+		}
+		if l.EvalTimeout > 0 {
+			e.limits.EvalTimeout = l.EvalTimeout
+		}
+		if l.MaxComplexity > 0 {
+			e.limits.MaxComplexity = l.MaxComplexity
+		}
+		e.limits.BannedFunctions = append([]string(nil), l.BannedFunctions...)
+		e.limits.BannedMacros = append([]string(nil), l.BannedMacros...)
+	}
+}
+
+// standardMacros are the macro names that can be banned.
+var standardMacros = map[string]bool{
+	"has": true, "all": true, "exists": true, "exists_one": true, "map": true, "filter": true,
 }
 
 // NewPolicyEnvironment creates a CEL environment configured for policy evaluation.
@@ -40,12 +117,42 @@ func NewPolicyEnvironment() (*cel.Env, error) {
 }
 
 // NewEvaluator creates a new CEL evaluator with the policy environment.
-func NewEvaluator() (*Evaluator, error) {
+func NewEvaluator(opts ...EvaluatorOption) (*Evaluator, error) {
 	env, err := NewPolicyEnvironment()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create policy environment: %w", err)
 	}
-	return &Evaluator{env: env}, nil
+	e := &Evaluator{env: env, limits: DefaultLimits()}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	e.banned = make(map[string]bool, len(e.limits.BannedFunctions))
+	for _, fn := range e.limits.BannedFunctions {
+		e.banned[fn] = true
+	}
+	e.macros = make(map[string]bool, len(e.limits.BannedMacros))
+	for _, m := range e.limits.BannedMacros {
+		if !standardMacros[m] {
+			return nil, fmt.Errorf("unknown CEL macro %q", m)
+		}
+		e.macros[m] = true
+	}
+	if len(e.macros) > 0 {
+		// Record macro calls in the AST so banned ones can be reported by name.
+		if e.env, err = env.Extend(cel.EnableMacroCallTracking()); err != nil {
+			return nil, fmt.Errorf("failed to create policy environment: %w", err)
+		}
+	}
+	return e, nil
+}
+
+// Limits returns the limits applied by e.
+func (e *Evaluator) Limits() Limits {
+	l := e.limits
+	l.BannedFunctions = append([]string(nil), l.BannedFunctions...)
+	l.BannedMacros = append([]string(nil), l.BannedMacros...)
+	return l
 }
 
 // Compile parses and type-checks a CEL expression, returning a compiled program.
@@ -54,10 +161,14 @@ func (e *Evaluator) Compile(expression string) (cel.Program, error) {
 	if issues != nil && issues.Err() != nil {
 		return nil, fmt.Errorf("compilation failed: %w", issues.Err())
 	}
+	return e.program(ast)
+}
 
+// program creates a program for a checked AST with the runtime limits.
+func (e *Evaluator) program(ast *cel.Ast) (cel.Program, error) {
 	prg, err := e.env.Program(ast,
 		cel.EvalOptions(cel.OptOptimize),
-		cel.CostLimit(maxCostBudget),
+		cel.CostLimit(e.limits.CostLimit),
 		cel.InterruptCheckFrequency(interruptCheckFreq),
 	)
 	if err != nil {
@@ -93,25 +204,111 @@ func validateNesting(expr string) error {
 
 // ValidateExpression checks that a CEL expression is syntactically valid and safe for
 // policy evaluation (SECU-05, HARDEN-02). It performs compile-time validation and enforces
-// safety limits (expression length, nesting depth).
+// safety limits (expression length, nesting depth, banned functions and macros,
+// complexity). Limit violations are returned as *LimitError.
 func (e *Evaluator) ValidateExpression(expr string) error {
+	_, err := e.Complexity(expr)
+	return err
+}
+
+// Complexity validates expr like ValidateExpression and returns its
+// complexity score: the estimated worst-case runtime cost, assuming lists,
+// maps and strings from the request hold up to complexitySizeHint elements.
+// The score is comparable to the runtime cost limit; 0 is a constant.
+func (e *Evaluator) Complexity(expr string) (uint64, error) {
 	if len(expr) > maxExpressionLength {
-		return fmt.Errorf("expression too long: %d characters (max %d)", len(expr), maxExpressionLength)
+		return 0, fmt.Errorf("expression too long: %d characters (max %d)", len(expr), maxExpressionLength)
 	}
 
 	if expr == "" {
-		return errors.New("expression is empty")
+		return 0, errors.New("expression is empty")
 	}
 
 	if err := validateNesting(expr); err != nil {
-		return err
+		return 0, err
 	}
 
-	_, err := e.Compile(expr)
+	ast, issues := e.env.Compile(expr)
+	if issues != nil && issues.Err() != nil {
+		return 0, fmt.Errorf("invalid CEL expression: compilation failed: %w", issues.Err())
+	}
+	if err := e.checkBanned(ast); err != nil {
+		return 0, err
+	}
+
+	est, err := e.env.EstimateCost(ast, sizeHintEstimator{})
 	if err != nil {
-		return fmt.Errorf("invalid CEL expression: %w", err)
+		return 0, fmt.Errorf("invalid CEL expression: cost estimation failed: %w", err)
+	}
+	if est.Max > e.limits.MaxComplexity {
+		return est.Max, &LimitError{
+			Reason:     fmt.Sprintf("expression too complex: score %d (max %d)", est.Max, e.limits.MaxComplexity),
+			Complexity: est.Max,
+		}
+	}
+
+	if _, err := e.program(ast); err != nil {
+		return 0, fmt.Errorf("invalid CEL expression: %w", err)
+	}
+	return est.Max, nil
+}
+
+// UsesRequestTime reports whether expression reads request_time, directly or
+// through isWeekend() and withinHours(). An expression that does not compile
+// reports false.
+func (e *Evaluator) UsesRequestTime(expression string) bool {
+	ast, issues := e.env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return false
 	}
+	found := false
+	celast.PreOrderVisit(ast.NativeRep().Expr(), celast.NewExprVisitor(func(x celast.Expr) {
+		if x.Kind() == celast.IdentKind && x.AsIdent() == "request_time" {
+			found = true
+		}
+	}))
+	return found
+}
 
+// checkBanned rejects calls to banned functions and uses of banned macros.
+func (e *Evaluator) checkBanned(ast *cel.Ast) error {
+	if len(e.banned) == 0 && len(e.macros) == 0 {
+		return nil
+	}
+	native := ast.NativeRep()
+	for _, call := range native.SourceInfo().MacroCalls() {
+		if name := call.AsCall().FunctionName(); e.macros[name] {
+			return &LimitError{Reason: fmt.Sprintf("macro %s is not allowed", name)}
+		}
+	}
+	var found string
+	celast.PreOrderVisit(native.Expr(), celast.NewExprVisitor(func(x celast.Expr) {
+		if found == "" && x.Kind() == celast.CallKind && e.banned[x.AsCall().FunctionName()] {
+			found = x.AsCall().FunctionName()
+		}
+	}))
+	if found != "" {
+		return &LimitError{Reason: fmt.Sprintf("function %s is not allowed", found)}
+	}
+	return nil
+}
+
+// complexitySizeHint is the assumed size of lists, maps and strings from the
+// request when estimating complexity.
+const complexitySizeHint = 100
+
+// sizeHintEstimator sizes every variable (and its elements) at
+// complexitySizeHint and uses the default cost of all functions.
+type sizeHintEstimator struct{}
+
+func (sizeHintEstimator) EstimateSize(node checker.AstNode) *checker.SizeEstimate {
+	if len(node.Path()) > 0 {
+		return &checker.SizeEstimate{Min: 0, Max: complexitySizeHint}
+	}
+	return nil
+}
+
+func (sizeHintEstimator) EstimateCallCost(string, string, *checker.AstNode, []checker.AstNode) *checker.CallEstimate {
 	return nil
 }
 
@@ -123,7 +320,7 @@ func (e *Evaluator) ValidateExpression(expr string) error {
 func (e *Evaluator) Evaluate(ctx context.Context, prg cel.Program, evalCtx policy.EvaluationContext) (bool, error) {
 	activation := BuildUniversalActivation(evalCtx)
 
-	ctx, cancel := context.WithTimeout(ctx, evalTimeout)
+	ctx, cancel := context.WithTimeout(ctx, e.limits.EvalTimeout)
 	defer cancel()
 
 	result, _, err := prg.ContextEval(ctx, activation)
@@ -138,3 +335,53 @@ func (e *Evaluator) Evaluate(ctx context.Context, prg cel.Program, evalCtx polic
 
 	return boolResult, nil
 }
+
+// PartialEvaluate evaluates expression with only the variables in known bound.
+// Every other policy variable is treated as unknown, so the result is decided
+// only when it holds for all possible values of the unknown inputs.
+// Returns (value, true) when decided and (false, false) when the result
+// depends on unknown variables or evaluation fails on the known ones.
+func (e *Evaluator) PartialEvaluate(ctx context.Context, expression string, known map[string]any) (bool, bool, error) {
+	ast, issues := e.env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return false, false, fmt.Errorf("compilation failed: %w", issues.Err())
+	}
+
+	prg, err := e.env.Program(ast,
+		cel.EvalOptions(cel.OptPartialEval),
+		cel.CostLimit(e.limits.CostLimit),
+		cel.InterruptCheckFrequency(interruptCheckFreq),
+	)
+	if err != nil {
+		return false, false, fmt.Errorf("program creation failed: %w", err)
+	}
+
+	// Bind zero values for every variable so unknowns can be declared by name;
+	// the zero values are never read for variables marked unknown.
+	vars := BuildUniversalActivation(policy.EvaluationContext{})
+	var unknowns []*cel.AttributePatternType
+	for name := range vars {
+		if v, ok := known[name]; ok {
+			vars[name] = v
+		} else {
+			unknowns = append(unknowns, cel.AttributePattern(name))
+		}
+	}
+	activation, err := cel.PartialVars(vars, unknowns...)
+	if err != nil {
+		return false, false, fmt.Errorf("partial activation failed: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.limits.EvalTimeout)
+	defer cancel()
+
+	result, _, err := prg.ContextEval(ctx, activation)
+	if err != nil || types.IsUnknown(result) {
+		return false, false, nil
+	}
+	boolResult, ok := result.Value().(bool)
+	if !ok {
+		return false, false, nil
+	}
+	return boolResult, true, nil
+}
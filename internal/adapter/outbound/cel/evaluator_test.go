@@ -2,6 +2,7 @@ package cel
 
 import (
 	"context"
+	"errors"
 	"strings"
 	"testing"
 	"time"
@@ -524,3 +525,161 @@ func TestValidateNesting(t *testing.T) {
 		})
 	}
 }
+
+func TestPartialEvaluate(t *testing.T) {
+	eval, err := NewEvaluator()
+	if err != nil {
+		t.Fatalf("NewEvaluator() error: %v", err)
+	}
+
+	known := map[string]any{
+		"user_roles": []string{"contractor"},
+		"tool_name":  "delete_database",
+	}
+
+	tests := []struct {
+		name        string
+		expr        string
+		wantValue   bool
+		wantDecided bool
+	}{
+		{"constant true", `true`, true, true},
+		{"role present", `"contractor" in user_roles`, true, true},
+		{"role absent", `"admin" in user_roles`, false, true},
+		{"unknown argument", `tool_args.force == true`, false, false},
+		{"and short-circuits on known false", `"admin" in user_roles && tool_args.force == true`, false, true},
+		{"or short-circuits on known true", `"contractor" in user_roles || tool_args.force == true`, true, true},
+		{"and with unknown stays unknown", `"contractor" in user_roles && tool_args.force == true`, false, false},
+		{"identity unknown", `identity_name == "bob"`, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, decided, err := eval.PartialEvaluate(context.Background(), tt.expr, known)
+			if err != nil {
+				t.Fatalf("PartialEvaluate() error: %v", err)
+			}
+			if decided != tt.wantDecided || value != tt.wantValue {
+				t.Errorf("PartialEvaluate(%q) = (%v, %v), want (%v, %v)",
+					tt.expr, value, decided, tt.wantValue, tt.wantDecided)
+			}
+		})
+	}
+}
+
+func TestEvaluator_Limits(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		eval, err := NewEvaluator(WithLimits(Limits{}))
+		if err != nil {
+			t.Fatalf("NewEvaluator() error: %v", err)
+		}
+		if got := eval.Limits(); got.CostLimit != maxCostBudget || got.EvalTimeout != evalTimeout || got.MaxComplexity != defaultMaxComplexity {
+			t.Errorf("Limits() = %+v, want the defaults", got)
+		}
+	})
+
+	t.Run("cost_limit_exceeded", func(t *testing.T) {
+		eval, err := NewEvaluator(WithLimits(Limits{CostLimit: 5}))
+		if err != nil {
+			t.Fatalf("NewEvaluator() error: %v", err)
+		}
+		prg, err := eval.Compile(`user_roles.exists(r, r == "admin")`)
+		if err != nil {
+			t.Fatalf("Compile() error: %v", err)
+		}
+		roles := make([]string, 50)
+		_, err = eval.Evaluate(context.Background(), prg, policy.EvaluationContext{UserRoles: roles, RequestTime: time.Now()})
+		if err == nil || !strings.Contains(err.Error(), "cost limit") {
+			t.Errorf("Evaluate() error = %v, want cost limit exceeded", err)
+		}
+	})
+
+	t.Run("banned_function", func(t *testing.T) {
+		eval, err := NewEvaluator(WithLimits(Limits{BannedFunctions: []string{"matches"}}))
+		if err != nil {
+			t.Fatalf("NewEvaluator() error: %v", err)
+		}
+		err = eval.ValidateExpression(`tool_name.matches("^read_.*")`)
+		var limitErr *LimitError
+		if !errors.As(err, &limitErr) || limitErr.Error() != "function matches is not allowed" {
+			t.Errorf("ValidateExpression() error = %v, want banned function", err)
+		}
+		if err := eval.ValidateExpression(`tool_name.startsWith("read_")`); err != nil {
+			t.Errorf("ValidateExpression() unexpected error: %v", err)
+		}
+	})
+
+	t.Run("banned_macro", func(t *testing.T) {
+		eval, err := NewEvaluator(WithLimits(Limits{BannedMacros: []string{"exists"}}))
+		if err != nil {
+			t.Fatalf("NewEvaluator() error: %v", err)
+		}
+		err = eval.ValidateExpression(`"a" in user_roles || user_roles.exists(r, r == "admin")`)
+		var limitErr *LimitError
+		if !errors.As(err, &limitErr) || limitErr.Error() != "macro exists is not allowed" {
+			t.Errorf("ValidateExpression() error = %v, want banned macro", err)
+		}
+		if err := eval.ValidateExpression(`user_roles.all(r, r != "guest")`); err != nil {
+			t.Errorf("ValidateExpression() unexpected error: %v", err)
+		}
+
+		if _, err := NewEvaluator(WithLimits(Limits{BannedMacros: []string{"loop"}})); err == nil {
+			t.Error("NewEvaluator() should reject an unknown macro")
+		}
+	})
+
+	t.Run("complexity", func(t *testing.T) {
+		eval, err := NewEvaluator()
+		if err != nil {
+			t.Fatalf("NewEvaluator() error: %v", err)
+		}
+		simple, err := eval.Complexity(`tool_name == "read_file"`)
+		if err != nil {
+			t.Fatalf("Complexity() error: %v", err)
+		}
+		loop, err := eval.Complexity(`user_roles.exists(r, r == "admin")`)
+		if err != nil {
+			t.Fatalf("Complexity() error: %v", err)
+		}
+		if simple == 0 || loop <= simple {
+			t.Errorf("Complexity() = %d (simple), %d (comprehension); want 0 < simple < comprehension", simple, loop)
+		}
+
+		nested := `user_roles.all(a, user_roles.all(b, user_roles.all(c, a + b + c != "")))`
+		score, err := eval.Complexity(nested)
+		var limitErr *LimitError
+		if !errors.As(err, &limitErr) || limitErr.Complexity != score || score <= defaultMaxComplexity {
+			t.Errorf("Complexity() = %d, %v; want a limit error above %d", score, err, defaultMaxComplexity)
+		}
+
+		strict, err := NewEvaluator(WithLimits(Limits{MaxComplexity: loop - 1}))
+		if err != nil {
+			t.Fatalf("NewEvaluator() error: %v", err)
+		}
+		if err := strict.ValidateExpression(`user_roles.exists(r, r == "admin")`); !errors.As(err, &limitErr) {
+			t.Errorf("ValidateExpression() error = %v, want complexity limit", err)
+		}
+	})
+}
+
+func TestEvaluator_UsesRequestTime(t *testing.T) {
+	eval, err := NewEvaluator()
+	if err != nil {
+		t.Fatalf("NewEvaluator() error: %v", err)
+	}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`withinHours("09:00", "18:00", "Europe/Rome")`, true},
+		{`"contractor" in identity_roles && !isWeekend()`, true},
+		{`request_time.getHours() >= 18`, true},
+		{`"contractor" in identity_roles && dest_domains.exists(d, dest_domain_matches(d, "*.ngrok.io"))`, false},
+		{`withinHours(`, false},
+	}
+	for _, tt := range tests {
+		if got := eval.UsesRequestTime(tt.expr); got != tt.want {
+			t.Errorf("UsesRequestTime(%s) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package cel
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/policy"
+)
+
+// SchemaVariable describes a variable available to policy conditions.
+type SchemaVariable struct {
+	// Name is the variable name, or the key for a field of a map variable.
+	Name string `json:"name"`
+	// Type is the CEL type, e.g. "string", "list(string)".
+	Type string `json:"type"`
+	// Group is the category of the variable, e.g. "action", "destination".
+	Group string `json:"group,omitempty"`
+	// Description explains what the variable holds.
+	Description string `json:"description"`
+	// Example is a condition using the variable.
+	Example string `json:"example,omitempty"`
+	// GoField is the policy.EvaluationContext field the value comes from.
+	GoField string `json:"go_field,omitempty"`
+	// GoType is the Go type of GoField.
+	GoType string `json:"go_type,omitempty"`
+	// Fields are the keys of map variables with a fixed structure, or of
+	// the entries of such lists.
+	Fields []SchemaVariable `json:"fields,omitempty"`
+}
+
+// SchemaFunction describes a custom function available to policy conditions.
+type SchemaFunction struct {
+	Name string `json:"name"`
+	// Signatures are the overloads, e.g. "glob(string, string) -> bool".
+	Signatures  []string `json:"signatures"`
+	Description string   `json:"description"`
+	Example     string   `json:"example,omitempty"`
+}
+
+// Schema is the catalog of what policy conditions can reference.
+type Schema struct {
+	Variables []SchemaVariable `json:"variables"`
+	Functions []SchemaFunction `json:"functions"`
+}
+
+// variableDoc documents a variable of the universal environment. field
+// names the policy.EvaluationContext field the variable is built from.
+type variableDoc struct {
+	group       string
+	field       string
+	description string
+	example     string
+	fields      []fieldDoc
+}
+
+// fieldDoc documents a key of a map variable.
+type fieldDoc struct {
+	name        string
+	typ         string
+	field       string
+	description string
+}
+
+// variableDocs documents every variable declared by
+// NewUniversalPolicyEnvironment, in catalog order.
+var variableDocs = []struct {
+	name string
+	doc  variableDoc
+}{
+	{"action_type", variableDoc{"action", "ActionType", `Canonical action type: "tool_call", "http_request", "command_exec", ...`, `action_type == "tool_call"`, nil}},
+	{"action_name", variableDoc{"action", "ActionName", "Name of the tool or action", `action_name.startsWith("write_")`, nil}},
+	{"arguments", variableDoc{"action", "ToolArguments", "Arguments of the action", `arguments.path.startsWith("/etc/")`, nil}},
+	{"tool_name", variableDoc{"action", "ToolName", "Name of the tool; backward-compatible alias for action_name", `tool_name == "read_file"`, nil}},
+	{"tool_args", variableDoc{"action", "ToolArguments", "Backward-compatible alias for arguments", `"path" in tool_args`, nil}},
+
+	{"identity_id", variableDoc{"identity", "IdentityID", "ID of the calling identity", `identity_id == "id-1"`, nil}},
+	{"identity_name", variableDoc{"identity", "IdentityName", "Name of the calling identity", `identity_name == "claude-prod"`, nil}},
+	{"identity_roles", variableDoc{"identity", "UserRoles", "Roles of the calling identity", `"admin" in identity_roles`, nil}},
+	{"user_roles", variableDoc{"identity", "UserRoles", "Backward-compatible alias for identity_roles", `"reader" in user_roles`, nil}},
+	{"session_id", variableDoc{"identity", "SessionID", "Current session identifier", `session_id != ""`, nil}},
+	{"request_time", variableDoc{"identity", "RequestTime", "When the request was received", `request_time.getHours() >= 18`, nil}},
+
+	{"protocol", variableDoc{"context", "Protocol", `Originating protocol: "mcp", "http", "websocket", "runtime"`, `protocol == "http"`, nil}},
+	{"framework", variableDoc{"context", "Framework", `Agent framework, e.g. "crewai", "autogen"; empty when unknown`, `framework == "crewai"`, nil}},
+	{"gateway", variableDoc{"context", "Gateway", `Gateway that received the request: "mcp-gateway", "http-gateway", "runtime"`, `gateway == "mcp-gateway"`, nil}},
+
+	{"dest_url", variableDoc{"destination", "DestURL", "Full destination URL", `dest_url.startsWith("http://")`, nil}},
+	{"dest_domain", variableDoc{"destination", "DestDomain", "Destination domain", `dest_domain_matches(dest_domain, "*.evil.com")`, nil}},
+	{"dest_ip", variableDoc{"destination", "DestIP", "Destination IP: the host when it is an IP address, or its address when dns.resolve_destinations is on", `dest_ip_in_cidr(dest_ip, "10.0.0.0/8")`, nil}},
+	{"dest_port", variableDoc{"destination", "DestPort", "Destination port; the default port of the scheme when the URL names none", `dest_port_in_range(dest_port, "8000-8999")`, nil}},
+	{"dest_scheme", variableDoc{"destination", "DestScheme", `Destination scheme: "http", "https", "ws", "wss"`, `dest_scheme != "https"`, nil}},
+	{"dest_path", variableDoc{"destination", "DestPath", "URL path or file path", `dest_path.startsWith("/admin")`, nil}},
+	{"dest_command", variableDoc{"destination", "DestCommand", "Command being executed", `dest_command.contains("rm -rf")`, nil}},
+	{"dest_urls", variableDoc{"destination", "DestURLs", "Every URL found in the arguments", `dest_urls.exists(u, u.startsWith("http://"))`, nil}},
+	{"dest_domains", variableDoc{"destination", "DestDomains", "Distinct domains of dest_urls", `dest_domains.exists(d, d.endsWith(".ru"))`, nil}},
+	{"dest_country", variableDoc{"destination", "DestCountry", "ISO 3166-1 alpha-2 country of dest_ip; empty without a GeoIP database or when not located", `dest_country in ["RU", "KP"]`, nil}},
+	{"dest_asn", variableDoc{"destination", "DestASN", "Autonomous system number of dest_ip; 0 without a GeoIP ASN database or when not located", `dest_asn == 14061`, nil}},
+	{"dest_asn_org", variableDoc{"destination", "DestASNOrg", "Organization of the autonomous system of dest_ip", `dest_asn_org.contains("DigitalOcean")`, nil}},
+
+	{"payload_bytes", variableDoc{"payload", "PayloadBytes", "Size in bytes of the request as received; 0 when unknown", `payload_bytes > 10485760`, nil}},
+
+	{"session_call_count", variableDoc{"session", "SessionCallCount", "Tool calls in the session", `session_call_count > 100`, nil}},
+	{"session_write_count", variableDoc{"session", "SessionWriteCount", "Write calls in the session", `session_write_count > 20`, nil}},
+	{"session_delete_count", variableDoc{"session", "SessionDeleteCount", "Delete calls in the session", `session_delete_count > 5`, nil}},
+	{"session_duration_seconds", variableDoc{"session", "SessionDurationSeconds", "Seconds since the session started", `session_duration_seconds > 3600`, nil}},
+	{"session_cumulative_cost", variableDoc{"session", "SessionCumulativeCost", "Running cost of the session", `session_cumulative_cost > 10.0`, nil}},
+	{"session_action_history", variableDoc{"session", "SessionActionHistory", "Calls of the session, oldest first", `session_count(session_action_history, "write") > 10`, []fieldDoc{
+		{"tool_name", "string", "ToolName", "Tool name without namespace prefix"},
+		{"call_type", "string", "CallType", `"read", "write", "delete" or "other"`},
+		{"timestamp", "timestamp", "Timestamp", "When the call was made"},
+		{"arg_keys", "list(string)", "ArgKeys", "Argument keys of the call"},
+	}}},
+	{"session_action_set", variableDoc{"session", "SessionActionSet", "Tools called in the session", `session_has_action(session_action_set, "read_file")`, nil}},
+	{"session_arg_key_set", variableDoc{"session", "SessionArgKeySet", "Argument keys used in the session", `session_has_arg(session_arg_key_set, "content")`, nil}},
+
+	{"action_tainted", variableDoc{"scanning", "ActionTainted", "Content flagged in an earlier result of the session reappears in the arguments or destination", `action_tainted && action_name == "send_email"`, nil}},
+	{"taint_sources", variableDoc{"scanning", "TaintSources", "Tools whose flagged results reappear", `"read_file" in taint_sources`, nil}},
+	{"anomaly", variableDoc{"scanning", "", "Deviation of the arguments from the tool's profile", `!anomaly.warming_up && anomaly.score > 0.8`, []fieldDoc{
+		{"score", "double", "AnomalyScore", "From 0 (usual) to 1"},
+		{"reasons", "list(string)", "AnomalyReasons", "Deviating arguments"},
+		{"warming_up", "bool", "AnomalyWarmingUp", "The profile is still learning"},
+	}}},
+
+	{"user_deny_rate", variableDoc{"health", "UserDenyRate", "Deny rate of the identity over the last 24h, from 0 to 1", `user_deny_rate > 0.5`, nil}},
+	{"user_drift_score", variableDoc{"health", "UserDriftScore", "Behavioral drift score of the identity, from 0 to 1", `user_drift_score > 0.7`, nil}},
+	{"user_violation_count", variableDoc{"health", "UserViolationCount", "Policy violations of the identity in the last 24h", `user_violation_count > 10`, nil}},
+	{"user_total_calls", variableDoc{"health", "UserTotalCalls", "Calls of the identity in the last 24h", `user_total_calls > 1000`, nil}},
+	{"user_error_rate", variableDoc{"health", "UserErrorRate", "Error rate of the identity over the last 24h, from 0 to 1", `user_error_rate > 0.3`, nil}},
+
+	{"vars", variableDoc{"labels", "Variables", "Policy variables managed from the admin API", `dest_domain in vars.allowed_domains`, nil}},
+	{"enrichment", variableDoc{"labels", "Enrichment", "Attributes from the policy enrichment endpoint", `enrichment.department == "finance"`, nil}},
+}
+
+// functionDocs documents the custom functions of NewUniversalPolicyEnvironment.
+var functionDocs = map[string]struct{ description, example string }{
+	"glob":                      {"Glob match on a name", `glob("read_*", action_name)`},
+	"dest_ip_in_cidr":           {"CIDR range check on an IP, against one range or any of a list", `dest_ip_in_cidr(dest_ip, ["10.0.0.0/8", "192.168.0.0/16"])`},
+	"dest_port_in_range":        {"Port is in a comma-separated list of ports and inclusive ranges", `!dest_port_in_range(dest_port, "80,443,8000-8999")`},
+	"dest_domain_matches":       {"Wildcard match on a domain; *. matches any depth of subdomains", `dest_domain_matches(dest_domain, "*.evil.com")`},
+	"action_arg":                {"Argument by key, or null", `action_arg(arguments, "url") == "https://example.com"`},
+	"action_arg_contains":       {"Any string argument contains a substring", `action_arg_contains(arguments, "password")`},
+	"arg":                       {"Value at a dotted path into the arguments, or null; numeric segments index lists", `arg("options.recursive") == true`},
+	"matchesGlob":               {"Path glob: * and ? stay within a segment, ** crosses segments", `matchesGlob(arg("path"), "/home/**/.ssh/*")`},
+	"cidrContains":              {"CIDR range check on any IP string", `cidrContains(arg("host"), "10.0.0.0/8")`},
+	"isWeekend":                 {"Request made on Saturday or Sunday", `isWeekend()`},
+	"withinHours":               {"Request made in a daily HH:MM window, in the server's or the given IANA time zone; windows may cross midnight", `!withinHours("09:00", "18:00", "Europe/Rome")`},
+	"argsSize":                  {"Size of the arguments encoded as JSON, in bytes", `argsSize() > 65536`},
+	"argsDepth":                 {"Nesting depth of the arguments; flat arguments are 1", `argsDepth() > 8`},
+	"session_count":             {"Calls of a call type in the session", `session_count(session_action_history, "delete") > 3`},
+	"session_count_for":         {"Calls of a tool in the session", `session_count_for(session_action_history, "read_file") > 50`},
+	"session_count_window":      {"Calls of a tool in the last N seconds", `session_count_window(session_action_history, "write_file", 60) > 10`},
+	"session_has_action":        {"The tool was called in the session", `session_has_action(session_action_set, "read_file")`},
+	"session_has_arg":           {"The argument key was used in the session", `session_has_arg(session_arg_key_set, "content")`},
+	"session_has_arg_in":        {"The argument key was used with the tool in the session", `session_has_arg_in(session_action_history, "content", "write_file")`},
+	"session_sequence":          {"The first tool was called before the second", `session_sequence(session_action_history, "read_file", "send_email")`},
+	"session_time_since_action": {"Seconds since the tool was last called, or -1", `session_time_since_action(session_action_history, "send_email") < 60`},
+}
+
+// schemaMacroForms are the short forms of the helpers that read the request
+// through activationMacro.
+var schemaMacroForms = map[string][]string{
+	"arg":         {"arg(string) -> dyn"},
+	"argsSize":    {"argsSize() -> int"},
+	"argsDepth":   {"argsDepth() -> int"},
+	"isWeekend":   {"isWeekend() -> bool"},
+	"withinHours": {"withinHours(string, string) -> bool", "withinHours(string, string, string) -> bool"},
+}
+
+var (
+	schemaOnce   sync.Once
+	schemaResult *Schema
+	schemaErr    error
+)
+
+// PolicySchema returns the catalog of the variables and custom functions of
+// the universal policy environment. Types are read from the environment
+// declarations and Go fields from policy.EvaluationContext, so the catalog
+// follows the code.
+func PolicySchema() (*Schema, error) {
+	schemaOnce.Do(func() {
+		schemaResult, schemaErr = buildPolicySchema()
+	})
+	return schemaResult, schemaErr
+}
+
+func buildPolicySchema() (*Schema, error) {
+	env, err := NewUniversalPolicyEnvironment()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CEL environment: %w", err)
+	}
+	ctxType := reflect.TypeOf(policy.EvaluationContext{})
+
+	declared := make(map[string]*types.Type)
+	for _, v := range env.Variables() {
+		declared[v.Name()] = v.Type()
+	}
+
+	schema := &Schema{}
+	for _, entry := range variableDocs {
+		t, ok := declared[entry.name]
+		if !ok {
+			return nil, fmt.Errorf("documented variable %q is not declared", entry.name)
+		}
+		delete(declared, entry.name)
+		v := SchemaVariable{
+			Name:        entry.name,
+			Type:        schemaTypeName(t),
+			Group:       entry.doc.group,
+			Description: entry.doc.description,
+			Example:     entry.doc.example,
+		}
+		if entry.doc.field != "" {
+			if v.GoField, v.GoType, err = goField(ctxType, entry.doc.field); err != nil {
+				return nil, err
+			}
+		}
+		fieldType := ctxType
+		if entry.name == "session_action_history" {
+			fieldType = reflect.TypeOf(policy.SessionActionRecord{})
+		}
+		for _, f := range entry.doc.fields {
+			sf := SchemaVariable{Name: f.name, Type: f.typ, Description: f.description}
+			if sf.GoField, sf.GoType, err = goField(fieldType, f.field); err != nil {
+				return nil, err
+			}
+			v.Fields = append(v.Fields, sf)
+		}
+		schema.Variables = append(schema.Variables, v)
+	}
+	if len(declared) > 0 {
+		return nil, fmt.Errorf("declared variables are not documented: %s", strings.Join(sortedTypeKeys(declared), ", "))
+	}
+
+	functions := env.Functions()
+	for name, doc := range functionDocs {
+		fn, ok := functions[name]
+		if !ok {
+			return nil, fmt.Errorf("documented function %q is not declared", name)
+		}
+		f := SchemaFunction{Name: name, Description: doc.description, Example: doc.example}
+		f.Signatures = append(f.Signatures, schemaMacroForms[name]...)
+		for _, o := range fn.OverloadDecls() {
+			args := make([]string, len(o.ArgTypes()))
+			for i, a := range o.ArgTypes() {
+				args[i] = schemaTypeName(a)
+			}
+			f.Signatures = append(f.Signatures,
+				fmt.Sprintf("%s(%s) -> %s", name, strings.Join(args, ", "), schemaTypeName(o.ResultType())))
+		}
+		schema.Functions = append(schema.Functions, f)
+	}
+	sort.Slice(schema.Functions, func(i, j int) bool {
+		return schema.Functions[i].Name < schema.Functions[j].Name
+	})
+	return schema, nil
+}
+
+// goField returns the qualified name and Go type of the named field of t.
+func goField(t reflect.Type, name string) (string, string, error) {
+	f, ok := t.FieldByName(name)
+	if !ok {
+		return "", "", fmt.Errorf("%s has no field %s", t.Name(), name)
+	}
+	return t.Name() + "." + f.Name, f.Type.String(), nil
+}
+
+// schemaTypeName returns the name of t as written in CEL expressions.
+func schemaTypeName(t *types.Type) string {
+	switch {
+	case t.IsExactType(cel.TimestampType):
+		return "timestamp"
+	case t.IsExactType(cel.DurationType):
+		return "duration"
+	}
+	return t.String()
+}
+
+func sortedTypeKeys(m map[string]*types.Type) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
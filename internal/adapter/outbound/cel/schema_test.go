@@ -0,0 +1,79 @@
+package cel
+
+import (
+	"testing"
+)
+
+func TestPolicySchema(t *testing.T) {
+	schema, err := PolicySchema()
+	if err != nil {
+		t.Fatalf("PolicySchema() error: %v", err)
+	}
+
+	env, err := NewUniversalPolicyEnvironment()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schema.Variables) != len(env.Variables()) {
+		t.Errorf("schema has %d variables, environment declares %d", len(schema.Variables), len(env.Variables()))
+	}
+
+	vars := make(map[string]SchemaVariable)
+	for _, v := range schema.Variables {
+		vars[v.Name] = v
+	}
+	tests := []struct {
+		name, typ, goField, goType string
+	}{
+		{"action_name", "string", "EvaluationContext.ActionName", "string"},
+		{"arguments", "map(string, dyn)", "EvaluationContext.ToolArguments", "map[string]interface {}"},
+		{"request_time", "timestamp", "EvaluationContext.RequestTime", "time.Time"},
+		{"dest_port", "int", "EvaluationContext.DestPort", "int"},
+		{"dest_urls", "list(string)", "EvaluationContext.DestURLs", "[]string"},
+		{"payload_bytes", "int", "EvaluationContext.PayloadBytes", "int64"},
+		{"anomaly", "map(string, dyn)", "", ""},
+	}
+	for _, tt := range tests {
+		v, ok := vars[tt.name]
+		if !ok {
+			t.Errorf("%s missing from the schema", tt.name)
+			continue
+		}
+		if v.Type != tt.typ || v.GoField != tt.goField || v.GoType != tt.goType {
+			t.Errorf("%s = {%s %s %s}, want {%s %s %s}", tt.name, v.Type, v.GoField, v.GoType, tt.typ, tt.goField, tt.goType)
+		}
+	}
+	if f := vars["anomaly"].Fields; len(f) != 3 || f[0].GoField != "EvaluationContext.AnomalyScore" {
+		t.Errorf("anomaly fields = %+v", f)
+	}
+	if f := vars["session_action_history"].Fields; len(f) != 4 || f[0].GoField != "SessionActionRecord.ToolName" {
+		t.Errorf("session_action_history fields = %+v", f)
+	}
+
+	for _, f := range schema.Functions {
+		if f.Name == "argsSize" && (len(f.Signatures) != 2 || f.Signatures[0] != "argsSize() -> int") {
+			t.Errorf("argsSize signatures = %v", f.Signatures)
+		}
+	}
+}
+
+func TestPolicySchema_ExamplesCompile(t *testing.T) {
+	schema, err := PolicySchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	eval, err := NewEvaluator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range schema.Variables {
+		if err := eval.ValidateExpression(v.Example); err != nil {
+			t.Errorf("%s example %q: %v", v.Name, v.Example, err)
+		}
+	}
+	for _, f := range schema.Functions {
+		if err := eval.ValidateExpression(f.Example); err != nil {
+			t.Errorf("%s example %q: %v", f.Name, f.Example, err)
+		}
+	}
+}
@@ -1,15 +1,24 @@
 package cel
 
 import (
+	"encoding/json"
+	"fmt"
 	"net"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/cel-go/cel"
+	celast "github.com/google/cel-go/common/ast"
 	"github.com/google/cel-go/common/types"
 	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
 	"github.com/google/cel-go/ext"
+	"github.com/google/cel-go/parser"
 
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/policy"
 )
@@ -18,8 +27,13 @@ import (
 // and custom functions for cross-protocol policy evaluation. It includes:
 //   - Backward-compatible variables: tool_name, tool_args, user_roles, session_id, identity_id, identity_name, request_time
 //   - Universal variables: action_type, action_name, protocol, framework, gateway, arguments, identity_roles
-//   - Destination variables: dest_url, dest_domain, dest_ip, dest_port, dest_scheme, dest_path, dest_command
-//   - Custom functions: glob, dest_ip_in_cidr, dest_domain_matches, action_arg, action_arg_contains
+//   - Anomaly variable: anomaly (score, reasons, warming_up)
+//   - Destination variables: dest_url, dest_domain, dest_ip, dest_port, dest_scheme, dest_path, dest_command,
+//     dest_urls, dest_domains, dest_country, dest_asn, dest_asn_org
+//   - Payload variable: payload_bytes
+//   - Custom functions: glob, dest_ip_in_cidr, dest_port_in_range, dest_domain_matches, action_arg,
+//     action_arg_contains
+//   - Argument helpers: arg, matchesGlob, cidrContains, isWeekend, withinHours, argsSize, argsDepth
 func NewUniversalPolicyEnvironment() (*cel.Env, error) {
 	return cel.NewEnv(
 		// Standard extensions
@@ -52,6 +66,14 @@ func NewUniversalPolicyEnvironment() (*cel.Env, error) {
 		cel.Variable("dest_scheme", cel.StringType),
 		cel.Variable("dest_path", cel.StringType),
 		cel.Variable("dest_command", cel.StringType),
+		cel.Variable("dest_urls", cel.ListType(cel.StringType)),
+		cel.Variable("dest_domains", cel.ListType(cel.StringType)),
+		cel.Variable("dest_country", cel.StringType),
+		cel.Variable("dest_asn", cel.IntType),
+		cel.Variable("dest_asn_org", cel.StringType),
+
+		// === Payload size ===
+		cel.Variable("payload_bytes", cel.IntType),
 
 		// === Session usage variables (Phase 15: Budget & Quota) ===
 		cel.Variable("session_call_count", cel.IntType),
@@ -72,6 +94,19 @@ func NewUniversalPolicyEnvironment() (*cel.Env, error) {
 		cel.Variable("session_action_set", cel.MapType(cel.StringType, cel.BoolType)),
 		cel.Variable("session_arg_key_set", cel.MapType(cel.StringType, cel.BoolType)),
 
+		// === Taint tracking variables ===
+		cel.Variable("action_tainted", cel.BoolType),
+		cel.Variable("taint_sources", cel.ListType(cel.StringType)),
+
+		// === Argument anomaly detection (score, reasons, warming_up) ===
+		cel.Variable("anomaly", cel.MapType(cel.StringType, cel.DynType)),
+
+		// === Policy variables (managed from the admin API) ===
+		cel.Variable("vars", cel.MapType(cel.StringType, cel.DynType)),
+
+		// === Enrichment (attributes from the policy enrichment endpoint) ===
+		cel.Variable("enrichment", cel.MapType(cel.StringType, cel.DynType)),
+
 		// === Custom functions ===
 
 		// glob: existing glob pattern matching for tool names
@@ -110,17 +145,49 @@ func NewUniversalPolicyEnvironment() (*cel.Env, error) {
 						return types.Bool(false)
 					}
 
-					ip := net.ParseIP(ipStr)
-					if ip == nil {
+					return types.Bool(ipInCIDR(ipStr, cidrStr))
+				}),
+			),
+			// Usage: dest_ip_in_cidr(dest_ip, ["10.0.0.0/8", "192.168.0.0/16"])
+			cel.Overload("dest_ip_in_cidr_string_list",
+				[]*cel.Type{cel.StringType, cel.ListType(cel.StringType)},
+				cel.BoolType,
+				cel.BinaryBinding(func(ipVal, cidrsVal ref.Val) ref.Val {
+					ipStr, ok := ipVal.Value().(string)
+					if !ok {
 						return types.Bool(false)
 					}
-
-					_, network, err := net.ParseCIDR(cidrStr)
-					if err != nil {
+					cidrs, ok := cidrsVal.(traits.Lister)
+					if !ok {
 						return types.Bool(false)
 					}
+					for it := cidrs.Iterator(); it.HasNext() == types.True; {
+						if cidrStr, ok := it.Next().Value().(string); ok && ipInCIDR(ipStr, cidrStr) {
+							return types.Bool(true)
+						}
+					}
+					return types.Bool(false)
+				}),
+			),
+		),
 
-					return types.Bool(network.Contains(ip))
+		// dest_port_in_range: checks if a port is in a comma-separated list
+		// of ports and inclusive ranges.
+		// Usage: dest_port_in_range(dest_port, "80,443,8000-8999")
+		cel.Function("dest_port_in_range",
+			cel.Overload("dest_port_in_range_int_string",
+				[]*cel.Type{cel.IntType, cel.StringType},
+				cel.BoolType,
+				cel.BinaryBinding(func(portVal, rangesVal ref.Val) ref.Val {
+					port, ok := portVal.Value().(int64)
+					if !ok {
+						return types.Bool(false)
+					}
+					ranges, ok := rangesVal.Value().(string)
+					if !ok {
+						return types.Bool(false)
+					}
+					return types.Bool(portInRanges(port, ranges))
 				}),
 			),
 		),
@@ -217,6 +284,149 @@ func NewUniversalPolicyEnvironment() (*cel.Env, error) {
 			),
 		),
 
+		// === Argument helpers ===
+		// The zero-argument forms read the request through macros:
+		// arg("a.b") is arg(arguments, "a.b") and isWeekend() is
+		// isWeekend(request_time).
+		cel.Macros(
+			activationMacro("arg", "arguments", 1),
+			activationMacro("argsSize", "arguments", 0),
+			activationMacro("argsDepth", "arguments", 0),
+			activationMacro("isWeekend", "request_time", 0),
+			activationMacro("withinHours", "request_time", 2),
+			activationMacro("withinHours", "request_time", 3),
+		),
+
+		// arg: value at a dotted path into the arguments, or null when the
+		// path does not exist. Numeric segments index lists.
+		// Usage: arg("options.recursive") == true, arg("files.0.path")
+		cel.Function("arg",
+			cel.Overload("arg_map_string",
+				[]*cel.Type{cel.MapType(cel.StringType, cel.DynType), cel.StringType},
+				cel.DynType,
+				cel.BinaryBinding(func(mapVal, pathVal ref.Val) ref.Val {
+					p, ok := pathVal.Value().(string)
+					if !ok {
+						return types.NullValue
+					}
+					v, found := argAtPath(nativeArgs(mapVal), p)
+					if !found {
+						return types.NullValue
+					}
+					return types.DefaultTypeAdapter.NativeToValue(v)
+				}),
+			),
+		),
+
+		// matchesGlob: path-aware glob match. "*" and "?" stay within one
+		// path segment, "**" matches across "/".
+		// Usage: matchesGlob(arg("path"), "/home/**/.ssh/*")
+		cel.Function("matchesGlob",
+			cel.Overload("matchesGlob_string_string",
+				[]*cel.Type{cel.StringType, cel.StringType},
+				cel.BoolType,
+				cel.BinaryBinding(func(sVal, patternVal ref.Val) ref.Val {
+					s, ok := sVal.Value().(string)
+					if !ok {
+						return types.Bool(false)
+					}
+					pattern, ok := patternVal.Value().(string)
+					if !ok {
+						return types.Bool(false)
+					}
+					return types.Bool(matchesGlob(s, pattern))
+				}),
+			),
+		),
+
+		// cidrContains: checks if an IP taken from anywhere (e.g. an argument)
+		// is within a CIDR range. Invalid input is false.
+		// Usage: cidrContains(arg("host"), "10.0.0.0/8")
+		cel.Function("cidrContains",
+			cel.Overload("cidrContains_string_string",
+				[]*cel.Type{cel.StringType, cel.StringType},
+				cel.BoolType,
+				cel.BinaryBinding(func(ipVal, cidrVal ref.Val) ref.Val {
+					ipStr, ok := ipVal.Value().(string)
+					if !ok {
+						return types.Bool(false)
+					}
+					cidrStr, ok := cidrVal.Value().(string)
+					if !ok {
+						return types.Bool(false)
+					}
+					return types.Bool(ipInCIDR(ipStr, cidrStr))
+				}),
+			),
+		),
+
+		// isWeekend: whether a timestamp falls on a Saturday or Sunday in
+		// its own time zone (the server's for request_time).
+		// Usage: isWeekend(), isWeekend(request_time)
+		cel.Function("isWeekend",
+			cel.Overload("isWeekend_timestamp",
+				[]*cel.Type{cel.TimestampType},
+				cel.BoolType,
+				cel.UnaryBinding(func(tsVal ref.Val) ref.Val {
+					ts, ok := tsVal.Value().(time.Time)
+					if !ok {
+						return types.Bool(false)
+					}
+					day := ts.Weekday()
+					return types.Bool(day == time.Saturday || day == time.Sunday)
+				}),
+			),
+		),
+
+		// withinHours: whether a timestamp falls in a daily window from
+		// "HH:MM" (inclusive) to "HH:MM" (exclusive), in the server's time
+		// zone or the named IANA one. Windows may cross midnight.
+		// Usage: withinHours("09:00", "18:00"), withinHours("22:00", "06:00", "Europe/Rome")
+		cel.Function("withinHours",
+			cel.Overload("withinHours_timestamp_string_string",
+				[]*cel.Type{cel.TimestampType, cel.StringType, cel.StringType},
+				cel.BoolType,
+				cel.FunctionBinding(func(args ...ref.Val) ref.Val {
+					return types.Bool(withinHoursVals(args[0], args[1], args[2], nil))
+				}),
+			),
+			cel.Overload("withinHours_timestamp_string_string_string",
+				[]*cel.Type{cel.TimestampType, cel.StringType, cel.StringType, cel.StringType},
+				cel.BoolType,
+				cel.FunctionBinding(func(args ...ref.Val) ref.Val {
+					return types.Bool(withinHoursVals(args[0], args[1], args[2], args[3]))
+				}),
+			),
+		),
+
+		// argsSize: size in bytes of the arguments encoded as JSON.
+		// Usage: argsSize() > 65536
+		cel.Function("argsSize",
+			cel.Overload("argsSize_map",
+				[]*cel.Type{cel.MapType(cel.StringType, cel.DynType)},
+				cel.IntType,
+				cel.UnaryBinding(func(mapVal ref.Val) ref.Val {
+					data, err := json.Marshal(nativeArgs(mapVal))
+					if err != nil {
+						return types.Int(0)
+					}
+					return types.Int(len(data))
+				}),
+			),
+		),
+
+		// argsDepth: nesting depth of the arguments. Flat arguments are 1.
+		// Usage: argsDepth() > 8
+		cel.Function("argsDepth",
+			cel.Overload("argsDepth_map",
+				[]*cel.Type{cel.MapType(cel.StringType, cel.DynType)},
+				cel.IntType,
+				cel.UnaryBinding(func(mapVal ref.Val) ref.Val {
+					return types.Int(valueDepth(nativeArgs(mapVal)))
+				}),
+			),
+		),
+
 		// === Session history functions (Phase 17: Session-Aware Policies) ===
 
 		// session_count: count actions by CallType ("read", "write", "delete", "other") in the full session history.
@@ -497,6 +707,278 @@ func domainMatchesWildcard(domain, pattern string) bool {
 	return matched
 }
 
+// activationMacro expands function(args...) to function(variable, args...),
+// so helpers can read a request variable without naming it.
+func activationMacro(function, variable string, argCount int) cel.Macro {
+	return cel.GlobalMacro(function, argCount,
+		func(eh parser.ExprHelper, _ celast.Expr, args []celast.Expr) (celast.Expr, *cel.Error) {
+			return eh.NewCall(function, append([]celast.Expr{eh.NewIdent(variable)}, args...)...), nil
+		})
+}
+
+// nativeArgs returns the Go value of a map or list. Values from the
+// activation are returned as they are; maps and lists built in the
+// expression are converted to map[string]any and []any.
+func nativeArgs(val ref.Val) any {
+	switch v := val.(type) {
+	case traits.Mapper:
+		if m, ok := v.Value().(map[string]any); ok {
+			return m
+		}
+		out := make(map[string]any)
+		for it := v.Iterator(); it.HasNext() == types.True; {
+			key := it.Next()
+			out[fmt.Sprint(key.Value())] = nativeArgs(v.Get(key))
+		}
+		return out
+	case traits.Lister:
+		if l, ok := v.Value().([]any); ok {
+			return l
+		}
+		size, _ := v.Size().(types.Int)
+		out := make([]any, int(size))
+		for i := range out {
+			out[i] = nativeArgs(v.Get(types.Int(i)))
+		}
+		return out
+	}
+	return val.Value()
+}
+
+// argAtPath walks a dotted path through nested maps and lists. Numeric
+// segments index lists.
+func argAtPath(v any, path string) (any, bool) {
+	for _, seg := range strings.Split(path, ".") {
+		rv := reflect.ValueOf(v)
+		switch rv.Kind() {
+		case reflect.Map:
+			if rv.Type().Key().Kind() != reflect.String {
+				return nil, false
+			}
+			elem := rv.MapIndex(reflect.ValueOf(seg).Convert(rv.Type().Key()))
+			if !elem.IsValid() {
+				return nil, false
+			}
+			v = elem.Interface()
+		case reflect.Slice, reflect.Array:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= rv.Len() {
+				return nil, false
+			}
+			v = rv.Index(i).Interface()
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// valueDepth returns the nesting depth of maps and lists in v; scalars are 0.
+func valueDepth(v any) int {
+	rv := reflect.ValueOf(v)
+	deepest := 0
+	switch rv.Kind() {
+	case reflect.Map:
+		iter := rv.MapRange()
+		for iter.Next() {
+			deepest = max(deepest, valueDepth(iter.Value().Interface()))
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			deepest = max(deepest, valueDepth(rv.Index(i).Interface()))
+		}
+	default:
+		return 0
+	}
+	return deepest + 1
+}
+
+// ipInCIDR reports whether ipStr is a valid IP within cidrStr.
+func ipInCIDR(ipStr, cidrStr string) bool {
+	ip := net.ParseIP(ipStr)
+	if ip == nil {
+		return false
+	}
+	_, network, err := net.ParseCIDR(cidrStr)
+	if err != nil {
+		return false
+	}
+	return network.Contains(ip)
+}
+
+// portInRanges reports whether port is in ranges, a comma-separated list
+// of ports and inclusive ranges such as "80,443,8000-8999". Invalid entries
+// match nothing.
+func portInRanges(port int64, ranges string) bool {
+	for _, r := range strings.Split(ranges, ",") {
+		lo, hi, isRange := strings.Cut(strings.TrimSpace(r), "-")
+		first, err := strconv.ParseInt(strings.TrimSpace(lo), 10, 32)
+		if err != nil {
+			continue
+		}
+		last := first
+		if isRange {
+			if last, err = strconv.ParseInt(strings.TrimSpace(hi), 10, 32); err != nil {
+				continue
+			}
+		}
+		if port >= first && port <= last {
+			return true
+		}
+	}
+	return false
+}
+
+// withinHoursVals unwraps the arguments of withinHours. tzVal is nil for
+// the server's time zone. Invalid arguments make it false.
+func withinHoursVals(tsVal, fromVal, toVal, tzVal ref.Val) bool {
+	ts, ok := tsVal.Value().(time.Time)
+	if !ok {
+		return false
+	}
+	from, ok1 := fromVal.Value().(string)
+	to, ok2 := toVal.Value().(string)
+	if !ok1 || !ok2 {
+		return false
+	}
+	if tzVal != nil {
+		tz, ok := tzVal.Value().(string)
+		if !ok {
+			return false
+		}
+		loc, err := loadLocation(tz)
+		if err != nil {
+			return false
+		}
+		ts = ts.In(loc)
+	} else {
+		ts = ts.Local()
+	}
+	return withinHours(ts, from, to)
+}
+
+// withinHours reports whether the clock time of ts is in [from, to), both
+// "HH:MM". When to is earlier than from the window crosses midnight; from
+// equal to to is the whole day, as in identity access windows.
+func withinHours(ts time.Time, from, to string) bool {
+	start, err := time.Parse("15:04", from)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse("15:04", to)
+	if err != nil {
+		return false
+	}
+	m := ts.Hour()*60 + ts.Minute()
+	lo := start.Hour()*60 + start.Minute()
+	hi := end.Hour()*60 + end.Minute()
+	switch {
+	case lo == hi:
+		return true
+	case lo < hi:
+		return m >= lo && m < hi
+	}
+	return m >= lo || m < hi
+}
+
+// maxLocationCache bounds the loaded time zone cache.
+const maxLocationCache = 64
+
+var (
+	locationCacheMu sync.Mutex
+	locationCache   = make(map[string]*time.Location)
+)
+
+// loadLocation returns the named IANA time zone, cached because loading
+// reads the time zone database.
+func loadLocation(name string) (*time.Location, error) {
+	locationCacheMu.Lock()
+	defer locationCacheMu.Unlock()
+	if loc, ok := locationCache[name]; ok {
+		return loc, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(locationCache) >= maxLocationCache {
+		locationCache = make(map[string]*time.Location)
+	}
+	locationCache[name] = loc
+	return loc, nil
+}
+
+// maxGlobCache bounds the compiled glob cache; patterns can come from
+// arguments, so the cache is dropped when full rather than growing.
+const maxGlobCache = 1024
+
+var (
+	globCacheMu sync.Mutex
+	globCache   = make(map[string]*regexp.Regexp)
+)
+
+// matchesGlob matches s against a path-aware glob pattern. Invalid
+// patterns match nothing.
+func matchesGlob(s, pattern string) bool {
+	globCacheMu.Lock()
+	re, ok := globCache[pattern]
+	globCacheMu.Unlock()
+	if !ok {
+		var err error
+		if re, err = regexp.Compile(globToRegexp(pattern)); err != nil {
+			re = nil
+		}
+		globCacheMu.Lock()
+		if len(globCache) >= maxGlobCache {
+			globCache = make(map[string]*regexp.Regexp)
+		}
+		globCache[pattern] = re
+		globCacheMu.Unlock()
+	}
+	return re != nil && re.MatchString(s)
+}
+
+// globToRegexp translates a glob to an anchored regular expression:
+// "**/" matches zero or more directories, "**" anything, "*" and "?" any
+// characters but "/", and "[...]" (or "[!...]") a character class.
+func globToRegexp(pattern string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			switch {
+			case strings.HasPrefix(pattern[i:], "**/"):
+				b.WriteString("(?:.*/)?")
+				i += 2
+			case strings.HasPrefix(pattern[i:], "**"):
+				b.WriteString(".*")
+				i++
+			default:
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				b.WriteString(regexp.QuoteMeta("["))
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
 // fillDefaults sets default values for universal fields when they are empty.
 // This ensures backward compatibility: legacy code that only populates ToolName,
 // ToolArguments, and UserRoles will still work with universal CEL rules.
@@ -597,6 +1079,14 @@ func BuildUniversalActivation(evalCtx policy.EvaluationContext) map[string]any {
 		"dest_scheme":  evalCtx.DestScheme,
 		"dest_path":    evalCtx.DestPath,
 		"dest_command": evalCtx.DestCommand,
+		"dest_urls":    nonNilStrings(evalCtx.DestURLs),
+		"dest_domains": nonNilStrings(evalCtx.DestDomains),
+		"dest_country": evalCtx.DestCountry,
+		"dest_asn":     evalCtx.DestASN,
+		"dest_asn_org": evalCtx.DestASNOrg,
+
+		// Payload size
+		"payload_bytes": evalCtx.PayloadBytes,
 
 		// Session usage (Phase 15)
 		"session_call_count":       evalCtx.SessionCallCount,
@@ -610,6 +1100,23 @@ func BuildUniversalActivation(evalCtx policy.EvaluationContext) map[string]any {
 		"session_action_set":     buildSessionSet(evalCtx.SessionActionSet),
 		"session_arg_key_set":    buildSessionSet(evalCtx.SessionArgKeySet),
 
+		// Taint tracking
+		"action_tainted": evalCtx.ActionTainted,
+		"taint_sources":  nonNilStrings(evalCtx.TaintSources),
+
+		// Argument anomaly detection
+		"anomaly": map[string]interface{}{
+			"score":      evalCtx.AnomalyScore,
+			"reasons":    nonNilStrings(evalCtx.AnomalyReasons),
+			"warming_up": evalCtx.AnomalyWarmingUp,
+		},
+
+		// Policy variables
+		"vars": buildVariables(evalCtx.Variables),
+
+		// Enrichment
+		"enrichment": buildVariables(evalCtx.Enrichment),
+
 		// Agent Health (Upgrade 11)
 		"user_deny_rate":       evalCtx.UserDenyRate,
 		"user_drift_score":     evalCtx.UserDriftScore,
@@ -622,6 +1129,15 @@ func BuildUniversalActivation(evalCtx policy.EvaluationContext) map[string]any {
 	}
 }
 
+// buildVariables returns a non-nil policy variable or enrichment map for
+// CEL evaluation.
+func buildVariables(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return map[string]interface{}{}
+	}
+	return m
+}
+
 // buildFrameworkAttrs returns a non-nil string map for CEL evaluation.
 func buildFrameworkAttrs(m map[string]string) map[string]string {
 	if m == nil {
@@ -629,3 +1145,11 @@ func buildFrameworkAttrs(m map[string]string) map[string]string {
 	}
 	return m
 }
+
+// nonNilStrings returns a non-nil string list for CEL evaluation.
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
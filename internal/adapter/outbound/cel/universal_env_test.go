@@ -1,6 +1,7 @@
 package cel
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -162,6 +163,78 @@ func TestUniversalEnv_DestIPInCIDR(t *testing.T) {
 	})
 }
 
+func TestUniversalEnv_DestIPInCIDRList(t *testing.T) {
+	ctx := baseMCPContext()
+	expr := `dest_ip_in_cidr(dest_ip, ["10.0.0.0/8", "bad", "192.168.0.0/16"])`
+
+	for ip, want := range map[string]bool{
+		"10.1.2.3":    true,
+		"192.168.5.5": true,
+		"172.16.0.1":  false,
+		"":            false,
+	} {
+		ctx.DestIP = ip
+		if got := compileAndEval(t, expr, ctx); got != want {
+			t.Errorf("%q: got %v, want %v", ip, got, want)
+		}
+	}
+
+	// The list can come from a policy variable.
+	ctx.DestIP = "10.1.2.3"
+	ctx.Variables = map[string]interface{}{"private": []interface{}{"10.0.0.0/8"}}
+	if !compileAndEval(t, `dest_ip_in_cidr(dest_ip, vars.private)`, ctx) {
+		t.Error("expected 10.1.2.3 to be in vars.private")
+	}
+}
+
+func TestUniversalEnv_DestGeo(t *testing.T) {
+	ctx := baseMCPContext()
+	ctx.DestCountry = "KP"
+	ctx.DestASN = 64500
+	ctx.DestASNOrg = "Example Net"
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`dest_country in ["RU", "KP"]`, true},
+		{`dest_country == "IT"`, false},
+		{`dest_asn == 64500`, true},
+		{`dest_asn_org.contains("Example")`, true},
+	}
+	for _, tt := range tests {
+		if got := compileAndEval(t, tt.expr, ctx); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+
+	// Destinations that were not located have empty values.
+	if compileAndEval(t, `dest_country in ["RU", "KP"] || dest_asn != 0`, baseMCPContext()) {
+		t.Error("unlocated destination matched a geo rule")
+	}
+}
+
+func TestUniversalEnv_DestPortInRange(t *testing.T) {
+	ctx := baseMCPContext()
+	expr := `dest_port_in_range(dest_port, "80, 443,8000-8999,x-1")`
+
+	for port, want := range map[int]bool{
+		80:   true,
+		443:  true,
+		8000: true,
+		8500: true,
+		8999: true,
+		9000: false,
+		22:   false,
+		0:    false,
+	} {
+		ctx.DestPort = port
+		if got := compileAndEval(t, expr, ctx); got != want {
+			t.Errorf("port %d: got %v, want %v", port, got, want)
+		}
+	}
+}
+
 func TestUniversalEnv_DestDomainMatches(t *testing.T) {
 	ctx := baseMCPContext()
 
@@ -180,6 +253,83 @@ func TestUniversalEnv_DestDomainMatches(t *testing.T) {
 	})
 }
 
+func TestUniversalEnv_Taint(t *testing.T) {
+	ctx := baseMCPContext()
+	if compileAndEval(t, `action_tainted`, ctx) {
+		t.Error("expected action_tainted to default to false")
+	}
+	ctx.ActionTainted = true
+	ctx.TaintSources = []string{"fetch_page"}
+	if !compileAndEval(t, `action_tainted && "fetch_page" in taint_sources`, ctx) {
+		t.Error("expected tainted action with fetch_page source")
+	}
+}
+
+func TestUniversalEnv_PayloadBytes(t *testing.T) {
+	ctx := baseMCPContext()
+	if compileAndEval(t, `payload_bytes > 0`, ctx) {
+		t.Error("expected payload_bytes to default to 0")
+	}
+	ctx.PayloadBytes = 12 * 1024 * 1024
+	if !compileAndEval(t, `payload_bytes > 10485760`, ctx) {
+		t.Error("expected a 12 MiB payload to exceed 10 MiB")
+	}
+}
+
+func TestUniversalEnv_Anomaly(t *testing.T) {
+	ctx := baseMCPContext()
+	if compileAndEval(t, `anomaly.score > 0.0 || anomaly.warming_up || size(anomaly.reasons) > 0`, ctx) {
+		t.Error("expected a zero anomaly by default")
+	}
+	ctx.AnomalyScore = 0.93
+	ctx.AnomalyReasons = []string{`argument "path" points under /etc, usually /workspace`}
+	if !compileAndEval(t, `anomaly.score >= 0.9 && anomaly.reasons[0].contains("/etc")`, ctx) {
+		t.Error("expected the anomaly score and reasons")
+	}
+}
+
+func TestUniversalEnv_Vars(t *testing.T) {
+	ctx := baseMCPContext()
+	if compileAndEval(t, `has(vars.prod_deploy_freeze) && vars.prod_deploy_freeze`, ctx) {
+		t.Error("expected no variables by default")
+	}
+	ctx.Variables = map[string]interface{}{
+		"prod_deploy_freeze": true,
+		"allowed_buckets":    []interface{}{"a", "b"},
+		"max_rows":           int64(100),
+	}
+	if !compileAndEval(t, `vars.prod_deploy_freeze && "b" in vars.allowed_buckets && vars.max_rows == 100`, ctx) {
+		t.Error("expected variables to be visible as vars")
+	}
+}
+
+func TestUniversalEnv_Enrichment(t *testing.T) {
+	ctx := baseMCPContext()
+	if compileAndEval(t, `has(enrichment.team)`, ctx) {
+		t.Error("expected no enrichment attributes by default")
+	}
+	ctx.Enrichment = map[string]interface{}{
+		"team":           "payments",
+		"classification": map[string]interface{}{"level": int64(3)},
+	}
+	if !compileAndEval(t, `enrichment.team == "payments" && enrichment.classification.level >= 3`, ctx) {
+		t.Error("expected enrichment attributes to be visible as enrichment")
+	}
+}
+
+func TestUniversalEnv_DestDomainsList(t *testing.T) {
+	ctx := baseMCPContext()
+	expr := `dest_domains.exists(d, dest_domain_matches(d, "*.pastebin.com"))`
+
+	if compileAndEval(t, expr, ctx) {
+		t.Error("expected no match with no extracted domains")
+	}
+	ctx.DestDomains = []string{"api.github.com", "raw.pastebin.com"}
+	if !compileAndEval(t, expr, ctx) {
+		t.Error("expected a later domain in dest_domains to match")
+	}
+}
+
 func TestDomainMatchesWildcard(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -281,6 +431,76 @@ func TestUniversalEnv_ActionArgContains(t *testing.T) {
 	})
 }
 
+func TestUniversalEnv_ArgumentHelpers(t *testing.T) {
+	ctx := baseMCPContext()
+	ctx.ToolArguments = map[string]interface{}{
+		"path":    "/home/alice/.ssh/id_rsa",
+		"host":    "10.1.2.3",
+		"options": map[string]interface{}{"recursive": true, "depth": float64(3)},
+		"files":   []interface{}{map[string]interface{}{"path": "a.txt"}},
+	}
+	// 2026-10-17 is a Saturday.
+	ctx.RequestTime = time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`arg("path") == "/home/alice/.ssh/id_rsa"`, true},
+		{`arg("options.recursive") == true`, true},
+		{`arg("options.depth") > 2`, true},
+		{`arg("files.0.path") == "a.txt"`, true},
+		{`arg("files.1.path") == null`, true},
+		{`arg("options.missing.deeper") == null`, true},
+		{`arg(tool_args, "host") == "10.1.2.3"`, true},
+		{`arg({"a": {"b": 1}}, "a.b") == 1`, true},
+		{`matchesGlob(arg("path"), "/home/**/.ssh/*")`, true},
+		{`matchesGlob(arg("path"), "/home/*/id_rsa")`, false},
+		{`matchesGlob(arg("path"), "/home/**/id_[!d]sa")`, true},
+		{`matchesGlob("a/b", "a/**/b")`, true},
+		{`matchesGlob("a[b", "a[b")`, true},
+		{`cidrContains(arg("host"), "10.0.0.0/8")`, true},
+		{`cidrContains(arg("host"), "192.168.0.0/16")`, false},
+		{`cidrContains("not-an-ip", "10.0.0.0/8")`, false},
+		{`isWeekend()`, true},
+		{`isWeekend(request_time - duration("24h"))`, false},
+		{`withinHours("09:00", "18:00", "UTC")`, true},
+		{`withinHours("13:00", "18:00", "UTC")`, false},
+		{`withinHours("12:00", "12:30", "UTC")`, true},
+		{`withinHours("11:00", "12:00", "UTC")`, false},
+		{`withinHours("22:00", "13:00", "UTC")`, true},
+		{`withinHours("09:00", "18:00", "America/Los_Angeles")`, false},
+		{`withinHours(request_time + duration("10h"), "22:00", "06:00", "UTC")`, true},
+		{`withinHours("9am", "18:00", "UTC")`, false},
+		{`withinHours("00:00", "23:59") || withinHours("23:59", "00:00")`, true},
+		{`withinHours("08:00", "08:00", "UTC")`, true},
+		{`withinHours("09:00", "18:00", "Not/AZone")`, false},
+		{`argsSize() > 50 && argsSize() < 200`, true},
+		{`argsDepth() == 3`, true},
+		{`argsSize({"a": ["b"]}) == 11`, true},
+		{`argsDepth({}) == 1`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if got := compileAndEval(t, tt.expr, ctx); got != tt.want {
+				t.Errorf("CEL %s: got %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGlobToRegexp_CacheBounded(t *testing.T) {
+	for i := 0; i < maxGlobCache+10; i++ {
+		matchesGlob("x", fmt.Sprintf("p%d*", i))
+	}
+	globCacheMu.Lock()
+	n := len(globCache)
+	globCacheMu.Unlock()
+	if n > maxGlobCache {
+		t.Errorf("glob cache holds %d patterns, want at most %d", n, maxGlobCache)
+	}
+}
+
 func TestUniversalEnv_CrossProtocol_CommandExec(t *testing.T) {
 	ctx := policy.EvaluationContext{
 		ToolName:      "",
@@ -476,6 +696,12 @@ func TestBuildUniversalActivation_NilSafety(t *testing.T) {
 	if activation["identity_roles"] == nil {
 		t.Error("identity_roles should not be nil")
 	}
+	if activation["vars"] == nil {
+		t.Error("vars should not be nil")
+	}
+	if activation["enrichment"] == nil {
+		t.Error("enrichment should not be nil")
+	}
 }
 
 // compileAndEvalInt is a helper that compiles and evaluates a CEL expression
@@ -0,0 +1,244 @@
+package dns
+
+import (
+	"encoding/binary"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxCacheEntries bounds the answers held by a cache.
+	maxCacheEntries = 4096
+	// headerSize is the size of the DNS message header.
+	headerSize = 12
+
+	// Response codes (RFC 1035 4.1.1).
+	rcodeSuccess  = 0
+	rcodeServFail = 2
+	rcodeNXDomain = 3
+	rcodeRefused  = 5
+
+	// typeSOA is the SOA record type.
+	typeSOA = 6
+)
+
+// CacheConfig configures the answer cache of a resolver.
+type CacheConfig struct {
+	// MaxTTL caps how long an answer is cached, whatever its TTL. Zero
+	// disables the cache.
+	MaxTTL time.Duration
+	// NegativeTTL caps how long a name error or an empty answer is cached
+	// (RFC 2308), and is used when the answer carries no SOA record.
+	NegativeTTL time.Duration
+}
+
+// cacheEntry is a cached answer.
+type cacheEntry struct {
+	answer   []byte
+	expires  time.Time
+	negative bool
+}
+
+// cache holds answers by question for their TTL. It is safe for
+// concurrent use.
+type cache struct {
+	cfg CacheConfig
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+func newCache(cfg CacheConfig) *cache {
+	return &cache{cfg: cfg, now: time.Now, entries: make(map[string]cacheEntry)}
+}
+
+// get returns the cached answer to query, with the ID of query, and
+// whether it is a negative answer.
+func (c *cache) get(query []byte) (answer []byte, negative, ok bool) {
+	key, ok := questionKey(query)
+	if !ok {
+		return nil, false, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false, false
+	}
+	if !c.now().Before(e.expires) {
+		delete(c.entries, key)
+		return nil, false, false
+	}
+	answer = append([]byte(nil), e.answer...)
+	copy(answer, query[:2])
+	return answer, e.negative, true
+}
+
+// put caches the answer to query for its TTL, capped by the configuration.
+// Failures, truncated answers and answers with a zero TTL are not cached.
+func (c *cache) put(query, answer []byte) {
+	key, ok := questionKey(query)
+	if !ok {
+		return
+	}
+	ttl, negative, ok := answerTTL(answer)
+	if !ok {
+		return
+	}
+	if negative && (ttl < 0 || ttl > c.cfg.NegativeTTL) {
+		ttl = c.cfg.NegativeTTL
+	}
+	ttl = min(ttl, c.cfg.MaxTTL)
+	if ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= maxCacheEntries {
+		c.evictLocked()
+	}
+	c.entries[key] = cacheEntry{
+		answer:   append([]byte(nil), answer...),
+		expires:  c.now().Add(ttl),
+		negative: negative,
+	}
+}
+
+// evictLocked drops the expired entries, or an arbitrary half of the
+// entries when none has expired.
+func (c *cache) evictLocked() {
+	now := c.now()
+	for k, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	for k := range c.entries {
+		if len(c.entries) < maxCacheEntries/2 {
+			break
+		}
+		delete(c.entries, k)
+	}
+}
+
+// len returns the number of cached answers, expired ones included.
+func (c *cache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// questionKey returns the cache key of the single question of msg: its
+// lowercased name, type and class.
+func questionKey(msg []byte) (string, bool) {
+	if len(msg) < headerSize || binary.BigEndian.Uint16(msg[4:]) != 1 {
+		return "", false
+	}
+	end, ok := skipName(msg, headerSize)
+	if !ok || end+4 > len(msg) {
+		return "", false
+	}
+	// Queries carry no compression pointers, so the name is contiguous.
+	return strings.ToLower(string(msg[headerSize:end])) + string(msg[end:end+4]), true
+}
+
+// answerTTL returns how long answer may be cached: the lowest TTL of its
+// answer records, or for a name error or empty answer the negative TTL of
+// its SOA record (-1 without one). ok is false for failures and truncated
+// answers.
+func answerTTL(answer []byte) (ttl time.Duration, negative, ok bool) {
+	if len(answer) < headerSize || answer[2]&0x02 != 0 { // TC
+		return 0, false, false
+	}
+	rcode := answer[3] & 0x0f
+	qdcount := int(binary.BigEndian.Uint16(answer[4:]))
+	ancount := int(binary.BigEndian.Uint16(answer[6:]))
+	nscount := int(binary.BigEndian.Uint16(answer[8:]))
+	switch {
+	case rcode == rcodeSuccess && ancount > 0:
+	case rcode == rcodeSuccess, rcode == rcodeNXDomain:
+		negative = true
+	default:
+		return 0, false, false
+	}
+
+	i := headerSize
+	for range qdcount {
+		if i, ok = skipName(answer, i); !ok {
+			return 0, false, false
+		}
+		i += 4
+	}
+	minTTL := int64(-1)
+	for n := range ancount + nscount {
+		var rrType uint16
+		var rrTTL uint32
+		var rdata []byte
+		if rrType, rrTTL, rdata, i, ok = readRecord(answer, i); !ok {
+			return 0, false, false
+		}
+		t := int64(rrTTL)
+		if n >= ancount {
+			// Authority section: only the SOA of a negative answer counts.
+			if !negative || rrType != typeSOA || len(rdata) < 4 {
+				continue
+			}
+			// The SOA MINIMUM field ends the record (RFC 2308 section 5).
+			t = min(t, int64(binary.BigEndian.Uint32(rdata[len(rdata)-4:])))
+		}
+		if minTTL < 0 || t < minTTL {
+			minTTL = t
+		}
+	}
+	if minTTL < 0 {
+		if !negative {
+			return 0, false, false
+		}
+		return -1, true, true
+	}
+	return time.Duration(minTTL) * time.Second, negative, true
+}
+
+// readRecord reads the resource record at i of msg and returns its type,
+// TTL, data and the index following it.
+func readRecord(msg []byte, i int) (rrType uint16, ttl uint32, rdata []byte, next int, ok bool) {
+	if i, ok = skipName(msg, i); !ok || i+10 > len(msg) {
+		return 0, 0, nil, 0, false
+	}
+	rrType = binary.BigEndian.Uint16(msg[i:])
+	ttl = binary.BigEndian.Uint32(msg[i+4:])
+	length := int(binary.BigEndian.Uint16(msg[i+8:]))
+	i += 10
+	if i+length > len(msg) {
+		return 0, 0, nil, 0, false
+	}
+	return rrType, ttl, msg[i : i+length], i + length, true
+}
+
+// skipName returns the index following the domain name at i of msg.
+func skipName(msg []byte, i int) (int, bool) {
+	for i < len(msg) {
+		switch l := int(msg[i]); {
+		case l == 0:
+			return i + 1, true
+		case l&0xc0 == 0xc0: // compression pointer ends the name
+			return i + 2, i+2 <= len(msg)
+		case l&0xc0 != 0:
+			return 0, false
+		default:
+			i += l + 1
+		}
+	}
+	return 0, false
+}
+
+// rcode returns the response code of msg.
+func rcode(msg []byte) int {
+	if len(msg) < headerSize {
+		return -1
+	}
+	return int(msg[3] & 0x0f)
+}
@@ -0,0 +1,203 @@
+package dns
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// buildQuery encodes a query for name with the given ID and type.
+func buildQuery(id uint16, name string, qtype uint16) []byte {
+	msg := binary.BigEndian.AppendUint16(nil, id)
+	msg = append(msg, 0x01, 0x00, 0, 1, 0, 0, 0, 0, 0, 0) // RD, QDCOUNT=1
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+	msg = append(msg, 0)
+	msg = binary.BigEndian.AppendUint16(msg, qtype)
+	return binary.BigEndian.AppendUint16(msg, 1) // IN
+}
+
+// buildAnswer answers query with rcode, A records with the given TTLs and,
+// when soa is set, an authority SOA record with TTL soa[0] and MINIMUM
+// soa[1].
+func buildAnswer(query []byte, rcode byte, ttls []uint32, soa []uint32) []byte {
+	end, _ := skipName(query, headerSize)
+	msg := append([]byte(nil), query[:2]...)
+	msg = append(msg, 0x81, 0x80|rcode, 0, 1)
+	msg = binary.BigEndian.AppendUint16(msg, uint16(len(ttls)))
+	nscount := 0
+	if soa != nil {
+		nscount = 1
+	}
+	msg = binary.BigEndian.AppendUint16(msg, uint16(nscount))
+	msg = append(msg, 0, 0)
+	msg = append(msg, query[headerSize:end+4]...)
+	for _, ttl := range ttls {
+		msg = append(msg, 0xc0, 12, 0, 1, 0, 1)
+		msg = binary.BigEndian.AppendUint32(msg, ttl)
+		msg = append(msg, 0, 4, 203, 0, 113, 7)
+	}
+	if soa != nil {
+		msg = append(msg, 0xc0, 12, 0, typeSOA, 0, 1)
+		msg = binary.BigEndian.AppendUint32(msg, soa[0])
+		rdata := []byte{0xc0, 12, 0xc0, 12} // MNAME, RNAME
+		for _, v := range []uint32{1, 3600, 600, 86400, soa[1]} {
+			rdata = binary.BigEndian.AppendUint32(rdata, v)
+		}
+		msg = binary.BigEndian.AppendUint16(msg, uint16(len(rdata)))
+		msg = append(msg, rdata...)
+	}
+	return msg
+}
+
+func TestAnswerTTL(t *testing.T) {
+	query := buildQuery(1, "upstream.test", 1)
+	tests := []struct {
+		name     string
+		answer   []byte
+		ttl      time.Duration
+		negative bool
+		ok       bool
+	}{
+		{"lowest record TTL", buildAnswer(query, rcodeSuccess, []uint32{300, 60}, nil), 60 * time.Second, false, true},
+		{"nxdomain with SOA", buildAnswer(query, rcodeNXDomain, nil, []uint32{900, 120}), 120 * time.Second, true, true},
+		{"empty answer with SOA", buildAnswer(query, rcodeSuccess, nil, []uint32{30, 120}), 30 * time.Second, true, true},
+		{"nxdomain without SOA", buildAnswer(query, rcodeNXDomain, nil, nil), -1, true, true},
+		{"servfail", buildAnswer(query, rcodeServFail, nil, nil), 0, false, false},
+		{"truncated", append(buildAnswer(query, rcodeSuccess, []uint32{60}, nil)[:2:2], 0x83, 0x80, 0, 1, 0, 0, 0, 0, 0, 0), 0, false, false},
+		{"short", []byte{0, 1}, 0, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ttl, negative, ok := answerTTL(tt.answer)
+			if ok != tt.ok || (ok && (ttl != tt.ttl || negative != tt.negative)) {
+				t.Errorf("answerTTL() = %v, %v, %v; want %v, %v, %v", ttl, negative, ok, tt.ttl, tt.negative, tt.ok)
+			}
+		})
+	}
+}
+
+func TestCache(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	c := newCache(CacheConfig{MaxTTL: 5 * time.Minute, NegativeTTL: 30 * time.Second})
+	c.now = func() time.Time { return now }
+
+	query := buildQuery(1, "Upstream.Test", 1)
+	c.put(query, buildAnswer(query, rcodeSuccess, []uint32{60}, nil))
+
+	// Another query for the same question, in another case, gets the
+	// answer with its own ID.
+	again := buildQuery(0xbeef, "upstream.test", 1)
+	answer, negative, ok := c.get(again)
+	if !ok || negative || binary.BigEndian.Uint16(answer) != 0xbeef {
+		t.Fatalf("get() = %x, %v, %v", answer, negative, ok)
+	}
+	if _, _, ok := c.get(buildQuery(2, "upstream.test", 28)); ok {
+		t.Error("AAAA query answered with the A answer")
+	}
+
+	now = now.Add(61 * time.Second)
+	if _, _, ok := c.get(again); ok {
+		t.Error("answer served after its TTL")
+	}
+
+	// MaxTTL caps long TTLs; NegativeTTL caps negative answers.
+	c.put(query, buildAnswer(query, rcodeSuccess, []uint32{86400}, nil))
+	missing := buildQuery(3, "missing.test", 1)
+	c.put(missing, buildAnswer(missing, rcodeNXDomain, nil, []uint32{3600, 3600}))
+	now = now.Add(31 * time.Second)
+	if _, _, ok := c.get(missing); ok {
+		t.Error("negative answer served past NegativeTTL")
+	}
+	if _, _, ok := c.get(query); !ok {
+		t.Error("answer expired before MaxTTL")
+	}
+	now = now.Add(5 * time.Minute)
+	if _, _, ok := c.get(query); ok {
+		t.Error("answer served past MaxTTL")
+	}
+
+	// Failures are not cached.
+	failed := buildQuery(4, "broken.test", 1)
+	c.put(failed, buildAnswer(failed, rcodeServFail, nil, nil))
+	if _, _, ok := c.get(failed); ok {
+		t.Error("SERVFAIL cached")
+	}
+}
+
+func TestResolver_CacheAndStats(t *testing.T) {
+	// A plain DNS server on UDP answering every name but missing.test,
+	// which does not exist.
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	var queries atomic.Int32
+	go func() {
+		buf := make([]byte, maxMessageSize)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			queries.Add(1)
+			query := buf[:n]
+			var answer []byte
+			if strings.Contains(string(query), "missing") {
+				answer = buildAnswer(query, rcodeNXDomain, nil, []uint32{60, 60})
+			} else {
+				answer = answerA(t, query, net.IPv4(203, 0, 113, 7))
+			}
+			_, _ = pc.WriteTo(answer, addr)
+		}
+	}()
+
+	r, err := New(Options{Cache: CacheConfig{MaxTTL: time.Minute, NegativeTTL: time.Minute}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	query := buildQuery(7, "upstream.test", 1)
+	for i := 0; i < 3; i++ {
+		answer, err := r.r.query(ctx, query, pc.LocalAddr().String())
+		if err != nil || rcode(answer) != rcodeSuccess {
+			t.Fatalf("query: %v", err)
+		}
+	}
+	missing := buildQuery(8, "missing.test", 1)
+	for i := 0; i < 2; i++ {
+		if _, err := r.r.query(ctx, missing, pc.LocalAddr().String()); err != nil {
+			t.Fatalf("query: %v", err)
+		}
+	}
+
+	if got := queries.Load(); got != 2 {
+		t.Errorf("server got %d queries, want 2", got)
+	}
+	s := r.Stats()
+	if s.CacheHits != 2 || s.NegativeCacheHits != 1 || s.CacheMisses != 2 || s.CacheEntries != 2 {
+		t.Errorf("stats = %+v", s)
+	}
+	if s.Failures[FailureNXDomain] != 1 || s.Failures[FailureTimeout] != 0 {
+		t.Errorf("failures = %v", s.Failures)
+	}
+
+	// An unreachable server is counted as a failure.
+	closed, _ := net.Listen("tcp", "127.0.0.1:0")
+	_ = closed.Close()
+	srv, _ := New(Options{Servers: []Server{{URL: "tls://" + closed.Addr().String()}}})
+	if _, err := srv.r.query(ctx, query, ""); err == nil {
+		t.Fatal("query to a closed server succeeded")
+	}
+	if got := srv.Stats().Failures[FailureUnreachable]; got != 1 {
+		t.Errorf("unreachable failures = %d, want 1", got)
+	}
+}
@@ -0,0 +1,113 @@
+package dns
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// revalidateTimeout bounds one lookup of a watched host.
+const revalidateTimeout = 5 * time.Second
+
+// IPResolver looks up the addresses of a host name. *net.Resolver and
+// *Resolver implement it.
+type IPResolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// Watcher detects DNS rebinding over the lifetime of long-lived
+// connections, such as upstream event streams: it resolves the host of each
+// watched connection again every interval, and closes the connection when
+// the host starts resolving to an internal address (loopback, private,
+// link-local or unspecified) while the connection went to a public one.
+// With a caching resolver, lookups within the TTL of the previous answer
+// are answered from the cache.
+type Watcher struct {
+	resolver   IPResolver
+	interval   time.Duration
+	logger     *slog.Logger
+	rebindings atomic.Uint64
+}
+
+// NewWatcher creates a Watcher that resolves hosts with resolver every
+// interval.
+func NewWatcher(resolver IPResolver, interval time.Duration, logger *slog.Logger) *Watcher {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Watcher{resolver: resolver, interval: interval, logger: logger}
+}
+
+// Rebindings returns how many connections were closed because their host
+// rebound to an internal address.
+func (w *Watcher) Rebindings() uint64 {
+	return w.rebindings.Load()
+}
+
+// Watch watches conn, dialed to host, until it is closed. It returns the
+// connection to use instead of conn. Connections to an IP address given as
+// the host, or to an internal address, are not watched.
+func (w *Watcher) Watch(conn net.Conn, host string) net.Conn {
+	if w == nil || w.interval <= 0 || net.ParseIP(host) != nil {
+		return conn
+	}
+	remote, ok := conn.RemoteAddr().(*net.TCPAddr)
+	if !ok || isInternal(remote.IP) {
+		return conn
+	}
+	wc := &watchedConn{Conn: conn, done: make(chan struct{})}
+	go w.watch(wc, host, remote.IP)
+	return wc
+}
+
+// watch checks host every interval until wc is closed.
+func (w *Watcher) watch(wc *watchedConn, host string, connected net.IP) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-wc.done:
+			return
+		case <-ticker.C:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), revalidateTimeout)
+		addrs, err := w.resolver.LookupIPAddr(ctx, host)
+		cancel()
+		if err != nil {
+			// Failures are counted by the resolver; the next check retries.
+			continue
+		}
+		for _, a := range addrs {
+			if isInternal(a.IP) {
+				w.rebindings.Add(1)
+				w.logger.Warn("DNS rebinding detected: host now resolves to an internal address, closing the connection",
+					"host", host, "connected_ip", connected.String(), "resolved_ip", a.IP.String())
+				_ = wc.Close()
+				return
+			}
+		}
+	}
+}
+
+// watchedConn is a connection watched by a Watcher.
+type watchedConn struct {
+	net.Conn
+	once sync.Once
+	done chan struct{}
+}
+
+// Close closes the connection and stops watching it.
+func (c *watchedConn) Close() error {
+	c.once.Do(func() { close(c.done) })
+	return c.Conn.Close()
+}
+
+// isInternal reports whether ip is an address outbound connections must
+// not reach: the ranges refused by the SSRF protection of upstream dialers.
+func isInternal(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast()
+}
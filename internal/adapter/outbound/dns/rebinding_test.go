@@ -0,0 +1,94 @@
+package dns
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// switchingResolver answers lookups with the current address of a host.
+type switchingResolver struct {
+	mu   sync.Mutex
+	addr net.IP
+}
+
+func (r *switchingResolver) set(ip net.IP) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.addr = ip
+}
+
+func (r *switchingResolver) LookupIPAddr(context.Context, string) ([]net.IPAddr, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return []net.IPAddr{{IP: r.addr}}, nil
+}
+
+// remoteConn is a connection reporting a fixed remote address.
+type remoteConn struct {
+	net.Conn
+	remote net.Addr
+}
+
+func (c *remoteConn) RemoteAddr() net.Addr { return c.remote }
+
+func newRemoteConn(t *testing.T, ip string) (*remoteConn, net.Conn) {
+	t.Helper()
+	client, server := net.Pipe()
+	t.Cleanup(func() { _ = server.Close() })
+	return &remoteConn{Conn: client, remote: &net.TCPAddr{IP: net.ParseIP(ip), Port: 443}}, server
+}
+
+func TestWatcher_ClosesRebindingConnection(t *testing.T) {
+	resolver := &switchingResolver{addr: net.ParseIP("203.0.113.7")}
+	w := NewWatcher(resolver, 10*time.Millisecond, nil)
+
+	raw, peer := newRemoteConn(t, "203.0.113.7")
+	conn := w.Watch(raw, "upstream.test")
+	if conn == raw {
+		t.Fatal("connection to a named host not watched")
+	}
+
+	// The host keeps a public address: the connection stays open.
+	time.Sleep(50 * time.Millisecond)
+	if w.Rebindings() != 0 {
+		t.Fatal("rebinding reported for an unchanged host")
+	}
+
+	resolver.set(net.ParseIP("169.254.169.254"))
+	closed := make(chan struct{})
+	go func() {
+		_, _ = peer.Read(make([]byte, 1)) // returns when conn is closed
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("connection not closed after the host rebound to an internal address")
+	}
+	if w.Rebindings() != 1 {
+		t.Errorf("Rebindings() = %d, want 1", w.Rebindings())
+	}
+}
+
+func TestWatcher_Unwatched(t *testing.T) {
+	w := NewWatcher(&switchingResolver{}, time.Minute, nil)
+
+	raw, _ := newRemoteConn(t, "203.0.113.7")
+	if w.Watch(raw, "203.0.113.7") != raw {
+		t.Error("connection to an IP address watched")
+	}
+	internal, _ := newRemoteConn(t, "10.0.0.5")
+	if w.Watch(internal, "db.corp.example") != internal {
+		t.Error("connection to an internal address watched")
+	}
+	if NewWatcher(&switchingResolver{}, 0, nil).Watch(raw, "upstream.test") != raw {
+		t.Error("connection watched with a zero interval")
+	}
+	var nilWatcher *Watcher
+	if nilWatcher.Watch(raw, "upstream.test") != raw {
+		t.Error("nil watcher wrapped the connection")
+	}
+}
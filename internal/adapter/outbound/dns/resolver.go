@@ -0,0 +1,487 @@
+// Package dns resolves host names through DNS-over-HTTPS (RFC 8484) and
+// DNS-over-TLS (RFC 7858) servers instead of the system resolver, so
+// outbound decisions do not depend on the DNS server of the host. Answers
+// can be cached for their TTL, and long-lived connections watched for DNS
+// rebinding.
+package dns
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultDoTPort is the DNS-over-TLS port (RFC 7858).
+	defaultDoTPort = "853"
+	// maxMessageSize is the largest DNS message (two-byte length prefix).
+	maxMessageSize = 65535
+	// dohContentType is the media type of DNS-over-HTTPS messages.
+	dohContentType = "application/dns-message"
+	// defaultExchangeTimeout bounds an exchange whose caller set no deadline.
+	defaultExchangeTimeout = 10 * time.Second
+)
+
+// Server is a DNS-over-HTTPS or DNS-over-TLS server.
+type Server struct {
+	// URL is "https://host[:port]/path" for DNS-over-HTTPS or
+	// "tls://host[:port]" for DNS-over-TLS (port 853 by default).
+	URL string
+	// Bootstrap holds the IP addresses of the server, used to reach it
+	// without resolving its name. Required unless the URL host is an IP
+	// address; the certificate is still verified against the host name.
+	Bootstrap []string
+}
+
+// server is a parsed Server.
+type server struct {
+	doh   *url.URL // DNS-over-HTTPS endpoint; nil for DNS-over-TLS
+	host  string   // TLS server name
+	addrs []string // ip:port addresses to dial, in order
+}
+
+// resolver sends DNS queries to its servers, trying them in order, or to
+// the system name servers when it has none.
+type resolver struct {
+	servers  []*server
+	client   *http.Client   // DNS-over-HTTPS client; dials bootstrap addresses only
+	roots    *x509.CertPool // nil = system roots
+	cache    *cache         // nil = answers are not cached
+	counters counters
+}
+
+// Options configures a Resolver.
+type Options struct {
+	// Servers are tried in order until one answers. Without servers,
+	// queries go over plain DNS to the name servers of the system
+	// (/etc/resolv.conf), which is only useful with Cache.
+	Servers []Server
+	// Cache caches answers. The zero value caches nothing.
+	Cache CacheConfig
+}
+
+// Resolver is a net.Resolver that sends queries to secure servers or to
+// the system name servers, optionally caching the answers, and counts them.
+type Resolver struct {
+	*net.Resolver
+	r *resolver
+}
+
+// New returns a Resolver configured by opts. Host names of the servers are
+// never resolved: connections go to their bootstrap addresses.
+func New(opts Options) (*Resolver, error) {
+	return newResolver(opts, nil)
+}
+
+// NewResolver returns a net.Resolver that sends every query to servers,
+// trying them in order until one answers.
+func NewResolver(servers []Server) (*net.Resolver, error) {
+	if len(servers) == 0 {
+		return nil, errors.New("dns: no servers")
+	}
+	r, err := New(Options{Servers: servers})
+	if err != nil {
+		return nil, err
+	}
+	return r.Resolver, nil
+}
+
+// Stats returns the query counts of r.
+func (r *Resolver) Stats() Stats {
+	s := r.r.counters.snapshot()
+	if r.r.cache != nil {
+		s.CacheEntries = r.r.cache.len()
+	}
+	return s
+}
+
+// newResolver is New with the roots that verify server certificates.
+func newResolver(opts Options, roots *x509.CertPool) (*Resolver, error) {
+	r := &resolver{roots: roots}
+	if opts.Cache.MaxTTL > 0 {
+		r.cache = newCache(opts.Cache)
+	}
+	dialAddrs := make(map[string][]string)
+	for i, s := range opts.Servers {
+		parsed, err := parseServer(s)
+		if err != nil {
+			return nil, fmt.Errorf("dns: servers[%d]: %w", i, err)
+		}
+		if parsed.doh != nil {
+			dialAddrs[parsed.doh.Host] = parsed.addrs
+		}
+		r.servers = append(r.servers, parsed)
+	}
+
+	dialer := &net.Dialer{Timeout: 5 * time.Second, KeepAlive: 30 * time.Second}
+	r.client = &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				addrs, ok := dialAddrs[addr]
+				if !ok {
+					return nil, fmt.Errorf("dns: no bootstrap address for %s", addr)
+				}
+				return dialFirst(ctx, addrs, func(ctx context.Context, a string) (net.Conn, error) {
+					return dialer.DialContext(ctx, network, a)
+				})
+			},
+			TLSClientConfig:     &tls.Config{MinVersion: tls.VersionTLS12, RootCAs: roots},
+			ForceAttemptHTTP2:   true,
+			MaxIdleConns:        4,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: 5 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	return &Resolver{
+		Resolver: &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, _, address string) (net.Conn, error) {
+				return &queryConn{r: r, ctx: ctx, addr: address}, nil
+			},
+		},
+		r: r,
+	}, nil
+}
+
+// parseServer validates a server and lists the addresses to dial.
+func parseServer(s Server) (*server, error) {
+	u, err := url.Parse(s.URL)
+	if err != nil || u.Hostname() == "" {
+		return nil, fmt.Errorf("url %q is not a valid URL", s.URL)
+	}
+	parsed := &server{host: u.Hostname()}
+	port := u.Port()
+	switch u.Scheme {
+	case "https":
+		if port == "" {
+			port = "443"
+		}
+		u.Host = net.JoinHostPort(parsed.host, port)
+		parsed.doh = u
+	case "tls":
+		if u.Path != "" && u.Path != "/" {
+			return nil, fmt.Errorf("url %q: DNS-over-TLS takes no path", s.URL)
+		}
+		if port == "" {
+			port = defaultDoTPort
+		}
+	default:
+		return nil, fmt.Errorf("url %q: scheme must be https (DNS-over-HTTPS) or tls (DNS-over-TLS)", s.URL)
+	}
+
+	for _, b := range s.Bootstrap {
+		ip := net.ParseIP(b)
+		if ip == nil {
+			return nil, fmt.Errorf("bootstrap %q is not an IP address", b)
+		}
+		parsed.addrs = append(parsed.addrs, net.JoinHostPort(ip.String(), port))
+	}
+	if len(parsed.addrs) == 0 {
+		if net.ParseIP(parsed.host) == nil {
+			return nil, fmt.Errorf("url %q: bootstrap addresses are required when the host is a name", s.URL)
+		}
+		parsed.addrs = []string{net.JoinHostPort(parsed.host, port)}
+	}
+	return parsed, nil
+}
+
+// query answers query from the cache, or else from the servers, or from the
+// system name server at addr when there are none.
+func (r *resolver) query(ctx context.Context, query []byte, addr string) ([]byte, error) {
+	if r.cache != nil {
+		if answer, negative, ok := r.cache.get(query); ok {
+			if negative {
+				r.counters.negativeHits.Add(1)
+			} else {
+				r.counters.hits.Add(1)
+			}
+			return answer, nil
+		}
+	}
+	r.counters.misses.Add(1)
+
+	var answer []byte
+	var err error
+	if len(r.servers) == 0 {
+		answer, err = exchangePlain(ctx, addr, query)
+	} else {
+		answer, err = r.exchange(ctx, query)
+	}
+	if reason := failureReason(answer, err); reason != "" {
+		r.counters.fail(reason)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if r.cache != nil {
+		r.cache.put(query, answer)
+	}
+	return answer, nil
+}
+
+// exchange sends query to each server in turn and returns the first answer.
+func (r *resolver) exchange(ctx context.Context, query []byte) ([]byte, error) {
+	var lastErr error
+	for _, s := range r.servers {
+		var answer []byte
+		var err error
+		if s.doh != nil {
+			answer, err = r.exchangeDoH(ctx, s, query)
+		} else {
+			answer, err = r.exchangeDoT(ctx, s, query)
+		}
+		if err == nil {
+			return answer, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+// exchangeDoH posts query to a DNS-over-HTTPS server.
+func (r *resolver) exchangeDoH(ctx context.Context, s *server, query []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.doh.String(), bytes.NewReader(query))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", dohContentType)
+	req.Header.Set("Accept", dohContentType)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("dns: %s: %w", s.host, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("dns: %s returned %d", s.host, resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, dohContentType) {
+		return nil, fmt.Errorf("dns: %s returned content type %q", s.host, ct)
+	}
+	answer, err := io.ReadAll(io.LimitReader(resp.Body, maxMessageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("dns: %s: %w", s.host, err)
+	}
+	if len(answer) > maxMessageSize {
+		return nil, fmt.Errorf("dns: %s: answer too large", s.host)
+	}
+	return answer, nil
+}
+
+// exchangeDoT sends query over a new TLS connection to a DNS-over-TLS
+// server, framed with a two-byte length as over TCP.
+func (r *resolver) exchangeDoT(ctx context.Context, s *server, query []byte) ([]byte, error) {
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: 5 * time.Second},
+		Config:    &tls.Config{ServerName: s.host, MinVersion: tls.VersionTLS12, RootCAs: r.roots},
+	}
+	conn, err := dialFirst(ctx, s.addrs, func(ctx context.Context, a string) (net.Conn, error) {
+		return dialer.DialContext(ctx, "tcp", a)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("dns: %s: %w", s.host, err)
+	}
+	defer func() { _ = conn.Close() }()
+	answer, err := exchangeStream(ctx, conn, query)
+	if err != nil {
+		return nil, fmt.Errorf("dns: %s: %w", s.host, err)
+	}
+	return answer, nil
+}
+
+// exchangePlain sends query over UDP to the name server at addr, and again
+// over TCP when the answer is truncated.
+func exchangePlain(ctx context.Context, addr string, query []byte) ([]byte, error) {
+	if len(query) < headerSize {
+		return nil, errors.New("dns: query too short")
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("dns: %s: %w", addr, err)
+	}
+	defer func() { _ = conn.Close() }()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	if _, err := conn.Write(query); err != nil {
+		return nil, fmt.Errorf("dns: %s: %w", addr, err)
+	}
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, fmt.Errorf("dns: %s: %w", addr, err)
+		}
+		// Skip stray datagrams that do not answer this query.
+		if n < headerSize || buf[0] != query[0] || buf[1] != query[1] {
+			continue
+		}
+		if buf[2]&0x02 == 0 { // not truncated
+			return append([]byte(nil), buf[:n]...), nil
+		}
+		break
+	}
+
+	tcp, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("dns: %s: %w", addr, err)
+	}
+	defer func() { _ = tcp.Close() }()
+	answer, err := exchangeStream(ctx, tcp, query)
+	if err != nil {
+		return nil, fmt.Errorf("dns: %s: %w", addr, err)
+	}
+	return answer, nil
+}
+
+// exchangeStream sends query over a stream connection, framed with a
+// two-byte length as over TCP, and reads the answer.
+func exchangeStream(ctx context.Context, conn net.Conn, query []byte) ([]byte, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	msg := make([]byte, 2+len(query))
+	binary.BigEndian.PutUint16(msg, uint16(len(query)))
+	copy(msg[2:], query)
+	if _, err := conn.Write(msg); err != nil {
+		return nil, err
+	}
+	var length [2]byte
+	if _, err := io.ReadFull(conn, length[:]); err != nil {
+		return nil, err
+	}
+	answer := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(conn, answer); err != nil {
+		return nil, err
+	}
+	return answer, nil
+}
+
+// dialFirst dials addrs in order and returns the first connection made.
+func dialFirst(ctx context.Context, addrs []string, dial func(context.Context, string) (net.Conn, error)) (net.Conn, error) {
+	var lastErr error
+	for _, a := range addrs {
+		conn, err := dial(ctx, a)
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+// queryConn is the connection the Go resolver exchanges messages over. It
+// is a stream connection to the resolver, so messages are framed with a
+// two-byte length as over TCP: each complete query written is sent to the
+// servers, and its answer is read back with the same framing.
+type queryConn struct {
+	r    *resolver
+	ctx  context.Context
+	addr string // name server the Go resolver dialed, used without servers
+
+	mu       sync.Mutex
+	deadline time.Time
+	wbuf     []byte
+	rbuf     []byte
+	closed   bool
+}
+
+func (c *queryConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return 0, net.ErrClosed
+	}
+	c.wbuf = append(c.wbuf, b...)
+	for len(c.wbuf) >= 2 {
+		n := int(binary.BigEndian.Uint16(c.wbuf))
+		if len(c.wbuf) < 2+n {
+			break
+		}
+		query := c.wbuf[2 : 2+n]
+		answer, err := c.exchangeLocked(query)
+		c.wbuf = c.wbuf[2+n:]
+		if err != nil {
+			return 0, err
+		}
+		c.rbuf = binary.BigEndian.AppendUint16(c.rbuf, uint16(len(answer)))
+		c.rbuf = append(c.rbuf, answer...)
+	}
+	return len(b), nil
+}
+
+// exchangeLocked sends one query within the connection deadline.
+func (c *queryConn) exchangeLocked(query []byte) ([]byte, error) {
+	ctx := c.ctx
+	var cancel context.CancelFunc
+	if !c.deadline.IsZero() {
+		ctx, cancel = context.WithDeadline(ctx, c.deadline)
+	} else {
+		ctx, cancel = context.WithTimeout(ctx, defaultExchangeTimeout)
+	}
+	defer cancel()
+	return c.r.query(ctx, query, c.addr)
+}
+
+func (c *queryConn) Read(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return 0, net.ErrClosed
+	}
+	if len(c.rbuf) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(b, c.rbuf)
+	c.rbuf = c.rbuf[n:]
+	return n, nil
+}
+
+func (c *queryConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+func (c *queryConn) SetDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deadline = t
+	return nil
+}
+
+func (c *queryConn) SetReadDeadline(time.Time) error { return nil }
+
+func (c *queryConn) SetWriteDeadline(t time.Time) error { return c.SetDeadline(t) }
+
+func (c *queryConn) LocalAddr() net.Addr { return queryAddr{} }
+
+func (c *queryConn) RemoteAddr() net.Addr { return queryAddr{} }
+
+// queryAddr is the address of a queryConn.
+type queryAddr struct{}
+
+func (queryAddr) Network() string { return "dns" }
+func (queryAddr) String() string  { return "secure-dns" }
@@ -0,0 +1,173 @@
+package dns
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// answerA answers an A query with ip and any other query with no records.
+func answerA(t *testing.T, query []byte, ip net.IP) []byte {
+	t.Helper()
+	if len(query) < 12 {
+		t.Fatalf("query too short: %d bytes", len(query))
+	}
+	// Skip the question name to find its type.
+	i := 12
+	for query[i] != 0 {
+		i += int(query[i]) + 1
+	}
+	qtype := binary.BigEndian.Uint16(query[i+1:])
+	question := query[12 : i+5]
+
+	resp := append([]byte(nil), query[:2]...) // ID
+	resp = append(resp, 0x81, 0x80, 0, 1)     // response, RD+RA, QDCOUNT=1
+	if qtype == 1 {
+		resp = append(resp, 0, 1, 0, 0, 0, 0) // ANCOUNT=1
+	} else {
+		resp = append(resp, 0, 0, 0, 0, 0, 0)
+	}
+	resp = append(resp, question...)
+	if qtype == 1 {
+		resp = append(resp, 0xc0, 12, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4)
+		resp = append(resp, ip.To4()...)
+	}
+	return resp
+}
+
+// testRoots returns a pool trusting the certificate of srv.
+func testRoots(srv *httptest.Server) *x509.CertPool {
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	return roots
+}
+
+func TestResolver_DoH(t *testing.T) {
+	var queries atomic.Int32
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != dohContentType {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		query, _ := io.ReadAll(r.Body)
+		queries.Add(1)
+		w.Header().Set("Content-Type", dohContentType)
+		_, _ = w.Write(answerA(t, query, net.IPv4(203, 0, 113, 7)))
+	}))
+	defer srv.Close()
+
+	// The certificate is issued to example.com; the bootstrap address is
+	// the test server, so example.com itself is never resolved.
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	r, err := newResolver(Options{Servers: []Server{{
+		URL:       "https://example.com:" + port + "/dns-query",
+		Bootstrap: []string{"127.0.0.1"},
+	}}}, testRoots(srv))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	addrs, err := r.LookupHost(ctx, "upstream.test.")
+	if err != nil {
+		t.Fatalf("LookupHost: %v", err)
+	}
+	if len(addrs) != 1 || addrs[0] != "203.0.113.7" {
+		t.Errorf("addrs = %v, want [203.0.113.7]", addrs)
+	}
+	if queries.Load() == 0 {
+		t.Error("no query reached the DoH server")
+	}
+}
+
+func TestResolver_DoTWithFailover(t *testing.T) {
+	srv := httptest.NewUnstartedServer(nil)
+	srv.StartTLS()
+	defer srv.Close()
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", srv.TLS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				var length [2]byte
+				if _, err := io.ReadFull(conn, length[:]); err != nil {
+					return
+				}
+				query := make([]byte, binary.BigEndian.Uint16(length[:]))
+				if _, err := io.ReadFull(conn, query); err != nil {
+					return
+				}
+				answer := answerA(t, query, net.IPv4(198, 51, 100, 9))
+				_, _ = conn.Write(binary.BigEndian.AppendUint16(nil, uint16(len(answer))))
+				_, _ = conn.Write(answer)
+			}()
+		}
+	}()
+
+	// The first server refuses connections; the second answers.
+	closed, _ := net.Listen("tcp", "127.0.0.1:0")
+	deadAddr := closed.Addr().String()
+	_ = closed.Close()
+	_, deadPort, _ := net.SplitHostPort(deadAddr)
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	r, err := newResolver(Options{Servers: []Server{
+		{URL: "tls://127.0.0.1:" + deadPort},
+		{URL: "tls://example.com:" + port, Bootstrap: []string{"127.0.0.1"}},
+	}}, testRoots(srv))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	addrs, err := r.LookupHost(ctx, "upstream.test.")
+	if err != nil {
+		t.Fatalf("LookupHost: %v", err)
+	}
+	if len(addrs) != 1 || addrs[0] != "198.51.100.9" {
+		t.Errorf("addrs = %v, want [198.51.100.9]", addrs)
+	}
+}
+
+func TestNewResolver_InvalidServers(t *testing.T) {
+	tests := []struct {
+		name   string
+		server Server
+		want   string
+	}{
+		{"scheme", Server{URL: "udp://1.1.1.1"}, "scheme must be"},
+		{"no bootstrap", Server{URL: "https://dns.example/dns-query"}, "bootstrap addresses are required"},
+		{"bad bootstrap", Server{URL: "tls://dns.example", Bootstrap: []string{"dns.example"}}, "not an IP address"},
+		{"dot path", Server{URL: "tls://1.1.1.1/dns-query"}, "takes no path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewResolver([]Server{tt.server})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+	if _, err := NewResolver(nil); err == nil {
+		t.Error("expected an error without servers")
+	}
+}
@@ -0,0 +1,92 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+)
+
+// Failure reasons reported in Stats.Failures.
+const (
+	// FailureTimeout means no server answered in time.
+	FailureTimeout = "timeout"
+	// FailureUnreachable means no server could be reached or the exchange
+	// failed.
+	FailureUnreachable = "unreachable"
+	// FailureServFail means the server could not resolve the name (SERVFAIL).
+	FailureServFail = "servfail"
+	// FailureRefused means the server refused the query (REFUSED).
+	FailureRefused = "refused"
+	// FailureNXDomain means the name does not exist (NXDOMAIN).
+	FailureNXDomain = "nxdomain"
+)
+
+// failureReasons lists the reasons in the order of counters.failures.
+var failureReasons = [...]string{FailureTimeout, FailureUnreachable, FailureServFail, FailureRefused, FailureNXDomain}
+
+// Stats counts the queries of a resolver, for metrics.
+type Stats struct {
+	// CacheHits counts queries answered from the cache with records.
+	CacheHits uint64
+	// NegativeCacheHits counts queries answered from the cache with a name
+	// error or an empty answer.
+	NegativeCacheHits uint64
+	// CacheMisses counts queries sent to a server.
+	CacheMisses uint64
+	// CacheEntries is the number of cached answers.
+	CacheEntries int
+	// Failures counts failed queries by reason (see the Failure constants).
+	Failures map[string]uint64
+}
+
+// counters are the live counters behind Stats.
+type counters struct {
+	hits, negativeHits, misses atomic.Uint64
+	failures                   [len(failureReasons)]atomic.Uint64
+}
+
+// fail counts a failure for reason.
+func (c *counters) fail(reason string) {
+	for i, r := range failureReasons {
+		if r == reason {
+			c.failures[i].Add(1)
+			return
+		}
+	}
+}
+
+// snapshot returns the current counts.
+func (c *counters) snapshot() Stats {
+	s := Stats{
+		CacheHits:         c.hits.Load(),
+		NegativeCacheHits: c.negativeHits.Load(),
+		CacheMisses:       c.misses.Load(),
+		Failures:          make(map[string]uint64, len(failureReasons)),
+	}
+	for i, r := range failureReasons {
+		s.Failures[r] = c.failures[i].Load()
+	}
+	return s
+}
+
+// failureReason classifies a failed exchange, or the response code of an
+// answer; it returns "" for answers that are not failures.
+func failureReason(answer []byte, err error) string {
+	if err != nil {
+		var netErr net.Error
+		if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+			return FailureTimeout
+		}
+		return FailureUnreachable
+	}
+	switch rcode(answer) {
+	case rcodeServFail:
+		return FailureServFail
+	case rcodeRefused:
+		return FailureRefused
+	case rcodeNXDomain:
+		return FailureNXDomain
+	}
+	return ""
+}
@@ -21,6 +21,9 @@ type VerifyResult struct {
 	PartialChain    bool   // true when the first record doesn't start at genesis
 	ChainBreakAt    int    // -1 if chain is valid, else index of first break
 	FirstError      string // first error message encountered
+	// SignaturesChecked is false when the file was verified without a key:
+	// only the hash chain was checked.
+	SignaturesChecked bool
 }
 
 // VerifyFile reads an evidence file and checks all signatures and the hash chain.
@@ -36,7 +39,7 @@ func VerifyFile(path string, keyPath string) (*VerifyResult, error) {
 	}
 	defer f.Close()
 
-	return verifyFromReader(f, verifier)
+	return VerifyReader(f, verifier)
 }
 
 // VerifyFileWithPubKey reads an evidence file and verifies using a PEM public key.
@@ -52,11 +55,13 @@ func VerifyFileWithPubKey(path string, pubKeyPEM []byte) (*VerifyResult, error)
 	}
 	defer f.Close()
 
-	return verifyFromReader(f, verifier)
+	return VerifyReader(f, verifier)
 }
 
-func verifyFromReader(r io.Reader, verifier *ECDSAVerifier) (*VerifyResult, error) {
-	result := &VerifyResult{ChainValid: true, ChainBreakAt: -1}
+// VerifyReader checks the evidence records read from r. With a nil
+// verifier only the hash chain is checked.
+func VerifyReader(r io.Reader, verifier *ECDSAVerifier) (*VerifyResult, error) {
+	result := &VerifyResult{ChainValid: true, ChainBreakAt: -1, SignaturesChecked: verifier != nil}
 	scanner := bufio.NewScanner(r)
 	scanner.Buffer(make([]byte, 0, 1024*1024), 1024*1024) // 1MB max line
 
@@ -80,42 +85,44 @@ func verifyFromReader(r io.Reader, verifier *ECDSAVerifier) (*VerifyResult, erro
 		}
 		result.TotalRecords++
 
-		// Verify signature: sign payload is the record without the signature field.
-		payload, err := canonicalPayload(record)
-		if err != nil {
-			result.InvalidSigs++
-			if result.FirstError == "" {
-				result.FirstError = fmt.Sprintf("record %s: canonical payload error: %v", record.ID, err)
+		if verifier != nil {
+			// Verify signature: sign payload is the record without the signature field.
+			payload, err := canonicalPayload(record)
+			if err != nil {
+				result.InvalidSigs++
+				if result.FirstError == "" {
+					result.FirstError = fmt.Sprintf("record %s: canonical payload error: %v", record.ID, err)
+				}
+				idx++
+				continue
 			}
-			idx++
-			continue
-		}
 
-		sigBytes, err := base64.StdEncoding.DecodeString(record.Signature.Value)
-		if err != nil {
-			result.InvalidSigs++
-			if result.FirstError == "" {
-				result.FirstError = fmt.Sprintf("record %s: invalid base64 signature", record.ID)
+			sigBytes, err := base64.StdEncoding.DecodeString(record.Signature.Value)
+			if err != nil {
+				result.InvalidSigs++
+				if result.FirstError == "" {
+					result.FirstError = fmt.Sprintf("record %s: invalid base64 signature", record.ID)
+				}
+				idx++
+				continue
 			}
-			idx++
-			continue
-		}
 
-		valid, verifyErr := verifier.Verify(payload, sigBytes)
-		if verifyErr != nil {
-			result.InvalidSigs++
-			if result.FirstError == "" {
-				result.FirstError = fmt.Sprintf("record %s: verification error: %v", record.ID, verifyErr)
+			valid, verifyErr := verifier.Verify(payload, sigBytes)
+			if verifyErr != nil {
+				result.InvalidSigs++
+				if result.FirstError == "" {
+					result.FirstError = fmt.Sprintf("record %s: verification error: %v", record.ID, verifyErr)
+				}
+				idx++
+				continue
 			}
-			idx++
-			continue
-		}
-		if valid {
-			result.ValidSignatures++
-		} else {
-			result.InvalidSigs++
-			if result.FirstError == "" {
-				result.FirstError = fmt.Sprintf("record %s: signature verification failed", record.ID)
+			if valid {
+				result.ValidSignatures++
+			} else {
+				result.InvalidSigs++
+				if result.FirstError == "" {
+					result.FirstError = fmt.Sprintf("record %s: signature verification failed", record.ID)
+				}
 			}
 		}
 
@@ -1,6 +1,7 @@
 package evidence
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
@@ -137,6 +138,33 @@ func TestVerifyFile_TamperedRecord(t *testing.T) {
 	}
 }
 
+func TestVerifyReader_ChainOnly(t *testing.T) {
+	dir := t.TempDir()
+	evidencePath, _ := writeValidEvidenceFile(t, dir, 3)
+	data, err := os.ReadFile(evidencePath)
+	if err != nil {
+		t.Fatalf("read file: %v", err)
+	}
+
+	result, err := VerifyReader(bytes.NewReader(data), nil)
+	if err != nil {
+		t.Fatalf("VerifyReader: %v", err)
+	}
+	if result.SignaturesChecked || !result.ChainValid || result.TotalRecords != 3 {
+		t.Errorf("result = %+v, want a valid chain of 3 without signature checks", result)
+	}
+
+	// Dropping the middle record breaks the chain.
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	result, err = VerifyReader(bytes.NewReader(append(lines[0], lines[2]...)), nil)
+	if err != nil {
+		t.Fatalf("VerifyReader: %v", err)
+	}
+	if result.ChainValid || result.ChainBreakAt != 1 {
+		t.Errorf("ChainValid = %v, ChainBreakAt = %d, want a break at index 1", result.ChainValid, result.ChainBreakAt)
+	}
+}
+
 func TestVerifyFile_EmptyFile(t *testing.T) {
 	dir := t.TempDir()
 	keyPath := filepath.Join(dir, "test-key.pem")
@@ -0,0 +1,114 @@
+// Package geoip resolves IP addresses to countries and autonomous systems
+// using local MaxMind DB (MMDB) files such as GeoLite2-Country, DB-IP
+// Country Lite or GeoLite2-ASN.
+package geoip
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/action"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/auth"
+)
+
+// Compile-time checks that Resolver implements auth.CountryResolver and
+// Locator implements action.GeoLocator.
+var (
+	_ auth.CountryResolver = (*Resolver)(nil)
+	_ action.GeoLocator    = (*Locator)(nil)
+)
+
+// Resolver looks up countries in an MMDB database. It is safe for
+// concurrent use.
+type Resolver struct {
+	db *maxminddb.Reader
+}
+
+// countryRecord is the subset of the GeoIP2/GeoLite2 country schema we read.
+type countryRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	RegisteredCountry struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"registered_country"`
+}
+
+// asnRecord is the subset of the GeoLite2-ASN schema we read.
+type asnRecord struct {
+	Number       uint   `maxminddb:"autonomous_system_number"`
+	Organization string `maxminddb:"autonomous_system_organization"`
+}
+
+// Open opens the MMDB database at path.
+func Open(path string) (*Resolver, error) {
+	db, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open GeoIP database %s: %w", path, err)
+	}
+	return &Resolver{db: db}, nil
+}
+
+// FromBytes creates a resolver from an in-memory MMDB database.
+func FromBytes(data []byte) (*Resolver, error) {
+	db, err := maxminddb.FromBytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("load GeoIP database: %w", err)
+	}
+	return &Resolver{db: db}, nil
+}
+
+// Country returns the ISO 3166-1 alpha-2 code for ip, falling back to the
+// registered country. It returns "" when the address is not in the database.
+func (r *Resolver) Country(ip net.IP) (string, error) {
+	var rec countryRecord
+	if err := r.db.Lookup(ip, &rec); err != nil {
+		return "", fmt.Errorf("GeoIP lookup %s: %w", ip, err)
+	}
+	if rec.Country.ISOCode != "" {
+		return rec.Country.ISOCode, nil
+	}
+	return rec.RegisteredCountry.ISOCode, nil
+}
+
+// ASN returns the autonomous system number and organization of ip. The
+// number is 0 when the address is not in the database.
+func (r *Resolver) ASN(ip net.IP) (uint, string, error) {
+	var rec asnRecord
+	if err := r.db.Lookup(ip, &rec); err != nil {
+		return 0, "", fmt.Errorf("GeoIP ASN lookup %s: %w", ip, err)
+	}
+	return rec.Number, rec.Organization, nil
+}
+
+// Close releases the database.
+func (r *Resolver) Close() error {
+	return r.db.Close()
+}
+
+// Locator locates destination addresses with a country database, an ASN
+// database, or both.
+type Locator struct {
+	countries *Resolver
+	asns      *Resolver
+}
+
+// NewLocator creates a Locator. Either database may be nil.
+func NewLocator(countries, asns *Resolver) *Locator {
+	return &Locator{countries: countries, asns: asns}
+}
+
+// Locate returns the country and autonomous system of ip. Lookups that
+// fail or find nothing leave the fields empty.
+func (l *Locator) Locate(ip net.IP) action.DestinationGeo {
+	var geo action.DestinationGeo
+	if l.countries != nil {
+		geo.Country, _ = l.countries.Country(ip)
+	}
+	if l.asns != nil {
+		geo.ASN, geo.ASNOrg, _ = l.asns.ASN(ip)
+	}
+	return geo
+}
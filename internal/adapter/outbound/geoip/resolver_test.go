@@ -0,0 +1,158 @@
+package geoip
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// buildTestDB assembles a minimal IPv4 MMDB mapping 10.0.0.0/8 to the
+// country code IT.
+func buildTestDB() []byte {
+	// {"country": {"iso_code": "IT"}}
+	data := []byte{0xE1}
+	data = appendString(data, "country")
+	data = append(data, 0xE1)
+	data = appendString(data, "iso_code")
+	data = appendString(data, "IT")
+	return buildDB(data, "Test-Country")
+}
+
+// buildASNTestDB assembles a minimal IPv4 MMDB mapping 10.0.0.0/8 to
+// AS64500, "Example Net".
+func buildASNTestDB() []byte {
+	data := []byte{0xE2}
+	data = appendString(data, "autonomous_system_number")
+	data = append(data, 0xC2, 0xFB, 0xF4) // uint32 64500
+	data = appendString(data, "autonomous_system_organization")
+	data = appendString(data, "Example Net")
+	return buildDB(data, "Test-ASN")
+}
+
+// buildDB assembles a minimal IPv4 MMDB mapping 10.0.0.0/8 to the encoded
+// record data. The search tree has one node per prefix bit; the branch off
+// the prefix points to node_count, meaning "no data".
+func buildDB(data []byte, dbType string) []byte {
+	const nodeCount = 8
+	const prefix = 10 // first octet of 10.0.0.0/8
+
+	var db []byte
+	for i := 0; i < nodeCount; i++ {
+		next := uint32(i + 1)
+		if i == nodeCount-1 {
+			next = nodeCount + 16 // data pointer: node_count + separator + offset 0
+		}
+		left, right := uint32(nodeCount), uint32(nodeCount)
+		if prefix&(0x80>>i) != 0 {
+			right = next
+		} else {
+			left = next
+		}
+		db = append(db, byte(left>>16), byte(left>>8), byte(left), byte(right>>16), byte(right>>8), byte(right))
+	}
+	db = append(db, make([]byte, 16)...)
+	db = append(db, data...)
+
+	db = append(db, "\xAB\xCD\xEFMaxMind.com"...)
+	db = append(db, 0xE5)
+	db = appendString(db, "node_count")
+	db = append(db, 0xC1, nodeCount)
+	db = appendString(db, "record_size")
+	db = append(db, 0xA1, 24)
+	db = appendString(db, "ip_version")
+	db = append(db, 0xA1, 4)
+	db = appendString(db, "binary_format_major_version")
+	db = append(db, 0xA1, 2)
+	db = appendString(db, "database_type")
+	db = appendString(db, dbType)
+	return db
+}
+
+func appendString(b []byte, s string) []byte {
+	if len(s) >= 29 {
+		b = append(b, 0x40|29, byte(len(s)-29))
+	} else {
+		b = append(b, 0x40|byte(len(s)))
+	}
+	return append(b, s...)
+}
+
+func openTestDB(t *testing.T, data []byte) *Resolver {
+	t.Helper()
+	r, err := FromBytes(data)
+	if err != nil {
+		t.Fatalf("FromBytes: %v", err)
+	}
+	t.Cleanup(func() { _ = r.Close() })
+	return r
+}
+
+func TestResolver_Country(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.mmdb")
+	if err := os.WriteFile(path, buildTestDB(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	r, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{"10.1.2.3", "IT"},
+		{"10.255.255.255", "IT"},
+		{"11.0.0.1", ""},
+		{"192.168.1.1", ""},
+	}
+	for _, tt := range tests {
+		got, err := r.Country(net.ParseIP(tt.ip))
+		if err != nil {
+			t.Errorf("Country(%s): %v", tt.ip, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Country(%s) = %q, want %q", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestResolver_ASN(t *testing.T) {
+	r := openTestDB(t, buildASNTestDB())
+
+	asn, org, err := r.ASN(net.ParseIP("10.1.2.3"))
+	if err != nil || asn != 64500 || org != "Example Net" {
+		t.Errorf("ASN(10.1.2.3) = %d, %q, %v, want 64500, \"Example Net\"", asn, org, err)
+	}
+	if asn, org, _ := r.ASN(net.ParseIP("192.168.1.1")); asn != 0 || org != "" {
+		t.Errorf("ASN(192.168.1.1) = %d, %q, want nothing", asn, org)
+	}
+}
+
+func TestLocator_Locate(t *testing.T) {
+	countries := openTestDB(t, buildTestDB())
+	asns := openTestDB(t, buildASNTestDB())
+
+	geo := NewLocator(countries, asns).Locate(net.ParseIP("10.1.2.3"))
+	if geo.Country != "IT" || geo.ASN != 64500 || geo.ASNOrg != "Example Net" {
+		t.Errorf("Locate() = %+v", geo)
+	}
+	if geo := NewLocator(nil, asns).Locate(net.ParseIP("10.1.2.3")); geo.Country != "" || geo.ASN != 64500 {
+		t.Errorf("Locate() without a country database = %+v", geo)
+	}
+	if geo := NewLocator(countries, nil).Locate(net.ParseIP("11.0.0.1")); geo.Country != "" || geo.ASN != 0 {
+		t.Errorf("Locate(11.0.0.1) = %+v, want nothing", geo)
+	}
+}
+
+func TestOpen_Invalid(t *testing.T) {
+	if _, err := Open(filepath.Join(t.TempDir(), "missing.mmdb")); err == nil {
+		t.Error("Open(missing) succeeded, want error")
+	}
+	if _, err := FromBytes([]byte("not a database")); err == nil {
+		t.Error("FromBytes(garbage) succeeded, want error")
+	}
+}
@@ -0,0 +1,57 @@
+package mcp
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ConnWatcher watches upstream connections over their lifetime, e.g. for
+// DNS rebinding. Implemented by dns.Watcher.
+type ConnWatcher interface {
+	// Watch watches conn, dialed to host, and returns the connection to
+	// use instead of it.
+	Watch(conn net.Conn, host string) net.Conn
+}
+
+// WithConnWatcher hands every connection to the server to w, so that the
+// long-lived event streams are checked while they stay open. A nil w
+// watches nothing.
+func WithConnWatcher(w ConnWatcher) ClientOption {
+	return func(c *HTTPClient) {
+		watchConns(c.httpClient.Transport, w)
+	}
+}
+
+// WithSSEConnWatcher hands every connection to the server to w, like
+// WithConnWatcher.
+func WithSSEConnWatcher(w ConnWatcher) SSEOption {
+	return func(c *SSEClient) {
+		watchConns(c.httpClient.Transport, w)
+	}
+}
+
+// watchConns wraps the dialer of rt so that its connections are watched
+// by w. TLS connections are made over the watched connections.
+func watchConns(rt http.RoundTripper, w ConnWatcher) {
+	t, ok := rt.(*http.Transport)
+	if !ok || w == nil {
+		return
+	}
+	dial := t.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+	}
+	t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return conn, nil
+		}
+		return w.Watch(conn, host), nil
+	}
+}
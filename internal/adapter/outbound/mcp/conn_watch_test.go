@@ -0,0 +1,41 @@
+package mcp
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// recordingWatcher records the hosts of watched connections.
+type recordingWatcher struct {
+	mu    sync.Mutex
+	hosts []string
+}
+
+func (w *recordingWatcher) Watch(conn net.Conn, host string) net.Conn {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.hosts = append(w.hosts, host)
+	return conn
+}
+
+func TestWithConnWatcher(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	w := &recordingWatcher{}
+	c := NewHTTPClient(srv.URL, WithConnWatcher(w))
+	resp, err := c.httpClient.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.hosts) != 1 || w.hosts[0] != "127.0.0.1" {
+		t.Errorf("watched hosts = %v, want [127.0.0.1]", w.hosts)
+	}
+}
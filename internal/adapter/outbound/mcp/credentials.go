@@ -0,0 +1,235 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Credentials authenticate the requests an HTTP, SSE or OpenAPI client
+// sends to its upstream. Type is "bearer" (Token), "basic" (Username and
+// Password), "header" (HeaderName and HeaderValue) or
+// "oauth2_client_credentials" (TokenURL, ClientID, ClientSecret and Scopes).
+type Credentials struct {
+	Type         string
+	Token        string
+	Username     string
+	Password     string
+	HeaderName   string
+	HeaderValue  string
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+}
+
+// tokenExpiryMargin is how long before its expiry an OAuth2 access token is
+// replaced, so a request never leaves with a token about to expire.
+const tokenExpiryMargin = 30 * time.Second
+
+// maxTokenResponseSize bounds the token endpoint response read.
+const maxTokenResponseSize = 1 << 20
+
+// credentialTransport adds credentials to every request sent through base.
+type credentialTransport struct {
+	base  http.RoundTripper
+	creds Credentials
+	oauth *oauth2TokenSource // set for oauth2_client_credentials
+}
+
+// withCredentials returns a copy of client whose requests carry creds. The
+// OAuth2 token endpoint is reached through the same transport, with its
+// SSRF protection and certificate verification.
+func withCredentials(client *http.Client, creds *Credentials) *http.Client {
+	if creds == nil {
+		return client
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	t := &credentialTransport{base: base, creds: *creds}
+	if creds.Type == "oauth2_client_credentials" {
+		t.oauth = &oauth2TokenSource{
+			tokenURL:     creds.TokenURL,
+			clientID:     creds.ClientID,
+			clientSecret: creds.ClientSecret,
+			scopes:       creds.Scopes,
+			client:       &http.Client{Transport: base, Timeout: defaultRequestTimeout},
+		}
+	}
+	c := *client
+	c.Transport = t
+	return &c
+}
+
+// RoundTrip sends req with the credentials. A 401 answered to an OAuth2
+// token discards it, and the request is retried once with a new token
+// when its body can be replayed.
+func (t *credentialTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	token, err := t.authorize(r)
+	if err != nil {
+		closeRequestBody(req)
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(r)
+	if err != nil || t.oauth == nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return resp, nil
+	}
+	t.oauth.invalidate(token)
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+		retry.Body = body
+	}
+	if _, err := t.authorize(retry); err != nil {
+		closeRequestBody(retry)
+		return resp, nil
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	_ = resp.Body.Close()
+	return t.base.RoundTrip(retry)
+}
+
+// authorize sets the credential header of r and returns the OAuth2 access
+// token used, if any.
+func (t *credentialTransport) authorize(r *http.Request) (string, error) {
+	switch t.creds.Type {
+	case "bearer":
+		r.Header.Set("Authorization", "Bearer "+t.creds.Token)
+	case "basic":
+		r.SetBasicAuth(t.creds.Username, t.creds.Password)
+	case "header":
+		r.Header.Set(t.creds.HeaderName, t.creds.HeaderValue)
+	case "oauth2_client_credentials":
+		token, err := t.oauth.Token(r.Context())
+		if err != nil {
+			return "", err
+		}
+		r.Header.Set("Authorization", "Bearer "+token)
+		return token, nil
+	default:
+		return "", fmt.Errorf("unsupported credential type %q", t.creds.Type)
+	}
+	return "", nil
+}
+
+// CloseIdleConnections closes the idle connections of the base transport.
+func (t *credentialTransport) CloseIdleConnections() {
+	if c, ok := t.base.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+}
+
+func closeRequestBody(r *http.Request) {
+	if r.Body != nil {
+		_ = r.Body.Close()
+	}
+}
+
+// oauth2TokenSource obtains access tokens with the OAuth2 client
+// credentials grant (RFC 6749 section 4.4) and caches each until shortly
+// before it expires.
+type oauth2TokenSource struct {
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	scopes       []string
+	client       *http.Client
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time // zero when the endpoint gave no expires_in
+}
+
+// Token returns a valid access token, requesting a new one when there is
+// none or the cached one is about to expire.
+func (s *oauth2TokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && (s.expiry.IsZero() || time.Now().Add(tokenExpiryMargin).Before(s.expiry)) {
+		return s.token, nil
+	}
+	token, expiresIn, err := s.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	s.token = token
+	s.expiry = time.Time{}
+	if expiresIn > 0 {
+		s.expiry = time.Now().Add(time.Duration(expiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// invalidate discards token if it is still the cached one, so the next
+// request fetches a new token.
+func (s *oauth2TokenSource) invalidate(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token == token {
+		s.token = ""
+	}
+}
+
+// fetch requests an access token from the token endpoint. The client
+// authenticates with HTTP basic authentication, as RFC 6749 requires every
+// authorization server to support.
+func (s *oauth2TokenSource) fetch(ctx context.Context) (string, int64, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(s.scopes) > 0 {
+		form.Set("scope", strings.Join(s.scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, fmt.Errorf("oauth2 token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(s.clientID), url.QueryEscape(s.clientSecret))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("oauth2 token request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxTokenResponseSize))
+	if err != nil {
+		return "", 0, fmt.Errorf("oauth2 token response: %w", err)
+	}
+
+	var tr struct {
+		AccessToken      string `json:"access_token"`
+		TokenType        string `json:"token_type"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	_ = json.Unmarshal(body, &tr)
+	if resp.StatusCode != http.StatusOK {
+		if tr.Error != "" {
+			return "", 0, fmt.Errorf("oauth2 token endpoint returned %d: %s %s", resp.StatusCode, tr.Error, tr.ErrorDescription)
+		}
+		return "", 0, fmt.Errorf("oauth2 token endpoint returned %d", resp.StatusCode)
+	}
+	if tr.AccessToken == "" {
+		return "", 0, fmt.Errorf("oauth2 token response has no access_token")
+	}
+	if tr.TokenType != "" && !strings.EqualFold(tr.TokenType, "bearer") {
+		return "", 0, fmt.Errorf("oauth2 token type %q is not supported", tr.TokenType)
+	}
+	return tr.AccessToken, tr.ExpiresIn, nil
+}
@@ -0,0 +1,109 @@
+package mcp
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCredentialTransport_StaticCredentials(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		creds  Credentials
+		header string
+		want   string
+	}{
+		{Credentials{Type: "bearer", Token: "t0ken"}, "Authorization", "Bearer t0ken"},
+		{Credentials{Type: "basic", Username: "svc", Password: "pw"}, "Authorization", "Basic c3ZjOnB3"},
+		{Credentials{Type: "header", HeaderName: "X-API-Key", HeaderValue: "k3y"}, "X-API-Key", "k3y"},
+	}
+	for _, tt := range tests {
+		client := withCredentials(srv.Client(), &tt.creds)
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.creds.Type, err)
+		}
+		_ = resp.Body.Close()
+		if v := got.Get(tt.header); v != tt.want {
+			t.Errorf("%s: %s = %q, want %q", tt.creds.Type, tt.header, v, tt.want)
+		}
+	}
+}
+
+func TestCredentialTransport_OAuth2ClientCredentials(t *testing.T) {
+	var issued, rejected atomic.Int32
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "client_credentials" ||
+			id != "gw" || secret != "s3cret" || r.PostForm.Get("scope") != "tools:read tools:call" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = io.WriteString(w, `{"error":"invalid_client"}`)
+			return
+		}
+		n := issued.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"access_token":"token-`+string(rune('0'+n))+`","token_type":"Bearer","expires_in":3600}`)
+	}))
+	defer tokenSrv.Close()
+
+	var bodies []string
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first token is revoked by the server.
+		if r.Header.Get("Authorization") == "Bearer token-1" && issued.Load() == 1 {
+			rejected.Add(1)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		_, _ = io.WriteString(w, r.Header.Get("Authorization"))
+	}))
+	defer apiSrv.Close()
+
+	client := withCredentials(apiSrv.Client(), &Credentials{
+		Type: "oauth2_client_credentials", TokenURL: tokenSrv.URL,
+		ClientID: "gw", ClientSecret: "s3cret", Scopes: []string{"tools:read", "tools:call"},
+	})
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Post(apiSrv.URL, "application/json", strings.NewReader(`{"n":1}`))
+		if err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+		b, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || string(b) != "Bearer token-2" {
+			t.Fatalf("request %d: status %d, authorization %q", i, resp.StatusCode, b)
+		}
+	}
+	if issued.Load() != 2 || rejected.Load() != 1 {
+		t.Errorf("tokens issued = %d, rejected = %d; want a refresh after the 401 and then the cached token", issued.Load(), rejected.Load())
+	}
+	if len(bodies) != 2 || bodies[0] != `{"n":1}` {
+		t.Errorf("bodies = %q, want the retried request body replayed", bodies)
+	}
+}
+
+func TestCredentialTransport_OAuth2Error(t *testing.T) {
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, `{"error":"invalid_scope","error_description":"unknown scope"}`)
+	}))
+	defer tokenSrv.Close()
+
+	client := withCredentials(http.DefaultClient, &Credentials{
+		Type: "oauth2_client_credentials", TokenURL: tokenSrv.URL, ClientID: "gw", ClientSecret: "x",
+	})
+	_, err := client.Get(tokenSrv.URL)
+	if err == nil || !strings.Contains(err.Error(), "invalid_scope") {
+		t.Fatalf("err = %v, want token endpoint error", err)
+	}
+}
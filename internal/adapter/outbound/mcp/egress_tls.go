@@ -0,0 +1,255 @@
+package mcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// TLS verification failure reasons reported in TLSVerificationError.Reason.
+const (
+	// TLSReasonUntrusted means the certificate chain did not verify against
+	// the trusted roots or does not cover the host.
+	TLSReasonUntrusted = "untrusted_certificate"
+	// TLSReasonPinMismatch means the chain verified but no certificate in it
+	// carries one of the pinned public keys.
+	TLSReasonPinMismatch = "pin_mismatch"
+)
+
+// spkiPinPrefix prefixes base64 SHA-256 hashes of a SubjectPublicKeyInfo.
+const spkiPinPrefix = "sha256/"
+
+// EgressTLSDestination overrides certificate verification for the
+// upstream hosts matching Domain.
+type EgressTLSDestination struct {
+	// Domain is a host name ("api.example.com") or a wildcard matching
+	// subdomains at any depth ("*.corp.internal").
+	Domain string
+	// CAFile is a PEM bundle trusted instead of the system roots.
+	CAFile string
+	// SPKIPins are "sha256/<base64>" hashes of public keys; one certificate
+	// of the verified chain must carry one of them.
+	SPKIPins []string
+}
+
+// TLSVerificationError is returned, and reported, when the gateway rejects
+// the certificate of an upstream.
+type TLSVerificationError struct {
+	Upstream string
+	Host     string
+	// Domain is the destination that matched Host, "" when none did.
+	Domain string
+	Reason string
+	Err    error
+}
+
+func (e *TLSVerificationError) Error() string {
+	return fmt.Sprintf("tls verification failed for %s (%s): %v", e.Host, e.Reason, e.Err)
+}
+
+func (e *TLSVerificationError) Unwrap() error { return e.Err }
+
+type egressTLSDestination struct {
+	domain string
+	roots  *x509.CertPool
+	pins   map[[sha256.Size]byte]bool
+}
+
+// EgressTLSPolicy verifies the certificates of HTTP and OpenAPI upstreams,
+// with custom roots and public key pins per destination. Verification
+// failures always fail the connection and are passed to the failure
+// callback, so an interception of upstream traffic is reported rather than
+// only surfacing as a connection error.
+type EgressTLSPolicy struct {
+	dests     []egressTLSDestination
+	onFailure func(*TLSVerificationError)
+}
+
+// NewEgressTLSPolicy loads the CA files and pins of dests. onFailure, when
+// non-nil, is called for every rejected certificate.
+func NewEgressTLSPolicy(dests []EgressTLSDestination, onFailure func(*TLSVerificationError)) (*EgressTLSPolicy, error) {
+	p := &EgressTLSPolicy{onFailure: onFailure}
+	seen := make(map[string]bool, len(dests))
+	for _, d := range dests {
+		domain := strings.ToLower(strings.TrimSpace(d.Domain))
+		if domain == "" {
+			return nil, errors.New("egress tls: destination without domain")
+		}
+		if seen[domain] {
+			return nil, fmt.Errorf("egress tls: duplicate destination %q", domain)
+		}
+		seen[domain] = true
+		if d.CAFile == "" && len(d.SPKIPins) == 0 {
+			return nil, fmt.Errorf("egress tls: destination %q needs a ca_file or spki_pins", domain)
+		}
+
+		dest := egressTLSDestination{domain: domain}
+		if d.CAFile != "" {
+			data, err := os.ReadFile(d.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("egress tls: destination %q: %w", domain, err)
+			}
+			dest.roots = x509.NewCertPool()
+			if !dest.roots.AppendCertsFromPEM(data) {
+				return nil, fmt.Errorf("egress tls: destination %q: no certificates in %s", domain, d.CAFile)
+			}
+		}
+		if len(d.SPKIPins) > 0 {
+			dest.pins = make(map[[sha256.Size]byte]bool, len(d.SPKIPins))
+			for _, pin := range d.SPKIPins {
+				sum, err := ParseSPKIPin(pin)
+				if err != nil {
+					return nil, fmt.Errorf("egress tls: destination %q: %w", domain, err)
+				}
+				dest.pins[sum] = true
+			}
+		}
+		p.dests = append(p.dests, dest)
+	}
+	return p, nil
+}
+
+// ParseSPKIPin decodes a "sha256/<base64>" public key pin.
+func ParseSPKIPin(pin string) ([sha256.Size]byte, error) {
+	var sum [sha256.Size]byte
+	encoded, ok := strings.CutPrefix(pin, spkiPinPrefix)
+	if !ok {
+		return sum, fmt.Errorf("spki pin %q must start with %q", pin, spkiPinPrefix)
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(raw) != sha256.Size {
+		return sum, fmt.Errorf("spki pin %q is not a base64 SHA-256 hash", pin)
+	}
+	copy(sum[:], raw)
+	return sum, nil
+}
+
+// SPKIPin returns the pin of the public key of cert.
+func SPKIPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return spkiPinPrefix + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// TLSConfig returns the client TLS configuration for a connection of the
+// named upstream to host.
+func (p *EgressTLSPolicy) TLSConfig(upstreamName, host string) *tls.Config {
+	return &tls.Config{
+		ServerName: host,
+		MinVersion: tls.VersionTLS12, // SECU-01: TLS 1.2 minimum
+		// The chain is verified in VerifyConnection, against the roots of
+		// the destination matching host.
+		InsecureSkipVerify: true, //nolint:gosec
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			return p.verify(upstreamName, host, cs)
+		},
+	}
+}
+
+// destination returns the destination matching host: an exact domain
+// first, then the longest matching wildcard.
+func (p *EgressTLSPolicy) destination(host string) *egressTLSDestination {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	var best *egressTLSDestination
+	for i := range p.dests {
+		d := &p.dests[i]
+		if d.domain == host {
+			return d
+		}
+		suffix, ok := strings.CutPrefix(d.domain, "*")
+		if ok && strings.HasPrefix(suffix, ".") && strings.HasSuffix(host, suffix) && len(host) > len(suffix) {
+			if best == nil || len(d.domain) > len(best.domain) {
+				best = d
+			}
+		}
+	}
+	return best
+}
+
+// verify checks the certificate chain of a connection to host. host is
+// passed rather than taken from cs, where it is empty for IP addresses.
+func (p *EgressTLSPolicy) verify(upstreamName, host string, cs tls.ConnectionState) error {
+	dest := p.destination(host)
+	fail := func(reason string, err error) error {
+		verr := &TLSVerificationError{Upstream: upstreamName, Host: host, Reason: reason, Err: err}
+		if dest != nil {
+			verr.Domain = dest.domain
+		}
+		if p.onFailure != nil {
+			p.onFailure(verr)
+		}
+		return verr
+	}
+
+	if len(cs.PeerCertificates) == 0 {
+		return fail(TLSReasonUntrusted, errors.New("no server certificate"))
+	}
+	opts := x509.VerifyOptions{
+		DNSName:       host,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	if dest != nil && dest.roots != nil {
+		opts.Roots = dest.roots
+	}
+	chains, err := cs.PeerCertificates[0].Verify(opts)
+	if err != nil {
+		return fail(TLSReasonUntrusted, err)
+	}
+
+	if dest == nil || len(dest.pins) == 0 {
+		return nil
+	}
+	for _, chain := range chains {
+		for _, cert := range chain {
+			if dest.pins[sha256.Sum256(cert.RawSubjectPublicKeyInfo)] {
+				return nil
+			}
+		}
+	}
+	return fail(TLSReasonPinMismatch, fmt.Errorf("no certificate matches the pins of %s (leaf key %s)", dest.domain, SPKIPin(cs.PeerCertificates[0])))
+}
+
+// applyEgressTLS makes transport open TLS connections configured by p.
+// The TLS configuration is built per connection because the host it is
+// verified against is only known when dialing. Connections are still
+// opened with the transport's DialContext, e.g. the SSRF-safe dialer.
+func applyEgressTLS(rt http.RoundTripper, p *EgressTLSPolicy, upstreamName string) {
+	if p == nil {
+		return
+	}
+	t, ok := rt.(*http.Transport)
+	if !ok {
+		return
+	}
+	t.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		dial := t.DialContext
+		if dial == nil {
+			dial = (&net.Dialer{Timeout: 30 * time.Second}).DialContext
+		}
+		raw, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		conn := tls.Client(raw, p.TLSConfig(upstreamName, host))
+		if err := conn.HandshakeContext(ctx); err != nil {
+			_ = raw.Close()
+			return nil, err
+		}
+		return conn, nil
+	}
+}
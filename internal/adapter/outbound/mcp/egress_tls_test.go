@@ -0,0 +1,111 @@
+package mcp
+
+import (
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEgressTLSPolicy(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	pemData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caFile, pemData, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	goodPin := SPKIPin(srv.Certificate())
+	otherPin := "sha256/" + "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="
+
+	tests := []struct {
+		name       string
+		dests      []EgressTLSDestination
+		wantReason string
+	}{
+		{"system roots do not trust the test CA", nil, TLSReasonUntrusted},
+		{"custom CA", []EgressTLSDestination{{Domain: "127.0.0.1", CAFile: caFile}}, ""},
+		{"custom CA and matching pin", []EgressTLSDestination{{Domain: "127.0.0.1", CAFile: caFile, SPKIPins: []string{otherPin, goodPin}}}, ""},
+		{"custom CA and wrong pin", []EgressTLSDestination{{Domain: "127.0.0.1", CAFile: caFile, SPKIPins: []string{otherPin}}}, TLSReasonPinMismatch},
+		{"CA of another domain", []EgressTLSDestination{{Domain: "*.corp.internal", CAFile: caFile}}, TLSReasonUntrusted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reported []*TLSVerificationError
+			p, err := NewEgressTLSPolicy(tt.dests, func(e *TLSVerificationError) { reported = append(reported, e) })
+			if err != nil {
+				t.Fatalf("NewEgressTLSPolicy(): %v", err)
+			}
+			client := &http.Client{Transport: &http.Transport{}}
+			applyEgressTLS(client.Transport, p, "billing")
+
+			resp, err := client.Get(srv.URL)
+			if resp != nil {
+				resp.Body.Close()
+			}
+			if tt.wantReason == "" {
+				if err != nil || len(reported) != 0 {
+					t.Fatalf("Get() error = %v, reported %v", err, reported)
+				}
+				return
+			}
+			var verr *TLSVerificationError
+			if !errors.As(err, &verr) || verr.Reason != tt.wantReason {
+				t.Fatalf("Get() error = %v, want reason %s", err, tt.wantReason)
+			}
+			if len(reported) != 1 || reported[0].Upstream != "billing" || reported[0].Host != "127.0.0.1" {
+				t.Errorf("reported = %+v", reported)
+			}
+		})
+	}
+}
+
+func TestEgressTLSPolicy_Destination(t *testing.T) {
+	p, err := NewEgressTLSPolicy([]EgressTLSDestination{
+		{Domain: "*.example.com", SPKIPins: []string{"sha256/47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="}},
+		{Domain: "*.api.example.com", SPKIPins: []string{"sha256/47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="}},
+		{Domain: "API.example.com", SPKIPins: []string{"sha256/47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewEgressTLSPolicy(): %v", err)
+	}
+	tests := map[string]string{
+		"api.example.com":       "api.example.com",
+		"eu.api.example.com":    "*.api.example.com",
+		"a.b.example.com":       "*.example.com",
+		"example.com":           "",
+		"example.com.evil.test": "",
+	}
+	for host, want := range tests {
+		got := ""
+		if d := p.destination(host); d != nil {
+			got = d.domain
+		}
+		if got != want {
+			t.Errorf("destination(%q) = %q, want %q", host, got, want)
+		}
+	}
+}
+
+func TestNewEgressTLSPolicy_Invalid(t *testing.T) {
+	tests := [][]EgressTLSDestination{
+		{{Domain: "", SPKIPins: []string{"sha256/47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="}}},
+		{{Domain: "a.example.com"}},
+		{{Domain: "a.example.com", SPKIPins: []string{"47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="}}},
+		{{Domain: "a.example.com", SPKIPins: []string{"sha256/c2hvcnQ="}}},
+		{{Domain: "a.example.com", CAFile: filepath.Join(t.TempDir(), "missing.pem")}},
+		{
+			{Domain: "a.example.com", SPKIPins: []string{"sha256/47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="}},
+			{Domain: "A.example.com", SPKIPins: []string{"sha256/47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="}},
+		},
+	}
+	for i, dests := range tests {
+		if _, err := NewEgressTLSPolicy(dests, nil); err == nil {
+			t.Errorf("case %d: NewEgressTLSPolicy() error = nil", i)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package mcp
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"crypto/tls"
 	"encoding/json"
@@ -13,13 +14,13 @@ import (
 	"net"
 	"net/http"
 	"regexp"
-	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/proxy"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/port/outbound"
 )
 
@@ -51,6 +52,24 @@ const (
 	// This replaces the global http.Client.Timeout, allowing SSE streams
 	// enough time to deliver progress notifications + final response.
 	defaultRequestTimeout = 120 * time.Second
+
+	// maxSSEResumeAttempts bounds how often the SSE response of one request
+	// is resumed with Last-Event-ID after the stream broke.
+	maxSSEResumeAttempts = 3
+
+	// maxServerStreamFailures is how many times in a row opening the
+	// standalone server stream may fail before the client stops trying.
+	maxServerStreamFailures = 5
+)
+
+var (
+	// errSessionExpired is returned when the server no longer knows the
+	// Mcp-Session-Id of the client (404): a new session must be initialized.
+	errSessionExpired = errors.New("upstream session expired")
+
+	// errStreamNotOffered is returned when the server does not offer a
+	// GET event stream at the endpoint (405).
+	errStreamNotOffered = errors.New("server does not offer an event stream")
 )
 
 // validUpstreamSessionIDPattern validates session IDs from upstream servers (M-7).
@@ -100,6 +119,7 @@ type HTTPClient struct {
 	endpoint       string
 	httpClient     *http.Client
 	requestTimeout time.Duration // Per-request timeout (context-based, not http.Client.Timeout)
+	credentials    *Credentials  // added to every request
 
 	mu        sync.Mutex
 	sessionID string      // Mcp-Session-Id from server
@@ -111,6 +131,18 @@ type HTTPClient struct {
 
 	sseRetryMs atomic.Int64 // M-41: server-suggested SSE reconnect delay in ms
 
+	// serverStream opens the standalone GET stream after initialization.
+	serverStream bool
+	listening    bool  // the server stream goroutine runs (guarded by mu)
+	runErr       error // why the run ended early, returned by Wait (guarded by mu)
+
+	// writeMu serializes the messages written to the response pipe by the
+	// request loop and the server stream.
+	writeMu sync.Mutex
+
+	// Per-message headers queued by requestWriter.
+	headers headerQueue
+
 	requestPipeReader  *io.PipeReader
 	requestPipeWriter  *io.PipeWriter
 	responsePipeReader *io.PipeReader
@@ -137,6 +169,17 @@ func WithTimeout(d time.Duration) ClientOption {
 	}
 }
 
+// WithServerStream opens the standalone GET event stream of the endpoint
+// once the session is initialized, so the notifications and requests the
+// server sends outside of a response reach the gateway. The stream is
+// resumed with Last-Event-ID when it drops. Servers that do not offer it
+// (405) are used without it.
+func WithServerStream() ClientOption {
+	return func(c *HTTPClient) {
+		c.serverStream = true
+	}
+}
+
 // WithSSRFProtection replaces the default transport's dialer with one that
 // rejects connections to private/loopback/link-local IPs at TCP connect time.
 // H-1: Prevents DNS rebinding TOCTOU where a hostname resolves to a safe IP
@@ -149,6 +192,22 @@ func WithSSRFProtection() ClientOption {
 	}
 }
 
+// WithEgressTLS verifies the server certificate with the destination
+// overrides of p, reporting failures as the named upstream. A nil p keeps
+// the default verification.
+func WithEgressTLS(p *EgressTLSPolicy, upstreamName string) ClientOption {
+	return func(c *HTTPClient) {
+		applyEgressTLS(c.httpClient.Transport, p, upstreamName)
+	}
+}
+
+// WithCredentials authenticates every request to the server with creds.
+func WithCredentials(creds *Credentials) ClientOption {
+	return func(c *HTTPClient) {
+		c.credentials = creds
+	}
+}
+
 // NewHTTPClient creates a client for the given MCP server HTTP endpoint.
 // The endpoint is the base URL of the remote MCP server.
 func NewHTTPClient(endpoint string, opts ...ClientOption) *HTTPClient {
@@ -175,6 +234,7 @@ func NewHTTPClient(endpoint string, opts ...ClientOption) *HTTPClient {
 	for _, opt := range opts {
 		opt(c)
 	}
+	c.httpClient = withCredentials(c.httpClient, c.credentials)
 
 	return c
 }
@@ -196,6 +256,8 @@ func (c *HTTPClient) Start(ctx context.Context) (io.WriteCloser, io.ReadCloser,
 	}
 
 	c.state = stateStarted
+	c.listening = false
+	c.runErr = nil
 	// Reset completion channel for this run
 	c.done = make(chan struct{})
 
@@ -208,11 +270,103 @@ func (c *HTTPClient) Start(ctx context.Context) (io.WriteCloser, io.ReadCloser,
 	// Response pipe: HTTPClient writes -> ProxyService reads
 	c.responsePipeReader, c.responsePipeWriter = io.Pipe()
 
+	c.headers.reset()
+
 	// Start goroutine to read requests and send HTTP POSTs
 	c.wg.Add(1)
 	go c.readRequestsAndSend()
 
-	return c.requestPipeWriter, c.responsePipeReader, nil
+	return &requestWriter{q: &c.headers, pw: c.requestPipeWriter}, c.responsePipeReader, nil
+}
+
+// reservedRequestHeaders are set by the client itself and cannot be
+// overridden by per-message headers.
+var reservedRequestHeaders = map[string]bool{
+	"Content-Type":   true,
+	"Content-Length": true,
+	"Accept":         true,
+	"Host":           true,
+	"Mcp-Session-Id": true,
+	"Last-Event-Id":  true,
+}
+
+// headerQueue holds the per-message headers of the lines written to a
+// request pipe, one entry per line (nil for lines without extra headers).
+type headerQueue struct {
+	mu      sync.Mutex
+	pending []map[string]string
+}
+
+// push queues headers for the first of lines lines, and none for the others.
+func (q *headerQueue) push(headers map[string]string, lines int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending = append(q.pending, headers)
+	for i := 1; i < lines; i++ {
+		q.pending = append(q.pending, nil)
+	}
+}
+
+// pop returns the headers queued for the next line read from the pipe.
+func (q *headerQueue) pop() map[string]string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.pending) == 0 {
+		return nil
+	}
+	h := q.pending[0]
+	q.pending[0] = nil
+	q.pending = q.pending[1:]
+	return h
+}
+
+// reset drops the queued headers.
+func (q *headerQueue) reset() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending = nil
+}
+
+// requestWriter is the request pipe handed out by Start. Besides plain writes
+// it accepts per-message headers (proxy.UpstreamHeaderWriter), which are
+// queued in line order and applied to the HTTP POST carrying that line.
+type requestWriter struct {
+	q  *headerQueue
+	pw *io.PipeWriter
+	mu sync.Mutex // keeps header queue order identical to pipe write order
+}
+
+// Write writes newline-delimited messages without extra headers.
+func (w *requestWriter) Write(p []byte) (int, error) {
+	return w.WriteWithHeaders(p, nil)
+}
+
+// WriteWithHeaders writes p and attaches headers to the first line in p.
+// A trailing newline is added if missing so the headers cannot drift onto
+// a later message.
+func (w *requestWriter) WriteWithHeaders(p []byte, headers map[string]string) (int, error) {
+	if len(headers) > 0 && (len(p) == 0 || p[len(p)-1] != '\n') {
+		p = append(p[:len(p):len(p)], '\n')
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	lines := 0
+	for _, b := range p {
+		if b == '\n' {
+			lines++
+		}
+	}
+	if lines > 0 {
+		w.q.push(headers, lines)
+	}
+	return w.pw.Write(p)
+}
+
+// Close closes the request pipe.
+func (w *requestWriter) Close() error {
+	return w.pw.Close()
 }
 
 // readRequestsAndSend reads newline-delimited JSON messages from the request pipe
@@ -239,6 +393,7 @@ func (c *HTTPClient) readRequestsAndSend() {
 		}
 
 		raw := scanner.Bytes()
+		headers := c.headers.pop()
 		if len(raw) == 0 {
 			continue
 		}
@@ -249,34 +404,33 @@ func (c *HTTPClient) readRequestsAndSend() {
 		isNotification := isJSONRPCNotification(raw)
 
 		// Send HTTP POST with the message
-		resp, err := c.sendRequest(raw)
+		resp, err := c.sendRequest(raw, headers)
 		if err != nil {
 			// Don't write error responses for notifications
 			if !isNotification {
 				c.writeErrorResponse(raw, err)
 			}
+			if errors.Is(err, errSessionExpired) {
+				// End the run: the connection is re-initialized by its owner.
+				slog.Warn("upstream session expired", "endpoint", c.endpoint)
+				c.mu.Lock()
+				c.runErr = err
+				c.mu.Unlock()
+				return
+			}
 			continue
 		}
 
+		if isNotification && c.serverStream && jsonRPCMethod(raw) == "notifications/initialized" {
+			c.startServerStream()
+		}
+
 		// Skip writing if no response body (202 Accepted) or notification
 		if resp == nil || isNotification {
 			continue
 		}
 
-		// Strip trailing newlines from response before writing the pipe delimiter.
-		// HTTP servers using json.Encoder.Encode() append a trailing newline to
-		// the response body. If we don't strip it, the response pipe gets two
-		// consecutive newlines (json\n\n), which causes the next bufio.Scanner
-		// on the reader side to see an empty line and desync.
-		for len(resp) > 0 && resp[len(resp)-1] == '\n' {
-			resp = resp[:len(resp)-1]
-		}
-
-		// Write response + exactly one newline to response pipe
-		if _, err := c.responsePipeWriter.Write(resp); err != nil {
-			return // Pipe closed
-		}
-		if _, err := c.responsePipeWriter.Write([]byte("\n")); err != nil {
+		if err := c.writeMessage(resp); err != nil {
 			return // Pipe closed
 		}
 	}
@@ -285,10 +439,33 @@ func (c *HTTPClient) readRequestsAndSend() {
 	}
 }
 
+// writeMessage writes one message to the response pipe, followed by exactly
+// one newline.
+func (c *HTTPClient) writeMessage(msg []byte) error {
+	// Strip trailing newlines from response before writing the pipe delimiter.
+	// HTTP servers using json.Encoder.Encode() append a trailing newline to
+	// the response body. If we don't strip it, the response pipe gets two
+	// consecutive newlines (json\n\n), which causes the next bufio.Scanner
+	// on the reader side to see an empty line and desync.
+	for len(msg) > 0 && msg[len(msg)-1] == '\n' {
+		msg = msg[:len(msg)-1]
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if _, err := c.responsePipeWriter.Write(msg); err != nil {
+		return err
+	}
+	_, err := c.responsePipeWriter.Write([]byte("\n"))
+	return err
+}
+
 // sendRequest sends an HTTP POST request with the JSON-RPC message.
 // Handles both JSON and SSE (text/event-stream) responses per MCP Streamable HTTP spec.
 // Returns nil, nil for 202 Accepted (notification acknowledgement).
-func (c *HTTPClient) sendRequest(body []byte) ([]byte, error) {
+// Extra headers (e.g. an end-user Authorization header) are applied first,
+// so they can never replace the protocol headers set below.
+func (c *HTTPClient) sendRequest(body []byte, headers map[string]string) ([]byte, error) {
 	// Per-request context timeout instead of global http.Client.Timeout.
 	// This allows SSE streams to be read without being killed mid-stream.
 	reqCtx, reqCancel := context.WithTimeout(c.ctx, c.requestTimeout)
@@ -303,17 +480,18 @@ func (c *HTTPClient) sendRequest(body []byte) ([]byte, error) {
 	req.Body = io.NopCloser(newBytesReader(body))
 	req.ContentLength = int64(len(body))
 
+	for k, v := range headers {
+		if !reservedRequestHeaders[http.CanonicalHeaderKey(k)] {
+			req.Header.Set(k, v)
+		}
+	}
+
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json, text/event-stream")
 
 	// Add session ID if we have one
-	c.mu.Lock()
-	sessionID := c.sessionID
-	c.mu.Unlock()
-	if sessionID != "" {
-		req.Header.Set("Mcp-Session-Id", sessionID)
-	}
+	sessionID := c.setSessionHeader(req)
 
 	// Execute request
 	resp, err := c.httpClient.Do(req)
@@ -337,6 +515,17 @@ func (c *HTTPClient) sendRequest(body []byte) ([]byte, error) {
 		return nil, nil
 	}
 
+	// A 404 for a request with a session ID means the server ended the
+	// session; the client must initialize a new one.
+	if resp.StatusCode == http.StatusNotFound && sessionID != "" {
+		c.mu.Lock()
+		if c.sessionID == sessionID {
+			c.sessionID = ""
+		}
+		c.mu.Unlock()
+		return nil, errSessionExpired
+	}
+
 	// Handle non-2xx status codes
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		errBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBodySize))
@@ -346,7 +535,7 @@ func (c *HTTPClient) sendRequest(body []byte) ([]byte, error) {
 	// Branch based on Content-Type: SSE vs JSON.
 	ct := resp.Header.Get("Content-Type")
 	if strings.HasPrefix(ct, "text/event-stream") {
-		return c.handleSSEResponse(resp.Body, body)
+		return c.handleSSEResponse(reqCtx, resp.Body, body, headers)
 	}
 
 	// Default: JSON response (existing behavior).
@@ -358,20 +547,28 @@ func (c *HTTPClient) sendRequest(body []byte) ([]byte, error) {
 	return respBody, nil
 }
 
+// setSessionHeader sets the Mcp-Session-Id header of req, when the server
+// assigned one, and returns it.
+func (c *HTTPClient) setSessionHeader(req *http.Request) string {
+	c.mu.Lock()
+	sessionID := c.sessionID
+	c.mu.Unlock()
+	if sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", sessionID)
+	}
+	return sessionID
+}
+
 // handleSSEResponse parses a Server-Sent Events stream from the response body
 // and returns the JSON-RPC response matching the original request's id.
 // L-28: Matches response id against the original request id and distinguishes
 // responses (with "result" or "error") from server-to-client requests (with "method").
 // Notifications (messages without "id") are silently consumed.
 //
-// SSE format per spec:
-//
-//	event: message
-//	data: {"jsonrpc":"2.0","id":1,"result":{...}}
-//	<blank line>
-//
-// Multiple data: lines for the same event are joined with newlines.
-func (c *HTTPClient) handleSSEResponse(body io.Reader, originalRequest []byte) ([]byte, error) {
+// When the stream breaks before the response and the server gave its events
+// IDs, the stream is resumed with a GET carrying Last-Event-ID, so the server
+// can replay what was missed. headers are the extra headers of the request.
+func (c *HTTPClient) handleSSEResponse(ctx context.Context, body io.Reader, originalRequest []byte, headers map[string]string) ([]byte, error) {
 	// L-28: Extract the request id to match against response id.
 	var reqIDProbe struct {
 		ID json.RawMessage `json:"id"`
@@ -381,60 +578,197 @@ func (c *HTTPClient) handleSSEResponse(body io.Reader, originalRequest []byte) (
 		requestID = string(reqIDProbe.ID)
 	}
 
-	scanner := bufio.NewScanner(body)
-	// Use a generous buffer for SSE — events can contain large JSON payloads.
-	scanner.Buffer(make([]byte, 0, 64*1024), maxResponseBodySize)
+	var resumed io.Closer
+	defer func() {
+		if resumed != nil {
+			_ = resumed.Close()
+		}
+	}()
+
+	events := newSSEReader(body, &c.sseRetryMs)
+	for attempt := 0; ; attempt++ {
+		var err error
+		for {
+			var ev sseEvent
+			if ev, err = events.next(); err != nil {
+				break
+			}
+			if matched, ok := c.matchSSEResponseMessage([]byte(ev.Data), requestID); ok {
+				return matched, nil
+			}
+			// Not a matching response — consume and continue.
+		}
 
-	var dataLines []string
+		if events.lastID == "" || attempt >= maxSSEResumeAttempts {
+			if !errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("SSE stream read: %w", err)
+			}
+			return nil, fmt.Errorf("SSE stream ended without JSON-RPC response")
+		}
 
-	for scanner.Scan() {
-		line := scanner.Text()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(sseRetryDelay(&c.sseRetryMs)):
+		}
+		resp, rerr := c.openStream(ctx, events.lastID, headers)
+		if rerr != nil {
+			return nil, fmt.Errorf("resume SSE stream: %w", rerr)
+		}
+		if resumed != nil {
+			_ = resumed.Close()
+		}
+		resumed = resp.Body
+		lastID := events.lastID
+		events = newSSEReader(resp.Body, &c.sseRetryMs)
+		events.lastID = lastID
+	}
+}
+
+// openStream opens a GET event stream at the endpoint: the standalone
+// server stream, or the resumption of a broken one after lastEventID.
+// It returns errStreamNotOffered when the server has no such stream.
+func (c *HTTPClient) openStream(ctx context.Context, lastEventID string, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	for k, v := range headers {
+		if !reservedRequestHeaders[http.CanonicalHeaderKey(k)] {
+			req.Header.Set(k, v)
+		}
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	c.setSessionHeader(req)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("http request: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusMethodNotAllowed:
+		_ = resp.Body.Close()
+		return nil, errStreamNotOffered
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		errBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("http status %d: %s", resp.StatusCode, string(errBody))
+	case !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream"):
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("unexpected content type %q", resp.Header.Get("Content-Type"))
+	}
+	return resp, nil
+}
+
+// startServerStream starts reading the standalone server stream, once per run.
+func (c *HTTPClient) startServerStream() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.listening || c.state != stateStarted {
+		return
+	}
+	c.listening = true
+	c.wg.Add(1)
+	go c.readServerStream(c.ctx)
+}
 
+// readServerStream reads the standalone GET stream, on which the server sends
+// the notifications and requests that are not part of a response. It resumes
+// the stream with Last-Event-ID when it drops and returns when the client
+// closes, the server does not offer the stream, or opening it keeps failing.
+func (c *HTTPClient) readServerStream(ctx context.Context) {
+	defer c.wg.Done()
+
+	lastID := ""
+	failures := 0
+	for ctx.Err() == nil {
+		resp, err := c.openStream(ctx, lastID, nil)
 		switch {
-		case line == "":
-			// Blank line = end of event. Process accumulated data lines.
-			if len(dataLines) > 0 {
-				data := strings.Join(dataLines, "\n")
-				dataLines = nil
-
-				if matched, ok := c.matchSSEResponseMessage([]byte(data), requestID); ok {
-					return matched, nil
-				}
-				// Not a matching response — consume and continue.
+		case errors.Is(err, errStreamNotOffered):
+			slog.Debug("upstream offers no server stream", "endpoint", c.endpoint)
+			return
+		case err != nil:
+			if ctx.Err() != nil {
+				return
 			}
-
-		case strings.HasPrefix(line, "data:"):
-			// Extract data payload, trimming optional leading space after "data:".
-			payload := line[5:]
-			if len(payload) > 0 && payload[0] == ' ' {
-				payload = payload[1:]
+			failures++
+			if failures >= maxServerStreamFailures {
+				slog.Warn("giving up on upstream server stream", "endpoint", c.endpoint, "error", err)
+				return
 			}
-			dataLines = append(dataLines, payload)
-
-		case strings.HasPrefix(line, "retry:"):
-			// M-41: parse server-suggested reconnect delay
-			retryStr := strings.TrimSpace(line[6:])
-			if val, err := strconv.ParseInt(retryStr, 10, 64); err == nil && val >= 0 {
-				c.sseRetryMs.Store(val)
+			slog.Debug("failed to open upstream server stream", "endpoint", c.endpoint, "error", err)
+		default:
+			failures = 0
+			events := newSSEReader(resp.Body, &c.sseRetryMs)
+			events.lastID = lastID
+			stopped := c.relayServerEvents(ctx, events)
+			lastID = events.lastID
+			_ = resp.Body.Close()
+			if stopped {
+				return
 			}
+		}
 
-			// event:, id:, : (comment) lines are ignored per SSE spec.
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(sseRetryDelay(&c.sseRetryMs)):
 		}
 	}
+}
 
-	// Stream ended. Check for remaining buffered data (server may omit trailing blank line).
-	if len(dataLines) > 0 {
-		data := strings.Join(dataLines, "\n")
-		if matched, ok := c.matchSSEResponseMessage([]byte(data), requestID); ok {
-			return matched, nil
+// relayServerEvents writes the notifications of the server stream to the
+// response pipe and answers the server requests, until the stream ends.
+// It reports whether the response pipe is closed.
+func (c *HTTPClient) relayServerEvents(ctx context.Context, events *sseReader) bool {
+	for {
+		ev, err := events.next()
+		if err != nil {
+			return false
+		}
+		if ev.Event != "message" {
+			continue
+		}
+		msg, ok := compactJSON(ev.Data)
+		if !ok {
+			slog.Debug("dropping invalid message from upstream server stream", "endpoint", c.endpoint)
+			continue
+		}
+		if reply := serverRequestReply(msg); reply != nil {
+			// Requests outside of a call have no client to go to.
+			if err := c.postReply(ctx, reply); err != nil {
+				slog.Debug("failed to answer upstream request", "endpoint", c.endpoint, "error", err)
+			}
+			continue
+		}
+		if err := c.writeMessage(msg); err != nil {
+			return true
 		}
 	}
+}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("SSE stream read: %w", err)
+// postReply sends the reply to a server request.
+func (c *HTTPClient) postReply(ctx context.Context, reply []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, newBytesReader(reply))
+	if err != nil {
+		return err
 	}
-
-	return nil, fmt.Errorf("SSE stream ended without JSON-RPC response")
+	req.ContentLength = int64(len(reply))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	c.setSessionHeader(req)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("http status %d", resp.StatusCode)
+	}
+	return nil
 }
 
 // matchSSEResponseMessage checks if an SSE data message is a JSON-RPC response
@@ -493,9 +827,64 @@ func isJSONRPCNotification(raw []byte) bool {
 	return !hasID
 }
 
+// jsonRPCMethod returns the method of a JSON-RPC message, "" for responses.
+func jsonRPCMethod(raw []byte) string {
+	var probe struct {
+		Method string `json:"method"`
+	}
+	_ = json.Unmarshal(raw, &probe)
+	return probe.Method
+}
+
+// compactJSON returns the JSON of an event data on a single line, as the
+// response pipe is newline-delimited. ok is false when data is not JSON.
+func compactJSON(data string) ([]byte, bool) {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(data)); err != nil {
+		return nil, false
+	}
+	return buf.Bytes(), true
+}
+
+// serverRequestReply returns the reply to a request the server sends outside
+// of a call (with both "method" and "id"), or nil when msg is not one. There
+// is no client waiting to answer it: ping is answered, anything else is
+// rejected as not supported so the server does not wait for ever.
+func serverRequestReply(msg []byte) []byte {
+	var probe struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	if json.Unmarshal(msg, &probe) != nil || probe.Method == "" || len(probe.ID) == 0 {
+		return nil
+	}
+	reply := map[string]interface{}{"jsonrpc": "2.0", "id": probe.ID}
+	if probe.Method == "ping" {
+		reply["result"] = map[string]interface{}{}
+	} else {
+		reply["error"] = map[string]interface{}{
+			"code":    codeMethodNotFound,
+			"message": "Method not supported outside of a client request: " + probe.Method,
+		}
+	}
+	out, err := json.Marshal(reply)
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
 // writeErrorResponse writes a JSON-RPC error response to the response pipe.
-// SECURITY: Error messages are sanitized to prevent internal details from leaking to clients.
 func (c *HTTPClient) writeErrorResponse(rawRequest []byte, err error) {
+	if werr := c.writeMessage(jsonRPCErrorResponse(rawRequest, err)); werr != nil {
+		slog.Warn("failed to write error response to pipe", "error", werr)
+	}
+}
+
+// jsonRPCErrorResponse returns the JSON-RPC error response to a request that
+// could not be sent upstream.
+// SECURITY: Error messages are sanitized to prevent internal details from leaking to clients.
+func jsonRPCErrorResponse(rawRequest []byte, err error) []byte {
 	// M-23: Use json.RawMessage for request ID to preserve exact numeric precision.
 	var requestID json.RawMessage
 	var req struct {
@@ -532,20 +921,17 @@ func (c *HTTPClient) writeErrorResponse(rawRequest []byte, err error) {
 		slog.Warn("failed to marshal error response, using fallback", "error", marshalErr)
 		respBytes = []byte(`{"jsonrpc":"2.0","id":null,"error":{"code":-32603,"message":"Internal error"}}`)
 	}
-	if _, werr := c.responsePipeWriter.Write(respBytes); werr != nil {
-		slog.Warn("failed to write error response to pipe", "error", werr)
-		return
-	}
-	if _, werr := c.responsePipeWriter.Write([]byte("\n")); werr != nil {
-		slog.Warn("failed to write newline to response pipe", "error", werr)
-	}
+	return respBytes
 }
 
 // Wait blocks until the HTTP connection is closed.
-// Returns nil (HTTP has no process exit like stdio).
+// Returns nil (HTTP has no process exit like stdio), or errSessionExpired
+// when the server ended the session.
 func (c *HTTPClient) Wait() error {
 	<-c.done
-	return nil
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.runErr
 }
 
 // Close terminates the HTTP connection and cleans up resources.
@@ -585,17 +971,20 @@ func (c *HTTPClient) Close() error {
 	c.state = stateClosed
 	c.mu.Unlock()
 
-	// Wait for goroutine to finish (with timeout) outside of lock
-	// Only wait if the client was started (goroutine was spawned)
-	if c.done != nil {
-		timer := time.NewTimer(5 * time.Second)
-		defer timer.Stop()
-		select {
-		case <-c.done:
-			// Clean exit
-		case <-timer.C:
-			errs = append(errs, errors.New("timeout waiting for goroutine"))
-		}
+	// Wait for the request loop and the server stream to finish (with
+	// timeout) outside of lock.
+	stopped := make(chan struct{})
+	go func() {
+		c.wg.Wait()
+		close(stopped)
+	}()
+	timer := time.NewTimer(5 * time.Second)
+	defer timer.Stop()
+	select {
+	case <-stopped:
+		// Clean exit
+	case <-timer.C:
+		errs = append(errs, errors.New("timeout waiting for goroutine"))
 	}
 
 	// Re-acquire lock to close response pipes and reset state
@@ -649,3 +1038,6 @@ func (r *bytesReader) Read(p []byte) (n int, err error) {
 
 // Compile-time check that HTTPClient implements MCPClient interface.
 var _ outbound.MCPClient = (*HTTPClient)(nil)
+
+// Compile-time check that requestWriter can carry per-message headers.
+var _ proxy.UpstreamHeaderWriter = (*requestWriter)(nil)
@@ -750,7 +750,7 @@ func TestHTTPClient_SSEMultiLineData(t *testing.T) {
 		// L-28: Pass original request with matching id for response correlation.
 		client := NewHTTPClient("http://unused")
 		originalReq := []byte(`{"jsonrpc":"2.0","id":1,"method":"test"}`)
-		resp, err := client.handleSSEResponse(sseBody, originalReq)
+		resp, err := client.handleSSEResponse(context.Background(), sseBody, originalReq, nil)
 		if err != nil {
 			// Multi-line data joined with \n won't be valid JSON (embedded newlines).
 			// This is expected — the SSE spec says to join with \n, but real servers
@@ -919,3 +919,215 @@ func TestIsJSONRPCNotification(t *testing.T) {
 		})
 	}
 }
+
+// TestHTTPClient_PerMessageHeaders verifies that headers passed through
+// WriteWithHeaders reach only the HTTP POST for that message, and that
+// protocol headers cannot be overridden.
+func TestHTTPClient_PerMessageHeaders(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	authByID := make(chan [2]string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		authByID <- [2]string{string(req.ID), r.Header.Get("Authorization") + "|" + r.Header.Get("Content-Type")}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":{}}`, req.ID)
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(server.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	writer, reader, err := client.Start(ctx)
+	if err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	hw, ok := writer.(interface {
+		WriteWithHeaders(p []byte, headers map[string]string) (int, error)
+	})
+	if !ok {
+		t.Fatal("HTTP client writer should accept per-message headers")
+	}
+
+	scanner := bufio.NewScanner(reader)
+
+	_, _ = hw.WriteWithHeaders([]byte(`{"jsonrpc":"2.0","method":"tools/call","id":1}`), map[string]string{
+		"Authorization": "Bearer user-token",
+		"Content-Type":  "text/plain",
+	})
+	if !scanner.Scan() {
+		t.Fatalf("expected first response: %v", scanner.Err())
+	}
+	_, _ = writer.Write([]byte(`{"jsonrpc":"2.0","method":"tools/call","id":2}` + "\n"))
+	if !scanner.Scan() {
+		t.Fatalf("expected second response: %v", scanner.Err())
+	}
+
+	first := <-authByID
+	second := <-authByID
+	if first != [2]string{"1", "Bearer user-token|application/json"} {
+		t.Errorf("first request headers = %v", first)
+	}
+	if second != [2]string{"2", "|application/json"} {
+		t.Errorf("second request must not inherit headers, got %v", second)
+	}
+
+	_ = client.Close()
+}
+
+// TestHTTPClient_SSEResume verifies that an SSE response stream that breaks
+// before the response is resumed with a GET carrying Last-Event-ID.
+func TestHTTPClient_SSEResume(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	lastEventID := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		if r.Method == http.MethodGet {
+			lastEventID <- r.Header.Get("Last-Event-ID")
+			fmt.Fprint(w, "id: 2\ndata: {\"jsonrpc\":\"2.0\",\"id\":1,\"result\":{\"resumed\":true}}\n\n")
+			return
+		}
+		// The stream ends after a progress notification, before the response.
+		fmt.Fprint(w, "retry: 10\nid: 1\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\"}\n\n")
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(server.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	writer, reader, err := client.Start(ctx)
+	if err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	_, _ = writer.Write([]byte(`{"jsonrpc":"2.0","method":"tools/call","id":1}` + "\n"))
+
+	scanner := bufio.NewScanner(reader)
+	if !scanner.Scan() {
+		t.Fatalf("expected response: %v", scanner.Err())
+	}
+	if got := scanner.Text(); !strings.Contains(got, `"resumed":true`) {
+		t.Errorf("response = %s, want the resumed one", got)
+	}
+	if got := <-lastEventID; got != "1" {
+		t.Errorf("Last-Event-ID = %q, want 1", got)
+	}
+	_ = client.Close()
+}
+
+// TestHTTPClient_ServerStream verifies that WithServerStream opens the GET
+// stream after initialization, relays its notifications and answers the
+// server's ping requests.
+func TestHTTPClient_ServerStream(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	replies := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			if r.Header.Get("Mcp-Session-Id") != "sess-1" {
+				http.Error(w, "missing session", http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "data: {\"jsonrpc\":\"2.0\",\"id\":\"srv-1\",\"method\":\"ping\"}\n\n")
+			fmt.Fprint(w, "data: {\"jsonrpc\":\"2.0\",\n")
+			fmt.Fprint(w, "data:  \"method\":\"notifications/resources/updated\"}\n\n")
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+			return
+		}
+		var msg struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		raw, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(raw, &msg)
+		switch {
+		case msg.Method == "initialize":
+			w.Header().Set("Mcp-Session-Id", "sess-1")
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":{}}`, msg.ID)
+		case msg.Method == "":
+			replies <- string(raw)
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(server.URL, WithServerStream())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	writer, reader, err := client.Start(ctx)
+	if err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	scanner := bufio.NewScanner(reader)
+	_, _ = writer.Write([]byte(`{"jsonrpc":"2.0","method":"initialize","id":1}` + "\n"))
+	if !scanner.Scan() {
+		t.Fatalf("expected initialize response: %v", scanner.Err())
+	}
+	_, _ = writer.Write([]byte(`{"jsonrpc":"2.0","method":"notifications/initialized"}` + "\n"))
+
+	if !scanner.Scan() {
+		t.Fatalf("expected a notification from the server stream: %v", scanner.Err())
+	}
+	if got := scanner.Text(); got != `{"jsonrpc":"2.0","method":"notifications/resources/updated"}` {
+		t.Errorf("relayed message = %s", got)
+	}
+	select {
+	case reply := <-replies:
+		if !strings.Contains(reply, `"id":"srv-1"`) || !strings.Contains(reply, `"result":{}`) {
+			t.Errorf("ping reply = %s", reply)
+		}
+	case <-ctx.Done():
+		t.Fatal("the server ping was not answered")
+	}
+	_ = client.Close()
+}
+
+// TestHTTPClient_SessionExpired verifies that a 404 for a request carrying a
+// session ID ends the run with errSessionExpired and forgets the session.
+func TestHTTPClient_SessionExpired(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Mcp-Session-Id") != "" {
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Mcp-Session-Id", "sess-1")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"result":{}}`)
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(server.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	writer, reader, err := client.Start(ctx)
+	if err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	scanner := bufio.NewScanner(reader)
+	_, _ = writer.Write([]byte(`{"jsonrpc":"2.0","method":"initialize","id":1}` + "\n"))
+	if !scanner.Scan() {
+		t.Fatalf("expected initialize response: %v", scanner.Err())
+	}
+	_, _ = writer.Write([]byte(`{"jsonrpc":"2.0","method":"tools/list","id":2}` + "\n"))
+	if !scanner.Scan() || !strings.Contains(scanner.Text(), `"error"`) {
+		t.Fatalf("expected an error response, got %q", scanner.Text())
+	}
+	if err := client.Wait(); !errors.Is(err, errSessionExpired) {
+		t.Errorf("Wait() = %v, want errSessionExpired", err)
+	}
+	if client.sessionID != "" {
+		t.Errorf("session ID = %q after expiry, want none", client.sessionID)
+	}
+	_ = client.Close()
+}
@@ -0,0 +1,625 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/port/outbound"
+)
+
+// openAPIProtocolVersion is answered on initialize when the client does not
+// send a protocol version.
+const openAPIProtocolVersion = "2025-11-25"
+
+// JSON-RPC error codes answered by the OpenAPI bridge.
+const (
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// OpenAPIClient exposes the operations of a REST API described by an
+// OpenAPI 3 document as MCP tools. It answers the MCP protocol itself:
+// tools/list lists one tool per operation and tools/call sends the
+// operation's HTTP request. Because it is an ordinary upstream, every call
+// passes through the proxy's interceptor chain and policies before it
+// reaches the API.
+// It implements the outbound.MCPClient interface.
+type OpenAPIClient struct {
+	specSource     string            // URL or file path of the OpenAPI document or tool mapping
+	toolMapping    bool              // specSource is a shim tool mapping
+	baseURL        string            // overrides the document's servers when set
+	headers        map[string]string // added to every request
+	httpClient     *http.Client
+	requestTimeout time.Duration
+	credentials    *Credentials // added to every request
+
+	mu    sync.Mutex
+	state clientState
+	spec  *openAPISpec // loaded by the first successful Start
+	base  string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	calls  sync.WaitGroup // in-flight tools/call requests
+
+	writeMu            sync.Mutex // serializes messages on the response pipe
+	requestPipeReader  *io.PipeReader
+	requestPipeWriter  *io.PipeWriter
+	responsePipeReader *io.PipeReader
+	responsePipeWriter *io.PipeWriter
+
+	done chan struct{}
+}
+
+// OpenAPIOption is a functional option for configuring OpenAPIClient.
+type OpenAPIOption func(*OpenAPIClient)
+
+// WithOpenAPIHTTPClient sets a custom HTTP client.
+func WithOpenAPIHTTPClient(client *http.Client) OpenAPIOption {
+	return func(c *OpenAPIClient) {
+		c.httpClient = client
+	}
+}
+
+// WithOpenAPITimeout sets the timeout of each API request.
+func WithOpenAPITimeout(d time.Duration) OpenAPIOption {
+	return func(c *OpenAPIClient) {
+		c.requestTimeout = d
+	}
+}
+
+// WithOpenAPISSRFProtection rejects connections to private, loopback and
+// link-local IPs at TCP connect time, like WithSSRFProtection.
+func WithOpenAPISSRFProtection() OpenAPIOption {
+	return func(c *OpenAPIClient) {
+		if t, ok := c.httpClient.Transport.(*http.Transport); ok {
+			t.DialContext = ssrfSafeDialer().DialContext
+		}
+	}
+}
+
+// WithOpenAPIEgressTLS verifies the server certificate like WithEgressTLS,
+// for API requests and for fetching the document.
+func WithOpenAPIEgressTLS(p *EgressTLSPolicy, upstreamName string) OpenAPIOption {
+	return func(c *OpenAPIClient) {
+		applyEgressTLS(c.httpClient.Transport, p, upstreamName)
+	}
+}
+
+// WithOpenAPIToolMapping reads the document as a shim tool mapping (see
+// parseToolMapping) instead of an OpenAPI document, for backends that do
+// not publish one.
+func WithOpenAPIToolMapping() OpenAPIOption {
+	return func(c *OpenAPIClient) {
+		c.toolMapping = true
+	}
+}
+
+// WithOpenAPICredentials authenticates every API request, and the request
+// fetching the document, with creds.
+func WithOpenAPICredentials(creds *Credentials) OpenAPIOption {
+	return func(c *OpenAPIClient) {
+		c.credentials = creds
+	}
+}
+
+// NewOpenAPIClient creates a client for the API described by the OpenAPI
+// document at spec (an http(s) URL or a file path). baseURL overrides the
+// first server listed in the document; headers are sent with every request,
+// including the one fetching the document.
+func NewOpenAPIClient(spec, baseURL string, headers map[string]string, opts ...OpenAPIOption) *OpenAPIClient {
+	c := &OpenAPIClient{
+		specSource: spec,
+		baseURL:    baseURL,
+		headers:    headers,
+		httpClient: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{
+					MinVersion: tls.VersionTLS12, // SECU-01: TLS 1.2 minimum
+				},
+				MaxIdleConns:        10,
+				MaxIdleConnsPerHost: 5,
+				IdleConnTimeout:     90 * time.Second,
+			},
+		},
+		requestTimeout: defaultRequestTimeout,
+		done:           make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.httpClient = withCredentials(c.httpClient, c.credentials)
+
+	return c
+}
+
+// Start loads the OpenAPI document (once per client) and returns the pipes
+// MCP messages are exchanged on. A document that cannot be loaded or parsed
+// fails Start, so the upstream reports the error.
+func (c *OpenAPIClient) Start(ctx context.Context) (io.WriteCloser, io.ReadCloser, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.state {
+	case stateStarted:
+		return nil, nil, errors.New("client already started")
+	case stateClosed:
+		return nil, nil, errors.New("client is closed, create a new instance")
+	case stateNew:
+		// Proceed with start
+	}
+
+	if c.spec == nil {
+		spec, err := c.loadSpec(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		base, err := spec.resolveBaseURL(c.baseURL, c.specSource)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(spec.skipped) > 0 {
+			slog.Warn("OpenAPI operations without a JSON request body are not exposed as tools",
+				"spec", c.specSource, "operations", spec.skipped)
+		}
+		c.spec, c.base = spec, base
+	}
+
+	c.state = stateStarted
+	c.done = make(chan struct{})
+	c.ctx, c.cancel = context.WithCancel(ctx)
+	c.requestPipeReader, c.requestPipeWriter = io.Pipe()
+	c.responsePipeReader, c.responsePipeWriter = io.Pipe()
+
+	go c.serve()
+
+	return c.requestPipeWriter, c.responsePipeReader, nil
+}
+
+// loadSpec fetches or reads the OpenAPI document, or the tool mapping of a
+// shim, and parses it.
+func (c *OpenAPIClient) loadSpec(ctx context.Context) (*openAPISpec, error) {
+	data, err := c.readDocument(ctx, c.specSource)
+	if err != nil {
+		return nil, err
+	}
+	if !c.toolMapping {
+		return parseOpenAPISpec(data)
+	}
+	return parseToolMapping(data, func(ref string) (*openAPISpec, error) {
+		source, err := relativeSource(c.specSource, ref)
+		if err != nil {
+			return nil, err
+		}
+		data, err := c.readDocument(ctx, source)
+		if err != nil {
+			return nil, err
+		}
+		spec, err := parseOpenAPISpec(data)
+		if err != nil {
+			return nil, err
+		}
+		// A relative server URL is relative to the OpenAPI document, not to
+		// the mapping file.
+		if base, err := spec.resolveBaseURL("", source); err == nil {
+			spec.serverURL = base
+		}
+		return spec, nil
+	})
+}
+
+// readDocument fetches an http(s) URL or reads a file.
+func (c *OpenAPIClient) readDocument(ctx context.Context, source string) ([]byte, error) {
+	if !isURLSource(source) {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", c.documentKind(), err)
+		}
+		return data, nil
+	}
+	reqCtx, cancel := context.WithTimeout(ctx, c.requestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", c.documentKind(), err)
+	}
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", c.documentKind(), unwrapURLError(err))
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch %s: HTTP %d", c.documentKind(), resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBodySize))
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", c.documentKind(), err)
+	}
+	return data, nil
+}
+
+func (c *OpenAPIClient) documentKind() string {
+	if c.toolMapping {
+		return "tool mapping"
+	}
+	return "OpenAPI document"
+}
+
+func isURLSource(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// relativeSource resolves ref, a URL or file path named in the document at
+// base, against base. A document fetched over HTTP can only name URLs, so
+// a remote mapping cannot make the proxy read local files.
+func relativeSource(base, ref string) (string, error) {
+	if !isURLSource(base) {
+		if isURLSource(ref) || filepath.IsAbs(ref) {
+			return ref, nil
+		}
+		return filepath.Join(filepath.Dir(base), ref), nil
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("invalid document URL: %w", err)
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("invalid openapi reference %q: %w", ref, err)
+	}
+	resolved := baseURL.ResolveReference(refURL).String()
+	if !isURLSource(resolved) {
+		return "", fmt.Errorf("openapi reference %q must be an http(s) URL", ref)
+	}
+	return resolved, nil
+}
+
+// serve reads JSON-RPC messages from the request pipe and answers them.
+// Tool calls run concurrently; every other message is answered in order.
+func (c *OpenAPIClient) serve() {
+	defer close(c.done)
+	defer func() { _ = c.responsePipeWriter.Close() }()
+	defer c.calls.Wait()
+	defer func() { _ = c.requestPipeReader.CloseWithError(errors.New("pipe goroutine exited")) }()
+
+	scanner := bufio.NewScanner(c.requestPipeReader)
+	buf := make([]byte, 0, scannerInitialBufSize)
+	scanner.Buffer(buf, scannerMaxBufSize)
+
+	for scanner.Scan() {
+		if c.ctx.Err() != nil {
+			return
+		}
+		raw := scanner.Bytes()
+		if len(raw) == 0 {
+			continue
+		}
+
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.Unmarshal(raw, &req); err != nil || len(req.ID) == 0 || string(req.ID) == "null" {
+			continue // notifications and unparseable lines need no answer
+		}
+
+		switch req.Method {
+		case "initialize":
+			c.writeResult(req.ID, c.initializeResult(req.Params))
+		case "ping":
+			c.writeResult(req.ID, struct{}{})
+		case "tools/list":
+			c.writeResult(req.ID, map[string]interface{}{"tools": c.toolList()})
+		case "tools/call":
+			id, params := req.ID, req.Params
+			c.calls.Add(1)
+			go func() {
+				defer c.calls.Done()
+				result, code, msg := c.callTool(params)
+				if code != 0 {
+					c.writeError(id, code, msg)
+					return
+				}
+				c.writeResult(id, result)
+			}()
+		default:
+			c.writeError(req.ID, codeMethodNotFound, "Method not found: "+req.Method)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		slog.Warn("scanner error reading request pipe", "error", err)
+	}
+}
+
+func (c *OpenAPIClient) initializeResult(params json.RawMessage) map[string]interface{} {
+	var p struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	_ = json.Unmarshal(params, &p)
+	version := p.ProtocolVersion
+	if version == "" {
+		version = openAPIProtocolVersion
+	}
+	name := c.spec.title
+	if name == "" {
+		name = "openapi"
+	}
+	return map[string]interface{}{
+		"protocolVersion": version,
+		"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+		"serverInfo":      map[string]interface{}{"name": name, "version": c.spec.version},
+	}
+}
+
+func (c *OpenAPIClient) toolList() []map[string]interface{} {
+	tools := make([]map[string]interface{}, 0, len(c.spec.operations))
+	for _, op := range c.spec.operations {
+		tools = append(tools, map[string]interface{}{
+			"name":        op.tool,
+			"description": op.description,
+			"inputSchema": op.inputSchema,
+		})
+	}
+	return tools
+}
+
+// callTool sends the HTTP request of the called operation. API and network
+// errors are returned as tool results with isError set; a non-zero code is
+// a JSON-RPC error for an unknown tool or malformed parameters.
+func (c *OpenAPIClient) callTool(params json.RawMessage) (result map[string]interface{}, code int, msg string) {
+	var p struct {
+		Name      string                 `json:"name"`
+		Arguments map[string]interface{} `json:"arguments"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(params))
+	dec.UseNumber()
+	if err := dec.Decode(&p); err != nil {
+		return nil, codeInvalidParams, "Invalid params"
+	}
+	op, ok := c.spec.byTool[p.Name]
+	if !ok {
+		return nil, codeInvalidParams, "Unknown tool: " + p.Name
+	}
+
+	ctx, cancel := context.WithTimeout(c.ctx, c.requestTimeout)
+	defer cancel()
+	req, err := c.buildRequest(ctx, op, p.Arguments)
+	if err != nil {
+		return toolResult(err.Error(), true), 0, ""
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return toolResult("request failed: "+unwrapURLError(err).Error(), true), 0, ""
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBodySize+1))
+	if err != nil {
+		return toolResult("read response: "+unwrapURLError(err).Error(), true), 0, ""
+	}
+	if len(body) > maxResponseBodySize {
+		return toolResult(fmt.Sprintf("response exceeds %d bytes", maxResponseBodySize), true), 0, ""
+	}
+
+	text := string(body)
+	if !utf8.Valid(body) {
+		text = fmt.Sprintf("binary response (%d bytes, %s)", len(body), resp.Header.Get("Content-Type"))
+	}
+	if resp.StatusCode >= 400 {
+		return toolResult(fmt.Sprintf("HTTP %s\n%s", resp.Status, text), true), 0, ""
+	}
+	return toolResult(text, false), 0, ""
+}
+
+// buildRequest turns the tool arguments into the operation's HTTP request.
+// Configured headers are applied last so arguments cannot replace them.
+func (c *OpenAPIClient) buildRequest(ctx context.Context, op *openAPIOperation, args map[string]interface{}) (*http.Request, error) {
+	path := op.path
+	query := url.Values{}
+	header := http.Header{}
+	for _, param := range op.params {
+		v, ok := args[param.name]
+		if !ok || v == nil {
+			if param.required {
+				return nil, fmt.Errorf("missing required argument %q", param.name)
+			}
+			continue
+		}
+		switch param.in {
+		case "path":
+			path = strings.ReplaceAll(path, "{"+param.name+"}", url.PathEscape(formatParam(v)))
+		case "query":
+			addQueryParam(query, param.name, v)
+		case "header":
+			header.Set(param.name, formatParam(v))
+		}
+	}
+
+	var body io.Reader
+	if op.bodyArg != "" {
+		if v, ok := args[op.bodyArg]; ok {
+			data, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("encode request body: %w", err)
+			}
+			body = bytes.NewReader(data)
+		}
+	}
+
+	// Mapped tools send the arguments that are not parameters as a JSON
+	// object body, or as query parameters for methods without a body.
+	if op.restIn != "" {
+		rest := make(map[string]interface{})
+		for name, v := range args {
+			if !op.hasParam(name) {
+				rest[name] = v
+			}
+		}
+		if op.restIn == "query" {
+			for name, v := range rest {
+				addQueryParam(query, name, v)
+			}
+		} else {
+			data, err := json.Marshal(rest)
+			if err != nil {
+				return nil, fmt.Errorf("encode request body: %w", err)
+			}
+			body = bytes.NewReader(data)
+		}
+	}
+
+	target := c.base + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, op.method, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+
+	req.Header = header
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	return req, nil
+}
+
+// addQueryParam adds a query argument, once per item when it is a list.
+func addQueryParam(query url.Values, name string, v interface{}) {
+	if list, ok := v.([]interface{}); ok {
+		for _, item := range list {
+			query.Add(name, formatParam(item))
+		}
+		return
+	}
+	query.Set(name, formatParam(v))
+}
+
+// formatParam renders a path, query or header argument.
+func formatParam(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case json.Number:
+		return val.String()
+	case bool:
+		return strconv.FormatBool(val)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		data, _ := json.Marshal(val)
+		return string(data)
+	}
+}
+
+func toolResult(text string, isError bool) map[string]interface{} {
+	return map[string]interface{}{
+		"content": []map[string]interface{}{{"type": "text", "text": text}},
+		"isError": isError,
+	}
+}
+
+// unwrapURLError drops the request URL from HTTP client errors; it can hold
+// resolved secret references.
+func unwrapURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
+
+func (c *OpenAPIClient) writeResult(id json.RawMessage, result interface{}) {
+	c.writeMessage(map[string]interface{}{"jsonrpc": "2.0", "id": id, "result": result})
+}
+
+func (c *OpenAPIClient) writeError(id json.RawMessage, code int, message string) {
+	c.writeMessage(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"error":   map[string]interface{}{"code": code, "message": message},
+	})
+}
+
+// writeMessage writes one newline-terminated message to the response pipe.
+func (c *OpenAPIClient) writeMessage(msg map[string]interface{}) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		slog.Warn("failed to marshal OpenAPI bridge response", "error", err)
+		data = []byte(`{"jsonrpc":"2.0","id":null,"error":{"code":-32603,"message":"Internal error"}}`)
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if _, err := c.responsePipeWriter.Write(append(data, '\n')); err != nil {
+		slog.Debug("OpenAPI bridge response dropped, pipe closed", "error", err)
+	}
+}
+
+// Wait blocks until the client is closed.
+func (c *OpenAPIClient) Wait() error {
+	c.mu.Lock()
+	done := c.done
+	c.mu.Unlock()
+	<-done
+	return nil
+}
+
+// Close cancels in-flight API requests and closes the pipes. Close is
+// idempotent, and the client can be started again afterwards.
+func (c *OpenAPIClient) Close() error {
+	c.mu.Lock()
+	if c.state != stateStarted {
+		c.mu.Unlock()
+		return nil
+	}
+	c.state = stateClosed
+	c.cancel()
+	_ = c.requestPipeWriter.Close()
+	_ = c.responsePipeReader.Close()
+	done := c.done
+	c.mu.Unlock()
+
+	var err error
+	timer := time.NewTimer(5 * time.Second)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		err = errors.New("timeout waiting for goroutine")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.httpClient.CloseIdleConnections()
+	c.state = stateNew
+	return err
+}
+
+// Compile-time interface verification.
+var _ outbound.MCPClient = (*OpenAPIClient)(nil)
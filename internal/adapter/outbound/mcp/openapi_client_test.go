@@ -0,0 +1,343 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+)
+
+const petstoreSpec = `
+openapi: 3.0.3
+info:
+  title: Petstore
+  version: "1.2"
+servers:
+  - url: https://{host}/v1
+    variables:
+      host:
+        default: api.example.com
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      summary: List pets
+      parameters:
+        - name: limit
+          in: query
+          schema: {type: integer}
+        - name: tag
+          in: query
+          schema: {type: array, items: {type: string}}
+    post:
+      operationId: create pet
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema: {$ref: '#/components/schemas/Pet'}
+  /pets/{petId}:
+    parameters:
+      - $ref: '#/components/parameters/PetId'
+    get:
+      summary: Get a pet
+    delete:
+      operationId: deletePet
+      parameters:
+        - name: X-Reason
+          in: header
+          required: true
+          schema: {type: string}
+components:
+  parameters:
+    PetId:
+      name: petId
+      in: path
+      description: The pet ID
+      schema: {type: string}
+  schemas:
+    Pet:
+      type: object
+      properties:
+        name: {type: string}
+        parent: {$ref: '#/components/schemas/Pet'}
+`
+
+func TestParseOpenAPISpec(t *testing.T) {
+	spec, err := parseOpenAPISpec([]byte(petstoreSpec))
+	if err != nil {
+		t.Fatalf("parseOpenAPISpec() error: %v", err)
+	}
+	if spec.title != "Petstore" || spec.version != "1.2" {
+		t.Errorf("info = %q %q", spec.title, spec.version)
+	}
+	if spec.serverURL != "https://api.example.com/v1" {
+		t.Errorf("serverURL = %q", spec.serverURL)
+	}
+
+	var names []string
+	for _, op := range spec.operations {
+		names = append(names, op.tool)
+	}
+	want := "listPets,create_pet,get_pets_petId,deletePet"
+	if got := strings.Join(names, ","); got != want {
+		t.Fatalf("tools = %s, want %s", got, want)
+	}
+
+	get := spec.byTool["get_pets_petId"]
+	if get.description != "Get a pet" || get.method != "GET" {
+		t.Errorf("get = %+v", get)
+	}
+	props := get.inputSchema["properties"].(map[string]interface{})
+	petID := props["petId"].(map[string]interface{})
+	if petID["description"] != "The pet ID" {
+		t.Errorf("petId schema = %v", petID)
+	}
+	if req := get.inputSchema["required"].([]string); len(req) != 1 || req[0] != "petId" {
+		t.Errorf("required = %v", req)
+	}
+
+	create := spec.byTool["create_pet"]
+	if create.bodyArg != "body" {
+		t.Fatalf("bodyArg = %q", create.bodyArg)
+	}
+	body := create.inputSchema["properties"].(map[string]interface{})["body"].(map[string]interface{})
+	parent := body["properties"].(map[string]interface{})["parent"].(map[string]interface{})
+	if len(parent) != 0 {
+		t.Errorf("recursive $ref should resolve to an empty schema, got %v", parent)
+	}
+	if _, err := json.Marshal(create.inputSchema); err != nil {
+		t.Errorf("input schema not JSON-encodable: %v", err)
+	}
+}
+
+func TestParseOpenAPISpec_Rejects(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+	}{
+		{"swagger 2", `{"swagger":"2.0","paths":{}}`},
+		{"no version", `{"paths":{"/a":{"get":{}}}}`},
+		{"no operations", `{"openapi":"3.1.0","paths":{}}`},
+		{"only non-JSON bodies", `{"openapi":"3.1.0","paths":{"/a":{"post":{"requestBody":{"content":{"text/plain":{}}}}}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseOpenAPISpec([]byte(tt.doc)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestParseOpenAPISpec_SkipsNonJSONBodies(t *testing.T) {
+	doc := `{"openapi":"3.1.0","paths":{"/upload":{
+		"get":{"operationId":"listUploads"},
+		"post":{"operationId":"upload","requestBody":{"content":{"multipart/form-data":{}}}}}}}`
+	spec, err := parseOpenAPISpec([]byte(doc))
+	if err != nil {
+		t.Fatalf("parseOpenAPISpec() error: %v", err)
+	}
+	if len(spec.operations) != 1 || spec.operations[0].tool != "listUploads" {
+		t.Errorf("operations = %v", spec.operations)
+	}
+	if len(spec.skipped) != 1 || spec.skipped[0] != "POST /upload" {
+		t.Errorf("skipped = %v", spec.skipped)
+	}
+}
+
+func TestOpenAPISpec_ResolveBaseURL(t *testing.T) {
+	spec := &openAPISpec{serverURL: "/api"}
+	if got, err := spec.resolveBaseURL("", "https://example.com/openapi.json"); err != nil || got != "https://example.com/api" {
+		t.Errorf("relative server = %q, %v", got, err)
+	}
+	if got, err := spec.resolveBaseURL("https://override.example.com/", "spec.yaml"); err != nil || got != "https://override.example.com" {
+		t.Errorf("override = %q, %v", got, err)
+	}
+	if _, err := spec.resolveBaseURL("", "spec.yaml"); err == nil {
+		t.Error("relative server with a file spec should fail")
+	}
+}
+
+// openAPIExchange sends one JSON-RPC request and returns the decoded response.
+func openAPIExchange(t *testing.T, w io.Writer, scanner *bufio.Scanner, req string) map[string]interface{} {
+	t.Helper()
+	if _, err := io.WriteString(w, req+"\n"); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if !scanner.Scan() {
+		t.Fatalf("no response: %v", scanner.Err())
+	}
+	var resp map[string]interface{}
+	if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+		t.Fatalf("bad response %s: %v", scanner.Text(), err)
+	}
+	return resp
+}
+
+func TestOpenAPIClient_RoundTrip(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	var gotMethod, gotPath, gotQuery, gotAuth, gotReason, gotBody string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotPath, gotQuery = r.Method, r.URL.Path, r.URL.RawQuery
+		gotAuth, gotReason = r.Header.Get("Authorization"), r.Header.Get("X-Reason")
+		data, _ := io.ReadAll(r.Body)
+		gotBody = string(data)
+		if r.URL.Path == "/v1/pets/missing" {
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer api.Close()
+
+	specPath := filepath.Join(t.TempDir(), "petstore.yaml")
+	if err := os.WriteFile(specPath, []byte(petstoreSpec), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewOpenAPIClient(specPath, api.URL+"/v1", map[string]string{"Authorization": "Bearer admin"},
+		WithOpenAPITimeout(5*time.Second))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	w, r, err := client.Start(ctx)
+	if err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	defer func() { _ = client.Close() }()
+	scanner := bufio.NewScanner(r)
+
+	resp := openAPIExchange(t, w, scanner, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18"}}`)
+	result := resp["result"].(map[string]interface{})
+	if result["protocolVersion"] != "2025-06-18" || result["serverInfo"].(map[string]interface{})["name"] != "Petstore" {
+		t.Errorf("initialize result = %v", result)
+	}
+	if _, err := io.WriteString(w, `{"jsonrpc":"2.0","method":"notifications/initialized"}`+"\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	resp = openAPIExchange(t, w, scanner, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	tools := resp["result"].(map[string]interface{})["tools"].([]interface{})
+	if len(tools) != 4 {
+		t.Errorf("tools/list returned %d tools, want 4", len(tools))
+	}
+
+	resp = openAPIExchange(t, w, scanner, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"listPets","arguments":{"limit":10,"tag":["a","b"]}}}`)
+	result = resp["result"].(map[string]interface{})
+	if result["isError"] != false {
+		t.Errorf("listPets isError = %v", result["isError"])
+	}
+	if gotMethod != "GET" || gotPath != "/v1/pets" || gotQuery != "limit=10&tag=a&tag=b" || gotAuth != "Bearer admin" {
+		t.Errorf("listPets request = %s %s?%s auth=%q", gotMethod, gotPath, gotQuery, gotAuth)
+	}
+
+	openAPIExchange(t, w, scanner, `{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"create_pet","arguments":{"body":{"name":"Rex"}}}}`)
+	if gotMethod != "POST" || gotBody != `{"name":"Rex"}` {
+		t.Errorf("create_pet request = %s body %s", gotMethod, gotBody)
+	}
+
+	// Arguments cannot replace the configured headers.
+	resp = openAPIExchange(t, w, scanner, `{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"deletePet","arguments":{"petId":"missing","X-Reason":"gone","Authorization":"Bearer agent"}}}`)
+	result = resp["result"].(map[string]interface{})
+	if result["isError"] != true || !strings.Contains(result["content"].([]interface{})[0].(map[string]interface{})["text"].(string), "404") {
+		t.Errorf("deletePet result = %v", result)
+	}
+	if gotMethod != "DELETE" || gotPath != "/v1/pets/missing" || gotReason != "gone" || gotAuth != "Bearer admin" {
+		t.Errorf("deletePet request = %s %s reason=%q auth=%q", gotMethod, gotPath, gotReason, gotAuth)
+	}
+
+	resp = openAPIExchange(t, w, scanner, `{"jsonrpc":"2.0","id":6,"method":"tools/call","params":{"name":"deletePet","arguments":{"petId":"1"}}}`)
+	if resp["result"].(map[string]interface{})["isError"] != true {
+		t.Errorf("missing required argument should be a tool error, got %v", resp)
+	}
+
+	resp = openAPIExchange(t, w, scanner, `{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"nope"}}`)
+	if code := resp["error"].(map[string]interface{})["code"]; code != float64(codeInvalidParams) {
+		t.Errorf("unknown tool error code = %v", code)
+	}
+
+	resp = openAPIExchange(t, w, scanner, `{"jsonrpc":"2.0","id":8,"method":"resources/list"}`)
+	if code := resp["error"].(map[string]interface{})["code"]; code != float64(codeMethodNotFound) {
+		t.Errorf("resources/list error code = %v", code)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+	if _, _, err := client.Start(ctx); err != nil {
+		t.Fatalf("Start() after Close() error: %v", err)
+	}
+}
+
+func TestOpenAPIClient_ToolMappingRoundTrip(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	var gotMethod, gotPath, gotQuery, gotBody string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotPath, gotQuery = r.Method, r.URL.Path, r.URL.RawQuery
+		data, _ := io.ReadAll(r.Body)
+		gotBody = string(data)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer api.Close()
+
+	mappingPath := filepath.Join(t.TempDir(), "tools.yaml")
+	if err := os.WriteFile(mappingPath, []byte(legacyMapping), 0600); err != nil {
+		t.Fatal(err)
+	}
+	client := NewOpenAPIClient(mappingPath, api.URL, nil, WithOpenAPIToolMapping(), WithOpenAPITimeout(5*time.Second))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	w, r, err := client.Start(ctx)
+	if err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	defer func() { _ = client.Close() }()
+	scanner := bufio.NewScanner(r)
+
+	resp := openAPIExchange(t, w, scanner, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`)
+	if name := resp["result"].(map[string]interface{})["serverInfo"].(map[string]interface{})["name"]; name != "legacy-tools" {
+		t.Errorf("serverInfo.name = %v", name)
+	}
+
+	openAPIExchange(t, w, scanner, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"lookup_user","arguments":{"id":"u/1","fields":["a","b"]}}}`)
+	if gotMethod != "GET" || gotPath != "/users/u/1" || gotQuery != "fields=a&fields=b" || gotBody != "" {
+		t.Errorf("lookup_user request = %s %s?%s body %q", gotMethod, gotPath, gotQuery, gotBody)
+	}
+
+	openAPIExchange(t, w, scanner, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"create_ticket","arguments":{"queue":"ops","dry_run":true,"title":"Disk full"}}}`)
+	if gotMethod != "POST" || gotPath != "/tickets/ops" || gotQuery != "dry_run=true" || gotBody != `{"title":"Disk full"}` {
+		t.Errorf("create_ticket request = %s %s?%s body %s", gotMethod, gotPath, gotQuery, gotBody)
+	}
+
+	resp = openAPIExchange(t, w, scanner, `{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"create_ticket","arguments":{"title":"x"}}}`)
+	if resp["result"].(map[string]interface{})["isError"] != true {
+		t.Errorf("missing path argument should be a tool error, got %v", resp)
+	}
+}
+
+func TestOpenAPIClient_StartFailsOnBadSpec(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	specPath := filepath.Join(t.TempDir(), "bad.yaml")
+	if err := os.WriteFile(specPath, []byte(`swagger: "2.0"`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	client := NewOpenAPIClient(specPath, "", nil)
+	if _, _, err := client.Start(context.Background()); err == nil {
+		_ = client.Close()
+		t.Fatal("Start() should fail for a swagger 2.0 document")
+	}
+}
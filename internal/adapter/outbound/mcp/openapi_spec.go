@@ -0,0 +1,424 @@
+package mcp
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxRefDepth bounds $ref chains and nesting. Recursive and deeper
+// references are replaced with an empty (any value) schema.
+const maxRefDepth = 16
+
+// maxToolNameLength is the longest tool name generated for an operation.
+const maxToolNameLength = 64
+
+// openAPIMethods are the operation keys of a path item, in tool order.
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// toolNameInvalidChars matches characters not allowed in generated tool names.
+var toolNameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// errUnsupportedBody marks operations whose request body cannot be sent as
+// JSON. They are skipped rather than failing the whole document.
+var errUnsupportedBody = errors.New("request body does not support application/json")
+
+// openAPIParam is an operation parameter sent in the path, query or headers.
+type openAPIParam struct {
+	name     string
+	in       string // path, query or header
+	required bool
+}
+
+// openAPIOperation is one REST operation exposed as an MCP tool.
+type openAPIOperation struct {
+	tool        string
+	description string
+	method      string // upper case
+	path        string // path template, e.g. /pets/{petId}
+	params      []openAPIParam
+	bodyArg     string // argument holding the JSON request body, "" if none
+	restIn      string // body or query: where mapped tools send other arguments
+	inputSchema map[string]interface{}
+}
+
+// openAPISpec is the part of an OpenAPI document the bridge uses.
+type openAPISpec struct {
+	title      string
+	version    string
+	serverURL  string // first server URL with variables substituted, may be relative
+	operations []*openAPIOperation
+	byTool     map[string]*openAPIOperation
+	skipped    []string // "METHOD /path" of operations that are not exposed
+}
+
+// parseOpenAPISpec parses an OpenAPI 3.x document in JSON or YAML.
+// Only local references ("#/components/...") are resolved.
+func parseOpenAPISpec(data []byte) (*openAPISpec, error) {
+	var root map[string]interface{}
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("parse OpenAPI document: %w", err)
+	}
+	if root == nil {
+		return nil, errors.New("empty OpenAPI document")
+	}
+	if _, ok := root["swagger"]; ok {
+		return nil, errors.New("swagger 2.0 documents are not supported, convert to OpenAPI 3")
+	}
+	version, _ := root["openapi"].(string)
+	if !strings.HasPrefix(version, "3.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q (3.x required)", version)
+	}
+
+	spec := &openAPISpec{byTool: make(map[string]*openAPIOperation)}
+	if info, ok := root["info"].(map[string]interface{}); ok {
+		spec.title, _ = info["title"].(string)
+		spec.version = fmt.Sprint(valueOr(info["version"], ""))
+	}
+	if servers, ok := root["servers"].([]interface{}); ok && len(servers) > 0 {
+		if server, ok := servers[0].(map[string]interface{}); ok {
+			spec.serverURL = serverURLWithDefaults(server)
+		}
+	}
+
+	r := &refResolver{
+		root:     root,
+		resolved: make(map[string]interface{}),
+		active:   make(map[string]bool),
+	}
+	paths, _ := root["paths"].(map[string]interface{})
+	pathKeys := make([]string, 0, len(paths))
+	for p := range paths {
+		pathKeys = append(pathKeys, p)
+	}
+	sort.Strings(pathKeys)
+
+	for _, p := range pathKeys {
+		item, ok := r.resolve(paths[p], 0).(map[string]interface{})
+		if !ok {
+			continue
+		}
+		shared := r.params(item["parameters"])
+		for _, method := range openAPIMethods {
+			op, ok := item[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			operation, err := r.operation(p, method, op, shared)
+			if errors.Is(err, errUnsupportedBody) {
+				spec.skipped = append(spec.skipped, strings.ToUpper(method)+" "+p)
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", strings.ToUpper(method), p, err)
+			}
+			operation.tool = uniqueToolName(spec.byTool, toolName(op, method, p))
+			spec.byTool[operation.tool] = operation
+			spec.operations = append(spec.operations, operation)
+		}
+	}
+	if len(spec.operations) == 0 {
+		return nil, errors.New("OpenAPI document defines no operations")
+	}
+	return spec, nil
+}
+
+// serverURLWithDefaults substitutes the default values of a server object's
+// variables into its URL.
+func serverURLWithDefaults(server map[string]interface{}) string {
+	u, _ := server["url"].(string)
+	vars, _ := server["variables"].(map[string]interface{})
+	for name, v := range vars {
+		if def, ok := v.(map[string]interface{}); ok {
+			u = strings.ReplaceAll(u, "{"+name+"}", fmt.Sprint(valueOr(def["default"], "")))
+		}
+	}
+	return u
+}
+
+// toolName returns the sanitized operationId, or method_path when the
+// operation has none.
+func toolName(op map[string]interface{}, method, path string) string {
+	name, _ := op["operationId"].(string)
+	if name == "" {
+		name = method + path
+	}
+	name = strings.Trim(toolNameInvalidChars.ReplaceAllString(name, "_"), "_")
+	if name == "" {
+		name = method
+	}
+	if len(name) > maxToolNameLength {
+		name = name[:maxToolNameLength]
+	}
+	return name
+}
+
+// uniqueToolName appends a numeric suffix to name until it is not in taken.
+func uniqueToolName(taken map[string]*openAPIOperation, name string) string {
+	if _, ok := taken[name]; !ok {
+		return name
+	}
+	for i := 2; ; i++ {
+		suffix := "_" + strconv.Itoa(i)
+		base := name
+		if len(base)+len(suffix) > maxToolNameLength {
+			base = base[:maxToolNameLength-len(suffix)]
+		}
+		if _, ok := taken[base+suffix]; !ok {
+			return base + suffix
+		}
+	}
+}
+
+// refResolver resolves local $ref pointers against the document root.
+// Resolved schemas are shared between the operations that reference them
+// and must not be modified.
+type refResolver struct {
+	root     map[string]interface{}
+	resolved map[string]interface{} // schema of each finished reference
+	active   map[string]bool        // references being resolved, to cut cycles
+}
+
+// resolve returns v with its $ref followed, if it has one.
+func (r *refResolver) resolve(v interface{}, depth int) interface{} {
+	for {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return v
+		}
+		ref, ok := m["$ref"].(string)
+		if !ok {
+			return v
+		}
+		if depth >= maxRefDepth {
+			return map[string]interface{}{}
+		}
+		depth++
+		v = r.lookup(ref)
+	}
+}
+
+// lookup returns the value at a local JSON pointer reference, or an empty
+// schema when the reference is remote or does not exist.
+func (r *refResolver) lookup(ref string) interface{} {
+	if !strings.HasPrefix(ref, "#/") {
+		return map[string]interface{}{}
+	}
+	var cur interface{} = r.root
+	for _, tok := range strings.Split(ref[2:], "/") {
+		tok = strings.ReplaceAll(strings.ReplaceAll(tok, "~1", "/"), "~0", "~")
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return map[string]interface{}{}
+		}
+		if cur, ok = m[tok]; !ok {
+			return map[string]interface{}{}
+		}
+	}
+	return cur
+}
+
+// schema returns v with every $ref replaced by its target, so the result
+// is a self-contained JSON schema.
+func (r *refResolver) schema(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if ref, ok := val["$ref"].(string); ok {
+			return r.refSchema(ref)
+		}
+		out := make(map[string]interface{}, len(val))
+		for k, inner := range val {
+			out[k] = r.schema(inner)
+		}
+		return out
+	case map[interface{}]interface{}:
+		// YAML maps with non-string keys (e.g. in examples) are not valid JSON.
+		out := make(map[string]interface{}, len(val))
+		for k, inner := range val {
+			out[fmt.Sprint(k)] = r.schema(inner)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, inner := range val {
+			out[i] = r.schema(inner)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// refSchema returns the resolved schema of ref, resolving each reference
+// once however often it is used.
+func (r *refResolver) refSchema(ref string) interface{} {
+	if s, ok := r.resolved[ref]; ok {
+		return s
+	}
+	if r.active[ref] || len(r.active) >= maxRefDepth {
+		return map[string]interface{}{}
+	}
+	r.active[ref] = true
+	s := r.schema(r.lookup(ref))
+	delete(r.active, ref)
+	r.resolved[ref] = s
+	return s
+}
+
+// propertySchema returns the resolved schema v as a new top-level map with
+// description set, leaving shared resolved schemas untouched.
+func (r *refResolver) propertySchema(v interface{}, description string) map[string]interface{} {
+	resolved, _ := r.schema(v).(map[string]interface{})
+	prop := make(map[string]interface{}, len(resolved)+1)
+	for k, inner := range resolved {
+		prop[k] = inner
+	}
+	if description != "" {
+		prop["description"] = description
+	}
+	return prop
+}
+
+// params resolves a parameters list.
+func (r *refResolver) params(v interface{}) []map[string]interface{} {
+	list, _ := v.([]interface{})
+	out := make([]map[string]interface{}, 0, len(list))
+	for _, p := range list {
+		if m, ok := r.resolve(p, 0).(map[string]interface{}); ok {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// operation builds the tool definition of one operation. Operation-level
+// parameters override path-level ones with the same name and location.
+func (r *refResolver) operation(path, method string, op map[string]interface{}, shared []map[string]interface{}) (*openAPIOperation, error) {
+	operation := &openAPIOperation{
+		method: strings.ToUpper(method),
+		path:   path,
+	}
+	operation.description, _ = op["summary"].(string)
+	if desc, _ := op["description"].(string); desc != "" {
+		if operation.description != "" {
+			operation.description += "\n\n"
+		}
+		operation.description += desc
+	}
+	if operation.description == "" {
+		operation.description = operation.method + " " + path
+	}
+
+	merged := make(map[string]map[string]interface{})
+	var order []string
+	all := append(append([]map[string]interface{}(nil), shared...), r.params(op["parameters"])...)
+	for _, p := range all {
+		name, _ := p["name"].(string)
+		in, _ := p["in"].(string)
+		if name == "" {
+			continue
+		}
+		key := in + ":" + name
+		if _, seen := merged[key]; !seen {
+			order = append(order, key)
+		}
+		merged[key] = p
+	}
+
+	properties := make(map[string]interface{})
+	var required []string
+	for _, key := range order {
+		p := merged[key]
+		name, _ := p["name"].(string)
+		in, _ := p["in"].(string)
+		switch in {
+		case "path", "query", "header":
+		default:
+			continue // cookie parameters are not supported
+		}
+		if _, dup := properties[name]; dup {
+			return nil, fmt.Errorf("parameter %q is defined in more than one location", name)
+		}
+		req, _ := p["required"].(bool)
+		if in == "path" {
+			req = true
+		}
+		desc, _ := p["description"].(string)
+		prop := r.propertySchema(p["schema"], desc)
+		if p["schema"] == nil {
+			prop["type"] = "string"
+		}
+		properties[name] = prop
+		operation.params = append(operation.params, openAPIParam{name: name, in: in, required: req})
+		if req {
+			required = append(required, name)
+		}
+	}
+
+	if body, ok := r.resolve(op["requestBody"], 0).(map[string]interface{}); ok {
+		content, _ := body["content"].(map[string]interface{})
+		media, ok := content["application/json"].(map[string]interface{})
+		if !ok {
+			return nil, errUnsupportedBody
+		}
+		operation.bodyArg = "body"
+		if _, dup := properties["body"]; dup {
+			operation.bodyArg = "request_body"
+		}
+		desc, _ := body["description"].(string)
+		properties[operation.bodyArg] = r.propertySchema(media["schema"], desc)
+		if req, _ := body["required"].(bool); req {
+			required = append(required, operation.bodyArg)
+		}
+	}
+
+	operation.inputSchema = map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		operation.inputSchema["required"] = required
+	}
+	return operation, nil
+}
+
+// resolveBaseURL returns the URL operations are sent to: override when set,
+// otherwise the document's first server, resolved against specURL when it
+// is relative.
+func (s *openAPISpec) resolveBaseURL(override, specURL string) (string, error) {
+	base := override
+	if base == "" {
+		base = s.serverURL
+	}
+	if base == "" {
+		return "", errors.New("no base URL: set the upstream url or list servers in the document")
+	}
+	parsed, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL %q: %w", base, err)
+	}
+	if !parsed.IsAbs() {
+		ref, err := url.Parse(specURL)
+		if err != nil || !ref.IsAbs() {
+			return "", fmt.Errorf("relative server URL %q needs an upstream url", base)
+		}
+		parsed = ref.ResolveReference(parsed)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return "", fmt.Errorf("base URL scheme must be http or https, got %q", parsed.Scheme)
+	}
+	return strings.TrimRight(parsed.String(), "/"), nil
+}
+
+func valueOr(v, def interface{}) interface{} {
+	if v == nil {
+		return def
+	}
+	return v
+}
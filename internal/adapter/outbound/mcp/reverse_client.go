@@ -0,0 +1,355 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/port/outbound"
+)
+
+// maxReverseIdle caps the unused connections kept per reverse upstream. A
+// new registration beyond it replaces the oldest idle connection, so links
+// left behind by a connector that went away do not pile up.
+const maxReverseIdle = 8
+
+var (
+	// ErrReverseNotConnected is returned by ReverseClient.Start when the
+	// server of a reverse upstream has no unused registered connection.
+	ErrReverseNotConnected = errors.New("reverse upstream server is not registered")
+	// ErrReverseHubClosed is returned when registering after Close.
+	ErrReverseHubClosed = errors.New("reverse upstream hub is closed")
+)
+
+// ReverseHub holds the connections opened by servers of reverse upstreams:
+// MCP servers behind NAT or a firewall that dial in to the gateway with
+// their upstream's registration token. Each registered connection is kept
+// idle until a ReverseClient takes it; the upstream manager, tool discovery
+// and conformance checks each use their own.
+type ReverseHub struct {
+	verify func(ctx context.Context, token string) (string, error)
+	logger *slog.Logger
+
+	mu     sync.Mutex
+	idle   map[string][]*reverseLink
+	links  map[string]map[*reverseLink]struct{}
+	closed bool
+
+	attachMu sync.Mutex
+	onAttach func(upstreamID string)
+}
+
+// NewReverseHub creates a hub. verify maps a registration token to the ID
+// of its upstream.
+func NewReverseHub(verify func(ctx context.Context, token string) (string, error), logger *slog.Logger) *ReverseHub {
+	return &ReverseHub{
+		verify: verify,
+		logger: logger,
+		idle:   make(map[string][]*reverseLink),
+		links:  make(map[string]map[*reverseLink]struct{}),
+	}
+}
+
+// SetOnAttach sets a callback run after each registration, used to start
+// the upstream once its server is reachable. Calls are serialized and run
+// off the registering goroutine.
+func (h *ReverseHub) SetOnAttach(fn func(upstreamID string)) {
+	h.attachMu.Lock()
+	defer h.attachMu.Unlock()
+	h.onAttach = fn
+}
+
+// Authenticate returns the upstream ID a registration token belongs to.
+func (h *ReverseHub) Authenticate(ctx context.Context, token string) (string, error) {
+	return h.verify(ctx, token)
+}
+
+// Register adds a connection for upstreamID. The returned channel is closed
+// when the connection ends, whether the peer went away or the gateway
+// closed it.
+func (h *ReverseHub) Register(upstreamID string, conn outbound.ReverseConn) (<-chan struct{}, error) {
+	l := &reverseLink{conn: conn, msgs: make(chan []byte), done: make(chan struct{})}
+
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return nil, ErrReverseHubClosed
+	}
+	if h.links[upstreamID] == nil {
+		h.links[upstreamID] = make(map[*reverseLink]struct{})
+	}
+	h.links[upstreamID][l] = struct{}{}
+	h.idle[upstreamID] = append(h.idle[upstreamID], l)
+	var evicted *reverseLink
+	if idle := h.idle[upstreamID]; len(idle) > maxReverseIdle {
+		evicted = idle[0]
+		h.idle[upstreamID] = idle[1:]
+	}
+	h.mu.Unlock()
+
+	if evicted != nil {
+		evicted.close()
+	}
+	go h.readLoop(upstreamID, l)
+	h.logger.Info("reverse upstream server registered", "upstream_id", upstreamID)
+
+	go func() {
+		h.attachMu.Lock()
+		defer h.attachMu.Unlock()
+		if h.onAttach != nil {
+			h.onAttach(upstreamID)
+		}
+	}()
+	return l.done, nil
+}
+
+// Connections returns the number of registered connections of an upstream,
+// idle or in use.
+func (h *ReverseHub) Connections(upstreamID string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.links[upstreamID])
+}
+
+// Disconnect closes every connection of an upstream, for example after its
+// token was rotated or the upstream was deleted.
+func (h *ReverseHub) Disconnect(upstreamID string) {
+	h.mu.Lock()
+	links := make([]*reverseLink, 0, len(h.links[upstreamID]))
+	for l := range h.links[upstreamID] {
+		links = append(links, l)
+	}
+	h.mu.Unlock()
+	for _, l := range links {
+		l.close()
+	}
+}
+
+// Close closes every connection and refuses new registrations.
+func (h *ReverseHub) Close() error {
+	h.mu.Lock()
+	h.closed = true
+	var links []*reverseLink
+	for _, set := range h.links {
+		for l := range set {
+			links = append(links, l)
+		}
+	}
+	h.mu.Unlock()
+	for _, l := range links {
+		l.close()
+	}
+	return nil
+}
+
+// take removes an idle connection of the upstream from the pool.
+func (h *ReverseHub) take(upstreamID string) (*reverseLink, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for len(h.idle[upstreamID]) > 0 {
+		l := h.idle[upstreamID][0]
+		h.idle[upstreamID] = h.idle[upstreamID][1:]
+		select {
+		case <-l.done:
+			continue
+		default:
+			return l, nil
+		}
+	}
+	return nil, ErrReverseNotConnected
+}
+
+// readLoop reads the messages of a connection for as long as it lives, so
+// a peer that goes away is noticed even while the connection is idle.
+func (h *ReverseHub) readLoop(upstreamID string, l *reverseLink) {
+	defer h.remove(upstreamID, l)
+	for {
+		msg, err := l.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		select {
+		case l.msgs <- msg:
+		case <-l.done:
+			return
+		}
+	}
+}
+
+func (h *ReverseHub) remove(upstreamID string, l *reverseLink) {
+	l.close()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.links[upstreamID], l)
+	if len(h.links[upstreamID]) == 0 {
+		delete(h.links, upstreamID)
+	}
+	idle := h.idle[upstreamID]
+	for i, c := range idle {
+		if c == l {
+			h.idle[upstreamID] = append(idle[:i:i], idle[i+1:]...)
+			break
+		}
+	}
+	if len(h.idle[upstreamID]) == 0 {
+		delete(h.idle, upstreamID)
+	}
+	h.logger.Debug("reverse upstream connection closed", "upstream_id", upstreamID)
+}
+
+// reverseLink is one registered connection.
+type reverseLink struct {
+	conn outbound.ReverseConn
+	msgs chan []byte
+	done chan struct{}
+	once sync.Once
+}
+
+func (l *reverseLink) close() {
+	l.once.Do(func() {
+		_ = l.conn.Close()
+		close(l.done)
+	})
+}
+
+// ReverseClient is the client of a reverse upstream. Start takes one of the
+// connections the upstream's server registered with the hub and exchanges
+// newline-delimited messages over it, like a stdio server.
+// It implements the outbound.MCPClient interface.
+type ReverseClient struct {
+	hub        *ReverseHub
+	upstreamID string
+
+	mu                 sync.Mutex
+	state              clientState
+	link               *reverseLink
+	requestPipeReader  *io.PipeReader
+	requestPipeWriter  *io.PipeWriter
+	responsePipeReader *io.PipeReader
+	responsePipeWriter *io.PipeWriter
+	done               chan struct{}
+}
+
+// NewReverseClient creates a client for the reverse upstream upstreamID.
+func NewReverseClient(hub *ReverseHub, upstreamID string) *ReverseClient {
+	return &ReverseClient{hub: hub, upstreamID: upstreamID}
+}
+
+// Start takes an idle registered connection. It fails with
+// ErrReverseNotConnected when the server has none.
+func (c *ReverseClient) Start(ctx context.Context) (io.WriteCloser, io.ReadCloser, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state == stateStarted {
+		return nil, nil, errors.New("client already started")
+	}
+	link, err := c.hub.take(c.upstreamID)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.link = link
+	c.requestPipeReader, c.requestPipeWriter = io.Pipe()
+	c.responsePipeReader, c.responsePipeWriter = io.Pipe()
+	c.done = make(chan struct{})
+	c.state = stateStarted
+
+	go c.writeLoop(link, c.requestPipeReader)
+	go c.readLoop(link, c.responsePipeWriter, c.done)
+	return c.requestPipeWriter, c.responsePipeReader, nil
+}
+
+// writeLoop sends each line written to the request pipe as one message.
+func (c *ReverseClient) writeLoop(link *reverseLink, r *io.PipeReader) {
+	defer func() { _ = r.CloseWithError(errors.New("reverse connection closed")) }()
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, scannerInitialBufSize), scannerMaxBufSize)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if err := link.conn.WriteMessage(append([]byte(nil), line...)); err != nil {
+			link.close()
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		slog.Warn("scanner error reading request pipe", "upstream_id", c.upstreamID, "error", err)
+	}
+}
+
+// readLoop writes each received message to the response pipe as one line
+// until the connection ends.
+func (c *ReverseClient) readLoop(link *reverseLink, w *io.PipeWriter, done chan struct{}) {
+	defer close(done)
+	defer func() { _ = w.Close() }()
+	for {
+		select {
+		case msg := <-link.msgs:
+			var buf bytes.Buffer
+			if err := json.Compact(&buf, msg); err != nil {
+				slog.Warn("dropping invalid JSON from reverse upstream", "upstream_id", c.upstreamID, "error", err)
+				continue
+			}
+			buf.WriteByte('\n')
+			if _, err := w.Write(buf.Bytes()); err != nil {
+				link.close()
+				return
+			}
+		case <-link.done:
+			return
+		}
+	}
+}
+
+// Wait blocks until the connection ends.
+func (c *ReverseClient) Wait() error {
+	c.mu.Lock()
+	done := c.done
+	c.mu.Unlock()
+	if done == nil {
+		return errors.New("client not started")
+	}
+	<-done
+	return nil
+}
+
+// Close closes the connection, which makes the server's connector register
+// a new one. The client can be started again afterwards.
+func (c *ReverseClient) Close() error {
+	c.mu.Lock()
+	if c.state != stateStarted {
+		c.mu.Unlock()
+		return nil
+	}
+	c.state = stateClosed
+	c.link.close()
+	_ = c.requestPipeWriter.Close()
+	_ = c.responsePipeReader.Close()
+	done := c.done
+	c.mu.Unlock()
+
+	var err error
+	timer := time.NewTimer(5 * time.Second)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		err = fmt.Errorf("timeout waiting for reverse upstream %s to close", c.upstreamID)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.state = stateNew
+	return err
+}
+
+// Compile-time check that ReverseClient implements MCPClient interface.
+var _ outbound.MCPClient = (*ReverseClient)(nil)
@@ -0,0 +1,395 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	mcpwire "github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
+)
+
+// Reverse connector defaults.
+const (
+	reverseDialTimeout  = 10 * time.Second
+	reverseWriteTimeout = 10 * time.Second
+	reverseMinBackoff   = time.Second
+	reverseMaxBackoff   = time.Minute
+	// reverseMaxMessage bounds a message received from the gateway.
+	reverseMaxMessage = 32 << 20
+	// reverseWSGUID is the RFC 6455 key suffix for Sec-WebSocket-Accept.
+	reverseWSGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+)
+
+// ErrRegistrationRejected is returned by ReverseConnector.Run when the
+// gateway refuses the registration token.
+var ErrRegistrationRejected = errors.New("gateway rejected the registration token")
+
+// ReverseConnectorConfig configures a ReverseConnector.
+type ReverseConnectorConfig struct {
+	// GatewayURL is the registration endpoint, ws(s)://host/upstream/register.
+	GatewayURL string
+	// Token is the registration token of the reverse upstream.
+	Token string
+	// Connections is how many connections are kept registered. The gateway
+	// uses one for the upstream itself and others for tool discovery and
+	// checks. Zero uses 2.
+	Connections int
+	// Command and Args start the MCP server, once per connection in use.
+	Command string
+	Args    []string
+	Env     map[string]string
+	// Framing is the server's stdio framing. Empty uses FramingAuto.
+	Framing mcpwire.Framing
+	// TLSConfig is used for wss:// URLs. Nil uses the system roots.
+	TLSConfig *tls.Config
+}
+
+// ReverseConnector runs next to an MCP server the gateway cannot reach and
+// registers it as a reverse upstream: it dials out to the gateway over
+// WebSocket with the upstream's registration token and keeps the configured
+// number of connections registered. The server is started when the gateway
+// first uses a connection and stopped when the connection ends; the
+// connection is then replaced.
+type ReverseConnector struct {
+	cfg    ReverseConnectorConfig
+	target *url.URL
+	logger *slog.Logger
+}
+
+// NewReverseConnector validates the configuration and creates a connector.
+func NewReverseConnector(cfg ReverseConnectorConfig, logger *slog.Logger) (*ReverseConnector, error) {
+	u, err := url.Parse(cfg.GatewayURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gateway URL: %w", err)
+	}
+	if u.Scheme != "ws" && u.Scheme != "wss" {
+		return nil, fmt.Errorf("gateway URL must be ws:// or wss://, got %q", cfg.GatewayURL)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("gateway URL %q has no host", cfg.GatewayURL)
+	}
+	if cfg.Token == "" {
+		return nil, errors.New("registration token is required")
+	}
+	if cfg.Command == "" {
+		return nil, errors.New("server command is required")
+	}
+	if cfg.Connections <= 0 {
+		cfg.Connections = 2
+	}
+	if cfg.Framing == "" {
+		cfg.Framing = mcpwire.FramingAuto
+	}
+	return &ReverseConnector{cfg: cfg, target: u, logger: logger}, nil
+}
+
+// Run keeps the connections registered until ctx is done. It returns
+// ErrRegistrationRejected as soon as the gateway refuses the token.
+func (c *ReverseConnector) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	for i := 0; i < c.cfg.Connections; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.keepRegistered(ctx); err != nil {
+				errOnce.Do(func() { firstErr = err })
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// keepRegistered registers one connection after another, backing off while
+// the gateway is unreachable.
+func (c *ReverseConnector) keepRegistered(ctx context.Context) error {
+	backoff := reverseMinBackoff
+	for ctx.Err() == nil {
+		ws, err := c.dial(ctx)
+		if errors.Is(err, ErrRegistrationRejected) {
+			return err
+		}
+		if err != nil {
+			c.logger.Warn("cannot register with the gateway, retrying", "gateway", c.cfg.GatewayURL, "error", err, "retry_in", backoff)
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+			}
+			backoff = min(backoff*2, reverseMaxBackoff)
+			continue
+		}
+		backoff = reverseMinBackoff
+		c.logger.Debug("registered with the gateway", "gateway", c.cfg.GatewayURL)
+		c.serve(ctx, ws)
+	}
+	return nil
+}
+
+// dial opens a WebSocket connection to the registration endpoint.
+func (c *ReverseConnector) dial(ctx context.Context) (*clientWSConn, error) {
+	host := c.target.Host
+	if c.target.Port() == "" {
+		if c.target.Scheme == "wss" {
+			host = net.JoinHostPort(c.target.Hostname(), "443")
+		} else {
+			host = net.JoinHostPort(c.target.Hostname(), "80")
+		}
+	}
+	dialer := &net.Dialer{Timeout: reverseDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, err
+	}
+	if c.target.Scheme == "wss" {
+		cfg := c.cfg.TLSConfig
+		if cfg == nil {
+			cfg = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		cfg = cfg.Clone()
+		if cfg.ServerName == "" {
+			cfg.ServerName = c.target.Hostname()
+		}
+		tlsConn := tls.Client(conn, cfg)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	ws, err := c.handshake(conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return ws, nil
+}
+
+func (c *ReverseConnector) handshake(conn net.Conn) (*clientWSConn, error) {
+	rawKey := make([]byte, 16)
+	if _, err := rand.Read(rawKey); err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(rawKey)
+
+	reqURL := *c.target
+	reqURL.Scheme = "http"
+	if c.target.Scheme == "wss" {
+		reqURL.Scheme = "https"
+	}
+	req, err := http.NewRequest(http.MethodGet, reqURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Protocol", "mcp")
+	req.Header.Set("Authorization", "Bearer "+c.cfg.Token)
+
+	_ = conn.SetDeadline(time.Now().Add(reverseDialTimeout))
+	if err := req.Write(conn); err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, err
+	}
+	_ = resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return nil, ErrRegistrationRejected
+	case resp.StatusCode != http.StatusSwitchingProtocols:
+		return nil, fmt.Errorf("unexpected handshake response: %s", resp.Status)
+	}
+	h := sha1.New() // fixed by RFC 6455, not a security use
+	h.Write([]byte(key + reverseWSGUID))
+	if resp.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(h.Sum(nil)) {
+		return nil, errors.New("invalid Sec-WebSocket-Accept in handshake response")
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return &clientWSConn{conn: conn, br: br}, nil
+}
+
+// serve waits for the gateway to use the connection, then runs the server
+// and relays messages until either side ends.
+func (c *ReverseConnector) serve(ctx context.Context, ws *clientWSConn) {
+	stop := context.AfterFunc(ctx, func() { ws.close() })
+	defer stop()
+	defer ws.close()
+
+	first, err := ws.readMessage()
+	if err != nil {
+		return
+	}
+
+	client := NewStdioClient(c.cfg.Command, c.cfg.Args...)
+	client.SetFraming(c.cfg.Framing)
+	if len(c.cfg.Env) > 0 {
+		client.SetEnv(c.cfg.Env)
+	}
+	stdin, stdout, err := client.Start(ctx)
+	if err != nil {
+		c.logger.Error("failed to start MCP server", "command", c.cfg.Command, "error", err)
+		return
+	}
+	defer func() { _ = client.Close() }()
+	c.logger.Info("gateway connected, MCP server started", "command", c.cfg.Command)
+
+	go func() {
+		defer ws.close()
+		dec := json.NewDecoder(stdout)
+		for {
+			var msg json.RawMessage
+			if err := dec.Decode(&msg); err != nil {
+				if !errors.Is(err, io.EOF) {
+					c.logger.Warn("reading MCP server output", "error", err)
+				}
+				return
+			}
+			if err := ws.writeMessage(msg); err != nil {
+				return
+			}
+		}
+	}()
+
+	for msg := first; ; {
+		if _, err := stdin.Write(append(msg, '\n')); err != nil {
+			return
+		}
+		if msg, err = ws.readMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// clientWSConn is the client end of a WebSocket connection: frames it
+// writes are masked, frames it reads are not.
+type clientWSConn struct {
+	conn net.Conn
+	br   *bufio.Reader
+
+	wmu    sync.Mutex
+	closed bool
+}
+
+// readMessage returns the next data message, answering pings.
+func (c *clientWSConn) readMessage() ([]byte, error) {
+	var msg []byte
+	for {
+		var hdr [2]byte
+		if _, err := io.ReadFull(c.br, hdr[:]); err != nil {
+			return nil, err
+		}
+		fin, op := hdr[0]&0x80 != 0, hdr[0]&0x0f
+		length := uint64(hdr[1] & 0x7f)
+		switch length {
+		case 126:
+			var ext [2]byte
+			if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+				return nil, err
+			}
+			length = uint64(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+				return nil, err
+			}
+			length = binary.BigEndian.Uint64(ext[:])
+		}
+		if length > reverseMaxMessage-uint64(len(msg)) {
+			return nil, errors.New("message from gateway too large")
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(c.br, payload); err != nil {
+			return nil, err
+		}
+		switch op {
+		case 0x9: // ping
+			if err := c.writeFrame(0xA, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case 0xA: // pong
+			continue
+		case 0x8: // close
+			return nil, io.EOF
+		}
+		msg = append(msg, payload...)
+		if fin {
+			return msg, nil
+		}
+	}
+}
+
+// writeMessage sends a text message.
+func (c *clientWSConn) writeMessage(msg []byte) error {
+	return c.writeFrame(0x1, msg)
+}
+
+func (c *clientWSConn) writeFrame(op byte, payload []byte) error {
+	var mask [4]byte
+	if _, err := rand.Read(mask[:]); err != nil {
+		return err
+	}
+	frame := make([]byte, 0, len(payload)+14)
+	frame = append(frame, 0x80|op)
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, 0x80|byte(n))
+	case n <= 0xffff:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 0x80|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closed {
+		return net.ErrClosed
+	}
+	_ = c.conn.SetWriteDeadline(time.Now().Add(reverseWriteTimeout))
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// close sends a close frame and closes the connection. It is idempotent.
+func (c *clientWSConn) close() {
+	_ = c.writeFrame(0x8, binary.BigEndian.AppendUint16(nil, 1000))
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if !c.closed {
+		c.closed = true
+		_ = c.conn.Close()
+	}
+}
@@ -0,0 +1,134 @@
+package mcp
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// defaultSSERetry is how long to wait before reconnecting an event
+	// stream when the server suggested no delay.
+	defaultSSERetry = time.Second
+
+	// maxSSERetry caps the reconnection delay of event streams, whatever
+	// the server suggests.
+	maxSSERetry = 30 * time.Second
+)
+
+// sseEvent is one event of a text/event-stream body.
+type sseEvent struct {
+	// ID is the last event ID after this event.
+	ID string
+	// Event is the event type, "message" when the server set none.
+	Event string
+	// Data holds the data: lines, joined with newlines.
+	Data string
+}
+
+// sseReader reads the events of a text/event-stream body.
+//
+// SSE format per spec:
+//
+//	event: message
+//	id: 42
+//	data: {"jsonrpc":"2.0","id":1,"result":{...}}
+//	<blank line>
+//
+// Multiple data: lines for the same event are joined with newlines. event:,
+// id:, retry: and comment lines are handled per the spec.
+type sseReader struct {
+	scanner *bufio.Scanner
+	// lastID is the last event ID seen, sent as Last-Event-ID to resume.
+	lastID string
+	// retryMs receives the reconnection delay the server suggests (M-41).
+	retryMs *atomic.Int64
+}
+
+// newSSEReader returns a reader of the events in body. retryMs, when not
+// nil, is updated with the retry: values of the stream.
+func newSSEReader(body io.Reader, retryMs *atomic.Int64) *sseReader {
+	scanner := bufio.NewScanner(body)
+	// Use a generous buffer for SSE — events can contain large JSON payloads.
+	scanner.Buffer(make([]byte, 0, 64*1024), maxResponseBodySize)
+	return &sseReader{scanner: scanner, retryMs: retryMs}
+}
+
+// next returns the next event with data. It returns io.EOF at the end of the
+// stream, or the read error. An event the server did not end with a blank
+// line before closing the stream is still returned.
+func (r *sseReader) next() (sseEvent, error) {
+	var (
+		eventType string
+		dataLines []string
+		hasData   bool
+	)
+	dispatch := func() sseEvent {
+		if eventType == "" {
+			eventType = "message"
+		}
+		return sseEvent{ID: r.lastID, Event: eventType, Data: strings.Join(dataLines, "\n")}
+	}
+
+	for r.scanner.Scan() {
+		line := r.scanner.Text()
+		if line == "" {
+			// Blank line = end of event. Events without data are not dispatched.
+			if hasData {
+				return dispatch(), nil
+			}
+			eventType = ""
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue // comment, often a keep-alive
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		// Trim the optional leading space after the colon.
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "data":
+			dataLines = append(dataLines, value)
+			hasData = true
+		case "event":
+			eventType = value
+		case "id":
+			// An ID containing NUL is ignored per spec.
+			if !strings.ContainsRune(value, 0) {
+				r.lastID = value
+			}
+		case "retry":
+			// M-41: parse server-suggested reconnect delay
+			if val, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64); err == nil && val >= 0 && r.retryMs != nil {
+				r.retryMs.Store(val)
+			}
+		}
+	}
+
+	// Stream ended. Return remaining buffered data (server may omit trailing blank line).
+	if hasData {
+		return dispatch(), nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return sseEvent{}, err
+	}
+	return sseEvent{}, io.EOF
+}
+
+// sseRetryDelay returns the reconnection delay suggested by the server in
+// retryMs, or defaultSSERetry, capped at maxSSERetry.
+func sseRetryDelay(retryMs *atomic.Int64) time.Duration {
+	ms := retryMs.Load()
+	if ms <= 0 {
+		return defaultSSERetry
+	}
+	d := time.Duration(ms) * time.Millisecond
+	if d > maxSSERetry {
+		return maxSSERetry
+	}
+	return d
+}
@@ -0,0 +1,399 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/port/outbound"
+)
+
+// errEventStreamClosed is returned by SSEClient.Wait when the server closed
+// the event stream, which ends the session of the HTTP+SSE transport.
+var errEventStreamClosed = errors.New("upstream event stream closed")
+
+// SSEClient connects to an MCP server over the HTTP+SSE transport of the
+// 2024-11-05 protocol revision, still the only one of many remote servers:
+// the server sends all its messages on a GET event stream, whose first
+// "endpoint" event names the URL the client POSTs its own messages to.
+// The session lives as long as the stream; when it closes, Wait returns
+// and the connection is re-initialized by its owner.
+// It implements the outbound.MCPClient interface.
+type SSEClient struct {
+	url            string // URL of the event stream
+	httpClient     *http.Client
+	requestTimeout time.Duration
+	credentials    *Credentials // added to every request
+
+	mu       sync.Mutex
+	state    clientState
+	endpoint string // POST URL announced by the server, per run
+	runErr   error  // why the run ended, returned by Wait
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	headers            headerQueue
+	writeMu            sync.Mutex // serializes messages on the response pipe
+	requestPipeReader  *io.PipeReader
+	requestPipeWriter  *io.PipeWriter
+	responsePipeReader *io.PipeReader
+	responsePipeWriter *io.PipeWriter
+
+	done chan struct{}
+}
+
+// SSEOption is a functional option for configuring SSEClient.
+type SSEOption func(*SSEClient)
+
+// WithSSETimeout sets the timeout of each message POST and of the wait for
+// the endpoint event.
+func WithSSETimeout(d time.Duration) SSEOption {
+	return func(c *SSEClient) {
+		c.requestTimeout = d
+	}
+}
+
+// WithSSESSRFProtection rejects connections to private, loopback and
+// link-local IPs at TCP connect time, like WithSSRFProtection.
+func WithSSESSRFProtection() SSEOption {
+	return func(c *SSEClient) {
+		if t, ok := c.httpClient.Transport.(*http.Transport); ok {
+			t.DialContext = ssrfSafeDialer().DialContext
+		}
+	}
+}
+
+// WithSSEEgressTLS verifies the server certificate like WithEgressTLS.
+func WithSSEEgressTLS(p *EgressTLSPolicy, upstreamName string) SSEOption {
+	return func(c *SSEClient) {
+		applyEgressTLS(c.httpClient.Transport, p, upstreamName)
+	}
+}
+
+// WithSSECredentials authenticates the event stream and every message POST
+// with creds.
+func WithSSECredentials(creds *Credentials) SSEOption {
+	return func(c *SSEClient) {
+		c.credentials = creds
+	}
+}
+
+// NewSSEClient creates a client for the MCP server whose event stream is at
+// streamURL (often ending in /sse).
+func NewSSEClient(streamURL string, opts ...SSEOption) *SSEClient {
+	c := &SSEClient{
+		url: streamURL,
+		httpClient: &http.Client{
+			// The event stream stays open for the whole session: timeouts
+			// are per POST, through their context.
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{
+					MinVersion: tls.VersionTLS12, // SECU-01: TLS 1.2 minimum
+				},
+				MaxIdleConns:        10,
+				MaxIdleConnsPerHost: 5,
+				IdleConnTimeout:     90 * time.Second,
+			},
+		},
+		requestTimeout: defaultRequestTimeout,
+		done:           make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.httpClient = withCredentials(c.httpClient, c.credentials)
+
+	return c
+}
+
+// Start opens the event stream and waits for the endpoint event, then
+// returns the pipes MCP messages are exchanged on. A server that does not
+// announce an endpoint in time fails Start.
+func (c *SSEClient) Start(ctx context.Context) (io.WriteCloser, io.ReadCloser, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.state {
+	case stateStarted:
+		return nil, nil, errors.New("client already started")
+	case stateClosed:
+		return nil, nil, errors.New("client is closed, create a new instance")
+	case stateNew:
+		// Proceed with start
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	// Only the wait for the endpoint is bounded: the stream itself stays
+	// open until the run ends.
+	timer := time.AfterFunc(c.requestTimeout, cancel)
+	resp, events, endpoint, err := c.connect(runCtx)
+	if !timer.Stop() {
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+		err = fmt.Errorf("no endpoint event within %s", c.requestTimeout)
+	}
+	if err != nil {
+		cancel()
+		c.httpClient.CloseIdleConnections()
+		return nil, nil, err
+	}
+
+	c.state = stateStarted
+	c.endpoint = endpoint
+	c.runErr = nil
+	c.done = make(chan struct{})
+	c.ctx, c.cancel = runCtx, cancel
+	c.requestPipeReader, c.requestPipeWriter = io.Pipe()
+	c.responsePipeReader, c.responsePipeWriter = io.Pipe()
+	c.headers.reset()
+
+	c.wg.Add(2)
+	go c.readStream(resp.Body, events)
+	go c.sendMessages()
+	done := c.done
+	go func() {
+		c.wg.Wait()
+		close(done)
+	}()
+
+	return &requestWriter{q: &c.headers, pw: c.requestPipeWriter}, c.responsePipeReader, nil
+}
+
+// connect opens the event stream and reads it up to the endpoint event,
+// returning the stream and the POST URL it announced.
+func (c *SSEClient) connect(ctx context.Context) (*http.Response, *sseReader, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("http request: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		errBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		_ = resp.Body.Close()
+		return nil, nil, "", fmt.Errorf("http status %d: %s", resp.StatusCode, string(errBody))
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		_ = resp.Body.Close()
+		return nil, nil, "", fmt.Errorf("unexpected content type %q (not an SSE endpoint)", ct)
+	}
+
+	events := newSSEReader(resp.Body, nil)
+	for {
+		ev, err := events.next()
+		if err != nil {
+			_ = resp.Body.Close()
+			if errors.Is(err, io.EOF) {
+				return nil, nil, "", errors.New("event stream closed before the endpoint event")
+			}
+			return nil, nil, "", fmt.Errorf("read endpoint event: %w", err)
+		}
+		if ev.Event != "endpoint" {
+			continue
+		}
+		endpoint, err := c.resolveEndpoint(strings.TrimSpace(ev.Data))
+		if err != nil {
+			_ = resp.Body.Close()
+			return nil, nil, "", err
+		}
+		return resp, events, endpoint, nil
+	}
+}
+
+// resolveEndpoint resolves the URL of the endpoint event against the stream
+// URL. It must be on the same origin: a server cannot have the gateway send
+// messages, and their credentials, to another host.
+func (c *SSEClient) resolveEndpoint(ref string) (string, error) {
+	base, err := url.Parse(c.url)
+	if err != nil {
+		return "", fmt.Errorf("invalid stream URL: %w", err)
+	}
+	rel, err := url.Parse(ref)
+	if err != nil || ref == "" {
+		return "", fmt.Errorf("invalid endpoint %q", ref)
+	}
+	endpoint := base.ResolveReference(rel)
+	if endpoint.Scheme != base.Scheme || endpoint.Host != base.Host {
+		return "", fmt.Errorf("endpoint %q is not on the origin of the stream", ref)
+	}
+	return endpoint.String(), nil
+}
+
+// readStream writes the messages of the event stream to the response pipe,
+// answering the requests the server sends on its own, until the stream or
+// the run ends.
+func (c *SSEClient) readStream(body io.ReadCloser, events *sseReader) {
+	defer c.wg.Done()
+	defer func() { _ = c.responsePipeWriter.Close() }()
+	defer func() { _ = c.requestPipeReader.CloseWithError(errEventStreamClosed) }()
+	defer func() { _ = body.Close() }()
+	defer c.cancel()
+
+	for {
+		ev, err := events.next()
+		if err != nil {
+			if c.ctx.Err() == nil {
+				slog.Warn("upstream event stream closed", "url", c.url, "error", err)
+				c.mu.Lock()
+				c.runErr = errEventStreamClosed
+				c.mu.Unlock()
+			}
+			return
+		}
+		if ev.Event != "message" {
+			continue
+		}
+		msg, ok := compactJSON(ev.Data)
+		if !ok {
+			slog.Debug("dropping invalid message from upstream event stream", "url", c.url)
+			continue
+		}
+		// The stream carries no link between a request of the server and the
+		// call it belongs to, so there is no client to relay it to.
+		if reply := serverRequestReply(msg); reply != nil {
+			if err := c.post(c.ctx, reply, nil); err != nil {
+				slog.Debug("failed to answer upstream request", "url", c.url, "error", err)
+			}
+			continue
+		}
+		if err := c.writeMessage(msg); err != nil {
+			return // Pipe closed
+		}
+	}
+}
+
+// sendMessages POSTs each message of the request pipe to the endpoint. The
+// answers arrive on the event stream.
+func (c *SSEClient) sendMessages() {
+	defer c.wg.Done()
+	// The client closing the request pipe ends the run.
+	defer c.cancel()
+
+	scanner := bufio.NewScanner(c.requestPipeReader)
+	scanner.Buffer(make([]byte, 0, scannerInitialBufSize), scannerMaxBufSize)
+	for scanner.Scan() {
+		if c.ctx.Err() != nil {
+			return
+		}
+		raw := scanner.Bytes()
+		headers := c.headers.pop()
+		if len(raw) == 0 {
+			continue
+		}
+		if err := c.post(c.ctx, raw, headers); err != nil && !isJSONRPCNotification(raw) {
+			if werr := c.writeMessage(jsonRPCErrorResponse(raw, err)); werr != nil {
+				return
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, errEventStreamClosed) {
+		slog.Warn("scanner error reading request pipe", "error", err)
+	}
+}
+
+// post sends one message to the endpoint. Extra headers are applied first,
+// so they can never replace the protocol headers.
+func (c *SSEClient) post(ctx context.Context, body []byte, headers map[string]string) error {
+	reqCtx, reqCancel := context.WithTimeout(ctx, c.requestTimeout)
+	defer reqCancel()
+
+	c.mu.Lock()
+	endpoint := c.endpoint
+	c.mu.Unlock()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, endpoint, newBytesReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.ContentLength = int64(len(body))
+	for k, v := range headers {
+		if !reservedRequestHeaders[http.CanonicalHeaderKey(k)] {
+			req.Header.Set(k, v)
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("http request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		errBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("http status %d: %s", resp.StatusCode, string(errBody))
+	}
+	// The answer comes on the event stream; the body is only an acknowledgement.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBodySize))
+	return nil
+}
+
+// writeMessage writes one newline-terminated message to the response pipe.
+func (c *SSEClient) writeMessage(msg []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_, err := c.responsePipeWriter.Write(append(msg[:len(msg):len(msg)], '\n'))
+	return err
+}
+
+// Wait blocks until the run ends: the client closed, or the server closed
+// the event stream (errEventStreamClosed).
+func (c *SSEClient) Wait() error {
+	c.mu.Lock()
+	done := c.done
+	c.mu.Unlock()
+	<-done
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.runErr
+}
+
+// Close closes the event stream, cancels in-flight POSTs and closes the
+// pipes. Close is idempotent, and the client can be started again
+// afterwards, with a new session.
+func (c *SSEClient) Close() error {
+	c.mu.Lock()
+	if c.state != stateStarted {
+		c.mu.Unlock()
+		return nil
+	}
+	c.state = stateClosed
+	c.cancel()
+	_ = c.requestPipeWriter.Close()
+	_ = c.responsePipeReader.Close()
+	done := c.done
+	c.mu.Unlock()
+
+	var err error
+	timer := time.NewTimer(5 * time.Second)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		err = errors.New("timeout waiting for goroutine")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.httpClient.CloseIdleConnections()
+	c.state = stateNew
+	return err
+}
+
+// Compile-time interface verification.
+var _ outbound.MCPClient = (*SSEClient)(nil)
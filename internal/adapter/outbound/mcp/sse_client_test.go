@@ -0,0 +1,166 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+)
+
+// legacySSEServer is an MCP server on the HTTP+SSE transport: the event
+// stream at /sse announces /messages, whose POSTs are answered on the stream.
+type legacySSEServer struct {
+	*httptest.Server
+	endpoint string
+	events   chan string
+	posts    chan string
+}
+
+func newLegacySSEServer(t *testing.T, endpoint string) *legacySSEServer {
+	t.Helper()
+	s := &legacySSEServer{endpoint: endpoint, events: make(chan string, 8), posts: make(chan string, 8)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sse", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, ": keep-alive\n\nevent: endpoint\ndata: %s\n\n", s.endpoint)
+		w.(http.Flusher).Flush()
+		for {
+			select {
+			case ev, ok := <-s.events:
+				if !ok {
+					return
+				}
+				fmt.Fprintf(w, "event: message\ndata: %s\n\n", ev)
+				w.(http.Flusher).Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	})
+	mux.HandleFunc("/messages", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("sessionId") != "abc" {
+			http.Error(w, "unknown session", http.StatusNotFound)
+			return
+		}
+		raw, _ := io.ReadAll(r.Body)
+		s.posts <- string(raw)
+		var msg struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		_ = json.Unmarshal(raw, &msg)
+		w.WriteHeader(http.StatusAccepted)
+		if msg.Method != "" && len(msg.ID) > 0 {
+			s.events <- fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":{"method":%q}}`, msg.ID, msg.Method)
+		}
+	})
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+func TestSSEClient_RoundTrip(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	server := newLegacySSEServer(t, "/messages?sessionId=abc")
+	defer server.Close()
+
+	client := NewSSEClient(server.URL+"/sse", WithSSETimeout(2*time.Second))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	writer, reader, err := client.Start(ctx)
+	if err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	scanner := bufio.NewScanner(reader)
+
+	_, _ = writer.Write([]byte(`{"jsonrpc":"2.0","method":"tools/list","id":7}` + "\n"))
+	if !scanner.Scan() {
+		t.Fatalf("expected response: %v", scanner.Err())
+	}
+	if got := scanner.Text(); got != `{"jsonrpc":"2.0","id":7,"result":{"method":"tools/list"}}` {
+		t.Errorf("response = %s", got)
+	}
+	<-server.posts
+
+	// Notifications are relayed; requests of the server are answered.
+	server.events <- `{"jsonrpc":"2.0","id":"srv-1","method":"ping"}`
+	server.events <- `{"jsonrpc":"2.0","method":"notifications/tools/list_changed"}`
+	if !scanner.Scan() {
+		t.Fatalf("expected notification: %v", scanner.Err())
+	}
+	if got := scanner.Text(); !strings.Contains(got, "notifications/tools/list_changed") {
+		t.Errorf("relayed message = %s, want the notification", got)
+	}
+	if reply := <-server.posts; !strings.Contains(reply, `"id":"srv-1"`) || !strings.Contains(reply, `"result":{}`) {
+		t.Errorf("ping reply = %s", reply)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Errorf("Close() = %v", err)
+	}
+}
+
+func TestSSEClient_Endpoint(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	tests := []struct {
+		name     string
+		endpoint string
+		wantErr  string
+	}{
+		{"other origin", "http://attacker.example/messages", "not on the origin"},
+		{"empty", "", "invalid endpoint"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newLegacySSEServer(t, tt.endpoint)
+			defer server.Close()
+			client := NewSSEClient(server.URL+"/sse", WithSSETimeout(2*time.Second))
+			_, _, err := client.Start(context.Background())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Start() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("not an event stream", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{}`)
+		}))
+		defer server.Close()
+		if _, _, err := NewSSEClient(server.URL).Start(context.Background()); err == nil {
+			t.Error("Start() on a JSON endpoint succeeded")
+		}
+	})
+}
+
+func TestSSEClient_StreamClosed(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	server := newLegacySSEServer(t, "/messages?sessionId=abc")
+	defer server.Close()
+
+	client := NewSSEClient(server.URL+"/sse", WithSSETimeout(2*time.Second))
+	_, reader, err := client.Start(context.Background())
+	if err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	close(server.events)
+
+	// The end of the stream ends the run: the response pipe closes and
+	// Wait reports why.
+	if _, err := io.ReadAll(reader); err != nil {
+		t.Errorf("read response pipe: %v", err)
+	}
+	if err := client.Wait(); !errors.Is(err, errEventStreamClosed) {
+		t.Errorf("Wait() = %v, want errEventStreamClosed", err)
+	}
+	_ = client.Close()
+}
@@ -12,6 +12,7 @@ import (
 	"sync"
 
 	"github.com/Sentinel-Gate/Sentinelgate/internal/port/outbound"
+	mcpwire "github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
 )
 
 // sensitiveEnvKeys lists environment variable names that must not be
@@ -82,6 +83,7 @@ type StdioClient struct {
 	serverPath string
 	serverArgs []string
 	serverEnv  map[string]string
+	framing    mcpwire.Framing
 
 	mu     sync.Mutex
 	cmd    *exec.Cmd
@@ -95,6 +97,7 @@ func NewStdioClient(serverPath string, serverArgs ...string) *StdioClient {
 	return &StdioClient{
 		serverPath: serverPath,
 		serverArgs: serverArgs,
+		framing:    mcpwire.FramingAuto,
 	}
 }
 
@@ -104,6 +107,16 @@ func (c *StdioClient) SetEnv(env map[string]string) {
 	c.serverEnv = env
 }
 
+// SetFraming sets how messages are delimited on the server's stdio. The
+// returned pipes always speak newline-delimited JSON; with
+// FramingContentLength they are translated to and from LSP-style framing.
+// FramingAuto (default) writes newline-delimited messages until the server
+// is seen answering with Content-Length frames, so servers that only accept
+// framed input need FramingContentLength.
+func (c *StdioClient) SetFraming(f mcpwire.Framing) {
+	c.framing = f
+}
+
 // Start launches the upstream MCP server as a subprocess.
 // Returns the server's stdin (for sending) and stdout (for receiving).
 // The server's stderr is forwarded to os.Stderr (MCP spec allows server logging).
@@ -150,7 +163,17 @@ func (c *StdioClient) Start(ctx context.Context) (io.WriteCloser, io.ReadCloser,
 		return nil, nil, fmt.Errorf("failed to start server: %w", err)
 	}
 
-	return stdin, stdout, nil
+	if c.framing == mcpwire.FramingNewline {
+		return stdin, stdout, nil
+	}
+	reader := mcpwire.NewFrameReader(stdout, c.framing)
+	return mcpwire.NewFrameWriter(stdin, c.framing, reader), framedReadCloser{reader, stdout}, nil
+}
+
+// framedReadCloser reads through a FrameReader and closes the raw pipe.
+type framedReadCloser struct {
+	*mcpwire.FrameReader
+	io.Closer
 }
 
 // Wait blocks until the upstream server process terminates.
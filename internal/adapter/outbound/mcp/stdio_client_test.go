@@ -1,10 +1,13 @@
 package mcp
 
 import (
+	"bufio"
 	"context"
 	"strings"
 	"testing"
 	"time"
+
+	mcpwire "github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
 )
 
 // ---------------------------------------------------------------------------
@@ -222,6 +225,32 @@ func TestStdioClient_StartAndCommunicate(t *testing.T) {
 	_ = c.Close()
 }
 
+func TestStdioClient_ContentLengthFraming(t *testing.T) {
+	// "cat" echoes the frames back, so the reader must parse what the writer framed.
+	c := NewStdioClient("/bin/cat")
+	c.SetFraming(mcpwire.FramingContentLength)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stdin, stdout, err := c.Start(ctx)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() { _ = c.Close() }()
+
+	if _, err := stdin.Write([]byte(`{"jsonrpc":"2.0","id":1,"method":"ping"}` + "\n")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if line != `{"jsonrpc":"2.0","id":1,"method":"ping"}`+"\n" {
+		t.Errorf("round trip = %q", line)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Sensitive env filtering tests
 // ---------------------------------------------------------------------------
@@ -0,0 +1,237 @@
+package mcp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// shimMethods are the HTTP methods a mapped tool can use.
+var shimMethods = map[string]bool{
+	"GET": true, "HEAD": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true,
+}
+
+// pathPlaceholder matches the {name} placeholders of a mapped tool's path.
+var pathPlaceholder = regexp.MustCompile(`\{([^{}/]+)\}`)
+
+// toolMapping is a shim mapping file: it describes the tools of a backend
+// that does not speak MCP, each one an HTTP endpoint or an operation of an
+// OpenAPI document.
+//
+//	name: legacy-tools
+//	base_url: http://tools.internal:8080
+//	openapi: ./inventory.yaml
+//	tools:
+//	  - name: lookup_user
+//	    description: Look up a user by ID
+//	    method: GET
+//	    path: /users/{id}
+//	  - operation: listItems
+//	    name: list_items
+type toolMapping struct {
+	Name    string        `yaml:"name"`
+	Version string        `yaml:"version"`
+	BaseURL string        `yaml:"base_url"`
+	OpenAPI string        `yaml:"openapi"` // OpenAPI document, relative to the mapping file
+	Tools   []toolMapItem `yaml:"tools"`
+}
+
+// toolMapItem maps one tool. It names either an OpenAPI operation or an
+// HTTP endpoint (method, path and query).
+type toolMapItem struct {
+	Name        string                 `yaml:"name"`
+	Description string                 `yaml:"description"`
+	Operation   string                 `yaml:"operation"` // operationId (tool name) in the OpenAPI document
+	Method      string                 `yaml:"method"`    // default POST
+	Path        string                 `yaml:"path"`
+	Query       []string               `yaml:"query"` // arguments sent as query parameters
+	InputSchema map[string]interface{} `yaml:"input_schema"`
+}
+
+// parseToolMapping parses a shim mapping file in JSON or YAML into the
+// tools it exposes. loadOpenAPI loads the document named by the openapi
+// key; it is only called when the mapping has one.
+//
+// Tools mapped to an endpoint send their path placeholders in the path,
+// the arguments listed in query as query parameters, and every other
+// argument as a JSON object body (as query parameters for GET, HEAD and
+// DELETE). When the mapping lists no operation, every operation of the
+// OpenAPI document is exposed.
+func parseToolMapping(data []byte, loadOpenAPI func(ref string) (*openAPISpec, error)) (*openAPISpec, error) {
+	var m toolMapping
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("parse tool mapping: %w", err)
+	}
+
+	spec := &openAPISpec{
+		title:     m.Name,
+		version:   m.Version,
+		serverURL: m.BaseURL,
+		byTool:    make(map[string]*openAPIOperation),
+	}
+	if spec.title == "" {
+		spec.title = "shim"
+	}
+
+	var api *openAPISpec
+	if m.OpenAPI != "" {
+		var err error
+		if api, err = loadOpenAPI(m.OpenAPI); err != nil {
+			return nil, err
+		}
+		if spec.serverURL == "" {
+			spec.serverURL = api.serverURL
+		}
+	}
+
+	exposeAll := api != nil
+	for i, item := range m.Tools {
+		op, err := mappedOperation(item, api)
+		if err != nil {
+			return nil, fmt.Errorf("tools[%d]: %w", i, err)
+		}
+		if _, dup := spec.byTool[op.tool]; dup {
+			return nil, fmt.Errorf("tools[%d]: duplicate tool name %q", i, op.tool)
+		}
+		if item.Operation != "" {
+			exposeAll = false
+		}
+		spec.byTool[op.tool] = op
+		spec.operations = append(spec.operations, op)
+	}
+	if exposeAll {
+		for _, op := range api.operations {
+			if _, dup := spec.byTool[op.tool]; dup {
+				return nil, fmt.Errorf("OpenAPI operation %q has the name of a mapped tool", op.tool)
+			}
+			spec.byTool[op.tool] = op
+			spec.operations = append(spec.operations, op)
+		}
+	}
+	if len(spec.operations) == 0 {
+		return nil, errors.New("tool mapping defines no tools")
+	}
+	return spec, nil
+}
+
+// mappedOperation builds the operation of one mapped tool.
+func mappedOperation(item toolMapItem, api *openAPISpec) (*openAPIOperation, error) {
+	if item.Operation != "" {
+		if item.Method != "" || item.Path != "" || len(item.Query) > 0 || item.InputSchema != nil {
+			return nil, errors.New("operation cannot be combined with method, path, query or input_schema")
+		}
+		if api == nil {
+			return nil, fmt.Errorf("operation %q needs an openapi document", item.Operation)
+		}
+		found, ok := api.byTool[item.Operation]
+		if !ok {
+			return nil, fmt.Errorf("operation %q is not in the OpenAPI document", item.Operation)
+		}
+		op := *found
+		if item.Name != "" {
+			op.tool = item.Name
+		}
+		if item.Description != "" {
+			op.description = item.Description
+		}
+		if err := validateMappedToolName(op.tool); err != nil {
+			return nil, err
+		}
+		return &op, nil
+	}
+
+	if err := validateMappedToolName(item.Name); err != nil {
+		return nil, err
+	}
+	op := &openAPIOperation{
+		tool:        item.Name,
+		description: item.Description,
+		method:      strings.ToUpper(item.Method),
+		path:        item.Path,
+	}
+	if op.method == "" {
+		op.method = "POST"
+	}
+	if !shimMethods[op.method] {
+		return nil, fmt.Errorf("tool %q: unsupported method %q", item.Name, item.Method)
+	}
+	if !strings.HasPrefix(op.path, "/") {
+		return nil, fmt.Errorf("tool %q: path must start with /", item.Name)
+	}
+	if op.description == "" {
+		op.description = op.method + " " + op.path
+	}
+	switch op.method {
+	case "GET", "HEAD", "DELETE":
+		op.restIn = "query"
+	default:
+		op.restIn = "body"
+	}
+
+	properties := make(map[string]interface{})
+	var required []string
+	for _, match := range pathPlaceholder.FindAllStringSubmatch(op.path, -1) {
+		name := match[1]
+		if op.hasParam(name) {
+			continue
+		}
+		op.params = append(op.params, openAPIParam{name: name, in: "path", required: true})
+		properties[name] = map[string]interface{}{"type": "string"}
+		required = append(required, name)
+	}
+	for _, name := range item.Query {
+		if name == "" || op.hasParam(name) {
+			return nil, fmt.Errorf("tool %q: invalid or duplicate query argument %q", item.Name, name)
+		}
+		op.params = append(op.params, openAPIParam{name: name, in: "query"})
+		properties[name] = map[string]interface{}{"type": "string"}
+	}
+
+	if item.InputSchema != nil {
+		r := &refResolver{root: item.InputSchema, resolved: make(map[string]interface{}), active: make(map[string]bool)}
+		schema, _ := r.schema(item.InputSchema).(map[string]interface{})
+		if t, ok := schema["type"]; ok && t != "object" {
+			return nil, fmt.Errorf("tool %q: input_schema must be an object schema", item.Name)
+		}
+		schema["type"] = "object"
+		op.inputSchema = schema
+		return op, nil
+	}
+	op.inputSchema = map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		op.inputSchema["required"] = required
+	}
+	return op, nil
+}
+
+// validateMappedToolName checks a tool name given in a mapping file.
+func validateMappedToolName(name string) error {
+	switch {
+	case name == "":
+		return errors.New("tool name is required")
+	case len(name) > maxToolNameLength:
+		return fmt.Errorf("tool name %q is longer than %d characters", name, maxToolNameLength)
+	case toolNameInvalidChars.MatchString(name):
+		return fmt.Errorf("tool name %q may only contain letters, digits, _ and -", name)
+	}
+	return nil
+}
+
+// hasParam reports whether the operation has a parameter called name.
+func (op *openAPIOperation) hasParam(name string) bool {
+	for _, p := range op.params {
+		if p.name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package mcp
+
+import (
+	"strings"
+	"testing"
+)
+
+const legacyMapping = `
+name: legacy-tools
+version: "3"
+base_url: http://tools.internal:8080
+tools:
+  - name: lookup_user
+    description: Look up a user
+    method: get
+    path: /users/{id}
+  - name: create_ticket
+    path: /tickets/{queue}
+    query: [dry_run]
+    input_schema:
+      type: object
+      properties:
+        queue: {type: string}
+        title: {type: string}
+      required: [queue, title]
+`
+
+func TestParseToolMapping(t *testing.T) {
+	spec, err := parseToolMapping([]byte(legacyMapping), nil)
+	if err != nil {
+		t.Fatalf("parseToolMapping() error: %v", err)
+	}
+	if spec.title != "legacy-tools" || spec.version != "3" || spec.serverURL != "http://tools.internal:8080" {
+		t.Errorf("spec = %q %q %q", spec.title, spec.version, spec.serverURL)
+	}
+
+	lookup := spec.byTool["lookup_user"]
+	if lookup.method != "GET" || lookup.restIn != "query" || len(lookup.params) != 1 || lookup.params[0].in != "path" {
+		t.Errorf("lookup_user = %+v", lookup)
+	}
+	if req := lookup.inputSchema["required"].([]string); len(req) != 1 || req[0] != "id" {
+		t.Errorf("lookup_user required = %v", req)
+	}
+
+	create := spec.byTool["create_ticket"]
+	if create.method != "POST" || create.restIn != "body" || create.description != "POST /tickets/{queue}" {
+		t.Errorf("create_ticket = %+v", create)
+	}
+	if _, ok := create.inputSchema["properties"].(map[string]interface{})["title"]; !ok {
+		t.Errorf("create_ticket should keep its input_schema, got %v", create.inputSchema)
+	}
+}
+
+func TestParseToolMapping_OpenAPI(t *testing.T) {
+	load := func(ref string) (*openAPISpec, error) {
+		if ref != "petstore.yaml" {
+			t.Errorf("openapi ref = %q", ref)
+		}
+		return parseOpenAPISpec([]byte(petstoreSpec))
+	}
+
+	// Listed operations are the only tools exposed.
+	spec, err := parseToolMapping([]byte(`
+openapi: petstore.yaml
+tools:
+  - operation: listPets
+    name: list_pets
+    description: All the pets
+`), load)
+	if err != nil {
+		t.Fatalf("parseToolMapping() error: %v", err)
+	}
+	if len(spec.operations) != 1 || spec.operations[0].tool != "list_pets" || spec.operations[0].description != "All the pets" {
+		t.Errorf("operations = %+v", spec.operations)
+	}
+	if spec.serverURL != "https://api.example.com/v1" {
+		t.Errorf("serverURL = %q, want the document's server", spec.serverURL)
+	}
+
+	// Without listed operations, the whole document is exposed.
+	spec, err = parseToolMapping([]byte(`{"openapi":"petstore.yaml"}`), load)
+	if err != nil {
+		t.Fatalf("parseToolMapping() error: %v", err)
+	}
+	if len(spec.operations) != 4 {
+		t.Errorf("got %d tools, want the 4 operations", len(spec.operations))
+	}
+}
+
+func TestParseToolMapping_Rejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		wantErr string
+	}{
+		{"no tools", `name: x`, "no tools"},
+		{"unknown key", "tools:\n  - name: a\n    path: /a\n    mehtod: GET", "mehtod"},
+		{"no name", "tools:\n  - path: /a", "name is required"},
+		{"bad name", "tools:\n  - name: a b\n    path: /a", "may only contain"},
+		{"duplicate", "tools:\n  - {name: a, path: /a}\n  - {name: a, path: /b}", "duplicate"},
+		{"bad method", "tools:\n  - {name: a, path: /a, method: TRACE}", "unsupported method"},
+		{"relative path", "tools:\n  - {name: a, path: a}", "must start with /"},
+		{"array schema", "tools:\n  - {name: a, path: /a, input_schema: {type: array}}", "object schema"},
+		{"operation without openapi", "tools:\n  - {operation: listPets}", "needs an openapi"},
+		{"operation with path", "openapi: p.yaml\ntools:\n  - {operation: listPets, path: /a}", "cannot be combined"},
+		{"unknown operation", "openapi: p.yaml\ntools:\n  - {operation: nope}", "not in the OpenAPI"},
+	}
+	load := func(string) (*openAPISpec, error) { return parseOpenAPISpec([]byte(petstoreSpec)) }
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseToolMapping([]byte(tt.doc), load)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRelativeSource(t *testing.T) {
+	tests := []struct {
+		base, ref, want string
+		wantErr         bool
+	}{
+		{"/etc/sg/tools.yaml", "api.yaml", "/etc/sg/api.yaml", false},
+		{"/etc/sg/tools.yaml", "https://example.com/api.yaml", "https://example.com/api.yaml", false},
+		{"https://example.com/sg/tools.yaml", "api.yaml", "https://example.com/sg/api.yaml", false},
+		{"https://example.com/sg/tools.yaml", "/etc/passwd", "https://example.com/etc/passwd", false},
+		{"https://example.com/sg/tools.yaml", "file:///etc/passwd", "", true},
+	}
+	for _, tt := range tests {
+		got, err := relativeSource(tt.base, tt.ref)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("relativeSource(%q, %q) = %q, %v", tt.base, tt.ref, got, err)
+		}
+	}
+}
@@ -144,9 +144,12 @@ func (s *MemoryAuditStore) Query(_ context.Context, filter audit.AuditFilter) ([
 	}
 
 	var result []audit.AuditRecord
-	// Iterate newest first.
-	for i := len(s.recent) - 1; i >= 0 && len(result) < limit; i-- {
-		rec := s.recent[i]
+	// Iterate newest first, or oldest first when ascending.
+	for n := 0; n < len(s.recent) && len(result) < limit; n++ {
+		rec := s.recent[len(s.recent)-1-n]
+		if filter.Ascending {
+			rec = s.recent[n]
+		}
 		if !filter.StartTime.IsZero() && rec.Timestamp.Before(filter.StartTime) {
 			continue
 		}
@@ -176,6 +179,9 @@ func (s *MemoryAuditStore) Query(_ context.Context, filter audit.AuditFilter) ([
 		if filter.Protocol != "" && !strings.EqualFold(rec.Protocol, filter.Protocol) {
 			continue
 		}
+		if !filter.MatchesLabels(rec.SessionLabels) || !filter.MatchesDetails(rec) {
+			continue
+		}
 		result = append(result, rec)
 	}
 
@@ -430,6 +430,41 @@ func TestAuditStore_FilterToolMatchesNamespaced(t *testing.T) {
 	}
 }
 
+func TestAuditStore_FilterBySessionLabels(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := NewAuditStoreWithWriter(&bytes.Buffer{})
+
+	now := time.Now().UTC()
+	_ = store.Append(ctx,
+		audit.AuditRecord{RequestID: "r1", ToolName: "read_file", Timestamp: now,
+			SessionLabels: map[string]string{"project": "checkout-bot", "run-id": "1234"}},
+		audit.AuditRecord{RequestID: "r2", ToolName: "read_file", Timestamp: now,
+			SessionLabels: map[string]string{"project": "search"}},
+		audit.AuditRecord{RequestID: "r3", ToolName: "read_file", Timestamp: now},
+	)
+
+	tests := []struct {
+		labels map[string]string
+		want   int
+	}{
+		{map[string]string{"project": "checkout-bot"}, 1},
+		{map[string]string{"project": ""}, 2},
+		{map[string]string{"project": "checkout-bot", "run-id": "9"}, 0},
+		{nil, 3},
+	}
+	for _, tt := range tests {
+		results, _, err := store.Query(ctx, audit.AuditFilter{Labels: tt.labels})
+		if err != nil {
+			t.Fatalf("Query error: %v", err)
+		}
+		if len(results) != tt.want {
+			t.Errorf("Query(Labels=%v) returned %d, want %d", tt.labels, len(results), tt.want)
+		}
+	}
+}
+
 func TestAuditStore_DefaultStdout(t *testing.T) {
 	// Note: This test just verifies NewAuditStore doesn't panic
 	// We don't actually write to stdout in tests
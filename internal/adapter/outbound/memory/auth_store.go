@@ -117,6 +117,14 @@ func (s *AuthStore) AddIdentity(identity *auth.Identity) {
 	s.identities[identity.ID] = &identityCopy
 }
 
+// RemoveIdentity removes an identity by ID. Its API keys no longer resolve
+// to an identity and fail authentication.
+func (s *AuthStore) RemoveIdentity(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.identities, id)
+}
+
 // ListAPIKeys returns all stored API keys for iteration-based verification.
 func (s *AuthStore) ListAPIKeys(ctx context.Context) ([]*auth.APIKey, error) {
 	s.mu.RLock()
@@ -91,6 +91,20 @@ func (s *MemoryPolicyStore) AddPolicy(p *policy.Policy) {
 	s.policies[p.ID] = copyPolicy(p)
 }
 
+// ReplacePolicies removes the policies with the given IDs and adds the given
+// policies in one step, so readers never see a partial set.
+func (s *MemoryPolicyStore) ReplacePolicies(remove []string, add []*policy.Policy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range remove {
+		delete(s.policies, id)
+	}
+	for _, p := range add {
+		s.policies[p.ID] = copyPolicy(p)
+	}
+}
+
 // DeletePolicy removes a policy by ID.
 // Returns ErrPolicyNotFound if policy doesn't exist.
 func (s *MemoryPolicyStore) DeletePolicy(ctx context.Context, id string) error {
@@ -123,6 +123,20 @@ func (r *MemoryRateLimiter) Allow(ctx context.Context, key string, config rateli
 	}, nil
 }
 
+// Peek reports the state of key under config without counting a request.
+func (r *MemoryRateLimiter) Peek(ctx context.Context, key string, config ratelimit.RateLimitConfig) (ratelimit.RateLimitResult, error) {
+	s := r.shard(key)
+	s.mu.Lock()
+	tat, exists := s.cells[key]
+	s.mu.Unlock()
+
+	now := time.Now()
+	if !exists || tat.Before(now) {
+		tat = now
+	}
+	return ratelimit.PeekResult(tat.Sub(now), config), nil
+}
+
 // StartCleanup starts the background cleanup goroutine.
 // The goroutine periodically removes keys older than maxTTL,
 // one shard at a time to minimize lock contention.
@@ -194,4 +208,7 @@ func (r *MemoryRateLimiter) Size() int {
 }
 
 // Compile-time interface verification.
-var _ ratelimit.RateLimiter = (*MemoryRateLimiter)(nil)
+var (
+	_ ratelimit.RateLimiter = (*MemoryRateLimiter)(nil)
+	_ ratelimit.Inspector   = (*MemoryRateLimiter)(nil)
+)
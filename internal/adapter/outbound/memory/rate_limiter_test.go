@@ -653,3 +653,30 @@ func TestRateLimiter_ManyUniqueKeys(t *testing.T) {
 		t.Errorf("Size %d too large after cleanup (expected < %d)", sizeAfterCleanup, totalKeys/10)
 	}
 }
+
+func TestRateLimiter_Peek(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	limiter := NewRateLimiter()
+	config := ratelimit.RateLimitConfig{Rate: 3, Burst: 3, Period: time.Minute}
+
+	// A fresh key has its whole burst, and peeking does not use it.
+	for i := 0; i < 5; i++ {
+		result, err := limiter.Peek(ctx, "peek-key", config)
+		if err != nil || !result.Allowed || result.Remaining != 3 {
+			t.Fatalf("Peek() = %+v, %v; want allowed with 3 remaining", result, err)
+		}
+	}
+
+	for {
+		result, _ := limiter.Allow(ctx, "peek-key", config)
+		if !result.Allowed {
+			break
+		}
+	}
+	result, err := limiter.Peek(ctx, "peek-key", config)
+	if err != nil || result.Allowed || result.Remaining != 0 || result.RetryAfter <= 0 {
+		t.Errorf("Peek() after exhaustion = %+v, %v; want denied with a retry after", result, err)
+	}
+}
@@ -163,6 +163,7 @@ func copySession(sess *session.Session) *session.Session {
 		ExpiresAt:    sess.ExpiresAt,
 		LastAccess:   sess.LastAccess,
 		Roles:        make([]auth.Role, len(sess.Roles)),
+		Labels:       session.CopyLabels(sess.Labels),
 	}
 	copy(sessCopy.Roles, sess.Roles)
 	return sessCopy
@@ -85,17 +85,22 @@ func (s *MemoryUpstreamStore) Delete(ctx context.Context, id string) error {
 // copyUpstream creates a deep copy of an Upstream to prevent mutation.
 func copyUpstream(u *upstream.Upstream) *upstream.Upstream {
 	c := &upstream.Upstream{
-		ID:        u.ID,
-		Name:      u.Name,
-		Type:      u.Type,
-		Enabled:   u.Enabled,
-		Command:   u.Command,
-		URL:       u.URL,
-		Status:    u.Status,
-		LastError: u.LastError,
-		ToolCount: u.ToolCount,
-		CreatedAt: u.CreatedAt,
-		UpdatedAt: u.UpdatedAt,
+		ID:                    u.ID,
+		Name:                  u.Name,
+		Type:                  u.Type,
+		Enabled:               u.Enabled,
+		Command:               u.Command,
+		URL:                   u.URL,
+		Spec:                  u.Spec,
+		Lazy:                  u.Lazy,
+		Framing:               u.Framing,
+		ToolPrefix:            u.ToolPrefix,
+		Status:                u.Status,
+		RegistrationTokenHash: u.RegistrationTokenHash,
+		LastError:             u.LastError,
+		ToolCount:             u.ToolCount,
+		CreatedAt:             u.CreatedAt,
+		UpdatedAt:             u.UpdatedAt,
 	}
 
 	// Deep copy slices and maps.
@@ -103,12 +108,29 @@ func copyUpstream(u *upstream.Upstream) *upstream.Upstream {
 		c.Args = make([]string, len(u.Args))
 		copy(c.Args, u.Args)
 	}
+	if u.ToolInclude != nil {
+		c.ToolInclude = append([]string(nil), u.ToolInclude...)
+	}
+	if u.ToolExclude != nil {
+		c.ToolExclude = append([]string(nil), u.ToolExclude...)
+	}
 	if u.Env != nil {
 		c.Env = make(map[string]string, len(u.Env))
 		for k, v := range u.Env {
 			c.Env[k] = v
 		}
 	}
+	if u.Headers != nil {
+		c.Headers = make(map[string]string, len(u.Headers))
+		for k, v := range u.Headers {
+			c.Headers[k] = v
+		}
+	}
+	if u.Credentials != nil {
+		creds := *u.Credentials
+		creds.Scopes = append([]string(nil), u.Credentials.Scopes...)
+		c.Credentials = &creds
+	}
 
 	return c
 }
@@ -0,0 +1,268 @@
+package opa
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PolicyIDPrefix prefixes the IDs of the policies pushed from the bundle
+// directory, followed by the path of the file in the directory.
+const PolicyIDPrefix = "sentinelgate/"
+
+// maxBundleFileSize bounds the size of one bundle file.
+const maxBundleFileSize = 8 << 20
+
+// fileStamp identifies a version of a bundle file.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// bundle is a directory of .rego modules and data.json documents, with the
+// version of every file last pushed to the server.
+type bundle struct {
+	dir    string
+	pushed map[string]fileStamp // by slash-separated path
+}
+
+func newBundle(dir string) *bundle {
+	return &bundle{dir: dir, pushed: make(map[string]fileStamp)}
+}
+
+// scan lists the .rego and data.json files of the directory tree. Dot files
+// and directories are skipped.
+func (b *bundle) scan() (map[string]fileStamp, error) {
+	files := make(map[string]fileStamp)
+	err := filepath.WalkDir(b.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != b.dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !isBundleFile(d.Name()) {
+			return nil
+		}
+		info, err := os.Stat(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(b.dir, p)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = fileStamp{info.ModTime(), info.Size()}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read bundle directory: %w", err)
+	}
+	return files, nil
+}
+
+func isBundleFile(name string) bool {
+	return strings.HasSuffix(name, ".rego") || name == "data.json"
+}
+
+// SyncBundle pushes the bundle files added or changed since the last push
+// and deletes the removed ones: data documents first, then policies. A file
+// the server rejects keeps its previous version there and is pushed again
+// on the next call. It returns the number of files pushed or deleted, and
+// does nothing when no bundle directory is configured.
+func (e *Engine) SyncBundle(ctx context.Context) (int, error) {
+	if e.bundle == nil {
+		return 0, nil
+	}
+	e.syncMu.Lock()
+	defer e.syncMu.Unlock()
+	changed, err := e.syncBundle(ctx)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := time.Now().UTC()
+	if err != nil {
+		e.status.LastError = err.Error()
+		e.status.LastErrorAt = &now
+		return changed, err
+	}
+	e.status.LastError = ""
+	e.status.LastErrorAt = nil
+	e.status.Files = e.status.Files[:0]
+	for rel := range e.bundle.pushed {
+		e.status.Files = append(e.status.Files, rel)
+	}
+	sort.Strings(e.status.Files)
+	if changed > 0 || e.status.LoadedAt == nil {
+		e.status.LoadedAt = &now
+	}
+	return changed, nil
+}
+
+func (e *Engine) syncBundle(ctx context.Context) (int, error) {
+	b := e.bundle
+	if e.resync.Swap(false) {
+		b.pushed = make(map[string]fileStamp)
+	}
+	files, err := b.scan()
+	if err != nil {
+		return 0, err
+	}
+
+	var push, remove []string
+	for rel, stamp := range files {
+		if prev, ok := b.pushed[rel]; !ok || !prev.modTime.Equal(stamp.modTime) || prev.size != stamp.size {
+			push = append(push, rel)
+		}
+	}
+	for rel := range b.pushed {
+		if _, ok := files[rel]; !ok {
+			remove = append(remove, rel)
+		}
+	}
+	// Data before the policies reading it; policies removed before their data.
+	sort.Slice(push, func(i, j int) bool { return bundleOrder(push[i], push[j], true) })
+	sort.Slice(remove, func(i, j int) bool { return bundleOrder(remove[i], remove[j], false) })
+
+	changed := 0
+	for _, rel := range push {
+		if err := e.pushFile(ctx, rel); err != nil {
+			return changed, err
+		}
+		b.pushed[rel] = files[rel]
+		changed++
+	}
+	for _, rel := range remove {
+		if err := e.removeFile(ctx, rel); err != nil {
+			return changed, err
+		}
+		delete(b.pushed, rel)
+		changed++
+	}
+	return changed, nil
+}
+
+// bundleOrder sorts data documents before policies when dataFirst, after
+// them otherwise, then by path.
+func bundleOrder(a, b string, dataFirst bool) bool {
+	aData, bData := path.Base(a) == "data.json", path.Base(b) == "data.json"
+	if aData != bData {
+		return aData == dataFirst
+	}
+	return a < b
+}
+
+func (e *Engine) pushFile(ctx context.Context, rel string) error {
+	f, err := os.Open(filepath.Join(e.bundle.dir, filepath.FromSlash(rel)))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxBundleFileSize+1))
+	if err != nil {
+		return fmt.Errorf("%s: %w", rel, err)
+	}
+	if len(data) > maxBundleFileSize {
+		return fmt.Errorf("%s: larger than %d bytes", rel, maxBundleFileSize)
+	}
+
+	if path.Base(rel) == "data.json" {
+		if err := e.do(ctx, http.MethodPut, dataPath(rel), "application/json", data, nil); err != nil {
+			return fmt.Errorf("%s: %w", rel, err)
+		}
+		return nil
+	}
+	if err := e.do(ctx, http.MethodPut, policyPath(rel), "text/plain", data, nil); err != nil {
+		return fmt.Errorf("%s: %w", rel, err)
+	}
+	return nil
+}
+
+func (e *Engine) removeFile(ctx context.Context, rel string) error {
+	var err error
+	switch {
+	case rel == "data.json":
+		// The root document cannot be deleted; empty it.
+		err = e.do(ctx, http.MethodPut, "/v1/data", "application/json", []byte("{}"), nil)
+	case path.Base(rel) == "data.json":
+		err = e.do(ctx, http.MethodDelete, dataPath(rel), "", nil, nil)
+	default:
+		err = e.do(ctx, http.MethodDelete, policyPath(rel), "", nil, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", rel, err)
+	}
+	return nil
+}
+
+// policyPath is the REST path of the policy pushed from rel.
+func policyPath(rel string) string {
+	return "/v1/policies/" + escapePath(PolicyIDPrefix+rel)
+}
+
+// dataPath is the REST path of the document a data.json file sets: the
+// directory of the file under data.
+func dataPath(rel string) string {
+	dir := path.Dir(rel)
+	if dir == "." {
+		return "/v1/data"
+	}
+	return "/v1/data/" + escapePath(dir)
+}
+
+func escapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// Run pushes the bundle directory every interval until ctx is cancelled. A
+// failure is logged once until the error changes or a push succeeds.
+func (e *Engine) Run(ctx context.Context, interval time.Duration) {
+	if e.bundle == nil {
+		return
+	}
+	if interval <= 0 {
+		interval = DefaultReloadInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	lastErr := ""
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := e.SyncBundle(ctx)
+			switch {
+			case err != nil && ctx.Err() != nil:
+				return
+			case err != nil:
+				if err.Error() != lastErr {
+					e.logger.Warn("rego bundle push failed", "dir", e.bundle.dir, "error", err)
+				}
+				lastErr = err.Error()
+			default:
+				if changed > 0 {
+					e.logger.Info("rego bundle pushed", "dir", e.bundle.dir,
+						"changed", changed, "files", len(e.Status().Files))
+				}
+				lastErr = ""
+			}
+		}
+	}
+}
@@ -0,0 +1,250 @@
+// Package opa evaluates policy decisions with Rego policies on an Open
+// Policy Agent server, through its REST API.
+package opa
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/cel"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/policy"
+)
+
+// RuleIDPrefix prefixes the RuleID of decisions made by the Rego engine,
+// followed by the rule reported by the decision (or the decision path).
+const RuleIDPrefix = "rego:"
+
+// DefaultReloadInterval is how often the bundle directory is checked for
+// changes.
+const DefaultReloadInterval = 5 * time.Second
+
+// maxResponseSize bounds the size of an OPA response.
+const maxResponseSize = 1 << 20
+
+// Config configures an Engine.
+type Config struct {
+	// URL is the OPA server, e.g. "http://127.0.0.1:8181".
+	URL string
+	// Decision is the path of the decision document under data,
+	// e.g. "sentinelgate/decision".
+	Decision string
+	// Token is sent as a bearer token when set.
+	Token string
+	// Timeout bounds one request. Defaults to 2s.
+	Timeout time.Duration
+	// BundleDir is a directory of .rego and data.json files pushed to the
+	// server (optional).
+	BundleDir string
+}
+
+// Status describes the Rego engine, as reported by the system info endpoint.
+type Status struct {
+	URL       string     `json:"url"`
+	Decision  string     `json:"decision"`
+	BundleDir string     `json:"bundle_dir,omitempty"`
+	Files     []string   `json:"files,omitempty"`
+	LoadedAt  *time.Time `json:"loaded_at,omitempty"`
+	// LastError is the last failed bundle push, cleared by the next
+	// successful one.
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+// result is the decision document. A decision may also be a plain boolean,
+// meaning allow.
+type result struct {
+	Allow            bool   `json:"allow"`
+	ApprovalRequired bool   `json:"approval_required"`
+	ApprovalTimeout  string `json:"approval_timeout"`
+	TimeoutAction    string `json:"timeout_action"`
+	Reason           string `json:"reason"`
+	Rule             string `json:"rule"`
+	HelpText         string `json:"help_text"`
+	HelpURL          string `json:"help_url"`
+}
+
+// Engine is a policy.PolicyEngine querying the decision document of an OPA
+// server. The input document holds the variables CEL conditions see, under
+// the same names (tool_name, tool_args, identity_roles, dest_domain, ...),
+// with request_time in RFC 3339.
+//
+// An undefined decision denies the call, and a server that cannot be
+// reached fails the evaluation, which denies it too. The decision ID OPA
+// returns when its decision logs are enabled is recorded in the audit trail.
+type Engine struct {
+	baseURL   string
+	decision  string
+	token     string
+	client    *http.Client
+	variables func() map[string]interface{}
+	logger    *slog.Logger
+
+	bundle *bundle
+	syncMu sync.Mutex // serializes bundle pushes
+	// resync asks the next bundle check to push every file again, set when
+	// the decision is undefined (e.g. after an OPA restart).
+	resync atomic.Bool
+
+	mu     sync.Mutex
+	status Status
+}
+
+// Compile-time check that Engine implements policy.PolicyEngine.
+var _ policy.PolicyEngine = (*Engine)(nil)
+
+// New creates an Engine. variables, when non-nil, returns the policy
+// variables passed as input.vars.
+func New(cfg Config, variables func() map[string]interface{}, logger *slog.Logger) (*Engine, error) {
+	if cfg.URL == "" || cfg.Decision == "" {
+		return nil, fmt.Errorf("opa: url and decision are required")
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	e := &Engine{
+		baseURL:   strings.TrimRight(cfg.URL, "/"),
+		decision:  strings.Trim(cfg.Decision, "/"),
+		token:     cfg.Token,
+		client:    &http.Client{Timeout: timeout},
+		variables: variables,
+		logger:    logger,
+		status:    Status{URL: cfg.URL, Decision: strings.Trim(cfg.Decision, "/"), BundleDir: cfg.BundleDir},
+	}
+	if cfg.BundleDir != "" {
+		e.bundle = newBundle(cfg.BundleDir)
+	}
+	return e, nil
+}
+
+// Status returns the state of the engine.
+func (e *Engine) Status() Status {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	status := e.status
+	status.Files = append([]string(nil), e.status.Files...)
+	return status
+}
+
+// Evaluate queries the decision document for evalCtx.
+func (e *Engine) Evaluate(ctx context.Context, evalCtx policy.EvaluationContext) (policy.Decision, error) {
+	if evalCtx.Variables == nil && e.variables != nil {
+		evalCtx.Variables = e.variables()
+	}
+	body, err := json.Marshal(map[string]any{"input": cel.BuildUniversalActivation(evalCtx)})
+	if err != nil {
+		return policy.Decision{}, fmt.Errorf("opa: encode input: %w", err)
+	}
+
+	var resp struct {
+		Result     json.RawMessage `json:"result"`
+		DecisionID string          `json:"decision_id"`
+	}
+	if err := e.do(ctx, http.MethodPost, "/v1/data/"+e.decision, "application/json", body, &resp); err != nil {
+		return policy.Decision{}, err
+	}
+
+	decision := policy.Decision{
+		RuleID:     RuleIDPrefix + e.decision,
+		RuleName:   e.decision,
+		DecisionID: resp.DecisionID,
+	}
+	raw := bytes.TrimSpace(resp.Result)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		if e.bundle != nil {
+			e.resync.Store(true)
+		}
+		decision.Reason = fmt.Sprintf("rego decision %s is undefined", e.decision)
+		return decision, nil
+	}
+
+	var allow bool
+	if err := json.Unmarshal(raw, &allow); err == nil {
+		decision.Allowed = allow
+		decision.Reason = fmt.Sprintf("rego decision %s", e.decision)
+		return decision, nil
+	}
+	var r result
+	if err := json.Unmarshal(raw, &r); err != nil {
+		return policy.Decision{}, fmt.Errorf("opa: decision %s is neither a boolean nor an object: %w", e.decision, err)
+	}
+	if r.Rule != "" {
+		decision.RuleID = RuleIDPrefix + r.Rule
+		decision.RuleName = r.Rule
+	}
+	decision.Reason = r.Reason
+	if decision.Reason == "" {
+		decision.Reason = fmt.Sprintf("matched rego rule %s", decision.RuleName)
+	}
+	decision.HelpText = r.HelpText
+	decision.HelpURL = r.HelpURL
+	switch {
+	case r.ApprovalRequired:
+		decision.RequiresApproval = true
+		if r.ApprovalTimeout != "" {
+			d, err := time.ParseDuration(r.ApprovalTimeout)
+			if err != nil || d <= 0 {
+				return policy.Decision{}, fmt.Errorf("opa: decision %s: invalid approval_timeout %q", e.decision, r.ApprovalTimeout)
+			}
+			decision.ApprovalTimeout = d
+		}
+		switch policy.Action(r.TimeoutAction) {
+		case "", policy.ActionDeny, policy.ActionAllow:
+			decision.ApprovalTimeoutAction = policy.Action(r.TimeoutAction)
+		default:
+			return policy.Decision{}, fmt.Errorf("opa: decision %s: timeout_action must be allow or deny", e.decision)
+		}
+	case r.Allow:
+		decision.Allowed = true
+	}
+	return decision, nil
+}
+
+// do sends a request to the OPA server and decodes the JSON response into
+// out, when non-nil.
+func (e *Engine) do(ctx context.Context, method, path, contentType string, body []byte, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, e.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("opa: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if e.token != "" {
+		req.Header.Set("Authorization", "Bearer "+e.token)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("opa: %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return fmt.Errorf("opa: %s %s: %w", method, path, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("opa: %s %s: %s: %s", method, path, resp.Status, apiErr.Message)
+		}
+		return fmt.Errorf("opa: %s %s: %s", method, path, resp.Status)
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("opa: decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package opa
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/policy"
+)
+
+// fakeOPA records the requests of an Engine and answers decisions with the
+// configured result.
+type fakeOPA struct {
+	mu       sync.Mutex
+	result   string // raw JSON, "" = undefined
+	input    map[string]any
+	policies map[string]string
+	data     map[string]string
+	reject   string // policy ID answered with 400
+}
+
+func (f *fakeOPA) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	body, _ := io.ReadAll(r.Body)
+	switch {
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/v1/data/"):
+		var req struct {
+			Input map[string]any `json:"input"`
+		}
+		_ = json.Unmarshal(body, &req)
+		f.input = req.Input
+		if f.result == "" {
+			_, _ = w.Write([]byte(`{"decision_id":"d-undefined"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"decision_id":"d-1","result":` + f.result + `}`))
+	case strings.HasPrefix(r.URL.Path, "/v1/policies/"):
+		id := strings.TrimPrefix(r.URL.Path, "/v1/policies/")
+		if id == f.reject {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code":"invalid_parameter","message":"error(s) occurred while compiling module(s)"}`))
+			return
+		}
+		if r.Method == http.MethodDelete {
+			delete(f.policies, id)
+		} else {
+			f.policies[id] = string(body)
+		}
+		_, _ = w.Write([]byte(`{}`))
+	case strings.HasPrefix(r.URL.Path, "/v1/data"):
+		if r.Method == http.MethodDelete {
+			delete(f.data, r.URL.Path)
+		} else {
+			f.data[r.URL.Path] = string(body)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestEngine(t *testing.T, f *fakeOPA, bundleDir string) *Engine {
+	t.Helper()
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	vars := func() map[string]interface{} { return map[string]interface{}{"env": "prod"} }
+	e, err := New(Config{URL: srv.URL, Decision: "sentinelgate/decision", BundleDir: bundleDir}, vars, logger)
+	if err != nil {
+		t.Fatalf("New(): %v", err)
+	}
+	return e
+}
+
+func TestEngine_Evaluate(t *testing.T) {
+	f := &fakeOPA{}
+	e := newTestEngine(t, f, "")
+	evalCtx := policy.EvaluationContext{
+		ToolName:      "delete_file",
+		ToolArguments: map[string]interface{}{"path": "/etc/passwd"},
+		UserRoles:     []string{"dev"},
+		IdentityName:  "alice",
+		RequestTime:   time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name   string
+		result string
+		want   policy.Decision
+	}{
+		{"boolean allow", `true`, policy.Decision{Allowed: true, RuleID: "rego:sentinelgate/decision", DecisionID: "d-1"}},
+		{"boolean deny", `false`, policy.Decision{RuleID: "rego:sentinelgate/decision", DecisionID: "d-1"}},
+		{"object deny", `{"allow":false,"rule":"no-deletes","reason":"deletes are blocked","help_text":"ask the platform team"}`,
+			policy.Decision{RuleID: "rego:no-deletes", RuleName: "no-deletes", Reason: "deletes are blocked", HelpText: "ask the platform team", DecisionID: "d-1"}},
+		{"approval", `{"approval_required":true,"approval_timeout":"10m","timeout_action":"deny","rule":"ask"}`,
+			policy.Decision{RequiresApproval: true, ApprovalTimeout: 10 * time.Minute, ApprovalTimeoutAction: policy.ActionDeny, RuleID: "rego:ask", DecisionID: "d-1"}},
+		{"undefined", ``, policy.Decision{RuleID: "rego:sentinelgate/decision", DecisionID: "d-undefined"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f.mu.Lock()
+			f.result = tt.result
+			f.mu.Unlock()
+			got, err := e.Evaluate(context.Background(), evalCtx)
+			if err != nil {
+				t.Fatalf("Evaluate(): %v", err)
+			}
+			if got.Allowed != tt.want.Allowed || got.RequiresApproval != tt.want.RequiresApproval ||
+				got.RuleID != tt.want.RuleID || got.DecisionID != tt.want.DecisionID ||
+				got.ApprovalTimeout != tt.want.ApprovalTimeout || got.ApprovalTimeoutAction != tt.want.ApprovalTimeoutAction ||
+				got.HelpText != tt.want.HelpText || (tt.want.Reason != "" && got.Reason != tt.want.Reason) {
+				t.Errorf("Evaluate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	f.mu.Lock()
+	input := f.input
+	f.mu.Unlock()
+	if input["tool_name"] != "delete_file" || input["identity_name"] != "alice" || input["request_time"] != "2026-03-02T10:00:00Z" {
+		t.Errorf("input = %v", input)
+	}
+	if args, _ := input["tool_args"].(map[string]any); args["path"] != "/etc/passwd" {
+		t.Errorf("input.tool_args = %v", input["tool_args"])
+	}
+	if vars, _ := input["vars"].(map[string]any); vars["env"] != "prod" {
+		t.Errorf("input.vars = %v", input["vars"])
+	}
+
+	f.mu.Lock()
+	f.result = `{"approval_required":true,"timeout_action":"maybe"}`
+	f.mu.Unlock()
+	if _, err := e.Evaluate(context.Background(), evalCtx); err == nil {
+		t.Error("Evaluate() with an invalid timeout_action: error = nil")
+	}
+	f.mu.Lock()
+	f.result = `"yes"`
+	f.mu.Unlock()
+	if _, err := e.Evaluate(context.Background(), evalCtx); err == nil {
+		t.Error("Evaluate() with a string decision: error = nil")
+	}
+}
+
+func TestEngine_EvaluateServerDown(t *testing.T) {
+	e, err := New(Config{URL: "http://127.0.0.1:1", Decision: "sentinelgate/decision"}, nil, slog.Default())
+	if err != nil {
+		t.Fatalf("New(): %v", err)
+	}
+	if _, err := e.Evaluate(context.Background(), policy.EvaluationContext{ToolName: "read_file"}); err == nil {
+		t.Error("Evaluate() error = nil, want an error when OPA is unreachable")
+	}
+}
+
+func TestEngine_SyncBundle(t *testing.T) {
+	dir := t.TempDir()
+	write := func(rel, content string) {
+		t.Helper()
+		p := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("authz.rego", "package sentinelgate\ndefault decision := false\n")
+	write("teams/data.json", `{"admins":["alice"]}`)
+	write("teams/teams.rego", "package teams\n")
+	write(".git/HEAD.rego", "ignored")
+	write("README.md", "ignored")
+
+	f := &fakeOPA{policies: map[string]string{}, data: map[string]string{}}
+	e := newTestEngine(t, f, dir)
+	ctx := context.Background()
+
+	if n, err := e.SyncBundle(ctx); err != nil || n != 3 {
+		t.Fatalf("SyncBundle() = %d, %v; want 3 files pushed", n, err)
+	}
+	if len(f.policies) != 2 || f.policies["sentinelgate/authz.rego"] == "" || f.data["/v1/data/teams"] != `{"admins":["alice"]}` {
+		t.Fatalf("policies = %v, data = %v", f.policies, f.data)
+	}
+	if st := e.Status(); len(st.Files) != 3 || st.LoadedAt == nil || st.LastError != "" {
+		t.Errorf("Status() = %+v", st)
+	}
+	if n, err := e.SyncBundle(ctx); err != nil || n != 0 {
+		t.Errorf("SyncBundle() unchanged = %d, %v", n, err)
+	}
+
+	// A changed file is pushed again, a removed one deleted.
+	write("authz.rego", "package sentinelgate\ndefault decision := true\n")
+	if err := os.Remove(filepath.Join(dir, "teams", "teams.rego")); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := e.SyncBundle(ctx); err != nil || n != 2 {
+		t.Fatalf("SyncBundle() after change = %d, %v", n, err)
+	}
+	if !strings.Contains(f.policies["sentinelgate/authz.rego"], "true") || len(f.policies) != 1 {
+		t.Errorf("policies = %v", f.policies)
+	}
+
+	// A rejected module is reported and pushed again on the next call.
+	write("bad.rego", "package")
+	f.reject = "sentinelgate/bad.rego"
+	if _, err := e.SyncBundle(ctx); err == nil || !strings.Contains(e.Status().LastError, "compiling") {
+		t.Fatalf("SyncBundle() with a rejected module: error = %v, status %+v", err, e.Status())
+	}
+	f.reject = ""
+	if n, err := e.SyncBundle(ctx); err != nil || n != 1 || e.Status().LastError != "" {
+		t.Errorf("SyncBundle() after fix = %d, %v", n, err)
+	}
+
+	// An undefined decision (e.g. OPA restarted empty) pushes everything again.
+	f.policies = map[string]string{}
+	if _, err := e.Evaluate(ctx, policy.EvaluationContext{ToolName: "read_file"}); err != nil {
+		t.Fatalf("Evaluate(): %v", err)
+	}
+	if n, err := e.SyncBundle(ctx); err != nil || n != 3 || len(f.policies) != 2 {
+		t.Errorf("SyncBundle() after undefined decision = %d, %v, policies %v", n, err, f.policies)
+	}
+}
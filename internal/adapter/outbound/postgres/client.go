@@ -0,0 +1,762 @@
+// Package postgres provides the minimal PostgreSQL client used by the
+// PostgreSQL-backed stores. It speaks version 3 of the frontend/backend
+// protocol with text-format parameters and results.
+package postgres
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/md5" //nolint:gosec // required by the md5 password authentication method
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Client defaults.
+const (
+	DefaultTimeout  = 5 * time.Second
+	DefaultPoolSize = 4
+	// protocolVersion is 3.0, sent in the startup message.
+	protocolVersion = 3 << 16
+	// sslRequestCode asks the server to switch to TLS.
+	sslRequestCode = 80877103
+	// maxMessageLen caps backend messages; larger ones indicate a protocol
+	// error.
+	maxMessageLen = 512 << 20
+	// applicationName is reported in pg_stat_activity.
+	applicationName = "sentinelgate"
+)
+
+// ErrClosed is returned by Exec and Begin after Close.
+var ErrClosed = errors.New("postgres: client closed")
+
+// ErrTxDone is returned by the methods of a transaction that was committed
+// or rolled back.
+var ErrTxDone = errors.New("postgres: transaction already committed or rolled back")
+
+// Error is an ErrorResponse sent by the server.
+type Error struct {
+	Severity string
+	// Code is the SQLSTATE code (e.g., "23505" for unique violations).
+	Code    string
+	Message string
+	Detail  string
+}
+
+func (e *Error) Error() string {
+	msg := "postgres: " + e.Message
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	return msg + " (SQLSTATE " + e.Code + ")"
+}
+
+// Options configures a Client.
+type Options struct {
+	// Address is the host:port of the server.
+	Address string
+	// User and Password authenticate each connection. Cleartext, MD5 and
+	// SCRAM-SHA-256 password authentication are supported.
+	User     string
+	Password string
+	// Database is the database connected to. Empty uses the user name.
+	Database string
+	// TLSConfig enables TLS when non-nil; the server must accept it.
+	TLSConfig *tls.Config
+	// Timeout bounds dialing and each statement when the context has no
+	// deadline. Zero uses 5s.
+	Timeout time.Duration
+	// PoolSize is the maximum number of idle connections kept. Zero uses 4.
+	PoolSize int
+}
+
+// Result is the outcome of a statement.
+type Result struct {
+	// Columns are the names of the returned columns.
+	Columns []string
+	// Rows holds the returned values in text format; NULL is nil.
+	Rows [][]*string
+	// Tag is the command tag (e.g., "SELECT 2", "INSERT 0 1").
+	Tag string
+}
+
+// RowsAffected returns the row count of the command tag, or 0 if it has
+// none.
+func (r *Result) RowsAffected() int64 {
+	i := strings.LastIndexByte(r.Tag, ' ')
+	if i < 0 {
+		return 0
+	}
+	n, _ := strconv.ParseInt(r.Tag[i+1:], 10, 64)
+	return n
+}
+
+// Client is a PostgreSQL client holding a small pool of connections. It is
+// safe for concurrent use. A connection runs one statement at a time; one
+// that fails outside of a server error is discarded.
+type Client struct {
+	opts Options
+
+	mu     sync.Mutex
+	idle   []*conn
+	closed bool
+}
+
+// conn is a single connection with its buffered reader.
+type conn struct {
+	nc net.Conn
+	br *bufio.Reader
+	// tls reports whether nc is encrypted; cleartext passwords are only
+	// sent over TLS.
+	tls bool
+	// txStatus is the status of the last ReadyForQuery: 'I' idle, 'T' in a
+	// transaction, 'E' in a failed transaction.
+	txStatus byte
+}
+
+// NewClient creates a client. Connections are opened on first use.
+func NewClient(opts Options) *Client {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = DefaultPoolSize
+	}
+	if opts.Database == "" {
+		opts.Database = opts.User
+	}
+	return &Client{opts: opts}
+}
+
+// Exec runs a statement with the extended query protocol and returns its
+// result. Arguments are bound to $1, $2, ... in text format; string, []byte,
+// integers, bool, time.Time and nil (NULL) are supported. Server errors
+// return an *Error.
+func (c *Client) Exec(ctx context.Context, query string, args ...any) (*Result, error) {
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res, err := cn.exec(ctx, c.opts.Timeout, query, args)
+	c.release(cn, err)
+	return res, err
+}
+
+// Ping checks that the server answers.
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.Exec(ctx, "SELECT 1")
+	return err
+}
+
+// Begin starts a transaction on a connection reserved until Commit or
+// Rollback.
+func (c *Client) Begin(ctx context.Context) (*Tx, error) {
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := cn.exec(ctx, c.opts.Timeout, "BEGIN", nil); err != nil {
+		c.release(cn, err)
+		return nil, err
+	}
+	return &Tx{c: c, cn: cn}, nil
+}
+
+// Close closes the idle connections; later statements return ErrClosed.
+// Safe to call multiple times.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	idle := c.idle
+	c.idle = nil
+	c.closed = true
+	c.mu.Unlock()
+	for _, cn := range idle {
+		cn.terminate()
+	}
+	return nil
+}
+
+func (c *Client) get(ctx context.Context) (*conn, error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, ErrClosed
+	}
+	if n := len(c.idle); n > 0 {
+		cn := c.idle[n-1]
+		c.idle = c.idle[:n-1]
+		c.mu.Unlock()
+		return cn, nil
+	}
+	c.mu.Unlock()
+	return c.dial(ctx)
+}
+
+// release returns cn to the pool unless err left it in an unknown state or
+// inside a transaction.
+func (c *Client) release(cn *conn, err error) {
+	var serverErr *Error
+	if (err != nil && !errors.As(err, &serverErr)) || cn.txStatus != 'I' {
+		_ = cn.nc.Close()
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed || len(c.idle) >= c.opts.PoolSize {
+		cn.terminate()
+		return
+	}
+	c.idle = append(c.idle, cn)
+}
+
+// Tx is a transaction. It is not safe for concurrent use.
+type Tx struct {
+	c    *Client
+	cn   *conn
+	done bool
+}
+
+// Exec runs a statement in the transaction. After a server error the
+// transaction is aborted and only Rollback succeeds.
+func (tx *Tx) Exec(ctx context.Context, query string, args ...any) (*Result, error) {
+	if tx.done {
+		return nil, ErrTxDone
+	}
+	res, err := tx.cn.exec(ctx, tx.c.opts.Timeout, query, args)
+	var serverErr *Error
+	if err != nil && !errors.As(err, &serverErr) {
+		tx.done = true
+		_ = tx.cn.nc.Close()
+	}
+	return res, err
+}
+
+// Commit commits the transaction. Committing an aborted transaction rolls
+// it back and returns an error.
+func (tx *Tx) Commit(ctx context.Context) error {
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+	res, err := tx.cn.exec(ctx, tx.c.opts.Timeout, "COMMIT", nil)
+	tx.c.release(tx.cn, err)
+	if err != nil {
+		return err
+	}
+	if res.Tag == "ROLLBACK" {
+		return errors.New("postgres: transaction aborted, rolled back")
+	}
+	return nil
+}
+
+// Rollback aborts the transaction. It does nothing after Commit, so it can
+// be deferred.
+func (tx *Tx) Rollback(ctx context.Context) error {
+	if tx.done {
+		return nil
+	}
+	tx.done = true
+	_, err := tx.cn.exec(ctx, tx.c.opts.Timeout, "ROLLBACK", nil)
+	tx.c.release(tx.cn, err)
+	return err
+}
+
+// dial opens a connection, negotiates TLS and authenticates it.
+func (c *Client) dial(ctx context.Context) (*conn, error) {
+	dialer := &net.Dialer{Timeout: c.opts.Timeout}
+	nc, err := dialer.DialContext(ctx, "tcp", c.opts.Address)
+	if err != nil {
+		return nil, fmt.Errorf("postgres: connect %s: %w", c.opts.Address, err)
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(c.opts.Timeout)
+	}
+	if err := nc.SetDeadline(deadline); err != nil {
+		_ = nc.Close()
+		return nil, err
+	}
+	if c.opts.TLSConfig != nil {
+		if nc, err = startTLS(ctx, nc, c.opts.TLSConfig); err != nil {
+			return nil, err
+		}
+	}
+	cn := &conn{nc: nc, br: bufio.NewReader(nc), tls: c.opts.TLSConfig != nil}
+	if err := cn.startup(c.opts); err != nil {
+		_ = nc.Close()
+		return nil, err
+	}
+	return cn, nil
+}
+
+// startTLS sends an SSLRequest and upgrades nc when the server accepts it.
+func startTLS(ctx context.Context, nc net.Conn, cfg *tls.Config) (net.Conn, error) {
+	var req [8]byte
+	binary.BigEndian.PutUint32(req[0:], 8)
+	binary.BigEndian.PutUint32(req[4:], sslRequestCode)
+	if _, err := nc.Write(req[:]); err != nil {
+		_ = nc.Close()
+		return nil, fmt.Errorf("postgres: write: %w", err)
+	}
+	var answer [1]byte
+	if _, err := io.ReadFull(nc, answer[:]); err != nil {
+		_ = nc.Close()
+		return nil, fmt.Errorf("postgres: read: %w", err)
+	}
+	if answer[0] != 'S' {
+		_ = nc.Close()
+		return nil, errors.New("postgres: server does not accept TLS connections")
+	}
+	tc := tls.Client(nc, cfg)
+	if err := tc.HandshakeContext(ctx); err != nil {
+		_ = nc.Close()
+		return nil, fmt.Errorf("postgres: TLS handshake: %w", err)
+	}
+	return tc, nil
+}
+
+// startup sends the startup message and answers the authentication
+// requests until the server is ready for queries. A cleartext password is
+// only sent over TLS, and a SCRAM exchange only succeeds once the server has
+// proven it knows the password with a valid SASLFinal.
+func (cn *conn) startup(opts Options) error {
+	var w msgWriter
+	w.startUntyped()
+	w.int32(protocolVersion)
+	for _, kv := range [][2]string{{"user", opts.User}, {"database", opts.Database}, {"application_name", applicationName}, {"client_encoding", "UTF8"}} {
+		w.cstring(kv[0])
+		w.cstring(kv[1])
+	}
+	w.byte(0)
+	if err := cn.write(w.finish()); err != nil {
+		return err
+	}
+
+	var scram *scramClient
+	scramVerified := false
+	for {
+		typ, msg, err := cn.read()
+		if err != nil {
+			return err
+		}
+		switch typ {
+		case 'R':
+			r := msgReader{b: msg}
+			switch code := r.int32(); code {
+			case 0: // AuthenticationOk
+				if scram != nil && !scramVerified {
+					return errors.New("postgres: server skipped the SCRAM server signature")
+				}
+			case 3: // AuthenticationCleartextPassword
+				if !cn.tls {
+					return errors.New("postgres: server asks for a cleartext password over an unencrypted connection")
+				}
+				if err := cn.sendPassword(opts.Password); err != nil {
+					return err
+				}
+			case 5: // AuthenticationMD5Password
+				salt := r.next(4)
+				if err := cn.sendPassword(md5Password(opts.User, opts.Password, salt)); err != nil {
+					return err
+				}
+			case 10: // AuthenticationSASL
+				if !containsString(r.cstrings(), "SCRAM-SHA-256") {
+					return errors.New("postgres: server offers no supported SASL mechanism")
+				}
+				nonce, err := newNonce()
+				if err != nil {
+					return err
+				}
+				scram = newSCRAMClient("", opts.Password, nonce)
+				first := scram.clientFirst()
+				var w msgWriter
+				w.start('p')
+				w.cstring("SCRAM-SHA-256")
+				w.int32(int32(len(first)))
+				w.bytes([]byte(first))
+				if err := cn.write(w.finish()); err != nil {
+					return err
+				}
+			case 11: // AuthenticationSASLContinue
+				if scram == nil {
+					return errors.New("postgres: unexpected SASL continue")
+				}
+				final, err := scram.clientFinal(string(r.rest()))
+				if err != nil {
+					return err
+				}
+				var w msgWriter
+				w.start('p')
+				w.bytes([]byte(final))
+				if err := cn.write(w.finish()); err != nil {
+					return err
+				}
+			case 12: // AuthenticationSASLFinal
+				if scram == nil {
+					return errors.New("postgres: unexpected SASL final")
+				}
+				if err := scram.verifyServerFinal(string(r.rest())); err != nil {
+					return err
+				}
+				scramVerified = true
+			default:
+				return fmt.Errorf("postgres: unsupported authentication method %d", code)
+			}
+		case 'E':
+			return fmt.Errorf("postgres: authenticate: %w", parseError(msg))
+		case 'Z':
+			if len(msg) > 0 {
+				cn.txStatus = msg[0]
+			}
+			return nil
+		case 'S', 'K', 'N': // ParameterStatus, BackendKeyData, NoticeResponse
+		default:
+			return fmt.Errorf("postgres: unexpected message %q during startup", typ)
+		}
+	}
+}
+
+func (cn *conn) sendPassword(password string) error {
+	var w msgWriter
+	w.start('p')
+	w.cstring(password)
+	return cn.write(w.finish())
+}
+
+// exec sends Parse, Bind, Describe, Execute and Sync for query and reads
+// the replies up to ReadyForQuery.
+func (cn *conn) exec(ctx context.Context, timeout time.Duration, query string, args []any) (*Result, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(timeout)
+	}
+	if err := cn.nc.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	var w msgWriter
+	w.start('P')
+	w.cstring("")
+	w.cstring(query)
+	w.int16(0)
+	w.finish()
+	w.start('B')
+	w.cstring("")
+	w.cstring("")
+	w.int16(0) // all parameters in text format
+	w.int16(int16(len(args)))
+	for i, a := range args {
+		v, null, err := encodeArg(a)
+		if err != nil {
+			return nil, fmt.Errorf("postgres: argument $%d: %w", i+1, err)
+		}
+		if null {
+			w.int32(-1)
+			continue
+		}
+		w.int32(int32(len(v)))
+		w.bytes(v)
+	}
+	w.int16(0) // all results in text format
+	w.finish()
+	w.start('D')
+	w.byte('P')
+	w.cstring("")
+	w.finish()
+	w.start('E')
+	w.cstring("")
+	w.int32(0)
+	w.finish()
+	w.start('S')
+	if err := cn.write(w.finish()); err != nil {
+		return nil, err
+	}
+
+	res := &Result{}
+	var serverErr error
+	for {
+		typ, msg, err := cn.read()
+		if err != nil {
+			return nil, err
+		}
+		switch typ {
+		case 'T': // RowDescription
+			r := msgReader{b: msg}
+			n := int(r.int16())
+			res.Columns = make([]string, 0, n)
+			for i := 0; i < n && r.err == nil; i++ {
+				res.Columns = append(res.Columns, r.cstring())
+				r.next(18) // table OID, column, type OID, size, modifier, format
+			}
+			if r.err != nil {
+				return nil, r.err
+			}
+		case 'D': // DataRow
+			r := msgReader{b: msg}
+			n := int(r.int16())
+			row := make([]*string, n)
+			for i := 0; i < n && r.err == nil; i++ {
+				if size := r.int32(); size >= 0 {
+					v := string(r.next(int(size)))
+					row[i] = &v
+				}
+			}
+			if r.err != nil {
+				return nil, r.err
+			}
+			res.Rows = append(res.Rows, row)
+		case 'C': // CommandComplete
+			r := msgReader{b: msg}
+			res.Tag = r.cstring()
+		case 'E':
+			if serverErr == nil {
+				serverErr = parseError(msg)
+			}
+		case 'Z':
+			if len(msg) > 0 {
+				cn.txStatus = msg[0]
+			}
+			if serverErr != nil {
+				return nil, serverErr
+			}
+			return res, nil
+		case '1', '2', 'n', 'I', 'N', 'S', 's':
+			// ParseComplete, BindComplete, NoData, EmptyQueryResponse,
+			// NoticeResponse, ParameterStatus, PortalSuspended.
+		default:
+			return nil, fmt.Errorf("postgres: unexpected message %q", typ)
+		}
+	}
+}
+
+// terminate sends Terminate and closes the connection.
+func (cn *conn) terminate() {
+	_ = cn.nc.SetDeadline(time.Now().Add(time.Second))
+	_, _ = cn.nc.Write([]byte{'X', 0, 0, 0, 4})
+	_ = cn.nc.Close()
+}
+
+func (cn *conn) write(b []byte) error {
+	if _, err := cn.nc.Write(b); err != nil {
+		return fmt.Errorf("postgres: write: %w", err)
+	}
+	return nil
+}
+
+// read reads one backend message.
+func (cn *conn) read() (byte, []byte, error) {
+	return readMessage(cn.br)
+}
+
+// readMessage reads a typed message: a type byte, a length including
+// itself and the payload.
+func readMessage(br *bufio.Reader) (byte, []byte, error) {
+	var hdr [5]byte
+	if _, err := io.ReadFull(br, hdr[:]); err != nil {
+		return 0, nil, fmt.Errorf("postgres: read: %w", err)
+	}
+	n := int(int32(binary.BigEndian.Uint32(hdr[1:]))) - 4
+	if n < 0 || n > maxMessageLen {
+		return 0, nil, fmt.Errorf("postgres: invalid message length %d", n)
+	}
+	msg := make([]byte, n)
+	if _, err := io.ReadFull(br, msg); err != nil {
+		return 0, nil, fmt.Errorf("postgres: read: %w", err)
+	}
+	return hdr[0], msg, nil
+}
+
+// encodeArg returns the text format of a parameter.
+func encodeArg(a any) (v []byte, null bool, err error) {
+	switch a := a.(type) {
+	case nil:
+		return nil, true, nil
+	case string:
+		return []byte(a), false, nil
+	case []byte:
+		if a == nil {
+			return nil, true, nil
+		}
+		return a, false, nil
+	case int:
+		return strconv.AppendInt(nil, int64(a), 10), false, nil
+	case int32:
+		return strconv.AppendInt(nil, int64(a), 10), false, nil
+	case int64:
+		return strconv.AppendInt(nil, a, 10), false, nil
+	case bool:
+		return strconv.AppendBool(nil, a), false, nil
+	case time.Time:
+		return []byte(a.Format(time.RFC3339Nano)), false, nil
+	}
+	return nil, false, fmt.Errorf("unsupported type %T", a)
+}
+
+// parseError decodes the fields of an ErrorResponse.
+func parseError(msg []byte) *Error {
+	e := &Error{}
+	r := msgReader{b: msg}
+	for r.err == nil {
+		field := r.byte()
+		if field == 0 || r.err != nil {
+			break
+		}
+		v := r.cstring()
+		switch field {
+		case 'S':
+			e.Severity = v
+		case 'C':
+			e.Code = v
+		case 'M':
+			e.Message = v
+		case 'D':
+			e.Detail = v
+		}
+	}
+	return e
+}
+
+// md5Password returns the response to an MD5 password request:
+// "md5" + md5(md5(password + user) + salt) in hex.
+func md5Password(user, password string, salt []byte) string {
+	inner := md5.Sum([]byte(password + user)) //nolint:gosec // protocol-defined
+	h := md5.New()                            //nolint:gosec // protocol-defined
+	h.Write([]byte(hex.EncodeToString(inner[:])))
+	h.Write(salt)
+	return "md5" + hex.EncodeToString(h.Sum(nil))
+}
+
+// newNonce returns a random SCRAM client nonce.
+func newNonce() (string, error) {
+	b := make([]byte, 18)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("postgres: generate nonce: %w", err)
+	}
+	return base64.RawStdEncoding.EncodeToString(b), nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// msgWriter builds frontend messages, filling in their lengths.
+type msgWriter struct {
+	b   []byte
+	pos int // offset of the length of the current message
+}
+
+// start begins a message of type typ.
+func (w *msgWriter) start(typ byte) {
+	w.b = append(w.b, typ)
+	w.startUntyped()
+}
+
+// startUntyped begins a message without a type byte, like the startup
+// message.
+func (w *msgWriter) startUntyped() {
+	w.pos = len(w.b)
+	w.b = append(w.b, 0, 0, 0, 0)
+}
+
+// finish writes the length of the current message and returns the buffer.
+func (w *msgWriter) finish() []byte {
+	binary.BigEndian.PutUint32(w.b[w.pos:], uint32(len(w.b)-w.pos))
+	return w.b
+}
+
+func (w *msgWriter) byte(v byte)      { w.b = append(w.b, v) }
+func (w *msgWriter) int16(v int16)    { w.b = binary.BigEndian.AppendUint16(w.b, uint16(v)) }
+func (w *msgWriter) int32(v int32)    { w.b = binary.BigEndian.AppendUint32(w.b, uint32(v)) }
+func (w *msgWriter) bytes(v []byte)   { w.b = append(w.b, v...) }
+func (w *msgWriter) cstring(s string) { w.b = append(append(w.b, s...), 0) }
+
+// msgReader decodes backend messages. After the first short read every
+// method returns zero values and err is set.
+type msgReader struct {
+	b   []byte
+	err error
+}
+
+func (r *msgReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.b) {
+		r.err = errors.New("postgres: truncated message")
+		return nil
+	}
+	v := r.b[:n]
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *msgReader) byte() byte {
+	if v := r.next(1); v != nil {
+		return v[0]
+	}
+	return 0
+}
+
+func (r *msgReader) int16() int16 {
+	if v := r.next(2); v != nil {
+		return int16(binary.BigEndian.Uint16(v))
+	}
+	return 0
+}
+
+func (r *msgReader) int32() int32 {
+	if v := r.next(4); v != nil {
+		return int32(binary.BigEndian.Uint32(v))
+	}
+	return 0
+}
+
+func (r *msgReader) cstring() string {
+	if r.err != nil {
+		return ""
+	}
+	i := bytes.IndexByte(r.b, 0)
+	if i < 0 {
+		r.err = errors.New("postgres: unterminated string")
+		return ""
+	}
+	s := string(r.b[:i])
+	r.b = r.b[i+1:]
+	return s
+}
+
+// cstrings reads strings up to an empty one.
+func (r *msgReader) cstrings() []string {
+	var list []string
+	for r.err == nil && len(r.b) > 0 {
+		s := r.cstring()
+		if s == "" {
+			break
+		}
+		list = append(list, s)
+	}
+	return list
+}
+
+// rest returns the unread bytes.
+func (r *msgReader) rest() []byte {
+	v := r.b
+	r.b = nil
+	return v
+}
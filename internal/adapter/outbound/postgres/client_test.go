@@ -0,0 +1,520 @@
+package postgres
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeServer is an in-process PostgreSQL speaking enough of the protocol
+// for the client: TLS, the startup message, trust, cleartext, MD5 and
+// SCRAM-SHA-256 authentication, and extended queries answered by handle.
+type fakeServer struct {
+	ln net.Listener
+	// auth is "trust", "password", "md5", "scram" or "scram-unsigned", a
+	// SCRAM exchange without the server signature.
+	auth     string
+	user     string
+	password string
+	// clientTLS is the client configuration matching the server's
+	// certificate when the server expects TLS.
+	clientTLS *tls.Config
+
+	mu    sync.Mutex
+	conns int
+	// tlsConfig makes the server expect an SSLRequest.
+	tlsConfig *tls.Config
+	queries   []string
+	args      [][]*string
+	// handle answers a query; nil answers "SELECT 0".
+	handle func(query string, args []*string) (*Result, *Error)
+}
+
+func newFakeServer(t *testing.T, auth, password string) *fakeServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &fakeServer{ln: ln, auth: auth, user: "gate", password: password}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.conns++
+			tlsConfig := s.tlsConfig
+			s.mu.Unlock()
+			go s.serve(c, tlsConfig)
+		}
+	}()
+	return s
+}
+
+func (s *fakeServer) client() *Client {
+	return NewClient(Options{Address: s.ln.Addr().String(), User: s.user, Password: s.password, Database: "gate", TLSConfig: s.clientTLS})
+}
+
+// useTLS makes the server accept only TLS connections, with a certificate
+// for 127.0.0.1.
+func (s *fakeServer) useTLS(t *testing.T) {
+	t.Helper()
+	ts := httptest.NewTLSServer(nil)
+	ts.Close()
+	s.mu.Lock()
+	s.tlsConfig = ts.TLS
+	s.mu.Unlock()
+	s.clientTLS = ts.Client().Transport.(*http.Transport).TLSClientConfig.Clone()
+	s.clientTLS.ServerName = "127.0.0.1"
+}
+
+func (s *fakeServer) send(c net.Conn, typ byte, build func(w *msgWriter)) error {
+	var w msgWriter
+	w.start(typ)
+	if build != nil {
+		build(&w)
+	}
+	_, err := c.Write(w.finish())
+	return err
+}
+
+func (s *fakeServer) sendError(c net.Conn, e *Error) error {
+	return s.send(c, 'E', func(w *msgWriter) {
+		w.byte('S')
+		w.cstring("ERROR")
+		w.byte('C')
+		w.cstring(e.Code)
+		w.byte('M')
+		w.cstring(e.Message)
+		w.byte(0)
+	})
+}
+
+func (s *fakeServer) serve(c net.Conn, tlsConfig *tls.Config) {
+	defer func() { _ = c.Close() }()
+	if tlsConfig != nil {
+		var req [8]byte
+		if _, err := io.ReadFull(c, req[:]); err != nil || binary.BigEndian.Uint32(req[4:]) != sslRequestCode {
+			return
+		}
+		if _, err := c.Write([]byte{'S'}); err != nil {
+			return
+		}
+		c = tls.Server(c, tlsConfig)
+	}
+	br := bufio.NewReader(c)
+
+	var hdr [8]byte
+	if _, err := io.ReadFull(br, hdr[:]); err != nil {
+		return
+	}
+	startup := make([]byte, binary.BigEndian.Uint32(hdr[:4])-8)
+	if _, err := io.ReadFull(br, startup); err != nil {
+		return
+	}
+	params := (&msgReader{b: startup}).cstrings()
+	if len(params) < 2 || params[0] != "user" || params[1] != s.user {
+		_ = s.sendError(c, &Error{Code: "28000", Message: "unknown user"})
+		return
+	}
+	if !s.authenticate(c, br) {
+		return
+	}
+	_ = s.send(c, 'R', func(w *msgWriter) { w.int32(0) })
+	_ = s.send(c, 'S', func(w *msgWriter) { w.cstring("server_version"); w.cstring("16.0") })
+	_ = s.send(c, 'Z', func(w *msgWriter) { w.byte('I') })
+
+	status := byte('I')
+	var query string
+	var args []*string
+	var res *Result
+	var failed *Error
+	for {
+		typ, msg, err := readMessage(br)
+		if err != nil {
+			return
+		}
+		r := msgReader{b: msg}
+		switch typ {
+		case 'P':
+			r.cstring()
+			query = r.cstring()
+			res, failed = nil, nil
+		case 'B':
+			r.cstring()
+			r.cstring()
+			r.next(2 * int(r.int16()))
+			args = make([]*string, r.int16())
+			for i := range args {
+				if n := r.int32(); n >= 0 {
+					v := string(r.next(int(n)))
+					args[i] = &v
+				}
+			}
+		case 'E':
+			res, failed = s.exec(query, args, &status)
+		case 'S':
+			if failed != nil {
+				_ = s.sendError(c, failed)
+			} else {
+				_ = s.send(c, '1', nil)
+				_ = s.send(c, '2', nil)
+				if len(res.Columns) > 0 {
+					_ = s.send(c, 'T', func(w *msgWriter) {
+						w.int16(int16(len(res.Columns)))
+						for _, col := range res.Columns {
+							w.cstring(col)
+							w.bytes(make([]byte, 18))
+						}
+					})
+				} else {
+					_ = s.send(c, 'n', nil)
+				}
+				for _, row := range res.Rows {
+					_ = s.send(c, 'D', func(w *msgWriter) {
+						w.int16(int16(len(row)))
+						for _, v := range row {
+							if v == nil {
+								w.int32(-1)
+								continue
+							}
+							w.int32(int32(len(*v)))
+							w.bytes([]byte(*v))
+						}
+					})
+				}
+				_ = s.send(c, 'C', func(w *msgWriter) { w.cstring(res.Tag) })
+			}
+			_ = s.send(c, 'Z', func(w *msgWriter) { w.byte(status) })
+		case 'X':
+			return
+		}
+	}
+}
+
+// exec answers one statement, tracking the transaction status.
+func (s *fakeServer) exec(query string, args []*string, status *byte) (*Result, *Error) {
+	s.mu.Lock()
+	s.queries = append(s.queries, query)
+	s.args = append(s.args, args)
+	handle := s.handle
+	s.mu.Unlock()
+
+	switch query {
+	case "BEGIN":
+		*status = 'T'
+		return &Result{Tag: "BEGIN"}, nil
+	case "COMMIT":
+		tag := "COMMIT"
+		if *status == 'E' {
+			tag = "ROLLBACK"
+		}
+		*status = 'I'
+		return &Result{Tag: tag}, nil
+	case "ROLLBACK":
+		*status = 'I'
+		return &Result{Tag: "ROLLBACK"}, nil
+	}
+	if *status == 'E' {
+		return nil, &Error{Code: "25P02", Message: "current transaction is aborted"}
+	}
+	res, e := &Result{Tag: "SELECT 0"}, (*Error)(nil)
+	if handle != nil {
+		res, e = handle(query, args)
+	}
+	if e != nil && *status == 'T' {
+		*status = 'E'
+	}
+	return res, e
+}
+
+// authenticate runs the configured authentication exchange.
+func (s *fakeServer) authenticate(c net.Conn, br *bufio.Reader) bool {
+	readPassword := func() string {
+		typ, msg, err := readMessage(br)
+		if err != nil || typ != 'p' {
+			return ""
+		}
+		return (&msgReader{b: msg}).cstring()
+	}
+	fail := func() bool {
+		_ = s.sendError(c, &Error{Code: "28P01", Message: "password authentication failed"})
+		return false
+	}
+	switch s.auth {
+	case "password":
+		_ = s.send(c, 'R', func(w *msgWriter) { w.int32(3) })
+		if readPassword() != s.password {
+			return fail()
+		}
+	case "md5":
+		salt := []byte{1, 2, 3, 4}
+		_ = s.send(c, 'R', func(w *msgWriter) { w.int32(5); w.bytes(salt) })
+		if readPassword() != md5Password(s.user, s.password, salt) {
+			return fail()
+		}
+	case "scram", "scram-unsigned":
+		return s.scram(c, br, fail)
+	}
+	return true
+}
+
+func (s *fakeServer) scram(c net.Conn, br *bufio.Reader, fail func() bool) bool {
+	_ = s.send(c, 'R', func(w *msgWriter) { w.int32(10); w.cstring("SCRAM-SHA-256"); w.byte(0) })
+	typ, msg, err := readMessage(br)
+	if err != nil || typ != 'p' {
+		return false
+	}
+	r := msgReader{b: msg}
+	if r.cstring() != "SCRAM-SHA-256" {
+		return fail()
+	}
+	clientFirst := string(r.next(int(r.int32())))
+	bare := strings.TrimPrefix(clientFirst, "n,,")
+	_, clientNonce, _ := strings.Cut(bare, ",r=")
+
+	salt := []byte("pepper")
+	serverFirst := "r=" + clientNonce + "server,s=" + base64.StdEncoding.EncodeToString(salt) + ",i=4096"
+	_ = s.send(c, 'R', func(w *msgWriter) { w.int32(11); w.bytes([]byte(serverFirst)) })
+
+	typ, msg, err = readMessage(br)
+	if err != nil || typ != 'p' {
+		return false
+	}
+	clientFinal := string(msg)
+	withoutProof, proof64, _ := strings.Cut(clientFinal, ",p=")
+	proof, _ := base64.StdEncoding.DecodeString(proof64)
+
+	// The server keeps the keys derived from the password.
+	keys := newSCRAMClient("", s.password, clientNonce)
+	keys.clientFirst()
+	if _, err := keys.clientFinal(serverFirst); err != nil {
+		return fail()
+	}
+	clientKey := hmacSHA256(keys.saltedPassword, "Client Key")
+	storedKey := sha256.Sum256(clientKey)
+	authMessage := bare + "," + serverFirst + "," + withoutProof
+	sig := hmacSHA256(storedKey[:], authMessage)
+	if len(proof) != len(sig) {
+		return fail()
+	}
+	for i := range sig {
+		sig[i] ^= proof[i]
+	}
+	if got := sha256.Sum256(sig); subtle.ConstantTimeCompare(got[:], storedKey[:]) != 1 {
+		return fail()
+	}
+	mac := hmac.New(sha256.New, hmacSHA256(keys.saltedPassword, "Server Key"))
+	mac.Write([]byte(authMessage))
+	serverFinal := "v=" + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	if s.auth != "scram-unsigned" {
+		_ = s.send(c, 'R', func(w *msgWriter) { w.int32(12); w.bytes([]byte(serverFinal)) })
+	}
+	return true
+}
+
+func strp(s string) *string { return &s }
+
+func TestClient_Exec(t *testing.T) {
+	s := newFakeServer(t, "trust", "")
+	s.handle = func(query string, args []*string) (*Result, *Error) {
+		return &Result{
+			Columns: []string{"id", "note"},
+			Rows:    [][]*string{{strp("7"), nil}, {strp("8"), strp("héllo")}},
+			Tag:     "SELECT 2",
+		}, nil
+	}
+	c := s.client()
+	defer func() { _ = c.Close() }()
+
+	res, err := c.Exec(context.Background(), "SELECT id, note FROM t WHERE id >= $1 AND name = $2 AND x IS $3", 7, "a'b", nil)
+	if err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+	if strings.Join(res.Columns, ",") != "id,note" || len(res.Rows) != 2 {
+		t.Fatalf("result = %+v", res)
+	}
+	if *res.Rows[0][0] != "7" || res.Rows[0][1] != nil || *res.Rows[1][1] != "héllo" {
+		t.Errorf("rows = %v %v", res.Rows[0], res.Rows[1])
+	}
+	if res.RowsAffected() != 2 {
+		t.Errorf("RowsAffected() = %d, want 2", res.RowsAffected())
+	}
+	args := s.args[0]
+	if len(args) != 3 || *args[0] != "7" || *args[1] != "a'b" || args[2] != nil {
+		t.Errorf("server got args %v", args)
+	}
+
+	if _, err := c.Exec(context.Background(), "SELECT $1", struct{}{}); err == nil {
+		t.Error("Exec with an unsupported argument type succeeded")
+	}
+}
+
+func TestClient_Authentication(t *testing.T) {
+	for _, auth := range []string{"trust", "password", "md5", "scram"} {
+		t.Run(auth, func(t *testing.T) {
+			s := newFakeServer(t, auth, "s3cret")
+			if auth == "password" {
+				s.useTLS(t)
+			}
+			c := s.client()
+			defer func() { _ = c.Close() }()
+			if err := c.Ping(context.Background()); err != nil {
+				t.Fatalf("Ping: %v", err)
+			}
+			if auth == "trust" {
+				return
+			}
+			bad := NewClient(Options{Address: s.ln.Addr().String(), User: "gate", Password: "wrong", TLSConfig: s.clientTLS})
+			defer func() { _ = bad.Close() }()
+			err := bad.Ping(context.Background())
+			var pgErr *Error
+			if !errors.As(err, &pgErr) || pgErr.Code != "28P01" {
+				t.Errorf("Ping with a wrong password = %v, want SQLSTATE 28P01", err)
+			}
+		})
+	}
+}
+
+func TestClient_CleartextPasswordRequiresTLS(t *testing.T) {
+	s := newFakeServer(t, "password", "s3cret")
+	c := s.client()
+	defer func() { _ = c.Close() }()
+	if err := c.Ping(context.Background()); err == nil || !strings.Contains(err.Error(), "cleartext password") {
+		t.Errorf("Ping without TLS = %v, want a refusal to send the cleartext password", err)
+	}
+}
+
+func TestClient_SCRAMRequiresServerSignature(t *testing.T) {
+	s := newFakeServer(t, "scram-unsigned", "s3cret")
+	c := s.client()
+	defer func() { _ = c.Close() }()
+	if err := c.Ping(context.Background()); err == nil || !strings.Contains(err.Error(), "SCRAM server signature") {
+		t.Errorf("Ping without SASLFinal = %v, want a missing server signature error", err)
+	}
+}
+
+func TestClient_ServerErrorKeepsConnection(t *testing.T) {
+	s := newFakeServer(t, "trust", "")
+	s.handle = func(query string, args []*string) (*Result, *Error) {
+		if strings.HasPrefix(query, "INSERT") {
+			return nil, &Error{Code: "23505", Message: "duplicate key value violates unique constraint"}
+		}
+		return &Result{Tag: "SELECT 0"}, nil
+	}
+	c := s.client()
+	defer func() { _ = c.Close() }()
+
+	_, err := c.Exec(context.Background(), "INSERT INTO t VALUES ($1)", "a")
+	var pgErr *Error
+	if !errors.As(err, &pgErr) || pgErr.Code != "23505" {
+		t.Fatalf("Exec error = %v, want SQLSTATE 23505", err)
+	}
+	if err := c.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping after a server error: %v", err)
+	}
+	if s.conns != 1 {
+		t.Errorf("connections = %d, want 1 (reused after a server error)", s.conns)
+	}
+}
+
+func TestTx(t *testing.T) {
+	s := newFakeServer(t, "trust", "")
+	s.handle = func(query string, args []*string) (*Result, *Error) {
+		if query == "FAIL" {
+			return nil, &Error{Code: "22P02", Message: "invalid input syntax"}
+		}
+		return &Result{Tag: "UPDATE 1"}, nil
+	}
+	c := s.client()
+	defer func() { _ = c.Close() }()
+	ctx := context.Background()
+
+	tx, err := c.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if _, err := tx.Exec(ctx, "UPDATE t SET v = $1", "x"); err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if err := tx.Rollback(ctx); err != nil {
+		t.Errorf("Rollback after Commit = %v, want nil", err)
+	}
+	if _, err := tx.Exec(ctx, "UPDATE t SET v = 1"); !errors.Is(err, ErrTxDone) {
+		t.Errorf("Exec after Commit = %v, want ErrTxDone", err)
+	}
+
+	// A failed statement aborts the transaction: Commit rolls it back.
+	tx, err = c.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if _, err := tx.Exec(ctx, "FAIL"); err == nil {
+		t.Fatal("Exec(FAIL) succeeded")
+	}
+	if err := tx.Commit(ctx); err == nil {
+		t.Error("Commit of an aborted transaction succeeded")
+	}
+
+	want := "BEGIN|UPDATE t SET v = $1|COMMIT|BEGIN|FAIL|COMMIT"
+	if got := strings.Join(s.queries, "|"); got != want {
+		t.Errorf("queries = %s, want %s", got, want)
+	}
+	if s.conns != 1 {
+		t.Errorf("connections = %d, want 1", s.conns)
+	}
+}
+
+func TestClient_Closed(t *testing.T) {
+	s := newFakeServer(t, "trust", "")
+	c := s.client()
+	_ = c.Close()
+	if _, err := c.Exec(context.Background(), "SELECT 1"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Exec after Close = %v, want ErrClosed", err)
+	}
+}
+
+// TestSCRAM_RFC7677 checks the exchange against the example of RFC 7677.
+func TestSCRAM_RFC7677(t *testing.T) {
+	s := newSCRAMClient("user", "pencil", "rOprNGfwEbeRWgbNEkqO")
+	if got := s.clientFirst(); got != "n,,n=user,r=rOprNGfwEbeRWgbNEkqO" {
+		t.Fatalf("clientFirst() = %q", got)
+	}
+	final, err := s.clientFinal("r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096")
+	if err != nil {
+		t.Fatalf("clientFinal: %v", err)
+	}
+	want := "c=biws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,p=dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ="
+	if final != want {
+		t.Errorf("clientFinal() = %q, want %q", final, want)
+	}
+	if err := s.verifyServerFinal("v=6rriTRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4="); err != nil {
+		t.Errorf("verifyServerFinal: %v", err)
+	}
+	if err := s.verifyServerFinal("v=AAAATRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4="); err == nil {
+		t.Error("verifyServerFinal accepted a wrong signature")
+	}
+
+	if _, err := newSCRAMClient("", "pencil", "abc").clientFinal("r=xyz,s=W22Z,i=4096"); err == nil {
+		t.Error("clientFinal accepted a nonce not extending the client nonce")
+	}
+}
@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxSCRAMIterations bounds the PBKDF2 work a server can request.
+const maxSCRAMIterations = 1 << 20
+
+// scramClient runs the client side of SCRAM-SHA-256 (RFC 5802, RFC 7677).
+// PostgreSQL takes the user from the startup message, so the user in the
+// exchange is left empty.
+type scramClient struct {
+	user, password, nonce string
+
+	clientFirstBare string
+	authMessage     string
+	saltedPassword  []byte
+}
+
+func newSCRAMClient(user, password, nonce string) *scramClient {
+	return &scramClient{user: user, password: password, nonce: nonce}
+}
+
+// clientFirst returns the client-first-message, without channel binding.
+func (s *scramClient) clientFirst() string {
+	s.clientFirstBare = "n=" + s.user + ",r=" + s.nonce
+	return "n,," + s.clientFirstBare
+}
+
+// clientFinal returns the client-final-message with the proof for
+// serverFirst.
+func (s *scramClient) clientFinal(serverFirst string) (string, error) {
+	var nonce, salt64, iters string
+	for _, attr := range strings.Split(serverFirst, ",") {
+		k, v, _ := strings.Cut(attr, "=")
+		switch k {
+		case "r":
+			nonce = v
+		case "s":
+			salt64 = v
+		case "i":
+			iters = v
+		}
+	}
+	if !strings.HasPrefix(nonce, s.nonce) || len(nonce) == len(s.nonce) {
+		return "", errors.New("postgres: SCRAM server nonce does not extend the client nonce")
+	}
+	salt, err := base64.StdEncoding.DecodeString(salt64)
+	if err != nil || len(salt) == 0 {
+		return "", errors.New("postgres: invalid SCRAM salt")
+	}
+	n, err := strconv.Atoi(iters)
+	if err != nil || n < 1 || n > maxSCRAMIterations {
+		return "", fmt.Errorf("postgres: invalid SCRAM iteration count %q", iters)
+	}
+	s.saltedPassword, err = pbkdf2.Key(sha256.New, s.password, salt, n, sha256.Size)
+	if err != nil {
+		return "", fmt.Errorf("postgres: SCRAM key derivation: %w", err)
+	}
+
+	withoutProof := "c=biws,r=" + nonce // biws is base64("n,,")
+	s.authMessage = s.clientFirstBare + "," + serverFirst + "," + withoutProof
+	clientKey := hmacSHA256(s.saltedPassword, "Client Key")
+	storedKey := sha256.Sum256(clientKey)
+	proof := hmacSHA256(storedKey[:], s.authMessage)
+	for i := range proof {
+		proof[i] ^= clientKey[i]
+	}
+	return withoutProof + ",p=" + base64.StdEncoding.EncodeToString(proof), nil
+}
+
+// verifyServerFinal checks the server signature, proving the server knows
+// the password too.
+func (s *scramClient) verifyServerFinal(serverFinal string) error {
+	if msg, ok := strings.CutPrefix(serverFinal, "e="); ok {
+		return fmt.Errorf("postgres: SCRAM authentication failed: %s", msg)
+	}
+	v64, ok := strings.CutPrefix(serverFinal, "v=")
+	if !ok {
+		return errors.New("postgres: invalid SCRAM server-final-message")
+	}
+	got, err := base64.StdEncoding.DecodeString(v64)
+	if err != nil {
+		return errors.New("postgres: invalid SCRAM server signature")
+	}
+	serverKey := hmacSHA256(s.saltedPassword, "Server Key")
+	want := hmacSHA256(serverKey, s.authMessage)
+	if subtle.ConstantTimeCompare(got, want) != 1 {
+		return errors.New("postgres: SCRAM server signature mismatch")
+	}
+	return nil
+}
+
+func hmacSHA256(key []byte, msg string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(msg))
+	return h.Sum(nil)
+}
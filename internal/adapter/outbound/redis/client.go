@@ -0,0 +1,279 @@
+// Package redis provides Redis-backed implementations of outbound ports and
+// the minimal RESP2 client they share.
+package redis
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Client defaults.
+const (
+	DefaultTimeout  = 5 * time.Second
+	DefaultPoolSize = 10
+	// maxBulkLen caps bulk replies; larger values indicate a protocol error.
+	maxBulkLen = 512 << 20
+)
+
+// ErrNil is returned by Do when Redis answers with a nil reply (e.g., GET
+// of a missing key, SET ... XX of a missing key).
+var ErrNil = errors.New("redis: nil reply")
+
+// ErrClosed is returned by Do after Close.
+var ErrClosed = errors.New("redis: client closed")
+
+// Error is an error reply sent by the server (e.g., "WRONGTYPE ...").
+type Error string
+
+func (e Error) Error() string { return "redis: " + string(e) }
+
+// Options configures a Client.
+type Options struct {
+	// Address is the host:port of the server.
+	Address string
+	// Username and Password authenticate each connection with AUTH when
+	// Password is set. Username is only sent for Redis 6 ACL users.
+	Username string
+	Password string
+	// DB is the database selected on each connection.
+	DB int
+	// TLSConfig enables TLS when non-nil.
+	TLSConfig *tls.Config
+	// Timeout bounds dialing and each command when the context has no
+	// deadline. Zero uses 5s.
+	Timeout time.Duration
+	// PoolSize is the maximum number of idle connections kept. Zero uses 10.
+	PoolSize int
+}
+
+// Client is a Redis client holding a small pool of connections. It is safe
+// for concurrent use. Commands are sent one at a time per connection; a
+// connection that fails is discarded.
+type Client struct {
+	opts Options
+
+	mu     sync.Mutex
+	idle   []*conn
+	closed bool
+}
+
+// conn is a single connection with its buffered reader.
+type conn struct {
+	nc net.Conn
+	br *bufio.Reader
+}
+
+// NewClient creates a client. Connections are opened on first use.
+func NewClient(opts Options) *Client {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = DefaultPoolSize
+	}
+	return &Client{opts: opts}
+}
+
+// Do sends a command and returns its reply: string for simple and bulk
+// strings, int64 for integers and []interface{} for arrays. Nil replies
+// return ErrNil and error replies return an Error.
+func (c *Client) Do(ctx context.Context, args ...string) (interface{}, error) {
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := cn.do(ctx, c.opts.Timeout, args)
+	var serverErr Error
+	if err != nil && !errors.Is(err, ErrNil) && !errors.As(err, &serverErr) {
+		// The connection state is unknown after an I/O or protocol error.
+		_ = cn.nc.Close()
+		return nil, err
+	}
+	c.put(cn)
+	return reply, err
+}
+
+// Ping checks that the server answers.
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.Do(ctx, "PING")
+	return err
+}
+
+// Close closes the idle connections; later commands return ErrClosed.
+// Safe to call multiple times.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	idle := c.idle
+	c.idle = nil
+	c.closed = true
+	c.mu.Unlock()
+	for _, cn := range idle {
+		_ = cn.nc.Close()
+	}
+	return nil
+}
+
+func (c *Client) get(ctx context.Context) (*conn, error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, ErrClosed
+	}
+	if n := len(c.idle); n > 0 {
+		cn := c.idle[n-1]
+		c.idle = c.idle[:n-1]
+		c.mu.Unlock()
+		return cn, nil
+	}
+	c.mu.Unlock()
+	return c.dial(ctx)
+}
+
+func (c *Client) put(cn *conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed || len(c.idle) >= c.opts.PoolSize {
+		_ = cn.nc.Close()
+		return
+	}
+	c.idle = append(c.idle, cn)
+}
+
+// dial opens a connection, authenticates it and selects the database.
+func (c *Client) dial(ctx context.Context) (*conn, error) {
+	dialer := &net.Dialer{Timeout: c.opts.Timeout}
+	var nc net.Conn
+	var err error
+	if c.opts.TLSConfig != nil {
+		td := &tls.Dialer{NetDialer: dialer, Config: c.opts.TLSConfig}
+		nc, err = td.DialContext(ctx, "tcp", c.opts.Address)
+	} else {
+		nc, err = dialer.DialContext(ctx, "tcp", c.opts.Address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis: connect %s: %w", c.opts.Address, err)
+	}
+	cn := &conn{nc: nc, br: bufio.NewReader(nc)}
+
+	if c.opts.Password != "" {
+		args := []string{"AUTH", c.opts.Password}
+		if c.opts.Username != "" {
+			args = []string{"AUTH", c.opts.Username, c.opts.Password}
+		}
+		if _, err := cn.do(ctx, c.opts.Timeout, args); err != nil {
+			_ = nc.Close()
+			return nil, fmt.Errorf("redis: authenticate: %w", err)
+		}
+	}
+	if c.opts.DB != 0 {
+		if _, err := cn.do(ctx, c.opts.Timeout, []string{"SELECT", strconv.Itoa(c.opts.DB)}); err != nil {
+			_ = nc.Close()
+			return nil, fmt.Errorf("redis: select database %d: %w", c.opts.DB, err)
+		}
+	}
+	return cn, nil
+}
+
+// do writes one command and reads its reply.
+func (cn *conn) do(ctx context.Context, timeout time.Duration, args []string) (interface{}, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(timeout)
+	}
+	if err := cn.nc.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	if _, err := cn.nc.Write(appendCommand(nil, args)); err != nil {
+		return nil, fmt.Errorf("redis: write: %w", err)
+	}
+	return readReply(cn.br)
+}
+
+// appendCommand encodes args as a RESP array of bulk strings.
+func appendCommand(b []byte, args []string) []byte {
+	b = append(b, '*')
+	b = strconv.AppendInt(b, int64(len(args)), 10)
+	b = append(b, '\r', '\n')
+	for _, a := range args {
+		b = append(b, '$')
+		b = strconv.AppendInt(b, int64(len(a)), 10)
+		b = append(b, '\r', '\n')
+		b = append(b, a...)
+		b = append(b, '\r', '\n')
+	}
+	return b
+}
+
+// readReply reads one RESP2 reply.
+func readReply(br *bufio.Reader) (interface{}, error) {
+	line, err := readLine(br)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, errors.New("redis: empty reply line")
+	}
+	switch line[0] {
+	case '+':
+		return string(line[1:]), nil
+	case '-':
+		return nil, Error(line[1:])
+	case ':':
+		n, err := strconv.ParseInt(string(line[1:]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid integer reply %q", line)
+		}
+		return n, nil
+	case '$':
+		n, err := strconv.Atoi(string(line[1:]))
+		if err != nil || n > maxBulkLen {
+			return nil, fmt.Errorf("redis: invalid bulk length %q", line)
+		}
+		if n < 0 {
+			return nil, ErrNil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(br, buf); err != nil {
+			return nil, fmt.Errorf("redis: read: %w", err)
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(string(line[1:]))
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid array length %q", line)
+		}
+		if n < 0 {
+			return nil, ErrNil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			item, err := readReply(br)
+			if err != nil && !errors.Is(err, ErrNil) {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unexpected reply %q", line)
+}
+
+// readLine reads a CRLF-terminated line without its terminator.
+func readLine(br *bufio.Reader) ([]byte, error) {
+	line, err := br.ReadSlice('\n')
+	if err != nil {
+		return nil, fmt.Errorf("redis: read: %w", err)
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: malformed reply line %q", line)
+	}
+	return line[:len(line)-2], nil
+}
@@ -0,0 +1,237 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeServer is an in-process Redis speaking enough RESP2 for the stores:
+// AUTH, SELECT, PING, GET, SET (PX, NX, XX), DEL and PTTL, plus EVAL and
+// EVALSHA of the rate limiter scripts, emulated in Go.
+type fakeServer struct {
+	ln       net.Listener
+	password string
+
+	mu      sync.Mutex
+	data    map[string]string
+	expires map[string]time.Time
+	scripts map[string]bool // SHA1 of the scripts run with EVAL
+	conns   int
+	evals   int
+}
+
+func newFakeServer(t *testing.T, password string) *fakeServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &fakeServer{ln: ln, password: password, data: map[string]string{}, expires: map[string]time.Time{}, scripts: map[string]bool{}}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.conns++
+			s.mu.Unlock()
+			go s.serve(c)
+		}
+	}()
+	return s
+}
+
+func (s *fakeServer) addr() string { return s.ln.Addr().String() }
+
+func (s *fakeServer) serve(c net.Conn) {
+	defer func() { _ = c.Close() }()
+	br := bufio.NewReader(c)
+	authed := s.password == ""
+	for {
+		reply, err := readReply(br)
+		if err != nil {
+			return
+		}
+		items, _ := reply.([]interface{})
+		args := make([]string, len(items))
+		for i, it := range items {
+			args[i], _ = it.(string)
+		}
+		var out string
+		switch cmd := strings.ToUpper(args[0]); {
+		case cmd == "AUTH":
+			if args[len(args)-1] != s.password {
+				out = "-WRONGPASS invalid password\r\n"
+				break
+			}
+			authed = true
+			out = "+OK\r\n"
+		case !authed:
+			out = "-NOAUTH Authentication required.\r\n"
+		default:
+			out = s.exec(cmd, args[1:])
+		}
+		if _, err := c.Write([]byte(out)); err != nil {
+			return
+		}
+	}
+}
+
+func (s *fakeServer) exec(cmd string, args []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, exp := range s.expires {
+		if time.Now().After(exp) {
+			delete(s.data, k)
+			delete(s.expires, k)
+		}
+	}
+	switch cmd {
+	case "PING":
+		return "+PONG\r\n"
+	case "SELECT":
+		return "+OK\r\n"
+	case "GET":
+		v, ok := s.data[args[0]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return "$" + strconv.Itoa(len(v)) + "\r\n" + v + "\r\n"
+	case "SET":
+		key, val := args[0], args[1]
+		_, exists := s.data[key]
+		var ttl time.Duration
+		for i := 2; i < len(args); i++ {
+			switch strings.ToUpper(args[i]) {
+			case "PX":
+				ms, _ := strconv.Atoi(args[i+1])
+				ttl = time.Duration(ms) * time.Millisecond
+				i++
+			case "NX":
+				if exists {
+					return "$-1\r\n"
+				}
+			case "XX":
+				if !exists {
+					return "$-1\r\n"
+				}
+			}
+		}
+		s.data[key] = val
+		delete(s.expires, key)
+		if ttl > 0 {
+			s.expires[key] = time.Now().Add(ttl)
+		}
+		return "+OK\r\n"
+	case "DEL":
+		n := 0
+		for _, k := range args {
+			if _, ok := s.data[k]; ok {
+				delete(s.data, k)
+				delete(s.expires, k)
+				n++
+			}
+		}
+		return ":" + strconv.Itoa(n) + "\r\n"
+	case "PTTL":
+		if _, ok := s.data[args[0]]; !ok {
+			return ":-2\r\n"
+		}
+		exp, ok := s.expires[args[0]]
+		if !ok {
+			return ":-1\r\n"
+		}
+		return ":" + strconv.FormatInt(time.Until(exp).Milliseconds(), 10) + "\r\n"
+	case "EVAL":
+		switch args[0] {
+		case gcraScript:
+			s.scripts[gcraScriptSHA] = true
+			return s.evalGCRA(args[2:])
+		case peekScript:
+			s.scripts[peekScriptSHA] = true
+			return s.evalPeek(args[2:])
+		}
+		return "-ERR unknown script\r\n"
+	case "EVALSHA":
+		if !s.scripts[args[0]] {
+			return "-NOSCRIPT No matching script. Please use EVAL.\r\n"
+		}
+		if args[0] == peekScriptSHA {
+			return s.evalPeek(args[2:])
+		}
+		return s.evalGCRA(args[2:])
+	}
+	return "-ERR unknown command '" + cmd + "'\r\n"
+}
+
+func TestClient_Commands(t *testing.T) {
+	srv := newFakeServer(t, "secret")
+	c := NewClient(Options{Address: srv.addr(), Password: "secret", DB: 2})
+	defer c.Close()
+	ctx := context.Background()
+
+	if err := c.Ping(ctx); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	if _, err := c.Do(ctx, "SET", "k", "multi\r\nline"); err != nil {
+		t.Fatalf("SET: %v", err)
+	}
+	if got, err := c.Do(ctx, "GET", "k"); err != nil || got != "multi\r\nline" {
+		t.Errorf("GET = %v, %v", got, err)
+	}
+	if _, err := c.Do(ctx, "GET", "missing"); !errors.Is(err, ErrNil) {
+		t.Errorf("GET missing error = %v, want ErrNil", err)
+	}
+	if got, err := c.Do(ctx, "DEL", "k", "missing"); err != nil || got != int64(1) {
+		t.Errorf("DEL = %v, %v, want 1", got, err)
+	}
+	var serverErr Error
+	if _, err := c.Do(ctx, "NOPE"); !errors.As(err, &serverErr) {
+		t.Errorf("unknown command error = %v, want Error", err)
+	}
+
+	// Replies and server errors keep the connection in the pool.
+	srv.mu.Lock()
+	conns := srv.conns
+	srv.mu.Unlock()
+	if conns != 1 {
+		t.Errorf("connections = %d, want 1", conns)
+	}
+
+	_ = c.Close()
+	if err := c.Ping(ctx); !errors.Is(err, ErrClosed) {
+		t.Errorf("Ping after Close = %v, want ErrClosed", err)
+	}
+}
+
+func TestClient_AuthFailure(t *testing.T) {
+	srv := newFakeServer(t, "secret")
+	c := NewClient(Options{Address: srv.addr(), Password: "wrong"})
+	defer c.Close()
+
+	err := c.Ping(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "WRONGPASS") {
+		t.Errorf("Ping error = %v, want WRONGPASS", err)
+	}
+}
+
+func TestReadReply_Array(t *testing.T) {
+	br := bufio.NewReader(strings.NewReader("*3\r\n$1\r\na\r\n:5\r\n$-1\r\n"))
+	got, err := readReply(br)
+	if err != nil {
+		t.Fatalf("readReply: %v", err)
+	}
+	items, ok := got.([]interface{})
+	if !ok || len(items) != 3 || items[0] != "a" || items[1] != int64(5) || items[2] != nil {
+		t.Errorf("reply = %#v", got)
+	}
+}
@@ -0,0 +1,173 @@
+package redis
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/ratelimit"
+)
+
+// gcraScript is the token bucket of the memory rate limiter (GCRA) run
+// atomically in Redis. The key holds the theoretical arrival time (TAT) in
+// microseconds of the Redis clock, so replicas with skewed clocks share one
+// bucket. It expires once the bucket is full again.
+//
+// ARGV: emission interval and burst offset, in microseconds.
+// Returns {allowed (0/1), retry after, reset after}, in microseconds.
+const gcraScript = `
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local emission = tonumber(ARGV[1])
+local burst_offset = tonumber(ARGV[2])
+local tat = tonumber(redis.call('GET', KEYS[1]) or '0')
+if tat < now then
+  tat = now
+end
+local allow_at = tat - burst_offset
+if now < allow_at then
+  return {0, allow_at - now, tat - now}
+end
+local new_tat = tat + emission
+redis.call('SET', KEYS[1], string.format('%d', new_tat), 'PX', math.ceil((new_tat - now) / 1000) + 1)
+return {1, 0, new_tat - now}
+`
+
+// peekScript returns how far the TAT of KEYS[1] is ahead of the Redis
+// clock, in microseconds (0 for a full bucket), without changing it.
+const peekScript = `
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local tat = tonumber(redis.call('GET', KEYS[1]) or '0')
+if tat < now then
+  return 0
+end
+return tat - now
+`
+
+var (
+	gcraScriptSHA = scriptSHA(gcraScript)
+	peekScriptSHA = scriptSHA(peekScript)
+)
+
+func scriptSHA(script string) string {
+	sum := sha1.Sum([]byte(script))
+	return hex.EncodeToString(sum[:])
+}
+
+// RateLimiter implements ratelimit.RateLimiter in Redis so that several
+// gateway replicas enforce the same IP, user and session limits. It uses
+// the same algorithm as the memory limiter, in a Lua script, so a request
+// is counted exactly once however many replicas check the same key.
+//
+// Each key is stored under <prefix><key> (e.g.
+// "sentinelgate:ratelimit:user:<id>") and expires when its bucket is full
+// again, so Redis itself does the memory limiter's cleanup.
+type RateLimiter struct {
+	client *Client
+	prefix string
+}
+
+// NewRateLimiter creates a rate limiter using client. An empty prefix uses
+// DefaultKeyPrefix.
+func NewRateLimiter(client *Client, prefix string) *RateLimiter {
+	if prefix == "" {
+		prefix = DefaultKeyPrefix
+	}
+	return &RateLimiter{client: client, prefix: prefix}
+}
+
+// Allow checks if a request is allowed under the given rate limit config.
+func (r *RateLimiter) Allow(ctx context.Context, key string, config ratelimit.RateLimitConfig) (ratelimit.RateLimitResult, error) {
+	if config.Rate <= 0 {
+		config.Rate = 1
+	}
+	if config.Burst <= 0 {
+		config.Burst = config.Rate
+	}
+	emission := config.Period / time.Duration(config.Rate)
+	if emission < time.Microsecond {
+		emission = time.Microsecond
+	}
+	burstOffset := time.Duration(config.Burst) * emission
+
+	reply, err := r.eval(ctx, gcraScript, gcraScriptSHA, r.prefix+key,
+		strconv.FormatInt(emission.Microseconds(), 10),
+		strconv.FormatInt(burstOffset.Microseconds(), 10))
+	if err != nil {
+		return ratelimit.RateLimitResult{}, fmt.Errorf("check rate limit: %w", err)
+	}
+	items, _ := reply.([]interface{})
+	if len(items) != 3 {
+		return ratelimit.RateLimitResult{}, fmt.Errorf("check rate limit: unexpected reply %v", reply)
+	}
+	allowed, _ := items[0].(int64)
+	retryAfter, _ := items[1].(int64)
+	resetAfter, _ := items[2].(int64)
+
+	result := ratelimit.RateLimitResult{
+		Allowed:    allowed == 1,
+		RetryAfter: time.Duration(retryAfter) * time.Microsecond,
+		ResetAfter: time.Duration(resetAfter) * time.Microsecond,
+	}
+	if result.Allowed {
+		remaining := int((burstOffset - result.ResetAfter) / emission)
+		if remaining < 0 {
+			remaining = 0
+		}
+		if remaining > config.Burst {
+			remaining = config.Burst
+		}
+		result.Remaining = remaining
+	}
+	return result, nil
+}
+
+// Peek reports the state of key under config without counting a request.
+func (r *RateLimiter) Peek(ctx context.Context, key string, config ratelimit.RateLimitConfig) (ratelimit.RateLimitResult, error) {
+	reply, err := r.eval(ctx, peekScript, peekScriptSHA, r.prefix+key)
+	if err != nil {
+		return ratelimit.RateLimitResult{}, fmt.Errorf("peek rate limit: %w", err)
+	}
+	wait, ok := reply.(int64)
+	if !ok {
+		return ratelimit.RateLimitResult{}, fmt.Errorf("peek rate limit: unexpected reply %v", reply)
+	}
+	return ratelimit.PeekResult(time.Duration(wait)*time.Microsecond, config), nil
+}
+
+// eval runs a script by its SHA1 and sends its source only when Redis
+// doesn't have it cached yet (after a restart or SCRIPT FLUSH).
+func (r *RateLimiter) eval(ctx context.Context, script, sha, key string, args ...string) (interface{}, error) {
+	reply, err := r.client.Do(ctx, append([]string{"EVALSHA", sha, "1", key}, args...)...)
+	var serverErr Error
+	if errors.As(err, &serverErr) && strings.HasPrefix(string(serverErr), "NOSCRIPT") {
+		return r.client.Do(ctx, append([]string{"EVAL", script, "1", key}, args...)...)
+	}
+	return reply, err
+}
+
+// StartCleanup exists for parity with the memory limiter. Redis expires
+// keys through their TTL, so no goroutine is started.
+func (r *RateLimiter) StartCleanup(ctx context.Context) {}
+
+// Stop closes the connections to Redis. Safe to call multiple times.
+func (r *RateLimiter) Stop() {
+	_ = r.client.Close()
+}
+
+// Ping checks that Redis answers.
+func (r *RateLimiter) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx)
+}
+
+// Compile-time interface verification.
+var (
+	_ ratelimit.RateLimiter = (*RateLimiter)(nil)
+	_ ratelimit.Inspector   = (*RateLimiter)(nil)
+)
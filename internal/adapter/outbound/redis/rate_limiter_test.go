@@ -0,0 +1,174 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/ratelimit"
+)
+
+// evalGCRA runs gcraScript on the fake server's data. args are the key
+// count's key followed by ARGV.
+func (s *fakeServer) evalGCRA(args []string) string {
+	s.evals++
+	key := args[0]
+	emission, _ := strconv.ParseInt(args[1], 10, 64)
+	burstOffset, _ := strconv.ParseInt(args[2], 10, 64)
+	now := time.Now().UnixMicro()
+
+	tat, _ := strconv.ParseInt(s.data[key], 10, 64)
+	if tat < now {
+		tat = now
+	}
+	if allowAt := tat - burstOffset; now < allowAt {
+		return "*3\r\n:0\r\n:" + strconv.FormatInt(allowAt-now, 10) + "\r\n:" + strconv.FormatInt(tat-now, 10) + "\r\n"
+	}
+	newTAT := tat + emission
+	s.data[key] = strconv.FormatInt(newTAT, 10)
+	s.expires[key] = time.Now().Add(time.Duration(newTAT-now)*time.Microsecond + time.Millisecond)
+	return "*3\r\n:1\r\n:0\r\n:" + strconv.FormatInt(newTAT-now, 10) + "\r\n"
+}
+
+// evalPeek runs peekScript on the fake server's data.
+func (s *fakeServer) evalPeek(args []string) string {
+	s.evals++
+	now := time.Now().UnixMicro()
+	tat, _ := strconv.ParseInt(s.data[args[0]], 10, 64)
+	if tat < now {
+		return ":0\r\n"
+	}
+	return ":" + strconv.FormatInt(tat-now, 10) + "\r\n"
+}
+
+func TestRateLimiter_Allow(t *testing.T) {
+	srv := newFakeServer(t, "")
+	limiter := NewRateLimiter(NewClient(Options{Address: srv.addr()}), "")
+	defer limiter.Stop()
+	ctx := context.Background()
+	cfg := ratelimit.RateLimitConfig{Rate: 60, Burst: 3, Period: time.Minute}
+	key := ratelimit.FormatKey(ratelimit.KeyTypeUser, "user-1")
+
+	// Like the memory limiter, a burst allows Burst requests, plus the one
+	// the bucket has refilled by the time the last one is checked.
+	var allowed int
+	var res ratelimit.RateLimitResult
+	for i := 0; i < 10; i++ {
+		var err error
+		res, err = limiter.Allow(ctx, key, cfg)
+		if err != nil {
+			t.Fatalf("Allow() error: %v", err)
+		}
+		if i == 0 && res.Remaining != 2 {
+			t.Errorf("first request: Remaining = %d, want 2", res.Remaining)
+		}
+		if res.Allowed {
+			allowed++
+		}
+	}
+	if allowed < 3 || allowed > 4 {
+		t.Errorf("allowed %d of 10 requests, want 3 or 4", allowed)
+	}
+	if res.Allowed {
+		t.Fatal("request beyond burst allowed")
+	}
+	if res.RetryAfter <= 0 || res.RetryAfter > time.Second {
+		t.Errorf("RetryAfter = %v, want (0, 1s]", res.RetryAfter)
+	}
+
+	// Another key has its own bucket.
+	if res, _ := limiter.Allow(ctx, ratelimit.FormatKey(ratelimit.KeyTypeUser, "user-2"), cfg); !res.Allowed {
+		t.Error("other key denied")
+	}
+
+	// The bucket lives under the prefix and expires once full again.
+	srv.mu.Lock()
+	_, ok := srv.expires["sentinelgate:"+key]
+	srv.mu.Unlock()
+	if !ok {
+		t.Error("bucket key not stored with a TTL under the default prefix")
+	}
+}
+
+func TestRateLimiter_SharedAcrossClients(t *testing.T) {
+	srv := newFakeServer(t, "")
+	a := NewRateLimiter(NewClient(Options{Address: srv.addr()}), "gw:")
+	b := NewRateLimiter(NewClient(Options{Address: srv.addr()}), "gw:")
+	defer a.Stop()
+	defer b.Stop()
+	ctx := context.Background()
+	cfg := ratelimit.RateLimitConfig{Rate: 60, Burst: 2, Period: time.Minute}
+	key := ratelimit.FormatKey(ratelimit.KeyTypeIP, "10.0.0.1")
+
+	allowed := 0
+	for i := 0; i < 10; i++ {
+		l := a
+		if i%2 == 1 {
+			l = b
+		}
+		res, err := l.Allow(ctx, key, cfg)
+		if err != nil {
+			t.Fatalf("Allow() error: %v", err)
+		}
+		if res.Allowed {
+			allowed++
+		}
+	}
+	if allowed > 3 {
+		t.Errorf("allowed %d of 10 requests across two clients, want at most 3", allowed)
+	}
+}
+
+func TestRateLimiter_LoadsScriptOnce(t *testing.T) {
+	srv := newFakeServer(t, "")
+	limiter := NewRateLimiter(NewClient(Options{Address: srv.addr()}), "")
+	defer limiter.Stop()
+	cfg := ratelimit.RateLimitConfig{Rate: 100, Period: time.Minute}
+
+	for i := 0; i < 3; i++ {
+		if _, err := limiter.Allow(context.Background(), "k", cfg); err != nil {
+			t.Fatalf("Allow() error: %v", err)
+		}
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.evals != 3 || !srv.scripts[gcraScriptSHA] {
+		t.Errorf("evals = %d, script cached = %v", srv.evals, srv.scripts[gcraScriptSHA])
+	}
+}
+
+func TestRateLimiter_ServerDown(t *testing.T) {
+	srv := newFakeServer(t, "")
+	_ = srv.ln.Close()
+	limiter := NewRateLimiter(NewClient(Options{Address: srv.addr(), Timeout: time.Second}), "")
+	defer limiter.Stop()
+
+	_, err := limiter.Allow(context.Background(), "k", ratelimit.RateLimitConfig{Rate: 1, Period: time.Second})
+	if err == nil || !strings.Contains(err.Error(), "check rate limit") {
+		t.Errorf("Allow() error = %v, want a connection error", err)
+	}
+}
+
+func TestRateLimiter_Peek(t *testing.T) {
+	srv := newFakeServer(t, "")
+	limiter := NewRateLimiter(NewClient(Options{Address: srv.addr()}), "")
+	defer limiter.Stop()
+	ctx := context.Background()
+	cfg := ratelimit.RateLimitConfig{Rate: 2, Burst: 2, Period: time.Minute}
+
+	for i := 0; i < 3; i++ {
+		res, err := limiter.Peek(ctx, "k", cfg)
+		if err != nil || !res.Allowed || res.Remaining != 2 {
+			t.Fatalf("Peek() = %+v, %v; want allowed with 2 remaining", res, err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		_, _ = limiter.Allow(ctx, "k", cfg)
+	}
+	res, err := limiter.Peek(ctx, "k", cfg)
+	if err != nil || res.Allowed || res.RetryAfter <= 0 {
+		t.Errorf("Peek() after exhaustion = %+v, %v; want denied", res, err)
+	}
+}
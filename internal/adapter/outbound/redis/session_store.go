@@ -0,0 +1,178 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/auth"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/session"
+)
+
+// DefaultKeyPrefix is prepended to every key written by the stores.
+const DefaultKeyPrefix = "sentinelgate:"
+
+// SessionStore implements session.SessionStore in Redis so that several
+// gateway replicas share their sessions.
+//
+// Each session is a JSON string under <prefix>session:<id> whose Redis TTL
+// follows ExpiresAt: it is set on Create and moved on every Update, so a
+// session refreshed by SessionService keeps living and an idle one is
+// removed by Redis itself, the equivalent of the memory store's cleanup.
+type SessionStore struct {
+	client *Client
+	prefix string
+}
+
+// sessionRecord is the stored form of a session.
+type sessionRecord struct {
+	ID           string            `json:"id"`
+	IdentityID   string            `json:"identity_id"`
+	IdentityName string            `json:"identity_name"`
+	Roles        []auth.Role       `json:"roles,omitempty"`
+	CreatedAt    time.Time         `json:"created_at"`
+	ExpiresAt    time.Time         `json:"expires_at"`
+	LastAccess   time.Time         `json:"last_access"`
+	Labels       map[string]string `json:"labels,omitempty"`
+}
+
+// NewSessionStore creates a session store using client. An empty prefix
+// uses DefaultKeyPrefix.
+func NewSessionStore(client *Client, prefix string) *SessionStore {
+	if prefix == "" {
+		prefix = DefaultKeyPrefix
+	}
+	return &SessionStore{client: client, prefix: prefix}
+}
+
+// StartCleanup exists for parity with the memory store. Redis expires
+// sessions through their key TTL, so no goroutine is started.
+func (s *SessionStore) StartCleanup(ctx context.Context) {}
+
+// Stop closes the connections to Redis. Safe to call multiple times.
+func (s *SessionStore) Stop() {
+	_ = s.client.Close()
+}
+
+// Ping checks that Redis answers.
+func (s *SessionStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx)
+}
+
+// Create stores a new session, replacing any session with the same ID.
+// A session that is already expired is not stored.
+func (s *SessionStore) Create(ctx context.Context, sess *session.Session) error {
+	ttl, ok := sessionTTL(sess)
+	if !ok {
+		return nil
+	}
+	data, err := encodeSession(sess)
+	if err != nil {
+		return err
+	}
+	if _, err := s.client.Do(ctx, "SET", s.key(sess.ID), data, "PX", ttl); err != nil {
+		return fmt.Errorf("store session: %w", err)
+	}
+	return nil
+}
+
+// Get retrieves a session by ID.
+// Returns session.ErrSessionNotFound if session doesn't exist or is expired.
+func (s *SessionStore) Get(ctx context.Context, id string) (*session.Session, error) {
+	reply, err := s.client.Do(ctx, "GET", s.key(id))
+	if errors.Is(err, ErrNil) {
+		return nil, session.ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load session: %w", err)
+	}
+	data, _ := reply.(string)
+	var rec sessionRecord
+	if err := json.Unmarshal([]byte(data), &rec); err != nil {
+		return nil, fmt.Errorf("decode session %s: %w", id, err)
+	}
+	sess := &session.Session{
+		ID:           rec.ID,
+		IdentityID:   rec.IdentityID,
+		IdentityName: rec.IdentityName,
+		Roles:        rec.Roles,
+		CreatedAt:    rec.CreatedAt,
+		ExpiresAt:    rec.ExpiresAt,
+		LastAccess:   rec.LastAccess,
+		Labels:       rec.Labels,
+	}
+	// Redis expiry has millisecond precision and may lag slightly.
+	if sess.IsExpired() {
+		return nil, session.ErrSessionNotFound
+	}
+	return sess, nil
+}
+
+// Update saves changes to an existing session and moves its TTL to the new
+// ExpiresAt. Returns session.ErrSessionNotFound if the session doesn't
+// exist anymore.
+func (s *SessionStore) Update(ctx context.Context, sess *session.Session) error {
+	ttl, ok := sessionTTL(sess)
+	if !ok {
+		return s.Delete(ctx, sess.ID)
+	}
+	data, err := encodeSession(sess)
+	if err != nil {
+		return err
+	}
+	// XX only replaces an existing key, so an update racing with expiry or
+	// deletion on another replica does not resurrect the session.
+	_, err = s.client.Do(ctx, "SET", s.key(sess.ID), data, "PX", ttl, "XX")
+	if errors.Is(err, ErrNil) {
+		return session.ErrSessionNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("update session: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a session.
+func (s *SessionStore) Delete(ctx context.Context, id string) error {
+	if _, err := s.client.Do(ctx, "DEL", s.key(id)); err != nil {
+		return fmt.Errorf("delete session: %w", err)
+	}
+	return nil
+}
+
+func (s *SessionStore) key(id string) string {
+	return s.prefix + "session:" + id
+}
+
+// sessionTTL returns the remaining lifetime of sess in milliseconds, and
+// false when it is already expired.
+func sessionTTL(sess *session.Session) (string, bool) {
+	ms := time.Until(sess.ExpiresAt).Milliseconds()
+	if ms <= 0 {
+		return "", false
+	}
+	return strconv.FormatInt(ms, 10), true
+}
+
+func encodeSession(sess *session.Session) (string, error) {
+	data, err := json.Marshal(sessionRecord{
+		ID:           sess.ID,
+		IdentityID:   sess.IdentityID,
+		IdentityName: sess.IdentityName,
+		Roles:        sess.Roles,
+		CreatedAt:    sess.CreatedAt,
+		ExpiresAt:    sess.ExpiresAt,
+		LastAccess:   sess.LastAccess,
+		Labels:       sess.Labels,
+	})
+	if err != nil {
+		return "", fmt.Errorf("encode session %s: %w", sess.ID, err)
+	}
+	return string(data), nil
+}
+
+// Compile-time interface verification.
+var _ session.SessionStore = (*SessionStore)(nil)
@@ -0,0 +1,135 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/auth"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/session"
+)
+
+func newTestSessionStore(t *testing.T) (*SessionStore, *fakeServer) {
+	t.Helper()
+	srv := newFakeServer(t, "")
+	store := NewSessionStore(NewClient(Options{Address: srv.addr()}), "")
+	t.Cleanup(store.Stop)
+	return store, srv
+}
+
+func TestSessionStore_CRUD(t *testing.T) {
+	store, _ := newTestSessionStore(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	sess := &session.Session{
+		ID:           "sess-1",
+		IdentityID:   "user-1",
+		IdentityName: "alice",
+		Roles:        []auth.Role{auth.RoleUser},
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(30 * time.Minute),
+		LastAccess:   now,
+		Labels:       map[string]string{"project": "checkout"},
+	}
+	if err := store.Create(ctx, sess); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+
+	got, err := store.Get(ctx, "sess-1")
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	if got.IdentityName != "alice" || len(got.Roles) != 1 || got.Roles[0] != auth.RoleUser ||
+		got.Labels["project"] != "checkout" || !got.ExpiresAt.Equal(sess.ExpiresAt) {
+		t.Errorf("Get() = %+v", got)
+	}
+
+	got.Labels = map[string]string{"project": "billing"}
+	if err := store.Update(ctx, got); err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+	if got, _ := store.Get(ctx, "sess-1"); got.Labels["project"] != "billing" {
+		t.Errorf("Labels after Update = %v", got.Labels)
+	}
+
+	if err := store.Delete(ctx, "sess-1"); err != nil {
+		t.Fatalf("Delete() error: %v", err)
+	}
+	if _, err := store.Get(ctx, "sess-1"); !errors.Is(err, session.ErrSessionNotFound) {
+		t.Errorf("Get() after Delete error = %v, want ErrSessionNotFound", err)
+	}
+	if err := store.Update(ctx, sess); !errors.Is(err, session.ErrSessionNotFound) {
+		t.Errorf("Update() after Delete error = %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestSessionStore_TTLFollowsExpiresAt(t *testing.T) {
+	store, _ := newTestSessionStore(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	sess := &session.Session{ID: "sess-1", IdentityID: "user-1", CreatedAt: now, ExpiresAt: now.Add(time.Minute), LastAccess: now}
+	if err := store.Create(ctx, sess); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	pttl := func() int64 {
+		reply, err := store.client.Do(ctx, "PTTL", store.key("sess-1"))
+		if err != nil {
+			t.Fatalf("PTTL: %v", err)
+		}
+		return reply.(int64)
+	}
+	if ttl := pttl(); ttl <= 50_000 || ttl > 60_000 {
+		t.Errorf("TTL after Create = %dms, want about 60000", ttl)
+	}
+
+	// A refresh through SessionService moves the key expiry.
+	svc := session.NewSessionService(store, session.Config{Timeout: time.Hour})
+	if err := svc.Refresh(ctx, "sess-1"); err != nil {
+		t.Fatalf("Refresh() error: %v", err)
+	}
+	if ttl := pttl(); ttl <= 3_500_000 {
+		t.Errorf("TTL after Refresh = %dms, want about 3600000", ttl)
+	}
+
+	// Expired sessions are removed by Redis.
+	short := &session.Session{ID: "sess-2", IdentityID: "user-1", CreatedAt: now, ExpiresAt: time.Now().Add(50 * time.Millisecond), LastAccess: now}
+	if err := store.Create(ctx, short); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if _, err := store.Get(ctx, "sess-2"); !errors.Is(err, session.ErrSessionNotFound) {
+		t.Errorf("Get() of expired session error = %v, want ErrSessionNotFound", err)
+	}
+	if _, err := store.client.Do(ctx, "GET", store.key("sess-2")); !errors.Is(err, ErrNil) {
+		t.Errorf("expired key still present: %v", err)
+	}
+}
+
+func TestSessionStore_SharedAcrossReplicas(t *testing.T) {
+	srv := newFakeServer(t, "")
+	a := NewSessionStore(NewClient(Options{Address: srv.addr()}), "sg:")
+	b := NewSessionStore(NewClient(Options{Address: srv.addr()}), "sg:")
+	defer a.Stop()
+	defer b.Stop()
+	ctx := context.Background()
+
+	svcA := session.NewSessionService(a, session.Config{})
+	svcB := session.NewSessionService(b, session.Config{})
+	sess, err := svcA.Create(ctx, &auth.Identity{ID: "user-1", Name: "alice"})
+	if err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	got, err := svcB.Get(ctx, sess.ID)
+	if err != nil || got.IdentityID != "user-1" {
+		t.Fatalf("Get() on other replica = %+v, %v", got, err)
+	}
+	if err := svcB.Delete(ctx, sess.ID); err != nil {
+		t.Fatalf("Delete() error: %v", err)
+	}
+	if _, err := svcA.Get(ctx, sess.ID); !errors.Is(err, session.ErrSessionNotFound) {
+		t.Errorf("Get() after delete on other replica error = %v, want ErrSessionNotFound", err)
+	}
+}
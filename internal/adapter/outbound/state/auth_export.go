@@ -0,0 +1,267 @@
+package state
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// AuthExportFormat identifies an encrypted auth export file.
+const AuthExportFormat = "sentinelgate-auth-export"
+
+// Argon2id parameters for deriving the export key from a passphrase. They are
+// stronger than the API key hashing parameters because an export is a single,
+// offline-attackable file. They are written into each export, so they can be
+// raised later without breaking old files.
+const (
+	authExportTime    uint32 = 3
+	authExportMemory  uint32 = 64 * 1024 // KiB
+	authExportThreads uint8  = 4
+	authExportSaltLen        = 16
+	authExportKeyLen         = 32
+)
+
+// maxAuthExportMemory bounds the memory cost accepted from an export file so a
+// crafted file cannot make the import allocate unbounded memory.
+const maxAuthExportMemory uint32 = 1024 * 1024 // 1 GiB
+
+// ErrAuthExportPassphrase is returned when an export cannot be decrypted,
+// which almost always means the passphrase is wrong.
+var ErrAuthExportPassphrase = errors.New("wrong passphrase or corrupted export")
+
+// AuthBundle is the plaintext of an auth export: what a new host needs to
+// authenticate the same clients and apply the same policies. API keys only
+// carry their hashes; cleartext keys are never stored.
+type AuthBundle struct {
+	ExportedAt    time.Time       `json:"exported_at"`
+	DefaultPolicy string          `json:"default_policy,omitempty"`
+	Identities    []IdentityEntry `json:"identities"`
+	APIKeys       []APIKeyEntry   `json:"api_keys"`
+	Policies      []PolicyEntry   `json:"policies"`
+}
+
+// authExportFile is the on-disk envelope of an encrypted AuthBundle.
+type authExportFile struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	CreatedAt  time.Time `json:"created_at"`
+	KDF        string    `json:"kdf"`
+	Time       uint32    `json:"time"`
+	Memory     uint32    `json:"memory"`
+	Threads    uint8     `json:"threads"`
+	Salt       []byte    `json:"salt"`
+	Nonce      []byte    `json:"nonce"`
+	Ciphertext []byte    `json:"ciphertext"`
+}
+
+// ExportAuth copies the identities, API keys and policies of st into a
+// bundle. Entries sourced from the YAML config are left out: the config
+// recreates them on the new host.
+func ExportAuth(st *AppState) *AuthBundle {
+	b := &AuthBundle{
+		ExportedAt:    time.Now().UTC(),
+		DefaultPolicy: st.DefaultPolicy,
+		Identities:    []IdentityEntry{},
+		APIKeys:       []APIKeyEntry{},
+		Policies:      []PolicyEntry{},
+	}
+	for _, e := range st.Identities {
+		if !e.ReadOnly {
+			b.Identities = append(b.Identities, e)
+		}
+	}
+	for _, e := range st.APIKeys {
+		if !e.ReadOnly {
+			b.APIKeys = append(b.APIKeys, e)
+		}
+	}
+	for _, e := range st.Policies {
+		if !e.ReadOnly {
+			b.Policies = append(b.Policies, e)
+		}
+	}
+	return b
+}
+
+// SealAuthBundle encrypts b with AES-256-GCM under a key derived from
+// passphrase with Argon2id and returns the export file contents.
+func SealAuthBundle(b *AuthBundle, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, errors.New("passphrase must not be empty")
+	}
+	plaintext, err := json.Marshal(b)
+	if err != nil {
+		return nil, fmt.Errorf("marshal auth bundle: %w", err)
+	}
+
+	f := authExportFile{
+		Format:    AuthExportFormat,
+		Version:   1,
+		CreatedAt: b.ExportedAt,
+		KDF:       "argon2id",
+		Time:      authExportTime,
+		Memory:    authExportMemory,
+		Threads:   authExportThreads,
+		Salt:      make([]byte, authExportSaltLen),
+	}
+	if _, err := rand.Read(f.Salt); err != nil {
+		return nil, fmt.Errorf("generate salt: %w", err)
+	}
+	gcm, err := f.cipher(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	f.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(f.Nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+	f.Ciphertext = gcm.Seal(nil, f.Nonce, plaintext, []byte(AuthExportFormat))
+
+	return json.MarshalIndent(f, "", "  ")
+}
+
+// OpenAuthBundle decrypts an export file produced by SealAuthBundle.
+func OpenAuthBundle(data []byte, passphrase string) (*AuthBundle, error) {
+	var f authExportFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse auth export: %w", err)
+	}
+	if f.Format != AuthExportFormat {
+		return nil, fmt.Errorf("not an auth export (format %q)", f.Format)
+	}
+	if f.Version != 1 {
+		return nil, fmt.Errorf("unsupported auth export version %d", f.Version)
+	}
+	if f.KDF != "argon2id" {
+		return nil, fmt.Errorf("unsupported key derivation %q", f.KDF)
+	}
+	if f.Time == 0 || f.Threads == 0 || f.Memory == 0 || f.Memory > maxAuthExportMemory {
+		return nil, errors.New("invalid key derivation parameters")
+	}
+
+	gcm, err := f.cipher(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if len(f.Nonce) != gcm.NonceSize() {
+		return nil, errors.New("invalid nonce")
+	}
+	plaintext, err := gcm.Open(nil, f.Nonce, f.Ciphertext, []byte(AuthExportFormat))
+	if err != nil {
+		return nil, ErrAuthExportPassphrase
+	}
+
+	var b AuthBundle
+	if err := json.Unmarshal(plaintext, &b); err != nil {
+		return nil, fmt.Errorf("parse auth bundle: %w", err)
+	}
+	return &b, nil
+}
+
+// cipher derives the AES-256-GCM cipher for passphrase from the parameters in f.
+func (f *authExportFile) cipher(passphrase string) (cipher.AEAD, error) {
+	key := argon2.IDKey([]byte(passphrase), f.Salt, f.Time, f.Memory, f.Threads, authExportKeyLen)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// AuthMergeResult counts what MergeAuth did with each kind of entry.
+type AuthMergeResult struct {
+	IdentitiesAdded   int `json:"identities_added"`
+	IdentitiesUpdated int `json:"identities_updated"`
+	IdentitiesKept    int `json:"identities_kept"`
+	KeysAdded         int `json:"keys_added"`
+	KeysRevoked       int `json:"keys_revoked"`
+	KeysKept          int `json:"keys_kept"`
+	KeysSkipped       int `json:"keys_skipped"`
+	PoliciesAdded     int `json:"policies_added"`
+	PoliciesUpdated   int `json:"policies_updated"`
+	PoliciesKept      int `json:"policies_kept"`
+}
+
+// MergeAuth merges b into st without clobbering newer local entries:
+//   - entries missing locally are added;
+//   - an identity or policy present on both sides is replaced only when the
+//     exported copy has a later UpdatedAt, and never when the local copy comes
+//     from the YAML config;
+//   - an existing API key is only ever revoked (keys are immutable otherwise,
+//     and a revocation must not be undone by an older export);
+//   - keys whose identity exists on neither side are skipped.
+func MergeAuth(st *AppState, b *AuthBundle) AuthMergeResult {
+	var res AuthMergeResult
+
+	identities := make(map[string]int, len(st.Identities))
+	for i, e := range st.Identities {
+		identities[e.ID] = i
+	}
+	for _, e := range b.Identities {
+		e.ReadOnly = false
+		i, ok := identities[e.ID]
+		switch {
+		case !ok:
+			identities[e.ID] = len(st.Identities)
+			st.Identities = append(st.Identities, e)
+			res.IdentitiesAdded++
+		case !st.Identities[i].ReadOnly && e.UpdatedAt.After(st.Identities[i].UpdatedAt):
+			st.Identities[i] = e
+			res.IdentitiesUpdated++
+		default:
+			res.IdentitiesKept++
+		}
+	}
+
+	keys := make(map[string]int, len(st.APIKeys))
+	for i, e := range st.APIKeys {
+		keys[e.ID] = i
+	}
+	for _, e := range b.APIKeys {
+		e.ReadOnly = false
+		i, ok := keys[e.ID]
+		switch {
+		case !ok:
+			if _, known := identities[e.IdentityID]; !known {
+				res.KeysSkipped++
+				continue
+			}
+			keys[e.ID] = len(st.APIKeys)
+			st.APIKeys = append(st.APIKeys, e)
+			res.KeysAdded++
+		case e.Revoked && !st.APIKeys[i].Revoked && !st.APIKeys[i].ReadOnly:
+			st.APIKeys[i].Revoked = true
+			res.KeysRevoked++
+		default:
+			res.KeysKept++
+		}
+	}
+
+	policies := make(map[string]int, len(st.Policies))
+	for i, e := range st.Policies {
+		policies[e.ID] = i
+	}
+	for _, e := range b.Policies {
+		e.ReadOnly = false
+		i, ok := policies[e.ID]
+		switch {
+		case !ok:
+			policies[e.ID] = len(st.Policies)
+			st.Policies = append(st.Policies, e)
+			res.PoliciesAdded++
+		case !st.Policies[i].ReadOnly && e.UpdatedAt.After(st.Policies[i].UpdatedAt):
+			st.Policies[i] = e
+			res.PoliciesUpdated++
+		default:
+			res.PoliciesKept++
+		}
+	}
+
+	return res
+}
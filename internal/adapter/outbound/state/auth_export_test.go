@@ -0,0 +1,115 @@
+package state
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAuthExport_SealOpenRoundTrip(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	st := &AppState{
+		DefaultPolicy: "deny",
+		Identities: []IdentityEntry{
+			{ID: "id-1", Name: "ci", Roles: []string{"user"}, UpdatedAt: now},
+			{ID: "id-yaml", Name: "from-config", ReadOnly: true},
+		},
+		APIKeys: []APIKeyEntry{
+			{ID: "key-1", KeyHash: "$argon2id$v=19$m=47104,t=1,p=1$c2FsdA$aGFzaA", KeyPrefix: "sg_abcde", IdentityID: "id-1"},
+		},
+		Policies: []PolicyEntry{{ID: "rule-1", Name: "allow reads", ToolPattern: "read_*", Action: "allow", UpdatedAt: now}},
+	}
+
+	data, err := SealAuthBundle(ExportAuth(st), "correct horse battery staple")
+	if err != nil {
+		t.Fatalf("SealAuthBundle: %v", err)
+	}
+	if bytes.Contains(data, []byte("sg_abcde")) || bytes.Contains(data, []byte("argon2id$v=19")) {
+		t.Fatal("export contains plaintext key material")
+	}
+
+	if _, err := OpenAuthBundle(data, "wrong"); !errors.Is(err, ErrAuthExportPassphrase) {
+		t.Errorf("OpenAuthBundle(wrong passphrase) error = %v", err)
+	}
+	b, err := OpenAuthBundle(data, "correct horse battery staple")
+	if err != nil {
+		t.Fatalf("OpenAuthBundle: %v", err)
+	}
+	if b.DefaultPolicy != "deny" || len(b.Identities) != 1 || b.Identities[0].ID != "id-1" {
+		t.Errorf("identities = %+v (YAML identities must be left out)", b.Identities)
+	}
+	if len(b.APIKeys) != 1 || b.APIKeys[0].KeyHash != st.APIKeys[0].KeyHash {
+		t.Errorf("api keys = %+v", b.APIKeys)
+	}
+	if len(b.Policies) != 1 || !b.Policies[0].UpdatedAt.Equal(now) {
+		t.Errorf("policies = %+v", b.Policies)
+	}
+
+	if _, err := SealAuthBundle(b, ""); err == nil {
+		t.Error("empty passphrase should be rejected")
+	}
+	if _, err := OpenAuthBundle([]byte(`{"format":"other"}`), "x"); err == nil {
+		t.Error("foreign file should be rejected")
+	}
+}
+
+func TestMergeAuth_KeepsNewerLocalEntries(t *testing.T) {
+	older := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+
+	local := &AppState{
+		Identities: []IdentityEntry{
+			{ID: "a", Name: "local-newer", UpdatedAt: newer},
+			{ID: "b", Name: "local-older", UpdatedAt: older},
+			{ID: "c", Name: "yaml", ReadOnly: true, UpdatedAt: older},
+		},
+		APIKeys: []APIKeyEntry{
+			{ID: "k1", IdentityID: "a"},
+			{ID: "k2", IdentityID: "b", Revoked: true},
+		},
+		Policies: []PolicyEntry{{ID: "p1", Action: "deny", UpdatedAt: newer}},
+	}
+	bundle := &AuthBundle{
+		Identities: []IdentityEntry{
+			{ID: "a", Name: "export-older", UpdatedAt: older},
+			{ID: "b", Name: "export-newer", UpdatedAt: newer},
+			{ID: "c", Name: "export", UpdatedAt: newer},
+			{ID: "d", Name: "new"},
+		},
+		APIKeys: []APIKeyEntry{
+			{ID: "k1", IdentityID: "a", Revoked: true},
+			{ID: "k2", IdentityID: "b"},
+			{ID: "k3", IdentityID: "d"},
+			{ID: "k4", IdentityID: "gone"},
+		},
+		Policies: []PolicyEntry{
+			{ID: "p1", Action: "allow", UpdatedAt: older},
+			{ID: "p2", Action: "allow"},
+		},
+	}
+
+	res := MergeAuth(local, bundle)
+	want := AuthMergeResult{
+		IdentitiesAdded: 1, IdentitiesUpdated: 1, IdentitiesKept: 2,
+		KeysAdded: 1, KeysRevoked: 1, KeysKept: 1, KeysSkipped: 1,
+		PoliciesAdded: 1, PoliciesKept: 1,
+	}
+	if res != want {
+		t.Errorf("result = %+v, want %+v", res, want)
+	}
+
+	names := map[string]string{}
+	for _, e := range local.Identities {
+		names[e.ID] = e.Name
+	}
+	if names["a"] != "local-newer" || names["b"] != "export-newer" || names["c"] != "yaml" || names["d"] != "new" {
+		t.Errorf("identities after merge = %v", names)
+	}
+	if !local.APIKeys[0].Revoked || !local.APIKeys[1].Revoked {
+		t.Errorf("keys after merge = %+v (revocations must win)", local.APIKeys)
+	}
+	if local.Policies[0].Action != "deny" || len(local.Policies) != 2 {
+		t.Errorf("policies after merge = %+v", local.Policies)
+	}
+}
@@ -0,0 +1,490 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/postgres"
+)
+
+// DefaultPostgresTable is the table PostgresStateStore uses by default.
+const DefaultPostgresTable = "sentinelgate_state"
+
+// pgStateKind is the kind of the row holding the fields of AppState that
+// are not stored one row per entry.
+const pgStateKind = "state"
+
+// pgTableName matches a table name, optionally qualified by its schema.
+var pgTableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// pgExecer runs statements; implemented by postgres.Client and postgres.Tx.
+type pgExecer interface {
+	Exec(ctx context.Context, query string, args ...any) (*postgres.Result, error)
+}
+
+// pgTx is a transaction; implemented by postgres.Tx.
+type pgTx interface {
+	pgExecer
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+}
+
+// pgRow is a row of the state table.
+type pgRow struct {
+	kind, id string
+	pos      int
+	data     string
+}
+
+// pgRowKey identifies a row of the state table.
+type pgRowKey struct{ kind, id string }
+
+// pgCollection stores an AppState list one row per entry, keyed by the
+// entry ID.
+type pgCollection struct {
+	kind string
+	// rows marshals the entries of st.
+	rows func(st *AppState) ([]pgRow, error)
+	// set replaces the entries of st with the given JSON documents, in
+	// order.
+	set func(st *AppState, docs []string) error
+	// clear removes the entries from st.
+	clear func(st *AppState)
+}
+
+// pgCollections are the lists of AppState stored one row per entry, so that
+// a change to one entry rewrites one row. Outbound rules are policies.
+var pgCollections = []pgCollection{
+	newPGCollection("upstream", func(st *AppState) *[]UpstreamEntry { return &st.Upstreams }, func(e *UpstreamEntry) string { return e.ID }),
+	newPGCollection("policy", func(st *AppState) *[]PolicyEntry { return &st.Policies }, func(e *PolicyEntry) string { return e.ID }),
+	newPGCollection("identity", func(st *AppState) *[]IdentityEntry { return &st.Identities }, func(e *IdentityEntry) string { return e.ID }),
+	newPGCollection("api_key", func(st *AppState) *[]APIKeyEntry { return &st.APIKeys }, func(e *APIKeyEntry) string { return e.ID }),
+	newPGCollection("admin_token", func(st *AppState) *[]AdminTokenEntry { return &st.AdminTokens }, func(e *AdminTokenEntry) string { return e.ID }),
+}
+
+func newPGCollection[T any](kind string, field func(*AppState) *[]T, id func(*T) string) pgCollection {
+	return pgCollection{
+		kind: kind,
+		rows: func(st *AppState) ([]pgRow, error) {
+			entries := *field(st)
+			rows := make([]pgRow, 0, len(entries))
+			seen := make(map[string]bool, len(entries))
+			for i := range entries {
+				entryID := id(&entries[i])
+				if entryID == "" {
+					return nil, fmt.Errorf("%s at index %d has no ID", kind, i)
+				}
+				if seen[entryID] {
+					return nil, fmt.Errorf("duplicate %s ID %q", kind, entryID)
+				}
+				seen[entryID] = true
+				data, err := json.Marshal(&entries[i])
+				if err != nil {
+					return nil, fmt.Errorf("marshal %s %q: %w", kind, entryID, err)
+				}
+				rows = append(rows, pgRow{kind: kind, id: entryID, pos: i, data: string(data)})
+			}
+			return rows, nil
+		},
+		set: func(st *AppState, docs []string) error {
+			entries := make([]T, len(docs))
+			for i, doc := range docs {
+				if err := json.Unmarshal([]byte(doc), &entries[i]); err != nil {
+					return fmt.Errorf("parse %s row: %w", kind, err)
+				}
+			}
+			*field(st) = entries
+			return nil
+		},
+		clear: func(st *AppState) { *field(st) = nil },
+	}
+}
+
+// PostgresStateStore keeps the AppState in a PostgreSQL table, so that
+// several gateway replicas can share it. Upstreams, policies, identities,
+// API keys and admin tokens are stored one row each and the rest of the
+// state in a single row; Save rewrites only the rows that changed. Writes
+// take a transaction-scoped advisory lock, which makes Mutate atomic across
+// replicas. Secrets are encrypted as in state.json.
+type PostgresStateStore struct {
+	db      pgExecer
+	begin   func(ctx context.Context) (pgTx, error)
+	table   string
+	lockKey int64
+	logger  *slog.Logger
+
+	mu     sync.Mutex
+	cipher *SecretCipher // encrypts secrets; nil stores them as is
+	// plaintext counts the secrets found unencrypted by the last load.
+	plaintext int
+}
+
+// NewPostgresStateStore creates a store in table ("schema.table" or
+// "table"; empty uses DefaultPostgresTable). Call EnsureSchema before
+// using it.
+func NewPostgresStateStore(client *postgres.Client, table string, logger *slog.Logger) (*PostgresStateStore, error) {
+	return newPostgresStateStore(client, func(ctx context.Context) (pgTx, error) {
+		return client.Begin(ctx)
+	}, table, logger)
+}
+
+func newPostgresStateStore(db pgExecer, begin func(ctx context.Context) (pgTx, error), table string, logger *slog.Logger) (*PostgresStateStore, error) {
+	if table == "" {
+		table = DefaultPostgresTable
+	}
+	if !pgTableName.MatchString(table) {
+		return nil, fmt.Errorf("invalid table name %q", table)
+	}
+	h := fnv.New64a()
+	h.Write([]byte("sentinelgate:" + table))
+	return &PostgresStateStore{
+		db:      db,
+		begin:   begin,
+		table:   table,
+		lockKey: int64(h.Sum64()),
+		logger:  logger,
+	}, nil
+}
+
+// EnsureSchema creates the state table if it does not exist.
+func (s *PostgresStateStore) EnsureSchema(ctx context.Context) error {
+	return s.inTx(ctx, func(tx pgTx) error {
+		_, err := tx.Exec(ctx, `CREATE TABLE IF NOT EXISTS `+s.table+` (
+	kind TEXT NOT NULL,
+	id TEXT NOT NULL,
+	pos INTEGER NOT NULL DEFAULT 0,
+	data JSONB NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	PRIMARY KEY (kind, id)
+)`)
+		if err != nil {
+			return fmt.Errorf("create state table: %w", err)
+		}
+		return nil
+	})
+}
+
+// SetSecretCipher encrypts the secrets written to the table with c and
+// decrypts them on load. Call it before the first Load.
+func (s *PostgresStateStore) SetSecretCipher(c *SecretCipher) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cipher = c
+}
+
+// Load reads the state. If none is stored, it returns DefaultState().
+func (s *PostgresStateStore) Load() (*AppState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ctx := context.Background()
+	rows, err := s.readRows(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+	return s.decode(rows)
+}
+
+// Save replaces the stored state with state.
+func (s *PostgresStateStore) Save(state *AppState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ctx := context.Background()
+	return s.inTx(ctx, func(tx pgTx) error {
+		current, err := s.readRows(ctx, tx)
+		if err != nil {
+			return err
+		}
+		return s.write(ctx, tx, current, state)
+	})
+}
+
+// Mutate atomically reads the state, applies fn, and writes back. The
+// advisory lock is held throughout, so concurrent Mutate calls, from this
+// or another replica, apply one after the other. If fn returns an error
+// the state is NOT saved and the error is returned.
+func (s *PostgresStateStore) Mutate(fn func(*AppState) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ctx := context.Background()
+	return s.inTx(ctx, func(tx pgTx) error {
+		current, err := s.readRows(ctx, tx)
+		if err != nil {
+			return err
+		}
+		st, err := s.decode(current)
+		if err != nil {
+			return err
+		}
+		if err := fn(st); err != nil {
+			return err
+		}
+		return s.write(ctx, tx, current, st)
+	})
+}
+
+// EncryptPlaintextSecrets rewrites the rows holding secrets stored in
+// plaintext, e.g. by a tool run without the key, and returns how many were
+// encrypted. Without a cipher it does nothing.
+func (s *PostgresStateStore) EncryptPlaintextSecrets() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cipher == nil {
+		return 0, nil
+	}
+	ctx := context.Background()
+	var n int
+	err := s.inTx(ctx, func(tx pgTx) error {
+		current, err := s.readRows(ctx, tx)
+		if err != nil {
+			return err
+		}
+		if _, ok := current[pgRowKey{pgStateKind, pgStateKind}]; !ok {
+			return nil
+		}
+		st, err := s.decode(current)
+		if err != nil {
+			return err
+		}
+		if s.plaintext == 0 {
+			return nil
+		}
+		n = s.plaintext
+		return s.write(ctx, tx, current, st)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// Snapshot writes the stored state, with its secrets encrypted, into dir
+// as state-<UTC timestamp>.json and deletes the oldest snapshots beyond
+// keep (keep <= 0 keeps all). A snapshot can be used as a state.json.
+func (s *PostgresStateStore) Snapshot(dir string, keep int) (string, error) {
+	s.mu.Lock()
+	rows, err := s.readRows(context.Background(), s.db)
+	s.mu.Unlock()
+	if err != nil {
+		return "", err
+	}
+	if _, ok := rows[pgRowKey{pgStateKind, pgStateKind}]; !ok {
+		return "", fmt.Errorf("no state to snapshot in %s", s.Path())
+	}
+	st, err := s.assemble(rows)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal state: %w", err)
+	}
+	return writeSnapshot(dir, keep, append(data, '\n'), s.logger)
+}
+
+// DefaultState returns the state of a first boot; see
+// FileStateStore.DefaultState.
+func (s *PostgresStateStore) DefaultState() *AppState {
+	return defaultState()
+}
+
+// Exists reports whether a state is stored in the table. Errors are logged
+// and reported as false.
+func (s *PostgresStateStore) Exists() bool {
+	res, err := s.db.Exec(context.Background(),
+		`SELECT 1 FROM `+s.table+` WHERE kind = $1 AND id = $1`, pgStateKind)
+	if err != nil {
+		s.logger.Warn("failed to check for stored state", "path", s.Path(), "error", err)
+		return false
+	}
+	return len(res.Rows) > 0
+}
+
+// Path describes the table holding the state.
+func (s *PostgresStateStore) Path() string {
+	return "postgres table " + s.table
+}
+
+// inTx runs fn in a transaction holding the store's advisory lock.
+func (s *PostgresStateStore) inTx(ctx context.Context, fn func(tx pgTx) error) error {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1::bigint)`, s.lockKey); err != nil {
+		return fmt.Errorf("lock state: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit state: %w", err)
+	}
+	return nil
+}
+
+// readRows reads every row of the table.
+func (s *PostgresStateStore) readRows(ctx context.Context, db pgExecer) (map[pgRowKey]pgRow, error) {
+	res, err := db.Exec(ctx, `SELECT kind, id, pos, data::text FROM `+s.table)
+	if err != nil {
+		return nil, fmt.Errorf("read state: %w", err)
+	}
+	rows := make(map[pgRowKey]pgRow, len(res.Rows))
+	for _, r := range res.Rows {
+		if len(r) != 4 || r[0] == nil || r[1] == nil || r[2] == nil || r[3] == nil {
+			return nil, fmt.Errorf("read state: unexpected row")
+		}
+		pos, err := strconv.Atoi(*r[2])
+		if err != nil {
+			return nil, fmt.Errorf("read state: invalid position %q", *r[2])
+		}
+		row := pgRow{kind: *r[0], id: *r[1], pos: pos, data: *r[3]}
+		rows[pgRowKey{row.kind, row.id}] = row
+	}
+	return rows, nil
+}
+
+// decode assembles the state from rows and decrypts its secrets. Without
+// a state row it returns the default state. Caller must hold s.mu.
+func (s *PostgresStateStore) decode(rows map[pgRowKey]pgRow) (*AppState, error) {
+	if _, ok := rows[pgRowKey{pgStateKind, pgStateKind}]; !ok {
+		s.logger.Info("no state stored, using default state", "path", s.Path())
+		s.plaintext = 0
+		return s.DefaultState(), nil
+	}
+	st, err := s.assemble(rows)
+	if err != nil {
+		return nil, err
+	}
+	s.plaintext = 0
+	if s.cipher != nil {
+		n, err := s.cipher.decryptSecrets(st)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt state secrets: %w", err)
+		}
+		s.plaintext = n
+	}
+	checkVersion(st, s.logger, s.Path())
+	validateState(st, s.logger)
+	return st, nil
+}
+
+// assemble builds the state, as stored, from rows.
+func (s *PostgresStateStore) assemble(rows map[pgRowKey]pgRow) (*AppState, error) {
+	var st AppState
+	if err := json.Unmarshal([]byte(rows[pgRowKey{pgStateKind, pgStateKind}].data), &st); err != nil {
+		return nil, fmt.Errorf("parse state row: %w", err)
+	}
+	byKind := make(map[string][]pgRow)
+	for _, r := range rows {
+		byKind[r.kind] = append(byKind[r.kind], r)
+	}
+	for _, c := range pgCollections {
+		list := byKind[c.kind]
+		delete(byKind, c.kind)
+		sortRows(list)
+		docs := make([]string, len(list))
+		for i, r := range list {
+			docs[i] = r.data
+		}
+		if err := c.set(&st, docs); err != nil {
+			return nil, err
+		}
+	}
+	delete(byKind, pgStateKind)
+	for kind := range byKind {
+		s.logger.Warn("ignoring state rows of unknown kind", "path", s.Path(), "kind", kind)
+	}
+	return &st, nil
+}
+
+// write stores state, encrypting its secrets, by deleting, inserting and
+// updating the rows that differ from current. Caller must hold s.mu and the
+// advisory lock.
+func (s *PostgresStateStore) write(ctx context.Context, tx pgExecer, current map[pgRowKey]pgRow, state *AppState) error {
+	state.UpdatedAt = time.Now().UTC()
+
+	stored := state
+	if s.cipher != nil {
+		var err error
+		if stored, err = s.cipher.encryptSecrets(state); err != nil {
+			return fmt.Errorf("encrypt state secrets: %w", err)
+		}
+	}
+
+	var rows []pgRow
+	for _, c := range pgCollections {
+		r, err := c.rows(stored)
+		if err != nil {
+			return fmt.Errorf("save state: %w", err)
+		}
+		rows = append(rows, r...)
+	}
+	// The state row holds every other field.
+	rest := *stored
+	for _, c := range pgCollections {
+		c.clear(&rest)
+	}
+	data, err := json.Marshal(&rest)
+	if err != nil {
+		return fmt.Errorf("marshal state: %w", err)
+	}
+	rows = append(rows, pgRow{kind: pgStateKind, id: pgStateKind, data: string(data)})
+
+	keep := make(map[pgRowKey]bool, len(rows))
+	for _, r := range rows {
+		key := pgRowKey{r.kind, r.id}
+		keep[key] = true
+		if old, ok := current[key]; ok && old.pos == r.pos && sameJSON(old.data, r.data) {
+			continue
+		}
+		if _, err := tx.Exec(ctx, `INSERT INTO `+s.table+` (kind, id, pos, data, updated_at) VALUES ($1, $2, $3, $4, now())
+ON CONFLICT (kind, id) DO UPDATE SET pos = EXCLUDED.pos, data = EXCLUDED.data, updated_at = EXCLUDED.updated_at`,
+			r.kind, r.id, r.pos, r.data); err != nil {
+			return fmt.Errorf("save %s %q: %w", r.kind, r.id, err)
+		}
+	}
+	for key := range current {
+		if keep[key] {
+			continue
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM `+s.table+` WHERE kind = $1 AND id = $2`, key.kind, key.id); err != nil {
+			return fmt.Errorf("delete %s %q: %w", key.kind, key.id, err)
+		}
+	}
+	s.logger.Debug("state saved", "path", s.Path())
+	return nil
+}
+
+// sameJSON reports whether two JSON documents hold the same value; the
+// server normalizes JSONB, so the texts may differ.
+func sameJSON(a, b string) bool {
+	if a == b {
+		return true
+	}
+	var va, vb any
+	if json.Unmarshal([]byte(a), &va) != nil || json.Unmarshal([]byte(b), &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// sortRows orders rows by position, then ID.
+func sortRows(rows []pgRow) {
+	slices.SortFunc(rows, func(a, b pgRow) int {
+		if a.pos != b.pos {
+			return a.pos - b.pos
+		}
+		return strings.Compare(a.id, b.id)
+	})
+}
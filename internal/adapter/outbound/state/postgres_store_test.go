@@ -0,0 +1,323 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/postgres"
+)
+
+// fakePG is an in-memory state table answering the statements of
+// PostgresStateStore. A transaction works on a copy of the table that
+// Commit publishes; the advisory lock serializes transactions.
+type fakePG struct {
+	lock sync.Mutex // the advisory lock
+
+	mu      sync.Mutex
+	rows    map[pgRowKey]pgRow
+	created bool
+	writes  []string // "INSERT kind/id" and "DELETE kind/id"
+}
+
+func newFakePG() *fakePG { return &fakePG{rows: map[pgRowKey]pgRow{}} }
+
+func (db *fakePG) Exec(ctx context.Context, query string, args ...any) (*postgres.Result, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.exec(db.rows, query, args)
+}
+
+func (db *fakePG) begin(ctx context.Context) (pgTx, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	rows := make(map[pgRowKey]pgRow, len(db.rows))
+	for k, v := range db.rows {
+		rows[k] = v
+	}
+	return &fakeTx{db: db, rows: rows}, nil
+}
+
+func (db *fakePG) exec(rows map[pgRowKey]pgRow, query string, args []any) (*postgres.Result, error) {
+	str := func(i int) string { return fmt.Sprint(args[i]) }
+	switch {
+	case strings.HasPrefix(query, "CREATE TABLE IF NOT EXISTS sentinelgate_state "):
+		db.created = true
+		return &postgres.Result{Tag: "CREATE TABLE"}, nil
+	case query == "SELECT kind, id, pos, data::text FROM sentinelgate_state":
+		res := &postgres.Result{Columns: []string{"kind", "id", "pos", "data"}}
+		for _, r := range rows {
+			kind, id, pos, data := r.kind, r.id, strconv.Itoa(r.pos), r.data
+			res.Rows = append(res.Rows, []*string{&kind, &id, &pos, &data})
+		}
+		return res, nil
+	case query == "SELECT 1 FROM sentinelgate_state WHERE kind = $1 AND id = $1":
+		res := &postgres.Result{}
+		if _, ok := rows[pgRowKey{str(0), str(0)}]; ok {
+			one := "1"
+			res.Rows = append(res.Rows, []*string{&one})
+		}
+		return res, nil
+	case strings.HasPrefix(query, "INSERT INTO sentinelgate_state "):
+		pos, _ := args[2].(int)
+		rows[pgRowKey{str(0), str(1)}] = pgRow{kind: str(0), id: str(1), pos: pos, data: str(3)}
+		db.writes = append(db.writes, "INSERT "+str(0)+"/"+str(1))
+		return &postgres.Result{Tag: "INSERT 0 1"}, nil
+	case query == "DELETE FROM sentinelgate_state WHERE kind = $1 AND id = $2":
+		delete(rows, pgRowKey{str(0), str(1)})
+		db.writes = append(db.writes, "DELETE "+str(0)+"/"+str(1))
+		return &postgres.Result{Tag: "DELETE 1"}, nil
+	}
+	return nil, &postgres.Error{Code: "42601", Message: "unexpected statement: " + query}
+}
+
+// takeWrites returns and clears the recorded writes.
+func (db *fakePG) takeWrites() []string {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	w := db.writes
+	db.writes = nil
+	return w
+}
+
+type fakeTx struct {
+	db     *fakePG
+	rows   map[pgRowKey]pgRow
+	locked bool
+	done   bool
+}
+
+func (tx *fakeTx) Exec(ctx context.Context, query string, args ...any) (*postgres.Result, error) {
+	if query == "SELECT pg_advisory_xact_lock($1::bigint)" {
+		tx.db.lock.Lock()
+		tx.locked = true
+		// Read the table as committed once the lock is held, as a new
+		// statement of a read committed transaction would.
+		tx.db.mu.Lock()
+		for k, v := range tx.db.rows {
+			tx.rows[k] = v
+		}
+		tx.db.mu.Unlock()
+		return &postgres.Result{Tag: "SELECT 1"}, nil
+	}
+	tx.db.mu.Lock()
+	defer tx.db.mu.Unlock()
+	return tx.db.exec(tx.rows, query, args)
+}
+
+func (tx *fakeTx) Commit(ctx context.Context) error {
+	tx.db.mu.Lock()
+	tx.db.rows = tx.rows
+	tx.db.mu.Unlock()
+	return tx.end()
+}
+
+func (tx *fakeTx) Rollback(ctx context.Context) error { return tx.end() }
+
+func (tx *fakeTx) end() error {
+	if tx.done {
+		return nil
+	}
+	tx.done = true
+	if tx.locked {
+		tx.db.lock.Unlock()
+	}
+	return nil
+}
+
+func newTestPostgresStore(t *testing.T, db *fakePG) *PostgresStateStore {
+	t.Helper()
+	s, err := newPostgresStateStore(db, db.begin, "", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("newPostgresStateStore: %v", err)
+	}
+	if err := s.EnsureSchema(context.Background()); err != nil {
+		t.Fatalf("EnsureSchema: %v", err)
+	}
+	return s
+}
+
+func TestPostgresStateStore_SaveLoad(t *testing.T) {
+	db := newFakePG()
+	s := newTestPostgresStore(t, db)
+	if !db.created {
+		t.Fatal("EnsureSchema did not create the table")
+	}
+	if s.Exists() {
+		t.Fatal("Exists() on an empty table = true")
+	}
+	st, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if st.DefaultPolicy != "deny" || st.Upstreams == nil {
+		t.Fatalf("Load of an empty table = %+v, want the default state", st)
+	}
+
+	st.Upstreams = []UpstreamEntry{{ID: "u2", Name: "second"}, {ID: "u1", Name: "first"}}
+	st.Policies = []PolicyEntry{{ID: "p1", Name: "allow-read"}}
+	st.Identities = []IdentityEntry{{ID: "i1", Name: "alice"}}
+	st.APIKeys = []APIKeyEntry{{ID: "k1", IdentityID: "i1", KeyHash: "hash"}}
+	st.QuarantinedTools = []string{"rm"}
+	if err := s.Save(st); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if !s.Exists() {
+		t.Fatal("Exists() after Save = false")
+	}
+	if got := len(db.takeWrites()); got != 6 {
+		t.Errorf("first Save wrote %d rows, want 6", got)
+	}
+
+	got, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(got.Upstreams) != 2 || got.Upstreams[0].ID != "u2" || got.Upstreams[1].ID != "u1" {
+		t.Errorf("upstreams = %+v, want u2, u1 in order", got.Upstreams)
+	}
+	if len(got.Policies) != 1 || len(got.Identities) != 1 || len(got.APIKeys) != 1 || got.APIKeys[0].KeyHash != "hash" {
+		t.Errorf("loaded state = %+v", got)
+	}
+	if len(got.QuarantinedTools) != 1 || got.AdminTokens == nil {
+		t.Errorf("quarantined tools = %v, admin tokens = %v", got.QuarantinedTools, got.AdminTokens)
+	}
+
+	// Only the changed rows, and the state row, are written.
+	got.Upstreams[1].Name = "renamed"
+	got.Identities = nil
+	got.APIKeys = nil
+	if err := s.Save(got); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	w := db.takeWrites()
+	slices.Sort(w)
+	writes := strings.Join(w, ",")
+	if want := "DELETE api_key/k1,DELETE identity/i1,INSERT state/state,INSERT upstream/u1"; writes != want {
+		t.Errorf("second Save wrote %s, want %s", writes, want)
+	}
+}
+
+func TestPostgresStateStore_DuplicateIDs(t *testing.T) {
+	s := newTestPostgresStore(t, newFakePG())
+	st := s.DefaultState()
+	st.Policies = []PolicyEntry{{ID: "p1"}, {ID: "p1"}}
+	if err := s.Save(st); err == nil || !strings.Contains(err.Error(), "duplicate policy ID") {
+		t.Errorf("Save with duplicate IDs = %v, want a duplicate ID error", err)
+	}
+}
+
+// TestPostgresStateStore_MutateAcrossReplicas runs Mutate from two stores
+// sharing the table, as two replicas would: no update is lost.
+func TestPostgresStateStore_MutateAcrossReplicas(t *testing.T) {
+	db := newFakePG()
+	a, b := newTestPostgresStore(t, db), newTestPostgresStore(t, db)
+	if err := a.Save(a.DefaultState()); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		store := a
+		if i%2 == 1 {
+			store = b
+		}
+		wg.Add(1)
+		go func(i int, store *PostgresStateStore) {
+			defer wg.Done()
+			err := store.Mutate(func(st *AppState) error {
+				st.Identities = append(st.Identities, IdentityEntry{ID: fmt.Sprintf("id-%d", i)})
+				return nil
+			})
+			if err != nil {
+				t.Errorf("Mutate: %v", err)
+			}
+		}(i, store)
+	}
+	wg.Wait()
+
+	st, err := b.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(st.Identities) != 20 {
+		t.Errorf("identities = %d, want 20", len(st.Identities))
+	}
+
+	// An error from fn leaves the state unchanged.
+	errStop := errors.New("stop")
+	if err := a.Mutate(func(st *AppState) error { st.Identities = nil; return errStop }); !errors.Is(err, errStop) {
+		t.Fatalf("Mutate = %v, want errStop", err)
+	}
+	if st, _ := a.Load(); len(st.Identities) != 20 {
+		t.Errorf("identities after a failed Mutate = %d, want 20", len(st.Identities))
+	}
+}
+
+func TestPostgresStateStore_Secrets(t *testing.T) {
+	db := newFakePG()
+	plain := newTestPostgresStore(t, db)
+	st := plain.DefaultState()
+	st.Upstreams = []UpstreamEntry{{ID: "u1", Env: map[string]string{"TOKEN": "s3cret"}}}
+	if err := plain.Save(st); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	cipher := testSecretCipher(t)
+	s := newTestPostgresStore(t, db)
+	s.SetSecretCipher(cipher)
+	n, err := s.EncryptPlaintextSecrets()
+	if err != nil || n != 1 {
+		t.Fatalf("EncryptPlaintextSecrets() = %d, %v, want 1", n, err)
+	}
+	if row := db.rows[pgRowKey{"upstream", "u1"}]; strings.Contains(row.data, "s3cret") {
+		t.Errorf("upstream row still holds the plaintext secret: %s", row.data)
+	}
+	if n, err := s.EncryptPlaintextSecrets(); err != nil || n != 0 {
+		t.Errorf("second EncryptPlaintextSecrets() = %d, %v, want 0", n, err)
+	}
+
+	got, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got.Upstreams[0].Env["TOKEN"] != "s3cret" {
+		t.Errorf("decrypted env = %v", got.Upstreams[0].Env)
+	}
+
+	// Snapshots keep the secrets encrypted and read back as a state file.
+	path, err := s.Snapshot(t.TempDir(), 3)
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	file := NewFileStateStore(path, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	file.SetSecretCipher(cipher)
+	fromFile, err := file.Load()
+	if err != nil {
+		t.Fatalf("Load snapshot: %v", err)
+	}
+	if fromFile.Upstreams[0].Env["TOKEN"] != "s3cret" {
+		t.Errorf("snapshot env = %v", fromFile.Upstreams[0].Env)
+	}
+}
+
+func TestNewPostgresStateStore_TableName(t *testing.T) {
+	db := newFakePG()
+	for _, table := range []string{"state", "ops.gateway_state"} {
+		if _, err := newPostgresStateStore(db, db.begin, table, slog.Default()); err != nil {
+			t.Errorf("table %q: %v", table, err)
+		}
+	}
+	for _, table := range []string{"state; DROP TABLE x", "a.b.c", "1state"} {
+		if _, err := newPostgresStateStore(db, db.begin, table, slog.Default()); err == nil {
+			t.Errorf("table %q accepted", table)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package state
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SecretKeySize is the size in bytes of the AES-256 key that encrypts
+// secrets in state.json.
+const SecretKeySize = 32
+
+// encryptedPrefix marks a value encrypted by SecretCipher.
+const encryptedPrefix = "enc:v1:"
+
+// SecretCipher encrypts the secrets kept in state.json with AES-256-GCM.
+// Each value is bound to where it is stored, so an encrypted value copied
+// to another upstream or field does not decrypt.
+type SecretCipher struct {
+	aead cipher.AEAD
+}
+
+// NewSecretCipher creates a cipher from a SecretKeySize-byte key.
+func NewSecretCipher(key []byte) (*SecretCipher, error) {
+	if len(key) != SecretKeySize {
+		return nil, fmt.Errorf("secret key must be %d bytes, got %d", SecretKeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &SecretCipher{aead: aead}, nil
+}
+
+// Encrypt returns v encrypted for location. Empty values, values already
+// encrypted and ${env:NAME} references are returned unchanged.
+func (c *SecretCipher) Encrypt(v, location string) (string, error) {
+	if v == "" || IsEncrypted(v) || isEnvRef(v) {
+		return v, nil
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("generate nonce: %w", err)
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(v), []byte(location))
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt returns the plaintext of a value encrypted for location. Values
+// that are not encrypted are returned unchanged.
+func (c *SecretCipher) Decrypt(v, location string) (string, error) {
+	if !IsEncrypted(v) {
+		return v, nil
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(v, encryptedPrefix))
+	if err != nil || len(sealed) < c.aead.NonceSize() {
+		return "", fmt.Errorf("%s: malformed encrypted value", location)
+	}
+	n := c.aead.NonceSize()
+	plain, err := c.aead.Open(nil, sealed[:n], sealed[n:], []byte(location))
+	if err != nil {
+		return "", fmt.Errorf("%s: cannot decrypt (wrong secret key?)", location)
+	}
+	return string(plain), nil
+}
+
+// IsEncrypted reports whether v was encrypted by a SecretCipher.
+func IsEncrypted(v string) bool {
+	return strings.HasPrefix(v, encryptedPrefix)
+}
+
+// isEnvRef reports whether v is exactly one ${env:NAME} reference, which
+// holds no secret.
+func isEnvRef(v string) bool {
+	return strings.HasPrefix(v, "${env:") && strings.HasSuffix(v, "}") && strings.Count(v, "${") == 1
+}
+
+// credentialSecrets returns the secret fields of c by name.
+func credentialSecrets(c *UpstreamCredentialsEntry) map[string]*string {
+	return map[string]*string{
+		"token":         &c.Token,
+		"password":      &c.Password,
+		"header_value":  &c.HeaderValue,
+		"client_secret": &c.ClientSecret,
+	}
+}
+
+// forEachSecret replaces each secret of st with what fn returns for it and
+// its location: upstream credentials, env values and headers, API key and
+// admin token hashes, and the admin password hash. owner names the entry
+// holding the secret, for errors.
+func forEachSecret(st *AppState, fn func(v, location, owner string) (string, error)) error {
+	apply := func(v *string, location, owner string) error {
+		out, err := fn(*v, location, owner)
+		if err != nil {
+			return err
+		}
+		*v = out
+		return nil
+	}
+	applyMap := func(m map[string]string, location, owner string) error {
+		for k, v := range m {
+			if err := apply(&v, location+"/"+k, owner); err != nil {
+				return err
+			}
+			m[k] = v
+		}
+		return nil
+	}
+
+	for i := range st.Upstreams {
+		u := &st.Upstreams[i]
+		owner := fmt.Sprintf("upstream %q", u.Name)
+		if u.Credentials != nil {
+			for name, v := range credentialSecrets(u.Credentials) {
+				if err := apply(v, "upstreams/"+u.ID+"/credentials/"+name, owner); err != nil {
+					return err
+				}
+			}
+		}
+		if err := applyMap(u.Env, "upstreams/"+u.ID+"/env", owner); err != nil {
+			return err
+		}
+		if err := applyMap(u.Headers, "upstreams/"+u.ID+"/headers", owner); err != nil {
+			return err
+		}
+	}
+	for i := range st.APIKeys {
+		k := &st.APIKeys[i]
+		if err := apply(&k.KeyHash, "api_keys/"+k.ID+"/key_hash", fmt.Sprintf("API key %q", k.Name)); err != nil {
+			return err
+		}
+	}
+	for i := range st.AdminTokens {
+		t := &st.AdminTokens[i]
+		if err := apply(&t.TokenHash, "admin_tokens/"+t.ID+"/token_hash", fmt.Sprintf("admin token %q", t.Name)); err != nil {
+			return err
+		}
+	}
+	return apply(&st.AdminPasswordHash, "admin_password_hash", "admin password")
+}
+
+// encryptSecrets returns a copy of st whose secrets are encrypted. st
+// itself is left in plaintext for its callers.
+func (c *SecretCipher) encryptSecrets(st *AppState) (*AppState, error) {
+	out := *st
+	out.Upstreams = make([]UpstreamEntry, len(st.Upstreams))
+	copy(out.Upstreams, st.Upstreams)
+	for i := range out.Upstreams {
+		u := &out.Upstreams[i]
+		if u.Credentials != nil {
+			creds := *u.Credentials
+			u.Credentials = &creds
+		}
+		u.Env = copyStringMap(u.Env)
+		u.Headers = copyStringMap(u.Headers)
+	}
+	out.APIKeys = append([]APIKeyEntry(nil), st.APIKeys...)
+	out.AdminTokens = append([]AdminTokenEntry(nil), st.AdminTokens...)
+
+	err := forEachSecret(&out, func(v, location, _ string) (string, error) {
+		return c.Encrypt(v, location)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// decryptSecrets decrypts the secrets of st in place and returns how many
+// were stored in plaintext.
+func (c *SecretCipher) decryptSecrets(st *AppState) (plaintext int, err error) {
+	err = forEachSecret(st, func(v, location, owner string) (string, error) {
+		if v != "" && !IsEncrypted(v) && !isEnvRef(v) {
+			plaintext++
+		}
+		plain, err := c.Decrypt(v, location)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", owner, err)
+		}
+		return plain, nil
+	})
+	return plaintext, err
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// ParseSecretKey decodes the base64-encoded key of a SecretCipher, e.g. from
+// a key file or an environment variable.
+func ParseSecretKey(data []byte) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(data)))
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != SecretKeySize {
+		return nil, fmt.Errorf("must be %d bytes, got %d", SecretKeySize, len(key))
+	}
+	return key, nil
+}
+
+// LoadSecretKey reads the base64-encoded key of a SecretCipher.
+func LoadSecretKey(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ParseSecretKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("secret key %s: %w", path, err)
+	}
+	return key, nil
+}
+
+// LoadOrCreateSecretKey reads the secret key at path, generating and saving
+// a new one (mode 0600) if the file does not exist. created reports whether
+// a new key was written.
+func LoadOrCreateSecretKey(path string) (key []byte, created bool, err error) {
+	key, err = LoadSecretKey(path)
+	if err == nil || !errors.Is(err, fs.ErrNotExist) {
+		return key, false, err
+	}
+
+	key = make([]byte, SecretKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, false, fmt.Errorf("generate secret key: %w", err)
+	}
+	if dir := filepath.Dir(path); dir != "." && dir != "" {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, false, fmt.Errorf("create key directory: %w", err)
+		}
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, false, fmt.Errorf("create secret key file: %w", err)
+	}
+	_, werr := f.WriteString(base64.StdEncoding.EncodeToString(key) + "\n")
+	if werr == nil {
+		werr = f.Sync()
+	}
+	if cerr := f.Close(); werr == nil {
+		werr = cerr
+	}
+	if werr != nil {
+		_ = os.Remove(path)
+		return nil, false, fmt.Errorf("write secret key file: %w", werr)
+	}
+	return key, true, nil
+}
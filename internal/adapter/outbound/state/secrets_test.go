@@ -0,0 +1,191 @@
+package state
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testSecretCipher(t *testing.T) *SecretCipher {
+	t.Helper()
+	key, _, err := LoadOrCreateSecretKey(filepath.Join(t.TempDir(), "credentials-key"))
+	if err != nil {
+		t.Fatalf("LoadOrCreateSecretKey: %v", err)
+	}
+	c, err := NewSecretCipher(key)
+	if err != nil {
+		t.Fatalf("NewSecretCipher: %v", err)
+	}
+	return c
+}
+
+func TestFileStateStore_EncryptsUpstreamCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	store := NewFileStateStore(path, testLogger())
+	cipher := testSecretCipher(t)
+	store.SetSecretCipher(cipher)
+
+	st := store.DefaultState()
+	st.Upstreams = append(st.Upstreams, UpstreamEntry{
+		ID: "u1", Name: "billing", Type: "http", URL: "https://billing.example.com/mcp",
+		Credentials: &UpstreamCredentialsEntry{Type: "oauth2_client_credentials", TokenURL: "https://auth.example.com/token",
+			ClientID: "billing-gw", ClientSecret: "plain-client-secret"},
+	}, UpstreamEntry{
+		ID: "u2", Name: "search", Type: "http", URL: "https://search.example.com/mcp",
+		Credentials: &UpstreamCredentialsEntry{Type: "bearer", Token: "${env:SEARCH_TOKEN}"},
+	})
+	if err := store.Save(st); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if st.Upstreams[0].Credentials.ClientSecret != "plain-client-secret" {
+		t.Error("Save modified the caller's state")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("plain-client-secret")) {
+		t.Error("state.json contains the plaintext client secret")
+	}
+	if !bytes.Contains(data, []byte("${env:SEARCH_TOKEN}")) {
+		t.Error("secret reference should be stored as is")
+	}
+
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := loaded.Upstreams[0].Credentials.ClientSecret; got != "plain-client-secret" {
+		t.Errorf("ClientSecret = %q, want decrypted value", got)
+	}
+
+	// Without the cipher the encrypted value is kept, and survives a save.
+	plain := NewFileStateStore(path, testLogger())
+	raw, err := plain.Load()
+	if err != nil {
+		t.Fatalf("Load without cipher: %v", err)
+	}
+	if !IsEncrypted(raw.Upstreams[0].Credentials.ClientSecret) {
+		t.Errorf("ClientSecret = %q, want encrypted value", raw.Upstreams[0].Credentials.ClientSecret)
+	}
+
+	// Another key cannot read the state.
+	other := NewFileStateStore(path, testLogger())
+	other.SetSecretCipher(testSecretCipher(t))
+	if _, err := other.Load(); err == nil || !strings.Contains(err.Error(), "billing") {
+		t.Errorf("Load with wrong key = %v, want decryption error naming the upstream", err)
+	}
+}
+
+func TestSecretCipher_BoundToLocation(t *testing.T) {
+	c := testSecretCipher(t)
+	enc, err := c.Encrypt("s3cret", "upstreams/a/credentials/token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Decrypt(enc, "upstreams/b/credentials/token"); err == nil {
+		t.Error("value encrypted for one upstream decrypted for another")
+	}
+	if again, _ := c.Encrypt(enc, "upstreams/a/credentials/token"); again != enc {
+		t.Error("encrypting an encrypted value should be a no-op")
+	}
+}
+
+func TestFileStateStore_EncryptsKeyHashesAndUpstreamEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	store := NewFileStateStore(path, testLogger())
+	store.SetSecretCipher(testSecretCipher(t))
+
+	st := store.DefaultState()
+	st.Upstreams = append(st.Upstreams, UpstreamEntry{
+		ID: "u1", Name: "github", Type: "stdio", Command: "github-mcp",
+		Env:     map[string]string{"GITHUB_TOKEN": "ghp_plaintexttoken", "HOME_DIR": "${env:HOME}"},
+		Headers: map[string]string{"X-Api-Key": "plain-header-key"},
+	})
+	st.APIKeys = append(st.APIKeys, APIKeyEntry{ID: "k1", Name: "ci", KeyHash: "$argon2id$v=19$m=65536,t=3,p=4$salt$hash", IdentityID: "id1"})
+	st.AdminTokens = append(st.AdminTokens, AdminTokenEntry{ID: "t1", Name: "deploy", TokenHash: "$argon2id$token-hash"})
+	st.AdminPasswordHash = "$argon2id$password-hash"
+	if err := store.Save(st); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if st.Upstreams[0].Env["GITHUB_TOKEN"] != "ghp_plaintexttoken" || st.APIKeys[0].KeyHash[0] != '$' {
+		t.Error("Save modified the caller's state")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"ghp_plaintexttoken", "plain-header-key", "$argon2id$"} {
+		if bytes.Contains(data, []byte(secret)) {
+			t.Errorf("state.json contains %q in plaintext", secret)
+		}
+	}
+
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	u := loaded.Upstreams[0]
+	if u.Env["GITHUB_TOKEN"] != "ghp_plaintexttoken" || u.Env["HOME_DIR"] != "${env:HOME}" || u.Headers["X-Api-Key"] != "plain-header-key" {
+		t.Errorf("upstream = env %v, headers %v; want decrypted values", u.Env, u.Headers)
+	}
+	if loaded.APIKeys[0].KeyHash != st.APIKeys[0].KeyHash || loaded.AdminTokens[0].TokenHash != "$argon2id$token-hash" ||
+		loaded.AdminPasswordHash != "$argon2id$password-hash" {
+		t.Errorf("hashes not decrypted: %q, %q, %q", loaded.APIKeys[0].KeyHash, loaded.AdminTokens[0].TokenHash, loaded.AdminPasswordHash)
+	}
+}
+
+func TestFileStateStore_EncryptPlaintextSecrets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	plain := NewFileStateStore(path, testLogger())
+	st := plain.DefaultState()
+	st.APIKeys = append(st.APIKeys, APIKeyEntry{ID: "k1", Name: "ci", KeyHash: "$argon2id$plain-hash", IdentityID: "id1"})
+	// Two saves, so that the backup holds the plaintext hash as well.
+	for i := 0; i < 2; i++ {
+		if err := plain.Save(st); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+
+	store := NewFileStateStore(path, testLogger())
+	if n, err := store.EncryptPlaintextSecrets(); err != nil || n != 0 {
+		t.Errorf("EncryptPlaintextSecrets() without cipher = %d, %v; want 0, nil", n, err)
+	}
+	store.SetSecretCipher(testSecretCipher(t))
+	n, err := store.EncryptPlaintextSecrets()
+	if err != nil || n != 1 {
+		t.Fatalf("EncryptPlaintextSecrets() = %d, %v; want 1, nil", n, err)
+	}
+	for _, p := range []string{path, path + ".bak"} {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(data, []byte("plain-hash")) {
+			t.Errorf("%s still holds the plaintext hash", filepath.Base(p))
+		}
+	}
+	loaded, err := store.Load()
+	if err != nil || loaded.APIKeys[0].KeyHash != "$argon2id$plain-hash" {
+		t.Fatalf("Load after migration = %v, %v", loaded, err)
+	}
+	if n, err := store.EncryptPlaintextSecrets(); err != nil || n != 0 {
+		t.Errorf("second EncryptPlaintextSecrets() = %d, %v; want 0, nil", n, err)
+	}
+}
+
+func TestParseSecretKey(t *testing.T) {
+	if _, err := ParseSecretKey([]byte(" " + strings.Repeat("A", 43) + "=\n")); err != nil {
+		t.Errorf("ParseSecretKey(32-byte key) = %v", err)
+	}
+	if _, err := ParseSecretKey([]byte("c2hvcnQ=")); err == nil {
+		t.Error("ParseSecretKey(short key) = nil, want error")
+	}
+	if _, err := ParseSecretKey([]byte("not base64!")); err == nil {
+		t.Error("ParseSecretKey(invalid base64) = nil, want error")
+	}
+}
@@ -0,0 +1,81 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	snapshotPrefix = "state-"
+	snapshotSuffix = ".json"
+	// snapshotTimeFormat sorts lexically in time order.
+	snapshotTimeFormat = "20060102T150405Z"
+)
+
+// Snapshot copies the current state file into dir as
+// state-<UTC timestamp>.json and deletes the oldest snapshots beyond keep
+// (keep <= 0 keeps all). Returns the path of the new snapshot.
+func (s *FileStateStore) Snapshot(dir string, keep int) (string, error) {
+	s.mu.Lock()
+	data, err := os.ReadFile(s.path)
+	s.mu.Unlock()
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("no state file to snapshot at %s", s.path)
+		}
+		return "", fmt.Errorf("read state file: %w", err)
+	}
+	return writeSnapshot(dir, keep, data, s.logger)
+}
+
+// writeSnapshot writes data into dir as a new snapshot and prunes the
+// oldest ones beyond keep.
+func writeSnapshot(dir string, keep int, data []byte, logger *slog.Logger) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("create snapshot directory: %w", err)
+	}
+	name := snapshotPrefix + time.Now().UTC().Format(snapshotTimeFormat) + snapshotSuffix
+	path := filepath.Join(dir, name)
+	if err := writeFileAtomic(path, data); err != nil {
+		return "", fmt.Errorf("write snapshot: %w", err)
+	}
+
+	if keep > 0 {
+		if err := pruneSnapshots(dir, keep); err != nil {
+			logger.Warn("failed to prune state snapshots", "dir", dir, "error", err)
+		}
+	}
+	return path, nil
+}
+
+// pruneSnapshots deletes all but the newest keep snapshots in dir.
+func pruneSnapshots(dir string, keep int) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasPrefix(e.Name(), snapshotPrefix) && strings.HasSuffix(e.Name(), snapshotSuffix) {
+			names = append(names, e.Name())
+		}
+	}
+	if len(names) <= keep {
+		return nil
+	}
+	sort.Strings(names)
+	var errs []error
+	for _, name := range names[:len(names)-keep] {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
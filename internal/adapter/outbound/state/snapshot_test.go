@@ -0,0 +1,48 @@
+package state
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSnapshot_CopiesStateAndPrunes(t *testing.T) {
+	dir := t.TempDir()
+	s := NewFileStateStore(filepath.Join(dir, "state.json"), testLogger())
+	if _, err := s.Snapshot(filepath.Join(dir, "snapshots"), 2); err == nil {
+		t.Fatal("Snapshot without a state file should fail")
+	}
+	if err := s.Save(s.DefaultState()); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	snapDir := filepath.Join(dir, "snapshots")
+	if err := os.MkdirAll(snapDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	for _, old := range []string{"state-20200101T000000Z.json", "state-20200102T000000Z.json", "unrelated.json"} {
+		if err := os.WriteFile(filepath.Join(snapDir, old), []byte("{}"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path, err := s.Snapshot(snapDir, 2)
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	got, _ := os.ReadFile(path)
+	want, _ := os.ReadFile(filepath.Join(dir, "state.json"))
+	if !bytes.Equal(got, want) {
+		t.Error("snapshot content differs from state.json")
+	}
+
+	entries, _ := os.ReadDir(snapDir)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if len(names) != 3 || names[0] != "state-20200102T000000Z.json" || names[2] != "unrelated.json" {
+		t.Errorf("snapshot dir = %v, want newest 2 snapshots and the unrelated file", names)
+	}
+}
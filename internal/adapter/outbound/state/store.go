@@ -13,6 +13,39 @@ import (
 	"time"
 )
 
+// Store persists the AppState. FileStateStore keeps it in state.json;
+// PostgresStateStore keeps it in a PostgreSQL table that gateway replicas
+// share.
+type Store interface {
+	// Load returns the stored state, or DefaultState if none is stored.
+	Load() (*AppState, error)
+	// Save replaces the stored state with state.
+	Save(state *AppState) error
+	// Mutate atomically loads the state, applies fn and saves it. If fn
+	// returns an error the state is not saved.
+	Mutate(fn func(*AppState) error) error
+	// DefaultState returns the state of a first boot.
+	DefaultState() *AppState
+	// Exists reports whether a state is stored.
+	Exists() bool
+	// Path describes where the state is stored, for messages.
+	Path() string
+	// SetSecretCipher encrypts the stored secrets with c. Call it before
+	// the first Load.
+	SetSecretCipher(c *SecretCipher)
+	// EncryptPlaintextSecrets encrypts the secrets stored in plaintext and
+	// returns how many there were.
+	EncryptPlaintextSecrets() (int, error)
+	// Snapshot writes a copy of the stored state, in the state.json format,
+	// into dir and returns its path, keeping the newest keep copies.
+	Snapshot(dir string, keep int) (string, error)
+}
+
+var (
+	_ Store = (*FileStateStore)(nil)
+	_ Store = (*PostgresStateStore)(nil)
+)
+
 // FileStateStore manages reading and writing the state.json file.
 // It provides atomic writes (write-tmp-then-rename), automatic backups,
 // file locking (flock for cross-process, mutex for in-process), and
@@ -21,6 +54,9 @@ type FileStateStore struct {
 	path   string
 	mu     sync.Mutex
 	logger *slog.Logger
+	cipher *SecretCipher // encrypts secrets; nil stores them as is
+	// plaintext counts the secrets found unencrypted by the last load.
+	plaintext int
 }
 
 // NewFileStateStore creates a new FileStateStore for the given file path.
@@ -31,6 +67,15 @@ func NewFileStateStore(path string, logger *slog.Logger) *FileStateStore {
 	}
 }
 
+// SetSecretCipher encrypts the secrets written to state.json with c and
+// decrypts them on load. Without a cipher encrypted values are
+// kept as they are. Call it before the first Load.
+func (s *FileStateStore) SetSecretCipher(c *SecretCipher) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cipher = c
+}
+
 // Load reads and parses the state.json file.
 // If the file does not exist, it returns DefaultState().
 // If the file contains invalid JSON, it returns an error.
@@ -87,11 +132,18 @@ func (s *FileStateStore) loadLocked() (*AppState, error) {
 		s.logger.Warn("loaded state from backup file", "path", bakPath)
 		// M4: Signal that backup data was used so callers know it may be stale.
 		bakState.RestoredFromBackup = true
+		if err := s.decryptSecrets(&bakState); err != nil {
+			return nil, err
+		}
 		s.validateVersion(&bakState)
 		validateState(&bakState, s.logger)
 		return &bakState, nil
 	}
 
+	if err := s.decryptSecrets(&state); err != nil {
+		return nil, err
+	}
+
 	// L-43: Validate version field after loading state.
 	s.validateVersion(&state)
 	validateState(&state, s.logger)
@@ -144,8 +196,16 @@ func (s *FileStateStore) saveLocked(state *AppState) error {
 		}
 	}
 
+	// Encrypt secrets in a copy; the caller keeps the plaintext state.
+	onDisk := state
+	if s.cipher != nil {
+		if onDisk, err = s.cipher.encryptSecrets(state); err != nil {
+			return fmt.Errorf("encrypt state secrets: %w", err)
+		}
+	}
+
 	// Marshal state as indented JSON with trailing newline.
-	data, err := json.MarshalIndent(state, "", "  ")
+	data, err := json.MarshalIndent(onDisk, "", "  ")
 	if err != nil {
 		return fmt.Errorf("marshal state: %w", err)
 	}
@@ -182,6 +242,55 @@ func (s *FileStateStore) Mutate(fn func(*AppState) error) error {
 	return s.saveLocked(st)
 }
 
+// decryptSecrets decrypts the secrets of st when a cipher is set. Caller
+// must hold s.mu.
+func (s *FileStateStore) decryptSecrets(st *AppState) error {
+	if s.cipher == nil {
+		return nil
+	}
+	n, err := s.cipher.decryptSecrets(st)
+	if err != nil {
+		return fmt.Errorf("decrypt state secrets: %w", err)
+	}
+	s.plaintext = n
+	return nil
+}
+
+// EncryptPlaintextSecrets rewrites state.json with its secrets encrypted if
+// some are stored in plaintext, e.g. by a version that did not encrypt them
+// or a tool run without the key. It returns how many were encrypted. The
+// backup file is rewritten too, so that it keeps no plaintext copy. Without
+// a cipher it does nothing.
+func (s *FileStateStore) EncryptPlaintextSecrets() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cipher == nil {
+		return 0, nil
+	}
+	if _, err := os.Stat(s.path); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("stat state file: %w", err)
+	}
+	st, err := s.loadLocked()
+	if err != nil {
+		return 0, err
+	}
+	n := s.plaintext
+	if n == 0 || st.RestoredFromBackup {
+		return 0, nil
+	}
+	// Saving copies the current file to the backup: the second save
+	// replaces the plaintext copy with an encrypted one.
+	for i := 0; i < 2; i++ {
+		if err := s.saveLocked(st); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
 // writeAtomic writes data to s.path atomically.
 func (s *FileStateStore) writeAtomic(data []byte) error {
 	return writeFileAtomic(s.path, data)
@@ -235,6 +344,11 @@ func writeFileAtomic(targetPath string, data []byte) error {
 // - DefaultPolicy "deny" (deny-all until explicit allow rules are added)
 // - Empty slices for upstreams, identities, and API keys
 func (s *FileStateStore) DefaultState() *AppState {
+	return defaultState()
+}
+
+// defaultState returns the first-boot state shared by every Store.
+func defaultState() *AppState {
 	now := time.Now().UTC()
 	return &AppState{
 		Version:       "1",
@@ -251,15 +365,21 @@ func (s *FileStateStore) DefaultState() *AppState {
 // validateVersion checks the Version field of the loaded state.
 // L-43: If empty, set to "1". If unrecognized, log a warning for future migration support.
 func (s *FileStateStore) validateVersion(st *AppState) {
+	checkVersion(st, s.logger, s.path)
+}
+
+// checkVersion implements validateVersion for every Store; path says where
+// the state was loaded from.
+func checkVersion(st *AppState, logger *slog.Logger, path string) {
 	switch st.Version {
 	case "":
 		st.Version = "1"
-		s.logger.Info("state version was empty, set to \"1\"", "path", s.path)
+		logger.Info("state version was empty, set to \"1\"", "path", path)
 	case "1":
 		// Current version, nothing to do.
 	default:
-		s.logger.Warn("state.json has unrecognized version, proceeding with best effort",
-			"path", s.path, "version", st.Version)
+		logger.Warn("state has unrecognized version, proceeding with best effort",
+			"path", path, "version", st.Version)
 	}
 }
 
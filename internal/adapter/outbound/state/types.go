@@ -5,7 +5,10 @@
 // atomic writes, file locking, and backup functionality.
 package state
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // AppState is the top-level structure persisted in state.json.
 // It holds all runtime configuration that survives restarts.
@@ -33,7 +36,7 @@ type AppState struct {
 	PolicyEvaluations []PolicyEvaluationEntry `json:"policy_evaluations,omitempty"`
 
 	// ContentScanningConfig holds the response scanning configuration.
-	// Mode is "monitor" (default) or "enforce".
+	// Mode is "monitor" (default), "enforce" or "redact".
 	ContentScanningConfig *ContentScanningConfig `json:"content_scanning_config,omitempty"`
 
 	// ToolBaseline stores the tool schema baseline for drift detection.
@@ -42,6 +45,10 @@ type AppState struct {
 	// QuarantinedTools lists tool names that are currently quarantined.
 	QuarantinedTools []string `json:"quarantined_tools,omitempty"`
 
+	// ResponseBaselines stores the accepted canary results of tools watched
+	// by the response guard, keyed by tool name.
+	ResponseBaselines map[string]ResponseBaselineEntry `json:"response_baselines,omitempty"`
+
 	// Quotas are the per-identity quota configurations.
 	// Uses omitempty so existing state.json files without quotas load cleanly.
 	Quotas []QuotaConfigEntry `json:"quotas,omitempty"`
@@ -82,6 +89,38 @@ type AppState struct {
 	// Changes take effect after restart since the EvidenceService is not hot-reloadable.
 	EvidenceConfig *EvidenceConfigEntry `json:"evidence_config,omitempty"`
 
+	// OutboundLearning holds the outbound allowlist learning window and the
+	// destinations observed during it.
+	// Nil when learning has never been started (backward compatible).
+	OutboundLearning *OutboundLearningEntry `json:"outbound_learning,omitempty"`
+
+	// ArgumentProfiles holds the per-tool argument profiles learned by
+	// anomaly detection. Empty when anomaly detection never ran.
+	ArgumentProfiles []ArgumentProfileEntry `json:"argument_profiles,omitempty"`
+
+	// JobSchedules holds schedules of recurring jobs changed from the admin
+	// API, keyed by job name. They override the YAML config.
+	JobSchedules map[string]JobScheduleEntry `json:"job_schedules,omitempty"`
+
+	// PolicyVariables holds the policy variables set from the admin API,
+	// keyed by name. Rule conditions read them as vars.<name>.
+	PolicyVariables map[string]PolicyVariableEntry `json:"policy_variables,omitempty"`
+
+	// Notice holds the banner and terms returned with initialize, with
+	// per-identity overrides. Nil when no notice was ever set.
+	Notice *NoticeEntry `json:"notice,omitempty"`
+
+	// RateLimitOverrides holds the per-identity and per-tool rate limit
+	// overrides created from the admin API.
+	RateLimitOverrides []RateLimitOverrideEntry `json:"rate_limit_overrides,omitempty"`
+
+	// PolicyVersions holds the change history of the policies managed from
+	// the admin API, keyed by policy ID, oldest first.
+	PolicyVersions map[string][]PolicyVersionEntry `json:"policy_versions,omitempty"`
+
+	// AdminTokens are the scoped tokens for automating the admin API.
+	AdminTokens []AdminTokenEntry `json:"admin_tokens,omitempty"`
+
 	// RestoredFromBackup indicates that the state was loaded from the .bak
 	// file because the primary state.json was corrupt or unreadable.
 	// Callers should treat the data as potentially stale.
@@ -106,7 +145,7 @@ type UpstreamEntry struct {
 	// Name is the human-readable display name.
 	Name string `json:"name"`
 
-	// Type is the transport type: "stdio" or "http".
+	// Type is the transport type: "stdio", "http", "sse", "openapi", "shim" or "reverse".
 	Type string `json:"type"`
 
 	// Enabled indicates whether this upstream is active.
@@ -118,12 +157,44 @@ type UpstreamEntry struct {
 	// Args are the command-line arguments for stdio upstreams.
 	Args []string `json:"args,omitempty"`
 
-	// URL is the endpoint for HTTP upstreams.
+	// URL is the endpoint for HTTP upstreams, or the base URL override for
+	// openapi and shim upstreams.
 	URL string `json:"url,omitempty"`
 
-	// Env holds environment variables passed to stdio upstreams.
+	// Spec is the OpenAPI document URL or path for openapi upstreams, or the
+	// tool mapping URL or path for shim upstreams.
+	Spec string `json:"spec,omitempty"`
+
+	// Headers are added to every request of openapi and shim upstreams.
+	// Values are stored encrypted (see SecretCipher).
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Env holds environment variables passed to stdio upstreams. Values are
+	// stored encrypted (see SecretCipher).
 	Env map[string]string `json:"env,omitempty"`
 
+	// Lazy defers spawning a stdio upstream until it is first used.
+	Lazy bool `json:"lazy,omitempty"`
+
+	// Framing is the stdio message framing (auto, newline, content-length).
+	Framing string `json:"framing,omitempty"`
+
+	// RegistrationTokenHash is the SHA-256 of the registration token of a
+	// reverse upstream.
+	RegistrationTokenHash string `json:"registration_token_hash,omitempty"`
+
+	// Credentials authenticate the gateway to HTTP, SSE, openapi and shim
+	// upstreams. Plaintext secrets are stored encrypted (see SecretCipher).
+	Credentials *UpstreamCredentialsEntry `json:"credentials,omitempty"`
+
+	// ToolPrefix is prepended to the names of the upstream's tools.
+	ToolPrefix string `json:"tool_prefix,omitempty"`
+
+	// ToolInclude and ToolExclude are glob patterns selecting the tools of
+	// the upstream exposed to clients.
+	ToolInclude []string `json:"tool_include,omitempty"`
+	ToolExclude []string `json:"tool_exclude,omitempty"`
+
 	// CreatedAt is when this upstream was added.
 	CreatedAt time.Time `json:"created_at"`
 
@@ -131,6 +202,24 @@ type UpstreamEntry struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// UpstreamCredentialsEntry holds the credentials of an upstream. Token,
+// Password, HeaderValue and ClientSecret are encrypted on disk unless they
+// are ${env:NAME} references.
+type UpstreamCredentialsEntry struct {
+	// Type is "bearer", "basic", "header" or "oauth2_client_credentials".
+	Type string `json:"type"`
+
+	Token        string   `json:"token,omitempty"`
+	Username     string   `json:"username,omitempty"`
+	Password     string   `json:"password,omitempty"`
+	HeaderName   string   `json:"header_name,omitempty"`
+	HeaderValue  string   `json:"header_value,omitempty"`
+	TokenURL     string   `json:"token_url,omitempty"`
+	ClientID     string   `json:"client_id,omitempty"`
+	ClientSecret string   `json:"client_secret,omitempty"`
+	Scopes       []string `json:"scopes,omitempty"`
+}
+
 // PolicyEntry represents a single access control rule.
 type PolicyEntry struct {
 	// ID is the unique identifier.
@@ -172,9 +261,18 @@ type PolicyEntry struct {
 	// TimeoutAction specifies what to do when an approval request times out ("deny" or "allow").
 	TimeoutAction string `json:"timeout_action,omitempty"`
 
+	// ExemptScope is what an exempt_rate_limit rule exempts ("call", "session" or "identity").
+	ExemptScope string `json:"exempt_scope,omitempty"`
+
+	// ExemptDuration is how long a session or identity exemption lasts (e.g. "8h").
+	ExemptDuration string `json:"exempt_duration,omitempty"`
+
 	// HelpText is optional admin-provided guidance shown when this rule denies an action.
 	HelpText string `json:"help_text,omitempty"`
 
+	// HelpURL is an optional link shown when this rule denies an action.
+	HelpURL string `json:"help_url,omitempty"`
+
 	// Source identifies the origin of this rule (e.g., "template:read-only", "redteam").
 	Source string `json:"source,omitempty"`
 
@@ -199,6 +297,10 @@ type IdentityEntry struct {
 	// ReadOnly is true for identities sourced from YAML config.
 	ReadOnly bool `json:"read_only"`
 
+	// Access restricts when and from where the identity may connect.
+	// Nil means unrestricted.
+	Access *IdentityAccessEntry `json:"access,omitempty"`
+
 	// CreatedAt is when this identity was created.
 	CreatedAt time.Time `json:"created_at"`
 
@@ -206,6 +308,33 @@ type IdentityEntry struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// IdentityAccessEntry restricts an identity to time windows and source countries.
+type IdentityAccessEntry struct {
+	// Timezone is the IANA timezone the windows are evaluated in (default "UTC").
+	Timezone string `json:"timezone,omitempty"`
+
+	// Windows are the allowed periods. Empty means any time.
+	Windows []AccessWindowEntry `json:"windows,omitempty"`
+
+	// AllowCountries admits only clients from these ISO 3166-1 alpha-2 countries.
+	AllowCountries []string `json:"allow_countries,omitempty"`
+
+	// DenyCountries refuses clients from these countries.
+	DenyCountries []string `json:"deny_countries,omitempty"`
+}
+
+// AccessWindowEntry is a recurring period during which an identity may connect.
+type AccessWindowEntry struct {
+	// Days are weekday names ("mon", "tuesday", ...). Empty means every day.
+	Days []string `json:"days,omitempty"`
+
+	// Start is the "HH:MM" time the window opens.
+	Start string `json:"start"`
+
+	// End is the "HH:MM" time the window closes.
+	End string `json:"end"`
+}
+
 // PolicyEvaluationEntry represents a stored policy evaluation record.
 type PolicyEvaluationEntry struct {
 	// RequestID is the unique identifier for this evaluation.
@@ -250,7 +379,7 @@ type APIKeyEntry struct {
 	// ID is the unique identifier.
 	ID string `json:"id"`
 
-	// KeyHash is the Argon2id hash of the API key.
+	// KeyHash is the Argon2id hash of the API key, stored encrypted.
 	KeyHash string `json:"key_hash"`
 
 	// KeyPrefix stores the first 8 chars of the cleartext key for fast-path Argon2id lookup.
@@ -274,11 +403,45 @@ type APIKeyEntry struct {
 
 	// ReadOnly is true for keys sourced from YAML config.
 	ReadOnly bool `json:"read_only"`
+
+	// AllowedOrigins binds the key to browser origins (scheme://host[:port]).
+	// Empty means the key can be used from any allowed origin.
+	AllowedOrigins []string `json:"allowed_origins,omitempty"`
+}
+
+// AdminTokenEntry is a scoped admin API token. Unlike API keys, admin
+// tokens do not authenticate an identity on the MCP endpoint.
+type AdminTokenEntry struct {
+	// ID is the unique identifier.
+	ID string `json:"id"`
+
+	// Name is a human-readable display name, e.g. the CI pipeline using it.
+	Name string `json:"name"`
+
+	// TokenHash is the Argon2id hash of the token, stored encrypted.
+	TokenHash string `json:"token_hash"`
+
+	// TokenPrefix stores the first 12 chars of the cleartext token for lookup.
+	TokenPrefix string `json:"token_prefix"`
+
+	// Scopes are the permissions granted: "read-only", "policies:write",
+	// "upstreams:write", "audit:read".
+	Scopes []string `json:"scopes"`
+
+	// CreatedAt is when this token was created.
+	CreatedAt time.Time `json:"created_at"`
+
+	// ExpiresAt is when this token expires. Nil means it never expires.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	// Revoked indicates whether this token has been revoked.
+	Revoked bool `json:"revoked"`
 }
 
 // ContentScanningConfig configures the response content scanning feature.
 type ContentScanningConfig struct {
-	// Mode is "monitor" (log only) or "enforce" (block on detection).
+	// Mode is "monitor" (log only), "enforce" (block on detection) or
+	// "redact" (replace detections with placeholders).
 	// Default is "monitor" to avoid false positives disrupting workflows.
 	Mode string `json:"mode"`
 	// Enabled indicates whether content scanning is active.
@@ -291,10 +454,47 @@ type ContentScanningConfig struct {
 	Whitelist []ContentWhitelistEntry `json:"whitelist,omitempty"`
 	// PatternActions maps pattern type to action override (off/alert/mask/block).
 	PatternActions map[string]string `json:"pattern_actions,omitempty"`
+	// Detectors are the custom response scanning detectors added from the
+	// admin API.
+	Detectors []ScanDetectorEntry `json:"detectors,omitempty"`
+	// ArgumentScan configures scanning of tool call arguments for secrets,
+	// internal hostnames and prompt injection. Nil means disabled.
+	ArgumentScan *ArgumentScanConfig `json:"argument_scan,omitempty"`
 	// UpdatedAt is when the config was last changed.
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// ArgumentScanConfig is the persisted configuration of argument scanning.
+type ArgumentScanConfig struct {
+	// Enabled indicates whether argument scanning is active.
+	Enabled bool `json:"enabled"`
+	// Mode is "monitor", "enforce" or "redact", as for response scanning.
+	Mode string `json:"mode"`
+	// InternalDomains are the domain suffixes of internal hostnames; an
+	// empty list turns hostname detection off. Null means the defaults.
+	InternalDomains []string `json:"internal_domains"`
+}
+
+// ScanDetectorEntry is a persisted custom response scanning detector.
+type ScanDetectorEntry struct {
+	// ID uniquely identifies this entry.
+	ID string `json:"id"`
+	// Name identifies the detector in findings.
+	Name string `json:"name"`
+	// Pattern is the regular expression matched; empty when Keywords is set.
+	Pattern string `json:"pattern,omitempty"`
+	// Keywords are the literal strings matched, case-insensitively.
+	Keywords []string `json:"keywords,omitempty"`
+	// Severity is "info", "warning" or "critical".
+	Severity string `json:"severity"`
+	// Category groups the detector's findings.
+	Category string `json:"category"`
+	// CreatedAt is when this entry was created.
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt is when this entry was last changed.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
 // ContentWhitelistEntry is a persisted whitelist rule for content scanning.
 type ContentWhitelistEntry struct {
 	// ID uniquely identifies this entry.
@@ -309,6 +509,22 @@ type ContentWhitelistEntry struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// ResponseBaselineEntry is the accepted canary result of one guarded tool.
+type ResponseBaselineEntry struct {
+	// Compare is the comparison mode the fingerprint was taken with
+	// ("structure" or "hash").
+	Compare string `json:"compare"`
+	// ArgumentsHash identifies the canary arguments; a baseline taken with
+	// other arguments is replaced instead of compared.
+	ArgumentsHash string `json:"arguments_hash"`
+	// Fingerprint is the SHA-256 of the result structure or content.
+	Fingerprint string `json:"fingerprint"`
+	// Shape lists the result's JSON paths and types (structure mode only).
+	Shape []string `json:"shape,omitempty"`
+	// CapturedAt is when the baseline was taken or last accepted.
+	CapturedAt time.Time `json:"captured_at"`
+}
+
 // ToolBaselineEntry stores a snapshot of a tool's schema at baseline capture time.
 type ToolBaselineEntry struct {
 	// Name is the tool's unique identifier.
@@ -337,6 +553,8 @@ type QuotaConfigEntry struct {
 	MaxCallsPerDay int64 `json:"max_calls_per_day,omitempty"`
 	// ToolLimits are per-tool call limits.
 	ToolLimits map[string]int64 `json:"tool_limits,omitempty"`
+	// ToolWeights are the units of MaxCallsPerDay a call to each tool uses.
+	ToolWeights map[string]int64 `json:"tool_weights,omitempty"`
 	// Action is "deny" or "warn".
 	Action string `json:"action"`
 	// Enabled controls whether this quota is active.
@@ -489,3 +707,134 @@ type DriftConfigEntry struct {
 	// UpdatedAt is when the config was last changed.
 	UpdatedAt time.Time `json:"updated_at"`
 }
+
+// OutboundLearningEntry persists outbound allowlist learning across restarts.
+type OutboundLearningEntry struct {
+	// StartedAt is when the learning window was opened.
+	StartedAt time.Time `json:"started_at"`
+	// EndsAt is when the learning window closes.
+	EndsAt time.Time `json:"ends_at"`
+	// Stopped is true when learning was stopped before EndsAt.
+	Stopped bool `json:"stopped,omitempty"`
+	// Dropped counts observations discarded because the entry cap was reached.
+	Dropped int64 `json:"dropped,omitempty"`
+	// Observations are the destinations seen, one per identity, tool and host.
+	Observations []OutboundObservationEntry `json:"observations,omitempty"`
+}
+
+// OutboundObservationEntry is one destination observed during learning.
+type OutboundObservationEntry struct {
+	IdentityID   string    `json:"identity_id"`
+	IdentityName string    `json:"identity_name,omitempty"`
+	ToolName     string    `json:"tool_name"`
+	Host         string    `json:"host"`
+	Count        int64     `json:"count"`
+	FirstSeen    time.Time `json:"first_seen"`
+	LastSeen     time.Time `json:"last_seen"`
+}
+
+// ArgumentProfileEntry persists the argument profile of one tool.
+type ArgumentProfileEntry struct {
+	ToolName string `json:"tool_name"`
+	// Calls is the number of calls learned from.
+	Calls     int64     `json:"calls"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	// Args are the statistics of each argument, keyed by dotted path.
+	Args []ArgumentStatsEntry `json:"args,omitempty"`
+}
+
+// ArgumentStatsEntry is what a tool's profile learned about one argument.
+type ArgumentStatsEntry struct {
+	// Path is the argument's dotted path; "[]" stands for list elements.
+	Path string `json:"path"`
+	// Count is the number of values seen.
+	Count int64 `json:"count"`
+	// Values counts the distinct short string values, until there are
+	// too many of them to track (HighCardinality).
+	Values          map[string]int64 `json:"values,omitempty"`
+	HighCardinality bool             `json:"high_cardinality,omitempty"`
+	// Prefixes counts the directory prefixes of file path values.
+	Prefixes  map[string]int64 `json:"prefixes,omitempty"`
+	PathCount int64            `json:"path_count,omitempty"`
+	// Numeric values: count, range, mean and sum of squared deviations.
+	NumCount int64   `json:"num_count,omitempty"`
+	Min      float64 `json:"min,omitempty"`
+	Max      float64 `json:"max,omitempty"`
+	Mean     float64 `json:"mean,omitempty"`
+	M2       float64 `json:"m2,omitempty"`
+}
+
+// JobScheduleEntry is a recurring job schedule set from the admin API.
+type JobScheduleEntry struct {
+	// Schedule is a cron expression or descriptor.
+	Schedule string `json:"schedule"`
+	// Enabled is false when the job only runs when triggered by hand.
+	Enabled bool `json:"enabled"`
+	// UpdatedAt is when the schedule was last changed.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PolicyVariableEntry is a policy variable set from the admin API.
+type PolicyVariableEntry struct {
+	// Value is the JSON value of the variable.
+	Value json.RawMessage `json:"value"`
+	// Description says what the variable is for.
+	Description string `json:"description,omitempty"`
+	// UpdatedAt is when the value was last changed.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NoticeEntry is the notice returned to agents with the initialize result.
+type NoticeEntry struct {
+	// Banner is a short line such as an environment label.
+	Banner string `json:"banner,omitempty"`
+	// Terms is the usage policy text.
+	Terms string `json:"terms,omitempty"`
+	// Identities overrides the notice per identity ID.
+	Identities map[string]NoticeTextEntry `json:"identities,omitempty"`
+	// UpdatedAt is when the notice was last changed.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NoticeTextEntry is the notice text shown to one identity.
+type NoticeTextEntry struct {
+	Banner string `json:"banner,omitempty"`
+	Terms  string `json:"terms,omitempty"`
+}
+
+// RateLimitOverrideEntry is a rate limit override created from the admin API.
+type RateLimitOverrideEntry struct {
+	ID string `json:"id"`
+	// Identity is an identity ID or name (empty = every identity).
+	Identity string `json:"identity,omitempty"`
+	// Tool is a tool name or glob (empty = the identity's user rate).
+	Tool string `json:"tool,omitempty"`
+	// Rate and Burst are per minute.
+	Rate      int       `json:"rate"`
+	Burst     int       `json:"burst,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PolicyVersionEntry is one version of a policy in its change history.
+type PolicyVersionEntry struct {
+	// Version numbers the versions of a policy, starting at 1.
+	Version int `json:"version"`
+	// Change is "create", "update", "delete", "rollback", or "initial" for
+	// a policy that existed before its first recorded change.
+	Change string `json:"change"`
+	// RestoredVersion is the version a rollback restored.
+	RestoredVersion int `json:"restored_version,omitempty"`
+	// ChangedBy identifies who made the change.
+	ChangedBy string    `json:"changed_by,omitempty"`
+	ChangedAt time.Time `json:"changed_at"`
+
+	// The policy as of this version. For a deletion, the policy as it was
+	// when deleted. Rule names are stored without the policy name prefix.
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	Priority    int           `json:"priority,omitempty"`
+	Enabled     bool          `json:"enabled"`
+	Rules       []PolicyEntry `json:"rules"`
+}
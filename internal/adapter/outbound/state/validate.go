@@ -31,7 +31,7 @@ func validateState(st *AppState, logger *slog.Logger) {
 
 func validateContentScanningConfig(c *ContentScanningConfig, logger *slog.Logger) {
 	switch c.Mode {
-	case "", "monitor", "enforce":
+	case "", "monitor", "enforce", "redact":
 		// Valid (empty means not explicitly set, leave as-is).
 	default:
 		logger.Warn("invalid content scanning mode, resetting to monitor", "mode", c.Mode)
@@ -91,6 +91,12 @@ func validateQuotaEntry(q *QuotaConfigEntry, logger *slog.Logger) {
 			q.ToolLimits[name] = 0
 		}
 	}
+	for name, weight := range q.ToolWeights {
+		if weight < 0 {
+			logger.Warn("negative tool weight, resetting to 1", "identity_id", q.IdentityID, "tool", name)
+			q.ToolWeights[name] = 1
+		}
+	}
 }
 
 func validateRecordingConfig(c *RecordingConfigEntry, logger *slog.Logger) {
@@ -4,7 +4,6 @@
 // simplicity and file-based configuration. It intentionally excludes Pro and
 // Enterprise features:
 //
-//   - NO Redis session storage (in-memory only)
 //   - NO PostgreSQL for audit logs (stdout/file only)
 //   - NO SIEM integration (Splunk, Datadog)
 //   - NO Admin web interface
@@ -14,12 +13,14 @@
 //   - NO Multi-tenant support
 //   - NO Approval workflows (allow/deny only)
 //   - NO Framework context variables
-//   - NO TLS configuration (handle via reverse proxy)
 //
 // For Pro features, see the sentinel-gate-pro module.
 package config
 
-import "os"
+import (
+	"fmt"
+	"os"
+)
 
 // OSSConfig is the top-level configuration for Sentinel Gate OSS.
 // It contains only the essential fields for a minimalist MCP proxy.
@@ -32,10 +33,26 @@ type OSSConfig struct {
 	// In multi-upstream mode, upstreams are configured via state.json instead.
 	Upstream UpstreamConfig `yaml:"upstream" mapstructure:"upstream"`
 
+	// EgressTLS overrides certificate verification of the gateway's
+	// connections to HTTP and OpenAPI upstreams per destination domain.
+	EgressTLS EgressTLSConfig `yaml:"egress_tls" mapstructure:"egress_tls"`
+
+	// DNS sends the gateway's own DNS lookups to DNS-over-HTTPS or
+	// DNS-over-TLS servers instead of the system resolver.
+	DNS DNSConfig `yaml:"dns" mapstructure:"dns"`
+
 	// AuditFile configures the file-based audit persistence.
 	// Only used when audit output is "file://" or for structured file audit.
 	AuditFile AuditFileConfig `yaml:"audit_file" mapstructure:"audit_file"`
 
+	// AuditPayloads samples the full arguments and results of chosen tools
+	// into a payload store kept apart from the audit log.
+	AuditPayloads AuditPayloadsConfig `yaml:"audit_payloads" mapstructure:"audit_payloads"`
+
+	// AuditSQLite configures an indexed SQLite copy of the audit log that
+	// answers admin audit queries over long periods.
+	AuditSQLite AuditSQLiteConfig `yaml:"audit_sqlite" mapstructure:"audit_sqlite"`
+
 	// Auth configures file-based identities and API keys.
 	// Optional: when empty, only localhost admin UI access works (no API key auth).
 	// Identities and API keys can be created from the admin UI.
@@ -47,19 +64,210 @@ type OSSConfig struct {
 	// RateLimit configures optional rate limiting.
 	RateLimit RateLimitConfig `yaml:"rate_limit" mapstructure:"rate_limit"`
 
+	// DenyLoop configures the detection of deny loops: an identity retrying
+	// the same denied tool call over and over.
+	DenyLoop DenyLoopConfig `yaml:"deny_loop" mapstructure:"deny_loop"`
+
 	// Policies defines the access control rules.
 	// Optional: when empty, the server uses default-deny (no tool calls allowed).
 	// Policies can be managed from the admin UI.
 	Policies []PolicyConfig `yaml:"policies" mapstructure:"policies" validate:"omitempty,dive"`
 
+	// PolicyDirectory loads policies from a directory of YAML files and
+	// reloads them when the files change, for policies managed in git.
+	PolicyDirectory PolicyDirectoryConfig `yaml:"policy_directory" mapstructure:"policy_directory"`
+
 	// Evidence configures cryptographic audit evidence (Upgrade 1).
 	Evidence EvidenceConfig `yaml:"evidence" mapstructure:"evidence"`
 
 	// Webhook configures event webhook notifications.
 	Webhook WebhookConfig `yaml:"webhook" mapstructure:"webhook"`
 
-	rateLimitEnabledExplicit bool
-	evidenceEnabledExplicit  bool
+	// Events configures the event sinks other than webhooks.
+	Events EventsConfig `yaml:"events" mapstructure:"events"`
+
+	// TokenExchange configures end-user OAuth token passthrough to HTTP upstreams.
+	TokenExchange TokenExchangeConfig `yaml:"token_exchange" mapstructure:"token_exchange"`
+
+	// Watchdog configures detection of requests stalled inside the interceptor chain.
+	Watchdog WatchdogConfig `yaml:"watchdog" mapstructure:"watchdog"`
+
+	// SLO configures service level objectives and burn-rate alerting.
+	SLO SLOConfig `yaml:"slo" mapstructure:"slo"`
+
+	// GeoIP configures the local country database used by identity
+	// country restrictions.
+	GeoIP GeoIPConfig `yaml:"geoip" mapstructure:"geoip"`
+
+	// URLExtraction configures how destinations are found in tool call
+	// arguments for the dest_* policy variables.
+	URLExtraction URLExtractionConfig `yaml:"url_extraction" mapstructure:"url_extraction"`
+
+	// Scheduler configures the in-process scheduler for recurring admin jobs.
+	Scheduler SchedulerConfig `yaml:"scheduler" mapstructure:"scheduler"`
+
+	// SensitiveArguments configures encryption of sensitive tool arguments
+	// in audit records and session recordings.
+	SensitiveArguments SensitiveArgumentsConfig `yaml:"sensitive_arguments" mapstructure:"sensitive_arguments"`
+
+	// ResponseGuard periodically calls designated read-only tools and alerts
+	// when their results change unexpectedly.
+	ResponseGuard ResponseGuardConfig `yaml:"response_guard" mapstructure:"response_guard"`
+
+	// CostAccounting prices completed tool calls and aggregates usage per
+	// identity and day for chargeback.
+	CostAccounting CostAccountingConfig `yaml:"cost_accounting" mapstructure:"cost_accounting"`
+
+	// CEL limits the cost of policy conditions and the functions and macros
+	// they may use.
+	CEL CELConfig `yaml:"cel" mapstructure:"cel"`
+
+	// Policy selects the evaluator of policy decisions: the built-in CEL
+	// rules or Rego policies on an OPA server.
+	Policy PolicyEngineConfig `yaml:"policy" mapstructure:"policy"`
+
+	// Admission configures load shedding under memory or goroutine pressure.
+	Admission AdmissionConfig `yaml:"admission" mapstructure:"admission"`
+
+	// MCPMethods configures how MCP methods other than tool calls (ping,
+	// completion/complete, experimental methods) are handled.
+	MCPMethods MCPMethodsConfig `yaml:"mcp_methods" mapstructure:"mcp_methods"`
+
+	// UpstreamNotifications configures how notifications sent by upstreams
+	// (progress, log messages, resource updates) reach client connections.
+	UpstreamNotifications UpstreamNotificationsConfig `yaml:"upstream_notifications" mapstructure:"upstream_notifications"`
+
+	// TaintTracking follows content flagged by response scanning into the
+	// arguments of later calls of the same session (action_tainted in CEL).
+	TaintTracking TaintTrackingConfig `yaml:"taint_tracking" mapstructure:"taint_tracking"`
+
+	// AnomalyDetection profiles the arguments each tool receives and scores
+	// calls that deviate from the profile (anomaly.score in CEL).
+	AnomalyDetection AnomalyDetectionConfig `yaml:"anomaly_detection" mapstructure:"anomaly_detection"`
+
+	// ContentInspection bounds the content scanning of large tool
+	// arguments and results, such as multi-MB file contents.
+	ContentInspection ContentInspectionConfig `yaml:"content_inspection" mapstructure:"content_inspection"`
+
+	// Session selects where sessions are stored. A shared Redis store lets
+	// several gateway replicas serve the same sessions.
+	Session SessionConfig `yaml:"session" mapstructure:"session"`
+
+	// State selects where the state (upstreams, policies, identities, API
+	// keys and admin settings) is stored: state.json, or a PostgreSQL table
+	// that several gateway replicas share.
+	State StateConfig `yaml:"state" mapstructure:"state"`
+
+	// Approval configures how long approvals of approval_required rules
+	// wait and how long session and identity approvals last.
+	Approval ApprovalConfig `yaml:"approval" mapstructure:"approval"`
+
+	rateLimitEnabledExplicit      bool
+	evidenceEnabledExplicit       bool
+	watchdogEnabledExplicit       bool
+	urlExtractionEnabledExplicit  bool
+	urlExtractionCommandsExplicit bool
+	webhookRetriesExplicit        bool
+	schedulerEnabledExplicit      bool
+	denyLoopEnabledExplicit       bool
+}
+
+// URLExtractionConfig configures destination extraction from tool call
+// arguments. The first destination found fills dest_url, dest_domain,
+// dest_scheme, dest_port and dest_path; all of them are listed in dest_urls
+// and dest_domains.
+type URLExtractionConfig struct {
+	// Enabled turns extraction on or off. Defaults to true.
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+
+	// MaxDepth is how many levels of nested objects and arrays are
+	// searched. Defaults to 8.
+	MaxDepth int `yaml:"max_depth" mapstructure:"max_depth" validate:"omitempty,min=1,max=64"`
+
+	// BareDomains detects scheme-less domains ("evil.com/upload") in free
+	// text, limited to well-known TLDs. Defaults to false.
+	BareDomains bool `yaml:"bare_domains" mapstructure:"bare_domains"`
+
+	// CommandStrings detects host arguments of network clients (curl, wget,
+	// ssh, scp, git, nc, ...) in command-line strings. Defaults to true.
+	CommandStrings bool `yaml:"command_strings" mapstructure:"command_strings"`
+
+	// Tools holds per-tool hints, keyed by tool name or glob pattern.
+	Tools map[string]URLExtractionToolConfig `yaml:"tools" mapstructure:"tools"`
+}
+
+// URLExtractionToolConfig narrows destination extraction for one tool.
+type URLExtractionToolConfig struct {
+	// Fields limits the search to these dotted argument paths
+	// (e.g., "request.uri") and their children.
+	Fields []string `yaml:"fields" mapstructure:"fields"`
+
+	// Ignore skips these dotted argument paths and their children.
+	Ignore []string `yaml:"ignore" mapstructure:"ignore"`
+
+	// Disabled turns extraction off for the tool.
+	Disabled bool `yaml:"disabled" mapstructure:"disabled"`
+}
+
+// WatchdogConfig configures the interceptor chain stall watchdog.
+// In-flight requests are tracked per processing stage; a stage running past
+// its threshold is logged with a goroutine dump and published as a
+// "watchdog.stall" event.
+type WatchdogConfig struct {
+	// Enabled turns the watchdog on or off. Defaults to true.
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+
+	// CheckInterval is how often in-flight requests are inspected (e.g., "5s").
+	// Defaults to "5s".
+	CheckInterval string `yaml:"check_interval" mapstructure:"check_interval" validate:"omitempty"`
+
+	// Thresholds overrides the stall threshold per stage. Keys: normalize,
+	// policy, outbound_dns, approval, upstream. "0s" disables a stage.
+	// Defaults: normalize 1s, policy 5s, outbound_dns 5s, approval 10m, upstream 2m.
+	Thresholds map[string]string `yaml:"thresholds" mapstructure:"thresholds"`
+}
+
+// SLOConfig configures availability and latency SLOs for the gateway and
+// individual upstreams. Burn rates are computed in-process, exposed as
+// Prometheus metrics and in the admin API, and burn-rate violations are
+// published as "slo.burn_rate" events (delivered by the webhook).
+// The subsystem is off when no objectives are defined.
+type SLOConfig struct {
+	// Objectives lists the SLOs to track.
+	Objectives []SLOObjectiveConfig `yaml:"objectives" mapstructure:"objectives" validate:"omitempty,dive"`
+
+	// FastBurnThreshold is the burn rate that fires a critical alert when
+	// exceeded over both the 1h and 5m windows. Defaults to 14.4.
+	FastBurnThreshold float64 `yaml:"fast_burn_threshold" mapstructure:"fast_burn_threshold" validate:"gte=0"`
+
+	// SlowBurnThreshold is the burn rate that fires a warning alert when
+	// exceeded over both the 6h and 30m windows. Defaults to 6.
+	SlowBurnThreshold float64 `yaml:"slow_burn_threshold" mapstructure:"slow_burn_threshold" validate:"gte=0"`
+
+	// EvaluationInterval is how often burn rates are checked for alerts
+	// (e.g., "30s"). Defaults to "30s".
+	EvaluationInterval string `yaml:"evaluation_interval" mapstructure:"evaluation_interval"`
+}
+
+// SLOObjectiveConfig defines a single SLO.
+type SLOObjectiveConfig struct {
+	// Name identifies the SLO in metrics, alerts and events.
+	Name string `yaml:"name" mapstructure:"name" validate:"required"`
+
+	// Target is "gateway" for requests handled by the gateway itself, or the
+	// name of an upstream for tool calls forwarded to it.
+	Target string `yaml:"target" mapstructure:"target" validate:"required"`
+
+	// Type is "availability" (share of requests that did not fail) or
+	// "latency" (share of requests that succeeded within LatencyThreshold).
+	Type string `yaml:"type" mapstructure:"type" validate:"required,oneof=availability latency"`
+
+	// Objective is the target percentage of good requests (e.g., 99.9).
+	Objective float64 `yaml:"objective" mapstructure:"objective" validate:"gt=0,lt=100"`
+
+	// LatencyThreshold is the maximum duration of a good request (e.g., "500ms").
+	// Required for latency SLOs.
+	LatencyThreshold string `yaml:"latency_threshold" mapstructure:"latency_threshold"`
 }
 
 // WebhookConfig configures a single HTTP webhook for event notifications.
@@ -69,15 +277,146 @@ type WebhookConfig struct {
 	// Secret is an optional HMAC-SHA256 secret for signing payloads.
 	Secret string `yaml:"secret" mapstructure:"secret"`
 	// Events filters which event types trigger the webhook (empty = all).
+	// A trailing "*" matches a prefix, e.g. "upstream.*".
+	Events []string `yaml:"events" mapstructure:"events"`
+	// Categories filters which event categories trigger the webhook
+	// (empty = all): security, lifecycle, admin, audit_overflow, other.
+	Categories []string `yaml:"categories" mapstructure:"categories"`
+	// MinSeverity drops events below this severity: info (default),
+	// warning or critical.
+	MinSeverity string `yaml:"min_severity" mapstructure:"min_severity"`
+	// Retries is how many times a failed delivery is retried (network
+	// error, 429 or 5xx). Applies to every endpoint. Defaults to 3.
+	Retries int `yaml:"retries" mapstructure:"retries" validate:"min=0,max=10"`
+	// RetryBackoff is the delay before the first retry, doubled for each
+	// further attempt (e.g., "2s"). Defaults to "2s".
+	RetryBackoff string `yaml:"retry_backoff" mapstructure:"retry_backoff"`
+	// Endpoints are additional webhooks, each with its own URL, secret and
+	// event filter (e.g., upstream outages to an on-call pager).
+	Endpoints []WebhookEndpointConfig `yaml:"endpoints" mapstructure:"endpoints" validate:"dive"`
+}
+
+// WebhookEndpointConfig configures one additional webhook endpoint.
+type WebhookEndpointConfig struct {
+	// Name identifies the endpoint in the delivery log. Defaults to
+	// "endpoint-N" (1-based position in the list).
+	Name string `yaml:"name" mapstructure:"name"`
+	// URL is the HTTP endpoint to POST events to.
+	URL string `yaml:"url" mapstructure:"url" validate:"required"`
+	// Secret is an optional HMAC-SHA256 secret for signing payloads.
+	Secret string `yaml:"secret" mapstructure:"secret"`
+	// Events filters which event types are sent (empty = all).
 	Events []string `yaml:"events" mapstructure:"events"`
+	// Categories filters which event categories are sent (empty = all).
+	Categories []string `yaml:"categories" mapstructure:"categories"`
+	// MinSeverity drops events below this severity.
+	MinSeverity string `yaml:"min_severity" mapstructure:"min_severity"`
+}
+
+// EventsConfig configures the event sinks. Every event is shown in the
+// admin UI; webhooks are configured under webhook.
+type EventsConfig struct {
+	// Log writes matching events to the gateway log.
+	Log EventLogConfig `yaml:"log" mapstructure:"log"`
+	// SinkQueueSize is the number of events queued per sink before further
+	// events are dropped. Defaults to 256.
+	SinkQueueSize int `yaml:"sink_queue_size" mapstructure:"sink_queue_size" validate:"min=0"`
+}
+
+// EventLogConfig configures the log sink.
+type EventLogConfig struct {
+	// Enabled turns the log sink on.
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+	// Events filters which event types are logged (empty = all). A
+	// trailing "*" matches a prefix.
+	Events []string `yaml:"events" mapstructure:"events"`
+	// Categories filters which event categories are logged (empty = all).
+	Categories []string `yaml:"categories" mapstructure:"categories"`
+	// MinSeverity drops events below this severity.
+	MinSeverity string `yaml:"min_severity" mapstructure:"min_severity"`
+}
+
+// TokenExchangeConfig configures OAuth token passthrough and RFC 8693 token
+// exchange for HTTP upstreams that expect the end user's token.
+type TokenExchangeConfig struct {
+	// Enabled turns token passthrough on or off.
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+
+	// Header is the inbound request header carrying the user's token.
+	// A "Bearer " prefix is stripped. Defaults to "X-Upstream-Token".
+	Header string `yaml:"header" mapstructure:"header"`
+
+	// JWT verifies user tokens locally: the signature against the
+	// provider's published keys, the issuer, the audience and the lifetime.
+	JWT TokenExchangeJWTConfig `yaml:"jwt" mapstructure:"jwt"`
+
+	// IntrospectionURL is an RFC 7662 endpoint used to validate user tokens
+	// before they are forwarded or exchanged. When enabled, token exchange
+	// requires jwt.issuer, introspection_url or both.
+	IntrospectionURL string `yaml:"introspection_url" mapstructure:"introspection_url" validate:"omitempty,url"`
+
+	// TokenURL is the RFC 8693 token endpoint. Required when any upstream uses mode "exchange".
+	TokenURL string `yaml:"token_url" mapstructure:"token_url" validate:"omitempty,url"`
+
+	// ClientID and ClientSecret authenticate SentinelGate to the IdP (HTTP Basic).
+	ClientID     string `yaml:"client_id" mapstructure:"client_id"`
+	ClientSecret string `yaml:"client_secret" mapstructure:"client_secret"`
+
+	// CacheTTL bounds how long validated and exchanged tokens are cached (e.g., "5m").
+	// Tokens are never cached past their own expiry. Defaults to "5m".
+	CacheTTL string `yaml:"cache_ttl" mapstructure:"cache_ttl"`
+
+	// Upstreams binds upstreams (by name) to a passthrough or exchange mode.
+	// Upstreams not listed here never receive user tokens.
+	Upstreams []TokenExchangeUpstreamConfig `yaml:"upstreams" mapstructure:"upstreams" validate:"omitempty,dive"`
+}
+
+// TokenExchangeJWTConfig configures the local verification of JWT user
+// tokens by token exchange.
+type TokenExchangeJWTConfig struct {
+	// Issuer is the provider's issuer URL. Tokens must carry it in "iss",
+	// and the JWKS is discovered from its /.well-known/openid-configuration.
+	Issuer string `yaml:"issuer" mapstructure:"issuer" validate:"omitempty,url"`
+
+	// Audiences are the accepted "aud" values; a token must name at least one.
+	Audiences []string `yaml:"audiences" mapstructure:"audiences"`
+
+	// JWKSURL overrides the discovered signing key endpoint.
+	JWKSURL string `yaml:"jwks_url" mapstructure:"jwks_url" validate:"omitempty,url"`
+}
+
+// TokenExchangeUpstreamConfig binds one upstream to a token mode.
+type TokenExchangeUpstreamConfig struct {
+	// Upstream is the upstream name (or ID) as configured in the admin UI.
+	Upstream string `yaml:"upstream" mapstructure:"upstream" validate:"required"`
+
+	// Mode is "passthrough" (forward the user token) or "exchange" (RFC 8693).
+	Mode string `yaml:"mode" mapstructure:"mode" validate:"required,oneof=passthrough exchange"`
+
+	// Audience, Scope and Resource are sent with exchange requests.
+	Audience string `yaml:"audience" mapstructure:"audience"`
+	Scope    string `yaml:"scope" mapstructure:"scope"`
+	Resource string `yaml:"resource" mapstructure:"resource"`
 }
 
 // ServerConfig configures the HTTP server.
-// OSS version only supports HTTP (use a reverse proxy for TLS).
 type ServerConfig struct {
 	// HTTPAddr is the address to listen on (e.g., "127.0.0.1:8080", "0.0.0.0:8080").
+	// The host selects the address family: an IPv4 address (including
+	// 0.0.0.0) listens on IPv4 only, an IPv6 address (including [::]) on
+	// IPv6 only, and an empty host (":8080") on both.
 	// Defaults to "127.0.0.1:8080" (localhost only) if empty.
-	HTTPAddr string `yaml:"http_addr" mapstructure:"http_addr" validate:"omitempty,hostname_port"`
+	HTTPAddr string `yaml:"http_addr" mapstructure:"http_addr" validate:"omitempty,listen_addr"`
+
+	// ExtraHTTPAddrs are further addresses the HTTP server listens on, with
+	// the same rules as HTTPAddr (e.g., ["10.0.0.5:8080", "[fd00::5]:8080"]).
+	// Use them to expose the gateway on selected interfaces only.
+	ExtraHTTPAddrs []string `yaml:"extra_http_addrs" mapstructure:"extra_http_addrs" validate:"omitempty,dive,listen_addr"`
+
+	// ReusePort sets SO_REUSEPORT on the listening sockets so several
+	// processes can bind the same addresses, e.g. to start a new gateway
+	// before stopping the old one. Not supported on Windows. Defaults to false.
+	ReusePort bool `yaml:"reuse_port" mapstructure:"reuse_port"`
 
 	// LogLevel sets the minimum log level.
 	// Valid values: "debug", "info", "warn", "error".
@@ -87,6 +426,211 @@ type ServerConfig struct {
 	// SessionTimeout is the duration before sessions expire (e.g., "30m", "1h").
 	// Defaults to "30m" if not specified.
 	SessionTimeout string `yaml:"session_timeout" mapstructure:"session_timeout" validate:"omitempty"`
+
+	// MaxRequestBodySize is the maximum MCP POST body size in bytes.
+	// Bodies are parsed as they arrive and rejected as soon as they cross
+	// this limit; accepted bodies are buffered whole, so this also bounds the
+	// memory a request holds. Capped at 10MB (the proxy's per-message ceiling).
+	// Defaults to 1048576 (1MB) if not specified or 0.
+	MaxRequestBodySize int64 `yaml:"max_request_body_size" mapstructure:"max_request_body_size" validate:"omitempty,min=1024,max=10485760"`
+
+	// ToolProvenance controls how tool results report where they came from
+	// (serving upstream, request ID, scan verdict, policy rule, latency).
+	// Valid values: "off", "headers" (X-SentinelGate-* response headers, HTTP only),
+	// "meta" (result._meta["sentinelgate/provenance"]), "both".
+	// Defaults to "off" if empty.
+	ToolProvenance string `yaml:"tool_provenance" mapstructure:"tool_provenance" validate:"omitempty,oneof=off headers meta both"`
+
+	// LatencyBreakdown reports how much of a request's time went to the
+	// gateway (auth, policy, scanning) and how much to the upstream.
+	LatencyBreakdown LatencyBreakdownConfig `yaml:"latency_breakdown" mapstructure:"latency_breakdown"`
+
+	// Correlation gives every request a correlation ID, recorded in the
+	// audit log and optionally passed on to upstreams, so one agent trace
+	// can be followed through several gateways.
+	Correlation CorrelationConfig `yaml:"correlation" mapstructure:"correlation"`
+
+	// StdioFraming is how messages are delimited when the proxy itself is
+	// served over stdio ("start -- command"): "newline" (MCP default),
+	// "content-length" (LSP-style headers) or "auto" (answer in the framing
+	// the client uses). Defaults to "auto".
+	StdioFraming string `yaml:"stdio_framing" mapstructure:"stdio_framing" validate:"omitempty,oneof=auto newline content-length"`
+
+	// AllowedOrigins lists browser origins (scheme://host[:port]) allowed to
+	// call the MCP endpoint; CORS headers are returned for them. Requests with
+	// any other Origin header are rejected. Empty means browser requests are
+	// blocked (DNS rebinding protection).
+	AllowedOrigins []string `yaml:"allowed_origins" mapstructure:"allowed_origins"`
+
+	// MaxSubscriptionsPerIdentity caps the active resources/subscribe
+	// subscriptions one identity may hold across all of its sessions.
+	// Defaults to 100 if not specified or 0.
+	MaxSubscriptionsPerIdentity int `yaml:"max_subscriptions_per_identity" mapstructure:"max_subscriptions_per_identity" validate:"omitempty,min=0"`
+
+	// SSE configures framing of server-sent events on the MCP endpoint.
+	SSE SSEConfig `yaml:"sse" mapstructure:"sse"`
+
+	// WebSocket lets MCP clients upgrade a GET on the MCP endpoint to a
+	// single bidirectional WebSocket connection.
+	WebSocket WebSocketConfig `yaml:"websocket" mapstructure:"websocket"`
+
+	// Health configures how much the /health and /healthz endpoints reveal.
+	Health HealthEndpointConfig `yaml:"health" mapstructure:"health"`
+
+	// TLS serves HTTPS on every listen address. Without a certificate the
+	// server speaks plain HTTP (e.g. behind a TLS-terminating proxy).
+	TLS ServerTLSConfig `yaml:"tls" mapstructure:"tls"`
+
+	// AdminUI selects what is served at /admin/: the bundled UI, a UI bundle
+	// from a directory, or nothing (API-only mode).
+	AdminUI AdminUIConfig `yaml:"admin_ui" mapstructure:"admin_ui"`
+}
+
+// AdminUIConfig configures the admin web UI. The admin API under
+// /admin/api/ is served in every mode.
+type AdminUIConfig struct {
+	// Mode is "embedded" (the UI built into the binary), "directory" (the
+	// files in Directory, e.g. an organization's own console) or "disabled"
+	// (API-only mode). Defaults to "embedded".
+	Mode string `yaml:"mode" mapstructure:"mode" validate:"omitempty,oneof=embedded directory disabled"`
+
+	// Directory holds the UI bundle served in "directory" mode. It must
+	// contain index.html, which is also returned for paths matching no file
+	// so client-side routing works.
+	Directory string `yaml:"directory" mapstructure:"directory"`
+
+	// ContentSecurityPolicy replaces the Content-Security-Policy header sent
+	// with the files of a "directory" bundle. Defaults to the policy of the
+	// embedded UI, which only allows same-origin resources.
+	ContentSecurityPolicy string `yaml:"content_security_policy" mapstructure:"content_security_policy"`
+}
+
+// ServerTLSConfig configures HTTPS. The certificate files are checked for
+// changes and reloaded without a restart, so certificates rotated on disk
+// (e.g. by cert-manager or certbot) are picked up automatically.
+type ServerTLSConfig struct {
+	// CertFile is the PEM certificate chain. Requires KeyFile.
+	CertFile string `yaml:"cert_file" mapstructure:"cert_file"`
+
+	// KeyFile is the PEM private key. Requires CertFile.
+	KeyFile string `yaml:"key_file" mapstructure:"key_file"`
+
+	// ReloadInterval is how often the files are checked for changes
+	// (e.g., "1m"). Defaults to "1m".
+	ReloadInterval string `yaml:"reload_interval" mapstructure:"reload_interval"`
+}
+
+// LatencyBreakdownConfig selects how and to whom the latency breakdown of
+// requests is reported.
+type LatencyBreakdownConfig struct {
+	// Mode is "off", "headers" (Server-Timing response header, HTTP only),
+	// "meta" (result._meta["sentinelgate/timing"]) or "both".
+	// Defaults to "off".
+	Mode string `yaml:"mode" mapstructure:"mode" validate:"omitempty,oneof=off headers meta both"`
+
+	// Identities limits the breakdown to these identity IDs or names, e.g.
+	// the identities of agent developers. Empty reports it to every
+	// identity, which suits development setups.
+	Identities []string `yaml:"identities" mapstructure:"identities"`
+}
+
+// CorrelationConfig configures request correlation IDs. A request takes
+// its ID from Header (HTTP) or params._meta[MetaKey], otherwise a new one
+// is generated.
+type CorrelationConfig struct {
+	// Header is the HTTP header carrying the correlation ID, read from
+	// clients and sent to HTTP upstreams. Defaults to "X-Correlation-ID".
+	Header string `yaml:"header" mapstructure:"header"`
+
+	// MetaKey is the params._meta key carrying the correlation ID, read
+	// from clients and set on requests to upstreams. Defaults to
+	// "sentinelgate/correlation_id".
+	MetaKey string `yaml:"meta_key" mapstructure:"meta_key"`
+
+	// Propagate selects how the ID is passed to upstreams: "off",
+	// "header" (HTTP upstreams only), "meta" or "both". Defaults to "off".
+	Propagate string `yaml:"propagate" mapstructure:"propagate" validate:"omitempty,oneof=off header meta both"`
+}
+
+// HealthEndpointConfig sets the detail level of health responses.
+// Levels: "status" (only healthy/unhealthy), "summary" (component checks
+// without versions or upstream names) and "full" (everything).
+type HealthEndpointConfig struct {
+	// PublicDetail is the level for unauthenticated callers.
+	// Defaults to "status".
+	PublicDetail string `yaml:"public_detail" mapstructure:"public_detail" validate:"omitempty,oneof=status summary full"`
+
+	// AuthenticatedDetail is the level for callers presenting a valid API key
+	// as a Bearer token, and for localhost callers (the admin session).
+	// Defaults to "full".
+	AuthenticatedDetail string `yaml:"authenticated_detail" mapstructure:"authenticated_detail" validate:"omitempty,oneof=status summary full"`
+}
+
+// SSEConfig configures server-sent event framing on the MCP endpoint.
+type SSEConfig struct {
+	// MaxLineSize is the longest "data:" line written, in bytes. JSON messages
+	// are split into several data lines at token boundaries; a single JSON
+	// string longer than this stays on one line. Defaults to 8192.
+	MaxLineSize int `yaml:"max_line_size" mapstructure:"max_line_size" validate:"omitempty,min=256"`
+
+	// MaxEventSize is the largest message sent inline in one SSE event, in
+	// bytes. Larger POST responses are sent as plain JSON when the client
+	// accepts it; otherwise the message is kept for overflow_ttl and an
+	// "overflow" event points to GET /mcp/overflow/{id}. 0 means unlimited
+	// (the default).
+	MaxEventSize int64 `yaml:"max_event_size" mapstructure:"max_event_size" validate:"omitempty,min=0"`
+
+	// OverflowTTL is how long spilled messages can be fetched (e.g., "5m").
+	// Defaults to "5m".
+	OverflowTTL string `yaml:"overflow_ttl" mapstructure:"overflow_ttl" validate:"omitempty"`
+
+	// OverflowMaxBytes caps the memory held by spilled messages; the oldest
+	// are dropped first. Defaults to 67108864 (64MB).
+	OverflowMaxBytes int64 `yaml:"overflow_max_bytes" mapstructure:"overflow_max_bytes" validate:"omitempty,min=0"`
+
+	// Compression enables zstd/gzip compression of SSE streams and overflow
+	// fetches for clients that send a matching Accept-Encoding. Defaults to false.
+	Compression bool `yaml:"compression" mapstructure:"compression"`
+
+	// ReplayBufferSize is how many server-initiated events per session are
+	// kept so that a client reconnecting its GET stream with Last-Event-ID
+	// receives the ones it missed. Defaults to 100.
+	ReplayBufferSize int `yaml:"replay_buffer_size" mapstructure:"replay_buffer_size" validate:"omitempty,min=1,max=10000"`
+
+	// Queue limits the messages waiting on one SSE stream of a client that
+	// reads slower than they are produced.
+	Queue SSEQueueConfig `yaml:"queue" mapstructure:"queue"`
+}
+
+// SSEQueueConfig limits the outbound queue of an SSE stream.
+type SSEQueueConfig struct {
+	// MaxMessages is how many messages one stream queues. Defaults to 100.
+	MaxMessages int `yaml:"max_messages" mapstructure:"max_messages" validate:"omitempty,min=1,max=100000"`
+
+	// MaxBytes caps the bytes queued on one stream; a single larger message
+	// is still queued when the stream is idle. Defaults to 16777216 (16MB).
+	MaxBytes int64 `yaml:"max_bytes" mapstructure:"max_bytes" validate:"omitempty,min=0"`
+
+	// DropPolicy is applied when a message does not fit: "newest" drops it,
+	// "oldest" drops queued messages to make room, "close" closes the
+	// stream so the client reconnects and resumes with Last-Event-ID.
+	// Defaults to "newest".
+	DropPolicy string `yaml:"drop_policy" mapstructure:"drop_policy" validate:"omitempty,oneof=newest oldest close"`
+}
+
+// WebSocketConfig configures the WebSocket transport on the MCP endpoint.
+type WebSocketConfig struct {
+	// Enabled accepts "Upgrade: websocket" on GET requests to the MCP
+	// endpoint. Defaults to false.
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+
+	// PingInterval is how often idle connections are pinged (e.g., "30s").
+	// A client silent for two intervals is disconnected. Defaults to "30s".
+	PingInterval string `yaml:"ping_interval" mapstructure:"ping_interval" validate:"omitempty"`
+
+	// MaxInFlight caps the messages of one connection processed
+	// concurrently. Defaults to 16.
+	MaxInFlight int `yaml:"max_in_flight" mapstructure:"max_in_flight" validate:"omitempty,min=0"`
 }
 
 // UpstreamConfig configures the upstream MCP server.
@@ -104,6 +648,129 @@ type UpstreamConfig struct {
 	// HTTPTimeout is the timeout for HTTP requests to upstream (e.g., "30s", "1m").
 	// Defaults to "30s" if not specified.
 	HTTPTimeout string `yaml:"http_timeout" mapstructure:"http_timeout" validate:"omitempty"`
+
+	// LazyStartTimeout bounds how long a request waits for a lazy stdio
+	// upstream to start (e.g., "30s"). Defaults to "30s".
+	LazyStartTimeout string `yaml:"lazy_start_timeout" mapstructure:"lazy_start_timeout" validate:"omitempty"`
+
+	// LazyIdleTimeout is how long a lazy stdio upstream may go without
+	// requests before it is stopped (e.g., "10m"). "0s" disables idle shutdown.
+	// Defaults to "10m".
+	LazyIdleTimeout string `yaml:"lazy_idle_timeout" mapstructure:"lazy_idle_timeout" validate:"omitempty"`
+
+	// SecretDetection controls what happens when an upstream saved through
+	// the admin API has args, env values or a URL that look like plaintext
+	// credentials: "warn" saves it and reports the findings, "block" rejects
+	// it unless the secrets are converted to ${env:NAME} references, "off"
+	// disables detection. Defaults to "warn".
+	SecretDetection string `yaml:"secret_detection" mapstructure:"secret_detection" validate:"omitempty,oneof=off warn block"`
+
+	// CredentialsKeyPath is the file holding the base64-encoded AES-256 key
+	// that encrypts the secrets in state.json, unless the key is given by
+	// $SENTINEL_GATE_CREDENTIALS_KEY. If the file doesn't exist, a new key is
+	// generated. Defaults to "credentials-key" next to state.json.
+	CredentialsKeyPath string `yaml:"credentials_key_path" mapstructure:"credentials_key_path"`
+
+	// SchemaCompressMin is the size in bytes from which discovered tool
+	// input schemas are kept compressed in memory and inflated on demand.
+	// Useful for catalogs with thousands of tools. 0 (default) disables it.
+	SchemaCompressMin int `yaml:"schema_compress_min" mapstructure:"schema_compress_min" validate:"min=0"`
+
+	// DiscoveryMaxPages bounds the tools/list pages fetched from one upstream
+	// during discovery. When it is reached, the tools fetched so far are kept
+	// and the discovery is reported as incomplete. Defaults to 100.
+	DiscoveryMaxPages int `yaml:"discovery_max_pages" mapstructure:"discovery_max_pages" validate:"min=0"`
+
+	// ToolNamespacing selects when tools are exposed under a namespace:
+	// "conflicts" prefixes only tools whose name is shared by several
+	// upstreams ("upstream/tool"); "always" exposes every tool as
+	// "upstream.tool". Upstreams with a tool_prefix always use it.
+	// Defaults to "conflicts".
+	ToolNamespacing string `yaml:"tool_namespacing" mapstructure:"tool_namespacing" validate:"omitempty,oneof=conflicts always"`
+
+	// Framing is how messages are delimited on the stdio of Command:
+	// "newline", "content-length" or "auto" (newline until the server answers
+	// with Content-Length frames). Defaults to "auto". Upstreams added through
+	// the admin API set this per upstream.
+	Framing string `yaml:"framing" mapstructure:"framing" validate:"omitempty,oneof=auto newline content-length"`
+}
+
+// EgressTLSConfig configures how the certificates of upstream servers are
+// verified. Hosts without a matching destination are verified against the
+// system roots. A certificate that fails verification always fails the
+// connection and is recorded in the audit log.
+type EgressTLSConfig struct {
+	// Destinations lists per-domain overrides.
+	Destinations []EgressTLSDestinationConfig `yaml:"destinations" mapstructure:"destinations" validate:"omitempty,dive"`
+}
+
+// EgressTLSDestinationConfig overrides certificate verification for one
+// domain.
+type EgressTLSDestinationConfig struct {
+	// Domain is a host name ("api.example.com") or a wildcard matching
+	// subdomains at any depth ("*.corp.internal"). An exact domain takes
+	// precedence over wildcards, and the longest wildcard over shorter ones.
+	Domain string `yaml:"domain" mapstructure:"domain" validate:"required"`
+
+	// CAFile is a PEM bundle of CA certificates trusted for the domain
+	// instead of the system roots, e.g. an internal CA.
+	CAFile string `yaml:"ca_file" mapstructure:"ca_file"`
+
+	// SPKIPins are "sha256/<base64>" hashes of the SubjectPublicKeyInfo of
+	// the server, an intermediate or a root certificate. When set, the
+	// verified chain must contain one of them.
+	SPKIPins []string `yaml:"spki_pins" mapstructure:"spki_pins"`
+}
+
+// DNSConfig configures how the gateway resolves the host names it
+// connects to (upstreams, webhooks, token endpoints). Without servers the
+// system resolver is used.
+type DNSConfig struct {
+	// Servers are tried in order until one answers.
+	Servers []DNSServerConfig `yaml:"servers" mapstructure:"servers" validate:"omitempty,dive"`
+
+	// ResolveDestinations looks up the destination hosts of tool calls and
+	// HTTP requests before policy evaluation, so dest_ip holds their
+	// address and CIDR rules apply to named hosts. Off by default: each
+	// evaluated call with a named destination then costs a lookup.
+	ResolveDestinations bool `yaml:"resolve_destinations" mapstructure:"resolve_destinations"`
+
+	// Cache caches answers for their TTL, so repeated lookups of upstream
+	// and destination hosts cost no query.
+	Cache DNSCacheConfig `yaml:"cache" mapstructure:"cache"`
+
+	// RevalidateInterval is how often the host of a long-lived upstream
+	// connection (HTTP and SSE event streams) is resolved again. The
+	// connection is closed when the host starts resolving to an internal
+	// address (DNS rebinding). "0s" disables the checks. Defaults to "1m".
+	RevalidateInterval string `yaml:"revalidate_interval" mapstructure:"revalidate_interval"`
+}
+
+// DNSCacheConfig configures the DNS answer cache. Without dns.servers, the
+// gateway then queries the system name servers itself.
+type DNSCacheConfig struct {
+	// Enabled turns the cache on. Off by default.
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+
+	// MaxTTL caps how long an answer is cached, whatever its TTL.
+	// Defaults to "5m".
+	MaxTTL string `yaml:"max_ttl" mapstructure:"max_ttl"`
+
+	// NegativeTTL caps how long a name error or an empty answer is cached.
+	// "0s" disables negative caching. Defaults to "30s".
+	NegativeTTL string `yaml:"negative_ttl" mapstructure:"negative_ttl"`
+}
+
+// DNSServerConfig is one DNS-over-HTTPS or DNS-over-TLS server.
+type DNSServerConfig struct {
+	// URL is "https://host/path" for DNS-over-HTTPS or "tls://host[:port]"
+	// for DNS-over-TLS (port 853 by default).
+	URL string `yaml:"url" mapstructure:"url" validate:"required"`
+
+	// Bootstrap lists the IP addresses of the server, so reaching it does
+	// not depend on the system resolver. Required when the URL host is a
+	// name.
+	Bootstrap []string `yaml:"bootstrap" mapstructure:"bootstrap"`
 }
 
 // AuthConfig configures file-based authentication.
@@ -116,6 +783,50 @@ type AuthConfig struct {
 	// APIKeys defines the API keys that map to identities.
 	// Optional: can be managed from the admin UI instead.
 	APIKeys []APIKeyConfig `yaml:"api_keys" mapstructure:"api_keys" validate:"omitempty,dive"`
+
+	// OIDC accepts bearer tokens from an OpenID Connect provider next to
+	// API keys, so clients can authenticate with short-lived tokens.
+	OIDC OIDCConfig `yaml:"oidc" mapstructure:"oidc"`
+}
+
+// OIDCConfig configures validation of OIDC bearer tokens (JWTs) sent by MCP
+// clients instead of an API key. Each token maps to an identity whose roles
+// come from a token claim.
+type OIDCConfig struct {
+	// Enabled turns OIDC token validation on or off.
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+
+	// Issuer is the provider's issuer URL. Tokens must carry it in "iss",
+	// and the JWKS is discovered from its /.well-known/openid-configuration.
+	Issuer string `yaml:"issuer" mapstructure:"issuer" validate:"omitempty,url"`
+
+	// Audiences are the accepted "aud" values; a token must name at least one.
+	Audiences []string `yaml:"audiences" mapstructure:"audiences"`
+
+	// JWKSURL overrides the discovered signing key endpoint.
+	JWKSURL string `yaml:"jwks_url" mapstructure:"jwks_url" validate:"omitempty,url"`
+
+	// IdentityClaim names the claim identifying the user (default "sub").
+	// The identity ID is "oidc:" followed by its value.
+	IdentityClaim string `yaml:"identity_claim" mapstructure:"identity_claim"`
+
+	// NameClaim names the claim used as the display name. When empty,
+	// "name", "preferred_username" and "email" are tried in turn.
+	NameClaim string `yaml:"name_claim" mapstructure:"name_claim"`
+
+	// RolesClaim names the claim holding groups or roles (default "roles").
+	// Nested claims use dots, e.g. "realm_access.roles".
+	RolesClaim string `yaml:"roles_claim" mapstructure:"roles_claim"`
+
+	// RoleMappings maps values of the roles claim to SentinelGate roles.
+	// Values without a mapping are ignored.
+	RoleMappings map[string][]string `yaml:"role_mappings" mapstructure:"role_mappings"`
+
+	// DefaultRoles are given to every authenticated token.
+	DefaultRoles []string `yaml:"default_roles" mapstructure:"default_roles"`
+
+	// ClockSkew is the tolerance for exp, nbf and iat (default "1m").
+	ClockSkew string `yaml:"clock_skew" mapstructure:"clock_skew"`
 }
 
 // IdentityConfig defines a file-based identity.
@@ -128,6 +839,587 @@ type IdentityConfig struct {
 
 	// Roles are the roles assigned to this identity (used in policy evaluation).
 	Roles []string `yaml:"roles" mapstructure:"roles" validate:"required,min=1"`
+
+	// Access restricts when and from where this identity may connect.
+	// Optional: when nil, the identity is unrestricted.
+	Access *IdentityAccessConfig `yaml:"access" mapstructure:"access"`
+}
+
+// IdentityAccessConfig restricts an identity to time windows and source
+// countries, e.g. for contractors or region-restricted credentials.
+type IdentityAccessConfig struct {
+	// Timezone is the IANA timezone the windows are evaluated in (default "UTC").
+	Timezone string `yaml:"timezone" mapstructure:"timezone"`
+
+	// Windows are the allowed periods. Empty means any time.
+	Windows []AccessWindowConfig `yaml:"windows" mapstructure:"windows"`
+
+	// AllowCountries admits only clients from these ISO 3166-1 alpha-2
+	// countries. Requires geoip.database; unresolvable clients are refused.
+	AllowCountries []string `yaml:"allow_countries" mapstructure:"allow_countries"`
+
+	// DenyCountries refuses clients from these countries.
+	DenyCountries []string `yaml:"deny_countries" mapstructure:"deny_countries"`
+}
+
+// AccessWindowConfig is a recurring period during which an identity may connect.
+type AccessWindowConfig struct {
+	// Days are weekday names ("mon", "tuesday", ...). Empty means every day.
+	Days []string `yaml:"days" mapstructure:"days"`
+
+	// Start is the "HH:MM" time the window opens.
+	Start string `yaml:"start" mapstructure:"start"`
+
+	// End is the "HH:MM" time the window closes. An end before start spans midnight.
+	End string `yaml:"end" mapstructure:"end"`
+}
+
+// GeoIPConfig configures IP to country and autonomous system resolution,
+// for identity country restrictions and outbound destinations.
+type GeoIPConfig struct {
+	// Database is the path to a MaxMind DB (.mmdb) country database, such
+	// as GeoLite2-Country or DB-IP Country Lite.
+	Database string `yaml:"database" mapstructure:"database"`
+
+	// ASNDatabase is the path to a MaxMind DB autonomous system database,
+	// such as GeoLite2-ASN, used to locate outbound destinations.
+	ASNDatabase string `yaml:"asn_database" mapstructure:"asn_database"`
+}
+
+// SchedulerConfig configures the in-process scheduler that runs recurring
+// admin jobs (audit archival, API key expiry notifications, compliance
+// reports, state snapshots and upstream conformance checks).
+type SchedulerConfig struct {
+	// Enabled turns the scheduler on or off. Defaults to true.
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+
+	// Jobs overrides the schedule of built-in jobs, keyed by job name.
+	// Schedules changed from the admin API take precedence.
+	Jobs map[string]SchedulerJobConfig `yaml:"jobs" mapstructure:"jobs"`
+
+	// SnapshotDir is where the state-snapshot job writes copies of
+	// state.json. Defaults to a "snapshots" directory next to state.json.
+	SnapshotDir string `yaml:"snapshot_dir" mapstructure:"snapshot_dir"`
+
+	// SnapshotKeep is how many state snapshots are kept. Defaults to 7.
+	SnapshotKeep int `yaml:"snapshot_keep" mapstructure:"snapshot_keep" validate:"gte=0,lte=1000"`
+
+	// ReportDir is where the compliance-report job writes bundles.
+	// Defaults to a "reports" directory next to state.json.
+	ReportDir string `yaml:"report_dir" mapstructure:"report_dir"`
+
+	// ReportKeep is how many reports are kept per compliance pack.
+	// Defaults to 12.
+	ReportKeep int `yaml:"report_keep" mapstructure:"report_keep" validate:"gte=0,lte=1000"`
+
+	// KeyExpiryWarning is how long before expiry an API key is reported by
+	// the key-expiry job (e.g., "168h"). Defaults to "168h".
+	KeyExpiryWarning string `yaml:"key_expiry_warning" mapstructure:"key_expiry_warning"`
+}
+
+// SchedulerJobConfig overrides one built-in job.
+type SchedulerJobConfig struct {
+	// Schedule is a five-field cron expression ("0 3 * * *"), a descriptor
+	// (@hourly, @daily, @weekly, @monthly) or "@every <duration>".
+	// Cron schedules use UTC.
+	Schedule string `yaml:"schedule" mapstructure:"schedule"`
+
+	// Enabled turns the job's schedule on or off. Unset keeps the job's
+	// default. Disabled jobs can still be run by hand.
+	Enabled *bool `yaml:"enabled" mapstructure:"enabled"`
+}
+
+// AuditPayloadsConfig configures payload sampling: the full (redacted)
+// arguments and result of a share of the calls to chosen tools are stored
+// with their own retention, linked to their audit records by request ID.
+type AuditPayloadsConfig struct {
+	// Dir is the directory of the payload files. Defaults to "payloads"
+	// next to state.json.
+	Dir string `yaml:"dir" mapstructure:"dir"`
+
+	// RetentionDays is the number of days payload files are kept.
+	// Defaults to 30.
+	RetentionDays int `yaml:"retention_days" mapstructure:"retention_days" validate:"omitempty,min=1"`
+
+	// MaxPayloadBytes caps the arguments and the result of one sample; a
+	// larger part is left out and the sample marked truncated. Defaults to
+	// 1 MiB.
+	MaxPayloadBytes int `yaml:"max_payload_bytes" mapstructure:"max_payload_bytes" validate:"omitempty,min=1"`
+
+	// Tools sets the sampling rate per tool; the first matching entry
+	// applies. Sampling is off when empty.
+	Tools []AuditPayloadToolConfig `yaml:"tools" mapstructure:"tools" validate:"omitempty,dive"`
+}
+
+// AuditPayloadToolConfig sets the payload sampling rate of one tool.
+type AuditPayloadToolConfig struct {
+	// Tool is a tool name or glob pattern ("payments_refund", "read_*").
+	Tool string `yaml:"tool" mapstructure:"tool" validate:"required"`
+
+	// Rate is the fraction of calls sampled: 1 samples every call, 0.01
+	// one in a hundred, 0 none.
+	Rate float64 `yaml:"rate" mapstructure:"rate" validate:"min=0,max=1"`
+}
+
+// SensitiveArgumentsConfig marks tool arguments that must not be stored in
+// the clear. Their values are encrypted with a dedicated key in audit
+// records and session recordings, masked in the admin UI and API, and passed
+// to the upstream unchanged.
+type SensitiveArgumentsConfig struct {
+	// KeyPath is the file holding the base64-encoded AES-256 key. If the
+	// file doesn't exist, a new key is generated. Defaults to
+	// "argument-key" next to state.json.
+	KeyPath string `yaml:"key_path" mapstructure:"key_path"`
+
+	// Tools lists the sensitive argument paths per tool. Encryption is off
+	// when empty.
+	Tools []SensitiveToolArgsConfig `yaml:"tools" mapstructure:"tools" validate:"omitempty,dive"`
+}
+
+// SensitiveToolArgsConfig lists the sensitive arguments of one tool.
+type SensitiveToolArgsConfig struct {
+	// Tool is a tool name or glob pattern ("deploy", "github_*").
+	Tool string `yaml:"tool" mapstructure:"tool" validate:"required"`
+
+	// Paths are dot-separated argument paths ("token", "auth.password").
+	// A "*" segment matches every key of an object or element of an array.
+	Paths []string `yaml:"paths" mapstructure:"paths" validate:"required,min=1,dive,required"`
+}
+
+// ResponseGuardConfig configures canary calls to idempotent read-only tools.
+// Each result is compared with a baseline taken from the first call; the
+// calls run as the "response-guard" scheduler job.
+type ResponseGuardConfig struct {
+	// Tools lists the guarded tools. The guard is off when empty.
+	Tools []ResponseGuardToolConfig `yaml:"tools" mapstructure:"tools" validate:"omitempty,dive"`
+}
+
+// ResponseGuardToolConfig configures the canary call of one tool.
+type ResponseGuardToolConfig struct {
+	// Tool is the exact tool name as listed by tools/list.
+	Tool string `yaml:"tool" mapstructure:"tool" validate:"required"`
+
+	// Arguments is a JSON object sent with every canary call, e.g.
+	// '{"baseCurrency": "EUR"}'. It is a string because map keys in the
+	// config file lose their case.
+	Arguments string `yaml:"arguments" mapstructure:"arguments"`
+
+	// Compare selects what must stay the same: "structure" (JSON paths and
+	// value types) or "hash" (the exact result). Defaults to "structure".
+	Compare string `yaml:"compare" mapstructure:"compare" validate:"omitempty,oneof=structure hash"`
+
+	// Threshold is the number of consecutive changed results that raises an
+	// alert. Defaults to 1.
+	Threshold int `yaml:"threshold" mapstructure:"threshold" validate:"omitempty,min=0"`
+
+	// Quarantine quarantines the tool when an alert is raised.
+	Quarantine bool `yaml:"quarantine" mapstructure:"quarantine"`
+}
+
+// CostAccountingConfig configures per-call cost accounting. Every tool call
+// that reaches an upstream is priced by the cost table and the cost webhook
+// and summed per identity and UTC day (GET /admin/api/costs).
+type CostAccountingConfig struct {
+	// Enabled turns cost accounting on.
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+
+	// Currency labels the costs in reports. Defaults to "USD".
+	Currency string `yaml:"currency" mapstructure:"currency"`
+
+	// Table prices calls with the first matching rate. Calls matching no
+	// rate cost nothing (unless the webhook prices them).
+	Table []CostRateConfig `yaml:"table" mapstructure:"table" validate:"omitempty,dive"`
+
+	// Webhook receives every call and may answer with its cost.
+	Webhook CostWebhookConfig `yaml:"webhook" mapstructure:"webhook"`
+}
+
+// CELConfig limits policy conditions, which admins can submit through the
+// admin API. The cost limit and timeout apply to every evaluation; banned
+// functions, banned macros and the complexity limit are checked when a
+// condition is submitted, linted or replayed.
+type CELConfig struct {
+	// CostLimit is the runtime cost budget of one evaluation. Evaluations
+	// exceeding it fail (fail-closed). Defaults to 100000.
+	CostLimit int `yaml:"cost_limit" mapstructure:"cost_limit" validate:"min=0"`
+
+	// EvalTimeout bounds the wall time of one evaluation. Defaults to "5s".
+	EvalTimeout string `yaml:"eval_timeout" mapstructure:"eval_timeout"`
+
+	// MaxComplexity rejects conditions whose estimated worst-case cost is
+	// higher. Defaults to 1000000.
+	MaxComplexity int `yaml:"max_complexity" mapstructure:"max_complexity" validate:"min=0"`
+
+	// BannedFunctions are functions conditions may not call
+	// (e.g., "matches", "session_sequence").
+	BannedFunctions []string `yaml:"banned_functions" mapstructure:"banned_functions"`
+
+	// BannedMacros are macros conditions may not use: has, all, exists,
+	// exists_one, map, filter.
+	BannedMacros []string `yaml:"banned_macros" mapstructure:"banned_macros"`
+}
+
+// PolicyEngineConfig selects the policy evaluator.
+type PolicyEngineConfig struct {
+	// Engine is "cel" (default) or "rego".
+	Engine string `yaml:"engine" mapstructure:"engine" validate:"omitempty,oneof=cel rego"`
+
+	// Rego configures the OPA server evaluating decisions when Engine is
+	// "rego".
+	Rego RegoConfig `yaml:"rego" mapstructure:"rego"`
+
+	// Enrichment fetches extra attributes about each call from an internal
+	// endpoint before evaluation, exposed to conditions as enrichment.
+	Enrichment PolicyEnrichmentConfig `yaml:"enrichment" mapstructure:"enrichment"`
+}
+
+// PolicyEnrichmentConfig configures the policy enrichment lookup. Every
+// evaluated call POSTs the identity, the tool and its destination to URL,
+// which answers with a JSON object of attributes.
+type PolicyEnrichmentConfig struct {
+	// URL is the enrichment endpoint. Empty disables enrichment.
+	URL string `yaml:"url" mapstructure:"url"`
+
+	// Token is sent as a bearer token when set.
+	Token string `yaml:"token" mapstructure:"token"`
+
+	// Timeout bounds one lookup. Defaults to "500ms".
+	Timeout string `yaml:"timeout" mapstructure:"timeout"`
+
+	// CacheTTL is how long the answer to an identical lookup is reused.
+	// "0s" disables caching. Defaults to "5m".
+	CacheTTL string `yaml:"cache_ttl" mapstructure:"cache_ttl"`
+
+	// Arguments are the names of the tool arguments sent with the lookup
+	// (e.g., "bucket"). Other arguments are never sent.
+	Arguments []string `yaml:"arguments" mapstructure:"arguments"`
+
+	// FailClosed denies calls when the lookup fails. By default they are
+	// evaluated with an empty enrichment map.
+	FailClosed bool `yaml:"fail_closed" mapstructure:"fail_closed"`
+}
+
+// RegoConfig configures policy evaluation by an OPA server through its REST
+// API.
+type RegoConfig struct {
+	// URL is the OPA server. Defaults to "http://127.0.0.1:8181".
+	URL string `yaml:"url" mapstructure:"url"`
+
+	// Decision is the path of the decision document under data
+	// (e.g., "sentinelgate/decision"). Defaults to "sentinelgate/decision".
+	Decision string `yaml:"decision" mapstructure:"decision"`
+
+	// Token is sent as a bearer token when the OPA server requires one.
+	Token string `yaml:"token" mapstructure:"token"`
+
+	// Timeout bounds one decision request. Defaults to "2s".
+	Timeout string `yaml:"timeout" mapstructure:"timeout"`
+
+	// BundleDir is an optional directory of .rego and data.json files
+	// pushed to the OPA server and pushed again when they change. Leave it
+	// empty when OPA loads its bundles itself.
+	BundleDir string `yaml:"bundle_dir" mapstructure:"bundle_dir"`
+
+	// ReloadInterval is how often BundleDir is checked for changes.
+	// Defaults to "5s".
+	ReloadInterval string `yaml:"reload_interval" mapstructure:"reload_interval"`
+}
+
+// AdmissionConfig configures admission control. While process memory or
+// the goroutine count is above its threshold, list requests and progress/log
+// notifications on /mcp are refused with 503 and Retry-After; tool calls and
+// the admin API are served as usual. Shedding stops once both are below 90%
+// of their thresholds.
+type AdmissionConfig struct {
+	// Enabled turns admission control on.
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+
+	// MemoryThresholdMB is the Go runtime memory in megabytes above which
+	// traffic is shed. 0 disables the memory signal.
+	MemoryThresholdMB int `yaml:"memory_threshold_mb" mapstructure:"memory_threshold_mb" validate:"min=0"`
+
+	// GoroutineThreshold is the goroutine count above which traffic is
+	// shed. 0 disables the goroutine signal.
+	GoroutineThreshold int `yaml:"goroutine_threshold" mapstructure:"goroutine_threshold" validate:"min=0"`
+
+	// CheckInterval is how often memory and goroutines are sampled.
+	// Defaults to "1s".
+	CheckInterval string `yaml:"check_interval" mapstructure:"check_interval"`
+
+	// RetryAfter is sent to shed clients. Defaults to "5s".
+	RetryAfter string `yaml:"retry_after" mapstructure:"retry_after"`
+}
+
+// MCPMethodsConfig configures the handling of MCP methods that are not
+// tool calls. Each method is handled by the first matching rule; methods
+// matching no rule keep their built-in handling (ping is answered by the
+// gateway, resources/*, prompts/*, completion/complete and logging/setLevel
+// are forwarded) and methods outside the MCP specification use
+// UnknownAction. initialize, tools/list, tools/call, resources/subscribe,
+// resources/unsubscribe and notifications cannot be configured.
+type MCPMethodsConfig struct {
+	// UnknownAction handles methods outside the MCP specification: "deny"
+	// (default), "forward" or "route".
+	UnknownAction string `yaml:"unknown_action" mapstructure:"unknown_action"`
+
+	// UnknownUpstream is the upstream name for UnknownAction "route".
+	UnknownUpstream string `yaml:"unknown_upstream" mapstructure:"unknown_upstream"`
+
+	// Rules are checked in order.
+	Rules []MCPMethodRuleConfig `yaml:"rules" mapstructure:"rules"`
+}
+
+// UpstreamNotificationsConfig configures the delivery of upstream
+// notifications. Each notification is handled by the first matching rule;
+// notifications matching no rule are forwarded to every session.
+type UpstreamNotificationsConfig struct {
+	// Rules are checked in order.
+	Rules []UpstreamNotificationRuleConfig `yaml:"rules" mapstructure:"rules"`
+}
+
+// UpstreamNotificationRuleConfig sets the delivery of matching
+// notifications.
+type UpstreamNotificationRuleConfig struct {
+	// Upstream limits the rule to the upstream with this name. Empty
+	// matches every upstream.
+	Upstream string `yaml:"upstream" mapstructure:"upstream"`
+
+	// Method is a glob matched against the notification method (e.g.,
+	// "notifications/message", "notifications/resources/*"); "*" does not
+	// match "/".
+	Method string `yaml:"method" mapstructure:"method"`
+
+	// Action is "forward" (every session, on one of its connections),
+	// "latest" (only the most recently opened connection of the session
+	// whose request was in flight) or "drop".
+	Action string `yaml:"action" mapstructure:"action"`
+}
+
+// TaintTrackingConfig configures argument provenance tracking. Tool results
+// flagged by response scanning are fingerprinted per session; a later tool
+// call or outbound request whose arguments or destination contain that
+// content has action_tainted set to true for policy evaluation.
+type TaintTrackingConfig struct {
+	// Enabled turns taint tracking on. Defaults to false.
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+
+	// WindowSize is the length in characters of the fingerprinted windows;
+	// about 1.5 windows of copied content are needed for a match. Flagged
+	// matches shorter than a window are tracked as they are. Defaults to 32.
+	WindowSize int `yaml:"window_size" mapstructure:"window_size" validate:"omitempty,min=8,max=1024"`
+
+	// MaxFingerprintsPerSession caps the fingerprints kept per session.
+	// Defaults to 4096.
+	MaxFingerprintsPerSession int `yaml:"max_fingerprints_per_session" mapstructure:"max_fingerprints_per_session" validate:"omitempty,min=0"`
+}
+
+// AnomalyDetectionConfig configures argument anomaly detection. Every tool
+// builds a profile of the arguments of the calls policy allowed: the values
+// of low-cardinality arguments, numeric ranges and path prefixes. Once the
+// profile is past its warm-up, each call is scored against it; calls at or
+// above the threshold emit an anomaly.detected event.
+type AnomalyDetectionConfig struct {
+	// Enabled turns anomaly detection on. Defaults to false.
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+
+	// WarmupCalls is how many calls a tool's profile learns from before
+	// calls are scored. Defaults to 100.
+	WarmupCalls int `yaml:"warmup_calls" mapstructure:"warmup_calls" validate:"omitempty,min=1"`
+
+	// WarmupPeriod is how long after the first call of a tool its profile
+	// keeps learning before calls are scored, whatever the call count
+	// (e.g. "168h"). Defaults to "24h".
+	WarmupPeriod string `yaml:"warmup_period" mapstructure:"warmup_period"`
+
+	// Threshold is the score, between 0 and 1, at or above which a call
+	// emits an anomaly.detected event. Defaults to 0.8.
+	Threshold float64 `yaml:"threshold" mapstructure:"threshold" validate:"omitempty,gt=0,lte=1"`
+
+	// Tools override the settings for the tools matching a glob pattern;
+	// the first matching entry applies.
+	Tools []AnomalyToolConfig `yaml:"tools" mapstructure:"tools"`
+}
+
+// AnomalyToolConfig overrides anomaly detection settings for some tools.
+// Zero fields keep the global setting.
+type AnomalyToolConfig struct {
+	// Tool is a glob pattern matched against tool names.
+	Tool string `yaml:"tool" mapstructure:"tool"`
+
+	// Disabled turns anomaly detection off for the matching tools.
+	Disabled bool `yaml:"disabled" mapstructure:"disabled"`
+
+	// WarmupCalls overrides anomaly_detection.warmup_calls.
+	WarmupCalls int `yaml:"warmup_calls" mapstructure:"warmup_calls" validate:"omitempty,min=1"`
+
+	// WarmupPeriod overrides anomaly_detection.warmup_period.
+	WarmupPeriod string `yaml:"warmup_period" mapstructure:"warmup_period"`
+
+	// Threshold overrides anomaly_detection.threshold.
+	Threshold float64 `yaml:"threshold" mapstructure:"threshold" validate:"omitempty,gt=0,lte=1"`
+}
+
+// ContentInspectionConfig configures how content scanning handles large
+// payloads. Strings longer than a chunk are scanned chunk by chunk, each
+// chunk sharing Overlap bytes with the previous one so that matches across
+// a chunk boundary are found; scanning stops at MaxScanBytes.
+type ContentInspectionConfig struct {
+	// ChunkSize is the size in bytes of the scanned chunks. Defaults to
+	// 262144 (256 KiB).
+	ChunkSize int `yaml:"chunk_size" mapstructure:"chunk_size" validate:"omitempty,min=4096"`
+
+	// Overlap is how many bytes consecutive chunks share, which is also the
+	// longest match found across a chunk boundary. Must be smaller than
+	// ChunkSize. Defaults to 4096.
+	Overlap int `yaml:"overlap" mapstructure:"overlap" validate:"omitempty,min=256"`
+
+	// MaxScanBytes caps the bytes scanned per argument or result; the rest
+	// is not scanned and a warning is logged. Policy rules can deny larger
+	// requests with payload_bytes. Defaults to 33554432 (32 MiB).
+	MaxScanBytes int64 `yaml:"max_scan_bytes" mapstructure:"max_scan_bytes" validate:"omitempty,min=0"`
+}
+
+// SessionConfig configures the session store.
+type SessionConfig struct {
+	// Store is "memory" (default) or "redis".
+	Store string `yaml:"store" mapstructure:"store" validate:"omitempty,oneof=memory redis"`
+
+	// Redis configures the server used by the "redis" store.
+	Redis RedisConfig `yaml:"redis" mapstructure:"redis"`
+}
+
+// StateConfig configures the state store.
+type StateConfig struct {
+	// Backend is "file" (default; state.json) or "postgres".
+	Backend string `yaml:"backend" mapstructure:"backend" validate:"omitempty,oneof=file postgres"`
+
+	// Postgres configures the database used by the "postgres" backend.
+	Postgres PostgresConfig `yaml:"postgres" mapstructure:"postgres"`
+}
+
+// PostgresConfig configures the connection to a PostgreSQL server.
+type PostgresConfig struct {
+	// Address is the host:port of the server. Defaults to "localhost:5432".
+	Address string `yaml:"address" mapstructure:"address"`
+
+	// User is the database user. Defaults to "sentinelgate".
+	User string `yaml:"user" mapstructure:"user"`
+
+	// Password authenticates connections (cleartext, MD5 or SCRAM-SHA-256).
+	Password string `yaml:"password" mapstructure:"password"`
+
+	// Database is the database name. Defaults to "sentinelgate".
+	Database string `yaml:"database" mapstructure:"database"`
+
+	// Table is the table holding the state, created if missing; it may be
+	// qualified by a schema ("ops.gateway_state"). Replicas sharing the
+	// state must use the same table. Defaults to "sentinelgate_state".
+	Table string `yaml:"table" mapstructure:"table"`
+
+	// TLS connects with TLS, verifying the server against the system roots.
+	TLS bool `yaml:"tls" mapstructure:"tls"`
+
+	// Timeout bounds connecting and each statement. Defaults to "5s".
+	Timeout string `yaml:"timeout" mapstructure:"timeout"`
+
+	// PoolSize is the maximum number of idle connections. Defaults to 4.
+	PoolSize int `yaml:"pool_size" mapstructure:"pool_size" validate:"omitempty,min=0"`
+}
+
+// ApprovalConfig configures the approval store.
+type ApprovalConfig struct {
+	// DefaultTimeout is how long an approval waits for a decision when its
+	// rule sets no approval_timeout; the rule's timeout action then applies.
+	// Defaults to "5m".
+	DefaultTimeout string `yaml:"default_timeout" mapstructure:"default_timeout"`
+
+	// MaxPending caps the approvals waiting at once; further calls needing
+	// approval are denied. Defaults to 100.
+	MaxPending int `yaml:"max_pending" mapstructure:"max_pending" validate:"omitempty,min=1"`
+
+	// GrantDuration is how long a session or identity approval lasts when
+	// the approver sets no duration. Defaults to "24h".
+	GrantDuration string `yaml:"grant_duration" mapstructure:"grant_duration"`
+
+	// MaxGrantDuration caps the duration an approver may set. Defaults to
+	// "168h".
+	MaxGrantDuration string `yaml:"max_grant_duration" mapstructure:"max_grant_duration"`
+}
+
+// RedisConfig configures the connection to a Redis server.
+type RedisConfig struct {
+	// Address is the host:port of the server. Defaults to "localhost:6379".
+	Address string `yaml:"address" mapstructure:"address"`
+
+	// Username is the ACL user (Redis 6+). Leave empty for password-only AUTH.
+	Username string `yaml:"username" mapstructure:"username"`
+
+	// Password authenticates connections when set.
+	Password string `yaml:"password" mapstructure:"password"`
+
+	// DB is the database number. Defaults to 0.
+	DB int `yaml:"db" mapstructure:"db" validate:"omitempty,min=0"`
+
+	// KeyPrefix is prepended to every key. Replicas sharing sessions must
+	// use the same prefix. Defaults to "sentinelgate:".
+	KeyPrefix string `yaml:"key_prefix" mapstructure:"key_prefix"`
+
+	// TLS connects with TLS, verifying the server against the system roots.
+	TLS bool `yaml:"tls" mapstructure:"tls"`
+
+	// Timeout bounds connecting and each command. Defaults to "5s".
+	Timeout string `yaml:"timeout" mapstructure:"timeout"`
+
+	// PoolSize is the maximum number of idle connections. Defaults to 10.
+	PoolSize int `yaml:"pool_size" mapstructure:"pool_size" validate:"omitempty,min=0"`
+}
+
+// MCPMethodRuleConfig sets the handling of matching methods.
+type MCPMethodRuleConfig struct {
+	// Method is a glob matched against the method name (e.g.,
+	// "completion/complete", "experimental/*"); "*" does not match "/".
+	Method string `yaml:"method" mapstructure:"method"`
+
+	// Action is "forward" (first upstream that implements the method),
+	// "deny" (method not found), "route" (the named upstream) or "local"
+	// (answered by the gateway; ping only).
+	Action string `yaml:"action" mapstructure:"action"`
+
+	// Upstream is the upstream name for action "route".
+	Upstream string `yaml:"upstream" mapstructure:"upstream"`
+}
+
+// CostRateConfig prices the calls of matching tools.
+type CostRateConfig struct {
+	// Tool is a glob matched against the tool name (e.g., "search_*").
+	// Empty matches any tool.
+	Tool string `yaml:"tool" mapstructure:"tool"`
+
+	// Upstream is a glob matched against the upstream name. Empty matches
+	// any upstream.
+	Upstream string `yaml:"upstream" mapstructure:"upstream"`
+
+	// PerCall is charged for every call.
+	PerCall float64 `yaml:"per_call" mapstructure:"per_call" validate:"min=0"`
+
+	// PerSecond is charged per second of call duration.
+	PerSecond float64 `yaml:"per_second" mapstructure:"per_second" validate:"min=0"`
+
+	// PerMB is charged per megabyte (1,000,000 bytes) of request and result.
+	PerMB float64 `yaml:"per_mb" mapstructure:"per_mb" validate:"min=0"`
+}
+
+// CostWebhookConfig configures the external cost hook.
+type CostWebhookConfig struct {
+	// URL receives a JSON POST for every call. Empty disables the webhook.
+	URL string `yaml:"url" mapstructure:"url" validate:"omitempty,url"`
+
+	// Secret is an optional HMAC-SHA256 secret for signing payloads
+	// (at least 32 characters).
+	Secret string `yaml:"secret" mapstructure:"secret"`
+
+	// Timeout bounds each webhook call (e.g., "5s"). Defaults to "5s".
+	Timeout string `yaml:"timeout" mapstructure:"timeout"`
 }
 
 // APIKeyConfig defines an API key that authenticates as an identity.
@@ -140,14 +1432,22 @@ type APIKeyConfig struct {
 	// IdentityID references the identity this key authenticates as.
 	// Must match an ID in Auth.Identities.
 	IdentityID string `yaml:"identity_id" mapstructure:"identity_id" validate:"required"`
+
+	// AllowedOrigins binds the key to browser origins (scheme://host[:port]).
+	// A request sending an Origin header outside this list is rejected even
+	// if the origin is in server.allowed_origins. Empty means unbound.
+	AllowedOrigins []string `yaml:"allowed_origins" mapstructure:"allowed_origins"`
 }
 
 // AuditConfig configures audit log output.
-// OSS supports stdout or file output only (no PostgreSQL, SIEM).
+// OSS supports stdout, file or syslog output (no PostgreSQL).
 type AuditConfig struct {
 	// Output specifies where audit logs are written.
-	// Valid values: "stdout" or "file:///absolute/path/to/audit.log"
-	// Defaults to "stdout" if empty.
+	// Valid values: "stdout", "file:///absolute/path/to/audit.log",
+	// "syslog://host:port" (TCP) or "tcp+tls://host:port" (TCP with TLS,
+	// optionally "?ca_file=/path/ca.pem" for a private CA).
+	// Syslog records are sent in the batches set by BatchSize and
+	// FlushInterval. Defaults to "stdout" if empty.
 	Output string `yaml:"output" mapstructure:"output" validate:"required,audit_output"`
 
 	// ChannelSize is the buffer size for the audit channel.
@@ -179,6 +1479,13 @@ type AuditConfig struct {
 	// BufferSize is the number of recent audit records to keep in the in-memory ring buffer.
 	// Used for the admin UI's recent audit display. Defaults to 1000 if not specified or 0.
 	BufferSize int `yaml:"buffer_size" mapstructure:"buffer_size" validate:"omitempty,min=1"`
+
+	// JournalPath enables the write-ahead journal: every record is appended
+	// and fsynced to this file before the call goes on, and the audit
+	// outputs are written from it, so no record is lost on a crash. Records
+	// not written yet are written on the next start. Trades one fsync per
+	// call for durability. Empty disables the journal (default).
+	JournalPath string `yaml:"journal_path" mapstructure:"journal_path"`
 }
 
 // EvidenceConfig configures cryptographic evidence for audit records.
@@ -224,6 +1531,15 @@ type RateLimitConfig struct {
 	// Defaults to UserRate if not specified.
 	UserBurst int `yaml:"user_burst" mapstructure:"user_burst" validate:"omitempty,min=1"`
 
+	// SessionRate is the maximum requests per minute per session. It caps a
+	// single agent so it cannot exhaust the user budget shared by all sessions
+	// of the same identity. 0 disables the per-session tier.
+	SessionRate int `yaml:"session_rate" mapstructure:"session_rate" validate:"omitempty,min=0"`
+
+	// SessionBurst is the maximum burst size for per-session rate limiting.
+	// Defaults to SessionRate if not specified.
+	SessionBurst int `yaml:"session_burst" mapstructure:"session_burst" validate:"omitempty,min=1"`
+
 	// CleanupInterval is how often to clean up expired rate limit entries (e.g., "5m").
 	// Only applies when rate limiting is enabled.
 	// Defaults to "5m" if not specified.
@@ -233,6 +1549,59 @@ type RateLimitConfig struct {
 	// Only applies when rate limiting is enabled.
 	// Defaults to "1h" if not specified.
 	MaxTTL string `yaml:"max_ttl" mapstructure:"max_ttl" validate:"omitempty"`
+
+	// Overrides change the user rate of an identity or limit the calls of a
+	// tool per user. Overrides from the config are read-only in the admin API.
+	Overrides []RateLimitOverrideConfig `yaml:"overrides" mapstructure:"overrides" validate:"omitempty,dive"`
+
+	// Store is "memory" (default) or "redis". With "redis", all gateway
+	// instances sharing the server enforce the same IP, user and session
+	// limits.
+	Store string `yaml:"store" mapstructure:"store" validate:"omitempty,oneof=memory redis"`
+
+	// Redis configures the server used by the "redis" store.
+	Redis RedisConfig `yaml:"redis" mapstructure:"redis"`
+}
+
+// DenyLoopConfig configures deny loop detection. A tool call denied
+// Threshold times within Window by the same identity with the same
+// arguments is rejected without evaluation for a backoff that starts at
+// BaseBackoff and doubles with every further attempt, up to MaxBackoff.
+// The start of a loop is published as a "policy.deny_loop" event.
+type DenyLoopConfig struct {
+	// Enabled turns detection on or off. Defaults to true.
+	Enabled bool `yaml:"enabled" mapstructure:"enabled"`
+
+	// Threshold is the number of denials that makes a loop. Defaults to 5.
+	Threshold int `yaml:"threshold" mapstructure:"threshold" validate:"omitempty,min=1,max=1000"`
+
+	// Window is how long a denial counts (e.g., "1m"). Defaults to "1m".
+	Window string `yaml:"window" mapstructure:"window"`
+
+	// BaseBackoff is the first backoff (e.g., "1s"). Defaults to "1s".
+	BaseBackoff string `yaml:"base_backoff" mapstructure:"base_backoff"`
+
+	// MaxBackoff caps the backoff (e.g., "1m"). Defaults to "1m".
+	MaxBackoff string `yaml:"max_backoff" mapstructure:"max_backoff"`
+}
+
+// RateLimitOverrideConfig is one rate limit override.
+//
+// With only Identity set it replaces user_rate/user_burst for that identity.
+// With Tool set it limits calls of matching tools per user, for every
+// identity or only for Identity when both are set.
+type RateLimitOverrideConfig struct {
+	// Identity is an identity ID or name.
+	Identity string `yaml:"identity" mapstructure:"identity"`
+
+	// Tool is a tool name or glob pattern (e.g. "send_*").
+	Tool string `yaml:"tool" mapstructure:"tool"`
+
+	// Rate is the maximum requests per minute.
+	Rate int `yaml:"rate" mapstructure:"rate" validate:"min=1"`
+
+	// Burst is the maximum burst size. Defaults to Rate if not specified.
+	Burst int `yaml:"burst" mapstructure:"burst" validate:"omitempty,min=1"`
 }
 
 // PolicyConfig defines a named set of access control rules.
@@ -245,6 +1614,18 @@ type PolicyConfig struct {
 	Rules []RuleConfig `yaml:"rules" mapstructure:"rules" validate:"required,min=1,dive"`
 }
 
+// PolicyDirectoryConfig configures the policy directory: one policy per
+// YAML file, loaded in addition to the policies of the config file and of
+// state.json. A change applies only if every file is valid.
+type PolicyDirectoryConfig struct {
+	// Path is the directory. Empty disables the policy directory.
+	Path string `yaml:"path" mapstructure:"path"`
+
+	// ReloadInterval is how often the files are checked for changes
+	// (e.g., "5s"). Defaults to "5s".
+	ReloadInterval string `yaml:"reload_interval" mapstructure:"reload_interval"`
+}
+
 // RuleConfig defines a single access control rule.
 // OSS supports only allow/deny actions (no approval_required).
 type RuleConfig struct {
@@ -258,6 +1639,14 @@ type RuleConfig struct {
 	// Action is what to do when the condition matches.
 	// OSS supports only "allow" or "deny" (no "approval_required").
 	Action string `yaml:"action" mapstructure:"action" validate:"required,oneof=allow deny"`
+
+	// HelpText is optional guidance shown when this rule denies a call.
+	// May reference variables such as {{.ToolName}} or {{.Destination.Domain}}.
+	HelpText string `yaml:"help_text" mapstructure:"help_text"`
+
+	// HelpURL is an optional link shown when this rule denies a call.
+	// May reference the same variables as HelpText; values are URL-escaped.
+	HelpURL string `yaml:"help_url" mapstructure:"help_url"`
 }
 
 // AuditFileConfig configures the file-based audit persistence.
@@ -273,6 +1662,31 @@ type AuditFileConfig struct {
 	// CacheSize is the number of recent audit records to keep in memory.
 	// Defaults to 1000.
 	CacheSize int `yaml:"cache_size" mapstructure:"cache_size" validate:"omitempty,min=1"` // L-69
+	// Compress enables zstd compression of rotated audit files. The file
+	// currently written to is never compressed.
+	Compress bool `yaml:"compress" mapstructure:"compress"`
+	// MaxTotalSizeMB caps the on-disk size of all audit files in megabytes.
+	// The oldest files are deleted first. 0 means no cap.
+	MaxTotalSizeMB int `yaml:"max_total_size_mb" mapstructure:"max_total_size_mb"`
+	// Chain links the records in a SHA-256 hash chain: each line carries
+	// the hash of the previous record and its own hash, so edited, removed
+	// or inserted records are found by "sentinel-gate audit verify".
+	Chain bool `yaml:"chain" mapstructure:"chain"`
+	// CheckpointInterval is how often the head of the chain is signed with
+	// the evidence key and appended to audit-checkpoints.jsonl. "0s"
+	// disables checkpoints. Defaults to "1h". Only used with Chain.
+	CheckpointInterval string `yaml:"checkpoint_interval" mapstructure:"checkpoint_interval"`
+}
+
+// AuditSQLiteConfig configures the SQLite audit store. When Path is set,
+// every audit record is also written to the database and /admin/api/audit
+// queries are answered from its indexes.
+type AuditSQLiteConfig struct {
+	// Path is the database file. Empty disables the store.
+	Path string `yaml:"path" mapstructure:"path"`
+	// RetentionDays deletes records older than this many days.
+	// 0 keeps every record.
+	RetentionDays int `yaml:"retention_days" mapstructure:"retention_days" validate:"omitempty,min=0"`
 }
 
 // SetDefaults applies sensible default values to the configuration.
@@ -293,11 +1707,95 @@ func (c *OSSConfig) SetDefaults() {
 	if c.Server.SessionTimeout == "" {
 		c.Server.SessionTimeout = "30m"
 	}
+	if c.Server.MaxRequestBodySize == 0 {
+		c.Server.MaxRequestBodySize = 1 << 20
+	}
+	if c.Server.ToolProvenance == "" {
+		c.Server.ToolProvenance = "off"
+	}
+	if c.Server.LatencyBreakdown.Mode == "" {
+		c.Server.LatencyBreakdown.Mode = "off"
+	}
+	if c.Server.Correlation.Header == "" {
+		c.Server.Correlation.Header = "X-Correlation-ID"
+	}
+	if c.Server.Correlation.MetaKey == "" {
+		c.Server.Correlation.MetaKey = "sentinelgate/correlation_id"
+	}
+	if c.Server.Correlation.Propagate == "" {
+		c.Server.Correlation.Propagate = "off"
+	}
+	if c.Server.StdioFraming == "" {
+		c.Server.StdioFraming = "auto"
+	}
+	if c.Server.MaxSubscriptionsPerIdentity == 0 {
+		c.Server.MaxSubscriptionsPerIdentity = 100
+	}
+	if c.Server.TLS.ReloadInterval == "" {
+		c.Server.TLS.ReloadInterval = "1m"
+	}
+	if c.PolicyDirectory.ReloadInterval == "" {
+		c.PolicyDirectory.ReloadInterval = "5s"
+	}
+	if c.Server.AdminUI.Mode == "" {
+		c.Server.AdminUI.Mode = "embedded"
+	}
+	if c.Server.SSE.MaxLineSize == 0 {
+		c.Server.SSE.MaxLineSize = 8192
+	}
+	if c.Server.SSE.OverflowTTL == "" {
+		c.Server.SSE.OverflowTTL = "5m"
+	}
+	if c.Server.SSE.OverflowMaxBytes == 0 {
+		c.Server.SSE.OverflowMaxBytes = 64 << 20
+	}
+	if c.Server.SSE.ReplayBufferSize == 0 {
+		c.Server.SSE.ReplayBufferSize = 100
+	}
+	if c.Server.SSE.Queue.MaxMessages == 0 {
+		c.Server.SSE.Queue.MaxMessages = 100
+	}
+	if c.Server.SSE.Queue.MaxBytes == 0 {
+		c.Server.SSE.Queue.MaxBytes = 16 << 20
+	}
+	if c.Server.SSE.Queue.DropPolicy == "" {
+		c.Server.SSE.Queue.DropPolicy = "newest"
+	}
+	if c.Server.WebSocket.PingInterval == "" {
+		c.Server.WebSocket.PingInterval = "30s"
+	}
+	if c.Server.WebSocket.MaxInFlight == 0 {
+		c.Server.WebSocket.MaxInFlight = 16
+	}
+	if c.Server.Health.PublicDetail == "" {
+		c.Server.Health.PublicDetail = "status"
+	}
+	if c.Server.Health.AuthenticatedDetail == "" {
+		c.Server.Health.AuthenticatedDetail = "full"
+	}
 
 	// Upstream defaults
 	if c.Upstream.HTTPTimeout == "" {
 		c.Upstream.HTTPTimeout = "30s"
 	}
+	if c.Upstream.LazyStartTimeout == "" {
+		c.Upstream.LazyStartTimeout = "30s"
+	}
+	if c.Upstream.LazyIdleTimeout == "" {
+		c.Upstream.LazyIdleTimeout = "10m"
+	}
+	if c.Upstream.SecretDetection == "" {
+		c.Upstream.SecretDetection = "warn"
+	}
+	if c.Upstream.Framing == "" {
+		c.Upstream.Framing = "auto"
+	}
+	if c.Upstream.DiscoveryMaxPages == 0 {
+		c.Upstream.DiscoveryMaxPages = 100
+	}
+	if c.Upstream.ToolNamespacing == "" {
+		c.Upstream.ToolNamespacing = "conflicts"
+	}
 
 	// Audit defaults
 	if c.Audit.Output == "" {
@@ -326,6 +1824,219 @@ func (c *OSSConfig) SetDefaults() {
 		c.RateLimit.Enabled = true
 	}
 
+	// Deny loop defaults — enabled by default, it only acts on calls that
+	// are denied anyway
+	if !c.denyLoopEnabledExplicit {
+		c.DenyLoop.Enabled = true
+	}
+	if c.DenyLoop.Threshold == 0 {
+		c.DenyLoop.Threshold = 5
+	}
+	if c.DenyLoop.Window == "" {
+		c.DenyLoop.Window = "1m"
+	}
+	if c.DenyLoop.BaseBackoff == "" {
+		c.DenyLoop.BaseBackoff = "1s"
+	}
+	if c.DenyLoop.MaxBackoff == "" {
+		c.DenyLoop.MaxBackoff = "1m"
+	}
+
+	// Watchdog defaults — enabled by default, it only reads timestamps
+	if !c.watchdogEnabledExplicit {
+		c.Watchdog.Enabled = true
+	}
+	if c.Watchdog.CheckInterval == "" {
+		c.Watchdog.CheckInterval = "5s"
+	}
+
+	// URL extraction defaults — on, with command-line hosts but without the
+	// bare-domain heuristic
+	if !c.urlExtractionEnabledExplicit {
+		c.URLExtraction.Enabled = true
+	}
+	if !c.urlExtractionCommandsExplicit {
+		c.URLExtraction.CommandStrings = true
+	}
+	if c.URLExtraction.MaxDepth == 0 {
+		c.URLExtraction.MaxDepth = 8
+	}
+
+	// Webhook defaults — retry failed deliveries with exponential backoff
+	if !c.webhookRetriesExplicit && c.Webhook.Retries == 0 {
+		c.Webhook.Retries = 3
+	}
+	if c.Webhook.RetryBackoff == "" {
+		c.Webhook.RetryBackoff = "2s"
+	}
+	if c.Events.SinkQueueSize == 0 {
+		c.Events.SinkQueueSize = 256
+	}
+	for i := range c.Webhook.Endpoints {
+		if c.Webhook.Endpoints[i].Name == "" {
+			c.Webhook.Endpoints[i].Name = fmt.Sprintf("endpoint-%d", i+1)
+		}
+	}
+
+	// SLO defaults — multiwindow burn-rate thresholds for a 30-day budget
+	if c.SLO.FastBurnThreshold == 0 {
+		c.SLO.FastBurnThreshold = 14.4
+	}
+	if c.SLO.SlowBurnThreshold == 0 {
+		c.SLO.SlowBurnThreshold = 6
+	}
+	if c.SLO.EvaluationInterval == "" {
+		c.SLO.EvaluationInterval = "30s"
+	}
+
+	// Scheduler defaults — on, keeping a week of daily state snapshots
+	if !c.schedulerEnabledExplicit {
+		c.Scheduler.Enabled = true
+	}
+	if c.Scheduler.SnapshotKeep == 0 {
+		c.Scheduler.SnapshotKeep = 7
+	}
+	if c.Scheduler.ReportKeep == 0 {
+		c.Scheduler.ReportKeep = 12
+	}
+	if c.Scheduler.KeyExpiryWarning == "" {
+		c.Scheduler.KeyExpiryWarning = "168h"
+	}
+
+	// Response guard defaults — compare result structure, alert on the first change
+	// Cost accounting defaults
+	if c.CostAccounting.Currency == "" {
+		c.CostAccounting.Currency = "USD"
+	}
+	if c.CostAccounting.Webhook.Timeout == "" {
+		c.CostAccounting.Webhook.Timeout = "5s"
+	}
+	if c.CEL.CostLimit == 0 {
+		c.CEL.CostLimit = 100000
+	}
+	if c.CEL.EvalTimeout == "" {
+		c.CEL.EvalTimeout = "5s"
+	}
+	if c.Policy.Engine == "" {
+		c.Policy.Engine = "cel"
+	}
+	if c.Policy.Rego.URL == "" {
+		c.Policy.Rego.URL = "http://127.0.0.1:8181"
+	}
+	if c.Policy.Rego.Decision == "" {
+		c.Policy.Rego.Decision = "sentinelgate/decision"
+	}
+	if c.Policy.Rego.Timeout == "" {
+		c.Policy.Rego.Timeout = "2s"
+	}
+	if c.Policy.Rego.ReloadInterval == "" {
+		c.Policy.Rego.ReloadInterval = "5s"
+	}
+	if c.DNS.Cache.MaxTTL == "" {
+		c.DNS.Cache.MaxTTL = "5m"
+	}
+	if c.DNS.Cache.NegativeTTL == "" {
+		c.DNS.Cache.NegativeTTL = "30s"
+	}
+	if c.DNS.RevalidateInterval == "" {
+		c.DNS.RevalidateInterval = "1m"
+	}
+	if c.Policy.Enrichment.Timeout == "" {
+		c.Policy.Enrichment.Timeout = "500ms"
+	}
+	if c.Policy.Enrichment.CacheTTL == "" {
+		c.Policy.Enrichment.CacheTTL = "5m"
+	}
+	if c.CEL.MaxComplexity == 0 {
+		c.CEL.MaxComplexity = 1000000
+	}
+	if c.Admission.CheckInterval == "" {
+		c.Admission.CheckInterval = "1s"
+	}
+	if c.Admission.RetryAfter == "" {
+		c.Admission.RetryAfter = "5s"
+	}
+	if c.MCPMethods.UnknownAction == "" {
+		c.MCPMethods.UnknownAction = "deny"
+	}
+	if c.TaintTracking.WindowSize == 0 {
+		c.TaintTracking.WindowSize = 32
+	}
+	if c.TaintTracking.MaxFingerprintsPerSession == 0 {
+		c.TaintTracking.MaxFingerprintsPerSession = 4096
+	}
+	if c.AnomalyDetection.WarmupCalls == 0 {
+		c.AnomalyDetection.WarmupCalls = 100
+	}
+	if c.AnomalyDetection.WarmupPeriod == "" {
+		c.AnomalyDetection.WarmupPeriod = "24h"
+	}
+	if c.AnomalyDetection.Threshold == 0 {
+		c.AnomalyDetection.Threshold = 0.8
+	}
+	if c.ContentInspection.ChunkSize == 0 {
+		c.ContentInspection.ChunkSize = 256 * 1024
+	}
+	if c.ContentInspection.Overlap == 0 {
+		c.ContentInspection.Overlap = 4096
+	}
+	if c.ContentInspection.MaxScanBytes == 0 {
+		c.ContentInspection.MaxScanBytes = 32 * 1024 * 1024
+	}
+
+	if c.Session.Store == "" {
+		c.Session.Store = "memory"
+	}
+	setRedisDefaults(&c.Session.Redis)
+	if c.RateLimit.Store == "" {
+		c.RateLimit.Store = "memory"
+	}
+	setRedisDefaults(&c.RateLimit.Redis)
+
+	if c.State.Backend == "" {
+		c.State.Backend = "file"
+	}
+	if c.State.Postgres.Address == "" {
+		c.State.Postgres.Address = "localhost:5432"
+	}
+	if c.State.Postgres.User == "" {
+		c.State.Postgres.User = "sentinelgate"
+	}
+	if c.State.Postgres.Database == "" {
+		c.State.Postgres.Database = "sentinelgate"
+	}
+	if c.State.Postgres.Table == "" {
+		c.State.Postgres.Table = "sentinelgate_state"
+	}
+	if c.State.Postgres.Timeout == "" {
+		c.State.Postgres.Timeout = "5s"
+	}
+	if c.State.Postgres.PoolSize == 0 {
+		c.State.Postgres.PoolSize = 4
+	}
+
+	if c.Approval.DefaultTimeout == "" {
+		c.Approval.DefaultTimeout = "5m"
+	}
+	if c.Approval.MaxPending == 0 {
+		c.Approval.MaxPending = 100
+	}
+	if c.Approval.GrantDuration == "" {
+		c.Approval.GrantDuration = "24h"
+	}
+	if c.Approval.MaxGrantDuration == "" {
+		c.Approval.MaxGrantDuration = "168h"
+	}
+
+	for i := range c.ResponseGuard.Tools {
+		if c.ResponseGuard.Tools[i].Compare == "" {
+			c.ResponseGuard.Tools[i].Compare = "structure"
+		}
+		if c.ResponseGuard.Tools[i].Threshold == 0 {
+			c.ResponseGuard.Tools[i].Threshold = 1
+		}
+	}
+
 	// Evidence defaults — enabled by default for compliance
 	if !c.evidenceEnabledExplicit {
 		c.Evidence.Enabled = true
@@ -348,10 +2059,49 @@ func (c *OSSConfig) SetDefaults() {
 	if c.RateLimit.UserBurst == 0 {
 		c.RateLimit.UserBurst = c.RateLimit.UserRate
 	}
+	if c.RateLimit.SessionBurst == 0 && c.RateLimit.SessionRate > 0 {
+		c.RateLimit.SessionBurst = c.RateLimit.SessionRate
+	}
 	if c.RateLimit.CleanupInterval == "" {
 		c.RateLimit.CleanupInterval = "5m"
 	}
 	if c.RateLimit.MaxTTL == "" {
 		c.RateLimit.MaxTTL = "1h"
 	}
+
+	// Token exchange defaults
+	if c.TokenExchange.Header == "" {
+		c.TokenExchange.Header = "X-Upstream-Token"
+	}
+	if c.TokenExchange.CacheTTL == "" {
+		c.TokenExchange.CacheTTL = "5m"
+	}
+
+	// OIDC defaults
+	if c.Auth.OIDC.IdentityClaim == "" {
+		c.Auth.OIDC.IdentityClaim = "sub"
+	}
+	if c.Auth.OIDC.RolesClaim == "" {
+		c.Auth.OIDC.RolesClaim = "roles"
+	}
+	if c.Auth.OIDC.ClockSkew == "" {
+		c.Auth.OIDC.ClockSkew = "1m"
+	}
+}
+
+// setRedisDefaults fills in the connection defaults shared by every Redis
+// store.
+func setRedisDefaults(r *RedisConfig) {
+	if r.Address == "" {
+		r.Address = "localhost:6379"
+	}
+	if r.KeyPrefix == "" {
+		r.KeyPrefix = "sentinelgate:"
+	}
+	if r.Timeout == "" {
+		r.Timeout = "5s"
+	}
+	if r.PoolSize == 0 {
+		r.PoolSize = 10
+	}
 }
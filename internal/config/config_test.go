@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -116,6 +117,223 @@ func TestOSSConfig_SetDefaults_SessionTimeout(t *testing.T) {
 	}
 }
 
+func TestOSSConfig_SetDefaults_MaxRequestBodySize(t *testing.T) {
+	t.Parallel()
+
+	cfg := OSSConfig{}
+	cfg.SetDefaults()
+	if cfg.Server.MaxRequestBodySize != 1<<20 {
+		t.Errorf("MaxRequestBodySize default: got %d, want %d",
+			cfg.Server.MaxRequestBodySize, 1<<20)
+	}
+
+	cfg2 := OSSConfig{
+		Server: ServerConfig{MaxRequestBodySize: 4096},
+	}
+	cfg2.SetDefaults()
+	if cfg2.Server.MaxRequestBodySize != 4096 {
+		t.Errorf("MaxRequestBodySize custom: got %d, want %d",
+			cfg2.Server.MaxRequestBodySize, 4096)
+	}
+}
+
+func TestOSSConfig_SetDefaults_SessionRateLimit(t *testing.T) {
+	t.Parallel()
+
+	cfg := OSSConfig{}
+	cfg.SetDefaults()
+	if cfg.RateLimit.SessionRate != 0 || cfg.RateLimit.SessionBurst != 0 {
+		t.Errorf("session rate limit should be off by default, got rate=%d burst=%d",
+			cfg.RateLimit.SessionRate, cfg.RateLimit.SessionBurst)
+	}
+
+	cfg2 := OSSConfig{
+		RateLimit: RateLimitConfig{SessionRate: 50},
+	}
+	cfg2.SetDefaults()
+	if cfg2.RateLimit.SessionBurst != 50 {
+		t.Errorf("SessionBurst default: got %d, want %d", cfg2.RateLimit.SessionBurst, 50)
+	}
+}
+
+func TestOSSConfig_SetDefaults_RateLimitStore(t *testing.T) {
+	t.Parallel()
+
+	cfg := OSSConfig{}
+	cfg.SetDefaults()
+	if cfg.RateLimit.Store != "memory" {
+		t.Errorf("RateLimit.Store default: got %q, want %q", cfg.RateLimit.Store, "memory")
+	}
+	if cfg.RateLimit.Redis != cfg.Session.Redis {
+		t.Errorf("RateLimit.Redis defaults %+v differ from Session.Redis %+v", cfg.RateLimit.Redis, cfg.Session.Redis)
+	}
+	if cfg.RateLimit.Redis.Address != "localhost:6379" || cfg.RateLimit.Redis.KeyPrefix != "sentinelgate:" {
+		t.Errorf("RateLimit.Redis defaults: got %+v", cfg.RateLimit.Redis)
+	}
+}
+
+func TestOSSConfig_SetDefaults_Watchdog(t *testing.T) {
+	t.Parallel()
+
+	cfg := OSSConfig{}
+	cfg.SetDefaults()
+	if !cfg.Watchdog.Enabled {
+		t.Error("Watchdog should be enabled by default")
+	}
+	if cfg.Watchdog.CheckInterval != "5s" {
+		t.Errorf("Watchdog.CheckInterval default: got %q, want %q", cfg.Watchdog.CheckInterval, "5s")
+	}
+
+	cfg2 := OSSConfig{watchdogEnabledExplicit: true}
+	cfg2.SetDefaults()
+	if cfg2.Watchdog.Enabled {
+		t.Error("explicit watchdog.enabled: false should be kept")
+	}
+}
+
+func TestOSSConfig_SetDefaults_DenyLoop(t *testing.T) {
+	t.Parallel()
+
+	cfg := OSSConfig{}
+	cfg.SetDefaults()
+	dl := cfg.DenyLoop
+	if !dl.Enabled {
+		t.Error("DenyLoop should be enabled by default")
+	}
+	if dl.Threshold != 5 || dl.Window != "1m" || dl.BaseBackoff != "1s" || dl.MaxBackoff != "1m" {
+		t.Errorf("DenyLoop defaults: got %+v", dl)
+	}
+
+	cfg2 := OSSConfig{denyLoopEnabledExplicit: true}
+	cfg2.SetDefaults()
+	if cfg2.DenyLoop.Enabled {
+		t.Error("explicit deny_loop.enabled: false should be kept")
+	}
+}
+
+func TestOSSConfig_SetDefaults_Scheduler(t *testing.T) {
+	t.Parallel()
+
+	cfg := OSSConfig{}
+	cfg.SetDefaults()
+	sc := cfg.Scheduler
+	if !sc.Enabled {
+		t.Error("Scheduler should be enabled by default")
+	}
+	if sc.SnapshotKeep != 7 || sc.ReportKeep != 12 || sc.KeyExpiryWarning != "168h" {
+		t.Errorf("Scheduler defaults = %+v, want snapshot_keep 7, report_keep 12, key_expiry_warning 168h", sc)
+	}
+
+	cfg2 := OSSConfig{schedulerEnabledExplicit: true}
+	cfg2.SetDefaults()
+	if cfg2.Scheduler.Enabled {
+		t.Error("explicit scheduler.enabled: false should be kept")
+	}
+}
+
+func TestOSSConfig_SetDefaults_URLExtraction(t *testing.T) {
+	t.Parallel()
+
+	cfg := OSSConfig{}
+	cfg.SetDefaults()
+	ue := cfg.URLExtraction
+	if !ue.Enabled || !ue.CommandStrings || ue.BareDomains || ue.MaxDepth != 8 {
+		t.Errorf("URLExtraction defaults = %+v, want enabled, command strings, no bare domains, depth 8", ue)
+	}
+
+	cfg2 := OSSConfig{urlExtractionEnabledExplicit: true, urlExtractionCommandsExplicit: true}
+	cfg2.SetDefaults()
+	if cfg2.URLExtraction.Enabled || cfg2.URLExtraction.CommandStrings {
+		t.Error("explicit url_extraction.enabled/command_strings: false should be kept")
+	}
+}
+
+func TestOSSConfig_SetDefaults_Webhook(t *testing.T) {
+	t.Parallel()
+
+	cfg := OSSConfig{Webhook: WebhookConfig{Endpoints: []WebhookEndpointConfig{{URL: "https://a.example.com"}, {Name: "pager", URL: "https://b.example.com"}}}}
+	cfg.SetDefaults()
+	if cfg.Webhook.Retries != 3 || cfg.Webhook.RetryBackoff != "2s" {
+		t.Errorf("Webhook retry defaults = %d/%q, want 3/\"2s\"", cfg.Webhook.Retries, cfg.Webhook.RetryBackoff)
+	}
+	if cfg.Webhook.Endpoints[0].Name != "endpoint-1" || cfg.Webhook.Endpoints[1].Name != "pager" {
+		t.Errorf("endpoint names = %q, %q", cfg.Webhook.Endpoints[0].Name, cfg.Webhook.Endpoints[1].Name)
+	}
+
+	cfg2 := OSSConfig{webhookRetriesExplicit: true}
+	cfg2.SetDefaults()
+	if cfg2.Webhook.Retries != 0 {
+		t.Error("explicit webhook.retries: 0 should be kept")
+	}
+}
+
+func TestOSSConfig_SetDefaults_SLO(t *testing.T) {
+	t.Parallel()
+
+	cfg := OSSConfig{}
+	cfg.SetDefaults()
+	if cfg.SLO.FastBurnThreshold != 14.4 {
+		t.Errorf("SLO.FastBurnThreshold default: got %v, want 14.4", cfg.SLO.FastBurnThreshold)
+	}
+	if cfg.SLO.SlowBurnThreshold != 6 {
+		t.Errorf("SLO.SlowBurnThreshold default: got %v, want 6", cfg.SLO.SlowBurnThreshold)
+	}
+	if cfg.SLO.EvaluationInterval != "30s" {
+		t.Errorf("SLO.EvaluationInterval default: got %q, want %q", cfg.SLO.EvaluationInterval, "30s")
+	}
+}
+
+func TestOSSConfig_SetDefaults_SecretDetection(t *testing.T) {
+	cfg := &OSSConfig{}
+	cfg.SetDefaults()
+	if cfg.Upstream.SecretDetection != "warn" {
+		t.Errorf("SecretDetection default: got %q, want %q", cfg.Upstream.SecretDetection, "warn")
+	}
+}
+
+func TestOSSConfig_SetDefaults_Framing(t *testing.T) {
+	cfg := &OSSConfig{}
+	cfg.SetDefaults()
+	if cfg.Server.StdioFraming != "auto" || cfg.Upstream.Framing != "auto" {
+		t.Errorf("framing defaults: got server %q, upstream %q, want auto", cfg.Server.StdioFraming, cfg.Upstream.Framing)
+	}
+}
+
+func TestOSSConfig_SetDefaults_HealthDetail(t *testing.T) {
+	cfg := &OSSConfig{}
+	cfg.SetDefaults()
+	if cfg.Server.Health.PublicDetail != "status" || cfg.Server.Health.AuthenticatedDetail != "full" {
+		t.Errorf("health detail defaults: got public %q, authenticated %q, want status/full",
+			cfg.Server.Health.PublicDetail, cfg.Server.Health.AuthenticatedDetail)
+	}
+}
+
+func TestOSSConfig_SetDefaults_MaxSubscriptionsPerIdentity(t *testing.T) {
+	cfg := &OSSConfig{}
+	cfg.SetDefaults()
+	if cfg.Server.MaxSubscriptionsPerIdentity != 100 {
+		t.Errorf("MaxSubscriptionsPerIdentity default: got %d, want 100", cfg.Server.MaxSubscriptionsPerIdentity)
+	}
+}
+
+func TestOSSConfig_SetDefaults_ToolProvenance(t *testing.T) {
+	t.Parallel()
+
+	cfg := OSSConfig{}
+	cfg.SetDefaults()
+	if cfg.Server.ToolProvenance != "off" {
+		t.Errorf("ToolProvenance default: got %q, want %q", cfg.Server.ToolProvenance, "off")
+	}
+
+	cfg2 := OSSConfig{
+		Server: ServerConfig{ToolProvenance: "both"},
+	}
+	cfg2.SetDefaults()
+	if cfg2.Server.ToolProvenance != "both" {
+		t.Errorf("ToolProvenance custom: got %q, want %q", cfg2.Server.ToolProvenance, "both")
+	}
+}
+
 func TestOSSConfig_SetDefaults_HTTPTimeout(t *testing.T) {
 	t.Parallel()
 
@@ -140,6 +358,30 @@ func TestOSSConfig_SetDefaults_HTTPTimeout(t *testing.T) {
 	}
 }
 
+func TestOSSConfig_SetDefaults_LazyUpstreamTimeouts(t *testing.T) {
+	t.Parallel()
+
+	cfg := OSSConfig{}
+	cfg.SetDefaults()
+
+	if cfg.Upstream.LazyStartTimeout != "30s" {
+		t.Errorf("LazyStartTimeout default: got %q, want %q", cfg.Upstream.LazyStartTimeout, "30s")
+	}
+	if cfg.Upstream.LazyIdleTimeout != "10m" {
+		t.Errorf("LazyIdleTimeout default: got %q, want %q", cfg.Upstream.LazyIdleTimeout, "10m")
+	}
+
+	// "0s" disables idle shutdown and must not be replaced by the default.
+	cfg2 := OSSConfig{
+		Upstream: UpstreamConfig{LazyIdleTimeout: "0s"},
+	}
+	cfg2.SetDefaults()
+
+	if cfg2.Upstream.LazyIdleTimeout != "0s" {
+		t.Errorf("LazyIdleTimeout custom: got %q, want %q", cfg2.Upstream.LazyIdleTimeout, "0s")
+	}
+}
+
 func TestOSSConfig_SetDefaults_RateLimitDurations(t *testing.T) {
 	t.Parallel()
 
@@ -252,3 +494,111 @@ func TestFindConfigFileInPaths_PrefersYAMLOverYML(t *testing.T) {
 		t.Errorf("findConfigFileInPaths = %q, want %q (.yaml preferred)", got, yamlPath)
 	}
 }
+
+func TestOSSConfig_Validate_TokenExchange(t *testing.T) {
+	t.Parallel()
+
+	cfg := OSSConfig{
+		TokenExchange: TokenExchangeConfig{
+			Enabled: true,
+			Upstreams: []TokenExchangeUpstreamConfig{
+				{Upstream: "github", Mode: "exchange", Audience: "https://api.github.com"},
+			},
+		},
+	}
+	cfg.SetDefaults()
+	if cfg.TokenExchange.Header != "X-Upstream-Token" {
+		t.Errorf("Header default = %q, want X-Upstream-Token", cfg.TokenExchange.Header)
+	}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error: exchange mode without token_url")
+	}
+
+	cfg.TokenExchange.TokenURL = "https://idp.example.com/token"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "jwt.issuer or introspection_url") {
+		t.Errorf("expected error: no user token verification, got %v", err)
+	}
+
+	cfg.TokenExchange.JWT.Issuer = "https://idp.example.com"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "jwt.audiences") {
+		t.Errorf("expected error: jwt.issuer without audiences, got %v", err)
+	}
+
+	cfg.TokenExchange.JWT.Audiences = []string{"sentinelgate"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	cfg.TokenExchange.JWT = TokenExchangeJWTConfig{}
+	cfg.TokenExchange.IntrospectionURL = "https://idp.example.com/introspect"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error with introspection only: %v", err)
+	}
+
+	cfg.TokenExchange.Upstreams[0].Mode = "impersonate"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
+
+func TestOSSConfig_Validate_OIDC(t *testing.T) {
+	t.Parallel()
+
+	cfg := OSSConfig{Auth: AuthConfig{OIDC: OIDCConfig{Enabled: true}}}
+	cfg.SetDefaults()
+	if cfg.Auth.OIDC.IdentityClaim != "sub" || cfg.Auth.OIDC.RolesClaim != "roles" {
+		t.Errorf("claim defaults = %q, %q", cfg.Auth.OIDC.IdentityClaim, cfg.Auth.OIDC.RolesClaim)
+	}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error: oidc enabled without issuer")
+	}
+
+	cfg.Auth.OIDC.Issuer = "https://idp.example.com"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error: oidc enabled without audiences")
+	}
+
+	cfg.Auth.OIDC.Audiences = []string{"sentinelgate"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	cfg.Auth.OIDC.ClockSkew = "a minute"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for invalid clock_skew")
+	}
+}
+
+func TestOSSConfig_Validate_RateLimitOverrides(t *testing.T) {
+	t.Parallel()
+
+	cfg := OSSConfig{}
+	cfg.SetDefaults()
+	cfg.RateLimit.Overrides = []RateLimitOverrideConfig{
+		{Identity: "ci-bot", Rate: 1000},
+		{Tool: "send_email", Rate: 10},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg.RateLimit.Overrides = append(cfg.RateLimit.Overrides, RateLimitOverrideConfig{Tool: "send_email", Rate: 20})
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for duplicate override")
+	}
+
+	cfg.RateLimit.Overrides = []RateLimitOverrideConfig{{Rate: 10}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error: neither identity nor tool")
+	}
+
+	cfg.RateLimit.Overrides = []RateLimitOverrideConfig{{Tool: "send_[", Rate: 10}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for invalid tool pattern")
+	}
+
+	cfg.RateLimit.Overrides = []RateLimitOverrideConfig{{Tool: "send_email"}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for missing rate")
+	}
+}
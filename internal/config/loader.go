@@ -86,13 +86,56 @@ func bindNestedEnvKeys() {
 
 	// Server config
 	bindEnv("server.http_addr")
+	bindEnv("server.extra_http_addrs")
+	bindEnv("server.reuse_port")
 	bindEnv("server.session_timeout")
 	bindEnv("server.log_level")
+	bindEnv("server.max_request_body_size")
+	bindEnv("server.tool_provenance")
+	bindEnv("server.latency_breakdown.mode")
+	bindEnv("server.correlation.header")
+	bindEnv("server.correlation.meta_key")
+	bindEnv("server.correlation.propagate")
+	bindEnv("server.stdio_framing")
+	bindEnv("server.allowed_origins")
+	bindEnv("server.max_subscriptions_per_identity")
+	bindEnv("server.sse.max_line_size")
+	bindEnv("server.sse.max_event_size")
+	bindEnv("server.sse.overflow_ttl")
+	bindEnv("server.sse.overflow_max_bytes")
+	bindEnv("server.sse.compression")
+	bindEnv("server.sse.replay_buffer_size")
+	bindEnv("server.sse.queue.max_messages")
+	bindEnv("server.sse.queue.max_bytes")
+	bindEnv("server.sse.queue.drop_policy")
+	bindEnv("server.websocket.enabled")
+	bindEnv("server.websocket.ping_interval")
+	bindEnv("server.websocket.max_in_flight")
+	bindEnv("server.health.public_detail")
+	bindEnv("server.health.authenticated_detail")
+	bindEnv("server.tls.cert_file")
+	bindEnv("server.tls.key_file")
+	bindEnv("server.tls.reload_interval")
+	bindEnv("server.admin_ui.mode")
+	bindEnv("server.admin_ui.directory")
+	bindEnv("server.admin_ui.content_security_policy")
+
+	// Policy directory
+	bindEnv("policy_directory.path")
+	bindEnv("policy_directory.reload_interval")
 
 	// Upstream config (mutually exclusive: http OR command)
 	bindEnv("upstream.http")
 	bindEnv("upstream.command")
 	bindEnv("upstream.http_timeout")
+	bindEnv("upstream.lazy_start_timeout")
+	bindEnv("upstream.lazy_idle_timeout")
+	bindEnv("upstream.secret_detection")
+	bindEnv("upstream.credentials_key_path")
+	bindEnv("upstream.schema_compress_min")
+	bindEnv("upstream.tool_namespacing")
+	bindEnv("upstream.discovery_max_pages")
+	bindEnv("upstream.framing")
 	// Note: upstream.args is an array, handled by Viper's env parsing
 
 	// Auth config
@@ -107,12 +150,21 @@ func bindNestedEnvKeys() {
 	bindEnv("audit.warning_threshold")
 	bindEnv("audit.flush_interval")
 	bindEnv("audit.send_timeout")
+	bindEnv("audit.journal_path")
 
 	// Audit file config (L-44)
 	bindEnv("audit_file.dir")
 	bindEnv("audit_file.retention_days")
 	bindEnv("audit_file.max_file_size_mb")
 	bindEnv("audit_file.cache_size")
+	bindEnv("audit_file.compress")
+	bindEnv("audit_file.max_total_size_mb")
+	bindEnv("audit_file.chain")
+	bindEnv("audit_file.checkpoint_interval")
+
+	// Audit SQLite store
+	bindEnv("audit_sqlite.path")
+	bindEnv("audit_sqlite.retention_days")
 
 	// Rate limit config
 	bindEnv("rate_limit.enabled")
@@ -120,8 +172,61 @@ func bindNestedEnvKeys() {
 	bindEnv("rate_limit.user_rate")
 	bindEnv("rate_limit.ip_burst")
 	bindEnv("rate_limit.user_burst")
+	bindEnv("rate_limit.session_rate")
+	bindEnv("rate_limit.session_burst")
 	bindEnv("rate_limit.cleanup_interval")
 	bindEnv("rate_limit.max_ttl")
+	bindEnv("rate_limit.store")
+	bindEnv("rate_limit.redis.address")
+	bindEnv("rate_limit.redis.username")
+	bindEnv("rate_limit.redis.password")
+	bindEnv("rate_limit.redis.db")
+	bindEnv("rate_limit.redis.key_prefix")
+	bindEnv("rate_limit.redis.tls")
+	bindEnv("rate_limit.redis.timeout")
+	bindEnv("rate_limit.redis.pool_size")
+	bindEnv("deny_loop.enabled")
+	bindEnv("deny_loop.threshold")
+	bindEnv("deny_loop.window")
+	bindEnv("deny_loop.base_backoff")
+	bindEnv("deny_loop.max_backoff")
+
+	// Watchdog config
+	bindEnv("watchdog.enabled")
+	bindEnv("watchdog.check_interval")
+
+	// DNS config (servers are YAML-only)
+	bindEnv("dns.resolve_destinations")
+	bindEnv("dns.cache.enabled")
+	bindEnv("dns.cache.max_ttl")
+	bindEnv("dns.cache.negative_ttl")
+	bindEnv("dns.revalidate_interval")
+
+	// URL extraction config (per-tool hints are YAML-only)
+	bindEnv("url_extraction.enabled")
+	bindEnv("url_extraction.max_depth")
+	bindEnv("url_extraction.bare_domains")
+	bindEnv("url_extraction.command_strings")
+
+	// SLO config (objectives are YAML-only)
+	bindEnv("slo.fast_burn_threshold")
+	bindEnv("slo.slow_burn_threshold")
+	bindEnv("slo.evaluation_interval")
+
+	// GeoIP config
+	bindEnv("geoip.database")
+	bindEnv("geoip.asn_database")
+
+	// Scheduler config (per-job schedules are YAML-only)
+	bindEnv("scheduler.enabled")
+	bindEnv("scheduler.snapshot_dir")
+	bindEnv("scheduler.snapshot_keep")
+	bindEnv("scheduler.report_dir")
+	bindEnv("scheduler.report_keep")
+	bindEnv("scheduler.key_expiry_warning")
+
+	// Sensitive argument encryption (tool rules are YAML-only)
+	bindEnv("sensitive_arguments.key_path")
 
 	// Evidence config
 	bindEnv("evidence.enabled")
@@ -133,6 +238,112 @@ func bindNestedEnvKeys() {
 	bindEnv("webhook.url")
 	bindEnv("webhook.secret")
 	bindEnv("webhook.events") // L-46: Bind webhook.events for env var override
+	bindEnv("webhook.retries")
+	bindEnv("webhook.retry_backoff")
+	bindEnv("webhook.categories")
+	bindEnv("webhook.min_severity")
+	bindEnv("events.log.enabled")
+	bindEnv("events.log.events")
+	bindEnv("events.log.categories")
+	bindEnv("events.log.min_severity")
+	bindEnv("events.sink_queue_size")
+
+	// Cost accounting (the cost table is YAML-only)
+	bindEnv("cost_accounting.enabled")
+	bindEnv("cost_accounting.currency")
+	bindEnv("cost_accounting.webhook.url")
+	bindEnv("cost_accounting.webhook.secret")
+	bindEnv("cost_accounting.webhook.timeout")
+
+	// Admission control
+	bindEnv("cel.cost_limit")
+	bindEnv("cel.eval_timeout")
+	bindEnv("cel.max_complexity")
+	bindEnv("policy.engine")
+	bindEnv("policy.rego.url")
+	bindEnv("policy.rego.decision")
+	bindEnv("policy.rego.token")
+	bindEnv("policy.rego.timeout")
+	bindEnv("policy.rego.bundle_dir")
+	bindEnv("policy.rego.reload_interval")
+	bindEnv("policy.enrichment.url")
+	bindEnv("policy.enrichment.token")
+	bindEnv("policy.enrichment.timeout")
+	bindEnv("policy.enrichment.cache_ttl")
+	bindEnv("policy.enrichment.fail_closed")
+	bindEnv("admission.enabled")
+	bindEnv("admission.memory_threshold_mb")
+	bindEnv("admission.goroutine_threshold")
+	bindEnv("admission.check_interval")
+	bindEnv("admission.retry_after")
+
+	// MCP method handling (the rules are YAML-only)
+	bindEnv("mcp_methods.unknown_action")
+	bindEnv("mcp_methods.unknown_upstream")
+
+	// Taint tracking
+	bindEnv("taint_tracking.enabled")
+	bindEnv("taint_tracking.window_size")
+	bindEnv("taint_tracking.max_fingerprints_per_session")
+
+	// Anomaly detection (per-tool overrides are YAML-only)
+	bindEnv("anomaly_detection.enabled")
+	bindEnv("anomaly_detection.warmup_calls")
+	bindEnv("anomaly_detection.warmup_period")
+	bindEnv("anomaly_detection.threshold")
+
+	// Content inspection
+	bindEnv("content_inspection.chunk_size")
+	bindEnv("content_inspection.overlap")
+	bindEnv("content_inspection.max_scan_bytes")
+
+	// Session store
+	bindEnv("session.store")
+	bindEnv("session.redis.address")
+	bindEnv("session.redis.username")
+	bindEnv("session.redis.password")
+	bindEnv("session.redis.db")
+	bindEnv("session.redis.key_prefix")
+	bindEnv("session.redis.tls")
+	bindEnv("session.redis.timeout")
+	bindEnv("session.redis.pool_size")
+
+	// State store
+	bindEnv("state.backend")
+	bindEnv("state.postgres.address")
+	bindEnv("state.postgres.user")
+	bindEnv("state.postgres.password")
+	bindEnv("state.postgres.database")
+	bindEnv("state.postgres.table")
+	bindEnv("state.postgres.tls")
+	bindEnv("state.postgres.timeout")
+	bindEnv("state.postgres.pool_size")
+
+	bindEnv("approval.default_timeout")
+	bindEnv("approval.max_pending")
+	bindEnv("approval.grant_duration")
+	bindEnv("approval.max_grant_duration")
+
+	// Token exchange config
+	bindEnv("token_exchange.enabled")
+	bindEnv("token_exchange.header")
+	bindEnv("token_exchange.jwt.issuer")
+	bindEnv("token_exchange.jwt.jwks_url")
+	bindEnv("token_exchange.introspection_url")
+	bindEnv("token_exchange.token_url")
+	bindEnv("token_exchange.client_id")
+	bindEnv("token_exchange.client_secret")
+	bindEnv("token_exchange.cache_ttl")
+	// Note: token_exchange.upstreams is an array, use the config file
+
+	// OIDC client authentication (role mappings are YAML-only)
+	bindEnv("auth.oidc.enabled")
+	bindEnv("auth.oidc.issuer")
+	bindEnv("auth.oidc.jwks_url")
+	bindEnv("auth.oidc.identity_claim")
+	bindEnv("auth.oidc.name_claim")
+	bindEnv("auth.oidc.roles_claim")
+	bindEnv("auth.oidc.clock_skew")
 
 	// Note: policies is an array, complex to override via env
 	// Users should use config file for policies
@@ -192,6 +403,24 @@ func applyPreDefaults(cfg *OSSConfig) {
 	if viper.IsSet("evidence.enabled") {
 		cfg.evidenceEnabledExplicit = true
 	}
+	if viper.IsSet("watchdog.enabled") {
+		cfg.watchdogEnabledExplicit = true
+	}
+	if viper.IsSet("url_extraction.enabled") {
+		cfg.urlExtractionEnabledExplicit = true
+	}
+	if viper.IsSet("url_extraction.command_strings") {
+		cfg.urlExtractionCommandsExplicit = true
+	}
+	if viper.IsSet("webhook.retries") {
+		cfg.webhookRetriesExplicit = true
+	}
+	if viper.IsSet("scheduler.enabled") {
+		cfg.schedulerEnabledExplicit = true
+	}
+	if viper.IsSet("deny_loop.enabled") {
+		cfg.denyLoopEnabledExplicit = true
+	}
 }
 
 // ConfigFileUsed returns the path to the configuration file that was loaded.
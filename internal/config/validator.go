@@ -1,9 +1,19 @@
 package config
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"path"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,11 +27,44 @@ func RegisterCustomValidators(v *validator.Validate) error {
 	if err := v.RegisterValidation("audit_output", validateAuditOutput); err != nil {
 		return fmt.Errorf("failed to register audit_output validator: %w", err)
 	}
+	// listen_addr: validates "host:port" where host may be empty, an IPv4 or
+	// bracketed IPv6 address, or a host name
+	if err := v.RegisterValidation("listen_addr", validateListenAddr); err != nil {
+		return fmt.Errorf("failed to register listen_addr validator: %w", err)
+	}
 	return nil
 }
 
+// validateListenAddr validates a listen address such as "127.0.0.1:8080",
+// "[::]:8080", "[fe80::1%eth0]:8080", ":8080" or "localhost:8080".
+func validateListenAddr(fl validator.FieldLevel) bool {
+	host, port, err := net.SplitHostPort(fl.Field().String())
+	if err != nil {
+		return false
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return false
+	}
+	if host == "" {
+		return true
+	}
+	if _, err := netip.ParseAddr(host); err == nil {
+		return true
+	}
+	if len(host) > 253 {
+		return false
+	}
+	for _, r := range host {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
 // validateAuditOutput validates the audit output field.
-// Valid values: "stdout" or "file://<absolute-path>"
+// Valid values: "stdout", "file://<absolute-path>", "syslog://host:port" or
+// "tcp+tls://host:port"
 func validateAuditOutput(fl validator.FieldLevel) bool {
 	output := fl.Field().String()
 
@@ -38,6 +81,13 @@ func validateAuditOutput(fl validator.FieldLevel) bool {
 		return path != "" && (filepath.IsAbs(path) || strings.HasPrefix(path, "/"))
 	}
 
+	// "syslog://host:port" and "tcp+tls://host:port[?ca_file=...]" stream
+	// to a syslog collector.
+	if strings.HasPrefix(output, "syslog://") || strings.HasPrefix(output, "tcp+tls://") {
+		u, err := url.Parse(output)
+		return err == nil && u.Hostname() != "" && u.Port() != ""
+	}
+
 	return false
 }
 
@@ -77,6 +127,118 @@ func (c *OSSConfig) Validate() error {
 		return err
 	}
 
+	if err := c.validateAuditPayloads(); err != nil {
+		return err
+	}
+
+	if err := c.validateTokenExchange(); err != nil {
+		return err
+	}
+
+	if err := c.validateOIDC(); err != nil {
+		return err
+	}
+
+	if err := c.validateWatchdog(); err != nil {
+		return err
+	}
+
+	if err := c.validateSLO(); err != nil {
+		return err
+	}
+
+	if err := c.validateAllowedOrigins(); err != nil {
+		return err
+	}
+
+	if err := c.validateListenAddrs(); err != nil {
+		return err
+	}
+
+	if err := c.validateServerTLS(); err != nil {
+		return err
+	}
+
+	if err := c.validateAdminUI(); err != nil {
+		return err
+	}
+
+	if err := c.validateCorrelation(); err != nil {
+		return err
+	}
+
+	if err := c.validateEgressTLS(); err != nil {
+		return err
+	}
+
+	if err := c.validateDNS(); err != nil {
+		return err
+	}
+
+	if err := c.validateWebhookEndpoints(); err != nil {
+		return err
+	}
+
+	if err := c.validateEventFilters(); err != nil {
+		return err
+	}
+
+	if err := c.validateRateLimitOverrides(); err != nil {
+		return err
+	}
+
+	if err := c.validateSensitiveArguments(); err != nil {
+		return err
+	}
+
+	if err := c.validateResponseGuard(); err != nil {
+		return err
+	}
+
+	if err := c.validateCostAccounting(); err != nil {
+		return err
+	}
+
+	if err := c.validateMCPMethods(); err != nil {
+		return err
+	}
+
+	if err := c.validateAnomalyDetection(); err != nil {
+		return err
+	}
+
+	if err := c.validateContentInspection(); err != nil {
+		return err
+	}
+
+	if err := c.validateUpstreamNotifications(); err != nil {
+		return err
+	}
+
+	if err := c.validateCEL(); err != nil {
+		return err
+	}
+
+	if err := c.validatePolicyEngine(); err != nil {
+		return err
+	}
+
+	if err := c.validateAdmission(); err != nil {
+		return err
+	}
+
+	if err := c.validateSessionStore(); err != nil {
+		return err
+	}
+
+	if err := c.validateStateStore(); err != nil {
+		return err
+	}
+
+	if err := c.validateApproval(); err != nil {
+		return err
+	}
+
 	// L-42: Convert relative evidence paths to absolute for consistent resolution.
 	c.resolveEvidencePaths()
 
@@ -143,17 +305,22 @@ func formatSingleValidationError(e validator.FieldError) string {
 	case "required":
 		return fmt.Sprintf("%s is required", field)
 	case "min":
+		if e.Kind() == reflect.Int64 {
+			return fmt.Sprintf("%s must be at least %s", field, e.Param())
+		}
 		return fmt.Sprintf("%s must have at least %s items", field, e.Param())
+	case "max":
+		return fmt.Sprintf("%s must be at most %s", field, e.Param())
 	case "oneof":
 		return fmt.Sprintf("%s must be one of: %s", field, e.Param())
 	case "startswith":
 		return fmt.Sprintf("%s must start with %q", field, e.Param())
 	case "url":
 		return fmt.Sprintf("%s must be a valid URL", field)
-	case "hostname_port":
+	case "hostname_port", "listen_addr":
 		return fmt.Sprintf("%s must be a valid host:port", field)
 	case "audit_output":
-		return fmt.Sprintf("%s must be 'stdout' or 'file://<absolute-path>'", field)
+		return fmt.Sprintf("%s must be 'stdout', 'file://<absolute-path>', 'syslog://host:port' or 'tcp+tls://host:port'", field)
 	default:
 		return fmt.Sprintf("%s failed validation: %s", field, tag)
 	}
@@ -184,11 +351,42 @@ func (c *OSSConfig) validateDurations() error {
 		value string
 	}{
 		{"server.session_timeout", c.Server.SessionTimeout},
+		{"server.sse.overflow_ttl", c.Server.SSE.OverflowTTL},
+		{"server.websocket.ping_interval", c.Server.WebSocket.PingInterval},
+		{"server.tls.reload_interval", c.Server.TLS.ReloadInterval},
 		{"upstream.http_timeout", c.Upstream.HTTPTimeout},
+		{"upstream.lazy_start_timeout", c.Upstream.LazyStartTimeout},
+		{"upstream.lazy_idle_timeout", c.Upstream.LazyIdleTimeout},
 		{"audit.flush_interval", c.Audit.FlushInterval},
 		{"audit.send_timeout", c.Audit.SendTimeout},
 		{"rate_limit.cleanup_interval", c.RateLimit.CleanupInterval},
 		{"rate_limit.max_ttl", c.RateLimit.MaxTTL},
+		{"policy_directory.reload_interval", c.PolicyDirectory.ReloadInterval},
+		{"deny_loop.window", c.DenyLoop.Window},
+		{"deny_loop.base_backoff", c.DenyLoop.BaseBackoff},
+		{"deny_loop.max_backoff", c.DenyLoop.MaxBackoff},
+		{"token_exchange.cache_ttl", c.TokenExchange.CacheTTL},
+		{"auth.oidc.clock_skew", c.Auth.OIDC.ClockSkew},
+		{"watchdog.check_interval", c.Watchdog.CheckInterval},
+		{"slo.evaluation_interval", c.SLO.EvaluationInterval},
+		{"webhook.retry_backoff", c.Webhook.RetryBackoff},
+		{"cel.eval_timeout", c.CEL.EvalTimeout},
+		{"policy.rego.timeout", c.Policy.Rego.Timeout},
+		{"policy.rego.reload_interval", c.Policy.Rego.ReloadInterval},
+		{"policy.enrichment.timeout", c.Policy.Enrichment.Timeout},
+		{"policy.enrichment.cache_ttl", c.Policy.Enrichment.CacheTTL},
+		{"admission.check_interval", c.Admission.CheckInterval},
+		{"admission.retry_after", c.Admission.RetryAfter},
+		{"session.redis.timeout", c.Session.Redis.Timeout},
+		{"rate_limit.redis.timeout", c.RateLimit.Redis.Timeout},
+		{"state.postgres.timeout", c.State.Postgres.Timeout},
+		{"audit_file.checkpoint_interval", c.AuditFile.CheckpointInterval},
+		{"approval.default_timeout", c.Approval.DefaultTimeout},
+		{"approval.grant_duration", c.Approval.GrantDuration},
+		{"approval.max_grant_duration", c.Approval.MaxGrantDuration},
+		{"dns.cache.max_ttl", c.DNS.Cache.MaxTTL},
+		{"dns.cache.negative_ttl", c.DNS.Cache.NegativeTTL},
+		{"dns.revalidate_interval", c.DNS.RevalidateInterval},
 	}
 	for _, chk := range checks {
 		if err := validateDuration(chk.field, chk.value); err != nil {
@@ -207,6 +405,650 @@ func (c *OSSConfig) validateAuditFileConfig() error {
 	if c.AuditFile.MaxFileSizeMB < 0 {
 		return fmt.Errorf("audit_file.max_file_size_mb must be >= 0, got %d", c.AuditFile.MaxFileSizeMB)
 	}
+	if c.AuditFile.MaxTotalSizeMB < 0 {
+		return fmt.Errorf("audit_file.max_total_size_mb must be >= 0, got %d", c.AuditFile.MaxTotalSizeMB)
+	}
+	if d, err := time.ParseDuration(c.AuditFile.CheckpointInterval); err == nil && d < 0 {
+		return fmt.Errorf("audit_file.checkpoint_interval must be >= 0, got %s", c.AuditFile.CheckpointInterval)
+	}
+	return nil
+}
+
+// validateAuditPayloads checks the tool patterns of payload sampling.
+func (c *OSSConfig) validateAuditPayloads() error {
+	for i, t := range c.AuditPayloads.Tools {
+		if _, err := path.Match(t.Tool, ""); err != nil {
+			return fmt.Errorf("audit_payloads.tools[%d].tool: invalid glob pattern %q", i, t.Tool)
+		}
+	}
+	return nil
+}
+
+// validateTokenExchange checks cross-field token exchange requirements.
+func (c *OSSConfig) validateTokenExchange() error {
+	if !c.TokenExchange.Enabled {
+		return nil
+	}
+	if c.TokenExchange.JWT.Issuer == "" && c.TokenExchange.IntrospectionURL == "" {
+		return fmt.Errorf("token_exchange requires jwt.issuer or introspection_url to verify user tokens")
+	}
+	if c.TokenExchange.JWT.Issuer != "" && len(c.TokenExchange.JWT.Audiences) == 0 {
+		return fmt.Errorf("token_exchange.jwt.audiences must list at least one audience")
+	}
+	seen := make(map[string]struct{}, len(c.TokenExchange.Upstreams))
+	for i, u := range c.TokenExchange.Upstreams {
+		if _, dup := seen[u.Upstream]; dup {
+			return fmt.Errorf("token_exchange.upstreams[%d]: duplicate upstream %q", i, u.Upstream)
+		}
+		seen[u.Upstream] = struct{}{}
+		if u.Mode == "exchange" && c.TokenExchange.TokenURL == "" {
+			return fmt.Errorf("token_exchange.upstreams[%d]: mode \"exchange\" requires token_exchange.token_url", i)
+		}
+	}
+	return nil
+}
+
+// validateOIDC checks that an enabled OIDC provider has an issuer and an
+// audience.
+func (c *OSSConfig) validateOIDC() error {
+	o := c.Auth.OIDC
+	if !o.Enabled {
+		return nil
+	}
+	if o.Issuer == "" {
+		return fmt.Errorf("auth.oidc.issuer is required when auth.oidc.enabled is true")
+	}
+	if len(o.Audiences) == 0 {
+		return fmt.Errorf("auth.oidc.audiences must list at least one audience")
+	}
+	return nil
+}
+
+// watchdogStages lists the stage names accepted in watchdog.thresholds.
+var watchdogStages = map[string]struct{}{
+	"normalize": {}, "policy": {}, "outbound_dns": {}, "approval": {}, "upstream": {},
+}
+
+// validateWatchdog checks watchdog threshold keys and durations.
+func (c *OSSConfig) validateWatchdog() error {
+	for stage, value := range c.Watchdog.Thresholds {
+		if _, ok := watchdogStages[stage]; !ok {
+			return fmt.Errorf("watchdog.thresholds: unknown stage %q (valid: normalize, policy, outbound_dns, approval, upstream)", stage)
+		}
+		if err := validateDuration("watchdog.thresholds."+stage, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateAllowedOrigins checks that server and API key origin lists hold
+// bare origins (scheme://host[:port]), the form browsers send in Origin.
+func (c *OSSConfig) validateAllowedOrigins() error {
+	for i, origin := range c.Server.AllowedOrigins {
+		if err := validateOrigin(origin); err != nil {
+			return fmt.Errorf("server.allowed_origins[%d]: %w", i, err)
+		}
+	}
+	for i, key := range c.Auth.APIKeys {
+		for j, origin := range key.AllowedOrigins {
+			if err := validateOrigin(origin); err != nil {
+				return fmt.Errorf("api_keys[%d].allowed_origins[%d]: %w", i, j, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateListenAddrs rejects listen addresses that appear more than once;
+// the second bind would fail at startup.
+func (c *OSSConfig) validateListenAddrs() error {
+	seen := map[string]bool{c.Server.HTTPAddr: true}
+	for i, addr := range c.Server.ExtraHTTPAddrs {
+		if seen[addr] {
+			return fmt.Errorf("server.extra_http_addrs[%d]: duplicate listen address %q", i, addr)
+		}
+		seen[addr] = true
+	}
+	return nil
+}
+
+// validateOrigin checks a single browser origin.
+func validateOrigin(origin string) error {
+	u, err := url.Parse(strings.TrimSuffix(origin, "/"))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+		u.User != nil || u.Path != "" || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("invalid origin %q: must be http(s)://host[:port]", origin)
+	}
+	return nil
+}
+
+// validateSLO checks SLO names are unique and latency SLOs have a threshold.
+func (c *OSSConfig) validateSLO() error {
+	seen := make(map[string]struct{}, len(c.SLO.Objectives))
+	for i, o := range c.SLO.Objectives {
+		if _, dup := seen[o.Name]; dup {
+			return fmt.Errorf("slo.objectives[%d]: duplicate name %q", i, o.Name)
+		}
+		seen[o.Name] = struct{}{}
+		field := fmt.Sprintf("slo.objectives[%d].latency_threshold", i)
+		if o.Type == "latency" && o.LatencyThreshold == "" {
+			return fmt.Errorf("%s is required for latency SLOs", field)
+		}
+		if err := validateDuration(field, o.LatencyThreshold); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateWebhookEndpoints rejects duplicate endpoint names; "default" is
+// reserved for the top-level webhook.url.
+func (c *OSSConfig) validateWebhookEndpoints() error {
+	seen := map[string]struct{}{"default": {}}
+	for i, ep := range c.Webhook.Endpoints {
+		if _, dup := seen[ep.Name]; dup {
+			return fmt.Errorf("webhook.endpoints[%d]: duplicate name %q", i, ep.Name)
+		}
+		seen[ep.Name] = struct{}{}
+	}
+	return nil
+}
+
+// eventCategories are the categories accepted in event filters.
+var eventCategories = map[string]bool{
+	"security": true, "lifecycle": true, "admin": true, "audit_overflow": true, "other": true,
+}
+
+// validateEventFilters checks the categories and minimum severities of the
+// webhook and event sink filters.
+func (c *OSSConfig) validateEventFilters() error {
+	check := func(field string, categories []string, minSeverity string) error {
+		for _, cat := range categories {
+			if !eventCategories[cat] {
+				return fmt.Errorf("%s.categories: unknown category %q (valid: security, lifecycle, admin, audit_overflow, other)", field, cat)
+			}
+		}
+		switch minSeverity {
+		case "", "info", "warning", "critical":
+			return nil
+		}
+		return fmt.Errorf("%s.min_severity: must be info, warning or critical, got %q", field, minSeverity)
+	}
+	if err := check("webhook", c.Webhook.Categories, c.Webhook.MinSeverity); err != nil {
+		return err
+	}
+	for i, ep := range c.Webhook.Endpoints {
+		if err := check(fmt.Sprintf("webhook.endpoints[%d]", i), ep.Categories, ep.MinSeverity); err != nil {
+			return err
+		}
+	}
+	return check("events.log", c.Events.Log.Categories, c.Events.Log.MinSeverity)
+}
+
+// validateRateLimitOverrides checks that every override names an identity
+// or a valid tool pattern, and that no two cover the same identity and tool.
+func (c *OSSConfig) validateRateLimitOverrides() error {
+	seen := make(map[[2]string]bool, len(c.RateLimit.Overrides))
+	for i, o := range c.RateLimit.Overrides {
+		if o.Identity == "" && o.Tool == "" {
+			return fmt.Errorf("rate_limit.overrides[%d]: identity or tool is required", i)
+		}
+		if _, err := path.Match(o.Tool, ""); err != nil {
+			return fmt.Errorf("rate_limit.overrides[%d]: invalid tool pattern %q", i, o.Tool)
+		}
+		key := [2]string{o.Identity, o.Tool}
+		if seen[key] {
+			return fmt.Errorf("rate_limit.overrides[%d]: duplicate override for identity %q and tool %q", i, o.Identity, o.Tool)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// validateSensitiveArguments checks tool patterns and argument paths.
+func (c *OSSConfig) validateSensitiveArguments() error {
+	for i, t := range c.SensitiveArguments.Tools {
+		if _, err := path.Match(t.Tool, ""); err != nil {
+			return fmt.Errorf("sensitive_arguments.tools[%d]: invalid tool pattern %q", i, t.Tool)
+		}
+		for _, p := range t.Paths {
+			for _, seg := range strings.Split(p, ".") {
+				if seg == "" {
+					return fmt.Errorf("sensitive_arguments.tools[%d]: invalid argument path %q", i, p)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// validateResponseGuard rejects tools guarded more than once and arguments
+// that are not a JSON object.
+func (c *OSSConfig) validateResponseGuard() error {
+	seen := make(map[string]struct{}, len(c.ResponseGuard.Tools))
+	for i, t := range c.ResponseGuard.Tools {
+		if _, dup := seen[t.Tool]; dup {
+			return fmt.Errorf("response_guard.tools[%d]: duplicate tool %q", i, t.Tool)
+		}
+		seen[t.Tool] = struct{}{}
+		if t.Arguments != "" {
+			var args map[string]interface{}
+			if err := json.Unmarshal([]byte(t.Arguments), &args); err != nil || args == nil {
+				return fmt.Errorf("response_guard.tools[%d]: arguments must be a JSON object", i)
+			}
+		}
+	}
+	return nil
+}
+
+// validateServerTLS requires the certificate and key to be set together
+// and to be readable.
+func (c *OSSConfig) validateServerTLS() error {
+	t := c.Server.TLS
+	if t.CertFile == "" && t.KeyFile == "" {
+		return nil
+	}
+	if t.CertFile == "" || t.KeyFile == "" {
+		return fmt.Errorf("server.tls: cert_file and key_file must be set together")
+	}
+	for _, f := range []struct{ field, path string }{{"cert_file", t.CertFile}, {"key_file", t.KeyFile}} {
+		file, err := os.Open(f.path)
+		if err != nil {
+			return fmt.Errorf("server.tls.%s: %w", f.field, err)
+		}
+		_ = file.Close()
+	}
+	return nil
+}
+
+// validateEgressTLS requires each egress TLS destination to name a domain
+// once and to set a readable CA file or well-formed SPKI pins.
+func (c *OSSConfig) validateEgressTLS() error {
+	seen := make(map[string]bool, len(c.EgressTLS.Destinations))
+	for i, d := range c.EgressTLS.Destinations {
+		field := fmt.Sprintf("egress_tls.destinations[%d]", i)
+		domain := strings.ToLower(d.Domain)
+		if seen[domain] {
+			return fmt.Errorf("%s: duplicate domain %q", field, d.Domain)
+		}
+		seen[domain] = true
+		if strings.Contains(strings.TrimPrefix(domain, "*."), "*") {
+			return fmt.Errorf("%s.domain: only a leading \"*.\" wildcard is supported", field)
+		}
+		if d.CAFile == "" && len(d.SPKIPins) == 0 {
+			return fmt.Errorf("%s: set ca_file, spki_pins or both", field)
+		}
+		if d.CAFile != "" {
+			file, err := os.Open(d.CAFile)
+			if err != nil {
+				return fmt.Errorf("%s.ca_file: %w", field, err)
+			}
+			_ = file.Close()
+		}
+		for j, pin := range d.SPKIPins {
+			encoded, ok := strings.CutPrefix(pin, "sha256/")
+			raw, err := base64.StdEncoding.DecodeString(encoded)
+			if !ok || err != nil || len(raw) != 32 {
+				return fmt.Errorf("%s.spki_pins[%d]: must be \"sha256/\" followed by a base64 SHA-256 hash", field, j)
+			}
+		}
+	}
+	return nil
+}
+
+// validateDNS requires each DNS server to be a DNS-over-HTTPS or
+// DNS-over-TLS URL, with bootstrap IP addresses when its host is a name,
+// and a positive maximum TTL for the cache.
+func (c *OSSConfig) validateDNS() error {
+	for i, s := range c.DNS.Servers {
+		field := fmt.Sprintf("dns.servers[%d]", i)
+		u, err := url.Parse(s.URL)
+		if err != nil || u.Hostname() == "" || (u.Scheme != "https" && u.Scheme != "tls") {
+			return fmt.Errorf("%s.url: must be an https:// (DNS-over-HTTPS) or tls:// (DNS-over-TLS) URL", field)
+		}
+		for j, b := range s.Bootstrap {
+			if net.ParseIP(b) == nil {
+				return fmt.Errorf("%s.bootstrap[%d]: %q is not an IP address", field, j, b)
+			}
+		}
+		if len(s.Bootstrap) == 0 && net.ParseIP(u.Hostname()) == nil {
+			return fmt.Errorf("%s.bootstrap: required when the server host is a name", field)
+		}
+	}
+	if c.DNS.Cache.Enabled {
+		if d, err := time.ParseDuration(c.DNS.Cache.MaxTTL); err == nil && d <= 0 {
+			return fmt.Errorf("dns.cache.max_ttl: must be positive")
+		}
+	}
+	return nil
+}
+
+// validateAdminUI requires a directory holding index.html in "directory"
+// mode and a single-line Content-Security-Policy.
+func (c *OSSConfig) validateAdminUI() error {
+	ui := c.Server.AdminUI
+	if strings.ContainsAny(ui.ContentSecurityPolicy, "\r\n") {
+		return fmt.Errorf("server.admin_ui.content_security_policy: must be a single line")
+	}
+	if ui.Mode != "directory" {
+		if ui.Directory != "" {
+			return fmt.Errorf("server.admin_ui.directory: only used with mode directory")
+		}
+		return nil
+	}
+	if ui.Directory == "" {
+		return fmt.Errorf("server.admin_ui: mode directory requires directory")
+	}
+	info, err := os.Stat(ui.Directory)
+	if err != nil {
+		return fmt.Errorf("server.admin_ui.directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("server.admin_ui.directory: %s is not a directory", ui.Directory)
+	}
+	if _, err := os.Stat(filepath.Join(ui.Directory, "index.html")); err != nil {
+		return fmt.Errorf("server.admin_ui.directory: %w", err)
+	}
+	return nil
+}
+
+// validateCorrelation checks that the correlation header is a valid HTTP
+// header name. Empty values are replaced by defaults.
+func (c *OSSConfig) validateCorrelation() error {
+	header := c.Server.Correlation.Header
+	for _, r := range header {
+		if !strings.ContainsRune("!#$%&'*+-.^_`|~", r) &&
+			(r < '0' || r > '9') && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return fmt.Errorf("server.correlation.header: %q is not a valid header name", header)
+		}
+	}
+	return nil
+}
+
+// validateCostAccounting checks cost table patterns and the webhook settings.
+func (c *OSSConfig) validateCostAccounting() error {
+	ca := c.CostAccounting
+	for i, r := range ca.Table {
+		if _, err := path.Match(r.Tool, ""); err != nil {
+			return fmt.Errorf("cost_accounting.table[%d]: invalid tool pattern %q", i, r.Tool)
+		}
+		if _, err := path.Match(r.Upstream, ""); err != nil {
+			return fmt.Errorf("cost_accounting.table[%d]: invalid upstream pattern %q", i, r.Upstream)
+		}
+	}
+	if ca.Webhook.URL == "" {
+		return nil
+	}
+	if !strings.HasPrefix(ca.Webhook.URL, "http://") && !strings.HasPrefix(ca.Webhook.URL, "https://") {
+		return fmt.Errorf("cost_accounting.webhook.url: only http:// and https:// URLs are allowed")
+	}
+	if s := ca.Webhook.Secret; s != "" && len(s) < 32 {
+		return fmt.Errorf("cost_accounting.webhook.secret: must be at least 32 characters")
+	}
+	if d, err := time.ParseDuration(ca.Webhook.Timeout); err != nil || d <= 0 {
+		return fmt.Errorf("cost_accounting.webhook.timeout: invalid duration %q", ca.Webhook.Timeout)
+	}
+	return nil
+}
+
+// celMacros are the CEL macros that can be banned.
+var celMacros = map[string]bool{
+	"has": true, "all": true, "exists": true, "exists_one": true, "map": true, "filter": true,
+}
+
+// validateCEL checks banned macro names and the evaluation timeout.
+func (c *OSSConfig) validateCEL() error {
+	for i, m := range c.CEL.BannedMacros {
+		if !celMacros[m] {
+			return fmt.Errorf("cel.banned_macros[%d]: unknown macro %q (valid: has, all, exists, exists_one, map, filter)", i, m)
+		}
+	}
+	for i, fn := range c.CEL.BannedFunctions {
+		if strings.TrimSpace(fn) == "" {
+			return fmt.Errorf("cel.banned_functions[%d]: must not be empty", i)
+		}
+	}
+	if d, err := time.ParseDuration(c.CEL.EvalTimeout); err == nil && d <= 0 {
+		return fmt.Errorf("cel.eval_timeout: must be positive")
+	}
+	return nil
+}
+
+// validatePolicyEngine checks the OPA server settings when the rego engine
+// is selected, and the enrichment endpoint when one is configured.
+func (c *OSSConfig) validatePolicyEngine() error {
+	if err := c.validatePolicyEnrichment(); err != nil {
+		return err
+	}
+	if c.Policy.Engine != "rego" {
+		return nil
+	}
+	r := c.Policy.Rego
+	u, err := url.Parse(r.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("policy.rego.url: must be an http or https URL, got %q", r.URL)
+	}
+	decision := strings.Trim(r.Decision, "/")
+	if decision == "" || strings.Contains(decision, "..") || strings.ContainsAny(decision, "?# ") {
+		return fmt.Errorf("policy.rego.decision: invalid path %q", r.Decision)
+	}
+	if d, err := time.ParseDuration(r.Timeout); err == nil && d <= 0 {
+		return fmt.Errorf("policy.rego.timeout: must be positive")
+	}
+	if r.BundleDir != "" {
+		info, err := os.Stat(r.BundleDir)
+		if err != nil {
+			return fmt.Errorf("policy.rego.bundle_dir: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("policy.rego.bundle_dir: %s is not a directory", r.BundleDir)
+		}
+	}
+	return nil
+}
+
+// validatePolicyEnrichment checks the enrichment endpoint and its timeout.
+func (c *OSSConfig) validatePolicyEnrichment() error {
+	e := c.Policy.Enrichment
+	if e.URL == "" {
+		return nil
+	}
+	u, err := url.Parse(e.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("policy.enrichment.url: must be an http or https URL, got %q", e.URL)
+	}
+	if d, err := time.ParseDuration(e.Timeout); err == nil && d <= 0 {
+		return fmt.Errorf("policy.enrichment.timeout: must be positive")
+	}
+	for _, name := range e.Arguments {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("policy.enrichment.arguments: names must not be empty")
+		}
+	}
+	return nil
+}
+
+// validateAdmission requires a threshold when admission control is enabled.
+func (c *OSSConfig) validateAdmission() error {
+	a := c.Admission
+	if !a.Enabled {
+		return nil
+	}
+	if a.MemoryThresholdMB <= 0 && a.GoroutineThreshold <= 0 {
+		return fmt.Errorf("admission: memory_threshold_mb or goroutine_threshold is required when enabled")
+	}
+	if d, err := time.ParseDuration(a.CheckInterval); err == nil && d <= 0 {
+		return fmt.Errorf("admission.check_interval: must be positive")
+	}
+	return nil
+}
+
+// validateSessionStore checks the Redis address when sessions or rate
+// limits are stored in Redis.
+func (c *OSSConfig) validateSessionStore() error {
+	if c.Session.Store == "redis" {
+		if err := validateRedis("session.redis", c.Session.Redis); err != nil {
+			return err
+		}
+	}
+	if c.RateLimit.Store == "redis" {
+		if err := validateRedis("rate_limit.redis", c.RateLimit.Redis); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pgTableName matches a PostgreSQL table name, optionally qualified by its
+// schema.
+var pgTableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// validateStateStore checks the PostgreSQL settings when the state is
+// stored in PostgreSQL.
+func (c *OSSConfig) validateStateStore() error {
+	if c.State.Backend != "postgres" {
+		return nil
+	}
+	p := c.State.Postgres
+	if _, _, err := net.SplitHostPort(p.Address); err != nil {
+		return fmt.Errorf("state.postgres.address: %w", err)
+	}
+	if p.User == "" {
+		return fmt.Errorf("state.postgres.user: required")
+	}
+	if p.Table != "" && !pgTableName.MatchString(p.Table) {
+		return fmt.Errorf("state.postgres.table: invalid table name %q", p.Table)
+	}
+	if d, err := time.ParseDuration(p.Timeout); err == nil && d <= 0 {
+		return fmt.Errorf("state.postgres.timeout: must be positive")
+	}
+	return nil
+}
+
+// validateApproval requires positive approval durations and a grant
+// duration within the maximum.
+func (c *OSSConfig) validateApproval() error {
+	a := c.Approval
+	if d, err := time.ParseDuration(a.DefaultTimeout); err == nil && d <= 0 {
+		return fmt.Errorf("approval.default_timeout: must be positive")
+	}
+	grant, err := time.ParseDuration(a.GrantDuration)
+	if err == nil && grant <= 0 {
+		return fmt.Errorf("approval.grant_duration: must be positive")
+	}
+	maxGrant, maxErr := time.ParseDuration(a.MaxGrantDuration)
+	if maxErr == nil && maxGrant <= 0 {
+		return fmt.Errorf("approval.max_grant_duration: must be positive")
+	}
+	if err == nil && maxErr == nil && grant > maxGrant {
+		return fmt.Errorf("approval.grant_duration %s exceeds approval.max_grant_duration %s", a.GrantDuration, a.MaxGrantDuration)
+	}
+	return nil
+}
+
+// validateRedis checks the connection settings of a Redis store.
+func validateRedis(field string, r RedisConfig) error {
+	if _, _, err := net.SplitHostPort(r.Address); err != nil {
+		return fmt.Errorf("%s.address: %w", field, err)
+	}
+	if d, err := time.ParseDuration(r.Timeout); err == nil && d <= 0 {
+		return fmt.Errorf("%s.timeout: must be positive", field)
+	}
+	return nil
+}
+
+// validateMCPMethods checks method patterns, actions and route targets.
+// validateAnomalyDetection checks the warm-up periods and the tool
+// patterns of anomaly_detection.
+func (c *OSSConfig) validateAnomalyDetection() error {
+	a := c.AnomalyDetection
+	if err := validateDuration("anomaly_detection.warmup_period", a.WarmupPeriod); err != nil {
+		return err
+	}
+	for i, t := range a.Tools {
+		if t.Tool == "" {
+			return fmt.Errorf("anomaly_detection.tools[%d]: tool is required", i)
+		}
+		if _, err := path.Match(t.Tool, ""); err != nil {
+			return fmt.Errorf("anomaly_detection.tools[%d]: invalid tool pattern %q", i, t.Tool)
+		}
+		if err := validateDuration(fmt.Sprintf("anomaly_detection.tools[%d].warmup_period", i), t.WarmupPeriod); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateContentInspection checks that the chunks of content_inspection
+// overlap by less than a chunk and fit under the size cap.
+func (c *OSSConfig) validateContentInspection() error {
+	ci := c.ContentInspection
+	if ci.ChunkSize > 0 && ci.Overlap >= ci.ChunkSize {
+		return fmt.Errorf("content_inspection.overlap (%d) must be smaller than content_inspection.chunk_size (%d)", ci.Overlap, ci.ChunkSize)
+	}
+	if ci.MaxScanBytes > 0 && ci.MaxScanBytes < int64(ci.ChunkSize) {
+		return fmt.Errorf("content_inspection.max_scan_bytes (%d) must be at least content_inspection.chunk_size (%d)", ci.MaxScanBytes, ci.ChunkSize)
+	}
+	return nil
+}
+
+func (c *OSSConfig) validateMCPMethods() error {
+	m := c.MCPMethods
+	switch m.UnknownAction {
+	case "", "deny", "forward":
+		if m.UnknownUpstream != "" {
+			return fmt.Errorf("mcp_methods.unknown_upstream: only valid with unknown_action \"route\"")
+		}
+	case "route":
+		if m.UnknownUpstream == "" {
+			return fmt.Errorf("mcp_methods.unknown_upstream: required with unknown_action \"route\"")
+		}
+	default:
+		return fmt.Errorf("mcp_methods.unknown_action: must be deny, forward or route, got %q", m.UnknownAction)
+	}
+	for i, r := range m.Rules {
+		if r.Method == "" {
+			return fmt.Errorf("mcp_methods.rules[%d]: method is required", i)
+		}
+		if _, err := path.Match(r.Method, ""); err != nil {
+			return fmt.Errorf("mcp_methods.rules[%d]: invalid method pattern %q", i, r.Method)
+		}
+		switch r.Action {
+		case "deny", "forward":
+		case "route":
+			if r.Upstream == "" {
+				return fmt.Errorf("mcp_methods.rules[%d]: upstream is required with action \"route\"", i)
+			}
+		case "local":
+			if r.Method != "ping" {
+				return fmt.Errorf("mcp_methods.rules[%d]: action \"local\" is only valid for ping", i)
+			}
+		default:
+			return fmt.Errorf("mcp_methods.rules[%d]: action must be forward, deny, route or local, got %q", i, r.Action)
+		}
+		if r.Upstream != "" && r.Action != "route" {
+			return fmt.Errorf("mcp_methods.rules[%d]: upstream is only valid with action \"route\"", i)
+		}
+	}
+	return nil
+}
+
+// validateUpstreamNotifications checks notification patterns and actions.
+func (c *OSSConfig) validateUpstreamNotifications() error {
+	for i, r := range c.UpstreamNotifications.Rules {
+		if r.Method == "" {
+			return fmt.Errorf("upstream_notifications.rules[%d]: method is required", i)
+		}
+		if _, err := path.Match(r.Method, ""); err != nil {
+			return fmt.Errorf("upstream_notifications.rules[%d]: invalid method pattern %q", i, r.Method)
+		}
+		switch r.Action {
+		case "forward", "latest", "drop":
+		default:
+			return fmt.Errorf("upstream_notifications.rules[%d]: action must be forward, latest or drop, got %q", i, r.Action)
+		}
+	}
 	return nil
 }
 
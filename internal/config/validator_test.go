@@ -1,6 +1,8 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -132,6 +134,28 @@ func TestValidate_InvalidAuditOutputRelativePath(t *testing.T) {
 	}
 }
 
+func TestValidate_AuditOutputSyslog(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		output  string
+		wantErr bool
+	}{
+		{"syslog://siem.internal:514", false},
+		{"tcp+tls://siem.internal:6514", false},
+		{"tcp+tls://10.0.0.5:6514?ca_file=/etc/ssl/siem-ca.pem", false},
+		{"syslog://siem.internal", true},
+		{"tcp+tls://:6514", true},
+	}
+	for _, tt := range tests {
+		cfg := minimalValidConfig()
+		cfg.Audit.Output = tt.output
+		if err := cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("Validate() with %q error = %v, wantErr %v", tt.output, err, tt.wantErr)
+		}
+	}
+}
+
 func TestValidate_UnknownIdentityReference(t *testing.T) {
 	t.Parallel()
 
@@ -275,3 +299,895 @@ func TestValidate_EmptyRules(t *testing.T) {
 		t.Fatal("Validate() expected error for empty rules, got nil")
 	}
 }
+
+func TestValidate_WatchdogThresholds(t *testing.T) {
+	t.Parallel()
+
+	cfg := minimalValidConfig()
+	cfg.Watchdog.Thresholds = map[string]string{"policy": "2s", "approval": "0s"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with valid watchdog thresholds unexpected error: %v", err)
+	}
+
+	cfg = minimalValidConfig()
+	cfg.Watchdog.Thresholds = map[string]string{"parsing": "2s"}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "unknown stage") {
+		t.Errorf("Validate() error = %v, want unknown stage error", err)
+	}
+
+	cfg = minimalValidConfig()
+	cfg.Watchdog.Thresholds = map[string]string{"upstream": "soon"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() expected error for invalid threshold duration, got nil")
+	}
+}
+
+func TestValidate_SLOObjectives(t *testing.T) {
+	t.Parallel()
+
+	valid := []SLOObjectiveConfig{
+		{Name: "gateway-availability", Target: "gateway", Type: "availability", Objective: 99.9},
+		{Name: "github-latency", Target: "github", Type: "latency", Objective: 99, LatencyThreshold: "500ms"},
+	}
+	cfg := minimalValidConfig()
+	cfg.SLO.Objectives = valid
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with valid SLOs unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		slo     SLOObjectiveConfig
+		wantErr string
+	}{
+		{"duplicate name", SLOObjectiveConfig{Name: "gateway-availability", Target: "gateway", Type: "availability", Objective: 99}, "duplicate name"},
+		{"latency without threshold", SLOObjectiveConfig{Name: "x", Target: "gateway", Type: "latency", Objective: 99}, "latency_threshold is required"},
+		{"invalid threshold", SLOObjectiveConfig{Name: "x", Target: "gateway", Type: "latency", Objective: 99, LatencyThreshold: "fast"}, "invalid duration"},
+		{"unknown type", SLOObjectiveConfig{Name: "x", Target: "gateway", Type: "errors", Objective: 99}, "Type"},
+		{"objective out of range", SLOObjectiveConfig{Name: "x", Target: "gateway", Type: "availability", Objective: 100}, "Objective"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := minimalValidConfig()
+			cfg.SLO.Objectives = append(append([]SLOObjectiveConfig{}, valid...), tt.slo)
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_AllowedOrigins(t *testing.T) {
+	t.Parallel()
+
+	cfg := minimalValidConfig()
+	cfg.Server.AllowedOrigins = []string{"https://app.example.com", "http://localhost:3000"}
+	cfg.Auth.APIKeys[0].AllowedOrigins = []string{"https://app.example.com/"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with valid origins unexpected error: %v", err)
+	}
+
+	cfg = minimalValidConfig()
+	cfg.Server.AllowedOrigins = []string{"app.example.com"}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "server.allowed_origins[0]") {
+		t.Errorf("Validate() error = %v, want server.allowed_origins error", err)
+	}
+
+	cfg = minimalValidConfig()
+	cfg.Auth.APIKeys[0].AllowedOrigins = []string{"https://app.example.com/path"}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "api_keys[0].allowed_origins[0]") {
+		t.Errorf("Validate() error = %v, want api_keys allowed_origins error", err)
+	}
+}
+
+func TestValidate_ExtraHTTPAddrs(t *testing.T) {
+	t.Parallel()
+
+	cfg := minimalValidConfig()
+	cfg.Server.HTTPAddr = "0.0.0.0:8080"
+	cfg.Server.ExtraHTTPAddrs = []string{"[::]:8080", "10.0.0.5:9090", "[fe80::1%eth0]:8080", "gw.internal:8080"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with valid extra addrs unexpected error: %v", err)
+	}
+
+	cfg = minimalValidConfig()
+	for _, bad := range []string{"not-an-addr", "127.0.0.1:http", "[::1]:70000", "bad_host:80"} {
+		cfg.Server.ExtraHTTPAddrs = []string{bad}
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Validate() should reject extra address %q", bad)
+		}
+	}
+
+	cfg = minimalValidConfig()
+	cfg.Server.HTTPAddr = "127.0.0.1:8080"
+	cfg.Server.ExtraHTTPAddrs = []string{"127.0.0.1:8080"}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "server.extra_http_addrs[0]") {
+		t.Errorf("Validate() error = %v, want duplicate address error", err)
+	}
+}
+
+func TestValidate_AuditFileMaxTotalSize(t *testing.T) {
+	t.Parallel()
+
+	cfg := minimalValidConfig()
+	cfg.AuditFile.Compress = true
+	cfg.AuditFile.MaxTotalSizeMB = 500
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with audit size cap unexpected error: %v", err)
+	}
+
+	cfg = minimalValidConfig()
+	cfg.AuditFile.MaxTotalSizeMB = -1
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "audit_file.max_total_size_mb") {
+		t.Errorf("Validate() error = %v, want audit_file.max_total_size_mb error", err)
+	}
+}
+
+func TestValidate_AuditFileChain(t *testing.T) {
+	t.Parallel()
+
+	cfg := minimalValidConfig()
+	cfg.AuditFile.Chain = true
+	cfg.AuditFile.CheckpointInterval = "15m"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with audit chain unexpected error: %v", err)
+	}
+
+	cfg.AuditFile.CheckpointInterval = "0s"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with checkpoints disabled unexpected error: %v", err)
+	}
+
+	for _, interval := range []string{"hourly", "-1h"} {
+		cfg = minimalValidConfig()
+		cfg.AuditFile.Chain = true
+		cfg.AuditFile.CheckpointInterval = interval
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "audit_file.checkpoint_interval") {
+			t.Errorf("Validate(%q) error = %v, want audit_file.checkpoint_interval error", interval, err)
+		}
+	}
+}
+
+func TestValidate_ServerSSE(t *testing.T) {
+	t.Parallel()
+
+	cfg := minimalValidConfig()
+	cfg.Server.SSE = SSEConfig{MaxLineSize: 4096, MaxEventSize: 1 << 20, OverflowTTL: "2m", Compression: true}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with valid SSE config unexpected error: %v", err)
+	}
+
+	cfg = minimalValidConfig()
+	cfg.Server.SSE.OverflowTTL = "later"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "server.sse.overflow_ttl") {
+		t.Errorf("Validate() error = %v, want server.sse.overflow_ttl error", err)
+	}
+
+	cfg = minimalValidConfig()
+	cfg.Server.SSE.MaxLineSize = 16
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "MaxLineSize") {
+		t.Errorf("Validate() error = %v, want MaxLineSize error", err)
+	}
+
+	cfg = minimalValidConfig()
+	cfg.Server.SSE.MaxEventSize = -1
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "MaxEventSize") {
+		t.Errorf("Validate() error = %v, want MaxEventSize error", err)
+	}
+
+	cfg = minimalValidConfig()
+	cfg.Server.SSE.ReplayBufferSize = 20000
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "ReplayBufferSize") {
+		t.Errorf("Validate() error = %v, want ReplayBufferSize error", err)
+	}
+
+	cfg = minimalValidConfig()
+	cfg.Server.SSE.Queue.DropPolicy = "random"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "DropPolicy") {
+		t.Errorf("Validate() error = %v, want DropPolicy error", err)
+	}
+}
+
+func TestValidate_WebhookEndpoints(t *testing.T) {
+	t.Parallel()
+
+	cfg := minimalValidConfig()
+	cfg.Webhook = WebhookConfig{Retries: 3, RetryBackoff: "1s", Endpoints: []WebhookEndpointConfig{
+		{Name: "pager", URL: "https://pager.example.com/hook", Events: []string{"upstream.*"}},
+		{Name: "chat", URL: "https://chat.example.com/hook"},
+	}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with valid endpoints unexpected error: %v", err)
+	}
+
+	cfg.Webhook.Endpoints[1].Name = "pager"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "duplicate name") {
+		t.Errorf("Validate() error = %v, want duplicate name error", err)
+	}
+
+	cfg = minimalValidConfig()
+	cfg.Webhook.Endpoints = []WebhookEndpointConfig{{Name: "x"}}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "URL") {
+		t.Errorf("Validate() error = %v, want URL required error", err)
+	}
+
+	cfg = minimalValidConfig()
+	cfg.Webhook.Retries = 11
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "Retries") {
+		t.Errorf("Validate() error = %v, want Retries error", err)
+	}
+
+	cfg = minimalValidConfig()
+	cfg.Webhook.RetryBackoff = "soon"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "webhook.retry_backoff") {
+		t.Errorf("Validate() error = %v, want webhook.retry_backoff error", err)
+	}
+}
+
+func TestValidate_EventFilters(t *testing.T) {
+	t.Parallel()
+
+	cfg := minimalValidConfig()
+	cfg.Webhook.Categories = []string{"security", "audit_overflow"}
+	cfg.Webhook.MinSeverity = "warning"
+	cfg.Events.Log = EventLogConfig{Enabled: true, Categories: []string{"lifecycle"}, MinSeverity: "critical"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with valid filters unexpected error: %v", err)
+	}
+
+	cfg = minimalValidConfig()
+	cfg.Webhook.Endpoints = []WebhookEndpointConfig{{Name: "pager", URL: "https://pager.example.com/hook", Categories: []string{"alerts"}}}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "webhook.endpoints[0].categories") {
+		t.Errorf("Validate() error = %v, want unknown category error", err)
+	}
+
+	cfg = minimalValidConfig()
+	cfg.Events.Log.MinSeverity = "high"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "events.log.min_severity") {
+		t.Errorf("Validate() error = %v, want min_severity error", err)
+	}
+}
+
+func TestValidate_SensitiveArguments(t *testing.T) {
+	t.Parallel()
+
+	cfg := minimalValidConfig()
+	cfg.SensitiveArguments.Tools = []SensitiveToolArgsConfig{
+		{Tool: "deploy", Paths: []string{"token", "auth.headers.*"}},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with valid rules unexpected error: %v", err)
+	}
+
+	cfg.SensitiveArguments.Tools[0].Paths = []string{"auth..token"}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "invalid argument path") {
+		t.Errorf("Validate() error = %v, want invalid argument path error", err)
+	}
+
+	cfg = minimalValidConfig()
+	cfg.SensitiveArguments.Tools = []SensitiveToolArgsConfig{{Tool: "deploy[", Paths: []string{"token"}}}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "invalid tool pattern") {
+		t.Errorf("Validate() error = %v, want invalid tool pattern error", err)
+	}
+
+	cfg = minimalValidConfig()
+	cfg.SensitiveArguments.Tools = []SensitiveToolArgsConfig{{Tool: "deploy"}}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "Paths") {
+		t.Errorf("Validate() error = %v, want Paths required error", err)
+	}
+}
+
+func TestValidate_ResponseGuard(t *testing.T) {
+	t.Parallel()
+
+	cfg := minimalValidConfig()
+	cfg.ResponseGuard.Tools = []ResponseGuardToolConfig{
+		{Tool: "get_rates", Arguments: `{"baseCurrency": "EUR"}`, Compare: "hash"},
+		{Tool: "list_repos", Threshold: 3, Quarantine: true},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with valid response guard unexpected error: %v", err)
+	}
+
+	cfg.ResponseGuard.Tools[1].Compare = "fuzzy"
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() should reject an unknown compare mode")
+	}
+
+	cfg.ResponseGuard.Tools[1].Compare = ""
+	cfg.ResponseGuard.Tools[1].Arguments = `["EUR"]`
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "JSON object") {
+		t.Errorf("Validate() error = %v, want arguments error", err)
+	}
+
+	cfg.ResponseGuard.Tools[1].Arguments = ""
+	cfg.ResponseGuard.Tools[1].Tool = "get_rates"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "response_guard.tools[1]") {
+		t.Errorf("Validate() error = %v, want duplicate tool error", err)
+	}
+}
+
+func TestValidate_CostAccounting(t *testing.T) {
+	t.Parallel()
+	cfg := minimalValidConfig()
+	cfg.CostAccounting = CostAccountingConfig{
+		Enabled: true,
+		Table: []CostRateConfig{
+			{Tool: "search_*", PerCall: 0.002},
+			{Upstream: "gpu-*", PerSecond: 0.01, PerMB: 0.05},
+		},
+		Webhook: CostWebhookConfig{URL: "https://billing.example.com/usage", Timeout: "5s"},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with valid cost accounting unexpected error: %v", err)
+	}
+
+	cfg.CostAccounting.Table[0].PerCall = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() should reject a negative rate")
+	}
+
+	cfg.CostAccounting.Table[0].PerCall = 0
+	cfg.CostAccounting.Table[1].Tool = "[a-"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "cost_accounting.table[1]") {
+		t.Errorf("Validate() error = %v, want tool pattern error", err)
+	}
+
+	cfg.CostAccounting.Table[1].Tool = ""
+	cfg.CostAccounting.Webhook.Secret = "short"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "secret") {
+		t.Errorf("Validate() error = %v, want secret error", err)
+	}
+
+	cfg.CostAccounting.Webhook.Secret = ""
+	cfg.CostAccounting.Webhook.Timeout = "soon"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "timeout") {
+		t.Errorf("Validate() error = %v, want timeout error", err)
+	}
+}
+
+func TestValidate_ServerTLS(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	for _, f := range []string{certFile, keyFile} {
+		if err := os.WriteFile(f, []byte("pem"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := minimalValidConfig()
+	cfg.Server.TLS = ServerTLSConfig{CertFile: certFile, KeyFile: keyFile, ReloadInterval: "30s"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with valid TLS files unexpected error: %v", err)
+	}
+
+	cfg.Server.TLS.KeyFile = ""
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "set together") {
+		t.Errorf("Validate() error = %v, want pairing error", err)
+	}
+
+	cfg.Server.TLS.KeyFile = filepath.Join(dir, "missing.pem")
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "server.tls.key_file") {
+		t.Errorf("Validate() error = %v, want missing key error", err)
+	}
+
+	cfg.Server.TLS.KeyFile = keyFile
+	cfg.Server.TLS.ReloadInterval = "often"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "reload_interval") {
+		t.Errorf("Validate() error = %v, want reload_interval error", err)
+	}
+}
+
+func TestValidate_EgressTLS(t *testing.T) {
+	t.Parallel()
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, []byte("pem"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	pin := "sha256/47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="
+
+	cfg := minimalValidConfig()
+	cfg.EgressTLS.Destinations = []EgressTLSDestinationConfig{
+		{Domain: "*.corp.internal", CAFile: caFile},
+		{Domain: "api.example.com", SPKIPins: []string{pin}},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with valid destinations unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		dest EgressTLSDestinationConfig
+		want string
+	}{
+		{EgressTLSDestinationConfig{CAFile: caFile}, "required"},
+		{EgressTLSDestinationConfig{Domain: "API.example.com", CAFile: caFile}, "duplicate domain"},
+		{EgressTLSDestinationConfig{Domain: "a.*.example.com", CAFile: caFile}, "wildcard"},
+		{EgressTLSDestinationConfig{Domain: "b.example.com"}, "ca_file, spki_pins"},
+		{EgressTLSDestinationConfig{Domain: "b.example.com", CAFile: caFile + ".missing"}, "ca_file"},
+		{EgressTLSDestinationConfig{Domain: "b.example.com", SPKIPins: []string{"sha1/abc="}}, "spki_pins[0]"},
+	}
+	for _, tt := range tests {
+		cfg := minimalValidConfig()
+		cfg.EgressTLS.Destinations = []EgressTLSDestinationConfig{{Domain: "api.example.com", SPKIPins: []string{pin}}, tt.dest}
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Validate(%+v) error = %v, want %q", tt.dest, err, tt.want)
+		}
+	}
+}
+
+func TestValidate_DNS(t *testing.T) {
+	t.Parallel()
+	cfg := minimalValidConfig()
+	cfg.DNS.Servers = []DNSServerConfig{
+		{URL: "https://dns.example/dns-query", Bootstrap: []string{"192.0.2.1", "2001:db8::1"}},
+		{URL: "tls://192.0.2.2"},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with valid servers unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		server DNSServerConfig
+		want   string
+	}{
+		{DNSServerConfig{}, "required"},
+		{DNSServerConfig{URL: "udp://192.0.2.1"}, "dns.servers[0].url"},
+		{DNSServerConfig{URL: "tls://dns.example"}, "dns.servers[0].bootstrap"},
+		{DNSServerConfig{URL: "tls://dns.example", Bootstrap: []string{"dns.example"}}, "bootstrap[0]"},
+	}
+	for _, tt := range tests {
+		cfg := minimalValidConfig()
+		cfg.DNS.Servers = []DNSServerConfig{tt.server}
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Validate(%+v) error = %v, want %q", tt.server, err, tt.want)
+		}
+	}
+
+	cfg = minimalValidConfig()
+	cfg.DNS.Cache = DNSCacheConfig{Enabled: true, MaxTTL: "0s"}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "dns.cache.max_ttl") {
+		t.Errorf("Validate() with a zero max_ttl error = %v", err)
+	}
+	cfg.DNS.Cache.MaxTTL = "5m"
+	cfg.DNS.RevalidateInterval = "1 minute"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "dns.revalidate_interval") {
+		t.Errorf("Validate() with an invalid revalidate_interval error = %v", err)
+	}
+}
+
+func TestValidate_AdminUI(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+
+	cfg := minimalValidConfig()
+	cfg.Server.AdminUI = AdminUIConfig{Mode: "directory", Directory: dir}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "index.html") {
+		t.Errorf("Validate() error = %v, want missing index.html error", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "index.html"), []byte("<html></html>"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with a UI directory unexpected error: %v", err)
+	}
+
+	cfg.Server.AdminUI.ContentSecurityPolicy = "default-src 'self'\r\nX-Injected: 1"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "single line") {
+		t.Errorf("Validate() error = %v, want single line error", err)
+	}
+
+	cfg.Server.AdminUI = AdminUIConfig{Mode: "directory"}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "requires directory") {
+		t.Errorf("Validate() error = %v, want missing directory error", err)
+	}
+
+	cfg.Server.AdminUI = AdminUIConfig{Mode: "disabled", Directory: dir}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "only used with mode directory") {
+		t.Errorf("Validate() error = %v, want directory without mode error", err)
+	}
+
+	cfg.Server.AdminUI = AdminUIConfig{Mode: "hidden"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() should reject an unknown mode")
+	}
+}
+
+func TestValidate_Correlation(t *testing.T) {
+	t.Parallel()
+	cfg := minimalValidConfig()
+	cfg.Server.Correlation = CorrelationConfig{Header: "X-Trace-Id", MetaKey: "acme/trace", Propagate: "both"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with valid correlation config unexpected error: %v", err)
+	}
+
+	cfg.Server.Correlation.Header = "X-Trace Id"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "server.correlation.header") {
+		t.Errorf("Validate() error = %v, want invalid header error", err)
+	}
+
+	cfg.Server.Correlation.Header = "X-Trace-Id"
+	cfg.Server.Correlation.Propagate = "always"
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() should reject an unknown propagate mode")
+	}
+}
+
+func TestValidate_MCPMethods(t *testing.T) {
+	t.Parallel()
+	cfg := minimalValidConfig()
+	cfg.MCPMethods = MCPMethodsConfig{
+		UnknownAction: "forward",
+		Rules: []MCPMethodRuleConfig{
+			{Method: "ping", Action: "local"},
+			{Method: "completion/complete", Action: "route", Upstream: "search"},
+			{Method: "experimental/*", Action: "deny"},
+		},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with valid method rules unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(*MCPMethodsConfig)
+		want   string
+	}{
+		{"bad unknown action", func(m *MCPMethodsConfig) { m.UnknownAction = "drop" }, "unknown_action"},
+		{"route without upstream", func(m *MCPMethodsConfig) { m.UnknownAction = "route" }, "unknown_upstream"},
+		{"local not ping", func(m *MCPMethodsConfig) { m.Rules[0].Method = "prompts/get" }, "only valid for ping"},
+		{"rule without upstream", func(m *MCPMethodsConfig) { m.Rules[1].Upstream = "" }, "rules[1]"},
+		{"bad pattern", func(m *MCPMethodsConfig) { m.Rules[2].Method = "[a-" }, "invalid method pattern"},
+		{"stray upstream", func(m *MCPMethodsConfig) { m.Rules[2].Upstream = "search" }, "only valid with action"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := minimalValidConfig()
+			c.MCPMethods = cfg.MCPMethods
+			c.MCPMethods.Rules = append([]MCPMethodRuleConfig(nil), cfg.MCPMethods.Rules...)
+			tt.mutate(&c.MCPMethods)
+			if err := c.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate_AnomalyDetection(t *testing.T) {
+	t.Parallel()
+	cfg := minimalValidConfig()
+	cfg.AnomalyDetection = AnomalyDetectionConfig{
+		Enabled: true, WarmupCalls: 100, WarmupPeriod: "168h", Threshold: 0.8,
+		Tools: []AnomalyToolConfig{{Tool: "read_*", Threshold: 0.9}, {Tool: "search", Disabled: true}},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with valid anomaly detection unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(*AnomalyDetectionConfig)
+		want   string
+	}{
+		{"bad warmup period", func(a *AnomalyDetectionConfig) { a.WarmupPeriod = "a week" }, "anomaly_detection.warmup_period"},
+		{"threshold above 1", func(a *AnomalyDetectionConfig) { a.Threshold = 1.5 }, "Threshold"},
+		{"tool without pattern", func(a *AnomalyDetectionConfig) { a.Tools[0].Tool = "" }, "tools[0]: tool is required"},
+		{"bad pattern", func(a *AnomalyDetectionConfig) { a.Tools[1].Tool = "[a-" }, "invalid tool pattern"},
+		{"bad tool warmup period", func(a *AnomalyDetectionConfig) { a.Tools[1].WarmupPeriod = "-1h" }, "tools[1].warmup_period"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := minimalValidConfig()
+			c.AnomalyDetection = cfg.AnomalyDetection
+			c.AnomalyDetection.Tools = append([]AnomalyToolConfig(nil), cfg.AnomalyDetection.Tools...)
+			tt.mutate(&c.AnomalyDetection)
+			if err := c.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate_ContentInspection(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		ci   ContentInspectionConfig
+		want string
+	}{
+		{"valid", ContentInspectionConfig{ChunkSize: 65536, Overlap: 1024, MaxScanBytes: 1 << 20}, ""},
+		{"overlap of a chunk", ContentInspectionConfig{ChunkSize: 8192, Overlap: 8192, MaxScanBytes: 1 << 20}, "content_inspection.overlap"},
+		{"cap below a chunk", ContentInspectionConfig{ChunkSize: 65536, Overlap: 1024, MaxScanBytes: 4096}, "content_inspection.max_scan_bytes"},
+		{"chunk too small", ContentInspectionConfig{ChunkSize: 1024, Overlap: 256, MaxScanBytes: 1 << 20}, "ChunkSize"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := minimalValidConfig()
+			c.ContentInspection = tt.ci
+			err := c.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("Validate() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate_Admission(t *testing.T) {
+	t.Parallel()
+	cfg := minimalValidConfig()
+	cfg.Admission = AdmissionConfig{Enabled: true, MemoryThresholdMB: 512, CheckInterval: "1s", RetryAfter: "5s"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with valid admission config unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(*AdmissionConfig)
+		want   string
+	}{
+		{"no threshold", func(a *AdmissionConfig) { a.MemoryThresholdMB = 0 }, "required when enabled"},
+		{"negative memory", func(a *AdmissionConfig) { a.MemoryThresholdMB = -1 }, "MemoryThresholdMB"},
+		{"zero interval", func(a *AdmissionConfig) { a.CheckInterval = "0s" }, "check_interval"},
+		{"bad retry_after", func(a *AdmissionConfig) { a.RetryAfter = "soon" }, "retry_after"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := minimalValidConfig()
+			c.Admission = cfg.Admission
+			tt.mutate(&c.Admission)
+			if err := c.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	// Disabled admission control needs no threshold.
+	c := minimalValidConfig()
+	c.Admission = AdmissionConfig{CheckInterval: "1s", RetryAfter: "5s"}
+	if err := c.Validate(); err != nil {
+		t.Errorf("Validate() with disabled admission unexpected error: %v", err)
+	}
+}
+
+func TestValidate_SessionStore(t *testing.T) {
+	t.Parallel()
+	cfg := minimalValidConfig()
+	cfg.Session = SessionConfig{Store: "redis", Redis: RedisConfig{Address: "redis:6379", Timeout: "5s"}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with valid redis session store unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(*SessionConfig)
+		want   string
+	}{
+		{"unknown store", func(s *SessionConfig) { s.Store = "etcd" }, "Store"},
+		{"address without port", func(s *SessionConfig) { s.Redis.Address = "redis" }, "session.redis.address"},
+		{"bad timeout", func(s *SessionConfig) { s.Redis.Timeout = "soon" }, "session.redis.timeout"},
+		{"zero timeout", func(s *SessionConfig) { s.Redis.Timeout = "0s" }, "must be positive"},
+		{"negative db", func(s *SessionConfig) { s.Redis.DB = -1 }, "DB"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := minimalValidConfig()
+			c.Session = cfg.Session
+			tt.mutate(&c.Session)
+			if err := c.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate_StateStore(t *testing.T) {
+	t.Parallel()
+	cfg := minimalValidConfig()
+	cfg.State = StateConfig{Backend: "postgres", Postgres: PostgresConfig{
+		Address: "db:5432", User: "gate", Table: "ops.gateway_state", Timeout: "5s",
+	}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with valid postgres state store unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(*StateConfig)
+		want   string
+	}{
+		{"unknown backend", func(s *StateConfig) { s.Backend = "etcd" }, "Backend"},
+		{"address without port", func(s *StateConfig) { s.Postgres.Address = "db" }, "state.postgres.address"},
+		{"no user", func(s *StateConfig) { s.Postgres.User = "" }, "state.postgres.user"},
+		{"bad table", func(s *StateConfig) { s.Postgres.Table = "state; DROP TABLE x" }, "state.postgres.table"},
+		{"zero timeout", func(s *StateConfig) { s.Postgres.Timeout = "0s" }, "must be positive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := minimalValidConfig()
+			c.State = cfg.State
+			tt.mutate(&c.State)
+			if err := c.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	// The file backend ignores the PostgreSQL settings.
+	c := minimalValidConfig()
+	c.State = StateConfig{Backend: "file", Postgres: PostgresConfig{Address: "db"}}
+	if err := c.Validate(); err != nil {
+		t.Errorf("Validate() with file backend unexpected error: %v", err)
+	}
+}
+
+func TestValidate_RateLimitStore(t *testing.T) {
+	t.Parallel()
+	cfg := minimalValidConfig()
+	cfg.RateLimit.Store = "redis"
+	cfg.RateLimit.Redis = RedisConfig{Address: "redis:6379", Timeout: "5s"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with valid redis rate limit store unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(*RateLimitConfig)
+		want   string
+	}{
+		{"unknown store", func(r *RateLimitConfig) { r.Store = "etcd" }, "Store"},
+		{"address without port", func(r *RateLimitConfig) { r.Redis.Address = "redis" }, "rate_limit.redis.address"},
+		{"bad timeout", func(r *RateLimitConfig) { r.Redis.Timeout = "soon" }, "rate_limit.redis.timeout"},
+		{"zero timeout", func(r *RateLimitConfig) { r.Redis.Timeout = "0s" }, "must be positive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := minimalValidConfig()
+			c.RateLimit = cfg.RateLimit
+			tt.mutate(&c.RateLimit)
+			if err := c.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate_CEL(t *testing.T) {
+	t.Parallel()
+	cfg := minimalValidConfig()
+	cfg.CEL = CELConfig{CostLimit: 50000, EvalTimeout: "2s", BannedFunctions: []string{"matches"}, BannedMacros: []string{"exists", "map"}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with valid cel config unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(*CELConfig)
+		want   string
+	}{
+		{"unknown macro", func(c *CELConfig) { c.BannedMacros = []string{"loop"} }, "banned_macros[0]"},
+		{"empty function", func(c *CELConfig) { c.BannedFunctions = []string{" "} }, "banned_functions[0]"},
+		{"zero timeout", func(c *CELConfig) { c.EvalTimeout = "0s" }, "cel.eval_timeout"},
+		{"bad timeout", func(c *CELConfig) { c.EvalTimeout = "fast" }, "cel.eval_timeout"},
+		{"negative cost limit", func(c *CELConfig) { c.CostLimit = -1 }, "CostLimit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := minimalValidConfig()
+			c.CEL = cfg.CEL
+			tt.mutate(&c.CEL)
+			if err := c.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate_PolicyEngine(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	cfg := minimalValidConfig()
+	cfg.Policy = PolicyEngineConfig{Engine: "rego", Rego: RegoConfig{
+		URL: "http://127.0.0.1:8181", Decision: "sentinelgate/decision", Timeout: "2s", BundleDir: dir, ReloadInterval: "5s",
+	}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with valid rego config unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(*PolicyEngineConfig)
+		want   string
+	}{
+		{"unknown engine", func(c *PolicyEngineConfig) { c.Engine = "wasm" }, "Engine"},
+		{"bad url", func(c *PolicyEngineConfig) { c.Rego.URL = "opa:8181" }, "policy.rego.url"},
+		{"empty decision", func(c *PolicyEngineConfig) { c.Rego.Decision = "/" }, "policy.rego.decision"},
+		{"decision query", func(c *PolicyEngineConfig) { c.Rego.Decision = "x?pretty=true" }, "policy.rego.decision"},
+		{"zero timeout", func(c *PolicyEngineConfig) { c.Rego.Timeout = "0s" }, "policy.rego.timeout"},
+		{"bad reload interval", func(c *PolicyEngineConfig) { c.Rego.ReloadInterval = "often" }, "policy.rego.reload_interval"},
+		{"missing bundle dir", func(c *PolicyEngineConfig) { c.Rego.BundleDir = dir + "/missing" }, "policy.rego.bundle_dir"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := minimalValidConfig()
+			c.Policy = cfg.Policy
+			tt.mutate(&c.Policy)
+			if err := c.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate_PolicyEnrichment(t *testing.T) {
+	t.Parallel()
+	cfg := minimalValidConfig()
+	cfg.Policy.Enrichment = PolicyEnrichmentConfig{
+		URL: "http://directory.internal/enrich", Timeout: "500ms", CacheTTL: "0s", Arguments: []string{"bucket"},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with valid enrichment config unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(*PolicyEnrichmentConfig)
+		want   string
+	}{
+		{"bad url", func(c *PolicyEnrichmentConfig) { c.URL = "directory.internal" }, "policy.enrichment.url"},
+		{"zero timeout", func(c *PolicyEnrichmentConfig) { c.Timeout = "0s" }, "policy.enrichment.timeout"},
+		{"bad cache ttl", func(c *PolicyEnrichmentConfig) { c.CacheTTL = "forever" }, "policy.enrichment.cache_ttl"},
+		{"negative cache ttl", func(c *PolicyEnrichmentConfig) { c.CacheTTL = "-1m" }, "policy.enrichment.cache_ttl"},
+		{"empty argument", func(c *PolicyEnrichmentConfig) { c.Arguments = []string{""} }, "policy.enrichment.arguments"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := minimalValidConfig()
+			c.Policy.Enrichment = cfg.Policy.Enrichment
+			tt.mutate(&c.Policy.Enrichment)
+			if err := c.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate_Approval(t *testing.T) {
+	t.Parallel()
+	cfg := minimalValidConfig()
+	cfg.Approval = ApprovalConfig{DefaultTimeout: "10m", MaxPending: 50, GrantDuration: "8h", MaxGrantDuration: "72h"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with valid approval config unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(*ApprovalConfig)
+		want   string
+	}{
+		{"bad default timeout", func(a *ApprovalConfig) { a.DefaultTimeout = "later" }, "approval.default_timeout"},
+		{"zero default timeout", func(a *ApprovalConfig) { a.DefaultTimeout = "0s" }, "approval.default_timeout"},
+		{"zero grant duration", func(a *ApprovalConfig) { a.GrantDuration = "0s" }, "approval.grant_duration"},
+		{"grant over max", func(a *ApprovalConfig) { a.GrantDuration = "96h" }, "exceeds approval.max_grant_duration"},
+		{"negative max pending", func(a *ApprovalConfig) { a.MaxPending = -1 }, "MaxPending"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := minimalValidConfig()
+			c.Approval = cfg.Approval
+			tt.mutate(&c.Approval)
+			if err := c.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -21,7 +21,12 @@ type ActionAuditInterceptor struct {
 	logger            *slog.Logger
 	frameworkGetter   func(sessionID string) string // optional, returns client framework for session
 	cbMu              sync.RWMutex
-	recordingCallback func(audit.AuditRecord) // optional, spawned in goroutine
+	recordingCallback func(audit.AuditRecord)  // optional, spawned in goroutine
+	provenanceMeta    bool                     // attach provenance to result._meta
+	toolStats         ToolStatsRecorder        // optional, per-tool call statistics
+	usage             UsageRecorder            // optional, per-call usage for cost accounting
+	argEncryptor      *audit.ArgumentEncryptor // optional, seals sensitive arguments
+	payloads          *payloadSampling         // optional, samples full payloads
 	callbackWg        sync.WaitGroup
 }
 
@@ -58,6 +63,8 @@ func (a *ActionAuditInterceptor) Intercept(ctx context.Context, act *CanonicalAc
 	ctx, transformHolder := audit.NewTransformResultContext(ctx)
 	ctx, quotaWarningHolder := audit.NewQuotaWarningContext(ctx)
 	ctx, policyHolder := audit.NewPolicyDecisionContext(ctx)
+	ctx, upstreamTokenHolder := audit.NewUpstreamTokenContext(ctx)
+	ctx, routeHolder := audit.NewUpstreamRouteContext(ctx)
 
 	// Call next interceptor to get decision
 	result, err := a.next.Intercept(ctx, act)
@@ -128,6 +135,54 @@ func (a *ActionAuditInterceptor) Intercept(ctx context.Context, act *CanonicalAc
 	if policyHolder != nil && policyHolder.RuleID != "" {
 		record.RuleID = policyHolder.RuleID
 	}
+	if policyHolder != nil {
+		record.PolicyDecisionID = policyHolder.DecisionID
+	}
+
+	// Populate upstream token use from holder (filled by the credential injector)
+	if upstreamTokenHolder != nil && upstreamTokenHolder.Use != nil {
+		record.UpstreamToken = upstreamTokenHolder.Use
+	}
+
+	// Attach provenance to successful tool results (for the transport and/or result._meta)
+	if err == nil {
+		a.attachProvenance(ctx, act, result, record, scanHolder, routeHolder)
+	}
+
+	// Record per-tool statistics
+	a.cbMu.RLock()
+	toolStats := a.toolStats
+	a.cbMu.RUnlock()
+	outcome := toolCallOutcome(result, err)
+	if toolStats != nil {
+		toolStats.RecordToolCall(act.Name, outcome, time.Duration(record.LatencyMicros)*time.Microsecond)
+	}
+
+	// Report the usage of calls that reached an upstream for cost accounting
+	a.cbMu.RLock()
+	usage := a.usage
+	a.cbMu.RUnlock()
+	if usage != nil && err == nil {
+		u := CallUsage{
+			Timestamp:    record.Timestamp,
+			RequestID:    record.RequestID,
+			SessionID:    record.SessionID,
+			IdentityID:   record.IdentityID,
+			IdentityName: record.IdentityName,
+			Tool:         act.Name,
+			Duration:     time.Duration(record.LatencyMicros) * time.Microsecond,
+			BytesIn:      messageSize(act),
+			BytesOut:     messageSize(result),
+			Outcome:      outcome,
+		}
+		if routeHolder != nil {
+			u.Upstream = routeHolder.UpstreamName
+		}
+		usage.RecordUsage(u)
+	}
+
+	// Sample the full payloads of high-risk tools into the payload store
+	a.samplePayload(&record, result)
 
 	// Record asynchronously (non-blocking)
 	a.recorder.Record(record)
@@ -167,6 +222,7 @@ func (a *ActionAuditInterceptor) buildAuditRecord(act *CanonicalAction, startTim
 		record.IdentityID = act.Identity.ID
 		record.IdentityName = act.Identity.Name
 		record.Roles = act.Identity.Roles
+		record.SessionLabels = act.Identity.Labels
 	} else {
 		record.SessionID = "anonymous"
 		record.IdentityID = "anonymous"
@@ -174,7 +230,15 @@ func (a *ActionAuditInterceptor) buildAuditRecord(act *CanonicalAction, startTim
 
 	// Tool info from CanonicalAction (already parsed by normalizer)
 	record.ToolName = act.Name
-	record.ToolArguments = audit.RedactSensitiveArgs(act.Arguments)
+	// Sensitive arguments are sealed on a copy; act.Arguments still goes to the upstream unchanged.
+	args := act.Arguments
+	a.cbMu.RLock()
+	enc := a.argEncryptor
+	a.cbMu.RUnlock()
+	if enc != nil {
+		args = enc.Seal(act.Name, args)
+	}
+	record.ToolArguments = audit.RedactSensitiveArgs(args)
 
 	// Decision based on error type
 	if err == nil {
@@ -192,14 +256,103 @@ func (a *ActionAuditInterceptor) buildAuditRecord(act *CanonicalAction, startTim
 		record.Reason = err.Error()
 	}
 
-	// Request ID from CanonicalAction
+	// Request and correlation IDs from CanonicalAction
 	record.RequestID = act.RequestID
+	record.CorrelationID = act.CorrelationID
+
+	// Destination address and location, filled in by policy evaluation
+	record.DestIP = act.Destination.IP
+	record.DestCountry = act.Destination.Country
+	record.DestASN = act.Destination.ASN
+	record.DestASNOrg = act.Destination.ASNOrg
 
 	// RuleID is populated by the PolicyDecisionHolder after chain execution
 
 	return record
 }
 
+// attachProvenance builds the provenance of a successful tool result and hands
+// it to the transport's ProvenanceHolder (if any) and, when enabled, stores it
+// under result._meta[ProvenanceMetaKey].
+func (a *ActionAuditInterceptor) attachProvenance(
+	ctx context.Context,
+	act *CanonicalAction,
+	result *CanonicalAction,
+	record audit.AuditRecord,
+	scanHolder *audit.ScanResultHolder,
+	routeHolder *audit.UpstreamRouteHolder,
+) {
+	a.cbMu.RLock()
+	withMeta := a.provenanceMeta
+	a.cbMu.RUnlock()
+	outer := audit.ProvenanceFromContext(ctx)
+	if outer == nil && !withMeta {
+		return
+	}
+
+	msg := successfulToolResult(result)
+	if msg == nil {
+		return
+	}
+
+	prov := &audit.Provenance{
+		RequestID:   act.RequestID,
+		ScanVerdict: scanVerdict(scanHolder),
+		RuleID:      record.RuleID,
+		LatencyMs:   float64(record.LatencyMicros) / 1000,
+	}
+	if routeHolder != nil {
+		prov.Upstream = routeHolder.UpstreamName
+	}
+	if policyHolder := audit.PolicyDecisionFromContext(ctx); policyHolder != nil {
+		prov.RuleName = policyHolder.RuleName
+	}
+	if outer != nil {
+		if outer.RequestID != "" {
+			prov.RequestID = outer.RequestID
+		}
+		outer.Provenance = prov
+	}
+
+	if withMeta {
+		if raw, ok := withProvenanceMeta(msg.Raw, prov); ok {
+			msg.Raw = raw
+		}
+	}
+}
+
+// SetProvenanceMeta enables or disables attaching provenance to tool results
+// under result._meta[ProvenanceMetaKey].
+func (a *ActionAuditInterceptor) SetProvenanceMeta(enabled bool) {
+	a.cbMu.Lock()
+	a.provenanceMeta = enabled
+	a.cbMu.Unlock()
+}
+
+// SetToolStatsRecorder registers an optional recorder for per-tool call
+// counts, outcomes and latencies. Pass nil to remove it.
+func (a *ActionAuditInterceptor) SetToolStatsRecorder(r ToolStatsRecorder) {
+	a.cbMu.Lock()
+	a.toolStats = r
+	a.cbMu.Unlock()
+}
+
+// SetUsageRecorder registers an optional recorder for the usage of tool
+// calls that reached an upstream. Pass nil to remove it.
+func (a *ActionAuditInterceptor) SetUsageRecorder(r UsageRecorder) {
+	a.cbMu.Lock()
+	a.usage = r
+	a.cbMu.Unlock()
+}
+
+// SetArgumentEncryptor registers an optional encryptor for the sensitive
+// arguments of audited tool calls. Pass nil to store arguments as received.
+func (a *ActionAuditInterceptor) SetArgumentEncryptor(e *audit.ArgumentEncryptor) {
+	a.cbMu.Lock()
+	a.argEncryptor = e
+	a.cbMu.Unlock()
+}
+
 // SetFrameworkGetter registers a function that returns the client framework name
 // extracted from the MCP initialize handshake. This enables the Framework Activity
 // widget in the admin dashboard.
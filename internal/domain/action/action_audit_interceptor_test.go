@@ -2,13 +2,19 @@ package action
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"log/slog"
 	"os"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/proxy"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/toolstats"
+	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
 )
 
 // stubRecorder captures audit records for assertion.
@@ -256,3 +262,265 @@ func TestActionAuditInterceptor_NilStats(t *testing.T) {
 		t.Fatalf("expected 1 audit record, got %d", len(records))
 	}
 }
+
+// provenanceNext simulates the inner chain: policy match, router and response scan.
+func provenanceNext(response string) ActionInterceptor {
+	return ActionInterceptorFunc(func(ctx context.Context, act *CanonicalAction) (*CanonicalAction, error) {
+		if h := audit.PolicyDecisionFromContext(ctx); h != nil {
+			h.RuleID = "allow-read"
+			h.RuleName = "Allow reads"
+		}
+		if h := audit.UpstreamRouteFromContext(ctx); h != nil {
+			h.UpstreamID = "up-1"
+			h.UpstreamName = "filesystem"
+		}
+		if h := audit.ScanResultFromContext(ctx); h != nil {
+			h.Scanned = true
+		}
+		return &CanonicalAction{
+			Type: act.Type,
+			Name: act.Name,
+			OriginalMessage: &mcp.Message{
+				Raw:       []byte(response),
+				Direction: mcp.ServerToClient,
+			},
+		}, nil
+	})
+}
+
+func TestActionAuditInterceptor_ProvenanceMeta(t *testing.T) {
+	interceptor := NewActionAuditInterceptor(&stubRecorder{}, nil,
+		provenanceNext(`{"jsonrpc":"2.0","id":1,"result":{"content":[{"type":"text","text":"hi"}],"_meta":{"other":1}}}`),
+		newAuditLogger())
+	interceptor.SetProvenanceMeta(true)
+
+	act := &CanonicalAction{Type: ActionToolCall, Name: "read_file", RequestID: "1"}
+	result, err := interceptor.Intercept(context.Background(), act)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var resp struct {
+		Result struct {
+			Meta map[string]json.RawMessage `json:"_meta"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(result.OriginalMessage.(*mcp.Message).Raw, &resp); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+	if _, ok := resp.Result.Meta["other"]; !ok {
+		t.Error("existing _meta entry was dropped")
+	}
+	var prov audit.Provenance
+	if err := json.Unmarshal(resp.Result.Meta[ProvenanceMetaKey], &prov); err != nil {
+		t.Fatalf("unmarshal provenance: %v", err)
+	}
+	if prov.Upstream != "filesystem" || prov.RuleID != "allow-read" || prov.RuleName != "Allow reads" {
+		t.Errorf("provenance = %+v, want upstream filesystem and rule allow-read", prov)
+	}
+	if prov.ScanVerdict != audit.ProvenanceScanClean || prov.RequestID != "1" {
+		t.Errorf("provenance = %+v, want clean scan and request ID 1", prov)
+	}
+}
+
+func TestActionAuditInterceptor_ProvenanceHolder(t *testing.T) {
+	interceptor := NewActionAuditInterceptor(&stubRecorder{}, nil,
+		provenanceNext(`{"jsonrpc":"2.0","id":1,"result":{"content":[]}}`), newAuditLogger())
+
+	ctx, holder := audit.NewProvenanceContext(context.Background(), "req-abc")
+	act := &CanonicalAction{Type: ActionToolCall, Name: "read_file", RequestID: "1"}
+	result, err := interceptor.Intercept(ctx, act)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if holder.Provenance == nil {
+		t.Fatal("expected provenance in holder")
+	}
+	if holder.Provenance.RequestID != "req-abc" {
+		t.Errorf("RequestID = %q, want transport request ID", holder.Provenance.RequestID)
+	}
+	// Meta provenance is disabled: the response must be untouched.
+	if raw := string(result.OriginalMessage.(*mcp.Message).Raw); raw != `{"jsonrpc":"2.0","id":1,"result":{"content":[]}}` {
+		t.Errorf("response modified without meta provenance: %s", raw)
+	}
+}
+
+func TestActionAuditInterceptor_ProvenanceSkipsErrors(t *testing.T) {
+	interceptor := NewActionAuditInterceptor(&stubRecorder{}, nil,
+		provenanceNext(`{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"Tool not found"}}`), newAuditLogger())
+	interceptor.SetProvenanceMeta(true)
+
+	ctx, holder := audit.NewProvenanceContext(context.Background(), "req-abc")
+	result, err := interceptor.Intercept(ctx, &CanonicalAction{Type: ActionToolCall, Name: "missing"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if holder.Provenance != nil {
+		t.Errorf("expected no provenance for error response, got %+v", holder.Provenance)
+	}
+	if raw := string(result.OriginalMessage.(*mcp.Message).Raw); strings.Contains(raw, ProvenanceMetaKey) {
+		t.Errorf("error response carries provenance: %s", raw)
+	}
+}
+
+// stubToolStats captures per-tool outcomes for assertion.
+type stubToolStats struct {
+	mu       sync.Mutex
+	outcomes map[string]toolstats.Outcome
+}
+
+func (s *stubToolStats) RecordToolCall(tool string, outcome toolstats.Outcome, _ time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.outcomes[tool] = outcome
+}
+
+func TestActionAuditInterceptor_ToolStatsOutcomes(t *testing.T) {
+	tests := []struct {
+		name string
+		next ActionInterceptor
+		want toolstats.Outcome
+	}{
+		{"success", provenanceNext(`{"jsonrpc":"2.0","id":1,"result":{"content":[]}}`), toolstats.OutcomeOK},
+		{"tool isError", provenanceNext(`{"jsonrpc":"2.0","id":1,"result":{"content":[],"isError":true}}`), toolstats.OutcomeError},
+		{"json-rpc error", provenanceNext(`{"jsonrpc":"2.0","id":1,"error":{"code":-32603,"message":"boom"}}`), toolstats.OutcomeError},
+		{"policy denial", ActionInterceptorFunc(func(context.Context, *CanonicalAction) (*CanonicalAction, error) {
+			return nil, proxy.ErrPolicyDenied
+		}), toolstats.OutcomeDenied},
+		{"internal error", ActionInterceptorFunc(func(context.Context, *CanonicalAction) (*CanonicalAction, error) {
+			return nil, errors.New("upstream connection reset")
+		}), toolstats.OutcomeError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := &stubToolStats{outcomes: make(map[string]toolstats.Outcome)}
+			interceptor := NewActionAuditInterceptor(&stubRecorder{}, nil, tt.next, newAuditLogger())
+			interceptor.SetToolStatsRecorder(stats)
+
+			_, _ = interceptor.Intercept(context.Background(), &CanonicalAction{Type: ActionToolCall, Name: "read_file"})
+
+			got, ok := stats.outcomes["read_file"]
+			if !ok {
+				t.Fatal("tool call was not recorded")
+			}
+			if got != tt.want {
+				t.Errorf("outcome = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// stubUsage captures reported call usage.
+type stubUsage struct {
+	calls []CallUsage
+}
+
+func (s *stubUsage) RecordUsage(u CallUsage) { s.calls = append(s.calls, u) }
+
+func TestActionAuditInterceptor_ReportsUsage(t *testing.T) {
+	const response = `{"jsonrpc":"2.0","id":1,"result":{"content":[]}}`
+	usage := &stubUsage{}
+	interceptor := NewActionAuditInterceptor(&stubRecorder{}, nil, provenanceNext(response), newAuditLogger())
+	interceptor.SetUsageRecorder(usage)
+
+	request := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"read_file"}}`
+	act := &CanonicalAction{
+		Type:            ActionToolCall,
+		Name:            "read_file",
+		Identity:        ActionIdentity{ID: "user-1", Name: "alice", SessionID: "sess-1"},
+		OriginalMessage: &mcp.Message{Raw: []byte(request), Direction: mcp.ClientToServer},
+	}
+	if _, err := interceptor.Intercept(context.Background(), act); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(usage.calls) != 1 {
+		t.Fatalf("usage calls = %d, want 1", len(usage.calls))
+	}
+	u := usage.calls[0]
+	if u.Tool != "read_file" || u.Upstream != "filesystem" || u.IdentityID != "user-1" || u.IdentityName != "alice" {
+		t.Errorf("usage = %+v", u)
+	}
+	if u.BytesIn != int64(len(request)) || u.BytesOut != int64(len(response)) || u.Outcome != toolstats.OutcomeOK {
+		t.Errorf("bytes in/out = %d/%d outcome = %v", u.BytesIn, u.BytesOut, u.Outcome)
+	}
+
+	// Denied calls never reached an upstream and are not reported.
+	denied := NewActionAuditInterceptor(&stubRecorder{}, nil, &denyNext{}, newAuditLogger())
+	denied.SetUsageRecorder(usage)
+	_, _ = denied.Intercept(context.Background(), &CanonicalAction{Type: ActionToolCall, Name: "read_file"})
+	if len(usage.calls) != 1 {
+		t.Errorf("denied call reported usage: %+v", usage.calls[1:])
+	}
+}
+
+func TestActionAuditInterceptor_SealsSensitiveArguments(t *testing.T) {
+	key := make([]byte, audit.ArgumentKeySize)
+	enc, err := audit.NewArgumentEncryptor(key, []audit.SensitiveArgRule{{Tool: "deploy", Paths: []string{"token", "target.host"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var forwarded map[string]interface{}
+	next := ActionInterceptorFunc(func(_ context.Context, act *CanonicalAction) (*CanonicalAction, error) {
+		forwarded = act.Arguments
+		return act, nil
+	})
+	rec := &stubRecorder{}
+	interceptor := NewActionAuditInterceptor(rec, nil, next, newAuditLogger())
+	interceptor.SetArgumentEncryptor(enc)
+
+	args := map[string]interface{}{
+		"token":  "s3cr3t",
+		"target": map[string]interface{}{"host": "prod-1", "port": float64(22)},
+		"env":    "prod",
+	}
+	act := &CanonicalAction{
+		Type:      ActionToolCall,
+		Name:      "deploy",
+		Arguments: args,
+		Identity:  ActionIdentity{ID: "user-1", SessionID: "sess-1"},
+	}
+	if _, err := interceptor.Intercept(context.Background(), act); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if forwarded["token"] != "s3cr3t" || forwarded["target"].(map[string]interface{})["host"] != "prod-1" {
+		t.Errorf("upstream got %v, want the original arguments", forwarded)
+	}
+	recorded := rec.getRecords()[0].ToolArguments
+	if !audit.IsEncryptedArg(recorded["token"]) || !audit.IsEncryptedArg(recorded["target"].(map[string]interface{})["host"]) {
+		t.Fatalf("recorded %v, want token and target.host encrypted", recorded)
+	}
+	if recorded["env"] != "prod" {
+		t.Errorf("recorded env = %v, want prod", recorded["env"])
+	}
+	opened, n, err := enc.Open(map[string]interface{}(recorded))
+	if err != nil || n != 2 {
+		t.Fatalf("Open = %d values, %v", n, err)
+	}
+	if opened.(map[string]interface{})["token"] != "s3cr3t" {
+		t.Errorf("decrypted token = %v", opened.(map[string]interface{})["token"])
+	}
+}
+
+func TestActionAuditInterceptor_RecordsDestinationGeo(t *testing.T) {
+	rec := &stubRecorder{}
+	interceptor := NewActionAuditInterceptor(rec, nil, &passThrough{}, newAuditLogger())
+
+	act := &CanonicalAction{
+		Type:        ActionToolCall,
+		Name:        "http_get",
+		Destination: Destination{Domain: "files.example", IP: "203.0.113.7", Country: "KP", ASN: 64500, ASNOrg: "Example Net"},
+	}
+	if _, err := interceptor.Intercept(context.Background(), act); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	records := rec.getRecords()
+	if len(records) != 1 {
+		t.Fatalf("expected 1 audit record, got %d", len(records))
+	}
+	r := records[0]
+	if r.DestIP != "203.0.113.7" || r.DestCountry != "KP" || r.DestASN != 64500 || r.DestASNOrg != "Example Net" {
+		t.Errorf("destination fields = %q %q %d %q", r.DestIP, r.DestCountry, r.DestASN, r.DestASNOrg)
+	}
+}
@@ -9,9 +9,11 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/auth"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/proxy"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/session"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/timing"
 	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
 )
 
@@ -21,6 +23,22 @@ type authCacheEntry struct {
 	identityID string // identity that owns this session (for role-change invalidation)
 	lastAccess time.Time
 	apiKeyHash string
+	// allowedOrigins is the origin binding of the API key (empty = unbound).
+	allowedOrigins []string
+	// access holds the identity's time/country restrictions (nil = unrestricted).
+	access *auth.AccessRestrictions
+	// expiresAt ends a session authenticated by a bearer token together
+	// with the token (zero = API key, no expiry).
+	expiresAt time.Time
+}
+
+// TokenVerifier authenticates bearer tokens that are not API keys, such as
+// OIDC access tokens. Implementations must be safe for concurrent use.
+type TokenVerifier interface {
+	// Accepts reports whether the credential is a token for this verifier.
+	Accepts(credential string) bool
+	// VerifyToken returns the identity of a valid token and its expiry.
+	VerifyToken(ctx context.Context, token string) (*auth.Identity, time.Time, error)
 }
 
 // ActionAuthInterceptor validates API keys and manages sessions.
@@ -46,6 +64,17 @@ type ActionAuthInterceptor struct {
 	// maxCacheSize caps the session cache to prevent unbounded memory growth.
 	maxCacheSize int
 
+	// countryResolver resolves client IPs for identity country restrictions
+	// (nil = country unknown).
+	countryResolver auth.CountryResolver
+	// auditRecorder records access restriction denials, which happen before
+	// the audit interceptor runs (nil = not recorded).
+	auditRecorder proxy.AuditRecorder
+	// tokenVerifier authenticates bearer tokens (nil = API keys only).
+	tokenVerifier TokenVerifier
+	// now is the clock for identity time-window restrictions.
+	now func() time.Time
+
 	// Cleanup goroutine control
 	stopChan        chan struct{}
 	wg              sync.WaitGroup
@@ -75,6 +104,7 @@ func NewActionAuthInterceptor(
 		sessionTracker:  tracker,
 		sessionCache:    make(map[string]*authCacheEntry),
 		maxCacheSize:    defaultActionAuthMaxCacheSize,
+		now:             time.Now,
 		stopChan:        make(chan struct{}),
 		cleanupInterval: 5 * time.Minute,
 		cacheMaxAge:     30 * time.Minute,
@@ -99,14 +129,41 @@ func NewActionAuthInterceptorWithConfig(
 		sessionTracker:  tracker,
 		sessionCache:    make(map[string]*authCacheEntry),
 		maxCacheSize:    defaultActionAuthMaxCacheSize,
+		now:             time.Now,
 		stopChan:        make(chan struct{}),
 		cleanupInterval: cleanupInterval,
 		cacheMaxAge:     cacheMaxAge,
 	}
 }
 
+// SetCountryResolver sets the GeoIP resolver used by identity country
+// restrictions. Must be called before the interceptor serves requests.
+func (a *ActionAuthInterceptor) SetCountryResolver(r auth.CountryResolver) {
+	a.countryResolver = r
+}
+
+// SetAuditRecorder sets the recorder for access restriction denials.
+// Must be called before the interceptor serves requests.
+func (a *ActionAuthInterceptor) SetAuditRecorder(r proxy.AuditRecorder) {
+	a.auditRecorder = r
+}
+
+// SetTokenVerifier enables authentication with bearer tokens accepted by v
+// alongside API keys. Must be called before the interceptor serves requests.
+func (a *ActionAuthInterceptor) SetTokenVerifier(v TokenVerifier) {
+	a.tokenVerifier = v
+}
+
+// SetClock overrides the clock used for time-window restrictions (for testing).
+func (a *ActionAuthInterceptor) SetClock(now func() time.Time) {
+	a.now = now
+}
+
 // Intercept validates authentication before passing to next interceptor.
 func (a *ActionAuthInterceptor) Intercept(ctx context.Context, act *CanonicalAction) (*CanonicalAction, error) {
+	stopAuth := timing.Track(ctx, timing.PhaseAuth)
+	defer stopAuth()
+
 	// Get connection ID from context (set by transport layer)
 	connID, _ := ctx.Value(proxy.ConnectionIDKey).(string)
 	if connID == "" {
@@ -127,6 +184,9 @@ func (a *ActionAuthInterceptor) Intercept(ctx context.Context, act *CanonicalAct
 		mcpMsg.APIKey = apiKey
 	}
 
+	// Browser origin, already checked against the global allowlist by the transport
+	origin, _ := ctx.Value(proxy.OriginContextKey).(string)
+
 	// Check sessionCache for existing session ID by connection ID
 	a.sessionMu.RLock()
 	entry, hasCachedSession := a.sessionCache[connID]
@@ -161,6 +221,30 @@ func (a *ActionAuthInterceptor) Intercept(ctx context.Context, act *CanonicalAct
 		}
 	}
 
+	// A token-authenticated session does not outlive its token: the client
+	// has to present a valid one again.
+	if hasCachedSession && !entry.expiresAt.IsZero() && !a.now().Before(entry.expiresAt) {
+		a.sessionMu.Lock()
+		delete(a.sessionCache, connID)
+		a.sessionMu.Unlock()
+		a.logger.Debug("bearer token expired on cached connection",
+			"connection_id", connID,
+			"session_id", entry.sessionID,
+		)
+		hasCachedSession = false
+	}
+
+	// The connection ID is derived from the API key alone, so the cached
+	// session is shared by every origin using the key: re-check the binding
+	// on each request.
+	if hasCachedSession && !auth.OriginAllowed(entry.allowedOrigins, origin) {
+		a.logger.Debug("origin not allowed for API key",
+			"connection_id", connID,
+			"origin", origin,
+		)
+		return nil, proxy.ErrOriginNotAllowed
+	}
+
 	if hasCachedSession {
 		cachedSessionID = entry.sessionID
 		// Update last access time
@@ -173,6 +257,11 @@ func (a *ActionAuthInterceptor) Intercept(ctx context.Context, act *CanonicalAct
 		// Try to use cached session
 		sess, err := a.sessionService.Get(ctx, cachedSessionID)
 		if err == nil && !sess.IsExpired() {
+			// Restrictions are re-checked on every request: an access
+			// window can close while the session is still alive.
+			if err := a.checkAccess(ctx, act, entry.access, sess.ID, sess.IdentityID, sess.IdentityName, sess.Roles); err != nil {
+				return nil, err
+			}
 			a.setIdentity(act, sess, mcpMsg)
 			// Write session ID to HTTP handler's slot so Mcp-Session-Id matches audit records
 			if slot, ok := ctx.Value(proxy.SessionIDSlotKey).(*string); ok {
@@ -186,6 +275,7 @@ func (a *ActionAuthInterceptor) Intercept(ctx context.Context, act *CanonicalAct
 				"session_id", sess.ID,
 				"identity_id", sess.IdentityID,
 			)
+			stopAuth()
 			return a.next.Intercept(ctx, act)
 		}
 		// Session expired or not found - remove from cache
@@ -206,20 +296,13 @@ func (a *ActionAuthInterceptor) Intercept(ctx context.Context, act *CanonicalAct
 		return nil, proxy.ErrUnauthenticated
 	}
 
-	// Validate API key
-	identity, err := a.apiKeyService.Validate(ctx, apiKey)
+	identity, allowedOrigins, expiresAt, err := a.authenticate(ctx, connID, apiKey, origin)
 	if err != nil {
-		if errors.Is(err, auth.ErrInvalidKey) {
-			a.logger.Debug("invalid API key",
-				"connection_id", connID,
-			)
-			return nil, proxy.ErrInvalidAPIKey
-		}
-		a.logger.Debug("API key validation failed",
-			"connection_id", connID,
-			"error", err,
-		)
-		return nil, proxy.ErrInvalidAPIKey
+		return nil, err
+	}
+
+	if err := a.checkAccess(ctx, act, identity.Access, "", identity.ID, identity.Name, identity.Roles); err != nil {
+		return nil, err
 	}
 
 	// Create new session
@@ -233,6 +316,21 @@ func (a *ActionAuthInterceptor) Intercept(ctx context.Context, act *CanonicalAct
 		return nil, proxy.ErrInternalError
 	}
 
+	// Labels sent by the client with initialize tag the new session. Invalid
+	// labels are dropped rather than failing the handshake.
+	if act.Type == ActionProtocol && act.Name == "initialize" && mcpMsg != nil {
+		if labels := mcpMsg.ExtractLabels(); labels != nil {
+			if labeled, err := a.sessionService.SetLabels(ctx, sess.ID, labels); err != nil {
+				a.logger.Warn("ignoring invalid session labels",
+					"session_id", sess.ID,
+					"error", err,
+				)
+			} else {
+				sess = labeled
+			}
+		}
+	}
+
 	// Pre-register in usage tracker so the session appears in Agents page immediately
 	if a.sessionTracker != nil {
 		a.sessionTracker.TrackSession(sess.ID, identity.ID, identity.Name)
@@ -244,10 +342,13 @@ func (a *ActionAuthInterceptor) Intercept(ctx context.Context, act *CanonicalAct
 		a.evictOldestLocked()
 	}
 	a.sessionCache[connID] = &authCacheEntry{
-		sessionID:  sess.ID,
-		identityID: identity.ID,
-		lastAccess: time.Now(),
-		apiKeyHash: actionAuthHashKey(apiKey),
+		sessionID:      sess.ID,
+		identityID:     identity.ID,
+		lastAccess:     time.Now(),
+		apiKeyHash:     actionAuthHashKey(apiKey),
+		allowedOrigins: allowedOrigins,
+		access:         identity.Access,
+		expiresAt:      expiresAt,
 	}
 	a.sessionMu.Unlock()
 
@@ -265,9 +366,97 @@ func (a *ActionAuthInterceptor) Intercept(ctx context.Context, act *CanonicalAct
 		"identity_name", identity.Name,
 	)
 
+	stopAuth()
 	return a.next.Intercept(ctx, act)
 }
 
+// authenticate validates the credential: a bearer token when the token
+// verifier accepts it, an API key otherwise. It returns the identity, the
+// origin binding of the API key and the token expiry.
+func (a *ActionAuthInterceptor) authenticate(ctx context.Context, connID, credential, origin string) (*auth.Identity, []string, time.Time, error) {
+	if a.tokenVerifier != nil && a.tokenVerifier.Accepts(credential) {
+		identity, expiresAt, err := a.tokenVerifier.VerifyToken(ctx, credential)
+		if err != nil {
+			a.logger.Debug("bearer token validation failed",
+				"connection_id", connID,
+				"error", err,
+			)
+			return nil, nil, time.Time{}, proxy.ErrInvalidAPIKey
+		}
+		return identity, nil, expiresAt, nil
+	}
+
+	// Validate API key
+	identity, key, err := a.apiKeyService.ValidateKey(ctx, credential)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidKey) {
+			a.logger.Debug("invalid API key",
+				"connection_id", connID,
+			)
+			return nil, nil, time.Time{}, proxy.ErrInvalidAPIKey
+		}
+		a.logger.Debug("API key validation failed",
+			"connection_id", connID,
+			"error", err,
+		)
+		return nil, nil, time.Time{}, proxy.ErrInvalidAPIKey
+	}
+
+	if !key.AllowsOrigin(origin) {
+		a.logger.Debug("origin not allowed for API key",
+			"connection_id", connID,
+			"origin", origin,
+		)
+		return nil, nil, time.Time{}, proxy.ErrOriginNotAllowed
+	}
+	return identity, key.AllowedOrigins, time.Time{}, nil
+}
+
+// checkAccess enforces the identity's time-window and country restrictions.
+// Denials are logged and audited with the restriction and source country.
+func (a *ActionAuthInterceptor) checkAccess(ctx context.Context, act *CanonicalAction, access *auth.AccessRestrictions, sessionID, identityID, identityName string, roles []auth.Role) error {
+	if access == nil {
+		return nil
+	}
+	clientIP, _ := ctx.Value(proxy.IPAddressKey).(string)
+	now := a.now()
+	denial := access.Check(now, clientIP, a.countryResolver)
+	if denial == nil {
+		return nil
+	}
+
+	a.logger.Info("identity access restricted",
+		"identity_id", identityID,
+		"restriction", denial.Restriction,
+		"reason", denial.Reason,
+		"client_ip", clientIP,
+		"country", denial.Country,
+	)
+	denyErr := &proxy.AccessRestrictedError{Restriction: denial.Restriction, Reason: denial.Reason}
+
+	if a.auditRecorder != nil {
+		roleNames := make([]string, len(roles))
+		for i, r := range roles {
+			roleNames[i] = string(r)
+		}
+		a.auditRecorder.Record(audit.AuditRecord{
+			Timestamp:         now,
+			SessionID:         sessionID,
+			IdentityID:        identityID,
+			IdentityName:      identityName,
+			Roles:             roleNames,
+			ToolName:          act.Name,
+			Decision:          audit.DecisionDeny,
+			Reason:            denyErr.Error(),
+			RequestID:         act.RequestID,
+			Protocol:          act.Protocol,
+			SourceCountry:     denial.Country,
+			AccessRestriction: denial.Restriction,
+		})
+	}
+	return denyErr
+}
+
 // setIdentity populates identity on both the CanonicalAction and the mcp.Message.
 // Setting msg.Session ensures backward compatibility with downstream code that
 // reads from mcp.Message (e.g., UpstreamRouter via LegacyAdapter).
@@ -282,6 +471,7 @@ func (a *ActionAuthInterceptor) setIdentity(act *CanonicalAction, sess *session.
 		Name:      sess.IdentityName,
 		SessionID: sess.ID,
 		Roles:     roles,
+		Labels:    sess.Labels,
 	}
 
 	// Set on mcp.Message for backward compatibility
@@ -4,14 +4,17 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"net"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/memory"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/auth"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/proxy"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/session"
+	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
 )
 
 // setupAuthInterceptor creates a fully wired ActionAuthInterceptor for testing.
@@ -162,6 +165,114 @@ func TestActionAuthInterceptor_SessionCache(t *testing.T) {
 	}
 }
 
+func TestActionAuthInterceptor_OriginBinding(t *testing.T) {
+	authStore := memory.NewAuthStore()
+	authStore.AddIdentity(&auth.Identity{ID: "test-id", Name: "test-user", Roles: []auth.Role{auth.RoleUser}})
+	authStore.AddKey(&auth.APIKey{
+		Key:            auth.HashKey("bound-key"), //nolint:staticcheck // SHA-256 for test
+		IdentityID:     "test-id",
+		CreatedAt:      time.Now(),
+		AllowedOrigins: []string{"https://app.example.com"},
+	})
+	sessionSvc := session.NewSessionService(memory.NewSessionStore(), session.Config{Timeout: 30 * time.Minute})
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	interceptor := NewActionAuthInterceptor(auth.NewAPIKeyService(authStore), sessionSvc, &passThrough{}, logger, nil)
+	t.Cleanup(func() { interceptor.Stop() })
+
+	call := func(origin string) error {
+		ctx := context.WithValue(context.Background(), proxy.APIKeyContextKey, "bound-key")
+		ctx = context.WithValue(ctx, proxy.ConnectionIDKey, "conn-bound")
+		if origin != "" {
+			ctx = context.WithValue(ctx, proxy.OriginContextKey, origin)
+		}
+		_, err := interceptor.Intercept(ctx, &CanonicalAction{Type: ActionToolCall, Name: "test_tool"})
+		return err
+	}
+
+	// A foreign origin is rejected before any session exists.
+	if err := call("https://evil.example"); !errors.Is(err, proxy.ErrOriginNotAllowed) {
+		t.Fatalf("foreign origin: expected ErrOriginNotAllowed, got %v", err)
+	}
+	// The bound origin (case-insensitive) creates a session.
+	if err := call("HTTPS://app.example.com"); err != nil {
+		t.Fatalf("bound origin: unexpected error %v", err)
+	}
+	// The cached session is keyed by API key, so it must not let a foreign origin in.
+	if err := call("https://evil.example"); !errors.Is(err, proxy.ErrOriginNotAllowed) {
+		t.Fatalf("foreign origin with cached session: expected ErrOriginNotAllowed, got %v", err)
+	}
+	// Non-browser clients send no Origin and are unaffected.
+	if err := call(""); err != nil {
+		t.Fatalf("no origin: unexpected error %v", err)
+	}
+}
+
+type countryStub map[string]string
+
+func (c countryStub) Country(ip net.IP) (string, error) { return c[ip.String()], nil }
+
+func TestActionAuthInterceptor_AccessRestrictions(t *testing.T) {
+	access, err := auth.NewAccessRestrictions("UTC",
+		[]auth.AccessWindow{{Start: "09:00", End: "17:00"}}, []string{"IT"}, nil)
+	if err != nil {
+		t.Fatalf("NewAccessRestrictions: %v", err)
+	}
+	authStore := memory.NewAuthStore()
+	authStore.AddIdentity(&auth.Identity{ID: "contractor", Name: "contractor", Roles: []auth.Role{auth.RoleUser}, Access: access})
+	authStore.AddKey(&auth.APIKey{
+		Key:        auth.HashKey("contractor-key"), //nolint:staticcheck // SHA-256 for test
+		IdentityID: "contractor",
+		CreatedAt:  time.Now(),
+	})
+	sessionSvc := session.NewSessionService(memory.NewSessionStore(), session.Config{Timeout: 30 * time.Minute})
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	interceptor := NewActionAuthInterceptor(auth.NewAPIKeyService(authStore), sessionSvc, &passThrough{}, logger, nil)
+	t.Cleanup(func() { interceptor.Stop() })
+
+	recorder := &stubRecorder{}
+	interceptor.SetAuditRecorder(recorder)
+	interceptor.SetCountryResolver(countryStub{"203.0.113.7": "IT", "198.51.100.7": "US"})
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	interceptor.SetClock(func() time.Time { return now })
+
+	call := func(ip string) error {
+		ctx := context.WithValue(context.Background(), proxy.APIKeyContextKey, "contractor-key")
+		ctx = context.WithValue(ctx, proxy.ConnectionIDKey, "conn-contractor")
+		ctx = context.WithValue(ctx, proxy.IPAddressKey, ip)
+		_, err := interceptor.Intercept(ctx, &CanonicalAction{Type: ActionToolCall, Name: "test_tool"})
+		return err
+	}
+
+	var restricted *proxy.AccessRestrictedError
+	if err := call("198.51.100.7"); !errors.As(err, &restricted) || restricted.Restriction != auth.RestrictionCountry {
+		t.Fatalf("foreign country: expected country restriction, got %v", err)
+	}
+	if err := call("203.0.113.7"); err != nil {
+		t.Fatalf("allowed country inside window: unexpected error %v", err)
+	}
+
+	// The window closes while the session is cached.
+	now = time.Date(2026, 3, 2, 18, 0, 0, 0, time.UTC)
+	err = call("203.0.113.7")
+	if !errors.As(err, &restricted) || restricted.Restriction != auth.RestrictionTimeWindow {
+		t.Fatalf("outside window: expected time window restriction, got %v", err)
+	}
+	if msg := proxy.SafeErrorMessage(err); msg != "Access restricted (outside allowed access hours (every day 09:00-17:00 UTC))" {
+		t.Errorf("SafeErrorMessage = %q", msg)
+	}
+
+	records := recorder.getRecords()
+	if len(records) != 2 {
+		t.Fatalf("expected 2 audited denials, got %d", len(records))
+	}
+	if r := records[0]; r.Decision != audit.DecisionDeny || r.AccessRestriction != auth.RestrictionCountry || r.SourceCountry != "US" || r.IdentityID != "contractor" {
+		t.Errorf("country denial record = %+v", r)
+	}
+	if r := records[1]; r.AccessRestriction != auth.RestrictionTimeWindow || r.SessionID == "" {
+		t.Errorf("time window denial record = %+v, want cached session ID", r)
+	}
+}
+
 func TestActionAuthInterceptor_Stop(t *testing.T) {
 	interceptor := setupAuthInterceptor(t, true)
 
@@ -174,3 +285,124 @@ func TestActionAuthInterceptor_Stop(t *testing.T) {
 	// Calling Stop again should be safe (sync.Once)
 	interceptor.Stop()
 }
+
+func TestActionAuthInterceptor_InitializeLabels(t *testing.T) {
+	interceptor := setupAuthInterceptor(t, true)
+
+	tests := []struct {
+		name   string
+		connID string
+		labels string
+		want   map[string]string
+	}{
+		{
+			name:   "labels applied to the session",
+			connID: "conn-labels-1",
+			labels: `{"project":"checkout-bot","run-id":"1234"}`,
+			want:   map[string]string{"project": "checkout-bot", "run-id": "1234"},
+		},
+		{
+			name:   "invalid labels dropped",
+			connID: "conn-labels-2",
+			labels: `{"bad key":"x"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"_meta":{"apiKey":"test-api-key","labels":` + tt.labels + `}}}`
+			decoded, err := mcp.DecodeMessage([]byte(raw))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			act := &CanonicalAction{
+				Type:            ActionProtocol,
+				Name:            "initialize",
+				OriginalMessage: &mcp.Message{Raw: []byte(raw), Direction: mcp.ClientToServer, Decoded: decoded},
+			}
+			ctx := context.WithValue(context.Background(), proxy.ConnectionIDKey, tt.connID)
+
+			result, err := interceptor.Intercept(ctx, act)
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if len(result.Identity.Labels) != len(tt.want) {
+				t.Fatalf("labels = %v, want %v", result.Identity.Labels, tt.want)
+			}
+			for k, v := range tt.want {
+				if result.Identity.Labels[k] != v {
+					t.Errorf("label %q = %q, want %q", k, result.Identity.Labels[k], v)
+				}
+			}
+
+			// Later calls on the connection carry the session labels.
+			next, err := interceptor.Intercept(ctx, &CanonicalAction{Type: ActionToolCall, Name: "test_tool"})
+			if err != nil {
+				t.Fatalf("follow-up call failed: %v", err)
+			}
+			if len(next.Identity.Labels) != len(tt.want) {
+				t.Errorf("follow-up labels = %v, want %v", next.Identity.Labels, tt.want)
+			}
+		})
+	}
+}
+
+// tokenStub accepts credentials starting with "tok." and maps them to a
+// fixed identity.
+type tokenStub struct {
+	expiry time.Time
+}
+
+func (s tokenStub) Accepts(credential string) bool { return len(credential) > 4 && credential[:4] == "tok." }
+
+func (s tokenStub) VerifyToken(_ context.Context, token string) (*auth.Identity, time.Time, error) {
+	if token != "tok.valid" {
+		return nil, time.Time{}, errors.New("bad token")
+	}
+	return &auth.Identity{ID: "oidc:alice", Name: "alice", Roles: []auth.Role{auth.RoleDeveloper}}, s.expiry, nil
+}
+
+func TestActionAuthInterceptor_BearerToken(t *testing.T) {
+	interceptor := setupAuthInterceptor(t, true)
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	interceptor.SetClock(func() time.Time { return now })
+	interceptor.SetTokenVerifier(tokenStub{expiry: now.Add(5 * time.Minute)})
+
+	call := func(credential string) (*CanonicalAction, error) {
+		ctx := context.WithValue(context.Background(), proxy.APIKeyContextKey, credential)
+		ctx = context.WithValue(ctx, proxy.ConnectionIDKey, "conn-"+credential)
+		return interceptor.Intercept(ctx, &CanonicalAction{Type: ActionToolCall, Name: "test_tool"})
+	}
+
+	result, err := call("tok.valid")
+	if err != nil {
+		t.Fatalf("valid token: %v", err)
+	}
+	if result.Identity.ID != "oidc:alice" || len(result.Identity.Roles) != 1 || result.Identity.Roles[0] != "developer" {
+		t.Errorf("identity = %+v", result.Identity)
+	}
+	firstSession := result.Identity.SessionID
+
+	if _, err := call("tok.forged"); !errors.Is(err, proxy.ErrInvalidAPIKey) {
+		t.Errorf("invalid token: err = %v, want ErrInvalidAPIKey", err)
+	}
+
+	// API keys keep working next to tokens.
+	if result, err := call("test-api-key"); err != nil || result.Identity.ID != "test-id" {
+		t.Errorf("API key: result = %+v, err = %v", result, err)
+	}
+
+	// The cached session is reused until the token expires.
+	result, err = call("tok.valid")
+	if err != nil || result.Identity.SessionID != firstSession {
+		t.Fatalf("cached session not reused: err = %v", err)
+	}
+	now = now.Add(10 * time.Minute)
+	interceptor.SetTokenVerifier(tokenStub{expiry: now.Add(5 * time.Minute)})
+	result, err = call("tok.valid")
+	if err != nil {
+		t.Fatalf("refreshed token: %v", err)
+	}
+	if result.Identity.SessionID == firstSession {
+		t.Error("session outlived its token")
+	}
+}
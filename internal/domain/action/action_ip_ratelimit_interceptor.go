@@ -3,6 +3,7 @@ package action
 import (
 	"context"
 	"log/slog"
+	"sync/atomic"
 
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/proxy"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/ratelimit"
@@ -14,7 +15,7 @@ import (
 // Native ActionInterceptor replacement for proxy.IPRateLimitInterceptor.
 type ActionIPRateLimitInterceptor struct {
 	limiter  ratelimit.RateLimiter
-	ipConfig ratelimit.RateLimitConfig
+	ipConfig atomic.Pointer[ratelimit.RateLimitConfig]
 	next     ActionInterceptor
 	logger   *slog.Logger
 }
@@ -29,12 +30,19 @@ func NewActionIPRateLimitInterceptor(
 	next ActionInterceptor,
 	logger *slog.Logger,
 ) *ActionIPRateLimitInterceptor {
-	return &ActionIPRateLimitInterceptor{
-		limiter:  limiter,
-		ipConfig: ipConfig,
-		next:     next,
-		logger:   logger,
+	r := &ActionIPRateLimitInterceptor{
+		limiter: limiter,
+		next:    next,
+		logger:  logger,
 	}
+	r.ipConfig.Store(&ipConfig)
+	return r
+}
+
+// SetConfig replaces the IP rate and burst. Safe to call while the
+// interceptor serves requests; buckets already in use keep their state.
+func (r *ActionIPRateLimitInterceptor) SetConfig(cfg ratelimit.RateLimitConfig) {
+	r.ipConfig.Store(&cfg)
 }
 
 // Intercept checks IP rate limits before passing to the next interceptor.
@@ -54,7 +62,7 @@ func (r *ActionIPRateLimitInterceptor) Intercept(ctx context.Context, act *Canon
 
 	// Check IP rate limit
 	ipKey := ratelimit.FormatKey(ratelimit.KeyTypeIP, ip)
-	ipResult, err := r.limiter.Allow(ctx, ipKey, r.ipConfig)
+	ipResult, err := r.limiter.Allow(ctx, ipKey, *r.ipConfig.Load())
 	if err != nil {
 		r.logger.Error("failed to check IP rate limit",
 			"ip", ip,
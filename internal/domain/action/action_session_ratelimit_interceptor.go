@@ -0,0 +1,87 @@
+package action
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/proxy"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/ratelimit"
+	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
+)
+
+// ActionSessionRateLimitInterceptor enforces per-session rate limits on
+// authenticated requests. It sits in front of the per-user limiter so that
+// one busy session of an identity is throttled on its own bucket before it
+// can drain the user bucket shared by all of that identity's sessions.
+type ActionSessionRateLimitInterceptor struct {
+	limiter       ratelimit.RateLimiter
+	sessionConfig atomic.Pointer[ratelimit.RateLimitConfig]
+	next          ActionInterceptor
+	logger        *slog.Logger
+}
+
+// Compile-time check that ActionSessionRateLimitInterceptor implements ActionInterceptor.
+var _ ActionInterceptor = (*ActionSessionRateLimitInterceptor)(nil)
+
+// NewActionSessionRateLimitInterceptor creates a new ActionSessionRateLimitInterceptor.
+func NewActionSessionRateLimitInterceptor(
+	limiter ratelimit.RateLimiter,
+	sessionConfig ratelimit.RateLimitConfig,
+	next ActionInterceptor,
+	logger *slog.Logger,
+) *ActionSessionRateLimitInterceptor {
+	r := &ActionSessionRateLimitInterceptor{
+		limiter: limiter,
+		next:    next,
+		logger:  logger,
+	}
+	r.sessionConfig.Store(&sessionConfig)
+	return r
+}
+
+// SetConfig replaces the session rate and burst. Safe to call while the
+// interceptor serves requests; buckets already in use keep their state.
+func (r *ActionSessionRateLimitInterceptor) SetConfig(cfg ratelimit.RateLimitConfig) {
+	r.sessionConfig.Store(&cfg)
+}
+
+// Intercept checks per-session rate limits for authenticated requests.
+func (r *ActionSessionRateLimitInterceptor) Intercept(ctx context.Context, act *CanonicalAction) (*CanonicalAction, error) {
+	// Only rate limit client-to-server requests
+	if mcpMsg, ok := act.OriginalMessage.(*mcp.Message); ok {
+		if mcpMsg.Direction != mcp.ClientToServer {
+			return r.next.Intercept(ctx, act)
+		}
+	}
+
+	// Rate limit by session (skip if no authenticated session)
+	if act.Identity.ID != "" && act.Identity.SessionID != "" {
+		sessionKey := ratelimit.FormatKey(ratelimit.KeyTypeSession, act.Identity.SessionID)
+		sessionResult, err := r.limiter.Allow(ctx, sessionKey, *r.sessionConfig.Load())
+		if err != nil {
+			r.logger.Error("failed to check session rate limit",
+				"session_id", act.Identity.SessionID,
+				"error", err,
+			)
+			// On error, allow through (fail-open)
+			return r.next.Intercept(ctx, act)
+		}
+
+		if !sessionResult.Allowed {
+			r.logger.Warn("session rate limited",
+				"session_id", act.Identity.SessionID,
+				"identity_id", act.Identity.ID,
+				"retry_after", sessionResult.RetryAfter,
+			)
+			return nil, &proxy.RateLimitError{RetryAfter: sessionResult.RetryAfter}
+		}
+
+		r.logger.Debug("session rate limit check passed",
+			"session_id", act.Identity.SessionID,
+			"remaining", sessionResult.Remaining,
+		)
+	}
+
+	return r.next.Intercept(ctx, act)
+}
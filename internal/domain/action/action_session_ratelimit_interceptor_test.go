@@ -0,0 +1,65 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/memory"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/proxy"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/ratelimit"
+)
+
+func TestActionSessionRateLimit_IsolatesSessions(t *testing.T) {
+	limiter := memory.NewRateLimiter()
+	// GCRA with Rate=1 Burst=1 allows two requests before denying.
+	cfg := ratelimit.RateLimitConfig{Rate: 1, Burst: 1, Period: time.Minute}
+	interceptor := NewActionSessionRateLimitInterceptor(limiter, cfg, &passThrough{}, newTestLogger())
+
+	ctx := context.Background()
+	busy := &CanonicalAction{
+		Type:     ActionToolCall,
+		Name:     "read_file",
+		Identity: ActionIdentity{ID: "user-1", Name: "Alice", SessionID: "sess-busy"},
+	}
+	quiet := &CanonicalAction{
+		Type:     ActionToolCall,
+		Name:     "read_file",
+		Identity: ActionIdentity{ID: "user-1", Name: "Alice", SessionID: "sess-quiet"},
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := interceptor.Intercept(ctx, busy); err != nil {
+			t.Fatalf("request %d: expected no error, got %v", i+1, err)
+		}
+	}
+
+	_, err := interceptor.Intercept(ctx, busy)
+	var rateLimitErr *proxy.RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatalf("expected *proxy.RateLimitError for busy session, got %T: %v", err, err)
+	}
+
+	// Another session of the same identity has its own bucket.
+	if _, err := interceptor.Intercept(ctx, quiet); err != nil {
+		t.Fatalf("expected quiet session to pass, got %v", err)
+	}
+}
+
+func TestActionSessionRateLimit_NoSession(t *testing.T) {
+	limiter := memory.NewRateLimiter()
+	cfg := ratelimit.RateLimitConfig{Rate: 1, Burst: 1, Period: time.Minute}
+	interceptor := NewActionSessionRateLimitInterceptor(limiter, cfg, &passThrough{}, newTestLogger())
+
+	act := &CanonicalAction{
+		Type:     ActionToolCall,
+		Name:     "read_file",
+		Identity: ActionIdentity{}, // Unauthenticated => skip session check
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := interceptor.Intercept(context.Background(), act); err != nil {
+			t.Fatalf("call %d: expected no error without session, got %v", i+1, err)
+		}
+	}
+}
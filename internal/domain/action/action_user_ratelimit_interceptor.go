@@ -2,21 +2,38 @@ package action
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"sync/atomic"
+	"time"
 
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/proxy"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/ratelimit"
 	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
 )
 
+// RateLimitExempter decides whether a call bypasses the user rate limit.
+// Implemented by service.RateLimitExemptionService.
+type RateLimitExempter interface {
+	Exempt(ctx context.Context, act *CanonicalAction) bool
+}
+
 // ActionUserRateLimitInterceptor enforces per-user rate limits on authenticated requests.
 // It runs after authentication so action.Identity is populated.
 // Native ActionInterceptor replacement for proxy.UserRateLimitInterceptor.
 type ActionUserRateLimitInterceptor struct {
 	limiter    ratelimit.RateLimiter
-	userConfig ratelimit.RateLimitConfig
+	userConfig atomic.Pointer[ratelimit.RateLimitConfig]
 	next       ActionInterceptor
 	logger     *slog.Logger
+
+	// overrides replace the user rate of some identities and limit the
+	// calls of some tools (nil = none).
+	overrides atomic.Pointer[ratelimit.Overrides]
+
+	// exempter lets calls matched by exempt_rate_limit rules bypass the
+	// user rate (nil = no exemptions).
+	exempter RateLimitExempter
 }
 
 // Compile-time check that ActionUserRateLimitInterceptor implements ActionInterceptor.
@@ -29,12 +46,31 @@ func NewActionUserRateLimitInterceptor(
 	next ActionInterceptor,
 	logger *slog.Logger,
 ) *ActionUserRateLimitInterceptor {
-	return &ActionUserRateLimitInterceptor{
-		limiter:    limiter,
-		userConfig: userConfig,
-		next:       next,
-		logger:     logger,
+	r := &ActionUserRateLimitInterceptor{
+		limiter: limiter,
+		next:    next,
+		logger:  logger,
 	}
+	r.userConfig.Store(&userConfig)
+	return r
+}
+
+// SetConfig replaces the user rate and burst. Safe to call while the
+// interceptor serves requests; buckets already in use keep their state.
+func (r *ActionUserRateLimitInterceptor) SetConfig(cfg ratelimit.RateLimitConfig) {
+	r.userConfig.Store(&cfg)
+}
+
+// SetOverrides replaces the per-identity and per-tool overrides. Safe to
+// call while the interceptor serves requests.
+func (r *ActionUserRateLimitInterceptor) SetOverrides(o *ratelimit.Overrides) {
+	r.overrides.Store(o)
+}
+
+// SetExempter sets the exempter consulted before the user rate is checked.
+// Must be called before the interceptor serves requests.
+func (r *ActionUserRateLimitInterceptor) SetExempter(e RateLimitExempter) {
+	r.exempter = e
 }
 
 // Intercept checks per-user rate limits for authenticated requests.
@@ -48,30 +84,144 @@ func (r *ActionUserRateLimitInterceptor) Intercept(ctx context.Context, act *Can
 
 	// Rate limit by identity (skip if not authenticated)
 	if act.Identity.ID != "" {
-		userKey := ratelimit.FormatKey(ratelimit.KeyTypeUser, act.Identity.ID)
-		userResult, err := r.limiter.Allow(ctx, userKey, r.userConfig)
-		if err != nil {
-			r.logger.Error("failed to check user rate limit",
+		overrides := r.overrides.Load()
+		userConfig := *r.userConfig.Load()
+		if o, ok := overrides.ForIdentity(act.Identity.ID, act.Identity.Name); ok {
+			userConfig = o.Config()
+		}
+		// Exempted calls skip the user rate only: tool overrides still apply.
+		if r.exempter != nil && r.exempter.Exempt(ctx, act) {
+			r.logger.Debug("user rate limit exempted",
 				"identity_id", act.Identity.ID,
-				"error", err,
+				"tool", act.Name,
 			)
-			// On error, allow through (fail-open)
-			return r.next.Intercept(ctx, act)
+		} else if err := r.checkUserLimit(ctx, act, userConfig); err != nil {
+			return nil, err
 		}
 
-		if !userResult.Allowed {
-			r.logger.Warn("user rate limited",
-				"identity_id", act.Identity.ID,
-				"retry_after", userResult.RetryAfter,
-			)
-			return nil, &proxy.RateLimitError{RetryAfter: userResult.RetryAfter}
+		if act.Type == ActionToolCall {
+			if o, ok := overrides.ForTool(act.Identity.ID, act.Identity.Name, act.Name); ok {
+				if err := r.checkToolLimit(ctx, act, o); err != nil {
+					return nil, err
+				}
+			}
 		}
+	}
+
+	return r.next.Intercept(ctx, act)
+}
 
-		r.logger.Debug("user rate limit check passed",
+// checkUserLimit enforces the user rate on the identity's bucket.
+func (r *ActionUserRateLimitInterceptor) checkUserLimit(ctx context.Context, act *CanonicalAction, userConfig ratelimit.RateLimitConfig) error {
+	userKey := ratelimit.FormatKey(ratelimit.KeyTypeUser, act.Identity.ID)
+	userResult, err := r.limiter.Allow(ctx, userKey, userConfig)
+	if err != nil {
+		r.logger.Error("failed to check user rate limit",
 			"identity_id", act.Identity.ID,
-			"remaining", userResult.Remaining,
+			"error", err,
 		)
+		return nil // fail-open
 	}
 
-	return r.next.Intercept(ctx, act)
+	if !userResult.Allowed {
+		r.logger.Warn("user rate limited",
+			"identity_id", act.Identity.ID,
+			"retry_after", userResult.RetryAfter,
+		)
+		return &proxy.RateLimitError{RetryAfter: userResult.RetryAfter}
+	}
+
+	r.logger.Debug("user rate limit check passed",
+		"identity_id", act.Identity.ID,
+		"remaining", userResult.Remaining,
+	)
+	return nil
+}
+
+// checkToolLimit enforces a tool override on the identity's own bucket for
+// the override, shared by every tool the override matches.
+func (r *ActionUserRateLimitInterceptor) checkToolLimit(ctx context.Context, act *CanonicalAction, o ratelimit.Override) error {
+	key := ratelimit.FormatKey(ratelimit.KeyTypeTool, o.ID+":"+act.Identity.ID)
+	result, err := r.limiter.Allow(ctx, key, o.Config())
+	if err != nil {
+		r.logger.Error("failed to check tool rate limit",
+			"identity_id", act.Identity.ID,
+			"tool", act.Name,
+			"error", err,
+		)
+		return nil // fail-open, like the user tier
+	}
+	if !result.Allowed {
+		r.logger.Warn("tool rate limited",
+			"identity_id", act.Identity.ID,
+			"tool", act.Name,
+			"override", o.ID,
+			"retry_after", result.RetryAfter,
+		)
+		return &proxy.RateLimitError{RetryAfter: result.RetryAfter}
+	}
+	return nil
+}
+
+// RateLimitStatus is the state of one rate limit bucket of an identity.
+type RateLimitStatus struct {
+	// Scope is "user" for the user rate, "tool" for a tool override.
+	Scope string `json:"scope"`
+	// Tool is the tool name or pattern of a tool override.
+	Tool       string `json:"tool,omitempty"`
+	OverrideID string `json:"override_id,omitempty"`
+	// Rate is the number of requests allowed per minute.
+	Rate      int `json:"rate"`
+	Burst     int `json:"burst"`
+	Remaining int `json:"remaining"`
+	// Limited is true while requests are rejected, until RetryAfter.
+	Limited    bool          `json:"limited"`
+	RetryAfter time.Duration `json:"-"`
+}
+
+// Status returns the user rate bucket of the identity, then the buckets of
+// the tool overrides applying to it. The counters are not changed.
+func (r *ActionUserRateLimitInterceptor) Status(ctx context.Context, identityID, identityName string) ([]RateLimitStatus, error) {
+	inspector, ok := r.limiter.(ratelimit.Inspector)
+	if !ok {
+		return nil, fmt.Errorf("rate limiter %T cannot report its state", r.limiter)
+	}
+	overrides := r.overrides.Load()
+	userConfig := *r.userConfig.Load()
+	userOverride := ""
+	if o, ok := overrides.ForIdentity(identityID, identityName); ok {
+		userConfig = o.Config()
+		userOverride = o.ID
+	}
+
+	peek := func(key string, config ratelimit.RateLimitConfig, st RateLimitStatus) (RateLimitStatus, error) {
+		result, err := inspector.Peek(ctx, key, config)
+		if err != nil {
+			return st, err
+		}
+		st.Rate, st.Burst = config.Rate, config.Burst
+		if st.Burst <= 0 {
+			st.Burst = st.Rate
+		}
+		st.Remaining = result.Remaining
+		st.Limited = !result.Allowed
+		st.RetryAfter = result.RetryAfter
+		return st, nil
+	}
+
+	user, err := peek(ratelimit.FormatKey(ratelimit.KeyTypeUser, identityID), userConfig,
+		RateLimitStatus{Scope: "user", OverrideID: userOverride})
+	if err != nil {
+		return nil, err
+	}
+	statuses := []RateLimitStatus{user}
+	for _, o := range overrides.ToolsFor(identityID, identityName) {
+		st, err := peek(ratelimit.FormatKey(ratelimit.KeyTypeTool, o.ID+":"+identityID), o.Config(),
+			RateLimitStatus{Scope: "tool", Tool: o.Tool, OverrideID: o.ID})
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, st)
+	}
+	return statuses, nil
 }
@@ -124,3 +124,124 @@ func TestActionUserRateLimit_SkipsServerToClient(t *testing.T) {
 		}
 	}
 }
+
+func TestActionUserRateLimit_Overrides(t *testing.T) {
+	limiter := memory.NewRateLimiter()
+	// Rate=1 Burst=1 allows two requests, then denies.
+	cfg := ratelimit.RateLimitConfig{Rate: 1, Burst: 1, Period: time.Minute}
+	interceptor := NewActionUserRateLimitInterceptor(limiter, cfg, &passThrough{}, newTestLogger())
+	interceptor.SetOverrides(ratelimit.NewOverrides([]ratelimit.Override{
+		{ID: "bot", Identity: "ci-bot", Rate: 1000, Burst: 1000},
+		{ID: "email", Tool: "send_email", Rate: 1, Burst: 1},
+	}))
+
+	ctx := context.Background()
+	bot := ActionIdentity{ID: "id-bot", Name: "ci-bot"}
+	for i := 0; i < 10; i++ {
+		act := &CanonicalAction{Type: ActionToolCall, Name: "read_file", Identity: bot}
+		if _, err := interceptor.Intercept(ctx, act); err != nil {
+			t.Fatalf("ci-bot request %d: expected the identity override to allow, got %v", i+1, err)
+		}
+	}
+
+	// send_email is limited per user on its own bucket, even for ci-bot.
+	var err error
+	for i := 0; i < 3; i++ {
+		_, err = interceptor.Intercept(ctx, &CanonicalAction{Type: ActionToolCall, Name: "send_email", Identity: bot})
+	}
+	var rateLimitErr *proxy.RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatalf("expected the tool override to deny the 3rd send_email, got %v", err)
+	}
+
+	// Another user has a bucket of its own for the tool.
+	alice := ActionIdentity{ID: "id-alice", Name: "alice"}
+	if _, err := interceptor.Intercept(ctx, &CanonicalAction{Type: ActionToolCall, Name: "send_email", Identity: alice}); err != nil {
+		t.Fatalf("alice send_email: expected no error, got %v", err)
+	}
+
+	// Removing the overrides restores the global user rate.
+	interceptor.SetOverrides(nil)
+	for i := 0; i < 3; i++ {
+		_, err = interceptor.Intercept(ctx, &CanonicalAction{Type: ActionToolCall, Name: "read_file", Identity: alice})
+	}
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatalf("expected the global user rate to deny, got %v", err)
+	}
+}
+
+func TestActionUserRateLimit_Status(t *testing.T) {
+	limiter := memory.NewRateLimiter()
+	cfg := ratelimit.RateLimitConfig{Rate: 1, Burst: 1, Period: time.Minute}
+	interceptor := NewActionUserRateLimitInterceptor(limiter, cfg, &passThrough{}, newTestLogger())
+	interceptor.SetOverrides(ratelimit.NewOverrides([]ratelimit.Override{
+		{ID: "email", Tool: "send_email", Rate: 5},
+		{ID: "bob-deploy", Identity: "bob", Tool: "deploy", Rate: 1},
+	}))
+	ctx := context.Background()
+	alice := ActionIdentity{ID: "id-alice", Name: "alice"}
+
+	statuses, err := interceptor.Status(ctx, alice.ID, alice.Name)
+	if err != nil {
+		t.Fatalf("Status() error: %v", err)
+	}
+	if len(statuses) != 2 || statuses[0].Scope != "user" || statuses[0].Remaining != 1 || statuses[0].Limited ||
+		statuses[1].OverrideID != "email" || statuses[1].Burst != 5 || statuses[1].Remaining != 5 {
+		t.Fatalf("Status() = %+v", statuses)
+	}
+
+	for i := 0; i < 3; i++ {
+		_, _ = interceptor.Intercept(ctx, &CanonicalAction{Type: ActionToolCall, Name: "send_email", Identity: alice})
+	}
+	statuses, _ = interceptor.Status(ctx, alice.ID, alice.Name)
+	if !statuses[0].Limited || statuses[0].RetryAfter <= 0 {
+		t.Errorf("user status after exhaustion = %+v, want limited", statuses[0])
+	}
+	// The user rate denied the third call before the tool override counted it.
+	if statuses[1].Limited || statuses[1].Remaining >= 5 {
+		t.Errorf("send_email status = %+v, want some requests used", statuses[1])
+	}
+}
+
+type exemptTools map[string]bool
+
+func (e exemptTools) Exempt(_ context.Context, act *CanonicalAction) bool { return e[act.Name] }
+
+func TestActionUserRateLimit_Exempter(t *testing.T) {
+	limiter := memory.NewRateLimiter()
+	cfg := ratelimit.RateLimitConfig{Rate: 1, Burst: 1, Period: time.Minute}
+	interceptor := NewActionUserRateLimitInterceptor(limiter, cfg, &passThrough{}, newTestLogger())
+	interceptor.SetOverrides(ratelimit.NewOverrides([]ratelimit.Override{
+		{ID: "export", Tool: "export_db", Rate: 1, Burst: 1},
+	}))
+	interceptor.SetExempter(exemptTools{"read_file": true, "export_db": true})
+
+	ctx := context.Background()
+	backup := ActionIdentity{ID: "id-backup", Name: "backup-agent"}
+	for i := 0; i < 10; i++ {
+		if _, err := interceptor.Intercept(ctx, &CanonicalAction{Type: ActionToolCall, Name: "read_file", Identity: backup}); err != nil {
+			t.Fatalf("exempt request %d: expected no error, got %v", i+1, err)
+		}
+	}
+
+	// Tool overrides still apply to exempted calls.
+	var err error
+	for i := 0; i < 3; i++ {
+		_, err = interceptor.Intercept(ctx, &CanonicalAction{Type: ActionToolCall, Name: "export_db", Identity: backup})
+	}
+	var rateLimitErr *proxy.RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatalf("expected the tool override to deny the 3rd export_db, got %v", err)
+	}
+
+	// Exempted calls did not use the user bucket; other calls still do.
+	for i := 0; i < 3; i++ {
+		_, err = interceptor.Intercept(ctx, &CanonicalAction{Type: ActionToolCall, Name: "write_file", Identity: backup})
+		if i < 2 && err != nil {
+			t.Fatalf("write_file request %d: expected no error, got %v", i+1, err)
+		}
+	}
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatalf("expected the user rate to deny the 3rd write_file, got %v", err)
+	}
+}
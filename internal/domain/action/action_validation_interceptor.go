@@ -38,6 +38,12 @@ func NewActionValidationInterceptor(next ActionInterceptor, logger *slog.Logger)
 	}
 }
 
+// SetMethodPolicy sets the policy deciding which non-tool methods pass
+// validation. Call before serving requests.
+func (v *ActionValidationInterceptor) SetMethodPolicy(p *validation.MethodPolicy) {
+	v.validator.SetMethodPolicy(p)
+}
+
 // Intercept validates the message based on direction.
 func (v *ActionValidationInterceptor) Intercept(ctx context.Context, act *CanonicalAction) (*CanonicalAction, error) {
 	mcpMsg, ok := act.OriginalMessage.(*mcp.Message)
@@ -0,0 +1,301 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ApprovalScope is how far an approval reaches.
+type ApprovalScope string
+
+const (
+	// ApprovalScopeOnce approves the pending call only.
+	ApprovalScopeOnce ApprovalScope = "once"
+	// ApprovalScopeSession also approves the later calls of the same tool,
+	// matched by the same rule, in the same session.
+	ApprovalScopeSession ApprovalScope = "session"
+	// ApprovalScopeIdentity also approves the later calls of the same tool,
+	// matched by the same rule, of the same identity in any session.
+	ApprovalScopeIdentity ApprovalScope = "identity"
+)
+
+const (
+	// DefaultApprovalGrantDuration is how long a session or identity
+	// approval lasts when the approver sets no duration.
+	DefaultApprovalGrantDuration = 24 * time.Hour
+	// DefaultMaxApprovalGrantDuration caps the duration of session and
+	// identity approvals.
+	DefaultMaxApprovalGrantDuration = 7 * 24 * time.Hour
+)
+
+var (
+	// ErrInvalidApprovalScope is returned for an unknown scope, or a
+	// duration the scope does not take or the store does not allow.
+	ErrInvalidApprovalScope = errors.New("invalid approval scope")
+	// ErrApprovalGrantNotFound is returned when a grant ID does not exist.
+	ErrApprovalGrantNotFound = errors.New("approval grant not found")
+	// ErrNotDelegated is returned when an identity decides an approval that
+	// was not delegated to it.
+	ErrNotDelegated = errors.New("approval not delegated to this identity")
+	// ErrSelfApproval is returned when an identity decides an approval of
+	// one of its own calls.
+	ErrSelfApproval = errors.New("an identity cannot decide its own approval")
+)
+
+// ApprovalDelegation names the identities and roles that may decide an
+// approval besides the admins.
+type ApprovalDelegation struct {
+	// Identities are identity IDs or names.
+	Identities []string `json:"identities,omitempty"`
+	Roles      []string `json:"roles,omitempty"`
+}
+
+// Empty reports whether nothing is delegated.
+func (d ApprovalDelegation) Empty() bool {
+	return len(d.Identities) == 0 && len(d.Roles) == 0
+}
+
+// Allows reports whether the identity is one of the delegates.
+func (d ApprovalDelegation) Allows(identityID, identityName string, roles []string) bool {
+	for _, id := range d.Identities {
+		if id == identityID || (identityName != "" && id == identityName) {
+			return true
+		}
+	}
+	for _, want := range d.Roles {
+		for _, role := range roles {
+			if role == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (d ApprovalDelegation) clone() ApprovalDelegation {
+	return ApprovalDelegation{
+		Identities: append([]string(nil), d.Identities...),
+		Roles:      append([]string(nil), d.Roles...),
+	}
+}
+
+// ApprovalGrant approves the later calls of a tool matched by the same rule,
+// in a session or for an identity, without asking again until it expires.
+type ApprovalGrant struct {
+	ID           string        `json:"id"`
+	Scope        ApprovalScope `json:"scope"`
+	ToolName     string        `json:"tool_name"`
+	RuleID       string        `json:"rule_id,omitempty"`
+	RuleName     string        `json:"rule_name,omitempty"`
+	IdentityID   string        `json:"identity_id"`
+	IdentityName string        `json:"identity_name,omitempty"`
+	// SessionID is set for session grants only.
+	SessionID string `json:"session_id,omitempty"`
+	// ApprovalID is the approval the grant was made with.
+	ApprovalID string    `json:"approval_id"`
+	GrantedBy  string    `json:"granted_by,omitempty"`
+	GrantedAt  time.Time `json:"granted_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// Uses counts the calls the grant approved.
+	Uses int64 `json:"uses"`
+}
+
+// DefaultTimeout returns how long an approval waits when its rule sets no
+// timeout.
+func (s *ApprovalStore) DefaultTimeout() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.defaultTimeout
+}
+
+// newGrantLocked builds the grant an approval with opts makes, nil for a
+// one-time approval. Caller must hold s.mu.
+func (s *ApprovalStore) newGrantLocked(p *PendingApproval, opts ApproveOptions) (*ApprovalGrant, error) {
+	switch opts.Scope {
+	case "", ApprovalScopeOnce:
+		if opts.Duration != 0 {
+			return nil, fmt.Errorf("%w: a one-time approval takes no duration", ErrInvalidApprovalScope)
+		}
+		return nil, nil
+	case ApprovalScopeSession:
+		if p.SessionID == "" {
+			return nil, fmt.Errorf("%w: the call has no session", ErrInvalidApprovalScope)
+		}
+	case ApprovalScopeIdentity:
+		if p.IdentityID == "" {
+			return nil, fmt.Errorf("%w: the call has no identity", ErrInvalidApprovalScope)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %q (want once, session or identity)", ErrInvalidApprovalScope, opts.Scope)
+	}
+	duration := opts.Duration
+	if duration == 0 {
+		duration = s.grantDuration
+	}
+	if duration < 0 || duration > s.maxGrantDuration {
+		return nil, fmt.Errorf("%w: duration must be positive and at most %s", ErrInvalidApprovalScope, s.maxGrantDuration)
+	}
+	now := s.now().UTC()
+	g := &ApprovalGrant{
+		ID:           uuid.New().String(),
+		Scope:        opts.Scope,
+		ToolName:     p.ToolName,
+		RuleID:       p.RuleID,
+		RuleName:     p.RuleName,
+		IdentityID:   p.IdentityID,
+		IdentityName: p.IdentityName,
+		ApprovalID:   p.ID,
+		GrantedBy:    opts.By,
+		GrantedAt:    now,
+		ExpiresAt:    now.Add(duration),
+	}
+	if opts.Scope == ApprovalScopeSession {
+		g.SessionID = p.SessionID
+	}
+	return g, nil
+}
+
+// matchGrant returns a copy of the unexpired grant covering a call of tool
+// matched by ruleID, counting the use, or nil.
+func (s *ApprovalStore) matchGrant(identityID, sessionID, tool, ruleID string) *ApprovalGrant {
+	if identityID == "" {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.grants) == 0 {
+		return nil
+	}
+	now := s.now()
+	s.pruneGrantsLocked(now)
+	for _, g := range s.grants {
+		if g.IdentityID != identityID || g.ToolName != tool || g.RuleID != ruleID {
+			continue
+		}
+		if g.Scope == ApprovalScopeSession && g.SessionID != sessionID {
+			continue
+		}
+		g.Uses++
+		cp := *g
+		return &cp
+	}
+	return nil
+}
+
+// Grants returns the unexpired grants, soonest to expire first.
+func (s *ApprovalStore) Grants() []ApprovalGrant {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneGrantsLocked(s.now())
+	grants := make([]ApprovalGrant, 0, len(s.grants))
+	for _, g := range s.grants {
+		grants = append(grants, *g)
+	}
+	sort.Slice(grants, func(i, j int) bool {
+		if !grants[i].ExpiresAt.Equal(grants[j].ExpiresAt) {
+			return grants[i].ExpiresAt.Before(grants[j].ExpiresAt)
+		}
+		return grants[i].ID < grants[j].ID
+	})
+	return grants
+}
+
+// RevokeGrant ends a grant: later calls are held for approval again.
+func (s *ApprovalStore) RevokeGrant(id string) error {
+	s.mu.Lock()
+	g, ok := s.grants[id]
+	if !ok {
+		s.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrApprovalGrantNotFound, id)
+	}
+	delete(s.grants, id)
+	snap := approvalEventPayload{
+		ID:           g.ApprovalID,
+		ToolName:     g.ToolName,
+		IdentityName: g.IdentityName,
+		IdentityID:   g.IdentityID,
+		SessionID:    g.SessionID,
+		RuleID:       g.RuleID,
+		RuleName:     g.RuleName,
+	}
+	s.mu.Unlock()
+	s.emitEvent("approval.grant_revoked", snap, "", "")
+	return nil
+}
+
+// ClearGrants ends every grant (factory reset).
+func (s *ApprovalStore) ClearGrants() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.grants = make(map[string]*ApprovalGrant)
+}
+
+// pruneGrantsLocked drops the expired grants. Caller must hold s.mu.
+func (s *ApprovalStore) pruneGrantsLocked(now time.Time) {
+	for id, g := range s.grants {
+		if !now.Before(g.ExpiresAt) {
+			delete(s.grants, id)
+		}
+	}
+}
+
+// Delegate sets who besides the admins may decide a pending approval,
+// replacing any previous delegation. An empty delegation takes it back.
+func (s *ApprovalStore) Delegate(id string, d ApprovalDelegation, by string) error {
+	s.mu.Lock()
+	p, ok := s.pending[id]
+	if !ok {
+		s.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrApprovalNotFound, id)
+	}
+	if p.Status != "pending" {
+		s.mu.Unlock()
+		return fmt.Errorf("%w: approval %s is already %s", ErrAlreadyResolved, id, p.Status)
+	}
+	p.Delegation = d.clone()
+	snap := snapshotApproval(p)
+	s.mu.Unlock()
+	s.emitEvent("approval.delegated", snap, "", "delegated by "+by)
+	return nil
+}
+
+// ListDelegated returns the pending approvals delegated to the identity,
+// except those of its own calls.
+func (s *ApprovalStore) ListDelegated(identityID, identityName string, roles []string) []*PendingApproval {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var result []*PendingApproval
+	for _, id := range s.order {
+		p, ok := s.pending[id]
+		if !ok || p.Status != "pending" || p.IdentityID == identityID {
+			continue
+		}
+		if p.Delegation.Allows(identityID, identityName, roles) {
+			result = append(result, copyApproval(p))
+		}
+	}
+	return result
+}
+
+// CheckDelegate returns nil when the identity may decide the approval:
+// ErrApprovalNotFound when there is no such pending approval, ErrSelfApproval
+// for one of its own calls and ErrNotDelegated when it is not a delegate.
+func (s *ApprovalStore) CheckDelegate(id, identityID, identityName string, roles []string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	p, ok := s.pending[id]
+	if !ok || p.Status != "pending" {
+		return fmt.Errorf("%w: %s", ErrApprovalNotFound, id)
+	}
+	if p.IdentityID == identityID {
+		return ErrSelfApproval
+	}
+	if !p.Delegation.Allows(identityID, identityName, roles) {
+		return ErrNotDelegated
+	}
+	return nil
+}
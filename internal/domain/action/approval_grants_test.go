@@ -0,0 +1,220 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/policy"
+)
+
+func TestApprovalStore_ApproveScopes(t *testing.T) {
+	store := NewApprovalStore(10)
+	add := func(id, session string) {
+		t.Helper()
+		if err := store.Add(NewTestPendingApproval(id, "delete_file", "agent", "agent-1", session, "rule-1", "ask", time.Minute)); err != nil {
+			t.Fatalf("Add(%s): %v", id, err)
+		}
+	}
+
+	add("once", "s1")
+	if g, err := store.ApproveWith("once", ApproveOptions{}); err != nil || g != nil {
+		t.Fatalf("ApproveWith(once) = %+v, %v; want no grant", g, err)
+	}
+	if g := store.matchGrant("agent-1", "s1", "delete_file", "rule-1"); g != nil {
+		t.Fatalf("matchGrant() after a one-time approval = %+v", g)
+	}
+
+	add("bad", "s1")
+	for _, opts := range []ApproveOptions{
+		{Scope: "forever"},
+		{Scope: ApprovalScopeOnce, Duration: time.Hour},
+		{Scope: ApprovalScopeIdentity, Duration: 30 * 24 * time.Hour},
+	} {
+		if _, err := store.ApproveWith("bad", opts); !errors.Is(err, ErrInvalidApprovalScope) {
+			t.Errorf("ApproveWith(%+v) error = %v, want ErrInvalidApprovalScope", opts, err)
+		}
+	}
+	if p := store.Get("bad"); p.Status != "pending" {
+		t.Errorf("status after invalid scope = %s, want pending", p.Status)
+	}
+
+	g, err := store.ApproveWith("bad", ApproveOptions{Scope: ApprovalScopeSession, By: "admin@127.0.0.1"})
+	if err != nil || g == nil || g.SessionID != "s1" || g.GrantedBy != "admin@127.0.0.1" {
+		t.Fatalf("ApproveWith(session) = %+v, %v", g, err)
+	}
+	if d := g.ExpiresAt.Sub(g.GrantedAt); d != DefaultApprovalGrantDuration {
+		t.Errorf("grant duration = %s, want %s", d, DefaultApprovalGrantDuration)
+	}
+	if p := store.Get("bad"); p.ResolvedBy != "admin@127.0.0.1" {
+		t.Errorf("ResolvedBy = %q", p.ResolvedBy)
+	}
+	if store.matchGrant("agent-1", "s1", "delete_file", "rule-1") == nil {
+		t.Error("session grant does not cover a call in the same session")
+	}
+	if store.matchGrant("agent-1", "s2", "delete_file", "rule-1") != nil {
+		t.Error("session grant covers a call in another session")
+	}
+	if store.matchGrant("agent-1", "s1", "delete_file", "rule-2") != nil {
+		t.Error("session grant covers a call matched by another rule")
+	}
+
+	add("identity", "s1")
+	if _, err := store.ApproveWith("identity", ApproveOptions{Scope: ApprovalScopeIdentity, Duration: time.Hour}); err != nil {
+		t.Fatalf("ApproveWith(identity): %v", err)
+	}
+	if store.matchGrant("agent-1", "s9", "delete_file", "rule-1") == nil {
+		t.Error("identity grant does not cover a call in another session")
+	}
+	if store.matchGrant("agent-2", "s1", "delete_file", "rule-1") != nil {
+		t.Error("identity grant covers another identity")
+	}
+
+	grants := store.Grants()
+	if len(grants) != 2 || grants[0].Scope != ApprovalScopeIdentity || grants[1].Uses != 1 {
+		t.Fatalf("Grants() = %+v", grants)
+	}
+
+	// Grants end when they expire.
+	store.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	grants = store.Grants()
+	if len(grants) != 1 || grants[0].Scope != ApprovalScopeSession {
+		t.Fatalf("Grants() after the identity grant expired = %+v", grants)
+	}
+
+	// ... or when they are revoked.
+	if err := store.RevokeGrant(grants[0].ID); err != nil {
+		t.Fatalf("RevokeGrant(): %v", err)
+	}
+	if err := store.RevokeGrant(grants[0].ID); !errors.Is(err, ErrApprovalGrantNotFound) {
+		t.Errorf("RevokeGrant() twice error = %v, want ErrApprovalGrantNotFound", err)
+	}
+	if store.matchGrant("agent-1", "s1", "delete_file", "rule-1") != nil {
+		t.Error("revoked grant still covers calls")
+	}
+}
+
+func TestApprovalStore_SetTimeouts(t *testing.T) {
+	store := NewApprovalStore(10)
+	store.SetTimeouts(time.Minute, 2*time.Hour, 4*time.Hour)
+	store.SetTimeouts(0, 0, 0)
+	if got := store.DefaultTimeout(); got != time.Minute {
+		t.Errorf("DefaultTimeout() = %s, want 1m", got)
+	}
+	_ = store.Add(NewTestPendingApproval("a", "t", "agent", "agent-1", "s1", "r", "r", time.Minute))
+	if _, err := store.ApproveWith("a", ApproveOptions{Scope: ApprovalScopeSession, Duration: 5 * time.Hour}); !errors.Is(err, ErrInvalidApprovalScope) {
+		t.Errorf("ApproveWith() over the maximum error = %v, want ErrInvalidApprovalScope", err)
+	}
+	g, err := store.ApproveWith("a", ApproveOptions{Scope: ApprovalScopeSession})
+	if err != nil || g.ExpiresAt.Sub(g.GrantedAt) != 2*time.Hour {
+		t.Errorf("ApproveWith() = %+v, %v; want a 2h grant", g, err)
+	}
+}
+
+func TestApprovalStore_Delegation(t *testing.T) {
+	bus := newTestEventBus()
+	store := NewApprovalStore(10)
+	store.SetEventBus(bus)
+	_ = store.Add(NewTestPendingApproval("a1", "deploy", "agent", "agent-1", "s1", "r", "r", time.Minute))
+	_ = store.Add(NewTestPendingApproval("a2", "deploy", "lead", "lead-1", "s2", "r", "r", time.Minute))
+
+	if err := store.CheckDelegate("a1", "lead-1", "lead", []string{"oncall"}); !errors.Is(err, ErrNotDelegated) {
+		t.Errorf("CheckDelegate() before delegation error = %v, want ErrNotDelegated", err)
+	}
+	d := ApprovalDelegation{Roles: []string{"oncall"}}
+	if err := store.Delegate("a1", d, "admin@127.0.0.1"); err != nil {
+		t.Fatalf("Delegate(): %v", err)
+	}
+	if err := store.Delegate("a2", d, "admin@127.0.0.1"); err != nil {
+		t.Fatalf("Delegate(): %v", err)
+	}
+	if len(bus.EventsByType("approval.delegated")) != 2 {
+		t.Errorf("expected 2 approval.delegated events")
+	}
+
+	if err := store.CheckDelegate("a1", "lead-1", "lead", []string{"oncall"}); err != nil {
+		t.Errorf("CheckDelegate() for a delegated role: %v", err)
+	}
+	if err := store.CheckDelegate("a1", "dev-1", "dev", []string{"dev"}); !errors.Is(err, ErrNotDelegated) {
+		t.Errorf("CheckDelegate() for another role error = %v, want ErrNotDelegated", err)
+	}
+	// An identity never decides its own calls, even with a delegated role.
+	if err := store.CheckDelegate("a2", "lead-1", "lead", []string{"oncall"}); !errors.Is(err, ErrSelfApproval) {
+		t.Errorf("CheckDelegate() for an own call error = %v, want ErrSelfApproval", err)
+	}
+	if list := store.ListDelegated("lead-1", "lead", []string{"oncall"}); len(list) != 1 || list[0].ID != "a1" {
+		t.Errorf("ListDelegated() = %+v, want a1 only", list)
+	}
+
+	// Delegating to a name works too; an empty delegation takes it back.
+	if err := store.Delegate("a1", ApprovalDelegation{Identities: []string{"dev"}}, ""); err != nil {
+		t.Fatalf("Delegate(): %v", err)
+	}
+	if err := store.CheckDelegate("a1", "dev-1", "dev", nil); err != nil {
+		t.Errorf("CheckDelegate() for a delegated name: %v", err)
+	}
+	if err := store.CheckDelegate("a1", "lead-1", "lead", []string{"oncall"}); !errors.Is(err, ErrNotDelegated) {
+		t.Errorf("CheckDelegate() after the delegation changed error = %v, want ErrNotDelegated", err)
+	}
+	_ = store.Delegate("a1", ApprovalDelegation{}, "")
+	if list := store.ListDelegated("dev-1", "dev", nil); len(list) != 0 {
+		t.Errorf("ListDelegated() after the delegation was taken back = %+v", list)
+	}
+
+	_ = store.Approve("a1", "")
+	if err := store.Delegate("a1", d, ""); !errors.Is(err, ErrAlreadyResolved) {
+		t.Errorf("Delegate() of a resolved approval error = %v, want ErrAlreadyResolved", err)
+	}
+	if err := store.CheckDelegate("a1", "lead-1", "lead", []string{"oncall"}); !errors.Is(err, ErrApprovalNotFound) {
+		t.Errorf("CheckDelegate() of a resolved approval error = %v, want ErrApprovalNotFound", err)
+	}
+}
+
+func TestApprovalInterceptor_SessionGrant(t *testing.T) {
+	store := NewApprovalStore(10)
+	calls := 0
+	next := &mockInterceptor{fn: func(ctx context.Context, act *CanonicalAction) (*CanonicalAction, error) {
+		calls++
+		return act, nil
+	}}
+	interceptor := NewApprovalInterceptor(store, next, approvalTestLogger())
+	ctx := policy.WithDecision(context.Background(), &policy.Decision{
+		Allowed:          true,
+		RequiresApproval: true,
+		ApprovalTimeout:  50 * time.Millisecond,
+		RuleID:           "rule-1",
+	})
+	act := &CanonicalAction{
+		Name:     "delete_file",
+		Identity: ActionIdentity{Name: "agent", ID: "agent-1", SessionID: "s1"},
+	}
+
+	go func() {
+		for {
+			if list := store.List(); len(list) > 0 {
+				_, _ = store.ApproveWith(list[0].ID, ApproveOptions{Scope: ApprovalScopeSession})
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}()
+	if _, err := interceptor.Intercept(ctx, act); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+
+	// The next call of the session passes without waiting.
+	if _, err := interceptor.Intercept(ctx, act); err != nil {
+		t.Fatalf("second call: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("next called %d times, want 2", calls)
+	}
+
+	// A call in another session waits again and times out.
+	other := *act
+	other.Identity.SessionID = "s2"
+	if _, err := interceptor.Intercept(ctx, &other); err == nil {
+		t.Error("call in another session: error = nil, want a timeout denial")
+	}
+}
@@ -13,6 +13,8 @@ import (
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/event"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/policy"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/proxy"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/timing"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/watchdog"
 )
 
 var ErrAlreadyResolved = errors.New("approval already resolved")
@@ -42,11 +44,18 @@ type PendingApproval struct {
 	CreatedAt     time.Time              `json:"created_at"`
 	ResolvedAt    *time.Time             `json:"resolved_at,omitempty"`
 	AuditNote     string                 `json:"audit_note,omitempty"`
+	ResolvedBy    string                 `json:"resolved_by,omitempty"` // admin API actor, or "identity:<name>" for a delegate
+	Delegation    ApprovalDelegation     `json:"delegation"`            // who may decide besides the admins
 	Timeout       time.Duration          `json:"-"`
 	TimeoutAction policy.Action          `json:"-"`
 	result        chan ApprovalResult
 }
 
+// ExpiresAt is when the approval times out and its timeout action applies.
+func (p *PendingApproval) ExpiresAt() time.Time {
+	return p.CreatedAt.Add(p.Timeout)
+}
+
 // ApprovalResult carries the outcome of an approval decision.
 type ApprovalResult struct {
 	Approved bool
@@ -61,6 +70,14 @@ type ApprovalStore struct {
 	order    []string
 	maxSize  int
 	eventBus event.Bus
+
+	// Defaults for approvals and standing grants (see SetTimeouts).
+	defaultTimeout   time.Duration
+	grantDuration    time.Duration
+	maxGrantDuration time.Duration
+
+	grants map[string]*ApprovalGrant // by ID
+	now    func() time.Time
 }
 
 // SetEventBus wires the event bus for emitting approval events.
@@ -76,9 +93,31 @@ func NewApprovalStore(maxSize int) *ApprovalStore {
 		maxSize = DefaultMaxPending
 	}
 	return &ApprovalStore{
-		pending: make(map[string]*PendingApproval),
-		order:   make([]string, 0, maxSize),
-		maxSize: maxSize,
+		pending:          make(map[string]*PendingApproval),
+		order:            make([]string, 0, maxSize),
+		maxSize:          maxSize,
+		defaultTimeout:   DefaultApprovalTimeout,
+		grantDuration:    DefaultApprovalGrantDuration,
+		maxGrantDuration: DefaultMaxApprovalGrantDuration,
+		grants:           make(map[string]*ApprovalGrant),
+		now:              time.Now,
+	}
+}
+
+// SetTimeouts sets how long an approval waits when its rule sets no timeout,
+// and the default and longest duration of session and identity approvals.
+// Zero values keep the current setting.
+func (s *ApprovalStore) SetTimeouts(defaultTimeout, grantDuration, maxGrantDuration time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if defaultTimeout > 0 {
+		s.defaultTimeout = defaultTimeout
+	}
+	if grantDuration > 0 {
+		s.grantDuration = grantDuration
+	}
+	if maxGrantDuration > 0 {
+		s.maxGrantDuration = maxGrantDuration
 	}
 }
 
@@ -113,15 +152,7 @@ func (s *ApprovalStore) List() []*PendingApproval {
 	var result []*PendingApproval
 	for _, id := range s.order {
 		if p, ok := s.pending[id]; ok && p.Status == "pending" {
-			cp := *p
-			if p.Arguments != nil {
-				cp.Arguments = make(map[string]interface{}, len(p.Arguments))
-				for k, v := range p.Arguments {
-					cp.Arguments[k] = v
-				}
-			}
-			cp.result = nil // internal channel must not be shared
-			result = append(result, &cp)
+			result = append(result, copyApproval(p))
 		}
 	}
 	return result
@@ -135,6 +166,12 @@ func (s *ApprovalStore) Get(id string) *PendingApproval {
 	if !ok {
 		return nil
 	}
+	return copyApproval(p)
+}
+
+// copyApproval returns a copy of p callers cannot use to mutate the live
+// entry. Caller must hold s.mu.
+func copyApproval(p *PendingApproval) *PendingApproval {
 	cp := *p
 	if p.Arguments != nil {
 		cp.Arguments = make(map[string]interface{}, len(p.Arguments))
@@ -142,28 +179,65 @@ func (s *ApprovalStore) Get(id string) *PendingApproval {
 			cp.Arguments[k] = v
 		}
 	}
-	cp.result = nil
+	cp.Delegation = p.Delegation.clone()
+	cp.result = nil // internal channel must not be shared
 	return &cp
 }
 
 // Approve sends an approval result to the blocked goroutine and removes the entry.
 func (s *ApprovalStore) Approve(id, note string) error {
+	_, err := s.ApproveWith(id, ApproveOptions{Note: note})
+	return err
+}
+
+// ApproveOptions qualifies an approval.
+type ApproveOptions struct {
+	Note string
+	// Scope is ApprovalScopeOnce (default), ApprovalScopeSession or
+	// ApprovalScopeIdentity.
+	Scope ApprovalScope
+	// Duration is how long a session or identity approval lasts; 0 uses the
+	// store default.
+	Duration time.Duration
+	// By is who approves, recorded as ResolvedBy.
+	By string
+}
+
+// ApproveWith approves a pending call. With a session or identity scope it
+// also grants the later calls of the same tool matched by the same rule, in
+// the session or for the identity, until the grant expires; the grant is
+// returned. An invalid scope or duration returns ErrInvalidApprovalScope.
+func (s *ApprovalStore) ApproveWith(id string, opts ApproveOptions) (*ApprovalGrant, error) {
 	s.mu.Lock()
 
 	p, ok := s.pending[id]
 	if !ok {
 		s.mu.Unlock()
-		return fmt.Errorf("%w: %s", ErrApprovalNotFound, id)
+		return nil, fmt.Errorf("%w: %s", ErrApprovalNotFound, id)
 	}
 	if p.Status != "pending" {
 		s.mu.Unlock()
-		return fmt.Errorf("%w: approval %s is already %s", ErrAlreadyResolved, id, p.Status)
+		return nil, fmt.Errorf("%w: approval %s is already %s", ErrAlreadyResolved, id, p.Status)
+	}
+	grant, err := s.newGrantLocked(p, opts)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
 	}
 
 	now := time.Now().UTC()
 	p.Status = "approved"
 	p.ResolvedAt = &now
-	p.AuditNote = note
+	p.AuditNote = opts.Note
+	p.ResolvedBy = opts.By
+	// The grant is copied under the lock: once stored, matchGrant updates
+	// its use count concurrently.
+	var granted *ApprovalGrant
+	if grant != nil {
+		s.grants[grant.ID] = grant
+		cp := *grant
+		granted = &cp
+	}
 	snap := snapshotApproval(p)
 	// M-9: Remove resolved entry from order so it doesn't count against capacity.
 	s.removeFromOrderLocked(id)
@@ -175,12 +249,17 @@ func (s *ApprovalStore) Approve(id, note string) error {
 	default:
 	}
 
-	s.emitEvent("approval.approved", snap, "", note)
-	return nil
+	s.emitEvent("approval.approved", snap, "", opts.Note)
+	return granted, nil
 }
 
 // Deny sends a denial result to the blocked goroutine and removes the entry.
 func (s *ApprovalStore) Deny(id, reason, note string) error {
+	return s.DenyBy(id, "", reason, note)
+}
+
+// DenyBy denies a pending call like Deny, recording by as ResolvedBy.
+func (s *ApprovalStore) DenyBy(id, by, reason, note string) error {
 	s.mu.Lock()
 
 	p, ok := s.pending[id]
@@ -197,6 +276,7 @@ func (s *ApprovalStore) Deny(id, reason, note string) error {
 	p.Status = "denied"
 	p.ResolvedAt = &now
 	p.AuditNote = note
+	p.ResolvedBy = by
 	snap := snapshotApproval(p)
 	// M-9: Remove resolved entry from order.
 	s.removeFromOrderLocked(id)
@@ -233,6 +313,7 @@ type approvalEventPayload struct {
 	RuleID       string
 	RuleName     string
 	TimeoutSecs  int
+	ResolvedBy   string
 }
 
 func snapshotApproval(p *PendingApproval) approvalEventPayload {
@@ -245,6 +326,7 @@ func snapshotApproval(p *PendingApproval) approvalEventPayload {
 		RuleID:       p.RuleID,
 		RuleName:     p.RuleName,
 		TimeoutSecs:  int(p.Timeout.Seconds()),
+		ResolvedBy:   p.ResolvedBy,
 	}
 }
 
@@ -277,6 +359,7 @@ func (s *ApprovalStore) emitEvent(eventType string, snap approvalEventPayload, r
 			"timeout_secs":  snap.TimeoutSecs,
 			"reason":        reason,
 			"audit_note":    note,
+			"resolved_by":   snap.ResolvedBy,
 		},
 	})
 }
@@ -382,10 +465,21 @@ func (a *ApprovalInterceptor) Intercept(ctx context.Context, act *CanonicalActio
 		return a.next.Intercept(ctx, act)
 	}
 
+	// A session or identity approval of an earlier call covers this one.
+	if g := a.store.matchGrant(act.Identity.ID, act.Identity.SessionID, act.Name, decision.RuleID); g != nil {
+		a.logger.Info("tool call approved by standing approval",
+			"grant_id", g.ID,
+			"scope", g.Scope,
+			"tool", act.Name,
+			"identity", act.Identity.Name,
+		)
+		return a.next.Intercept(ctx, act)
+	}
+
 	// Determine timeout
 	timeout := decision.ApprovalTimeout
 	if timeout <= 0 {
-		timeout = DefaultApprovalTimeout
+		timeout = a.store.DefaultTimeout()
 	}
 
 	// Determine timeout action
@@ -429,6 +523,8 @@ func (a *ApprovalInterceptor) Intercept(ctx context.Context, act *CanonicalActio
 	timer := time.NewTimer(timeout)
 	defer timer.Stop()
 
+	exitApproval := watchdog.Enter(ctx, watchdog.StageApproval)
+	stopApproval := timing.Track(ctx, timing.PhaseApproval)
 	var result ApprovalResult
 	select {
 	case result = <-pending.result:
@@ -450,9 +546,13 @@ func (a *ApprovalInterceptor) Intercept(ctx context.Context, act *CanonicalActio
 		a.store.emitEvent("approval.timeout", snapshotApproval(pending), result.Reason, "")
 	case <-ctx.Done():
 		// Context cancelled
+		stopApproval()
+		exitApproval()
 		a.store.remove(pending.ID)
 		return nil, ctx.Err()
 	}
+	stopApproval()
+	exitApproval()
 
 	// Clean up the store entry after resolution
 	defer a.store.remove(pending.ID)
@@ -475,5 +575,28 @@ func (a *ApprovalInterceptor) Intercept(ctx context.Context, act *CanonicalActio
 		"tool", act.Name,
 		"reason", reason,
 	)
-	return nil, fmt.Errorf("%w: %s", proxy.ErrPolicyDenied, reason)
+	helpURL, helpText := policy.RenderDecisionHelp(*decision, helpTextData(act, decision.RuleName))
+	return nil, &proxy.PolicyDenyError{
+		RuleID:   decision.RuleID,
+		RuleName: decision.RuleName,
+		Reason:   reason,
+		HelpURL:  helpURL,
+		HelpText: helpText,
+	}
+}
+
+// helpTextData builds the help template data for a denied action.
+func helpTextData(act *CanonicalAction, ruleName string) policy.HelpTextData {
+	return policy.NewHelpTextData(policy.EvaluationContext{
+		ToolName:     act.Name,
+		IdentityID:   act.Identity.ID,
+		IdentityName: act.Identity.Name,
+		DestURL:      act.Destination.URL,
+		DestDomain:   act.Destination.Domain,
+		DestIP:       act.Destination.IP,
+		DestPort:     act.Destination.Port,
+		DestScheme:   act.Destination.Scheme,
+		DestPath:     act.Destination.Path,
+		DestCommand:  act.Destination.Command,
+	}, ruleName)
 }
@@ -0,0 +1,242 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/event"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/timing"
+	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
+)
+
+// ArgumentScanInterceptor scans tool call arguments for secrets, private
+// keys and internal hostnames being sent out, and for prompt injection
+// payloads, before they reach the upstream. It sits after input content
+// scanning in the chain.
+//
+// In monitor mode, detections are logged and the call passes.
+// In enforce mode, the call is blocked.
+// In redact mode, the matches are replaced with placeholders and the
+// rewritten call is forwarded.
+type ArgumentScanInterceptor struct {
+	scanner  *ArgumentScanner
+	next     ActionInterceptor
+	logger   *slog.Logger
+	mode     *atomic.Value // stores ScanMode
+	enabled  *atomic.Bool
+	mu       sync.RWMutex
+	eventBus event.Bus
+}
+
+// Compile-time check.
+var _ ActionInterceptor = (*ArgumentScanInterceptor)(nil)
+
+// NewArgumentScanInterceptor creates a new ArgumentScanInterceptor.
+func NewArgumentScanInterceptor(
+	scanner *ArgumentScanner,
+	next ActionInterceptor,
+	mode ScanMode,
+	enabled bool,
+	logger *slog.Logger,
+) *ArgumentScanInterceptor {
+	modeVal := &atomic.Value{}
+	modeVal.Store(mode)
+	enabledVal := &atomic.Bool{}
+	enabledVal.Store(enabled)
+	return &ArgumentScanInterceptor{
+		scanner: scanner,
+		next:    next,
+		logger:  logger,
+		mode:    modeVal,
+		enabled: enabledVal,
+	}
+}
+
+// Intercept scans the arguments of tool calls before forwarding them.
+func (c *ArgumentScanInterceptor) Intercept(ctx context.Context, a *CanonicalAction) (*CanonicalAction, error) {
+	if !c.enabled.Load() || a == nil || a.Type != ActionToolCall || len(a.Arguments) == 0 {
+		return c.next.Intercept(ctx, a)
+	}
+
+	mode := c.Mode()
+	stopScan := timing.Track(ctx, timing.PhaseScan)
+	args, findings := c.scanner.ScanArguments(a.Arguments, mode == ScanModeRedact)
+	stopScan()
+	if len(findings) == 0 {
+		return c.next.Intercept(ctx, a)
+	}
+
+	// A redaction that cannot be written into the forwarded message is
+	// blocked rather than forwarded as is.
+	redacted := false
+	if mode == ScanModeRedact {
+		a.Arguments = args
+		redacted = true
+		if mcpMsg, ok := a.OriginalMessage.(*mcp.Message); ok && mcpMsg != nil && mcpMsg.Raw != nil {
+			if rebuilt, err := rebuildRawWithMaskedArgs(mcpMsg.Raw, args); err == nil {
+				mcpMsg.Raw = rebuilt
+			} else {
+				if c.logger != nil {
+					c.logger.Warn("argument scanning: failed to re-serialize redacted arguments", "tool", a.Name, "error", err)
+				}
+				redacted = false
+			}
+		}
+	}
+
+	names, categories := argumentFindingNames(findings)
+	paths := argumentFindingPaths(findings)
+	if c.logger != nil {
+		c.logger.Warn("argument scanning: suspicious content in tool arguments",
+			"mode", string(mode),
+			"tool", a.Name,
+			"identity", a.Identity.ID,
+			"findings_count", len(findings),
+			"patterns", strings.Join(names, ","),
+			"paths", strings.Join(paths, ","),
+		)
+	}
+
+	c.mu.RLock()
+	bus := c.eventBus
+	c.mu.RUnlock()
+	if bus != nil {
+		scanFindings := make([]ScanFinding, len(findings))
+		for i, f := range findings {
+			scanFindings[i] = f.ScanFinding
+		}
+		severity := findingsSeverity(scanFindings)
+		if mode == ScanModeEnforce {
+			severity = event.SeverityCritical
+		}
+		bus.Publish(ctx, event.Event{
+			Type:     "content.argument_flagged",
+			Source:   "argument-scanner",
+			Severity: severity,
+			Payload: map[string]interface{}{
+				"tool":          a.Name,
+				"identity_id":   a.Identity.ID,
+				"identity_name": a.Identity.Name,
+				"findings":      len(findings),
+				"patterns":      strings.Join(names, ", "),
+				"categories":    categories,
+				"paths":         paths,
+				"mode":          string(mode),
+			},
+			RequiresAction: mode == ScanModeEnforce,
+		})
+	}
+
+	action := "blocked"
+	switch {
+	case mode == ScanModeMonitor:
+		action = "monitored"
+	case mode == ScanModeRedact && redacted:
+		action = "redacted"
+	}
+	if holder := audit.ScanResultFromContext(ctx); holder != nil {
+		mergeScanHolder(holder, len(findings), categories, action)
+	}
+
+	if action == "blocked" {
+		return nil, fmt.Errorf("%w: %s", ErrContentBlocked, strings.Join(names, ", "))
+	}
+	return c.next.Intercept(ctx, a)
+}
+
+// argumentFindingNames returns the distinct pattern names and categories of
+// findings, sorted.
+func argumentFindingNames(findings []ArgumentFinding) ([]string, []string) {
+	nameSet := make(map[string]bool)
+	catSet := make(map[string]bool)
+	for _, f := range findings {
+		nameSet[f.PatternName] = true
+		catSet[f.PatternCategory] = true
+	}
+	return sortedKeys(nameSet), sortedKeys(catSet)
+}
+
+// argumentFindingPaths returns the distinct argument paths of findings, in
+// order of appearance.
+func argumentFindingPaths(findings []ArgumentFinding) []string {
+	seen := make(map[string]bool)
+	paths := make([]string, 0, len(findings))
+	for _, f := range findings {
+		if !seen[f.Path] {
+			seen[f.Path] = true
+			paths = append(paths, f.Path)
+		}
+	}
+	return paths
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// mergeScanHolder adds argument findings to the scan result of the call,
+// which input content scanning may already have filled. A block takes
+// precedence over the other actions.
+func mergeScanHolder(holder *audit.ScanResultHolder, detections int, categories []string, action string) {
+	holder.Detections += detections
+	types := make(map[string]bool)
+	if holder.Types != "" {
+		for _, t := range strings.Split(holder.Types, ",") {
+			types[t] = true
+		}
+	}
+	for _, c := range categories {
+		types[c] = true
+	}
+	holder.Types = strings.Join(sortedKeys(types), ",")
+	if holder.Action != "blocked" {
+		holder.Action = action
+	}
+}
+
+// SetMode updates the scan mode thread-safely.
+func (c *ArgumentScanInterceptor) SetMode(mode ScanMode) {
+	c.mode.Store(mode)
+}
+
+// Mode returns the current scan mode.
+func (c *ArgumentScanInterceptor) Mode() ScanMode {
+	m, ok := c.mode.Load().(ScanMode)
+	if !ok {
+		return ScanModeMonitor
+	}
+	return m
+}
+
+// SetEnabled updates the enabled state thread-safely.
+func (c *ArgumentScanInterceptor) SetEnabled(enabled bool) {
+	c.enabled.Store(enabled)
+}
+
+// Enabled returns whether argument scanning is active.
+func (c *ArgumentScanInterceptor) Enabled() bool {
+	return c.enabled.Load()
+}
+
+// Scanner returns the scanner of the interceptor.
+func (c *ArgumentScanInterceptor) Scanner() *ArgumentScanner {
+	return c.scanner
+}
+
+// SetEventBus sets the event bus for emitting detection events.
+func (c *ArgumentScanInterceptor) SetEventBus(bus event.Bus) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.eventBus = bus
+}
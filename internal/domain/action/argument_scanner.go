@@ -0,0 +1,286 @@
+package action
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Argument scanner finding categories, next to the prompt injection
+// categories of the ResponseScanner.
+const (
+	// ArgumentCategorySecret covers API keys, tokens and private keys.
+	ArgumentCategorySecret = "secret"
+	// ArgumentCategoryInternalHost covers hostnames of internal domains.
+	ArgumentCategoryInternalHost = "internal_host"
+)
+
+// DefaultInternalDomains are the domain suffixes of internal hostnames when
+// none are configured.
+var DefaultInternalDomains = []string{"internal", "local", "localdomain", "corp", "lan", "intranet", "home.arpa"}
+
+// maxInternalDomains caps the configured internal domain suffixes.
+const maxInternalDomains = 100
+
+var (
+	// privateKeyRe matches PEM private key blocks, up to the end of the
+	// content when the END line was cut off.
+	privateKeyRe = regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY-----(?s:.*?)(?:-----END (?:[A-Z0-9]+ )*PRIVATE KEY-----|\z)`)
+
+	// internalDomainRe matches a configured internal domain suffix.
+	internalDomainRe = regexp.MustCompile(`^[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?(?:\.[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?)*$`)
+
+	// argumentSecretPatterns are the ContentScanner patterns the argument
+	// scanner reports; the PII patterns are left to input scanning.
+	argumentSecretPatterns = map[ContentPatternType]bool{
+		PatternAWSKey: true, PatternGCPKey: true, PatternAzureKey: true,
+		PatternStripe: true, PatternGitHub: true, PatternGeneric: true,
+	}
+)
+
+// ArgumentFinding is a finding of the ArgumentScanner.
+type ArgumentFinding struct {
+	ScanFinding
+	// Path locates the argument, e.g. "arguments.body" or "arguments.files[0]".
+	Path string
+}
+
+// ArgumentScanner detects data leaving through tool call arguments (API
+// keys, private keys, internal hostnames) and prompt injection payloads
+// passed on to upstream tools. Prompt injection is detected with the
+// patterns and custom detectors of the response scanner.
+type ArgumentScanner struct {
+	injection *ResponseScanner
+	secrets   *ContentScanner
+
+	mu              sync.RWMutex
+	internalDomains []string
+	hostRe          *regexp.Regexp
+}
+
+// NewArgumentScanner creates an ArgumentScanner using the prompt injection
+// patterns of injection and the default internal domains.
+func NewArgumentScanner(injection *ResponseScanner) *ArgumentScanner {
+	secrets := NewContentScanner()
+	for pt := range secrets.GetPatternActions() {
+		if !argumentSecretPatterns[pt] {
+			secrets.SetPatternAction(pt, "off")
+		}
+	}
+	s := &ArgumentScanner{injection: injection, secrets: secrets}
+	_ = s.SetInternalDomains(nil)
+	return s
+}
+
+// SetInternalDomains replaces the domain suffixes of internal hostnames,
+// e.g. "corp.example.com". Nil restores DefaultInternalDomains.
+func (s *ArgumentScanner) SetInternalDomains(domains []string) error {
+	if domains == nil {
+		domains = DefaultInternalDomains
+	}
+	if len(domains) > maxInternalDomains {
+		return fmt.Errorf("more than %d internal domains", maxInternalDomains)
+	}
+	normalized := make([]string, 0, len(domains))
+	quoted := make([]string, 0, len(domains))
+	for _, d := range domains {
+		d = strings.Trim(strings.ToLower(strings.TrimSpace(d)), ".")
+		if !internalDomainRe.MatchString(d) {
+			return fmt.Errorf("invalid internal domain %q", d)
+		}
+		normalized = append(normalized, d)
+		quoted = append(quoted, regexp.QuoteMeta(d))
+	}
+
+	var hostRe *regexp.Regexp
+	if len(quoted) > 0 {
+		hostRe = regexp.MustCompile(`(?i)\b(?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+(?:` + strings.Join(quoted, "|") + `)\b`)
+	}
+	s.mu.Lock()
+	s.internalDomains = normalized
+	s.hostRe = hostRe
+	s.mu.Unlock()
+	return nil
+}
+
+// SetStreamLimits sets the chunking and size cap of secret scans. Prompt
+// injection scans use the limits of the response scanner.
+func (s *ArgumentScanner) SetStreamLimits(limits StreamLimits) {
+	s.secrets.SetStreamLimits(limits)
+}
+
+// InternalDomains returns the domain suffixes of internal hostnames.
+func (s *ArgumentScanner) InternalDomains() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	domains := make([]string, len(s.internalDomains))
+	copy(domains, s.internalDomains)
+	return domains
+}
+
+// ScanArguments scans every string of args. With redact set it returns a
+// copy of args with the findings replaced by placeholders; otherwise args
+// is returned unchanged.
+func (s *ArgumentScanner) ScanArguments(args map[string]interface{}, redact bool) (map[string]interface{}, []ArgumentFinding) {
+	var findings []ArgumentFinding
+	out := s.scanValue(args, "arguments", redact, &findings)
+	if !redact || len(findings) == 0 {
+		return args, findings
+	}
+	return out.(map[string]interface{}), findings
+}
+
+// scanValue scans a decoded JSON value, rebuilding maps and slices when
+// redacting so that the caller's arguments are left untouched.
+func (s *ArgumentScanner) scanValue(v interface{}, path string, redact bool, findings *[]ArgumentFinding) interface{} {
+	switch val := v.(type) {
+	case string:
+		out, found := s.ScanString(val)
+		for _, f := range found {
+			*findings = append(*findings, ArgumentFinding{ScanFinding: f, Path: path})
+		}
+		if redact {
+			return out
+		}
+		return val
+	case map[string]interface{}:
+		var out map[string]interface{}
+		if redact {
+			out = make(map[string]interface{}, len(val))
+		}
+		for k, item := range val {
+			scanned := s.scanValue(item, path+"."+k, redact, findings)
+			if redact {
+				out[k] = scanned
+			}
+		}
+		if redact {
+			return out
+		}
+		return val
+	case []interface{}:
+		var out []interface{}
+		if redact {
+			out = make([]interface{}, len(val))
+		}
+		for i, item := range val {
+			scanned := s.scanValue(item, path+"["+strconv.Itoa(i)+"]", redact, findings)
+			if redact {
+				out[i] = scanned
+			}
+		}
+		if redact {
+			return out
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+// ScanString scans text and returns it with the findings replaced by
+// placeholders. Private keys are matched first so that a key body is
+// redacted as a whole.
+func (s *ArgumentScanner) ScanString(text string) (string, []ScanFinding) {
+	if text == "" {
+		return text, nil
+	}
+	var findings []ScanFinding
+
+	text = replaceMatches(text, privateKeyRe, "[REDACTED-PRIVATE-KEY]", nil, func(match string, pos int) {
+		findings = append(findings, ScanFinding{
+			PatternName:     "private_key",
+			PatternCategory: ArgumentCategorySecret,
+			MatchedText:     privateKeyHeader(match),
+			Position:        pos,
+		})
+	})
+
+	text, secrets := s.secrets.RedactString(text, "")
+	for _, f := range secrets {
+		findings = append(findings, ScanFinding{
+			PatternName:     string(f.PatternType),
+			PatternCategory: ArgumentCategorySecret,
+			MatchedText:     f.MatchedText,
+			Position:        f.Position,
+		})
+	}
+
+	s.mu.RLock()
+	hostRe := s.hostRe
+	s.mu.RUnlock()
+	if hostRe != nil {
+		text = replaceMatches(text, hostRe, "[REDACTED-INTERNAL-HOST]", isHostContinuation, func(match string, pos int) {
+			findings = append(findings, ScanFinding{
+				PatternName:     "internal_hostname",
+				PatternCategory: ArgumentCategoryInternalHost,
+				MatchedText:     truncateMatch(match),
+				Position:        pos,
+			})
+		})
+	}
+
+	text, injections := s.injection.Redact(text)
+	findings = append(findings, injections...)
+	return text, findings
+}
+
+// replaceMatches replaces the matches of re in text with placeholder,
+// calling found for each. Matches for which skip reports true, given the
+// text and the end of the match, are left alone.
+func replaceMatches(text string, re *regexp.Regexp, placeholder string, skip func(string, int) bool, found func(match string, pos int)) string {
+	locs := re.FindAllStringIndex(text, -1)
+	if len(locs) == 0 {
+		return text
+	}
+	var b strings.Builder
+	last := 0
+	for _, loc := range locs {
+		if skip != nil && skip(text, loc[1]) {
+			continue
+		}
+		found(text[loc[0]:loc[1]], loc[0])
+		b.WriteString(text[last:loc[0]])
+		b.WriteString(placeholder)
+		last = loc[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// isHostContinuation reports whether the hostname ending at end goes on,
+// as "db.internal" does in "db.internal.example.com" or "db.internal-2".
+func isHostContinuation(text string, end int) bool {
+	if end >= len(text) {
+		return false
+	}
+	c := text[end]
+	if c == '-' || c == '_' {
+		return true
+	}
+	return c == '.' && end+1 < len(text) && isHostChar(text[end+1])
+}
+
+func isHostChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// privateKeyHeader returns the BEGIN line of a private key block, keeping
+// the key material out of findings.
+func privateKeyHeader(block string) string {
+	const marker = "PRIVATE KEY-----"
+	if i := strings.Index(block, marker); i >= 0 {
+		return block[:i+len(marker)]
+	}
+	return truncateMatch(block)
+}
+
+// truncateMatch shortens matched text to 100 bytes, like the other scanners.
+func truncateMatch(s string) string {
+	if len(s) > 100 {
+		return s[:100]
+	}
+	return s
+}
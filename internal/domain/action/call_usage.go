@@ -0,0 +1,51 @@
+package action
+
+import (
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/toolstats"
+	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
+)
+
+// CallUsage describes the resource use of one completed tool call, for cost
+// accounting.
+type CallUsage struct {
+	// Timestamp is when the tool call was received.
+	Timestamp time.Time
+	// RequestID is the JSON-RPC request ID of the call.
+	RequestID string
+	// SessionID, IdentityID and IdentityName identify the caller.
+	SessionID    string
+	IdentityID   string
+	IdentityName string
+	// Tool is the name of the called tool.
+	Tool string
+	// Upstream is the name of the upstream that served the call.
+	Upstream string
+	// Duration is the time from receiving the call to its result.
+	Duration time.Duration
+	// BytesIn is the size of the request message, BytesOut the size of the
+	// result message, both as raw JSON-RPC.
+	BytesIn  int64
+	BytesOut int64
+	// Outcome is OutcomeOK or OutcomeError (the tool reported a failure).
+	Outcome toolstats.Outcome
+}
+
+// UsageRecorder receives the usage of every tool call that reached an
+// upstream. RecordUsage is called on the request path and must not block.
+type UsageRecorder interface {
+	RecordUsage(u CallUsage)
+}
+
+// messageSize returns the raw size of the MCP message carried by act, or 0.
+func messageSize(act *CanonicalAction) int64 {
+	if act == nil {
+		return 0
+	}
+	msg, ok := act.OriginalMessage.(*mcp.Message)
+	if !ok || msg == nil {
+		return 0
+	}
+	return int64(len(msg.Raw))
+}
@@ -2,10 +2,16 @@ package action
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"sync/atomic"
+	"time"
 
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/proxy"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/timing"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/watchdog"
 	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
 )
 
@@ -19,6 +25,23 @@ type InterceptorChain struct {
 	normalizer Normalizer
 	head       ActionInterceptor // First interceptor in the chain
 	logger     *slog.Logger
+	watchdog   atomic.Pointer[watchdog.Watchdog] // optional, tracks per-stage processing time
+	observer   atomic.Pointer[callObserverRef]   // optional, receives request outcomes
+	timing     atomic.Pointer[TimingReport]      // optional, reports the latency breakdown
+}
+
+// GatewayCallObserver receives the outcome of every client request handled by
+// the chain (e.g., for SLO tracking). failed is true only for internal gateway
+// errors; deliberate rejections (auth, policy, rate limit, ...) and requests
+// cancelled by the client are not failures. Implementations must be safe for
+// concurrent use.
+type GatewayCallObserver interface {
+	ObserveGatewayCall(latency time.Duration, failed bool)
+}
+
+// callObserverRef wraps an observer so it can be stored atomically.
+type callObserverRef struct {
+	obs GatewayCallObserver
 }
 
 // Compile-time check that InterceptorChain implements proxy.MessageInterceptor.
@@ -38,15 +61,53 @@ func NewInterceptorChain(normalizer Normalizer, head ActionInterceptor, logger *
 // Intercept implements proxy.MessageInterceptor. It normalizes the mcp.Message
 // into a CanonicalAction, runs the ActionInterceptor chain, and extracts the
 // resulting mcp.Message from the CanonicalAction.
-func (c *InterceptorChain) Intercept(ctx context.Context, msg *mcp.Message) (*mcp.Message, error) {
+func (c *InterceptorChain) Intercept(ctx context.Context, msg *mcp.Message) (_ *mcp.Message, err error) {
+	// 0. Register the request with the stall watchdog (if enabled)
+	if wd := c.watchdog.Load(); wd != nil {
+		var done func()
+		ctx, done = wd.Begin(ctx, msg.Method())
+		defer done()
+	}
+
+	// Report the outcome of client requests (not notifications) to the observer
+	if ref := c.observer.Load(); ref != nil && msg.IsRequest() {
+		start := time.Now()
+		defer func() {
+			cancelled := errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+			ref.obs.ObserveGatewayCall(time.Since(start), !cancelled && proxy.IsInternalError(err))
+		}()
+	}
+
+	// Time the phases of client requests for the latency breakdown. A
+	// transport that reports it out of band has already placed it in ctx.
+	var breakdown *timing.Breakdown
+	report := c.timing.Load()
+	if report != nil && msg.IsRequest() {
+		if breakdown = timing.FromContext(ctx); breakdown == nil {
+			ctx, breakdown = timing.NewContext(ctx)
+		}
+	}
+
 	// 1. Normalize: mcp.Message -> CanonicalAction
+	exitNormalize := watchdog.Enter(ctx, watchdog.StageNormalize)
 	action, err := c.normalizer.Normalize(ctx, msg)
+	exitNormalize()
 	if err != nil {
 		return nil, fmt.Errorf("normalize failed: %w", err)
 	}
+	if action.Type == ActionToolCall {
+		watchdog.SetTool(ctx, action.Name)
+	}
+	// The upstream router passes the correlation ID on to upstreams.
+	if action.CorrelationID != "" {
+		ctx = audit.WithCorrelationID(ctx, action.CorrelationID)
+	}
 
 	// 2. Run through ActionInterceptor chain
 	result, err := c.head.Intercept(ctx, action)
+	if breakdown != nil {
+		report.reportTiming(breakdown, action, result)
+	}
 	if err != nil {
 		return nil, err // Preserve original error (SafeErrorMessage compatibility)
 	}
@@ -62,3 +123,25 @@ func (c *InterceptorChain) Intercept(ctx context.Context, msg *mcp.Message) (*mc
 	}
 	return mcpMsg, nil
 }
+
+// SetWatchdog enables stall tracking for requests passing through the chain.
+// Pass nil to disable.
+func (c *InterceptorChain) SetWatchdog(wd *watchdog.Watchdog) {
+	c.watchdog.Store(wd)
+}
+
+// SetTimingReport enables the latency breakdown of client requests. Pass
+// nil to disable.
+func (c *InterceptorChain) SetTimingReport(r *TimingReport) {
+	c.timing.Store(r)
+}
+
+// SetCallObserver sets the observer notified after each client request.
+// Pass nil to disable.
+func (c *InterceptorChain) SetCallObserver(obs GatewayCallObserver) {
+	if obs == nil {
+		c.observer.Store(nil)
+		return
+	}
+	c.observer.Store(&callObserverRef{obs: obs})
+}
@@ -2,12 +2,17 @@ package action
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"log/slog"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/proxy"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/timing"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/watchdog"
 	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
 )
 
@@ -184,3 +189,123 @@ func TestInterceptorChain_ImplementsMessageInterceptor(t *testing.T) {
 		t.Fatalf("chain used as MessageInterceptor failed: %v", err)
 	}
 }
+
+func TestInterceptorChain_WatchdogTracksRequest(t *testing.T) {
+	wd := watchdog.New(nil, time.Second, testLogger())
+
+	var inFlight int
+	head := ActionInterceptorFunc(func(ctx context.Context, action *CanonicalAction) (*CanonicalAction, error) {
+		inFlight = wd.InFlight()
+		return action, nil
+	})
+	chain := NewInterceptorChain(NewMCPNormalizer(), head, testLogger())
+	chain.SetWatchdog(wd)
+
+	msg := newToolCallMessage("read_file", map[string]interface{}{"path": "/tmp"}, testSession())
+	if _, err := chain.Intercept(context.Background(), msg); err != nil {
+		t.Fatalf("Intercept() error = %v", err)
+	}
+	if inFlight != 1 {
+		t.Errorf("InFlight() during chain = %d, want 1", inFlight)
+	}
+	if n := wd.InFlight(); n != 0 {
+		t.Errorf("InFlight() after chain = %d, want 0", n)
+	}
+}
+
+// recordingGatewayObserver records gateway call outcomes.
+type recordingGatewayObserver struct {
+	failed []bool
+}
+
+func (o *recordingGatewayObserver) ObserveGatewayCall(_ time.Duration, failed bool) {
+	o.failed = append(o.failed, failed)
+}
+
+func TestInterceptorChain_CallObserver(t *testing.T) {
+	var next error
+	head := ActionInterceptorFunc(func(ctx context.Context, action *CanonicalAction) (*CanonicalAction, error) {
+		return action, next
+	})
+	chain := NewInterceptorChain(NewMCPNormalizer(), head, testLogger())
+	obs := &recordingGatewayObserver{}
+	chain.SetCallObserver(obs)
+
+	for _, err := range []error{
+		nil,
+		proxy.ErrPolicyDenied,
+		errors.New("unexpected failure"),
+		context.Canceled,
+	} {
+		next = err
+		_, _ = chain.Intercept(context.Background(), newToolCallMessage("read_file", nil, testSession()))
+	}
+
+	want := []bool{false, false, true, false}
+	if len(obs.failed) != len(want) {
+		t.Fatalf("observed %d calls, want %d", len(obs.failed), len(want))
+	}
+	for i := range want {
+		if obs.failed[i] != want[i] {
+			t.Errorf("call %d failed = %v, want %v", i, obs.failed[i], want[i])
+		}
+	}
+}
+
+func TestInterceptorChain_TimingReport(t *testing.T) {
+	identityID := "id-dev"
+	head := ActionInterceptorFunc(func(ctx context.Context, action *CanonicalAction) (*CanonicalAction, error) {
+		action.Identity = ActionIdentity{ID: identityID, Name: "dev"}
+		stop := timing.Track(ctx, timing.PhaseUpstream)
+		time.Sleep(2 * time.Millisecond)
+		stop()
+		return &CanonicalAction{
+			Identity: action.Identity,
+			OriginalMessage: &mcp.Message{
+				Direction: mcp.ServerToClient,
+				Raw:       []byte(`{"jsonrpc":"2.0","id":1,"result":{"content":[],"_meta":{"other":true}}}`),
+			},
+		}, nil
+	})
+	chain := NewInterceptorChain(NewMCPNormalizer(), head, testLogger())
+	chain.SetTimingReport(&TimingReport{Meta: true, Identities: []string{"dev"}})
+
+	ctx, breakdown := timing.NewContext(context.Background())
+	out, err := chain.Intercept(ctx, newToolCallMessage("read_file", nil, testSession()))
+	if err != nil {
+		t.Fatalf("Intercept() error = %v", err)
+	}
+	if !breakdown.Reported() {
+		t.Error("breakdown not reported for a listed identity")
+	}
+	var resp struct {
+		Result struct {
+			Meta map[string]json.RawMessage `json:"_meta"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(out.Raw, &resp); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+	if _, ok := resp.Result.Meta["other"]; !ok {
+		t.Error("existing _meta entry dropped")
+	}
+	var summary timing.Summary
+	if err := json.Unmarshal(resp.Result.Meta[TimingMetaKey], &summary); err != nil {
+		t.Fatalf("unmarshal timing: %v", err)
+	}
+	if summary.UpstreamMs < 2 || summary.TotalMs < summary.UpstreamMs {
+		t.Errorf("summary = %+v", summary)
+	}
+
+	// Identities not listed get nothing.
+	identityID = "id-other"
+	chain.SetTimingReport(&TimingReport{Meta: true, Identities: []string{"id-dev"}})
+	ctx, breakdown = timing.NewContext(context.Background())
+	out, err = chain.Intercept(ctx, newToolCallMessage("read_file", nil, testSession()))
+	if err != nil {
+		t.Fatalf("Intercept() error = %v", err)
+	}
+	if breakdown.Reported() || strings.Contains(string(out.Raw), TimingMetaKey) {
+		t.Error("breakdown reported for an identity that is not listed")
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/event"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/proxy"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/timing"
 	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
 )
 
@@ -148,7 +149,12 @@ func (c *ContentScanInterceptor) Intercept(ctx context.Context, a *CanonicalActi
 		return c.next.Intercept(ctx, a)
 	}
 
+	stopScan := timing.Track(ctx, timing.PhaseScan)
 	result := c.scanner.ScanArguments(a.Arguments)
+	stopScan()
+	if result.Truncated && c.logger != nil {
+		c.logger.Warn("input content scanning: argument exceeds the scan size cap, remainder not scanned", "tool", a.Name)
+	}
 	if !result.Detected {
 		return c.next.Intercept(ctx, a)
 	}
@@ -50,7 +50,8 @@ type ContentScanResult struct {
 	Detected       bool             `json:"detected"`
 	Findings       []ContentFinding `json:"findings"`
 	ScanDurationNs int64            `json:"scan_duration_ns"`
-	HasBlock       bool             `json:"has_block"` // at least one finding requires blocking
+	HasBlock       bool             `json:"has_block"`           // at least one finding requires blocking
+	Truncated      bool             `json:"truncated,omitempty"` // an argument exceeded the scan size cap
 }
 
 // contentPattern is a compiled pattern for sensitive content detection.
@@ -67,6 +68,7 @@ type contentPattern struct {
 type ContentScanner struct {
 	mu       sync.RWMutex
 	patterns []contentPattern
+	limits   StreamLimits
 }
 
 // NewContentScanner creates a ContentScanner with the default pattern set.
@@ -145,7 +147,15 @@ func NewContentScanner() *ContentScanner {
 		},
 	}
 
-	return &ContentScanner{patterns: patterns}
+	return &ContentScanner{patterns: patterns, limits: DefaultStreamLimits}
+}
+
+// SetStreamLimits sets the chunking and size cap of scans. Invalid limits
+// restore DefaultStreamLimits.
+func (s *ContentScanner) SetStreamLimits(limits StreamLimits) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limits = limits.orDefault()
 }
 
 // SetPatternAction changes the action for a specific pattern type.
@@ -185,7 +195,7 @@ func (s *ContentScanner) ScanArguments(args map[string]interface{}) ContentScanR
 	defer s.mu.RUnlock()
 
 	var findings []ContentFinding
-	s.scanMap(args, "arguments", &findings)
+	truncated := s.scanMap(args, "arguments", &findings)
 
 	hasBlock := false
 	for _, f := range findings {
@@ -200,6 +210,7 @@ func (s *ContentScanner) ScanArguments(args map[string]interface{}) ContentScanR
 		Findings:       findings,
 		ScanDurationNs: time.Since(start).Nanoseconds(),
 		HasBlock:       hasBlock,
+		Truncated:      truncated,
 	}
 }
 
@@ -226,57 +237,98 @@ func (s *ContentScanner) MaskArguments(args map[string]interface{}) map[string]i
 	return copy
 }
 
-// scanMap recursively scans a map for sensitive content.
-func (s *ContentScanner) scanMap(m map[string]interface{}, prefix string, findings *[]ContentFinding) {
+// RedactString replaces every match of an enabled pattern in content with
+// the pattern's placeholder, whatever its action, and returns the findings.
+// Used on tool results, where there is no call left to block.
+func (s *ContentScanner) RedactString(content, path string) (string, []ContentFinding) {
+	if content == "" {
+		return content, nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var findings []ContentFinding
+	s.scanString(content, path, &findings)
+	if len(findings) == 0 {
+		return content, nil
+	}
+	for _, p := range s.patterns {
+		if p.action == "off" {
+			continue
+		}
+		content = p.re.ReplaceAllStringFunc(content, func(match string) string {
+			if p.validate != nil && !p.validate(match) {
+				return match
+			}
+			return p.redactLabel
+		})
+	}
+	return content, findings
+}
+
+// scanMap recursively scans a map for sensitive content. It reports
+// whether a string was cut at the scan size cap.
+func (s *ContentScanner) scanMap(m map[string]interface{}, prefix string, findings *[]ContentFinding) bool {
+	truncated := false
 	for key, val := range m {
 		path := prefix + "." + key
-		s.scanValue(val, path, findings)
+		truncated = s.scanValue(val, path, findings) || truncated
 	}
+	return truncated
 }
 
 // scanValue recursively scans a value.
-func (s *ContentScanner) scanValue(v interface{}, path string, findings *[]ContentFinding) {
+func (s *ContentScanner) scanValue(v interface{}, path string, findings *[]ContentFinding) bool {
 	switch val := v.(type) {
 	case string:
-		s.scanString(val, path, findings)
+		return s.scanString(val, path, findings)
 	case map[string]interface{}:
-		s.scanMap(val, path, findings)
+		return s.scanMap(val, path, findings)
 	case []interface{}:
+		truncated := false
 		for i, item := range val {
-			s.scanValue(item, path+"["+strconv.Itoa(i)+"]", findings)
+			truncated = s.scanValue(item, path+"["+strconv.Itoa(i)+"]", findings) || truncated
 		}
+		return truncated
 	}
+	return false
 }
 
-// scanString runs all patterns against a single string.
-func (s *ContentScanner) scanString(content, path string, findings *[]ContentFinding) {
+// scanString runs all patterns against a single string, chunk by chunk
+// when it is larger than a chunk. It reports whether the string was cut at
+// the scan size cap.
+func (s *ContentScanner) scanString(content, path string, findings *[]ContentFinding) bool {
 	if content == "" {
-		return
+		return false
 	}
-	for _, p := range s.patterns {
-		if p.action == "off" {
-			continue
-		}
-		matches := p.re.FindAllStringIndex(content, -1)
-		for _, loc := range matches {
-			matched := content[loc[0]:loc[1]]
-			if p.validate != nil && !p.validate(matched) {
+	return scanLarge(content, s.limits, func(window string, offset int64, limit int) {
+		for _, p := range s.patterns {
+			if p.action == "off" {
 				continue
 			}
-			displayText := matched
-			if len(displayText) > 20 {
-				displayText = displayText[:8] + "..." + displayText[len(displayText)-4:]
+			for _, loc := range p.re.FindAllStringIndex(window, -1) {
+				if loc[0] >= limit {
+					break
+				}
+				matched := window[loc[0]:loc[1]]
+				if p.validate != nil && !p.validate(matched) {
+					continue
+				}
+				displayText := matched
+				if len(displayText) > 20 {
+					displayText = displayText[:8] + "..." + displayText[len(displayText)-4:]
+				}
+				*findings = append(*findings, ContentFinding{
+					PatternType: p.patternType,
+					Action:      p.action,
+					MatchedText: displayText,
+					Redacted:    p.redactLabel,
+					Position:    int(offset) + loc[0],
+					FieldPath:   path,
+				})
 			}
-			*findings = append(*findings, ContentFinding{
-				PatternType: p.patternType,
-				Action:      p.action,
-				MatchedText: displayText,
-				Redacted:    p.redactLabel,
-				Position:    loc[0],
-				FieldPath:   path,
-			})
 		}
-	}
+	})
 }
 
 // maskMap recursively applies masking to all string values in a map.
@@ -0,0 +1,115 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/denyloop"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/event"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/proxy"
+)
+
+// EventDenyLoop is published when an identity starts a deny loop.
+const EventDenyLoop = "policy.deny_loop"
+
+// DenyLoopInterceptor detects deny loops: the same identity retrying the
+// same denied tool call over and over. Once a call has been denied
+// Threshold times within the window, it is rejected with a DenyLoopError
+// for an escalating backoff instead of being evaluated again.
+//
+// It sits before the rate limiters, so calls rejected by the backoff don't
+// use up the identity's rate limit.
+type DenyLoopInterceptor struct {
+	detector *denyloop.Detector
+	next     ActionInterceptor
+	logger   *slog.Logger
+	eventBus event.Bus
+}
+
+// Compile-time check.
+var _ ActionInterceptor = (*DenyLoopInterceptor)(nil)
+
+// NewDenyLoopInterceptor creates a DenyLoopInterceptor.
+func NewDenyLoopInterceptor(detector *denyloop.Detector, next ActionInterceptor, logger *slog.Logger) *DenyLoopInterceptor {
+	return &DenyLoopInterceptor{detector: detector, next: next, logger: logger}
+}
+
+// SetEventBus sets the event bus for deny loop events.
+func (d *DenyLoopInterceptor) SetEventBus(bus event.Bus) {
+	d.eventBus = bus
+}
+
+// Intercept rejects calls in their backoff and counts policy denials of
+// the others. A call that goes through ends its loop.
+func (d *DenyLoopInterceptor) Intercept(ctx context.Context, act *CanonicalAction) (*CanonicalAction, error) {
+	if act.Type != ActionToolCall || act.Identity.ID == "" {
+		return d.next.Intercept(ctx, act)
+	}
+	key := denyloop.Key(act.Identity.ID, act.Name, act.Arguments)
+	denial := denyloop.Denial{
+		IdentityID:   act.Identity.ID,
+		IdentityName: act.Identity.Name,
+		Tool:         act.Name,
+	}
+
+	if _, blocked := d.detector.Blocked(key); blocked {
+		loop, _ := d.detector.RecordDenial(key, denial)
+		retryAfter := loop.BlockedUntil.Sub(loop.LastDeniedAt)
+		d.logger.Debug("tool call rejected: deny loop backoff",
+			"tool", act.Name,
+			"identity", act.Identity.Name,
+			"denials", loop.Denials,
+			"retry_after", retryAfter,
+		)
+		return nil, &proxy.DenyLoopError{Denials: loop.Denials, RetryAfter: retryAfter}
+	}
+
+	result, err := d.next.Intercept(ctx, act)
+	switch {
+	case err == nil:
+		d.detector.Reset(key)
+	case errors.Is(err, proxy.ErrPolicyDenied):
+		var denyErr *proxy.PolicyDenyError
+		if errors.As(err, &denyErr) {
+			denial.Rule = denyErr.RuleName
+			if denial.Rule == "" {
+				denial.Rule = denyErr.RuleID
+			}
+			denial.Reason = denyErr.Reason
+		}
+		if loop, started := d.detector.RecordDenial(key, denial); started {
+			d.logger.Warn("deny loop detected",
+				"tool", act.Name,
+				"identity", act.Identity.Name,
+				"denials", loop.Denials,
+				"rule", loop.Rule,
+			)
+			d.emit(ctx, loop)
+		}
+	}
+	return result, err
+}
+
+func (d *DenyLoopInterceptor) emit(ctx context.Context, loop denyloop.Loop) {
+	if d.eventBus == nil {
+		return
+	}
+	d.eventBus.Publish(ctx, event.Event{
+		Type:     EventDenyLoop,
+		Source:   "deny-loop",
+		Severity: event.SeverityWarning,
+		Payload: map[string]interface{}{
+			"loop_id":       loop.ID,
+			"tool":          loop.Tool,
+			"identity_id":   loop.IdentityID,
+			"identity_name": loop.IdentityName,
+			"denials":       loop.Denials,
+			"rule":          loop.Rule,
+			"reason":        loop.Reason,
+			"blocked_until": loop.BlockedUntil.UTC().Format(time.RFC3339),
+		},
+		Timestamp: time.Now().UTC(),
+	})
+}
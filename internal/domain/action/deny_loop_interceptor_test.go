@@ -0,0 +1,127 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/denyloop"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/event"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/proxy"
+)
+
+// denyingInterceptor denies calls of the listed tools and counts the calls
+// that reach it.
+type denyingInterceptor struct {
+	denied map[string]bool
+	calls  int
+}
+
+func (d *denyingInterceptor) Intercept(_ context.Context, a *CanonicalAction) (*CanonicalAction, error) {
+	d.calls++
+	if d.denied[a.Name] {
+		return nil, &proxy.PolicyDenyError{RuleID: "r1", RuleName: "no-delete", Reason: "deletes are not allowed"}
+	}
+	return a, nil
+}
+
+func TestDenyLoopInterceptor(t *testing.T) {
+	next := &denyingInterceptor{denied: map[string]bool{"delete_file": true}}
+	detector := denyloop.NewDetector(denyloop.Config{Threshold: 3, Window: time.Minute, BaseBackoff: time.Minute, MaxBackoff: time.Hour})
+	interceptor := NewDenyLoopInterceptor(detector, next, newTestLogger())
+
+	bus := event.NewBus(100)
+	bus.Start()
+	defer bus.Stop()
+	interceptor.SetEventBus(bus)
+	received := make(chan event.Event, 10)
+	bus.SubscribeAll(func(_ context.Context, evt event.Event) {
+		received <- evt
+	})
+
+	call := func(tool, path string) error {
+		_, err := interceptor.Intercept(context.Background(), &CanonicalAction{
+			Type:      ActionToolCall,
+			Name:      tool,
+			Arguments: map[string]interface{}{"path": path},
+			Identity:  ActionIdentity{ID: "id-1", Name: "alice"},
+		})
+		return err
+	}
+
+	for i := 0; i < 3; i++ {
+		err := call("delete_file", "/etc/hosts")
+		var denyErr *proxy.PolicyDenyError
+		if !errors.As(err, &denyErr) {
+			t.Fatalf("call %d: expected a policy denial, got %v", i+1, err)
+		}
+	}
+
+	// The loop is reported once.
+	select {
+	case evt := <-received:
+		payload, _ := evt.Payload.(map[string]interface{})
+		if evt.Type != EventDenyLoop || payload["identity_id"] != "id-1" || payload["rule"] != "no-delete" {
+			t.Errorf("unexpected event %+v", evt)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for the deny loop event")
+	}
+
+	// The next attempts are rejected without reaching the policy, with a
+	// growing backoff.
+	var last time.Duration
+	for i := 0; i < 2; i++ {
+		err := call("delete_file", "/etc/hosts")
+		var loopErr *proxy.DenyLoopError
+		if !errors.As(err, &loopErr) {
+			t.Fatalf("expected a DenyLoopError, got %v", err)
+		}
+		if !errors.Is(err, proxy.ErrPolicyDenied) {
+			t.Error("DenyLoopError should wrap ErrPolicyDenied")
+		}
+		if loopErr.RetryAfter <= last {
+			t.Errorf("backoff %v did not grow from %v", loopErr.RetryAfter, last)
+		}
+		last = loopErr.RetryAfter
+	}
+	if next.calls != 3 {
+		t.Errorf("calls reaching the policy = %d, want 3", next.calls)
+	}
+	if loops := detector.Loops(); len(loops) != 1 || loops[0].Denials != 5 {
+		t.Errorf("Loops() = %+v", loops)
+	}
+
+	// Other arguments and allowed calls are not affected.
+	if err := call("delete_file", "/tmp/x"); errors.As(err, new(*proxy.DenyLoopError)) {
+		t.Error("call with other arguments rejected by the backoff")
+	}
+	if err := call("read_file", "/etc/hosts"); err != nil {
+		t.Errorf("allowed call failed: %v", err)
+	}
+
+	select {
+	case evt := <-received:
+		t.Errorf("unexpected second event %+v", evt)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestDenyLoopInterceptor_SuccessResets(t *testing.T) {
+	next := &denyingInterceptor{denied: map[string]bool{"tool": true}}
+	detector := denyloop.NewDetector(denyloop.Config{Threshold: 2})
+	interceptor := NewDenyLoopInterceptor(detector, next, newTestLogger())
+	act := &CanonicalAction{Type: ActionToolCall, Name: "tool", Identity: ActionIdentity{ID: "id-1"}}
+
+	_, _ = interceptor.Intercept(context.Background(), act)
+	next.denied["tool"] = false
+	if _, err := interceptor.Intercept(context.Background(), act); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	next.denied["tool"] = true
+	_, _ = interceptor.Intercept(context.Background(), act)
+	if len(detector.Loops()) != 0 {
+		t.Error("denials before a success should not count")
+	}
+}
@@ -0,0 +1,95 @@
+package action
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/watchdog"
+)
+
+// destinationLookupTimeout bounds the DNS lookup of a destination host, so
+// a slow resolver delays the policy decision by at most this much.
+const destinationLookupTimeout = 2 * time.Second
+
+// IPResolver looks up the addresses of a host name. *net.Resolver
+// implements it.
+type IPResolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// DestinationGeo is where a destination address is.
+type DestinationGeo struct {
+	// Country is the ISO 3166-1 alpha-2 country code.
+	Country string
+	// ASN is the autonomous system number, 0 when unknown.
+	ASN uint
+	// ASNOrg is the organization of the autonomous system.
+	ASNOrg string
+}
+
+// GeoLocator locates destination addresses. Implemented by geoip.Locator;
+// it reads local databases and must return quickly.
+type GeoLocator interface {
+	Locate(ip net.IP) DestinationGeo
+}
+
+// defaultPorts are the ports of the URL schemes destinations commonly use,
+// for URLs that do not name one.
+var defaultPorts = map[string]int{
+	"http":  80,
+	"https": 443,
+	"ws":    80,
+	"wss":   443,
+	"ftp":   21,
+	"ssh":   22,
+}
+
+// resolveDestination fills in the IP and port of dest so that rules can
+// match dest_ip against CIDR ranges and dest_port against port ranges:
+//   - IP is the domain itself when it is an IP address, or else its first
+//     address from resolver; it stays empty without a resolver or when the
+//     lookup fails.
+//   - Port is the default port of the scheme when the URL names none.
+func resolveDestination(ctx context.Context, resolver IPResolver, dest *Destination) error {
+	if dest.Port == 0 {
+		dest.Port = defaultPorts[dest.Scheme]
+	}
+	if dest.IP != "" || dest.Domain == "" {
+		return nil
+	}
+	if ip := net.ParseIP(dest.Domain); ip != nil {
+		dest.IP = ip.String()
+		return nil
+	}
+	if resolver == nil {
+		return nil
+	}
+	defer watchdog.Enter(ctx, watchdog.StageOutboundDNS)()
+	ctx, cancel := context.WithTimeout(ctx, destinationLookupTimeout)
+	defer cancel()
+	addrs, err := resolver.LookupIPAddr(ctx, dest.Domain)
+	if err != nil {
+		return err
+	}
+	if len(addrs) > 0 {
+		dest.IP = addrs[0].IP.String()
+	}
+	return nil
+}
+
+// locateDestination sets the country and autonomous system of dest from
+// its IP, when it has one.
+func locateDestination(locator GeoLocator, dest *Destination) {
+	if locator == nil || dest.IP == "" || dest.Country != "" || dest.ASN != 0 {
+		return
+	}
+	ip := net.ParseIP(dest.IP)
+	if ip == nil {
+		return
+	}
+	geo := locator.Locate(ip)
+	dest.Country = geo.Country
+	dest.ASN = geo.ASN
+	dest.ASNOrg = geo.ASNOrg
+}
@@ -0,0 +1,135 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/policy"
+)
+
+// fakeIPResolver answers lookups from a fixed table.
+type fakeIPResolver struct {
+	hosts   map[string]string
+	lookups int
+}
+
+func (r *fakeIPResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	r.lookups++
+	ip, ok := r.hosts[host]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	return []net.IPAddr{{IP: net.ParseIP(ip)}}, nil
+}
+
+func TestResolveDestination(t *testing.T) {
+	resolver := &fakeIPResolver{hosts: map[string]string{"db.corp.example": "10.1.2.3"}}
+
+	tests := []struct {
+		name     string
+		dest     Destination
+		resolver IPResolver
+		wantIP   string
+		wantPort int
+		wantErr  bool
+	}{
+		{"ip literal", Destination{Domain: "192.168.1.10", Scheme: "http"}, nil, "192.168.1.10", 80, false},
+		{"ipv6 literal", Destination{Domain: "::1", Port: 8080}, nil, "::1", 8080, false},
+		{"resolved", Destination{Domain: "db.corp.example", Scheme: "https"}, resolver, "10.1.2.3", 443, false},
+		{"without resolver", Destination{Domain: "db.corp.example", Scheme: "wss"}, nil, "", 443, false},
+		{"lookup failure", Destination{Domain: "unknown.example", Scheme: "ssh"}, resolver, "", 22, true},
+		{"ip already set", Destination{Domain: "db.corp.example", IP: "172.16.0.1"}, resolver, "172.16.0.1", 0, false},
+		{"no domain", Destination{Command: "ls"}, resolver, "", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := tt.dest
+			err := resolveDestination(context.Background(), tt.resolver, &dest)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveDestination() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if dest.IP != tt.wantIP || dest.Port != tt.wantPort {
+				t.Errorf("IP, Port = %q, %d; want %q, %d", dest.IP, dest.Port, tt.wantIP, tt.wantPort)
+			}
+		})
+	}
+}
+
+// fakeGeoLocator locates addresses from a fixed table.
+type fakeGeoLocator map[string]DestinationGeo
+
+func (l fakeGeoLocator) Locate(ip net.IP) DestinationGeo {
+	return l[ip.String()]
+}
+
+func TestLocateDestination(t *testing.T) {
+	locator := fakeGeoLocator{"203.0.113.7": {Country: "KP", ASN: 64500, ASNOrg: "Example Net"}}
+
+	dest := Destination{IP: "203.0.113.7"}
+	locateDestination(locator, &dest)
+	if dest.Country != "KP" || dest.ASN != 64500 || dest.ASNOrg != "Example Net" {
+		t.Errorf("located destination = %+v", dest)
+	}
+
+	for _, dest := range []Destination{{}, {IP: "not-an-ip"}, {IP: "198.51.100.1"}} {
+		locateDestination(locator, &dest)
+		if dest.Country != "" || dest.ASN != 0 {
+			t.Errorf("destination %q located as %+v", dest.IP, dest)
+		}
+	}
+
+	dest = Destination{IP: "203.0.113.7"}
+	locateDestination(nil, &dest)
+	if dest.Country != "" {
+		t.Errorf("destination located without a locator: %+v", dest)
+	}
+}
+
+func TestPolicyActionInterceptor_DestinationResolver(t *testing.T) {
+	resolver := &fakeIPResolver{hosts: map[string]string{"db.corp.example": "10.1.2.3"}}
+	var got policy.EvaluationContext
+	engine := &mockPolicyEngine{evaluateFn: func(_ context.Context, evalCtx policy.EvaluationContext) (policy.Decision, error) {
+		got = evalCtx
+		return policy.Decision{Allowed: true}, nil
+	}}
+	interceptor := NewPolicyActionInterceptor(engine, &mockNextInterceptor{}, testLogger(), WithDestinationResolver(resolver))
+
+	a := newTestToolCallAction()
+	a.Destination = Destination{URL: "https://db.corp.example/query", Domain: "db.corp.example", Scheme: "https"}
+	if _, err := interceptor.Intercept(context.Background(), a); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.DestIP != "10.1.2.3" || got.DestPort != 443 {
+		t.Errorf("DestIP, DestPort = %q, %d; want 10.1.2.3, 443", got.DestIP, got.DestPort)
+	}
+	if resolver.lookups != 1 {
+		t.Errorf("lookups = %d, want 1", resolver.lookups)
+	}
+}
+
+func TestPolicyActionInterceptor_GeoLocator(t *testing.T) {
+	resolver := &fakeIPResolver{hosts: map[string]string{"files.example": "203.0.113.7"}}
+	locator := fakeGeoLocator{"203.0.113.7": {Country: "KP", ASN: 64500, ASNOrg: "Example Net"}}
+	var got policy.EvaluationContext
+	engine := &mockPolicyEngine{evaluateFn: func(_ context.Context, evalCtx policy.EvaluationContext) (policy.Decision, error) {
+		got = evalCtx
+		return policy.Decision{Allowed: true}, nil
+	}}
+	interceptor := NewPolicyActionInterceptor(engine, &mockNextInterceptor{}, testLogger(),
+		WithDestinationResolver(resolver), WithGeoLocator(locator))
+
+	a := newTestToolCallAction()
+	a.Destination = Destination{URL: "https://files.example/x", Domain: "files.example", Scheme: "https"}
+	if _, err := interceptor.Intercept(context.Background(), a); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.DestCountry != "KP" || got.DestASN != 64500 || got.DestASNOrg != "Example Net" {
+		t.Errorf("DestCountry, DestASN, DestASNOrg = %q, %d, %q", got.DestCountry, got.DestASN, got.DestASNOrg)
+	}
+	// The action carries the location on to the audit record.
+	if a.Destination.Country != "KP" {
+		t.Errorf("action destination = %+v", a.Destination)
+	}
+}
@@ -72,6 +72,7 @@ func (a *LegacyAdapter) Intercept(ctx context.Context, action *CanonicalAction)
 			Name:      resultMsg.Session.IdentityName,
 			SessionID: resultMsg.Session.ID,
 			Roles:     roles,
+			Labels:    resultMsg.Session.Labels,
 		}
 	}
 
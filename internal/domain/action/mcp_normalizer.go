@@ -4,14 +4,22 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
 
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
 	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
 )
 
 // MCPNormalizer converts mcp.Message to/from CanonicalAction.
-// It handles tools/call, sampling/createMessage, and elicitation/create methods,
-// mapping each to the appropriate ActionType.
-type MCPNormalizer struct{}
+// It handles tools/call, sampling/createMessage, elicitation/create and
+// resources/subscribe methods, mapping each to the appropriate ActionType.
+type MCPNormalizer struct {
+	// urlExtractor finds destinations in tool call arguments (nil = none).
+	urlExtractor *URLExtractor
+	// correlationMetaKey is the params._meta key clients may send a
+	// correlation ID in ("" = not read).
+	correlationMetaKey string
+}
 
 // Compile-time check that MCPNormalizer implements Normalizer.
 var _ Normalizer = (*MCPNormalizer)(nil)
@@ -21,6 +29,20 @@ func NewMCPNormalizer() *MCPNormalizer {
 	return &MCPNormalizer{}
 }
 
+// SetURLExtractor enables destination extraction from tool call arguments,
+// populating Destination so policies can match dest_url, dest_domain,
+// dest_urls and dest_domains. Must be called before the normalizer is used.
+func (n *MCPNormalizer) SetURLExtractor(e *URLExtractor) {
+	n.urlExtractor = e
+}
+
+// SetCorrelationMetaKey makes requests take their correlation ID from
+// params._meta[key] when the transport did not supply one. Must be called
+// before the normalizer is used.
+func (n *MCPNormalizer) SetCorrelationMetaKey(key string) {
+	n.correlationMetaKey = key
+}
+
 // Normalize converts an mcp.Message to a CanonicalAction.
 // The msg parameter must be a *mcp.Message; other types return an error.
 // Non-request messages (responses) are passed through with minimal fields.
@@ -47,6 +69,7 @@ func (n *MCPNormalizer) Normalize(ctx context.Context, msg interface{}) (*Canoni
 	// Set timing and request ID
 	action.RequestTime = mcpMsg.Timestamp
 	action.RequestID = formatRawID(mcpMsg.RawID())
+	action.CorrelationID = n.correlationID(ctx, mcpMsg)
 
 	// Populate identity from session (nil-safe)
 	if mcpMsg.Session != nil {
@@ -59,6 +82,7 @@ func (n *MCPNormalizer) Normalize(ctx context.Context, msg interface{}) (*Canoni
 			Name:      mcpMsg.Session.IdentityName,
 			SessionID: mcpMsg.Session.ID,
 			Roles:     roles,
+			Labels:    mcpMsg.Session.Labels,
 		}
 	}
 
@@ -71,9 +95,15 @@ func (n *MCPNormalizer) Normalize(ctx context.Context, msg interface{}) (*Canoni
 	case "sampling/createMessage":
 		action.Type = ActionSampling
 		action.Name = method
+		action.Arguments = mcpMsg.ParseParams()
 	case "elicitation/create":
 		action.Type = ActionElicitation
 		action.Name = method
+		action.Arguments = mcpMsg.ParseParams()
+	case "resources/subscribe":
+		action.Type = ActionResourceSubscribe
+		action.Name = method
+		n.extractSubscribeParams(mcpMsg, action)
 	default:
 		// Protocol methods (initialize, ping, tools/list, notifications/*, etc.)
 		// are not subject to policy evaluation — only tools/call is.
@@ -84,6 +114,23 @@ func (n *MCPNormalizer) Normalize(ctx context.Context, msg interface{}) (*Canoni
 	return action, nil
 }
 
+// correlationID returns the correlation ID of a request: the one the
+// transport received, else the one in params._meta, else a new one. IDs
+// that are not valid are replaced.
+func (n *MCPNormalizer) correlationID(ctx context.Context, msg *mcp.Message) string {
+	if id := audit.CorrelationIDFromContext(ctx); audit.ValidCorrelationID(id) {
+		return id
+	}
+	if n.correlationMetaKey != "" {
+		if meta, ok := msg.ParseParams()["_meta"].(map[string]interface{}); ok {
+			if id, _ := meta[n.correlationMetaKey].(string); audit.ValidCorrelationID(id) {
+				return id
+			}
+		}
+	}
+	return audit.NewCorrelationID()
+}
+
 // extractToolCallParams parses tools/call params to set Name and Arguments.
 func (n *MCPNormalizer) extractToolCallParams(msg *mcp.Message, action *CanonicalAction) {
 	params := msg.ParseParams()
@@ -98,6 +145,53 @@ func (n *MCPNormalizer) extractToolCallParams(msg *mcp.Message, action *Canonica
 	if args, ok := params["arguments"].(map[string]interface{}); ok {
 		action.Arguments = args
 	}
+
+	if n.urlExtractor != nil {
+		setExtractedDestination(action, n.urlExtractor.Extract(action.Name, action.Arguments))
+	}
+}
+
+// setExtractedDestination fills Destination from the first extracted URL and
+// lists all of them in URLs and Domains.
+func setExtractedDestination(action *CanonicalAction, found []ExtractedURL) {
+	if len(found) == 0 {
+		return
+	}
+	first := found[0]
+	action.Destination.URL = first.URL
+	action.Destination.Scheme = first.Scheme
+	action.Destination.Domain = first.Domain
+	action.Destination.Port = first.Port
+	action.Destination.Path = first.Path
+	seen := make(map[string]bool, len(found))
+	for _, f := range found {
+		action.Destination.URLs = append(action.Destination.URLs, f.URL)
+		if f.Domain != "" && !seen[f.Domain] {
+			seen[f.Domain] = true
+			action.Destination.Domains = append(action.Destination.Domains, f.Domain)
+		}
+	}
+}
+
+// extractSubscribeParams sets the subscribed URI as the "uri" argument and as
+// the destination, so policies can match it with dest_url, dest_scheme and
+// dest_path (e.g. glob("file:///etc/*", dest_url)).
+func (n *MCPNormalizer) extractSubscribeParams(msg *mcp.Message, action *CanonicalAction) {
+	params := msg.ParseParams()
+	if params == nil {
+		return
+	}
+	uri, ok := params["uri"].(string)
+	if !ok {
+		return
+	}
+	action.Arguments = map[string]interface{}{"uri": uri}
+	action.Destination.URL = uri
+	if u, err := url.Parse(uri); err == nil {
+		action.Destination.Scheme = u.Scheme
+		action.Destination.Domain = u.Hostname()
+		action.Destination.Path = u.Path
+	}
 }
 
 // Denormalize converts an InterceptResult back to a protocol-specific response.
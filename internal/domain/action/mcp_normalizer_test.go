@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/auth"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/session"
 	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
@@ -177,6 +178,35 @@ func TestMCPNormalizer_Normalize_Elicitation(t *testing.T) {
 	}
 }
 
+func TestMCPNormalizer_Normalize_ResourceSubscribe(t *testing.T) {
+	normalizer := NewMCPNormalizer()
+	paramsJSON := json.RawMessage(`{"uri":"https://docs.example.com/guides/intro.md"}`)
+	id, _ := jsonrpc.MakeID(float64(3))
+	req := &jsonrpc.Request{ID: id, Method: "resources/subscribe", Params: paramsJSON}
+	raw, _ := jsonrpc.EncodeMessage(req)
+	msg := &mcp.Message{Raw: raw, Direction: mcp.ClientToServer, Decoded: req, Session: testSession()}
+
+	action, err := normalizer.Normalize(context.Background(), msg)
+	if err != nil {
+		t.Fatalf("Normalize() error = %v", err)
+	}
+
+	if action.Type != ActionResourceSubscribe {
+		t.Errorf("Type = %q, want %q", action.Type, ActionResourceSubscribe)
+	}
+	if action.Name != "resources/subscribe" {
+		t.Errorf("Name = %q, want %q", action.Name, "resources/subscribe")
+	}
+	if action.Arguments["uri"] != "https://docs.example.com/guides/intro.md" {
+		t.Errorf("Arguments[uri] = %v", action.Arguments["uri"])
+	}
+	dest := action.Destination
+	if dest.URL != "https://docs.example.com/guides/intro.md" || dest.Scheme != "https" ||
+		dest.Domain != "docs.example.com" || dest.Path != "/guides/intro.md" {
+		t.Errorf("Destination = %+v", dest)
+	}
+}
+
 func TestMCPNormalizer_Normalize_NonRequest(t *testing.T) {
 	normalizer := NewMCPNormalizer()
 
@@ -315,3 +345,54 @@ func TestMCPNormalizer_Normalize_InvalidType(t *testing.T) {
 		t.Error("Normalize() should return error for non-mcp.Message type")
 	}
 }
+
+func TestMCPNormalizer_Normalize_CorrelationID(t *testing.T) {
+	withMeta := func(id string) *mcp.Message {
+		msg := newMethodMessage("tools/list", nil)
+		params := []byte(`{"_meta":{"acme/corr":"` + id + `"}}`)
+		msg.Decoded.(*jsonrpc.Request).Params = params
+		msg.Raw = []byte(`{"jsonrpc":"2.0","id":2,"method":"tools/list","params":` + string(params) + `}`)
+		return msg
+	}
+
+	normalizer := NewMCPNormalizer()
+	normalizer.SetCorrelationMetaKey("acme/corr")
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		msg  *mcp.Message
+		want string
+	}{
+		{"from transport", audit.WithCorrelationID(context.Background(), "from-header"), withMeta("from-meta"), "from-header"},
+		{"from meta", context.Background(), withMeta("from-meta"), "from-meta"},
+		{"invalid transport value", audit.WithCorrelationID(context.Background(), "bad id"), withMeta("from-meta"), "from-meta"},
+		{"generated", context.Background(), withMeta("bad id"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action, err := normalizer.Normalize(tt.ctx, tt.msg)
+			if err != nil {
+				t.Fatalf("Normalize() error: %v", err)
+			}
+			if tt.want == "" {
+				if !audit.ValidCorrelationID(action.CorrelationID) {
+					t.Errorf("CorrelationID = %q, want a generated ID", action.CorrelationID)
+				}
+				return
+			}
+			if action.CorrelationID != tt.want {
+				t.Errorf("CorrelationID = %q, want %q", action.CorrelationID, tt.want)
+			}
+		})
+	}
+
+	// Without a meta key, _meta is not read.
+	action, err := NewMCPNormalizer().Normalize(context.Background(), withMeta("from-meta"))
+	if err != nil {
+		t.Fatalf("Normalize() error: %v", err)
+	}
+	if action.CorrelationID == "from-meta" || action.CorrelationID == "" {
+		t.Errorf("CorrelationID = %q, want a generated ID", action.CorrelationID)
+	}
+}
@@ -0,0 +1,102 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
+)
+
+// DefaultPayloadMaxBytes caps the arguments and the result of a payload
+// sample when no limit is set.
+const DefaultPayloadMaxBytes = 1 << 20
+
+// payloadSampling holds the payload sampling settings of the audit
+// interceptor.
+type payloadSampling struct {
+	sampler  *audit.PayloadSampler
+	store    audit.PayloadStore
+	maxBytes int
+}
+
+// SetPayloadSampling stores the full arguments and result of the tool calls
+// chosen by sampler in store, each capped at maxBytes (0 uses
+// DefaultPayloadMaxBytes). Pass a nil sampler to turn sampling off.
+func (a *ActionAuditInterceptor) SetPayloadSampling(sampler *audit.PayloadSampler, store audit.PayloadStore, maxBytes int) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultPayloadMaxBytes
+	}
+	a.cbMu.Lock()
+	defer a.cbMu.Unlock()
+	if sampler == nil || store == nil {
+		a.payloads = nil
+		return
+	}
+	a.payloads = &payloadSampling{sampler: sampler, store: store, maxBytes: maxBytes}
+}
+
+// samplePayload stores the payloads of the call when it is sampled, in the
+// background, and marks the record. Called before the record is written.
+func (a *ActionAuditInterceptor) samplePayload(record *audit.AuditRecord, result *CanonicalAction) {
+	a.cbMu.RLock()
+	p := a.payloads
+	a.cbMu.RUnlock()
+	if p == nil || !p.sampler.Sample(record.ToolName) {
+		return
+	}
+	record.PayloadSampled = true
+
+	sample := audit.PayloadSample{
+		Timestamp:    record.Timestamp,
+		RequestID:    record.RequestID,
+		SessionID:    record.SessionID,
+		IdentityID:   record.IdentityID,
+		IdentityName: record.IdentityName,
+		ToolName:     record.ToolName,
+		Decision:     record.Decision,
+		Arguments:    record.ToolArguments,
+		Result:       responsePayload(result),
+	}
+	if len(sample.Result) > p.maxBytes {
+		sample.Result = nil
+		sample.Truncated = true
+	}
+	if sample.Arguments != nil {
+		if data, err := json.Marshal(sample.Arguments); err != nil || len(data) > p.maxBytes {
+			sample.Arguments = nil
+			sample.Truncated = true
+		}
+	}
+
+	a.callbackWg.Add(1)
+	go func() {
+		defer a.callbackWg.Done()
+		if err := p.store.Append(context.Background(), sample); err != nil {
+			a.logger.Warn("failed to store payload sample", "tool", sample.ToolName, "request_id", sample.RequestID, "error", err)
+		}
+	}()
+}
+
+// responsePayload returns the JSON-RPC result, or error, of a tool call
+// response, or nil when there is none.
+func responsePayload(result *CanonicalAction) json.RawMessage {
+	if result == nil {
+		return nil
+	}
+	msg, ok := result.OriginalMessage.(*mcp.Message)
+	if !ok || msg == nil || msg.Raw == nil {
+		return nil
+	}
+	var envelope struct {
+		Result json.RawMessage `json:"result"`
+		Error  json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(msg.Raw, &envelope); err != nil {
+		return nil
+	}
+	if envelope.Result != nil {
+		return envelope.Result
+	}
+	return envelope.Error
+}
@@ -0,0 +1,101 @@
+package action
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+)
+
+// stubPayloadStore captures payload samples for assertion.
+type stubPayloadStore struct {
+	mu      sync.Mutex
+	samples []audit.PayloadSample
+}
+
+func (s *stubPayloadStore) Append(_ context.Context, sample audit.PayloadSample) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.samples = append(s.samples, sample)
+	return nil
+}
+
+func (s *stubPayloadStore) Query(context.Context, audit.PayloadFilter) ([]audit.PayloadSample, error) {
+	return nil, nil
+}
+
+func (s *stubPayloadStore) Close() error { return nil }
+
+func TestActionAuditInterceptor_PayloadSampling(t *testing.T) {
+	rec := &stubRecorder{}
+	store := &stubPayloadStore{}
+	interceptor := NewActionAuditInterceptor(rec, nil,
+		provenanceNext(`{"jsonrpc":"2.0","id":1,"result":{"content":[{"type":"text","text":"refunded"}]}}`),
+		newAuditLogger())
+	sampler, err := audit.NewPayloadSampler([]audit.PayloadSampleRule{
+		{Tool: "payments_refund", Rate: 1},
+		{Tool: "*", Rate: 0},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	interceptor.SetPayloadSampling(sampler, store, 0)
+
+	for _, name := range []string{"payments_refund", "read_file"} {
+		act := &CanonicalAction{
+			Type:      ActionToolCall,
+			Name:      name,
+			RequestID: "req-" + name,
+			Arguments: map[string]interface{}{"amount": float64(10), "api_key": "k"},
+			Identity:  ActionIdentity{ID: "user-1", SessionID: "sess-1"},
+		}
+		if _, err := interceptor.Intercept(context.Background(), act); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	interceptor.Drain()
+
+	if len(store.samples) != 1 {
+		t.Fatalf("samples = %d, want 1", len(store.samples))
+	}
+	s := store.samples[0]
+	if s.ToolName != "payments_refund" || s.RequestID != "req-payments_refund" || s.Decision != audit.DecisionAllow {
+		t.Errorf("sample = %+v, want the payments_refund call", s)
+	}
+	if s.Arguments["amount"] != float64(10) || s.Arguments["api_key"] != "***REDACTED***" {
+		t.Errorf("arguments = %v, want amount kept and api_key redacted", s.Arguments)
+	}
+	if string(s.Result) != `{"content":[{"type":"text","text":"refunded"}]}` {
+		t.Errorf("result = %s", s.Result)
+	}
+
+	records := rec.getRecords()
+	if len(records) != 2 || !records[0].PayloadSampled || records[1].PayloadSampled {
+		t.Errorf("payload_sampled = %v/%v, want true for the sampled call only",
+			records[0].PayloadSampled, records[1].PayloadSampled)
+	}
+}
+
+func TestActionAuditInterceptor_PayloadSamplingTruncates(t *testing.T) {
+	store := &stubPayloadStore{}
+	interceptor := NewActionAuditInterceptor(&stubRecorder{}, nil,
+		provenanceNext(`{"jsonrpc":"2.0","id":1,"result":{"content":[{"type":"text","text":"a long result"}]}}`),
+		newAuditLogger())
+	sampler, _ := audit.NewPayloadSampler([]audit.PayloadSampleRule{{Tool: "*", Rate: 1}})
+	interceptor.SetPayloadSampling(sampler, store, 32)
+
+	act := &CanonicalAction{Type: ActionToolCall, Name: "read_file", Arguments: map[string]interface{}{"path": "/a"}}
+	if _, err := interceptor.Intercept(context.Background(), act); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	interceptor.Drain()
+
+	if len(store.samples) != 1 {
+		t.Fatalf("samples = %d, want 1", len(store.samples))
+	}
+	s := store.samples[0]
+	if !s.Truncated || s.Result != nil || s.Arguments["path"] != "/a" {
+		t.Errorf("sample = %+v, want the result dropped and the arguments kept", s)
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/policy"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/proxy"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/timing"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/watchdog"
 )
 
 // SessionUsageProvider provides session usage data for CEL policy evaluation.
@@ -58,6 +60,43 @@ type HealthMetricsProvider interface {
 	GetHealthMetrics(ctx context.Context, identityID string) HealthMetricsData
 }
 
+// DestinationObserver is told about every destination that reaches policy
+// evaluation. Implemented by service.OutboundLearningService; it must return
+// quickly and never affects the decision.
+type DestinationObserver interface {
+	ObserveDestination(identityID, identityName, toolName string, dest Destination)
+}
+
+// ContextEnricher looks up attributes about an action that the gateway does
+// not store, exposed to rule conditions as enrichment. Implemented by
+// service.PolicyEnrichmentService. An error denies the call; an enricher
+// that fails open returns no attributes instead.
+type ContextEnricher interface {
+	Enrich(ctx context.Context, action *CanonicalAction) (map[string]interface{}, error)
+}
+
+// AnomalyAssessment is how far the arguments of a tool call deviate from
+// the profile of its tool.
+type AnomalyAssessment struct {
+	// Score is 0 for usual arguments and 1 for arguments unlike anything
+	// the tool received before.
+	Score float64
+	// Reasons describe the deviating arguments, highest score first.
+	Reasons []string
+	// WarmingUp is true while the tool's profile is still learning; Score
+	// is then 0.
+	WarmingUp bool
+}
+
+// AnomalyDetector scores tool call arguments against per-tool profiles and
+// learns from the calls policy allowed, given with their assessment.
+// Implemented by service.ArgumentAnomalyService; both methods must return
+// quickly.
+type AnomalyDetector interface {
+	Assess(ctx context.Context, action *CanonicalAction) AnomalyAssessment
+	Learn(action *CanonicalAction, assessment AnomalyAssessment)
+}
+
 // PolicyActionInterceptor evaluates CanonicalActions against RBAC policies.
 // This is the natively migrated version of proxy.PolicyInterceptor -- it
 // operates directly on CanonicalAction instead of going through LegacyAdapter.
@@ -68,6 +107,12 @@ type PolicyActionInterceptor struct {
 	policyEngine  policy.PolicyEngine
 	sessionUsage  SessionUsageProvider  // optional, nil = no session data
 	healthMetrics HealthMetricsProvider // optional, nil = no health data
+	destObserver  DestinationObserver   // optional, nil = destinations not observed
+	taint         *TaintTracker         // optional, nil = no taint tracking
+	enricher      ContextEnricher       // optional, nil = no enrichment
+	anomaly       AnomalyDetector       // optional, nil = no anomaly scoring
+	resolver      IPResolver            // optional, nil = only IP literals fill dest_ip
+	geo           GeoLocator            // optional, nil = destinations not located
 	next          ActionInterceptor
 	logger        *slog.Logger
 }
@@ -88,6 +133,36 @@ func WithHealthMetrics(p HealthMetricsProvider) PolicyActionOption {
 	return func(i *PolicyActionInterceptor) { i.healthMetrics = p }
 }
 
+// WithTaintTracker sets the TaintTracker populating the action_tainted and
+// taint_sources CEL variables.
+func WithTaintTracker(t *TaintTracker) PolicyActionOption {
+	return func(i *PolicyActionInterceptor) { i.taint = t }
+}
+
+// WithContextEnricher sets the ContextEnricher populating the enrichment
+// CEL variable.
+func WithContextEnricher(e ContextEnricher) PolicyActionOption {
+	return func(i *PolicyActionInterceptor) { i.enricher = e }
+}
+
+// WithAnomalyDetector sets the AnomalyDetector populating the anomaly CEL
+// variable.
+func WithAnomalyDetector(d AnomalyDetector) PolicyActionOption {
+	return func(i *PolicyActionInterceptor) { i.anomaly = d }
+}
+
+// WithDestinationResolver sets the IPResolver looking up destination hosts,
+// so that dest_ip holds the address of named destinations too.
+func WithDestinationResolver(r IPResolver) PolicyActionOption {
+	return func(i *PolicyActionInterceptor) { i.resolver = r }
+}
+
+// WithGeoLocator sets the GeoLocator filling the country and autonomous
+// system of destinations with an IP.
+func WithGeoLocator(l GeoLocator) PolicyActionOption {
+	return func(i *PolicyActionInterceptor) { i.geo = l }
+}
+
 // SetHealthMetrics sets the health metrics provider after construction (late binding).
 func (p *PolicyActionInterceptor) SetHealthMetrics(provider HealthMetricsProvider) {
 	p.mu.Lock()
@@ -95,6 +170,13 @@ func (p *PolicyActionInterceptor) SetHealthMetrics(provider HealthMetricsProvide
 	p.healthMetrics = provider
 }
 
+// SetDestinationObserver sets the observer told about evaluated destinations.
+func (p *PolicyActionInterceptor) SetDestinationObserver(o DestinationObserver) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.destObserver = o
+}
+
 // NewPolicyActionInterceptor creates a new PolicyActionInterceptor.
 // Accepts optional PolicyActionOption values for backward compatibility.
 func NewPolicyActionInterceptor(engine policy.PolicyEngine, next ActionInterceptor, logger *slog.Logger, opts ...PolicyActionOption) *PolicyActionInterceptor {
@@ -112,9 +194,10 @@ func NewPolicyActionInterceptor(engine policy.PolicyEngine, next ActionIntercept
 // Intercept evaluates tool calls and HTTP requests against policies before passing
 // to the next interceptor. Other action types pass through without policy evaluation.
 func (p *PolicyActionInterceptor) Intercept(ctx context.Context, action *CanonicalAction) (*CanonicalAction, error) {
-	// Evaluate tool calls, HTTP requests, sampling, and elicitation against policies.
+	// Evaluate tool calls, HTTP requests, sampling, elicitation and resource
+	// subscriptions against policies.
 	switch action.Type {
-	case ActionToolCall, ActionHTTPRequest, ActionSampling, ActionElicitation:
+	case ActionToolCall, ActionHTTPRequest, ActionSampling, ActionElicitation, ActionResourceSubscribe:
 		// Fall through to policy evaluation
 	default:
 		return p.next.Intercept(ctx, action)
@@ -126,6 +209,13 @@ func (p *PolicyActionInterceptor) Intercept(ctx context.Context, action *Canonic
 		return nil, proxy.ErrMissingSession
 	}
 
+	// Fill in the IP, default port and location of the destination for
+	// CIDR, port and country rules. A failed lookup leaves dest_ip empty.
+	if err := resolveDestination(ctx, p.resolver, &action.Destination); err != nil {
+		p.logger.Debug("destination lookup failed", "domain", action.Destination.Domain, "error", err)
+	}
+	locateDestination(p.geo, &action.Destination)
+
 	// Build EvaluationContext directly from CanonicalAction fields
 	evalCtx := policy.EvaluationContext{
 		ToolName:      action.Name,
@@ -151,6 +241,22 @@ func (p *PolicyActionInterceptor) Intercept(ctx context.Context, action *Canonic
 		DestScheme:  action.Destination.Scheme,
 		DestPath:    action.Destination.Path,
 		DestCommand: action.Destination.Command,
+		DestURLs:    action.Destination.URLs,
+		DestDomains: action.Destination.Domains,
+		DestCountry: action.Destination.Country,
+		DestASN:     int64(action.Destination.ASN),
+		DestASNOrg:  action.Destination.ASNOrg,
+
+		PayloadBytes: PayloadBytes(action),
+	}
+
+	// Outbound learning sees every destination that is about to be
+	// evaluated, whatever the decision turns out to be.
+	p.mu.RLock()
+	destObserver := p.destObserver
+	p.mu.RUnlock()
+	if destObserver != nil && (action.Destination.Domain != "" || len(action.Destination.Domains) > 0) {
+		destObserver.ObserveDestination(action.Identity.ID, action.Identity.Name, action.Name, action.Destination)
 	}
 
 	// Populate session usage from tracker if available
@@ -207,6 +313,20 @@ func (p *PolicyActionInterceptor) Intercept(ctx context.Context, action *Canonic
 		}
 	}
 
+	// Flag arguments that carry content scanning flagged in an earlier
+	// result of the session.
+	if p.taint != nil {
+		if m := p.taint.Check(ctx, action); m.Tainted {
+			evalCtx.ActionTainted = true
+			evalCtx.TaintSources = m.Sources
+			p.logger.Warn("tainted content in action arguments",
+				"tool", action.Name,
+				"sources", m.Sources,
+				"session_id", action.Identity.SessionID,
+			)
+		}
+	}
+
 	// Populate agent health metrics (Upgrade 11)
 	p.mu.RLock()
 	healthProvider := p.healthMetrics
@@ -220,8 +340,36 @@ func (p *PolicyActionInterceptor) Intercept(ctx context.Context, action *Canonic
 		evalCtx.UserErrorRate = hm.ErrorRate
 	}
 
+	// Score tool call arguments against the tool's profile.
+	var anomaly AnomalyAssessment
+	if p.anomaly != nil && action.Type == ActionToolCall {
+		anomaly = p.anomaly.Assess(ctx, action)
+		evalCtx.AnomalyScore = anomaly.Score
+		evalCtx.AnomalyReasons = anomaly.Reasons
+		evalCtx.AnomalyWarmingUp = anomaly.WarmingUp
+	}
+
+	// Attributes from the enrichment endpoint (identity's team, data
+	// classification of the destination, ...).
+	if p.enricher != nil {
+		attrs, err := p.enricher.Enrich(ctx, action)
+		if err != nil {
+			p.logger.Error("policy enrichment failed",
+				"error", err,
+				"tool", evalCtx.ToolName,
+				"session_id", action.Identity.SessionID,
+			)
+			return nil, fmt.Errorf("policy evaluation error: %w", err)
+		}
+		evalCtx.Enrichment = attrs
+	}
+
 	// Evaluate against policy engine
+	exitPolicy := watchdog.Enter(ctx, watchdog.StagePolicy)
+	stopPolicy := timing.Track(ctx, timing.PhasePolicy)
 	decision, err := p.policyEngine.Evaluate(ctx, evalCtx)
+	stopPolicy()
+	exitPolicy()
 	if err != nil {
 		p.logger.Error("policy evaluation failed",
 			"error", err,
@@ -235,6 +383,7 @@ func (p *PolicyActionInterceptor) Intercept(ctx context.Context, action *Canonic
 	if holder := audit.PolicyDecisionFromContext(ctx); holder != nil {
 		holder.RuleID = decision.RuleID
 		holder.RuleName = decision.RuleName
+		holder.DecisionID = decision.DecisionID
 	}
 
 	// Check decision
@@ -246,7 +395,14 @@ func (p *PolicyActionInterceptor) Intercept(ctx context.Context, action *Canonic
 			"session_id", action.Identity.SessionID,
 			"identity_id", action.Identity.ID,
 		)
-		return nil, fmt.Errorf("%w: %s", proxy.ErrPolicyDenied, decision.Reason)
+		helpURL, helpText := policy.RenderDecisionHelp(decision, policy.NewHelpTextData(evalCtx, decision.RuleName))
+		return nil, &proxy.PolicyDenyError{
+			RuleID:   decision.RuleID,
+			RuleName: decision.RuleName,
+			Reason:   decision.Reason,
+			HelpURL:  helpURL,
+			HelpText: helpText,
+		}
 	}
 
 	// Store decision in context for downstream interceptors (ApprovalInterceptor)
@@ -266,6 +422,10 @@ func (p *PolicyActionInterceptor) Intercept(ctx context.Context, action *Canonic
 			"rule_id", decision.RuleID,
 			"session_id", action.Identity.SessionID,
 		)
+		// Only calls policy let through shape the tool's profile.
+		if p.anomaly != nil && action.Type == ActionToolCall {
+			p.anomaly.Learn(action, anomaly)
+		}
 	}
 
 	return p.next.Intercept(ctx, action)
@@ -185,6 +185,38 @@ func TestPolicyActionInterceptor_SamplingEvaluated(t *testing.T) {
 	}
 }
 
+func TestPolicyActionInterceptor_ResourceSubscribeDenied(t *testing.T) {
+	var got policy.EvaluationContext
+	engine := &mockPolicyEngine{
+		evaluateFn: func(ctx context.Context, evalCtx policy.EvaluationContext) (policy.Decision, error) {
+			got = evalCtx
+			return policy.Decision{Allowed: false, Reason: "subscriptions to /etc are denied"}, nil
+		},
+	}
+
+	next := &mockNextInterceptor{}
+	interceptor := NewPolicyActionInterceptor(engine, next, testLogger())
+
+	action := &CanonicalAction{
+		Type:        ActionResourceSubscribe,
+		Name:        "resources/subscribe",
+		Protocol:    "mcp",
+		Arguments:   map[string]interface{}{"uri": "file:///etc/passwd"},
+		Destination: Destination{URL: "file:///etc/passwd", Scheme: "file", Path: "/etc/passwd"},
+		Identity:    ActionIdentity{SessionID: "sess-123"},
+	}
+
+	if _, err := interceptor.Intercept(context.Background(), action); err == nil {
+		t.Fatal("expected denied subscription to return an error")
+	}
+	if got.ActionType != "resource_subscribe" || got.DestURL != "file:///etc/passwd" {
+		t.Errorf("evaluation context = type %q, dest_url %q", got.ActionType, got.DestURL)
+	}
+	if next.called {
+		t.Error("next interceptor should not be called when denied")
+	}
+}
+
 func TestPolicyActionInterceptor_MissingIdentity(t *testing.T) {
 	engine := &mockPolicyEngine{
 		evaluateFn: func(ctx context.Context, evalCtx policy.EvaluationContext) (policy.Decision, error) {
@@ -702,3 +734,119 @@ func TestPolicyActionInterceptor_EvalContextFields(t *testing.T) {
 		t.Errorf("DestPath = %q, want %q", capturedCtx.DestPath, "/files")
 	}
 }
+
+type recordingDestinationObserver struct {
+	calls []string
+}
+
+func (o *recordingDestinationObserver) ObserveDestination(identityID, _, toolName string, dest Destination) {
+	o.calls = append(o.calls, identityID+"|"+toolName+"|"+dest.Domain)
+}
+
+func TestPolicyActionInterceptor_DestinationObserver(t *testing.T) {
+	engine := &mockPolicyEngine{
+		evaluateFn: func(ctx context.Context, evalCtx policy.EvaluationContext) (policy.Decision, error) {
+			return policy.Decision{Allowed: false, RuleID: "deny-all", Reason: "denied"}, nil
+		},
+	}
+	observer := &recordingDestinationObserver{}
+	interceptor := NewPolicyActionInterceptor(engine, &mockNextInterceptor{}, testLogger())
+	interceptor.SetDestinationObserver(observer)
+
+	// Calls without a destination are not observed.
+	_, _ = interceptor.Intercept(context.Background(), newTestToolCallAction())
+
+	// Denied calls are observed: learning sees what would be evaluated.
+	act := newTestToolCallAction()
+	act.Destination = Destination{Domain: "api.example.com"}
+	if _, err := interceptor.Intercept(context.Background(), act); err == nil {
+		t.Fatal("Intercept() should deny")
+	}
+
+	if len(observer.calls) != 1 || observer.calls[0] != "id-456|read_file|api.example.com" {
+		t.Errorf("observed = %v, want one call for api.example.com", observer.calls)
+	}
+}
+
+type stubContextEnricher struct {
+	attrs map[string]interface{}
+	err   error
+}
+
+func (e *stubContextEnricher) Enrich(context.Context, *CanonicalAction) (map[string]interface{}, error) {
+	return e.attrs, e.err
+}
+
+func TestPolicyActionInterceptor_Enrichment(t *testing.T) {
+	var got map[string]interface{}
+	engine := &mockPolicyEngine{
+		evaluateFn: func(ctx context.Context, evalCtx policy.EvaluationContext) (policy.Decision, error) {
+			got = evalCtx.Enrichment
+			return policy.Decision{Allowed: true, RuleID: "allow-all"}, nil
+		},
+	}
+	enricher := &stubContextEnricher{attrs: map[string]interface{}{"team": "payments"}}
+	interceptor := NewPolicyActionInterceptor(engine, &mockNextInterceptor{}, testLogger(), WithContextEnricher(enricher))
+
+	if _, err := interceptor.Intercept(context.Background(), newTestToolCallAction()); err != nil {
+		t.Fatalf("Intercept() error: %v", err)
+	}
+	if got["team"] != "payments" {
+		t.Errorf("Enrichment = %v, want team=payments", got)
+	}
+
+	// A failed lookup of an enricher that fails closed denies the call.
+	enricher.err = errors.New("directory unavailable")
+	got = nil
+	if _, err := interceptor.Intercept(context.Background(), newTestToolCallAction()); err == nil {
+		t.Fatal("Intercept() should fail when enrichment fails")
+	}
+	if got != nil {
+		t.Error("policy engine should not be called when enrichment fails")
+	}
+}
+
+type stubAnomalyDetector struct {
+	assessment AnomalyAssessment
+	learned    []AnomalyAssessment
+}
+
+func (d *stubAnomalyDetector) Assess(context.Context, *CanonicalAction) AnomalyAssessment {
+	return d.assessment
+}
+
+func (d *stubAnomalyDetector) Learn(_ *CanonicalAction, a AnomalyAssessment) {
+	d.learned = append(d.learned, a)
+}
+
+func TestPolicyActionInterceptor_AnomalyDetector(t *testing.T) {
+	allowed := true
+	var got policy.EvaluationContext
+	engine := &mockPolicyEngine{
+		evaluateFn: func(_ context.Context, evalCtx policy.EvaluationContext) (policy.Decision, error) {
+			got = evalCtx
+			return policy.Decision{Allowed: allowed, RuleID: "r"}, nil
+		},
+	}
+	detector := &stubAnomalyDetector{assessment: AnomalyAssessment{Score: 0.9, Reasons: []string{"path"}}}
+	interceptor := NewPolicyActionInterceptor(engine, &mockNextInterceptor{}, testLogger(), WithAnomalyDetector(detector))
+
+	if _, err := interceptor.Intercept(context.Background(), newTestToolCallAction()); err != nil {
+		t.Fatalf("Intercept() error: %v", err)
+	}
+	if got.AnomalyScore != 0.9 || len(got.AnomalyReasons) != 1 {
+		t.Errorf("eval context anomaly = %v %v, want 0.9 [path]", got.AnomalyScore, got.AnomalyReasons)
+	}
+	if len(detector.learned) != 1 || detector.learned[0].Score != 0.9 {
+		t.Errorf("learned = %+v, want the allowed call with its assessment", detector.learned)
+	}
+
+	// Denied calls are not learned.
+	allowed = false
+	if _, err := interceptor.Intercept(context.Background(), newTestToolCallAction()); err == nil {
+		t.Fatal("Intercept() should deny")
+	}
+	if len(detector.learned) != 1 {
+		t.Errorf("learned %d calls, want the denied call skipped", len(detector.learned))
+	}
+}
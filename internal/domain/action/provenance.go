@@ -0,0 +1,96 @@
+package action
+
+import (
+	"encoding/json"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
+)
+
+// ProvenanceMetaKey is the key under result._meta that carries tool result
+// provenance when meta provenance is enabled.
+const ProvenanceMetaKey = "sentinelgate/provenance"
+
+// successfulToolResult returns the MCP response carried by result if it is a
+// server-to-client JSON-RPC success response (has "result", no "error").
+func successfulToolResult(result *CanonicalAction) *mcp.Message {
+	if result == nil {
+		return nil
+	}
+	msg, ok := result.OriginalMessage.(*mcp.Message)
+	if !ok || msg == nil || msg.Direction != mcp.ServerToClient || msg.Raw == nil {
+		return nil
+	}
+	var envelope struct {
+		Result json.RawMessage `json:"result"`
+		Error  json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(msg.Raw, &envelope); err != nil {
+		return nil
+	}
+	if len(envelope.Result) == 0 || len(envelope.Error) > 0 {
+		return nil
+	}
+	return msg
+}
+
+// withProvenanceMeta returns raw with p stored under result._meta[ProvenanceMetaKey].
+// Existing _meta entries are preserved. Returns false if raw has no object result.
+func withProvenanceMeta(raw []byte, p *audit.Provenance) ([]byte, bool) {
+	return withResultMeta(raw, ProvenanceMetaKey, p)
+}
+
+// withResultMeta returns raw with v stored under result._meta[key].
+// Existing _meta entries are preserved. Returns false if raw has no object result.
+func withResultMeta(raw []byte, key string, v interface{}) ([]byte, bool) {
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return nil, false
+	}
+	var result map[string]json.RawMessage
+	if err := json.Unmarshal(envelope["result"], &result); err != nil || result == nil {
+		return nil, false
+	}
+	meta := map[string]json.RawMessage{}
+	if existing, ok := result["_meta"]; ok {
+		if err := json.Unmarshal(existing, &meta); err != nil || meta == nil {
+			return nil, false
+		}
+	}
+
+	valueJSON, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+	meta[key] = valueJSON
+
+	metaJSON, err := json.Marshal(meta)
+	if err != nil {
+		return nil, false
+	}
+	result["_meta"] = metaJSON
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return nil, false
+	}
+	envelope["result"] = resultJSON
+	out, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, false
+	}
+	return out, true
+}
+
+// scanVerdict derives the provenance scan verdict from the scan holder.
+func scanVerdict(holder *audit.ScanResultHolder) string {
+	switch {
+	case holder == nil || !holder.Scanned:
+		return audit.ProvenanceScanSkipped
+	case holder.Detections > 0 && holder.Action == "redacted":
+		return audit.ProvenanceScanRedacted
+	case holder.Detections > 0:
+		return audit.ProvenanceScanMonitored
+	default:
+		return audit.ProvenanceScanClean
+	}
+}
@@ -1,18 +1,22 @@
 package action
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/event"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/proxy"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/timing"
 	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
 )
 
@@ -26,6 +30,8 @@ var ErrResponseBlocked = proxy.ErrResponseBlocked
 //
 // In monitor mode, detections are logged but responses pass through.
 // In enforce mode, responses containing injection patterns are blocked.
+// In redact mode, detected snippets are replaced with placeholders, as are
+// PII and secrets when a ContentScanner is set, and the response passes.
 type ResponseScanInterceptor struct {
 	scanner  *ResponseScanner
 	next     ActionInterceptor
@@ -33,6 +39,8 @@ type ResponseScanInterceptor struct {
 	mode     *atomic.Value // stores ScanMode string
 	enabled  *atomic.Bool
 	eventBus event.Bus
+	taint    *TaintTracker
+	secrets  *ContentScanner // PII/secret patterns redacted in redact mode
 	mu       sync.RWMutex
 }
 
@@ -89,8 +97,27 @@ func (r *ResponseScanInterceptor) Intercept(ctx context.Context, a *CanonicalAct
 		return result, nil
 	}
 
-	// Extract and scan response content from the mcp.Message.
-	scanResult := r.scanResponseContent(mcpMsg)
+	// Extract and scan response content from the mcp.Message. Redact mode
+	// rewrites it in the same pass.
+	currentMode := r.Mode()
+	stopScan := timing.Track(ctx, timing.PhaseScan)
+	var scanResult ScanResult
+	var redacted []byte
+	if currentMode == ScanModeRedact {
+		scanResult, redacted = r.redactResponseContent(mcpMsg)
+	} else {
+		scanResult = r.scanResponseContent(mcpMsg)
+	}
+	stopScan()
+	if holder := audit.ScanResultFromContext(ctx); holder != nil {
+		holder.Scanned = true
+	}
+	if scanResult.Truncated {
+		r.logger.Warn("response content scanning: result exceeds the scan size cap, remainder not scanned",
+			"tool", a.Name,
+			"payload_bytes", len(mcpMsg.Raw),
+		)
+	}
 	if !scanResult.Detected {
 		return result, nil
 	}
@@ -105,7 +132,6 @@ func (r *ResponseScanInterceptor) Intercept(ctx context.Context, a *CanonicalAct
 		}
 	}
 
-	currentMode := r.Mode()
 	method := mcpMsg.Method()
 
 	r.logger.Warn("response content scanning: prompt injection detected",
@@ -121,7 +147,7 @@ func (r *ResponseScanInterceptor) Intercept(ctx context.Context, a *CanonicalAct
 	bus := r.eventBus
 	r.mu.RUnlock()
 	if bus != nil {
-		severity := event.SeverityWarning
+		severity := findingsSeverity(scanResult.Findings)
 		if currentMode == ScanModeEnforce {
 			severity = event.SeverityCritical
 		}
@@ -141,6 +167,23 @@ func (r *ResponseScanInterceptor) Intercept(ctx context.Context, a *CanonicalAct
 		})
 	}
 
+	// Remember the flagged content so its reuse in later arguments of the
+	// session is detected.
+	r.mu.RLock()
+	taint := r.taint
+	r.mu.RUnlock()
+	if taint != nil {
+		content := extractResponseText(result)
+		if content == "" {
+			content = string(mcpMsg.Raw)
+		}
+		matches := make([]string, 0, len(scanResult.Findings))
+		for _, f := range scanResult.Findings {
+			matches = append(matches, f.MatchedText)
+		}
+		taint.Record(a.Identity.SessionID, a.Name, content, matches)
+	}
+
 	// Populate scan result holder in context (for AuditInterceptor).
 	if holder := audit.ScanResultFromContext(ctx); holder != nil {
 		holder.Detections = len(scanResult.Findings)
@@ -155,16 +198,27 @@ func (r *ResponseScanInterceptor) Intercept(ctx context.Context, a *CanonicalAct
 		}
 		sort.Strings(cats)
 		holder.Types = strings.Join(cats, ",")
-		if currentMode == ScanModeEnforce {
-			holder.Action = "blocked"
-		} else {
+		switch {
+		case currentMode == ScanModeRedact && redacted != nil:
+			holder.Action = "redacted"
+		case currentMode == ScanModeMonitor:
 			holder.Action = "monitored"
+		default:
+			holder.Action = "blocked"
 		}
 	}
 
-	// In monitor mode: log only, return the result.
-	if currentMode == ScanModeMonitor {
+	switch currentMode {
+	case ScanModeMonitor:
+		// Log only, return the result.
 		return result, nil
+	case ScanModeRedact:
+		// Forward the rewritten result; one that could not be rewritten is
+		// blocked rather than forwarded as is.
+		if redacted != nil {
+			mcpMsg.Raw = redacted
+			return result, nil
+		}
 	}
 
 	// In enforce mode: block the response.
@@ -187,7 +241,8 @@ func (r *ResponseScanInterceptor) scanResponseContent(msg *mcp.Message) ScanResu
 	}
 	if err := json.Unmarshal(msg.Raw, &envelope); err != nil || envelope.Result == nil {
 		// No result field, fall back to scanning entire raw content.
-		return r.scanner.Scan(string(msg.Raw))
+		sr, _ := r.scanner.ScanReader(bytes.NewReader(msg.Raw))
+		return sr
 	}
 
 	// Try to parse result as MCP tool result format with content array.
@@ -200,21 +255,21 @@ func (r *ResponseScanInterceptor) scanResponseContent(msg *mcp.Message) ScanResu
 	if err := json.Unmarshal(envelope.Result, &toolResult); err == nil && len(toolResult.Content) > 0 {
 		// Scan each text content item.
 		var allFindings []ScanFinding
+		truncated := false
 		for _, c := range toolResult.Content {
 			if c.Type == "text" || c.Text != "" {
 				sr := r.scanner.Scan(c.Text)
 				if sr.Detected {
 					allFindings = append(allFindings, sr.Findings...)
 				}
+				truncated = truncated || sr.Truncated
 			}
 		}
-		if len(allFindings) > 0 {
-			return ScanResult{
-				Detected: true,
-				Findings: allFindings,
-			}
+		return ScanResult{
+			Detected:  len(allFindings) > 0,
+			Findings:  allFindings,
+			Truncated: truncated,
 		}
-		return ScanResult{}
 	}
 
 	// Try scanning as a plain string.
@@ -232,6 +287,87 @@ func (r *ResponseScanInterceptor) scanResponseContent(msg *mcp.Message) ScanResu
 	return ScanResult{}
 }
 
+// redactResponseContent replaces the detected snippets in every string of
+// the result of msg with placeholders. It returns the findings and the
+// rewritten message, which is nil when there was nothing to redact or the
+// result could not be re-encoded.
+func (r *ResponseScanInterceptor) redactResponseContent(msg *mcp.Message) (ScanResult, []byte) {
+	start := time.Now()
+	if msg.Raw == nil {
+		return ScanResult{}, nil
+	}
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(msg.Raw, &envelope); err != nil || envelope["result"] == nil {
+		return ScanResult{}, nil
+	}
+	// UseNumber keeps large integers in the result intact.
+	dec := json.NewDecoder(bytes.NewReader(envelope["result"]))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return ScanResult{}, nil
+	}
+
+	r.mu.RLock()
+	secrets := r.secrets
+	r.mu.RUnlock()
+
+	var findings []ScanFinding
+	value = r.redactValue(value, "result", secrets, &findings)
+	scanResult := ScanResult{
+		Detected:       len(findings) > 0,
+		Findings:       findings,
+		ScanDurationNs: time.Since(start).Nanoseconds(),
+	}
+	if len(findings) == 0 {
+		return scanResult, nil
+	}
+	rebuilt, err := json.Marshal(value)
+	if err != nil {
+		return scanResult, nil
+	}
+	envelope["result"] = rebuilt
+	raw, err := json.Marshal(envelope)
+	if err != nil {
+		return scanResult, nil
+	}
+	return scanResult, raw
+}
+
+// redactValue rewrites the strings of a decoded JSON value.
+func (r *ResponseScanInterceptor) redactValue(v interface{}, path string, secrets *ContentScanner, findings *[]ScanFinding) interface{} {
+	switch val := v.(type) {
+	case string:
+		out, found := r.scanner.Redact(val)
+		*findings = append(*findings, found...)
+		if secrets != nil {
+			var sensitive []ContentFinding
+			out, sensitive = secrets.RedactString(out, path)
+			for _, f := range sensitive {
+				*findings = append(*findings, ScanFinding{
+					PatternName:     string(f.PatternType),
+					PatternCategory: "sensitive_data",
+					MatchedText:     f.MatchedText,
+					Position:        f.Position,
+				})
+			}
+		}
+		return out
+	case map[string]interface{}:
+		for k, item := range val {
+			val[k] = r.redactValue(item, path+"."+k, secrets, findings)
+		}
+		return val
+	case []interface{}:
+		for i, item := range val {
+			val[i] = r.redactValue(item, path+"["+strconv.Itoa(i)+"]", secrets, findings)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
 // SetMode updates the scan mode thread-safely.
 func (r *ResponseScanInterceptor) SetMode(mode ScanMode) {
 	r.mode.Store(mode)
@@ -262,3 +398,38 @@ func (r *ResponseScanInterceptor) SetEventBus(bus event.Bus) {
 	defer r.mu.Unlock()
 	r.eventBus = bus
 }
+
+// SetContentScanner sets the scanner whose PII and secret patterns are
+// redacted from results in redact mode. Patterns set to "off" are skipped.
+func (r *ResponseScanInterceptor) SetContentScanner(cs *ContentScanner) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.secrets = cs
+}
+
+// SetTaintTracker sets the tracker fed with flagged response content.
+func (r *ResponseScanInterceptor) SetTaintTracker(t *TaintTracker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.taint = t
+}
+
+// findingsSeverity returns the event severity of a detection: critical when
+// a critical custom detector matched, info when only info detectors did,
+// warning otherwise.
+func findingsSeverity(findings []ScanFinding) event.Severity {
+	allInfo := len(findings) > 0
+	for _, f := range findings {
+		switch f.Severity {
+		case DetectorSeverityCritical:
+			return event.SeverityCritical
+		case DetectorSeverityInfo:
+		default:
+			allInfo = false
+		}
+	}
+	if allInfo {
+		return event.SeverityInfo
+	}
+	return event.SeverityWarning
+}
@@ -1,7 +1,10 @@
 package action
 
 import (
+	"io"
 	"regexp"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -13,6 +16,9 @@ const (
 	ScanModeMonitor ScanMode = "monitor"
 	// ScanModeEnforce blocks responses containing prompt injection.
 	ScanModeEnforce ScanMode = "enforce"
+	// ScanModeRedact replaces detected content with placeholders and
+	// forwards the rest of the response.
+	ScanModeRedact ScanMode = "redact"
 )
 
 // ScanFinding represents a single pattern match found during scanning.
@@ -25,6 +31,9 @@ type ScanFinding struct {
 	MatchedText string
 	// Position is the byte offset where the match starts in the scanned content.
 	Position int
+	// Severity is the severity of the custom detector that matched; empty
+	// for built-in patterns.
+	Severity string
 }
 
 // ScanResult contains the outcome of scanning content for prompt injection.
@@ -35,19 +44,30 @@ type ScanResult struct {
 	Findings []ScanFinding
 	// ScanDurationNs is how long the scan took in nanoseconds.
 	ScanDurationNs int64
+	// Truncated is true when content beyond the scan size cap was not
+	// scanned.
+	Truncated bool
 }
 
 // compiledPattern holds a pre-compiled regex pattern with metadata.
 type compiledPattern struct {
 	name     string
 	category string
+	severity string
 	re       *regexp.Regexp
 }
 
 // ResponseScanner detects prompt injection patterns in MCP tool results.
 // All patterns are compiled at construction time for minimal per-scan overhead.
+// Custom detectors added with SetCustomDetectors are compiled when set and
+// scanned after the built-in patterns.
 type ResponseScanner struct {
-	patterns []compiledPattern
+	patterns []compiledPattern // built-in
+
+	mu     sync.RWMutex
+	custom []CustomDetector
+	active []compiledPattern // built-in followed by custom
+	limits StreamLimits
 }
 
 // NewResponseScanner creates a ResponseScanner with compiled regex patterns
@@ -128,12 +148,76 @@ func NewResponseScanner() *ResponseScanner {
 
 	return &ResponseScanner{
 		patterns: compiled,
+		active:   compiled,
+		limits:   DefaultStreamLimits,
 	}
 }
 
+// activePatterns returns the patterns to scan with.
+func (s *ResponseScanner) activePatterns() []compiledPattern {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.active
+}
+
+// SetStreamLimits sets the chunking and size cap of scans. Invalid limits
+// restore DefaultStreamLimits.
+func (s *ResponseScanner) SetStreamLimits(limits StreamLimits) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limits = limits.orDefault()
+}
+
+// StreamLimits returns the chunking and size cap of scans.
+func (s *ResponseScanner) StreamLimits() StreamLimits {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.limits
+}
+
+// scanWindow appends the matches of patterns in window starting before
+// limit, with their positions in the whole content.
+func scanWindow(patterns []compiledPattern, window string, offset int64, limit int, findings *[]ScanFinding) {
+	for _, p := range patterns {
+		for _, loc := range p.re.FindAllStringIndex(window, -1) {
+			if loc[0] >= limit {
+				break
+			}
+			matchedText := window[loc[0]:loc[1]]
+			if len(matchedText) > 100 {
+				matchedText = matchedText[:100]
+			}
+			*findings = append(*findings, ScanFinding{
+				PatternName:     p.name,
+				PatternCategory: p.category,
+				MatchedText:     matchedText,
+				Position:        int(offset) + loc[0],
+				Severity:        p.severity,
+			})
+		}
+	}
+}
+
+// ScanReader scans content read from r in chunks, holding at most one
+// chunk in memory, and stops at the scan size cap.
+func (s *ResponseScanner) ScanReader(r io.Reader) (ScanResult, error) {
+	start := time.Now()
+	patterns := s.activePatterns()
+	var findings []ScanFinding
+	res, err := ScanStream(r, s.StreamLimits(), func(window string, offset int64, limit int) {
+		scanWindow(patterns, window, offset, limit, &findings)
+	})
+	return ScanResult{
+		Detected:       len(findings) > 0,
+		Findings:       findings,
+		ScanDurationNs: time.Since(start).Nanoseconds(),
+		Truncated:      res.Truncated,
+	}, err
+}
+
 // Scan runs all compiled patterns against the given content string.
 // Returns a ScanResult with any findings. Empty content returns immediately
-// with no findings.
+// with no findings. Content larger than a chunk is scanned chunk by chunk.
 func (s *ResponseScanner) Scan(content string) ScanResult {
 	start := time.Now()
 
@@ -143,10 +227,37 @@ func (s *ResponseScanner) Scan(content string) ScanResult {
 		}
 	}
 
+	patterns := s.activePatterns()
+	var findings []ScanFinding
+	truncated := scanLarge(content, s.StreamLimits(), func(window string, offset int64, limit int) {
+		scanWindow(patterns, window, offset, limit, &findings)
+	})
+
+	return ScanResult{
+		Detected:       len(findings) > 0,
+		Findings:       findings,
+		ScanDurationNs: time.Since(start).Nanoseconds(),
+		Truncated:      truncated,
+	}
+}
+
+// Redact replaces every pattern match in content with the placeholder of
+// the pattern's category (e.g. "[REDACTED-PROMPT-INJECTION]") and returns
+// the rewritten content with the findings.
+func (s *ResponseScanner) Redact(content string) (string, []ScanFinding) {
+	if content == "" {
+		return content, nil
+	}
 	var findings []ScanFinding
-	for _, p := range s.patterns {
-		matches := p.re.FindAllStringIndex(content, -1)
-		for _, loc := range matches {
+	for _, p := range s.activePatterns() {
+		locs := p.re.FindAllStringIndex(content, -1)
+		if len(locs) == 0 {
+			continue
+		}
+		placeholder := redactPlaceholder(p.category)
+		var b strings.Builder
+		last := 0
+		for _, loc := range locs {
 			matchedText := content[loc[0]:loc[1]]
 			if len(matchedText) > 100 {
 				matchedText = matchedText[:100]
@@ -156,15 +267,21 @@ func (s *ResponseScanner) Scan(content string) ScanResult {
 				PatternCategory: p.category,
 				MatchedText:     matchedText,
 				Position:        loc[0],
+				Severity:        p.severity,
 			})
+			b.WriteString(content[last:loc[0]])
+			b.WriteString(placeholder)
+			last = loc[1]
 		}
+		b.WriteString(content[last:])
+		content = b.String()
 	}
+	return content, findings
+}
 
-	return ScanResult{
-		Detected:       len(findings) > 0,
-		Findings:       findings,
-		ScanDurationNs: time.Since(start).Nanoseconds(),
-	}
+// redactPlaceholder returns the placeholder for a pattern category.
+func redactPlaceholder(category string) string {
+	return "[REDACTED-" + strings.ToUpper(strings.ReplaceAll(category, "_", "-")) + "]"
 }
 
 // ScanJSON recursively scans JSON-compatible values (strings, maps, slices)
@@ -174,31 +291,36 @@ func (s *ResponseScanner) ScanJSON(v interface{}) ScanResult {
 	start := time.Now()
 
 	var findings []ScanFinding
-	s.scanValue(v, &findings)
+	truncated := s.scanValue(v, &findings)
 
 	return ScanResult{
 		Detected:       len(findings) > 0,
 		Findings:       findings,
 		ScanDurationNs: time.Since(start).Nanoseconds(),
+		Truncated:      truncated,
 	}
 }
 
-// scanValue recursively extracts strings from JSON-compatible values and scans them.
-func (s *ResponseScanner) scanValue(v interface{}, findings *[]ScanFinding) {
+// scanValue recursively extracts strings from JSON-compatible values and
+// scans them. It reports whether a string was cut at the scan size cap.
+func (s *ResponseScanner) scanValue(v interface{}, findings *[]ScanFinding) bool {
+	truncated := false
 	switch val := v.(type) {
 	case string:
 		result := s.Scan(val)
 		if result.Detected {
 			*findings = append(*findings, result.Findings...)
 		}
+		truncated = result.Truncated
 	case map[string]interface{}:
 		for _, mapVal := range val {
-			s.scanValue(mapVal, findings)
+			truncated = s.scanValue(mapVal, findings) || truncated
 		}
 	case []interface{}:
 		for _, item := range val {
-			s.scanValue(item, findings)
+			truncated = s.scanValue(item, findings) || truncated
 		}
 		// Skip other types (numbers, bools, nil)
 	}
+	return truncated
 }
@@ -518,3 +518,17 @@ func TestResponseScanner_FindingFields(t *testing.T) {
 		t.Error("ScanDurationNs should be non-negative")
 	}
 }
+
+func TestResponseScanner_Redact(t *testing.T) {
+	s := NewResponseScanner()
+	out, findings := s.Redact("ok <|im_start|> then [INST] more")
+	if out != "ok [REDACTED-CONTEXT-ESCAPE] then [REDACTED-CONTEXT-ESCAPE] more" {
+		t.Errorf("Redact() = %q", out)
+	}
+	if len(findings) != 2 || findings[0].Position != 3 {
+		t.Errorf("findings = %+v, want 2 starting at 3", findings)
+	}
+	if out, findings := s.Redact("plain text"); out != "plain text" || len(findings) != 0 {
+		t.Errorf("Redact(clean) = %q, %d findings", out, len(findings))
+	}
+}
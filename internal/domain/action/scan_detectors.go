@@ -0,0 +1,192 @@
+package action
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Custom detector severities. They set the severity of the
+// content.ipi_detected event of a detection.
+const (
+	DetectorSeverityInfo     = "info"
+	DetectorSeverityWarning  = "warning"
+	DetectorSeverityCritical = "critical"
+)
+
+// Custom detector limits.
+const (
+	// MaxCustomDetectors caps the custom detectors of a scanner.
+	MaxCustomDetectors = 200
+	// maxDetectorPatternLen caps the length of a detector regex.
+	maxDetectorPatternLen = 2048
+	// maxDetectorKeywords caps the keywords of a detector.
+	maxDetectorKeywords = 500
+	// defaultDetectorCategory is the category of detectors without one.
+	defaultDetectorCategory = "custom"
+)
+
+var (
+	// detectorNameRe matches detector names and categories: they appear in
+	// audit records and redaction placeholders.
+	detectorNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_]{0,63}$`)
+
+	// ErrDetectorNotFound is returned when a custom detector does not exist.
+	ErrDetectorNotFound = errors.New("detector not found")
+)
+
+// CustomDetector is an organization-specific detection added to the
+// response scanner at runtime, e.g. an internal secret format. It matches
+// either a regular expression (RE2 syntax) or any of a list of keywords,
+// compared case-insensitively.
+type CustomDetector struct {
+	// ID uniquely identifies the detector.
+	ID string `json:"id"`
+	// Name identifies the detector in findings, like the names of the
+	// built-in patterns. Lower case letters, digits and underscores.
+	Name string `json:"name"`
+	// Pattern is the regular expression to match. Exclusive with Keywords.
+	Pattern string `json:"pattern,omitempty"`
+	// Keywords are literal strings to match. Exclusive with Pattern.
+	Keywords []string `json:"keywords,omitempty"`
+	// Severity is info, warning or critical. Defaults to warning.
+	Severity string `json:"severity"`
+	// Category groups findings in audit records and names the redaction
+	// placeholder. Defaults to "custom".
+	Category string `json:"category"`
+}
+
+// Normalize fills the defaults of a detector and checks it, compiling its
+// pattern. Names are checked for uniqueness by SetCustomDetectors.
+func (d *CustomDetector) Normalize() error {
+	if !detectorNameRe.MatchString(d.Name) {
+		return fmt.Errorf("name %q must be 1-64 lower case letters, digits or underscores", d.Name)
+	}
+	if d.Category == "" {
+		d.Category = defaultDetectorCategory
+	}
+	if !detectorNameRe.MatchString(d.Category) {
+		return fmt.Errorf("category %q must be 1-64 lower case letters, digits or underscores", d.Category)
+	}
+	switch d.Severity {
+	case "":
+		d.Severity = DetectorSeverityWarning
+	case DetectorSeverityInfo, DetectorSeverityWarning, DetectorSeverityCritical:
+	default:
+		return fmt.Errorf("severity must be info, warning or critical, got %q", d.Severity)
+	}
+	_, err := d.compile()
+	return err
+}
+
+// compile returns the regular expression of a detector.
+func (d *CustomDetector) compile() (*regexp.Regexp, error) {
+	switch {
+	case d.Pattern != "" && len(d.Keywords) > 0:
+		return nil, errors.New("set either pattern or keywords, not both")
+	case d.Pattern != "":
+		if len(d.Pattern) > maxDetectorPatternLen {
+			return nil, fmt.Errorf("pattern is longer than %d characters", maxDetectorPatternLen)
+		}
+		re, err := regexp.Compile(d.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+		// A pattern matching the empty string would flag every result.
+		if re.MatchString("") {
+			return nil, errors.New("pattern matches empty content")
+		}
+		return re, nil
+	case len(d.Keywords) > 0:
+		if len(d.Keywords) > maxDetectorKeywords {
+			return nil, fmt.Errorf("more than %d keywords", maxDetectorKeywords)
+		}
+		quoted := make([]string, 0, len(d.Keywords))
+		for _, k := range d.Keywords {
+			if strings.TrimSpace(k) == "" {
+				return nil, errors.New("keywords must not be empty")
+			}
+			quoted = append(quoted, regexp.QuoteMeta(k))
+		}
+		return regexp.MustCompile(`(?i)(?:` + strings.Join(quoted, "|") + `)`), nil
+	default:
+		return nil, errors.New("pattern or keywords is required")
+	}
+}
+
+// Match scans text with the detector alone, to try a detector before
+// adding it.
+func (d CustomDetector) Match(text string) ([]ScanFinding, error) {
+	if err := d.Normalize(); err != nil {
+		return nil, err
+	}
+	re, _ := d.compile()
+	scanner := &ResponseScanner{active: []compiledPattern{{
+		name: d.Name, category: d.Category, severity: d.Severity, re: re,
+	}}}
+	return scanner.Scan(text).Findings, nil
+}
+
+// SetCustomDetectors replaces the custom detectors of the scanner. The
+// detectors are normalized and compiled first; on error the scanner keeps
+// its current detectors. Scans already running finish with the previous
+// set.
+func (s *ResponseScanner) SetCustomDetectors(detectors []CustomDetector) error {
+	if len(detectors) > MaxCustomDetectors {
+		return fmt.Errorf("more than %d custom detectors", MaxCustomDetectors)
+	}
+	names := make(map[string]bool, len(s.patterns)+len(detectors))
+	for _, p := range s.patterns {
+		names[p.name] = true
+	}
+	custom := make([]CustomDetector, len(detectors))
+	active := make([]compiledPattern, len(s.patterns), len(s.patterns)+len(detectors))
+	copy(active, s.patterns)
+	for i, d := range detectors {
+		d.Keywords = append([]string(nil), d.Keywords...)
+		if err := d.Normalize(); err != nil {
+			return fmt.Errorf("detector %q: %w", d.Name, err)
+		}
+		if names[d.Name] {
+			return fmt.Errorf("detector %q: name already used", d.Name)
+		}
+		names[d.Name] = true
+		re, _ := d.compile()
+		custom[i] = d
+		active = append(active, compiledPattern{name: d.Name, category: d.Category, severity: d.Severity, re: re})
+	}
+
+	s.mu.Lock()
+	s.custom = custom
+	s.active = active
+	s.mu.Unlock()
+	return nil
+}
+
+// CustomDetectors returns a copy of the custom detectors.
+func (s *ResponseScanner) CustomDetectors() []CustomDetector {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]CustomDetector, len(s.custom))
+	for i, d := range s.custom {
+		d.Keywords = append([]string(nil), d.Keywords...)
+		out[i] = d
+	}
+	return out
+}
+
+// BuiltinPattern describes a built-in pattern of the scanner.
+type BuiltinPattern struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`
+}
+
+// BuiltinPatterns returns the names and categories of the built-in patterns.
+func (s *ResponseScanner) BuiltinPatterns() []BuiltinPattern {
+	out := make([]BuiltinPattern, len(s.patterns))
+	for i, p := range s.patterns {
+		out[i] = BuiltinPattern{Name: p.name, Category: p.category}
+	}
+	return out
+}
@@ -0,0 +1,120 @@
+package action
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResponseScanner_CustomDetectors(t *testing.T) {
+	s := NewResponseScanner()
+	err := s.SetCustomDetectors([]CustomDetector{
+		{ID: "det_1", Name: "acme_token", Pattern: `acme_[a-f0-9]{32}`, Severity: DetectorSeverityCritical, Category: "secret"},
+		{ID: "det_2", Name: "codenames", Keywords: []string{"Project Falcon", "blue.harbor"}},
+	})
+	if err != nil {
+		t.Fatalf("SetCustomDetectors: %v", err)
+	}
+
+	result := s.Scan("key acme_0123456789abcdef0123456789abcdef for PROJECT FALCON, not blueXharbor")
+	if len(result.Findings) != 2 {
+		t.Fatalf("findings = %+v, want 2", result.Findings)
+	}
+	byName := map[string]ScanFinding{}
+	for _, f := range result.Findings {
+		byName[f.PatternName] = f
+	}
+	if f := byName["acme_token"]; f.Severity != DetectorSeverityCritical || f.PatternCategory != "secret" {
+		t.Errorf("acme_token finding = %+v", f)
+	}
+	if f := byName["codenames"]; f.Severity != DetectorSeverityWarning || f.PatternCategory != "custom" || f.MatchedText != "PROJECT FALCON" {
+		t.Errorf("codenames finding = %+v, want defaults and a case-insensitive match", f)
+	}
+
+	out, _ := s.Redact("token acme_0123456789abcdef0123456789abcdef")
+	if out != "token [REDACTED-SECRET]" {
+		t.Errorf("Redact() = %q", out)
+	}
+
+	// Built-in findings carry no severity.
+	if f := s.Scan("<|im_start|>").Findings; len(f) != 1 || f[0].Severity != "" {
+		t.Errorf("built-in findings = %+v", f)
+	}
+
+	if got := s.CustomDetectors(); len(got) != 2 || got[1].Severity != DetectorSeverityWarning {
+		t.Errorf("CustomDetectors() = %+v", got)
+	}
+	if err := s.SetCustomDetectors(nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Scan("PROJECT FALCON").Findings) != 0 {
+		t.Error("detectors still active after clearing them")
+	}
+}
+
+func TestResponseScanner_SetCustomDetectorsErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		detectors []CustomDetector
+		wantErr   string
+	}{
+		{"bad name", []CustomDetector{{Name: "Acme Token", Pattern: "x"}}, "name"},
+		{"built-in name", []CustomDetector{{Name: "role_hijack", Pattern: "x"}}, "already used"},
+		{"duplicate", []CustomDetector{{Name: "a", Pattern: "x"}, {Name: "a", Keywords: []string{"y"}}}, "already used"},
+		{"both", []CustomDetector{{Name: "a", Pattern: "x", Keywords: []string{"y"}}}, "not both"},
+		{"neither", []CustomDetector{{Name: "a"}}, "required"},
+		{"invalid regex", []CustomDetector{{Name: "a", Pattern: "(x"}}, "invalid pattern"},
+		{"empty match", []CustomDetector{{Name: "a", Pattern: "x*"}}, "empty content"},
+		{"blank keyword", []CustomDetector{{Name: "a", Keywords: []string{"x", " "}}}, "empty"},
+		{"severity", []CustomDetector{{Name: "a", Pattern: "x", Severity: "high"}}, "severity"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewResponseScanner()
+			if err := s.SetCustomDetectors([]CustomDetector{{Name: "kept", Pattern: "kept"}}); err != nil {
+				t.Fatal(err)
+			}
+			err := s.SetCustomDetectors(tt.detectors)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+			if got := s.CustomDetectors(); len(got) != 1 || got[0].Name != "kept" {
+				t.Errorf("detectors after a failed update = %+v, want the previous set", got)
+			}
+		})
+	}
+}
+
+func TestCustomDetector_Match(t *testing.T) {
+	d := CustomDetector{Name: "ticket", Pattern: `INC-\d{6}`}
+	findings, err := d.Match("see INC-123456 and INC-654321")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 2 || findings[1].Position != 19 {
+		t.Errorf("findings = %+v", findings)
+	}
+	if _, err := (CustomDetector{Name: "ticket"}).Match("x"); err == nil {
+		t.Error("expected an error for a detector without pattern")
+	}
+}
+
+func TestFindingsSeverity(t *testing.T) {
+	tests := []struct {
+		severities []string
+		want       string
+	}{
+		{[]string{""}, "warning"},
+		{[]string{DetectorSeverityInfo}, "info"},
+		{[]string{DetectorSeverityInfo, ""}, "warning"},
+		{[]string{DetectorSeverityInfo, DetectorSeverityCritical}, "critical"},
+	}
+	for _, tt := range tests {
+		findings := make([]ScanFinding, len(tt.severities))
+		for i, s := range tt.severities {
+			findings[i].Severity = s
+		}
+		if got := findingsSeverity(findings).String(); got != tt.want {
+			t.Errorf("findingsSeverity(%v) = %q, want %q", tt.severities, got, tt.want)
+		}
+	}
+}
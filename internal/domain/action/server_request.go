@@ -0,0 +1,210 @@
+package action
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/proxy"
+	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
+)
+
+// ErrNoClientChannel is returned when a request from an upstream cannot be
+// sent to the client: the transport cannot carry server-initiated requests.
+var ErrNoClientChannel = errors.New("client cannot receive requests")
+
+// promptFields are the sampling/createMessage params covered by the prompt
+// hash and left out of audit records.
+var promptFields = []string{"messages", "systemPrompt"}
+
+// ServerRequestGate handles the requests upstreams send to clients
+// (sampling/createMessage, elicitation/create). Each request runs through
+// an action chain, typically policy evaluation, approval and a
+// ClientRequestInterceptor, so server-initiated LLM calls can be denied or
+// held for approval, and is audited with the hash of its prompt.
+type ServerRequestGate struct {
+	normalizer *MCPNormalizer
+	next       ActionInterceptor
+	recorder   proxy.AuditRecorder
+	logger     *slog.Logger
+}
+
+// Compile-time check that ServerRequestGate implements proxy.ServerRequestHandler.
+var _ proxy.ServerRequestHandler = (*ServerRequestGate)(nil)
+
+// NewServerRequestGate creates a gate passing requests to next. recorder may
+// be nil to skip auditing.
+func NewServerRequestGate(next ActionInterceptor, recorder proxy.AuditRecorder, logger *slog.Logger) *ServerRequestGate {
+	return &ServerRequestGate{
+		normalizer: NewMCPNormalizer(),
+		next:       next,
+		recorder:   recorder,
+		logger:     logger,
+	}
+}
+
+// HandleServerRequest evaluates a request an upstream sent while inFlight
+// was being handled, on behalf of inFlight's session, and returns the
+// client's response or a JSON-RPC error for the upstream.
+func (g *ServerRequestGate) HandleServerRequest(ctx context.Context, upstreamID string, inFlight *mcp.Message, req []byte) []byte {
+	decoded, err := mcp.DecodeMessage(req)
+	if err != nil {
+		return proxy.CreateJSONRPCError(nil, -32600, "Invalid Request")
+	}
+	msg := &mcp.Message{
+		Raw:       req,
+		Direction: mcp.ServerToClient,
+		Decoded:   decoded,
+		Timestamp: time.Now(),
+		Session:   inFlight.Session,
+	}
+	id := msg.RawID()
+	act, err := g.normalizer.Normalize(ctx, msg)
+	if err != nil {
+		return proxy.CreateJSONRPCError(id, -32600, "Invalid Request")
+	}
+	if act.Type != ActionSampling && act.Type != ActionElicitation {
+		return proxy.CreateJSONRPCError(id, int(proxy.ErrCodeMethodNotFound), "Method not found: "+msg.Method())
+	}
+
+	startTime := time.Now()
+	ctx, policyHolder := audit.NewPolicyDecisionContext(ctx)
+	result, err := g.next.Intercept(ctx, act)
+	g.record(act, startTime, err, policyHolder)
+	if err != nil {
+		g.logger.Info("upstream request to client refused",
+			"method", act.Name,
+			"upstream", upstreamID,
+			"session_id", act.Identity.SessionID,
+			"error", err,
+		)
+		return proxy.CreateJSONRPCError(id, -32600, proxy.SafeErrorMessage(err))
+	}
+	resp, ok := result.OriginalMessage.(*mcp.Message)
+	if !ok || resp == nil || len(resp.Raw) == 0 {
+		return proxy.CreateJSONRPCError(id, -32603, "Internal error")
+	}
+	return resp.Raw
+}
+
+// record writes the audit record of a request from an upstream.
+func (g *ServerRequestGate) record(act *CanonicalAction, startTime time.Time, err error, policyHolder *audit.PolicyDecisionHolder) {
+	if g.recorder == nil {
+		return
+	}
+	record := audit.AuditRecord{
+		Timestamp:     startTime,
+		LatencyMicros: time.Since(startTime).Microseconds(),
+		Protocol:      act.Protocol,
+		ToolName:      act.Name,
+		RequestID:     act.RequestID,
+		SessionID:     "anonymous",
+		IdentityID:    "anonymous",
+		Decision:      audit.DecisionAllow,
+	}
+	if act.Identity.SessionID != "" {
+		record.SessionID = act.Identity.SessionID
+		record.IdentityID = act.Identity.ID
+		record.IdentityName = act.Identity.Name
+		record.Roles = act.Identity.Roles
+		record.SessionLabels = act.Identity.Labels
+	}
+	if err != nil {
+		record.Decision = audit.DecisionDeny
+		record.Reason = err.Error()
+	}
+	if policyHolder != nil {
+		record.RuleID = policyHolder.RuleID
+		record.PolicyDecisionID = policyHolder.DecisionID
+	}
+	// The prompt itself is not recorded, only its hash.
+	args := make(map[string]interface{}, len(act.Arguments))
+	for k, v := range act.Arguments {
+		args[k] = v
+	}
+	if act.Type == ActionSampling {
+		record.PromptHash = samplingPromptHash(act.Arguments)
+		for _, f := range promptFields {
+			delete(args, f)
+		}
+	}
+	record.ToolArguments = audit.RedactSensitiveArgs(args)
+	g.recorder.Record(record)
+}
+
+// samplingPromptHash returns the hex SHA-256 of the messages and system
+// prompt of sampling/createMessage params. Maps marshal with sorted keys,
+// so the hash does not depend on the upstream's field order or spacing.
+func samplingPromptHash(params map[string]interface{}) string {
+	prompt := make(map[string]interface{}, len(promptFields))
+	for _, f := range promptFields {
+		if v, ok := params[f]; ok {
+			prompt[f] = v
+		}
+	}
+	data, err := json.Marshal(prompt)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ClientRequestInterceptor ends the chain of a ServerRequestGate: it sends
+// the request to the client session of the action and returns the client's
+// response as the action's OriginalMessage.
+type ClientRequestInterceptor struct {
+	mu        sync.RWMutex
+	requester proxy.ClientRequester
+}
+
+// Compile-time check that ClientRequestInterceptor implements ActionInterceptor.
+var _ ActionInterceptor = (*ClientRequestInterceptor)(nil)
+
+// NewClientRequestInterceptor creates the interceptor. Requests fail with
+// ErrNoClientChannel until a requester is set.
+func NewClientRequestInterceptor() *ClientRequestInterceptor {
+	return &ClientRequestInterceptor{}
+}
+
+// SetClientRequester sets the transport that delivers requests to clients.
+func (c *ClientRequestInterceptor) SetClientRequester(r proxy.ClientRequester) {
+	c.mu.Lock()
+	c.requester = r
+	c.mu.Unlock()
+}
+
+// Intercept sends the request to the client and waits for the response.
+func (c *ClientRequestInterceptor) Intercept(ctx context.Context, act *CanonicalAction) (*CanonicalAction, error) {
+	msg, ok := act.OriginalMessage.(*mcp.Message)
+	if !ok || msg == nil {
+		return nil, fmt.Errorf("ClientRequestInterceptor: expected *mcp.Message, got %T", act.OriginalMessage)
+	}
+	if act.Identity.SessionID == "" {
+		return nil, proxy.ErrMissingSession
+	}
+	c.mu.RLock()
+	requester := c.requester
+	c.mu.RUnlock()
+	if requester == nil {
+		return nil, ErrNoClientChannel
+	}
+	resp, err := requester.RequestClient(ctx, act.Identity.SessionID, msg.Raw)
+	if err != nil {
+		return nil, err
+	}
+	act.OriginalMessage = &mcp.Message{
+		Raw:       resp,
+		Direction: mcp.ClientToServer,
+		Timestamp: time.Now(),
+		Session:   msg.Session,
+	}
+	return act, nil
+}
@@ -0,0 +1,141 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
+)
+
+// stubClientRequester answers every request with a fixed result.
+type stubClientRequester struct {
+	sessionID string
+	req       []byte
+	err       error
+}
+
+func (s *stubClientRequester) RequestClient(_ context.Context, sessionID string, req []byte) ([]byte, error) {
+	s.sessionID, s.req = sessionID, req
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []byte(`{"jsonrpc":"2.0","id":7,"result":{"role":"assistant","content":{"type":"text","text":"hi"}}}`), nil
+}
+
+const samplingRequest = `{"jsonrpc":"2.0","id":7,"method":"sampling/createMessage","params":{"messages":[{"role":"user","content":{"type":"text","text":"secret prompt"}}],"systemPrompt":"be brief","maxTokens":100}}`
+
+func TestServerRequestGate_ForwardsToClient(t *testing.T) {
+	requester := &stubClientRequester{}
+	client := NewClientRequestInterceptor()
+	client.SetClientRequester(requester)
+	recorder := &stubRecorder{}
+	gate := NewServerRequestGate(client, recorder, newTestLogger())
+
+	inFlight := &mcp.Message{Session: testSession()}
+	resp := gate.HandleServerRequest(context.Background(), "up-1", inFlight, []byte(samplingRequest))
+
+	if !strings.Contains(string(resp), `"result"`) {
+		t.Fatalf("response = %s, want the client's result", resp)
+	}
+	if requester.sessionID != "sess-123" {
+		t.Errorf("requested session = %q, want sess-123", requester.sessionID)
+	}
+	records := recorder.getRecords()
+	if len(records) != 1 {
+		t.Fatalf("got %d audit records, want 1", len(records))
+	}
+	rec := records[0]
+	if rec.Decision != audit.DecisionAllow || rec.ToolName != "sampling/createMessage" {
+		t.Errorf("record = %+v, want allowed sampling/createMessage", rec)
+	}
+	if rec.PromptHash == "" || len(rec.PromptHash) != 64 {
+		t.Errorf("PromptHash = %q, want a hex SHA-256", rec.PromptHash)
+	}
+	if _, ok := rec.ToolArguments["messages"]; ok {
+		t.Error("audit record contains the prompt messages")
+	}
+	if _, ok := rec.ToolArguments["maxTokens"]; !ok {
+		t.Error("audit record lost the non-prompt arguments")
+	}
+}
+
+func TestServerRequestGate_Denied(t *testing.T) {
+	next := &denyingInterceptor{denied: map[string]bool{"sampling/createMessage": true}}
+	recorder := &stubRecorder{}
+	gate := NewServerRequestGate(next, recorder, newTestLogger())
+
+	resp := gate.HandleServerRequest(context.Background(), "up-1", &mcp.Message{Session: testSession()}, []byte(samplingRequest))
+
+	var out struct {
+		ID    int `json:"id"`
+		Error *struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(resp, &out); err != nil {
+		t.Fatalf("invalid response %s: %v", resp, err)
+	}
+	if out.ID != 7 || out.Error == nil || out.Error.Code != -32600 {
+		t.Errorf("response = %s, want error -32600 for id 7", resp)
+	}
+	records := recorder.getRecords()
+	if len(records) != 1 || records[0].Decision != audit.DecisionDeny {
+		t.Fatalf("records = %+v, want one deny", records)
+	}
+	if records[0].PromptHash == "" {
+		t.Error("denied sampling request has no prompt hash")
+	}
+}
+
+func TestServerRequestGate_UnsupportedMethod(t *testing.T) {
+	gate := NewServerRequestGate(NewClientRequestInterceptor(), nil, newTestLogger())
+	req := `{"jsonrpc":"2.0","id":"r1","method":"roots/list"}`
+
+	resp := gate.HandleServerRequest(context.Background(), "up-1", &mcp.Message{Session: testSession()}, []byte(req))
+
+	if !strings.Contains(string(resp), "-32601") || !strings.Contains(string(resp), `"r1"`) {
+		t.Errorf("response = %s, want method not found for id r1", resp)
+	}
+}
+
+func TestClientRequestInterceptor_NoChannel(t *testing.T) {
+	gate := NewServerRequestGate(NewClientRequestInterceptor(), nil, newTestLogger())
+
+	resp := gate.HandleServerRequest(context.Background(), "up-1", &mcp.Message{Session: testSession()}, []byte(samplingRequest))
+
+	if !strings.Contains(string(resp), `"error"`) {
+		t.Errorf("response = %s, want an error without a client channel", resp)
+	}
+}
+
+func TestClientRequestInterceptor_RequesterError(t *testing.T) {
+	client := NewClientRequestInterceptor()
+	client.SetClientRequester(&stubClientRequester{err: errors.New("client gone")})
+	recorder := &stubRecorder{}
+	gate := NewServerRequestGate(client, recorder, newTestLogger())
+
+	resp := gate.HandleServerRequest(context.Background(), "up-1", &mcp.Message{Session: testSession()}, []byte(samplingRequest))
+
+	if !strings.Contains(string(resp), `"error"`) {
+		t.Errorf("response = %s, want an error", resp)
+	}
+	if records := recorder.getRecords(); len(records) != 1 || records[0].Decision != audit.DecisionDeny {
+		t.Errorf("records = %+v, want one failed request", records)
+	}
+}
+
+func TestSamplingPromptHash_IgnoresOtherParams(t *testing.T) {
+	a := map[string]interface{}{"messages": []interface{}{"x"}, "systemPrompt": "s", "maxTokens": 10}
+	b := map[string]interface{}{"systemPrompt": "s", "messages": []interface{}{"x"}, "maxTokens": 500}
+	if samplingPromptHash(a) != samplingPromptHash(b) {
+		t.Error("prompt hash depends on non-prompt params")
+	}
+	c := map[string]interface{}{"messages": []interface{}{"y"}, "systemPrompt": "s"}
+	if samplingPromptHash(a) == samplingPromptHash(c) {
+		t.Error("different prompts have the same hash")
+	}
+}
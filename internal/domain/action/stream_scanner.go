@@ -0,0 +1,149 @@
+package action
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
+)
+
+// StreamLimits bound the memory and time spent scanning large payloads,
+// such as multi-MB file contents passed as a tool argument or returned in a
+// tool result. Content is scanned in windows of ChunkSize bytes, each
+// starting Overlap bytes before the end of the previous one so that
+// matches spanning two chunks are found.
+type StreamLimits struct {
+	// ChunkSize is the size in bytes of the scanned windows.
+	ChunkSize int
+	// Overlap is how many bytes consecutive windows share. Matches longer
+	// than Overlap that span two windows can be missed.
+	Overlap int
+	// MaxBytes caps the bytes scanned per payload; the rest is not scanned.
+	// Zero means no cap.
+	MaxBytes int64
+}
+
+// DefaultStreamLimits are the limits used when none are configured.
+var DefaultStreamLimits = StreamLimits{
+	ChunkSize: 256 * 1024,
+	Overlap:   4 * 1024,
+	MaxBytes:  32 * 1024 * 1024,
+}
+
+// valid reports whether l can be used to scan.
+func (l StreamLimits) valid() bool {
+	return l.ChunkSize > 0 && l.Overlap >= 0 && l.Overlap < l.ChunkSize &&
+		(l.MaxBytes == 0 || l.MaxBytes >= int64(l.ChunkSize))
+}
+
+// orDefault returns l, or DefaultStreamLimits when l is not valid.
+func (l StreamLimits) orDefault() StreamLimits {
+	if !l.valid() {
+		return DefaultStreamLimits
+	}
+	return l
+}
+
+// ChunkFunc scans window, which starts at offset in the payload. It must
+// report only the matches starting before limit: the following ones are
+// reported with the next window, which starts at limit.
+type ChunkFunc func(window string, offset int64, limit int)
+
+// StreamResult describes a streamed scan.
+type StreamResult struct {
+	// BytesScanned is how many bytes of the payload were scanned.
+	BytesScanned int64
+	// Truncated is true when the payload was larger than the MaxBytes cap.
+	Truncated bool
+}
+
+// ScanStream reads r window by window and calls fn for each window, so that
+// at most ChunkSize bytes of the payload are held at a time. Windows end on
+// UTF-8 character boundaries. Reading stops at the MaxBytes cap.
+func ScanStream(r io.Reader, limits StreamLimits, fn ChunkFunc) (StreamResult, error) {
+	limits = limits.orDefault()
+	var res StreamResult
+	if limits.MaxBytes > 0 {
+		r = io.LimitReader(r, limits.MaxBytes+1)
+	}
+
+	buf := make([]byte, limits.ChunkSize)
+	n := 0 // bytes in buf
+	var offset int64
+	eof := false
+	for !eof {
+		m, err := io.ReadFull(r, buf[n:])
+		n += m
+		switch {
+		case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+			eof = true
+		case err != nil:
+			return res, err
+		}
+		if limits.MaxBytes > 0 && offset+int64(n) > limits.MaxBytes {
+			n = int(limits.MaxBytes - offset)
+			res.Truncated = true
+			eof = true
+		}
+		if n == 0 {
+			break
+		}
+
+		limit := n
+		if !eof {
+			limit = runeBoundary(buf[:n], n-limits.Overlap)
+		}
+		fn(string(buf[:n]), offset, limit)
+		res.BytesScanned = offset + int64(n)
+
+		// The bytes from limit on start the next window.
+		copy(buf, buf[limit:n])
+		n -= limit
+		offset += int64(limit)
+	}
+	return res, nil
+}
+
+// runeBoundary returns the largest index at or before i, and after 0, that
+// does not split a UTF-8 encoded character of b.
+func runeBoundary(b []byte, i int) int {
+	for j := i; j > 0 && j > i-utf8.UTFMax; j-- {
+		if utf8.RuneStart(b[j]) {
+			return j
+		}
+	}
+	return i
+}
+
+// scanLarge runs fn over content in windows when content is longer than a
+// chunk, and over the whole of it otherwise. It returns whether content was
+// cut at the MaxBytes cap.
+func scanLarge(content string, limits StreamLimits, fn ChunkFunc) bool {
+	limits = limits.orDefault()
+	if len(content) <= limits.ChunkSize {
+		fn(content, 0, len(content))
+		return false
+	}
+	res, _ := ScanStream(strings.NewReader(content), limits, fn)
+	return res.Truncated
+}
+
+// PayloadBytes returns the size of the request of a, as exposed to policy
+// rules as payload_bytes: the JSON-RPC message of an MCP request, or the
+// declared body length of an HTTP request. It is 0 when unknown.
+func PayloadBytes(a *CanonicalAction) int64 {
+	switch msg := a.OriginalMessage.(type) {
+	case *mcp.Message:
+		if msg != nil {
+			return int64(len(msg.Raw))
+		}
+	case *http.Request:
+		if msg != nil && msg.ContentLength > 0 {
+			return msg.ContentLength
+		}
+	}
+	return 0
+}
@@ -0,0 +1,318 @@
+package action
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/event"
+)
+
+// Taint tracking defaults.
+const (
+	defaultTaintWindowSize      = 32
+	defaultTaintMaxFingerprints = 4096
+	defaultTaintIdleTTL         = 30 * time.Minute
+	// maxTaintValues caps the short flagged values kept per session.
+	maxTaintValues = 256
+	// minTaintValueLen is the shortest flagged value tracked on its own;
+	// shorter matches would taint ordinary words.
+	minTaintValueLen = 8
+	// taintHashBase is the multiplier of the rolling window hash.
+	taintHashBase = 1099511628211
+)
+
+// TaintConfig configures a TaintTracker.
+type TaintConfig struct {
+	// WindowSize is the length, in normalized bytes, of the fingerprinted
+	// windows. Content copied into an argument is detected once at least
+	// 1.5 windows of it reappear. Zero uses 32.
+	WindowSize int
+	// MaxFingerprints caps the windows kept per session; content flagged
+	// after the cap is reached is not tracked. Zero uses 4096.
+	MaxFingerprints int
+	// IdleTTL drops the fingerprints of sessions without activity for this
+	// long. Zero uses 30m.
+	IdleTTL time.Duration
+}
+
+// TaintMatch is the result of checking an action for tainted content.
+type TaintMatch struct {
+	// Tainted is true when flagged result content reappears in the action.
+	Tainted bool
+	// Sources are the tools whose flagged results reappear, sorted.
+	Sources []string
+}
+
+// sessionTaint holds the fingerprints of one session.
+type sessionTaint struct {
+	windows  map[uint64]string // window hash → source tool
+	values   map[string]string // short flagged value → source tool
+	lastSeen time.Time
+}
+
+// TaintTracker follows content that scanning flagged in tool results and
+// reports when it reappears in the arguments or destination of a later
+// action of the same session. This catches indirect exfiltration, where an
+// agent copies what one tool returned into the request of another.
+//
+// Flagged text is normalized (lower case, whitespace collapsed) and
+// fingerprinted as overlapping windows; the flagged matches themselves are
+// also kept when shorter than a window. Only hashes and short matches are
+// stored, never whole results.
+type TaintTracker struct {
+	cfg TaintConfig
+
+	mu        sync.Mutex
+	sessions  map[string]*sessionTaint
+	lastSweep time.Time
+	eventBus  event.Bus
+}
+
+// NewTaintTracker creates a tracker. Zero config fields use their defaults.
+func NewTaintTracker(cfg TaintConfig) *TaintTracker {
+	if cfg.WindowSize <= 0 {
+		cfg.WindowSize = defaultTaintWindowSize
+	}
+	if cfg.MaxFingerprints <= 0 {
+		cfg.MaxFingerprints = defaultTaintMaxFingerprints
+	}
+	if cfg.IdleTTL <= 0 {
+		cfg.IdleTTL = defaultTaintIdleTTL
+	}
+	return &TaintTracker{cfg: cfg, sessions: make(map[string]*sessionTaint), lastSweep: time.Now()}
+}
+
+// SetEventBus sets the bus receiving content.tainted_argument events.
+func (t *TaintTracker) SetEventBus(bus event.Bus) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.eventBus = bus
+}
+
+// Record fingerprints flagged result content of sourceTool. matches are
+// the flagged substrings reported by the scanner.
+func (t *TaintTracker) Record(sessionID, sourceTool, content string, matches []string) {
+	if sessionID == "" || content == "" {
+		return
+	}
+	norm := normalizeTaintText(content)
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sweepLocked(now)
+	st := t.sessions[sessionID]
+	if st == nil {
+		st = &sessionTaint{windows: make(map[uint64]string), values: make(map[string]string)}
+		t.sessions[sessionID] = st
+	}
+	st.lastSeen = now
+
+	// Windows start every half window, so any copied run of 1.5 windows
+	// fully contains at least one of them.
+	stride := max(t.cfg.WindowSize/2, 1)
+	forEachWindowHash(norm, t.cfg.WindowSize, func(offset int, h uint64) bool {
+		if offset%stride != 0 {
+			return true
+		}
+		if len(st.windows) >= t.cfg.MaxFingerprints {
+			return false
+		}
+		if _, ok := st.windows[h]; !ok {
+			st.windows[h] = sourceTool
+		}
+		return true
+	})
+
+	short := matches
+	if len(norm) < t.cfg.WindowSize {
+		short = append(short[:len(short):len(short)], content)
+	}
+	for _, m := range short {
+		v := normalizeTaintText(m)
+		if len(v) < minTaintValueLen || len(v) >= t.cfg.WindowSize || len(st.values) >= maxTaintValues {
+			continue
+		}
+		if _, ok := st.values[v]; !ok {
+			st.values[v] = sourceTool
+		}
+	}
+}
+
+// Check reports whether flagged content of the session reappears in the
+// arguments or destination of a. A match publishes a
+// content.tainted_argument event.
+func (t *TaintTracker) Check(ctx context.Context, a *CanonicalAction) TaintMatch {
+	sessionID := a.Identity.SessionID
+	t.mu.Lock()
+	st := t.sessions[sessionID]
+	if st == nil {
+		t.mu.Unlock()
+		return TaintMatch{}
+	}
+	st.lastSeen = time.Now()
+
+	sources := make(map[string]bool)
+	for _, text := range taintCandidateTexts(a) {
+		norm := normalizeTaintText(text)
+		forEachWindowHash(norm, t.cfg.WindowSize, func(_ int, h uint64) bool {
+			if src, ok := st.windows[h]; ok {
+				sources[src] = true
+			}
+			return true
+		})
+		for v, src := range st.values {
+			if !sources[src] && strings.Contains(norm, v) {
+				sources[src] = true
+			}
+		}
+	}
+	bus := t.eventBus
+	t.mu.Unlock()
+
+	if len(sources) == 0 {
+		return TaintMatch{}
+	}
+	match := TaintMatch{Tainted: true, Sources: make([]string, 0, len(sources))}
+	for src := range sources {
+		match.Sources = append(match.Sources, src)
+	}
+	sort.Strings(match.Sources)
+
+	if bus != nil {
+		bus.Publish(ctx, event.Event{
+			Type:     "content.tainted_argument",
+			Source:   "taint-tracker",
+			Severity: event.SeverityWarning,
+			Payload: map[string]interface{}{
+				"tool":          a.Name,
+				"action_type":   string(a.Type),
+				"identity_id":   a.Identity.ID,
+				"identity_name": a.Identity.Name,
+				"session_id":    sessionID,
+				"sources":       strings.Join(match.Sources, ", "),
+				"destination":   a.Destination.Domain,
+			},
+		})
+	}
+	return match
+}
+
+// ClearSession drops the fingerprints of a session.
+func (t *TaintTracker) ClearSession(sessionID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.sessions, sessionID)
+}
+
+// Sessions returns the number of sessions with tracked content.
+func (t *TaintTracker) Sessions() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.sessions)
+}
+
+// sweepLocked drops idle sessions, at most once per half TTL.
+func (t *TaintTracker) sweepLocked(now time.Time) {
+	if now.Sub(t.lastSweep) < t.cfg.IdleTTL/2 {
+		return
+	}
+	t.lastSweep = now
+	for id, st := range t.sessions {
+		if now.Sub(st.lastSeen) > t.cfg.IdleTTL {
+			delete(t.sessions, id)
+		}
+	}
+}
+
+// taintCandidateTexts returns the strings of an action that may carry
+// copied content: every string argument, nested ones included, and the
+// destination URLs. Percent-encoded strings are also checked decoded.
+func taintCandidateTexts(a *CanonicalAction) []string {
+	var out []string
+	add := func(s string) {
+		if s == "" {
+			return
+		}
+		out = append(out, s)
+		if strings.Contains(s, "%") || strings.Contains(s, "+") {
+			if dec, err := url.QueryUnescape(s); err == nil && dec != s {
+				out = append(out, dec)
+			}
+		}
+	}
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch val := v.(type) {
+		case string:
+			add(val)
+		case map[string]interface{}:
+			for _, child := range val {
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range val {
+				walk(child)
+			}
+		}
+	}
+	walk(a.Arguments)
+	add(a.Destination.URL)
+	for _, u := range a.Destination.URLs {
+		if u != a.Destination.URL {
+			add(u)
+		}
+	}
+	return out
+}
+
+// normalizeTaintText lowercases s and collapses whitespace runs into one
+// space, so reformatted copies still match.
+func normalizeTaintText(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	space := false
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			space = b.Len() > 0
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// forEachWindowHash calls fn with the rolling hash of every size-byte
+// window of s until fn returns false.
+func forEachWindowHash(s string, size int, fn func(offset int, h uint64) bool) {
+	if len(s) < size {
+		return
+	}
+	// pow is base^(size-1), the weight of the byte leaving the window.
+	pow := uint64(1)
+	for i := 1; i < size; i++ {
+		pow *= taintHashBase
+	}
+	var h uint64
+	for i := 0; i < size; i++ {
+		h = h*taintHashBase + uint64(s[i])
+	}
+	if !fn(0, h) {
+		return
+	}
+	for i := size; i < len(s); i++ {
+		h = (h-uint64(s[i-size])*pow)*taintHashBase + uint64(s[i])
+		if !fn(i-size+1, h) {
+			return
+		}
+	}
+}
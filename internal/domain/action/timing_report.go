@@ -0,0 +1,59 @@
+package action
+
+import (
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/timing"
+)
+
+// TimingMetaKey is the key under result._meta that carries the latency
+// breakdown when meta reporting is enabled.
+const TimingMetaKey = "sentinelgate/timing"
+
+// TimingReport selects whose requests report their latency breakdown and
+// whether it is attached to results.
+type TimingReport struct {
+	// Meta stores the breakdown under result._meta[TimingMetaKey] of
+	// successful responses.
+	Meta bool
+	// Identities limits the breakdown to these identity IDs or names.
+	// Empty means every identity.
+	Identities []string
+}
+
+// appliesTo reports whether requests of identity get the breakdown.
+// Unauthenticated requests never do.
+func (r *TimingReport) appliesTo(identity ActionIdentity) bool {
+	if identity.ID == "" {
+		return false
+	}
+	if len(r.Identities) == 0 {
+		return true
+	}
+	for _, want := range r.Identities {
+		if want == identity.ID || want == identity.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// reportTiming finishes the breakdown of a request and, if the identity gets
+// it, marks it for the transport and attaches it to a successful response.
+func (r *TimingReport) reportTiming(b *timing.Breakdown, act, result *CanonicalAction) {
+	b.Finish()
+	identity := act.Identity
+	if result != nil && result.Identity.ID != "" {
+		identity = result.Identity
+	}
+	if !r.appliesTo(identity) {
+		return
+	}
+	b.SetReported(true)
+	if !r.Meta {
+		return
+	}
+	if msg := successfulToolResult(result); msg != nil {
+		if raw, ok := withResultMeta(msg.Raw, TimingMetaKey, b.Summary()); ok {
+			msg.Raw = raw
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package action
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/proxy"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/toolstats"
+	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
+)
+
+// ToolStatsRecorder records per-tool call statistics.
+type ToolStatsRecorder interface {
+	RecordToolCall(tool string, outcome toolstats.Outcome, latency time.Duration)
+}
+
+// toolCallOutcome classifies a tool call from the interceptor chain result.
+// Errors other than internal ones are deliberate rejections (policy, quota,
+// rate limit, scanning); JSON-RPC errors and isError results are failures of
+// the tool itself.
+func toolCallOutcome(result *CanonicalAction, err error) toolstats.Outcome {
+	if err != nil {
+		if proxy.IsInternalError(err) {
+			return toolstats.OutcomeError
+		}
+		return toolstats.OutcomeDenied
+	}
+	if result == nil {
+		return toolstats.OutcomeOK
+	}
+	msg, ok := result.OriginalMessage.(*mcp.Message)
+	if !ok || msg == nil || msg.Direction != mcp.ServerToClient || msg.Raw == nil {
+		return toolstats.OutcomeOK
+	}
+	var envelope struct {
+		Result struct {
+			IsError bool `json:"isError"`
+		} `json:"result"`
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(msg.Raw, &envelope) != nil {
+		return toolstats.OutcomeOK
+	}
+	if len(envelope.Error) > 0 && string(envelope.Error) != "null" || envelope.Result.IsError {
+		return toolstats.OutcomeError
+	}
+	return toolstats.OutcomeOK
+}
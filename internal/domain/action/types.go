@@ -26,6 +26,8 @@ const (
 	ActionSampling ActionType = "sampling"
 	// ActionElicitation represents an MCP elicitation/create request.
 	ActionElicitation ActionType = "elicitation"
+	// ActionResourceSubscribe represents an MCP resources/subscribe request.
+	ActionResourceSubscribe ActionType = "resource_subscribe"
 	// ActionProtocol represents MCP protocol methods (initialize, ping, tools/list,
 	// notifications/*, etc.) that are NOT subject to policy evaluation.
 	ActionProtocol ActionType = "protocol"
@@ -55,6 +57,17 @@ type Destination struct {
 	Command string
 	// CmdArgs are the command arguments for command_exec actions.
 	CmdArgs []string
+	// URLs lists every destination found in tool call arguments; URL and
+	// the fields above describe the first one.
+	URLs []string
+	// Domains lists the distinct domains of URLs.
+	Domains []string
+	// Country is the ISO 3166-1 alpha-2 country of IP, when located.
+	Country string
+	// ASN is the autonomous system number of IP, 0 when not located.
+	ASN uint
+	// ASNOrg is the organization of the autonomous system.
+	ASNOrg string
 }
 
 // ActionIdentity represents the WHO of an action: the actor performing it.
@@ -67,6 +80,8 @@ type ActionIdentity struct {
 	Roles []string
 	// SessionID is the session identifier for the actor.
 	SessionID string
+	// Labels are the session labels (see session.Session.Labels).
+	Labels map[string]string
 }
 
 // CanonicalAction is the universal representation of any agent action.
@@ -107,6 +122,9 @@ type CanonicalAction struct {
 	RequestTime time.Time
 	// RequestID uniquely identifies this action request.
 	RequestID string
+	// CorrelationID identifies the trace the request belongs to, across
+	// gateways. It comes from the client or is generated on arrival.
+	CorrelationID string
 	// Metadata is an extensible bag for protocol-specific data.
 	Metadata map[string]interface{}
 
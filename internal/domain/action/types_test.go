@@ -17,6 +17,7 @@ func TestActionType_String(t *testing.T) {
 		{ActionNetworkConnect, "network_connect"},
 		{ActionSampling, "sampling"},
 		{ActionElicitation, "elicitation"},
+		{ActionResourceSubscribe, "resource_subscribe"},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,431 @@
+package action
+
+import (
+	"net"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultURLMaxDepth is the default nesting depth searched for URLs.
+const DefaultURLMaxDepth = 8
+
+// URL extraction sources, recorded on each ExtractedURL.
+const (
+	// URLSourceScheme is a URL with an explicit scheme ("https://...").
+	URLSourceScheme = "url"
+	// URLSourceKey is a host-like value of a key such as "host" or "endpoint".
+	URLSourceKey = "key"
+	// URLSourceDomain is a bare domain found in free text.
+	URLSourceDomain = "domain"
+	// URLSourceCommand is a host argument of a network command (curl, ssh, ...).
+	URLSourceCommand = "command"
+)
+
+// ExtractedURL is a destination found in tool call arguments.
+type ExtractedURL struct {
+	// URL is the destination as a URL. Bare hosts get an "https" or
+	// command-specific scheme.
+	URL    string
+	Scheme string
+	Domain string
+	Port   int
+	Path   string
+	// Field is the dotted argument path the value was found under
+	// (e.g. "request.uri"); array elements share their parent's path.
+	Field string
+	// Source is how the destination was recognized (URLSource* constants).
+	Source string
+}
+
+// ToolURLHint narrows URL extraction for one tool.
+type ToolURLHint struct {
+	// Fields limits the search to these dotted argument paths and their
+	// children. Empty means all arguments.
+	Fields []string
+	// Ignore skips these dotted argument paths and their children.
+	Ignore []string
+	// Disabled turns extraction off for the tool.
+	Disabled bool
+}
+
+// URLExtractionConfig configures a URLExtractor.
+type URLExtractionConfig struct {
+	// MaxDepth is how many levels of nested objects and arrays are searched.
+	// Zero uses DefaultURLMaxDepth.
+	MaxDepth int
+	// BareDomains enables detection of scheme-less domains ("evil.com/x")
+	// in free text. Only well-known TLDs that do not collide with common
+	// file extensions are recognized.
+	BareDomains bool
+	// CommandStrings enables host detection in shell command strings for
+	// known network clients (curl, wget, ssh, scp, git, nc, ...).
+	CommandStrings bool
+	// Tools holds per-tool hints keyed by tool name or glob pattern.
+	// An exact name takes precedence over patterns.
+	Tools map[string]ToolURLHint
+}
+
+// URLExtractor finds destination URLs in tool call arguments, including
+// nested values, bare domains and command-line strings.
+// It is safe for concurrent use.
+type URLExtractor struct {
+	cfg URLExtractionConfig
+}
+
+// NewURLExtractor creates a URLExtractor.
+func NewURLExtractor(cfg URLExtractionConfig) *URLExtractor {
+	if cfg.MaxDepth <= 0 {
+		cfg.MaxDepth = DefaultURLMaxDepth
+	}
+	return &URLExtractor{cfg: cfg}
+}
+
+var (
+	schemeURLRegexp  = regexp.MustCompile(`(?i)\b(?:https?|wss?|ftps?|sftp|ssh|git|file)://[^\s"'<>` + "`" + `\\]+`)
+	bareDomainRegexp = regexp.MustCompile(`(?i)(?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+([a-z]{2,24})(?::\d{1,5})?(?:/[^\s"'<>` + "`" + `\\]*)?`)
+)
+
+// hostKeys are argument names whose whole value is taken as a destination.
+var hostKeys = map[string]bool{
+	"url": true, "uri": true, "href": true, "link": true, "endpoint": true,
+	"host": true, "hostname": true, "domain": true, "server": true,
+	"base_url": true, "baseurl": true, "webhook": true, "webhook_url": true,
+}
+
+// bareDomainTLDs are the TLDs recognized for bare domains. Country codes
+// that double as file extensions (py, sh, md, rs, pl, cc, so, ps, zip, mov)
+// are deliberately left out.
+var bareDomainTLDs = map[string]bool{
+	"com": true, "net": true, "org": true, "edu": true, "gov": true, "mil": true, "int": true,
+	"io": true, "ai": true, "co": true, "app": true, "dev": true, "cloud": true, "info": true,
+	"biz": true, "me": true, "tv": true, "xyz": true, "site": true, "online": true, "tech": true,
+	"store": true, "page": true, "link": true, "ly": true, "gg": true, "to": true, "top": true,
+	"us": true, "uk": true, "de": true, "fr": true, "it": true, "es": true, "nl": true, "eu": true,
+	"ca": true, "au": true, "jp": true, "cn": true, "ru": true, "br": true, "in": true, "ch": true,
+	"se": true, "no": true, "fi": true, "dk": true, "at": true, "be": true, "ie": true, "nz": true,
+	"kr": true, "tw": true, "hk": true, "sg": true, "za": true, "mx": true, "ar": true, "ua": true,
+}
+
+// networkCommands are command-line clients whose host arguments are
+// destinations, with the scheme given to bare hosts.
+var networkCommands = map[string]string{
+	"curl": "https", "wget": "https", "http": "http", "https": "https", "xh": "https",
+	"ssh": "ssh", "scp": "ssh", "sftp": "sftp", "rsync": "ssh", "git": "ssh",
+	"nc": "tcp", "ncat": "tcp", "netcat": "tcp", "telnet": "telnet", "ftp": "ftp",
+}
+
+// Extract returns the destinations found in args for the given tool, in a
+// stable order (sorted argument keys, depth first), without duplicates.
+func (e *URLExtractor) Extract(toolName string, args map[string]interface{}) []ExtractedURL {
+	if e == nil || len(args) == 0 {
+		return nil
+	}
+	hint, _ := e.hintFor(toolName)
+	if hint.Disabled {
+		return nil
+	}
+	w := &urlWalker{e: e, hint: hint, seen: make(map[string]bool)}
+	w.walkMap(args, "", 1)
+	return w.out
+}
+
+// hintFor returns the hint for a tool: an exact match, else the first
+// matching glob pattern in sorted order.
+func (e *URLExtractor) hintFor(toolName string) (ToolURLHint, bool) {
+	if h, ok := e.cfg.Tools[toolName]; ok {
+		return h, true
+	}
+	patterns := make([]string, 0, len(e.cfg.Tools))
+	for p := range e.cfg.Tools {
+		patterns = append(patterns, p)
+	}
+	sort.Strings(patterns)
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, toolName); ok {
+			return e.cfg.Tools[p], true
+		}
+	}
+	return ToolURLHint{}, false
+}
+
+type urlWalker struct {
+	e    *URLExtractor
+	hint ToolURLHint
+	seen map[string]bool
+	out  []ExtractedURL
+}
+
+func (w *urlWalker) walkMap(m map[string]interface{}, prefix string, depth int) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		field := k
+		if prefix != "" {
+			field = prefix + "." + k
+		}
+		w.walk(m[k], field, k, depth)
+	}
+}
+
+func (w *urlWalker) walk(v interface{}, field, key string, depth int) {
+	if depth > w.e.cfg.MaxDepth || pathListed(w.hint.Ignore, field) {
+		return
+	}
+	switch val := v.(type) {
+	case map[string]interface{}:
+		w.walkMap(val, field, depth+1)
+	case []interface{}:
+		for _, item := range val {
+			w.walk(item, field, key, depth+1)
+		}
+	case string:
+		if len(w.hint.Fields) > 0 && !pathListed(w.hint.Fields, field) {
+			return
+		}
+		w.scanString(val, field, key)
+	}
+}
+
+// pathListed reports whether field is one of paths or nested under one.
+func pathListed(paths []string, field string) bool {
+	for _, p := range paths {
+		if field == p || strings.HasPrefix(field, p+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *urlWalker) scanString(s, field, key string) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return
+	}
+	for _, m := range schemeURLRegexp.FindAllString(s, -1) {
+		w.add(strings.TrimRight(m, ".,;:!?)]}'\""), "", field, URLSourceScheme)
+	}
+	if hostKeys[strings.ToLower(key)] && !strings.ContainsAny(s, " \t\n") && !strings.Contains(s, "://") {
+		if isHostLike(s) {
+			w.add(s, "https", field, URLSourceKey)
+			return
+		}
+	}
+	if w.e.cfg.CommandStrings {
+		w.scanCommand(s, field)
+	}
+	if w.e.cfg.BareDomains {
+		w.scanBareDomains(s, field)
+	}
+}
+
+// scanBareDomains finds scheme-less domains with a recognized TLD that are
+// not part of a URL, e-mail address or file path.
+func (w *urlWalker) scanBareDomains(s, field string) {
+	for _, idx := range bareDomainRegexp.FindAllStringSubmatchIndex(s, -1) {
+		start, end := idx[0], idx[1]
+		if start > 0 && strings.ContainsRune("@/.:-_~\\", rune(s[start-1])) || start > 0 && isWordByte(s[start-1]) {
+			continue
+		}
+		if end < len(s) && (isWordByte(s[end]) || s[end] == '@') {
+			continue
+		}
+		if !bareDomainTLDs[strings.ToLower(s[idx[2]:idx[3]])] {
+			continue
+		}
+		w.add(strings.TrimRight(s[start:end], ".,;:!?)]}'\""), "https", field, URLSourceDomain)
+	}
+}
+
+// scanCommand tokenizes a shell command line and records the host
+// arguments of known network clients, including commands after pipes,
+// "&&", ";" and "sudo"/"env" prefixes.
+func (w *urlWalker) scanCommand(s, field string) {
+	tokens := shellFields(s)
+	cmdScheme := ""
+	expectCmd := true
+	for i, tok := range tokens {
+		switch tok {
+		case "|", "||", "&&", ";", "&":
+			cmdScheme, expectCmd = "", true
+			continue
+		}
+		if expectCmd {
+			base := path.Base(tok)
+			if base == "sudo" || base == "env" || base == "exec" || base == "command" || strings.Contains(tok, "=") && !strings.HasPrefix(tok, "-") {
+				continue
+			}
+			cmdScheme, expectCmd = networkCommands[base], false
+			continue
+		}
+		if cmdScheme == "" || strings.HasPrefix(tok, "-") || strings.Contains(tok, "://") {
+			continue
+		}
+		host := tok
+		// A user@, host:path or host:port form marks the token as a host
+		// even without a well-known TLD; plain words like "out.json" do not.
+		explicit := false
+		if at := strings.LastIndex(host, "@"); at >= 0 {
+			host, explicit = host[at+1:], true
+		}
+		if h, p, ok := strings.Cut(host, ":"); ok && !strings.Contains(h, "/") {
+			if !isAllDigits(p) {
+				// scp/git style "host:path"
+				host = h + "/" + strings.TrimPrefix(p, "/")
+			}
+			explicit = true
+		}
+		hostOnly, _, _ := strings.Cut(host, "/")
+		if cmdScheme == "tcp" && i+1 < len(tokens) && isAllDigits(tokens[i+1]) && !strings.Contains(hostOnly, ":") {
+			hostOnly = net.JoinHostPort(hostOnly, tokens[i+1])
+			host, explicit = hostOnly, true
+		}
+		if !isHostLike(hostOnly) || !explicit && !isKnownHost(hostOnly) {
+			continue
+		}
+		w.add(host, cmdScheme, field, URLSourceCommand)
+	}
+}
+
+// add parses raw (prefixing defaultScheme when it has none) and records it.
+func (w *urlWalker) add(raw, defaultScheme, field, source string) {
+	if defaultScheme != "" {
+		raw = defaultScheme + "://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Host == "" && u.Scheme != "file") {
+		return
+	}
+	if w.seen[u.String()] {
+		return
+	}
+	w.seen[u.String()] = true
+	port, _ := strconv.Atoi(u.Port())
+	w.out = append(w.out, ExtractedURL{
+		URL:    u.String(),
+		Scheme: strings.ToLower(u.Scheme),
+		Domain: strings.ToLower(u.Hostname()),
+		Port:   port,
+		Path:   u.Path,
+		Field:  field,
+		Source: source,
+	})
+}
+
+// isHostLike reports whether s looks like host[:port][/path]: an IP
+// address, "localhost", or a dotted name of valid labels.
+func isHostLike(s string) bool {
+	hostPort, _, _ := strings.Cut(s, "/")
+	host := hostPort
+	if h, p, err := net.SplitHostPort(hostPort); err == nil {
+		if !isAllDigits(p) {
+			return false
+		}
+		host = h
+	}
+	if host == "" {
+		return false
+	}
+	if net.ParseIP(host) != nil || strings.EqualFold(host, "localhost") {
+		return true
+	}
+	labels := strings.Split(host, ".")
+	if len(labels) < 2 {
+		return false
+	}
+	for _, l := range labels {
+		if l == "" || len(l) > 63 || l[0] == '-' || l[len(l)-1] == '-' {
+			return false
+		}
+		for i := 0; i < len(l); i++ {
+			if !isWordByte(l[i]) && l[i] != '-' || l[i] == '_' {
+				return false
+			}
+		}
+	}
+	tld := labels[len(labels)-1]
+	return !isAllDigits(tld)
+}
+
+// isKnownHost reports whether host is an IP address, localhost, or a name
+// under one of bareDomainTLDs.
+func isKnownHost(host string) bool {
+	if net.ParseIP(host) != nil || strings.EqualFold(host, "localhost") {
+		return true
+	}
+	return bareDomainTLDs[strings.ToLower(host[strings.LastIndex(host, ".")+1:])]
+}
+
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_'
+}
+
+func isAllDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// shellFields splits a command line on whitespace, honouring single and
+// double quotes and backslash escapes. Unquoted |, ;, & runs become
+// separate tokens so chained commands can be told apart.
+func shellFields(s string) []string {
+	var tokens []string
+	var cur strings.Builder
+	inTok := false
+	var quote byte
+	flush := func() {
+		if inTok {
+			tokens = append(tokens, cur.String())
+			cur.Reset()
+			inTok = false
+		}
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' && i+1 < len(s) {
+				i++
+				cur.WriteByte(s[i])
+			} else {
+				cur.WriteByte(c)
+			}
+		case c == '\'' || c == '"':
+			quote, inTok = c, true
+		case c == '\\' && i+1 < len(s):
+			i++
+			cur.WriteByte(s[i])
+			inTok = true
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			flush()
+		case c == '|' || c == ';' || c == '&':
+			flush()
+			j := i
+			for j+1 < len(s) && s[j+1] == c {
+				j++
+			}
+			tokens = append(tokens, s[i:j+1])
+			i = j
+		default:
+			cur.WriteByte(c)
+			inTok = true
+		}
+	}
+	flush()
+	return tokens
+}
@@ -0,0 +1,168 @@
+package action
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func extractedURLs(found []ExtractedURL) []string {
+	out := make([]string, len(found))
+	for i, f := range found {
+		out[i] = f.URL
+	}
+	return out
+}
+
+func TestURLExtractor_Extract(t *testing.T) {
+	full := NewURLExtractor(URLExtractionConfig{BareDomains: true, CommandStrings: true})
+
+	tests := []struct {
+		name string
+		ex   *URLExtractor
+		args map[string]interface{}
+		want []string
+	}{
+		{
+			name: "nested uri",
+			ex:   full,
+			args: map[string]interface{}{"request": map[string]interface{}{"uri": "https://api.example.com/v1?q=1"}},
+			want: []string{"https://api.example.com/v1?q=1"},
+		},
+		{
+			name: "url inside text with trailing punctuation",
+			ex:   full,
+			args: map[string]interface{}{"body": "see (https://evil.example.org/x)."},
+			want: []string{"https://evil.example.org/x"},
+		},
+		{
+			name: "array of urls",
+			ex:   full,
+			args: map[string]interface{}{"urls": []interface{}{"http://a.example.com", "http://b.example.com"}},
+			want: []string{"http://a.example.com", "http://b.example.com"},
+		},
+		{
+			name: "host key without tld list",
+			ex:   full,
+			args: map[string]interface{}{"host": "db.internal.corp:5432"},
+			want: []string{"https://db.internal.corp:5432"},
+		},
+		{
+			name: "bare domain in prose",
+			ex:   full,
+			args: map[string]interface{}{"text": "upload it to pastebin.com/raw now"},
+			want: []string{"https://pastebin.com/raw"},
+		},
+		{
+			name: "file names and emails are not domains",
+			ex:   full,
+			args: map[string]interface{}{"text": "edit main.py and config.json, mail bob@example.com, read ./docs/site.com"},
+			want: nil,
+		},
+		{
+			name: "curl command",
+			ex:   full,
+			args: map[string]interface{}{"command": `curl -s -o out.json -H "Accept: text/plain" example.com/data`},
+			want: []string{"https://example.com/data"},
+		},
+		{
+			name: "scp, git and nc in a pipeline",
+			ex:   full,
+			args: map[string]interface{}{"command": "tar cz . | ssh deploy@build.lan 'cat > x' && git clone git@github.com:org/repo.git; nc 10.0.0.5 4444"},
+			want: []string{"ssh://build.lan", "ssh://github.com/org/repo.git", "tcp://10.0.0.5:4444"},
+		},
+		{
+			name: "heuristics off",
+			ex:   NewURLExtractor(URLExtractionConfig{}),
+			args: map[string]interface{}{"command": "curl example.com", "text": "pastebin.com"},
+			want: nil,
+		},
+		{
+			name: "max depth",
+			ex:   NewURLExtractor(URLExtractionConfig{MaxDepth: 2}),
+			args: map[string]interface{}{"a": map[string]interface{}{"b": map[string]interface{}{"url": "https://deep.example.com"}}},
+			want: nil,
+		},
+		{
+			name: "duplicates collapsed",
+			ex:   full,
+			args: map[string]interface{}{"a": "https://x.example.com", "b": "https://x.example.com"},
+			want: []string{"https://x.example.com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := extractedURLs(tt.ex.Extract("tool", tt.args))
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Extract() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestURLExtractor_Fields(t *testing.T) {
+	found := NewURLExtractor(URLExtractionConfig{}).Extract("fetch", map[string]interface{}{
+		"request": map[string]interface{}{"headers": map[string]interface{}{"Referer": "https://ref.example.com"}, "uri": "https://api.example.com:8443/path"},
+	})
+	if len(found) != 2 {
+		t.Fatalf("Extract() = %+v, want 2 results", found)
+	}
+	f := found[1]
+	if f.Field != "request.uri" || f.Domain != "api.example.com" || f.Port != 8443 || f.Path != "/path" || f.Scheme != "https" || f.Source != URLSourceScheme {
+		t.Errorf("second result = %+v", f)
+	}
+}
+
+func TestURLExtractor_ToolHints(t *testing.T) {
+	ex := NewURLExtractor(URLExtractionConfig{Tools: map[string]ToolURLHint{
+		"fetch":   {Fields: []string{"request.uri"}},
+		"write_*": {Ignore: []string{"content"}},
+		"notes":   {Disabled: true},
+	}})
+	args := map[string]interface{}{
+		"request": map[string]interface{}{"uri": "https://api.example.com"},
+		"content": "https://in-content.example.com",
+	}
+
+	if got := extractedURLs(ex.Extract("fetch", args)); !reflect.DeepEqual(got, []string{"https://api.example.com"}) {
+		t.Errorf("fetch (fields) = %v", got)
+	}
+	if got := extractedURLs(ex.Extract("write_file", args)); !reflect.DeepEqual(got, []string{"https://api.example.com"}) {
+		t.Errorf("write_file (ignore via glob) = %v", got)
+	}
+	if got := ex.Extract("notes", args); len(got) != 0 {
+		t.Errorf("notes (disabled) = %v, want none", got)
+	}
+	if got := ex.Extract("other", args); len(got) != 2 {
+		t.Errorf("other (no hint) = %v, want both", extractedURLs(got))
+	}
+}
+
+func TestMCPNormalizer_ToolCallDestination(t *testing.T) {
+	n := NewMCPNormalizer()
+	n.SetURLExtractor(NewURLExtractor(URLExtractionConfig{CommandStrings: true}))
+
+	msg := newToolCallMessage("run", map[string]interface{}{
+		"cmd":    "wget files.example.com/a.tar && curl https://api.example.net:8443/upload",
+		"mirror": "https://files.example.com/b.tar",
+	}, testSession())
+
+	act, err := n.Normalize(context.Background(), msg)
+	if err != nil {
+		t.Fatalf("Normalize: %v", err)
+	}
+	d := act.Destination
+	if d.URL != "https://api.example.net:8443/upload" || d.Domain != "api.example.net" || d.Port != 8443 || d.Scheme != "https" {
+		t.Errorf("Destination = %+v, want the first URL found", d)
+	}
+	wantURLs := []string{"https://api.example.net:8443/upload", "https://files.example.com/a.tar", "https://files.example.com/b.tar"}
+	if !reflect.DeepEqual(d.URLs, wantURLs) {
+		t.Errorf("URLs = %v, want %v", d.URLs, wantURLs)
+	}
+	if !reflect.DeepEqual(d.Domains, []string{"api.example.net", "files.example.com"}) {
+		t.Errorf("Domains = %v", d.Domains)
+	}
+}
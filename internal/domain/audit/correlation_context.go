@@ -0,0 +1,44 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// correlationContextKey is the context key type for correlation ID propagation.
+type correlationContextKey struct{}
+
+// WithCorrelationID returns a new context carrying the correlation ID of the
+// request. A transport that received one from the client stores it here;
+// the interceptor chain stores the ID it settled on for the upstream router.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationContextKey{}, id)
+}
+
+// CorrelationIDFromContext returns the correlation ID in ctx, or "".
+func CorrelationIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(correlationContextKey{}).(string)
+	return id
+}
+
+// NewCorrelationID returns a new random correlation ID.
+func NewCorrelationID() string {
+	return uuid.New().String()
+}
+
+// ValidCorrelationID reports whether id can be accepted from a client: 1 to
+// 128 characters among letters, digits, '.', '_', '-' and ':'. Other values
+// are replaced by a new ID rather than written to logs and headers.
+func ValidCorrelationID(id string) bool {
+	if len(id) == 0 || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') &&
+			c != '.' && c != '_' && c != '-' && c != ':' {
+			return false
+		}
+	}
+	return true
+}
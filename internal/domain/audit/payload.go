@@ -0,0 +1,109 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"path"
+	"time"
+)
+
+// PayloadSample is the full request and result of a sampled tool call. It
+// is kept in a PayloadStore, apart from audit records, so forensic depth
+// on a few high-risk tools does not grow the audit log of every call.
+type PayloadSample struct {
+	// Timestamp is when the tool call was received (the audit record's).
+	Timestamp time.Time `json:"timestamp"`
+	// RequestID links the sample to its audit record.
+	RequestID    string `json:"request_id,omitempty"`
+	SessionID    string `json:"session_id"`
+	IdentityID   string `json:"identity_id"`
+	IdentityName string `json:"identity_name,omitempty"`
+	ToolName     string `json:"tool_name"`
+	Decision     string `json:"decision"`
+	// Arguments are the tool arguments as stored in the audit record:
+	// sensitive arguments encrypted and secret-looking keys redacted.
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	// Result is the JSON-RPC result or error returned to the client, after
+	// response scanning and transforms. Empty for calls denied before
+	// reaching the upstream.
+	Result json.RawMessage `json:"result,omitempty"`
+	// Truncated is set when Arguments or Result exceeded the size limit and
+	// were dropped from the sample.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// PayloadFilter selects payload samples. Zero fields match everything.
+type PayloadFilter struct {
+	RequestID string
+	ToolName  string
+	StartTime time.Time
+	EndTime   time.Time
+	// Limit caps the number of samples returned, newest first.
+	Limit int
+}
+
+// PayloadStore persists payload samples with their own retention.
+type PayloadStore interface {
+	// Append stores one sample.
+	Append(ctx context.Context, sample PayloadSample) error
+	// Query returns the samples matching filter, newest first.
+	Query(ctx context.Context, filter PayloadFilter) ([]PayloadSample, error)
+	// Close flushes and releases the store.
+	Close() error
+}
+
+// PayloadSampleRule sets the sampling rate of the matching tools.
+type PayloadSampleRule struct {
+	// Tool is a tool name or glob pattern ("payments_refund", "read_*").
+	Tool string
+	// Rate is the fraction of calls sampled, from 0 (never) to 1 (always).
+	Rate float64
+}
+
+// PayloadSampler decides which tool calls have their payloads sampled. The
+// first rule matching a tool sets its rate; tools matching no rule are
+// never sampled.
+type PayloadSampler struct {
+	rules []PayloadSampleRule
+	// random returns a number in [0, 1); replaced in tests.
+	random func() float64
+}
+
+// NewPayloadSampler validates rules and returns a sampler.
+func NewPayloadSampler(rules []PayloadSampleRule) (*PayloadSampler, error) {
+	for i, r := range rules {
+		if r.Tool == "" {
+			return nil, fmt.Errorf("payload sampling rule %d has no tool", i)
+		}
+		if _, err := path.Match(r.Tool, ""); err != nil {
+			return nil, fmt.Errorf("invalid tool pattern %q: %w", r.Tool, err)
+		}
+		if r.Rate < 0 || r.Rate > 1 {
+			return nil, fmt.Errorf("payload sampling rate of %q must be between 0 and 1, got %v", r.Tool, r.Rate)
+		}
+	}
+	return &PayloadSampler{
+		rules:  append([]PayloadSampleRule(nil), rules...),
+		random: rand.Float64,
+	}, nil
+}
+
+// Sample reports whether the payloads of a call to toolName are sampled.
+func (s *PayloadSampler) Sample(toolName string) bool {
+	for _, r := range s.rules {
+		if matched, _ := path.Match(r.Tool, toolName); !matched {
+			continue
+		}
+		switch {
+		case r.Rate >= 1:
+			return true
+		case r.Rate <= 0:
+			return false
+		default:
+			return s.random() < r.Rate
+		}
+	}
+	return false
+}
@@ -14,6 +14,8 @@ type PolicyDecisionHolder struct {
 	RuleID string
 	// RuleName is the name of the policy rule that matched (if any).
 	RuleName string
+	// DecisionID is the decision log ID of an external policy engine (if any).
+	DecisionID string
 }
 
 // NewPolicyDecisionContext returns a new context with an empty PolicyDecisionHolder.
@@ -0,0 +1,89 @@
+package audit
+
+import "context"
+
+// Scan verdicts reported in tool result provenance.
+const (
+	// ProvenanceScanClean means the response was scanned and nothing was found.
+	ProvenanceScanClean = "clean"
+	// ProvenanceScanMonitored means findings were logged but the response passed (monitor mode).
+	ProvenanceScanMonitored = "monitored"
+	// ProvenanceScanRedacted means findings were replaced with placeholders (redact mode).
+	ProvenanceScanRedacted = "redacted"
+	// ProvenanceScanSkipped means response scanning did not run for this call.
+	ProvenanceScanSkipped = "skipped"
+)
+
+// Provenance describes where a tool result came from. It is attached to
+// results returned to downstream clients so agent frameworks can log or
+// display the origin of the data.
+type Provenance struct {
+	// Upstream is the name of the upstream that served the call.
+	Upstream string `json:"upstream,omitempty"`
+	// RequestID is the gateway request ID (X-Request-ID over HTTP,
+	// otherwise the request ID recorded in the audit log).
+	RequestID string `json:"request_id,omitempty"`
+	// ScanVerdict is the response scan outcome: clean, monitored, redacted or skipped.
+	ScanVerdict string `json:"scan_verdict"`
+	// RuleID is the policy rule that allowed the call; empty for the default allow.
+	RuleID string `json:"rule_id,omitempty"`
+	// RuleName is the name of the policy rule that allowed the call.
+	RuleName string `json:"rule_name,omitempty"`
+	// LatencyMs is the time spent in the gateway and upstream, in milliseconds.
+	LatencyMs float64 `json:"latency_ms"`
+}
+
+// provenanceContextKey is the context key type for provenance propagation.
+type provenanceContextKey struct{}
+
+// ProvenanceHolder is a mutable container placed in context by a transport
+// that wants to expose provenance out of band (e.g., as HTTP response
+// headers). The AuditInterceptor populates it after a tool call succeeds.
+type ProvenanceHolder struct {
+	// RequestID is pre-filled by the transport with its own request ID.
+	RequestID string
+	// Provenance is set by the AuditInterceptor; nil if the request was not a
+	// successful tool call.
+	Provenance *Provenance
+}
+
+// NewProvenanceContext returns a new context with a ProvenanceHolder carrying
+// the transport's request ID.
+func NewProvenanceContext(ctx context.Context, requestID string) (context.Context, *ProvenanceHolder) {
+	holder := &ProvenanceHolder{RequestID: requestID}
+	return context.WithValue(ctx, provenanceContextKey{}, holder), holder
+}
+
+// ProvenanceFromContext retrieves the ProvenanceHolder from context.
+// Returns nil if not present.
+func ProvenanceFromContext(ctx context.Context) *ProvenanceHolder {
+	holder, _ := ctx.Value(provenanceContextKey{}).(*ProvenanceHolder)
+	return holder
+}
+
+// upstreamRouteContextKey is the context key type for upstream route propagation.
+type upstreamRouteContextKey struct{}
+
+// UpstreamRouteHolder is a mutable container placed in context by the
+// AuditInterceptor. The upstream router populates it with the upstream a
+// tool call was forwarded to.
+type UpstreamRouteHolder struct {
+	// UpstreamID is the ID of the upstream that served the call.
+	UpstreamID string
+	// UpstreamName is the human-readable name of the upstream.
+	UpstreamName string
+}
+
+// NewUpstreamRouteContext returns a new context with an empty UpstreamRouteHolder.
+// The AuditInterceptor calls this before invoking the chain.
+func NewUpstreamRouteContext(ctx context.Context) (context.Context, *UpstreamRouteHolder) {
+	holder := &UpstreamRouteHolder{}
+	return context.WithValue(ctx, upstreamRouteContextKey{}, holder), holder
+}
+
+// UpstreamRouteFromContext retrieves the UpstreamRouteHolder from context.
+// Returns nil if not present.
+func UpstreamRouteFromContext(ctx context.Context) *UpstreamRouteHolder {
+	holder, _ := ctx.Value(upstreamRouteContextKey{}).(*UpstreamRouteHolder)
+	return holder
+}
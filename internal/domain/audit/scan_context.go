@@ -12,10 +12,13 @@ type scanResultContextKey struct{}
 type ScanResultHolder struct {
 	// Detections is the number of scan findings.
 	Detections int
-	// Action is the action taken: "blocked" (enforce) or "monitored" (monitor).
+	// Action is the action taken: "blocked" (enforce), "monitored" (monitor)
+	// or "redacted" (redact).
 	Action string
 	// Types is a comma-separated list of unique finding categories (e.g., "prompt_injection").
 	Types string
+	// Scanned is true when the response scanner ran, with or without findings.
+	Scanned bool
 }
 
 // NewScanResultContext returns a new context with an empty ScanResultHolder.
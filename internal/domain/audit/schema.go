@@ -0,0 +1,174 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// CurrentSchemaVersion is the AuditRecord layout written by this release.
+// Bump it whenever a field is added, renamed or changes meaning, and record
+// the change in schemaVersions (and schemaFieldSince for new fields).
+const CurrentSchemaVersion = 8
+
+// MinSupportedSchemaVersion is the oldest layout DecodeRecord still reads.
+// At least the two versions before CurrentSchemaVersion must stay readable
+// so audit files survive upgrades across releases.
+const MinSupportedSchemaVersion = 1
+
+// SchemaVersionInfo describes one AuditRecord schema version.
+type SchemaVersionInfo struct {
+	Version int      `json:"version"`
+	Summary string   `json:"summary"`
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+// SchemaField describes one field of the current AuditRecord layout.
+type SchemaField struct {
+	Name string `json:"name"`
+	// Type is the JSON type: string, integer, number, boolean, timestamp,
+	// array or object.
+	Type string `json:"type"`
+	// Since is the schema version that introduced the field.
+	Since int `json:"since"`
+	// Optional is true when the field is omitted from records that have
+	// no value for it.
+	Optional bool `json:"optional"`
+}
+
+// SchemaDescriptor is the machine-readable description of the audit record
+// schema and its evolution.
+type SchemaDescriptor struct {
+	CurrentVersion      int                 `json:"current_version"`
+	MinSupportedVersion int                 `json:"min_supported_version"`
+	Versions            []SchemaVersionInfo `json:"versions"`
+	Fields              []SchemaField       `json:"fields"`
+}
+
+// schemaVersions is the field evolution of AuditRecord, oldest first.
+// Records written before versioning carry no schema_version; DecodeRecord
+// infers 1 or 2 from the fields present.
+var schemaVersions = []SchemaVersionInfo{
+	{Version: 1, Summary: "Initial unversioned layout"},
+	{Version: 2, Summary: "Upstream token use and identity access restrictions (still unversioned)",
+		Added: []string{"upstream_token", "source_country", "access_restriction"}},
+	{Version: 3, Summary: "Explicit schema version on every record",
+		Added: []string{"schema_version"}},
+	{Version: 4, Summary: "Session labels",
+		Added: []string{"session_labels"}},
+	{Version: 5, Summary: "Payload sampling marker",
+		Added: []string{"payload_sampled"}},
+	{Version: 6, Summary: "Prompt hash of server-initiated sampling requests",
+		Added: []string{"prompt_hash"}},
+	{Version: 7, Summary: "Destination address and geolocation",
+		Added: []string{"dest_ip", "dest_country", "dest_asn", "dest_asn_org"}},
+	{Version: 8, Summary: "Correlation ID shared across gateways",
+		Added: []string{"correlation_id"}},
+}
+
+// schemaFieldSince maps fields added after version 1 to the version that
+// introduced them.
+var schemaFieldSince = map[string]int{
+	"upstream_token":     2,
+	"source_country":     2,
+	"access_restriction": 2,
+	"schema_version":     3,
+	"session_labels":     4,
+	"payload_sampled":    5,
+	"prompt_hash":        6,
+	"dest_ip":            7,
+	"dest_country":       7,
+	"dest_asn":           7,
+	"dest_asn_org":       7,
+	"correlation_id":     8,
+}
+
+// Schema returns the descriptor of the current AuditRecord schema.
+func Schema() SchemaDescriptor {
+	t := reflect.TypeOf(AuditRecord{})
+	fields := make([]SchemaField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, opts, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		since := schemaFieldSince[name]
+		if since == 0 {
+			since = 1
+		}
+		fields = append(fields, SchemaField{
+			Name:     name,
+			Type:     schemaJSONType(t.Field(i).Type),
+			Since:    since,
+			Optional: strings.Contains(opts, "omitempty"),
+		})
+	}
+	versions := make([]SchemaVersionInfo, len(schemaVersions))
+	copy(versions, schemaVersions)
+	return SchemaDescriptor{
+		CurrentVersion:      CurrentSchemaVersion,
+		MinSupportedVersion: MinSupportedSchemaVersion,
+		Versions:            versions,
+		Fields:              fields,
+	}
+}
+
+func schemaJSONType(t reflect.Type) string {
+	if t == reflect.TypeOf(time.Time{}) {
+		return "timestamp"
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// DecodeRecord decodes one stored audit record written by this or an earlier
+// release and rolls it forward to the current layout. Records from a newer
+// release are decoded best effort (unknown fields are dropped) and keep
+// their version, so callers can tell they may be incomplete.
+func DecodeRecord(data []byte) (AuditRecord, error) {
+	var rec AuditRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return AuditRecord{}, err
+	}
+	version := rec.SchemaVersion
+	if version == 0 {
+		version = legacySchemaVersion(rec)
+	}
+	if version < MinSupportedSchemaVersion {
+		return AuditRecord{}, fmt.Errorf("audit record schema version %d is no longer supported (minimum %d)",
+			version, MinSupportedSchemaVersion)
+	}
+	if version > CurrentSchemaVersion {
+		return rec, nil
+	}
+	// Versions 1 to 8 only added optional fields, so the decoded record is
+	// already in the current layout. A future rename or type change would
+	// be upgraded here, one version step at a time.
+	rec.SchemaVersion = CurrentSchemaVersion
+	return rec, nil
+}
+
+// legacySchemaVersion infers the version of a record written before
+// schema_version existed.
+func legacySchemaVersion(rec AuditRecord) int {
+	if rec.UpstreamToken != nil || rec.SourceCountry != "" || rec.AccessRestriction != "" {
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,316 @@
+package audit
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// EncryptedArgPrefix starts every argument value encrypted by an
+// ArgumentEncryptor. The full form is
+// "sgenc:v1:<key id>:<base64 nonce and ciphertext>".
+const EncryptedArgPrefix = "sgenc:v1:"
+
+// MaskedEncryptedArg replaces encrypted argument values in admin views.
+const MaskedEncryptedArg = "***ENCRYPTED***"
+
+// ArgumentKeySize is the size in bytes of the AES-256 argument key.
+const ArgumentKeySize = 32
+
+// ErrArgumentKeyMismatch is returned when a value was encrypted with a
+// different key than the one used to decrypt it.
+var ErrArgumentKeyMismatch = errors.New("argument encrypted with a different key")
+
+// SensitiveArgRule marks argument paths of the matching tools as sensitive.
+type SensitiveArgRule struct {
+	// Tool is a tool name or glob pattern ("deploy", "github_*").
+	Tool string
+	// Paths are dot-separated argument paths ("token", "auth.password").
+	// A "*" segment matches every key of an object or element of an array;
+	// a number matches one array element.
+	Paths []string
+}
+
+// ArgumentEncryptor encrypts the sensitive arguments of tool calls before
+// they are written to audit records and session recordings. The upstream
+// always receives the original arguments; only the stored copy is sealed.
+// Values are encrypted with AES-256-GCM under a key kept apart from every
+// other SentinelGate key, so reading them back takes that key (see Open).
+type ArgumentEncryptor struct {
+	aead  cipher.AEAD
+	keyID string
+	rules []sensitiveArgRule
+}
+
+type sensitiveArgRule struct {
+	tool  string
+	paths [][]string
+}
+
+// NewArgumentEncryptor creates an encryptor for key, which must be
+// ArgumentKeySize bytes. rules may be empty when the encryptor is only used
+// to decrypt.
+func NewArgumentEncryptor(key []byte, rules []SensitiveArgRule) (*ArgumentEncryptor, error) {
+	if len(key) != ArgumentKeySize {
+		return nil, fmt.Errorf("argument key must be %d bytes, got %d", ArgumentKeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(key)
+	e := &ArgumentEncryptor{aead: aead, keyID: hex.EncodeToString(sum[:4])}
+
+	for _, r := range rules {
+		if r.Tool == "" {
+			return nil, fmt.Errorf("sensitive argument rule without tool")
+		}
+		if _, err := path.Match(r.Tool, ""); err != nil {
+			return nil, fmt.Errorf("invalid tool pattern %q: %w", r.Tool, err)
+		}
+		compiled := sensitiveArgRule{tool: r.Tool}
+		for _, p := range r.Paths {
+			segs := strings.Split(p, ".")
+			for _, s := range segs {
+				if s == "" {
+					return nil, fmt.Errorf("invalid argument path %q for tool %q", p, r.Tool)
+				}
+			}
+			compiled.paths = append(compiled.paths, segs)
+		}
+		if len(compiled.paths) == 0 {
+			return nil, fmt.Errorf("sensitive argument rule for tool %q has no paths", r.Tool)
+		}
+		e.rules = append(e.rules, compiled)
+	}
+	return e, nil
+}
+
+// KeyID identifies the key. It is embedded in every encrypted value.
+func (e *ArgumentEncryptor) KeyID() string {
+	return e.keyID
+}
+
+// Seal returns a copy of args in which the values at the sensitive paths of
+// toolName are encrypted. args itself is never modified, and is returned as
+// is when no rule matches the tool. Paths that do not exist in args are
+// ignored.
+func (e *ArgumentEncryptor) Seal(toolName string, args map[string]interface{}) map[string]interface{} {
+	if len(args) == 0 {
+		return args
+	}
+	var result interface{} = args
+	for _, r := range e.rules {
+		if matched, _ := path.Match(r.tool, toolName); !matched {
+			continue
+		}
+		for _, segs := range r.paths {
+			result = e.sealPath(result, segs)
+		}
+	}
+	sealed, _ := result.(map[string]interface{})
+	return sealed
+}
+
+// sealPath encrypts the values at segs below v, copying every object and
+// array on the way so v is left untouched.
+func (e *ArgumentEncryptor) sealPath(v interface{}, segs []string) interface{} {
+	if len(segs) == 0 {
+		if IsEncryptedArg(v) {
+			return v
+		}
+		sealed, err := e.encrypt(v)
+		if err != nil {
+			// Never store a value meant to be sealed in the clear.
+			return MaskedEncryptedArg
+		}
+		return sealed
+	}
+	seg, rest := segs[0], segs[1:]
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, inner := range val {
+			if seg == "*" || seg == k {
+				out[k] = e.sealPath(inner, rest)
+			} else {
+				out[k] = inner
+			}
+		}
+		return out
+	case []interface{}:
+		idx := -1
+		if seg != "*" {
+			n, err := strconv.Atoi(seg)
+			if err != nil || n < 0 || n >= len(val) {
+				return v
+			}
+			idx = n
+		}
+		out := make([]interface{}, len(val))
+		for i, inner := range val {
+			if idx < 0 || i == idx {
+				out[i] = e.sealPath(inner, rest)
+			} else {
+				out[i] = inner
+			}
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func (e *ArgumentEncryptor) encrypt(v interface{}) (string, error) {
+	plaintext, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := e.aead.Seal(nonce, nonce, plaintext, nil)
+	return EncryptedArgPrefix + e.keyID + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Open returns a copy of v with every encrypted value decrypted, together
+// with the number of values decrypted. v may be any decoded JSON value, such
+// as a whole audit record.
+func (e *ArgumentEncryptor) Open(v interface{}) (interface{}, int, error) {
+	switch val := v.(type) {
+	case string:
+		if !strings.HasPrefix(val, EncryptedArgPrefix) {
+			return v, 0, nil
+		}
+		plain, err := e.decrypt(val)
+		if err != nil {
+			return nil, 0, err
+		}
+		return plain, 1, nil
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		total := 0
+		for k, inner := range val {
+			opened, n, err := e.Open(inner)
+			if err != nil {
+				return nil, 0, fmt.Errorf("%s: %w", k, err)
+			}
+			out[k] = opened
+			total += n
+		}
+		return out, total, nil
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		total := 0
+		for i, inner := range val {
+			opened, n, err := e.Open(inner)
+			if err != nil {
+				return nil, 0, fmt.Errorf("[%d]: %w", i, err)
+			}
+			out[i] = opened
+			total += n
+		}
+		return out, total, nil
+	default:
+		return v, 0, nil
+	}
+}
+
+func (e *ArgumentEncryptor) decrypt(s string) (interface{}, error) {
+	keyID, encoded, ok := strings.Cut(strings.TrimPrefix(s, EncryptedArgPrefix), ":")
+	if !ok {
+		return nil, fmt.Errorf("malformed encrypted argument")
+	}
+	if keyID != e.keyID {
+		return nil, fmt.Errorf("%w (value key %s, key %s)", ErrArgumentKeyMismatch, keyID, e.keyID)
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < e.aead.NonceSize() {
+		return nil, fmt.Errorf("malformed encrypted argument")
+	}
+	nonce, ciphertext := sealed[:e.aead.NonceSize()], sealed[e.aead.NonceSize():]
+	plaintext, err := e.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt argument: %w", err)
+	}
+	var v interface{}
+	if err := json.Unmarshal(plaintext, &v); err != nil {
+		return nil, fmt.Errorf("decode argument: %w", err)
+	}
+	return v, nil
+}
+
+// IsEncryptedArg reports whether v is a value sealed by an ArgumentEncryptor.
+func IsEncryptedArg(v interface{}) bool {
+	s, ok := v.(string)
+	return ok && strings.HasPrefix(s, EncryptedArgPrefix)
+}
+
+// MaskEncryptedArgs returns a copy of args with encrypted values replaced by
+// MaskedEncryptedArg, for display. args is returned as is when it holds no
+// encrypted value.
+func MaskEncryptedArgs(args map[string]interface{}) map[string]interface{} {
+	if !containsEncrypted(args) {
+		return args
+	}
+	masked, _ := maskValue(args).(map[string]interface{})
+	return masked
+}
+
+func containsEncrypted(v interface{}) bool {
+	switch val := v.(type) {
+	case string:
+		return strings.HasPrefix(val, EncryptedArgPrefix)
+	case map[string]interface{}:
+		for _, inner := range val {
+			if containsEncrypted(inner) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, inner := range val {
+			if containsEncrypted(inner) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func maskValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case string:
+		if strings.HasPrefix(val, EncryptedArgPrefix) {
+			return MaskedEncryptedArg
+		}
+		return val
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, inner := range val {
+			out[k] = maskValue(inner)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, inner := range val {
+			out[i] = maskValue(inner)
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -0,0 +1,154 @@
+package audit
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func testArgumentEncryptor(t *testing.T, fill byte, rules []SensitiveArgRule) *ArgumentEncryptor {
+	t.Helper()
+	key := make([]byte, ArgumentKeySize)
+	for i := range key {
+		key[i] = fill
+	}
+	e, err := NewArgumentEncryptor(key, rules)
+	if err != nil {
+		t.Fatalf("NewArgumentEncryptor: %v", err)
+	}
+	return e
+}
+
+func TestArgumentEncryptor_SealAndOpen(t *testing.T) {
+	e := testArgumentEncryptor(t, 1, []SensitiveArgRule{
+		{Tool: "github_*", Paths: []string{"auth", "headers.*", "items.*.secret", "hosts.1"}},
+	})
+	args := map[string]interface{}{
+		"auth":    map[string]interface{}{"user": "bot", "pin": float64(1234)},
+		"headers": map[string]interface{}{"Authorization": "Bearer x", "X-Id": "7"},
+		"items": []interface{}{
+			map[string]interface{}{"secret": "a", "name": "one"},
+			map[string]interface{}{"name": "two"},
+		},
+		"hosts": []interface{}{"a.example.com", "b.example.com"},
+		"repo":  "sentinel",
+	}
+	original := deepCopyJSON(args)
+
+	if got := e.Seal("deploy", args); !reflect.DeepEqual(got, args) {
+		t.Errorf("Seal for an unmatched tool changed the arguments: %v", got)
+	}
+
+	sealed := e.Seal("github_push", args)
+	if !reflect.DeepEqual(args, original) {
+		t.Fatalf("Seal modified its input: %v", args)
+	}
+	headers := sealed["headers"].(map[string]interface{})
+	items := sealed["items"].([]interface{})
+	hosts := sealed["hosts"].([]interface{})
+	for name, v := range map[string]interface{}{
+		"auth":                  sealed["auth"],
+		"headers.Authorization": headers["Authorization"],
+		"headers.X-Id":          headers["X-Id"],
+		"items.0.secret":        items[0].(map[string]interface{})["secret"],
+		"hosts.1":               hosts[1],
+	} {
+		if !IsEncryptedArg(v) {
+			t.Errorf("%s = %v, want encrypted", name, v)
+		}
+	}
+	if sealed["repo"] != "sentinel" || hosts[0] != "a.example.com" || items[1].(map[string]interface{})["name"] != "two" {
+		t.Errorf("non-sensitive values changed: %v", sealed)
+	}
+
+	// Sealing twice must not encrypt the ciphertext again.
+	if again := e.Seal("github_push", sealed); !reflect.DeepEqual(again, sealed) {
+		t.Errorf("second Seal changed sealed values")
+	}
+
+	opened, n, err := e.Open(sealed)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if n != 5 {
+		t.Errorf("Open decrypted %d values, want 5", n)
+	}
+	if !reflect.DeepEqual(opened, original) {
+		t.Errorf("Open = %v, want %v", opened, original)
+	}
+}
+
+func TestArgumentEncryptor_OpenWithOtherKey(t *testing.T) {
+	rules := []SensitiveArgRule{{Tool: "deploy", Paths: []string{"token"}}}
+	sealed := testArgumentEncryptor(t, 1, rules).Seal("deploy", map[string]interface{}{"token": "t"})
+
+	_, _, err := testArgumentEncryptor(t, 2, nil).Open(sealed)
+	if !errors.Is(err, ErrArgumentKeyMismatch) {
+		t.Errorf("Open with another key error = %v, want ErrArgumentKeyMismatch", err)
+	}
+}
+
+func TestNewArgumentEncryptor_Invalid(t *testing.T) {
+	key := make([]byte, ArgumentKeySize)
+	if _, err := NewArgumentEncryptor(key[:16], nil); err == nil {
+		t.Error("short key accepted")
+	}
+	for _, rules := range [][]SensitiveArgRule{
+		{{Tool: "", Paths: []string{"token"}}},
+		{{Tool: "deploy[", Paths: []string{"token"}}},
+		{{Tool: "deploy"}},
+		{{Tool: "deploy", Paths: []string{"auth..token"}}},
+	} {
+		if _, err := NewArgumentEncryptor(key, rules); err == nil {
+			t.Errorf("rules %+v accepted", rules)
+		}
+	}
+}
+
+func TestMaskEncryptedArgs(t *testing.T) {
+	e := testArgumentEncryptor(t, 1, []SensitiveArgRule{{Tool: "deploy", Paths: []string{"token", "nested.key"}}})
+	sealed := e.Seal("deploy", map[string]interface{}{
+		"token":  "t",
+		"nested": map[string]interface{}{"key": "k"},
+		"env":    "prod",
+	})
+
+	masked := MaskEncryptedArgs(sealed)
+	if masked["token"] != MaskedEncryptedArg || masked["nested"].(map[string]interface{})["key"] != MaskedEncryptedArg {
+		t.Errorf("masked = %v, want encrypted values masked", masked)
+	}
+	if masked["env"] != "prod" {
+		t.Errorf("masked env = %v, want prod", masked["env"])
+	}
+	if !IsEncryptedArg(sealed["token"]) {
+		t.Error("MaskEncryptedArgs modified its input")
+	}
+
+	// Encrypted values survive keyword redaction.
+	if redacted := RedactSensitiveArgs(sealed); !IsEncryptedArg(redacted["token"]) {
+		t.Errorf("RedactSensitiveArgs replaced the encrypted token: %v", redacted["token"])
+	}
+}
+
+func deepCopyJSON(v map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(v))
+	for k, inner := range v {
+		out[k] = deepCopyValue(inner)
+	}
+	return out
+}
+
+func deepCopyValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return deepCopyJSON(val)
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, inner := range val {
+			out[i] = deepCopyValue(inner)
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -3,6 +3,7 @@ package audit
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
 )
 
@@ -10,6 +11,10 @@ import (
 var (
 	// ErrDateRangeExceeded is returned when the query date range exceeds the maximum allowed.
 	ErrDateRangeExceeded = errors.New("date range exceeds maximum of 7 days")
+
+	// ErrInvalidCursor is returned when a query cursor was not issued by
+	// the store.
+	ErrInvalidCursor = errors.New("invalid audit cursor")
 )
 
 // AuditStore persists audit records.
@@ -42,6 +47,21 @@ type AuditFilter struct {
 	Decision string
 	// Protocol filters by originating protocol (optional: "mcp", "http", "websocket", "runtime").
 	Protocol string
+	// Labels filters by session labels (optional): every key must be
+	// present with the given value, or with any value when it is empty.
+	Labels map[string]string
+	// Upstream filters by upstream name (optional): records of the tools
+	// namespaced "<upstream>/".
+	Upstream string
+	// RuleID filters by the ID of the matched rule (optional).
+	RuleID string
+	// Reason filters by a case-insensitive substring of the decision
+	// reason (optional).
+	Reason string
+	// CorrelationID filters by correlation ID (optional).
+	CorrelationID string
+	// Ascending returns the oldest records first instead of the newest.
+	Ascending bool
 	// Limit is the maximum number of records to return (default 100, max 100).
 	Limit int
 	// LimitExplicit is true when the client explicitly set the limit parameter.
@@ -50,6 +70,35 @@ type AuditFilter struct {
 	Cursor string
 }
 
+// MatchesLabels reports whether labels satisfy the Labels filter.
+func (f AuditFilter) MatchesLabels(labels map[string]string) bool {
+	for k, want := range f.Labels {
+		got, ok := labels[k]
+		if !ok || (want != "" && got != want) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchesDetails reports whether rec satisfies the Upstream, RuleID, Reason
+// and CorrelationID filters.
+func (f AuditFilter) MatchesDetails(rec AuditRecord) bool {
+	if f.Upstream != "" && !strings.HasPrefix(rec.ToolName, f.Upstream+"/") {
+		return false
+	}
+	if f.RuleID != "" && rec.RuleID != f.RuleID {
+		return false
+	}
+	if f.Reason != "" && !strings.Contains(strings.ToLower(rec.Reason), strings.ToLower(f.Reason)) {
+		return false
+	}
+	if f.CorrelationID != "" && rec.CorrelationID != f.CorrelationID {
+		return false
+	}
+	return true
+}
+
 // ToolCallStats contains per-tool audit statistics.
 type ToolCallStats struct {
 	// Calls is the total number of calls to this tool.
@@ -100,6 +149,33 @@ type AuditQueryStore interface {
 	QueryStats(ctx context.Context, start, end time.Time) (*AuditStats, error)
 }
 
+// StorageStats summarizes the on-disk footprint of file-based audit storage.
+type StorageStats struct {
+	// Dir is the directory holding the audit files.
+	Dir string
+	// Files is the number of audit files.
+	Files int
+	// CompressedFiles is the number of zstd-compressed (rotated) files.
+	CompressedFiles int
+	// DiskBytes is the total size of the audit files on disk.
+	DiskBytes int64
+	// UncompressedBytes is the total size of the audit files once decompressed.
+	UncompressedBytes int64
+	// Compression reports whether rotated files are compressed.
+	Compression bool
+	// MaxTotalBytes is the on-disk size cap enforced by retention (0 = none).
+	MaxTotalBytes int64
+	// OldestDate and NewestDate are the dates (YYYY-MM-DD) of the oldest and
+	// newest audit files, empty when there are none.
+	OldestDate string
+	NewestDate string
+}
+
+// StorageReporter reports the on-disk footprint of audit storage.
+type StorageReporter interface {
+	StorageStats() (StorageStats, error)
+}
+
 // ComplianceAuditFilter specifies query parameters for compliance audit queries.
 type ComplianceAuditFilter struct {
 	// StartTime is the beginning of the time range (required).
@@ -99,14 +99,15 @@ var sensitiveKeywords = []string{
 
 // RedactSensitiveArgs returns a copy of args with sensitive values masked.
 // A key is considered sensitive if it contains any of the sensitiveKeywords
-// (case-insensitive). Values are replaced with "***REDACTED***".
+// (case-insensitive). Values are replaced with "***REDACTED***", except
+// values already encrypted by an ArgumentEncryptor, which are kept.
 func RedactSensitiveArgs(args map[string]interface{}) map[string]interface{} {
 	if len(args) == 0 {
 		return args
 	}
 	redacted := make(map[string]interface{}, len(args))
 	for k, v := range args {
-		if isSensitiveKey(k) {
+		if isSensitiveKey(k) && !IsEncryptedArg(v) {
 			redacted[k] = "***REDACTED***"
 		} else {
 			redacted[k] = v
@@ -128,6 +129,10 @@ func isSensitiveKey(key string) bool {
 
 // AuditRecord represents a single auditable event from a tool call.
 type AuditRecord struct {
+	// SchemaVersion is the record layout version (see CurrentSchemaVersion).
+	// Stamped when the record is recorded; zero in records written before
+	// versioning.
+	SchemaVersion int `json:"schema_version,omitempty"`
 	// Timestamp is when the tool call was received.
 	Timestamp time.Time `json:"timestamp"`
 	// SessionID from the authenticated session.
@@ -138,6 +143,9 @@ type AuditRecord struct {
 	IdentityName string `json:"identity_name"`
 	// Roles assigned to the identity at decision time.
 	Roles []string `json:"roles,omitempty"`
+	// SessionLabels are the labels of the session at decision time
+	// (e.g., project=checkout-bot).
+	SessionLabels map[string]string `json:"session_labels,omitempty"`
 	// ToolName is the name of the tool being invoked.
 	ToolName string `json:"tool_name"`
 	// ToolArguments are the arguments passed to the tool (may be redacted).
@@ -148,8 +156,15 @@ type AuditRecord struct {
 	Reason string `json:"reason,omitempty"`
 	// RuleID is the ID of the rule that matched (if any).
 	RuleID string `json:"rule_id,omitempty"`
+	// PolicyDecisionID is the ID of the decision in the decision log of the
+	// Rego policy engine, when it made the decision.
+	PolicyDecisionID string `json:"policy_decision_id,omitempty"`
 	// RequestID is for correlation across systems.
 	RequestID string `json:"request_id,omitempty"`
+	// CorrelationID identifies the trace the request belongs to. It is
+	// taken from the client or generated, and passed on to upstreams, so the
+	// records of one agent trace can be joined across gateways.
+	CorrelationID string `json:"correlation_id,omitempty"`
 	// LatencyMicros is the policy evaluation latency in microseconds.
 	LatencyMicros int64 `json:"latency_micros,omitempty"`
 
@@ -174,7 +189,36 @@ type AuditRecord struct {
 	// Populated when the tool call is allowed and a response is received.
 	ResponseBody string `json:"response_body,omitempty"`
 
+	// UpstreamToken describes the end-user token presented to the upstream,
+	// if token passthrough or exchange was applied to this call.
+	UpstreamToken *UpstreamTokenUse `json:"upstream_token,omitempty"`
+
 	// Source indicates the origin of the audit record (M-19).
 	// Empty for real traffic; "admin_evaluate" for policy evaluate endpoint simulations.
 	Source string `json:"source,omitempty"`
+
+	// SourceCountry is the client's resolved ISO country code, set when an
+	// identity's country restrictions were evaluated.
+	SourceCountry string `json:"source_country,omitempty"`
+	// AccessRestriction names the identity access restriction that denied
+	// the request: "time_window" or "country".
+	AccessRestriction string `json:"access_restriction,omitempty"`
+
+	// PayloadSampled is set when the full arguments and result of the call
+	// were stored in the payload store, under the same request ID.
+	PayloadSampled bool `json:"payload_sampled,omitempty"`
+
+	// PromptHash is the SHA-256 of the messages and system prompt of a
+	// sampling/createMessage request sent by an upstream, hex-encoded.
+	PromptHash string `json:"prompt_hash,omitempty"`
+
+	// DestIP is the destination address of the call, when known.
+	DestIP string `json:"dest_ip,omitempty"`
+	// DestCountry is the ISO country code of DestIP, when located by the
+	// GeoIP database.
+	DestCountry string `json:"dest_country,omitempty"`
+	// DestASN and DestASNOrg are the autonomous system of DestIP, when
+	// located by the GeoIP ASN database.
+	DestASN    uint   `json:"dest_asn,omitempty"`
+	DestASNOrg string `json:"dest_asn_org,omitempty"`
 }
@@ -266,3 +266,99 @@ func TestAuditTransformContext_MissingReturnsNil(t *testing.T) {
 		t.Error("TransformResultFromContext on plain context should return nil")
 	}
 }
+
+func TestDecodeRecord_RollsForward(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{"v1 unversioned", `{"timestamp":"2025-01-02T03:04:05Z","tool_name":"read_file","decision":"allow"}`},
+		{"v2 unversioned", `{"timestamp":"2025-01-02T03:04:05Z","tool_name":"read_file","decision":"deny","source_country":"IT","access_restriction":"country"}`},
+		{"v3", `{"schema_version":3,"timestamp":"2025-01-02T03:04:05Z","tool_name":"read_file","decision":"allow"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, err := DecodeRecord([]byte(tt.line))
+			if err != nil {
+				t.Fatalf("DecodeRecord: %v", err)
+			}
+			if rec.SchemaVersion != CurrentSchemaVersion || rec.ToolName != "read_file" {
+				t.Errorf("record = %+v, want read_file at version %d", rec, CurrentSchemaVersion)
+			}
+		})
+	}
+
+	rec, err := DecodeRecord([]byte(`{"schema_version":99,"tool_name":"x","future_field":true}`))
+	if err != nil || rec.SchemaVersion != 99 {
+		t.Errorf("newer record = %+v, %v; want decoded with its own version", rec, err)
+	}
+	if _, err := DecodeRecord([]byte(`{"schema_version":-1}`)); err == nil {
+		t.Error("expected error for unsupported version")
+	}
+}
+
+func TestSchema_DescribesAllFields(t *testing.T) {
+	desc := Schema()
+	if desc.MinSupportedVersion > CurrentSchemaVersion-2 {
+		t.Errorf("min supported version %d, want the two previous versions readable", desc.MinSupportedVersion)
+	}
+	if last := desc.Versions[len(desc.Versions)-1].Version; last != CurrentSchemaVersion {
+		t.Errorf("last described version = %d, want %d", last, CurrentSchemaVersion)
+	}
+
+	byName := make(map[string]SchemaField)
+	for _, f := range desc.Fields {
+		byName[f.Name] = f
+	}
+	data, err := json.Marshal(AuditRecord{SchemaVersion: CurrentSchemaVersion, Timestamp: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var present map[string]json.RawMessage
+	_ = json.Unmarshal(data, &present)
+	for name := range present {
+		if _, ok := byName[name]; !ok {
+			t.Errorf("field %q missing from descriptor", name)
+		}
+	}
+	if f := byName["timestamp"]; f.Type != "timestamp" || f.Optional {
+		t.Errorf("timestamp field = %+v", f)
+	}
+	if f := byName["schema_version"]; f.Since != 3 || f.Type != "integer" {
+		t.Errorf("schema_version field = %+v", f)
+	}
+	for name, since := range schemaFieldSince {
+		if _, ok := byName[name]; !ok || since > CurrentSchemaVersion {
+			t.Errorf("schemaFieldSince entry %q (%d) does not match the record", name, since)
+		}
+	}
+}
+
+func TestPayloadSampler(t *testing.T) {
+	s, err := NewPayloadSampler([]PayloadSampleRule{
+		{Tool: "payments_refund", Rate: 1},
+		{Tool: "read_secret", Rate: 0},
+		{Tool: "read_*", Rate: 0.01},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.random = func() float64 { return 0.005 }
+	if !s.Sample("payments_refund") || s.Sample("read_secret") || !s.Sample("read_file") || s.Sample("write_file") {
+		t.Error("unexpected sampling with a draw below the read_* rate")
+	}
+	s.random = func() float64 { return 0.5 }
+	if s.Sample("read_file") || !s.Sample("payments_refund") {
+		t.Error("unexpected sampling with a draw above the read_* rate")
+	}
+
+	for _, rules := range [][]PayloadSampleRule{
+		{{Tool: "", Rate: 1}},
+		{{Tool: "[bad", Rate: 1}},
+		{{Tool: "x", Rate: 1.5}},
+	} {
+		if _, err := NewPayloadSampler(rules); err == nil {
+			t.Errorf("NewPayloadSampler(%+v) should fail", rules)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package audit
+
+import "context"
+
+// UpstreamTokenUse records that an end-user token was presented to an
+// upstream on behalf of the caller. The token itself is never stored.
+type UpstreamTokenUse struct {
+	// Upstream is the name of the upstream that received the token.
+	Upstream string `json:"upstream"`
+	// Mode is "passthrough" or "exchange".
+	Mode string `json:"mode"`
+	// Audience is the exchanged token audience (exchange mode only).
+	Audience string `json:"audience,omitempty"`
+	// Subject is the token subject, when known.
+	Subject string `json:"subject,omitempty"`
+	// Fingerprint is a short hash of the token sent upstream.
+	Fingerprint string `json:"fingerprint"`
+	// Cached reports whether an exchanged token was served from cache.
+	Cached bool `json:"cached,omitempty"`
+}
+
+// upstreamTokenContextKey is the context key type for upstream token use propagation.
+type upstreamTokenContextKey struct{}
+
+// UpstreamTokenHolder is a mutable container placed in context by the
+// AuditInterceptor. The upstream credential injector populates it when a
+// user token is attached to the forwarded request.
+type UpstreamTokenHolder struct {
+	Use *UpstreamTokenUse
+}
+
+// NewUpstreamTokenContext returns a new context with an empty UpstreamTokenHolder.
+// The AuditInterceptor calls this before invoking the chain.
+func NewUpstreamTokenContext(ctx context.Context) (context.Context, *UpstreamTokenHolder) {
+	holder := &UpstreamTokenHolder{}
+	return context.WithValue(ctx, upstreamTokenContextKey{}, holder), holder
+}
+
+// UpstreamTokenFromContext retrieves the UpstreamTokenHolder from context.
+// Returns nil if not present.
+func UpstreamTokenFromContext(ctx context.Context) *UpstreamTokenHolder {
+	holder, _ := ctx.Value(upstreamTokenContextKey{}).(*UpstreamTokenHolder)
+	return holder
+}
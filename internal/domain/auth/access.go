@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Access restriction kinds, reported in denials and audit records.
+const (
+	// RestrictionTimeWindow denies connections outside the allowed hours.
+	RestrictionTimeWindow = "time_window"
+	// RestrictionCountry denies connections from disallowed countries.
+	RestrictionCountry = "country"
+)
+
+// CountryResolver maps a client IP address to its ISO 3166-1 alpha-2
+// country code. Interface owned by domain per hexagonal architecture;
+// implemented by the GeoIP adapter.
+type CountryResolver interface {
+	// Country returns the country code of ip, or "" when it is unknown.
+	Country(ip net.IP) (string, error)
+}
+
+// AccessWindow is a recurring period during which an identity may connect.
+type AccessWindow struct {
+	// Days limits the window to these weekdays. Empty means every day.
+	Days []time.Weekday
+	// Start and End are "HH:MM" times of day. An End before Start spans
+	// midnight; Start equal to End covers the whole day.
+	Start string
+	End   string
+
+	start, end int // minutes since midnight
+}
+
+// AccessRestrictions limits when and from where an identity may connect.
+// Build it with NewAccessRestrictions; it is immutable afterwards.
+type AccessRestrictions struct {
+	// Timezone is the IANA zone the windows are evaluated in.
+	Timezone string
+	// Windows are the allowed periods. Empty means any time.
+	Windows []AccessWindow
+	// AllowCountries, when set, admits only clients from these countries.
+	// Clients whose country cannot be resolved are refused.
+	AllowCountries []string
+	// DenyCountries refuses clients from these countries.
+	DenyCountries []string
+
+	loc *time.Location
+}
+
+// AccessDenial explains why an identity was refused.
+type AccessDenial struct {
+	// Restriction is RestrictionTimeWindow or RestrictionCountry.
+	Restriction string
+	// Reason is a human-readable explanation, safe to show to the client.
+	Reason string
+	// Country is the resolved client country, empty when unknown.
+	Country string
+}
+
+// NewAccessRestrictions validates the restrictions and prepares them for
+// Check. timezone defaults to UTC; countries are ISO 3166-1 alpha-2 codes.
+func NewAccessRestrictions(timezone string, windows []AccessWindow, allowCountries, denyCountries []string) (*AccessRestrictions, error) {
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+	}
+	r := &AccessRestrictions{Timezone: timezone, loc: loc}
+	for i, w := range windows {
+		if w.start, err = parseTimeOfDay(w.Start); err != nil {
+			return nil, fmt.Errorf("windows[%d].start: %w", i, err)
+		}
+		if w.end, err = parseTimeOfDay(w.End); err != nil {
+			return nil, fmt.Errorf("windows[%d].end: %w", i, err)
+		}
+		w.Days = slices.Clone(w.Days)
+		r.Windows = append(r.Windows, w)
+	}
+	if r.AllowCountries, err = normalizeCountries(allowCountries); err != nil {
+		return nil, fmt.Errorf("allow_countries: %w", err)
+	}
+	if r.DenyCountries, err = normalizeCountries(denyCountries); err != nil {
+		return nil, fmt.Errorf("deny_countries: %w", err)
+	}
+	return r, nil
+}
+
+// ParseWeekday parses a weekday name such as "mon" or "Monday".
+func ParseWeekday(s string) (time.Weekday, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if s == name || s == name[:3] {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid weekday %q", s)
+}
+
+func parseTimeOfDay(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (want HH:MM)", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func normalizeCountries(codes []string) ([]string, error) {
+	var out []string
+	for _, c := range codes {
+		c = strings.ToUpper(strings.TrimSpace(c))
+		if len(c) != 2 || c[0] < 'A' || c[0] > 'Z' || c[1] < 'A' || c[1] > 'Z' {
+			return nil, fmt.Errorf("invalid country code %q (want ISO 3166-1 alpha-2, e.g. \"IT\")", c)
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// HasCountryRules reports whether the restrictions depend on the client's
+// country.
+func (r *AccessRestrictions) HasCountryRules() bool {
+	return r != nil && (len(r.AllowCountries) > 0 || len(r.DenyCountries) > 0)
+}
+
+// Check returns why a connection at now from clientIP is refused, or nil if
+// it is allowed. geo may be nil, in which case the country is unknown.
+func (r *AccessRestrictions) Check(now time.Time, clientIP string, geo CountryResolver) *AccessDenial {
+	if r == nil {
+		return nil
+	}
+	if len(r.Windows) > 0 && !r.inWindow(now) {
+		return &AccessDenial{
+			Restriction: RestrictionTimeWindow,
+			Reason:      fmt.Sprintf("outside allowed access hours (%s)", r.describeWindows()),
+		}
+	}
+	if !r.HasCountryRules() {
+		return nil
+	}
+
+	country := ""
+	if ip := net.ParseIP(clientIP); ip != nil && geo != nil {
+		country, _ = geo.Country(ip)
+	}
+	if country != "" && slices.Contains(r.DenyCountries, country) {
+		return &AccessDenial{
+			Restriction: RestrictionCountry,
+			Reason:      fmt.Sprintf("connections from %s are not allowed", country),
+			Country:     country,
+		}
+	}
+	if len(r.AllowCountries) > 0 && !slices.Contains(r.AllowCountries, country) {
+		from := "an unknown country"
+		if country != "" {
+			from = country
+		}
+		return &AccessDenial{
+			Restriction: RestrictionCountry,
+			Reason:      fmt.Sprintf("connections from %s are not allowed (allowed: %s)", from, strings.Join(r.AllowCountries, ", ")),
+			Country:     country,
+		}
+	}
+	return nil
+}
+
+// inWindow reports whether now falls in any access window.
+func (r *AccessRestrictions) inWindow(now time.Time) bool {
+	loc := r.loc
+	if loc == nil {
+		loc = time.UTC
+	}
+	t := now.In(loc)
+	minute := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+	yesterday := (day + 6) % 7
+	for _, w := range r.Windows {
+		switch {
+		case w.start == w.end:
+			if w.onDay(day) {
+				return true
+			}
+		case w.start < w.end:
+			if w.onDay(day) && minute >= w.start && minute < w.end {
+				return true
+			}
+		default: // spans midnight: the part after midnight belongs to the previous day
+			if (w.onDay(day) && minute >= w.start) || (w.onDay(yesterday) && minute < w.end) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (w AccessWindow) onDay(d time.Weekday) bool {
+	return len(w.Days) == 0 || slices.Contains(w.Days, d)
+}
+
+// describeWindows renders the windows for denial messages, e.g.
+// "Mon,Tue 09:00-18:00 Europe/Rome".
+func (r *AccessRestrictions) describeWindows() string {
+	parts := make([]string, 0, len(r.Windows))
+	for _, w := range r.Windows {
+		days := "every day"
+		if len(w.Days) > 0 {
+			names := make([]string, len(w.Days))
+			for i, d := range w.Days {
+				names[i] = d.String()[:3]
+			}
+			days = strings.Join(names, ",")
+		}
+		parts = append(parts, fmt.Sprintf("%s %s-%s", days, w.Start, w.End))
+	}
+	return strings.Join(parts, "; ") + " " + r.Timezone
+}
@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+type fakeResolver map[string]string
+
+func (f fakeResolver) Country(ip net.IP) (string, error) {
+	return f[ip.String()], nil
+}
+
+func TestAccessRestrictions_Windows(t *testing.T) {
+	r, err := NewAccessRestrictions("Europe/Rome", []AccessWindow{
+		{Days: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}, Start: "09:00", End: "18:00"},
+		{Days: []time.Weekday{time.Saturday}, Start: "22:00", End: "02:00"},
+	}, nil, nil)
+	if err != nil {
+		t.Fatalf("NewAccessRestrictions: %v", err)
+	}
+	rome, _ := time.LoadLocation("Europe/Rome")
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want bool
+	}{
+		{"weekday inside", time.Date(2026, 3, 2, 10, 0, 0, 0, rome), true}, // Monday
+		{"weekday before start", time.Date(2026, 3, 2, 8, 59, 0, 0, rome), false},
+		{"end is exclusive", time.Date(2026, 3, 2, 18, 0, 0, 0, rome), false},
+		{"evaluated in identity timezone", time.Date(2026, 3, 2, 8, 30, 0, 0, time.UTC), true},
+		{"saturday before midnight", time.Date(2026, 3, 7, 23, 0, 0, 0, rome), true},
+		{"sunday after midnight belongs to saturday", time.Date(2026, 3, 8, 1, 0, 0, 0, rome), true},
+		{"sunday evening", time.Date(2026, 3, 8, 23, 0, 0, 0, rome), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			denial := r.Check(tt.at, "", nil)
+			if got := denial == nil; got != tt.want {
+				t.Errorf("allowed = %v, want %v (denial %+v)", got, tt.want, denial)
+			}
+			if denial != nil && denial.Restriction != RestrictionTimeWindow {
+				t.Errorf("restriction = %q, want %q", denial.Restriction, RestrictionTimeWindow)
+			}
+		})
+	}
+}
+
+func TestAccessRestrictions_Countries(t *testing.T) {
+	geo := fakeResolver{"203.0.113.1": "IT", "198.51.100.1": "RU"}
+	now := time.Now()
+
+	allow, _ := NewAccessRestrictions("", nil, []string{"it", "DE"}, nil)
+	if d := allow.Check(now, "203.0.113.1", geo); d != nil {
+		t.Errorf("allowed country denied: %+v", d)
+	}
+	if d := allow.Check(now, "198.51.100.1", geo); d == nil || d.Restriction != RestrictionCountry || d.Country != "RU" {
+		t.Errorf("other country = %+v, want country denial for RU", d)
+	}
+	if d := allow.Check(now, "192.0.2.1", geo); d == nil {
+		t.Error("unknown country allowed by allow list, want denial")
+	}
+	if d := allow.Check(now, "203.0.113.1", nil); d == nil {
+		t.Error("allow list without resolver allowed, want denial")
+	}
+
+	deny, _ := NewAccessRestrictions("", nil, nil, []string{"RU"})
+	if d := deny.Check(now, "198.51.100.1", geo); d == nil || d.Reason != "connections from RU are not allowed" {
+		t.Errorf("denied country = %+v", d)
+	}
+	if d := deny.Check(now, "local", geo); d != nil {
+		t.Errorf("unknown country denied by deny list: %+v", d)
+	}
+
+	var unrestricted *AccessRestrictions
+	if d := unrestricted.Check(now, "198.51.100.1", geo); d != nil {
+		t.Errorf("nil restrictions denied: %+v", d)
+	}
+}
+
+func TestNewAccessRestrictions_Invalid(t *testing.T) {
+	cases := map[string]func() error{
+		"timezone": func() error { _, err := NewAccessRestrictions("Mars/Olympus", nil, nil, nil); return err },
+		"start": func() error {
+			_, err := NewAccessRestrictions("", []AccessWindow{{Start: "9am", End: "17:00"}}, nil, nil)
+			return err
+		},
+		"end": func() error {
+			_, err := NewAccessRestrictions("", []AccessWindow{{Start: "09:00", End: "24:30"}}, nil, nil)
+			return err
+		},
+		"country": func() error { _, err := NewAccessRestrictions("", nil, []string{"ITA"}, nil); return err },
+	}
+	for name, fn := range cases {
+		if fn() == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	if _, err := ParseWeekday("Thu"); err != nil {
+		t.Errorf("ParseWeekday(Thu): %v", err)
+	}
+	if _, err := ParseWeekday("someday"); err == nil {
+		t.Error("ParseWeekday(someday): expected error")
+	}
+}
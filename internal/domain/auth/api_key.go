@@ -37,16 +37,23 @@ func NewAPIKeyService(store AuthStore) *APIKeyService {
 //  2. Argon2id prefix lookup (O(1), fast path for UI-created keys with stored prefix)
 //  3. Full iteration fallback (O(n), backward compat for keys without prefix)
 func (s *APIKeyService) Validate(ctx context.Context, rawKey string) (*Identity, error) {
+	identity, _, err := s.ValidateKey(ctx, rawKey)
+	return identity, err
+}
+
+// ValidateKey is like Validate but also returns the matched key, so callers
+// can enforce per-key restrictions such as the origin binding.
+func (s *APIKeyService) ValidateKey(ctx context.Context, rawKey string) (*Identity, *APIKey, error) {
 	// M-31: Reject empty keys immediately — the SHA-256 of "" is a well-known
 	// constant and must never authenticate.
 	if rawKey == "" {
-		return nil, ErrInvalidKey
+		return nil, nil, ErrInvalidKey
 	}
 	// Fast path 1: SHA-256 direct lookup (for YAML-seeded keys)
 	keyHash := HashKey(rawKey)
 	apiKey, err := s.store.GetAPIKey(ctx, keyHash)
 	if err == nil {
-		return s.validateAndResolve(ctx, apiKey)
+		return s.resolveKey(ctx, apiKey)
 	}
 
 	// Fast path 2: Argon2id prefix lookup (O(1) instead of O(n) iteration)
@@ -58,7 +65,7 @@ func (s *APIKeyService) Validate(ctx context.Context, rawKey string) (*Identity,
 			// Found a candidate by prefix — do single Argon2id verification
 			match, verifyErr := VerifyKey(rawKey, candidate.Key)
 			if verifyErr == nil && match {
-				return s.validateAndResolve(ctx, candidate)
+				return s.resolveKey(ctx, candidate)
 			}
 			// Prefix matched but key didn't verify — wrong key, don't fall through to allow
 			// (prefix collision is theoretically possible; fall through to iteration for safety)
@@ -68,7 +75,7 @@ func (s *APIKeyService) Validate(ctx context.Context, rawKey string) (*Identity,
 	// Fallback: iterate all keys (backward compat for keys without prefix stored)
 	allKeys, err := s.store.ListAPIKeys(ctx)
 	if err != nil {
-		return nil, ErrInvalidKey
+		return nil, nil, ErrInvalidKey
 	}
 
 	for _, candidate := range allKeys {
@@ -81,11 +88,20 @@ func (s *APIKeyService) Validate(ctx context.Context, rawKey string) (*Identity,
 			continue
 		}
 		if match {
-			return s.validateAndResolve(ctx, candidate)
+			return s.resolveKey(ctx, candidate)
 		}
 	}
 
-	return nil, ErrInvalidKey
+	return nil, nil, ErrInvalidKey
+}
+
+// resolveKey resolves the identity of a matched key and returns both.
+func (s *APIKeyService) resolveKey(ctx context.Context, apiKey *APIKey) (*Identity, *APIKey, error) {
+	identity, err := s.validateAndResolve(ctx, apiKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return identity, apiKey, nil
 }
 
 // validateAndResolve checks revocation/expiry and returns the identity.
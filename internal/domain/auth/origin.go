@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// NormalizeOrigin returns the canonical form of a browser origin:
+// lowercase, without a trailing slash.
+func NormalizeOrigin(origin string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(origin)), "/")
+}
+
+// ValidateOrigin checks that origin is a bare browser origin of the form
+// scheme://host[:port], as sent in the Origin header.
+func ValidateOrigin(origin string) error {
+	u, err := url.Parse(NormalizeOrigin(origin))
+	if err != nil {
+		return fmt.Errorf("invalid origin %q: %w", origin, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid origin %q: scheme must be http or https", origin)
+	}
+	if u.Host == "" || u.User != nil || u.Path != "" || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("invalid origin %q: must be scheme://host[:port]", origin)
+	}
+	return nil
+}
+
+// AllowsOrigin reports whether the key may be used from the given origin.
+// Keys without a binding allow any origin, and an empty origin (a
+// non-browser client) is always allowed: the binding only stops web pages
+// on other origins from using a leaked key.
+func (k *APIKey) AllowsOrigin(origin string) bool {
+	return OriginAllowed(k.AllowedOrigins, origin)
+}
+
+// OriginAllowed applies the AllowsOrigin rules to an origin binding.
+func OriginAllowed(allowedOrigins []string, origin string) bool {
+	if len(allowedOrigins) == 0 || origin == "" {
+		return true
+	}
+	origin = NormalizeOrigin(origin)
+	for _, allowed := range allowedOrigins {
+		if NormalizeOrigin(allowed) == origin {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package auth
+
+import "testing"
+
+func TestAPIKey_AllowsOrigin(t *testing.T) {
+	bound := &APIKey{AllowedOrigins: []string{"https://app.example.com", "http://localhost:3000/"}}
+	unbound := &APIKey{}
+
+	tests := []struct {
+		name   string
+		key    *APIKey
+		origin string
+		want   bool
+	}{
+		{"unbound key allows any origin", unbound, "https://evil.example", true},
+		{"no origin is a non-browser client", bound, "", true},
+		{"exact match", bound, "https://app.example.com", true},
+		{"case-insensitive", bound, "HTTPS://App.Example.com", true},
+		{"trailing slash ignored", bound, "http://localhost:3000", true},
+		{"other origin", bound, "https://evil.example", false},
+		{"scheme must match", bound, "http://app.example.com", false},
+		{"port must match", bound, "http://localhost:3001", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.key.AllowsOrigin(tt.origin); got != tt.want {
+				t.Errorf("AllowsOrigin(%q) = %v, want %v", tt.origin, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateOrigin(t *testing.T) {
+	for _, origin := range []string{"https://app.example.com", "http://localhost:3000", "https://app.example.com/"} {
+		if err := ValidateOrigin(origin); err != nil {
+			t.Errorf("ValidateOrigin(%q) unexpected error: %v", origin, err)
+		}
+	}
+	for _, origin := range []string{"", "app.example.com", "ftp://app.example.com", "https://app.example.com/path", "https://user@app.example.com", "https://app.example.com?x=1"} {
+		if err := ValidateOrigin(origin); err == nil {
+			t.Errorf("ValidateOrigin(%q) expected error", origin)
+		}
+	}
+}
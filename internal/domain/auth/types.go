@@ -39,6 +39,9 @@ type Identity struct {
 	Name string
 	// Roles are the roles assigned to this identity.
 	Roles []Role
+	// Access restricts when and from where the identity may connect.
+	// Nil means unrestricted.
+	Access *AccessRestrictions
 }
 
 // HasRole returns true if the identity has the specified role.
@@ -79,6 +82,10 @@ type APIKey struct {
 	ExpiresAt *time.Time
 	// Revoked indicates if the key has been revoked.
 	Revoked bool
+	// AllowedOrigins binds the key to browser origins (scheme://host[:port]).
+	// Requests sending an Origin header outside this list are rejected.
+	// Empty means the key is not bound to any origin.
+	AllowedOrigins []string
 }
 
 // IsExpired returns true if the API key has expired.
@@ -0,0 +1,250 @@
+// Package denyloop detects deny loops: an identity retrying the same denied
+// tool call over and over in a short window, as agents stuck in a retry
+// loop do. Once a call has been denied Threshold times within Window, it is
+// rejected without evaluation for a backoff that doubles with every further
+// attempt, so a runaway loop stops burning rate limits and audit records.
+package denyloop
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Defaults used for zero Config fields.
+const (
+	DefaultThreshold   = 5
+	DefaultWindow      = time.Minute
+	DefaultBaseBackoff = time.Second
+	DefaultMaxBackoff  = time.Minute
+)
+
+// maxEntries bounds the calls tracked at once. When full, new calls are not
+// tracked until older entries expire.
+const maxEntries = 10000
+
+// Config configures a Detector.
+type Config struct {
+	// Threshold is the number of denials of the same call within Window
+	// that makes a loop.
+	Threshold int
+	// Window is how long a denial counts. A call not denied for Window
+	// leaves its loop.
+	Window time.Duration
+	// BaseBackoff is the backoff after the denial reaching Threshold. It
+	// doubles with every further denial.
+	BaseBackoff time.Duration
+	// MaxBackoff caps the backoff.
+	MaxBackoff time.Duration
+}
+
+func (c Config) withDefaults() Config {
+	if c.Threshold <= 0 {
+		c.Threshold = DefaultThreshold
+	}
+	if c.Window <= 0 {
+		c.Window = DefaultWindow
+	}
+	if c.BaseBackoff <= 0 {
+		c.BaseBackoff = DefaultBaseBackoff
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = DefaultMaxBackoff
+	}
+	if c.MaxBackoff < c.BaseBackoff {
+		c.MaxBackoff = c.BaseBackoff
+	}
+	return c
+}
+
+// Denial describes one denied call.
+type Denial struct {
+	IdentityID   string
+	IdentityName string
+	Tool         string
+	// Rule and Reason are those of the policy rule that denied the call.
+	// They are kept from the last policy denial; calls rejected by the
+	// backoff leave them unchanged.
+	Rule   string
+	Reason string
+}
+
+// Loop is a call currently in a deny loop.
+type Loop struct {
+	// ID is the key of the call: a hash of the identity, tool and arguments.
+	ID            string    `json:"id"`
+	IdentityID    string    `json:"identity_id"`
+	IdentityName  string    `json:"identity_name,omitempty"`
+	Tool          string    `json:"tool"`
+	Denials       int       `json:"denials"`
+	FirstDeniedAt time.Time `json:"first_denied_at"`
+	LastDeniedAt  time.Time `json:"last_denied_at"`
+	BlockedUntil  time.Time `json:"blocked_until"`
+	Rule          string    `json:"rule,omitempty"`
+	Reason        string    `json:"reason,omitempty"`
+}
+
+// Detector counts denials per call. It is safe for concurrent use.
+type Detector struct {
+	cfg Config
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*Loop
+}
+
+// NewDetector creates a Detector. Zero Config fields take the defaults.
+func NewDetector(cfg Config) *Detector {
+	return &Detector{
+		cfg:     cfg.withDefaults(),
+		now:     time.Now,
+		entries: make(map[string]*Loop),
+	}
+}
+
+// Config returns the effective configuration.
+func (d *Detector) Config() Config {
+	return d.cfg
+}
+
+// Key identifies a call by identity, tool and arguments. Arguments are
+// compared by their JSON encoding, which sorts object keys.
+func Key(identityID, tool string, args map[string]interface{}) string {
+	h := sha256.New()
+	h.Write([]byte(identityID))
+	h.Write([]byte{0})
+	h.Write([]byte(tool))
+	h.Write([]byte{0})
+	if len(args) > 0 {
+		if b, err := json.Marshal(args); err == nil {
+			h.Write(b)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// Blocked reports whether the call is in its backoff, and for how long.
+func (d *Detector) Blocked(key string) (time.Duration, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	e, ok := d.entries[key]
+	if !ok {
+		return 0, false
+	}
+	remaining := e.BlockedUntil.Sub(d.now())
+	if remaining <= 0 {
+		return 0, false
+	}
+	return remaining, true
+}
+
+// RecordDenial counts a denial of the call. It returns the state of the
+// call and whether this denial started a loop. Once in a loop, every
+// denial sets a new backoff.
+func (d *Detector) RecordDenial(key string, denial Denial) (Loop, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := d.now()
+
+	e, ok := d.entries[key]
+	if ok && d.expired(e, now) {
+		delete(d.entries, key)
+		ok = false
+	}
+	if !ok {
+		if len(d.entries) >= maxEntries {
+			d.purgeLocked(now)
+			if len(d.entries) >= maxEntries {
+				return Loop{}, false
+			}
+		}
+		e = &Loop{
+			ID:            key,
+			IdentityID:    denial.IdentityID,
+			IdentityName:  denial.IdentityName,
+			Tool:          denial.Tool,
+			FirstDeniedAt: now,
+		}
+		d.entries[key] = e
+	}
+
+	e.Denials++
+	e.LastDeniedAt = now
+	if denial.Rule != "" || denial.Reason != "" {
+		e.Rule = denial.Rule
+		e.Reason = denial.Reason
+	}
+	if e.Denials >= d.cfg.Threshold {
+		e.BlockedUntil = now.Add(d.backoff(e.Denials - d.cfg.Threshold))
+	}
+	return *e, e.Denials == d.cfg.Threshold
+}
+
+// Reset forgets the call, after it succeeded.
+func (d *Detector) Reset(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.entries, key)
+}
+
+// Clear ends the loop with the given ID. It reports whether there was one.
+func (d *Detector) Clear(id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	e, ok := d.entries[id]
+	if !ok || e.Denials < d.cfg.Threshold || d.expired(e, d.now()) {
+		return false
+	}
+	delete(d.entries, id)
+	return true
+}
+
+// Loops returns the calls currently in a loop, most recently denied first.
+func (d *Detector) Loops() []Loop {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := d.now()
+	d.purgeLocked(now)
+	loops := make([]Loop, 0)
+	for _, e := range d.entries {
+		if e.Denials >= d.cfg.Threshold {
+			loops = append(loops, *e)
+		}
+	}
+	sort.Slice(loops, func(i, j int) bool {
+		if !loops[i].LastDeniedAt.Equal(loops[j].LastDeniedAt) {
+			return loops[i].LastDeniedAt.After(loops[j].LastDeniedAt)
+		}
+		return loops[i].ID < loops[j].ID
+	})
+	return loops
+}
+
+// backoff returns BaseBackoff doubled n times, capped at MaxBackoff.
+func (d *Detector) backoff(n int) time.Duration {
+	b := d.cfg.BaseBackoff
+	for i := 0; i < n && b < d.cfg.MaxBackoff; i++ {
+		b *= 2
+	}
+	if b > d.cfg.MaxBackoff {
+		b = d.cfg.MaxBackoff
+	}
+	return b
+}
+
+// expired reports whether the call was last denied more than Window ago
+// and its backoff is over.
+func (d *Detector) expired(e *Loop, now time.Time) bool {
+	return now.Sub(e.LastDeniedAt) > d.cfg.Window && !now.Before(e.BlockedUntil)
+}
+
+func (d *Detector) purgeLocked(now time.Time) {
+	for key, e := range d.entries {
+		if d.expired(e, now) {
+			delete(d.entries, key)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package denyloop
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestDetector(cfg Config) (*Detector, *time.Time) {
+	d := NewDetector(cfg)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+	return d, &now
+}
+
+func TestKey(t *testing.T) {
+	a := Key("id-1", "read_file", map[string]interface{}{"path": "/etc/passwd", "lines": 10})
+	b := Key("id-1", "read_file", map[string]interface{}{"lines": 10, "path": "/etc/passwd"})
+	if a != b {
+		t.Error("key depends on argument order")
+	}
+	if a == Key("id-2", "read_file", map[string]interface{}{"path": "/etc/passwd", "lines": 10}) {
+		t.Error("key ignores the identity")
+	}
+	if a == Key("id-1", "read_file", map[string]interface{}{"path": "/etc/shadow", "lines": 10}) {
+		t.Error("key ignores the arguments")
+	}
+	if Key("id-1", "ab", nil) == Key("id-1a", "b", nil) {
+		t.Error("identity and tool are not separated")
+	}
+}
+
+func TestDetector_LoopAndBackoff(t *testing.T) {
+	d, now := newTestDetector(Config{Threshold: 3, Window: time.Minute, BaseBackoff: time.Second, MaxBackoff: 4 * time.Second})
+	key := Key("id-1", "delete_file", nil)
+	denial := Denial{IdentityID: "id-1", IdentityName: "alice", Tool: "delete_file", Rule: "no-delete", Reason: "denied"}
+
+	for i := 1; i < 3; i++ {
+		if _, started := d.RecordDenial(key, denial); started {
+			t.Fatalf("loop started after %d denials", i)
+		}
+		if _, blocked := d.Blocked(key); blocked {
+			t.Fatalf("blocked after %d denials", i)
+		}
+	}
+	if len(d.Loops()) != 0 {
+		t.Fatal("loop listed before the threshold")
+	}
+
+	loop, started := d.RecordDenial(key, denial)
+	if !started || loop.Denials != 3 {
+		t.Fatalf("started = %v, denials = %d; want true, 3", started, loop.Denials)
+	}
+	if retry, blocked := d.Blocked(key); !blocked || retry != time.Second {
+		t.Fatalf("Blocked = %v, %v; want 1s, true", retry, blocked)
+	}
+
+	// Every further denial doubles the backoff, up to the cap. Rejections
+	// by the backoff keep the rule of the last policy denial.
+	wants := []time.Duration{2 * time.Second, 4 * time.Second, 4 * time.Second}
+	for _, want := range wants {
+		loop, started = d.RecordDenial(key, Denial{IdentityID: "id-1", Tool: "delete_file"})
+		if started {
+			t.Fatal("loop started twice")
+		}
+		if got := loop.BlockedUntil.Sub(*now); got != want {
+			t.Fatalf("backoff = %v, want %v", got, want)
+		}
+	}
+	if loop.Rule != "no-delete" {
+		t.Errorf("rule = %q, want no-delete", loop.Rule)
+	}
+
+	loops := d.Loops()
+	if len(loops) != 1 || loops[0].ID != key || loops[0].IdentityName != "alice" || loops[0].Denials != 6 {
+		t.Fatalf("Loops() = %+v", loops)
+	}
+
+	*now = now.Add(5 * time.Second)
+	if _, blocked := d.Blocked(key); blocked {
+		t.Fatal("still blocked after the backoff")
+	}
+}
+
+func TestDetector_WindowExpiry(t *testing.T) {
+	d, now := newTestDetector(Config{Threshold: 2, Window: time.Minute})
+	key := Key("id-1", "tool", nil)
+
+	d.RecordDenial(key, Denial{IdentityID: "id-1", Tool: "tool"})
+	*now = now.Add(2 * time.Minute)
+	if _, started := d.RecordDenial(key, Denial{IdentityID: "id-1", Tool: "tool"}); started {
+		t.Fatal("denials outside the window counted")
+	}
+
+	d.RecordDenial(key, Denial{IdentityID: "id-1", Tool: "tool"})
+	if len(d.Loops()) != 1 {
+		t.Fatal("expected a loop")
+	}
+	*now = now.Add(2 * time.Minute)
+	if len(d.Loops()) != 0 {
+		t.Fatal("loop still listed after the window")
+	}
+}
+
+func TestDetector_ResetAndClear(t *testing.T) {
+	d, _ := newTestDetector(Config{Threshold: 1})
+	key := Key("id-1", "tool", nil)
+
+	d.RecordDenial(key, Denial{IdentityID: "id-1", Tool: "tool"})
+	d.Reset(key)
+	if _, blocked := d.Blocked(key); blocked {
+		t.Fatal("blocked after reset")
+	}
+
+	d.RecordDenial(key, Denial{IdentityID: "id-1", Tool: "tool"})
+	if !d.Clear(key) {
+		t.Fatal("Clear() = false for a current loop")
+	}
+	if d.Clear(key) {
+		t.Fatal("Clear() = true for a cleared loop")
+	}
+	if _, blocked := d.Blocked(key); blocked {
+		t.Fatal("blocked after clear")
+	}
+}
@@ -45,6 +45,7 @@ func (s Severity) String() string {
 // Event is the standard internal event type emitted by all upgrades.
 type Event struct {
 	Type           string    `json:"type"`            // e.g. "tool.changed", "drift.anomaly"
+	Category       Category  `json:"category"`        // Set from Type on Publish when empty
 	Source         string    `json:"source"`          // e.g. "tool-integrity", "drift-detector"
 	Severity       Severity  `json:"severity"`        // Critical, Warning, Info
 	Payload        any       `json:"payload"`         // Type-specific data
@@ -136,6 +137,9 @@ func (b *InProcessBus) Publish(ctx context.Context, event Event) {
 	if event.Timestamp.IsZero() {
 		event.Timestamp = time.Now()
 	}
+	if event.Category == "" {
+		event.Category = CategoryOf(event.Type)
+	}
 	select {
 	case <-b.done:
 		b.dropped.Add(1)
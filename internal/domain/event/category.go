@@ -0,0 +1,148 @@
+package event
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Category groups event types for routing to sinks.
+type Category string
+
+const (
+	// CategorySecurity covers detections and decisions that need a security
+	// review: tool integrity, content scanning, drift, approvals, permissions.
+	CategorySecurity Category = "security"
+	// CategoryLifecycle covers the state of the gateway and its upstreams:
+	// connections, scheduled jobs, health and budgets.
+	CategoryLifecycle Category = "lifecycle"
+	// CategoryAdmin covers changes made by administrators.
+	CategoryAdmin Category = "admin"
+	// CategoryAuditOverflow covers audit records lost to backpressure.
+	CategoryAuditOverflow Category = "audit_overflow"
+	// CategoryOther is the category of event types not listed below.
+	CategoryOther Category = "other"
+)
+
+// Categories lists the known categories in order.
+var Categories = []Category{CategorySecurity, CategoryLifecycle, CategoryAdmin, CategoryAuditOverflow, CategoryOther}
+
+// typeCategories assigns single event types whose category differs from
+// their prefix.
+var typeCategories = map[string]Category{
+	"content.argument_scan_updated":    CategoryAdmin,
+	"content.detector_added":           CategoryAdmin,
+	"content.detector_removed":         CategoryAdmin,
+	"content.detector_updated":         CategoryAdmin,
+	"content.whitelist_added":          CategoryAdmin,
+	"content.whitelist_removed":        CategoryAdmin,
+	"drift.baseline_reset":             CategoryAdmin,
+	"upstream.tools_quarantined":       CategorySecurity,
+	"upstream.tls_verification_failed": CategorySecurity,
+}
+
+// prefixCategories assigns event types by the part before the first dot.
+var prefixCategories = map[string]Category{
+	"tool":        CategorySecurity,
+	"content":     CategorySecurity,
+	"approval":    CategorySecurity,
+	"drift":       CategorySecurity,
+	"permissions": CategorySecurity,
+	"redteam":     CategorySecurity,
+	"evidence":    CategorySecurity,
+	"identity":    CategorySecurity,
+	"policy":      CategorySecurity,
+	"upstream":    CategoryLifecycle,
+	"scheduler":   CategoryLifecycle,
+	"watchdog":    CategoryLifecycle,
+	"health":      CategoryLifecycle,
+	"slo":         CategoryLifecycle,
+	"finops":      CategoryLifecycle,
+	"admin":       CategoryAdmin,
+	"config":      CategoryAdmin,
+	"access":      CategoryAdmin,
+	"user":        CategoryAdmin,
+	"audit":       CategoryAuditOverflow,
+}
+
+// CategoryOf returns the category of an event type, CategoryOther when the
+// type is unknown.
+func CategoryOf(eventType string) Category {
+	if c, ok := typeCategories[eventType]; ok {
+		return c
+	}
+	prefix, _, _ := strings.Cut(eventType, ".")
+	if c, ok := prefixCategories[prefix]; ok {
+		return c
+	}
+	return CategoryOther
+}
+
+// ParseCategory validates a category name.
+func ParseCategory(s string) (Category, error) {
+	for _, c := range Categories {
+		if string(c) == s {
+			return c, nil
+		}
+	}
+	return "", fmt.Errorf("unknown event category %q", s)
+}
+
+// ParseSeverity converts "info", "warning" or "critical" to a Severity.
+func ParseSeverity(s string) (Severity, error) {
+	switch s {
+	case "info", "":
+		return SeverityInfo, nil
+	case "warning":
+		return SeverityWarning, nil
+	case "critical":
+		return SeverityCritical, nil
+	}
+	return SeverityInfo, fmt.Errorf("unknown event severity %q", s)
+}
+
+// Filter selects the events delivered to a sink. Empty lists match
+// everything.
+type Filter struct {
+	// Types are event types; a trailing "*" matches a prefix, e.g.
+	// "upstream.*".
+	Types []string
+	// Categories are the accepted categories.
+	Categories []Category
+	// MinSeverity drops events below this severity.
+	MinSeverity Severity
+}
+
+// Matches reports whether f accepts evt.
+func (f Filter) Matches(evt Event) bool {
+	if evt.Severity < f.MinSeverity {
+		return false
+	}
+	if len(f.Categories) > 0 {
+		c := evt.Category
+		if c == "" {
+			c = CategoryOf(evt.Type)
+		}
+		found := false
+		for _, want := range f.Categories {
+			if want == c {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return len(f.Types) == 0 || MatchType(f.Types, evt.Type)
+}
+
+// MatchType reports whether eventType matches one of patterns. A trailing
+// "*" matches a prefix.
+func MatchType(patterns []string, eventType string) bool {
+	for _, p := range patterns {
+		if p == eventType || (strings.HasSuffix(p, "*") && strings.HasPrefix(eventType, strings.TrimSuffix(p, "*"))) {
+			return true
+		}
+	}
+	return false
+}
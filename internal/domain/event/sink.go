@@ -0,0 +1,279 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// DefaultSinkQueueSize is the number of events queued per sink.
+const DefaultSinkQueueSize = 256
+
+// Sink receives the events routed to it by a Dispatcher. Deliver is called
+// from one goroutine per sink; an error makes the dispatcher retry per the
+// sink's RetryPolicy.
+type Sink interface {
+	// Name identifies the sink in status and logs.
+	Name() string
+	// Deliver sends one event.
+	Deliver(ctx context.Context, evt Event) error
+}
+
+// RetryPolicy sets how failed deliveries are retried. The delay before
+// retry n is Backoff doubled n-1 times.
+type RetryPolicy struct {
+	Retries int
+	Backoff time.Duration
+}
+
+// SinkOptions configures a sink registered with a Dispatcher.
+type SinkOptions struct {
+	Filter Filter
+	Retry  RetryPolicy
+	// QueueSize is the number of pending events kept for the sink; further
+	// events are dropped and counted. Defaults to DefaultSinkQueueSize.
+	QueueSize int
+}
+
+// SinkStatus summarizes a sink for the admin API.
+type SinkStatus struct {
+	Name        string     `json:"name"`
+	Events      []string   `json:"events"`
+	Categories  []Category `json:"categories"`
+	MinSeverity string     `json:"min_severity"`
+	Retries     int        `json:"retries"`
+	Delivered   uint64     `json:"delivered"`
+	Failed      uint64     `json:"failed"`
+	Retried     uint64     `json:"retried"`
+	Dropped     uint64     `json:"dropped"`
+	Queued      int        `json:"queued"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+// registeredSink is a sink with its queue and counters.
+type registeredSink struct {
+	sink  Sink
+	opts  SinkOptions
+	queue chan dispatchItem
+
+	mu          sync.Mutex
+	delivered   uint64
+	failed      uint64
+	retried     uint64
+	dropped     uint64
+	lastError   string
+	lastErrorAt time.Time
+}
+
+// Dispatcher fans events from a Bus out to sinks. Each sink has its own
+// filter, queue and worker, so a slow or failing sink neither blocks the bus
+// nor delays the other sinks.
+type Dispatcher struct {
+	logger *slog.Logger
+
+	mu          sync.RWMutex
+	sinks       []*registeredSink
+	unsubscribe func()
+	stopped     bool
+
+	wg     sync.WaitGroup
+	stopCh chan struct{} // aborts retry backoff at shutdown
+}
+
+// NewDispatcher creates a dispatcher subscribed to every event on bus.
+func NewDispatcher(bus Bus, logger *slog.Logger) *Dispatcher {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	d := &Dispatcher{logger: logger, stopCh: make(chan struct{})}
+	d.unsubscribe = bus.SubscribeAll(d.route)
+	return d
+}
+
+// AddSink registers a sink and starts its worker.
+func (d *Dispatcher) AddSink(sink Sink, opts SinkOptions) {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = DefaultSinkQueueSize
+	}
+	rs := &registeredSink{sink: sink, opts: opts, queue: make(chan dispatchItem, opts.QueueSize)}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stopped {
+		return
+	}
+	d.sinks = append(d.sinks, rs)
+	d.wg.Add(1)
+	go d.run(rs)
+}
+
+// route queues evt for every sink whose filter accepts it.
+func (d *Dispatcher) route(ctx context.Context, evt Event) {
+	if evt.Category == "" {
+		evt.Category = CategoryOf(evt.Type)
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.stopped {
+		return
+	}
+	for _, rs := range d.sinks {
+		if !rs.opts.Filter.Matches(evt) {
+			continue
+		}
+		select {
+		case rs.queue <- dispatchItem{ctx: ctx, event: evt}:
+		default:
+			rs.mu.Lock()
+			rs.dropped++
+			rs.mu.Unlock()
+		}
+	}
+}
+
+// run delivers the queued events of one sink until its queue is closed.
+func (d *Dispatcher) run(rs *registeredSink) {
+	defer d.wg.Done()
+	for item := range rs.queue {
+		d.deliver(rs, item)
+	}
+}
+
+// deliver sends one event to a sink, retrying per the sink's policy.
+func (d *Dispatcher) deliver(rs *registeredSink, item dispatchItem) {
+	for attempt := 1; ; attempt++ {
+		err := d.call(rs.sink, item)
+		if err == nil {
+			rs.mu.Lock()
+			rs.delivered++
+			rs.mu.Unlock()
+			return
+		}
+		rs.mu.Lock()
+		rs.lastError, rs.lastErrorAt = err.Error(), time.Now().UTC()
+		rs.mu.Unlock()
+		if attempt > rs.opts.Retry.Retries {
+			rs.fail()
+			d.logger.Warn("event sink: delivery failed", "sink", rs.sink.Name(),
+				"event", item.event.Type, "attempts", attempt, "error", err)
+			return
+		}
+		timer := time.NewTimer(rs.opts.Retry.Backoff << (attempt - 1))
+		select {
+		case <-timer.C:
+			rs.mu.Lock()
+			rs.retried++
+			rs.mu.Unlock()
+		case <-d.stopCh:
+			timer.Stop()
+			rs.fail()
+			return
+		}
+	}
+}
+
+// fail counts an event that could not be delivered.
+func (rs *registeredSink) fail() {
+	rs.mu.Lock()
+	rs.failed++
+	rs.mu.Unlock()
+}
+
+// call invokes the sink, turning a panic into an error.
+func (d *Dispatcher) call(sink Sink, item dispatchItem) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("event sink panic recovered",
+				"sink", sink.Name(),
+				"event", item.event.Type,
+				"panic", fmt.Sprint(r),
+				"stack", string(debug.Stack()))
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return sink.Deliver(item.ctx, item.event)
+}
+
+// Sinks returns the status of every sink in registration order.
+func (d *Dispatcher) Sinks() []SinkStatus {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	out := make([]SinkStatus, 0, len(d.sinks))
+	for _, rs := range d.sinks {
+		f := rs.opts.Filter
+		st := SinkStatus{
+			Name:        rs.sink.Name(),
+			Events:      append([]string{}, f.Types...),
+			Categories:  append([]Category{}, f.Categories...),
+			MinSeverity: f.MinSeverity.String(),
+			Retries:     rs.opts.Retry.Retries,
+			Queued:      len(rs.queue),
+		}
+		rs.mu.Lock()
+		st.Delivered, st.Failed, st.Retried, st.Dropped = rs.delivered, rs.failed, rs.retried, rs.dropped
+		if rs.lastError != "" {
+			at := rs.lastErrorAt
+			st.LastError, st.LastErrorAt = rs.lastError, &at
+		}
+		rs.mu.Unlock()
+		out = append(out, st)
+	}
+	return out
+}
+
+// Stop unsubscribes from the bus and waits for queued events to be
+// delivered. Pending retries are abandoned and counted as failures.
+func (d *Dispatcher) Stop() {
+	d.mu.Lock()
+	if d.stopped {
+		d.mu.Unlock()
+		return
+	}
+	d.stopped = true
+	unsub := d.unsubscribe
+	for _, rs := range d.sinks {
+		close(rs.queue)
+	}
+	d.mu.Unlock()
+
+	unsub()
+	close(d.stopCh)
+	d.wg.Wait()
+}
+
+// LogSink writes events to a structured logger, at warning level for
+// warnings and error level for critical events.
+type LogSink struct {
+	logger *slog.Logger
+}
+
+// NewLogSink creates a sink logging to logger.
+func NewLogSink(logger *slog.Logger) *LogSink {
+	return &LogSink{logger: logger}
+}
+
+// Name implements Sink.
+func (s *LogSink) Name() string { return "log" }
+
+// Deliver implements Sink.
+func (s *LogSink) Deliver(ctx context.Context, evt Event) error {
+	level := slog.LevelInfo
+	switch evt.Severity {
+	case SeverityWarning:
+		level = slog.LevelWarn
+	case SeverityCritical:
+		level = slog.LevelError
+	}
+	s.logger.Log(ctx, level, "event",
+		"type", evt.Type,
+		"category", string(evt.Category),
+		"source", evt.Source,
+		"severity", evt.Severity.String(),
+		"requires_action", evt.RequiresAction,
+		"payload", evt.Payload)
+	return nil
+}
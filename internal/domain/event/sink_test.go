@@ -0,0 +1,181 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingSink records delivered events and fails the first failures calls.
+type recordingSink struct {
+	name     string
+	mu       sync.Mutex
+	calls    int
+	failures int
+	events   []Event
+}
+
+func (s *recordingSink) Name() string { return s.name }
+
+func (s *recordingSink) Deliver(ctx context.Context, evt Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.calls <= s.failures {
+		return errors.New("unavailable")
+	}
+	s.events = append(s.events, evt)
+	return nil
+}
+
+func (s *recordingSink) types() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]string, 0, len(s.events))
+	for _, e := range s.events {
+		out = append(out, e.Type)
+	}
+	return out
+}
+
+func TestCategoryOf(t *testing.T) {
+	tests := map[string]Category{
+		"tool.changed":                     CategorySecurity,
+		"content.pii_detected":             CategorySecurity,
+		"content.whitelist_added":          CategoryAdmin,
+		"upstream.disconnected":            CategoryLifecycle,
+		"upstream.tools_quarantined":       CategorySecurity,
+		"upstream.tls_verification_failed": CategorySecurity,
+		"audit.overflow":                   CategoryAuditOverflow,
+		"config.policy_update":             CategoryAdmin,
+		"policy.deny_loop":                 CategorySecurity,
+		"custom":                           CategoryOther,
+	}
+	for typ, want := range tests {
+		if got := CategoryOf(typ); got != want {
+			t.Errorf("CategoryOf(%q) = %q, want %q", typ, got, want)
+		}
+	}
+}
+
+func TestFilter_Matches(t *testing.T) {
+	f := Filter{Types: []string{"upstream.*", "tool.changed"}, Categories: []Category{CategoryLifecycle, CategorySecurity}, MinSeverity: SeverityWarning}
+
+	if !f.Matches(Event{Type: "upstream.disconnected", Severity: SeverityWarning}) {
+		t.Error("warning upstream event should match")
+	}
+	if f.Matches(Event{Type: "upstream.connected", Severity: SeverityInfo}) {
+		t.Error("info event should be below the minimum severity")
+	}
+	if f.Matches(Event{Type: "drift.anomaly", Severity: SeverityCritical}) {
+		t.Error("event type outside the list should not match")
+	}
+	if f.Matches(Event{Type: "tool.changed", Category: CategoryAdmin, Severity: SeverityCritical}) {
+		t.Error("explicit category outside the list should not match")
+	}
+	if !(Filter{}).Matches(Event{Type: "anything"}) {
+		t.Error("empty filter should match everything")
+	}
+}
+
+func TestDispatcher_FiltersPerSink(t *testing.T) {
+	bus := NewBus(100)
+	bus.Start()
+	defer bus.Stop()
+
+	d := NewDispatcher(bus, nil)
+	all := &recordingSink{name: "all"}
+	security := &recordingSink{name: "security"}
+	d.AddSink(all, SinkOptions{})
+	d.AddSink(security, SinkOptions{Filter: Filter{Categories: []Category{CategorySecurity}}})
+
+	bus.Publish(context.Background(), Event{Type: "tool.changed", Source: "test"})
+	bus.Publish(context.Background(), Event{Type: "upstream.connected", Source: "test"})
+	time.Sleep(50 * time.Millisecond)
+	d.Stop()
+
+	if got := all.types(); len(got) != 2 {
+		t.Errorf("all received %v", got)
+	}
+	if got := security.types(); len(got) != 1 || got[0] != "tool.changed" {
+		t.Errorf("security received %v", got)
+	}
+	if security.events[0].Category != CategorySecurity {
+		t.Errorf("category = %q, want it set on publish", security.events[0].Category)
+	}
+
+	st := d.Sinks()
+	if len(st) != 2 || st[0].Delivered != 2 || st[1].Delivered != 1 || st[1].Categories[0] != CategorySecurity {
+		t.Errorf("Sinks() = %+v", st)
+	}
+}
+
+func TestDispatcher_Retry(t *testing.T) {
+	bus := NewBus(100)
+	bus.Start()
+	defer bus.Stop()
+
+	d := NewDispatcher(bus, nil)
+	flaky := &recordingSink{name: "flaky", failures: 2}
+	broken := &recordingSink{name: "broken", failures: 100}
+	d.AddSink(flaky, SinkOptions{Retry: RetryPolicy{Retries: 3, Backoff: time.Millisecond}})
+	d.AddSink(broken, SinkOptions{Retry: RetryPolicy{Retries: 1, Backoff: time.Millisecond}})
+
+	bus.Publish(context.Background(), Event{Type: "x", Source: "test"})
+	time.Sleep(100 * time.Millisecond)
+	d.Stop()
+
+	st := d.Sinks()
+	if st[0].Delivered != 1 || st[0].Retried != 2 || st[0].Failed != 0 {
+		t.Errorf("flaky status = %+v", st[0])
+	}
+	if st[1].Delivered != 0 || st[1].Failed != 1 || st[1].LastError != "unavailable" || st[1].LastErrorAt == nil {
+		t.Errorf("broken status = %+v", st[1])
+	}
+	if broken.calls != 2 {
+		t.Errorf("broken sink called %d times, want 2", broken.calls)
+	}
+}
+
+// blockingSink blocks every delivery until release is closed.
+type blockingSink struct {
+	release chan struct{}
+}
+
+func (s *blockingSink) Name() string { return "blocking" }
+
+func (s *blockingSink) Deliver(ctx context.Context, evt Event) error {
+	<-s.release
+	return nil
+}
+
+func TestDispatcher_DropsWhenQueueFull(t *testing.T) {
+	bus := NewBus(100)
+	bus.Start()
+	defer bus.Stop()
+
+	d := NewDispatcher(bus, nil)
+	slow := &blockingSink{release: make(chan struct{})}
+	fast := &recordingSink{name: "fast"}
+	d.AddSink(slow, SinkOptions{QueueSize: 1})
+	d.AddSink(fast, SinkOptions{})
+
+	// The first event occupies the slow sink, the second fills its queue.
+	bus.Publish(context.Background(), Event{Type: "x", Source: "test"})
+	time.Sleep(20 * time.Millisecond)
+	for i := 0; i < 4; i++ {
+		bus.Publish(context.Background(), Event{Type: "x", Source: "test"})
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	if got := len(fast.types()); got != 5 {
+		t.Errorf("fast sink received %d events, want 5 despite the slow sink", got)
+	}
+	if st := d.Sinks()[0]; st.Dropped != 3 {
+		t.Errorf("slow sink dropped %d, want 3", st.Dropped)
+	}
+	close(slow.release)
+	d.Stop()
+}
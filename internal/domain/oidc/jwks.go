@@ -0,0 +1,119 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// minRSABits rejects signing keys too short to be trusted.
+const minRSABits = 2048
+
+// jwk is one key of a JSON Web Key Set (RFC 7517).
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC and OKP
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// jwks is a JSON Web Key Set document.
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+// signingKey is a parsed verification key.
+type signingKey struct {
+	kid string
+	alg string // empty when the JWK does not pin one
+	key crypto.PublicKey
+}
+
+// parseJWK converts a JWK into a public key. Keys not meant for signatures
+// are rejected.
+func parseJWK(k jwk) (*signingKey, error) {
+	if k.Use != "" && k.Use != "sig" {
+		return nil, fmt.Errorf("key %q is not a signing key", k.Kid)
+	}
+	sk := &signingKey{kid: k.Kid, alg: k.Alg}
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: modulus: %w", k.Kid, err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("key %q: invalid exponent", k.Kid)
+		}
+		if n.BitLen() < minRSABits {
+			return nil, fmt.Errorf("key %q: RSA key shorter than %d bits", k.Kid, minRSABits)
+		}
+		sk.key = &rsa.PublicKey{N: n, E: int(e.Int64())}
+	case "EC":
+		curve, err := jwkCurve(k.Crv)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.Kid, err)
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(k.X)
+		y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+		size := (curve.Params().BitSize + 7) / 8
+		if errX != nil || errY != nil || len(x) != size || len(y) != size {
+			return nil, fmt.Errorf("key %q: invalid coordinates", k.Kid)
+		}
+		point := append(append([]byte{4}, x...), y...)
+		pub, err := ecdsa.ParseUncompressedPublicKey(curve, point)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.Kid, err)
+		}
+		sk.key = pub
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("key %q: unsupported curve %q", k.Kid, k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("key %q: invalid Ed25519 key", k.Kid)
+		}
+		sk.key = ed25519.PublicKey(x)
+	default:
+		return nil, fmt.Errorf("key %q: unsupported key type %q", k.Kid, k.Kty)
+	}
+	return sk, nil
+}
+
+func jwkCurve(crv string) (elliptic.Curve, error) {
+	switch crv {
+	case "P-256":
+		return elliptic.P256(), nil
+	case "P-384":
+		return elliptic.P384(), nil
+	case "P-521":
+		return elliptic.P521(), nil
+	}
+	return nil, fmt.Errorf("unsupported curve %q", crv)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
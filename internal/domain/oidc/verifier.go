@@ -0,0 +1,582 @@
+package oidc
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256" // registers crypto.SHA256
+	_ "crypto/sha512" // registers crypto.SHA384 and crypto.SHA512
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/auth"
+)
+
+const (
+	// defaultClockSkew is used when Config.ClockSkew is unset.
+	defaultClockSkew = time.Minute
+	// defaultKeysTTL is used when Config.KeysTTL is unset.
+	defaultKeysTTL = time.Hour
+	// minKeysRefresh limits refetches triggered by unknown key IDs, so
+	// tokens with made-up key IDs cannot hammer the provider.
+	minKeysRefresh = 30 * time.Second
+	// maxProviderResponseSize bounds discovery and JWKS documents.
+	maxProviderResponseSize = 1 << 20
+	// maxTokenSize bounds the tokens accepted for verification.
+	maxTokenSize = 16 * 1024
+)
+
+// Verifier verifies OIDC bearer tokens. It is safe for concurrent use.
+type Verifier struct {
+	cfg    Config
+	client *http.Client
+	now    func() time.Time
+
+	fetchMu   sync.Mutex // serializes key fetches
+	mu        sync.RWMutex
+	jwksURL   string
+	keys      []*signingKey
+	fetchedAt time.Time
+	fetchErr  error
+}
+
+// VerifierOption configures a Verifier.
+type VerifierOption func(*Verifier)
+
+// WithHTTPClient sets the HTTP client used to reach the provider.
+func WithHTTPClient(client *http.Client) VerifierOption {
+	return func(v *Verifier) {
+		v.client = client
+	}
+}
+
+// WithClock overrides the clock used for lifetime checks (for testing).
+func WithClock(now func() time.Time) VerifierOption {
+	return func(v *Verifier) {
+		v.now = now
+	}
+}
+
+// NewVerifier creates a Verifier. Signing keys are fetched on first use.
+func NewVerifier(cfg Config, opts ...VerifierOption) (*Verifier, error) {
+	if cfg.Issuer == "" {
+		return nil, errors.New("oidc: issuer is required")
+	}
+	if len(cfg.Audiences) == 0 {
+		return nil, errors.New("oidc: at least one audience is required")
+	}
+	for _, role := range cfg.DefaultRoles {
+		if strings.TrimSpace(role) == "" {
+			return nil, errors.New("oidc: default_roles contains an empty role")
+		}
+	}
+	for value, roles := range cfg.RoleMappings {
+		for _, role := range roles {
+			if strings.TrimSpace(role) == "" {
+				return nil, fmt.Errorf("oidc: role mapping %q contains an empty role", value)
+			}
+		}
+	}
+	if cfg.IdentityClaim == "" {
+		cfg.IdentityClaim = "sub"
+	}
+	if cfg.RolesClaim == "" {
+		cfg.RolesClaim = "roles"
+	}
+	if cfg.ClockSkew <= 0 {
+		cfg.ClockSkew = defaultClockSkew
+	}
+	if cfg.KeysTTL <= 0 {
+		cfg.KeysTTL = defaultKeysTTL
+	}
+	v := &Verifier{
+		cfg:     cfg,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		now:     time.Now,
+		jwksURL: cfg.JWKSURL,
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v, nil
+}
+
+// Verify checks the token's signature, issuer, audience and lifetime and
+// maps its claims to an identity.
+func (v *Verifier) Verify(ctx context.Context, raw string) (*Token, error) {
+	claims, expiry, err := v.verifyClaims(ctx, raw)
+	if err != nil {
+		return nil, err
+	}
+	return v.mapIdentity(claims, expiry)
+}
+
+// VerifySubject checks the token like Verify but returns the value of the
+// identity claim and the expiry without mapping roles, for tokens that are
+// only forwarded, not used to authenticate.
+func (v *Verifier) VerifySubject(ctx context.Context, raw string) (string, time.Time, error) {
+	claims, expiry, err := v.verifyClaims(ctx, raw)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	subject, _ := claimPath(claims, v.cfg.IdentityClaim).(string)
+	if subject == "" {
+		return "", time.Time{}, fmt.Errorf("%w: missing %s claim", ErrInvalidToken, v.cfg.IdentityClaim)
+	}
+	return subject, expiry, nil
+}
+
+// verifyClaims checks the signature, issuer, audience and lifetime of a
+// token and returns its claims and expiry.
+func (v *Verifier) verifyClaims(ctx context.Context, raw string) (map[string]interface{}, time.Time, error) {
+	if len(raw) > maxTokenSize {
+		return nil, time.Time{}, fmt.Errorf("%w: token too large", ErrInvalidToken)
+	}
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, time.Time{}, fmt.Errorf("%w: not a compact JWS", ErrInvalidToken)
+	}
+	var header struct {
+		Alg  string   `json:"alg"`
+		Kid  string   `json:"kid"`
+		Crit []string `json:"crit"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, time.Time{}, fmt.Errorf("%w: header: %v", ErrInvalidToken, err)
+	}
+	if len(header.Crit) > 0 {
+		return nil, time.Time{}, fmt.Errorf("%w: unsupported critical header", ErrInvalidToken)
+	}
+	if _, ok := algorithmHash(header.Alg); !ok {
+		return nil, time.Time{}, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, header.Alg)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("%w: signature encoding", ErrInvalidToken)
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+	if err := v.verifySignature(ctx, header.Alg, header.Kid, signed, sig); err != nil {
+		return nil, time.Time{}, err
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, time.Time{}, fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+	expiry, err := v.checkClaims(claims)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return claims, expiry, nil
+}
+
+// Accepts reports whether a credential has the shape of a JWT, telling
+// tokens apart from API keys.
+func (v *Verifier) Accepts(credential string) bool {
+	return LooksLikeJWT(credential)
+}
+
+// VerifyToken verifies a token and returns its identity and expiry.
+func (v *Verifier) VerifyToken(ctx context.Context, raw string) (*auth.Identity, time.Time, error) {
+	tok, err := v.Verify(ctx, raw)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	roles := make([]auth.Role, len(tok.Roles))
+	for i, r := range tok.Roles {
+		roles[i] = auth.Role(r)
+	}
+	return &auth.Identity{ID: tok.IdentityID, Name: tok.Name, Roles: roles}, tok.Expiry, nil
+}
+
+// verifySignature finds the key for the token and checks the signature,
+// refetching the keys once if the key ID is unknown or no key matches.
+func (v *Verifier) verifySignature(ctx context.Context, alg, kid string, signed, sig []byte) error {
+	keys, err := v.currentKeys(ctx, false)
+	if err != nil {
+		return err
+	}
+	if verifyWithKeys(keys, alg, kid, signed, sig) {
+		return nil
+	}
+	if kid != "" && hasKid(keys, kid) {
+		return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+	}
+	keys, err = v.currentKeys(ctx, true)
+	if err != nil {
+		return err
+	}
+	if verifyWithKeys(keys, alg, kid, signed, sig) {
+		return nil
+	}
+	return fmt.Errorf("%w: no matching signing key", ErrInvalidToken)
+}
+
+func verifyWithKeys(keys []*signingKey, alg, kid string, signed, sig []byte) bool {
+	for _, k := range keys {
+		if kid != "" && k.kid != kid {
+			continue
+		}
+		if k.alg != "" && k.alg != alg {
+			continue
+		}
+		if verifyJWS(alg, k.key, signed, sig) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasKid(keys []*signingKey, kid string) bool {
+	for _, k := range keys {
+		if k.kid == kid {
+			return true
+		}
+	}
+	return false
+}
+
+// currentKeys returns the signing keys, fetching them when none are
+// cached, they are older than KeysTTL or the last fetch failed. refresh
+// asks for a refetch, for a key ID the cached keys do not know. Fetches
+// are at least minKeysRefresh apart, and cached keys stay in use while
+// refetching fails.
+func (v *Verifier) currentKeys(ctx context.Context, refresh bool) ([]*signingKey, error) {
+	if keys, ok := v.freshKeys(refresh); ok {
+		return keys, nil
+	}
+	v.fetchMu.Lock()
+	defer v.fetchMu.Unlock()
+	if keys, ok := v.freshKeys(refresh); ok {
+		return keys, nil
+	}
+
+	v.mu.RLock()
+	keys, fetchedAt, fetchErr := v.keys, v.fetchedAt, v.fetchErr
+	v.mu.RUnlock()
+	if fetchedAt.IsZero() || v.now().Sub(fetchedAt) >= minKeysRefresh {
+		var fetched []*signingKey
+		fetched, fetchErr = v.fetchKeys(ctx)
+		v.mu.Lock()
+		v.fetchedAt = v.now()
+		v.fetchErr = fetchErr
+		if fetchErr == nil {
+			v.keys = fetched
+		}
+		keys = v.keys
+		v.mu.Unlock()
+	}
+	if keys == nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeysUnavailable, fetchErr)
+	}
+	return keys, nil
+}
+
+// freshKeys returns the cached keys unless they need a fetch.
+func (v *Verifier) freshKeys(refresh bool) ([]*signingKey, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if refresh || v.keys == nil || v.fetchErr != nil || v.now().Sub(v.fetchedAt) > v.cfg.KeysTTL {
+		return nil, false
+	}
+	return v.keys, true
+}
+
+// fetchKeys downloads the JWKS, discovering its URL first if needed.
+func (v *Verifier) fetchKeys(ctx context.Context) ([]*signingKey, error) {
+	v.mu.RLock()
+	jwksURL := v.jwksURL
+	v.mu.RUnlock()
+	if jwksURL == "" {
+		var doc struct {
+			Issuer  string `json:"issuer"`
+			JWKSURI string `json:"jwks_uri"`
+		}
+		discovery := strings.TrimSuffix(v.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+		if err := v.getJSON(ctx, discovery, &doc); err != nil {
+			return nil, fmt.Errorf("discovery: %w", err)
+		}
+		if strings.TrimSuffix(doc.Issuer, "/") != strings.TrimSuffix(v.cfg.Issuer, "/") {
+			return nil, fmt.Errorf("discovery: issuer %q does not match %q", doc.Issuer, v.cfg.Issuer)
+		}
+		if doc.JWKSURI == "" {
+			return nil, errors.New("discovery: no jwks_uri")
+		}
+		jwksURL = doc.JWKSURI
+		v.mu.Lock()
+		v.jwksURL = jwksURL
+		v.mu.Unlock()
+	}
+
+	var set jwks
+	if err := v.getJSON(ctx, jwksURL, &set); err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+	keys := make([]*signingKey, 0, len(set.Keys))
+	for _, k := range set.Keys {
+		// Keys of unsupported types are skipped; the provider may publish
+		// keys for other purposes.
+		if sk, err := parseJWK(k); err == nil {
+			keys = append(keys, sk)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("jwks: no usable signing keys")
+	}
+	return keys, nil
+}
+
+func (v *Verifier) getJSON(ctx context.Context, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned HTTP %d", url, resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxProviderResponseSize))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, out)
+}
+
+// checkClaims verifies issuer, audience and lifetime and returns the
+// expiry.
+func (v *Verifier) checkClaims(claims map[string]interface{}) (time.Time, error) {
+	if iss, _ := claims["iss"].(string); iss != v.cfg.Issuer {
+		return time.Time{}, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, iss)
+	}
+	if !v.audienceAllowed(claims["aud"]) {
+		return time.Time{}, fmt.Errorf("%w: audience not accepted", ErrInvalidToken)
+	}
+	now := v.now()
+	skew := v.cfg.ClockSkew
+	exp, ok := numericDate(claims["exp"])
+	if !ok {
+		return time.Time{}, fmt.Errorf("%w: missing exp", ErrInvalidToken)
+	}
+	if now.After(exp.Add(skew)) {
+		return time.Time{}, fmt.Errorf("%w: token expired", ErrInvalidToken)
+	}
+	if nbf, ok := numericDate(claims["nbf"]); ok && now.Add(skew).Before(nbf) {
+		return time.Time{}, fmt.Errorf("%w: token not yet valid", ErrInvalidToken)
+	}
+	if iat, ok := numericDate(claims["iat"]); ok && now.Add(skew).Before(iat) {
+		return time.Time{}, fmt.Errorf("%w: token issued in the future", ErrInvalidToken)
+	}
+	return exp, nil
+}
+
+func (v *Verifier) audienceAllowed(aud interface{}) bool {
+	var values []string
+	switch a := aud.(type) {
+	case string:
+		values = []string{a}
+	case []interface{}:
+		for _, item := range a {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+	for _, got := range values {
+		for _, want := range v.cfg.Audiences {
+			if got == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// mapIdentity builds the identity of a verified token.
+func (v *Verifier) mapIdentity(claims map[string]interface{}, expiry time.Time) (*Token, error) {
+	subject, _ := claimPath(claims, v.cfg.IdentityClaim).(string)
+	if subject == "" {
+		return nil, fmt.Errorf("%w: missing %s claim", ErrInvalidToken, v.cfg.IdentityClaim)
+	}
+	name := ""
+	nameClaims := []string{"name", "preferred_username", "email"}
+	if v.cfg.NameClaim != "" {
+		nameClaims = []string{v.cfg.NameClaim}
+	}
+	for _, c := range nameClaims {
+		if s, _ := claimPath(claims, c).(string); s != "" {
+			name = s
+			break
+		}
+	}
+	if name == "" {
+		name = subject
+	}
+
+	roleSet := make(map[string]struct{})
+	for _, r := range v.cfg.DefaultRoles {
+		roleSet[r] = struct{}{}
+	}
+	for _, value := range claimStrings(claimPath(claims, v.cfg.RolesClaim)) {
+		for _, r := range v.cfg.RoleMappings[value] {
+			roleSet[r] = struct{}{}
+		}
+	}
+	if len(roleSet) == 0 {
+		return nil, ErrNoRoles
+	}
+	roles := make([]string, 0, len(roleSet))
+	for r := range roleSet {
+		roles = append(roles, r)
+	}
+	sort.Strings(roles)
+
+	return &Token{
+		IdentityID: IdentityPrefix + subject,
+		Name:       name,
+		Roles:      roles,
+		Expiry:     expiry,
+		Claims:     claims,
+	}, nil
+}
+
+// claimPath looks up a claim by name, following dots into nested objects.
+// A claim whose name itself contains dots is found first.
+func claimPath(claims map[string]interface{}, path string) interface{} {
+	if v, ok := claims[path]; ok {
+		return v
+	}
+	var cur interface{} = claims
+	for _, part := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		cur = m[part]
+	}
+	return cur
+}
+
+// claimStrings returns the string values of a claim holding a string or a
+// list; a string is split on spaces, like the OAuth "scope" claim.
+func claimStrings(v interface{}) []string {
+	switch val := v.(type) {
+	case string:
+		return strings.Fields(val)
+	case []interface{}:
+		out := make([]string, 0, len(val))
+		for _, item := range val {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func numericDate(v interface{}) (time.Time, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	sec := int64(f)
+	return time.Unix(sec, int64((f-float64(sec))*1e9)), true
+}
+
+func decodeSegment(seg string, out interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return dec.Decode(out)
+}
+
+// algorithmHash returns the hash of a supported JWS algorithm. "none" and
+// the HMAC algorithms are not supported: a token must be signed by the
+// provider's private key.
+func algorithmHash(alg string) (crypto.Hash, bool) {
+	switch alg {
+	case "RS256", "PS256", "ES256":
+		return crypto.SHA256, true
+	case "RS384", "PS384", "ES384":
+		return crypto.SHA384, true
+	case "RS512", "PS512", "ES512":
+		return crypto.SHA512, true
+	case "EdDSA":
+		return 0, true
+	}
+	return 0, false
+}
+
+// verifyJWS checks a JWS signature (RFC 7518) with the given key.
+func verifyJWS(alg string, key crypto.PublicKey, signed, sig []byte) bool {
+	if alg == "EdDSA" {
+		pub, ok := key.(ed25519.PublicKey)
+		return ok && ed25519.Verify(pub, signed, sig)
+	}
+	hash, ok := algorithmHash(alg)
+	if !ok {
+		return false
+	}
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch alg[:2] {
+	case "RS":
+		pub, ok := key.(*rsa.PublicKey)
+		return ok && rsa.VerifyPKCS1v15(pub, hash, digest, sig) == nil
+	case "PS":
+		pub, ok := key.(*rsa.PublicKey)
+		return ok && rsa.VerifyPSS(pub, hash, digest, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+	case "ES":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || pub.Curve != esCurve(alg) {
+			return false
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		return ecdsa.Verify(pub, digest, r, s)
+	}
+	return false
+}
+
+func esCurve(alg string) elliptic.Curve {
+	switch alg {
+	case "ES256":
+		return elliptic.P256()
+	case "ES384":
+		return elliptic.P384()
+	case "ES512":
+		return elliptic.P521()
+	}
+	return nil
+}
@@ -0,0 +1,355 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+// testProvider is an OIDC provider serving discovery and a JWKS.
+type testProvider struct {
+	srv        *httptest.Server
+	jwksCalls  atomic.Int32
+	mu         sync.Mutex
+	keys       []jwk
+	rsaKey     *rsa.PrivateKey
+	ecKey      *ecdsa.PrivateKey
+	issuerOver string
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &testProvider{rsaKey: rsaKey, ecKey: ecKey}
+	p.keys = []jwk{rsaJWK("rsa-1", &rsaKey.PublicKey), ecJWK("ec-1", &ecKey.PublicKey)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		issuer := p.srv.URL
+		if p.issuerOver != "" {
+			issuer = p.issuerOver
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"issuer": issuer, "jwks_uri": p.srv.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		p.jwksCalls.Add(1)
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(jwks{Keys: p.keys})
+	})
+	p.srv = httptest.NewServer(mux)
+	t.Cleanup(p.srv.Close)
+	return p
+}
+
+func (p *testProvider) verifier(t *testing.T, mutate func(*Config)) *Verifier {
+	t.Helper()
+	cfg := Config{
+		Issuer:       p.srv.URL,
+		Audiences:    []string{"sentinelgate"},
+		RoleMappings: map[string][]string{"eng": {"developer"}, "sec": {"auditor", "user"}},
+	}
+	if mutate != nil {
+		mutate(&cfg)
+	}
+	v, err := NewVerifier(cfg, WithHTTPClient(p.srv.Client()), WithClock(func() time.Time { return testNow }))
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	return v
+}
+
+func (p *testProvider) claims() map[string]interface{} {
+	return map[string]interface{}{
+		"iss":   p.srv.URL,
+		"aud":   "sentinelgate",
+		"sub":   "alice",
+		"name":  "Alice",
+		"exp":   testNow.Add(time.Hour).Unix(),
+		"iat":   testNow.Unix(),
+		"roles": []string{"eng", "unmapped"},
+	}
+}
+
+func rsaJWK(kid string, pub *rsa.PublicKey) jwk {
+	return jwk{
+		Kty: "RSA", Kid: kid, Use: "sig",
+		N: base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid string, pub *ecdsa.PublicKey) jwk {
+	return jwk{
+		Kty: "EC", Kid: kid, Crv: "P-256",
+		X: base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, 32))),
+		Y: base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, 32))),
+	}
+}
+
+func signToken(t *testing.T, alg, kid string, key crypto.Signer, claims map[string]interface{}) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var sig []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		var err error
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestVerifier_ValidTokens(t *testing.T) {
+	p := newTestProvider(t)
+	v := p.verifier(t, nil)
+
+	for _, tc := range []struct {
+		name string
+		raw  string
+	}{
+		{"RS256", signToken(t, "RS256", "rsa-1", p.rsaKey, p.claims())},
+		{"ES256", signToken(t, "ES256", "ec-1", p.ecKey, p.claims())},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if !LooksLikeJWT(tc.raw) {
+				t.Fatal("LooksLikeJWT = false")
+			}
+			tok, err := v.Verify(context.Background(), tc.raw)
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if tok.IdentityID != "oidc:alice" || tok.Name != "Alice" {
+				t.Errorf("identity = %q %q", tok.IdentityID, tok.Name)
+			}
+			if len(tok.Roles) != 1 || tok.Roles[0] != "developer" {
+				t.Errorf("roles = %v, want [developer]", tok.Roles)
+			}
+			if !tok.Expiry.Equal(testNow.Add(time.Hour)) {
+				t.Errorf("expiry = %v", tok.Expiry)
+			}
+		})
+	}
+	if n := p.jwksCalls.Load(); n != 1 {
+		t.Errorf("JWKS fetched %d times, want 1", n)
+	}
+}
+
+func TestVerifier_RejectsInvalidTokens(t *testing.T) {
+	p := newTestProvider(t)
+	v := p.verifier(t, nil)
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	with := func(mutate func(map[string]interface{})) map[string]interface{} {
+		c := p.claims()
+		mutate(c)
+		return c
+	}
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"alice"}`)) + "."
+
+	for _, tc := range []struct {
+		name string
+		raw  string
+		want error
+	}{
+		{"expired", signToken(t, "RS256", "rsa-1", p.rsaKey, with(func(c map[string]interface{}) {
+			c["exp"] = testNow.Add(-2 * time.Minute).Unix()
+		})), ErrInvalidToken},
+		{"not yet valid", signToken(t, "RS256", "rsa-1", p.rsaKey, with(func(c map[string]interface{}) {
+			c["nbf"] = testNow.Add(5 * time.Minute).Unix()
+		})), ErrInvalidToken},
+		{"no expiry", signToken(t, "RS256", "rsa-1", p.rsaKey, with(func(c map[string]interface{}) {
+			delete(c, "exp")
+		})), ErrInvalidToken},
+		{"wrong issuer", signToken(t, "RS256", "rsa-1", p.rsaKey, with(func(c map[string]interface{}) {
+			c["iss"] = "https://evil.example"
+		})), ErrInvalidToken},
+		{"wrong audience", signToken(t, "RS256", "rsa-1", p.rsaKey, with(func(c map[string]interface{}) {
+			c["aud"] = []string{"other-app"}
+		})), ErrInvalidToken},
+		{"missing subject", signToken(t, "RS256", "rsa-1", p.rsaKey, with(func(c map[string]interface{}) {
+			delete(c, "sub")
+		})), ErrInvalidToken},
+		{"no roles", signToken(t, "RS256", "rsa-1", p.rsaKey, with(func(c map[string]interface{}) {
+			c["roles"] = "unmapped"
+		})), ErrNoRoles},
+		{"bad signature", signToken(t, "RS256", "rsa-1", other, p.claims()), ErrInvalidToken},
+		{"alg none", none, ErrInvalidToken},
+		{"HS256", signToken(t, "HS256", "rsa-1", p.rsaKey, p.claims()), ErrInvalidToken},
+		{"malformed", "eyJhbGciOiJSUzI1NiJ9.e30", ErrInvalidToken},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := v.Verify(context.Background(), tc.raw); !errors.Is(err, tc.want) {
+				t.Errorf("err = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestVerifier_ClockSkew(t *testing.T) {
+	p := newTestProvider(t)
+	v := p.verifier(t, nil)
+	c := p.claims()
+	c["exp"] = testNow.Add(-30 * time.Second).Unix()
+	if _, err := v.Verify(context.Background(), signToken(t, "RS256", "rsa-1", p.rsaKey, c)); err != nil {
+		t.Errorf("token expired within the skew: %v", err)
+	}
+}
+
+func TestVerifier_RoleMapping(t *testing.T) {
+	p := newTestProvider(t)
+	v := p.verifier(t, func(cfg *Config) {
+		cfg.RolesClaim = "realm_access.roles"
+		cfg.DefaultRoles = []string{"user"}
+		cfg.IdentityClaim = "email"
+	})
+	c := p.claims()
+	delete(c, "roles")
+	c["email"] = "alice@example.com"
+	c["realm_access"] = map[string]interface{}{"roles": []string{"sec", "eng"}}
+
+	identity, expiry, err := v.VerifyToken(context.Background(), signToken(t, "RS256", "rsa-1", p.rsaKey, c))
+	if err != nil {
+		t.Fatalf("VerifyToken: %v", err)
+	}
+	if identity.ID != "oidc:alice@example.com" {
+		t.Errorf("ID = %q", identity.ID)
+	}
+	var roles []string
+	for _, r := range identity.Roles {
+		roles = append(roles, string(r))
+	}
+	if want := []string{"auditor", "developer", "user"}; len(roles) != len(want) || roles[0] != want[0] || roles[1] != want[1] || roles[2] != want[2] {
+		t.Errorf("roles = %v, want %v", roles, want)
+	}
+	if !expiry.Equal(testNow.Add(time.Hour)) {
+		t.Errorf("expiry = %v", expiry)
+	}
+}
+
+func TestVerifier_VerifySubject(t *testing.T) {
+	p := newTestProvider(t)
+	v := p.verifier(t, nil)
+	c := p.claims()
+	delete(c, "roles") // no role needed for a forwarded token
+
+	subject, expiry, err := v.VerifySubject(context.Background(), signToken(t, "RS256", "rsa-1", p.rsaKey, c))
+	if err != nil {
+		t.Fatalf("VerifySubject: %v", err)
+	}
+	if subject != "alice" || !expiry.Equal(testNow.Add(time.Hour)) {
+		t.Errorf("VerifySubject = %q, %v", subject, expiry)
+	}
+
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := v.VerifySubject(context.Background(), signToken(t, "ES256", "ec-1", other, c)); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("VerifySubject with a forged signature: err = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestVerifier_UnknownKidRefetchesKeys(t *testing.T) {
+	p := newTestProvider(t)
+	v := p.verifier(t, nil)
+	ctx := context.Background()
+	if _, err := v.Verify(ctx, signToken(t, "RS256", "rsa-1", p.rsaKey, p.claims())); err != nil {
+		t.Fatal(err)
+	}
+
+	// The provider rotates in a new key.
+	rotated, _ := rsa.GenerateKey(rand.Reader, 2048)
+	p.mu.Lock()
+	p.keys = append(p.keys, rsaJWK("rsa-2", &rotated.PublicKey))
+	p.mu.Unlock()
+
+	v.mu.Lock()
+	v.fetchedAt = v.fetchedAt.Add(-time.Minute)
+	v.mu.Unlock()
+	if _, err := v.Verify(ctx, signToken(t, "RS256", "rsa-2", rotated, p.claims())); err != nil {
+		t.Fatalf("rotated key: %v", err)
+	}
+	calls := p.jwksCalls.Load()
+	if calls != 2 {
+		t.Errorf("JWKS fetched %d times, want 2", calls)
+	}
+
+	// Unknown key IDs right after a fetch do not trigger another one.
+	if _, err := v.Verify(ctx, signToken(t, "RS256", "made-up", rotated, p.claims())); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("made-up kid: err = %v", err)
+	}
+	if n := p.jwksCalls.Load(); n != calls {
+		t.Errorf("JWKS fetched %d times, want %d", n, calls)
+	}
+}
+
+func TestVerifier_DiscoveryIssuerMismatch(t *testing.T) {
+	p := newTestProvider(t)
+	p.issuerOver = "https://other.example"
+	v := p.verifier(t, nil)
+	if _, err := v.Verify(context.Background(), signToken(t, "RS256", "rsa-1", p.rsaKey, p.claims())); !errors.Is(err, ErrKeysUnavailable) {
+		t.Errorf("err = %v, want ErrKeysUnavailable", err)
+	}
+}
+
+func TestNewVerifier_RejectsEmptyRoles(t *testing.T) {
+	_, err := NewVerifier(Config{
+		Issuer:       "https://idp.example",
+		Audiences:    []string{"sg"},
+		RoleMappings: map[string][]string{"eng": {""}},
+	})
+	if err == nil {
+		t.Error("expected an error for an empty role")
+	}
+}
+
+func TestLooksLikeJWT(t *testing.T) {
+	for s, want := range map[string]bool{
+		"sg_0123456789abcdef":      false,
+		"eyJhbGciOi.eyJzdWIi.sig":  true,
+		"eyJhbGciOi.eyJzdWIi":      false,
+		"eyJ.a.b.c":                false,
+		"plain.bearer.token-value": false,
+	} {
+		if got := LooksLikeJWT(s); got != want {
+			t.Errorf("LooksLikeJWT(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
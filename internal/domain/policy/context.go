@@ -64,6 +64,21 @@ type EvaluationContext struct {
 	DestPath string
 	// DestCommand is the command being executed (for command_exec actions).
 	DestCommand string
+	// DestURLs lists every destination URL found in the arguments.
+	DestURLs []string
+	// DestDomains lists the distinct domains of DestURLs.
+	DestDomains []string
+	// DestCountry is the ISO 3166-1 alpha-2 country of DestIP, when a
+	// GeoIP database locates it.
+	DestCountry string
+	// DestASN is the autonomous system number of DestIP, 0 when unknown.
+	DestASN int64
+	// DestASNOrg is the organization of the autonomous system.
+	DestASNOrg string
+
+	// PayloadBytes is the size in bytes of the request as received: the
+	// JSON-RPC message of a tool call, or the body of an HTTP request.
+	PayloadBytes int64
 
 	// Session usage fields (Phase 15: Budget & Quota)
 	// SessionCallCount is the total number of tool calls in the current session.
@@ -89,6 +104,30 @@ type EvaluationContext struct {
 	// Used by session_has_arg.
 	SessionArgKeySet map[string]bool
 
+	// Taint tracking
+	// ActionTainted is true when content flagged by scanning in an earlier
+	// result of the session reappears in the arguments or destination.
+	ActionTainted bool
+	// TaintSources are the tools whose flagged results reappear.
+	TaintSources []string
+
+	// Argument anomaly detection
+	// AnomalyScore is how far the arguments deviate from the tool's
+	// profile, from 0 (usual) to 1.
+	AnomalyScore float64
+	// AnomalyReasons describe the deviating arguments.
+	AnomalyReasons []string
+	// AnomalyWarmingUp is true while the tool's profile is still learning.
+	AnomalyWarmingUp bool
+
+	// Variables are the admin-managed policy variables, exposed to rule
+	// conditions as vars. Nil means the PolicyService's current set.
+	Variables map[string]interface{}
+
+	// Enrichment holds the attributes fetched from the policy enrichment
+	// endpoint, exposed to rule conditions as enrichment.
+	Enrichment map[string]interface{}
+
 	// Agent Health variables (Upgrade 11: Health Dashboard)
 	// UserDenyRate is the agent's deny rate (0.0 to 1.0) over the last 24h.
 	UserDenyRate float64
@@ -0,0 +1,221 @@
+package policy
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"unicode"
+)
+
+// ApprovalsURL is the Admin UI page where pending approvals are reviewed.
+const ApprovalsURL = "/admin/#/notifications"
+
+const (
+	// maxHelpValueLen caps each substituted value; tool names and destinations
+	// come from the caller and must not be able to flood the message.
+	maxHelpValueLen = 256
+	// maxHelpTextLen caps the rendered help text.
+	maxHelpTextLen = 2048
+)
+
+// HelpDestination describes the destination of a denied action.
+type HelpDestination struct {
+	Domain  string
+	URL     string
+	IP      string
+	Port    string
+	Scheme  string
+	Path    string
+	Command string
+}
+
+// HelpTextData is the data available to help text and help URL templates,
+// e.g. "{{.ToolName}} is blocked for {{.Identity}}".
+type HelpTextData struct {
+	// ToolName is the name of the denied tool or action.
+	ToolName string
+	// Identity is the caller's identity name, or its ID when unnamed.
+	Identity string
+	// RuleName is the name of the rule that produced the decision.
+	RuleName string
+	// Destination is the destination of the action, if any.
+	Destination HelpDestination
+	// ApprovalURL links to the Admin UI page where approvals are reviewed.
+	ApprovalURL string
+}
+
+// NewHelpTextData builds template data from an evaluation context.
+func NewHelpTextData(evalCtx EvaluationContext, ruleName string) HelpTextData {
+	identity := evalCtx.IdentityName
+	if identity == "" {
+		identity = evalCtx.IdentityID
+	}
+	toolName := evalCtx.ToolName
+	if toolName == "" {
+		toolName = evalCtx.ActionName
+	}
+	data := HelpTextData{
+		ToolName: toolName,
+		Identity: identity,
+		RuleName: ruleName,
+		Destination: HelpDestination{
+			Domain:  evalCtx.DestDomain,
+			URL:     evalCtx.DestURL,
+			IP:      evalCtx.DestIP,
+			Scheme:  evalCtx.DestScheme,
+			Path:    evalCtx.DestPath,
+			Command: evalCtx.DestCommand,
+		},
+		ApprovalURL: ApprovalsURL,
+	}
+	if evalCtx.DestPort > 0 {
+		data.Destination.Port = strconv.Itoa(evalCtx.DestPort)
+	}
+	return data
+}
+
+// helpTemplateFields lists the variables a help template may reference.
+var helpTemplateFields = map[string]struct{}{
+	"ToolName": {}, "Identity": {}, "RuleName": {}, "ApprovalURL": {},
+	"Destination.Domain": {}, "Destination.URL": {}, "Destination.IP": {}, "Destination.Port": {},
+	"Destination.Scheme": {}, "Destination.Path": {}, "Destination.Command": {},
+}
+
+// ValidateHelpTemplate checks that a help text or help URL only uses plain
+// variable references such as {{.ToolName}}. Functions, pipelines, conditionals
+// and loops are rejected so rendering can never run arbitrary logic.
+func ValidateHelpTemplate(text string) error {
+	_, err := parseHelpTemplate(text)
+	return err
+}
+
+// RenderHelpText renders a help text template. Substituted values are stripped
+// of control characters and truncated. Text without "{{" is returned unchanged.
+func RenderHelpText(text string, data HelpTextData) (string, error) {
+	return renderHelpTemplate(text, data, sanitizeHelpValue)
+}
+
+// RenderHelpURL renders a help URL template; substituted values are also
+// query-escaped so they cannot change the URL structure.
+func RenderHelpURL(text string, data HelpTextData) (string, error) {
+	return renderHelpTemplate(text, data, func(s string) string {
+		return url.QueryEscape(sanitizeHelpValue(s))
+	})
+}
+
+func renderHelpTemplate(text string, data HelpTextData, escape func(string) string) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	tmpl, err := parseHelpTemplate(text)
+	if err != nil {
+		return "", err
+	}
+	data.ToolName = escape(data.ToolName)
+	data.Identity = escape(data.Identity)
+	data.RuleName = escape(data.RuleName)
+	data.ApprovalURL = escape(data.ApprovalURL)
+	d := &data.Destination
+	for _, v := range []*string{&d.Domain, &d.URL, &d.IP, &d.Port, &d.Scheme, &d.Path, &d.Command} {
+		*v = escape(*v)
+	}
+
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("render help template: %w", err)
+	}
+	out := buf.String()
+	if len(out) > maxHelpTextLen {
+		out = strings.ToValidUTF8(out[:maxHelpTextLen], "")
+	}
+	return out, nil
+}
+
+// parseHelpTemplate parses text and checks that every action is a single
+// reference to a known variable.
+func parseHelpTemplate(text string) (*template.Template, error) {
+	if !strings.Contains(text, "{{") {
+		return nil, nil
+	}
+	tmpl, err := template.New("help").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid help template: %w", err)
+	}
+	if tmpl.Tree == nil || tmpl.Tree.Root == nil {
+		return tmpl, nil
+	}
+	for _, node := range tmpl.Tree.Root.Nodes {
+		switch n := node.(type) {
+		case *parse.TextNode:
+		case *parse.ActionNode:
+			field, ok := helpTemplateField(n)
+			if !ok {
+				return nil, fmt.Errorf("invalid help template: %s: only variables like {{.ToolName}} are allowed", n)
+			}
+			if _, known := helpTemplateFields[field]; !known {
+				return nil, fmt.Errorf("invalid help template: unknown variable {{.%s}} (valid: %s)", field, helpTemplateFieldList())
+			}
+		default:
+			return nil, fmt.Errorf("invalid help template: %s: only variables like {{.ToolName}} are allowed", n)
+		}
+	}
+	return tmpl, nil
+}
+
+// helpTemplateField returns the dotted field path of an action that is a
+// single field reference, e.g. "Destination.Domain" for {{.Destination.Domain}}.
+func helpTemplateField(n *parse.ActionNode) (string, bool) {
+	if n.Pipe == nil || len(n.Pipe.Decl) > 0 || len(n.Pipe.Cmds) != 1 || len(n.Pipe.Cmds[0].Args) != 1 {
+		return "", false
+	}
+	field, ok := n.Pipe.Cmds[0].Args[0].(*parse.FieldNode)
+	if !ok {
+		return "", false
+	}
+	return strings.Join(field.Ident, "."), true
+}
+
+// helpTemplateFieldList returns the valid variables for error messages.
+func helpTemplateFieldList() string {
+	names := make([]string, 0, len(helpTemplateFields))
+	for name := range helpTemplateFields {
+		names = append(names, "."+name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// sanitizeHelpValue removes control characters and truncates s.
+func sanitizeHelpValue(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+	if len(s) > maxHelpValueLen {
+		s = strings.ToValidUTF8(s[:maxHelpValueLen], "") + "..."
+	}
+	return s
+}
+
+// RenderDecisionHelp renders the help templates carried by a rule decision.
+// An empty result means the rule defines none (or it failed to render) and
+// the caller should fall back to its generic guidance.
+func RenderDecisionHelp(d Decision, data HelpTextData) (helpURL, helpText string) {
+	if d.HelpURL != "" {
+		if rendered, err := RenderHelpURL(d.HelpURL, data); err == nil {
+			helpURL = rendered
+		}
+	}
+	if d.HelpText != "" {
+		if rendered, err := RenderHelpText(d.HelpText, data); err == nil {
+			helpText = rendered
+		}
+	}
+	return helpURL, helpText
+}
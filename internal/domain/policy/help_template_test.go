@@ -0,0 +1,101 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+)
+
+func testHelpData() HelpTextData {
+	return NewHelpTextData(EvaluationContext{
+		ToolName:     "fetch_url",
+		IdentityID:   "id-1",
+		IdentityName: "ci-bot",
+		DestDomain:   "evil.example",
+		DestURL:      "https://evil.example/x?a=1&b=2",
+		DestPort:     443,
+	}, "block-exfil")
+}
+
+func TestRenderHelpText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"plain text unchanged", "Ask an admin.", "Ask an admin."},
+		{"tool and identity", "{{.ToolName}} is blocked for {{.Identity}}", "fetch_url is blocked for ci-bot"},
+		{"destination", "{{.Destination.Domain}}:{{.Destination.Port}} is not allowed", "evil.example:443 is not allowed"},
+		{"approval url", "Request access at {{.ApprovalURL}}", "Request access at " + ApprovalsURL},
+		{"rule name", "See rule {{.RuleName}}", "See rule block-exfil"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderHelpText(tt.text, testHelpData())
+			if err != nil {
+				t.Fatalf("RenderHelpText() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderHelpText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderHelpText_SanitizesValues(t *testing.T) {
+	data := testHelpData()
+	data.ToolName = "evil\n\x1b[31mtool" + strings.Repeat("x", 1000)
+
+	got, err := RenderHelpText("blocked: {{.ToolName}}", data)
+	if err != nil {
+		t.Fatalf("RenderHelpText() error: %v", err)
+	}
+	if strings.ContainsAny(got, "\n\x1b") {
+		t.Errorf("control characters not stripped: %q", got)
+	}
+	if len(got) > len("blocked: ")+maxHelpValueLen+len("...") {
+		t.Errorf("value not truncated, len = %d", len(got))
+	}
+}
+
+func TestRenderHelpURL_EscapesValues(t *testing.T) {
+	got, err := RenderHelpURL("https://wiki.example/access?tool={{.ToolName}}&dest={{.Destination.URL}}", testHelpData())
+	if err != nil {
+		t.Fatalf("RenderHelpURL() error: %v", err)
+	}
+	want := "https://wiki.example/access?tool=fetch_url&dest=https%3A%2F%2Fevil.example%2Fx%3Fa%3D1%26b%3D2"
+	if got != want {
+		t.Errorf("RenderHelpURL() = %q, want %q", got, want)
+	}
+}
+
+func TestValidateHelpTemplate(t *testing.T) {
+	valid := []string{
+		"",
+		"no variables",
+		"{{.ToolName}} via {{.Destination.Domain}}",
+	}
+	for _, text := range valid {
+		if err := ValidateHelpTemplate(text); err != nil {
+			t.Errorf("ValidateHelpTemplate(%q) unexpected error: %v", text, err)
+		}
+	}
+
+	invalid := map[string]string{
+		"{{.ToolName":                         "invalid help template",
+		"{{.Password}}":                       "unknown variable",
+		"{{.Destination}}":                    "unknown variable",
+		`{{printf "%0999999999d" 1}}`:         "only variables",
+		"{{.ToolName | len}}":                 "only variables",
+		"{{if .ToolName}}x{{end}}":            "only variables",
+		"{{range .ToolName}}{{end}}":          "only variables",
+		"{{$x := .ToolName}}{{$x}}":           "only variables",
+		`{{template "help" .}}`:               "only variables",
+		"{{/* comment */}}{{.Unknown.Field}}": "unknown variable",
+	}
+	for text, want := range invalid {
+		err := ValidateHelpTemplate(text)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ValidateHelpTemplate(%q) error = %v, want error containing %q", text, err, want)
+		}
+	}
+}
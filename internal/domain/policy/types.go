@@ -1,7 +1,11 @@
 // Package policy contains domain types for RBAC policy evaluation.
 package policy
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 // Action represents the result of a policy rule evaluation.
 type Action string
@@ -13,8 +17,53 @@ const (
 	ActionDeny Action = "deny"
 	// ActionApprovalRequired requires human approval before the tool call proceeds.
 	ActionApprovalRequired Action = "approval_required"
+	// ActionExemptRateLimit lets the matching calls bypass the user rate
+	// limit. It never allows or denies a call: rules with this action are
+	// skipped by policy evaluation.
+	ActionExemptRateLimit Action = "exempt_rate_limit"
+)
+
+// ExemptScope is what a matching exempt_rate_limit rule exempts.
+type ExemptScope string
+
+const (
+	// ExemptScopeCall exempts only the matching call (default).
+	ExemptScopeCall ExemptScope = "call"
+	// ExemptScopeSession exempts every call of the session for ExemptDuration.
+	ExemptScopeSession ExemptScope = "session"
+	// ExemptScopeIdentity exempts every call of the identity for ExemptDuration.
+	ExemptScopeIdentity ExemptScope = "identity"
 )
 
+// DefaultExemptDuration is how long a session or identity exemption lasts
+// when the rule sets no ExemptDuration.
+const DefaultExemptDuration = time.Hour
+
+// ValidateExemption checks the exemption fields of a rule: they are only
+// allowed on exempt_rate_limit rules, and a duration only on session and
+// identity scopes.
+func ValidateExemption(action Action, scope ExemptScope, duration time.Duration) error {
+	if action != ActionExemptRateLimit {
+		if scope != "" || duration != 0 {
+			return errors.New("exempt_scope and exempt_duration need action exempt_rate_limit")
+		}
+		return nil
+	}
+	switch scope {
+	case "", ExemptScopeCall:
+		if duration != 0 {
+			return errors.New("exempt_duration needs exempt_scope session or identity")
+		}
+	case ExemptScopeSession, ExemptScopeIdentity:
+		if duration < 0 {
+			return errors.New("exempt_duration must be positive")
+		}
+	default:
+		return fmt.Errorf("exempt_scope must be call, session or identity, got %q", scope)
+	}
+	return nil
+}
+
 // Rule defines a single policy rule for tool call authorization.
 type Rule struct {
 	// ID is the unique identifier for this rule.
@@ -39,9 +88,20 @@ type Rule struct {
 	// Must be ActionDeny (default) or ActionAllow.
 	TimeoutAction Action
 
+	// ExemptScope is what the rule exempts when Action is
+	// ActionExemptRateLimit. Defaults to ExemptScopeCall.
+	ExemptScope ExemptScope
+	// ExemptDuration is how long a session or identity exemption lasts.
+	// Defaults to DefaultExemptDuration.
+	ExemptDuration time.Duration
+
 	// HelpText is optional admin-provided guidance shown when this rule denies an action.
 	// When empty, a default help text is generated from the rule name.
+	// It may reference variables such as {{.ToolName}} (see RenderHelpText).
 	HelpText string
+	// HelpURL is an optional link shown with denials instead of the Admin UI
+	// rule link. It may reference the same variables as HelpText.
+	HelpURL string
 
 	// Source identifies the origin of this rule (e.g., "template:read-only", "redteam").
 	// Empty for manually created rules.
@@ -70,10 +130,16 @@ type Decision struct {
 	// Priority is the priority of the matched rule (used for simulation comparisons).
 	Priority int
 	// HelpURL is a direct link to the rule in the Admin UI (e.g., "/admin/policies#rule-{ruleID}").
+	// When set from the rule it is an unrendered template.
 	HelpURL string
 	// HelpText is a human explanation of how to resolve a denial
 	// (e.g., "This tool is blocked. Ask an admin to modify the 'block-exec' rule.").
+	// When set from the rule it is an unrendered template.
 	HelpText string
+
+	// DecisionID identifies the decision in the decision log of an external
+	// policy engine (OPA). Empty for CEL rules.
+	DecisionID string
 }
 
 // Policy is a collection of rules for tool call authorization.
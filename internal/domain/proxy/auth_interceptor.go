@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"sync"
 	"time"
@@ -20,6 +21,8 @@ type cacheEntry struct {
 	identityID string // identity that owns this session (for role-change invalidation)
 	lastAccess time.Time
 	apiKeyHash string // SHA-256 hash of the API key used to create this session (empty if no key)
+	// allowedOrigins is the origin binding of the API key (empty = unbound).
+	allowedOrigins []string
 }
 
 // apiKeyContextKey is the context key type for API key.
@@ -30,6 +33,15 @@ type apiKeyContextKey struct{}
 // Example: ctx = context.WithValue(ctx, proxy.APIKeyContextKey, "my-api-key")
 var APIKeyContextKey = apiKeyContextKey{}
 
+// originContextKey is the context key type for the request origin.
+type originContextKey struct{}
+
+// OriginContextKey is the context key for the browser Origin of the request.
+// HTTP transport sets it only after the origin passed the global allowlist;
+// it is absent for non-browser clients. AuthInterceptor checks it against
+// the API key's origin binding.
+var OriginContextKey = originContextKey{}
+
 // sessionIDSlotKey is the context key for the session ID write-back slot.
 type sessionIDSlotKey struct{}
 
@@ -45,8 +57,35 @@ var (
 	ErrInvalidAPIKey   = errors.New("invalid API key")
 	ErrSessionExpired  = errors.New("session expired")
 	ErrInternalError   = errors.New("internal error")
+
+	// ErrOriginNotAllowed is returned when an API key bound to specific
+	// origins is used from a different browser origin.
+	ErrOriginNotAllowed = errors.New("origin not allowed for API key")
+
+	// ErrAccessRestricted is returned when an identity connects outside its
+	// allowed time windows or from a disallowed country.
+	ErrAccessRestricted = errors.New("access restricted for identity")
 )
 
+// AccessRestrictedError carries the reason an identity's access
+// restrictions refused a request.
+type AccessRestrictedError struct {
+	// Restriction is auth.RestrictionTimeWindow or auth.RestrictionCountry.
+	Restriction string
+	// Reason is a human-readable explanation, safe to show to the client.
+	Reason string
+}
+
+// Error implements the error interface.
+func (e *AccessRestrictedError) Error() string {
+	return "access restricted: " + e.Reason
+}
+
+// Unwrap returns ErrAccessRestricted so errors.Is(err, ErrAccessRestricted) works.
+func (e *AccessRestrictedError) Unwrap() error {
+	return ErrAccessRestricted
+}
+
 // SafeErrorMessage returns a client-safe error message.
 // Internal error details are logged but not exposed to clients.
 // SECURITY: This function MUST be used for all client-facing error responses
@@ -58,6 +97,26 @@ func SafeErrorMessage(err error) string {
 		return "Rate limit exceeded"
 	}
 
+	// A deny loop backoff only reveals the caller's own retry count.
+	var loopErr *DenyLoopError
+	if errors.As(err, &loopErr) {
+		return fmt.Sprintf("Access denied by policy (call denied %d times, retry after %ds)", loopErr.Denials, int((loopErr.RetryAfter+time.Second-1)/time.Second))
+	}
+
+	// Rule-provided guidance is rendered and sanitized at denial time,
+	// so it is safe to show to the client.
+	var denyErr *PolicyDenyError
+	if errors.As(err, &denyErr) && denyErr.HelpText != "" {
+		return "Access denied by policy (" + denyErr.HelpText + ")"
+	}
+
+	// Restriction reasons are built from the identity's own configuration
+	// (hours, timezone, country codes) and carry no secrets.
+	var restrictedErr *AccessRestrictedError
+	if errors.As(err, &restrictedErr) {
+		return "Access restricted (" + restrictedErr.Reason + ")"
+	}
+
 	switch {
 	case errors.Is(err, ErrUnauthenticated):
 		return "Authentication required"
@@ -65,6 +124,10 @@ func SafeErrorMessage(err error) string {
 		return "Invalid API key"
 	case errors.Is(err, ErrSessionExpired):
 		return "Session expired"
+	case errors.Is(err, ErrOriginNotAllowed):
+		return "Origin not allowed for this API key"
+	case errors.Is(err, ErrAccessRestricted):
+		return "Access restricted"
 	case errors.Is(err, ErrPolicyDenied):
 		return "Access denied by policy"
 	case errors.Is(err, ErrMissingSession):
@@ -82,6 +145,13 @@ func SafeErrorMessage(err error) string {
 	}
 }
 
+// IsInternalError reports whether err is an unexpected gateway failure, i.e.
+// one that SafeErrorMessage hides behind "Internal error" rather than a
+// deliberate rejection (auth, policy, quota, rate limit, scanning).
+func IsInternalError(err error) bool {
+	return err != nil && SafeErrorMessage(err) == "Internal error"
+}
+
 // AuthInterceptor validates API keys and manages sessions.
 // It wraps another MessageInterceptor (e.g., policy engine).
 //
@@ -184,6 +254,9 @@ func (a *AuthInterceptor) Intercept(ctx context.Context, msg *mcp.Message) (*mcp
 	}
 	msg.APIKey = apiKey
 
+	// Browser origin, already checked against the global allowlist by the transport
+	origin, _ := ctx.Value(OriginContextKey).(string)
+
 	// Check sessionCache for existing session ID by connection ID
 	a.sessionMu.RLock()
 	entry, hasCachedSession := a.sessionCache[connID]
@@ -222,6 +295,17 @@ func (a *AuthInterceptor) Intercept(ctx context.Context, msg *mcp.Message) (*mcp
 		}
 	}
 
+	// The connection ID is derived from the API key alone, so the cached
+	// session is shared by every origin using the key: re-check the binding
+	// on each request.
+	if hasCachedSession && !auth.OriginAllowed(entry.allowedOrigins, origin) {
+		a.logger.Debug("origin not allowed for API key",
+			"connection_id", connID,
+			"origin", origin,
+		)
+		return nil, ErrOriginNotAllowed
+	}
+
 	if hasCachedSession {
 		cachedSessionID = entry.sessionID
 		// Update last access time
@@ -270,7 +354,7 @@ func (a *AuthInterceptor) Intercept(ctx context.Context, msg *mcp.Message) (*mcp
 	}
 
 	// Validate API key
-	identity, err := a.apiKeyService.Validate(ctx, apiKey)
+	identity, key, err := a.apiKeyService.ValidateKey(ctx, apiKey)
 	if err != nil {
 		if errors.Is(err, auth.ErrInvalidKey) {
 			a.logger.Debug("invalid API key",
@@ -286,6 +370,14 @@ func (a *AuthInterceptor) Intercept(ctx context.Context, msg *mcp.Message) (*mcp
 		return nil, ErrInvalidAPIKey
 	}
 
+	if !key.AllowsOrigin(origin) {
+		a.logger.Debug("origin not allowed for API key",
+			"connection_id", connID,
+			"origin", origin,
+		)
+		return nil, ErrOriginNotAllowed
+	}
+
 	// Create new session
 	sess, err := a.sessionService.Create(ctx, identity)
 	if err != nil {
@@ -304,10 +396,11 @@ func (a *AuthInterceptor) Intercept(ctx context.Context, msg *mcp.Message) (*mcp
 		a.evictOldestLocked()
 	}
 	a.sessionCache[connID] = &cacheEntry{
-		sessionID:  sess.ID,
-		identityID: identity.ID,
-		lastAccess: time.Now(),
-		apiKeyHash: apiKeyHashForCache(apiKey),
+		sessionID:      sess.ID,
+		identityID:     identity.ID,
+		lastAccess:     time.Now(),
+		apiKeyHash:     apiKeyHashForCache(apiKey),
+		allowedOrigins: key.AllowedOrigins,
 	}
 	a.sessionMu.Unlock()
 
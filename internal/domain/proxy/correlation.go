@@ -0,0 +1,98 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+)
+
+// SetCorrelationPropagation makes forwarded requests carry the correlation
+// ID of the client request: in the header (HTTP upstreams only) and under
+// params._meta[metaKey]. An empty header or metaKey disables that channel;
+// both empty (default) forwards requests unchanged.
+func (r *UpstreamRouter) SetCorrelationPropagation(header, metaKey string) {
+	r.corrMu.Lock()
+	defer r.corrMu.Unlock()
+	r.corrHeader = header
+	r.corrMetaKey = metaKey
+}
+
+func (r *UpstreamRouter) getCorrelationPropagation() (header, metaKey string) {
+	r.corrMu.RLock()
+	defer r.corrMu.RUnlock()
+	return r.corrHeader, r.corrMetaKey
+}
+
+// correlate adds the correlation ID in ctx to a request about to be written
+// to writer. headers are the credentials of the request; they are copied,
+// not modified. The header is only added when writer can carry headers.
+func (r *UpstreamRouter) correlate(ctx context.Context, writer io.Writer, data []byte, headers map[string]string) ([]byte, map[string]string) {
+	id := audit.CorrelationIDFromContext(ctx)
+	if id == "" {
+		return data, headers
+	}
+	header, metaKey := r.getCorrelationPropagation()
+	if metaKey != "" {
+		if raw, ok := withParamsMeta(data, metaKey, id); ok {
+			data = raw
+		}
+	}
+	if _, ok := writer.(UpstreamHeaderWriter); ok && header != "" {
+		withID := make(map[string]string, len(headers)+1)
+		for k, v := range headers {
+			withID[k] = v
+		}
+		withID[header] = id
+		headers = withID
+	}
+	return data, headers
+}
+
+// withParamsMeta returns the JSON-RPC request raw with value stored under
+// params._meta[key]. Missing params and _meta objects are created; other
+// entries are preserved. Returns false if raw is not a request whose
+// params and _meta are objects.
+func withParamsMeta(raw []byte, key, value string) ([]byte, bool) {
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return nil, false
+	}
+	if _, ok := envelope["method"]; !ok {
+		return nil, false
+	}
+	params := map[string]json.RawMessage{}
+	if existing, ok := envelope["params"]; ok {
+		if err := json.Unmarshal(existing, &params); err != nil || params == nil {
+			return nil, false
+		}
+	}
+	meta := map[string]json.RawMessage{}
+	if existing, ok := params["_meta"]; ok {
+		if err := json.Unmarshal(existing, &meta); err != nil || meta == nil {
+			return nil, false
+		}
+	}
+
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return nil, false
+	}
+	meta[key] = valueJSON
+	metaJSON, err := json.Marshal(meta)
+	if err != nil {
+		return nil, false
+	}
+	params["_meta"] = metaJSON
+	paramsJSON, err := json.Marshal(params)
+	if err != nil {
+		return nil, false
+	}
+	envelope["params"] = paramsJSON
+	out, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, false
+	}
+	return out, true
+}
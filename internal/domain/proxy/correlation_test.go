@@ -0,0 +1,109 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+)
+
+func TestWithParamsMeta(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string // params._meta as JSON, "" when not changed
+	}{
+		{"no params", `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`, `{"k":"v"}`},
+		{"no meta", `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"t"}}`, `{"k":"v"}`},
+		{"existing meta", `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"_meta":{"progressToken":7}}}`, `{"k":"v","progressToken":7}`},
+		{"replaces key", `{"jsonrpc":"2.0","id":1,"method":"ping","params":{"_meta":{"k":"old"}}}`, `{"k":"v"}`},
+		{"response", `{"jsonrpc":"2.0","id":1,"result":{}}`, ""},
+		{"array params", `{"jsonrpc":"2.0","id":1,"method":"m","params":[1]}`, ""},
+		{"non-object meta", `{"jsonrpc":"2.0","id":1,"method":"m","params":{"_meta":"x"}}`, ""},
+		{"invalid JSON", `{`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, ok := withParamsMeta([]byte(tt.raw), "k", "v")
+			if ok != (tt.want != "") {
+				t.Fatalf("withParamsMeta() ok = %v, want %v", ok, tt.want != "")
+			}
+			if !ok {
+				return
+			}
+			var msg struct {
+				Method string `json:"method"`
+				Params struct {
+					Meta json.RawMessage `json:"_meta"`
+				} `json:"params"`
+			}
+			if err := json.Unmarshal(out, &msg); err != nil {
+				t.Fatalf("output is not JSON: %v", err)
+			}
+			if string(msg.Params.Meta) != tt.want {
+				t.Errorf("_meta = %s, want %s", msg.Params.Meta, tt.want)
+			}
+			if msg.Method == "" {
+				t.Error("method was dropped")
+			}
+		})
+	}
+}
+
+func TestRouterToolsCall_PropagatesCorrelationID(t *testing.T) {
+	cache := newMockToolCacheReader(&RoutableTool{Name: "repo-read", UpstreamID: "upstream-1"})
+	manager := newMockUpstreamConnectionProvider()
+	manager.addConnection("upstream-1", `{"jsonrpc":"2.0","id":1,"result":{}}`)
+
+	hw := &mockHeaderWriteCloser{}
+	router := newTestRouter(cache, &headerProvider{mockUpstreamConnectionProvider: manager, writer: hw})
+	router.SetCorrelationPropagation("X-Correlation-ID", "sentinelgate/correlation_id")
+
+	ctx := audit.WithCorrelationID(context.Background(), "trace-42")
+	if _, err := router.Intercept(ctx, makeToolsCallRequest(t, 1, "repo-read", nil)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := hw.headers["X-Correlation-ID"]; got != "trace-42" {
+		t.Errorf("X-Correlation-ID header = %q, want %q", got, "trace-42")
+	}
+	var sent struct {
+		Params struct {
+			Meta map[string]string `json:"_meta"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(hw.buf, &sent); err != nil {
+		t.Fatalf("forwarded message is not JSON: %v", err)
+	}
+	if got := sent.Params.Meta["sentinelgate/correlation_id"]; got != "trace-42" {
+		t.Errorf("_meta correlation ID = %q, want %q", got, "trace-42")
+	}
+}
+
+func TestRouterToolsCall_CorrelationMetaOnStdio(t *testing.T) {
+	cache := newMockToolCacheReader(&RoutableTool{Name: "repo-read", UpstreamID: "upstream-1"})
+	manager := newMockUpstreamConnectionProvider()
+	manager.addConnection("upstream-1", `{"jsonrpc":"2.0","id":1,"result":{}}`)
+
+	router := newTestRouter(cache, manager)
+	// The header cannot reach a stdio upstream; the request still goes through.
+	router.SetCorrelationPropagation("X-Correlation-ID", "sentinelgate/correlation_id")
+
+	ctx := audit.WithCorrelationID(context.Background(), "trace-42")
+	if _, err := router.Intercept(ctx, makeToolsCallRequest(t, 1, "repo-read", nil)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	buf := manager.connections["upstream-1"].writer.buf
+	if !json.Valid(buf) {
+		t.Fatalf("forwarded message is not JSON: %s", buf)
+	}
+	var sent struct {
+		Params struct {
+			Meta map[string]string `json:"_meta"`
+		} `json:"params"`
+	}
+	_ = json.Unmarshal(buf, &sent)
+	if got := sent.Params.Meta["sentinelgate/correlation_id"]; got != "trace-42" {
+		t.Errorf("_meta correlation ID = %q, want %q", got, "trace-42")
+	}
+}
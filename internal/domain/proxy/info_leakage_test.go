@@ -32,6 +32,9 @@ func TestSafeErrorMessage_AllTypes(t *testing.T) {
 		{"InvalidAPIKey", ErrInvalidAPIKey, "Invalid API key"},
 		{"SessionExpired", ErrSessionExpired, "Session expired"},
 		{"PolicyDenied", ErrPolicyDenied, "Access denied by policy"},
+		{"OriginNotAllowed", ErrOriginNotAllowed, "Origin not allowed for this API key"},
+		{"AccessRestricted", ErrAccessRestricted, "Access restricted"},
+		{"AccessRestrictedReason", &AccessRestrictedError{Restriction: "country", Reason: "connections from US are not allowed"}, "Access restricted (connections from US are not allowed)"},
 		{"MissingSession", ErrMissingSession, "Session required"},
 		{"QuotaExceeded", ErrQuotaExceeded, "Quota exceeded"},
 		{"ContentBlocked", ErrContentBlocked, "Blocked by content scanning: sensitive data detected"},
@@ -40,6 +43,9 @@ func TestSafeErrorMessage_AllTypes(t *testing.T) {
 		{"RateLimit", &RateLimitError{RetryAfter: 5 * time.Second}, "Rate limit exceeded"},
 		{"GenericInternal", fmt.Errorf("connecting to db: password=xyz"), "Internal error"},
 		{"WrappedPolicyDenied", fmt.Errorf("wrapper: %w", ErrPolicyDenied), "Access denied by policy"},
+		{"PolicyDenyNoHelp", &PolicyDenyError{RuleID: "r1", Reason: "matched rule r1"}, "Access denied by policy"},
+		{"PolicyDenyHelpText", &PolicyDenyError{RuleID: "r1", HelpText: "fetch_url is blocked"}, "Access denied by policy (fetch_url is blocked)"},
+		{"DenyLoop", &DenyLoopError{Denials: 6, RetryAfter: 1500 * time.Millisecond}, "Access denied by policy (call denied 6 times, retry after 2s)"},
 	}
 
 	// Sensitive terms that must never appear in safe error messages.
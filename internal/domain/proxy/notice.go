@@ -0,0 +1,97 @@
+package proxy
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/session"
+	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
+)
+
+// NoticeMetaKey is the initialize result _meta key carrying the notice, for
+// agent frontends that show it apart from the model instructions.
+const NoticeMetaKey = "sentinelgate/notice"
+
+// Notice is text an operator wants the users of agent frontends to see,
+// such as an environment banner ("PRODUCTION — all actions audited") or a
+// usage policy. It is returned with the initialize result.
+type Notice struct {
+	// Banner is a short line such as an environment label.
+	Banner string `json:"banner,omitempty"`
+	// Terms is the usage policy text.
+	Terms string `json:"terms,omitempty"`
+	// Version identifies the text, so audit records show which version a
+	// session acknowledged.
+	Version string `json:"version"`
+}
+
+// instructions renders the notice for the initialize result's
+// instructions field.
+func (n *Notice) instructions() string {
+	parts := make([]string, 0, 2)
+	if n.Banner != "" {
+		parts = append(parts, n.Banner)
+	}
+	if n.Terms != "" {
+		parts = append(parts, n.Terms)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// NoticeProvider supplies the notice for a session and records its
+// acknowledgment. Implementations must be safe for concurrent use.
+type NoticeProvider interface {
+	// NoticeFor returns the notice for the session's identity, or nil when
+	// none applies.
+	NoticeFor(sess *session.Session) *Notice
+	// AcknowledgeNotice is called when a session that was served a notice
+	// completes the handshake with notifications/initialized.
+	AcknowledgeNotice(ctx context.Context, sess *session.Session, notice *Notice)
+}
+
+// SetNoticeProvider sets the source of the notice returned with initialize.
+// When nil (default), initialize results carry no notice.
+func (r *UpstreamRouter) SetNoticeProvider(p NoticeProvider) {
+	r.noticeMu.Lock()
+	defer r.noticeMu.Unlock()
+	r.noticeProvider = p
+}
+
+func (r *UpstreamRouter) getNoticeProvider() NoticeProvider {
+	r.noticeMu.RLock()
+	defer r.noticeMu.RUnlock()
+	return r.noticeProvider
+}
+
+// addNotice adds the session's notice to an initialize result and keeps it
+// until the client acknowledges it.
+func (r *UpstreamRouter) addNotice(msg *mcp.Message, result map[string]any) {
+	provider := r.getNoticeProvider()
+	if provider == nil || msg.Session == nil {
+		return
+	}
+	notice := provider.NoticeFor(msg.Session)
+	if notice == nil {
+		return
+	}
+	result["instructions"] = notice.instructions()
+	result["_meta"] = map[string]any{NoticeMetaKey: notice}
+	if msg.Session.ID != "" {
+		r.pendingNotices.Store(msg.Session.ID, notice)
+	}
+}
+
+// acknowledgeNotice reports the acknowledgment of the notice served to the
+// session's last initialize, once.
+func (r *UpstreamRouter) acknowledgeNotice(ctx context.Context, msg *mcp.Message) {
+	if msg.Session == nil || msg.Session.ID == "" {
+		return
+	}
+	v, ok := r.pendingNotices.LoadAndDelete(msg.Session.ID)
+	if !ok {
+		return
+	}
+	if provider := r.getNoticeProvider(); provider != nil {
+		provider.AcknowledgeNotice(ctx, msg.Session, v.(*Notice))
+	}
+}
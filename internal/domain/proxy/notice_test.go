@@ -0,0 +1,102 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/session"
+	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+)
+
+type mockNoticeProvider struct {
+	mu    sync.Mutex
+	acked []string
+}
+
+func (m *mockNoticeProvider) NoticeFor(sess *session.Session) *Notice {
+	if sess.IdentityID == "no-notice" {
+		return nil
+	}
+	return &Notice{Banner: "PRODUCTION", Terms: "All actions are audited.", Version: "v1"}
+}
+
+func (m *mockNoticeProvider) AcknowledgeNotice(_ context.Context, sess *session.Session, notice *Notice) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.acked = append(m.acked, sess.ID+"@"+notice.Version)
+}
+
+func TestRouterInitializeNotice(t *testing.T) {
+	router := newTestRouter(newMockToolCacheReader(), newMockUpstreamConnectionProvider())
+	provider := &mockNoticeProvider{}
+	router.SetNoticeProvider(provider)
+
+	initialized := func(sess *session.Session) {
+		t.Helper()
+		req := &jsonrpc.Request{Method: "notifications/initialized"}
+		msg := &mcp.Message{
+			Raw:       []byte(`{"jsonrpc":"2.0","method":"notifications/initialized"}`),
+			Direction: mcp.ClientToServer,
+			Decoded:   req,
+			Session:   sess,
+		}
+		if resp, err := router.Intercept(context.Background(), msg); err != nil || resp != nil {
+			t.Fatalf("notifications/initialized = %v, %v; want nil, nil", resp, err)
+		}
+	}
+
+	sess := &session.Session{ID: "s1", IdentityID: "alice"}
+	msg := makeInitializeRequest(t, 1)
+	msg.Session = sess
+	resp, err := router.Intercept(context.Background(), msg)
+	if err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+	var result struct {
+		Result struct {
+			Instructions string            `json:"instructions"`
+			Meta         map[string]Notice `json:"_meta"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(resp.Raw, &result); err != nil {
+		t.Fatalf("parse response: %v", err)
+	}
+	if want := "PRODUCTION\n\nAll actions are audited."; result.Result.Instructions != want {
+		t.Errorf("instructions = %q, want %q", result.Result.Instructions, want)
+	}
+	if got := result.Result.Meta[NoticeMetaKey]; got.Banner != "PRODUCTION" || got.Version != "v1" {
+		t.Errorf("_meta notice = %+v", got)
+	}
+
+	// The acknowledgment is reported once per initialize.
+	initialized(sess)
+	initialized(sess)
+	if len(provider.acked) != 1 || provider.acked[0] != "s1@v1" {
+		t.Errorf("acknowledgments = %v, want [s1@v1]", provider.acked)
+	}
+
+	// No notice for the identity: nothing is added or acknowledged.
+	other := &session.Session{ID: "s2", IdentityID: "no-notice"}
+	msg = makeInitializeRequest(t, 2)
+	msg.Session = other
+	resp, err = router.Intercept(context.Background(), msg)
+	if err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+	var plain struct {
+		Result map[string]any `json:"result"`
+	}
+	if err := json.Unmarshal(resp.Raw, &plain); err != nil {
+		t.Fatalf("parse response: %v", err)
+	}
+	if _, ok := plain.Result["instructions"]; ok {
+		t.Error("instructions set for an identity without a notice")
+	}
+	initialized(other)
+	if len(provider.acked) != 1 {
+		t.Errorf("acknowledgments = %v, want only s1", provider.acked)
+	}
+}
@@ -0,0 +1,151 @@
+package proxy
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"sync"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/validation"
+)
+
+// NotificationAction is how an upstream notification reaches clients.
+type NotificationAction string
+
+const (
+	// NotificationForward sends the notification to every session, on one
+	// of its connections (the default).
+	NotificationForward NotificationAction = "forward"
+	// NotificationLatest sends the notification only to the most recently
+	// opened connection of the session whose request was in flight.
+	NotificationLatest NotificationAction = "latest"
+	// NotificationDrop discards the notification.
+	NotificationDrop NotificationAction = "drop"
+)
+
+// NotificationRule sets the handling of the notifications matching Method,
+// a glob pattern (e.g., "notifications/message"), sent by the upstream
+// named Upstream (empty matches every upstream).
+type NotificationRule struct {
+	Upstream string             `json:"upstream,omitempty"`
+	Method   string             `json:"method"`
+	Action   NotificationAction `json:"action"`
+}
+
+// maxNotificationCounters caps the number of upstream and method pairs
+// counted individually. Method names come from upstreams, so further pairs
+// share one counter per upstream.
+const maxNotificationCounters = 256
+
+// maxCountedNotificationLen truncates method names used as counter keys.
+const maxCountedNotificationLen = 128
+
+// NotificationStats counts the handling of one notification method sent by
+// one upstream.
+type NotificationStats struct {
+	Upstream  string `json:"upstream"`
+	Method    string `json:"method"`
+	Forwarded uint64 `json:"forwarded"`
+	Latest    uint64 `json:"latest"`
+	Dropped   uint64 `json:"dropped"`
+}
+
+// notificationKey identifies a counter.
+type notificationKey struct{ upstream, method string }
+
+// NotificationPolicy decides how upstream notifications are delivered to
+// clients and counts the decisions per upstream and method. Safe for
+// concurrent use.
+type NotificationPolicy struct {
+	rules []NotificationRule
+
+	mu     sync.Mutex
+	counts map[notificationKey]*NotificationStats
+}
+
+// NewNotificationPolicy creates a policy from ordered rules. Notifications
+// matching no rule are forwarded.
+func NewNotificationPolicy(rules []NotificationRule) (*NotificationPolicy, error) {
+	for i, r := range rules {
+		if _, err := path.Match(r.Method, ""); err != nil || r.Method == "" {
+			return nil, fmt.Errorf("rule %d: invalid method pattern %q", i, r.Method)
+		}
+		switch r.Action {
+		case NotificationForward, NotificationLatest, NotificationDrop:
+		default:
+			return nil, fmt.Errorf("rule %d: unknown action %q", i, r.Action)
+		}
+	}
+	return &NotificationPolicy{
+		rules:  append([]NotificationRule(nil), rules...),
+		counts: make(map[notificationKey]*NotificationStats),
+	}, nil
+}
+
+// Rules returns the configured rules.
+func (p *NotificationPolicy) Rules() []NotificationRule {
+	return append([]NotificationRule{}, p.rules...)
+}
+
+// Decide returns the handling of a notification for method sent by the
+// upstream named upstream, and counts it.
+func (p *NotificationPolicy) Decide(upstream, method string) NotificationAction {
+	action := NotificationForward
+	for _, r := range p.rules {
+		if r.Upstream != "" && r.Upstream != upstream {
+			continue
+		}
+		if ok, _ := path.Match(r.Method, method); ok {
+			action = r.Action
+			break
+		}
+	}
+	p.record(upstream, method, action)
+	return action
+}
+
+// record counts one notification handled with action.
+func (p *NotificationPolicy) record(upstream, method string, action NotificationAction) {
+	if len(method) > maxCountedNotificationLen {
+		method = method[:maxCountedNotificationLen]
+	}
+	key := notificationKey{upstream, method}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s, ok := p.counts[key]
+	if !ok {
+		if len(p.counts) >= maxNotificationCounters {
+			key.method = validation.OtherMethods
+		}
+		if s, ok = p.counts[key]; !ok {
+			s = &NotificationStats{Upstream: key.upstream, Method: key.method}
+			p.counts[key] = s
+		}
+	}
+	switch action {
+	case NotificationForward:
+		s.Forwarded++
+	case NotificationLatest:
+		s.Latest++
+	case NotificationDrop:
+		s.Dropped++
+	}
+}
+
+// Stats returns the counters of all notifications seen, sorted by upstream
+// and method.
+func (p *NotificationPolicy) Stats() []NotificationStats {
+	p.mu.Lock()
+	out := make([]NotificationStats, 0, len(p.counts))
+	for _, s := range p.counts {
+		out = append(out, *s)
+	}
+	p.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Upstream != out[j].Upstream {
+			return out[i].Upstream < out[j].Upstream
+		}
+		return out[i].Method < out[j].Method
+	})
+	return out
+}
@@ -0,0 +1,155 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingNotificationForwarder records forwarded notifications and those
+// sent to the latest connection of a session.
+type recordingNotificationForwarder struct {
+	mu        sync.Mutex
+	forwarded []string
+	latest    map[string][]string
+}
+
+func (f *recordingNotificationForwarder) ForwardNotification(data []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.forwarded = append(f.forwarded, string(data))
+}
+
+func (f *recordingNotificationForwarder) ForwardNotificationToLatest(sessionID string, data []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.latest == nil {
+		f.latest = make(map[string][]string)
+	}
+	f.latest[sessionID] = append(f.latest[sessionID], string(data))
+}
+
+func TestNewNotificationPolicy_Invalid(t *testing.T) {
+	tests := []NotificationRule{
+		{Method: "", Action: NotificationDrop},
+		{Method: "[", Action: NotificationDrop},
+		{Method: "notifications/message", Action: "block"},
+	}
+	for _, r := range tests {
+		if _, err := NewNotificationPolicy([]NotificationRule{r}); err == nil {
+			t.Errorf("NewNotificationPolicy(%+v): expected error", r)
+		}
+	}
+}
+
+func TestNotificationPolicy_Decide(t *testing.T) {
+	p, err := NewNotificationPolicy([]NotificationRule{
+		{Upstream: "chatty", Method: "notifications/message", Action: NotificationDrop},
+		{Upstream: "chatty", Method: "notifications/*", Action: NotificationLatest},
+		{Method: "notifications/resources/*", Action: NotificationLatest},
+	})
+	if err != nil {
+		t.Fatalf("NewNotificationPolicy: %v", err)
+	}
+	tests := []struct {
+		upstream, method string
+		want             NotificationAction
+	}{
+		{"chatty", "notifications/message", NotificationDrop},
+		{"chatty", "notifications/progress", NotificationLatest},
+		{"quiet", "notifications/message", NotificationForward},
+		{"quiet", "notifications/resources/updated", NotificationLatest},
+		{"quiet", "notifications/resources/list_changed", NotificationLatest},
+		{"quiet", "notifications/resources", NotificationForward},
+	}
+	for _, tt := range tests {
+		if got := p.Decide(tt.upstream, tt.method); got != tt.want {
+			t.Errorf("Decide(%q, %q) = %q, want %q", tt.upstream, tt.method, got, tt.want)
+		}
+	}
+}
+
+func TestNotificationPolicy_Stats(t *testing.T) {
+	p, err := NewNotificationPolicy([]NotificationRule{
+		{Method: "notifications/message", Action: NotificationDrop},
+	})
+	if err != nil {
+		t.Fatalf("NewNotificationPolicy: %v", err)
+	}
+	p.Decide("b", "notifications/message")
+	p.Decide("b", "notifications/message")
+	p.Decide("a", "notifications/progress")
+
+	stats := p.Stats()
+	if len(stats) != 2 {
+		t.Fatalf("stats = %+v, want 2 entries", stats)
+	}
+	if stats[0].Upstream != "a" || stats[0].Forwarded != 1 {
+		t.Errorf("stats[0] = %+v", stats[0])
+	}
+	if stats[1].Upstream != "b" || stats[1].Dropped != 2 {
+		t.Errorf("stats[1] = %+v", stats[1])
+	}
+}
+
+func TestNotificationPolicy_StatsBounded(t *testing.T) {
+	p, err := NewNotificationPolicy(nil)
+	if err != nil {
+		t.Fatalf("NewNotificationPolicy: %v", err)
+	}
+	for i := 0; i < maxNotificationCounters+50; i++ {
+		p.Decide("up", fmt.Sprintf("notifications/m%d", i))
+	}
+	if n := len(p.Stats()); n > maxNotificationCounters+1 {
+		t.Errorf("got %d counters, want at most %d", n, maxNotificationCounters+1)
+	}
+}
+
+func TestUpstreamRouter_NotificationPolicy(t *testing.T) {
+	cache := newMockToolCacheReader(
+		&RoutableTool{Name: "tool-a", UpstreamID: "upstream-1", UpstreamName: "chatty"},
+	)
+	manager := newMockUpstreamConnectionProvider()
+	addConnectionMultiLine(manager, "upstream-1", []string{
+		`{"jsonrpc":"2.0","method":"notifications/message","params":{"data":"noise"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/progress","params":{"progress":1}}`,
+		`{"jsonrpc":"2.0","method":"notifications/resources/updated","params":{"uri":"file:///a"}}`,
+		`{"jsonrpc":"2.0","id":1,"result":{}}`,
+	})
+	router := newTestRouter(cache, manager)
+	router.SetUpstreamResolver(staticUpstreamResolver{"chatty": "upstream-1"})
+	policy, err := NewNotificationPolicy([]NotificationRule{
+		{Upstream: "chatty", Method: "notifications/message", Action: NotificationDrop},
+		{Method: "notifications/progress", Action: NotificationLatest},
+	})
+	if err != nil {
+		t.Fatalf("NewNotificationPolicy: %v", err)
+	}
+	router.SetNotificationPolicy(policy)
+	fwd := &recordingNotificationForwarder{}
+	router.SetNotificationForwarder(fwd)
+
+	msg := makeToolsCallRequestWithSession(t, 1, "tool-a", nil, nil)
+	if _, err := router.Intercept(context.Background(), msg); err != nil {
+		t.Fatalf("Intercept: %v", err)
+	}
+
+	if len(fwd.forwarded) != 1 || !strings.Contains(fwd.forwarded[0], "resources/updated") {
+		t.Errorf("forwarded = %v, want only the resource update", fwd.forwarded)
+	}
+	if got := fwd.latest["sess-test"]; len(got) != 1 || !strings.Contains(got[0], "progress") {
+		t.Errorf("latest = %v, want the progress notification for sess-test", fwd.latest)
+	}
+	var dropped uint64
+	for _, s := range policy.Stats() {
+		if s.Upstream != "chatty" {
+			t.Errorf("counter keyed by %q, want upstream name", s.Upstream)
+		}
+		dropped += s.Dropped
+	}
+	if dropped != 1 {
+		t.Errorf("dropped = %d, want 1", dropped)
+	}
+}
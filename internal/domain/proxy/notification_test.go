@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
@@ -219,20 +220,19 @@ func TestNotificationInterleaving_Ordering_ManyNotifications(t *testing.T) {
 
 // TestNotification_MethodWithID verifies that a message with both "method" and "id"
 // is NOT treated as a notification by forwardToUpstream's skip logic. In JSON-RPC 2.0,
-// a message with an "id" is a request (or response), not a notification — even if it
-// has a "method" field. The notification skip only applies when id is absent.
+// a message with an "id" and a "method" is a request from the upstream to the client
+// (e.g. sampling). Without a server request handler it is answered with "method not
+// found" and the router keeps waiting for the actual response.
 func TestNotification_MethodWithID(t *testing.T) {
 	cache := newMockToolCacheReader(
 		&RoutableTool{Name: "tool-mid", UpstreamID: "upstream-1", Description: "Method with ID tool"},
 	)
 	manager := newMockUpstreamConnectionProvider()
 
-	// The upstream returns a message that has both "method" and "id".
-	// This looks like a server-to-client request (e.g., sampling), not a notification.
-	// forwardToUpstream should treat it as the response (not skip it).
 	methodWithID := `{"jsonrpc":"2.0","id":99,"method":"sampling/createMessage","params":{}}`
+	response := `{"jsonrpc":"2.0","id":1,"result":{"content":[]}}`
 
-	addConnectionMultiLine(manager, "upstream-1", []string{methodWithID})
+	addConnectionMultiLine(manager, "upstream-1", []string{methodWithID, response})
 	router := newTestRouter(cache, manager)
 
 	msg := makeToolsCallRequest(t, 1, "tool-mid", nil)
@@ -245,8 +245,6 @@ func TestNotification_MethodWithID(t *testing.T) {
 		t.Fatal("expected response, got nil")
 	}
 
-	// The message should have been accepted (not skipped) and the ID remapped
-	// to the client's request ID (1).
 	var parsed struct {
 		ID     json.RawMessage `json:"id"`
 		Method string          `json:"method"`
@@ -254,14 +252,14 @@ func TestNotification_MethodWithID(t *testing.T) {
 	if err := json.Unmarshal(resp.Raw, &parsed); err != nil {
 		t.Fatalf("failed to parse response: %v", err)
 	}
-
-	// ID should be remapped to client's original ID (1).
-	var respID float64
-	if err := json.Unmarshal(parsed.ID, &respID); err != nil {
-		t.Fatalf("failed to parse response ID: %v", err)
+	if parsed.Method != "" || string(parsed.ID) != "1" {
+		t.Errorf("expected the tool call response with id 1, got %s", resp.Raw)
 	}
-	if respID != 1 {
-		t.Errorf("expected ID to be remapped to 1, got %v", respID)
+
+	// The upstream request was answered with method not found, under its own ID.
+	written := string(manager.connections["upstream-1"].writer.buf)
+	if !strings.Contains(written, `"id":99`) || !strings.Contains(written, "-32601") {
+		t.Errorf("expected a -32601 answer with id 99 written upstream, got %s", written)
 	}
 }
 
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/policy"
 	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
@@ -52,6 +53,25 @@ func (e *PolicyDenyError) Unwrap() error {
 	return ErrPolicyDenied
 }
 
+// DenyLoopError rejects a call that was denied repeatedly in a short
+// window, without evaluating it again, until its backoff is over.
+type DenyLoopError struct {
+	// Denials is how many times the call was denied in the window.
+	Denials int
+	// RetryAfter is the remaining backoff.
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface.
+func (e *DenyLoopError) Error() string {
+	return fmt.Sprintf("policy denied: repeated denied call (%d denials), retry after %v", e.Denials, e.RetryAfter)
+}
+
+// Unwrap returns ErrPolicyDenied so errors.Is(err, ErrPolicyDenied) works.
+func (e *DenyLoopError) Unwrap() error {
+	return ErrPolicyDenied
+}
+
 // PolicyInterceptor evaluates tool calls against RBAC policies.
 // It wraps another MessageInterceptor (e.g., PassthroughInterceptor).
 type PolicyInterceptor struct {
@@ -119,12 +139,13 @@ func (p *PolicyInterceptor) Intercept(ctx context.Context, msg *mcp.Message) (*m
 			"session_id", msg.Session.ID,
 			"identity_id", msg.Session.IdentityID,
 		)
+		helpURL, helpText := policy.RenderDecisionHelp(decision, policy.NewHelpTextData(evalCtx, decision.RuleName))
 		return nil, &PolicyDenyError{
 			RuleID:   decision.RuleID,
 			RuleName: decision.RuleName,
 			Reason:   decision.Reason,
-			HelpURL:  decision.HelpURL,
-			HelpText: decision.HelpText,
+			HelpURL:  helpURL,
+			HelpText: helpText,
 		}
 	}
 
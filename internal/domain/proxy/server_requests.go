@@ -0,0 +1,63 @@
+package proxy
+
+import (
+	"context"
+	"io"
+
+	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
+)
+
+// ServerRequestHandler answers requests that an upstream sends to the client
+// while a client request is in flight (sampling/createMessage,
+// elicitation/create). It returns the JSON-RPC response to write back to the
+// upstream, with the ID of req. Implementations must be safe for concurrent
+// use.
+type ServerRequestHandler interface {
+	HandleServerRequest(ctx context.Context, upstreamID string, inFlight *mcp.Message, req []byte) []byte
+}
+
+// ClientRequester sends a JSON-RPC request to a client session and waits for
+// the client's response. Implemented by transports that can carry
+// server-initiated requests.
+type ClientRequester interface {
+	RequestClient(ctx context.Context, sessionID string, req []byte) ([]byte, error)
+}
+
+// SetServerRequestHandler sets the handler of requests upstreams send to the
+// client. When nil (default), such requests are answered with "method not
+// found", as by a client without sampling or elicitation support.
+func (r *UpstreamRouter) SetServerRequestHandler(h ServerRequestHandler) {
+	r.notifMu.Lock()
+	r.serverReqHandler = h
+	r.notifMu.Unlock()
+}
+
+func (r *UpstreamRouter) getServerRequestHandler() ServerRequestHandler {
+	r.notifMu.RLock()
+	defer r.notifMu.RUnlock()
+	return r.serverReqHandler
+}
+
+// answerServerRequest answers a request the upstream sent while msg was in
+// flight and writes the response back to the upstream. headers are the
+// credentials of msg, which the response carries as well.
+func (r *UpstreamRouter) answerServerRequest(ctx context.Context, writer io.Writer, headers map[string]string, upstreamID, method string, msg *mcp.Message, line []byte) {
+	var resp []byte
+	if h := r.getServerRequestHandler(); h != nil {
+		resp = h.HandleServerRequest(ctx, upstreamID, msg, line)
+	} else {
+		r.logger.Debug("rejecting upstream request (no handler)", "method", method, "upstream", upstreamID)
+		resp = CreateJSONRPCError(rawIDFromBytes(line), int(ErrCodeMethodNotFound), "Method not found: "+method)
+	}
+	resp = append(resp[:len(resp):len(resp)], '\n')
+
+	var err error
+	if hw, ok := writer.(UpstreamHeaderWriter); ok && len(headers) > 0 {
+		_, err = hw.WriteWithHeaders(resp, headers)
+	} else {
+		_, err = writer.Write(resp)
+	}
+	if err != nil {
+		r.logger.Warn("failed to answer upstream request", "method", method, "upstream", upstreamID, "error", err)
+	}
+}
@@ -0,0 +1,294 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
+)
+
+// unsubscribeTimeout bounds each upstream resources/unsubscribe sent when a
+// session ends.
+const unsubscribeTimeout = 10 * time.Second
+
+// DefaultMaxSubscriptionsPerIdentity is the number of resource subscriptions
+// one identity may hold across all of its sessions when no limit is configured.
+const DefaultMaxSubscriptionsPerIdentity = 100
+
+// ErrSubscriptionLimit is returned when an identity already holds the
+// maximum number of resource subscriptions.
+var ErrSubscriptionLimit = errors.New("resource subscription limit reached")
+
+// Subscription is an active resources/subscribe held by a client session.
+type Subscription struct {
+	SessionID  string    `json:"session_id"`
+	IdentityID string    `json:"identity_id"`
+	URI        string    `json:"uri"`
+	UpstreamID string    `json:"upstream_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// upstreamSubscription identifies a subscription held on an upstream. The
+// gateway shares one upstream connection between all client sessions, so an
+// upstream subscription stays active while any session still holds it.
+type upstreamSubscription struct {
+	upstreamID string
+	uri        string
+}
+
+// SubscriptionTracker tracks resource subscriptions per client session so
+// they can be limited per identity and released upstream when the session
+// ends. It is safe for concurrent use.
+type SubscriptionTracker struct {
+	mu         sync.Mutex
+	maxPerID   int
+	sessions   map[string]map[string]*Subscription // session ID → URI → subscription
+	byIdentity map[string]int                      // identity ID → subscription count
+	refs       map[upstreamSubscription]int        // upstream subscription → holder count
+}
+
+// NewSubscriptionTracker creates a tracker allowing maxPerIdentity
+// subscriptions per identity. Values <= 0 use DefaultMaxSubscriptionsPerIdentity.
+func NewSubscriptionTracker(maxPerIdentity int) *SubscriptionTracker {
+	if maxPerIdentity <= 0 {
+		maxPerIdentity = DefaultMaxSubscriptionsPerIdentity
+	}
+	return &SubscriptionTracker{
+		maxPerID:   maxPerIdentity,
+		sessions:   make(map[string]map[string]*Subscription),
+		byIdentity: make(map[string]int),
+		refs:       make(map[upstreamSubscription]int),
+	}
+}
+
+// Lookup returns the subscription of sessionID to uri, if any.
+func (t *SubscriptionTracker) Lookup(sessionID, uri string) (Subscription, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	sub, ok := t.sessions[sessionID][uri]
+	if !ok {
+		return Subscription{}, false
+	}
+	return *sub, true
+}
+
+// held reports whether any session holds uri on upstreamID.
+func (t *SubscriptionTracker) held(upstreamID, uri string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.refs[upstreamSubscription{upstreamID, uri}] > 0
+}
+
+// CheckLimit returns ErrSubscriptionLimit when identityID cannot take
+// another subscription.
+func (t *SubscriptionTracker) CheckLimit(identityID string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.byIdentity[identityID] >= t.maxPerID {
+		return ErrSubscriptionLimit
+	}
+	return nil
+}
+
+// Add records that sessionID subscribed to uri through upstreamID. A repeated
+// subscription of the same session to the same URI is a no-op. The limit is
+// enforced again here so concurrent subscribes cannot overshoot it.
+func (t *SubscriptionTracker) Add(sessionID, identityID, uri, upstreamID string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.sessions[sessionID][uri]; ok {
+		return nil
+	}
+	if t.byIdentity[identityID] >= t.maxPerID {
+		return ErrSubscriptionLimit
+	}
+	subs, ok := t.sessions[sessionID]
+	if !ok {
+		subs = make(map[string]*Subscription)
+		t.sessions[sessionID] = subs
+	}
+	subs[uri] = &Subscription{
+		SessionID:  sessionID,
+		IdentityID: identityID,
+		URI:        uri,
+		UpstreamID: upstreamID,
+		CreatedAt:  time.Now().UTC(),
+	}
+	t.byIdentity[identityID]++
+	t.refs[upstreamSubscription{upstreamID, uri}]++
+	return nil
+}
+
+// Remove drops the subscription of sessionID to uri. last reports whether it
+// was the final holder of the upstream subscription, i.e. whether the
+// upstream should be told to unsubscribe.
+func (t *SubscriptionTracker) Remove(sessionID, uri string) (sub Subscription, last bool, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.sessions[sessionID][uri]
+	if !ok {
+		return Subscription{}, false, false
+	}
+	return *s, t.removeLocked(s), true
+}
+
+// EndSession drops every subscription of sessionID and returns those that no
+// other session holds anymore, which must be unsubscribed upstream.
+func (t *SubscriptionTracker) EndSession(sessionID string) []Subscription {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var released []Subscription
+	for _, s := range t.sessions[sessionID] {
+		if t.removeLocked(s) {
+			released = append(released, *s)
+		}
+	}
+	return released
+}
+
+// removeLocked deletes s and reports whether its upstream subscription has
+// no holders left. Caller must hold t.mu.
+func (t *SubscriptionTracker) removeLocked(s *Subscription) bool {
+	if subs := t.sessions[s.SessionID]; subs != nil {
+		delete(subs, s.URI)
+		if len(subs) == 0 {
+			delete(t.sessions, s.SessionID)
+		}
+	}
+	if t.byIdentity[s.IdentityID] <= 1 {
+		delete(t.byIdentity, s.IdentityID)
+	} else {
+		t.byIdentity[s.IdentityID]--
+	}
+	key := upstreamSubscription{s.UpstreamID, s.URI}
+	if t.refs[key] <= 1 {
+		delete(t.refs, key)
+		return true
+	}
+	t.refs[key]--
+	return false
+}
+
+// subscriptionURI returns the "uri" param of a resources/(un)subscribe request.
+func subscriptionURI(msg *mcp.Message) string {
+	params := msg.ParseParams()
+	if params == nil {
+		return ""
+	}
+	uri, _ := params["uri"].(string)
+	return uri
+}
+
+// isErrorResponse reports whether msg is a JSON-RPC error response.
+func isErrorResponse(msg *mcp.Message) bool {
+	if msg == nil || len(msg.Raw) == 0 {
+		return true
+	}
+	var envelope struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(msg.Raw, &envelope) != nil {
+		return true
+	}
+	return len(envelope.Error) > 0 && string(envelope.Error) != "null"
+}
+
+// handleSubscribe forwards resources/subscribe and records the subscription
+// against the session once an upstream accepts it. Subscriptions beyond the
+// identity's limit are rejected without reaching any upstream.
+func (r *UpstreamRouter) handleSubscribe(ctx context.Context, msg *mcp.Message) (*mcp.Message, error) {
+	tracker := r.getSubscriptionTracker()
+	uri := subscriptionURI(msg)
+	if tracker == nil || msg.Session == nil || uri == "" {
+		return r.handleForward(ctx, msg)
+	}
+	sess := msg.Session
+
+	// Already subscribed: the upstream subscription is still active.
+	if _, ok := tracker.Lookup(sess.ID, uri); ok {
+		return r.buildResultResponse(msg, struct{}{})
+	}
+	if err := tracker.CheckLimit(sess.IdentityID); err != nil {
+		r.logger.Warn("resource subscription rejected", "identity", sess.IdentityID, "uri", uri, "limit", tracker.maxPerID)
+		return r.buildErrorResponse(msg, ErrCodeSubscriptionLimit,
+			fmt.Sprintf("Subscription limit reached: at most %d active resource subscriptions per identity", tracker.maxPerID)), nil
+	}
+
+	resp, upstreamID, err := r.forwardToFirst(ctx, msg)
+	if err != nil {
+		r.logger.Error("no upstream available for forwarding", "method", msg.Method(), "error", err)
+		return r.buildErrorResponse(msg, ErrCodeNoUpstreams, "No upstream available"), nil
+	}
+	if isErrorResponse(resp) {
+		return resp, nil
+	}
+	if err := tracker.Add(sess.ID, sess.IdentityID, uri, upstreamID); err != nil {
+		// A concurrent subscribe of the same identity took the last slot.
+		// Undo the upstream subscription unless another session holds it.
+		if !tracker.held(upstreamID, uri) {
+			go r.releaseSubscriptions([]Subscription{{URI: uri, UpstreamID: upstreamID}})
+		}
+		return r.buildErrorResponse(msg, ErrCodeSubscriptionLimit,
+			fmt.Sprintf("Subscription limit reached: at most %d active resource subscriptions per identity", tracker.maxPerID)), nil
+	}
+	return resp, nil
+}
+
+// handleUnsubscribe removes the session's subscription and forwards
+// resources/unsubscribe to its upstream only when no other session still
+// holds the same subscription there.
+func (r *UpstreamRouter) handleUnsubscribe(ctx context.Context, msg *mcp.Message) (*mcp.Message, error) {
+	tracker := r.getSubscriptionTracker()
+	uri := subscriptionURI(msg)
+	if tracker == nil || msg.Session == nil || uri == "" {
+		return r.handleForward(ctx, msg)
+	}
+	sub, last, ok := tracker.Remove(msg.Session.ID, uri)
+	if !ok {
+		return r.handleForward(ctx, msg)
+	}
+	if !last {
+		return r.buildResultResponse(msg, struct{}{})
+	}
+	resp, err := r.forwardToUpstream(ctx, sub.UpstreamID, msg)
+	if err != nil {
+		r.logger.Error("upstream unsubscribe failed", "upstream", sub.UpstreamID, "uri", uri, "error", err)
+		return r.buildErrorResponse(msg, ErrCodeNoUpstreams, "No upstream available"), nil
+	}
+	return resp, nil
+}
+
+// releaseSubscriptions sends resources/unsubscribe to the upstreams of subs.
+// Upstreams that are not running hold no subscriptions and are not started.
+// Errors are logged but not propagated.
+func (r *UpstreamRouter) releaseSubscriptions(subs []Subscription) {
+	for _, sub := range subs {
+		if active, ok := r.manager.(ActiveConnectionProvider); ok {
+			if _, _, err := active.GetActiveConnection(sub.UpstreamID); err != nil {
+				continue
+			}
+		}
+		raw, err := json.Marshal(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      fmt.Sprintf("sentinelgate-unsubscribe-%d", r.unsubSeq.Add(1)),
+			"method":  "resources/unsubscribe",
+			"params":  map[string]string{"uri": sub.URI},
+		})
+		if err != nil {
+			continue
+		}
+		msg := &mcp.Message{Raw: raw, Direction: mcp.ClientToServer, Timestamp: time.Now()}
+		ctx, cancel := context.WithTimeout(context.Background(), unsubscribeTimeout)
+		resp, err := r.forwardToUpstream(ctx, sub.UpstreamID, msg)
+		cancel()
+		if err != nil || isErrorResponse(resp) {
+			r.logger.Warn("failed to release resource subscription", "upstream", sub.UpstreamID, "uri", sub.URI, "error", err)
+			continue
+		}
+		r.logger.Debug("released resource subscription", "upstream", sub.UpstreamID, "uri", sub.URI)
+	}
+}
@@ -0,0 +1,179 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/session"
+	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+)
+
+// subscribeUpstream is a connection provider for one upstream that answers
+// every request with an empty result and records the methods it received.
+type subscribeUpstream struct {
+	mu      sync.Mutex
+	lineCh  chan []byte
+	methods []string
+}
+
+func newSubscribeUpstream() *subscribeUpstream {
+	return &subscribeUpstream{lineCh: make(chan []byte, 16)}
+}
+
+func (u *subscribeUpstream) Write(p []byte) (int, error) {
+	var req struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	_ = json.Unmarshal(p, &req)
+	u.mu.Lock()
+	u.methods = append(u.methods, req.Method)
+	u.mu.Unlock()
+	u.lineCh <- []byte(fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":{}}`, req.ID))
+	return len(p), nil
+}
+
+func (u *subscribeUpstream) Close() error { return nil }
+
+func (u *subscribeUpstream) GetConnection(upstreamID string) (io.WriteCloser, <-chan []byte, error) {
+	if upstreamID != "up-1" {
+		return nil, nil, fmt.Errorf("upstream %s not connected", upstreamID)
+	}
+	return u, u.lineCh, nil
+}
+
+func (u *subscribeUpstream) AllConnected() bool { return true }
+
+func (u *subscribeUpstream) received() []string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return append([]string(nil), u.methods...)
+}
+
+func makeSubscriptionRequest(t *testing.T, sess *session.Session, method, uri string) *mcp.Message {
+	t.Helper()
+	reqID, _ := jsonrpc.MakeID(float64(7))
+	req := &jsonrpc.Request{
+		ID:     reqID,
+		Method: method,
+		Params: json.RawMessage(fmt.Sprintf(`{"uri":%q}`, uri)),
+	}
+	raw, err := jsonrpc.EncodeMessage(req)
+	if err != nil {
+		t.Fatalf("failed to encode %s request: %v", method, err)
+	}
+	return &mcp.Message{Raw: raw, Direction: mcp.ClientToServer, Decoded: req, Session: sess}
+}
+
+func newSubscriptionTestRouter(maxPerIdentity int) (*UpstreamRouter, *subscribeUpstream) {
+	up := newSubscribeUpstream()
+	cache := newMockToolCacheReader(&RoutableTool{Name: "read", OriginalName: "read", UpstreamID: "up-1"})
+	r := newTestRouter(cache, up)
+	r.SetSubscriptionTracker(NewSubscriptionTracker(maxPerIdentity))
+	return r, up
+}
+
+func TestRouterSubscribe_PerIdentityLimit(t *testing.T) {
+	r, up := newSubscriptionTestRouter(2)
+	ctx := context.Background()
+	s1 := &session.Session{ID: "s1", IdentityID: "alice"}
+	s2 := &session.Session{ID: "s2", IdentityID: "alice"}
+
+	for _, req := range []*mcp.Message{
+		makeSubscriptionRequest(t, s1, "resources/subscribe", "file:///a"),
+		makeSubscriptionRequest(t, s1, "resources/subscribe", "file:///a"), // repeat, not counted
+		makeSubscriptionRequest(t, s2, "resources/subscribe", "file:///b"),
+	} {
+		resp, err := r.Intercept(ctx, req)
+		if err != nil || isErrorResponse(resp) {
+			t.Fatalf("subscribe failed: resp=%s err=%v", resp.Raw, err)
+		}
+	}
+
+	resp, err := r.Intercept(ctx, makeSubscriptionRequest(t, s2, "resources/subscribe", "file:///c"))
+	if err != nil {
+		t.Fatalf("Intercept: %v", err)
+	}
+	if !strings.Contains(string(resp.Raw), fmt.Sprintf(`"code":%d`, ErrCodeSubscriptionLimit)) {
+		t.Errorf("third subscription = %s, want limit error", resp.Raw)
+	}
+	if got := up.received(); len(got) != 2 {
+		t.Errorf("upstream received %v, want 2 subscribes (repeat and rejected ones stay local)", got)
+	}
+
+	// Another identity has its own budget.
+	resp, _ = r.Intercept(ctx, makeSubscriptionRequest(t, &session.Session{ID: "s3", IdentityID: "bob"}, "resources/subscribe", "file:///c"))
+	if isErrorResponse(resp) {
+		t.Errorf("subscription of another identity = %s, want success", resp.Raw)
+	}
+}
+
+func TestRouterUnsubscribe_RefcountedUpstream(t *testing.T) {
+	r, up := newSubscriptionTestRouter(10)
+	ctx := context.Background()
+	s1 := &session.Session{ID: "s1", IdentityID: "alice"}
+	s2 := &session.Session{ID: "s2", IdentityID: "bob"}
+
+	_, _ = r.Intercept(ctx, makeSubscriptionRequest(t, s1, "resources/subscribe", "file:///a"))
+	_, _ = r.Intercept(ctx, makeSubscriptionRequest(t, s2, "resources/subscribe", "file:///a"))
+
+	// s2 still holds file:///a, so the upstream subscription stays.
+	resp, _ := r.Intercept(ctx, makeSubscriptionRequest(t, s1, "resources/unsubscribe", "file:///a"))
+	if isErrorResponse(resp) {
+		t.Fatalf("unsubscribe = %s, want success", resp.Raw)
+	}
+	if got := up.received(); len(got) != 2 {
+		t.Fatalf("upstream received %v, want only the 2 subscribes", got)
+	}
+
+	_, _ = r.Intercept(ctx, makeSubscriptionRequest(t, s2, "resources/unsubscribe", "file:///a"))
+	got := up.received()
+	if len(got) != 3 || got[2] != "resources/unsubscribe" {
+		t.Errorf("upstream received %v, want a final resources/unsubscribe", got)
+	}
+}
+
+func TestRouterCleanupSession_ReleasesSubscriptions(t *testing.T) {
+	r, up := newSubscriptionTestRouter(10)
+	ctx := context.Background()
+	s1 := &session.Session{ID: "s1", IdentityID: "alice"}
+	s2 := &session.Session{ID: "s2", IdentityID: "bob"}
+
+	_, _ = r.Intercept(ctx, makeSubscriptionRequest(t, s1, "resources/subscribe", "file:///a"))
+	_, _ = r.Intercept(ctx, makeSubscriptionRequest(t, s1, "resources/subscribe", "file:///shared"))
+	_, _ = r.Intercept(ctx, makeSubscriptionRequest(t, s2, "resources/subscribe", "file:///shared"))
+
+	r.CleanupSession("s1")
+
+	// Only file:///a is released; file:///shared is still held by s2.
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		got := up.received()
+		if len(got) == 4 {
+			if got[3] != "resources/unsubscribe" {
+				t.Fatalf("upstream received %v, want a resources/unsubscribe", got)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("upstream received %v, want 3 subscribes and 1 unsubscribe", got)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := up.received(); len(got) != 4 {
+		t.Errorf("upstream received %v, want exactly one unsubscribe", got)
+	}
+
+	// The released budget is available again.
+	if err := r.getSubscriptionTracker().CheckLimit("alice"); err != nil {
+		t.Errorf("CheckLimit(alice) after cleanup = %v, want nil", err)
+	}
+}
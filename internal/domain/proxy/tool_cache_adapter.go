@@ -26,10 +26,11 @@ func (a *ToolCacheAdapter) GetTool(name string) (*RoutableTool, bool) {
 }
 
 // GetAllTools returns all discovered tools as RoutableTools with resolved names.
+// It reads the cache's shared snapshot, so schemas are not copied.
 func (a *ToolCacheAdapter) GetAllTools() []*RoutableTool {
-	allTools := a.cache.GetAllTools()
-	result := make([]*RoutableTool, len(allTools))
-	for i, dt := range allTools {
+	snap := a.cache.Snapshot()
+	result := make([]*RoutableTool, len(snap.Tools))
+	for i, dt := range snap.Tools {
 		result[i] = toRoutableTool(dt, dt.Name)
 	}
 	return result
@@ -40,6 +41,11 @@ func (a *ToolCacheAdapter) IsAmbiguous(name string) (bool, []string) {
 	return a.cache.IsAmbiguous(name)
 }
 
+// Version returns the wrapped cache's change counter.
+func (a *ToolCacheAdapter) Version() uint64 {
+	return a.cache.Version()
+}
+
 // toRoutableTool converts a DiscoveredTool to a RoutableTool.
 // resolvedName is the name as it appears in the resolved map (may include namespace prefix).
 func toRoutableTool(dt *upstream.DiscoveredTool, resolvedName string) *RoutableTool {
@@ -0,0 +1,88 @@
+package proxy
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// maxToolsListCacheEntries bounds the number of distinct role sets cached.
+// Role combinations are few in practice; when the bound is hit the cache is
+// simply emptied and refilled.
+const maxToolsListCacheEntries = 256
+
+// VersionedSource is optionally implemented by a ToolCacheReader or a
+// NamespaceFilter whose content can change at runtime. Version must return a
+// different value after every change. The router caches filtered tools/list
+// results only when both the tool cache and the namespace filter (if any)
+// are versioned.
+type VersionedSource interface {
+	Version() uint64
+}
+
+// toolsListCache holds the filtered tools/list result per role set. An entry
+// is valid only while the tool cache and namespace filter versions it was
+// built from are unchanged, so discovery and visibility changes invalidate
+// it without explicit hooks.
+type toolsListCache struct {
+	mu      sync.Mutex
+	entries map[string]toolsListCacheEntry
+	hits    atomic.Uint64
+	misses  atomic.Uint64
+}
+
+type toolsListCacheEntry struct {
+	toolsVersion  uint64
+	filterVersion uint64
+	result        json.RawMessage
+}
+
+func newToolsListCache() *toolsListCache {
+	return &toolsListCache{entries: make(map[string]toolsListCacheEntry)}
+}
+
+// get returns the cached result for key if it was built from the given versions.
+func (c *toolsListCache) get(key string, toolsVersion, filterVersion uint64) (json.RawMessage, bool) {
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	c.mu.Unlock()
+	if !ok || e.toolsVersion != toolsVersion || e.filterVersion != filterVersion {
+		c.misses.Add(1)
+		return nil, false
+	}
+	c.hits.Add(1)
+	return e.result, true
+}
+
+func (c *toolsListCache) put(key string, toolsVersion, filterVersion uint64, result json.RawMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= maxToolsListCacheEntries {
+		c.entries = make(map[string]toolsListCacheEntry)
+	}
+	c.entries[key] = toolsListCacheEntry{toolsVersion: toolsVersion, filterVersion: filterVersion, result: result}
+}
+
+func (c *toolsListCache) reset() {
+	c.mu.Lock()
+	c.entries = make(map[string]toolsListCacheEntry)
+	c.mu.Unlock()
+}
+
+// toolsListCacheKey returns an order-independent key for a role set.
+func toolsListCacheKey(roles []string) string {
+	if len(roles) == 0 {
+		return ""
+	}
+	sorted := append([]string(nil), roles...)
+	sort.Strings(sorted)
+	out := sorted[:1]
+	for _, r := range sorted[1:] {
+		if r != out[len(out)-1] {
+			out = append(out, r)
+		}
+	}
+	return strings.Join(out, "\x00")
+}
@@ -0,0 +1,135 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/auth"
+)
+
+// versionedToolCache is a mockToolCacheReader that reports a version and
+// counts GetAllTools calls.
+type versionedToolCache struct {
+	*mockToolCacheReader
+	version uint64
+	reads   int
+}
+
+func (c *versionedToolCache) GetAllTools() []*RoutableTool {
+	c.reads++
+	return c.mockToolCacheReader.GetAllTools()
+}
+
+func (c *versionedToolCache) Version() uint64 { return c.version }
+
+type versionedNamespaceFilter struct {
+	mockNamespaceFilter
+	version uint64
+}
+
+func (f *versionedNamespaceFilter) Version() uint64 { return f.version }
+
+func listedToolNames(t *testing.T, router *UpstreamRouter, id int64, roles ...auth.Role) []string {
+	t.Helper()
+	resp, err := router.Intercept(context.Background(), makeToolsListRequestWithSession(t, id, roles))
+	if err != nil {
+		t.Fatalf("Intercept: %v", err)
+	}
+	var out struct {
+		ID     int64 `json:"id"`
+		Result struct {
+			Tools []struct {
+				Name string `json:"name"`
+			} `json:"tools"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(resp.Raw, &out); err != nil {
+		t.Fatalf("parse response: %v", err)
+	}
+	if out.ID != id {
+		t.Errorf("response id = %d, want %d", out.ID, id)
+	}
+	names := make([]string, 0, len(out.Result.Tools))
+	for _, tool := range out.Result.Tools {
+		names = append(names, tool.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestRouterToolsListCache(t *testing.T) {
+	cache := &versionedToolCache{mockToolCacheReader: newMockToolCacheReader(
+		&RoutableTool{Name: "read_file", UpstreamID: "u1"},
+		&RoutableTool{Name: "exec", UpstreamID: "u1"},
+	)}
+	filter := &versionedNamespaceFilter{mockNamespaceFilter: mockNamespaceFilter{visible: map[string]map[string]bool{
+		"exec": {"admin": true},
+	}}}
+	router := newTestRouter(cache, newMockUpstreamConnectionProvider())
+	router.SetNamespaceFilter(filter)
+
+	if got := listedToolNames(t, router, 1, "admin", "user"); !reflect.DeepEqual(got, []string{"exec", "read_file"}) {
+		t.Fatalf("admin tools = %v", got)
+	}
+	// Same role set in a different order is served from the cache, with the
+	// caller's request ID.
+	if got := listedToolNames(t, router, 2, "user", "admin", "user"); !reflect.DeepEqual(got, []string{"exec", "read_file"}) {
+		t.Errorf("cached admin tools = %v", got)
+	}
+	if cache.reads != 1 {
+		t.Errorf("GetAllTools called %d times, want 1", cache.reads)
+	}
+
+	// A different role set gets its own entry.
+	if got := listedToolNames(t, router, 3, "user"); !reflect.DeepEqual(got, []string{"read_file"}) {
+		t.Errorf("user tools = %v", got)
+	}
+	if cache.reads != 2 {
+		t.Errorf("GetAllTools called %d times, want 2", cache.reads)
+	}
+
+	// Discovery change invalidates.
+	cache.tools["write_file"] = &RoutableTool{Name: "write_file", UpstreamID: "u2"}
+	cache.version++
+	if got := listedToolNames(t, router, 4, "user"); !reflect.DeepEqual(got, []string{"read_file", "write_file"}) {
+		t.Errorf("user tools after discovery = %v", got)
+	}
+
+	// Visibility rule change invalidates.
+	filter.visible["exec"]["user"] = true
+	filter.version++
+	if got := listedToolNames(t, router, 5, "user"); !reflect.DeepEqual(got, []string{"exec", "read_file", "write_file"}) {
+		t.Errorf("user tools after rule change = %v", got)
+	}
+	if hits := router.toolsList.hits.Load(); hits != 1 {
+		t.Errorf("cache hits = %d, want 1", hits)
+	}
+}
+
+func TestRouterToolsListCache_UnversionedFilter(t *testing.T) {
+	cache := &versionedToolCache{mockToolCacheReader: newMockToolCacheReader(&RoutableTool{Name: "exec", UpstreamID: "u1"})}
+	filter := &mockNamespaceFilter{visible: map[string]map[string]bool{"exec": {"admin": true}}}
+	router := newTestRouter(cache, newMockUpstreamConnectionProvider())
+	router.SetNamespaceFilter(filter)
+
+	listedToolNames(t, router, 1, "user")
+	filter.visible["exec"]["user"] = true
+	if got := listedToolNames(t, router, 2, "user"); !reflect.DeepEqual(got, []string{"exec"}) {
+		t.Errorf("tools = %v, want the filter re-evaluated", got)
+	}
+	if cache.reads != 2 {
+		t.Errorf("GetAllTools called %d times, want 2 (no caching)", cache.reads)
+	}
+}
+
+func TestToolsListCacheKey(t *testing.T) {
+	if toolsListCacheKey([]string{"b", "a", "b"}) != toolsListCacheKey([]string{"a", "b"}) {
+		t.Error("key depends on role order or duplicates")
+	}
+	if toolsListCacheKey([]string{"ab"}) == toolsListCacheKey([]string{"a", "b"}) {
+		t.Error("distinct role sets share a key")
+	}
+}
@@ -4,14 +4,20 @@ package proxy
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/timing"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/validation"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/watchdog"
 	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
 )
 
@@ -27,6 +33,9 @@ const (
 	// L-13: -32000 is the start of the JSON-RPC server error reserved range (-32000 to -32099);
 	// acceptable for "no upstreams" error as server-defined errors are intended for this range.
 	ErrCodeNoUpstreams int64 = -32000
+	// ErrCodeSubscriptionLimit is returned when an identity holds too many
+	// resource subscriptions.
+	ErrCodeSubscriptionLimit int64 = -32001
 
 	// mcpProtocolVersion is the MCP protocol version advertised by the proxy.
 	mcpProtocolVersion = "2025-11-25"
@@ -74,6 +83,20 @@ type UpstreamConnectionProvider interface {
 	AllConnected() bool
 }
 
+// ActiveConnectionProvider is optionally implemented by connection providers
+// that start upstreams on demand. GetActiveConnection returns a connection
+// only if the upstream is already running, without starting it.
+type ActiveConnectionProvider interface {
+	GetActiveConnection(upstreamID string) (io.WriteCloser, <-chan []byte, error)
+}
+
+// CallTracker is optionally implemented by connection providers that drain
+// upstreams before applying a new configuration. BeginCall holds the call
+// while the upstream drains and registers it; the returned function ends it.
+type CallTracker interface {
+	BeginCall(ctx context.Context, upstreamID string) (func(), error)
+}
+
 // NamespaceFilter optionally filters tools based on identity roles.
 // Returns true if the tool should be visible to the given roles.
 type NamespaceFilter interface {
@@ -88,6 +111,49 @@ type NotificationForwarder interface {
 	ForwardNotification(data []byte)
 }
 
+// SessionNotificationForwarder is implemented by forwarders that can send a
+// notification to a single connection of a session, for notifications the
+// notification policy delivers to the latest connection only.
+type SessionNotificationForwarder interface {
+	// ForwardNotificationToLatest sends a raw JSON-RPC notification to the
+	// most recently opened connection of the session.
+	ForwardNotificationToLatest(sessionID string, data []byte)
+}
+
+// ErrUpstreamCredentials is returned when per-request upstream credentials
+// (e.g. an exchanged user token) cannot be obtained.
+var ErrUpstreamCredentials = errors.New("upstream credentials unavailable")
+
+// UpstreamCredentialInjector supplies per-request transport headers (such as
+// an end-user Authorization header) for messages forwarded to an upstream.
+// Returning nil headers and a nil error means no credentials apply.
+type UpstreamCredentialInjector interface {
+	UpstreamHeaders(ctx context.Context, upstreamID string) (map[string]string, error)
+}
+
+// UpstreamCallObserver receives the outcome of every tool call forwarded to
+// an upstream (e.g., for SLO tracking). failed is true when the upstream could
+// not be reached or did not answer; tool-level errors returned by the
+// upstream count as answered. Implementations must be safe for concurrent use.
+type UpstreamCallObserver interface {
+	ObserveUpstreamCall(upstreamName string, latency time.Duration, failed bool)
+}
+
+// UpstreamResolver looks up upstreams by name, for methods the method
+// policy routes to a named upstream, and names upstreams for the
+// notification policy.
+type UpstreamResolver interface {
+	UpstreamIDByName(ctx context.Context, name string) (string, bool)
+	UpstreamNameByID(ctx context.Context, id string) (string, bool)
+}
+
+// UpstreamHeaderWriter is implemented by upstream writers that can carry
+// transport headers alongside a single newline-delimited message. Only HTTP
+// upstreams implement it; stdio upstreams have no notion of headers.
+type UpstreamHeaderWriter interface {
+	WriteWithHeaders(p []byte, headers map[string]string) (int, error)
+}
+
 // UpstreamRouter routes MCP messages to the appropriate upstream based on
 // tool name lookup in the shared ToolCache. It is the innermost interceptor
 // in the chain for multi-upstream mode.
@@ -102,18 +168,49 @@ type UpstreamRouter struct {
 	ioMutexes sync.Map // per-upstream ID → *sync.Mutex
 	notifMu            sync.RWMutex
 	notificationFwd    NotificationForwarder
+	notificationPolicy *NotificationPolicy
+	serverReqHandler   ServerRequestHandler
+	credMu             sync.RWMutex
+	credInjector       UpstreamCredentialInjector
+	corrMu             sync.RWMutex
+	corrHeader         string
+	corrMetaKey        string
+	obsMu              sync.RWMutex
+	callObserver       UpstreamCallObserver
+	subMu              sync.RWMutex
+	subscriptions      *SubscriptionTracker
+	unsubSeq           atomic.Uint64
+	callSeq            atomic.Uint64
+	toolsList          *toolsListCache
+	methodMu           sync.RWMutex
+	methodPolicy       *validation.MethodPolicy
+	upstreamResolver   UpstreamResolver
+	noticeMu           sync.RWMutex
+	noticeProvider     NoticeProvider
+	pendingNotices     sync.Map // session ID → *Notice awaiting acknowledgment
 }
 
+// defaultMethodPolicy handles non-tool methods when no policy is set. Its
+// counters are shared by all routers without a policy.
+var defaultMethodPolicy = validation.DefaultMethodPolicy()
+
 // CleanupUpstream removes the per-upstream I/O mutex entry for the given ID.
 // Call this when an upstream is permanently removed to prevent unbounded growth.
 func (r *UpstreamRouter) CleanupUpstream(upstreamID string) {
 	r.ioMutexes.Delete(upstreamID)
 }
 
-// CleanupSession removes the per-session framework entry for the given session ID.
+// CleanupSession removes the per-session framework entry for the given session ID
+// and releases the session's resource subscriptions upstream.
 // Call this when a session is terminated or expired to prevent unbounded growth.
 func (r *UpstreamRouter) CleanupSession(sessionID string) {
 	r.clientFrameworks.Delete(sessionID)
+	r.pendingNotices.Delete(sessionID)
+	if tracker := r.getSubscriptionTracker(); tracker != nil {
+		if released := tracker.EndSession(sessionID); len(released) > 0 {
+			go r.releaseSubscriptions(released)
+		}
+	}
 }
 
 // NewUpstreamRouter creates a new UpstreamRouter.
@@ -122,9 +219,53 @@ func NewUpstreamRouter(cache ToolCacheReader, manager UpstreamConnectionProvider
 		toolCache: cache,
 		manager:   manager,
 		logger:    logger,
+		toolsList: newToolsListCache(),
 	}
 }
 
+// SetCredentialInjector sets the source of per-request upstream credentials.
+// When nil (default), messages are forwarded without extra headers.
+func (r *UpstreamRouter) SetCredentialInjector(inj UpstreamCredentialInjector) {
+	r.credMu.Lock()
+	defer r.credMu.Unlock()
+	r.credInjector = inj
+}
+
+func (r *UpstreamRouter) getCredentialInjector() UpstreamCredentialInjector {
+	r.credMu.RLock()
+	defer r.credMu.RUnlock()
+	return r.credInjector
+}
+
+// SetCallObserver sets the observer notified after each forwarded tool call.
+// When nil (default), no observations are made.
+func (r *UpstreamRouter) SetCallObserver(obs UpstreamCallObserver) {
+	r.obsMu.Lock()
+	defer r.obsMu.Unlock()
+	r.callObserver = obs
+}
+
+func (r *UpstreamRouter) getCallObserver() UpstreamCallObserver {
+	r.obsMu.RLock()
+	defer r.obsMu.RUnlock()
+	return r.callObserver
+}
+
+// SetSubscriptionTracker sets the tracker used to limit resource subscriptions
+// per identity and release them upstream when sessions end. When nil
+// (default), resources/subscribe and resources/unsubscribe are forwarded as-is.
+func (r *UpstreamRouter) SetSubscriptionTracker(t *SubscriptionTracker) {
+	r.subMu.Lock()
+	defer r.subMu.Unlock()
+	r.subscriptions = t
+}
+
+func (r *UpstreamRouter) getSubscriptionTracker() *SubscriptionTracker {
+	r.subMu.RLock()
+	defer r.subMu.RUnlock()
+	return r.subscriptions
+}
+
 // SetNotificationForwarder sets the callback used to forward upstream notifications
 // (e.g. notifications/progress, notifications/message) to the connected client.
 // When nil (default), upstream notifications are silently dropped.
@@ -141,12 +282,59 @@ func (r *UpstreamRouter) getNotificationForwarder() NotificationForwarder {
 	return r.notificationFwd
 }
 
+// SetNotificationPolicy sets the policy deciding how upstream notifications
+// are delivered. When nil (default), every notification is forwarded.
+func (r *UpstreamRouter) SetNotificationPolicy(p *NotificationPolicy) {
+	r.notifMu.Lock()
+	r.notificationPolicy = p
+	r.notifMu.Unlock()
+}
+
+func (r *UpstreamRouter) getNotificationPolicy() *NotificationPolicy {
+	r.notifMu.RLock()
+	defer r.notifMu.RUnlock()
+	return r.notificationPolicy
+}
+
+// SetMethodPolicy sets the policy deciding how non-tool methods are handled.
+// When nil (default), validation.DefaultMethodPolicy applies.
+func (r *UpstreamRouter) SetMethodPolicy(p *validation.MethodPolicy) {
+	r.methodMu.Lock()
+	defer r.methodMu.Unlock()
+	r.methodPolicy = p
+}
+
+func (r *UpstreamRouter) getMethodPolicy() *validation.MethodPolicy {
+	r.methodMu.RLock()
+	defer r.methodMu.RUnlock()
+	if r.methodPolicy == nil {
+		return defaultMethodPolicy
+	}
+	return r.methodPolicy
+}
+
+// SetUpstreamResolver sets the lookup of upstreams by name, used for
+// methods the method policy routes to a named upstream. When nil (default),
+// routed methods fail with "No upstream available".
+func (r *UpstreamRouter) SetUpstreamResolver(res UpstreamResolver) {
+	r.methodMu.Lock()
+	defer r.methodMu.Unlock()
+	r.upstreamResolver = res
+}
+
+func (r *UpstreamRouter) getUpstreamResolver() UpstreamResolver {
+	r.methodMu.RLock()
+	defer r.methodMu.RUnlock()
+	return r.upstreamResolver
+}
+
 // SetNamespaceFilter sets an optional filter that restricts tool visibility per role.
 // When set, tools/list responses are filtered based on the caller's roles.
 func (r *UpstreamRouter) SetNamespaceFilter(filter NamespaceFilter) {
 	r.nsMu.Lock()
 	r.namespaceFilter = filter
 	r.nsMu.Unlock()
+	r.toolsList.reset()
 }
 
 // getNamespaceFilter returns the current namespace filter under read lock.
@@ -182,8 +370,11 @@ func (r *UpstreamRouter) Intercept(ctx context.Context, msg *mcp.Message) (*mcp.
 	// bytes directly avoids this correctness hazard. msg.Raw MUST NOT be mutated
 	// after construction — this is the immutability contract for Message.Raw.
 	if rawIDFromBytes(msg.Raw) == nil && msg.Direction == mcp.ClientToServer {
-		if method == "notifications/cancelled" {
+		switch method {
+		case "notifications/cancelled":
 			r.forwardCancelledNotification(ctx, msg)
+		case "notifications/initialized", "initialized":
+			r.acknowledgeNotice(ctx, msg)
 		}
 		return nil, nil
 	}
@@ -202,33 +393,51 @@ func (r *UpstreamRouter) Intercept(ctx context.Context, msg *mcp.Message) (*mcp.
 		return nil, nil
 	}
 
-	switch method {
-	case "tools/list":
+	if method == "tools/list" {
 		return r.handleToolsList(msg)
-	case "ping":
+	}
+
+	// Methods other than the reserved ones are handled as the method
+	// policy says; reserved methods keep an empty action.
+	rule := validation.MethodRule{Method: method}
+	if !validation.IsReservedMethod(method) {
+		policy := r.getMethodPolicy()
+		rule = policy.Resolve(method)
+		policy.Record(method, rule.Action)
+	}
+	switch rule.Action {
+	case validation.MethodLocal:
 		return r.buildResultResponse(msg, struct{}{})
-	default:
-		if !r.manager.AllConnected() {
-			r.logger.Warn("no upstreams available")
-			return r.buildErrorResponse(msg, ErrCodeNoUpstreams, "No upstreams available"), nil
-		}
-		if method == "tools/call" {
-			return r.handleToolsCall(ctx, msg)
-		}
-		return r.handleForward(ctx, msg)
+	case validation.MethodDeny:
+		r.logger.Debug("method denied by method policy", "method", method)
+		return r.buildErrorResponse(msg, ErrCodeMethodNotFound, fmt.Sprintf("Method not found: %s", method)), nil
 	}
+
+	if !r.manager.AllConnected() {
+		r.logger.Warn("no upstreams available")
+		return r.buildErrorResponse(msg, ErrCodeNoUpstreams, "No upstreams available"), nil
+	}
+	switch method {
+	case "tools/call":
+		return r.handleToolsCall(ctx, msg)
+	case "resources/subscribe":
+		return r.handleSubscribe(ctx, msg)
+	case "resources/unsubscribe":
+		return r.handleUnsubscribe(ctx, msg)
+	}
+	if rule.Action == validation.MethodRoute {
+		return r.handleRoute(ctx, msg, rule.Upstream)
+	}
+	return r.handleForward(ctx, msg)
 }
 
 // handleToolsList aggregates tools from all upstreams into a unified response.
 // When a NamespaceFilter is set, tools are filtered based on the caller's roles.
+//
+// Filtered results are cached per role set while the tool cache and the
+// namespace filter report the same versions (see VersionedSource), so
+// chatty clients do not repeat the filtering on every request.
 func (r *UpstreamRouter) handleToolsList(msg *mcp.Message) (*mcp.Message, error) {
-	allTools := r.toolCache.GetAllTools()
-
-	// Sort tools by name for deterministic ordering.
-	sort.SliceStable(allTools, func(i, j int) bool {
-		return allTools[i].Name < allTools[j].Name
-	})
-
 	// Extract caller roles for namespace filtering.
 	var callerRoles []string
 	if msg.Session != nil {
@@ -237,9 +446,59 @@ func (r *UpstreamRouter) handleToolsList(msg *mcp.Message) (*mcp.Message, error)
 		}
 	}
 
+	nsFilter := r.getNamespaceFilter()
+	// Versions are read before the tools so a concurrent change can only
+	// leave behind an entry that no longer matches, never a stale hit.
+	toolsVersion, filterVersion, cacheable := r.toolsListVersions(nsFilter)
+	cacheKey := ""
+	if nsFilter != nil {
+		cacheKey = toolsListCacheKey(callerRoles)
+	}
+	if cacheable {
+		if result, ok := r.toolsList.get(cacheKey, toolsVersion, filterVersion); ok {
+			return r.buildResultResponse(msg, result)
+		}
+	}
+
+	result, err := json.Marshal(r.buildToolsListResult(nsFilter, callerRoles))
+	if err != nil {
+		return nil, fmt.Errorf("marshaling result: %w", err)
+	}
+	if cacheable {
+		r.toolsList.put(cacheKey, toolsVersion, filterVersion, result)
+	}
+
+	return r.buildResultResponse(msg, json.RawMessage(result))
+}
+
+// toolsListVersions returns the versions a cached tools/list result depends
+// on. cacheable is false when the tool cache or the namespace filter cannot
+// report changes.
+func (r *UpstreamRouter) toolsListVersions(nsFilter NamespaceFilter) (toolsVersion, filterVersion uint64, cacheable bool) {
+	tv, ok := r.toolCache.(VersionedSource)
+	if !ok {
+		return 0, 0, false
+	}
+	if nsFilter != nil {
+		fv, ok := nsFilter.(VersionedSource)
+		if !ok {
+			return 0, 0, false
+		}
+		filterVersion = fv.Version()
+	}
+	return tv.Version(), filterVersion, true
+}
+
+// buildToolsListResult aggregates and filters the tools visible to callerRoles.
+func (r *UpstreamRouter) buildToolsListResult(nsFilter NamespaceFilter, callerRoles []string) toolsListResult {
+	allTools := r.toolCache.GetAllTools()
+
+	// Sort tools by name for deterministic ordering.
+	sort.SliceStable(allTools, func(i, j int) bool {
+		return allTools[i].Name < allTools[j].Name
+	})
 
 	// Build the tools array for the response, applying namespace filter.
-	nsFilter := r.getNamespaceFilter()
 	tools := make([]toolEntry, 0, len(allTools))
 	for _, t := range allTools {
 		// Namespace isolation: skip tools not visible to caller's roles.
@@ -261,10 +520,7 @@ func (r *UpstreamRouter) handleToolsList(msg *mcp.Message) (*mcp.Message, error)
 		tools = append(tools, entry)
 	}
 
-	// Build the JSON-RPC response.
-	result := toolsListResult{Tools: tools}
-
-	return r.buildResultResponse(msg, result)
+	return toolsListResult{Tools: tools}
 }
 
 // handleToolsCall routes a tools/call request to the upstream that owns the tool.
@@ -328,6 +584,12 @@ func (r *UpstreamRouter) handleToolsCall(ctx context.Context, msg *mcp.Message)
 
 	r.logger.Debug("routing tools/call", "tool", toolName, "upstream", tool.UpstreamID)
 
+	// Record the serving upstream for provenance (read by the audit interceptor).
+	if holder := audit.UpstreamRouteFromContext(ctx); holder != nil {
+		holder.UpstreamID = tool.UpstreamID
+		holder.UpstreamName = tool.UpstreamName
+	}
+
 	// If the resolved name differs from the original bare name (i.e. it's namespaced),
 	// rewrite the tool name in the message before forwarding to the upstream.
 	// The upstream doesn't know about namespacing and expects the bare name.
@@ -347,8 +609,17 @@ func (r *UpstreamRouter) handleToolsCall(ctx context.Context, msg *mcp.Message)
 		}
 	}
 
+	forwardStart := time.Now()
 	resp, err := r.forwardToUpstream(ctx, tool.UpstreamID, forwardMsg)
+	// Missing end-user credentials and client cancellation are not upstream failures.
+	if obs := r.getCallObserver(); obs != nil && !errors.Is(err, ErrUpstreamCredentials) && ctx.Err() == nil {
+		obs.ObserveUpstreamCall(tool.UpstreamName, time.Since(forwardStart), err != nil)
+	}
 	if err != nil {
+		if errors.Is(err, ErrUpstreamCredentials) {
+			r.logger.Warn("upstream credentials unavailable", "upstream", tool.UpstreamID, "error", err)
+			return r.buildErrorResponse(msg, ErrCodeInternal, "Upstream authorization failed"), nil
+		}
 		r.logger.Error("upstream forward failed", "upstream", tool.UpstreamID, "error", err)
 		// M-16: Do not expose upstream ID to clients; it is already logged server-side.
 		return r.buildErrorResponse(msg, ErrCodeInternal, "Upstream unavailable"), nil
@@ -356,6 +627,35 @@ func (r *UpstreamRouter) handleToolsCall(ctx context.Context, msg *mcp.Message)
 	return resp, nil
 }
 
+// CallTool calls a tool on behalf of the gateway itself, outside any client
+// session (e.g., response guard canaries). The tool is resolved like a client
+// call, but namespace visibility does not apply. The upstream's response is
+// returned unchanged, including JSON-RPC errors.
+func (r *UpstreamRouter) CallTool(ctx context.Context, toolName string, arguments map[string]interface{}) (*mcp.Message, error) {
+	tool, found := r.toolCache.GetTool(toolName)
+	if !found {
+		return nil, fmt.Errorf("tool %q not found", toolName)
+	}
+	name := toolName
+	if tool.OriginalName != "" {
+		name = tool.OriginalName
+	}
+	if arguments == nil {
+		arguments = map[string]interface{}{}
+	}
+	raw, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      fmt.Sprintf("sentinelgate-call-%d", r.callSeq.Add(1)),
+		"method":  "tools/call",
+		"params":  map[string]interface{}{"name": name, "arguments": arguments},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encode tools/call: %w", err)
+	}
+	msg := &mcp.Message{Raw: raw, Direction: mcp.ClientToServer, Timestamp: time.Now()}
+	return r.forwardToUpstream(ctx, tool.UpstreamID, msg)
+}
+
 // ClientFramework returns the last-seen client framework name (for backward compat / stats).
 func (r *UpstreamRouter) ClientFramework() string {
 	r.nsMu.RLock()
@@ -376,6 +676,7 @@ func (r *UpstreamRouter) ClientFrameworkForSession(sessionID string) string {
 
 // handleInitialize responds to the MCP initialize handshake directly.
 // The proxy advertises its own capabilities (tools) without forwarding to upstreams.
+// A notice set for the caller is added as instructions and in _meta.
 func (r *UpstreamRouter) handleInitialize(msg *mcp.Message) (*mcp.Message, error) {
 	r.logger.Debug("handling initialize locally")
 
@@ -425,34 +726,54 @@ func (r *UpstreamRouter) handleInitialize(msg *mcp.Message) (*mcp.Message, error
 			"version": serverVersion,
 		},
 	}
+	r.addNotice(msg, result)
 
 	return r.buildResultResponse(msg, result)
 }
 
-// forwardableMethodAllowlist defines which non-tool methods may be forwarded to upstreams.
-// M-16: Prevents arbitrary method forwarding that could reach unintended upstream endpoints.
-var forwardableMethodAllowlist = map[string]bool{
-	"resources/list":           true,
-	"resources/read":           true,
-	"resources/subscribe":      true,
-	"resources/unsubscribe":    true,
-	"resources/templates/list": true, // H-12: MCP resource templates
-	"prompts/list":             true,
-	"prompts/get":              true,
-	"completion/complete":      true,
-	"logging/setLevel":         true,
-}
-
 // handleForward forwards non-tool messages to the first available upstream.
+// M-16: Only methods the method policy forwards get here; by default that
+// is a fixed allowlist, so arbitrary methods cannot reach unintended
+// upstream endpoints.
 func (r *UpstreamRouter) handleForward(ctx context.Context, msg *mcp.Message) (*mcp.Message, error) {
-	// M-16: Only forward methods on the allowlist to prevent unvalidated forwarding.
 	method := msg.Method()
-	if !forwardableMethodAllowlist[method] {
-		r.logger.Warn("rejecting non-allowlisted method for forwarding", "method", method)
-		return r.buildErrorResponse(msg, ErrCodeMethodNotFound, fmt.Sprintf("Method not found: %s", method)), nil
-	}
 	r.logger.Debug("forwarding message to upstream", "method", method)
 
+	resp, _, err := r.forwardToFirst(ctx, msg)
+	if err != nil {
+		r.logger.Error("no upstream available for forwarding", "method", msg.Method(), "error", err)
+		return r.buildErrorResponse(msg, ErrCodeNoUpstreams, "No upstream available"), nil
+	}
+	return resp, nil
+}
+
+// handleRoute forwards a message to the upstream the method policy names.
+func (r *UpstreamRouter) handleRoute(ctx context.Context, msg *mcp.Message, upstreamName string) (*mcp.Message, error) {
+	method := msg.Method()
+	resolver := r.getUpstreamResolver()
+	if resolver == nil {
+		r.logger.Warn("method routed to an upstream but no resolver is set", "method", method, "upstream", upstreamName)
+		return r.buildErrorResponse(msg, ErrCodeNoUpstreams, "No upstream available"), nil
+	}
+	upstreamID, ok := resolver.UpstreamIDByName(ctx, upstreamName)
+	if !ok {
+		r.logger.Warn("method routed to an unknown upstream", "method", method, "upstream", upstreamName)
+		return r.buildErrorResponse(msg, ErrCodeNoUpstreams, "No upstream available"), nil
+	}
+	r.logger.Debug("routing message to upstream", "method", method, "upstream", upstreamName)
+
+	resp, err := r.forwardToUpstream(ctx, upstreamID, msg)
+	if err != nil {
+		r.logger.Error("routed upstream unavailable", "method", method, "upstream", upstreamName, "error", err)
+		return r.buildErrorResponse(msg, ErrCodeNoUpstreams, "No upstream available"), nil
+	}
+	return resp, nil
+}
+
+// forwardToFirst forwards msg to the upstreams in ID order and returns the
+// first response that is not method-not-found, with the ID of the upstream
+// that produced it. When no upstream answers, it falls back to "primary".
+func (r *UpstreamRouter) forwardToFirst(ctx context.Context, msg *mcp.Message) (*mcp.Message, string, error) {
 	allTools := r.toolCache.GetAllTools()
 	if len(allTools) > 0 {
 		seen := make(map[string]bool)
@@ -477,17 +798,15 @@ func (r *UpstreamRouter) handleForward(ctx context.Context, msg *mcp.Message) (*
 				r.logger.Debug("upstream returned method-not-found, trying next", "upstream", upstreamID, "method", msg.Method())
 				continue
 			}
-			return resp, nil
+			return resp, upstreamID, nil
 		}
 	}
 
 	resp, err := r.forwardToUpstream(ctx, "primary", msg)
 	if err != nil {
-		r.logger.Error("no upstream available for forwarding", "method", msg.Method(), "error", err)
-		return r.buildErrorResponse(msg, ErrCodeNoUpstreams, "No upstream available"), nil
+		return nil, "", err
 	}
-
-	return resp, nil
+	return resp, "primary", nil
 }
 
 func (r *UpstreamRouter) isMethodNotFoundResponse(msg *mcp.Message) bool {
@@ -521,6 +840,12 @@ func (r *UpstreamRouter) forwardCancelledNotification(ctx context.Context, msg *
 		data = dataCopy
 	}
 
+	// Idle on-demand upstreams have no request to cancel; don't wake them.
+	getConn := r.manager.GetConnection
+	if active, ok := r.manager.(ActiveConnectionProvider); ok {
+		getConn = active.GetActiveConnection
+	}
+
 	// Collect unique upstream IDs from the tool cache.
 	seen := make(map[string]bool)
 	for _, t := range r.toolCache.GetAllTools() {
@@ -528,7 +853,7 @@ func (r *UpstreamRouter) forwardCancelledNotification(ctx context.Context, msg *
 			continue
 		}
 		seen[t.UpstreamID] = true
-		writer, _, err := r.manager.GetConnection(t.UpstreamID)
+		writer, _, err := getConn(t.UpstreamID)
 		if err != nil {
 			r.logger.Debug("skipping cancelled notification for unavailable upstream", "upstream", t.UpstreamID)
 			continue
@@ -559,7 +884,35 @@ func (r *UpstreamRouter) forwardCancelledNotification(ctx context.Context, msg *
 // notifications/progress) are forwarded to the client via the
 // NotificationForwarder if one is set (H-4). Context cancellation unblocks
 // the select loop immediately instead of waiting up to 30s (H-5).
+//
+// If a credential injector is set, its headers travel with the message via
+// UpstreamHeaderWriter; transports that cannot carry them fail closed. The
+// correlation ID is added as SetCorrelationPropagation configures.
 func (r *UpstreamRouter) forwardToUpstream(ctx context.Context, upstreamID string, msg *mcp.Message) (*mcp.Message, error) {
+	defer watchdog.Enter(ctx, watchdog.StageUpstream)()
+	defer timing.Track(ctx, timing.PhaseUpstream)()
+
+	// Resolve per-request credentials outside the per-upstream lock: a
+	// slow IdP round-trip must not stall other callers of the same upstream.
+	var headers map[string]string
+	if inj := r.getCredentialInjector(); inj != nil {
+		h, err := inj.UpstreamHeaders(ctx, upstreamID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUpstreamCredentials, err)
+		}
+		headers = h
+	}
+
+	// Register the call before queueing on the upstream, so that a drain
+	// waits for the calls already accepted and holds the new ones.
+	if tracker, ok := r.manager.(CallTracker); ok {
+		end, err := tracker.BeginCall(ctx, upstreamID)
+		if err != nil {
+			return nil, fmt.Errorf("upstream %s unavailable: %w", upstreamID, err)
+		}
+		defer end()
+	}
+
 	// Serialize access to this upstream's stdin pipe.
 	muI, _ := r.ioMutexes.LoadOrStore(upstreamID, &sync.Mutex{})
 	mu := muI.(*sync.Mutex)
@@ -579,6 +932,14 @@ func (r *UpstreamRouter) forwardToUpstream(ctx context.Context, upstreamID strin
 		return nil, fmt.Errorf("empty message to forward")
 	}
 
+	if len(headers) > 0 {
+		if _, ok := writer.(UpstreamHeaderWriter); !ok {
+			// Fail closed: credentials were required but this transport cannot carry them.
+			return nil, fmt.Errorf("%w: upstream %s does not accept request headers", ErrUpstreamCredentials, upstreamID)
+		}
+	}
+	data, headers = r.correlate(ctx, writer, data, headers)
+
 	// Append newline if not already present.
 	if data[len(data)-1] != '\n' {
 		dataCopy := make([]byte, len(data), len(data)+1)
@@ -587,7 +948,11 @@ func (r *UpstreamRouter) forwardToUpstream(ctx context.Context, upstreamID strin
 		data = dataCopy
 	}
 
-	if _, err := writer.Write(data); err != nil {
+	if hw, ok := writer.(UpstreamHeaderWriter); ok && len(headers) > 0 {
+		if _, err := hw.WriteWithHeaders(data, headers); err != nil {
+			return nil, fmt.Errorf("writing to upstream: %w", err)
+		}
+	} else if _, err := writer.Write(data); err != nil {
 		return nil, fmt.Errorf("writing to upstream: %w", err)
 	}
 
@@ -610,12 +975,28 @@ func (r *UpstreamRouter) forwardToUpstream(ctx context.Context, upstreamID strin
 			var peek struct {
 				ID     json.RawMessage `json:"id"`
 				Method string          `json:"method"`
+				Result json.RawMessage `json:"result"`
+				Error  json.RawMessage `json:"error"`
+			}
+			isMessage := json.Unmarshal(line, &peek) == nil
+			if isMessage && peek.ID != nil && peek.Method != "" && peek.Result == nil && peek.Error == nil {
+				// A request from the upstream to the client (e.g.
+				// sampling/createMessage) is answered before the response
+				// to msg can arrive.
+				r.answerServerRequest(ctx, writer, headers, upstreamID, peek.Method, msg, line)
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(30 * time.Second)
+				continue
 			}
-			if json.Unmarshal(line, &peek) == nil && peek.ID == nil && peek.Method != "" {
+			if isMessage && peek.ID == nil && peek.Method != "" {
 				// Forward notification to client if a forwarder is set (H-4).
 				if notifFwd != nil {
-					notifFwd.ForwardNotification(line)
-					r.logger.Debug("forwarded upstream notification", "method", peek.Method, "upstream", upstreamID)
+					r.deliverNotification(ctx, notifFwd, upstreamID, peek.Method, msg, line)
 				} else {
 					r.logger.Debug("dropping upstream notification (no forwarder)", "method", peek.Method, "upstream", upstreamID)
 				}
@@ -651,6 +1032,41 @@ func (r *UpstreamRouter) forwardToUpstream(ctx context.Context, upstreamID strin
 	}, nil
 }
 
+// deliverNotification sends an upstream notification to the client as the
+// notification policy decides. msg is the request in flight; notifications
+// for the latest connection only go to its session. Without a session, or
+// when fwd cannot target one, they are forwarded like any other.
+func (r *UpstreamRouter) deliverNotification(ctx context.Context, fwd NotificationForwarder, upstreamID, method string, msg *mcp.Message, line []byte) {
+	action := NotificationForward
+	if p := r.getNotificationPolicy(); p != nil {
+		action = p.Decide(r.upstreamName(ctx, upstreamID), method)
+	}
+	switch action {
+	case NotificationDrop:
+		r.logger.Debug("dropping upstream notification (policy)", "method", method, "upstream", upstreamID)
+		return
+	case NotificationLatest:
+		if sf, ok := fwd.(SessionNotificationForwarder); ok && msg.Session != nil && msg.Session.ID != "" {
+			sf.ForwardNotificationToLatest(msg.Session.ID, line)
+			r.logger.Debug("forwarded upstream notification to latest connection", "method", method, "upstream", upstreamID)
+			return
+		}
+	}
+	fwd.ForwardNotification(line)
+	r.logger.Debug("forwarded upstream notification", "method", method, "upstream", upstreamID)
+}
+
+// upstreamName returns the name of the upstream with the given ID, or the ID
+// when no resolver is set or the upstream is unknown.
+func (r *UpstreamRouter) upstreamName(ctx context.Context, upstreamID string) string {
+	if resolver := r.getUpstreamResolver(); resolver != nil {
+		if name, ok := resolver.UpstreamNameByID(ctx, upstreamID); ok {
+			return name
+		}
+	}
+	return upstreamID
+}
+
 // remapResponseID replaces the "id" field in a JSON-RPC response with the given client ID.
 // This ensures the response ID matches the original client request ID, even if the
 // upstream assigned a different internal ID.
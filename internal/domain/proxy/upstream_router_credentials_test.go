@@ -0,0 +1,114 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// mockCredentialInjector implements UpstreamCredentialInjector for testing.
+type mockCredentialInjector struct {
+	headers map[string]string
+	err     error
+	calls   []string
+}
+
+func (m *mockCredentialInjector) UpstreamHeaders(_ context.Context, upstreamID string) (map[string]string, error) {
+	m.calls = append(m.calls, upstreamID)
+	return m.headers, m.err
+}
+
+// mockHeaderWriteCloser records headers passed via WriteWithHeaders.
+type mockHeaderWriteCloser struct {
+	mockWriteCloser
+	headers map[string]string
+}
+
+func (w *mockHeaderWriteCloser) WriteWithHeaders(p []byte, headers map[string]string) (int, error) {
+	w.headers = headers
+	return w.Write(p)
+}
+
+func TestRouterToolsCall_InjectsCredentialHeaders(t *testing.T) {
+	cache := newMockToolCacheReader(&RoutableTool{Name: "repo-read", UpstreamID: "upstream-1"})
+	manager := newMockUpstreamConnectionProvider()
+	manager.addConnection("upstream-1", `{"jsonrpc":"2.0","id":1,"result":{}}`)
+
+	hw := &mockHeaderWriteCloser{}
+	router := newTestRouter(cache, &headerProvider{mockUpstreamConnectionProvider: manager, writer: hw})
+	inj := &mockCredentialInjector{headers: map[string]string{"Authorization": "Bearer user-token"}}
+	router.SetCredentialInjector(inj)
+
+	resp, err := router.Intercept(context.Background(), makeToolsCallRequest(t, 1, "repo-read", nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(string(resp.Raw), `"error"`) {
+		t.Fatalf("expected success response, got %s", resp.Raw)
+	}
+	if got := hw.headers["Authorization"]; got != "Bearer user-token" {
+		t.Errorf("Authorization header = %q, want %q", got, "Bearer user-token")
+	}
+	if len(hw.buf) == 0 {
+		t.Error("expected message to be written via WriteWithHeaders")
+	}
+	if len(inj.calls) != 1 || inj.calls[0] != "upstream-1" {
+		t.Errorf("injector calls = %v, want [upstream-1]", inj.calls)
+	}
+}
+
+func TestRouterToolsCall_CredentialErrorFailsClosed(t *testing.T) {
+	cache := newMockToolCacheReader(&RoutableTool{Name: "repo-read", UpstreamID: "upstream-1"})
+	manager := newMockUpstreamConnectionProvider()
+	manager.addConnection("upstream-1", `{"jsonrpc":"2.0","id":1,"result":{}}`)
+
+	router := newTestRouter(cache, manager)
+	router.SetCredentialInjector(&mockCredentialInjector{err: errors.New("token expired")})
+
+	resp, err := router.Intercept(context.Background(), makeToolsCallRequest(t, 1, "repo-read", nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(resp.Raw), "Upstream authorization failed") {
+		t.Errorf("expected authorization error, got %s", resp.Raw)
+	}
+	if len(manager.connections["upstream-1"].writer.buf) != 0 {
+		t.Error("message must not reach the upstream when credentials fail")
+	}
+}
+
+func TestRouterToolsCall_HeadersRequireHeaderWriter(t *testing.T) {
+	cache := newMockToolCacheReader(&RoutableTool{Name: "repo-read", UpstreamID: "upstream-1"})
+	manager := newMockUpstreamConnectionProvider()
+	manager.addConnection("upstream-1", `{"jsonrpc":"2.0","id":1,"result":{}}`)
+
+	router := newTestRouter(cache, manager)
+	router.SetCredentialInjector(&mockCredentialInjector{headers: map[string]string{"Authorization": "Bearer x"}})
+
+	resp, err := router.Intercept(context.Background(), makeToolsCallRequest(t, 1, "repo-read", nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(resp.Raw), "Upstream authorization failed") {
+		t.Errorf("expected authorization error for stdio-like writer, got %s", resp.Raw)
+	}
+	if len(manager.connections["upstream-1"].writer.buf) != 0 {
+		t.Error("credentials must not be silently dropped")
+	}
+}
+
+// headerProvider returns a header-capable writer for every connection.
+type headerProvider struct {
+	*mockUpstreamConnectionProvider
+	writer *mockHeaderWriteCloser
+}
+
+func (p *headerProvider) GetConnection(upstreamID string) (io.WriteCloser, <-chan []byte, error) {
+	_, lineCh, err := p.mockUpstreamConnectionProvider.GetConnection(upstreamID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p.writer, lineCh, nil
+}
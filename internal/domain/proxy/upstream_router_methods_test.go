@@ -0,0 +1,134 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/validation"
+	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+)
+
+// staticUpstreamResolver resolves upstream names from a map.
+type staticUpstreamResolver map[string]string
+
+func (s staticUpstreamResolver) UpstreamIDByName(_ context.Context, name string) (string, bool) {
+	id, ok := s[name]
+	return id, ok
+}
+
+func (s staticUpstreamResolver) UpstreamNameByID(_ context.Context, id string) (string, bool) {
+	for name, v := range s {
+		if v == id {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func makeMethodRequest(t *testing.T, id int64, method string) *mcp.Message {
+	t.Helper()
+	reqID, _ := jsonrpc.MakeID(float64(id))
+	req := &jsonrpc.Request{ID: reqID, Method: method, Params: json.RawMessage(`{}`)}
+	raw, err := jsonrpc.EncodeMessage(req)
+	if err != nil {
+		t.Fatalf("failed to encode %s request: %v", method, err)
+	}
+	return &mcp.Message{Raw: raw, Direction: mcp.ClientToServer, Decoded: req}
+}
+
+// responseErrorCode returns the JSON-RPC error code of resp, or 0.
+func responseErrorCode(t *testing.T, resp *mcp.Message) int64 {
+	t.Helper()
+	if resp == nil {
+		t.Fatal("expected a response")
+	}
+	var envelope struct {
+		Error *struct {
+			Code int64 `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(resp.Raw, &envelope); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if envelope.Error == nil {
+		return 0
+	}
+	return envelope.Error.Code
+}
+
+func TestRouter_DefaultMethodHandling(t *testing.T) {
+	manager := newMockUpstreamConnectionProvider()
+	manager.allConnected = false
+	router := newTestRouter(newMockToolCacheReader(), manager)
+
+	// ping is answered locally, even without upstreams.
+	resp, err := router.Intercept(context.Background(), makeMethodRequest(t, 1, "ping"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code := responseErrorCode(t, resp); code != 0 {
+		t.Errorf("ping error code = %d, want a result", code)
+	}
+
+	// Client features are not forwarded.
+	resp, _ = router.Intercept(context.Background(), makeMethodRequest(t, 2, "sampling/createMessage"))
+	if code := responseErrorCode(t, resp); code != ErrCodeMethodNotFound {
+		t.Errorf("sampling/createMessage error code = %d, want %d", code, ErrCodeMethodNotFound)
+	}
+}
+
+func TestRouter_MethodPolicyRoutesAndDenies(t *testing.T) {
+	cache := newMockToolCacheReader(
+		&RoutableTool{Name: "read", UpstreamID: "id-a", UpstreamName: "a"},
+		&RoutableTool{Name: "search", UpstreamID: "id-b", UpstreamName: "b"},
+	)
+	manager := newMockUpstreamConnectionProvider()
+	manager.addConnection("id-a", `{"jsonrpc":"2.0","id":1,"result":{"from":"a"}}`)
+	manager.addConnection("id-b", `{"jsonrpc":"2.0","id":1,"result":{"from":"b"}}`)
+
+	policy, err := validation.NewMethodPolicy([]validation.MethodRule{
+		{Method: "completion/complete", Action: validation.MethodRoute, Upstream: "b"},
+		{Method: "ping", Action: validation.MethodDeny},
+	}, validation.MethodRule{Action: validation.MethodRoute, Upstream: "missing"})
+	if err != nil {
+		t.Fatalf("NewMethodPolicy: %v", err)
+	}
+	router := newTestRouter(cache, manager)
+	router.SetMethodPolicy(policy)
+	router.SetUpstreamResolver(staticUpstreamResolver{"a": "id-a", "b": "id-b"})
+
+	resp, err := router.Intercept(context.Background(), makeMethodRequest(t, 1, "completion/complete"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(resp.Raw), `"from":"b"`) {
+		t.Errorf("completion/complete answered by %s, want upstream b", resp.Raw)
+	}
+	if len(manager.connections["id-a"].writer.buf) != 0 {
+		t.Error("routed method should not reach upstream a")
+	}
+
+	resp, _ = router.Intercept(context.Background(), makeMethodRequest(t, 2, "ping"))
+	if code := responseErrorCode(t, resp); code != ErrCodeMethodNotFound {
+		t.Errorf("denied ping error code = %d, want %d", code, ErrCodeMethodNotFound)
+	}
+
+	resp, _ = router.Intercept(context.Background(), makeMethodRequest(t, 3, "vendor/custom"))
+	if code := responseErrorCode(t, resp); code != ErrCodeNoUpstreams {
+		t.Errorf("unknown upstream error code = %d, want %d", code, ErrCodeNoUpstreams)
+	}
+
+	got := make(map[string]validation.MethodStats)
+	for _, s := range policy.Stats() {
+		got[s.Method] = s
+	}
+	if got["completion/complete"].Routed != 1 || got["ping"].Denied != 1 || got["vendor/custom"].Routed != 1 {
+		t.Errorf("stats = %+v", got)
+	}
+	if _, ok := got["tools/call"]; ok {
+		t.Error("reserved methods should not be counted")
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"io"
 	"log/slog"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
 	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
@@ -387,6 +389,44 @@ func TestRouterToolsCallUpstreamUnavailable(t *testing.T) {
 	}
 }
 
+// recordingCallObserver records upstream call observations.
+type recordingCallObserver struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (o *recordingCallObserver) ObserveUpstreamCall(upstreamName string, _ time.Duration, failed bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.calls = append(o.calls, fmt.Sprintf("%s:%v", upstreamName, failed))
+}
+
+// TestRouterCallObserver tests that forwarded tool calls are reported with
+// the upstream name and whether the upstream failed.
+func TestRouterCallObserver(t *testing.T) {
+	cache := newMockToolCacheReader(
+		&RoutableTool{Name: "read-file", UpstreamID: "upstream-1", UpstreamName: "files"},
+		&RoutableTool{Name: "search-web", UpstreamID: "upstream-2", UpstreamName: "search"},
+	)
+	manager := newMockUpstreamConnectionProvider()
+	manager.addConnection("upstream-1", `{"jsonrpc":"2.0","id":1,"result":{"content":[]}}`)
+	// upstream-2 is not connected.
+	router := newTestRouter(cache, manager)
+	obs := &recordingCallObserver{}
+	router.SetCallObserver(obs)
+
+	for _, name := range []string{"read-file", "search-web", "missing-tool"} {
+		if _, err := router.Intercept(context.Background(), makeToolsCallRequest(t, 1, name, nil)); err != nil {
+			t.Fatalf("unexpected error for %s: %v", name, err)
+		}
+	}
+
+	want := []string{"files:false", "search:true"}
+	if fmt.Sprint(obs.calls) != fmt.Sprint(want) {
+		t.Errorf("observed calls = %v, want %v", obs.calls, want)
+	}
+}
+
 // TestRouterAllUpstreamsDisconnected tests 503-equivalent error when no upstreams available.
 func TestRouterAllUpstreamsDisconnected(t *testing.T) {
 	cache := newMockToolCacheReader()
@@ -890,3 +930,40 @@ func TestRouter_ForwardStripsNamespace(t *testing.T) {
 		t.Error("written JSON should not contain the namespaced name \"desktop/read_file\"")
 	}
 }
+
+func TestRouterCallTool(t *testing.T) {
+	cache := newMockToolCacheReader(
+		&RoutableTool{Name: "gh/get_repo", OriginalName: "get_repo", UpstreamID: "upstream-1"},
+	)
+	manager := newMockUpstreamConnectionProvider()
+	manager.addConnection("upstream-1", `{"jsonrpc":"2.0","id":"sentinelgate-call-1","result":{"content":[]}}`)
+	router := newTestRouter(cache, manager)
+	// Namespace visibility applies to clients only.
+	router.SetNamespaceFilter(&mockNamespaceFilter{visible: map[string]map[string]bool{"gh/get_repo": {}}})
+
+	resp, err := router.CallTool(context.Background(), "gh/get_repo", map[string]interface{}{"repo": "a/b"})
+	if err != nil {
+		t.Fatalf("CallTool() error: %v", err)
+	}
+	if !strings.Contains(string(resp.Raw), `"result"`) {
+		t.Errorf("response = %s", resp.Raw)
+	}
+
+	var sent struct {
+		Method string `json:"method"`
+		Params struct {
+			Name      string                 `json:"name"`
+			Arguments map[string]interface{} `json:"arguments"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(manager.connections["upstream-1"].writer.buf, &sent); err != nil {
+		t.Fatalf("written data is not valid JSON: %v", err)
+	}
+	if sent.Method != "tools/call" || sent.Params.Name != "get_repo" || sent.Params.Arguments["repo"] != "a/b" {
+		t.Errorf("forwarded request = %+v", sent)
+	}
+
+	if _, err := router.CallTool(context.Background(), "missing", nil); err == nil {
+		t.Error("CallTool() should fail for an unknown tool")
+	}
+}
@@ -0,0 +1,188 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/storage"
+)
+
+// dailyUsageSeries is the time series holding daily quota usage. Each data
+// point is the units one identity used on one UTC day during one flush
+// interval (tags "identity" and "day").
+const dailyUsageSeries = "quota:daily"
+
+// DefaultDailyFlushInterval is how often daily usage is persisted.
+const DefaultDailyFlushInterval = time.Minute
+
+// dailyDayLayout formats the UTC day of a counter.
+const dailyDayLayout = "2006-01-02"
+
+// dailyKey identifies the usage of an identity on a day.
+type dailyKey struct {
+	identity string
+	day      string
+}
+
+// DailyCounter counts the quota units each identity used on the current
+// UTC day. Counts reset at midnight UTC. With a time series store, usage is
+// flushed every interval by Run and loaded back by Load, so a restart does
+// not reset daily quotas; up to one interval of usage can be lost on a
+// crash.
+type DailyCounter struct {
+	mu      sync.Mutex
+	day     string
+	used    map[string]int64   // identity → units on day
+	pending map[dailyKey]int64 // units not yet flushed
+	tsStore storage.TimeSeriesStore
+	now     func() time.Time
+	logger  *slog.Logger
+}
+
+// NewDailyCounter creates a DailyCounter with no persistence.
+func NewDailyCounter(logger *slog.Logger) *DailyCounter {
+	return &DailyCounter{
+		used:    make(map[string]int64),
+		pending: make(map[dailyKey]int64),
+		now:     time.Now,
+		logger:  logger,
+	}
+}
+
+// SetTimeSeriesStore wires the store used to persist usage.
+func (c *DailyCounter) SetTimeSeriesStore(ts storage.TimeSeriesStore) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tsStore = ts
+}
+
+// today returns the current UTC day, resetting the counts when it changed.
+// Must be called with mu held.
+func (c *DailyCounter) today() string {
+	day := c.now().UTC().Format(dailyDayLayout)
+	if day != c.day {
+		c.day = day
+		c.used = make(map[string]int64)
+	}
+	return day
+}
+
+// Load adds the usage persisted for the current day to the counts. It is
+// called once at startup, before calls are counted.
+func (c *DailyCounter) Load(ctx context.Context) error {
+	c.mu.Lock()
+	ts := c.tsStore
+	day := c.today()
+	c.mu.Unlock()
+	if ts == nil {
+		return nil
+	}
+
+	start, _ := time.Parse(dailyDayLayout, day)
+	points, err := ts.Query(ctx, dailyUsageSeries, start, start.AddDate(0, 0, 1))
+	if err != nil {
+		return fmt.Errorf("load daily quota usage: %w", err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.today() != day {
+		return nil
+	}
+	for _, p := range points {
+		if p.Tags["day"] == day && p.Tags["identity"] != "" {
+			c.used[p.Tags["identity"]] += int64(p.Value)
+		}
+	}
+	return nil
+}
+
+// Reserve adds units to the usage of identityID today and returns the new
+// total. When limit is positive and the total would exceed it, nothing is
+// added and ok is false; the returned total is the one the call would have
+// reached.
+func (c *DailyCounter) Reserve(identityID string, units, limit int64) (total int64, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	day := c.today()
+	total = c.used[identityID] + units
+	if limit > 0 && total > limit {
+		return total, false
+	}
+	if units > 0 {
+		c.used[identityID] = total
+		c.pending[dailyKey{identity: identityID, day: day}] += units
+	}
+	return total, true
+}
+
+// Used returns the units identityID used today.
+func (c *DailyCounter) Used(identityID string) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.today()
+	return c.used[identityID]
+}
+
+// Day returns the current UTC day as YYYY-MM-DD.
+func (c *DailyCounter) Day() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.today()
+}
+
+// Run flushes usage every interval until ctx is cancelled. Callers flush
+// once more on shutdown.
+func (c *DailyCounter) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultDailyFlushInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.Flush(ctx); err != nil {
+				c.logger.Warn("failed to flush daily quota usage", "error", err)
+			}
+		}
+	}
+}
+
+// Flush persists the usage counted since the last flush. Usage that fails
+// to persist stays pending for the next flush. Without a store it only
+// discards it.
+func (c *DailyCounter) Flush(ctx context.Context) error {
+	c.mu.Lock()
+	ts := c.tsStore
+	pending := c.pending
+	c.pending = make(map[dailyKey]int64)
+	c.mu.Unlock()
+	if ts == nil || len(pending) == 0 {
+		return nil
+	}
+
+	now := c.now().UTC()
+	var firstErr error
+	for key, units := range pending {
+		err := ts.Append(ctx, dailyUsageSeries, storage.DataPoint{
+			Timestamp: now,
+			Value:     float64(units),
+			Tags:      map[string]string{"identity": key.identity, "day": key.day},
+		})
+		if err == nil {
+			continue
+		}
+		c.mu.Lock()
+		c.pending[key] += units
+		c.mu.Unlock()
+		if firstErr == nil {
+			firstErr = fmt.Errorf("persist daily quota usage for %s on %s: %w", key.identity, key.day, err)
+		}
+	}
+	return firstErr
+}
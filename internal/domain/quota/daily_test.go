@@ -0,0 +1,176 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/storage"
+)
+
+// mockTimeSeriesStore keeps data points in memory. Append fails with
+// appendErr when it is set.
+type mockTimeSeriesStore struct {
+	mu        sync.Mutex
+	points    map[string][]storage.DataPoint
+	appendErr error
+}
+
+func newMockTimeSeriesStore() *mockTimeSeriesStore {
+	return &mockTimeSeriesStore{points: make(map[string][]storage.DataPoint)}
+}
+
+func (m *mockTimeSeriesStore) Append(_ context.Context, series string, p storage.DataPoint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.appendErr != nil {
+		return m.appendErr
+	}
+	m.points[series] = append(m.points[series], p)
+	return nil
+}
+
+func (m *mockTimeSeriesStore) Query(_ context.Context, series string, from, to time.Time) ([]storage.DataPoint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []storage.DataPoint
+	for _, p := range m.points[series] {
+		if !p.Timestamp.Before(from) && !p.Timestamp.After(to) {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockTimeSeriesStore) Aggregate(context.Context, string, time.Time, time.Time, storage.AggFunc) (float64, error) {
+	return 0, nil
+}
+
+func (m *mockTimeSeriesStore) Prune(context.Context, time.Duration) (int, error) { return 0, nil }
+
+func (m *mockTimeSeriesStore) DeleteSeries(context.Context, string) (int, error) { return 0, nil }
+
+func (m *mockTimeSeriesStore) Close() error { return nil }
+
+func newTestDailyCounter(now *time.Time) *DailyCounter {
+	c := NewDailyCounter(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	c.now = func() time.Time { return *now }
+	return c
+}
+
+func TestDailyCounter_Reserve(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	c := newTestDailyCounter(&now)
+
+	if total, ok := c.Reserve("id-1", 3, 5); !ok || total != 3 {
+		t.Fatalf("Reserve(3) = %d, %v, want 3, true", total, ok)
+	}
+	if total, ok := c.Reserve("id-1", 3, 5); ok || total != 6 {
+		t.Fatalf("Reserve(3) over the limit = %d, %v, want 6, false", total, ok)
+	}
+	if got := c.Used("id-1"); got != 3 {
+		t.Errorf("Used() after a refused reservation = %d, want 3", got)
+	}
+	if total, ok := c.Reserve("id-1", 10, 0); !ok || total != 13 {
+		t.Errorf("Reserve without limit = %d, %v, want 13, true", total, ok)
+	}
+	if got := c.Used("id-2"); got != 0 {
+		t.Errorf("Used(id-2) = %d, want 0", got)
+	}
+
+	// Usage resets at midnight UTC.
+	now = time.Date(2026, 3, 11, 0, 0, 1, 0, time.UTC)
+	if got := c.Used("id-1"); got != 0 {
+		t.Errorf("Used() on the next day = %d, want 0", got)
+	}
+	if got := c.Day(); got != "2026-03-11" {
+		t.Errorf("Day() = %q, want 2026-03-11", got)
+	}
+}
+
+func TestDailyCounter_PersistsAcrossRestarts(t *testing.T) {
+	ctx := context.Background()
+	ts := newMockTimeSeriesStore()
+	now := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	c := newTestDailyCounter(&now)
+	c.SetTimeSeriesStore(ts)
+	c.Reserve("id-1", 4, 0)
+	if err := c.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error: %v", err)
+	}
+	now = now.Add(time.Hour)
+	c.Reserve("id-1", 2, 0)
+	c.Reserve("id-2", 1, 0)
+	if err := c.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error: %v", err)
+	}
+	// Nothing new to flush.
+	if err := c.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error: %v", err)
+	}
+	if n := len(ts.points[dailyUsageSeries]); n != 3 {
+		t.Errorf("persisted %d points, want 3", n)
+	}
+
+	restarted := newTestDailyCounter(&now)
+	restarted.SetTimeSeriesStore(ts)
+	if err := restarted.Load(ctx); err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if got := restarted.Used("id-1"); got != 6 {
+		t.Errorf("Used(id-1) after restart = %d, want 6", got)
+	}
+	if got := restarted.Used("id-2"); got != 1 {
+		t.Errorf("Used(id-2) after restart = %d, want 1", got)
+	}
+
+	// A restart on the next day starts from zero.
+	now = now.AddDate(0, 0, 1)
+	nextDay := newTestDailyCounter(&now)
+	nextDay.SetTimeSeriesStore(ts)
+	if err := nextDay.Load(ctx); err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if got := nextDay.Used("id-1"); got != 0 {
+		t.Errorf("Used(id-1) on the next day = %d, want 0", got)
+	}
+}
+
+func TestDailyCounter_FlushKeepsUsageWhenAppendFails(t *testing.T) {
+	ctx := context.Background()
+	ts := newMockTimeSeriesStore()
+	ts.appendErr = errors.New("disk full")
+	now := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	c := newTestDailyCounter(&now)
+	c.SetTimeSeriesStore(ts)
+	c.Reserve("id-1", 4, 0)
+	c.Reserve("id-2", 1, 0)
+	if err := c.Flush(ctx); err == nil {
+		t.Fatal("Flush() with a failing store returned nil error")
+	}
+
+	// Usage counted while the store was failing is flushed with the next one.
+	c.Reserve("id-1", 2, 0)
+	ts.appendErr = nil
+	if err := c.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error: %v", err)
+	}
+
+	restarted := newTestDailyCounter(&now)
+	restarted.SetTimeSeriesStore(ts)
+	if err := restarted.Load(ctx); err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if got := restarted.Used("id-1"); got != 6 {
+		t.Errorf("Used(id-1) after restart = %d, want 6", got)
+	}
+	if got := restarted.Used("id-2"); got != 1 {
+		t.Errorf("Used(id-2) after restart = %d, want 1", got)
+	}
+}
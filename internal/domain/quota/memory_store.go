@@ -33,14 +33,7 @@ func (s *MemoryQuotaStore) Get(_ context.Context, identityID string) (*QuotaConf
 	}
 
 	// Return a copy to prevent external mutation.
-	cp := *cfg
-	if cfg.ToolLimits != nil {
-		cp.ToolLimits = make(map[string]int64, len(cfg.ToolLimits))
-		for k, v := range cfg.ToolLimits {
-			cp.ToolLimits[k] = v
-		}
-	}
-	return &cp, nil
+	return cloneQuotaConfig(cfg), nil
 }
 
 // Put upserts a quota config (create or update).
@@ -49,14 +42,7 @@ func (s *MemoryQuotaStore) Put(_ context.Context, config *QuotaConfig) error {
 	defer s.mu.Unlock()
 
 	// Store a copy to prevent external mutation.
-	cp := *config
-	if config.ToolLimits != nil {
-		cp.ToolLimits = make(map[string]int64, len(config.ToolLimits))
-		for k, v := range config.ToolLimits {
-			cp.ToolLimits[k] = v
-		}
-	}
-	s.configs[config.IdentityID] = &cp
+	s.configs[config.IdentityID] = cloneQuotaConfig(config)
 	return nil
 }
 
@@ -77,14 +63,26 @@ func (s *MemoryQuotaStore) List(_ context.Context) ([]*QuotaConfig, error) {
 
 	result := make([]*QuotaConfig, 0, len(s.configs))
 	for _, cfg := range s.configs {
-		cp := *cfg
-		if cfg.ToolLimits != nil {
-			cp.ToolLimits = make(map[string]int64, len(cfg.ToolLimits))
-			for k, v := range cfg.ToolLimits {
-				cp.ToolLimits[k] = v
-			}
-		}
-		result = append(result, &cp)
+		result = append(result, cloneQuotaConfig(cfg))
 	}
 	return result, nil
 }
+
+// cloneQuotaConfig returns a deep copy of cfg.
+func cloneQuotaConfig(cfg *QuotaConfig) *QuotaConfig {
+	cp := *cfg
+	cp.ToolLimits = cloneInt64Map(cfg.ToolLimits)
+	cp.ToolWeights = cloneInt64Map(cfg.ToolWeights)
+	return &cp
+}
+
+func cloneInt64Map(m map[string]int64) map[string]int64 {
+	if m == nil {
+		return nil
+	}
+	cp := make(map[string]int64, len(m))
+	for k, v := range m {
+		cp[k] = v
+	}
+	return cp
+}
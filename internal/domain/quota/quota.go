@@ -4,6 +4,7 @@ package quota
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 // QuotaAction defines what happens when a quota limit is breached.
@@ -17,6 +18,9 @@ const (
 )
 
 // QuotaConfig specifies quota limits for an identity.
+// MaxCallsPerDay counts units rather than calls: a call uses the weight of
+// its tool in ToolWeights (default 1), so expensive tools can use up the
+// daily quota faster.
 type QuotaConfig struct {
 	IdentityID           string            `json:"identity_id"`
 	MaxCallsPerSession   int64             `json:"max_calls_per_session,omitempty"`
@@ -25,6 +29,7 @@ type QuotaConfig struct {
 	MaxCallsPerMinute    int64             `json:"max_calls_per_minute,omitempty"`
 	MaxCallsPerDay       int64             `json:"max_calls_per_day,omitempty"`
 	ToolLimits           map[string]int64  `json:"tool_limits,omitempty"`
+	ToolWeights          map[string]int64  `json:"tool_weights,omitempty"`
 	Action               QuotaAction       `json:"action"`
 	Enabled              bool              `json:"enabled"`
 }
@@ -36,10 +41,10 @@ func (c *QuotaConfig) Validate() error {
 		return errors.New("identity_id is required")
 	}
 
-	// M-22: MaxCallsPerDay is not enforced in Check() — reject if set so
-	// operators don't mistakenly believe they have daily-limit protection.
-	if c.MaxCallsPerDay != 0 {
-		return errors.New("max_calls_per_day is not yet supported")
+	for toolName, weight := range c.ToolWeights {
+		if weight < 0 {
+			return fmt.Errorf("tool weight for %s must be non-negative", toolName)
+		}
 	}
 
 	if c.Enabled {
@@ -51,6 +56,7 @@ func (c *QuotaConfig) Validate() error {
 			c.MaxWritesPerSession > 0 ||
 			c.MaxDeletesPerSession > 0 ||
 			c.MaxCallsPerMinute > 0 ||
+			c.MaxCallsPerDay > 0 ||
 			len(c.ToolLimits) > 0
 
 		if !hasLimit {
@@ -61,6 +67,21 @@ func (c *QuotaConfig) Validate() error {
 	return nil
 }
 
+// ToolWeight returns the units of MaxCallsPerDay a call to toolName uses.
+// Like ToolLimits, weights match the full name or the bare name of a
+// namespaced tool ("desktop/read_file" matches "read_file").
+func (c *QuotaConfig) ToolWeight(toolName string) int64 {
+	if w, ok := c.ToolWeights[toolName]; ok {
+		return w
+	}
+	if idx := strings.Index(toolName, "/"); idx >= 0 {
+		if w, ok := c.ToolWeights[toolName[idx+1:]]; ok {
+			return w
+		}
+	}
+	return 1
+}
+
 // QuotaCheckResult is the outcome of checking a call against quota limits.
 type QuotaCheckResult struct {
 	Allowed    bool             `json:"allowed"`
@@ -75,6 +96,7 @@ type QuotaUsageSummary struct {
 	WriteCalls  int64 `json:"write_calls"`
 	DeleteCalls int64 `json:"delete_calls"`
 	WindowCalls int64 `json:"window_calls"`
+	DailyUnits  int64 `json:"daily_units"`
 }
 
 // ErrQuotaNotFound is returned when no quota config exists for an identity.
@@ -69,6 +69,28 @@ func TestQuotaConfig_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "valid config with daily limit only",
+			config: QuotaConfig{
+				IdentityID:     "id-1",
+				MaxCallsPerDay: 500,
+				ToolWeights:    map[string]int64{"web_search": 5},
+				Action:         QuotaActionDeny,
+				Enabled:        true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid — negative tool weight",
+			config: QuotaConfig{
+				IdentityID:     "id-1",
+				MaxCallsPerDay: 500,
+				ToolWeights:    map[string]int64{"web_search": -1},
+				Action:         QuotaActionDeny,
+				Enabled:        true,
+			},
+			wantErr: true,
+		},
 		{
 			name: "valid config with tool limits only",
 			config: QuotaConfig{
@@ -90,3 +112,22 @@ func TestQuotaConfig_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestQuotaConfig_ToolWeight(t *testing.T) {
+	cfg := QuotaConfig{ToolWeights: map[string]int64{"web_search": 5, "desktop/read_file": 0}}
+	tests := []struct {
+		tool string
+		want int64
+	}{
+		{"web_search", 5},
+		{"search/web_search", 5},
+		{"desktop/read_file", 0},
+		{"read_file", 1},
+		{"other", 1},
+	}
+	for _, tt := range tests {
+		if got := cfg.ToolWeight(tt.tool); got != tt.want {
+			t.Errorf("ToolWeight(%q) = %d, want %d", tt.tool, got, tt.want)
+		}
+	}
+}
@@ -24,6 +24,7 @@ type QuotaService struct {
 	store      QuotaStore
 	tracker    *session.SessionTracker
 	classifier session.ToolCallClassifier
+	daily      *DailyCounter // optional, nil = max_calls_per_day not enforced
 	pendingMu  sync.Mutex
 	pending    map[string]*int64
 }
@@ -38,6 +39,17 @@ func NewQuotaService(store QuotaStore, tracker *session.SessionTracker) *QuotaSe
 	}
 }
 
+// SetDailyCounter sets the counter enforcing MaxCallsPerDay. It must be
+// called before the service is used.
+func (s *QuotaService) SetDailyCounter(c *DailyCounter) {
+	s.daily = c
+}
+
+// DailyCounter returns the counter enforcing MaxCallsPerDay, or nil.
+func (s *QuotaService) DailyCounter() *DailyCounter {
+	return s.daily
+}
+
 func (s *QuotaService) getPendingCounter(sessionID string) *int64 {
 	s.pendingMu.Lock()
 	defer s.pendingMu.Unlock()
@@ -52,7 +64,13 @@ func (s *QuotaService) getPendingCounter(sessionID string) *int64 {
 
 // Check evaluates whether a tool call is allowed under the identity's quota.
 // It compares current session usage (plus the pending call) against configured limits.
-// Returns Allowed=true if no config exists, config is disabled, or no session data.
+// Returns Allowed=true if no config exists or config is disabled. Session
+// limits are skipped when there is no session data yet.
+//
+// Daily usage is counted here, when the call is admitted: an allowed call
+// (or a call in warn mode) uses the weight of its tool, whether or not it
+// later succeeds. The daily limit is checked last and atomically, so
+// concurrent calls from several sessions cannot overshoot it.
 //
 // Note: the pending counter is a single total per session (not per call type).
 // This means per-type checks (writes, deletes) may over-count when multiple
@@ -89,15 +107,50 @@ func (s *QuotaService) Check(ctx context.Context, identityID, sessionID, toolNam
 		}
 	}()
 
+	var violations []string
+	var warnings []string
+
 	// Get current session usage
 	usage, found := s.tracker.GetUsage(sessionID)
-	if !found {
-		if pendingCount <= 1 {
-			return result
+	if found || pendingCount > 1 {
+		if !found {
+			usage = session.SessionUsage{CallsByToolName: make(map[string]int64)}
+		}
+		s.checkSession(cfg, usage, pendingCount, toolName, &result, &violations, &warnings)
+	}
+
+	// Check MaxCallsPerDay last: reserving units for a call that another
+	// limit denies would use up the daily quota for nothing.
+	if s.daily != nil && (len(violations) == 0 || cfg.Action != QuotaActionDeny) {
+		var limit int64
+		if cfg.Action == QuotaActionDeny {
+			limit = cfg.MaxCallsPerDay
+		}
+		next, _ := s.daily.Reserve(identityID, cfg.ToolWeight(toolName), limit)
+		result.Usage.DailyUnits = next
+		if cfg.MaxCallsPerDay > 0 {
+			s.checkLimit("calls per day", next, cfg.MaxCallsPerDay, &violations, &warnings)
+		}
+	}
+
+	// Apply action
+	if len(violations) > 0 {
+		if cfg.Action == QuotaActionDeny {
+			result.Allowed = false
+			result.DenyReason = violations[0]
+		} else {
+			// Warn mode — allow but add warnings
+			warnings = append(warnings, violations...)
 		}
-		usage = session.SessionUsage{CallsByToolName: make(map[string]int64)}
 	}
 
+	result.Warnings = warnings
+	return result
+}
+
+// checkSession checks the session limits of cfg for the pending call and
+// fills the usage summary of result.
+func (s *QuotaService) checkSession(cfg *QuotaConfig, usage session.SessionUsage, pendingCount int64, toolName string, result *QuotaCheckResult, violations, warnings *[]string) {
 	// Fill usage summary
 	result.Usage = QuotaUsageSummary{
 		TotalCalls:  usage.TotalCalls,
@@ -109,31 +162,28 @@ func (s *QuotaService) Check(ctx context.Context, identityID, sessionID, toolNam
 	// Classify the pending tool call
 	callType := s.classifier(toolName)
 
-	var violations []string
-	var warnings []string
-
 	// Check MaxCallsPerSession
 	if cfg.MaxCallsPerSession > 0 {
 		next := usage.TotalCalls + pendingCount
-		s.checkLimit("total calls per session", next, cfg.MaxCallsPerSession, &violations, &warnings)
+		s.checkLimit("total calls per session", next, cfg.MaxCallsPerSession, violations, warnings)
 	}
 
 	// Check MaxWritesPerSession
 	if cfg.MaxWritesPerSession > 0 && callType == session.CallTypeWrite {
 		next := usage.WriteCalls + pendingCount
-		s.checkLimit("writes per session", next, cfg.MaxWritesPerSession, &violations, &warnings)
+		s.checkLimit("writes per session", next, cfg.MaxWritesPerSession, violations, warnings)
 	}
 
 	// Check MaxDeletesPerSession
 	if cfg.MaxDeletesPerSession > 0 && callType == session.CallTypeDelete {
 		next := usage.DeleteCalls + pendingCount
-		s.checkLimit("deletes per session", next, cfg.MaxDeletesPerSession, &violations, &warnings)
+		s.checkLimit("deletes per session", next, cfg.MaxDeletesPerSession, violations, warnings)
 	}
 
 	// Check MaxCallsPerMinute (sliding window)
 	if cfg.MaxCallsPerMinute > 0 {
 		next := usage.WindowCalls + pendingCount
-		s.checkLimit("calls per minute", next, cfg.MaxCallsPerMinute, &violations, &warnings)
+		s.checkLimit("calls per minute", next, cfg.MaxCallsPerMinute, violations, warnings)
 	}
 
 	// Check per-tool limits.
@@ -154,22 +204,8 @@ func (s *QuotaService) Check(ctx context.Context, identityID, sessionID, toolNam
 			callCount += usage.CallsByToolName[bareToolName]
 		}
 		next := callCount + pendingCount
-		s.checkLimit(fmt.Sprintf("calls for tool %q", bareToolName), next, toolLimit, &violations, &warnings)
+		s.checkLimit(fmt.Sprintf("calls for tool %q", bareToolName), next, toolLimit, violations, warnings)
 	}
-
-	// Apply action
-	if len(violations) > 0 {
-		if cfg.Action == QuotaActionDeny {
-			result.Allowed = false
-			result.DenyReason = violations[0]
-		} else {
-			// Warn mode — allow but add warnings
-			warnings = append(warnings, violations...)
-		}
-	}
-
-	result.Warnings = warnings
-	return result
 }
 
 // checkLimit compares a next value against a limit and records violations/warnings.
@@ -341,3 +341,101 @@ func TestQuotaService_Check_NoSession_Allows(t *testing.T) {
 		t.Error("Check() Allowed = false, want true (no session data yet)")
 	}
 }
+
+func TestQuotaService_Check_ExceedsDailyLimit_Denies(t *testing.T) {
+	store := newMockQuotaStore()
+	tracker := session.NewSessionTracker(time.Minute, session.DefaultClassifier())
+	svc := NewQuotaService(store, tracker)
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	daily := newTestDailyCounter(&now)
+	svc.SetDailyCounter(daily)
+
+	_ = store.Put(context.Background(), &QuotaConfig{
+		IdentityID:     "id-1",
+		MaxCallsPerDay: 10,
+		ToolWeights:    map[string]int64{"web_search": 4, "read_file": 0},
+		Action:         QuotaActionDeny,
+		Enabled:        true,
+	})
+
+	// The daily limit spans sessions, and weights count units.
+	for i, sess := range []string{"sess-1", "sess-2"} {
+		if result := svc.Check(context.Background(), "id-1", sess, "search/web_search"); !result.Allowed {
+			t.Fatalf("call %d denied: %s", i, result.DenyReason)
+		}
+	}
+	result := svc.Check(context.Background(), "id-1", "sess-3", "web_search")
+	if result.Allowed {
+		t.Fatal("Check() Allowed = true, want false (12/10 units)")
+	}
+	if result.DenyReason != "calls per day: 12/10" {
+		t.Errorf("DenyReason = %q, want %q", result.DenyReason, "calls per day: 12/10")
+	}
+	if got := daily.Used("id-1"); got != 8 {
+		t.Errorf("daily usage = %d, want 8 (denied calls use nothing)", got)
+	}
+
+	// Cheaper and free calls still fit.
+	if result := svc.Check(context.Background(), "id-1", "sess-3", "list_files"); !result.Allowed || result.Usage.DailyUnits != 9 {
+		t.Errorf("Check(list_files) = %+v, want allowed with 9 units", result)
+	}
+	if result := svc.Check(context.Background(), "id-1", "sess-3", "read_file"); !result.Allowed {
+		t.Errorf("Check(read_file) denied: %s", result.DenyReason)
+	}
+
+	// The quota resets at midnight UTC.
+	now = now.Add(12 * time.Hour)
+	if result := svc.Check(context.Background(), "id-1", "sess-3", "web_search"); !result.Allowed {
+		t.Errorf("Check() on the next day denied: %s", result.DenyReason)
+	}
+}
+
+func TestQuotaService_Check_DailyLimitNotUsedBySessionDenial(t *testing.T) {
+	store := newMockQuotaStore()
+	tracker := session.NewSessionTracker(time.Minute, session.DefaultClassifier())
+	svc := NewQuotaService(store, tracker)
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	daily := newTestDailyCounter(&now)
+	svc.SetDailyCounter(daily)
+
+	_ = store.Put(context.Background(), &QuotaConfig{
+		IdentityID:         "id-1",
+		MaxCallsPerSession: 1,
+		MaxCallsPerDay:     100,
+		Action:             QuotaActionDeny,
+		Enabled:            true,
+	})
+	tracker.RecordCall("sess-1", "read_file", "id-1", "user", nil)
+
+	result := svc.Check(context.Background(), "id-1", "sess-1", "read_file")
+	if result.Allowed {
+		t.Fatal("Check() Allowed = true, want false (session limit)")
+	}
+	if got := daily.Used("id-1"); got != 0 {
+		t.Errorf("daily usage = %d, want 0", got)
+	}
+}
+
+func TestQuotaService_Check_DailyLimitWarnMode(t *testing.T) {
+	store := newMockQuotaStore()
+	tracker := session.NewSessionTracker(time.Minute, session.DefaultClassifier())
+	svc := NewQuotaService(store, tracker)
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	svc.SetDailyCounter(newTestDailyCounter(&now))
+
+	_ = store.Put(context.Background(), &QuotaConfig{
+		IdentityID:     "id-1",
+		MaxCallsPerDay: 1,
+		Action:         QuotaActionWarn,
+		Enabled:        true,
+	})
+
+	svc.Check(context.Background(), "id-1", "sess-1", "read_file")
+	result := svc.Check(context.Background(), "id-1", "sess-1", "read_file")
+	if !result.Allowed {
+		t.Fatal("Check() Allowed = false, want true (warn mode)")
+	}
+	if len(result.Warnings) == 0 || result.Warnings[len(result.Warnings)-1] != "calls per day: 2/1" {
+		t.Errorf("Warnings = %v, want the daily violation", result.Warnings)
+	}
+}
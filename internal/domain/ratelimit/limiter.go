@@ -1,6 +1,9 @@
 package ratelimit
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // RateLimiter is the interface for rate limiting operations.
 //
@@ -23,3 +26,36 @@ type RateLimiter interface {
 	// the next request will be allowed.
 	Allow(ctx context.Context, key string, config RateLimitConfig) (RateLimitResult, error)
 }
+
+// Inspector is implemented by rate limiters that can report the state of a
+// key without counting a request.
+type Inspector interface {
+	// Peek returns what Allow would return for key under config, without
+	// changing the counter. Remaining is the number of requests allowed
+	// right now.
+	Peek(ctx context.Context, key string, config RateLimitConfig) (RateLimitResult, error)
+}
+
+// PeekResult is the result of a Peek at a bucket whose theoretical arrival
+// time is wait ahead of now, for limiters using GCRA.
+func PeekResult(wait time.Duration, config RateLimitConfig) RateLimitResult {
+	if config.Rate <= 0 {
+		config.Rate = 1
+	}
+	if config.Burst <= 0 {
+		config.Burst = config.Rate
+	}
+	emission := config.Period / time.Duration(config.Rate)
+	if emission <= 0 {
+		emission = time.Microsecond
+	}
+	burstOffset := time.Duration(config.Burst) * emission
+	if wait > burstOffset {
+		return RateLimitResult{RetryAfter: wait - burstOffset, ResetAfter: wait}
+	}
+	remaining := int((burstOffset-wait)/emission) + 1
+	if remaining > config.Burst {
+		remaining = config.Burst
+	}
+	return RateLimitResult{Allowed: true, Remaining: remaining, ResetAfter: wait}
+}
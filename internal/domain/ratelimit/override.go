@@ -0,0 +1,151 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// KeyTypeTool is for per-user limits on a tool set by a tool override.
+const KeyTypeTool KeyType = "tool"
+
+// ErrInvalidOverride is returned by Override.Validate.
+var ErrInvalidOverride = errors.New("invalid rate limit override")
+
+// maxOverrideRate bounds override rates (requests per minute).
+const maxOverrideRate = 1_000_000
+
+// Override changes the per-user rate limit for one identity, or limits the
+// calls of a tool per user. With Tool empty it replaces the user rate of
+// Identity. With Tool set it adds a limit on calls of matching tools, for
+// every identity or only for Identity.
+type Override struct {
+	// ID identifies the override; tool overrides key their buckets by it.
+	ID string `json:"id"`
+	// Identity is an identity ID or name. Empty applies a tool override to
+	// every identity.
+	Identity string `json:"identity,omitempty"`
+	// Tool is a tool name or glob pattern (e.g. "send_*").
+	Tool string `json:"tool,omitempty"`
+	// Rate is the number of requests allowed per minute.
+	Rate int `json:"rate"`
+	// Burst is the largest burst allowed. Defaults to Rate.
+	Burst int `json:"burst,omitempty"`
+	// ReadOnly is true for overrides from the YAML config.
+	ReadOnly bool `json:"read_only"`
+}
+
+// Validate checks that the override names an identity or a tool and has a
+// sensible rate.
+func (o Override) Validate() error {
+	if strings.TrimSpace(o.Identity) == "" && strings.TrimSpace(o.Tool) == "" {
+		return fmt.Errorf("%w: identity or tool is required", ErrInvalidOverride)
+	}
+	if o.Tool != "" {
+		if _, err := path.Match(o.Tool, ""); err != nil {
+			return fmt.Errorf("%w: invalid tool pattern %q", ErrInvalidOverride, o.Tool)
+		}
+	}
+	if o.Rate < 1 || o.Rate > maxOverrideRate {
+		return fmt.Errorf("%w: rate must be between 1 and %d requests per minute", ErrInvalidOverride, maxOverrideRate)
+	}
+	if o.Burst < 0 || o.Burst > maxOverrideRate {
+		return fmt.Errorf("%w: burst must be between 0 and %d", ErrInvalidOverride, maxOverrideRate)
+	}
+	return nil
+}
+
+// Config returns the limiter configuration of the override.
+func (o Override) Config() RateLimitConfig {
+	return RateLimitConfig{Rate: o.Rate, Burst: o.Burst, Period: time.Minute}
+}
+
+// Overrides is an immutable set of overrides, indexed for lookups on the
+// request path.
+type Overrides struct {
+	identity map[string]Override // identity ID or name -> identity override
+	tool     []Override
+}
+
+// NewOverrides indexes list. When two identity overrides name the same
+// identity, the first wins.
+func NewOverrides(list []Override) *Overrides {
+	s := &Overrides{identity: make(map[string]Override)}
+	for _, o := range list {
+		if o.Tool == "" {
+			if _, dup := s.identity[o.Identity]; !dup {
+				s.identity[o.Identity] = o
+			}
+			continue
+		}
+		s.tool = append(s.tool, o)
+	}
+	return s
+}
+
+// Empty reports whether the set holds no override.
+func (s *Overrides) Empty() bool {
+	return s == nil || (len(s.identity) == 0 && len(s.tool) == 0)
+}
+
+// ForIdentity returns the override of the user rate for the identity. An
+// override naming the identity ID wins over one naming its display name.
+func (s *Overrides) ForIdentity(identityID, identityName string) (Override, bool) {
+	if s == nil {
+		return Override{}, false
+	}
+	if o, ok := s.identity[identityID]; ok {
+		return o, true
+	}
+	if identityName != "" {
+		if o, ok := s.identity[identityName]; ok {
+			return o, true
+		}
+	}
+	return Override{}, false
+}
+
+// ForTool returns the tool override applying to a call of tool by the
+// identity. The most specific one wins: an override naming the identity
+// over one for every identity, then an exact tool name over a pattern.
+func (s *Overrides) ForTool(identityID, identityName, tool string) (Override, bool) {
+	if s == nil || tool == "" {
+		return Override{}, false
+	}
+	best, bestScore := Override{}, -1
+	for _, o := range s.tool {
+		if o.Identity != "" && o.Identity != identityID && o.Identity != identityName {
+			continue
+		}
+		score := 0
+		if o.Tool == tool {
+			score++
+		} else if matched, _ := path.Match(o.Tool, tool); !matched {
+			continue
+		}
+		if o.Identity != "" {
+			score += 2
+		}
+		if score > bestScore {
+			best, bestScore = o, score
+		}
+	}
+	return best, bestScore >= 0
+}
+
+// ToolsFor returns the tool overrides that can apply to calls of the
+// identity: those naming it and those for every identity.
+func (s *Overrides) ToolsFor(identityID, identityName string) []Override {
+	if s == nil {
+		return nil
+	}
+	var list []Override
+	for _, o := range s.tool {
+		if o.Identity == "" || o.Identity == identityID || (identityName != "" && o.Identity == identityName) {
+			list = append(list, o)
+		}
+	}
+	return list
+}
@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOverride_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		o       Override
+		wantErr bool
+	}{
+		{"identity", Override{Identity: "ci-bot", Rate: 1000}, false},
+		{"tool", Override{Tool: "send_email", Rate: 10}, false},
+		{"tool glob for identity", Override{Identity: "ci-bot", Tool: "send_*", Rate: 10, Burst: 5}, false},
+		{"neither identity nor tool", Override{Rate: 10}, true},
+		{"invalid glob", Override{Tool: "send_[", Rate: 10}, true},
+		{"zero rate", Override{Tool: "send_email"}, true},
+		{"rate too high", Override{Tool: "send_email", Rate: maxOverrideRate + 1}, true},
+		{"negative burst", Override{Tool: "send_email", Rate: 10, Burst: -1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.o.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidOverride) {
+				t.Errorf("error %v does not wrap ErrInvalidOverride", err)
+			}
+		})
+	}
+}
+
+func TestOverrides_ForIdentity(t *testing.T) {
+	s := NewOverrides([]Override{
+		{ID: "by-name", Identity: "ci-bot", Rate: 1000},
+		{ID: "by-id", Identity: "id-42", Rate: 500},
+		{ID: "dup", Identity: "ci-bot", Rate: 1},
+		{ID: "tool", Identity: "alice", Tool: "send_email", Rate: 10},
+	})
+
+	if o, ok := s.ForIdentity("id-1", "ci-bot"); !ok || o.ID != "by-name" {
+		t.Errorf("by name = %+v, %v; want by-name", o, ok)
+	}
+	if o, ok := s.ForIdentity("id-42", "ci-bot"); !ok || o.ID != "by-id" {
+		t.Errorf("by id = %+v, %v; want by-id to win over name", o, ok)
+	}
+	if _, ok := s.ForIdentity("id-7", "alice"); ok {
+		t.Error("a tool override must not replace the user rate")
+	}
+	var none *Overrides
+	if _, ok := none.ForIdentity("id-1", "ci-bot"); ok || !none.Empty() {
+		t.Error("nil overrides must match nothing")
+	}
+}
+
+func TestOverrides_ForTool(t *testing.T) {
+	s := NewOverrides([]Override{
+		{ID: "all-send", Tool: "send_*", Rate: 30},
+		{ID: "all-email", Tool: "send_email", Rate: 10},
+		{ID: "bot-send", Identity: "ci-bot", Tool: "send_*", Rate: 100},
+	})
+
+	tests := []struct {
+		name, identity, tool, want string
+	}{
+		{"exact name wins over glob", "alice", "send_email", "all-email"},
+		{"glob", "alice", "send_sms", "all-send"},
+		{"identity wins over exact name", "ci-bot", "send_email", "bot-send"},
+		{"no match", "alice", "read_file", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, ok := s.ForTool("id-"+tt.identity, tt.identity, tt.tool)
+			if tt.want == "" {
+				if ok {
+					t.Fatalf("ForTool matched %q, want none", o.ID)
+				}
+				return
+			}
+			if !ok || o.ID != tt.want {
+				t.Errorf("ForTool = %q, %v; want %q", o.ID, ok, tt.want)
+			}
+		})
+	}
+}
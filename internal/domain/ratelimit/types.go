@@ -44,6 +44,9 @@ const (
 
 	// KeyTypeUser is for user/API key-based rate limiting.
 	KeyTypeUser KeyType = "user"
+
+	// KeyTypeSession is for per-session rate limiting.
+	KeyTypeSession KeyType = "session"
 )
 
 // keyPrefix is the base prefix for all rate limit keys.
@@ -88,4 +88,7 @@ func TestKeyTypeConstants(t *testing.T) {
 	if KeyTypeUser != "user" {
 		t.Errorf("KeyTypeUser = %q, want %q", KeyTypeUser, "user")
 	}
+	if KeyTypeSession != "session" {
+		t.Errorf("KeyTypeSession = %q, want %q", KeyTypeSession, "session")
+	}
 }
@@ -12,6 +12,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
 )
 
 // validSessionIDPattern allows only safe characters in session IDs.
@@ -775,6 +777,11 @@ func (r *FileRecorder) reapStale() {
 func redactValue(v interface{}, patterns []*regexp.Regexp) interface{} {
 	switch val := v.(type) {
 	case string:
+		// Encrypted arguments are already unreadable without the argument key;
+		// a pattern matching the ciphertext must not destroy it.
+		if audit.IsEncryptedArg(val) {
+			return val
+		}
 		return applyRedaction(val, patterns)
 	case map[string]interface{}:
 		result := make(map[string]interface{}, len(val))
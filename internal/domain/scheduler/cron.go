@@ -0,0 +1,241 @@
+// Package scheduler runs recurring in-process jobs on cron schedules, so
+// maintenance tasks (audit archival, state snapshots, reports, ...) do not
+// need an external cron hitting admin endpoints.
+package scheduler
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule computes when a job runs next.
+type Schedule interface {
+	// Next returns the first activation strictly after t, or the zero time
+	// when the schedule never fires again.
+	Next(t time.Time) time.Time
+}
+
+// maxSearchYears bounds the search for the next activation of expressions
+// that can never match (such as "0 0 31 2 *").
+const maxSearchYears = 5
+
+// Parse parses a schedule expression. Accepted forms:
+//
+//   - five-field cron: "minute hour day-of-month month day-of-week", with
+//     "*", lists ("1,15"), ranges ("1-5"), steps ("*/10", "0-30/5") and
+//     three-letter month and day names ("jan", "mon")
+//   - descriptors: @yearly (@annually), @monthly, @weekly, @daily
+//     (@midnight) and @hourly
+//   - a fixed interval: "@every 30m"
+//
+// Cron schedules are evaluated in UTC. As in standard cron, when both the
+// day of month and the day of week are restricted, a day matching either
+// one fires.
+func Parse(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, fmt.Errorf("empty schedule")
+	}
+	if strings.HasPrefix(expr, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expr, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid @every interval: %w", err)
+		}
+		if d < time.Second {
+			return nil, fmt.Errorf("@every interval must be at least 1s")
+		}
+		return everySchedule{interval: d}, nil
+	}
+	if strings.HasPrefix(expr, "@") {
+		switch strings.ToLower(expr) {
+		case "@yearly", "@annually":
+			expr = "0 0 1 1 *"
+		case "@monthly":
+			expr = "0 0 1 * *"
+		case "@weekly":
+			expr = "0 0 * * 0"
+		case "@daily", "@midnight":
+			expr = "0 0 * * *"
+		case "@hourly":
+			expr = "0 * * * *"
+		default:
+			return nil, fmt.Errorf("unknown schedule descriptor %q", expr)
+		}
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields (minute hour day-of-month month day-of-week)", expr)
+	}
+	var s cronSchedule
+	var err error
+	if s.minute, err = parseField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if s.hour, err = parseField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if s.dom, err = parseField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("day of month: %w", err)
+	}
+	if s.month, err = parseField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	if s.dow, err = parseField(fields[4], 0, 7, dayNames); err != nil {
+		return nil, fmt.Errorf("day of week: %w", err)
+	}
+	// 7 is an alias for Sunday.
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	s.domStar = fields[2] == "*" || fields[2] == "?"
+	s.dowStar = fields[4] == "*" || fields[4] == "?"
+	return s, nil
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var dayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// parseField parses one cron field into a bit set of the allowed values.
+func parseField(field string, min, max int, names map[string]int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		if part == "" {
+			return 0, fmt.Errorf("empty list element in %q", field)
+		}
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepPart)
+			}
+			step = n
+		}
+
+		lo, hi := min, max
+		switch {
+		case rangePart == "*" || rangePart == "?":
+		case strings.Contains(rangePart, "-"):
+			a, b, _ := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = parseValue(a, names); err != nil {
+				return 0, err
+			}
+			if hi, err = parseValue(b, names); err != nil {
+				return 0, err
+			}
+		default:
+			v, err := parseValue(rangePart, names)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			if !hasStep {
+				hi = v
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("value %q out of range %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+func parseValue(s string, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	return v, nil
+}
+
+// cronSchedule is a parsed five-field cron expression. Each field is a bit
+// set of the values it allows.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+}
+
+// Next implements Schedule.
+func (s cronSchedule) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(maxSearchYears, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			next := nextSetBit(s.minute, t.Minute()+1)
+			if next < 0 {
+				t = t.Truncate(time.Hour).Add(time.Hour)
+			} else {
+				t = t.Truncate(time.Hour).Add(time.Duration(next) * time.Minute)
+			}
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s cronSchedule) dayMatches(t time.Time) bool {
+	domOK := s.dom&(1<<uint(t.Day())) != 0
+	dowOK := s.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case s.domStar && s.dowStar:
+		return true
+	case s.domStar:
+		return dowOK
+	case s.dowStar:
+		return domOK
+	default:
+		return domOK || dowOK
+	}
+}
+
+// nextSetBit returns the lowest set bit of set at or above from, or -1.
+func nextSetBit(set uint64, from int) int {
+	if from >= 64 {
+		return -1
+	}
+	rest := set >> uint(from)
+	if rest == 0 {
+		return -1
+	}
+	return from + bits.TrailingZeros64(rest)
+}
+
+// everySchedule fires at a fixed interval.
+type everySchedule struct {
+	interval time.Duration
+}
+
+// Next implements Schedule.
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(s.interval)
+}
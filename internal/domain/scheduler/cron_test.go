@@ -0,0 +1,63 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse_Next(t *testing.T) {
+	base := time.Date(2026, 3, 14, 10, 17, 30, 0, time.UTC) // a Saturday
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 3, 14, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 3, 14, 10, 30, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2026, 3, 15, 3, 0, 0, 0, time.UTC)},
+		{"30 2 1 * *", time.Date(2026, 4, 1, 2, 30, 0, 0, time.UTC)},
+		{"0 9 * * mon-fri", time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1 jan *", time.Date(2027, 1, 1, 12, 0, 0, 0, time.UTC)},
+		{"0 0 13 * fri", time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC)}, // day of month OR day of week
+		{"5,45 10 * * *", time.Date(2026, 3, 14, 10, 45, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 3, 14, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 90m", base.Add(90 * time.Minute)},
+		{"0 0 31 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.expr, err)
+			}
+			if got := s.Next(base); !got.Equal(tt.want) {
+				t.Errorf("Next = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"1,,2 * * * *",
+		"@sometimes",
+		"@every soon",
+		"@every 10ms",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) should fail", expr)
+		}
+	}
+}
@@ -0,0 +1,374 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrJobNotFound is returned for a job name that was never registered.
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobRunning is returned when a job is triggered while it is running.
+	ErrJobRunning = errors.New("job is already running")
+)
+
+// Run outcomes.
+const (
+	RunStatusOK    = "ok"
+	RunStatusError = "error"
+)
+
+// Run triggers.
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+// historySize is how many past runs are kept per job.
+const historySize = 10
+
+// JobFunc does the work of a job. The returned message summarizes what was
+// done ("3 files compressed") and is shown in the job's run history.
+type JobFunc func(ctx context.Context) (string, error)
+
+// Job is a recurring task.
+type Job struct {
+	// Name identifies the job in configuration and the admin API.
+	Name string
+	// Description says what the job does.
+	Description string
+	// Schedule is a cron expression or descriptor accepted by Parse.
+	Schedule string
+	// Enabled is false for jobs that only run when triggered by hand.
+	Enabled bool
+	// Timeout bounds a single run. Zero means no limit.
+	Timeout time.Duration
+	// Run does the work.
+	Run JobFunc
+}
+
+// RunResult is the outcome of one job run.
+type RunResult struct {
+	Trigger    string    `json:"trigger"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	DurationMs int64     `json:"duration_ms"`
+	Status     string    `json:"status"`
+	Message    string    `json:"message,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// JobStatus is a snapshot of a job's schedule and recent runs.
+type JobStatus struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Schedule    string      `json:"schedule"`
+	Enabled     bool        `json:"enabled"`
+	Running     bool        `json:"running"`
+	NextRun     *time.Time  `json:"next_run,omitempty"`
+	LastRun     *RunResult  `json:"last_run,omitempty"`
+	Runs        int64       `json:"runs"`
+	Failures    int64       `json:"failures"`
+	History     []RunResult `json:"history,omitempty"`
+}
+
+// RunObserver is told about every finished run, e.g. to publish failures
+// on the event bus.
+type RunObserver func(job string, result RunResult)
+
+type jobState struct {
+	job      Job
+	schedule Schedule
+	next     time.Time
+	running  bool
+	runs     int64
+	failures int64
+	history  []RunResult // newest last
+}
+
+// Scheduler runs registered jobs on their schedules. Runs of the same job
+// never overlap: an activation that comes due while the previous run is
+// still going is skipped.
+type Scheduler struct {
+	mu       sync.Mutex
+	jobs     map[string]*jobState
+	wake     chan struct{}
+	baseCtx  context.Context
+	wg       sync.WaitGroup
+	observer RunObserver
+	logger   *slog.Logger
+	now      func() time.Time
+}
+
+// New creates a Scheduler. Jobs are registered with Register and run once
+// Run is called.
+func New(logger *slog.Logger) *Scheduler {
+	return &Scheduler{
+		jobs:    make(map[string]*jobState),
+		wake:    make(chan struct{}, 1),
+		baseCtx: context.Background(),
+		logger:  logger,
+		now:     time.Now,
+	}
+}
+
+// SetRunObserver sets the callback told about finished runs.
+func (s *Scheduler) SetRunObserver(o RunObserver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.observer = o
+}
+
+// Register adds a job. The schedule is validated even for disabled jobs so
+// configuration mistakes surface at startup.
+func (s *Scheduler) Register(job Job) error {
+	if job.Name == "" {
+		return fmt.Errorf("job name is required")
+	}
+	if job.Run == nil {
+		return fmt.Errorf("job %q has no run function", job.Name)
+	}
+	sched, err := Parse(job.Schedule)
+	if err != nil {
+		return fmt.Errorf("job %q: invalid schedule %q: %w", job.Name, job.Schedule, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.jobs[job.Name]; exists {
+		return fmt.Errorf("job %q is already registered", job.Name)
+	}
+	st := &jobState{job: job, schedule: sched}
+	if job.Enabled {
+		st.next = sched.Next(s.now())
+	}
+	s.jobs[job.Name] = st
+	s.signal()
+	return nil
+}
+
+// Update changes the schedule and enabled flag of a registered job.
+func (s *Scheduler) Update(name, schedule string, enabled bool) error {
+	sched, err := Parse(schedule)
+	if err != nil {
+		return fmt.Errorf("invalid schedule %q: %w", schedule, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.jobs[name]
+	if !ok {
+		return ErrJobNotFound
+	}
+	st.job.Schedule = schedule
+	st.job.Enabled = enabled
+	st.schedule = sched
+	st.next = time.Time{}
+	if enabled {
+		st.next = sched.Next(s.now())
+	}
+	s.signal()
+	return nil
+}
+
+// Trigger starts a run of the job now, outside its schedule. It returns
+// without waiting for the run to finish.
+func (s *Scheduler) Trigger(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.jobs[name]
+	if !ok {
+		return ErrJobNotFound
+	}
+	if st.running {
+		return ErrJobRunning
+	}
+	s.startLocked(st, TriggerManual)
+	return nil
+}
+
+// Get returns the status of one job.
+func (s *Scheduler) Get(name string) (JobStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.jobs[name]
+	if !ok {
+		return JobStatus{}, ErrJobNotFound
+	}
+	status := st.statusLocked()
+	status.History = make([]RunResult, 0, len(st.history))
+	for i := len(st.history) - 1; i >= 0; i-- {
+		status.History = append(status.History, st.history[i])
+	}
+	return status, nil
+}
+
+// List returns the status of every job, sorted by name.
+func (s *Scheduler) List() []JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]JobStatus, 0, len(s.jobs))
+	for _, st := range s.jobs {
+		result = append(result, st.statusLocked())
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+func (st *jobState) statusLocked() JobStatus {
+	status := JobStatus{
+		Name:        st.job.Name,
+		Description: st.job.Description,
+		Schedule:    st.job.Schedule,
+		Enabled:     st.job.Enabled,
+		Running:     st.running,
+		Runs:        st.runs,
+		Failures:    st.failures,
+	}
+	if !st.next.IsZero() {
+		next := st.next
+		status.NextRun = &next
+	}
+	if n := len(st.history); n > 0 {
+		last := st.history[n-1]
+		status.LastRun = &last
+	}
+	return status
+}
+
+// Run starts due jobs until ctx is cancelled, then waits for running jobs
+// to return. Jobs receive a context derived from ctx.
+func (s *Scheduler) Run(ctx context.Context) {
+	s.mu.Lock()
+	s.baseCtx = ctx
+	s.mu.Unlock()
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		wait := s.startDue()
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-ctx.Done():
+			s.wg.Wait()
+			return
+		case <-s.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// startDue starts every enabled job whose activation has come and returns
+// how long to sleep until the next one.
+func (s *Scheduler) startDue() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	wait := time.Hour
+	for _, st := range s.jobs {
+		if !st.job.Enabled || st.next.IsZero() {
+			continue
+		}
+		if !st.next.After(now) {
+			if st.running {
+				s.logger.Warn("scheduled job skipped, previous run still in progress", "job", st.job.Name)
+			} else {
+				s.startLocked(st, TriggerSchedule)
+			}
+			st.next = st.schedule.Next(now)
+			if st.next.IsZero() {
+				continue
+			}
+		}
+		if d := st.next.Sub(now); d < wait {
+			wait = d
+		}
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	return wait
+}
+
+// startLocked runs the job in its own goroutine. Must be called with s.mu held.
+func (s *Scheduler) startLocked(st *jobState, trigger string) {
+	st.running = true
+	job := st.job
+	ctx := s.baseCtx
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		result := s.execute(ctx, job, trigger)
+
+		s.mu.Lock()
+		st.running = false
+		st.runs++
+		if result.Status == RunStatusError {
+			st.failures++
+		}
+		st.history = append(st.history, result)
+		if len(st.history) > historySize {
+			st.history = st.history[len(st.history)-historySize:]
+		}
+		observer := s.observer
+		s.mu.Unlock()
+
+		if observer != nil {
+			observer(job.Name, result)
+		}
+	}()
+}
+
+// execute runs one job, turning panics into failed runs.
+func (s *Scheduler) execute(ctx context.Context, job Job, trigger string) (result RunResult) {
+	if job.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, job.Timeout)
+		defer cancel()
+	}
+	result = RunResult{Trigger: trigger, StartedAt: s.now().UTC()}
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error("scheduled job panicked", "job", job.Name, "panic", r, "stack", string(debug.Stack()))
+			result.Status = RunStatusError
+			result.Error = fmt.Sprintf("panic: %v", r)
+		}
+		result.FinishedAt = s.now().UTC()
+		result.DurationMs = result.FinishedAt.Sub(result.StartedAt).Milliseconds()
+		if result.Status == RunStatusError {
+			s.logger.Warn("scheduled job failed", "job", job.Name, "trigger", trigger, "error", result.Error)
+		} else {
+			s.logger.Info("scheduled job finished", "job", job.Name, "trigger", trigger,
+				"duration_ms", result.DurationMs, "message", result.Message)
+		}
+	}()
+
+	msg, err := job.Run(ctx)
+	result.Message = msg
+	if err != nil {
+		result.Status = RunStatusError
+		result.Error = err.Error()
+	} else {
+		result.Status = RunStatusOK
+	}
+	return result
+}
+
+// signal wakes Run so it recomputes the next activation.
+func (s *Scheduler) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,137 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// waitFor polls cond until it holds or the deadline passes.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before deadline")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestScheduler_RunsOnSchedule(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	s := New(testLogger())
+	var runs atomic.Int32
+	if err := s.Register(Job{Name: "tick", Schedule: "@every 1s", Enabled: true, Run: func(ctx context.Context) (string, error) {
+		runs.Add(1)
+		return "ticked", nil
+	}}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	// Fake clock: every loop sees the job as due.
+	var offset atomic.Int64
+	s.now = func() time.Time { return time.Now().Add(time.Duration(offset.Add(int64(time.Second)))) }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { s.Run(ctx); close(done) }()
+	waitFor(t, func() bool { return runs.Load() >= 1 })
+	cancel()
+	<-done
+
+	st, err := s.Get("tick")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if st.Runs < 1 || st.LastRun == nil || st.LastRun.Status != RunStatusOK || st.LastRun.Message != "ticked" ||
+		st.LastRun.Trigger != TriggerSchedule || st.NextRun == nil {
+		t.Errorf("status = %+v, want a successful scheduled run and a next run", st)
+	}
+}
+
+func TestScheduler_TriggerAndFailures(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	s := New(testLogger())
+	release := make(chan struct{})
+	if err := s.Register(Job{Name: "manual", Schedule: "@daily", Run: func(ctx context.Context) (string, error) {
+		<-release
+		return "", errors.New("boom")
+	}}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	var observed atomic.Value
+	s.SetRunObserver(func(job string, r RunResult) { observed.Store(job + ":" + r.Status) })
+
+	if st, _ := s.Get("manual"); st.Enabled || st.NextRun != nil {
+		t.Errorf("disabled job status = %+v, want no next run", st)
+	}
+	if err := s.Trigger("manual"); err != nil {
+		t.Fatalf("Trigger: %v", err)
+	}
+	if err := s.Trigger("manual"); !errors.Is(err, ErrJobRunning) {
+		t.Errorf("second Trigger error = %v, want ErrJobRunning", err)
+	}
+	if err := s.Trigger("missing"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("Trigger(missing) error = %v, want ErrJobNotFound", err)
+	}
+	close(release)
+	waitFor(t, func() bool { st, _ := s.Get("manual"); return st.Runs == 1 && !st.Running })
+
+	st, _ := s.Get("manual")
+	if st.Failures != 1 || st.LastRun.Error != "boom" || st.LastRun.Trigger != TriggerManual || len(st.History) != 1 {
+		t.Errorf("status = %+v, want one failed manual run", st)
+	}
+	waitFor(t, func() bool { return observed.Load() == "manual:error" })
+}
+
+func TestScheduler_PanicIsFailure(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	s := New(testLogger())
+	_ = s.Register(Job{Name: "panics", Schedule: "@daily", Run: func(ctx context.Context) (string, error) {
+		panic("oops")
+	}})
+	if err := s.Trigger("panics"); err != nil {
+		t.Fatalf("Trigger: %v", err)
+	}
+	waitFor(t, func() bool { st, _ := s.Get("panics"); return st.Runs == 1 })
+	if st, _ := s.Get("panics"); st.LastRun.Status != RunStatusError || st.LastRun.Error != "panic: oops" {
+		t.Errorf("last run = %+v, want panic recorded as failure", st.LastRun)
+	}
+}
+
+func TestScheduler_RegisterAndUpdateValidate(t *testing.T) {
+	s := New(testLogger())
+	noop := func(ctx context.Context) (string, error) { return "", nil }
+	if err := s.Register(Job{Name: "bad", Schedule: "every day", Run: noop}); err == nil {
+		t.Error("Register with invalid schedule should fail")
+	}
+	if err := s.Register(Job{Name: "job", Schedule: "@daily", Run: noop}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := s.Register(Job{Name: "job", Schedule: "@daily", Run: noop}); err == nil {
+		t.Error("duplicate Register should fail")
+	}
+	if err := s.Update("job", "*/5 * * * *", true); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	st, _ := s.Get("job")
+	if !st.Enabled || st.Schedule != "*/5 * * * *" || st.NextRun == nil {
+		t.Errorf("updated status = %+v", st)
+	}
+	if err := s.Update("job", "nope", true); err == nil {
+		t.Error("Update with invalid schedule should fail")
+	}
+	if err := s.Update("missing", "@daily", true); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("Update(missing) error = %v, want ErrJobNotFound", err)
+	}
+}
@@ -0,0 +1,86 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Limits on session labels. Labels come from clients, so they are kept
+// small enough to copy into every audit record.
+const (
+	MaxLabels           = 16
+	MaxLabelKeyLength   = 63
+	MaxLabelValueLength = 256
+)
+
+// ValidateLabels checks session labels: at most MaxLabels, keys of letters,
+// digits and ".", "_", "-", "/", non-empty values without control
+// characters.
+func ValidateLabels(labels map[string]string) error {
+	if len(labels) > MaxLabels {
+		return fmt.Errorf("too many labels: %d (max %d)", len(labels), MaxLabels)
+	}
+	for k, v := range labels {
+		if k == "" || len(k) > MaxLabelKeyLength {
+			return fmt.Errorf("label key %q: must be 1 to %d characters", k, MaxLabelKeyLength)
+		}
+		for _, c := range k {
+			if !isLabelKeyChar(c) {
+				return fmt.Errorf("label key %q: invalid character %q", k, c)
+			}
+		}
+		if v == "" || len(v) > MaxLabelValueLength {
+			return fmt.Errorf("label %q: value must be 1 to %d characters", k, MaxLabelValueLength)
+		}
+		if strings.IndexFunc(v, func(c rune) bool { return c < 0x20 || c == 0x7f }) >= 0 {
+			return fmt.Errorf("label %q: value contains control characters", k)
+		}
+	}
+	return nil
+}
+
+func isLabelKeyChar(c rune) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '.' || c == '_' || c == '-' || c == '/'
+}
+
+// ParseLabelSelector parses "key=value" or "key" terms, as used by the
+// label query parameter of the admin API. A bare key matches any value and
+// is stored with an empty value.
+func ParseLabelSelector(terms []string) (map[string]string, error) {
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	sel := make(map[string]string, len(terms))
+	for _, term := range terms {
+		k, v, _ := strings.Cut(term, "=")
+		if k == "" {
+			return nil, fmt.Errorf("invalid label selector %q: expected key=value or key", term)
+		}
+		sel[k] = v
+	}
+	return sel, nil
+}
+
+// MatchLabels reports whether labels satisfy every term of selector.
+func MatchLabels(labels, selector map[string]string) bool {
+	for k, want := range selector {
+		got, ok := labels[k]
+		if !ok || (want != "" && got != want) {
+			return false
+		}
+	}
+	return true
+}
+
+// CopyLabels returns a copy of labels, nil when empty.
+func CopyLabels(labels map[string]string) map[string]string {
+	if len(labels) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(labels))
+	for k, v := range labels {
+		out[k] = v
+	}
+	return out
+}
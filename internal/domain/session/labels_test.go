@@ -0,0 +1,102 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/auth"
+)
+
+func TestValidateLabels(t *testing.T) {
+	tooMany := make(map[string]string)
+	for i := 0; i <= MaxLabels; i++ {
+		tooMany[strings.Repeat("k", i+1)] = "v"
+	}
+
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		wantErr bool
+	}{
+		{name: "nil", labels: nil},
+		{name: "valid", labels: map[string]string{"project": "checkout-bot", "run-id": "1234", "team/owner": "payments"}},
+		{name: "too many", labels: tooMany, wantErr: true},
+		{name: "empty key", labels: map[string]string{"": "v"}, wantErr: true},
+		{name: "key too long", labels: map[string]string{strings.Repeat("k", MaxLabelKeyLength+1): "v"}, wantErr: true},
+		{name: "invalid key character", labels: map[string]string{"run id": "1"}, wantErr: true},
+		{name: "empty value", labels: map[string]string{"project": ""}, wantErr: true},
+		{name: "value too long", labels: map[string]string{"project": strings.Repeat("v", MaxLabelValueLength+1)}, wantErr: true},
+		{name: "control character", labels: map[string]string{"project": "a\nb"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateLabels(tt.labels)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateLabels() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseLabelSelector_Match(t *testing.T) {
+	sel, err := ParseLabelSelector([]string{"project=checkout-bot", "run-id"})
+	if err != nil {
+		t.Fatalf("ParseLabelSelector() error = %v", err)
+	}
+
+	if !MatchLabels(map[string]string{"project": "checkout-bot", "run-id": "1234"}, sel) {
+		t.Error("labels with both keys should match")
+	}
+	if MatchLabels(map[string]string{"project": "checkout-bot"}, sel) {
+		t.Error("labels without run-id should not match")
+	}
+	if MatchLabels(map[string]string{"project": "other", "run-id": "1234"}, sel) {
+		t.Error("labels with another project should not match")
+	}
+	if !MatchLabels(nil, nil) {
+		t.Error("an empty selector should match anything")
+	}
+
+	if _, err := ParseLabelSelector([]string{"=value"}); err == nil {
+		t.Error("ParseLabelSelector() should reject a term without key")
+	}
+}
+
+func TestSessionService_SetLabels(t *testing.T) {
+	store := newMockSessionStore()
+	service := NewSessionService(store, Config{Timeout: 30 * time.Minute})
+	ctx := context.Background()
+
+	sess, err := service.Create(ctx, &auth.Identity{ID: "user-123", Name: "Test User"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	labels := map[string]string{"project": "checkout-bot"}
+	updated, err := service.SetLabels(ctx, sess.ID, labels)
+	if err != nil {
+		t.Fatalf("SetLabels() error = %v", err)
+	}
+	labels["project"] = "mutated"
+	if updated.Labels["project"] != "checkout-bot" {
+		t.Errorf("SetLabels() labels = %v, want a copy of the input", updated.Labels)
+	}
+
+	got, err := service.Get(ctx, sess.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Labels["project"] != "checkout-bot" {
+		t.Errorf("stored labels = %v", got.Labels)
+	}
+
+	if _, err := service.SetLabels(ctx, sess.ID, map[string]string{"bad key": "v"}); err == nil {
+		t.Error("SetLabels() should reject invalid labels")
+	}
+	if _, err := service.SetLabels(ctx, "missing", labels); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("SetLabels() error = %v, want ErrSessionNotFound", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/auth"
@@ -23,6 +24,9 @@ type Config struct {
 type SessionService struct {
 	store   SessionStore
 	timeout time.Duration
+	// mu serializes read-modify-write updates so a refresh does not
+	// overwrite labels set concurrently.
+	mu sync.Mutex
 }
 
 // NewSessionService creates a new SessionService with the given store and config.
@@ -82,6 +86,9 @@ func (s *SessionService) Get(ctx context.Context, id string) (*Session, error) {
 
 // Refresh extends session expiration and updates last access time.
 func (s *SessionService) Refresh(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	session, err := s.store.Get(ctx, id)
 	if err != nil {
 		return err
@@ -101,6 +108,27 @@ func (s *SessionService) Refresh(ctx context.Context, id string) error {
 	return nil
 }
 
+// SetLabels replaces the labels of a session and returns the updated
+// session. Returns ErrSessionNotFound if the session doesn't exist.
+func (s *SessionService) SetLabels(ctx context.Context, id string, labels map[string]string) (*Session, error) {
+	if err := ValidateLabels(labels); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	session.Labels = CopyLabels(labels)
+	if err := s.store.Update(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to update session labels: %w", err)
+	}
+	return session, nil
+}
+
 // Delete terminates a session.
 func (s *SessionService) Delete(ctx context.Context, id string) error {
 	return s.store.Delete(ctx, id)
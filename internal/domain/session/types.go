@@ -126,6 +126,9 @@ type Session struct {
 	ExpiresAt time.Time
 	// LastAccess is the last time the session was used (UTC).
 	LastAccess time.Time
+	// Labels are key/value tags set by the client at initialize or by an
+	// admin (e.g., project=checkout-bot). Copied into audit records.
+	Labels map[string]string
 }
 
 // IsExpired checks if the session has exceeded its timeout.
@@ -0,0 +1,185 @@
+// Package timing breaks down where the time of a request goes: the gateway
+// phases (authentication, policy evaluation, content scanning), the wait for
+// a human approval and the upstream call. Components mark the phase they run
+// through the request context, like watchdog stages; the breakdown is
+// reported to clients so agent developers can tell a slow upstream from a
+// slow gateway.
+package timing
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Phase identifies a part of request processing.
+type Phase string
+
+const (
+	// PhaseAuth covers API key or token validation and session lookup.
+	PhaseAuth Phase = "auth"
+	// PhasePolicy covers policy (CEL) evaluation.
+	PhasePolicy Phase = "policy"
+	// PhaseScan covers content scanning of arguments and responses.
+	PhaseScan Phase = "scan"
+	// PhaseApproval covers waiting for a human approval decision.
+	PhaseApproval Phase = "approval"
+	// PhaseUpstream covers forwarding to an upstream and waiting for its response.
+	PhaseUpstream Phase = "upstream"
+)
+
+// Phases lists every phase in chain order.
+var Phases = []Phase{PhaseAuth, PhasePolicy, PhaseScan, PhaseApproval, PhaseUpstream}
+
+// Breakdown collects the time one request spends in each phase. It is safe
+// for concurrent use.
+type Breakdown struct {
+	mu       sync.Mutex
+	started  time.Time
+	finished time.Time
+	phases   map[Phase]time.Duration
+	report   bool
+	now      func() time.Time
+}
+
+// Summary is a finished breakdown in milliseconds.
+type Summary struct {
+	// Phases holds the time spent in each phase that ran.
+	Phases map[Phase]float64 `json:"phases"`
+	// GatewayMs is the time not spent upstream or waiting for an approval:
+	// the overhead added by the gateway.
+	GatewayMs float64 `json:"gateway_ms"`
+	// UpstreamMs is the time spent waiting for upstreams.
+	UpstreamMs float64 `json:"upstream_ms"`
+	// TotalMs is the time from entering to leaving the interceptor chain.
+	TotalMs float64 `json:"total_ms"`
+}
+
+// breakdownKey is the context key type for the request breakdown.
+type breakdownKey struct{}
+
+// NewContext returns a context carrying a new breakdown, started now.
+func NewContext(ctx context.Context) (context.Context, *Breakdown) {
+	b := &Breakdown{phases: make(map[Phase]time.Duration), now: time.Now}
+	b.started = b.now()
+	return context.WithValue(ctx, breakdownKey{}, b), b
+}
+
+// FromContext returns the breakdown in ctx, or nil if there is none.
+func FromContext(ctx context.Context) *Breakdown {
+	b, _ := ctx.Value(breakdownKey{}).(*Breakdown)
+	return b
+}
+
+// Track marks the request in ctx as entering phase and returns a function
+// that marks it as leaving. The function may be called more than once;
+// only the first call counts. It is a no-op if ctx has no breakdown.
+//
+//	defer timing.Track(ctx, timing.PhasePolicy)()
+func Track(ctx context.Context, phase Phase) func() {
+	b := FromContext(ctx)
+	if b == nil {
+		return func() {}
+	}
+	start := b.now()
+	var once sync.Once
+	return func() {
+		once.Do(func() { b.Add(phase, b.now().Sub(start)) })
+	}
+}
+
+// Add adds d to the time spent in phase. Concurrent upstream calls of one
+// request add up.
+func (b *Breakdown) Add(phase Phase, d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.phases[phase] += d
+}
+
+// Finish marks the end of the request. Later calls are ignored.
+func (b *Breakdown) Finish() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.finished.IsZero() {
+		b.finished = b.now()
+	}
+}
+
+// SetReported records whether the breakdown is reported to the client of
+// the request, which depends on the authenticated identity.
+func (b *Breakdown) SetReported(report bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.report = report
+}
+
+// Reported reports whether the breakdown is reported to the client.
+func (b *Breakdown) Reported() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.report
+}
+
+// Summary returns the breakdown in milliseconds. An unfinished breakdown is
+// measured up to now.
+func (b *Breakdown) Summary() Summary {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	end := b.finished
+	if end.IsZero() {
+		end = b.now()
+	}
+	total := end.Sub(b.started)
+	gateway := total - b.phases[PhaseUpstream] - b.phases[PhaseApproval]
+	if gateway < 0 {
+		gateway = 0
+	}
+	s := Summary{
+		Phases:     make(map[Phase]float64, len(b.phases)),
+		GatewayMs:  ms(gateway),
+		UpstreamMs: ms(b.phases[PhaseUpstream]),
+		TotalMs:    ms(total),
+	}
+	for p, d := range b.phases {
+		s.Phases[p] = ms(d)
+	}
+	return s
+}
+
+// ServerTiming renders the breakdown as a Server-Timing header value
+// (W3C Server Timing): one metric per phase that ran, then "gateway" and
+// "total".
+func (b *Breakdown) ServerTiming() string {
+	s := b.Summary()
+	phases := make([]Phase, 0, len(s.Phases))
+	for p := range s.Phases {
+		phases = append(phases, p)
+	}
+	sort.Slice(phases, func(i, j int) bool { return phaseOrder(phases[i]) < phaseOrder(phases[j]) })
+
+	metrics := make([]string, 0, len(phases)+2)
+	for _, p := range phases {
+		metrics = append(metrics, fmt.Sprintf("%s;dur=%.3f", p, s.Phases[p]))
+	}
+	metrics = append(metrics,
+		fmt.Sprintf("gateway;dur=%.3f", s.GatewayMs),
+		fmt.Sprintf("total;dur=%.3f", s.TotalMs),
+	)
+	return strings.Join(metrics, ", ")
+}
+
+func phaseOrder(p Phase) int {
+	for i, known := range Phases {
+		if p == known {
+			return i
+		}
+	}
+	return len(Phases)
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,91 @@
+package timing
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeClock advances only when told to.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestBreakdown() (context.Context, *Breakdown, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)}
+	ctx, b := NewContext(context.Background())
+	b.now = clock.now
+	b.started = clock.now()
+	return ctx, b, clock
+}
+
+func TestBreakdown_Summary(t *testing.T) {
+	ctx, b, clock := newTestBreakdown()
+
+	stopAuth := Track(ctx, PhaseAuth)
+	clock.advance(2 * time.Millisecond)
+	stopAuth()
+	stopAuth() // only the first call counts
+
+	stopPolicy := Track(ctx, PhasePolicy)
+	clock.advance(time.Millisecond)
+	stopPolicy()
+
+	stopApproval := Track(ctx, PhaseApproval)
+	clock.advance(time.Second)
+	stopApproval()
+
+	stopUpstream := Track(ctx, PhaseUpstream)
+	clock.advance(40 * time.Millisecond)
+	stopUpstream()
+
+	stopScan := Track(ctx, PhaseScan)
+	clock.advance(500 * time.Microsecond)
+	stopScan()
+
+	clock.advance(time.Millisecond)
+	b.Finish()
+	clock.advance(time.Hour) // after Finish, ignored
+
+	s := b.Summary()
+	if s.Phases[PhaseAuth] != 2 || s.Phases[PhasePolicy] != 1 || s.Phases[PhaseScan] != 0.5 {
+		t.Errorf("phases = %v", s.Phases)
+	}
+	if s.UpstreamMs != 40 {
+		t.Errorf("UpstreamMs = %v, want 40", s.UpstreamMs)
+	}
+	if s.TotalMs != 1044.5 {
+		t.Errorf("TotalMs = %v, want 1044.5", s.TotalMs)
+	}
+	// Approval waits are human time, not gateway overhead.
+	if s.GatewayMs != 4.5 {
+		t.Errorf("GatewayMs = %v, want 4.5", s.GatewayMs)
+	}
+
+	want := "auth;dur=2.000, policy;dur=1.000, scan;dur=0.500, approval;dur=1000.000, upstream;dur=40.000, gateway;dur=4.500, total;dur=1044.500"
+	if got := b.ServerTiming(); got != want {
+		t.Errorf("ServerTiming() =\n %q\nwant\n %q", got, want)
+	}
+}
+
+func TestTrack_NoBreakdown(t *testing.T) {
+	// Must not panic without a breakdown in context.
+	Track(context.Background(), PhaseAuth)()
+	if FromContext(context.Background()) != nil {
+		t.Error("FromContext on an empty context should return nil")
+	}
+}
+
+func TestBreakdown_ConcurrentUpstreamsClampGateway(t *testing.T) {
+	_, b, clock := newTestBreakdown()
+	b.Add(PhaseUpstream, 30*time.Millisecond)
+	b.Add(PhaseUpstream, 30*time.Millisecond)
+	clock.advance(35 * time.Millisecond)
+	b.Finish()
+
+	s := b.Summary()
+	if s.UpstreamMs != 60 || s.GatewayMs != 0 {
+		t.Errorf("summary = %+v, want upstream 60 and gateway 0", s)
+	}
+}
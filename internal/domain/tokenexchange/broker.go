@@ -0,0 +1,321 @@
+package tokenexchange
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultCacheTTL is used when Config.CacheTTL is unset.
+	defaultCacheTTL = 5 * time.Minute
+	// defaultMaxCacheEntries is used when Config.MaxCacheEntries is unset.
+	defaultMaxCacheEntries = 10000
+	// expirySkew is subtracted from token expiry so cached tokens are never
+	// presented in their last seconds of validity.
+	expirySkew = 30 * time.Second
+	// maxIdPResponseSize bounds IdP response bodies.
+	maxIdPResponseSize = 64 * 1024
+)
+
+// cacheEntry is a cached validation or exchange result.
+type cacheEntry struct {
+	result Result
+	until  time.Time
+}
+
+// Broker validates and exchanges user tokens. It is safe for concurrent use.
+type Broker struct {
+	cfg    Config
+	client *http.Client
+	now    func() time.Time
+
+	mu        sync.Mutex
+	validated map[string]cacheEntry // key: token fingerprint
+	exchanged map[string]cacheEntry // key: fingerprint + audience/scope/resource
+}
+
+// BrokerOption configures a Broker.
+type BrokerOption func(*Broker)
+
+// WithHTTPClient sets the HTTP client used to reach the IdP.
+func WithHTTPClient(client *http.Client) BrokerOption {
+	return func(b *Broker) {
+		b.client = client
+	}
+}
+
+// NewBroker creates a Broker for the given IdP configuration.
+func NewBroker(cfg Config, opts ...BrokerOption) *Broker {
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = defaultCacheTTL
+	}
+	if cfg.MaxCacheEntries <= 0 {
+		cfg.MaxCacheEntries = defaultMaxCacheEntries
+	}
+	b := &Broker{
+		cfg:       cfg,
+		client:    &http.Client{Timeout: 10 * time.Second},
+		now:       time.Now,
+		validated: make(map[string]cacheEntry),
+		exchanged: make(map[string]cacheEntry),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Resolve validates the user token and returns the token to send upstream
+// according to the binding mode.
+func (b *Broker) Resolve(ctx context.Context, binding Binding, subjectToken string) (*Result, error) {
+	if subjectToken == "" {
+		return nil, ErrNoToken
+	}
+
+	validated, err := b.validate(ctx, subjectToken)
+	if err != nil {
+		return nil, err
+	}
+
+	switch binding.Mode {
+	case ModeExchange:
+		return b.exchange(ctx, binding, subjectToken, validated)
+	default:
+		res := validated
+		res.Mode = ModePassthrough
+		return &res, nil
+	}
+}
+
+// validate checks the user token's signature with the verifier and/or
+// against the introspection endpoint; a token neither of them vouches for
+// is rejected. Positive results are cached.
+func (b *Broker) validate(ctx context.Context, token string) (Result, error) {
+	if b.cfg.Verifier == nil && b.cfg.IntrospectionURL == "" {
+		return Result{}, fmt.Errorf("%w: no verifier or introspection endpoint configured", ErrInvalidToken)
+	}
+	now := b.now()
+	key := Fingerprint(token)
+
+	b.mu.Lock()
+	entry, ok := b.validated[key]
+	b.mu.Unlock()
+	if ok && now.Before(entry.until) {
+		return entry.result, nil
+	}
+
+	res := Result{Token: token}
+	if b.cfg.Verifier != nil {
+		subject, expiry, err := b.cfg.Verifier.VerifySubject(ctx, token)
+		if err != nil {
+			return Result{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+		}
+		res.Subject = subject
+		res.ExpiresAt = expiry
+	} else if claims, ok := parseJWTClaims(token); ok && claims.Exp > 0 {
+		// The claims are unverified: an expired JWT is rejected early, but
+		// the subject only comes from the introspection response.
+		if !now.Before(time.Unix(claims.Exp, 0)) {
+			return Result{}, fmt.Errorf("%w: expired", ErrInvalidToken)
+		}
+	}
+
+	if b.cfg.IntrospectionURL != "" {
+		info, err := b.introspect(ctx, token)
+		if err != nil {
+			return Result{}, err
+		}
+		if !info.Active {
+			return Result{}, fmt.Errorf("%w: inactive", ErrInvalidToken)
+		}
+		if info.Sub != "" {
+			res.Subject = info.Sub
+		}
+		if info.Exp > 0 {
+			res.ExpiresAt = time.Unix(info.Exp, 0)
+		}
+	}
+
+	b.store(b.validated, key, res, now)
+	return res, nil
+}
+
+// exchange returns a cached upstream token or performs an RFC 8693 exchange.
+func (b *Broker) exchange(ctx context.Context, binding Binding, subjectToken string, validated Result) (*Result, error) {
+	if b.cfg.TokenURL == "" {
+		return nil, fmt.Errorf("%w: no token endpoint configured", ErrExchangeFailed)
+	}
+
+	now := b.now()
+	key := Fingerprint(subjectToken) + "|" + binding.Audience + "|" + binding.Scope + "|" + binding.Resource
+
+	b.mu.Lock()
+	entry, ok := b.exchanged[key]
+	b.mu.Unlock()
+	if ok && now.Before(entry.until) {
+		res := entry.result
+		res.Cached = true
+		return &res, nil
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", grantTypeTokenExchange)
+	form.Set("subject_token", subjectToken)
+	form.Set("subject_token_type", tokenTypeAccessToken)
+	form.Set("requested_token_type", tokenTypeAccessToken)
+	if binding.Audience != "" {
+		form.Set("audience", binding.Audience)
+	}
+	if binding.Scope != "" {
+		form.Set("scope", binding.Scope)
+	}
+	if binding.Resource != "" {
+		form.Set("resource", binding.Resource)
+	}
+
+	var tokenResp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := b.postForm(ctx, b.cfg.TokenURL, form, &tokenResp); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExchangeFailed, err)
+	}
+	if tokenResp.AccessToken == "" {
+		return nil, fmt.Errorf("%w: response has no access_token", ErrExchangeFailed)
+	}
+
+	res := Result{
+		Token:    tokenResp.AccessToken,
+		Mode:     ModeExchange,
+		Audience: binding.Audience,
+		Subject:  validated.Subject,
+	}
+	if tokenResp.ExpiresIn > 0 {
+		res.ExpiresAt = now.Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	}
+
+	b.store(b.exchanged, key, res, now)
+	return &res, nil
+}
+
+// introspectionResponse is the subset of RFC 7662 fields the broker uses.
+type introspectionResponse struct {
+	Active bool   `json:"active"`
+	Sub    string `json:"sub"`
+	Exp    int64  `json:"exp"`
+}
+
+// introspect calls the RFC 7662 introspection endpoint.
+func (b *Broker) introspect(ctx context.Context, token string) (*introspectionResponse, error) {
+	form := url.Values{}
+	form.Set("token", token)
+	form.Set("token_type_hint", "access_token")
+
+	var info introspectionResponse
+	if err := b.postForm(ctx, b.cfg.IntrospectionURL, form, &info); err != nil {
+		return nil, fmt.Errorf("%w: introspection: %v", ErrInvalidToken, err)
+	}
+	return &info, nil
+}
+
+// postForm sends a client-authenticated form POST and decodes the JSON reply.
+// Response bodies are never included in errors since they may echo tokens.
+func (b *Broker) postForm(ctx context.Context, endpoint string, form url.Values, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if b.cfg.ClientID != "" {
+		req.SetBasicAuth(url.QueryEscape(b.cfg.ClientID), url.QueryEscape(b.cfg.ClientSecret))
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxIdPResponseSize))
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("http status %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// store caches res until the earlier of CacheTTL and token expiry (minus skew).
+func (b *Broker) store(cache map[string]cacheEntry, key string, res Result, now time.Time) {
+	until := now.Add(b.cfg.CacheTTL)
+	if !res.ExpiresAt.IsZero() {
+		if exp := res.ExpiresAt.Add(-expirySkew); exp.Before(until) {
+			until = exp
+		}
+	}
+	if !now.Before(until) {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(cache) >= b.cfg.MaxCacheEntries {
+		for k, e := range cache {
+			if !now.Before(e.until) {
+				delete(cache, k)
+			}
+		}
+		// Still full: drop an arbitrary entry.
+		for k := range cache {
+			if len(cache) < b.cfg.MaxCacheEntries {
+				break
+			}
+			delete(cache, k)
+		}
+	}
+	cache[key] = cacheEntry{result: res, until: until}
+}
+
+// CacheSize returns the number of cached validations and exchanges.
+func (b *Broker) CacheSize() (validated, exchanged int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.validated), len(b.exchanged)
+}
+
+// jwtClaims holds the registered claims the broker inspects locally.
+type jwtClaims struct {
+	Exp int64 `json:"exp"`
+}
+
+// parseJWTClaims decodes the payload of a JWT without verifying its
+// signature. It is only used for early expiry checks; authenticity is
+// established by the verifier or by introspection.
+func parseJWTClaims(token string) (*jwtClaims, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, false
+	}
+	var claims jwtClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, false
+	}
+	return &claims, true
+}
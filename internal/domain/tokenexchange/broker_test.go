@@ -0,0 +1,282 @@
+package tokenexchange
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// makeJWT builds an unsigned JWT with the given claims.
+func makeJWT(t *testing.T, claims map[string]interface{}) string {
+	t.Helper()
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("marshal claims: %v", err)
+	}
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(`{"alg":"none"}`)) + "." + enc.EncodeToString(payload) + ".sig"
+}
+
+// stubVerifier is a TokenVerifier returning a fixed subject or error.
+type stubVerifier struct {
+	subject string
+	err     error
+	calls   atomic.Int32
+}
+
+func (v *stubVerifier) VerifySubject(_ context.Context, _ string) (string, time.Time, error) {
+	v.calls.Add(1)
+	if v.err != nil {
+		return "", time.Time{}, v.err
+	}
+	return v.subject, time.Now().Add(time.Hour), nil
+}
+
+func TestBroker_PassthroughNoToken(t *testing.T) {
+	b := NewBroker(Config{})
+	_, err := b.Resolve(context.Background(), Binding{Mode: ModePassthrough}, "")
+	if !errors.Is(err, ErrNoToken) {
+		t.Fatalf("Resolve() error = %v, want ErrNoToken", err)
+	}
+}
+
+func TestBroker_PassthroughReturnsUserToken(t *testing.T) {
+	b := NewBroker(Config{Verifier: &stubVerifier{subject: "alice"}})
+	tok := makeJWT(t, map[string]interface{}{"sub": "alice", "exp": time.Now().Add(time.Hour).Unix()})
+
+	res, err := b.Resolve(context.Background(), Binding{Mode: ModePassthrough}, tok)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if res.Token != tok {
+		t.Error("passthrough must forward the user token unchanged")
+	}
+	if res.Subject != "alice" {
+		t.Errorf("Subject = %q, want alice", res.Subject)
+	}
+	if res.Mode != ModePassthrough {
+		t.Errorf("Mode = %q, want passthrough", res.Mode)
+	}
+}
+
+func TestBroker_RejectsExpiredJWT(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("an expired JWT must be rejected before introspection")
+		_, _ = w.Write([]byte(`{"active":true}`))
+	}))
+	defer srv.Close()
+
+	b := NewBroker(Config{IntrospectionURL: srv.URL})
+	tok := makeJWT(t, map[string]interface{}{"sub": "alice", "exp": time.Now().Add(-time.Minute).Unix()})
+
+	_, err := b.Resolve(context.Background(), Binding{Mode: ModePassthrough}, tok)
+	if !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Resolve() error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestBroker_RejectsWithoutVerification(t *testing.T) {
+	b := NewBroker(Config{})
+	tok := makeJWT(t, map[string]interface{}{"sub": "alice", "exp": time.Now().Add(time.Hour).Unix()})
+
+	_, err := b.Resolve(context.Background(), Binding{Mode: ModePassthrough}, tok)
+	if !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Resolve() error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestBroker_VerifierRejects(t *testing.T) {
+	verifier := &stubVerifier{err: errors.New("bad signature")}
+	b := NewBroker(Config{Verifier: verifier})
+	tok := makeJWT(t, map[string]interface{}{"sub": "alice", "exp": time.Now().Add(time.Hour).Unix()})
+
+	for i := 0; i < 2; i++ {
+		if _, err := b.Resolve(context.Background(), Binding{Mode: ModePassthrough}, tok); !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("Resolve() error = %v, want ErrInvalidToken", err)
+		}
+	}
+	if got := verifier.calls.Load(); got != 2 {
+		t.Errorf("verifier calls = %d, want 2 (rejections are not cached)", got)
+	}
+}
+
+func TestBroker_IntrospectionIgnoresUnverifiedSubject(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"active":true}`))
+	}))
+	defer srv.Close()
+
+	b := NewBroker(Config{IntrospectionURL: srv.URL})
+	tok := makeJWT(t, map[string]interface{}{"sub": "mallory", "exp": time.Now().Add(time.Hour).Unix()})
+
+	res, err := b.Resolve(context.Background(), Binding{Mode: ModePassthrough}, tok)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if res.Subject != "" {
+		t.Errorf("Subject = %q, want empty: the JWT subject is unverified", res.Subject)
+	}
+}
+
+func TestBroker_IntrospectionInactive(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"active":false}`))
+	}))
+	defer srv.Close()
+
+	b := NewBroker(Config{IntrospectionURL: srv.URL})
+	_, err := b.Resolve(context.Background(), Binding{Mode: ModePassthrough}, "opaque-token")
+	if !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Resolve() error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestBroker_IntrospectionCached(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if user, pass, ok := r.BasicAuth(); !ok || user != "gateway" || pass != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("token") != "opaque-token" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"active":true,"sub":"bob"}`))
+	}))
+	defer srv.Close()
+
+	b := NewBroker(Config{IntrospectionURL: srv.URL, ClientID: "gateway", ClientSecret: "s3cret"})
+	for i := 0; i < 3; i++ {
+		res, err := b.Resolve(context.Background(), Binding{Mode: ModePassthrough}, "opaque-token")
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+		if res.Subject != "bob" {
+			t.Errorf("Subject = %q, want bob", res.Subject)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("introspection calls = %d, want 1", got)
+	}
+}
+
+func TestBroker_ExchangeAndCache(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f := r.PostForm
+		if f.Get("grant_type") != grantTypeTokenExchange ||
+			f.Get("subject_token") != "user-token" ||
+			f.Get("subject_token_type") != tokenTypeAccessToken ||
+			f.Get("audience") != "https://api.example.com" ||
+			f.Get("scope") != "repo:read" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"upstream-token","issued_token_type":"urn:ietf:params:oauth:token-type:access_token","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer srv.Close()
+
+	b := NewBroker(Config{TokenURL: srv.URL, Verifier: &stubVerifier{subject: "alice"}})
+	binding := Binding{Mode: ModeExchange, Audience: "https://api.example.com", Scope: "repo:read"}
+
+	res, err := b.Resolve(context.Background(), binding, "user-token")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if res.Token != "upstream-token" || res.Cached {
+		t.Errorf("first Resolve() = %+v, want fresh upstream-token", res)
+	}
+	if res.Audience != "https://api.example.com" {
+		t.Errorf("Audience = %q", res.Audience)
+	}
+
+	res, err = b.Resolve(context.Background(), binding, "user-token")
+	if err != nil {
+		t.Fatalf("second Resolve() error = %v", err)
+	}
+	if !res.Cached {
+		t.Error("second Resolve() should be served from cache")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("token endpoint calls = %d, want 1", got)
+	}
+
+	// A different audience is a separate exchange.
+	binding.Audience = "https://other.example.com"
+	if _, err := b.Resolve(context.Background(), binding, "user-token"); err == nil {
+		t.Error("expected exchange for unexpected audience to fail")
+	}
+}
+
+func TestBroker_ExchangeFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid_grant","error_description":"user-token rejected"}`))
+	}))
+	defer srv.Close()
+
+	b := NewBroker(Config{TokenURL: srv.URL, Verifier: &stubVerifier{subject: "alice"}})
+	_, err := b.Resolve(context.Background(), Binding{Mode: ModeExchange, Audience: "a"}, "user-token")
+	if !errors.Is(err, ErrExchangeFailed) {
+		t.Fatalf("Resolve() error = %v, want ErrExchangeFailed", err)
+	}
+	// The IdP response body may echo the token and must not leak into errors.
+	if err != nil && strings.Contains(err.Error(), "user-token") {
+		t.Errorf("error leaks token material: %v", err)
+	}
+	if _, exchanged := b.CacheSize(); exchanged != 0 {
+		t.Errorf("failed exchanges must not be cached, got %d entries", exchanged)
+	}
+}
+
+func TestBroker_ExchangeWithoutTokenURL(t *testing.T) {
+	b := NewBroker(Config{Verifier: &stubVerifier{subject: "alice"}})
+	_, err := b.Resolve(context.Background(), Binding{Mode: ModeExchange}, "user-token")
+	if !errors.Is(err, ErrExchangeFailed) {
+		t.Fatalf("Resolve() error = %v, want ErrExchangeFailed", err)
+	}
+}
+
+func TestBroker_CacheRespectsTokenExpiry(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		// Expires within the skew window: never worth caching.
+		_, _ = w.Write([]byte(`{"access_token":"short-lived","expires_in":10}`))
+	}))
+	defer srv.Close()
+
+	b := NewBroker(Config{TokenURL: srv.URL, Verifier: &stubVerifier{subject: "alice"}})
+	for i := 0; i < 2; i++ {
+		if _, err := b.Resolve(context.Background(), Binding{Mode: ModeExchange}, "user-token"); err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("token endpoint calls = %d, want 2 (short-lived tokens are not cached)", got)
+	}
+}
+
+func TestSubjectTokenContext(t *testing.T) {
+	ctx := WithSubjectToken(context.Background(), "abc")
+	if got := SubjectTokenFromContext(ctx); got != "abc" {
+		t.Errorf("SubjectTokenFromContext() = %q, want abc", got)
+	}
+	if got := SubjectTokenFromContext(context.Background()); got != "" {
+		t.Errorf("SubjectTokenFromContext(empty) = %q, want empty", got)
+	}
+}
@@ -0,0 +1,115 @@
+// Package tokenexchange implements end-user OAuth token passthrough for
+// upstream MCP servers. A user token received on the inbound request is
+// validated, optionally exchanged for an upstream-audience token via an
+// RFC 8693 token endpoint, and handed to the upstream connection.
+package tokenexchange
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// Mode selects how the user token reaches an upstream.
+type Mode string
+
+const (
+	// ModePassthrough forwards the validated user token unchanged.
+	ModePassthrough Mode = "passthrough"
+	// ModeExchange trades the user token for an upstream-audience token (RFC 8693).
+	ModeExchange Mode = "exchange"
+)
+
+// RFC 8693 identifiers used in token exchange requests.
+const (
+	grantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+	tokenTypeAccessToken   = "urn:ietf:params:oauth:token-type:access_token"
+)
+
+// Sentinel errors returned by Broker.Resolve.
+var (
+	// ErrNoToken is returned when the request carries no user token.
+	ErrNoToken = errors.New("no user token on request")
+	// ErrInvalidToken is returned when the user token fails verification,
+	// is expired or inactive, or cannot be validated at all.
+	ErrInvalidToken = errors.New("user token is invalid")
+	// ErrExchangeFailed is returned when the token endpoint rejects the exchange.
+	ErrExchangeFailed = errors.New("token exchange failed")
+)
+
+// Config holds identity provider settings shared by all upstream bindings.
+type Config struct {
+	// Verifier checks the signature, issuer, audience and lifetime of JWT
+	// user tokens against the provider's published keys.
+	Verifier TokenVerifier
+	// IntrospectionURL is an RFC 7662 endpoint used to validate user tokens.
+	// At least one of Verifier and IntrospectionURL must be set; tokens are
+	// rejected otherwise.
+	IntrospectionURL string
+	// TokenURL is the RFC 8693 token endpoint. Required for ModeExchange.
+	TokenURL string
+	// ClientID and ClientSecret authenticate the gateway to the IdP.
+	ClientID     string
+	ClientSecret string
+	// CacheTTL bounds how long validated and exchanged tokens are cached.
+	CacheTTL time.Duration
+	// MaxCacheEntries caps each cache (0 = default 10000).
+	MaxCacheEntries int
+}
+
+// TokenVerifier verifies a JWT user token and returns the subject and
+// expiry it asserts. *oidc.Verifier satisfies it.
+type TokenVerifier interface {
+	VerifySubject(ctx context.Context, raw string) (subject string, expiry time.Time, err error)
+}
+
+// Binding describes how one upstream receives user tokens.
+type Binding struct {
+	// Upstream is the upstream name or ID this binding applies to.
+	Upstream string
+	// Mode is passthrough or exchange.
+	Mode Mode
+	// Audience, Scope and Resource are sent with exchange requests.
+	Audience string
+	Scope    string
+	Resource string
+}
+
+// Result is the token to present to an upstream.
+type Result struct {
+	// Token is the bearer token for the upstream request.
+	Token string
+	// Mode is the binding mode that produced the token.
+	Mode Mode
+	// Audience is the requested audience (exchange only).
+	Audience string
+	// Subject is the token subject, when known.
+	Subject string
+	// Cached reports whether the token came from the exchange cache.
+	Cached bool
+	// ExpiresAt is when the token expires (zero if unknown).
+	ExpiresAt time.Time
+}
+
+// Fingerprint returns a short, non-reversible identifier for a token,
+// safe to write to logs and audit records.
+func Fingerprint(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:6])
+}
+
+// subjectTokenContextKey is the context key type for the inbound user token.
+type subjectTokenContextKey struct{}
+
+// WithSubjectToken returns a context carrying the inbound user token.
+func WithSubjectToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, subjectTokenContextKey{}, token)
+}
+
+// SubjectTokenFromContext returns the inbound user token, or "" if absent.
+func SubjectTokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(subjectTokenContextKey{}).(string)
+	return token
+}
@@ -0,0 +1,204 @@
+// Package toolstats provides per-tool call statistics: invocation counts,
+// outcomes and latency histograms that can be merged and persisted as rollups.
+package toolstats
+
+import (
+	"encoding/json"
+	"math/bits"
+	"time"
+)
+
+// subBucketBits sets the histogram precision: every power-of-two range is
+// split into 2^subBucketBits linear sub-buckets, so a recorded value is off
+// by at most 1/16 (6.25%) of itself, as in an HDR histogram.
+const (
+	subBucketBits = 4
+	subBuckets    = 1 << subBucketBits
+)
+
+// Histogram is a log-linear latency histogram with microsecond resolution.
+// Values below 32µs are exact; larger values fall into buckets at most 1/16
+// of their value wide. The zero value is ready to use. It is not safe
+// for concurrent use.
+type Histogram struct {
+	counts []uint64
+	count  uint64
+	sum    int64 // microseconds
+	max    int64 // microseconds
+}
+
+// bucketIndex returns the bucket holding v (microseconds).
+func bucketIndex(v int64) int {
+	if v < subBuckets {
+		if v < 0 {
+			return 0
+		}
+		return int(v)
+	}
+	// Shift v into [subBuckets, 2*subBuckets); e is the shift amount.
+	e := bits.Len64(uint64(v)) - subBucketBits - 1
+	return (e+1)*subBuckets + int(v>>e) - subBuckets
+}
+
+// bucketLowerBound returns the smallest value (microseconds) in bucket i.
+func bucketLowerBound(i int) int64 {
+	if i < 2*subBuckets {
+		return int64(i)
+	}
+	e := i/subBuckets - 1
+	return int64(i%subBuckets+subBuckets) << e
+}
+
+// Record adds a latency observation.
+func (h *Histogram) Record(d time.Duration) {
+	v := d.Microseconds()
+	if v < 0 {
+		v = 0
+	}
+	i := bucketIndex(v)
+	if i >= len(h.counts) {
+		grown := make([]uint64, i+1)
+		copy(grown, h.counts)
+		h.counts = grown
+	}
+	h.counts[i]++
+	h.count++
+	h.sum += v
+	if v > h.max {
+		h.max = v
+	}
+}
+
+// Merge adds all observations of o to h.
+func (h *Histogram) Merge(o *Histogram) {
+	if o == nil || o.count == 0 {
+		return
+	}
+	if len(o.counts) > len(h.counts) {
+		grown := make([]uint64, len(o.counts))
+		copy(grown, h.counts)
+		h.counts = grown
+	}
+	for i, c := range o.counts {
+		h.counts[i] += c
+	}
+	h.count += o.count
+	h.sum += o.sum
+	if o.max > h.max {
+		h.max = o.max
+	}
+}
+
+// Count returns the number of observations.
+func (h *Histogram) Count() uint64 { return h.count }
+
+// Max returns the largest observation.
+func (h *Histogram) Max() time.Duration { return time.Duration(h.max) * time.Microsecond }
+
+// Mean returns the average observation, or 0 when empty.
+func (h *Histogram) Mean() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	return time.Duration(h.sum/int64(h.count)) * time.Microsecond
+}
+
+// Quantile returns the value at quantile q (0..1): the upper end of the
+// bucket holding the q-th observation, capped at the maximum seen.
+func (h *Histogram) Quantile(q float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	if q <= 0 {
+		q = 0
+	}
+	rank := uint64(q*float64(h.count) + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > h.count {
+		rank = h.count
+	}
+	var seen uint64
+	for i, c := range h.counts {
+		seen += c
+		if seen >= rank {
+			v := bucketLowerBound(i+1) - 1
+			if v > h.max {
+				v = h.max
+			}
+			return time.Duration(v) * time.Microsecond
+		}
+	}
+	return h.Max()
+}
+
+// Bucket is a non-empty histogram bucket: Count observations were at most
+// UpperBound (and above the previous bucket's bound).
+type Bucket struct {
+	UpperBound time.Duration
+	Count      uint64
+}
+
+// Buckets returns the non-empty buckets in ascending order.
+func (h *Histogram) Buckets() []Bucket {
+	var out []Bucket
+	for i, c := range h.counts {
+		if c == 0 {
+			continue
+		}
+		out = append(out, Bucket{
+			UpperBound: time.Duration(bucketLowerBound(i+1)-1) * time.Microsecond,
+			Count:      c,
+		})
+	}
+	return out
+}
+
+// histogramJSON is the persisted form of a Histogram. Buckets are sparse
+// (bucket index -> count) since most latencies fall into a few buckets.
+type histogramJSON struct {
+	Buckets   map[int]uint64 `json:"buckets,omitempty"`
+	Count     uint64         `json:"count"`
+	SumMicros int64          `json:"sum_us"`
+	MaxMicros int64          `json:"max_us"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (h Histogram) MarshalJSON() ([]byte, error) {
+	out := histogramJSON{Count: h.count, SumMicros: h.sum, MaxMicros: h.max}
+	for i, c := range h.counts {
+		if c == 0 {
+			continue
+		}
+		if out.Buckets == nil {
+			out.Buckets = make(map[int]uint64)
+		}
+		out.Buckets[i] = c
+	}
+	return json.Marshal(out)
+}
+
+// maxBucketIndex bounds decoded bucket indexes (values up to ~2^60µs).
+const maxBucketIndex = 61 * subBuckets
+
+// UnmarshalJSON implements json.Unmarshaler. Out-of-range buckets are dropped.
+func (h *Histogram) UnmarshalJSON(data []byte) error {
+	var in histogramJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*h = Histogram{count: in.Count, sum: in.SumMicros, max: in.MaxMicros}
+	for i, c := range in.Buckets {
+		if i < 0 || i >= maxBucketIndex {
+			continue
+		}
+		if i >= len(h.counts) {
+			grown := make([]uint64, i+1)
+			copy(grown, h.counts)
+			h.counts = grown
+		}
+		h.counts[i] = c
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package toolstats
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestBucketIndex_RoundTrip(t *testing.T) {
+	prev := -1
+	for _, v := range []int64{0, 1, 15, 16, 31, 32, 33, 100, 1000, 123456, 1 << 40} {
+		i := bucketIndex(v)
+		if i < prev {
+			t.Fatalf("bucketIndex(%d) = %d, not monotonic", v, i)
+		}
+		prev = i
+		lo, hi := bucketLowerBound(i), bucketLowerBound(i+1)
+		if v < lo || v >= hi {
+			t.Errorf("value %d not in bucket %d [%d, %d)", v, i, lo, hi)
+		}
+		// Relative bucket width stays within the configured precision.
+		if v >= 2*subBuckets && float64(hi-lo)/float64(lo) > 1.0/subBuckets {
+			t.Errorf("bucket %d [%d, %d) wider than 1/%d", i, lo, hi, subBuckets)
+		}
+	}
+}
+
+func TestHistogram_Quantiles(t *testing.T) {
+	var h Histogram
+	for i := 1; i <= 1000; i++ {
+		h.Record(time.Duration(i) * time.Millisecond)
+	}
+
+	if h.Count() != 1000 {
+		t.Fatalf("Count() = %d, want 1000", h.Count())
+	}
+	if h.Max() != time.Second {
+		t.Errorf("Max() = %v, want 1s", h.Max())
+	}
+	for _, tc := range []struct {
+		q    float64
+		want time.Duration
+	}{{0.5, 500 * time.Millisecond}, {0.99, 990 * time.Millisecond}, {1, time.Second}} {
+		got := h.Quantile(tc.q)
+		if diff := float64(got-tc.want) / float64(tc.want); diff < 0 || diff > 1.0/subBuckets {
+			t.Errorf("Quantile(%v) = %v, want within 6.25%% above %v", tc.q, got, tc.want)
+		}
+	}
+	if mean := h.Mean(); mean < 500*time.Millisecond || mean > 501*time.Millisecond {
+		t.Errorf("Mean() = %v, want ~500.5ms", mean)
+	}
+}
+
+func TestHistogram_MergeAndJSON(t *testing.T) {
+	var a, b Histogram
+	a.Record(2 * time.Millisecond)
+	b.Record(40 * time.Millisecond)
+	b.Record(40 * time.Millisecond)
+	a.Merge(&b)
+
+	data, err := json.Marshal(a)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var decoded Histogram
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if decoded.Count() != 3 || decoded.Max() != 40*time.Millisecond {
+		t.Errorf("decoded count=%d max=%v, want 3 and 40ms", decoded.Count(), decoded.Max())
+	}
+	if got := decoded.Buckets(); len(got) != 2 || got[1].Count != 2 {
+		t.Errorf("Buckets() = %+v, want 2 buckets with 2 slow calls", got)
+	}
+	if decoded.Quantile(0.5) != a.Quantile(0.5) {
+		t.Errorf("p50 after round trip = %v, want %v", decoded.Quantile(0.5), a.Quantile(0.5))
+	}
+}
+
+func TestRollup_Record(t *testing.T) {
+	var r Rollup
+	r.Record(OutcomeOK, 10*time.Millisecond)
+	r.Record(OutcomeError, 20*time.Millisecond)
+	r.Record(OutcomeDenied, time.Microsecond)
+	r.Record(OutcomeOK, 30*time.Millisecond)
+
+	if r.Calls != 4 || r.Errors != 1 || r.Denied != 1 {
+		t.Errorf("rollup = %+v, want 4 calls, 1 error, 1 denied", r)
+	}
+	if r.Latency.Count() != 3 {
+		t.Errorf("latency count = %d, want 3 (denials are not timed)", r.Latency.Count())
+	}
+	if rate := r.ErrorRate(); rate < 0.33 || rate > 0.34 {
+		t.Errorf("ErrorRate() = %v, want 1/3", rate)
+	}
+}
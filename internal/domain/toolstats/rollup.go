@@ -0,0 +1,59 @@
+package toolstats
+
+import "time"
+
+// Outcome classifies a tool call for statistics.
+type Outcome int
+
+const (
+	// OutcomeOK is a call that reached the upstream and returned a result.
+	OutcomeOK Outcome = iota
+	// OutcomeError is a call that failed: a gateway or upstream error, or a
+	// tool result flagged isError.
+	OutcomeError
+	// OutcomeDenied is a call rejected by the gateway (policy, quota, rate
+	// limit, scanning). Denials are counted but not timed, so they do not
+	// skew the latency of the tool itself.
+	OutcomeDenied
+)
+
+// Rollup aggregates the calls of one tool over a period.
+type Rollup struct {
+	Calls   int64     `json:"calls"`
+	Errors  int64     `json:"errors"`
+	Denied  int64     `json:"denied"`
+	Latency Histogram `json:"latency"`
+}
+
+// Record adds a call with the given outcome and latency.
+func (r *Rollup) Record(outcome Outcome, latency time.Duration) {
+	r.Calls++
+	switch outcome {
+	case OutcomeDenied:
+		r.Denied++
+		return
+	case OutcomeError:
+		r.Errors++
+	}
+	r.Latency.Record(latency)
+}
+
+// Merge adds all calls of o to r.
+func (r *Rollup) Merge(o *Rollup) {
+	if o == nil {
+		return
+	}
+	r.Calls += o.Calls
+	r.Errors += o.Errors
+	r.Denied += o.Denied
+	r.Latency.Merge(&o.Latency)
+}
+
+// ErrorRate returns the share of executed (not denied) calls that failed.
+func (r *Rollup) ErrorRate() float64 {
+	executed := r.Calls - r.Denied
+	if executed <= 0 {
+		return 0
+	}
+	return float64(r.Errors) / float64(executed)
+}
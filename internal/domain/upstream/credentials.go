@@ -0,0 +1,154 @@
+package upstream
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// CredentialType selects how the gateway authenticates to an upstream.
+type CredentialType string
+
+const (
+	// CredentialBearer sends "Authorization: Bearer <token>".
+	CredentialBearer CredentialType = "bearer"
+	// CredentialBasic sends HTTP basic authentication.
+	CredentialBasic CredentialType = "basic"
+	// CredentialHeader sends a custom header, e.g. "X-API-Key: <value>".
+	CredentialHeader CredentialType = "header"
+	// CredentialOAuth2 obtains an access token from a token endpoint with
+	// the OAuth2 client credentials grant, refreshes it when it expires and
+	// sends it as a bearer token.
+	CredentialOAuth2 CredentialType = "oauth2_client_credentials"
+)
+
+// credentialHeaderPattern matches an HTTP header field name (RFC 9110 token).
+var credentialHeaderPattern = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$")
+
+// reservedCredentialHeaders are set by the gateway's transports and cannot
+// carry credentials.
+var reservedCredentialHeaders = map[string]bool{
+	"Accept":               true,
+	"Content-Type":         true,
+	"Content-Length":       true,
+	"Host":                 true,
+	"Last-Event-Id":        true,
+	"Mcp-Session-Id":       true,
+	"Mcp-Protocol-Version": true,
+}
+
+// Credentials authenticate the gateway to an HTTP, SSE, OpenAPI or shim
+// upstream. Every field accepts secret references (${env:NAME}); plaintext
+// secrets are encrypted in state.json.
+type Credentials struct {
+	// Type selects the fields used below.
+	Type CredentialType
+	// Token is the bearer token (bearer).
+	Token string
+	// Username and Password are the basic authentication pair (basic).
+	Username string
+	Password string
+	// HeaderName and HeaderValue are the injected header (header).
+	HeaderName  string
+	HeaderValue string
+	// TokenURL, ClientID, ClientSecret and Scopes configure the client
+	// credentials grant (oauth2_client_credentials).
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+}
+
+// supportsCredentials reports whether upstreams of type t are reached over
+// HTTP and can carry credentials.
+func supportsCredentials(t UpstreamType) bool {
+	switch t {
+	case UpstreamTypeHTTP, UpstreamTypeSSE, UpstreamTypeOpenAPI, UpstreamTypeShim:
+		return true
+	}
+	return false
+}
+
+// Validate checks that the fields required by the credential type are set
+// and that fields of other types are not.
+func (c *Credentials) Validate() error {
+	var unused []string
+	switch c.Type {
+	case CredentialBearer:
+		if c.Token == "" {
+			return fmt.Errorf("credentials: token is required for %s", c.Type)
+		}
+		unused = []string{c.Username, c.Password, c.HeaderName, c.HeaderValue, c.TokenURL, c.ClientID, c.ClientSecret}
+	case CredentialBasic:
+		if c.Username == "" {
+			return fmt.Errorf("credentials: username is required for %s", c.Type)
+		}
+		unused = []string{c.Token, c.HeaderName, c.HeaderValue, c.TokenURL, c.ClientID, c.ClientSecret}
+	case CredentialHeader:
+		if c.HeaderName == "" || c.HeaderValue == "" {
+			return fmt.Errorf("credentials: header_name and header_value are required for %s", c.Type)
+		}
+		if !credentialHeaderPattern.MatchString(c.HeaderName) {
+			return fmt.Errorf("credentials: invalid header name %q", c.HeaderName)
+		}
+		if reservedCredentialHeaders[http.CanonicalHeaderKey(c.HeaderName)] {
+			return fmt.Errorf("credentials: header %q is set by the gateway", c.HeaderName)
+		}
+		unused = []string{c.Token, c.Username, c.Password, c.TokenURL, c.ClientID, c.ClientSecret}
+	case CredentialOAuth2:
+		if c.TokenURL == "" || c.ClientID == "" || c.ClientSecret == "" {
+			return fmt.Errorf("credentials: token_url, client_id and client_secret are required for %s", c.Type)
+		}
+		if !IsSecretRef(c.TokenURL) {
+			parsed, err := url.Parse(c.TokenURL)
+			if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+				return fmt.Errorf("credentials: token_url must be an http or https URL")
+			}
+		}
+		unused = []string{c.Token, c.Username, c.Password, c.HeaderName, c.HeaderValue}
+	default:
+		return fmt.Errorf("credentials: type must be %q, %q, %q or %q", CredentialBearer, CredentialBasic, CredentialHeader, CredentialOAuth2)
+	}
+	for _, v := range unused {
+		if v != "" {
+			return fmt.Errorf("credentials: fields of another credential type are set for %s", c.Type)
+		}
+	}
+	if c.Type != CredentialOAuth2 && len(c.Scopes) > 0 {
+		return fmt.Errorf("credentials: scopes are only supported for %s", CredentialOAuth2)
+	}
+	for _, v := range []string{c.Token, c.Username, c.Password, c.HeaderValue, c.ClientID, c.ClientSecret} {
+		if strings.ContainsAny(v, "\r\n") {
+			return fmt.Errorf("credentials: values must not contain line breaks")
+		}
+	}
+	return nil
+}
+
+// resolved returns a copy of c with its secret references resolved.
+func (c *Credentials) resolved(lookup func(string) (string, bool)) (*Credentials, error) {
+	r := *c
+	fields := []struct {
+		name string
+		v    *string
+	}{
+		{"token", &r.Token},
+		{"username", &r.Username},
+		{"password", &r.Password},
+		{"header_value", &r.HeaderValue},
+		{"token_url", &r.TokenURL},
+		{"client_id", &r.ClientID},
+		{"client_secret", &r.ClientSecret},
+	}
+	for _, f := range fields {
+		v, err := ResolveSecretRefs(*f.v, lookup)
+		if err != nil {
+			return nil, fmt.Errorf("credentials %s: %w", f.name, err)
+		}
+		*f.v = v
+	}
+	r.Scopes = append([]string(nil), c.Scopes...)
+	return &r, nil
+}
@@ -0,0 +1,77 @@
+package upstream
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCredentials_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		creds   Credentials
+		wantErr string
+	}{
+		{"bearer", Credentials{Type: CredentialBearer, Token: "t0ken"}, ""},
+		{"bearer without token", Credentials{Type: CredentialBearer}, "token is required"},
+		{"basic", Credentials{Type: CredentialBasic, Username: "svc", Password: "pw"}, ""},
+		{"header", Credentials{Type: CredentialHeader, HeaderName: "X-API-Key", HeaderValue: "${env:API_KEY}"}, ""},
+		{"reserved header", Credentials{Type: CredentialHeader, HeaderName: "mcp-session-id", HeaderValue: "x"}, "set by the gateway"},
+		{"invalid header", Credentials{Type: CredentialHeader, HeaderName: "X Key", HeaderValue: "x"}, "invalid header name"},
+		{"oauth2", Credentials{Type: CredentialOAuth2, TokenURL: "https://auth.example.com/token", ClientID: "id", ClientSecret: "s", Scopes: []string{"read"}}, ""},
+		{"oauth2 bad url", Credentials{Type: CredentialOAuth2, TokenURL: "ftp://auth", ClientID: "id", ClientSecret: "s"}, "token_url"},
+		{"mixed fields", Credentials{Type: CredentialBearer, Token: "t", Password: "p"}, "another credential type"},
+		{"scopes without oauth2", Credentials{Type: CredentialBearer, Token: "t", Scopes: []string{"x"}}, "scopes"},
+		{"line break", Credentials{Type: CredentialBearer, Token: "t\r\nX-Evil: 1"}, "line breaks"},
+		{"unknown type", Credentials{Type: "digest"}, "type must be"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.creds.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUpstream_Validate_CredentialsOnlyOverHTTP(t *testing.T) {
+	u := &Upstream{Name: "local", Type: UpstreamTypeStdio, Command: "mcp",
+		Credentials: &Credentials{Type: CredentialBearer, Token: "t"}}
+	if err := u.Validate(); err == nil {
+		t.Fatal("expected error for credentials on a stdio upstream")
+	}
+	u = &Upstream{Name: "remote", Type: UpstreamTypeSSE, URL: "https://mcp.example.com/sse",
+		Credentials: &Credentials{Type: CredentialBearer, Token: "t"}}
+	if err := u.Validate(); err != nil {
+		t.Fatalf("Validate() = %v", err)
+	}
+}
+
+func TestWithResolvedSecrets_Credentials(t *testing.T) {
+	u := &Upstream{
+		Name: "billing", Type: UpstreamTypeHTTP, URL: "https://billing.example.com/mcp",
+		Credentials: &Credentials{
+			Type: CredentialOAuth2, TokenURL: "https://auth.example.com/token",
+			ClientID: "billing-gw", ClientSecret: "${env:BILLING_CLIENT_SECRET}",
+		},
+	}
+	resolved, err := u.WithResolvedSecrets(func(k string) (string, bool) { return "s3cret", k == "BILLING_CLIENT_SECRET" })
+	if err != nil {
+		t.Fatalf("WithResolvedSecrets: %v", err)
+	}
+	if resolved.Credentials.ClientSecret != "s3cret" {
+		t.Errorf("ClientSecret = %q, want resolved value", resolved.Credentials.ClientSecret)
+	}
+	if u.Credentials.ClientSecret != "${env:BILLING_CLIENT_SECRET}" {
+		t.Errorf("original credentials were modified: %q", u.Credentials.ClientSecret)
+	}
+	if _, err := u.WithResolvedSecrets(func(string) (string, bool) { return "", false }); err == nil {
+		t.Error("expected error for unset credential variable")
+	}
+}
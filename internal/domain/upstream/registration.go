@@ -0,0 +1,35 @@
+package upstream
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// registrationTokenPrefix marks reverse upstream registration tokens, so
+// they are told apart from API keys (sg_) in logs and secret scanners.
+const registrationTokenPrefix = "sgr_"
+
+// ErrInvalidRegistrationToken is returned when a registration token matches
+// no enabled reverse upstream.
+var ErrInvalidRegistrationToken = errors.New("invalid registration token")
+
+// NewRegistrationToken returns a random registration token for a reverse
+// upstream and the hash to store with it.
+func NewRegistrationToken() (token, hash string, err error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", fmt.Errorf("generate registration token: %w", err)
+	}
+	token = registrationTokenPrefix + hex.EncodeToString(raw)
+	return token, HashRegistrationToken(token), nil
+}
+
+// HashRegistrationToken returns the hex SHA-256 of a registration token.
+// Tokens are 256-bit random values, so a fast hash is enough.
+func HashRegistrationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
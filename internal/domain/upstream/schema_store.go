@@ -0,0 +1,121 @@
+package upstream
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+)
+
+// schemaEntry is one interned input schema. Tools with byte-identical
+// schemas (common in generated catalogs) share a single entry.
+type schemaEntry struct {
+	key [sha256.Size]byte
+	// data holds the schema, deflated when compressed is true.
+	data       []byte
+	compressed bool
+	// size is the uncompressed schema length.
+	size int
+	refs int
+}
+
+// schemaStore interns tool input schemas by content hash and optionally keeps
+// large ones deflated, inflating them only when a caller asks for the schema.
+// It is not safe for concurrent use; ToolCache guards it with its own lock.
+type schemaStore struct {
+	entries map[[sha256.Size]byte]*schemaEntry
+	// compressMin is the schema size in bytes from which schemas are stored
+	// compressed. Zero disables compression.
+	compressMin int
+}
+
+func newSchemaStore() *schemaStore {
+	return &schemaStore{entries: make(map[[sha256.Size]byte]*schemaEntry)}
+}
+
+// intern returns the shared entry for raw, adding a reference to it.
+// Returns nil for an empty schema.
+func (s *schemaStore) intern(raw json.RawMessage) *schemaEntry {
+	if len(raw) == 0 {
+		return nil
+	}
+	key := sha256.Sum256(raw)
+	if e, ok := s.entries[key]; ok {
+		e.refs++
+		return e
+	}
+	e := &schemaEntry{key: key, size: len(raw), refs: 1}
+	s.encode(e, raw)
+	s.entries[key] = e
+	return e
+}
+
+// release drops one reference to e and forgets it when none are left.
+func (s *schemaStore) release(e *schemaEntry) {
+	if e == nil {
+		return
+	}
+	e.refs--
+	if e.refs <= 0 {
+		delete(s.entries, e.key)
+	}
+}
+
+// load returns the schema held by e. Uncompressed schemas are returned
+// without copying and must be treated as read-only.
+func (s *schemaStore) load(e *schemaEntry) json.RawMessage {
+	if e == nil {
+		return nil
+	}
+	if !e.compressed {
+		return e.data
+	}
+	out, err := io.ReadAll(io.LimitReader(flate.NewReader(bytes.NewReader(e.data)), int64(e.size)))
+	if err != nil {
+		// Data was produced by encode, so this cannot happen short of
+		// memory corruption; an absent schema is the safest answer.
+		return nil
+	}
+	return out
+}
+
+// setCompressMin changes the compression threshold and re-encodes the
+// entries whose storage form changes.
+func (s *schemaStore) setCompressMin(n int) {
+	if n < 0 {
+		n = 0
+	}
+	s.compressMin = n
+	for _, e := range s.entries {
+		if e.compressed != s.shouldCompress(e.size) {
+			s.encode(e, s.load(e))
+		}
+	}
+}
+
+func (s *schemaStore) shouldCompress(size int) bool {
+	return s.compressMin > 0 && size >= s.compressMin
+}
+
+// encode stores raw in e, deflated when it is over the threshold and
+// compression actually saves space.
+func (s *schemaStore) encode(e *schemaEntry, raw []byte) {
+	if s.shouldCompress(len(raw)) {
+		var buf bytes.Buffer
+		w, err := flate.NewWriter(&buf, flate.DefaultCompression)
+		if err == nil {
+			_, err = w.Write(raw)
+			if cerr := w.Close(); err == nil {
+				err = cerr
+			}
+		}
+		if err == nil && buf.Len() < len(raw) {
+			e.data = bytes.Clone(buf.Bytes())
+			e.compressed = true
+			return
+		}
+	}
+	e.data = bytes.Clone(raw)
+	e.compressed = false
+}
@@ -0,0 +1,460 @@
+package upstream
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Secret references keep credentials out of the upstream configuration:
+// "${env:NAME}" in args, env values, headers or the URL is replaced with the
+// gateway's NAME environment variable when the upstream is started.
+var secretRefPattern = regexp.MustCompile(`\$\{env:([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// IsSecretRef reports whether v is exactly one secret reference.
+func IsSecretRef(v string) bool {
+	loc := secretRefPattern.FindStringIndex(v)
+	return loc != nil && loc[0] == 0 && loc[1] == len(v)
+}
+
+// EnvSecretRef returns the secret reference for environment variable name.
+func EnvSecretRef(name string) string {
+	return "${env:" + name + "}"
+}
+
+// ResolveSecretRefs replaces every secret reference in s using lookup.
+// Returns an error naming the first variable that is not set.
+func ResolveSecretRefs(s string, lookup func(string) (string, bool)) (string, error) {
+	if !strings.Contains(s, "${env:") {
+		return s, nil
+	}
+	var missing string
+	out := secretRefPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		name := secretRefPattern.FindStringSubmatch(ref)[1]
+		v, ok := lookup(name)
+		if !ok && missing == "" {
+			missing = name
+		}
+		return v
+	})
+	if missing != "" {
+		return "", fmt.Errorf("secret reference %s: environment variable %s is not set", EnvSecretRef(missing), missing)
+	}
+	return out, nil
+}
+
+// WithResolvedSecrets returns a copy of u with the secret references in its
+// args, env values, headers, spec, URL and credentials resolved using lookup.
+func (u *Upstream) WithResolvedSecrets(lookup func(string) (string, bool)) (*Upstream, error) {
+	c := *u
+	var err error
+	if c.URL, err = ResolveSecretRefs(u.URL, lookup); err != nil {
+		return nil, fmt.Errorf("url: %w", err)
+	}
+	if c.Spec, err = ResolveSecretRefs(u.Spec, lookup); err != nil {
+		return nil, fmt.Errorf("spec: %w", err)
+	}
+	if u.Args != nil {
+		c.Args = make([]string, len(u.Args))
+		for i, a := range u.Args {
+			if c.Args[i], err = ResolveSecretRefs(a, lookup); err != nil {
+				return nil, fmt.Errorf("args[%d]: %w", i, err)
+			}
+		}
+	}
+	if u.Env != nil {
+		c.Env = make(map[string]string, len(u.Env))
+		for k, v := range u.Env {
+			if c.Env[k], err = ResolveSecretRefs(v, lookup); err != nil {
+				return nil, fmt.Errorf("env %s: %w", k, err)
+			}
+		}
+	}
+	if u.Headers != nil {
+		c.Headers = make(map[string]string, len(u.Headers))
+		for k, v := range u.Headers {
+			if c.Headers[k], err = ResolveSecretRefs(v, lookup); err != nil {
+				return nil, fmt.Errorf("header %s: %w", k, err)
+			}
+		}
+	}
+	if u.Credentials != nil {
+		if c.Credentials, err = u.Credentials.resolved(lookup); err != nil {
+			return nil, err
+		}
+	}
+	return &c, nil
+}
+
+// Fields of an upstream that secret detection inspects.
+const (
+	SecretFieldEnv     = "env"
+	SecretFieldArgs    = "args"
+	SecretFieldURL     = "url"
+	SecretFieldHeaders = "headers"
+)
+
+// SecretFinding is a value in an upstream configuration that looks like a
+// plaintext credential.
+type SecretFinding struct {
+	// Field is where the value was found: env, args, url or headers.
+	Field string
+	// Key locates the value within Field: the env var name, the argument
+	// index, the URL part ("userinfo", "path" or "query:<param>") or the
+	// header name.
+	Key string
+	// Kind names the detector that matched (e.g. "aws_access_key_id",
+	// "sensitive_name", "high_entropy").
+	Kind string
+	// EnvVar is the suggested environment variable to hold the secret. It is
+	// empty when the value cannot be replaced with a reference.
+	EnvVar string
+
+	// Location of the secret in the value, for conversion.
+	arg        int
+	start, end int
+}
+
+// Reference returns the secret reference that replaces the value, or ""
+// when the finding cannot be converted.
+func (f SecretFinding) Reference() string {
+	if f.EnvVar == "" {
+		return ""
+	}
+	return EnvSecretRef(f.EnvVar)
+}
+
+// secretValuePatterns match well-known credential formats.
+var secretValuePatterns = []struct {
+	kind string
+	re   *regexp.Regexp
+}{
+	{"aws_access_key_id", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"github_token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{40,})\b`)},
+	{"gitlab_token", regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}`)},
+	{"slack_token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{"stripe_key", regexp.MustCompile(`\b(?:sk|rk)_live_[A-Za-z0-9]{16,}\b`)},
+	{"google_api_key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`)},
+	{"api_key", regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}`)},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	{"private_key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)},
+}
+
+// bearerPattern matches credentials in HTTP authorization values passed as
+// arguments, e.g. --header "Authorization: Bearer <token>".
+var bearerPattern = regexp.MustCompile(`(?i)\b(?:bearer|basic|token)\s+([A-Za-z0-9._~+/=-]{8,})`)
+
+// sensitiveNameParts are substrings of env var, flag and query parameter
+// names (upper-cased, "-" as "_") whose values are credentials.
+var sensitiveNameParts = []string{
+	"SECRET", "TOKEN", "PASSWORD", "PASSWD", "CREDENTIAL", "PRIVATE_KEY",
+	"API_KEY", "APIKEY", "ACCESS_KEY", "AUTH_KEY",
+}
+
+// isSensitiveName reports whether name suggests its value is a credential.
+func isSensitiveName(name string) bool {
+	upper := strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+	for _, part := range sensitiveNameParts {
+		if strings.Contains(upper, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// uuidPattern matches UUIDs, which are identifiers rather than secrets.
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// highEntropyPattern is the alphabet of generated tokens (base64, base62, hex).
+var highEntropyPattern = regexp.MustCompile(`^[A-Za-z0-9+=_.-]{24,}$`)
+
+// isHighEntropy reports whether v looks like a long random token.
+func isHighEntropy(v string) bool {
+	if !highEntropyPattern.MatchString(v) || uuidPattern.MatchString(v) {
+		return false
+	}
+	var lower, upper, digit bool
+	counts := make(map[rune]int)
+	for _, r := range v {
+		counts[r]++
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		}
+	}
+	classes := 0
+	for _, ok := range []bool{lower, upper, digit} {
+		if ok {
+			classes++
+		}
+	}
+	if classes < 2 || !digit {
+		return false
+	}
+	var entropy float64
+	n := float64(len(v))
+	for _, c := range counts {
+		p := float64(c) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy >= 3.5
+}
+
+// looksLikeSecretValue reports whether a value under a sensitive name is a
+// credential rather than a setting (a path, a number or a boolean).
+func looksLikeSecretValue(v string) bool {
+	if len(v) < 4 || strings.HasPrefix(v, "/") || strings.HasPrefix(v, "~") || strings.HasPrefix(v, "./") {
+		return false
+	}
+	if _, err := strconv.ParseFloat(v, 64); err == nil {
+		return false
+	}
+	switch strings.ToLower(v) {
+	case "true", "false", "yes", "no", "on", "off", "none", "null":
+		return false
+	}
+	return true
+}
+
+// findSecretInValue returns the kind and span of a credential inside v.
+// name is the env var, flag or parameter name v belongs to, if any.
+func findSecretInValue(name, v string) (kind string, start, end int, ok bool) {
+	if v == "" || secretRefPattern.MatchString(v) {
+		return "", 0, 0, false
+	}
+	for _, p := range secretValuePatterns {
+		if loc := p.re.FindStringIndex(v); loc != nil {
+			return p.kind, loc[0], loc[1], true
+		}
+	}
+	if loc := bearerPattern.FindStringSubmatchIndex(v); loc != nil {
+		return "authorization", loc[2], loc[3], true
+	}
+	if name != "" && isSensitiveName(name) && looksLikeSecretValue(v) {
+		return "sensitive_name", 0, len(v), true
+	}
+	if isHighEntropy(v) {
+		return "high_entropy", 0, len(v), true
+	}
+	return "", 0, 0, false
+}
+
+// envVarName converts s to an environment variable name.
+func envVarName(s string) string {
+	var b strings.Builder
+	underscore := false
+	for _, r := range strings.ToUpper(s) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			underscore = false
+		} else if !underscore && b.Len() > 0 {
+			b.WriteByte('_')
+			underscore = true
+		}
+	}
+	name := strings.TrimRight(b.String(), "_")
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "UPSTREAM_" + name
+	}
+	return name
+}
+
+// DetectSecrets returns the values in u's args, env, headers and URL that look like
+// plaintext credentials. Values that already use secret references are
+// ignored.
+func DetectSecrets(u *Upstream) []SecretFinding {
+	prefix := envVarName(u.Name)
+	used := make(map[string]bool)
+	suggest := func(base string) string {
+		base = envVarName(base)
+		if base == "" {
+			base = "SECRET"
+		}
+		name := base
+		if prefix != "" && !strings.HasPrefix(base, prefix) {
+			name = prefix + "_" + base
+		}
+		unique := name
+		for i := 2; used[unique]; i++ {
+			unique = name + "_" + strconv.Itoa(i)
+		}
+		used[unique] = true
+		return unique
+	}
+
+	var findings []SecretFinding
+
+	keys := make([]string, 0, len(u.Env))
+	for k := range u.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if kind, start, end, ok := findSecretInValue(k, u.Env[k]); ok {
+			findings = append(findings, SecretFinding{
+				Field: SecretFieldEnv, Key: k, Kind: kind, EnvVar: suggest(k),
+				start: start, end: end,
+			})
+		}
+	}
+
+	for i := 0; i < len(u.Args); i++ {
+		arg := u.Args[i]
+		name, value, offset := "", arg, 0
+		if strings.HasPrefix(arg, "-") {
+			flag, v, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+			if !hasValue {
+				// "--token <value>": check the next argument under this name.
+				if isSensitiveName(flag) && i+1 < len(u.Args) && !strings.HasPrefix(u.Args[i+1], "-") {
+					if kind, start, end, ok := findSecretInValue(flag, u.Args[i+1]); ok {
+						findings = append(findings, SecretFinding{
+							Field: SecretFieldArgs, Key: strconv.Itoa(i + 1), Kind: kind, EnvVar: suggest(flag),
+							arg: i + 1, start: start, end: end,
+						})
+						i++
+					}
+				}
+				continue
+			}
+			name, value, offset = flag, v, len(arg)-len(v)
+		}
+		if kind, start, end, ok := findSecretInValue(name, value); ok {
+			base := name
+			if base == "" {
+				base = kind
+			}
+			findings = append(findings, SecretFinding{
+				Field: SecretFieldArgs, Key: strconv.Itoa(i), Kind: kind, EnvVar: suggest(base),
+				arg: i, start: offset + start, end: offset + end,
+			})
+		}
+	}
+
+	headerNames := make([]string, 0, len(u.Headers))
+	for k := range u.Headers {
+		headerNames = append(headerNames, k)
+	}
+	sort.Strings(headerNames)
+	for _, k := range headerNames {
+		if kind, start, end, ok := findSecretInValue(k, u.Headers[k]); ok {
+			findings = append(findings, SecretFinding{
+				Field: SecretFieldHeaders, Key: k, Kind: kind, EnvVar: suggest(k),
+				start: start, end: end,
+			})
+		}
+	}
+
+	findings = append(findings, detectURLSecrets(u.URL, suggest)...)
+	return findings
+}
+
+// detectURLSecrets finds credentials in the userinfo, path and query of rawURL.
+func detectURLSecrets(rawURL string, suggest func(string) string) []SecretFinding {
+	if rawURL == "" {
+		return nil
+	}
+	var findings []SecretFinding
+	if parsed, err := url.Parse(rawURL); err == nil && parsed.User != nil {
+		if _, hasPassword := parsed.User.Password(); hasPassword {
+			// Userinfo cannot hold a reference; the admin has to move it.
+			findings = append(findings, SecretFinding{Field: SecretFieldURL, Key: "userinfo", Kind: "url_credentials"})
+		}
+	}
+
+	rest, _, _ := strings.Cut(rawURL, "#")
+	base, query, hasQuery := strings.Cut(rest, "?")
+
+	// Path segments (after the host).
+	if schemeEnd := strings.Index(base, "://"); schemeEnd >= 0 {
+		if slash := strings.IndexByte(base[schemeEnd+3:], '/'); slash >= 0 {
+			pos := schemeEnd + 3 + slash
+			for _, seg := range strings.Split(base[pos:], "/") {
+				if kind, start, end, ok := findSecretInValue("", seg); ok {
+					findings = append(findings, SecretFinding{
+						Field: SecretFieldURL, Key: "path", Kind: kind, EnvVar: suggest("token"),
+						start: pos + start, end: pos + end,
+					})
+				}
+				pos += len(seg) + 1
+			}
+		}
+	}
+
+	if hasQuery {
+		pos := len(base) + 1
+		for _, pair := range strings.Split(query, "&") {
+			k, v, _ := strings.Cut(pair, "=")
+			name, err := url.QueryUnescape(k)
+			if err != nil {
+				name = k
+			}
+			valueStart := pos + len(k) + 1
+			if kind, start, end, ok := findSecretInValue(name, v); ok {
+				findings = append(findings, SecretFinding{
+					Field: SecretFieldURL, Key: "query:" + name, Kind: kind, EnvVar: suggest(name),
+					start: valueStart + start, end: valueStart + end,
+				})
+			}
+			pos += len(pair) + 1
+		}
+	}
+	return findings
+}
+
+// ConvertSecrets replaces the detected plaintext credentials in u with
+// secret references and returns the findings. The caller must tell the
+// admin to set each finding's EnvVar in the gateway environment. Findings
+// without an EnvVar are left in place.
+func ConvertSecrets(u *Upstream) []SecretFinding {
+	findings := DetectSecrets(u)
+
+	// Replace from the end of each value so earlier spans stay valid.
+	ordered := make([]SecretFinding, len(findings))
+	copy(ordered, findings)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].start > ordered[j].start })
+
+	// Copy so callers holding the previous slices and maps are unaffected.
+	if u.Env != nil {
+		env := make(map[string]string, len(u.Env))
+		for k, v := range u.Env {
+			env[k] = v
+		}
+		u.Env = env
+	}
+	if u.Headers != nil {
+		headers := make(map[string]string, len(u.Headers))
+		for k, v := range u.Headers {
+			headers[k] = v
+		}
+		u.Headers = headers
+	}
+	u.Args = append([]string(nil), u.Args...)
+
+	replace := func(v string, f SecretFinding) string {
+		return v[:f.start] + f.Reference() + v[f.end:]
+	}
+	for _, f := range ordered {
+		if f.EnvVar == "" {
+			continue
+		}
+		switch f.Field {
+		case SecretFieldEnv:
+			u.Env[f.Key] = replace(u.Env[f.Key], f)
+		case SecretFieldArgs:
+			u.Args[f.arg] = replace(u.Args[f.arg], f)
+		case SecretFieldURL:
+			u.URL = replace(u.URL, f)
+		case SecretFieldHeaders:
+			u.Headers[f.Key] = replace(u.Headers[f.Key], f)
+		}
+	}
+	return findings
+}
@@ -4,6 +4,7 @@ package upstream
 import (
 	"encoding/json"
 	"log/slog"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -24,8 +25,16 @@ type DiscoveredTool struct {
 	UpstreamID string
 	// UpstreamName is the human-readable name of the upstream.
 	UpstreamName string
+	// ToolPrefix is the upstream's configured tool prefix. When set, the
+	// tool is always exposed as ToolPrefix + bare name.
+	ToolPrefix string
 	// DiscoveredAt records when this tool was discovered.
 	DiscoveredAt time.Time
+
+	// schema is the interned schema of a tool stored in a ToolCache. Stored
+	// tools with a schema keep InputSchema nil; copies handed out are
+	// hydrated from here.
+	schema *schemaEntry
 }
 
 // ToolConflict records a tool name that is shared across multiple upstreams.
@@ -43,6 +52,18 @@ type ToolConflict struct {
 	WinnerUpstreamName string
 }
 
+// ToolNamespacing selects when tools are exposed under a namespace.
+type ToolNamespacing string
+
+const (
+	// NamespaceConflicts namespaces only tools whose name is shared across
+	// upstreams, as "upstream_name/bare_name" (default).
+	NamespaceConflicts ToolNamespacing = "conflicts"
+	// NamespaceAlways exposes every tool as "upstream_name.bare_name", with
+	// spaces in the upstream name replaced by underscores.
+	NamespaceAlways ToolNamespacing = "always"
+)
+
 const (
 	// MaxToolsPerUpstream is the maximum number of tools a single upstream can register.
 	// Prevents memory DoS from a malicious upstream advertising excessive tool counts.
@@ -57,8 +78,13 @@ const (
 // When two or more upstreams register tools with the same bare name, the ToolCache
 // automatically exposes them with namespace prefixes: "upstream_name/bare_name".
 // Tools with unique names across all upstreams are exposed without any prefix.
+// Tools of an upstream with a ToolPrefix, and every tool in NamespaceAlways
+// mode, are exposed with their prefix whether or not their name conflicts.
 // This behavior is transparent to callers: GetAllTools() returns resolved names,
 // and GetTool() looks up by resolved name.
+//
+// Input schemas are interned by content, so identical schemas are held once,
+// and can be kept compressed (see SetSchemaCompression) for large catalogs.
 type ToolCache struct {
 	// tools maps bare name → list of tools (one per upstream that has it)
 	tools map[string][]*DiscoveredTool
@@ -71,8 +97,46 @@ type ToolCache struct {
 	// ambiguous tracks bare names that have tools from multiple upstreams
 	ambiguous map[string]bool
 	conflicts []ToolConflict
-	logger    *slog.Logger
-	mu        sync.RWMutex
+	// namespacing is the namespacing mode (zero value = NamespaceConflicts).
+	namespacing ToolNamespacing
+	// version is bumped on every mutation so readers can detect changes.
+	version uint64
+	schemas *schemaStore
+	// snapshot is the shared view for the current version, built lazily.
+	snapshot *ToolSnapshot
+	logger   *slog.Logger
+	mu       sync.RWMutex
+}
+
+// ToolSnapshot is an immutable view of the cache at one version, with tools
+// sorted by resolved name. Snapshots are shared between callers: neither the
+// slice nor the tools (including their InputSchema bytes) may be modified.
+type ToolSnapshot struct {
+	// Version is the cache version the snapshot was built from.
+	Version uint64
+	// Tools holds every tool with its resolved name and hydrated schema.
+	Tools []*DiscoveredTool
+}
+
+// ToolCacheMemoryStats reports how much memory the cache spends on schemas.
+type ToolCacheMemoryStats struct {
+	// Tools is the number of stored tool entries.
+	Tools int
+	// UniqueSchemas is the number of distinct schemas after interning.
+	UniqueSchemas int
+	// CompressedSchemas is how many of the unique schemas are stored compressed.
+	CompressedSchemas int
+	// SchemaBytes is the combined size of all tool schemas as discovered,
+	// i.e. what the cache would hold without interning or compression.
+	SchemaBytes int64
+	// StoredBytes is the size actually held for schemas.
+	StoredBytes int64
+	// MetadataBytes approximates the names and descriptions held.
+	MetadataBytes int64
+	// SnapshotRetained reports whether a list snapshot is currently cached.
+	SnapshotRetained bool
+	// CompressMinBytes is the compression threshold (0 = disabled).
+	CompressMinBytes int
 }
 
 // NewToolCache creates a new empty ToolCache.
@@ -82,6 +146,7 @@ func NewToolCache() *ToolCache {
 		byUpstream: make(map[string][]*DiscoveredTool),
 		resolved:   make(map[string]*DiscoveredTool),
 		ambiguous:  make(map[string]bool),
+		schemas:    newSchemaStore(),
 		logger:     slog.Default(),
 	}
 }
@@ -93,6 +158,7 @@ func NewToolCacheWithLogger(logger *slog.Logger) *ToolCache {
 		byUpstream: make(map[string][]*DiscoveredTool),
 		resolved:   make(map[string]*DiscoveredTool),
 		ambiguous:  make(map[string]bool),
+		schemas:    newSchemaStore(),
 		logger:     logger,
 	}
 }
@@ -110,11 +176,12 @@ func (c *ToolCache) SetToolsForUpstream(upstreamID string, tools []*DiscoveredTo
 		tools = tools[:MaxToolsPerUpstream]
 	}
 
-	// Remove old entries for this upstream from the tools list-map.
-	if oldTools, ok := c.byUpstream[upstreamID]; ok {
-		for _, t := range oldTools {
-			c.removeToolEntry(t.Name, upstreamID)
-		}
+	// Remove old entries for this upstream from the tools list-map. Their
+	// schemas are released only after the new tools are interned, so an
+	// unchanged schema keeps its entry instead of being re-encoded.
+	oldTools := c.byUpstream[upstreamID]
+	for _, t := range oldTools {
+		c.removeToolEntry(t.Name, upstreamID)
 	}
 
 	// Enforce global limit based on remaining capacity.
@@ -126,13 +193,22 @@ func (c *ToolCache) SetToolsForUpstream(upstreamID string, tools []*DiscoveredTo
 		tools = tools[:remaining]
 	}
 
-	// Store a defensive copy in byUpstream index to prevent caller mutation.
+	// Store defensive copies with interned schemas to prevent caller mutation.
 	stored := make([]*DiscoveredTool, len(tools))
-	copy(stored, tools)
+	for i, t := range tools {
+		cp := *t
+		if cp.schema = c.schemas.intern(t.InputSchema); cp.schema != nil {
+			cp.InputSchema = nil
+		}
+		stored[i] = &cp
+	}
+	for _, t := range oldTools {
+		c.schemas.release(t.schema)
+	}
 	c.byUpstream[upstreamID] = stored
 
 	// Add each tool to the tools list-map.
-	for _, t := range tools {
+	for _, t := range stored {
 		c.tools[t.Name] = append(c.tools[t.Name], t)
 	}
 
@@ -141,6 +217,8 @@ func (c *ToolCache) SetToolsForUpstream(upstreamID string, tools []*DiscoveredTo
 
 	// Rebuild the resolved name map.
 	c.rebuildResolved()
+	c.version++
+	c.snapshot = nil
 }
 
 // GetTool looks up a tool by its resolved name.
@@ -154,27 +232,56 @@ func (c *ToolCache) GetTool(name string) (*DiscoveredTool, bool) {
 	if !ok {
 		return nil, false
 	}
-	cp := *t
+	cp := c.hydrate(t)
 	cp.BareName = t.Name // preserve original bare name before overwrite
 	cp.Name = name       // expose the resolved name (may include namespace prefix)
-	return &cp, true
+	return cp, true
 }
 
 // GetAllTools returns all tools with resolved names.
 // When tools have name conflicts, they are returned with namespace prefixes.
-// Returns shallow copies to prevent callers from mutating the cache.
+// Returns shallow copies to prevent callers from mutating the cache; the
+// InputSchema bytes are shared and must not be modified.
 func (c *ToolCache) GetAllTools() []*DiscoveredTool {
+	snap := c.Snapshot()
+	result := make([]*DiscoveredTool, len(snap.Tools))
+	for i, t := range snap.Tools {
+		cp := *t
+		result[i] = &cp
+	}
+	return result
+}
+
+// Snapshot returns the tools of the current version as a shared, immutable
+// view sorted by resolved name. The snapshot is built once per version and
+// handed to every caller until the next change, so listing a large catalog
+// does not copy it. With schema compression enabled the snapshot is not
+// retained (it would hold every schema inflated): each call builds its own.
+func (c *ToolCache) Snapshot() *ToolSnapshot {
 	c.mu.RLock()
-	defer c.mu.RUnlock()
+	snap := c.snapshot
+	c.mu.RUnlock()
+	if snap != nil {
+		return snap
+	}
 
-	result := make([]*DiscoveredTool, 0, len(c.resolved))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.snapshot != nil {
+		return c.snapshot
+	}
+	snap = &ToolSnapshot{Version: c.version, Tools: make([]*DiscoveredTool, 0, len(c.resolved))}
 	for resolvedName, t := range c.resolved {
-		cp := *t
+		cp := c.hydrate(t)
 		cp.BareName = t.Name // preserve original bare name
 		cp.Name = resolvedName
-		result = append(result, &cp)
+		snap.Tools = append(snap.Tools, cp)
 	}
-	return result
+	sort.Slice(snap.Tools, func(i, j int) bool { return snap.Tools[i].Name < snap.Tools[j].Name })
+	if c.schemas.compressMin == 0 {
+		c.snapshot = snap
+	}
+	return snap
 }
 
 // GetToolsByUpstream returns all tools for a specific upstream with their original bare names.
@@ -189,8 +296,7 @@ func (c *ToolCache) GetToolsByUpstream(upstreamID string) []*DiscoveredTool {
 	}
 	result := make([]*DiscoveredTool, len(tools))
 	for i, t := range tools {
-		cp := *t
-		result[i] = &cp
+		result[i] = c.hydrate(t)
 	}
 	return result
 }
@@ -203,12 +309,15 @@ func (c *ToolCache) RemoveUpstream(upstreamID string) {
 	if tools, ok := c.byUpstream[upstreamID]; ok {
 		for _, t := range tools {
 			c.removeToolEntry(t.Name, upstreamID)
+			c.schemas.release(t.schema)
 		}
 	}
 	delete(c.byUpstream, upstreamID)
 
 	c.rebuildConflicts()
 	c.rebuildResolved()
+	c.version++
+	c.snapshot = nil
 }
 
 // IsAmbiguous checks if a bare tool name is shared across multiple upstreams.
@@ -244,7 +353,8 @@ func (c *ToolCache) IsAmbiguous(name string) (bool, []string) {
 }
 
 // OriginalName returns the bare tool name (without namespace prefix) for a resolved name.
-// If the name has no "/" prefix, it is returned as-is.
+// If the name has no "/" prefix, it is returned as-is. Names under a
+// ToolPrefix cannot be split this way; GetTool reports their BareName.
 func OriginalName(resolvedName string) string {
 	if idx := strings.Index(resolvedName, "/"); idx >= 0 {
 		return resolvedName[idx+1:]
@@ -326,41 +436,172 @@ func (c *ToolCache) Count() int {
 	return len(c.resolved)
 }
 
+// Version returns a counter that changes whenever the set of tools changes.
+// Callers can compare versions to tell whether data derived from the cache
+// (such as a filtered tools/list response) is still current.
+func (c *ToolCache) Version() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.version
+}
+
+// SetNamespacing sets when tools are exposed under a namespace. Resolved
+// names change immediately.
+func (c *ToolCache) SetNamespacing(mode ToolNamespacing) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if mode == c.namespacing {
+		return
+	}
+	c.namespacing = mode
+	c.rebuildConflicts()
+	c.rebuildResolved()
+	c.version++
+	c.snapshot = nil
+}
+
+// SetSchemaCompression stores schemas of at least minBytes compressed and
+// inflates them on demand. Zero (the default) keeps every schema as-is. Tools
+// already in the cache are re-encoded.
+func (c *ToolCache) SetSchemaCompression(minBytes int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.schemas.setCompressMin(minBytes)
+	c.snapshot = nil
+}
+
+// MemoryStats reports the memory the cache spends on schemas and metadata.
+func (c *ToolCache) MemoryStats() ToolCacheMemoryStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	stats := ToolCacheMemoryStats{
+		UniqueSchemas:    len(c.schemas.entries),
+		SnapshotRetained: c.snapshot != nil,
+		CompressMinBytes: c.schemas.compressMin,
+	}
+	for _, tools := range c.byUpstream {
+		for _, t := range tools {
+			stats.Tools++
+			stats.MetadataBytes += int64(len(t.Name) + len(t.Description) + len(t.UpstreamID) + len(t.UpstreamName))
+			if t.schema != nil {
+				stats.SchemaBytes += int64(t.schema.size)
+			}
+		}
+	}
+	for _, e := range c.schemas.entries {
+		stats.StoredBytes += int64(len(e.data))
+		if e.compressed {
+			stats.CompressedSchemas++
+		}
+	}
+	return stats
+}
+
+// hydrate returns a copy of a stored tool with its InputSchema filled in.
+// Must be called with c.mu held.
+func (c *ToolCache) hydrate(t *DiscoveredTool) *DiscoveredTool {
+	cp := *t
+	if t.schema != nil {
+		cp.InputSchema = c.schemas.load(t.schema)
+	}
+	cp.schema = nil
+	return &cp
+}
+
 // rebuildResolved recomputes the resolved name map from the tools list-map.
 // Must be called with c.mu held (write lock).
 func (c *ToolCache) rebuildResolved() {
 	c.resolved = make(map[string]*DiscoveredTool, c.countToolEntries())
 	c.ambiguous = make(map[string]bool)
 
+	// Tools with a prefix are exposed under it regardless of conflicts and
+	// take no part in conflict detection. A prefixed name that is taken
+	// (e.g. two upstreams with the same prefix) falls back to the
+	// upstream-ID form, resolved in upstream ID order for stable names.
+	var prefixed []*DiscoveredTool
+	plain := make(map[string][]*DiscoveredTool, len(c.tools))
 	for bareName, tools := range c.tools {
+		for _, t := range tools {
+			if c.toolPrefix(t) != "" {
+				prefixed = append(prefixed, t)
+			} else {
+				plain[bareName] = append(plain[bareName], t)
+			}
+		}
+	}
+	sort.Slice(prefixed, func(i, j int) bool {
+		if prefixed[i].UpstreamID != prefixed[j].UpstreamID {
+			return prefixed[i].UpstreamID < prefixed[j].UpstreamID
+		}
+		return prefixed[i].Name < prefixed[j].Name
+	})
+	for _, t := range prefixed {
+		nsName := c.toolPrefix(t) + t.Name
+		if _, taken := c.resolved[nsName]; taken {
+			nsName = t.UpstreamName + "_" + t.UpstreamID + "/" + t.Name
+		}
+		c.resolved[nsName] = t
+		// A bare name only reachable under a prefix suggests the prefixed
+		// names when a client calls it unprefixed.
+		if len(plain[t.Name]) == 0 {
+			c.ambiguous[t.Name] = true
+		}
+	}
+
+	for bareName, tools := range plain {
 		if len(tools) == 1 {
 			// Unique name across all upstreams — no namespace needed.
-			c.resolved[bareName] = tools[0]
-		} else if len(tools) > 1 {
-			// Multiple upstreams share this name — namespace all of them.
-			c.ambiguous[bareName] = true
-			// Check if any UpstreamNames collide — if so, ALL tools with that name
-			// get the _ID suffix for deterministic and symmetric disambiguation.
-			nameCount := make(map[string]int, len(tools))
-			for _, t := range tools {
-				nameCount[t.UpstreamName]++
+			if _, taken := c.resolved[bareName]; !taken {
+				c.resolved[bareName] = tools[0]
+				continue
 			}
-			for _, t := range tools {
-				nsName := t.UpstreamName + "/" + bareName
-				if nameCount[t.UpstreamName] > 1 {
-					nsName = t.UpstreamName + "_" + t.UpstreamID + "/" + bareName
-				}
-				c.resolved[nsName] = t
+		}
+		// Multiple upstreams share this name — namespace all of them.
+		c.ambiguous[bareName] = true
+		// Check if any UpstreamNames collide — if so, ALL tools with that name
+		// get the _ID suffix for deterministic and symmetric disambiguation.
+		nameCount := make(map[string]int, len(tools))
+		for _, t := range tools {
+			nameCount[t.UpstreamName]++
+		}
+		for _, t := range tools {
+			nsName := t.UpstreamName + "/" + bareName
+			if nameCount[t.UpstreamName] > 1 {
+				nsName = t.UpstreamName + "_" + t.UpstreamID + "/" + bareName
 			}
+			c.resolved[nsName] = t
 		}
 	}
 }
 
+// toolPrefix returns the prefix a tool is always exposed under, or "" when
+// it is namespaced only on conflict. Must be called with c.mu held.
+func (c *ToolCache) toolPrefix(t *DiscoveredTool) string {
+	if t.ToolPrefix != "" {
+		return t.ToolPrefix
+	}
+	if c.namespacing == NamespaceAlways {
+		return strings.ReplaceAll(t.UpstreamName, " ", "_") + "."
+	}
+	return ""
+}
+
 // rebuildConflicts regenerates the conflict list from current tool state.
+// Prefixed tools never conflict and are left out.
 // Must be called with c.mu held (write lock).
 func (c *ToolCache) rebuildConflicts() {
 	c.conflicts = nil
-	for bareName, tools := range c.tools {
+	for bareName, all := range c.tools {
+		var tools []*DiscoveredTool
+		for _, t := range all {
+			if c.toolPrefix(t) == "" {
+				tools = append(tools, t)
+			}
+		}
 		if len(tools) <= 1 {
 			continue
 		}
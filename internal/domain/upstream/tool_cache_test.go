@@ -3,6 +3,7 @@ package upstream
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -144,6 +145,140 @@ func TestToolCacheRemoveUpstream(t *testing.T) {
 	}
 }
 
+func TestToolCacheVersion(t *testing.T) {
+	cache := NewToolCache()
+	v0 := cache.Version()
+
+	cache.SetToolsForUpstream("u1", []*DiscoveredTool{makeTool("tool_x", "u1")})
+	v1 := cache.Version()
+	if v1 == v0 {
+		t.Error("Version unchanged after SetToolsForUpstream")
+	}
+	cache.GetAllTools()
+	if cache.Version() != v1 {
+		t.Error("Version changed on read")
+	}
+	cache.RemoveUpstream("u1")
+	if cache.Version() == v1 {
+		t.Error("Version unchanged after RemoveUpstream")
+	}
+}
+
+func TestToolCacheSchemaInterning(t *testing.T) {
+	cache := NewToolCache()
+	shared := json.RawMessage(`{"type":"object","properties":{"id":{"type":"string"}}}`)
+	other := json.RawMessage(`{"type":"object"}`)
+	cache.SetToolsForUpstream("u1", []*DiscoveredTool{
+		{Name: "get_a", InputSchema: shared, UpstreamID: "u1", UpstreamName: "s1"},
+		{Name: "get_b", InputSchema: append(json.RawMessage(nil), shared...), UpstreamID: "u1", UpstreamName: "s1"},
+	})
+	cache.SetToolsForUpstream("u2", []*DiscoveredTool{
+		{Name: "get_c", InputSchema: shared, UpstreamID: "u2", UpstreamName: "s2"},
+		{Name: "ping", InputSchema: other, UpstreamID: "u2", UpstreamName: "s2"},
+	})
+
+	stats := cache.MemoryStats()
+	if stats.Tools != 4 || stats.UniqueSchemas != 2 {
+		t.Fatalf("stats = %+v, want 4 tools sharing 2 schemas", stats)
+	}
+	if want := int64(3*len(shared) + len(other)); stats.SchemaBytes != want {
+		t.Errorf("SchemaBytes = %d, want %d", stats.SchemaBytes, want)
+	}
+	if want := int64(len(shared) + len(other)); stats.StoredBytes != want {
+		t.Errorf("StoredBytes = %d, want %d", stats.StoredBytes, want)
+	}
+
+	// Caller's slice is not retained.
+	shared[0] = '['
+	if tool, _ := cache.GetTool("get_c"); string(tool.InputSchema) != `{"type":"object","properties":{"id":{"type":"string"}}}` {
+		t.Errorf("InputSchema = %s, caller mutation leaked into the cache", tool.InputSchema)
+	}
+
+	// Releasing the last reference frees the entry.
+	cache.RemoveUpstream("u2")
+	cache.SetToolsForUpstream("u1", []*DiscoveredTool{makeTool("get_a", "u1")})
+	if stats := cache.MemoryStats(); stats.UniqueSchemas != 1 || stats.Tools != 1 {
+		t.Errorf("after removal stats = %+v, want 1 tool and 1 schema", stats)
+	}
+}
+
+func TestToolCacheSchemaCompression(t *testing.T) {
+	cache := NewToolCache()
+	props := make([]string, 0, 200)
+	for i := 0; i < 200; i++ {
+		props = append(props, fmt.Sprintf(`"field_%d":{"type":"string","description":"a field"}`, i))
+	}
+	large := json.RawMessage(`{"type":"object","properties":{` + strings.Join(props, ",") + `}}`)
+	small := json.RawMessage(`{"type":"object"}`)
+	cache.SetToolsForUpstream("u1", []*DiscoveredTool{
+		{Name: "big", InputSchema: large, UpstreamID: "u1", UpstreamName: "s1"},
+		{Name: "small", InputSchema: small, UpstreamID: "u1", UpstreamName: "s1"},
+	})
+	cache.Snapshot()
+	if !cache.MemoryStats().SnapshotRetained {
+		t.Error("snapshot not retained without compression")
+	}
+
+	cache.SetSchemaCompression(1024)
+	stats := cache.MemoryStats()
+	if stats.CompressedSchemas != 1 || stats.StoredBytes >= stats.SchemaBytes {
+		t.Fatalf("stats = %+v, want the large schema compressed", stats)
+	}
+	if stats.SnapshotRetained {
+		t.Error("snapshot retained with compression enabled")
+	}
+
+	tool, ok := cache.GetTool("big")
+	if !ok || string(tool.InputSchema) != string(large) {
+		t.Fatalf("GetTool(big) schema not inflated correctly")
+	}
+	for _, tool := range cache.GetAllTools() {
+		if tool.Name == "small" && string(tool.InputSchema) != string(small) {
+			t.Errorf("small schema = %s", tool.InputSchema)
+		}
+	}
+
+	cache.SetSchemaCompression(0)
+	if stats := cache.MemoryStats(); stats.CompressedSchemas != 0 || stats.StoredBytes != stats.SchemaBytes {
+		t.Errorf("after disabling compression stats = %+v", stats)
+	}
+	if tool, _ := cache.GetTool("big"); string(tool.InputSchema) != string(large) {
+		t.Error("schema changed after decompressing in place")
+	}
+}
+
+func TestToolCacheSnapshot(t *testing.T) {
+	cache := NewToolCache()
+	cache.SetToolsForUpstream("u1", []*DiscoveredTool{makeTool("zeta", "u1"), makeTool("alpha", "u1")})
+
+	s1 := cache.Snapshot()
+	if s1 != cache.Snapshot() {
+		t.Error("Snapshot rebuilt without a change")
+	}
+	if len(s1.Tools) != 2 || s1.Tools[0].Name != "alpha" || s1.Tools[1].Name != "zeta" {
+		t.Fatalf("snapshot tools not sorted: %+v", s1.Tools)
+	}
+	if s1.Version != cache.Version() {
+		t.Errorf("snapshot version = %d, want %d", s1.Version, cache.Version())
+	}
+
+	// GetAllTools hands out copies, never the shared snapshot entries.
+	all := cache.GetAllTools()
+	all[0].Description = "changed"
+	if cache.Snapshot().Tools[0].Description == "changed" {
+		t.Error("GetAllTools result aliases the snapshot")
+	}
+
+	cache.SetToolsForUpstream("u2", []*DiscoveredTool{makeTool("beta", "u2")})
+	s2 := cache.Snapshot()
+	if s2 == s1 || len(s2.Tools) != 3 {
+		t.Errorf("snapshot not rebuilt after change: %d tools", len(s2.Tools))
+	}
+	if len(s1.Tools) != 2 {
+		t.Error("old snapshot modified")
+	}
+}
+
 func TestToolCacheConcurrentAccess(t *testing.T) {
 	cache := NewToolCache()
 
@@ -627,6 +762,74 @@ func TestToolCache_DuplicateServerNames(t *testing.T) {
 	}
 }
 
+func TestToolCache_ToolPrefix(t *testing.T) {
+	cache := NewToolCache()
+
+	fs1 := makeToolWithName("read_file", "id1", "fs one")
+	fs1.ToolPrefix = "fs1."
+	fs2 := makeToolWithName("read_file", "id2", "fs two")
+	fs2.ToolPrefix = "fs2."
+	cache.SetToolsForUpstream("id1", []*DiscoveredTool{fs1})
+	cache.SetToolsForUpstream("id2", []*DiscoveredTool{fs2})
+	cache.SetToolsForUpstream("id3", []*DiscoveredTool{makeToolWithName("search", "id3", "web")})
+
+	for name, upstreamID := range map[string]string{"fs1.read_file": "id1", "fs2.read_file": "id2", "search": "id3"} {
+		got, ok := cache.GetTool(name)
+		if !ok {
+			t.Fatalf("expected %s to exist", name)
+		}
+		if got.UpstreamID != upstreamID {
+			t.Errorf("%s UpstreamID = %q, want %q", name, got.UpstreamID, upstreamID)
+		}
+	}
+	if got, _ := cache.GetTool("fs1.read_file"); got.BareName != "read_file" {
+		t.Errorf("BareName = %q, want read_file", got.BareName)
+	}
+	if conflicts := cache.GetConflicts(); len(conflicts) != 0 {
+		t.Errorf("prefixed tools reported as conflicts: %v", conflicts)
+	}
+	ambig, suggestions := cache.IsAmbiguous("read_file")
+	if !ambig || len(suggestions) != 2 {
+		t.Errorf("IsAmbiguous(read_file) = %v %v, want both prefixed names", ambig, suggestions)
+	}
+
+	// The same prefix on two upstreams falls back to the upstream-ID form.
+	dup := makeToolWithName("read_file", "id4", "fs four")
+	dup.ToolPrefix = "fs1."
+	cache.SetToolsForUpstream("id4", []*DiscoveredTool{dup})
+	if got, ok := cache.GetTool("fs1.read_file"); !ok || got.UpstreamID != "id1" {
+		t.Errorf("fs1.read_file should stay with id1, got %+v", got)
+	}
+	if _, ok := cache.GetTool("fs four_id4/read_file"); !ok {
+		t.Error("expected fs four_id4/read_file for the duplicate prefix")
+	}
+}
+
+func TestToolCache_NamespaceAlways(t *testing.T) {
+	cache := NewToolCache()
+	cache.SetToolsForUpstream("id1", []*DiscoveredTool{makeToolWithName("read_file", "id1", "my fs")})
+	cache.SetToolsForUpstream("id2", []*DiscoveredTool{makeToolWithName("search", "id2", "web")})
+	v := cache.Version()
+
+	cache.SetNamespacing(NamespaceAlways)
+	if cache.Version() == v {
+		t.Error("SetNamespacing should bump the version")
+	}
+	for _, name := range []string{"my_fs.read_file", "web.search"} {
+		if _, ok := cache.GetTool(name); !ok {
+			t.Errorf("expected %s to exist", name)
+		}
+	}
+	if _, ok := cache.GetTool("search"); ok {
+		t.Error("bare name should not resolve in always mode")
+	}
+
+	cache.SetNamespacing(NamespaceConflicts)
+	if _, ok := cache.GetTool("search"); !ok {
+		t.Error("bare name should resolve again in conflicts mode")
+	}
+}
+
 func TestToolCacheEmpty(t *testing.T) {
 	cache := NewToolCache()
 
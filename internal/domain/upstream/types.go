@@ -4,8 +4,11 @@ package upstream
 import (
 	"fmt"
 	"net/url"
+	"path"
 	"regexp"
 	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
 )
 
 // UpstreamType identifies the transport protocol for an upstream server.
@@ -16,6 +19,20 @@ const (
 	UpstreamTypeStdio UpstreamType = "stdio"
 	// UpstreamTypeHTTP represents an upstream that communicates via HTTP/SSE.
 	UpstreamTypeHTTP UpstreamType = "http"
+	// UpstreamTypeSSE represents an upstream on the HTTP+SSE transport of
+	// the 2024-11-05 protocol revision: an event stream and an endpoint
+	// the stream names for posting messages.
+	UpstreamTypeSSE UpstreamType = "sse"
+	// UpstreamTypeOpenAPI represents a REST API described by an OpenAPI
+	// document, whose operations are exposed as MCP tools.
+	UpstreamTypeOpenAPI UpstreamType = "openapi"
+	// UpstreamTypeShim represents a backend that does not speak MCP, whose
+	// HTTP endpoints are mapped to MCP tools by a mapping file.
+	UpstreamTypeShim UpstreamType = "shim"
+	// UpstreamTypeReverse represents an MCP server that dials in to the
+	// gateway with a registration token, for servers behind NAT or a
+	// firewall that the gateway cannot reach.
+	UpstreamTypeReverse UpstreamType = "reverse"
 )
 
 // ConnectionStatus represents the runtime connection state of an upstream.
@@ -30,6 +47,9 @@ const (
 	StatusConnecting ConnectionStatus = "connecting"
 	// StatusError indicates the upstream encountered a connection error.
 	StatusError ConnectionStatus = "error"
+	// StatusIdle indicates a lazy upstream that is not running and will be
+	// started on the next routed request.
+	StatusIdle ConnectionStatus = "idle"
 )
 
 // namePattern allows alphanumeric, spaces, hyphens, and underscores.
@@ -38,13 +58,16 @@ var namePattern = regexp.MustCompile(`^[a-zA-Z0-9 _-]+$`)
 // nameMaxLength is the maximum allowed length for an upstream name.
 const nameMaxLength = 100
 
+// toolPrefixPattern allows the characters of MCP tool names plus "/".
+var toolPrefixPattern = regexp.MustCompile(`^[a-zA-Z0-9_./-]{1,64}$`)
+
 // Upstream represents a configured MCP upstream server.
 type Upstream struct {
 	// ID is the unique identifier (UUID).
 	ID string
 	// Name is the human-readable display name (unique).
 	Name string
-	// Type is the transport type: stdio or http.
+	// Type is the transport type: stdio, http, sse, openapi, shim or reverse.
 	Type UpstreamType
 	// Enabled indicates whether this upstream is active.
 	Enabled bool
@@ -52,10 +75,42 @@ type Upstream struct {
 	Command string
 	// Args are the command-line arguments (stdio only).
 	Args []string
-	// URL is the endpoint (HTTP only).
+	// URL is the endpoint (HTTP only) or the event stream (SSE only). For
+	// openapi upstreams it is an optional base URL that overrides the
+	// servers listed in the document; for shim upstreams it overrides the
+	// mapping file's base_url.
 	URL string
+	// Spec is the URL or file path of the OpenAPI document (openapi) or of
+	// the tool mapping file (shim).
+	Spec string
+	// Headers are added to every request sent to the API (openapi and shim).
+	Headers map[string]string
 	// Env holds environment variables passed to stdio upstreams.
 	Env map[string]string
+	// Lazy defers spawning a stdio upstream until the first routed request
+	// and stops it again after an idle period.
+	Lazy bool
+	// Framing is the stdio message framing: "auto" (or empty), "newline" or
+	// "content-length" (stdio only).
+	Framing string
+	// RegistrationTokenHash is the SHA-256 of the token a reverse upstream
+	// registers with (reverse only). The token itself is never stored.
+	RegistrationTokenHash string
+	// Credentials authenticate the gateway to the upstream (http, sse,
+	// openapi and shim only). Nil sends no credentials.
+	Credentials *Credentials
+	// ToolPrefix, when set, is prepended to the name of every tool of the
+	// upstream (e.g. "fs1." exposes "read_file" as "fs1.read_file"), so
+	// upstreams with the same tools coexist. The prefix is stripped before
+	// calls are forwarded.
+	ToolPrefix string
+	// ToolInclude lists glob patterns (path.Match syntax, e.g. "read_*") of
+	// the tools exposed to clients; empty exposes every tool. Patterns match
+	// the upstream's own tool names, before ToolPrefix is applied.
+	ToolInclude []string
+	// ToolExclude lists glob patterns of tools never exposed, even when
+	// they match ToolInclude.
+	ToolExclude []string
 
 	// Status is the runtime connection state (not persisted).
 	Status ConnectionStatus
@@ -88,15 +143,15 @@ func (u *Upstream) Validate() error {
 		return fmt.Errorf("name contains invalid characters (allowed: alphanumeric, spaces, hyphens, underscores)")
 	}
 
-	// Type must be stdio or http.
+	// Type must be stdio, http, sse, openapi, shim or reverse.
 	switch u.Type {
 	case UpstreamTypeStdio:
 		if u.Command == "" {
 			return fmt.Errorf("command is required for stdio upstream")
 		}
-	case UpstreamTypeHTTP:
+	case UpstreamTypeHTTP, UpstreamTypeSSE:
 		if u.URL == "" {
-			return fmt.Errorf("url is required for http upstream")
+			return fmt.Errorf("url is required for %s upstream", u.Type)
 		}
 		parsed, err := url.Parse(u.URL)
 		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
@@ -106,9 +161,116 @@ func (u *Upstream) Validate() error {
 		if parsed.Scheme != "http" && parsed.Scheme != "https" {
 			return fmt.Errorf("url scheme must be http or https, got %q", parsed.Scheme)
 		}
+	case UpstreamTypeOpenAPI, UpstreamTypeShim:
+		if u.Spec == "" {
+			return fmt.Errorf("spec is required for %s upstream", u.Type)
+		}
+		if u.URL != "" {
+			parsed, err := url.Parse(u.URL)
+			if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+				return fmt.Errorf("url is not a valid URL")
+			}
+			if parsed.Scheme != "http" && parsed.Scheme != "https" {
+				return fmt.Errorf("url scheme must be http or https, got %q", parsed.Scheme)
+			}
+		}
+	case UpstreamTypeReverse:
+		if u.Command != "" || len(u.Args) > 0 || u.URL != "" {
+			return fmt.Errorf("command, args and url are not supported for reverse upstreams")
+		}
+		if u.RegistrationTokenHash == "" {
+			return fmt.Errorf("registration token is required for reverse upstream")
+		}
 	default:
-		return fmt.Errorf("type must be %q or %q", UpstreamTypeStdio, UpstreamTypeHTTP)
+		return fmt.Errorf("type must be %q, %q, %q, %q, %q or %q", UpstreamTypeStdio, UpstreamTypeHTTP, UpstreamTypeSSE, UpstreamTypeOpenAPI, UpstreamTypeShim, UpstreamTypeReverse)
+	}
+
+	if u.Type != UpstreamTypeReverse && u.RegistrationTokenHash != "" {
+		return fmt.Errorf("registration token is only supported for reverse upstreams")
+	}
+
+	if u.Type != UpstreamTypeOpenAPI && u.Type != UpstreamTypeShim && (u.Spec != "" || len(u.Headers) > 0) {
+		return fmt.Errorf("spec and headers are only supported for openapi and shim upstreams")
+	}
+
+	if u.Credentials != nil {
+		if !supportsCredentials(u.Type) {
+			return fmt.Errorf("credentials are only supported for http, sse, openapi and shim upstreams")
+		}
+		if err := u.Credentials.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if u.ToolPrefix != "" {
+		if err := ValidateToolPrefix(u.ToolPrefix); err != nil {
+			return err
+		}
+	}
+
+	if err := ValidateToolFilters(u.ToolInclude, u.ToolExclude); err != nil {
+		return err
+	}
+
+	if u.Lazy && u.Type != UpstreamTypeStdio {
+		return fmt.Errorf("lazy is only supported for stdio upstreams")
+	}
+
+	if u.Framing != "" {
+		if u.Type != UpstreamTypeStdio {
+			return fmt.Errorf("framing is only supported for stdio upstreams")
+		}
+		if _, err := mcp.ParseFraming(u.Framing); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ValidateToolFilters checks that the tool include and exclude lists hold
+// valid, non-empty glob patterns.
+func ValidateToolFilters(include, exclude []string) error {
+	if err := validateGlobs("tool_include", include); err != nil {
+		return err
+	}
+	return validateGlobs("tool_exclude", exclude)
+}
+
+// validateGlobs checks the patterns of one tool filter list.
+func validateGlobs(field string, patterns []string) error {
+	for i, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil || p == "" {
+			return fmt.Errorf("%s[%d]: %q is not a valid glob pattern", field, i, p)
+		}
 	}
+	return nil
+}
 
+// ExposesTool reports whether the upstream's tool filters let clients see
+// the tool with the given name, as listed by the upstream.
+func (u *Upstream) ExposesTool(name string) bool {
+	for _, p := range u.ToolExclude {
+		if ok, _ := path.Match(p, name); ok {
+			return false
+		}
+	}
+	if len(u.ToolInclude) == 0 {
+		return true
+	}
+	for _, p := range u.ToolInclude {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateToolPrefix checks that a tool prefix only holds characters of MCP
+// tool names (plus "/") and is at most 64 characters long.
+func ValidateToolPrefix(prefix string) error {
+	if !toolPrefixPattern.MatchString(prefix) {
+		return fmt.Errorf("tool_prefix must be 1-64 characters (allowed: alphanumeric, '_', '.', '/', '-')")
+	}
 	return nil
 }
@@ -2,6 +2,7 @@ package upstream
 
 import (
 	"errors"
+	"strings"
 	"testing"
 	"time"
 )
@@ -24,6 +25,7 @@ func TestConnectionStatusConstants(t *testing.T) {
 		{StatusDisconnected, "disconnected"},
 		{StatusConnecting, "connecting"},
 		{StatusError, "error"},
+		{StatusIdle, "idle"},
 	}
 	for _, tt := range tests {
 		if string(tt.got) != tt.want {
@@ -142,6 +144,111 @@ func TestUpstreamValidateHTTP(t *testing.T) {
 	}
 }
 
+func TestUpstreamValidateSSE(t *testing.T) {
+	u := &Upstream{
+		Name: "legacy-sse",
+		Type: UpstreamTypeSSE,
+		URL:  "https://example.com/sse",
+	}
+	if err := u.Validate(); err != nil {
+		t.Errorf("valid sse upstream: unexpected error: %v", err)
+	}
+
+	u.URL = ""
+	if err := u.Validate(); err == nil {
+		t.Error("sse upstream without URL should fail validation")
+	}
+
+	u.URL = "ws://example.com/sse"
+	if err := u.Validate(); err == nil {
+		t.Error("sse upstream with ws scheme should fail validation")
+	}
+
+	u.URL = "https://example.com/sse"
+	u.Lazy = true
+	if err := u.Validate(); err == nil {
+		t.Error("lazy sse upstream should fail validation")
+	}
+}
+
+func TestUpstreamValidateOpenAPI(t *testing.T) {
+	u := &Upstream{
+		Name:    "petstore",
+		Type:    UpstreamTypeOpenAPI,
+		Spec:    "https://petstore.example.com/openapi.json",
+		Headers: map[string]string{"Authorization": "Bearer ${env:PETSTORE_TOKEN}"},
+	}
+	if err := u.Validate(); err != nil {
+		t.Errorf("valid openapi upstream: unexpected error: %v", err)
+	}
+
+	// Base URL override must be http(s).
+	u.URL = "ftp://example.com"
+	if err := u.Validate(); err == nil {
+		t.Error("openapi upstream with ftp base URL should fail validation")
+	}
+	u.URL = "https://api.example.com/v2"
+	if err := u.Validate(); err != nil {
+		t.Errorf("openapi upstream with base URL: unexpected error: %v", err)
+	}
+
+	u.Spec = ""
+	if err := u.Validate(); err == nil {
+		t.Error("openapi upstream without spec should fail validation")
+	}
+
+	h := &Upstream{Name: "remote", Type: UpstreamTypeHTTP, URL: "https://example.com/mcp", Headers: map[string]string{"X": "y"}}
+	if err := h.Validate(); err == nil {
+		t.Error("headers on http upstream should fail validation")
+	}
+}
+
+func TestUpstreamValidateShim(t *testing.T) {
+	u := &Upstream{Name: "legacy-tools", Type: UpstreamTypeShim, Spec: "/etc/sentinelgate/tools.yaml"}
+	if err := u.Validate(); err != nil {
+		t.Errorf("valid shim upstream: unexpected error: %v", err)
+	}
+	u.URL = "http://tools.internal:8080"
+	u.Headers = map[string]string{"X-Api-Key": "${env:TOOLS_KEY}"}
+	if err := u.Validate(); err != nil {
+		t.Errorf("shim upstream with base URL and headers: unexpected error: %v", err)
+	}
+	u.Spec = ""
+	if err := u.Validate(); err == nil {
+		t.Error("shim upstream without mapping file should fail validation")
+	}
+}
+
+func TestUpstreamValidateReverse(t *testing.T) {
+	token, hash, err := NewRegistrationToken()
+	if err != nil {
+		t.Fatalf("NewRegistrationToken: %v", err)
+	}
+	if HashRegistrationToken(token) != hash {
+		t.Error("HashRegistrationToken does not match the returned hash")
+	}
+
+	u := &Upstream{Name: "on-prem", Type: UpstreamTypeReverse, RegistrationTokenHash: hash}
+	if err := u.Validate(); err != nil {
+		t.Errorf("valid reverse upstream: unexpected error: %v", err)
+	}
+
+	u.Command = "/usr/bin/mcp"
+	if err := u.Validate(); err == nil {
+		t.Error("reverse upstream with a command should fail validation")
+	}
+
+	u = &Upstream{Name: "on-prem", Type: UpstreamTypeReverse}
+	if err := u.Validate(); err == nil {
+		t.Error("reverse upstream without a registration token should fail validation")
+	}
+
+	s := &Upstream{Name: "local", Type: UpstreamTypeStdio, Command: "/usr/bin/mcp", RegistrationTokenHash: hash}
+	if err := s.Validate(); err == nil {
+		t.Error("registration token on stdio upstream should fail validation")
+	}
+}
+
 func TestUpstreamValidateNameRules(t *testing.T) {
 	base := Upstream{
 		Type:    UpstreamTypeStdio,
@@ -179,3 +286,81 @@ func TestUpstreamValidateInvalidType(t *testing.T) {
 		t.Error("unknown upstream type should fail validation")
 	}
 }
+
+func TestUpstreamValidateFraming(t *testing.T) {
+	u := &Upstream{Name: "lsp", Type: UpstreamTypeStdio, Command: "/usr/bin/mcp", Framing: "content-length"}
+	if err := u.Validate(); err != nil {
+		t.Errorf("content-length stdio upstream: unexpected error: %v", err)
+	}
+
+	u.Framing = "xml"
+	if err := u.Validate(); err == nil {
+		t.Error("unknown framing should fail validation")
+	}
+
+	u = &Upstream{Name: "remote", Type: UpstreamTypeHTTP, URL: "http://localhost:3000/mcp", Framing: "newline"}
+	if err := u.Validate(); err == nil {
+		t.Error("framing on http upstream should fail validation")
+	}
+}
+
+func TestUpstreamValidateLazy(t *testing.T) {
+	u := &Upstream{
+		Name:    "lazy-stdio",
+		Type:    UpstreamTypeStdio,
+		Command: "/usr/bin/mcp",
+		Lazy:    true,
+	}
+	if err := u.Validate(); err != nil {
+		t.Errorf("lazy stdio upstream: unexpected error: %v", err)
+	}
+
+	u = &Upstream{
+		Name: "lazy-http",
+		Type: UpstreamTypeHTTP,
+		URL:  "http://localhost:3000/mcp",
+		Lazy: true,
+	}
+	if err := u.Validate(); err == nil {
+		t.Error("lazy http upstream should fail validation")
+	}
+}
+
+func TestUpstreamExposesTool(t *testing.T) {
+	u := &Upstream{
+		ToolInclude: []string{"read_*", "list_*"},
+		ToolExclude: []string{"read_secret*"},
+	}
+	tests := map[string]bool{
+		"read_file":      true,
+		"list_dir":       true,
+		"write_file":     false,
+		"read_secrets":   false,
+		"read_secret_db": false,
+	}
+	for name, want := range tests {
+		if got := u.ExposesTool(name); got != want {
+			t.Errorf("ExposesTool(%q) = %v, want %v", name, got, want)
+		}
+	}
+
+	if !(&Upstream{}).ExposesTool("anything") {
+		t.Error("an upstream without filters should expose every tool")
+	}
+}
+
+func TestUpstreamValidateToolFilters(t *testing.T) {
+	u := &Upstream{Name: "fs", Type: UpstreamTypeStdio, Command: "/usr/bin/mcp", ToolInclude: []string{"read_*"}}
+	if err := u.Validate(); err != nil {
+		t.Errorf("valid filters: unexpected error: %v", err)
+	}
+	u.ToolExclude = []string{"[bad"}
+	if err := u.Validate(); err == nil || !strings.Contains(err.Error(), "tool_exclude[0]") {
+		t.Errorf("invalid pattern: err = %v, want tool_exclude[0] error", err)
+	}
+	u.ToolExclude = nil
+	u.ToolInclude = []string{""}
+	if err := u.Validate(); err == nil {
+		t.Error("empty pattern should fail validation")
+	}
+}
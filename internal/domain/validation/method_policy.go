@@ -0,0 +1,230 @@
+package validation
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MethodAction is how the proxy handles requests for an MCP method.
+type MethodAction string
+
+const (
+	// MethodForward sends the request to the first upstream that implements
+	// the method.
+	MethodForward MethodAction = "forward"
+	// MethodDeny answers with method not found.
+	MethodDeny MethodAction = "deny"
+	// MethodRoute sends the request to one named upstream.
+	MethodRoute MethodAction = "route"
+	// MethodLocal answers the request in the proxy. Only ping supports it.
+	MethodLocal MethodAction = "local"
+)
+
+// MethodRule sets the handling of the methods matching Method, a glob
+// pattern (e.g., "experimental/*").
+type MethodRule struct {
+	Method   string       `json:"method"`
+	Action   MethodAction `json:"action"`
+	Upstream string       `json:"upstream,omitempty"`
+}
+
+// reservedMethods have fixed handling in the proxy and are never matched by
+// method rules.
+var reservedMethods = map[string]bool{
+	"initialize":                true,
+	"initialized":               true,
+	"notifications/initialized": true,
+	"tools/list":                true,
+	"tools/call":                true,
+	"resources/subscribe":       true,
+	"resources/unsubscribe":     true,
+}
+
+// IsReservedMethod reports whether method has fixed handling that method
+// rules cannot change. Notifications are always reserved.
+func IsReservedMethod(method string) bool {
+	return reservedMethods[method] || strings.HasPrefix(method, "notifications/")
+}
+
+// builtinMethodActions is the handling of known methods that match no rule.
+// Known methods missing here (client features such as sampling/createMessage)
+// are denied.
+// M-16: Only these are forwarded by default, so arbitrary methods cannot
+// reach unintended upstream endpoints.
+var builtinMethodActions = map[string]MethodAction{
+	"ping":                     MethodLocal,
+	"resources/list":           MethodForward,
+	"resources/read":           MethodForward,
+	"resources/templates/list": MethodForward, // H-12: MCP resource templates
+	"prompts/list":             MethodForward,
+	"prompts/get":              MethodForward,
+	"completion/complete":      MethodForward,
+	"logging/setLevel":         MethodForward,
+}
+
+// maxMethodCounters caps the number of methods counted individually. Method
+// names come from clients, so further methods share one counter.
+const maxMethodCounters = 256
+
+// maxCountedMethodLen truncates method names used as counter keys.
+const maxCountedMethodLen = 128
+
+// OtherMethods is the counter key shared by methods beyond maxMethodCounters.
+const OtherMethods = "(other)"
+
+// MethodStats counts the handling of one method.
+type MethodStats struct {
+	Method    string `json:"method"`
+	Forwarded uint64 `json:"forwarded"`
+	Routed    uint64 `json:"routed"`
+	Denied    uint64 `json:"denied"`
+	Local     uint64 `json:"local"`
+}
+
+// MethodPolicy decides how non-tool MCP methods are handled and counts the
+// decisions per method. It is shared by the validation interceptor, which
+// rejects denied methods early, and the upstream router, which carries out
+// the other actions. Safe for concurrent use.
+type MethodPolicy struct {
+	rules   []MethodRule
+	unknown MethodRule
+
+	mu     sync.Mutex
+	counts map[string]*MethodStats
+}
+
+// NewMethodPolicy creates a policy from ordered rules and the handling of
+// methods outside the MCP specification (unknown.Method is ignored).
+func NewMethodPolicy(rules []MethodRule, unknown MethodRule) (*MethodPolicy, error) {
+	for i, r := range rules {
+		if _, err := path.Match(r.Method, ""); err != nil || r.Method == "" {
+			return nil, fmt.Errorf("rule %d: invalid method pattern %q", i, r.Method)
+		}
+		if IsReservedMethod(r.Method) {
+			return nil, fmt.Errorf("rule %d: %s cannot be configured", i, r.Method)
+		}
+		if err := checkMethodAction(r); err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i, err)
+		}
+	}
+	unknown.Method = ""
+	if unknown.Action == "" {
+		unknown.Action = MethodDeny
+	}
+	if unknown.Action == MethodLocal {
+		return nil, fmt.Errorf("unknown methods cannot be answered locally")
+	}
+	if err := checkMethodAction(unknown); err != nil {
+		return nil, fmt.Errorf("unknown methods: %w", err)
+	}
+	return &MethodPolicy{
+		rules:   append([]MethodRule(nil), rules...),
+		unknown: unknown,
+		counts:  make(map[string]*MethodStats),
+	}, nil
+}
+
+// DefaultMethodPolicy returns the built-in handling: known methods as
+// listed in builtinMethodActions, unknown methods denied.
+func DefaultMethodPolicy() *MethodPolicy {
+	p, _ := NewMethodPolicy(nil, MethodRule{Action: MethodDeny})
+	return p
+}
+
+// checkMethodAction validates the action and upstream of a rule.
+func checkMethodAction(r MethodRule) error {
+	switch r.Action {
+	case MethodForward, MethodDeny:
+	case MethodRoute:
+		if r.Upstream == "" {
+			return fmt.Errorf("action route requires an upstream")
+		}
+		return nil
+	case MethodLocal:
+		if r.Method != "ping" {
+			return fmt.Errorf("action local is only supported for ping")
+		}
+	default:
+		return fmt.Errorf("unknown action %q", r.Action)
+	}
+	if r.Upstream != "" {
+		return fmt.Errorf("upstream is only valid with action route")
+	}
+	return nil
+}
+
+// Resolve returns the handling of method: the first matching rule, else the
+// built-in handling of known methods, else the unknown-method rule. Reserved
+// methods are not resolved; the caller handles them.
+func (p *MethodPolicy) Resolve(method string) MethodRule {
+	for _, r := range p.rules {
+		if ok, _ := path.Match(r.Method, method); ok {
+			return r
+		}
+	}
+	if IsValidMCPMethod(method) {
+		if action, ok := builtinMethodActions[method]; ok {
+			return MethodRule{Method: method, Action: action}
+		}
+		return MethodRule{Method: method, Action: MethodDeny}
+	}
+	return p.unknown
+}
+
+// Permits reports whether a request for method may pass validation: known
+// methods that are not denied and unknown methods the policy does not deny.
+func (p *MethodPolicy) Permits(method string) bool {
+	if IsReservedMethod(method) {
+		return IsValidMCPMethod(method)
+	}
+	return p.Resolve(method).Action != MethodDeny
+}
+
+// Rules returns the configured rules and the unknown-method rule.
+func (p *MethodPolicy) Rules() ([]MethodRule, MethodRule) {
+	return append([]MethodRule{}, p.rules...), p.unknown
+}
+
+// Record counts one request for method handled with action.
+func (p *MethodPolicy) Record(method string, action MethodAction) {
+	if len(method) > maxCountedMethodLen {
+		method = method[:maxCountedMethodLen]
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s, ok := p.counts[method]
+	if !ok {
+		if len(p.counts) >= maxMethodCounters {
+			method = OtherMethods
+		}
+		if s, ok = p.counts[method]; !ok {
+			s = &MethodStats{Method: method}
+			p.counts[method] = s
+		}
+	}
+	switch action {
+	case MethodForward:
+		s.Forwarded++
+	case MethodRoute:
+		s.Routed++
+	case MethodDeny:
+		s.Denied++
+	case MethodLocal:
+		s.Local++
+	}
+}
+
+// Stats returns the counters of all methods seen, sorted by method.
+func (p *MethodPolicy) Stats() []MethodStats {
+	p.mu.Lock()
+	out := make([]MethodStats, 0, len(p.counts))
+	for _, s := range p.counts {
+		out = append(out, *s)
+	}
+	p.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Method < out[j].Method })
+	return out
+}
@@ -0,0 +1,144 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+)
+
+func TestMethodPolicy_Resolve(t *testing.T) {
+	p, err := NewMethodPolicy([]MethodRule{
+		{Method: "completion/complete", Action: MethodRoute, Upstream: "search"},
+		{Method: "ping", Action: MethodForward},
+		{Method: "experimental/*", Action: MethodForward},
+	}, MethodRule{Action: MethodDeny})
+	if err != nil {
+		t.Fatalf("NewMethodPolicy: %v", err)
+	}
+
+	tests := []struct {
+		method string
+		want   MethodAction
+	}{
+		{"completion/complete", MethodRoute},
+		{"ping", MethodForward},
+		{"experimental/trace", MethodForward},
+		{"prompts/get", MethodForward},         // built-in
+		{"sampling/createMessage", MethodDeny}, // known, not forwarded
+		{"vendor/custom", MethodDeny},          // unknown
+	}
+	for _, tt := range tests {
+		if got := p.Resolve(tt.method).Action; got != tt.want {
+			t.Errorf("Resolve(%q) = %q, want %q", tt.method, got, tt.want)
+		}
+	}
+	if got := p.Resolve("completion/complete").Upstream; got != "search" {
+		t.Errorf("route upstream = %q, want search", got)
+	}
+
+	if got := DefaultMethodPolicy().Resolve("ping").Action; got != MethodLocal {
+		t.Errorf("default ping = %q, want local", got)
+	}
+}
+
+func TestMethodPolicy_Permits(t *testing.T) {
+	p, err := NewMethodPolicy([]MethodRule{{Method: "prompts/get", Action: MethodDeny}},
+		MethodRule{Action: MethodForward})
+	if err != nil {
+		t.Fatalf("NewMethodPolicy: %v", err)
+	}
+	for method, want := range map[string]bool{
+		"tools/call":      true,
+		"prompts/get":     false,
+		"vendor/custom":   true,
+		"notifications/x": false, // notifications are not configurable
+	} {
+		if got := p.Permits(method); got != want {
+			t.Errorf("Permits(%q) = %v, want %v", method, got, want)
+		}
+	}
+}
+
+func TestNewMethodPolicy_RejectsInvalidRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []MethodRule
+		unknown MethodRule
+		want    string
+	}{
+		{"reserved", []MethodRule{{Method: "tools/call", Action: MethodDeny}}, MethodRule{}, "cannot be configured"},
+		{"bad pattern", []MethodRule{{Method: "[a-", Action: MethodDeny}}, MethodRule{}, "invalid method pattern"},
+		{"route without upstream", []MethodRule{{Method: "ping", Action: MethodRoute}}, MethodRule{}, "requires an upstream"},
+		{"local not ping", []MethodRule{{Method: "prompts/get", Action: MethodLocal}}, MethodRule{}, "only supported for ping"},
+		{"upstream without route", []MethodRule{{Method: "ping", Action: MethodForward, Upstream: "a"}}, MethodRule{}, "only valid with action route"},
+		{"unknown local", nil, MethodRule{Action: MethodLocal}, "answered locally"},
+		{"unknown action", nil, MethodRule{Action: "drop"}, "unknown action"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewMethodPolicy(tt.rules, tt.unknown)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMethodPolicy_RecordCapsMethods(t *testing.T) {
+	p := DefaultMethodPolicy()
+	p.Record("ping", MethodLocal)
+	p.Record("ping", MethodLocal)
+	p.Record("ping", MethodDeny)
+	for i := 0; i < maxMethodCounters+5; i++ {
+		p.Record(fmt.Sprintf("vendor/m%d", i), MethodDeny)
+	}
+
+	stats := p.Stats()
+	if len(stats) != maxMethodCounters+1 {
+		t.Fatalf("len(stats) = %d, want %d", len(stats), maxMethodCounters+1)
+	}
+	byMethod := make(map[string]MethodStats, len(stats))
+	for _, s := range stats {
+		byMethod[s.Method] = s
+	}
+	if s := byMethod["ping"]; s.Local != 2 || s.Denied != 1 {
+		t.Errorf("ping = %+v", s)
+	}
+	if s := byMethod[OtherMethods]; s.Denied != 6 {
+		t.Errorf("other = %+v, want 6 denied", s)
+	}
+}
+
+func TestMessageValidator_MethodPolicy(t *testing.T) {
+	p, err := NewMethodPolicy([]MethodRule{{Method: "completion/complete", Action: MethodDeny}},
+		MethodRule{Action: MethodForward})
+	if err != nil {
+		t.Fatalf("NewMethodPolicy: %v", err)
+	}
+	v := NewMessageValidator()
+	v.SetMethodPolicy(p)
+
+	request := func(method string) *mcp.Message {
+		id, _ := jsonrpc.MakeID(float64(1))
+		return &mcp.Message{Decoded: &jsonrpc.Request{ID: id, Method: method}}
+	}
+	if err := v.Validate(request("vendor/custom")); err != nil {
+		t.Errorf("forwarded unknown method rejected: %v", err)
+	}
+	err = v.Validate(request("completion/complete"))
+	if valErr, ok := err.(*ValidationError); !ok || valErr.Code != ErrCodeMethodNotFound {
+		t.Errorf("denied method error = %v, want method not found", err)
+	}
+	// Unknown notifications stay rejected.
+	if err := v.Validate(&mcp.Message{Decoded: &jsonrpc.Request{Method: "vendor/event"}}); err == nil {
+		t.Error("unknown notification should be rejected")
+	}
+
+	stats := p.Stats()
+	if len(stats) != 1 || stats[0].Method != "completion/complete" || stats[0].Denied != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
@@ -7,20 +7,28 @@ import (
 
 // MessageValidator validates MCP messages for JSON-RPC compliance
 // and MCP-specific requirements.
-type MessageValidator struct{}
+type MessageValidator struct {
+	methods *MethodPolicy
+}
 
 // NewMessageValidator creates a new MessageValidator.
 func NewMessageValidator() *MessageValidator {
 	return &MessageValidator{}
 }
 
+// SetMethodPolicy sets the policy deciding which non-tool methods are
+// accepted. When nil (default), exactly the MCP methods are accepted.
+func (v *MessageValidator) SetMethodPolicy(p *MethodPolicy) {
+	v.methods = p
+}
+
 // Validate checks if the message is a valid JSON-RPC/MCP message.
 // Returns nil if valid, or a *ValidationError if invalid.
 //
 // Validation rules:
 // - Message must have a non-nil Decoded field (parse error if nil)
 // - Requests must have non-nil ID and non-empty Method
-// - Request Method must be a valid MCP method
+// - Request Method must be a valid MCP method, or permitted by the method policy
 // - Notifications (Request with nil ID) must have non-empty Method
 // - Responses must have ID and either Result or Error (not both, not neither)
 func (v *MessageValidator) Validate(msg *mcp.Message) error {
@@ -48,6 +56,16 @@ func (v *MessageValidator) validateRequest(req *jsonrpc.Request) error {
 		return NewValidationError(ErrCodeInvalidRequest, "Invalid Request")
 	}
 
+	// Requests for configurable methods are accepted unless the method
+	// policy denies them.
+	if v.methods != nil && req.IsCall() && !IsReservedMethod(req.Method) {
+		if !v.methods.Permits(req.Method) {
+			v.methods.Record(req.Method, MethodDeny)
+			return NewValidationError(ErrCodeMethodNotFound, "Method not found")
+		}
+		return nil
+	}
+
 	// Validate method is a known MCP method.
 	if !IsValidMCPMethod(req.Method) {
 		return NewValidationError(ErrCodeMethodNotFound, "Method not found")
@@ -0,0 +1,308 @@
+// Package watchdog detects in-flight requests that stall inside the
+// interceptor chain. Components mark the stage a request is in (policy
+// evaluation, approval wait, upstream call, ...) through the request context;
+// a background loop reports any stage that runs past its threshold with a
+// goroutine dump, so a stuck resolver or a blocked approval no longer looks
+// like a silent hang.
+package watchdog
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/event"
+)
+
+// Stage identifies a processing step of a request.
+type Stage string
+
+const (
+	// StageNormalize covers converting the wire message into a CanonicalAction.
+	StageNormalize Stage = "normalize"
+	// StagePolicy covers policy (CEL) evaluation.
+	StagePolicy Stage = "policy"
+	// StageOutboundDNS covers resolving destination hosts on the request path.
+	StageOutboundDNS Stage = "outbound_dns"
+	// StageApproval covers waiting for a human approval decision.
+	StageApproval Stage = "approval"
+	// StageUpstream covers forwarding to an upstream and waiting for its response.
+	StageUpstream Stage = "upstream"
+)
+
+// Stages lists every known stage in chain order.
+var Stages = []Stage{StageNormalize, StagePolicy, StageOutboundDNS, StageApproval, StageUpstream}
+
+// DefaultThresholds returns the default per-stage stall thresholds.
+// Approval waits are bounded by the approval timeout (5m by default), so
+// the approval threshold only fires when a wait outlives any sane timeout.
+func DefaultThresholds() map[Stage]time.Duration {
+	return map[Stage]time.Duration{
+		StageNormalize:   time.Second,
+		StagePolicy:      5 * time.Second,
+		StageOutboundDNS: 5 * time.Second,
+		StageApproval:    10 * time.Minute,
+		StageUpstream:    2 * time.Minute,
+	}
+}
+
+const (
+	// DefaultCheckInterval is how often in-flight requests are inspected.
+	DefaultCheckInterval = 5 * time.Second
+	// defaultDumpInterval is the minimum time between two goroutine dumps.
+	defaultDumpInterval = time.Minute
+	// maxDumpBytes caps the goroutine dump attached to a stall report.
+	maxDumpBytes = 256 << 10
+)
+
+// Stall describes a request stage that exceeded its threshold.
+type Stall struct {
+	Method    string        `json:"method"`
+	Tool      string        `json:"tool,omitempty"`
+	Stage     Stage         `json:"stage"`
+	Elapsed   time.Duration `json:"elapsed"`
+	Threshold time.Duration `json:"threshold"`
+	// Total is the time since the request entered the chain.
+	Total time.Duration `json:"total"`
+}
+
+// stageFrame is one entered stage; stages may nest.
+type stageFrame struct {
+	stage   Stage
+	started time.Time
+	alerted bool
+}
+
+// request is the in-flight state of one tracked request.
+type request struct {
+	method  string
+	tool    string
+	started time.Time
+	stages  []*stageFrame
+}
+
+// Watchdog tracks in-flight requests and reports stalled stages.
+type Watchdog struct {
+	mu         sync.Mutex
+	inflight   map[uint64]*request
+	nextID     uint64
+	thresholds map[Stage]time.Duration
+	interval   time.Duration
+	logger     *slog.Logger
+	eventBus   event.Bus
+	lastDump   time.Time
+	now        func() time.Time
+}
+
+// New creates a Watchdog. Stages missing from thresholds use the defaults;
+// a zero or negative threshold disables reporting for that stage.
+func New(thresholds map[Stage]time.Duration, interval time.Duration, logger *slog.Logger) *Watchdog {
+	merged := DefaultThresholds()
+	for stage, d := range thresholds {
+		merged[stage] = d
+	}
+	if interval <= 0 {
+		interval = DefaultCheckInterval
+	}
+	return &Watchdog{
+		inflight:   make(map[uint64]*request),
+		thresholds: merged,
+		interval:   interval,
+		logger:     logger,
+		now:        time.Now,
+	}
+}
+
+// SetEventBus sets the event bus used to publish "watchdog.stall" events.
+func (w *Watchdog) SetEventBus(bus event.Bus) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.eventBus = bus
+}
+
+// Begin registers a request and returns a context carrying its tracker.
+// The returned function must be called when the request finishes.
+func (w *Watchdog) Begin(ctx context.Context, method string) (context.Context, func()) {
+	w.mu.Lock()
+	w.nextID++
+	id := w.nextID
+	w.inflight[id] = &request{method: method, started: w.now()}
+	w.mu.Unlock()
+
+	return context.WithValue(ctx, trackerKey{}, &tracker{w: w, id: id}), func() {
+		w.mu.Lock()
+		delete(w.inflight, id)
+		w.mu.Unlock()
+	}
+}
+
+// InFlight returns the number of tracked requests.
+func (w *Watchdog) InFlight() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.inflight)
+}
+
+// Run inspects in-flight requests every check interval until ctx is done.
+func (w *Watchdog) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.Check(ctx)
+		}
+	}
+}
+
+// Check inspects in-flight requests once and reports every stage that
+// crossed its threshold since the last check. Each stage entry is reported
+// at most once. Returns the stalls found.
+func (w *Watchdog) Check(ctx context.Context) []Stall {
+	w.mu.Lock()
+	now := w.now()
+	var stalls []Stall
+	for _, req := range w.inflight {
+		for _, frame := range req.stages {
+			threshold := w.thresholds[frame.stage]
+			if frame.alerted || threshold <= 0 {
+				continue
+			}
+			if elapsed := now.Sub(frame.started); elapsed > threshold {
+				frame.alerted = true
+				stalls = append(stalls, Stall{
+					Method:    req.method,
+					Tool:      req.tool,
+					Stage:     frame.stage,
+					Elapsed:   elapsed,
+					Threshold: threshold,
+					Total:     now.Sub(req.started),
+				})
+			}
+		}
+	}
+	dump := len(stalls) > 0 && now.Sub(w.lastDump) >= defaultDumpInterval
+	if dump {
+		w.lastDump = now
+	}
+	bus := w.eventBus
+	w.mu.Unlock()
+
+	if len(stalls) == 0 {
+		return nil
+	}
+	sort.Slice(stalls, func(i, j int) bool { return stalls[i].Elapsed > stalls[j].Elapsed })
+
+	for _, s := range stalls {
+		w.logger.Error("interceptor chain stall detected",
+			"stage", string(s.Stage),
+			"method", s.Method,
+			"tool", s.Tool,
+			"elapsed", s.Elapsed.Round(time.Millisecond),
+			"threshold", s.Threshold,
+			"request_age", s.Total.Round(time.Millisecond),
+		)
+		if bus != nil {
+			bus.Publish(ctx, event.Event{
+				Type:     "watchdog.stall",
+				Source:   "watchdog",
+				Severity: event.SeverityWarning,
+				Payload: map[string]interface{}{
+					"stage":      string(s.Stage),
+					"method":     s.Method,
+					"tool":       s.Tool,
+					"elapsed_ms": s.Elapsed.Milliseconds(),
+					"threshold":  s.Threshold.String(),
+				},
+			})
+		}
+	}
+	// One dump per check (and at most one per minute) covers every stall
+	// found in this pass; dumps are large and all requests share the process.
+	if dump {
+		w.logger.Error("goroutine dump for stalled requests",
+			"stalls", len(stalls),
+			"goroutines", goroutineDump(),
+		)
+	}
+	return stalls
+}
+
+// goroutineDump returns the stacks of all goroutines, truncated to maxDumpBytes.
+func goroutineDump() string {
+	buf := make([]byte, maxDumpBytes)
+	n := runtime.Stack(buf, true)
+	return string(buf[:n])
+}
+
+// enter pushes a stage for request id; the returned function pops it.
+func (w *Watchdog) enter(id uint64, stage Stage) func() {
+	frame := &stageFrame{stage: stage}
+	w.mu.Lock()
+	req, ok := w.inflight[id]
+	if ok {
+		frame.started = w.now()
+		req.stages = append(req.stages, frame)
+	}
+	w.mu.Unlock()
+	if !ok {
+		return func() {}
+	}
+	return func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		req, ok := w.inflight[id]
+		if !ok {
+			return
+		}
+		for i := len(req.stages) - 1; i >= 0; i-- {
+			if req.stages[i] == frame {
+				req.stages = append(req.stages[:i], req.stages[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// setTool records the tool name of request id.
+func (w *Watchdog) setTool(id uint64, tool string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if req, ok := w.inflight[id]; ok {
+		req.tool = tool
+	}
+}
+
+// trackerKey is the context key type for the request tracker.
+type trackerKey struct{}
+
+// tracker binds a context to one tracked request.
+type tracker struct {
+	w  *Watchdog
+	id uint64
+}
+
+// Enter marks the request in ctx as entering stage and returns a function
+// that marks it as leaving. It is a no-op if ctx is not tracked.
+//
+//	defer watchdog.Enter(ctx, watchdog.StagePolicy)()
+func Enter(ctx context.Context, stage Stage) func() {
+	t, ok := ctx.Value(trackerKey{}).(*tracker)
+	if !ok {
+		return func() {}
+	}
+	return t.w.enter(t.id, stage)
+}
+
+// SetTool records the tool name of the request in ctx for stall reports.
+// It is a no-op if ctx is not tracked.
+func SetTool(ctx context.Context, tool string) {
+	if t, ok := ctx.Value(trackerKey{}).(*tracker); ok {
+		t.w.setTool(t.id, tool)
+	}
+}
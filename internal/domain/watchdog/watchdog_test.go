@@ -0,0 +1,113 @@
+package watchdog
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock for deterministic stall checks.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+func newTestWatchdog(thresholds map[Stage]time.Duration) (*Watchdog, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	w := New(thresholds, time.Second, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	w.now = clock.Now
+	return w, clock
+}
+
+func TestWatchdog_ReportsStalledStageOnce(t *testing.T) {
+	w, clock := newTestWatchdog(map[Stage]time.Duration{StagePolicy: 2 * time.Second})
+
+	ctx, done := w.Begin(context.Background(), "tools/call")
+	defer done()
+	SetTool(ctx, "read_file")
+	exit := Enter(ctx, StagePolicy)
+	defer exit()
+
+	clock.Advance(time.Second)
+	if stalls := w.Check(context.Background()); len(stalls) != 0 {
+		t.Fatalf("expected no stall below threshold, got %+v", stalls)
+	}
+
+	clock.Advance(2 * time.Second)
+	stalls := w.Check(context.Background())
+	if len(stalls) != 1 {
+		t.Fatalf("expected 1 stall, got %d", len(stalls))
+	}
+	s := stalls[0]
+	if s.Stage != StagePolicy || s.Tool != "read_file" || s.Method != "tools/call" {
+		t.Errorf("stall = %+v, want policy stage of read_file tools/call", s)
+	}
+	if s.Elapsed != 3*time.Second {
+		t.Errorf("Elapsed = %v, want 3s", s.Elapsed)
+	}
+
+	clock.Advance(10 * time.Second)
+	if stalls := w.Check(context.Background()); len(stalls) != 0 {
+		t.Errorf("expected stall to be reported once, got %+v", stalls)
+	}
+}
+
+func TestWatchdog_ExitedStageNotReported(t *testing.T) {
+	w, clock := newTestWatchdog(nil)
+
+	ctx, done := w.Begin(context.Background(), "tools/call")
+	exit := Enter(ctx, StageUpstream)
+	clock.Advance(time.Second)
+	exit()
+	clock.Advance(time.Hour)
+
+	if stalls := w.Check(context.Background()); len(stalls) != 0 {
+		t.Errorf("expected no stall after stage exit, got %+v", stalls)
+	}
+
+	done()
+	if n := w.InFlight(); n != 0 {
+		t.Errorf("InFlight() = %d after done, want 0", n)
+	}
+}
+
+func TestWatchdog_NestedStagesAndDisabledThreshold(t *testing.T) {
+	w, clock := newTestWatchdog(map[Stage]time.Duration{
+		StageApproval: 0, // disabled
+		StageUpstream: time.Second,
+	})
+
+	ctx, done := w.Begin(context.Background(), "tools/call")
+	defer done()
+	exitApproval := Enter(ctx, StageApproval)
+	exitUpstream := Enter(ctx, StageUpstream)
+	defer exitApproval()
+	defer exitUpstream()
+
+	clock.Advance(time.Hour)
+	stalls := w.Check(context.Background())
+	if len(stalls) != 1 || stalls[0].Stage != StageUpstream {
+		t.Errorf("stalls = %+v, want only upstream", stalls)
+	}
+}
+
+func TestEnter_UntrackedContextIsNoop(t *testing.T) {
+	exit := Enter(context.Background(), StagePolicy)
+	exit()
+	SetTool(context.Background(), "read_file")
+}
@@ -0,0 +1,367 @@
+package mockupstream
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultProtocolVersion is answered on initialize when the client does not
+// send a protocol version.
+const DefaultProtocolVersion = "2025-11-25"
+
+// maxMessageSize bounds one JSON-RPC message read from stdio or HTTP.
+const maxMessageSize = 10 * 1024 * 1024
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+// Server answers MCP requests from a Spec. It is safe for concurrent use.
+type Server struct {
+	spec   *Spec
+	tools  map[string]*tool
+	logger *slog.Logger
+	exit   func(code int)
+
+	mu    sync.Mutex // guards rng and calls
+	rng   *rand.Rand
+	calls map[string]int
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithLogger sets the logger. Defaults to discarding logs.
+func WithLogger(l *slog.Logger) Option {
+	return func(s *Server) { s.logger = l }
+}
+
+// WithSeed makes random latency jitter and failure injection reproducible.
+func WithSeed(seed int64) Option {
+	return func(s *Server) { s.rng = rand.New(rand.NewSource(seed)) }
+}
+
+// WithExit sets the function called by the crash failure mode.
+// Defaults to os.Exit.
+func WithExit(fn func(code int)) Option {
+	return func(s *Server) { s.exit = fn }
+}
+
+// NewServer creates a server for spec.
+func NewServer(spec *Spec, opts ...Option) (*Server, error) {
+	tools, err := compileTools(spec.Tools)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		spec:   spec,
+		tools:  tools,
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		exit:   os.Exit,
+		rng:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		calls:  make(map[string]int),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// rpcRequest is an incoming JSON-RPC request or notification.
+type rpcRequest struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// rpcError is a JSON-RPC error object.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Handle processes one JSON-RPC message and returns the response to send,
+// or nil for notifications and calls that never answer (timeout failures,
+// or ctx cancelled while waiting).
+func (s *Server) Handle(ctx context.Context, raw []byte) []byte {
+	var req rpcRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return encodeResponse(json.RawMessage("null"), nil, &rpcError{Code: codeParseError, Message: "Parse error"})
+	}
+	if len(req.ID) == 0 {
+		return nil
+	}
+
+	var result interface{}
+	var rerr *rpcError
+	switch req.Method {
+	case "initialize":
+		result = s.initializeResult(req.Params)
+	case "ping":
+		result = struct{}{}
+	case "tools/list":
+		result = map[string]interface{}{"tools": s.toolList()}
+	case "tools/call":
+		var ok bool
+		result, rerr, ok = s.callTool(ctx, req.Params)
+		if !ok {
+			return nil
+		}
+	default:
+		rerr = &rpcError{Code: codeMethodNotFound, Message: "Method not found: " + req.Method}
+	}
+	return encodeResponse(req.ID, result, rerr)
+}
+
+func encodeResponse(id json.RawMessage, result interface{}, rerr *rpcError) []byte {
+	resp := map[string]interface{}{"jsonrpc": "2.0", "id": id}
+	if rerr != nil {
+		resp["error"] = rerr
+	} else {
+		resp["result"] = result
+	}
+	data, _ := json.Marshal(resp)
+	return data
+}
+
+func (s *Server) initializeResult(params json.RawMessage) map[string]interface{} {
+	var p struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	_ = json.Unmarshal(params, &p)
+	if p.ProtocolVersion == "" {
+		p.ProtocolVersion = DefaultProtocolVersion
+	}
+	name, version := s.spec.Server.Name, s.spec.Server.Version
+	if name == "" {
+		name = "mock-upstream"
+	}
+	if version == "" {
+		version = "0.0.0"
+	}
+	result := map[string]interface{}{
+		"protocolVersion": p.ProtocolVersion,
+		"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+		"serverInfo":      map[string]string{"name": name, "version": version},
+	}
+	if s.spec.Server.Instructions != "" {
+		result["instructions"] = s.spec.Server.Instructions
+	}
+	return result
+}
+
+// toolList returns the tools in spec order.
+func (s *Server) toolList() []map[string]interface{} {
+	list := make([]map[string]interface{}, 0, len(s.spec.Tools))
+	for _, t := range s.spec.Tools {
+		schema := t.InputSchema
+		if schema == nil {
+			schema = map[string]interface{}{"type": "object"}
+		}
+		entry := map[string]interface{}{"name": t.Name, "inputSchema": schema}
+		if t.Description != "" {
+			entry["description"] = t.Description
+		}
+		list = append(list, entry)
+	}
+	return list
+}
+
+// callTool runs a tools/call. ok is false when no response must be sent.
+func (s *Server) callTool(ctx context.Context, params json.RawMessage) (result interface{}, rerr *rpcError, ok bool) {
+	var p struct {
+		Name      string                 `json:"name"`
+		Arguments map[string]interface{} `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil || p.Name == "" {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "Invalid params: missing tool name"}, true
+	}
+	t, found := s.tools[p.Name]
+	if !found {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "Unknown tool: " + p.Name}, true
+	}
+
+	fail, delay := s.plan(t)
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, nil, false
+		}
+	}
+
+	if fail {
+		f := t.failure
+		s.logger.Info("injecting failure", "tool", p.Name, "mode", f.Mode)
+		message := f.Message
+		if message == "" {
+			message = "injected failure"
+		}
+		switch f.Mode {
+		case FailureToolError:
+			return toolResult(message, nil, true), nil, true
+		case FailureTimeout:
+			<-ctx.Done()
+			return nil, nil, false
+		case FailureCrash:
+			s.exit(1)
+			return nil, nil, false
+		default:
+			code := f.Code
+			if code == 0 {
+				code = codeInternalError
+			}
+			return nil, &rpcError{Code: code, Message: message}, true
+		}
+	}
+
+	if p.Arguments == nil {
+		p.Arguments = map[string]interface{}{}
+	}
+	res, err := t.respond(p.Arguments)
+	if err != nil {
+		return nil, &rpcError{Code: codeInternalError, Message: err.Error()}, true
+	}
+	return res, nil, true
+}
+
+// plan counts the call and decides whether it fails and how long it waits.
+func (s *Server) plan(t *tool) (fail bool, delay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls[t.spec.Name]++
+	n := s.calls[t.spec.Name]
+	if f := t.failure; f != nil {
+		fail = (f.Every > 0 && n%f.Every == 0) || (f.Rate > 0 && s.rng.Float64() < f.Rate)
+	}
+	delay = t.latency
+	if t.jitter > 0 {
+		delay += time.Duration(s.rng.Int63n(int64(t.jitter) + 1))
+	}
+	return fail, delay
+}
+
+// respond builds the result of the first response matching args, or echoes
+// the arguments when the tool defines no matching response.
+func (t *tool) respond(args map[string]interface{}) (interface{}, error) {
+	for _, r := range t.responses {
+		if !r.matches(args) {
+			continue
+		}
+		text := ""
+		if r.text != nil {
+			var buf strings.Builder
+			data := map[string]interface{}{"Tool": t.spec.Name, "Arguments": args}
+			if err := r.text.Execute(&buf, data); err != nil {
+				return nil, fmt.Errorf("render response: %w", err)
+			}
+			text = buf.String()
+		} else if r.json != nil {
+			encoded, err := json.Marshal(r.json)
+			if err != nil {
+				return nil, fmt.Errorf("encode response: %w", err)
+			}
+			text = string(encoded)
+		}
+		return toolResult(text, r.json, r.isError), nil
+	}
+	echo, _ := json.Marshal(map[string]interface{}{"tool": t.spec.Name, "arguments": args})
+	return toolResult(string(echo), nil, false), nil
+}
+
+func (r response) matches(args map[string]interface{}) bool {
+	for k, want := range r.match {
+		got, ok := args[k]
+		if !ok || fmt.Sprint(got) != want {
+			return false
+		}
+	}
+	return true
+}
+
+func toolResult(text string, structured interface{}, isError bool) map[string]interface{} {
+	result := map[string]interface{}{
+		"content": []map[string]string{{"type": "text", "text": text}},
+		"isError": isError,
+	}
+	if structured != nil {
+		result["structuredContent"] = structured
+	}
+	return result
+}
+
+// ServeStdio reads newline-delimited JSON-RPC messages from r and writes the
+// responses to w until r is exhausted or ctx is cancelled. Requests are
+// handled concurrently, so a slow tool does not block the others.
+func (s *Server) ServeStdio(ctx context.Context, r io.Reader, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg      sync.WaitGroup
+		writeMu sync.Mutex
+	)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+	for scanner.Scan() {
+		line := append([]byte(nil), scanner.Bytes()...)
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp := s.Handle(ctx, line)
+			if resp == nil {
+				return
+			}
+			writeMu.Lock()
+			defer writeMu.Unlock()
+			_, _ = w.Write(append(resp, '\n'))
+		}()
+	}
+	// The client is gone: abandon calls still waiting.
+	cancel()
+	wg.Wait()
+	return scanner.Err()
+}
+
+// ServeHTTP implements the Streamable HTTP transport with plain JSON
+// responses. Notifications are acknowledged with 202 Accepted.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxMessageSize+1))
+	if err != nil || len(body) > maxMessageSize {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	resp := s.Handle(r.Context(), body)
+	if resp == nil {
+		if r.Context().Err() == nil {
+			w.WriteHeader(http.StatusAccepted)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(resp)
+}
@@ -0,0 +1,251 @@
+package mockupstream
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSpec = `
+server:
+  name: mock-files
+  version: 1.2.3
+tools:
+  - name: read_file
+    description: Read a file
+    input_schema:
+      type: object
+      properties:
+        path: {type: string}
+    responses:
+      - match: {path: /etc/passwd}
+        text: permission denied
+        is_error: true
+      - text: "contents of {{.Arguments.path}}"
+  - name: search
+    responses:
+      - json: {results: [a, b]}
+  - name: echo
+  - name: flaky
+    failure:
+      every: 2
+      code: -32000
+      message: backend unavailable
+  - name: hang
+    failure:
+      rate: 1
+      mode: timeout
+`
+
+func newTestServer(t *testing.T, opts ...Option) *Server {
+	t.Helper()
+	spec, err := ParseSpec([]byte(testSpec))
+	if err != nil {
+		t.Fatalf("ParseSpec: %v", err)
+	}
+	s, err := NewServer(spec, append([]Option{WithSeed(1)}, opts...)...)
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	return s
+}
+
+type testResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result struct {
+		ServerInfo struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"serverInfo"`
+		ProtocolVersion string `json:"protocolVersion"`
+		Tools           []struct {
+			Name        string                 `json:"name"`
+			InputSchema map[string]interface{} `json:"inputSchema"`
+		} `json:"tools"`
+		Content []struct {
+			Text string `json:"text"`
+		} `json:"content"`
+		StructuredContent map[string]interface{} `json:"structuredContent"`
+		IsError           bool                   `json:"isError"`
+	} `json:"result"`
+	Error *rpcError `json:"error"`
+}
+
+func call(t *testing.T, s *Server, ctx context.Context, raw string) *testResponse {
+	t.Helper()
+	out := s.Handle(ctx, []byte(raw))
+	if out == nil {
+		return nil
+	}
+	var resp testResponse
+	if err := json.Unmarshal(out, &resp); err != nil {
+		t.Fatalf("invalid response %s: %v", out, err)
+	}
+	return &resp
+}
+
+func callTool(t *testing.T, s *Server, name, args string) *testResponse {
+	t.Helper()
+	return call(t, s, context.Background(),
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"`+name+`","arguments":`+args+`}}`)
+}
+
+func TestServer_InitializeAndList(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	resp := call(t, s, ctx, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18"}}`)
+	if resp.Result.ServerInfo.Name != "mock-files" || resp.Result.ServerInfo.Version != "1.2.3" || resp.Result.ProtocolVersion != "2025-06-18" {
+		t.Errorf("initialize result = %+v", resp.Result)
+	}
+	if got := call(t, s, ctx, `{"jsonrpc":"2.0","method":"notifications/initialized"}`); got != nil {
+		t.Errorf("notification answered with %+v", got)
+	}
+
+	resp = call(t, s, ctx, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	if len(resp.Result.Tools) != 5 || resp.Result.Tools[0].Name != "read_file" {
+		t.Fatalf("tools/list = %+v, want 5 tools in spec order", resp.Result.Tools)
+	}
+	if resp.Result.Tools[2].InputSchema["type"] != "object" {
+		t.Errorf("default inputSchema = %v, want type object", resp.Result.Tools[2].InputSchema)
+	}
+
+	resp = call(t, s, ctx, `{"jsonrpc":"2.0","id":3,"method":"resources/list"}`)
+	if resp.Error == nil || resp.Error.Code != codeMethodNotFound {
+		t.Errorf("unknown method = %+v, want -32601", resp.Error)
+	}
+	resp = call(t, s, ctx, `{not json`)
+	if resp.Error == nil || resp.Error.Code != codeParseError {
+		t.Errorf("malformed input = %+v, want -32700", resp.Error)
+	}
+}
+
+func TestServer_CannedResponses(t *testing.T) {
+	s := newTestServer(t)
+
+	resp := callTool(t, s, "read_file", `{"path":"/tmp/a.txt"}`)
+	if resp.Result.IsError || resp.Result.Content[0].Text != "contents of /tmp/a.txt" {
+		t.Errorf("templated response = %+v", resp.Result)
+	}
+	resp = callTool(t, s, "read_file", `{"path":"/etc/passwd"}`)
+	if !resp.Result.IsError || resp.Result.Content[0].Text != "permission denied" {
+		t.Errorf("matched response = %+v, want error result", resp.Result)
+	}
+	resp = callTool(t, s, "search", `{}`)
+	if resp.Result.StructuredContent == nil || !strings.Contains(resp.Result.Content[0].Text, `"results"`) {
+		t.Errorf("json response = %+v, want structured and text content", resp.Result)
+	}
+	resp = callTool(t, s, "echo", `{"x":1}`)
+	if resp.Result.Content[0].Text != `{"arguments":{"x":1},"tool":"echo"}` {
+		t.Errorf("echo response = %q", resp.Result.Content[0].Text)
+	}
+	resp = callTool(t, s, "missing", `{}`)
+	if resp.Error == nil || resp.Error.Code != codeInvalidParams {
+		t.Errorf("unknown tool = %+v, want -32602", resp.Error)
+	}
+}
+
+func TestServer_FailureInjection(t *testing.T) {
+	exited := make(chan int, 1)
+	s := newTestServer(t, WithExit(func(code int) { exited <- code }))
+
+	if resp := callTool(t, s, "flaky", `{}`); resp.Error != nil {
+		t.Errorf("first flaky call = %+v, want success", resp.Error)
+	}
+	resp := callTool(t, s, "flaky", `{}`)
+	if resp.Error == nil || resp.Error.Code != -32000 || resp.Error.Message != "backend unavailable" {
+		t.Errorf("second flaky call = %+v, want injected -32000", resp.Error)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if resp := call(t, s, ctx, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"hang"}}`); resp != nil {
+		t.Errorf("timeout failure answered with %+v", resp)
+	}
+
+	spec, _ := ParseSpec([]byte("tools:\n  - name: boom\n    failure: {rate: 1, mode: crash}\n"))
+	crashing, _ := NewServer(spec, WithExit(func(code int) { exited <- code }))
+	_ = crashing.Handle(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"boom"}}`))
+	select {
+	case code := <-exited:
+		if code != 1 {
+			t.Errorf("crash exit code = %d, want 1", code)
+		}
+	default:
+		t.Error("crash failure did not exit")
+	}
+}
+
+func TestServer_Latency(t *testing.T) {
+	spec, err := ParseSpec([]byte("tools:\n  - name: slow\n    latency: 30ms\n"))
+	if err != nil {
+		t.Fatalf("ParseSpec: %v", err)
+	}
+	s, _ := NewServer(spec)
+	start := time.Now()
+	_ = callTool(t, s, "slow", `{}`)
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("call took %v, want at least 30ms", elapsed)
+	}
+}
+
+func TestServeStdio(t *testing.T) {
+	s := newTestServer(t)
+	in := strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}` + "\n" +
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}` + "\n" +
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo"}}` + "\n")
+	var out bytes.Buffer
+	if err := s.ServeStdio(context.Background(), in, &out); err != nil {
+		t.Fatalf("ServeStdio: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("stdio output = %q, want 2 responses", out.String())
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	srv := httptest.NewServer(newTestServer(t))
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL, "application/json", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	if err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var body testResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || len(body.Result.Tools) != 5 {
+		t.Errorf("tools/list over HTTP = %+v (err=%v)", body, err)
+	}
+
+	resp2, err := http.Post(srv.URL, "application/json", strings.NewReader(`{"jsonrpc":"2.0","method":"notifications/initialized"}`))
+	if err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	_ = resp2.Body.Close()
+	if resp2.StatusCode != http.StatusAccepted {
+		t.Errorf("notification status = %d, want 202", resp2.StatusCode)
+	}
+}
+
+func TestParseSpec_Invalid(t *testing.T) {
+	for name, doc := range map[string]string{
+		"no tools":     "server: {name: x}\n",
+		"missing name": "tools:\n  - description: x\n",
+		"duplicate":    "tools:\n  - name: a\n  - name: a\n",
+		"bad latency":  "tools:\n  - name: a\n    latency: soon\n",
+		"bad rate":     "tools:\n  - name: a\n    failure: {rate: 2}\n",
+		"bad mode":     "tools:\n  - name: a\n    failure: {rate: 1, mode: explode}\n",
+		"bad template": "tools:\n  - name: a\n    responses:\n      - text: '{{.Arguments'\n",
+		"unknown key":  "tools:\n  - name: a\n    reponses: []\n",
+	} {
+		if _, err := ParseSpec([]byte(doc)); err == nil {
+			t.Errorf("%s: ParseSpec succeeded, want error", name)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+// Package mockupstream implements a configurable fake MCP server for policy
+// and integration testing. Tools, their canned responses, latency and
+// injected failures are described in a YAML file; the server speaks MCP over
+// stdio (newline-delimited JSON-RPC) or Streamable HTTP (JSON responses).
+package mockupstream
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Failure modes of a tool call.
+const (
+	// FailureError answers with a JSON-RPC error.
+	FailureError = "error"
+	// FailureToolError answers with a tool result flagged isError.
+	FailureToolError = "tool_error"
+	// FailureTimeout never answers.
+	FailureTimeout = "timeout"
+	// FailureCrash terminates the server.
+	FailureCrash = "crash"
+)
+
+// Spec is the YAML description of a mock upstream.
+type Spec struct {
+	Server ServerSpec `yaml:"server"`
+	Tools  []ToolSpec `yaml:"tools"`
+}
+
+// ServerSpec is the identity the server reports on initialize.
+type ServerSpec struct {
+	// Name defaults to "mock-upstream".
+	Name string `yaml:"name"`
+	// Version defaults to "0.0.0".
+	Version      string `yaml:"version"`
+	Instructions string `yaml:"instructions"`
+}
+
+// ToolSpec describes one tool.
+type ToolSpec struct {
+	Name        string                 `yaml:"name"`
+	Description string                 `yaml:"description"`
+	InputSchema map[string]interface{} `yaml:"input_schema"`
+	// Latency delays every call by this duration (e.g. "250ms").
+	Latency string `yaml:"latency"`
+	// Jitter adds a random delay between 0 and this duration.
+	Jitter string `yaml:"jitter"`
+	// Responses are tried in order; the first whose Match fits the call
+	// arguments is returned. Without responses the arguments are echoed.
+	Responses []ResponseSpec `yaml:"responses"`
+	Failure   *FailureSpec   `yaml:"failure"`
+}
+
+// ResponseSpec is a canned tool result.
+type ResponseSpec struct {
+	// Match lists argument values that must all be equal (compared as
+	// strings) for this response to apply. An empty Match always applies.
+	Match map[string]interface{} `yaml:"match"`
+	// Text is a Go template rendered with .Tool and .Arguments.
+	Text string `yaml:"text"`
+	// JSON is returned as structuredContent and, when Text is empty, as
+	// the JSON-encoded text content.
+	JSON    interface{} `yaml:"json"`
+	IsError bool        `yaml:"is_error"`
+}
+
+// FailureSpec injects failures into tool calls.
+type FailureSpec struct {
+	// Rate is the probability (0-1) that a call fails.
+	Rate float64 `yaml:"rate"`
+	// Every makes every Nth call fail, deterministically.
+	Every int `yaml:"every"`
+	// Mode is error (default), tool_error, timeout or crash.
+	Mode    string `yaml:"mode"`
+	Code    int    `yaml:"code"`
+	Message string `yaml:"message"`
+}
+
+// tool is a validated ToolSpec.
+type tool struct {
+	spec      ToolSpec
+	latency   time.Duration
+	jitter    time.Duration
+	responses []response
+	failure   *FailureSpec
+}
+
+// response is a ResponseSpec with its text template parsed.
+type response struct {
+	match   map[string]string
+	text    *template.Template
+	json    interface{}
+	isError bool
+}
+
+// LoadSpec reads and validates a mock upstream YAML file.
+func LoadSpec(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSpec(data)
+}
+
+// ParseSpec parses and validates a mock upstream YAML document.
+func ParseSpec(data []byte) (*Spec, error) {
+	var spec Spec
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("parse mock upstream spec: %w", err)
+	}
+	if _, err := compileTools(spec.Tools); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+// compileTools validates the tool specs and returns them keyed by name.
+func compileTools(specs []ToolSpec) (map[string]*tool, error) {
+	if len(specs) == 0 {
+		return nil, errors.New("mock upstream spec defines no tools")
+	}
+	tools := make(map[string]*tool, len(specs))
+	for i, s := range specs {
+		if s.Name == "" {
+			return nil, fmt.Errorf("tools[%d]: name is required", i)
+		}
+		if _, dup := tools[s.Name]; dup {
+			return nil, fmt.Errorf("tools[%d]: duplicate tool %q", i, s.Name)
+		}
+		t := &tool{spec: s, failure: s.Failure}
+		var err error
+		if t.latency, err = parseOptionalDuration(s.Latency); err != nil {
+			return nil, fmt.Errorf("tool %q: latency: %w", s.Name, err)
+		}
+		if t.jitter, err = parseOptionalDuration(s.Jitter); err != nil {
+			return nil, fmt.Errorf("tool %q: jitter: %w", s.Name, err)
+		}
+		for j, r := range s.Responses {
+			compiled := response{json: r.JSON, isError: r.IsError}
+			if r.Text != "" {
+				compiled.text, err = template.New(s.Name).Option("missingkey=zero").Parse(r.Text)
+				if err != nil {
+					return nil, fmt.Errorf("tool %q: responses[%d].text: %w", s.Name, j, err)
+				}
+			}
+			if len(r.Match) > 0 {
+				compiled.match = make(map[string]string, len(r.Match))
+				for k, v := range r.Match {
+					compiled.match[k] = fmt.Sprint(v)
+				}
+			}
+			t.responses = append(t.responses, compiled)
+		}
+		if f := s.Failure; f != nil {
+			if f.Rate < 0 || f.Rate > 1 {
+				return nil, fmt.Errorf("tool %q: failure.rate must be between 0 and 1, got %v", s.Name, f.Rate)
+			}
+			if f.Every < 0 {
+				return nil, fmt.Errorf("tool %q: failure.every must be >= 0, got %d", s.Name, f.Every)
+			}
+			switch f.Mode {
+			case "", FailureError, FailureToolError, FailureTimeout, FailureCrash:
+			default:
+				return nil, fmt.Errorf("tool %q: unknown failure.mode %q (want error, tool_error, timeout or crash)", s.Name, f.Mode)
+			}
+		}
+		tools[s.Name] = t
+	}
+	return tools, nil
+}
+
+func parseOptionalDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("must not be negative, got %s", s)
+	}
+	return d, nil
+}
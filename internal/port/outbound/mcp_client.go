@@ -21,3 +21,17 @@ type MCPClient interface {
 	// Close terminates the upstream connection and cleans up resources.
 	Close() error
 }
+
+// ReverseConn is a message connection opened by an upstream MCP server that
+// dialed in to the gateway (reverse upstream). Each message is one JSON-RPC
+// message.
+type ReverseConn interface {
+	// ReadMessage blocks until the next message arrives.
+	ReadMessage() ([]byte, error)
+
+	// WriteMessage sends one message.
+	WriteMessage(msg []byte) error
+
+	// Close closes the connection; blocked reads and writes return.
+	Close() error
+}
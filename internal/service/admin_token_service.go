@@ -0,0 +1,330 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/auth"
+	"github.com/google/uuid"
+)
+
+// Admin token scopes. Each scope grants a slice of the admin API; a token
+// can hold several.
+const (
+	// AdminScopeReadOnly allows reading everything except audit data.
+	AdminScopeReadOnly = "read-only"
+	// AdminScopePoliciesWrite allows managing policies and policy variables.
+	AdminScopePoliciesWrite = "policies:write"
+	// AdminScopeUpstreamsWrite allows managing upstreams.
+	AdminScopeUpstreamsWrite = "upstreams:write"
+	// AdminScopeAuditRead allows reading the audit log and recordings.
+	AdminScopeAuditRead = "audit:read"
+)
+
+// AdminTokenScopes lists the valid admin token scopes.
+var AdminTokenScopes = []string{AdminScopeReadOnly, AdminScopePoliciesWrite, AdminScopeUpstreamsWrite, AdminScopeAuditRead}
+
+// AdminTokenPrefix starts every admin token, so the admin API can tell
+// them apart from MCP API keys.
+const AdminTokenPrefix = "sgat_"
+
+// adminTokenPrefixLen is the length of the token prefix stored for lookup.
+const adminTokenPrefixLen = 12
+
+// maxAdminTokens bounds the tokens kept in state.json, revoked included.
+const maxAdminTokens = 100
+
+var (
+	// ErrAdminTokenNotFound is returned for an unknown admin token ID.
+	ErrAdminTokenNotFound = errors.New("admin token not found")
+	// ErrInvalidAdminToken is returned when a token does not authenticate:
+	// unknown, revoked or expired.
+	ErrInvalidAdminToken = errors.New("invalid admin token")
+	// ErrInvalidAdminTokenInput is returned for a bad name, scope or expiry.
+	ErrInvalidAdminTokenInput = errors.New("invalid admin token request")
+)
+
+// AdminToken is an admin API token as listed by the admin API. The
+// cleartext token is only returned once, on creation.
+type AdminToken struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	Scopes    []string   `json:"scopes"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Revoked   bool       `json:"revoked"`
+}
+
+// Expired reports whether the token has expired at now.
+func (t *AdminToken) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// HasScope reports whether the token was granted scope.
+func (t *AdminToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateAdminTokenInput holds the fields of a new admin token.
+type CreateAdminTokenInput struct {
+	Name      string
+	Scopes    []string
+	ExpiresAt *time.Time
+}
+
+// AdminTokenService manages the scoped tokens used to automate the admin
+// API, e.g. from CI pipelines. Tokens are stored as Argon2id hashes in
+// state.json; a verified token is remembered by its SHA-256 so repeated
+// requests do not pay for Argon2id again.
+type AdminTokenService struct {
+	stateStore state.Store
+	logger     *slog.Logger
+
+	mu       sync.Mutex
+	tokens   []state.AdminTokenEntry
+	verified map[[sha256.Size]byte]string // token digest -> token ID
+}
+
+// NewAdminTokenService creates an AdminTokenService.
+// stateStore may be nil, in which case tokens last until restart.
+func NewAdminTokenService(stateStore state.Store, logger *slog.Logger) *AdminTokenService {
+	return &AdminTokenService{
+		stateStore: stateStore,
+		logger:     logger,
+		verified:   make(map[[sha256.Size]byte]string),
+	}
+}
+
+// Load replaces the tokens with the persisted entries.
+func (s *AdminTokenService) Load(entries []state.AdminTokenEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = append([]state.AdminTokenEntry(nil), entries...)
+	s.verified = make(map[[sha256.Size]byte]string)
+}
+
+// List returns all tokens, revoked and expired included, in creation order.
+func (s *AdminTokenService) List() []AdminToken {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]AdminToken, 0, len(s.tokens))
+	for _, e := range s.tokens {
+		out = append(out, adminTokenFromEntry(e))
+	}
+	return out
+}
+
+// Create generates a new token and returns it with its cleartext, which is
+// not stored and cannot be retrieved again.
+func (s *AdminTokenService) Create(_ context.Context, input CreateAdminTokenInput) (*AdminToken, string, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, "", fmt.Errorf("%w: name is required", ErrInvalidAdminTokenInput)
+	}
+	scopes, err := normalizeAdminScopes(input.Scopes)
+	if err != nil {
+		return nil, "", err
+	}
+	now := time.Now().UTC()
+	var expiresAt *time.Time
+	if input.ExpiresAt != nil {
+		if !input.ExpiresAt.After(now) {
+			return nil, "", fmt.Errorf("%w: expires_at must be in the future", ErrInvalidAdminTokenInput)
+		}
+		t := input.ExpiresAt.UTC()
+		expiresAt = &t
+	}
+
+	// Generate and hash before taking the lock (Argon2id is CPU-intensive).
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", fmt.Errorf("generate random token: %w", err)
+	}
+	cleartext := AdminTokenPrefix + hex.EncodeToString(raw)
+	hash, err := auth.HashKeyArgon2id(cleartext)
+	if err != nil {
+		return nil, "", fmt.Errorf("hash token: %w", err)
+	}
+
+	entry := state.AdminTokenEntry{
+		ID:          uuid.New().String(),
+		Name:        name,
+		TokenHash:   hash,
+		TokenPrefix: cleartext[:adminTokenPrefixLen],
+		Scopes:      scopes,
+		CreatedAt:   now,
+		ExpiresAt:   expiresAt,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.tokens) >= maxAdminTokens {
+		return nil, "", fmt.Errorf("%w: at most %d tokens, delete revoked ones first", ErrInvalidAdminTokenInput, maxAdminTokens)
+	}
+	tokens := append(append([]state.AdminTokenEntry(nil), s.tokens...), entry)
+	if err := s.persistLocked(tokens); err != nil {
+		return nil, "", err
+	}
+	s.tokens = tokens
+
+	s.logger.Info("admin token created", "id", entry.ID, "name", name, "scopes", scopes)
+	token := adminTokenFromEntry(entry)
+	return &token, cleartext, nil
+}
+
+// Revoke revokes a token. Requests using it are rejected from then on.
+// Revoking a revoked token deletes it.
+func (s *AdminTokenService) Revoke(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := -1
+	for j, e := range s.tokens {
+		if e.ID == id {
+			i = j
+			break
+		}
+	}
+	if i < 0 {
+		return ErrAdminTokenNotFound
+	}
+	tokens := append([]state.AdminTokenEntry(nil), s.tokens...)
+	if tokens[i].Revoked {
+		tokens = append(tokens[:i], tokens[i+1:]...)
+	} else {
+		tokens[i].Revoked = true
+	}
+	if err := s.persistLocked(tokens); err != nil {
+		return err
+	}
+	s.tokens = tokens
+	for digest, tokenID := range s.verified {
+		if tokenID == id {
+			delete(s.verified, digest)
+		}
+	}
+	s.logger.Info("admin token revoked", "id", id)
+	return nil
+}
+
+// Reset drops all tokens from memory. Used by factory reset, which clears
+// them from state.json itself.
+func (s *AdminTokenService) Reset() {
+	s.Load(nil)
+}
+
+// Authenticate returns the token matching cleartext. Returns
+// ErrInvalidAdminToken if it is unknown, revoked or expired.
+func (s *AdminTokenService) Authenticate(cleartext string) (*AdminToken, error) {
+	if !strings.HasPrefix(cleartext, AdminTokenPrefix) || len(cleartext) < adminTokenPrefixLen {
+		return nil, ErrInvalidAdminToken
+	}
+	digest := sha256.Sum256([]byte(cleartext))
+	now := time.Now()
+
+	s.mu.Lock()
+	if id, ok := s.verified[digest]; ok {
+		for _, e := range s.tokens {
+			if e.ID == id {
+				s.mu.Unlock()
+				return usableAdminToken(e, now)
+			}
+		}
+	}
+	var candidates []state.AdminTokenEntry
+	for _, e := range s.tokens {
+		if e.TokenPrefix == cleartext[:adminTokenPrefixLen] {
+			candidates = append(candidates, e)
+		}
+	}
+	s.mu.Unlock()
+
+	// Verify outside the lock: Argon2id takes tens of milliseconds.
+	for _, e := range candidates {
+		match, err := auth.VerifyKey(cleartext, e.TokenHash)
+		if err != nil || !match {
+			continue
+		}
+		s.mu.Lock()
+		s.verified[digest] = e.ID
+		s.mu.Unlock()
+		return usableAdminToken(e, now)
+	}
+	return nil, ErrInvalidAdminToken
+}
+
+// usableAdminToken returns the token of e, or ErrInvalidAdminToken if it is
+// revoked or expired at now.
+func usableAdminToken(e state.AdminTokenEntry, now time.Time) (*AdminToken, error) {
+	token := adminTokenFromEntry(e)
+	if token.Revoked || token.Expired(now) {
+		return nil, ErrInvalidAdminToken
+	}
+	return &token, nil
+}
+
+func (s *AdminTokenService) persistLocked(tokens []state.AdminTokenEntry) error {
+	if s.stateStore == nil {
+		return nil
+	}
+	if err := s.stateStore.Mutate(func(appState *state.AppState) error {
+		appState.AdminTokens = tokens
+		return nil
+	}); err != nil {
+		return fmt.Errorf("persist admin tokens: %w", err)
+	}
+	return nil
+}
+
+// normalizeAdminScopes validates scopes and removes duplicates.
+func normalizeAdminScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", ErrInvalidAdminTokenInput)
+	}
+	out := make([]string, 0, len(scopes))
+	seen := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		valid := false
+		for _, known := range AdminTokenScopes {
+			if scope == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("%w: unknown scope %q (valid: %s)", ErrInvalidAdminTokenInput, scope, strings.Join(AdminTokenScopes, ", "))
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			out = append(out, scope)
+		}
+	}
+	return out, nil
+}
+
+func adminTokenFromEntry(e state.AdminTokenEntry) AdminToken {
+	return AdminToken{
+		ID:        e.ID,
+		Name:      e.Name,
+		Prefix:    e.TokenPrefix,
+		Scopes:    append([]string(nil), e.Scopes...),
+		CreatedAt: e.CreatedAt,
+		ExpiresAt: e.ExpiresAt,
+		Revoked:   e.Revoked,
+	}
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+)
+
+func TestAdminTokenService(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	stateStore := state.NewFileStateStore(filepath.Join(t.TempDir(), "state.json"), logger)
+	if err := stateStore.Save(stateStore.DefaultState()); err != nil {
+		t.Fatalf("save default state: %v", err)
+	}
+	svc := NewAdminTokenService(stateStore, logger)
+	ctx := context.Background()
+
+	token, cleartext, err := svc.Create(ctx, CreateAdminTokenInput{
+		Name:   "ci",
+		Scopes: []string{AdminScopePoliciesWrite, AdminScopeReadOnly, AdminScopePoliciesWrite},
+	})
+	if err != nil {
+		t.Fatalf("Create(): %v", err)
+	}
+	if !strings.HasPrefix(cleartext, AdminTokenPrefix) || token.Prefix != cleartext[:adminTokenPrefixLen] {
+		t.Errorf("token = %q, prefix %q", cleartext, token.Prefix)
+	}
+	if len(token.Scopes) != 2 {
+		t.Errorf("Scopes = %v, want duplicates removed", token.Scopes)
+	}
+
+	// Twice: the second time hits the verified cache.
+	for i := 0; i < 2; i++ {
+		got, err := svc.Authenticate(cleartext)
+		if err != nil || got.ID != token.ID || !got.HasScope(AdminScopePoliciesWrite) {
+			t.Fatalf("Authenticate() = %+v, %v", got, err)
+		}
+	}
+	if _, err := svc.Authenticate(cleartext[:len(cleartext)-1] + "x"); !errors.Is(err, ErrInvalidAdminToken) {
+		t.Errorf("Authenticate() wrong token error = %v", err)
+	}
+	if _, err := svc.Authenticate("sg_" + cleartext[len(AdminTokenPrefix):]); !errors.Is(err, ErrInvalidAdminToken) {
+		t.Errorf("Authenticate() API key error = %v", err)
+	}
+
+	// Tokens survive a restart.
+	appState, err := stateStore.Load()
+	if err != nil {
+		t.Fatalf("Load state: %v", err)
+	}
+	reloaded := NewAdminTokenService(stateStore, logger)
+	reloaded.Load(appState.AdminTokens)
+	if _, err := reloaded.Authenticate(cleartext); err != nil {
+		t.Errorf("Authenticate() after reload: %v", err)
+	}
+
+	// Revoking rejects the token, even when cached; revoking again deletes it.
+	if err := svc.Revoke(ctx, token.ID); err != nil {
+		t.Fatalf("Revoke(): %v", err)
+	}
+	if _, err := svc.Authenticate(cleartext); !errors.Is(err, ErrInvalidAdminToken) {
+		t.Errorf("Authenticate() revoked token error = %v", err)
+	}
+	if list := svc.List(); len(list) != 1 || !list[0].Revoked {
+		t.Errorf("List() = %+v, want the revoked token", list)
+	}
+	if err := svc.Revoke(ctx, token.ID); err != nil || len(svc.List()) != 0 {
+		t.Errorf("second Revoke() = %v, %d tokens left", err, len(svc.List()))
+	}
+	if err := svc.Revoke(ctx, token.ID); !errors.Is(err, ErrAdminTokenNotFound) {
+		t.Errorf("Revoke() unknown error = %v", err)
+	}
+}
+
+func TestAdminTokenService_Expiry(t *testing.T) {
+	svc := NewAdminTokenService(nil, slog.Default())
+	ctx := context.Background()
+
+	expires := time.Now().Add(time.Hour)
+	token, cleartext, err := svc.Create(ctx, CreateAdminTokenInput{Name: "short", Scopes: []string{AdminScopeAuditRead}, ExpiresAt: &expires})
+	if err != nil {
+		t.Fatalf("Create(): %v", err)
+	}
+	if _, err := svc.Authenticate(cleartext); err != nil {
+		t.Fatalf("Authenticate(): %v", err)
+	}
+	past := time.Now().Add(-time.Minute)
+	svc.mu.Lock()
+	svc.tokens[0].ExpiresAt = &past
+	svc.mu.Unlock()
+	if _, err := svc.Authenticate(cleartext); !errors.Is(err, ErrInvalidAdminToken) {
+		t.Errorf("Authenticate() expired token error = %v", err)
+	}
+	if !svc.List()[0].Expired(time.Now()) || token.Expired(time.Now()) {
+		t.Error("Expired() mismatch")
+	}
+}
+
+func TestAdminTokenService_CreateInvalid(t *testing.T) {
+	svc := NewAdminTokenService(nil, slog.Default())
+	past := time.Now().Add(-time.Hour)
+	tests := []CreateAdminTokenInput{
+		{Name: "", Scopes: []string{AdminScopeReadOnly}},
+		{Name: "ci"},
+		{Name: "ci", Scopes: []string{"admin"}},
+		{Name: "ci", Scopes: []string{AdminScopeReadOnly}, ExpiresAt: &past},
+	}
+	for _, in := range tests {
+		if _, _, err := svc.Create(context.Background(), in); !errors.Is(err, ErrInvalidAdminTokenInput) {
+			t.Errorf("Create(%+v) error = %v, want ErrInvalidAdminTokenInput", in, err)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"runtime/metrics"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultAdmissionCheckInterval is how often memory and goroutines are sampled.
+const DefaultAdmissionCheckInterval = time.Second
+
+// DefaultAdmissionRetryAfter is the Retry-After sent with shed requests.
+const DefaultAdmissionRetryAfter = 5 * time.Second
+
+// admissionResumeRatio is the fraction of a threshold the process must fall
+// below before shedding stops, so it does not flap around the threshold.
+const admissionResumeRatio = 0.9
+
+// AdmissionConfig configures the admission controller. A zero threshold
+// disables that signal.
+type AdmissionConfig struct {
+	// MemoryThreshold is the Go runtime memory in bytes (heap, stacks and
+	// runtime overhead, excluding memory returned to the OS) above which
+	// low-priority requests are shed.
+	MemoryThreshold uint64
+	// GoroutineThreshold is the goroutine count above which low-priority
+	// requests are shed.
+	GoroutineThreshold int
+	// RetryAfter is sent to shed clients. Defaults to DefaultAdmissionRetryAfter.
+	RetryAfter time.Duration
+}
+
+// AdmissionStatus is a snapshot of the admission controller.
+type AdmissionStatus struct {
+	Shedding           bool              `json:"shedding"`
+	Since              *time.Time        `json:"since,omitempty"`
+	MemoryBytes        uint64            `json:"memory_bytes"`
+	MemoryThreshold    uint64            `json:"memory_threshold,omitempty"`
+	Goroutines         int               `json:"goroutines"`
+	GoroutineThreshold int               `json:"goroutine_threshold,omitempty"`
+	Shed               map[string]uint64 `json:"shed"`
+}
+
+// AdmissionController samples process memory and goroutine count and
+// reports overload while either is above its threshold. Transports then shed
+// low-priority traffic (list requests, non-essential notifications) and keep
+// serving tool calls and the admin API. Shed counts are logged when an
+// overload episode ends.
+type AdmissionController struct {
+	cfg    AdmissionConfig
+	logger *slog.Logger
+
+	// readMemory and numGoroutine are replaced in tests.
+	readMemory   func() uint64
+	numGoroutine func() int
+
+	shedding atomic.Bool
+
+	mu         sync.Mutex
+	since      time.Time
+	memory     uint64
+	goroutines int
+	shed       map[string]uint64 // since start, by class
+	episode    map[string]uint64 // current overload episode, by class
+}
+
+// NewAdmissionController creates an AdmissionController.
+func NewAdmissionController(cfg AdmissionConfig, logger *slog.Logger) *AdmissionController {
+	if cfg.RetryAfter <= 0 {
+		cfg.RetryAfter = DefaultAdmissionRetryAfter
+	}
+	return &AdmissionController{
+		cfg:          cfg,
+		logger:       logger,
+		readMemory:   runtimeMemory,
+		numGoroutine: runtime.NumGoroutine,
+		shed:         make(map[string]uint64),
+		episode:      make(map[string]uint64),
+	}
+}
+
+// runtimeMemory returns the memory mapped by the Go runtime minus what was
+// returned to the OS, close to the resident size of a Go process.
+func runtimeMemory() uint64 {
+	samples := []metrics.Sample{
+		{Name: "/memory/classes/total:bytes"},
+		{Name: "/memory/classes/heap/released:bytes"},
+	}
+	metrics.Read(samples)
+	if samples[0].Value.Kind() != metrics.KindUint64 || samples[1].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return samples[0].Value.Uint64() - samples[1].Value.Uint64()
+}
+
+// Run samples every interval until ctx is cancelled.
+func (a *AdmissionController) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultAdmissionCheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.Check()
+		}
+	}
+}
+
+// Check takes one sample and starts or stops shedding.
+func (a *AdmissionController) Check() {
+	memory := a.readMemory()
+	goroutines := a.numGoroutine()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.memory, a.goroutines = memory, goroutines
+
+	if !a.shedding.Load() {
+		if !a.above(memory, goroutines, 1) {
+			return
+		}
+		a.since = time.Now().UTC()
+		a.episode = make(map[string]uint64)
+		a.shedding.Store(true)
+		a.logger.Warn("admission control: shedding low-priority requests",
+			"memory_bytes", memory, "memory_threshold", a.cfg.MemoryThreshold,
+			"goroutines", goroutines, "goroutine_threshold", a.cfg.GoroutineThreshold)
+		return
+	}
+	if a.above(memory, goroutines, admissionResumeRatio) {
+		return
+	}
+	a.shedding.Store(false)
+	args := []any{"duration", time.Since(a.since).Round(time.Second),
+		"memory_bytes", memory, "goroutines", goroutines}
+	for _, class := range sortedShedClasses(a.episode) {
+		args = append(args, "shed_"+class, a.episode[class])
+	}
+	a.logger.Info("admission control: load shedding stopped", args...)
+}
+
+// above reports whether memory or goroutines exceed ratio times their
+// threshold. Caller holds a.mu.
+func (a *AdmissionController) above(memory uint64, goroutines int, ratio float64) bool {
+	if t := a.cfg.MemoryThreshold; t > 0 && float64(memory) > float64(t)*ratio {
+		return true
+	}
+	if t := a.cfg.GoroutineThreshold; t > 0 && float64(goroutines) > float64(t)*ratio {
+		return true
+	}
+	return false
+}
+
+// Overloaded reports whether low-priority requests should be shed.
+func (a *AdmissionController) Overloaded() bool {
+	return a.shedding.Load()
+}
+
+// RetryAfter is how long shed clients are asked to wait.
+func (a *AdmissionController) RetryAfter() time.Duration {
+	return a.cfg.RetryAfter
+}
+
+// RecordShed counts one shed request of the given class.
+func (a *AdmissionController) RecordShed(class string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.shed[class]++
+	a.episode[class]++
+}
+
+// Status returns the current state and the shed counts since start.
+func (a *AdmissionController) Status() AdmissionStatus {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	st := AdmissionStatus{
+		Shedding:           a.shedding.Load(),
+		MemoryBytes:        a.memory,
+		MemoryThreshold:    a.cfg.MemoryThreshold,
+		Goroutines:         a.goroutines,
+		GoroutineThreshold: a.cfg.GoroutineThreshold,
+		Shed:               make(map[string]uint64, len(a.shed)),
+	}
+	if st.Shedding {
+		since := a.since
+		st.Since = &since
+	}
+	for k, v := range a.shed {
+		st.Shed[k] = v
+	}
+	return st
+}
+
+// sortedShedClasses returns the classes counted in m in order.
+func sortedShedClasses(m map[string]uint64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
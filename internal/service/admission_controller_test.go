@@ -0,0 +1,103 @@
+package service
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func newTestAdmissionController(cfg AdmissionConfig, memory *uint64, goroutines *int) *AdmissionController {
+	a := NewAdmissionController(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	a.readMemory = func() uint64 { return *memory }
+	a.numGoroutine = func() int { return *goroutines }
+	return a
+}
+
+func TestAdmissionController_MemoryHysteresis(t *testing.T) {
+	memory, goroutines := uint64(50), 10
+	a := newTestAdmissionController(AdmissionConfig{MemoryThreshold: 100}, &memory, &goroutines)
+
+	a.Check()
+	if a.Overloaded() {
+		t.Fatal("should not shed below the threshold")
+	}
+
+	memory = 120
+	a.Check()
+	if !a.Overloaded() {
+		t.Fatal("should shed above the memory threshold")
+	}
+	if st := a.Status(); !st.Shedding || st.Since == nil || st.MemoryBytes != 120 {
+		t.Errorf("status = %+v", st)
+	}
+
+	// Between 90% and 100% of the threshold shedding continues.
+	memory = 95
+	a.Check()
+	if !a.Overloaded() {
+		t.Fatal("should keep shedding until memory falls below 90% of the threshold")
+	}
+
+	memory = 80
+	a.Check()
+	if a.Overloaded() {
+		t.Fatal("should stop shedding below 90% of the threshold")
+	}
+	if st := a.Status(); st.Shedding || st.Since != nil {
+		t.Errorf("status = %+v", st)
+	}
+}
+
+func TestAdmissionController_GoroutineThreshold(t *testing.T) {
+	memory, goroutines := uint64(1<<40), 10
+	// No memory threshold: memory is ignored.
+	a := newTestAdmissionController(AdmissionConfig{GoroutineThreshold: 100}, &memory, &goroutines)
+
+	a.Check()
+	if a.Overloaded() {
+		t.Fatal("memory should be ignored without a memory threshold")
+	}
+	goroutines = 101
+	a.Check()
+	if !a.Overloaded() {
+		t.Fatal("should shed above the goroutine threshold")
+	}
+}
+
+func TestAdmissionController_ShedCounts(t *testing.T) {
+	memory, goroutines := uint64(200), 1
+	a := newTestAdmissionController(AdmissionConfig{MemoryThreshold: 100}, &memory, &goroutines)
+	if a.RetryAfter() != DefaultAdmissionRetryAfter {
+		t.Errorf("RetryAfter = %v, want %v", a.RetryAfter(), DefaultAdmissionRetryAfter)
+	}
+
+	a.Check()
+	a.RecordShed("list")
+	a.RecordShed("list")
+	a.RecordShed("notification")
+	memory = 10
+	a.Check()
+
+	memory = 200
+	a.Check()
+	a.RecordShed("list")
+
+	st := a.Status()
+	if st.Shed["list"] != 3 || st.Shed["notification"] != 1 {
+		t.Errorf("shed = %v, want list=3 notification=1", st.Shed)
+	}
+	if got := a.episode["list"]; got != 1 {
+		t.Errorf("episode list count = %d, want 1 after a new episode", got)
+	}
+}
+
+func TestNewAdmissionController_RetryAfter(t *testing.T) {
+	a := NewAdmissionController(AdmissionConfig{RetryAfter: 30 * time.Second}, slog.Default())
+	if a.RetryAfter() != 30*time.Second {
+		t.Errorf("RetryAfter = %v, want 30s", a.RetryAfter())
+	}
+	if a.readMemory() == 0 {
+		t.Error("runtime memory should be non-zero")
+	}
+}
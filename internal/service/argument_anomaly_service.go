@@ -0,0 +1,578 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/action"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/event"
+)
+
+// DefaultArgumentProfileFlushInterval is how often argument profiles are
+// persisted to state.json.
+const DefaultArgumentProfileFlushInterval = 5 * time.Minute
+
+// Argument profile limits. They bound the memory and state.json space of
+// profiles whatever the tools receive.
+const (
+	// maxProfiledTools caps the tools profiled; calls of further tools are
+	// neither scored nor learned.
+	maxProfiledTools = 1000
+	// maxProfiledArgs caps the argument paths profiled per tool.
+	maxProfiledArgs = 64
+	// maxArgValues is the number of distinct string values tracked per
+	// argument; an argument with more is high-cardinality and its values
+	// are no longer scored.
+	maxArgValues = 50
+	// maxArgValueLen is the longest string value tracked as a value.
+	maxArgValueLen = 128
+	// maxArgPrefixes caps the path prefixes tracked per argument.
+	maxArgPrefixes = 200
+	// maxArgDepth and maxArgListItems bound the walk of nested arguments.
+	maxArgDepth     = 4
+	maxArgListItems = 16
+	// minArgObservations is how many values an argument needs before its
+	// values, prefixes or range are scored.
+	minArgObservations = 10
+	// maxAnomalyReasons caps the reasons of an assessment.
+	maxAnomalyReasons = 5
+)
+
+// Component scores of the deviations that do not depend on the profile's
+// statistics.
+const (
+	newArgumentScore = 0.5
+	// valueScoreWeight scales the novelty of an unseen string value, which
+	// is weaker evidence than an unseen path prefix.
+	valueScoreWeight = 0.8
+)
+
+// ArgumentAnomalyToolConfig overrides the anomaly settings for the tools
+// matching Pattern. Zero fields keep the global setting.
+type ArgumentAnomalyToolConfig struct {
+	Pattern      string
+	Disabled     bool
+	WarmupCalls  int64
+	WarmupPeriod time.Duration
+	Threshold    float64
+}
+
+// ArgumentAnomalyConfig configures an ArgumentAnomalyService.
+type ArgumentAnomalyConfig struct {
+	// WarmupCalls is how many calls a profile learns from before scoring.
+	WarmupCalls int64
+	// WarmupPeriod is how long after its first call a profile keeps
+	// learning before scoring.
+	WarmupPeriod time.Duration
+	// Threshold is the score at or above which an anomaly.detected event is
+	// emitted and the call is not learned.
+	Threshold float64
+	// Tools are the per-tool overrides; the first match applies.
+	Tools []ArgumentAnomalyToolConfig
+}
+
+// argStats is the profile of one argument of a tool.
+type argStats = state.ArgumentStatsEntry
+
+// toolProfile is the argument profile of one tool.
+type toolProfile struct {
+	calls     int64
+	firstSeen time.Time
+	lastSeen  time.Time
+	args      map[string]*argStats
+}
+
+// argValue is one leaf of a call's arguments.
+type argValue struct {
+	path string
+	str  string
+	num  float64
+	kind byte // 's' string, 'n' number, 'o' other
+}
+
+// ArgumentAnomalyService profiles the arguments every tool receives and
+// scores calls against the profile: a value never seen in an argument that
+// usually takes a handful of values, a file path under a directory the tool
+// never touched, a number far outside its usual range, an argument the tool
+// never received. The score (0 to 1) is exposed to rule conditions as
+// anomaly.score; calls at or above the threshold emit an anomaly.detected
+// event.
+//
+// Profiles learn only from calls that policy allowed and that did not
+// reach the threshold, so a repeated anomaly keeps scoring high. A profile
+// scores nothing until it is past its warm-up, counted both in calls and in
+// time since the tool's first call.
+type ArgumentAnomalyService struct {
+	mu       sync.Mutex
+	profiles map[string]*toolProfile
+	dirty    bool
+
+	cfg        ArgumentAnomalyConfig
+	stateStore state.Store
+	eventBus   event.Bus
+	logger     *slog.Logger
+	now        func() time.Time
+}
+
+// Compile-time check that ArgumentAnomalyService is an anomaly detector.
+var _ action.AnomalyDetector = (*ArgumentAnomalyService)(nil)
+
+// NewArgumentAnomalyService creates an ArgumentAnomalyService. stateStore
+// may be nil, in which case profiles are kept in memory only.
+func NewArgumentAnomalyService(cfg ArgumentAnomalyConfig, stateStore state.Store, logger *slog.Logger) *ArgumentAnomalyService {
+	return &ArgumentAnomalyService{
+		profiles:   make(map[string]*toolProfile),
+		cfg:        cfg,
+		stateStore: stateStore,
+		logger:     logger,
+		now:        time.Now,
+	}
+}
+
+// SetEventBus sets the bus receiving anomaly.detected events.
+func (s *ArgumentAnomalyService) SetEventBus(bus event.Bus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.eventBus = bus
+}
+
+// LoadFromState restores the profiles persisted by a previous run.
+func (s *ArgumentAnomalyService) LoadFromState(appState *state.AppState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range appState.ArgumentProfiles {
+		p := &toolProfile{
+			calls:     e.Calls,
+			firstSeen: e.FirstSeen,
+			lastSeen:  e.LastSeen,
+			args:      make(map[string]*argStats, len(e.Args)),
+		}
+		for i := range e.Args {
+			a := e.Args[i]
+			p.args[a.Path] = &a
+		}
+		s.profiles[e.ToolName] = p
+	}
+}
+
+// Tools returns the number of tools profiled.
+func (s *ArgumentAnomalyService) Tools() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.profiles)
+}
+
+// toolSettings returns the warm-up and threshold of a tool, and false when
+// anomaly detection is disabled for it.
+func (s *ArgumentAnomalyService) toolSettings(tool string) (int64, time.Duration, float64, bool) {
+	calls, period, threshold := s.cfg.WarmupCalls, s.cfg.WarmupPeriod, s.cfg.Threshold
+	for _, t := range s.cfg.Tools {
+		if ok, _ := path.Match(t.Pattern, tool); !ok {
+			continue
+		}
+		if t.Disabled {
+			return 0, 0, 0, false
+		}
+		if t.WarmupCalls > 0 {
+			calls = t.WarmupCalls
+		}
+		if t.WarmupPeriod > 0 {
+			period = t.WarmupPeriod
+		}
+		if t.Threshold > 0 {
+			threshold = t.Threshold
+		}
+		break
+	}
+	return calls, period, threshold, true
+}
+
+// Assess scores the arguments of a tool call against the tool's profile.
+// A call at or above the threshold emits an anomaly.detected event.
+func (s *ArgumentAnomalyService) Assess(ctx context.Context, a *action.CanonicalAction) action.AnomalyAssessment {
+	warmupCalls, warmupPeriod, threshold, enabled := s.toolSettings(a.Name)
+	if !enabled {
+		return action.AnomalyAssessment{}
+	}
+	values := flattenArgs(a.Arguments)
+
+	s.mu.Lock()
+	p, ok := s.profiles[a.Name]
+	if !ok || p.calls < warmupCalls || s.now().Sub(p.firstSeen) < warmupPeriod {
+		s.mu.Unlock()
+		return action.AnomalyAssessment{WarmingUp: true}
+	}
+	type finding struct {
+		score  float64
+		reason string
+	}
+	var findings []finding
+	for i, v := range values {
+		st, known := p.args[v.path]
+		if !known {
+			// values is sorted by path: report each new argument once.
+			if len(p.args) < maxProfiledArgs && (i == 0 || values[i-1].path != v.path) {
+				findings = append(findings, finding{newArgumentScore, fmt.Sprintf("argument %q never seen before", v.path)})
+			}
+			continue
+		}
+		if score, reason := scoreArgValue(st, v); score > 0 {
+			findings = append(findings, finding{score, reason})
+		}
+	}
+	bus := s.eventBus
+	s.mu.Unlock()
+
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].score > findings[j].score })
+	var result action.AnomalyAssessment
+	for i, f := range findings {
+		if i == 0 {
+			result.Score = f.score
+		}
+		if i < maxAnomalyReasons {
+			result.Reasons = append(result.Reasons, f.reason)
+		}
+	}
+	if result.Score < threshold {
+		return result
+	}
+
+	s.logger.Warn("anomalous tool arguments",
+		"tool", a.Name,
+		"score", result.Score,
+		"reasons", result.Reasons,
+		"identity_id", a.Identity.ID,
+		"session_id", a.Identity.SessionID,
+	)
+	if bus != nil {
+		bus.Publish(ctx, event.Event{
+			Type:     "anomaly.detected",
+			Source:   "anomaly-detection",
+			Severity: event.SeverityWarning,
+			Payload: map[string]interface{}{
+				"tool":          a.Name,
+				"score":         result.Score,
+				"reasons":       strings.Join(result.Reasons, "; "),
+				"identity_id":   a.Identity.ID,
+				"identity_name": a.Identity.Name,
+				"session_id":    a.Identity.SessionID,
+			},
+		})
+	}
+	return result
+}
+
+// Learn adds the arguments of an allowed tool call to the tool's profile.
+// Calls assessed at or above the threshold are not learned.
+func (s *ArgumentAnomalyService) Learn(a *action.CanonicalAction, assessment action.AnomalyAssessment) {
+	_, _, threshold, enabled := s.toolSettings(a.Name)
+	if !enabled || (!assessment.WarmingUp && assessment.Score >= threshold) {
+		return
+	}
+	values := flattenArgs(a.Arguments)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now().UTC()
+	p, ok := s.profiles[a.Name]
+	if !ok {
+		if len(s.profiles) >= maxProfiledTools {
+			return
+		}
+		p = &toolProfile{firstSeen: now, args: make(map[string]*argStats)}
+		s.profiles[a.Name] = p
+	}
+	p.calls++
+	p.lastSeen = now
+	for _, v := range values {
+		st, ok := p.args[v.path]
+		if !ok {
+			if len(p.args) >= maxProfiledArgs {
+				continue
+			}
+			st = &argStats{Path: v.path}
+			p.args[v.path] = st
+		}
+		learnArgValue(st, v)
+	}
+	s.dirty = true
+}
+
+// learnArgValue adds one value to the statistics of its argument.
+func learnArgValue(st *argStats, v argValue) {
+	st.Count++
+	switch v.kind {
+	case 's':
+		if prefix, ok := pathPrefix(v.str); ok {
+			st.PathCount++
+			if _, seen := st.Prefixes[prefix]; seen || len(st.Prefixes) < maxArgPrefixes {
+				if st.Prefixes == nil {
+					st.Prefixes = make(map[string]int64)
+				}
+				st.Prefixes[prefix]++
+			}
+		}
+		if st.HighCardinality || len(v.str) > maxArgValueLen {
+			return
+		}
+		if _, seen := st.Values[v.str]; !seen && len(st.Values) >= maxArgValues {
+			// Too many distinct values to tell a new one from the usual.
+			st.HighCardinality = true
+			st.Values = nil
+			return
+		}
+		if st.Values == nil {
+			st.Values = make(map[string]int64)
+		}
+		st.Values[v.str]++
+	case 'n':
+		// Welford's online mean and variance.
+		st.NumCount++
+		if st.NumCount == 1 || v.num < st.Min {
+			st.Min = v.num
+		}
+		if st.NumCount == 1 || v.num > st.Max {
+			st.Max = v.num
+		}
+		delta := v.num - st.Mean
+		st.Mean += delta / float64(st.NumCount)
+		st.M2 += delta * (v.num - st.Mean)
+	}
+}
+
+// scoreArgValue scores one value against the statistics of its argument.
+// It returns 0 for a usual value.
+func scoreArgValue(st *argStats, v argValue) (float64, string) {
+	switch v.kind {
+	case 's':
+		if prefix, ok := pathPrefix(v.str); ok && st.PathCount >= minArgObservations {
+			if _, seen := st.Prefixes[prefix]; !seen {
+				score := 1 - novelty(st.Prefixes, st.PathCount)
+				return score, fmt.Sprintf("argument %q points under %s, usually %s", v.path, prefix, topKeys(st.Prefixes, 3))
+			}
+			return 0, ""
+		}
+		if st.HighCardinality || st.Values == nil || len(v.str) > maxArgValueLen || st.Count < minArgObservations {
+			return 0, ""
+		}
+		if _, seen := st.Values[v.str]; !seen {
+			score := valueScoreWeight * (1 - novelty(st.Values, st.Count))
+			return score, fmt.Sprintf("argument %q has a value not seen before (%d known values)", v.path, len(st.Values))
+		}
+	case 'n':
+		if st.NumCount < minArgObservations || (v.num >= st.Min && v.num <= st.Max) {
+			return 0, ""
+		}
+		score := 1.0
+		if std := math.Sqrt(st.M2 / float64(st.NumCount-1)); std > 0 {
+			// Three standard deviations past the mean start scoring; six
+			// score 1.
+			z := math.Abs(v.num-st.Mean) / std
+			score = math.Max(0, math.Min(1, (z-3)/3))
+		}
+		if score > 0 {
+			return score, fmt.Sprintf("argument %q = %s outside the usual range [%s, %s]",
+				v.path, formatArgNumber(v.num), formatArgNumber(st.Min), formatArgNumber(st.Max))
+		}
+	}
+	return 0, ""
+}
+
+// novelty estimates the probability that the next observation is a value
+// never seen before, as the share of values seen only once (Good-Turing).
+func novelty(counts map[string]int64, total int64) float64 {
+	if total == 0 {
+		return 1
+	}
+	var once int64
+	for _, c := range counts {
+		if c == 1 {
+			once++
+		}
+	}
+	return float64(once) / float64(total)
+}
+
+// topKeys returns the n most frequent keys of counts, comma-separated.
+func topKeys(counts map[string]int64, n int) string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+	return strings.Join(keys, ", ")
+}
+
+func formatArgNumber(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// windowsPath matches an absolute Windows path.
+var windowsPath = regexp.MustCompile(`^[A-Za-z]:[\\/]`)
+
+// pathPrefix returns the directory prefix of a file path value, at most two
+// levels deep: "/workspace/app/src/main.go" gives "/workspace/app" and
+// "/etc/shadow" gives "/etc". Only absolute, home-relative and file://
+// paths are recognized; ".." segments are resolved first.
+func pathPrefix(s string) (string, bool) {
+	s = strings.TrimPrefix(s, "file://")
+	if windowsPath.MatchString(s) {
+		s = "/" + strings.ToLower(s[:1]) + "/" + strings.ReplaceAll(s[3:], `\`, "/")
+	}
+	if !strings.HasPrefix(s, "/") && !strings.HasPrefix(s, "~/") {
+		return "", false
+	}
+	if strings.ContainsAny(s, "\n\r") {
+		return "", false
+	}
+	dir := path.Dir(path.Clean(s))
+	root := ""
+	if strings.HasPrefix(dir, "/") {
+		root, dir = "/", dir[1:]
+	}
+	parts := strings.Split(dir, "/")
+	if len(parts) > 2 {
+		parts = parts[:2]
+	}
+	return root + strings.Join(parts, "/"), true
+}
+
+// flattenArgs returns the string and numeric leaves of a call's arguments
+// with their dotted paths. Nested objects extend the path with their keys
+// and list elements with "[]".
+func flattenArgs(args map[string]interface{}) []argValue {
+	var out []argValue
+	var walk func(p string, v interface{}, depth int)
+	walk = func(p string, v interface{}, depth int) {
+		switch t := v.(type) {
+		case string:
+			out = append(out, argValue{path: p, str: t, kind: 's'})
+		case float64:
+			out = append(out, argValue{path: p, num: t, kind: 'n'})
+		case int:
+			out = append(out, argValue{path: p, num: float64(t), kind: 'n'})
+		case int64:
+			out = append(out, argValue{path: p, num: float64(t), kind: 'n'})
+		case json.Number:
+			if f, err := t.Float64(); err == nil {
+				out = append(out, argValue{path: p, num: f, kind: 'n'})
+			}
+		case map[string]interface{}:
+			if depth >= maxArgDepth {
+				return
+			}
+			for k, child := range t {
+				walk(p+"."+k, child, depth+1)
+			}
+		case []interface{}:
+			if depth >= maxArgDepth {
+				return
+			}
+			for i, child := range t {
+				if i >= maxArgListItems {
+					break
+				}
+				walk(p+"[]", child, depth+1)
+			}
+		default:
+			out = append(out, argValue{path: p, kind: 'o'})
+		}
+	}
+	for k, v := range args {
+		walk(k, v, 0)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].path < out[j].path })
+	return out
+}
+
+// Flush persists the profiles to state.json if they changed since the last
+// flush.
+func (s *ArgumentAnomalyService) Flush(_ context.Context) error {
+	if s.stateStore == nil {
+		return nil
+	}
+	s.mu.Lock()
+	if !s.dirty {
+		s.mu.Unlock()
+		return nil
+	}
+	entries := make([]state.ArgumentProfileEntry, 0, len(s.profiles))
+	for tool, p := range s.profiles {
+		e := state.ArgumentProfileEntry{
+			ToolName:  tool,
+			Calls:     p.calls,
+			FirstSeen: p.firstSeen,
+			LastSeen:  p.lastSeen,
+			Args:      make([]state.ArgumentStatsEntry, 0, len(p.args)),
+		}
+		for _, a := range p.args {
+			c := *a
+			c.Values = copyCounts(a.Values)
+			c.Prefixes = copyCounts(a.Prefixes)
+			e.Args = append(e.Args, c)
+		}
+		sort.Slice(e.Args, func(i, j int) bool { return e.Args[i].Path < e.Args[j].Path })
+		entries = append(entries, e)
+	}
+	s.dirty = false
+	s.mu.Unlock()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ToolName < entries[j].ToolName })
+
+	if err := s.stateStore.Mutate(func(appState *state.AppState) error {
+		appState.ArgumentProfiles = entries
+		return nil
+	}); err != nil {
+		s.mu.Lock()
+		s.dirty = true
+		s.mu.Unlock()
+		return fmt.Errorf("persist argument profiles: %w", err)
+	}
+	return nil
+}
+
+func copyCounts(m map[string]int64) map[string]int64 {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]int64, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// Run flushes the profiles every interval until ctx is cancelled. Callers
+// flush once more on shutdown.
+func (s *ArgumentAnomalyService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Flush(ctx); err != nil {
+				s.logger.Warn("failed to persist argument profiles", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/action"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/event"
+)
+
+func anomalyTestService(t *testing.T, cfg ArgumentAnomalyConfig, store *state.FileStateStore) (*ArgumentAnomalyService, *time.Time) {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	svc := NewArgumentAnomalyService(cfg, store, logger)
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	return svc, &now
+}
+
+func readFileCall(path string, limit float64) *action.CanonicalAction {
+	return &action.CanonicalAction{
+		Type:      action.ActionToolCall,
+		Name:      "read_file",
+		Arguments: map[string]interface{}{"path": path, "limit": limit, "encoding": "utf-8"},
+		Identity:  action.ActionIdentity{ID: "id-1", Name: "alice", SessionID: "sess-1"},
+	}
+}
+
+// warmReadFile teaches the read_file profile weeks of /workspace reads.
+func warmReadFile(svc *ArgumentAnomalyService, now *time.Time, calls int) {
+	for i := 0; i < calls; i++ {
+		a := readFileCall(fmt.Sprintf("/workspace/app/src/file%d.go", i), float64(100+i%20))
+		svc.Learn(a, svc.Assess(context.Background(), a))
+		*now = now.Add(time.Hour)
+	}
+}
+
+func TestArgumentAnomalyService_WarmupAndScoring(t *testing.T) {
+	svc, now := anomalyTestService(t, ArgumentAnomalyConfig{
+		WarmupCalls: 50, WarmupPeriod: 24 * time.Hour, Threshold: 0.8,
+	}, nil)
+	ctx := context.Background()
+
+	first := svc.Assess(ctx, readFileCall("/etc/shadow", 100))
+	if !first.WarmingUp || first.Score != 0 {
+		t.Fatalf("assessment without profile = %+v, want warming up", first)
+	}
+
+	warmReadFile(svc, now, 49)
+	if a := svc.Assess(ctx, readFileCall("/etc/shadow", 100)); !a.WarmingUp {
+		t.Fatalf("assessment after 49 calls = %+v, want warming up", a)
+	}
+	warmReadFile(svc, now, 1)
+
+	if a := svc.Assess(ctx, readFileCall("/workspace/app/README.md", 105)); a.WarmingUp || a.Score != 0 {
+		t.Errorf("usual call = %+v, want score 0", a)
+	}
+
+	shadow := svc.Assess(ctx, readFileCall("/etc/shadow", 100))
+	if shadow.Score < 0.8 {
+		t.Fatalf("/etc/shadow score = %v, want >= 0.8 (%v)", shadow.Score, shadow.Reasons)
+	}
+	if len(shadow.Reasons) != 1 || !strings.Contains(shadow.Reasons[0], "/etc") || !strings.Contains(shadow.Reasons[0], "/workspace/app") {
+		t.Errorf("reasons = %v, want the /etc prefix against /workspace/app", shadow.Reasons)
+	}
+	// Traversal resolves to the real directory.
+	if a := svc.Assess(ctx, readFileCall("/workspace/app/../../etc/passwd", 100)); a.Score < 0.8 {
+		t.Errorf("traversal score = %v, want >= 0.8", a.Score)
+	}
+
+	// A call policy let through despite the anomaly is not learned.
+	svc.Learn(readFileCall("/etc/shadow", 100), shadow)
+	if a := svc.Assess(ctx, readFileCall("/etc/shadow", 100)); a.Score < 0.8 {
+		t.Errorf("repeated anomaly score = %v, want it to stay high", a.Score)
+	}
+
+	huge := svc.Assess(ctx, readFileCall("/workspace/app/main.go", 1e6))
+	if huge.Score < 0.8 || !strings.Contains(huge.Reasons[0], `"limit"`) {
+		t.Errorf("out-of-range number = %+v, want a high limit score", huge)
+	}
+
+	enc := readFileCall("/workspace/app/main.go", 100)
+	enc.Arguments["encoding"] = "base64"
+	enc.Arguments["follow_symlinks"] = true
+	a := svc.Assess(ctx, enc)
+	if a.Score < 0.7 || len(a.Reasons) != 2 {
+		t.Errorf("new value and new argument = %+v, want two reasons scoring >= 0.7", a)
+	}
+	if !strings.Contains(a.Reasons[0], `"encoding"`) || !strings.Contains(a.Reasons[1], `"follow_symlinks"`) {
+		t.Errorf("reasons = %v, want the new value first, then the new argument", a.Reasons)
+	}
+}
+
+func TestArgumentAnomalyService_WarmupPeriod(t *testing.T) {
+	svc, now := anomalyTestService(t, ArgumentAnomalyConfig{
+		WarmupCalls: 10, WarmupPeriod: 7 * 24 * time.Hour, Threshold: 0.8,
+	}, nil)
+	ctx := context.Background()
+	for i := 0; i < 100; i++ {
+		a := readFileCall(fmt.Sprintf("/workspace/app/f%d", i), 100)
+		svc.Learn(a, svc.Assess(ctx, a))
+	}
+	if a := svc.Assess(ctx, readFileCall("/etc/shadow", 100)); !a.WarmingUp {
+		t.Fatalf("assessment before the warm-up period = %+v, want warming up", a)
+	}
+	*now = now.Add(7 * 24 * time.Hour)
+	if a := svc.Assess(ctx, readFileCall("/etc/shadow", 100)); a.WarmingUp || a.Score < 0.8 {
+		t.Errorf("assessment after the warm-up period = %+v, want scored", a)
+	}
+}
+
+func TestArgumentAnomalyService_HighCardinality(t *testing.T) {
+	svc, _ := anomalyTestService(t, ArgumentAnomalyConfig{WarmupCalls: 10, Threshold: 0.8}, nil)
+	ctx := context.Background()
+	for i := 0; i < 200; i++ {
+		a := &action.CanonicalAction{
+			Type:      action.ActionToolCall,
+			Name:      "search",
+			Arguments: map[string]interface{}{"query": fmt.Sprintf("query %d", i)},
+		}
+		svc.Learn(a, svc.Assess(ctx, a))
+	}
+	a := svc.Assess(ctx, &action.CanonicalAction{
+		Type: action.ActionToolCall, Name: "search",
+		Arguments: map[string]interface{}{"query": "something new"},
+	})
+	if a.Score != 0 {
+		t.Errorf("free-text argument score = %+v, want 0", a)
+	}
+}
+
+func TestArgumentAnomalyService_ToolOverrides(t *testing.T) {
+	svc, now := anomalyTestService(t, ArgumentAnomalyConfig{
+		WarmupCalls: 10, Threshold: 0.8,
+		Tools: []ArgumentAnomalyToolConfig{
+			{Pattern: "write_*", Disabled: true},
+			{Pattern: "read_*", WarmupCalls: 1000},
+		},
+	}, nil)
+	ctx := context.Background()
+	warmReadFile(svc, now, 100)
+	if a := svc.Assess(ctx, readFileCall("/etc/shadow", 100)); !a.WarmingUp {
+		t.Errorf("read_file with warmup_calls 1000 = %+v, want warming up", a)
+	}
+
+	w := &action.CanonicalAction{Type: action.ActionToolCall, Name: "write_file", Arguments: map[string]interface{}{"path": "/tmp/x"}}
+	svc.Learn(w, svc.Assess(ctx, w))
+	if a := svc.Assess(ctx, w); a.WarmingUp || a.Score != 0 {
+		t.Errorf("disabled tool = %+v, want a zero assessment", a)
+	}
+	if svc.Tools() != 1 {
+		t.Errorf("profiled tools = %d, want 1 (disabled tools are not learned)", svc.Tools())
+	}
+}
+
+func TestArgumentAnomalyService_Event(t *testing.T) {
+	svc, now := anomalyTestService(t, ArgumentAnomalyConfig{WarmupCalls: 20, Threshold: 0.8}, nil)
+	bus := event.NewBus(10)
+	received := make(chan event.Event, 1)
+	bus.Subscribe("anomaly.detected", func(_ context.Context, e event.Event) { received <- e })
+	bus.Start()
+	defer bus.Stop()
+	svc.SetEventBus(bus)
+
+	warmReadFile(svc, now, 40)
+	svc.Assess(context.Background(), readFileCall("/etc/shadow", 100))
+
+	select {
+	case e := <-received:
+		p := e.Payload.(map[string]interface{})
+		if p["tool"] != "read_file" || p["identity_name"] != "alice" || p["score"].(float64) < 0.8 {
+			t.Errorf("event payload = %+v", p)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no anomaly.detected event")
+	}
+}
+
+func TestArgumentAnomalyService_Persistence(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	store := state.NewFileStateStore(filepath.Join(t.TempDir(), "state.json"), logger)
+	cfg := ArgumentAnomalyConfig{WarmupCalls: 20, Threshold: 0.8}
+	svc, now := anomalyTestService(t, cfg, store)
+	warmReadFile(svc, now, 40)
+	if err := svc.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	appState, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(appState.ArgumentProfiles) != 1 || appState.ArgumentProfiles[0].Calls != 40 {
+		t.Fatalf("persisted profiles = %+v, want read_file with 40 calls", appState.ArgumentProfiles)
+	}
+
+	restored, _ := anomalyTestService(t, cfg, nil)
+	restored.LoadFromState(appState)
+	if a := restored.Assess(context.Background(), readFileCall("/etc/shadow", 100)); a.WarmingUp || a.Score < 0.8 {
+		t.Errorf("restored profile assessment = %+v, want /etc/shadow flagged", a)
+	}
+}
+
+func TestPathPrefix(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"/workspace/app/src/main.go", "/workspace/app", true},
+		{"/etc/shadow", "/etc", true},
+		{"/workspace/app/../../etc/shadow", "/etc", true},
+		{"~/.ssh/id_rsa", "~/.ssh", true},
+		{"file:///var/log/syslog", "/var/log", true},
+		{`C:\Users\bob\secrets.txt`, "/c/Users", true},
+		{"hello world", "", false},
+		{"src/main.go", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := pathPrefix(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("pathPrefix(%q) = %q, %v; want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -8,8 +8,28 @@ import (
 	"time"
 
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/event"
 )
 
+// EventAuditOverflow is published when audit records are dropped because
+// the audit channel stayed full.
+const EventAuditOverflow = "audit.overflow"
+
+// auditOverflowEventInterval rate-limits audit overflow events.
+const auditOverflowEventInterval = 10 * time.Second
+
+// AuditJournal is a write-ahead log of audit records (see WithJournal).
+type AuditJournal interface {
+	// Append durably writes records to the journal.
+	Append(records ...audit.AuditRecord) error
+	// Read returns up to max records not committed yet, oldest first.
+	Read(max int) ([]audit.AuditRecord, error)
+	// Commit marks the records returned by the last Read as written.
+	Commit() error
+	// Backlog returns the number of records not committed yet.
+	Backlog() int
+}
+
 // AuditService provides async audit logging with a buffered channel and background worker.
 // Tool calls are logged without blocking the proxy hot path.
 type AuditService struct {
@@ -23,16 +43,26 @@ type AuditService struct {
 
 	// Phase 2 backpressure additions
 	channelSize int           // Track capacity for monitoring
-	sendTimeout time.Duration // 0 = drop immediately, >0 = block up to this duration
+	sendTimeout atomic.Int64  // nanoseconds; 0 = drop immediately, >0 = block up to this duration
 	dropCount   atomic.Int64  // Lock-free drop counter
 
 	// Phase 2 channel depth warning
-	warningThreshold int          // Percentage (0-100), e.g., 80
+	warningThreshold atomic.Int32 // Percentage (0-100), e.g., 80
 	lastWarning      atomic.Int64 // Rate-limit warning logs (Unix nanos)
 
 	// Phase 5 adaptive flush
 	adaptiveFlushThreshold int // Depth % that triggers faster flushing (default 80)
 
+	// Write-ahead journal (nil = records are buffered in memory only)
+	journal      AuditJournal
+	journalReady chan struct{} // wakes the worker when a batch is journaled
+
+	// Overflow events
+	busMu         sync.RWMutex
+	eventBus      event.Bus
+	lastOverflow  atomic.Int64 // Rate-limit overflow events (Unix nanos)
+	reportedDrops atomic.Int64 // Drop count at the last overflow event
+
 	// Shutdown guard
 	stopOnce sync.Once
 	stopped  atomic.Bool
@@ -67,7 +97,7 @@ func WithChannelSize(size int) AuditOption {
 // 0 = drop immediately (no blocking), >0 = block up to this duration before dropping.
 func WithSendTimeout(timeout time.Duration) AuditOption {
 	return func(s *AuditService) {
-		s.sendTimeout = timeout
+		s.sendTimeout.Store(int64(timeout))
 	}
 }
 
@@ -75,14 +105,19 @@ func WithSendTimeout(timeout time.Duration) AuditOption {
 // A warning is logged when channel depth exceeds this percentage of capacity.
 func WithWarningThreshold(percent int) AuditOption {
 	return func(s *AuditService) {
-		if percent < 0 {
-			percent = 0
-		}
-		if percent > 100 {
-			percent = 100
-		}
-		s.warningThreshold = percent
+		s.setWarningThreshold(percent)
+	}
+}
+
+// setWarningThreshold stores percent, clamped to 0-100.
+func (s *AuditService) setWarningThreshold(percent int) {
+	if percent < 0 {
+		percent = 0
 	}
+	if percent > 100 {
+		percent = 100
+	}
+	s.warningThreshold.Store(int32(percent))
 }
 
 // WithAdaptiveFlushThreshold sets the channel depth % that triggers faster flushing.
@@ -100,6 +135,18 @@ func WithAdaptiveFlushThreshold(percent int) AuditOption {
 	}
 }
 
+// WithJournal makes Record append every record to journal, fsynced, before
+// it returns, and the worker read the records to write from the journal
+// instead of the channel. Records journaled but not yet written when the
+// process dies are written after the restart, so none is lost even on
+// SIGKILL, at the cost of one fsync per Record (shared by concurrent calls).
+// A record the journal fails to take goes through the channel instead.
+func WithJournal(journal AuditJournal) AuditOption {
+	return func(s *AuditService) {
+		s.journal = journal
+	}
+}
+
 // NewAuditService creates a new AuditService with the given store and options.
 func NewAuditService(store audit.AuditStore, logger *slog.Logger, opts ...AuditOption) *AuditService {
 	defaultChannelSize := 1000
@@ -110,15 +157,18 @@ func NewAuditService(store audit.AuditStore, logger *slog.Logger, opts ...AuditO
 		logger:                 logger,
 		batchSize:              100,
 		flushInterval:          time.Second,
-		channelSize:            defaultChannelSize,     // Track capacity for monitoring
-		sendTimeout:            100 * time.Millisecond, // Default 100ms backpressure
-		warningThreshold:       80,                     // Warn at 80% full
-		adaptiveFlushThreshold: 80,                     // Speed up flush at 80% full
+		channelSize:            defaultChannelSize, // Track capacity for monitoring
+		adaptiveFlushThreshold: 80,                 // Speed up flush at 80% full
 	}
+	s.sendTimeout.Store(int64(100 * time.Millisecond)) // Default 100ms backpressure
+	s.warningThreshold.Store(80)                       // Warn at 80% full
 
 	for _, opt := range opts {
 		opt(s)
 	}
+	if s.journal != nil {
+		s.journalReady = make(chan struct{}, 1)
+	}
 
 	return s
 }
@@ -133,6 +183,10 @@ func (s *AuditService) Start(ctx context.Context) {
 // Applies backpressure: attempts fast non-blocking send, then blocks up to sendTimeout.
 // If timeout expires, record is dropped and counted.
 func (s *AuditService) Record(record audit.AuditRecord) {
+	if record.SchemaVersion == 0 {
+		record.SchemaVersion = audit.CurrentSchemaVersion
+	}
+
 	// Guard against send on closed channel after Stop()
 	if s.stopped.Load() {
 		s.recordDrop(record)
@@ -146,10 +200,26 @@ func (s *AuditService) Record(record audit.AuditRecord) {
 		}
 	}()
 
+	if s.journal != nil {
+		err := s.journal.Append(record)
+		if err == nil {
+			select {
+			case s.journalReady <- struct{}{}:
+			default:
+			}
+			return
+		}
+		s.logger.Error("failed to journal audit record",
+			"tool", record.ToolName,
+			"session", record.SessionID,
+			"error", err,
+		)
+	}
+
 	// Check channel depth for early warning (rate-limited)
-	if s.warningThreshold > 0 {
+	if warningThreshold := int(s.warningThreshold.Load()); warningThreshold > 0 {
 		depth := len(s.auditChan)
-		threshold := s.channelSize * s.warningThreshold / 100
+		threshold := s.channelSize * warningThreshold / 100
 		if depth >= threshold {
 			s.warnChannelDepth(depth)
 		}
@@ -164,13 +234,14 @@ func (s *AuditService) Record(record audit.AuditRecord) {
 	}
 
 	// If no timeout configured, drop immediately (legacy behavior)
-	if s.sendTimeout <= 0 {
+	sendTimeout := time.Duration(s.sendTimeout.Load())
+	if sendTimeout <= 0 {
 		s.recordDrop(record)
 		return
 	}
 
 	// Slow path: block with timeout
-	timer := time.NewTimer(s.sendTimeout)
+	timer := time.NewTimer(sendTimeout)
 	select {
 	case s.auditChan <- record:
 		timer.Stop()
@@ -180,6 +251,21 @@ func (s *AuditService) Record(record audit.AuditRecord) {
 	}
 }
 
+// SetBackpressure changes the send timeout and the channel depth warning
+// percentage (see WithSendTimeout and WithWarningThreshold) while the
+// service runs.
+func (s *AuditService) SetBackpressure(sendTimeout time.Duration, warningThreshold int) {
+	s.sendTimeout.Store(int64(sendTimeout))
+	s.setWarningThreshold(warningThreshold)
+}
+
+// SetEventBus sets the bus on which audit overflow events are published.
+func (s *AuditService) SetEventBus(bus event.Bus) {
+	s.busMu.Lock()
+	defer s.busMu.Unlock()
+	s.eventBus = bus
+}
+
 // recordDrop increments counter and logs drop
 func (s *AuditService) recordDrop(record audit.AuditRecord) {
 	drops := s.dropCount.Add(1)
@@ -188,6 +274,35 @@ func (s *AuditService) recordDrop(record audit.AuditRecord) {
 		"session", record.SessionID,
 		"total_drops", drops,
 	)
+	s.publishOverflow(drops)
+}
+
+// publishOverflow publishes an audit overflow event, at most once per
+// auditOverflowEventInterval, with the drops since the previous event.
+func (s *AuditService) publishOverflow(drops int64) {
+	s.busMu.RLock()
+	bus := s.eventBus
+	s.busMu.RUnlock()
+	if bus == nil {
+		return
+	}
+	now := time.Now().UnixNano()
+	last := s.lastOverflow.Load()
+	if now-last < int64(auditOverflowEventInterval) || !s.lastOverflow.CompareAndSwap(last, now) {
+		return
+	}
+	since := drops - s.reportedDrops.Swap(drops)
+	bus.Publish(context.Background(), event.Event{
+		Type:     EventAuditOverflow,
+		Source:   "audit",
+		Severity: event.SeverityCritical,
+		Payload: map[string]interface{}{
+			"dropped":       since,
+			"total_dropped": drops,
+			"capacity":      s.channelSize,
+		},
+		RequiresAction: true,
+	})
 }
 
 // warnChannelDepth logs warning about channel capacity (rate-limited to once per second).
@@ -253,11 +368,12 @@ func (s *AuditService) worker(ctx context.Context) {
 				// Channel closed - final flush with bounded deadline.
 				// Budget (4s) is shorter than the lifecycle hook timeout (5s) so the
 				// flush completes before the hook proceeds to close the store.
+				flushCtx, flushCancel := context.WithTimeout(context.Background(), 4*time.Second)
 				if len(batch) > 0 {
-					flushCtx, flushCancel := context.WithTimeout(context.Background(), 4*time.Second)
 					s.flush(flushCtx, batch)
-					flushCancel()
 				}
+				s.flushJournal(flushCtx)
+				flushCancel()
 				return
 			}
 			batch = append(batch, record)
@@ -303,6 +419,11 @@ func (s *AuditService) worker(ctx context.Context) {
 				}
 			}
 
+		case <-s.journalReady:
+			if s.journal.Backlog() >= s.batchSize {
+				s.flushJournal(ctx)
+			}
+
 		case <-ticker.C:
 			// M2: Check context before flushing to avoid blocking if Stop()
 			// is never called but the context has been cancelled.
@@ -315,6 +436,7 @@ func (s *AuditService) worker(ctx context.Context) {
 				s.flush(ctx, batch)
 				batch = batch[:0]
 			}
+			s.flushJournal(ctx)
 
 		case <-ctx.Done():
 			goto ctxShutdown
@@ -338,11 +460,12 @@ ctxShutdown:
 		}
 	}
 ctxFlush:
+	flushCtx, flushCancel := context.WithTimeout(context.Background(), 4*time.Second)
 	if len(batch) > 0 {
-		flushCtx, flushCancel := context.WithTimeout(context.Background(), 4*time.Second)
 		s.flush(flushCtx, batch)
-		flushCancel()
 	}
+	s.flushJournal(flushCtx)
+	flushCancel()
 }
 
 // flush writes a batch of records to the store.
@@ -355,3 +478,29 @@ func (s *AuditService) flush(ctx context.Context, batch []audit.AuditRecord) {
 		)
 	}
 }
+
+// flushJournal writes the journaled records to the store in batches and
+// commits them. As with flush, a batch the store rejects is logged, not
+// retried. Records stay in the journal when it cannot be read or committed.
+func (s *AuditService) flushJournal(ctx context.Context) {
+	if s.journal == nil {
+		return
+	}
+	for ctx.Err() == nil {
+		records, err := s.journal.Read(s.batchSize)
+		if err != nil {
+			s.logger.Error("failed to read audit journal", "error", err)
+			return
+		}
+		if len(records) > 0 {
+			s.flush(ctx, records)
+		}
+		if err := s.journal.Commit(); err != nil {
+			s.logger.Error("failed to commit audit journal", "error", err)
+			return
+		}
+		if len(records) < s.batchSize {
+			return
+		}
+	}
+}
@@ -6,12 +6,15 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	auditadapter "github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/audit"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/event"
 	"go.uber.org/goleak"
 )
 
@@ -75,6 +78,48 @@ func TestAuditService_OverflowWithTimeout(t *testing.T) {
 	svc.Stop()
 }
 
+// capturingBus records published events synchronously.
+type capturingBus struct {
+	mu     sync.Mutex
+	events []event.Event
+}
+
+func (b *capturingBus) Publish(ctx context.Context, evt event.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.events = append(b.events, evt)
+}
+func (b *capturingBus) Subscribe(string, event.Subscriber) func() { return func() {} }
+func (b *capturingBus) SubscribeAll(event.Subscriber) func()      { return func() {} }
+func (b *capturingBus) DroppedCount() uint64                      { return 0 }
+
+func TestAuditService_OverflowEvent(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	// Not started and no send timeout: every record beyond the buffer drops.
+	svc := NewAuditService(&mockSlowAuditStore{}, logger, WithChannelSize(1), WithSendTimeout(0))
+	bus := &capturingBus{}
+	svc.SetEventBus(bus)
+
+	for i := 0; i < 5; i++ {
+		svc.Record(audit.AuditRecord{ToolName: "t", Timestamp: time.Now()})
+	}
+
+	if svc.DroppedRecords() != 4 {
+		t.Fatalf("DroppedRecords() = %d, want 4", svc.DroppedRecords())
+	}
+	// Rate-limited: one event for the burst.
+	if len(bus.events) != 1 {
+		t.Fatalf("published %d events, want 1", len(bus.events))
+	}
+	evt := bus.events[0]
+	if evt.Type != EventAuditOverflow || evt.Severity != event.SeverityCritical || event.CategoryOf(evt.Type) != event.CategoryAuditOverflow {
+		t.Errorf("event = %+v", evt)
+	}
+	if p := evt.Payload.(map[string]interface{}); p["dropped"] != int64(1) || p["capacity"] != 1 {
+		t.Errorf("payload = %v", p)
+	}
+}
+
 func TestAuditService_ChannelDepthWarning(t *testing.T) {
 	defer goleak.VerifyNone(t)
 
@@ -552,3 +597,72 @@ func TestAuditService_LongRunning(t *testing.T) {
 	cancel()
 	svc.Stop()
 }
+
+// recordingStore keeps the request IDs of the records appended to it.
+type recordingStore struct {
+	mu  sync.Mutex
+	ids []string
+}
+
+func (m *recordingStore) Append(ctx context.Context, records ...audit.AuditRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range records {
+		m.ids = append(m.ids, r.RequestID)
+	}
+	return nil
+}
+
+func (m *recordingStore) Flush(ctx context.Context) error { return nil }
+func (m *recordingStore) Close() error                    { return nil }
+
+func (m *recordingStore) IDs() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.ids...)
+}
+
+func TestAuditService_Journal(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	path := filepath.Join(t.TempDir(), "audit.wal")
+
+	// A process killed before the worker flushed: the records are only in
+	// the journal.
+	journal, err := auditadapter.OpenJournal(path, logger)
+	if err != nil {
+		t.Fatalf("OpenJournal() error: %v", err)
+	}
+	lost := NewAuditService(&recordingStore{}, logger, WithJournal(journal), WithFlushInterval(time.Hour))
+	for i := 0; i < 3; i++ {
+		lost.Record(audit.AuditRecord{RequestID: fmt.Sprintf("req-%d", i), Decision: audit.DecisionDeny})
+	}
+	_ = journal.Close()
+
+	// The next start writes them, then the new records.
+	journal, err = auditadapter.OpenJournal(path, logger)
+	if err != nil {
+		t.Fatalf("OpenJournal() error: %v", err)
+	}
+	defer func() { _ = journal.Close() }()
+	store := &recordingStore{}
+	svc := NewAuditService(store, logger, WithJournal(journal), WithBatchSize(2), WithFlushInterval(10*time.Millisecond))
+	svc.Start(context.Background())
+	svc.Record(audit.AuditRecord{RequestID: "req-3"})
+	deadline := time.Now().Add(2 * time.Second)
+	for len(store.IDs()) < 4 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	svc.Record(audit.AuditRecord{RequestID: "req-4"})
+	svc.Stop()
+
+	want := []string{"req-0", "req-1", "req-2", "req-3", "req-4"}
+	if got := store.IDs(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("store got %v, want %v", got, want)
+	}
+	if n := journal.Backlog(); n != 0 {
+		t.Errorf("journal Backlog() = %d after Stop, want 0", n)
+	}
+	if svc.DroppedRecords() != 0 || svc.ChannelDepth() != 0 {
+		t.Errorf("drops = %d, channel depth = %d; want 0", svc.DroppedRecords(), svc.ChannelDepth())
+	}
+}
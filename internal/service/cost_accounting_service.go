@@ -0,0 +1,655 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/action"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/storage"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/toolstats"
+)
+
+// costUsageSeries is the time series holding per-identity, per-day usage.
+// Each data point is one identity's usage on one day during one flush
+// interval (tags "identity" and "day").
+const costUsageSeries = "costs:usage"
+
+// DefaultCostFlushInterval is how often cost aggregates are persisted.
+const DefaultCostFlushInterval = 5 * time.Minute
+
+const (
+	// costQueueSize bounds the calls waiting to be priced; further calls
+	// are dropped and counted.
+	costQueueSize = 4096
+	// costWorkers is the number of goroutines running the cost hooks.
+	costWorkers = 4
+	// costMemoryDays is how many days are kept when aggregates are not
+	// persisted.
+	costMemoryDays = 31
+	// maxCostReportDays bounds the range of one usage report.
+	maxCostReportDays = 366
+	// costWebhookMaxResponse bounds the cost webhook response body.
+	costWebhookMaxResponse = 64 << 10
+	// costDayLayout formats the UTC day of an aggregate.
+	costDayLayout = "2006-01-02"
+)
+
+// ErrInvalidCostRange is returned for a report range that is inverted or
+// longer than maxCostReportDays.
+var ErrInvalidCostRange = errors.New("invalid cost report range")
+
+// CostHook prices one completed tool call. Hooks run off the request path,
+// in order; the cost of a call is the sum of what every hook returns.
+type CostHook interface {
+	// Name identifies the hook in error counters and logs.
+	Name() string
+	// Cost returns the cost of the call in the configured currency.
+	Cost(ctx context.Context, u action.CallUsage) (float64, error)
+}
+
+// CostRate prices the calls matching a tool and upstream pattern.
+type CostRate struct {
+	// Tool is a glob matched against the tool name; empty matches any tool.
+	Tool string
+	// Upstream is a glob matched against the upstream name; empty matches
+	// any upstream.
+	Upstream string
+	// PerCall is charged for every call.
+	PerCall float64
+	// PerSecond is charged for every second of call duration.
+	PerSecond float64
+	// PerMB is charged per megabyte (1,000,000 bytes) of request and
+	// result together.
+	PerMB float64
+}
+
+// StaticCostTable is a CostHook that prices calls with the first matching
+// rate of a fixed table. Calls matching no rate cost nothing.
+type StaticCostTable struct {
+	rates []CostRate
+}
+
+// NewStaticCostTable creates a cost table. Rates must not be negative and
+// patterns must be valid globs.
+func NewStaticCostTable(rates []CostRate) (*StaticCostTable, error) {
+	for i, r := range rates {
+		if _, err := path.Match(r.Tool, ""); err != nil {
+			return nil, fmt.Errorf("cost rate %d: invalid tool pattern %q", i, r.Tool)
+		}
+		if _, err := path.Match(r.Upstream, ""); err != nil {
+			return nil, fmt.Errorf("cost rate %d: invalid upstream pattern %q", i, r.Upstream)
+		}
+		if !isFiniteNonNegative(r.PerCall) || !isFiniteNonNegative(r.PerSecond) || !isFiniteNonNegative(r.PerMB) {
+			return nil, fmt.Errorf("cost rate %d: rates must be non-negative numbers", i)
+		}
+	}
+	return &StaticCostTable{rates: append([]CostRate(nil), rates...)}, nil
+}
+
+// Name implements CostHook.
+func (t *StaticCostTable) Name() string { return "table" }
+
+// Cost implements CostHook.
+func (t *StaticCostTable) Cost(_ context.Context, u action.CallUsage) (float64, error) {
+	for _, r := range t.rates {
+		if !globMatches(r.Tool, u.Tool) || !globMatches(r.Upstream, u.Upstream) {
+			continue
+		}
+		mb := float64(u.BytesIn+u.BytesOut) / 1e6
+		return r.PerCall + r.PerSecond*u.Duration.Seconds() + r.PerMB*mb, nil
+	}
+	return 0, nil
+}
+
+// globMatches reports whether name matches pattern; an empty pattern
+// matches anything.
+func globMatches(pattern, name string) bool {
+	if pattern == "" {
+		return true
+	}
+	ok, _ := path.Match(pattern, name)
+	return ok
+}
+
+// CostWebhookPayload is the JSON body POSTed to the cost webhook for every
+// completed tool call.
+type CostWebhookPayload struct {
+	Timestamp    time.Time `json:"timestamp"`
+	RequestID    string    `json:"request_id,omitempty"`
+	SessionID    string    `json:"session_id"`
+	IdentityID   string    `json:"identity_id"`
+	IdentityName string    `json:"identity_name,omitempty"`
+	Tool         string    `json:"tool"`
+	Upstream     string    `json:"upstream,omitempty"`
+	DurationMs   float64   `json:"duration_ms"`
+	BytesIn      int64     `json:"bytes_in"`
+	BytesOut     int64     `json:"bytes_out"`
+	IsError      bool      `json:"is_error"`
+}
+
+// WebhookCostHook is a CostHook that POSTs every call to an external
+// endpoint, e.g. a billing system. The endpoint may answer with
+// {"cost": <number>} to price the call; an empty 2xx response costs nothing.
+// Calls are not retried: a failed delivery counts as a hook error.
+type WebhookCostHook struct {
+	url    string
+	secret string
+	mu     sync.Mutex
+	client *http.Client
+}
+
+// NewWebhookCostHook creates a webhook cost hook. Payloads are signed with
+// HMAC-SHA256 in the X-Signature-256 header when secret is set. The client
+// refuses private and loopback addresses, like the event webhooks.
+func NewWebhookCostHook(url, secret string, timeout time.Duration) *WebhookCostHook {
+	return &WebhookCostHook{
+		url:    url,
+		secret: secret,
+		client: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				DialContext: webhookSSRFSafeDialer().DialContext,
+			},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// SetHTTPClient overrides the default SSRF-safe HTTP client (for testing only).
+func (h *WebhookCostHook) SetHTTPClient(c *http.Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.client = c
+}
+
+// Name implements CostHook.
+func (h *WebhookCostHook) Name() string { return "webhook" }
+
+// Cost implements CostHook.
+func (h *WebhookCostHook) Cost(ctx context.Context, u action.CallUsage) (float64, error) {
+	body, err := json.Marshal(CostWebhookPayload{
+		Timestamp:    u.Timestamp,
+		RequestID:    u.RequestID,
+		SessionID:    u.SessionID,
+		IdentityID:   u.IdentityID,
+		IdentityName: u.IdentityName,
+		Tool:         u.Tool,
+		Upstream:     u.Upstream,
+		DurationMs:   float64(u.Duration.Microseconds()) / 1000,
+		BytesIn:      u.BytesIn,
+		BytesOut:     u.BytesOut,
+		IsError:      u.Outcome == toolstats.OutcomeError,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", h.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "SentinelGate-CostHook/1.0")
+	if h.secret != "" {
+		mac := hmac.New(sha256.New, []byte(h.secret))
+		mac.Write(body)
+		req.Header.Set("X-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	h.mu.Lock()
+	client := h.client
+	h.mu.Unlock()
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, costWebhookMaxResponse))
+	if err != nil {
+		return 0, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return 0, fmt.Errorf("cost webhook returned %d", resp.StatusCode)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return 0, nil
+	}
+	var answer struct {
+		Cost float64 `json:"cost"`
+	}
+	if err := json.Unmarshal(data, &answer); err != nil {
+		return 0, fmt.Errorf("cost webhook response: %w", err)
+	}
+	if !isFiniteNonNegative(answer.Cost) {
+		return 0, fmt.Errorf("cost webhook returned invalid cost %v", answer.Cost)
+	}
+	return answer.Cost, nil
+}
+
+// CostUsage is the usage and cost of one identity on one UTC day.
+type CostUsage struct {
+	Day          string  `json:"day"`
+	IdentityID   string  `json:"identity_id"`
+	IdentityName string  `json:"identity_name,omitempty"`
+	Calls        int64   `json:"calls"`
+	Errors       int64   `json:"errors"`
+	DurationMs   int64   `json:"duration_ms"`
+	BytesIn      int64   `json:"bytes_in"`
+	BytesOut     int64   `json:"bytes_out"`
+	Cost         float64 `json:"cost"`
+	// Tools breaks the calls and cost down by tool name.
+	Tools map[string]*CostToolUsage `json:"tools,omitempty"`
+}
+
+// CostToolUsage is the share of one tool in a CostUsage.
+type CostToolUsage struct {
+	Calls int64   `json:"calls"`
+	Cost  float64 `json:"cost"`
+}
+
+// add records one priced call.
+func (c *CostUsage) add(u action.CallUsage, cost float64) {
+	if u.IdentityName != "" {
+		c.IdentityName = u.IdentityName
+	}
+	c.Calls++
+	if u.Outcome == toolstats.OutcomeError {
+		c.Errors++
+	}
+	c.DurationMs += u.Duration.Milliseconds()
+	c.BytesIn += u.BytesIn
+	c.BytesOut += u.BytesOut
+	c.Cost += cost
+	if c.Tools == nil {
+		c.Tools = make(map[string]*CostToolUsage)
+	}
+	t, ok := c.Tools[u.Tool]
+	if !ok {
+		if len(c.Tools) >= maxMapEntries {
+			return
+		}
+		t = &CostToolUsage{}
+		c.Tools[u.Tool] = t
+	}
+	t.Calls++
+	t.Cost += cost
+}
+
+// merge adds the usage of o to c.
+func (c *CostUsage) merge(o *CostUsage) {
+	if o.IdentityName != "" {
+		c.IdentityName = o.IdentityName
+	}
+	c.Calls += o.Calls
+	c.Errors += o.Errors
+	c.DurationMs += o.DurationMs
+	c.BytesIn += o.BytesIn
+	c.BytesOut += o.BytesOut
+	c.Cost += o.Cost
+	for name, ot := range o.Tools {
+		if c.Tools == nil {
+			c.Tools = make(map[string]*CostToolUsage)
+		}
+		t, ok := c.Tools[name]
+		if !ok {
+			if len(c.Tools) >= maxMapEntries {
+				continue
+			}
+			t = &CostToolUsage{}
+			c.Tools[name] = t
+		}
+		t.Calls += ot.Calls
+		t.Cost += ot.Cost
+	}
+}
+
+// CostUsageReport is the usage of every identity over a range of days.
+type CostUsageReport struct {
+	Currency string `json:"currency"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	// Persistent is false when usage is only kept in memory; the report
+	// then covers the time since Since.
+	Persistent bool      `json:"persistent"`
+	Since      time.Time `json:"since"`
+	Total      CostUsage `json:"total"`
+	// Identities sums each identity's usage over the range, highest cost first.
+	Identities []CostUsage `json:"identities"`
+	// Days lists the usage of each identity on each day, by day and identity.
+	Days []CostUsage `json:"days"`
+	// Dropped counts calls not priced because the queue was full.
+	Dropped uint64 `json:"dropped"`
+	// HookErrors counts failed hook invocations by hook name.
+	HookErrors map[string]uint64 `json:"hook_errors"`
+}
+
+// costKey identifies an aggregate.
+type costKey struct {
+	identity string
+	day      string
+}
+
+// CostAccountingService prices every completed tool call with its cost
+// hooks and aggregates usage and cost per identity and UTC day, so usage
+// can be charged back to the teams owning the agents. Calls are priced
+// asynchronously by Run; aggregates are flushed to the time series store
+// and survive restarts. Without a store, the last costMemoryDays days are
+// kept in memory.
+type CostAccountingService struct {
+	hooks    []CostHook
+	currency string
+	queue    chan action.CallUsage
+
+	mu         sync.Mutex
+	usage      map[costKey]*CostUsage // since the last flush, or all days without a store
+	since      time.Time
+	dropped    uint64
+	hookErrors map[string]uint64
+	capWarn    bool
+
+	tsStore storage.TimeSeriesStore
+	logger  *slog.Logger
+}
+
+// NewCostAccountingService creates a CostAccountingService. Calls are
+// priced by the hooks in order; with no hooks, usage is tracked at no cost.
+func NewCostAccountingService(hooks []CostHook, logger *slog.Logger) *CostAccountingService {
+	return &CostAccountingService{
+		hooks:      hooks,
+		currency:   "USD",
+		queue:      make(chan action.CallUsage, costQueueSize),
+		usage:      make(map[costKey]*CostUsage),
+		since:      time.Now().UTC(),
+		hookErrors: make(map[string]uint64),
+		logger:     logger,
+	}
+}
+
+// Compile-time check that CostAccountingService implements action.UsageRecorder.
+var _ action.UsageRecorder = (*CostAccountingService)(nil)
+
+// SetCurrency sets the currency label of reports.
+func (s *CostAccountingService) SetCurrency(currency string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.currency = currency
+}
+
+// SetTimeSeriesStore wires the store used to persist aggregates.
+func (s *CostAccountingService) SetTimeSeriesStore(ts storage.TimeSeriesStore) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tsStore = ts
+}
+
+// RecordUsage queues a completed call for pricing. It never blocks: when
+// the queue is full the call is dropped and counted.
+func (s *CostAccountingService) RecordUsage(u action.CallUsage) {
+	select {
+	case s.queue <- u:
+	default:
+		s.mu.Lock()
+		s.dropped++
+		first := s.dropped == 1
+		s.mu.Unlock()
+		if first {
+			s.logger.Warn("cost accounting: queue full, calls are not being priced", "queue_size", costQueueSize)
+		}
+	}
+}
+
+// Run prices queued calls and flushes aggregates every interval until ctx
+// is cancelled. Callers flush once more on shutdown.
+func (s *CostAccountingService) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultCostFlushInterval
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < costWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case u := <-s.queue:
+					s.price(ctx, u)
+				}
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case <-ticker.C:
+			if err := s.Flush(ctx); err != nil {
+				s.logger.Warn("failed to flush cost accounting", "error", err)
+			}
+		}
+	}
+}
+
+// price runs the hooks for one call and adds it to its aggregate. A failing
+// hook contributes nothing to the cost and is counted.
+func (s *CostAccountingService) price(ctx context.Context, u action.CallUsage) {
+	var cost float64
+	for _, h := range s.hooks {
+		c, err := h.Cost(ctx, u)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			s.mu.Lock()
+			s.hookErrors[h.Name()]++
+			s.mu.Unlock()
+			s.logger.Debug("cost hook failed", "hook", h.Name(), "tool", u.Tool, "error", err)
+			continue
+		}
+		cost += c
+	}
+	s.add(u, cost)
+}
+
+// add records a priced call in its identity and day aggregate.
+func (s *CostAccountingService) add(u action.CallUsage, cost float64) {
+	ts := u.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	key := costKey{identity: u.IdentityID, day: ts.UTC().Format(costDayLayout)}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.usage[key]
+	if !ok {
+		if len(s.usage) >= maxMapEntries {
+			if !s.capWarn {
+				s.capWarn = true
+				s.logger.Warn("cost accounting: aggregate map reached size cap, new identities will not be tracked", "cap", maxMapEntries)
+			}
+			return
+		}
+		c = &CostUsage{Day: key.day, IdentityID: key.identity}
+		s.usage[key] = c
+	}
+	c.add(u, cost)
+}
+
+// Flush persists the aggregates collected since the last flush. Without a
+// store it drops in-memory days older than costMemoryDays instead.
+func (s *CostAccountingService) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	ts := s.tsStore
+	if ts == nil {
+		cutoff := time.Now().UTC().AddDate(0, 0, -costMemoryDays).Format(costDayLayout)
+		for key := range s.usage {
+			if key.day < cutoff {
+				delete(s.usage, key)
+			}
+		}
+		s.capWarn = false
+		s.mu.Unlock()
+		return nil
+	}
+	if len(s.usage) == 0 {
+		s.mu.Unlock()
+		return nil
+	}
+	pending := s.usage
+	s.usage = make(map[costKey]*CostUsage)
+	s.capWarn = false
+	s.mu.Unlock()
+
+	now := time.Now().UTC()
+	var firstErr error
+	for key, c := range pending {
+		payload, err := json.Marshal(c)
+		if err != nil {
+			continue
+		}
+		err = ts.Append(ctx, costUsageSeries, storage.DataPoint{
+			Timestamp: now,
+			Value:     c.Cost,
+			Tags:      map[string]string{"identity": key.identity, "day": key.day},
+			Payload:   payload,
+		})
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("persist cost usage for %s on %s: %w", key.identity, key.day, err)
+		}
+	}
+	return firstErr
+}
+
+// Report returns the usage of every identity, or of identityID when set,
+// on the UTC days from through to (inclusive).
+func (s *CostAccountingService) Report(ctx context.Context, from, to time.Time, identityID string) (*CostUsageReport, error) {
+	fromDay := from.UTC().Format(costDayLayout)
+	toDay := to.UTC().Format(costDayLayout)
+	if toDay < fromDay || to.Sub(from) > maxCostReportDays*24*time.Hour {
+		return nil, fmt.Errorf("%w: from must not be after to and the range must not exceed %d days", ErrInvalidCostRange, maxCostReportDays)
+	}
+	inRange := func(identity, day string) bool {
+		return day >= fromDay && day <= toDay && (identityID == "" || identity == identityID)
+	}
+
+	s.mu.Lock()
+	report := &CostUsageReport{
+		Currency:   s.currency,
+		From:       fromDay,
+		To:         toDay,
+		Persistent: s.tsStore != nil,
+		Since:      s.since,
+		Dropped:    s.dropped,
+		HookErrors: make(map[string]uint64, len(s.hookErrors)),
+	}
+	for name, n := range s.hookErrors {
+		report.HookErrors[name] = n
+	}
+	merged := make(map[costKey]*CostUsage)
+	for key, c := range s.usage {
+		if !inRange(key.identity, key.day) {
+			continue
+		}
+		m := &CostUsage{Day: key.day, IdentityID: key.identity}
+		m.merge(c)
+		merged[key] = m
+	}
+	ts := s.tsStore
+	s.mu.Unlock()
+
+	if ts != nil {
+		// Points are stamped at flush time, which is on or after their day.
+		start, _ := time.Parse(costDayLayout, fromDay)
+		points, err := ts.Query(ctx, costUsageSeries, start, time.Now().UTC())
+		if err != nil {
+			return nil, fmt.Errorf("query cost usage: %w", err)
+		}
+		for _, p := range points {
+			key := costKey{identity: p.Tags["identity"], day: p.Tags["day"]}
+			if key.day == "" || !inRange(key.identity, key.day) {
+				continue
+			}
+			var c CostUsage
+			if err := json.Unmarshal(p.Payload, &c); err != nil {
+				continue
+			}
+			m, ok := merged[key]
+			if !ok {
+				m = &CostUsage{Day: key.day, IdentityID: key.identity}
+				merged[key] = m
+			}
+			m.merge(&c)
+		}
+	}
+
+	byIdentity := make(map[string]*CostUsage)
+	report.Days = make([]CostUsage, 0, len(merged))
+	for key, c := range merged {
+		report.Days = append(report.Days, *c)
+		id, ok := byIdentity[key.identity]
+		if !ok {
+			id = &CostUsage{IdentityID: key.identity}
+			byIdentity[key.identity] = id
+		}
+		id.merge(c)
+		report.Total.merge(c)
+	}
+	sort.Slice(report.Days, func(i, j int) bool {
+		if report.Days[i].Day != report.Days[j].Day {
+			return report.Days[i].Day < report.Days[j].Day
+		}
+		return report.Days[i].IdentityID < report.Days[j].IdentityID
+	})
+	report.Identities = make([]CostUsage, 0, len(byIdentity))
+	for _, c := range byIdentity {
+		c.Tools = nil
+		report.Identities = append(report.Identities, *c)
+	}
+	sort.Slice(report.Identities, func(i, j int) bool {
+		a, b := report.Identities[i], report.Identities[j]
+		if a.Cost != b.Cost {
+			return a.Cost > b.Cost
+		}
+		return a.IdentityID < b.IdentityID
+	})
+	report.Total.Tools = nil
+	report.Total.IdentityName = ""
+
+	roundCostUsage(&report.Total)
+	for i := range report.Identities {
+		roundCostUsage(&report.Identities[i])
+	}
+	for i := range report.Days {
+		roundCostUsage(&report.Days[i])
+	}
+	return report, nil
+}
+
+// roundCostUsage rounds the costs of c for presentation.
+func roundCostUsage(c *CostUsage) {
+	c.Cost = roundCost(c.Cost)
+	for _, t := range c.Tools {
+		t.Cost = roundCost(t.Cost)
+	}
+}
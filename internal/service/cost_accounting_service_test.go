@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	storageAdapter "github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/storage"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/action"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/toolstats"
+)
+
+func TestStaticCostTable_FirstMatchingRate(t *testing.T) {
+	table, err := NewStaticCostTable([]CostRate{
+		{Tool: "search_*", PerCall: 0.01},
+		{Upstream: "gpu-*", PerCall: 0.1, PerSecond: 1, PerMB: 2},
+	})
+	if err != nil {
+		t.Fatalf("NewStaticCostTable: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		u    action.CallUsage
+		want float64
+	}{
+		{"tool rate", action.CallUsage{Tool: "search_web", Upstream: "gpu-1", Duration: time.Minute}, 0.01},
+		{"upstream rate", action.CallUsage{Tool: "render", Upstream: "gpu-1", Duration: 1500 * time.Millisecond, BytesIn: 250000, BytesOut: 250000}, 0.1 + 1.5 + 1},
+		{"no rate", action.CallUsage{Tool: "render", Upstream: "cpu"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := table.Cost(context.Background(), tt.u)
+			if err != nil {
+				t.Fatalf("Cost: %v", err)
+			}
+			if roundCost(got) != roundCost(tt.want) {
+				t.Errorf("Cost = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewStaticCostTable([]CostRate{{Tool: "[a-"}}); err == nil {
+		t.Error("invalid pattern should be rejected")
+	}
+	if _, err := NewStaticCostTable([]CostRate{{PerCall: -1}}); err == nil {
+		t.Error("negative rate should be rejected")
+	}
+}
+
+func TestWebhookCostHook_PricesAndSigns(t *testing.T) {
+	const secret = "0123456789abcdef0123456789abcdef"
+	var got CostWebhookPayload
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		if r.Header.Get("X-Signature-256") != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+			t.Error("payload signature mismatch")
+		}
+		_ = json.Unmarshal(body, &got)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"cost": 0.25}`))
+	}))
+	defer srv.Close()
+
+	hook := NewWebhookCostHook(srv.URL, secret, time.Second)
+	hook.SetHTTPClient(srv.Client())
+	u := action.CallUsage{
+		IdentityID: "team-a-bot", Tool: "search", Upstream: "web",
+		Duration: 1500 * time.Microsecond, BytesIn: 10, BytesOut: 20, Outcome: toolstats.OutcomeError,
+	}
+	cost, err := hook.Cost(context.Background(), u)
+	if err != nil {
+		t.Fatalf("Cost: %v", err)
+	}
+	if cost != 0.25 {
+		t.Errorf("cost = %v, want 0.25", cost)
+	}
+	if got.IdentityID != "team-a-bot" || got.Upstream != "web" || got.DurationMs != 1.5 || got.BytesOut != 20 || !got.IsError {
+		t.Errorf("payload = %+v", got)
+	}
+
+	status = http.StatusBadGateway
+	if _, err := hook.Cost(context.Background(), u); err == nil {
+		t.Error("non-2xx response should be an error")
+	}
+}
+
+// failingCostHook always fails.
+type failingCostHook struct{}
+
+func (failingCostHook) Name() string { return "broken" }
+func (failingCostHook) Cost(context.Context, action.CallUsage) (float64, error) {
+	return 0, errors.New("unavailable")
+}
+
+func TestCostAccountingService_AggregatesPerIdentityAndDay(t *testing.T) {
+	ctx := context.Background()
+	table, _ := NewStaticCostTable([]CostRate{{PerCall: 0.5}})
+	svc := NewCostAccountingService([]CostHook{table, failingCostHook{}}, slog.Default())
+	svc.SetCurrency("EUR")
+
+	today := time.Now().UTC()
+	yesterday := today.AddDate(0, 0, -1)
+	svc.price(ctx, action.CallUsage{Timestamp: today, IdentityID: "a", IdentityName: "alice", Tool: "search", BytesIn: 5, Duration: 2 * time.Second})
+	svc.price(ctx, action.CallUsage{Timestamp: today, IdentityID: "a", Tool: "fetch", Outcome: toolstats.OutcomeError})
+	svc.price(ctx, action.CallUsage{Timestamp: yesterday, IdentityID: "a", Tool: "search"})
+	svc.price(ctx, action.CallUsage{Timestamp: today, IdentityID: "b", Tool: "search"})
+
+	report, err := svc.Report(ctx, yesterday, today, "")
+	if err != nil {
+		t.Fatalf("Report: %v", err)
+	}
+	if report.Currency != "EUR" || report.Total.Calls != 4 || report.Total.Cost != 2 {
+		t.Errorf("total = %+v (%s)", report.Total, report.Currency)
+	}
+	if report.HookErrors["broken"] != 4 {
+		t.Errorf("hook errors = %v", report.HookErrors)
+	}
+	if len(report.Days) != 3 || report.Days[0].Day != yesterday.Format("2006-01-02") {
+		t.Fatalf("days = %+v", report.Days)
+	}
+	todayA := report.Days[1]
+	if todayA.IdentityID != "a" || todayA.IdentityName != "alice" || todayA.Calls != 2 || todayA.Errors != 1 ||
+		todayA.BytesIn != 5 || todayA.DurationMs != 2000 || todayA.Tools["fetch"].Calls != 1 {
+		t.Errorf("today a = %+v", todayA)
+	}
+	if len(report.Identities) != 2 || report.Identities[0].IdentityID != "a" || report.Identities[0].Cost != 1.5 {
+		t.Errorf("identities = %+v", report.Identities)
+	}
+
+	only, err := svc.Report(ctx, today, today, "b")
+	if err != nil {
+		t.Fatalf("Report: %v", err)
+	}
+	if len(only.Days) != 1 || only.Total.Calls != 1 {
+		t.Errorf("filtered report = %+v", only)
+	}
+
+	if _, err := svc.Report(ctx, today, yesterday, ""); !errors.Is(err, ErrInvalidCostRange) {
+		t.Errorf("inverted range error = %v", err)
+	}
+}
+
+func TestCostAccountingService_PersistsAggregates(t *testing.T) {
+	ctx := context.Background()
+	ts, err := storageAdapter.NewSQLiteTimeSeriesStore(filepath.Join(t.TempDir(), "ts.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = ts.Close() }()
+
+	table, _ := NewStaticCostTable([]CostRate{{PerCall: 1}})
+	svc := NewCostAccountingService([]CostHook{table}, slog.Default())
+	svc.SetTimeSeriesStore(ts)
+	now := time.Now().UTC()
+	svc.price(ctx, action.CallUsage{Timestamp: now, IdentityID: "a", Tool: "search"})
+	if err := svc.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	// Not yet flushed: still included in reports.
+	svc.price(ctx, action.CallUsage{Timestamp: now, IdentityID: "a", Tool: "search"})
+
+	report, err := svc.Report(ctx, now, now, "")
+	if err != nil {
+		t.Fatalf("Report: %v", err)
+	}
+	if !report.Persistent || len(report.Days) != 1 || report.Days[0].Calls != 2 || report.Days[0].Cost != 2 {
+		t.Errorf("report = %+v", report)
+	}
+
+	restarted := NewCostAccountingService(nil, slog.Default())
+	restarted.SetTimeSeriesStore(ts)
+	report, err = restarted.Report(ctx, now, now, "a")
+	if err != nil {
+		t.Fatalf("Report after restart: %v", err)
+	}
+	if report.Total.Calls != 1 || report.Days[0].Tools["search"].Cost != 1 {
+		t.Errorf("report after restart = %+v", report)
+	}
+}
+
+func TestCostAccountingService_DropsWhenQueueFull(t *testing.T) {
+	svc := NewCostAccountingService(nil, slog.Default())
+	for i := 0; i < costQueueSize+3; i++ {
+		svc.RecordUsage(action.CallUsage{IdentityID: "a", Tool: "t"})
+	}
+	report, err := svc.Report(context.Background(), time.Now(), time.Now(), "")
+	if err != nil {
+		t.Fatalf("Report: %v", err)
+	}
+	if report.Dropped != 3 {
+		t.Errorf("dropped = %d, want 3", report.Dropped)
+	}
+}
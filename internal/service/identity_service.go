@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"reflect"
 	"sync"
 	"time"
 
@@ -22,12 +23,14 @@ var (
 	ErrAPIKeyNotFound   = errors.New("api key not found")
 	ErrDuplicateName    = errors.New("identity name already exists")
 	ErrReadOnly         = errors.New("cannot modify read-only resource")
+	ErrInvalidOrigin    = errors.New("invalid origin")
+	ErrInvalidAccess    = errors.New("invalid access restrictions")
 )
 
 // IdentityService provides CRUD operations on identities and API keys
 // with Argon2id key hashing and persistence to state.json.
 type IdentityService struct {
-	stateStore *state.FileStateStore
+	stateStore state.Store
 	logger     *slog.Logger
 	mu         sync.Mutex // serializes state reads and writes
 	// In-memory cache to avoid re-reading state.json on every request.
@@ -42,13 +45,14 @@ type IdentityService struct {
 	// The hook is called with the IdentityService mutex released.
 	postMutationHook func()
 
-	// sessionInvalidator is called when an identity's roles change, to clear
-	// cached sessions so stale roles are not used (H-1).
+	// sessionInvalidator is called when an identity's roles or access
+	// restrictions change, to clear cached sessions so stale roles and
+	// restrictions are not used (H-1).
 	sessionInvalidator func(identityID string)
 }
 
 // NewIdentityService creates a new IdentityService.
-func NewIdentityService(stateStore *state.FileStateStore, logger *slog.Logger) *IdentityService {
+func NewIdentityService(stateStore state.Store, logger *slog.Logger) *IdentityService {
 	return &IdentityService{
 		stateStore: stateStore,
 		logger:     logger,
@@ -106,6 +110,7 @@ func (s *IdentityService) refreshCache() error {
 		copy(roles, e.Roles)
 		ident := e
 		ident.Roles = roles
+		ident.Access = cloneAccessEntry(e.Access)
 		s.cachedIdentities[i] = ident
 	}
 	// M-22: Deep-copy APIKeyEntry to avoid sharing ExpiresAt pointer
@@ -135,6 +140,7 @@ func (s *IdentityService) ListIdentities(_ context.Context) ([]state.IdentityEnt
 		copy(roles, e.Roles)
 		entry := e
 		entry.Roles = roles
+		entry.Access = cloneAccessEntry(e.Access)
 		result[i] = entry
 	}
 	return result, nil
@@ -148,6 +154,7 @@ func (s *IdentityService) GetIdentity(_ context.Context, id string) (*state.Iden
 	for i := range s.cachedIdentities {
 		if s.cachedIdentities[i].ID == id {
 			entry := s.cachedIdentities[i]
+			entry.Access = cloneAccessEntry(entry.Access)
 			return &entry, nil
 		}
 	}
@@ -156,8 +163,9 @@ func (s *IdentityService) GetIdentity(_ context.Context, id string) (*state.Iden
 
 // CreateIdentityInput holds the input for creating an identity.
 type CreateIdentityInput struct {
-	Name  string   `json:"name"`
-	Roles []string `json:"roles"`
+	Name   string                     `json:"name"`
+	Roles  []string                   `json:"roles"`
+	Access *state.IdentityAccessEntry `json:"access,omitempty"`
 }
 
 // CreateIdentity creates a new identity and persists it to state.json.
@@ -165,11 +173,15 @@ func (s *IdentityService) CreateIdentity(_ context.Context, input CreateIdentity
 	if input.Name == "" {
 		return nil, fmt.Errorf("name is required")
 	}
+	access, err := normalizeAccessEntry(input.Access)
+	if err != nil {
+		return nil, err
+	}
 
 	s.mu.Lock()
 
 	var entry state.IdentityEntry
-	err := s.stateStore.Mutate(func(appState *state.AppState) error {
+	err = s.stateStore.Mutate(func(appState *state.AppState) error {
 		// Check name uniqueness.
 		for _, existing := range appState.Identities {
 			if existing.Name == input.Name {
@@ -187,6 +199,7 @@ func (s *IdentityService) CreateIdentity(_ context.Context, input CreateIdentity
 			ID:        uuid.New().String(),
 			Name:      input.Name,
 			Roles:     roles,
+			Access:    access,
 			CreatedAt: now,
 			UpdatedAt: now, // M-20: set UpdatedAt on create
 		}
@@ -209,17 +222,28 @@ func (s *IdentityService) CreateIdentity(_ context.Context, input CreateIdentity
 }
 
 // UpdateIdentityInput holds the input for updating an identity.
+// Access replaces the identity's access restrictions when set; an empty
+// object removes them.
 type UpdateIdentityInput struct {
-	Name  *string  `json:"name,omitempty"`
-	Roles []string `json:"roles,omitempty"`
+	Name   *string                    `json:"name,omitempty"`
+	Roles  []string                   `json:"roles,omitempty"`
+	Access *state.IdentityAccessEntry `json:"access,omitempty"`
 }
 
 // UpdateIdentity updates an existing identity and persists the change.
 func (s *IdentityService) UpdateIdentity(_ context.Context, id string, input UpdateIdentityInput) (*state.IdentityEntry, error) {
+	var access *state.IdentityAccessEntry
+	if input.Access != nil {
+		var err error
+		if access, err = normalizeAccessEntry(input.Access); err != nil {
+			return nil, err
+		}
+	}
+
 	s.mu.Lock()
 
 	var entry state.IdentityEntry
-	var rolesChanged bool
+	var rolesChanged, accessChanged bool
 	err := s.stateStore.Mutate(func(appState *state.AppState) error {
 		idx := -1
 		for i := range appState.Identities {
@@ -254,6 +278,11 @@ func (s *IdentityService) UpdateIdentity(_ context.Context, id string, input Upd
 			appState.Identities[idx].Roles = input.Roles
 		}
 
+		if input.Access != nil {
+			accessChanged = !reflect.DeepEqual(appState.Identities[idx].Access, access)
+			appState.Identities[idx].Access = access
+		}
+
 		// M-21: Update the timestamp on every mutation.
 		appState.Identities[idx].UpdatedAt = time.Now().UTC()
 		entry = appState.Identities[idx]
@@ -272,14 +301,64 @@ func (s *IdentityService) UpdateIdentity(_ context.Context, id string, input Upd
 	s.mu.Unlock()
 	s.callPostMutationHook()
 
-	// H-1: Invalidate cached sessions when roles change so stale roles are not used.
-	if rolesChanged && invalidator != nil {
+	// H-1: Invalidate cached sessions when roles or access restrictions change
+	// so stale roles and restrictions are not used.
+	if (rolesChanged || accessChanged) && invalidator != nil {
 		invalidator(id)
 	}
 
 	return &entry, nil
 }
 
+// AccessRestrictionsFromEntry converts stored access restrictions into their
+// domain form. It returns nil for a nil or empty entry.
+func AccessRestrictionsFromEntry(e *state.IdentityAccessEntry) (*auth.AccessRestrictions, error) {
+	if e == nil || (len(e.Windows) == 0 && len(e.AllowCountries) == 0 && len(e.DenyCountries) == 0) {
+		return nil, nil
+	}
+	windows := make([]auth.AccessWindow, len(e.Windows))
+	for i, w := range e.Windows {
+		windows[i] = auth.AccessWindow{Start: w.Start, End: w.End}
+		for _, name := range w.Days {
+			day, err := auth.ParseWeekday(name)
+			if err != nil {
+				return nil, fmt.Errorf("windows[%d].days: %w", i, err)
+			}
+			windows[i].Days = append(windows[i].Days, day)
+		}
+	}
+	return auth.NewAccessRestrictions(e.Timezone, windows, e.AllowCountries, e.DenyCountries)
+}
+
+// normalizeAccessEntry validates e and returns a copy to store, or nil when
+// it imposes no restriction.
+func normalizeAccessEntry(e *state.IdentityAccessEntry) (*state.IdentityAccessEntry, error) {
+	r, err := AccessRestrictionsFromEntry(e)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAccess, err)
+	}
+	if r == nil {
+		return nil, nil
+	}
+	return cloneAccessEntry(e), nil
+}
+
+// cloneAccessEntry deep-copies e so cached entries share no slices.
+func cloneAccessEntry(e *state.IdentityAccessEntry) *state.IdentityAccessEntry {
+	if e == nil {
+		return nil
+	}
+	c := *e
+	c.AllowCountries = append([]string(nil), e.AllowCountries...)
+	c.DenyCountries = append([]string(nil), e.DenyCountries...)
+	c.Windows = make([]state.AccessWindowEntry, len(e.Windows))
+	for i, w := range e.Windows {
+		w.Days = append([]string(nil), w.Days...)
+		c.Windows[i] = w
+	}
+	return &c
+}
+
 // stringSlicesEqual returns true if two string slices have the same elements in the same order.
 func stringSlicesEqual(a, b []string) bool {
 	if len(a) != len(b) {
@@ -353,6 +432,8 @@ func (s *IdentityService) DeleteIdentity(_ context.Context, id string) ([]string
 type GenerateKeyInput struct {
 	IdentityID string `json:"identity_id"`
 	Name       string `json:"name"`
+	// AllowedOrigins optionally binds the key to browser origins.
+	AllowedOrigins []string `json:"allowed_origins,omitempty"`
 }
 
 // GenerateKeyResult holds the result of key generation.
@@ -372,6 +453,13 @@ func (s *IdentityService) GenerateKey(_ context.Context, input GenerateKeyInput)
 	if input.Name == "" {
 		return nil, fmt.Errorf("name is required")
 	}
+	var allowedOrigins []string
+	for _, origin := range input.AllowedOrigins {
+		if err := auth.ValidateOrigin(origin); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidOrigin, err)
+		}
+		allowedOrigins = append(allowedOrigins, auth.NormalizeOrigin(origin))
+	}
 
 	// Generate key material before acquiring locks (Argon2id is CPU-intensive).
 	rawKey := make([]byte, 32)
@@ -415,12 +503,13 @@ func (s *IdentityService) GenerateKey(_ context.Context, input GenerateKeyInput)
 		}
 
 		entry = state.APIKeyEntry{
-			ID:         uuid.New().String(),
-			KeyHash:    hash,
-			KeyPrefix:  keyPrefix,
-			IdentityID: input.IdentityID,
-			Name:       input.Name,
-			CreatedAt:  time.Now().UTC(),
+			ID:             uuid.New().String(),
+			KeyHash:        hash,
+			KeyPrefix:      keyPrefix,
+			IdentityID:     input.IdentityID,
+			Name:           input.Name,
+			CreatedAt:      time.Now().UTC(),
+			AllowedOrigins: allowedOrigins,
 		}
 
 		appState.APIKeys = append(appState.APIKeys, entry)
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"os"
 	"path/filepath"
@@ -203,6 +204,55 @@ func TestIdentityService_UpdateIdentity(t *testing.T) {
 	}
 }
 
+func TestIdentityService_AccessRestrictions(t *testing.T) {
+	svc, _, _ := testIdentityEnv(t)
+	ctx := context.Background()
+
+	_, err := svc.CreateIdentity(ctx, CreateIdentityInput{
+		Name:   "bad-window",
+		Access: &state.IdentityAccessEntry{Windows: []state.AccessWindowEntry{{Days: []string{"funday"}, Start: "09:00", End: "17:00"}}},
+	})
+	if !errors.Is(err, ErrInvalidAccess) {
+		t.Fatalf("CreateIdentity() with invalid weekday: expected ErrInvalidAccess, got %v", err)
+	}
+
+	created, err := svc.CreateIdentity(ctx, CreateIdentityInput{
+		Name:   "contractor",
+		Roles:  []string{"user"},
+		Access: &state.IdentityAccessEntry{Timezone: "Europe/Rome", AllowCountries: []string{"IT"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateIdentity() unexpected error: %v", err)
+	}
+	if created.Access == nil || created.Access.Timezone != "Europe/Rome" {
+		t.Fatalf("CreateIdentity() Access = %+v", created.Access)
+	}
+
+	var invalidated []string
+	svc.SetSessionInvalidator(func(id string) { invalidated = append(invalidated, id) })
+
+	// An empty access object removes the restrictions and flushes cached sessions.
+	updated, err := svc.UpdateIdentity(ctx, created.ID, UpdateIdentityInput{Access: &state.IdentityAccessEntry{}})
+	if err != nil {
+		t.Fatalf("UpdateIdentity() unexpected error: %v", err)
+	}
+	if updated.Access != nil {
+		t.Errorf("UpdateIdentity() Access = %+v, want nil", updated.Access)
+	}
+	if len(invalidated) != 1 || invalidated[0] != created.ID {
+		t.Errorf("session invalidator calls = %v, want [%s]", invalidated, created.ID)
+	}
+
+	// Updates that leave access untouched do not invalidate sessions.
+	newName := "contractor-2"
+	if _, err := svc.UpdateIdentity(ctx, created.ID, UpdateIdentityInput{Name: &newName}); err != nil {
+		t.Fatalf("UpdateIdentity() unexpected error: %v", err)
+	}
+	if len(invalidated) != 1 {
+		t.Errorf("session invalidator calls = %v, want 1", invalidated)
+	}
+}
+
 func TestIdentityService_UpdateIdentity_NotFound(t *testing.T) {
 	svc, _, _ := testIdentityEnv(t)
 	ctx := context.Background()
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/event"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/scheduler"
+)
+
+// EventJobFailed fires when a recurring job run fails.
+const EventJobFailed = "scheduler.job_failed"
+
+// JobService exposes the recurring job scheduler to the admin API and
+// persists schedule changes made there to state.json.
+type JobService struct {
+	scheduler  *scheduler.Scheduler
+	stateStore state.Store
+	logger     *slog.Logger
+}
+
+// NewJobService creates a JobService over sched. stateStore may be nil, in
+// which case schedule changes last until restart.
+func NewJobService(sched *scheduler.Scheduler, stateStore state.Store, logger *slog.Logger) *JobService {
+	return &JobService{scheduler: sched, stateStore: stateStore, logger: logger}
+}
+
+// SetEventBus publishes a warning event for every failed run.
+func (s *JobService) SetEventBus(bus event.Bus) {
+	if bus == nil {
+		return
+	}
+	s.scheduler.SetRunObserver(func(job string, result scheduler.RunResult) {
+		if result.Status != scheduler.RunStatusError {
+			return
+		}
+		bus.Publish(context.Background(), event.Event{
+			Type:     EventJobFailed,
+			Source:   "scheduler",
+			Severity: event.SeverityWarning,
+			Payload: map[string]interface{}{
+				"job":        job,
+				"trigger":    result.Trigger,
+				"error":      result.Error,
+				"started_at": result.StartedAt,
+			},
+			Timestamp: time.Now().UTC(),
+		})
+	})
+}
+
+// List returns every job with its schedule and last run.
+func (s *JobService) List() []scheduler.JobStatus {
+	return s.scheduler.List()
+}
+
+// Get returns one job with its recent run history.
+func (s *JobService) Get(name string) (scheduler.JobStatus, error) {
+	return s.scheduler.Get(name)
+}
+
+// Trigger runs a job now. It returns without waiting for the run to finish.
+func (s *JobService) Trigger(name string) error {
+	return s.scheduler.Trigger(name)
+}
+
+// UpdateSchedule changes the schedule of a job and persists it, so it
+// overrides the YAML config from the next start on.
+func (s *JobService) UpdateSchedule(_ context.Context, name, schedule string, enabled bool) (scheduler.JobStatus, error) {
+	if err := s.scheduler.Update(name, schedule, enabled); err != nil {
+		return scheduler.JobStatus{}, err
+	}
+	if s.stateStore != nil {
+		if err := s.stateStore.Mutate(func(appState *state.AppState) error {
+			if appState.JobSchedules == nil {
+				appState.JobSchedules = make(map[string]state.JobScheduleEntry)
+			}
+			appState.JobSchedules[name] = state.JobScheduleEntry{
+				Schedule:  schedule,
+				Enabled:   enabled,
+				UpdatedAt: time.Now().UTC(),
+			}
+			return nil
+		}); err != nil {
+			return scheduler.JobStatus{}, fmt.Errorf("persist job schedule: %w", err)
+		}
+	}
+	s.logger.Info("job schedule updated", "job", name, "schedule", schedule, "enabled", enabled)
+	return s.scheduler.Get(name)
+}
@@ -27,9 +27,10 @@ func DefaultNamespaceConfig() NamespaceConfig {
 
 // NamespaceService manages tool visibility per role.
 type NamespaceService struct {
-	mu     sync.RWMutex
-	config NamespaceConfig
-	logger *slog.Logger
+	mu      sync.RWMutex
+	config  NamespaceConfig
+	version uint64
+	logger  *slog.Logger
 }
 
 // NewNamespaceService creates a new namespace service.
@@ -55,6 +56,15 @@ func (s *NamespaceService) SetConfig(cfg NamespaceConfig) {
 		cfg.Rules = make(map[string]*NamespaceRule)
 	}
 	s.config = cfg
+	s.version++
+}
+
+// Version returns a counter that changes on every SetConfig, so cached
+// per-role tool lists can be invalidated when visibility rules change.
+func (s *NamespaceService) Version() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.version
 }
 
 // IsToolVisible returns whether a tool should be visible for the given roles.
@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/event"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/proxy"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/session"
+)
+
+// EventNoticeUpdated is published when the notice changes. The "config."
+// prefix files it under the admin event category.
+const EventNoticeUpdated = "config.notice_updated"
+
+// Notice limits. The banner is meant for a single line in a frontend.
+const (
+	maxNoticeBanner     = 256
+	maxNoticeTerms      = 16 * 1024
+	maxNoticeIdentities = 1000
+)
+
+// ErrInvalidNotice is returned for a notice that is too long or malformed.
+var ErrInvalidNotice = errors.New("invalid notice")
+
+// NoticeText is the banner and terms shown to agent users.
+type NoticeText struct {
+	Banner string `json:"banner,omitempty"`
+	Terms  string `json:"terms,omitempty"`
+}
+
+// NoticeConfig is the notice returned with initialize: a default for every
+// identity and overrides keyed by identity ID. An override with no text
+// shows that identity no notice.
+type NoticeConfig struct {
+	Banner     string                `json:"banner"`
+	Terms      string                `json:"terms"`
+	Identities map[string]NoticeText `json:"identities,omitempty"`
+	UpdatedAt  time.Time             `json:"updated_at,omitempty"`
+}
+
+// NoticeService manages the notice set from the admin API and serves it to
+// the upstream router. It records an audit entry when a session
+// acknowledges the notice it was served.
+type NoticeService struct {
+	stateStore state.Store
+	logger     *slog.Logger
+	bus        event.Bus
+	recorder   proxy.AuditRecorder
+
+	mu  sync.RWMutex
+	cfg NoticeConfig
+}
+
+// NewNoticeService creates a NoticeService. stateStore may be nil, in which
+// case the notice lasts until restart.
+func NewNoticeService(stateStore state.Store, logger *slog.Logger) *NoticeService {
+	return &NoticeService{stateStore: stateStore, logger: logger}
+}
+
+// SetEventBus publishes an event for every change.
+func (s *NoticeService) SetEventBus(bus event.Bus) {
+	s.bus = bus
+}
+
+// SetAuditRecorder sets the recorder for notice acknowledgments.
+func (s *NoticeService) SetAuditRecorder(r proxy.AuditRecorder) {
+	s.recorder = r
+}
+
+// Load replaces the notice with the persisted entry. An entry that no
+// longer validates is skipped with a warning.
+func (s *NoticeService) Load(entry *state.NoticeEntry) {
+	cfg := NoticeConfig{}
+	if entry != nil {
+		cfg = NoticeConfig{Banner: entry.Banner, Terms: entry.Terms, UpdatedAt: entry.UpdatedAt}
+		if len(entry.Identities) > 0 {
+			cfg.Identities = make(map[string]NoticeText, len(entry.Identities))
+			for id, t := range entry.Identities {
+				cfg.Identities[id] = NoticeText{Banner: t.Banner, Terms: t.Terms}
+			}
+		}
+		if err := validateNotice(cfg); err != nil {
+			s.logger.Warn("skipping invalid notice from state", "error", err)
+			cfg = NoticeConfig{}
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg = cfg
+}
+
+// Get returns the notice configuration.
+func (s *NoticeService) Get() NoticeConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return copyNoticeConfig(s.cfg)
+}
+
+// Set replaces the notice configuration. It applies to the next initialize.
+func (s *NoticeService) Set(ctx context.Context, cfg NoticeConfig) (NoticeConfig, error) {
+	cfg = copyNoticeConfig(cfg)
+	cfg.Banner = strings.TrimSpace(cfg.Banner)
+	cfg.Terms = strings.TrimSpace(cfg.Terms)
+	for id, t := range cfg.Identities {
+		cfg.Identities[id] = NoticeText{Banner: strings.TrimSpace(t.Banner), Terms: strings.TrimSpace(t.Terms)}
+	}
+	if err := validateNotice(cfg); err != nil {
+		return NoticeConfig{}, err
+	}
+	cfg.UpdatedAt = time.Now().UTC()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stateStore != nil {
+		if err := s.stateStore.Mutate(func(appState *state.AppState) error {
+			appState.Notice = noticeEntry(cfg)
+			return nil
+		}); err != nil {
+			return NoticeConfig{}, fmt.Errorf("persist notice: %w", err)
+		}
+	}
+	s.cfg = cfg
+
+	s.emit(ctx, EventNoticeUpdated, map[string]interface{}{
+		"banner":     cfg.Banner,
+		"version":    noticeVersion(cfg.Banner, cfg.Terms),
+		"identities": len(cfg.Identities),
+	})
+	s.logger.Info("notice updated", "enabled", cfg.Banner != "" || cfg.Terms != "", "identity_overrides", len(cfg.Identities))
+	return copyNoticeConfig(cfg), nil
+}
+
+// Reset drops the notice from memory. Used by factory reset, which clears
+// it from state.json itself.
+func (s *NoticeService) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg = NoticeConfig{}
+}
+
+// NoticeFor returns the notice for the session's identity: its override if
+// one is set, the default otherwise. It returns nil when there is no text.
+func (s *NoticeService) NoticeFor(sess *session.Session) *proxy.Notice {
+	s.mu.RLock()
+	text := NoticeText{Banner: s.cfg.Banner, Terms: s.cfg.Terms}
+	if override, ok := s.cfg.Identities[sess.IdentityID]; ok {
+		text = override
+	}
+	s.mu.RUnlock()
+	if text.Banner == "" && text.Terms == "" {
+		return nil
+	}
+	return &proxy.Notice{Banner: text.Banner, Terms: text.Terms, Version: noticeVersion(text.Banner, text.Terms)}
+}
+
+// AcknowledgeNotice records that the session completed the handshake after
+// being served the notice.
+func (s *NoticeService) AcknowledgeNotice(_ context.Context, sess *session.Session, notice *proxy.Notice) {
+	s.logger.Debug("notice acknowledged",
+		"session_id", sess.ID,
+		"identity_id", sess.IdentityID,
+		"version", notice.Version,
+	)
+	if s.recorder == nil {
+		return
+	}
+	roles := make([]string, len(sess.Roles))
+	for i, r := range sess.Roles {
+		roles[i] = string(r)
+	}
+	s.recorder.Record(audit.AuditRecord{
+		Timestamp:     time.Now().UTC(),
+		SessionID:     sess.ID,
+		IdentityID:    sess.IdentityID,
+		IdentityName:  sess.IdentityName,
+		Roles:         roles,
+		SessionLabels: sess.Labels,
+		ToolName:      "notifications/initialized",
+		Decision:      audit.DecisionAllow,
+		Reason:        "notice " + notice.Version + " acknowledged",
+		Protocol:      "mcp",
+	})
+}
+
+func (s *NoticeService) emit(ctx context.Context, typ string, payload map[string]interface{}) {
+	if s.bus == nil {
+		return
+	}
+	s.bus.Publish(ctx, event.Event{
+		Type:      typ,
+		Source:    "notice",
+		Severity:  event.SeverityInfo,
+		Payload:   payload,
+		Timestamp: time.Now().UTC(),
+	})
+}
+
+func validateNotice(cfg NoticeConfig) error {
+	if err := validateNoticeText(cfg.Banner, cfg.Terms); err != nil {
+		return err
+	}
+	if len(cfg.Identities) > maxNoticeIdentities {
+		return fmt.Errorf("%w: at most %d identity overrides", ErrInvalidNotice, maxNoticeIdentities)
+	}
+	for id, t := range cfg.Identities {
+		if strings.TrimSpace(id) == "" {
+			return fmt.Errorf("%w: identity override without an identity ID", ErrInvalidNotice)
+		}
+		if err := validateNoticeText(t.Banner, t.Terms); err != nil {
+			return fmt.Errorf("identity %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+func validateNoticeText(banner, terms string) error {
+	if utf8.RuneCountInString(banner) > maxNoticeBanner {
+		return fmt.Errorf("%w: banner exceeds %d characters", ErrInvalidNotice, maxNoticeBanner)
+	}
+	if strings.ContainsAny(banner, "\r\n") {
+		return fmt.Errorf("%w: banner must be a single line", ErrInvalidNotice)
+	}
+	if len(terms) > maxNoticeTerms {
+		return fmt.Errorf("%w: terms exceed %d bytes", ErrInvalidNotice, maxNoticeTerms)
+	}
+	if !utf8.ValidString(banner) || !utf8.ValidString(terms) {
+		return fmt.Errorf("%w: text must be valid UTF-8", ErrInvalidNotice)
+	}
+	return nil
+}
+
+// noticeVersion identifies a notice text by its content, so a changed text
+// needs a new acknowledgment.
+func noticeVersion(banner, terms string) string {
+	sum := sha256.Sum256([]byte(banner + "\x00" + terms))
+	return hex.EncodeToString(sum[:6])
+}
+
+func noticeEntry(cfg NoticeConfig) *state.NoticeEntry {
+	entry := &state.NoticeEntry{Banner: cfg.Banner, Terms: cfg.Terms, UpdatedAt: cfg.UpdatedAt}
+	if len(cfg.Identities) > 0 {
+		entry.Identities = make(map[string]state.NoticeTextEntry, len(cfg.Identities))
+		for id, t := range cfg.Identities {
+			entry.Identities[id] = state.NoticeTextEntry{Banner: t.Banner, Terms: t.Terms}
+		}
+	}
+	return entry
+}
+
+func copyNoticeConfig(cfg NoticeConfig) NoticeConfig {
+	if cfg.Identities != nil {
+		ids := make(map[string]NoticeText, len(cfg.Identities))
+		for id, t := range cfg.Identities {
+			ids[id] = t
+		}
+		cfg.Identities = ids
+	}
+	return cfg
+}
+
+// Compile-time check that NoticeService implements proxy.NoticeProvider.
+var _ proxy.NoticeProvider = (*NoticeService)(nil)
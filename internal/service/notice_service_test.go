@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/session"
+)
+
+type noticeAuditRecorder struct {
+	records []audit.AuditRecord
+}
+
+func (m *noticeAuditRecorder) Record(record audit.AuditRecord) {
+	m.records = append(m.records, record)
+}
+
+func TestNoticeService(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	stateStore := state.NewFileStateStore(filepath.Join(t.TempDir(), "state.json"), logger)
+	if err := stateStore.Save(stateStore.DefaultState()); err != nil {
+		t.Fatalf("save default state: %v", err)
+	}
+	svc := NewNoticeService(stateStore, logger)
+	ctx := context.Background()
+	prod := &session.Session{ID: "s1", IdentityID: "id-prod"}
+	ci := &session.Session{ID: "s2", IdentityID: "id-ci"}
+
+	if n := svc.NoticeFor(prod); n != nil {
+		t.Fatalf("NoticeFor without a notice = %+v, want nil", n)
+	}
+
+	if _, err := svc.Set(ctx, NoticeConfig{
+		Banner: "  PRODUCTION — all actions audited ",
+		Terms:  "Use of this gateway is subject to the acceptable use policy.",
+		Identities: map[string]NoticeText{
+			"id-ci": {},
+		},
+	}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	n := svc.NoticeFor(prod)
+	if n == nil || n.Banner != "PRODUCTION — all actions audited" || n.Version == "" {
+		t.Fatalf("NoticeFor = %+v, want the trimmed default notice", n)
+	}
+	if got := svc.NoticeFor(ci); got != nil {
+		t.Errorf("NoticeFor with an empty override = %+v, want nil", got)
+	}
+
+	// A changed text gets a new version.
+	before := n.Version
+	if _, err := svc.Set(ctx, NoticeConfig{Banner: "STAGING"}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if got := svc.NoticeFor(prod).Version; got == before {
+		t.Errorf("version unchanged after the text changed: %s", got)
+	}
+
+	// The notice survives a restart.
+	appState, err := stateStore.Load()
+	if err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+	reloaded := NewNoticeService(stateStore, logger)
+	reloaded.Load(appState.Notice)
+	if got := reloaded.NoticeFor(ci); got == nil || got.Banner != "STAGING" {
+		t.Errorf("reloaded NoticeFor = %+v, want STAGING for every identity", got)
+	}
+
+	for _, bad := range []NoticeConfig{
+		{Banner: "line one\nline two"},
+		{Banner: strings.Repeat("x", maxNoticeBanner+1)},
+		{Terms: strings.Repeat("x", maxNoticeTerms+1)},
+		{Identities: map[string]NoticeText{" ": {Banner: "x"}}},
+	} {
+		if _, err := svc.Set(ctx, bad); !errors.Is(err, ErrInvalidNotice) {
+			t.Errorf("Set(%.40q) error = %v, want ErrInvalidNotice", bad.Banner, err)
+		}
+	}
+
+	recorder := &noticeAuditRecorder{}
+	svc.SetAuditRecorder(recorder)
+	svc.AcknowledgeNotice(ctx, prod, svc.NoticeFor(prod))
+	if len(recorder.records) != 1 {
+		t.Fatalf("audit records = %d, want 1", len(recorder.records))
+	}
+	rec := recorder.records[0]
+	if rec.SessionID != "s1" || rec.IdentityID != "id-prod" || !strings.Contains(rec.Reason, svc.NoticeFor(prod).Version) {
+		t.Errorf("acknowledgment record = %+v", rec)
+	}
+
+	svc.Reset()
+	if got := svc.NoticeFor(prod); got != nil {
+		t.Errorf("NoticeFor after Reset = %+v, want nil", got)
+	}
+}
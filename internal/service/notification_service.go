@@ -71,16 +71,26 @@ func NewNotificationService(maxSize int) *NotificationService {
 
 // SubscribeToBus registers this service as a consumer of all events on the bus.
 // The unsubscribe function is stored internally and called by Stop().
+// Use it when the service is not registered with an event.Dispatcher.
 func (s *NotificationService) SubscribeToBus(bus event.Bus) {
 	unsub := bus.SubscribeAll(func(ctx context.Context, evt event.Event) {
-		notif := s.eventToNotification(evt)
-		s.Add(notif)
+		_ = s.Deliver(ctx, evt)
 	})
 	s.mu.Lock()
 	s.unsubscribe = unsub
 	s.mu.Unlock()
 }
 
+// Name implements event.Sink. The service is the admin UI sink: it keeps
+// the notification list and streams it to SSE clients.
+func (s *NotificationService) Name() string { return "admin-ui" }
+
+// Deliver implements event.Sink.
+func (s *NotificationService) Deliver(ctx context.Context, evt event.Event) error {
+	s.Add(s.eventToNotification(evt))
+	return nil
+}
+
 // Stop unsubscribes from the event bus and closes all SSE client channels.
 func (s *NotificationService) Stop() {
 	s.mu.Lock()
@@ -270,6 +280,14 @@ func (s *NotificationService) eventToNotification(evt event.Event) Notification
 	}
 }
 
+// responseChangeKind names what a response guard compared, for messages.
+func responseChangeKind(compare interface{}) string {
+	if compare == ResponseCompareHash {
+		return "result"
+	}
+	return "result structure"
+}
+
 // resolveIdentityName extracts identity_name from event payload,
 // falling back to identity_id if name is not available.
 func resolveIdentityName(p map[string]interface{}) string {
@@ -400,6 +418,32 @@ func formatNotification(evt event.Event) (title, message string, actions []Notif
 		actions = []NotifAction{
 			{Label: "Review", Action: "navigate", Target: "#/security?tab=scanning"},
 		}
+	case "content.argument_flagged":
+		title = "Suspicious Tool Arguments"
+		if p, ok := evt.Payload.(map[string]interface{}); ok {
+			tool, ok2 := p["tool"].(string)
+			if !ok2 || tool == "" {
+				tool = "unknown"
+			}
+			identity := resolveIdentityName(p)
+			if identity == "" {
+				identity = "unknown"
+			}
+			patterns, _ := p["patterns"].(string)
+			switch mode, _ := p["mode"].(string); mode {
+			case "enforce":
+				message = "Blocked call to " + tool + " from " + identity + " — " + patterns
+			case "redact":
+				message = "Redacted " + patterns + " in arguments of " + tool + " from " + identity
+			default:
+				message = "Detected " + patterns + " in arguments of " + tool + " from " + identity
+			}
+		} else {
+			message = "Secrets or prompt injection detected in tool arguments"
+		}
+		actions = []NotifAction{
+			{Label: "Review", Action: "navigate", Target: "#/security?tab=scanning"},
+		}
 	case "content.whitelist_added":
 		title = "Whitelist Exception Added"
 		if p, ok := evt.Payload.(map[string]string); ok {
@@ -511,6 +555,19 @@ func formatNotification(evt event.Event) (title, message string, actions []Notif
 		} else {
 			message = "An agent's behavior has deviated from baseline"
 		}
+	case "anomaly.detected":
+		title = "Anomalous Tool Arguments"
+		if p, ok := evt.Payload.(map[string]interface{}); ok {
+			tool, _ := p["tool"].(string)
+			score, _ := p["score"].(float64)
+			reasons, _ := p["reasons"].(string)
+			message = resolveIdentityName(p) + " called " + tool + " — anomaly score " + fmtFloat(score, 2)
+			if reasons != "" {
+				message += ": " + reasons
+			}
+		} else {
+			message = "A tool call's arguments deviate from the tool's profile"
+		}
 	case "drift.baseline_reset":
 		title = "Drift Baseline Reset"
 		if p, ok := evt.Payload.(map[string]interface{}); ok {
@@ -629,6 +686,121 @@ func formatNotification(evt event.Event) (title, message string, actions []Notif
 		actions = []NotifAction{
 			{Label: "View Costs", Action: "navigate", Target: "#/finops"},
 		}
+	case EventUpstreamConnected, EventUpstreamDisconnected, EventUpstreamRestarting, EventUpstreamRetriesExhausted:
+		name := "An upstream"
+		var detail string
+		if p, ok := evt.Payload.(map[string]interface{}); ok {
+			if n, _ := p["upstream_name"].(string); n != "" {
+				name = n
+			}
+			detail, _ = p["error"].(string)
+			if detail == "" {
+				detail, _ = p["last_error"].(string)
+			}
+		}
+		switch evt.Type {
+		case EventUpstreamConnected:
+			title = "Upstream Connected"
+			message = name + " is connected"
+		case EventUpstreamDisconnected:
+			title = "Upstream Disconnected"
+			message = name + " went down, reconnecting"
+		case EventUpstreamRestarting:
+			title = "Upstream Restarting"
+			message = name + " is being restarted"
+		default:
+			title = "Upstream Down"
+			message = name + " gave up reconnecting and needs a manual restart"
+		}
+		if detail != "" && evt.Type != EventUpstreamConnected {
+			message += ": " + detail
+		}
+		actions = []NotifAction{
+			{Label: "View", Action: "navigate", Target: "#/tools"},
+		}
+	case EventUpstreamToolsQuarantined:
+		title = "Upstream Tools Quarantined"
+		if p, ok := evt.Payload.(map[string]interface{}); ok {
+			tools, _ := p["tools"].([]string)
+			name, _ := p["upstream_name"].(string)
+			message = itoa(len(tools)) + " tools from " + name + " quarantined until reviewed"
+		} else {
+			message = "Tools from an upstream have been quarantined"
+		}
+		actions = []NotifAction{
+			{Label: "View", Action: "navigate", Target: "#/tools?quarantine=true"},
+		}
+	case EventToolResponseChanged:
+		title = "Tool Response Changed"
+		if p, ok := evt.Payload.(map[string]interface{}); ok {
+			tool, _ := p["tool_name"].(string)
+			message = tool + " returned a different " + responseChangeKind(p["compare"]) + " than its baseline"
+			if quarantined, _ := p["quarantined"].(bool); quarantined {
+				message += " and was quarantined"
+			}
+		} else {
+			message = "A guarded tool returned a changed result"
+		}
+		actions = []NotifAction{
+			{Label: "View", Action: "navigate", Target: "#/tools?quarantine=true"},
+		}
+	case EventUpstreamConformanceFailed:
+		title = "Upstream Conformance Failed"
+		if p, ok := evt.Payload.(map[string]interface{}); ok {
+			name, _ := p["upstream_name"].(string)
+			if errMsg, _ := p["error"].(string); errMsg != "" {
+				message = name + " could not be checked: " + errMsg
+			} else {
+				failed, _ := p["failed_checks"].([]string)
+				message = name + " failed " + strings.Join(failed, ", ")
+			}
+		} else {
+			message = "An upstream failed the scheduled conformance check"
+		}
+		actions = []NotifAction{
+			{Label: "View", Action: "navigate", Target: "#/tools"},
+		}
+	case EventAPIKeyExpiring:
+		title = "API Key Expiring"
+		if p, ok := evt.Payload.(map[string]interface{}); ok {
+			keyName, _ := p["key_name"].(string)
+			in, _ := p["expires_in"].(string)
+			message = "API key " + keyName + " of " + resolveIdentityName(p) + " expires in " + in
+		} else {
+			message = "An API key is about to expire"
+		}
+		actions = []NotifAction{
+			{Label: "View", Action: "navigate", Target: "#/access"},
+		}
+	case "policy.deny_loop":
+		title = "Deny Loop Detected"
+		if p, ok := evt.Payload.(map[string]interface{}); ok {
+			tool, _ := p["tool"].(string)
+			denials, _ := p["denials"].(int)
+			message = resolveIdentityName(p) + " retried denied call " + tool + " " + itoa(denials) + " times; further attempts are backed off"
+		} else {
+			message = "An identity keeps retrying a denied tool call"
+		}
+		actions = []NotifAction{
+			{Label: "View", Action: "navigate", Target: "#/dashboard"},
+		}
+	case EventJobFailed:
+		title = "Scheduled Job Failed"
+		if p, ok := evt.Payload.(map[string]interface{}); ok {
+			job, _ := p["job"].(string)
+			errMsg, _ := p["error"].(string)
+			message = job + ": " + errMsg
+		} else {
+			message = "A scheduled job failed"
+		}
+	case EventAuditOverflow:
+		title = "Audit Records Dropped"
+		if p, ok := evt.Payload.(map[string]interface{}); ok {
+			dropped, _ := p["dropped"].(int64)
+			message = strconv.FormatInt(dropped, 10) + " audit records were dropped because the audit queue was full"
+		} else {
+			message = "Audit records were dropped because the audit queue was full"
+		}
 	default:
 		// Generic formatting for unknown event types.
 		title = evt.Type
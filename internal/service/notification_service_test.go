@@ -259,6 +259,48 @@ func TestNotificationService_WhitelistAddedEvent(t *testing.T) {
 	}
 }
 
+func TestNotificationService_DenyLoopEvent(t *testing.T) {
+	bus := event.NewBus(100)
+	bus.Start()
+	defer bus.Stop()
+
+	svc := NewNotificationService(100)
+	svc.SubscribeToBus(bus)
+	defer svc.Stop()
+
+	bus.Publish(context.Background(), event.Event{
+		Type:     "policy.deny_loop",
+		Source:   "deny-loop",
+		Severity: event.SeverityWarning,
+		Payload: map[string]interface{}{
+			"tool":          "delete_file",
+			"identity_id":   "agent-1",
+			"identity_name": "build-bot",
+			"denials":       5,
+		},
+	})
+
+	deadline := time.After(2 * time.Second)
+	for len(svc.List(false)) == 0 {
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for notification")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	n := svc.List(false)[0]
+	if n.Title != "Deny Loop Detected" {
+		t.Errorf("title = %q, want 'Deny Loop Detected'", n.Title)
+	}
+	if !strings.Contains(n.Message, "build-bot") || !strings.Contains(n.Message, "delete_file") || !strings.Contains(n.Message, "5 times") {
+		t.Errorf("message = %q", n.Message)
+	}
+	if len(n.Actions) == 0 || n.Actions[0].Target != "#/dashboard" {
+		t.Errorf("actions = %+v", n.Actions)
+	}
+}
+
 // --- Wave 4 Tests: Notification deduplication ---
 
 func TestNotificationService_DeduplicatesSameType(t *testing.T) {
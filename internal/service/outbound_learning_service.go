@@ -0,0 +1,454 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/action"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/policy"
+)
+
+// DefaultOutboundLearningFlushInterval is how often observations are
+// persisted to state.json while learning.
+const DefaultOutboundLearningFlushInterval = time.Minute
+
+// DefaultOutboundLearningMaxEntries caps the distinct identity/tool/host
+// observations kept; further new destinations are counted as dropped.
+const DefaultOutboundLearningMaxEntries = 10000
+
+// DefaultOutboundAllowlistPriority is the rule priority of applied
+// allowlists, above the built-in templates so learned egress limits win
+// over broad allow rules.
+const DefaultOutboundAllowlistPriority = 1000
+
+// outboundLearningRuleSource marks rules created from a learned allowlist.
+const outboundLearningRuleSource = "outbound-learning"
+
+var (
+	// ErrLearningActive is returned when learning is started while a window is open.
+	ErrLearningActive = errors.New("outbound learning is already active")
+	// ErrNoLearnedDestinations is returned when applying an empty proposal.
+	ErrNoLearnedDestinations = errors.New("no learned destinations to apply")
+)
+
+// OutboundPolicyCreator creates policies. Implemented by PolicyAdminService.
+type OutboundPolicyCreator interface {
+	Create(ctx context.Context, p *policy.Policy) (*policy.Policy, error)
+}
+
+// LearnedDestination is one host reached by an identity through a tool.
+type LearnedDestination struct {
+	Host      string    `json:"host"`
+	Count     int64     `json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// OutboundAllowlistProposal is the proposed allowlist for one identity and tool.
+type OutboundAllowlistProposal struct {
+	IdentityID   string               `json:"identity_id"`
+	IdentityName string               `json:"identity_name,omitempty"`
+	ToolName     string               `json:"tool_name"`
+	Destinations []LearnedDestination `json:"destinations"`
+}
+
+// OutboundLearningStatus reports the learning window.
+type OutboundLearningStatus struct {
+	Active       bool      `json:"active"`
+	StartedAt    time.Time `json:"started_at,omitempty"`
+	EndsAt       time.Time `json:"ends_at,omitempty"`
+	Observations int       `json:"observations"`
+	Dropped      int64     `json:"dropped"`
+}
+
+// OutboundApplyOptions controls how a proposal becomes policy rules.
+type OutboundApplyOptions struct {
+	// IdentityIDs limits the allowlist to these identities. Empty means all.
+	IdentityIDs []string `json:"identity_ids,omitempty"`
+	// DefaultDeny adds a rule denying every destination from identity/tool
+	// pairs that were not learned, for all identities.
+	DefaultDeny bool `json:"default_deny"`
+	// Priority is the priority of the generated rules.
+	// Defaults to DefaultOutboundAllowlistPriority.
+	Priority int `json:"priority,omitempty"`
+}
+
+type outboundLearnKey struct {
+	identityID string
+	toolName   string
+	host       string
+}
+
+type outboundObservation struct {
+	identityName string
+	LearnedDestination
+}
+
+// OutboundLearningService records the destinations tool calls reach during a
+// learning window, without blocking anything, and aggregates them into a
+// proposed egress allowlist grouped by identity and tool. ApplyProposal
+// turns the proposal into enforced policy rules, which makes moving from a
+// blocklist to default-deny egress a matter of reviewing what was learned.
+type OutboundLearningService struct {
+	mu           sync.Mutex
+	startedAt    time.Time
+	endsAt       time.Time
+	stopped      bool
+	observations map[outboundLearnKey]*outboundObservation
+	dropped      int64
+	dirty        bool
+	maxEntries   int
+
+	stateStore  state.Store
+	policyAdmin OutboundPolicyCreator
+	logger      *slog.Logger
+	now         func() time.Time
+}
+
+// Compile-time check that OutboundLearningService is a destination observer.
+var _ action.DestinationObserver = (*OutboundLearningService)(nil)
+
+// NewOutboundLearningService creates an OutboundLearningService. stateStore
+// may be nil, in which case observations are kept in memory only.
+func NewOutboundLearningService(stateStore state.Store, policyAdmin OutboundPolicyCreator, logger *slog.Logger) *OutboundLearningService {
+	return &OutboundLearningService{
+		observations: make(map[outboundLearnKey]*outboundObservation),
+		maxEntries:   DefaultOutboundLearningMaxEntries,
+		stateStore:   stateStore,
+		policyAdmin:  policyAdmin,
+		logger:       logger,
+		now:          time.Now,
+	}
+}
+
+// LoadFromState restores the learning window and observations persisted by
+// a previous run. A window that is still open resumes learning.
+func (s *OutboundLearningService) LoadFromState(appState *state.AppState) {
+	entry := appState.OutboundLearning
+	if entry == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.startedAt = entry.StartedAt
+	s.endsAt = entry.EndsAt
+	s.stopped = entry.Stopped
+	s.dropped = entry.Dropped
+	for _, o := range entry.Observations {
+		s.observations[outboundLearnKey{o.IdentityID, o.ToolName, o.Host}] = &outboundObservation{
+			identityName: o.IdentityName,
+			LearnedDestination: LearnedDestination{
+				Host: o.Host, Count: o.Count, FirstSeen: o.FirstSeen, LastSeen: o.LastSeen,
+			},
+		}
+	}
+}
+
+// Start opens a learning window of the given duration, discarding the
+// observations of any previous window.
+func (s *OutboundLearningService) Start(ctx context.Context, duration time.Duration) (OutboundLearningStatus, error) {
+	if duration <= 0 {
+		return OutboundLearningStatus{}, fmt.Errorf("learning duration must be positive")
+	}
+	s.mu.Lock()
+	now := s.now().UTC()
+	if s.activeLocked(now) {
+		s.mu.Unlock()
+		return OutboundLearningStatus{}, ErrLearningActive
+	}
+	s.startedAt = now
+	s.endsAt = now.Add(duration)
+	s.stopped = false
+	s.dropped = 0
+	s.observations = make(map[outboundLearnKey]*outboundObservation)
+	s.dirty = true
+	s.mu.Unlock()
+
+	s.logger.Info("outbound learning started", "ends_at", now.Add(duration))
+	return s.Status(), s.Flush(ctx)
+}
+
+// Stop closes the learning window early. Observations are kept so the
+// proposal can still be reviewed and applied.
+func (s *OutboundLearningService) Stop(ctx context.Context) (OutboundLearningStatus, error) {
+	s.mu.Lock()
+	if s.activeLocked(s.now()) {
+		s.stopped = true
+		s.dirty = true
+	}
+	s.mu.Unlock()
+	return s.Status(), s.Flush(ctx)
+}
+
+// Reset discards all observations and closes the window.
+func (s *OutboundLearningService) Reset(ctx context.Context) error {
+	s.mu.Lock()
+	s.startedAt = time.Time{}
+	s.endsAt = time.Time{}
+	s.stopped = false
+	s.dropped = 0
+	s.observations = make(map[outboundLearnKey]*outboundObservation)
+	s.dirty = true
+	s.mu.Unlock()
+	return s.Flush(ctx)
+}
+
+// Status reports the learning window and how much has been observed.
+func (s *OutboundLearningService) Status() OutboundLearningStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return OutboundLearningStatus{
+		Active:       s.activeLocked(s.now()),
+		StartedAt:    s.startedAt,
+		EndsAt:       s.endsAt,
+		Observations: len(s.observations),
+		Dropped:      s.dropped,
+	}
+}
+
+func (s *OutboundLearningService) activeLocked(now time.Time) bool {
+	return !s.startedAt.IsZero() && !s.stopped && now.Before(s.endsAt)
+}
+
+// ObserveDestination records the hosts of dest for the identity and tool
+// while a learning window is open. It never blocks the call.
+func (s *OutboundLearningService) ObserveDestination(identityID, identityName, toolName string, dest action.Destination) {
+	hosts := destinationHosts(dest)
+	if len(hosts) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now().UTC()
+	if !s.activeLocked(now) {
+		return
+	}
+	for _, host := range hosts {
+		key := outboundLearnKey{identityID: identityID, toolName: toolName, host: host}
+		o, ok := s.observations[key]
+		if !ok {
+			if len(s.observations) >= s.maxEntries {
+				s.dropped++
+				continue
+			}
+			o = &outboundObservation{LearnedDestination: LearnedDestination{Host: host, FirstSeen: now}}
+			s.observations[key] = o
+		}
+		if identityName != "" {
+			o.identityName = identityName
+		}
+		o.Count++
+		o.LastSeen = now
+	}
+	s.dirty = true
+}
+
+// destinationHosts returns the distinct lowercase hosts of dest.
+func destinationHosts(dest action.Destination) []string {
+	var hosts []string
+	seen := make(map[string]bool, len(dest.Domains)+1)
+	for _, d := range append([]string{dest.Domain}, dest.Domains...) {
+		d = strings.ToLower(strings.TrimSuffix(d, "."))
+		if d == "" || seen[d] {
+			continue
+		}
+		seen[d] = true
+		hosts = append(hosts, d)
+	}
+	return hosts
+}
+
+// Proposal returns the learned allowlist grouped by identity and tool,
+// sorted by identity name, then tool, with hosts in name order.
+func (s *OutboundLearningService) Proposal() []OutboundAllowlistProposal {
+	s.mu.Lock()
+	groups := make(map[[2]string]*OutboundAllowlistProposal)
+	for key, o := range s.observations {
+		g, ok := groups[[2]string{key.identityID, key.toolName}]
+		if !ok {
+			g = &OutboundAllowlistProposal{IdentityID: key.identityID, ToolName: key.toolName}
+			groups[[2]string{key.identityID, key.toolName}] = g
+		}
+		if o.identityName != "" {
+			g.IdentityName = o.identityName
+		}
+		g.Destinations = append(g.Destinations, o.LearnedDestination)
+	}
+	s.mu.Unlock()
+
+	result := make([]OutboundAllowlistProposal, 0, len(groups))
+	for _, g := range groups {
+		sort.Slice(g.Destinations, func(i, j int) bool { return g.Destinations[i].Host < g.Destinations[j].Host })
+		result = append(result, *g)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.IdentityName != b.IdentityName {
+			return a.IdentityName < b.IdentityName
+		}
+		if a.IdentityID != b.IdentityID {
+			return a.IdentityID < b.IdentityID
+		}
+		return a.ToolName < b.ToolName
+	})
+	return result
+}
+
+// ApplyProposal converts the learned allowlist into an enabled policy. For
+// every identity and tool, a rule denies destinations outside the hosts it
+// was seen reaching; calls to learned hosts fall through to the other rules
+// unchanged. With DefaultDeny, one more rule denies destinations of every
+// identity/tool pair that was not learned.
+func (s *OutboundLearningService) ApplyProposal(ctx context.Context, opts OutboundApplyOptions) (*policy.Policy, error) {
+	proposal := s.Proposal()
+	if len(opts.IdentityIDs) > 0 {
+		wanted := make(map[string]bool, len(opts.IdentityIDs))
+		for _, id := range opts.IdentityIDs {
+			wanted[id] = true
+		}
+		filtered := proposal[:0]
+		for _, p := range proposal {
+			if wanted[p.IdentityID] {
+				filtered = append(filtered, p)
+			}
+		}
+		proposal = filtered
+	}
+	if len(proposal) == 0 {
+		return nil, ErrNoLearnedDestinations
+	}
+	priority := opts.Priority
+	if priority == 0 {
+		priority = DefaultOutboundAllowlistPriority
+	}
+
+	const hasDestination = `(dest_domain != "" || size(dest_domains) > 0)`
+	rules := make([]policy.Rule, 0, len(proposal)+1)
+	pairs := make([]string, 0, len(proposal))
+	for _, p := range proposal {
+		hosts := make([]string, len(p.Destinations))
+		for i, d := range p.Destinations {
+			hosts[i] = strconv.Quote(d.Host)
+		}
+		list := "[" + strings.Join(hosts, ", ") + "]"
+		who := p.IdentityName
+		if who == "" {
+			who = p.IdentityID
+		}
+		rules = append(rules, policy.Rule{
+			Name:      fmt.Sprintf("Learned egress: %s via %s", who, p.ToolName),
+			Priority:  priority,
+			ToolMatch: p.ToolName,
+			Condition: fmt.Sprintf(`identity_id == %s && %s && !((dest_domain == "" || dest_domain in %s) && dest_domains.all(d, d in %s))`,
+				strconv.Quote(p.IdentityID), hasDestination, list, list),
+			Action:   policy.ActionDeny,
+			HelpText: fmt.Sprintf("This destination is not in the learned egress allowlist for %s.", p.ToolName),
+			Source:   outboundLearningRuleSource,
+		})
+		pairs = append(pairs, fmt.Sprintf("(identity_id == %s && tool_name == %s)",
+			strconv.Quote(p.IdentityID), strconv.Quote(p.ToolName)))
+	}
+	if opts.DefaultDeny {
+		rules = append(rules, policy.Rule{
+			Name:      "Learned egress: default deny",
+			Priority:  priority,
+			ToolMatch: "*",
+			Condition: fmt.Sprintf("%s && !(%s)", hasDestination, strings.Join(pairs, " || ")),
+			Action:    policy.ActionDeny,
+			HelpText:  "Outbound destinations are limited to the learned egress allowlist.",
+			Source:    outboundLearningRuleSource,
+		})
+	}
+
+	s.mu.Lock()
+	startedAt := s.startedAt
+	s.mu.Unlock()
+	p := &policy.Policy{
+		Name: fmt.Sprintf("Learned egress allowlist %s", s.now().UTC().Format("2006-01-02 15:04")),
+		Description: fmt.Sprintf("Generated from outbound destinations learned since %s.",
+			startedAt.Format(time.RFC3339)),
+		Enabled: true,
+		Rules:   rules,
+	}
+	created, err := s.policyAdmin.Create(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("learned egress allowlist applied", "policy_id", created.ID, "rules", len(rules))
+	return created, nil
+}
+
+// Flush persists the learning window and observations if they changed since
+// the last flush. Without a state store it is a no-op.
+func (s *OutboundLearningService) Flush(_ context.Context) error {
+	if s.stateStore == nil {
+		return nil
+	}
+	s.mu.Lock()
+	if !s.dirty {
+		s.mu.Unlock()
+		return nil
+	}
+	var entry *state.OutboundLearningEntry
+	if !s.startedAt.IsZero() {
+		entry = &state.OutboundLearningEntry{
+			StartedAt:    s.startedAt,
+			EndsAt:       s.endsAt,
+			Stopped:      s.stopped,
+			Dropped:      s.dropped,
+			Observations: make([]state.OutboundObservationEntry, 0, len(s.observations)),
+		}
+		for key, o := range s.observations {
+			entry.Observations = append(entry.Observations, state.OutboundObservationEntry{
+				IdentityID:   key.identityID,
+				IdentityName: o.identityName,
+				ToolName:     key.toolName,
+				Host:         o.Host,
+				Count:        o.Count,
+				FirstSeen:    o.FirstSeen,
+				LastSeen:     o.LastSeen,
+			})
+		}
+	}
+	s.dirty = false
+	s.mu.Unlock()
+
+	if err := s.stateStore.Mutate(func(appState *state.AppState) error {
+		appState.OutboundLearning = entry
+		return nil
+	}); err != nil {
+		s.mu.Lock()
+		s.dirty = true
+		s.mu.Unlock()
+		return fmt.Errorf("persist outbound learning: %w", err)
+	}
+	return nil
+}
+
+// Run flushes observations every interval until ctx is cancelled. Callers
+// flush once more on shutdown so the last observations are kept.
+func (s *OutboundLearningService) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultOutboundLearningFlushInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Flush(ctx); err != nil {
+				s.logger.Warn("failed to flush outbound learning", "error", err)
+			}
+		}
+	}
+}
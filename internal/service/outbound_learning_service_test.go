@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/action"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/policy"
+)
+
+func TestOutboundLearningService_ObserveAndPropose(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	svc := NewOutboundLearningService(nil, nil, logger)
+	ctx := context.Background()
+
+	// Nothing is recorded outside a learning window.
+	svc.ObserveDestination("id-1", "alice", "fetch", action.Destination{Domain: "api.github.com"})
+	if got := svc.Status().Observations; got != 0 {
+		t.Fatalf("observations before Start = %d, want 0", got)
+	}
+
+	if _, err := svc.Start(ctx, time.Hour); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if _, err := svc.Start(ctx, time.Hour); !errors.Is(err, ErrLearningActive) {
+		t.Fatalf("second Start error = %v, want ErrLearningActive", err)
+	}
+
+	svc.ObserveDestination("id-1", "alice", "fetch", action.Destination{Domain: "API.GitHub.com."})
+	svc.ObserveDestination("id-1", "alice", "fetch", action.Destination{Domain: "api.github.com"})
+	svc.ObserveDestination("id-1", "alice", "fetch", action.Destination{Domains: []string{"pypi.org", "files.pythonhosted.org"}})
+	svc.ObserveDestination("id-2", "bob", "http_get", action.Destination{Domain: "example.com"})
+
+	proposal := svc.Proposal()
+	if len(proposal) != 2 {
+		t.Fatalf("proposal groups = %d, want 2: %+v", len(proposal), proposal)
+	}
+	alice := proposal[0]
+	if alice.IdentityName != "alice" || alice.ToolName != "fetch" || len(alice.Destinations) != 3 {
+		t.Fatalf("first group = %+v, want alice/fetch with 3 hosts", alice)
+	}
+	if alice.Destinations[0].Host != "api.github.com" || alice.Destinations[0].Count != 2 {
+		t.Errorf("first host = %+v, want api.github.com seen twice", alice.Destinations[0])
+	}
+
+	if _, err := svc.Stop(ctx); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	svc.ObserveDestination("id-2", "bob", "http_get", action.Destination{Domain: "late.example.com"})
+	if st := svc.Status(); st.Active || st.Observations != 4 {
+		t.Errorf("status after Stop = %+v, want inactive with 4 observations", st)
+	}
+
+	if err := svc.Reset(ctx); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if got := len(svc.Proposal()); got != 0 {
+		t.Errorf("proposal after Reset = %d groups, want 0", got)
+	}
+}
+
+func TestOutboundLearningService_MaxEntries(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	svc := NewOutboundLearningService(nil, nil, logger)
+	svc.maxEntries = 2
+	if _, err := svc.Start(context.Background(), time.Hour); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	for _, host := range []string{"a.example", "b.example", "c.example", "a.example"} {
+		svc.ObserveDestination("id-1", "", "fetch", action.Destination{Domain: host})
+	}
+	if st := svc.Status(); st.Observations != 2 || st.Dropped != 1 {
+		t.Errorf("status = %+v, want 2 observations and 1 dropped", st)
+	}
+}
+
+func TestOutboundLearningService_PersistsAcrossRestart(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	stateStore := state.NewFileStateStore(filepath.Join(t.TempDir(), "state.json"), logger)
+	if err := stateStore.Save(stateStore.DefaultState()); err != nil {
+		t.Fatalf("save default state: %v", err)
+	}
+	ctx := context.Background()
+
+	svc := NewOutboundLearningService(stateStore, nil, logger)
+	if _, err := svc.Start(ctx, time.Hour); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	svc.ObserveDestination("id-1", "alice", "fetch", action.Destination{Domain: "api.github.com"})
+	if err := svc.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	appState, err := stateStore.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	restored := NewOutboundLearningService(stateStore, nil, logger)
+	restored.LoadFromState(appState)
+	if st := restored.Status(); !st.Active || st.Observations != 1 {
+		t.Fatalf("restored status = %+v, want active with 1 observation", st)
+	}
+	restored.ObserveDestination("id-1", "alice", "fetch", action.Destination{Domain: "api.github.com"})
+	if got := restored.Proposal()[0].Destinations[0].Count; got != 2 {
+		t.Errorf("restored count = %d, want 2", got)
+	}
+}
+
+func TestOutboundLearningService_ApplyProposal(t *testing.T) {
+	adminSvc, policySvc, _, _ := testPolicyAdminEnv(t)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	svc := NewOutboundLearningService(nil, adminSvc, logger)
+	ctx := context.Background()
+
+	if _, err := svc.ApplyProposal(ctx, OutboundApplyOptions{}); !errors.Is(err, ErrNoLearnedDestinations) {
+		t.Fatalf("ApplyProposal on empty proposal error = %v, want ErrNoLearnedDestinations", err)
+	}
+
+	if _, err := svc.Start(ctx, time.Hour); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	svc.ObserveDestination("id-1", "alice", "fetch", action.Destination{Domain: "api.github.com"})
+	svc.ObserveDestination("id-1", "alice", "fetch", action.Destination{Domain: "pypi.org"})
+
+	created, err := svc.ApplyProposal(ctx, OutboundApplyOptions{DefaultDeny: true})
+	if err != nil {
+		t.Fatalf("ApplyProposal: %v", err)
+	}
+	if len(created.Rules) != 2 {
+		t.Fatalf("rules = %d, want allowlist rule and default deny", len(created.Rules))
+	}
+	for _, r := range created.Rules {
+		if r.Source != outboundLearningRuleSource || r.Action != policy.ActionDeny || r.Priority != DefaultOutboundAllowlistPriority {
+			t.Errorf("rule %+v, want learned deny rule at default priority", r)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		evalCtx    policy.EvaluationContext
+		wantDenied bool
+	}{
+		{"learned host", policy.EvaluationContext{ToolName: "fetch", IdentityID: "id-1", DestDomain: "pypi.org"}, false},
+		{"unlearned host", policy.EvaluationContext{ToolName: "fetch", IdentityID: "id-1", DestDomain: "evil.example"}, true},
+		{"unlearned host in list", policy.EvaluationContext{ToolName: "fetch", IdentityID: "id-1", DestDomains: []string{"pypi.org", "evil.example"}}, true},
+		{"no destination", policy.EvaluationContext{ToolName: "fetch", IdentityID: "id-1"}, false},
+		{"unlearned identity", policy.EvaluationContext{ToolName: "fetch", IdentityID: "id-2", DestDomain: "pypi.org"}, true},
+		{"unlearned tool", policy.EvaluationContext{ToolName: "http_get", IdentityID: "id-1", DestDomain: "pypi.org"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.evalCtx.RequestTime = time.Now()
+			d, err := policySvc.Evaluate(ctx, tt.evalCtx)
+			if err != nil {
+				t.Fatalf("Evaluate: %v", err)
+			}
+			denied := strings.HasPrefix(d.RuleName, "Learned egress")
+			if denied != tt.wantDenied {
+				t.Errorf("matched rule %q (allowed=%v), want learned deny = %v", d.RuleName, d.Allowed, tt.wantDenied)
+			}
+			if denied && d.Allowed {
+				t.Errorf("learned rule %q allowed the call", d.RuleName)
+			}
+		})
+	}
+}
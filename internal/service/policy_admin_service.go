@@ -14,6 +14,7 @@ import (
 	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/memory"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/policy"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/proxy"
 )
 
 // ErrDefaultPolicyDelete is returned when attempting to delete the default policy.
@@ -25,6 +26,10 @@ var ErrPolicyNotFound = errors.New("policy not found")
 // ErrInvalidPolicy is returned when a policy has invalid configuration (e.g. bad CEL expression).
 var ErrInvalidPolicy = errors.New("invalid policy")
 
+// ErrFilePolicy is returned when attempting to change a policy loaded from
+// the policy directory. Such policies change only with their file.
+var ErrFilePolicy = errors.New("policy is managed by a file in the policy directory")
+
 // DefaultPolicyName is the name used to identify the default policy.
 const DefaultPolicyName = "Default RBAC Policy"
 
@@ -34,18 +39,23 @@ const DevDefaultPolicyName = "dev-allow-all"
 // PolicyAdminService provides CRUD operations on policies
 // with validation, default policy protection, and persistence to state.json.
 // After every mutation it calls PolicyService.Reload() to hot-reload CEL rules.
+// Every change is kept as a version in the policy's history (see Versions).
 type PolicyAdminService struct {
 	store         policy.PolicyStore
-	stateStore    *state.FileStateStore
+	stateStore    state.Store
 	policyService *PolicyService
 	logger        *slog.Logger
+	recorder      proxy.AuditRecorder
 	mu            sync.Mutex // serializes state writes
+
+	// versions is the change history per policy ID, oldest first (guarded by mu).
+	versions map[string][]state.PolicyVersionEntry
 }
 
 // NewPolicyAdminService creates a new PolicyAdminService.
 func NewPolicyAdminService(
 	store policy.PolicyStore,
-	stateStore *state.FileStateStore,
+	stateStore state.Store,
 	policyService *PolicyService,
 	logger *slog.Logger,
 ) *PolicyAdminService {
@@ -54,6 +64,7 @@ func NewPolicyAdminService(
 		stateStore:    stateStore,
 		policyService: policyService,
 		logger:        logger,
+		versions:      make(map[string][]state.PolicyVersionEntry),
 	}
 }
 
@@ -115,12 +126,15 @@ func (s *PolicyAdminService) Create(ctx context.Context, p *policy.Policy) (*pol
 	// Serialize mutation + persist to prevent concurrent CRUDs from
 	// creating inconsistent state on partial persist failure (M-18).
 	s.mu.Lock()
+	version, undo := s.recordVersionLocked(ctx, nil, p, PolicyChangeCreate, 0)
 	if err := s.store.SavePolicy(ctx, p); err != nil {
+		undo()
 		s.mu.Unlock()
 		return nil, fmt.Errorf("save policy: %w", err)
 	}
 	if err := s.persistStateLocked(ctx); err != nil {
 		s.logger.Error("policy persistence failed, rolling back in-memory create", "policy_id", p.ID, "error", err)
+		undo()
 		if rbErr := s.store.DeletePolicy(ctx, p.ID); rbErr != nil {
 			s.logger.Error("CRITICAL: rollback failed after persist error, in-memory state may be inconsistent", "policy_id", p.ID, "rollback_error", rbErr)
 		}
@@ -136,6 +150,7 @@ func (s *PolicyAdminService) Create(ctx context.Context, p *policy.Policy) (*pol
 	}
 
 	s.logger.Info("policy created", "id", p.ID, "name", p.Name, "rules", len(p.Rules))
+	s.auditChange(ctx, p, PolicyChangeCreate, version, 0)
 
 	// Return the policy as stored.
 	return s.store.GetPolicyWithRules(ctx, p.ID)
@@ -156,6 +171,9 @@ func (s *PolicyAdminService) Update(ctx context.Context, id string, p *policy.Po
 	if existing == nil {
 		return nil, ErrPolicyNotFound
 	}
+	if IsFilePolicy(existing) {
+		return nil, ErrFilePolicy
+	}
 
 	// Validate basic fields.
 	if p.Name == "" {
@@ -184,12 +202,15 @@ func (s *PolicyAdminService) Update(ctx context.Context, id string, p *policy.Po
 
 	// Serialize mutation + persist (M-18).
 	s.mu.Lock()
+	version, undo := s.recordVersionLocked(ctx, existing, p, PolicyChangeUpdate, 0)
 	if err := s.store.SavePolicy(ctx, p); err != nil {
+		undo()
 		s.mu.Unlock()
 		return nil, fmt.Errorf("save policy: %w", err)
 	}
 	if err := s.persistStateLocked(ctx); err != nil {
 		s.logger.Error("policy persistence failed, rolling back in-memory update", "policy_id", id, "error", err)
+		undo()
 		if rbErr := s.store.SavePolicy(ctx, existing); rbErr != nil {
 			s.logger.Error("CRITICAL: rollback failed after persist error, in-memory state may be inconsistent", "policy_id", id, "rollback_error", rbErr)
 		}
@@ -205,6 +226,7 @@ func (s *PolicyAdminService) Update(ctx context.Context, id string, p *policy.Po
 	}
 
 	s.logger.Info("policy updated", "id", id, "name", p.Name)
+	s.auditChange(ctx, p, PolicyChangeUpdate, version, 0)
 
 	return s.store.GetPolicyWithRules(ctx, id)
 }
@@ -230,15 +252,22 @@ func (s *PolicyAdminService) Delete(ctx context.Context, id string) error {
 	if existing.Name == DefaultPolicyName || existing.Name == DevDefaultPolicyName {
 		return ErrDefaultPolicyDelete
 	}
+	if IsFilePolicy(existing) {
+		return ErrFilePolicy
+	}
 
 	// Serialize mutation + persist (M-18).
 	s.mu.Lock()
+	version, undo := s.recordVersionLocked(ctx, nil, existing, PolicyChangeDelete, 0)
+	deleted := *existing
 	if err := s.store.DeletePolicy(ctx, id); err != nil {
+		undo()
 		s.mu.Unlock()
 		return fmt.Errorf("delete policy: %w", err)
 	}
 	if err := s.persistStateLocked(ctx); err != nil {
 		s.logger.Error("policy persistence failed, rolling back in-memory delete", "policy_id", id, "error", err)
+		undo()
 		if rbErr := s.store.SavePolicy(ctx, existing); rbErr != nil {
 			s.logger.Error("CRITICAL: rollback failed after persist error, in-memory state may be inconsistent", "policy_id", id, "rollback_error", rbErr)
 		}
@@ -254,6 +283,7 @@ func (s *PolicyAdminService) Delete(ctx context.Context, id string) error {
 	}
 
 	s.logger.Info("policy deleted", "id", id)
+	s.auditChange(ctx, &deleted, PolicyChangeDelete, version, 0)
 	return nil
 }
 
@@ -277,6 +307,9 @@ func (s *PolicyAdminService) DeleteRule(ctx context.Context, policyID, ruleID st
 	if existing.Name == DefaultPolicyName || existing.Name == DevDefaultPolicyName {
 		return ErrDefaultPolicyDelete
 	}
+	if IsFilePolicy(existing) {
+		return ErrFilePolicy
+	}
 
 	// If only one rule left, delete the entire policy instead of leaving an empty shell.
 	if len(existing.Rules) <= 1 {
@@ -285,12 +318,22 @@ func (s *PolicyAdminService) DeleteRule(ctx context.Context, policyID, ruleID st
 
 	// Serialize mutation + persist (M-18).
 	s.mu.Lock()
+	after := *existing
+	after.Rules = make([]policy.Rule, 0, len(existing.Rules))
+	for _, r := range existing.Rules {
+		if r.ID != ruleID {
+			after.Rules = append(after.Rules, r)
+		}
+	}
+	version, undo := s.recordVersionLocked(ctx, existing, &after, PolicyChangeUpdate, 0)
 	if err := s.store.DeleteRule(ctx, policyID, ruleID); err != nil {
+		undo()
 		s.mu.Unlock()
 		return fmt.Errorf("delete rule: %w", err)
 	}
 	if err := s.persistStateLocked(ctx); err != nil {
 		s.logger.Error("rule persistence failed, rolling back in-memory delete", "policy_id", policyID, "rule_id", ruleID, "error", err)
+		undo()
 		if rbErr := s.store.SavePolicy(ctx, existing); rbErr != nil {
 			s.logger.Error("CRITICAL: rollback failed after persist error, in-memory state may be inconsistent", "policy_id", policyID, "rule_id", ruleID, "rollback_error", rbErr)
 		}
@@ -306,6 +349,7 @@ func (s *PolicyAdminService) DeleteRule(ctx context.Context, policyID, ruleID st
 	}
 
 	s.logger.Info("rule deleted", "policy_id", policyID, "rule_id", ruleID)
+	s.auditChange(ctx, &after, PolicyChangeUpdate, version, 0)
 	return nil
 }
 
@@ -315,6 +359,7 @@ func (s *PolicyAdminService) DeleteRule(ctx context.Context, policyID, ruleID st
 // (e.g. seeded from YAML config) are skipped to avoid duplicates.
 // After loading, it triggers a PolicyService.Reload() to compile the rules.
 func (s *PolicyAdminService) LoadPoliciesFromState(ctx context.Context, appState *state.AppState) error {
+	s.loadVersions(appState.PolicyVersions)
 	if len(appState.Policies) == 0 {
 		return nil
 	}
@@ -368,30 +413,7 @@ func (s *PolicyAdminService) LoadPoliciesFromState(ctx context.Context, appState
 
 		rules := make([]policy.Rule, 0, len(g.entries))
 		for _, e := range g.entries {
-			condition := e.Condition
-			if condition == "" {
-				condition = "true"
-			}
-			r := policy.Rule{
-				ID:        e.ID,
-				Name:      e.Name,
-				Priority:  e.Priority,
-				ToolMatch: e.ToolPattern,
-				Condition: condition,
-				Action:    policy.Action(e.Action),
-				HelpText:  e.HelpText,
-				Source:    e.Source,
-				CreatedAt: e.CreatedAt,
-			}
-			if e.ApprovalTimeout != "" {
-				if d, parseErr := time.ParseDuration(e.ApprovalTimeout); parseErr == nil {
-					r.ApprovalTimeout = d
-				}
-			}
-			if e.TimeoutAction != "" {
-				r.TimeoutAction = policy.Action(e.TimeoutAction)
-			}
-			rules = append(rules, r)
+			rules = append(rules, ruleFromEntry(e))
 		}
 
 		var createdAt, updatedAt time.Time
@@ -464,38 +486,94 @@ func (s *PolicyAdminService) persistStateLocked(ctx context.Context) error {
 		return fmt.Errorf("list policies for persistence: %w", err)
 	}
 
-	// Convert to state entries.
+	// Convert to state entries. Policies from the policy directory are
+	// reloaded from their files and never persisted.
 	entries := make([]state.PolicyEntry, 0, len(policies))
 	for _, p := range policies {
+		if IsFilePolicy(&p) {
+			continue
+		}
 		for _, r := range p.Rules {
-			entry := state.PolicyEntry{
-				ID:             r.ID,
-				PolicyID:       p.ID, // L-14: persist parent policy UUID to survive restarts
-				Name:           fmt.Sprintf("%s: %s", p.Name, r.Name),
-				Description:    p.Description,
-				PolicyPriority: p.Priority,
-				Priority:       r.Priority,
-				ToolPattern:    r.ToolMatch,
-				Condition:      r.Condition,
-				Action:         string(r.Action),
-				Enabled:        p.Enabled,
-				HelpText:       r.HelpText,
-				Source:         r.Source,
-				CreatedAt:      r.CreatedAt,
-				UpdatedAt:      p.UpdatedAt,
-			}
-			if r.ApprovalTimeout > 0 {
-				entry.ApprovalTimeout = r.ApprovalTimeout.String()
-			}
-			if r.TimeoutAction != "" {
-				entry.TimeoutAction = string(r.TimeoutAction)
-			}
+			entry := ruleEntry(&p, r)
+			entry.Name = fmt.Sprintf("%s: %s", p.Name, r.Name)
 			entries = append(entries, entry)
 		}
 	}
 
+	versions := make(map[string][]state.PolicyVersionEntry, len(s.versions))
+	for id, history := range s.versions {
+		versions[id] = history
+	}
 	return s.stateStore.Mutate(func(appState *state.AppState) error {
 		appState.Policies = entries
+		appState.PolicyVersions = versions
 		return nil
 	})
 }
+
+// ruleEntry converts a rule of p to its state entry, named after the rule.
+func ruleEntry(p *policy.Policy, r policy.Rule) state.PolicyEntry {
+	entry := state.PolicyEntry{
+		ID:             r.ID,
+		PolicyID:       p.ID, // L-14: persist parent policy UUID to survive restarts
+		Name:           r.Name,
+		Description:    p.Description,
+		PolicyPriority: p.Priority,
+		Priority:       r.Priority,
+		ToolPattern:    r.ToolMatch,
+		Condition:      r.Condition,
+		Action:         string(r.Action),
+		Enabled:        p.Enabled,
+		HelpText:       r.HelpText,
+		HelpURL:        r.HelpURL,
+		Source:         r.Source,
+		CreatedAt:      r.CreatedAt,
+		UpdatedAt:      p.UpdatedAt,
+	}
+	if r.ApprovalTimeout > 0 {
+		entry.ApprovalTimeout = r.ApprovalTimeout.String()
+	}
+	if r.TimeoutAction != "" {
+		entry.TimeoutAction = string(r.TimeoutAction)
+	}
+	entry.ExemptScope = string(r.ExemptScope)
+	if r.ExemptDuration > 0 {
+		entry.ExemptDuration = r.ExemptDuration.String()
+	}
+	return entry
+}
+
+// ruleFromEntry converts a state entry, named after the rule, to a rule.
+func ruleFromEntry(e state.PolicyEntry) policy.Rule {
+	condition := e.Condition
+	if condition == "" {
+		condition = "true"
+	}
+	r := policy.Rule{
+		ID:        e.ID,
+		Name:      e.Name,
+		Priority:  e.Priority,
+		ToolMatch: e.ToolPattern,
+		Condition: condition,
+		Action:    policy.Action(e.Action),
+		HelpText:  e.HelpText,
+		HelpURL:   e.HelpURL,
+		Source:    e.Source,
+		CreatedAt: e.CreatedAt,
+	}
+	if e.ApprovalTimeout != "" {
+		if d, parseErr := time.ParseDuration(e.ApprovalTimeout); parseErr == nil {
+			r.ApprovalTimeout = d
+		}
+	}
+	if e.TimeoutAction != "" {
+		r.TimeoutAction = policy.Action(e.TimeoutAction)
+	}
+	r.ExemptScope = policy.ExemptScope(e.ExemptScope)
+	if e.ExemptDuration != "" {
+		if d, parseErr := time.ParseDuration(e.ExemptDuration); parseErr == nil {
+			r.ExemptDuration = d
+		}
+	}
+	return r
+}
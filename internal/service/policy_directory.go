@@ -0,0 +1,424 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/memory"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/policy"
+)
+
+// DefaultPolicyDirectoryReloadInterval is how often the policy directory is
+// checked for changes.
+const DefaultPolicyDirectoryReloadInterval = 5 * time.Second
+
+// PolicyFileSourcePrefix prefixes the Source of rules loaded from the policy
+// directory, followed by the file name.
+const PolicyFileSourcePrefix = "file:"
+
+// maxPolicyFileSize bounds the size of one policy file.
+const maxPolicyFileSize = 1 << 20
+
+// IsFilePolicy reports whether p was loaded from the policy directory.
+func IsFilePolicy(p *policy.Policy) bool {
+	return len(p.Rules) > 0 && strings.HasPrefix(p.Rules[0].Source, PolicyFileSourcePrefix)
+}
+
+// PolicyDirectoryStatus describes the policies loaded from the directory.
+type PolicyDirectoryStatus struct {
+	Path     string    `json:"path"`
+	Files    []string  `json:"files"`
+	Policies int       `json:"policies"`
+	LoadedAt time.Time `json:"loaded_at"`
+	// LastError is the last failed reload, cleared by the next successful
+	// one. The previously loaded policies stay in force while it is set.
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+// policyFile is the format of one file in the policy directory: a single
+// policy, in the format of the policies section of the config file.
+type policyFile struct {
+	Name        string           `yaml:"name"`
+	Description string           `yaml:"description"`
+	Enabled     *bool            `yaml:"enabled"`
+	Rules       []policyFileRule `yaml:"rules"`
+}
+
+type policyFileRule struct {
+	Name            string `yaml:"name"`
+	ToolMatch       string `yaml:"tool_match"`
+	Condition       string `yaml:"condition"`
+	Action          string `yaml:"action"`
+	Priority        *int   `yaml:"priority"`
+	ApprovalTimeout string `yaml:"approval_timeout"`
+	TimeoutAction   string `yaml:"timeout_action"`
+	ExemptScope     string `yaml:"exempt_scope"`
+	ExemptDuration  string `yaml:"exempt_duration"`
+	HelpText        string `yaml:"help_text"`
+	HelpURL         string `yaml:"help_url"`
+}
+
+// fileStamps identifies a version of the directory: the modification time
+// and size of every policy file, by name.
+type fileStamps map[string]fileStamp
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// PolicyDirectory loads policies from a directory of YAML files, one policy
+// per file, and reloads them when a file is added, changed or removed (like
+// nginx conf.d). Files are checked by modification time and size, which also
+// covers a Kubernetes ConfigMap or git-sync symlink swap; dot files are
+// ignored.
+//
+// Loading is all or nothing: every file is parsed and every rule compiled
+// before any change is applied, and the whole set is swapped into the policy
+// store at once. When one file is invalid the previous set stays in force
+// until the directory is fixed.
+//
+// Policies from the directory are read-only in the admin API and are never
+// written to state.json.
+type PolicyDirectory struct {
+	dir           string
+	store         *memory.MemoryPolicyStore
+	policyService *PolicyService
+	logger        *slog.Logger
+
+	mu      sync.Mutex
+	current []*policy.Policy
+	stamps  fileStamps
+	status  PolicyDirectoryStatus
+}
+
+// NewPolicyDirectory creates a PolicyDirectory for dir. Call Load to load
+// the policies.
+func NewPolicyDirectory(dir string, store *memory.MemoryPolicyStore, policyService *PolicyService, logger *slog.Logger) *PolicyDirectory {
+	return &PolicyDirectory{
+		dir:           dir,
+		store:         store,
+		policyService: policyService,
+		logger:        logger,
+		status:        PolicyDirectoryStatus{Path: dir, Files: []string{}},
+	}
+}
+
+// Status returns the state of the directory.
+func (d *PolicyDirectory) Status() PolicyDirectoryStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	status := d.status
+	status.Files = append([]string(nil), d.status.Files...)
+	return status
+}
+
+// Load reads every policy file and replaces the policies loaded before. On
+// failure nothing changes and the error is reported by Status.
+func (d *PolicyDirectory) Load(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	err := d.loadLocked(ctx)
+	if err != nil {
+		now := time.Now().UTC()
+		d.status.LastError = err.Error()
+		d.status.LastErrorAt = &now
+	}
+	return err
+}
+
+func (d *PolicyDirectory) loadLocked(ctx context.Context) error {
+	stamps, err := d.scan()
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(stamps))
+	for name := range stamps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	now := time.Now().UTC()
+	policies := make([]*policy.Policy, 0, len(names))
+	seen := make(map[string]string, len(names))
+	for _, name := range names {
+		p, err := d.parseFile(name, now)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if other, dup := seen[p.Name]; dup {
+			return fmt.Errorf("%s: policy %q is also defined in %s", name, p.Name, other)
+		}
+		seen[p.Name] = name
+		if err := d.policyService.ValidateRules(p.Rules); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		policies = append(policies, p)
+	}
+
+	if err := d.apply(ctx, policies); err != nil {
+		return err
+	}
+	d.stamps = stamps
+	d.status = PolicyDirectoryStatus{Path: d.dir, Files: names, Policies: len(policies), LoadedAt: now}
+	return nil
+}
+
+// apply swaps policies into the store in place of the current set and
+// recompiles the rules. If recompiling fails the current set is restored.
+func (d *PolicyDirectory) apply(ctx context.Context, policies []*policy.Policy) error {
+	d.store.ReplacePolicies(policyIDs(d.current), policies)
+	if err := d.policyService.Reload(ctx); err != nil {
+		d.store.ReplacePolicies(policyIDs(policies), d.current)
+		if rbErr := d.policyService.Reload(ctx); rbErr != nil {
+			d.logger.Error("CRITICAL: failed to restore policies after policy directory reload error", "error", rbErr)
+		}
+		return fmt.Errorf("reload policies: %w", err)
+	}
+	d.current = policies
+	return nil
+}
+
+// scan lists the policy files of the directory with their stamps.
+func (d *PolicyDirectory) scan() (fileStamps, error) {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return nil, fmt.Errorf("read policy directory: %w", err)
+	}
+	stamps := make(fileStamps, len(entries))
+	for _, e := range entries {
+		name := e.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		if ext := filepath.Ext(name); ext != ".yaml" && ext != ".yml" {
+			continue
+		}
+		// Stat follows symlinks, so a swapped link target counts as a change.
+		info, err := os.Stat(filepath.Join(d.dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("stat policy file %s: %w", name, err)
+		}
+		if info.IsDir() {
+			continue
+		}
+		stamps[name] = fileStamp{info.ModTime(), info.Size()}
+	}
+	return stamps, nil
+}
+
+// parseFile reads and checks one policy file. Unknown fields are rejected so
+// that a typo does not silently drop a condition.
+func (d *PolicyDirectory) parseFile(name string, now time.Time) (*policy.Policy, error) {
+	data, err := os.ReadFile(filepath.Join(d.dir, name))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxPolicyFileSize {
+		return nil, fmt.Errorf("file larger than %d bytes", maxPolicyFileSize)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var f policyFile
+	if err := dec.Decode(&f); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("file is empty")
+		}
+		return nil, err
+	}
+	var extra interface{}
+	if err := dec.Decode(&extra); !errors.Is(err, io.EOF) {
+		return nil, errors.New("a file holds a single policy")
+	}
+
+	if strings.TrimSpace(f.Name) == "" {
+		return nil, errors.New("policy name is required")
+	}
+	if len(f.Rules) == 0 {
+		return nil, fmt.Errorf("policy %q has no rules", f.Name)
+	}
+	enabled := true
+	if f.Enabled != nil {
+		enabled = *f.Enabled
+	}
+
+	rules := make([]policy.Rule, len(f.Rules))
+	ruleNames := make(map[string]bool, len(f.Rules))
+	for i, fr := range f.Rules {
+		r, err := fr.rule(name, len(f.Rules)-i, now)
+		if err != nil {
+			return nil, fmt.Errorf("policy %q rule %d: %w", f.Name, i+1, err)
+		}
+		if ruleNames[r.Name] {
+			return nil, fmt.Errorf("policy %q: duplicate rule name %q", f.Name, r.Name)
+		}
+		ruleNames[r.Name] = true
+		r.ID = fmt.Sprintf("%s%s-rule-%d", PolicyFileSourcePrefix, f.Name, i)
+		rules[i] = r
+	}
+
+	return &policy.Policy{
+		ID:          PolicyFileSourcePrefix + f.Name,
+		Name:        f.Name,
+		Description: f.Description,
+		Enabled:     enabled,
+		Rules:       rules,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}, nil
+}
+
+// rule converts a rule of a policy file. defaultPriority keeps the rules in
+// file order, first match wins.
+func (fr policyFileRule) rule(file string, defaultPriority int, now time.Time) (policy.Rule, error) {
+	if strings.TrimSpace(fr.Name) == "" {
+		return policy.Rule{}, errors.New("name is required")
+	}
+	action := policy.Action(fr.Action)
+	switch action {
+	case policy.ActionAllow, policy.ActionDeny, policy.ActionApprovalRequired, policy.ActionExemptRateLimit:
+	default:
+		return policy.Rule{}, fmt.Errorf("%q: action must be allow, deny, approval_required or exempt_rate_limit", fr.Name)
+	}
+	toolMatch := fr.ToolMatch
+	if toolMatch == "" {
+		toolMatch = "*"
+	}
+	if _, err := path.Match(toolMatch, ""); err != nil {
+		return policy.Rule{}, fmt.Errorf("%q: invalid tool_match %q", fr.Name, toolMatch)
+	}
+	cond := fr.Condition
+	if cond == "" {
+		cond = "true" // default: match all calls
+	}
+	r := policy.Rule{
+		Name:      fr.Name,
+		Priority:  defaultPriority,
+		ToolMatch: toolMatch,
+		Condition: cond,
+		Action:    action,
+		HelpText:  fr.HelpText,
+		HelpURL:   fr.HelpURL,
+		Source:    PolicyFileSourcePrefix + file,
+		CreatedAt: now,
+	}
+	if fr.Priority != nil {
+		r.Priority = *fr.Priority
+	}
+	if fr.ApprovalTimeout != "" || fr.TimeoutAction != "" {
+		if action != policy.ActionApprovalRequired {
+			return policy.Rule{}, fmt.Errorf("%q: approval_timeout and timeout_action need action approval_required", fr.Name)
+		}
+	}
+	if fr.ApprovalTimeout != "" {
+		timeout, err := time.ParseDuration(fr.ApprovalTimeout)
+		if err != nil || timeout <= 0 {
+			return policy.Rule{}, fmt.Errorf("%q: invalid approval_timeout %q", fr.Name, fr.ApprovalTimeout)
+		}
+		r.ApprovalTimeout = timeout
+	}
+	switch policy.Action(fr.TimeoutAction) {
+	case "":
+	case policy.ActionAllow, policy.ActionDeny:
+		r.TimeoutAction = policy.Action(fr.TimeoutAction)
+	default:
+		return policy.Rule{}, fmt.Errorf("%q: timeout_action must be allow or deny", fr.Name)
+	}
+	r.ExemptScope = policy.ExemptScope(fr.ExemptScope)
+	if fr.ExemptDuration != "" {
+		d, err := time.ParseDuration(fr.ExemptDuration)
+		if err != nil {
+			return policy.Rule{}, fmt.Errorf("%q: invalid exempt_duration %q", fr.Name, fr.ExemptDuration)
+		}
+		r.ExemptDuration = d
+	}
+	if err := policy.ValidateExemption(action, r.ExemptScope, r.ExemptDuration); err != nil {
+		return policy.Rule{}, fmt.Errorf("%q: %w", fr.Name, err)
+	}
+	return r, nil
+}
+
+// changed reports whether a policy file was added, changed or removed since
+// the last successful load.
+func (d *PolicyDirectory) changed() bool {
+	stamps, err := d.scan()
+	if err != nil {
+		// The directory may be mid-sync; try again on the next check.
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return !sameStamps(stamps, d.stamps)
+}
+
+// Run checks the directory every interval and reloads the policies when a
+// file changed, until ctx is cancelled. A failed reload is retried only once
+// the files change again.
+func (d *PolicyDirectory) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultPolicyDirectoryReloadInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var failed fileStamps
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !d.changed() {
+				continue
+			}
+			stamps, err := d.scan()
+			if err != nil || (failed != nil && sameStamps(stamps, failed)) {
+				continue
+			}
+			if err := d.Load(ctx); err != nil {
+				failed = stamps
+				d.logger.Warn("policy directory reload failed, keeping the current policies",
+					"dir", d.dir, "error", err)
+				continue
+			}
+			failed = nil
+			status := d.Status()
+			d.logger.Info("policy directory reloaded", "dir", d.dir,
+				"files", len(status.Files), "policies", status.Policies)
+		}
+	}
+}
+
+func sameStamps(a, b fileStamps) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, s := range a {
+		if old, ok := b[name]; !ok || old != s {
+			return false
+		}
+	}
+	return true
+}
+
+func policyIDs(policies []*policy.Policy) []string {
+	ids := make([]string, len(policies))
+	for i, p := range policies {
+		ids[i] = p.ID
+	}
+	return ids
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/memory"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/policy"
+)
+
+func TestPolicyDirectory(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	ctx := context.Background()
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	store := memory.NewPolicyStore()
+	policySvc, err := NewPolicyService(ctx, store, logger)
+	if err != nil {
+		t.Fatalf("NewPolicyService: %v", err)
+	}
+	allowed := func(tool string) bool {
+		t.Helper()
+		d, err := policySvc.Evaluate(ctx, policy.EvaluationContext{ToolName: tool})
+		if err != nil {
+			t.Fatalf("Evaluate(%s): %v", tool, err)
+		}
+		return d.Allowed
+	}
+
+	write("reads.yaml", `
+name: reads
+rules:
+  - name: allow-reads
+    tool_match: "read_*"
+    action: allow
+    priority: 10
+  - name: deny-rest
+    action: deny
+    priority: 0
+`)
+	write("notes.txt", "not a policy")
+	write(".hidden.yaml", "not: [valid")
+
+	pd := NewPolicyDirectory(dir, store, policySvc, logger)
+	if err := pd.Load(ctx); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !allowed("read_file") || allowed("write_file") {
+		t.Fatal("policy from reads.yaml not applied")
+	}
+	if st := pd.Status(); len(st.Files) != 1 || st.Policies != 1 || st.LastError != "" {
+		t.Errorf("status = %+v", st)
+	}
+
+	// One invalid file rejects the whole change, valid files included.
+	write("writes.yaml", `
+name: writes
+rules:
+  - name: allow-writes
+    tool_match: "write_*"
+    action: allow
+    priority: 10
+`)
+	write("broken.yaml", `
+name: broken
+rules:
+  - name: typo
+    action: allow
+    conditon: "false"
+`)
+	if err := pd.Load(ctx); err == nil {
+		t.Fatal("expected error for unknown field")
+	}
+	if allowed("write_file") || !allowed("read_file") {
+		t.Error("a failed load must keep the previous policies")
+	}
+	if st := pd.Status(); st.LastError == "" || st.Policies != 1 {
+		t.Errorf("status after failure = %+v", st)
+	}
+
+	write("broken.yaml", `
+name: broken
+rules:
+  - name: bad-cel
+    action: deny
+    condition: "tool_name =="
+`)
+	if err := pd.Load(ctx); err == nil {
+		t.Fatal("expected error for invalid CEL")
+	}
+
+	write("broken.yaml", `
+name: reads
+rules:
+  - name: again
+    action: deny
+`)
+	if err := pd.Load(ctx); err == nil {
+		t.Fatal("expected error for a policy name defined twice")
+	}
+
+	if err := os.Remove(filepath.Join(dir, "broken.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	if err := pd.Load(ctx); err != nil {
+		t.Fatalf("Load after fix: %v", err)
+	}
+	if !allowed("write_file") {
+		t.Error("writes.yaml not applied")
+	}
+	if st := pd.Status(); st.LastError != "" || st.Policies != 2 {
+		t.Errorf("status after fix = %+v", st)
+	}
+
+	// Directory policies are read-only and never persisted to state.json.
+	stateStore := state.NewFileStateStore(filepath.Join(t.TempDir(), "state.json"), logger)
+	if err := stateStore.Save(stateStore.DefaultState()); err != nil {
+		t.Fatalf("save default state: %v", err)
+	}
+	admin := NewPolicyAdminService(store, stateStore, policySvc, logger)
+	if _, err := admin.Update(ctx, PolicyFileSourcePrefix+"reads", &policy.Policy{Name: "reads"}); !errors.Is(err, ErrFilePolicy) {
+		t.Errorf("Update error = %v, want ErrFilePolicy", err)
+	}
+	if err := admin.Delete(ctx, PolicyFileSourcePrefix+"reads"); !errors.Is(err, ErrFilePolicy) {
+		t.Errorf("Delete error = %v, want ErrFilePolicy", err)
+	}
+	if _, err := admin.Create(ctx, &policy.Policy{Name: "admin", Enabled: true, Rules: []policy.Rule{
+		{Name: "deny-exec", ToolMatch: "exec", Condition: "true", Action: policy.ActionDeny, Priority: 1},
+	}}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	appState, err := stateStore.Load()
+	if err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+	if len(appState.Policies) != 1 || appState.Policies[0].Name != "admin: deny-exec" {
+		t.Errorf("persisted policies = %+v", appState.Policies)
+	}
+
+	// Removing a file removes its policy.
+	if err := os.Remove(filepath.Join(dir, "writes.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	if err := pd.Load(ctx); err != nil {
+		t.Fatalf("Load after remove: %v", err)
+	}
+	if allowed("write_file") {
+		t.Error("policy of a removed file still applies")
+	}
+}
+
+func TestPolicyDirectory_RunReloadsOnChange(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dir := t.TempDir()
+
+	store := memory.NewPolicyStore()
+	policySvc, err := NewPolicyService(ctx, store, logger)
+	if err != nil {
+		t.Fatalf("NewPolicyService: %v", err)
+	}
+	pd := NewPolicyDirectory(dir, store, policySvc, logger)
+	if err := pd.Load(ctx); err != nil {
+		t.Fatalf("Load of empty directory: %v", err)
+	}
+	go pd.Run(ctx, 10*time.Millisecond)
+
+	content := "name: tools\nrules:\n  - name: allow-all\n    action: allow\n"
+	if err := os.WriteFile(filepath.Join(dir, "tools.yml"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for pd.Status().Policies != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("policy directory not reloaded, status = %+v", pd.Status())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/action"
+)
+
+const (
+	// maxEnrichmentResponse bounds the body of an enrichment response.
+	maxEnrichmentResponse = 64 * 1024
+
+	// maxEnrichmentCacheSize bounds the number of cached lookups.
+	maxEnrichmentCacheSize = 10000
+)
+
+// PolicyEnrichmentConfig configures PolicyEnrichmentService.
+type PolicyEnrichmentConfig struct {
+	// URL receives a JSON POST describing each action.
+	URL string
+	// Token is sent as a bearer token when set.
+	Token string
+	// Timeout bounds one lookup.
+	Timeout time.Duration
+	// CacheTTL is how long the attributes of a lookup are reused. 0
+	// disables caching.
+	CacheTTL time.Duration
+	// Arguments are the tool arguments sent with the lookup. Other
+	// arguments never leave the gateway.
+	Arguments []string
+	// FailClosed denies the call when the lookup fails. Otherwise the call
+	// is evaluated with no attributes.
+	FailClosed bool
+}
+
+// EnrichmentRequest is the body POSTed to the enrichment endpoint.
+type EnrichmentRequest struct {
+	IdentityID    string                 `json:"identity_id"`
+	IdentityName  string                 `json:"identity_name"`
+	IdentityRoles []string               `json:"identity_roles"`
+	ActionType    string                 `json:"action_type"`
+	ToolName      string                 `json:"tool_name"`
+	DestURL       string                 `json:"dest_url,omitempty"`
+	DestDomain    string                 `json:"dest_domain,omitempty"`
+	DestPath      string                 `json:"dest_path,omitempty"`
+	Arguments     map[string]interface{} `json:"arguments,omitempty"`
+}
+
+// PolicyEnrichmentService fetches attributes the gateway does not store
+// (an identity's team, the data classification of a bucket) from an
+// internal HTTP endpoint before policy evaluation. The endpoint answers
+// with a JSON object, exposed to rule conditions as enrichment. Lookups are
+// cached by their request body, and concurrent identical lookups share one
+// request.
+// It implements action.ContextEnricher.
+type PolicyEnrichmentService struct {
+	cfg    PolicyEnrichmentConfig
+	client *http.Client
+	logger *slog.Logger
+
+	mu       sync.Mutex
+	cache    map[[32]byte]enrichmentCacheEntry
+	inflight map[[32]byte]*enrichmentInflight
+}
+
+type enrichmentCacheEntry struct {
+	attrs    map[string]interface{}
+	cachedAt time.Time
+}
+
+type enrichmentInflight struct {
+	done  chan struct{}
+	attrs map[string]interface{}
+	err   error
+}
+
+// Compile-time check that PolicyEnrichmentService implements action.ContextEnricher.
+var _ action.ContextEnricher = (*PolicyEnrichmentService)(nil)
+
+// NewPolicyEnrichmentService creates an enrichment service. The endpoint is
+// an internal service configured by the operator, so private addresses are
+// allowed; redirects are not followed.
+func NewPolicyEnrichmentService(cfg PolicyEnrichmentConfig, logger *slog.Logger) *PolicyEnrichmentService {
+	return &PolicyEnrichmentService{
+		cfg: cfg,
+		client: &http.Client{
+			Timeout: cfg.Timeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		logger:   logger,
+		cache:    make(map[[32]byte]enrichmentCacheEntry),
+		inflight: make(map[[32]byte]*enrichmentInflight),
+	}
+}
+
+// Enrich returns the attributes of a. When the lookup fails it returns an
+// error if the service fails closed, and no attributes otherwise. The
+// returned map must not be modified.
+func (s *PolicyEnrichmentService) Enrich(ctx context.Context, a *action.CanonicalAction) (map[string]interface{}, error) {
+	body, err := json.Marshal(s.request(a))
+	if err != nil {
+		return s.failed(a, fmt.Errorf("encode request: %w", err))
+	}
+	key := sha256.Sum256(body)
+
+	s.mu.Lock()
+	if entry, ok := s.cache[key]; ok && time.Since(entry.cachedAt) < s.cfg.CacheTTL {
+		s.mu.Unlock()
+		return entry.attrs, nil
+	}
+	if inf, ok := s.inflight[key]; ok {
+		s.mu.Unlock()
+		select {
+		case <-inf.done:
+		case <-ctx.Done():
+			return s.failed(a, ctx.Err())
+		}
+		if inf.err != nil {
+			return s.failed(a, inf.err)
+		}
+		return inf.attrs, nil
+	}
+	inf := &enrichmentInflight{done: make(chan struct{})}
+	s.inflight[key] = inf
+	s.mu.Unlock()
+
+	inf.attrs, inf.err = s.lookup(ctx, body)
+	close(inf.done)
+
+	s.mu.Lock()
+	delete(s.inflight, key)
+	if inf.err == nil && s.cfg.CacheTTL > 0 {
+		s.cache[key] = enrichmentCacheEntry{attrs: inf.attrs, cachedAt: time.Now()}
+		s.evictCacheLocked()
+	}
+	s.mu.Unlock()
+
+	if inf.err != nil {
+		return s.failed(a, inf.err)
+	}
+	return inf.attrs, nil
+}
+
+// request describes a to the endpoint, with only the configured arguments.
+func (s *PolicyEnrichmentService) request(a *action.CanonicalAction) EnrichmentRequest {
+	req := EnrichmentRequest{
+		IdentityID:    a.Identity.ID,
+		IdentityName:  a.Identity.Name,
+		IdentityRoles: a.Identity.Roles,
+		ActionType:    string(a.Type),
+		ToolName:      a.Name,
+		DestURL:       a.Destination.URL,
+		DestDomain:    a.Destination.Domain,
+		DestPath:      a.Destination.Path,
+	}
+	if req.IdentityRoles == nil {
+		req.IdentityRoles = []string{}
+	}
+	for _, name := range s.cfg.Arguments {
+		if v, ok := a.Arguments[name]; ok {
+			if req.Arguments == nil {
+				req.Arguments = make(map[string]interface{}, len(s.cfg.Arguments))
+			}
+			req.Arguments[name] = v
+		}
+	}
+	return req
+}
+
+// lookup POSTs body to the endpoint and decodes the attributes it answers.
+func (s *PolicyEnrichmentService) lookup(ctx context.Context, body []byte) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "SentinelGate-Enrichment/1.0")
+	if s.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.cfg.Token)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxEnrichmentResponse+1))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("enrichment endpoint returned %d", resp.StatusCode)
+	}
+	if len(data) > maxEnrichmentResponse {
+		return nil, fmt.Errorf("enrichment response exceeds %d bytes", maxEnrichmentResponse)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var attrs map[string]interface{}
+	if err := dec.Decode(&attrs); err != nil {
+		return nil, fmt.Errorf("enrichment response is not a JSON object: %w", err)
+	}
+	if attrs == nil {
+		attrs = map[string]interface{}{}
+	}
+	// Whole numbers become int64, as for policy variables.
+	return normalizePolicyValue(attrs).(map[string]interface{}), nil
+}
+
+// failed logs a failed lookup and applies the failure mode.
+func (s *PolicyEnrichmentService) failed(a *action.CanonicalAction, err error) (map[string]interface{}, error) {
+	s.logger.Warn("policy enrichment lookup failed",
+		"tool", a.Name,
+		"identity_id", a.Identity.ID,
+		"fail_closed", s.cfg.FailClosed,
+		"error", err,
+	)
+	if s.cfg.FailClosed {
+		return nil, fmt.Errorf("policy enrichment: %w", err)
+	}
+	return nil, nil
+}
+
+// evictCacheLocked removes expired entries when the cache exceeds
+// maxEnrichmentCacheSize, then the oldest ones. Must be called with s.mu held.
+func (s *PolicyEnrichmentService) evictCacheLocked() {
+	if len(s.cache) <= maxEnrichmentCacheSize {
+		return
+	}
+	for key, entry := range s.cache {
+		if time.Since(entry.cachedAt) >= s.cfg.CacheTTL {
+			delete(s.cache, key)
+		}
+	}
+	for len(s.cache) > maxEnrichmentCacheSize {
+		var oldestKey [32]byte
+		var oldestTime time.Time
+		first := true
+		for key, entry := range s.cache {
+			if first || entry.cachedAt.Before(oldestTime) {
+				oldestKey, oldestTime, first = key, entry.cachedAt, false
+			}
+		}
+		delete(s.cache, oldestKey)
+	}
+}
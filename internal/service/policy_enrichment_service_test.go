@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/action"
+)
+
+func enrichmentTestAction() *action.CanonicalAction {
+	return &action.CanonicalAction{
+		Type:      action.ActionToolCall,
+		Name:      "s3_put_object",
+		Arguments: map[string]interface{}{"bucket": "finance-reports", "body": "secret data"},
+		Identity:  action.ActionIdentity{ID: "id-1", Name: "alice", Roles: []string{"analyst"}, SessionID: "sess-1"},
+	}
+}
+
+func TestPolicyEnrichmentService_Enrich(t *testing.T) {
+	var calls atomic.Int32
+	var got EnrichmentRequest
+	var gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		gotAuth = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte(`{"team":"finance","classification":{"level":3,"label":"confidential"}}`))
+	}))
+	defer srv.Close()
+
+	svc := NewPolicyEnrichmentService(PolicyEnrichmentConfig{
+		URL: srv.URL, Token: "t0k", Timeout: time.Second, CacheTTL: time.Minute, Arguments: []string{"bucket", "region"},
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	attrs, err := svc.Enrich(context.Background(), enrichmentTestAction())
+	if err != nil {
+		t.Fatalf("Enrich() error: %v", err)
+	}
+	if attrs["team"] != "finance" {
+		t.Errorf("team = %v", attrs["team"])
+	}
+	// Whole numbers are integers, as for policy variables.
+	if level := attrs["classification"].(map[string]interface{})["level"]; level != int64(3) {
+		t.Errorf("level = %#v, want int64(3)", level)
+	}
+
+	// Only the configured arguments are sent.
+	if len(got.Arguments) != 1 || got.Arguments["bucket"] != "finance-reports" {
+		t.Errorf("arguments sent = %v, want only bucket", got.Arguments)
+	}
+	if got.IdentityID != "id-1" || got.ToolName != "s3_put_object" || gotAuth != "Bearer t0k" {
+		t.Errorf("request = %+v auth=%q", got, gotAuth)
+	}
+
+	// Identical lookups are answered from the cache.
+	if _, err := svc.Enrich(context.Background(), enrichmentTestAction()); err != nil {
+		t.Fatalf("Enrich() error: %v", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("endpoint called %d times, want 1", n)
+	}
+	other := enrichmentTestAction()
+	other.Arguments["bucket"] = "public-assets"
+	if _, err := svc.Enrich(context.Background(), other); err != nil {
+		t.Fatalf("Enrich() error: %v", err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("endpoint called %d times, want 2 for another bucket", n)
+	}
+}
+
+func TestPolicyEnrichmentService_Failure(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"server error", func(w http.ResponseWriter, r *http.Request) { http.Error(w, "down", http.StatusBadGateway) }},
+		{"not an object", func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte(`["team"]`)) }},
+		{"timeout", func(w http.ResponseWriter, r *http.Request) { time.Sleep(200 * time.Millisecond) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(tt.handler)
+			defer srv.Close()
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			cfg := PolicyEnrichmentConfig{URL: srv.URL, Timeout: 50 * time.Millisecond, CacheTTL: time.Minute}
+
+			// Failing open evaluates the call without attributes.
+			attrs, err := NewPolicyEnrichmentService(cfg, logger).Enrich(context.Background(), enrichmentTestAction())
+			if err != nil || attrs != nil {
+				t.Errorf("fail open: Enrich() = %v, %v; want no attributes and no error", attrs, err)
+			}
+
+			cfg.FailClosed = true
+			if _, err := NewPolicyEnrichmentService(cfg, logger).Enrich(context.Background(), enrichmentTestAction()); err == nil {
+				t.Error("fail closed: Enrich() should return an error")
+			}
+		})
+	}
+}
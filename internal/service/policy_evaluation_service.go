@@ -73,7 +73,7 @@ type PolicyEvaluation struct {
 type PolicyEvaluationService struct {
 	policyEngine policy.PolicyEngine
 	policyStore  policy.PolicyStore
-	stateStore   *state.FileStateStore
+	stateStore   state.Store
 	logger       *slog.Logger
 
 	// In-memory evaluation store for status polling.
@@ -87,7 +87,7 @@ type PolicyEvaluationService struct {
 func NewPolicyEvaluationService(
 	engine policy.PolicyEngine,
 	store policy.PolicyStore,
-	stateStore *state.FileStateStore,
+	stateStore state.Store,
 	logger *slog.Logger,
 ) *PolicyEvaluationService {
 	return &PolicyEvaluationService{
@@ -140,7 +140,11 @@ func (s *PolicyEvaluationService) Evaluate(ctx context.Context, req PolicyEvalua
 
 	// Generate helpful deny information.
 	if resp.Decision == "deny" || resp.Decision == "approval_required" {
-		resp.HelpURL = GenerateHelpURL(decision.RuleID)
+		decision.HelpURL, decision.HelpText = policy.RenderDecisionHelp(decision, policy.NewHelpTextData(evalCtx, decision.RuleName))
+		resp.HelpURL = decision.HelpURL
+		if resp.HelpURL == "" {
+			resp.HelpURL = GenerateHelpURL(decision.RuleID)
+		}
 		resp.HelpText = GenerateHelpText(decision)
 	}
 
@@ -328,6 +332,7 @@ func (s *PolicyEvaluationService) LoadFromState(appState *state.AppState) {
 }
 
 // GenerateHelpText creates a human-readable help text from a policy decision.
+// decision.HelpText must already be rendered (see policy.RenderDecisionHelp).
 func GenerateHelpText(decision policy.Decision) string {
 	ruleName := decision.RuleName
 	if ruleName == "" {
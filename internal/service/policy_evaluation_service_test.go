@@ -219,6 +219,39 @@ func TestPolicyEvaluationService_HelpURL_Generation(t *testing.T) {
 	}
 }
 
+func TestPolicyEvaluationService_Evaluate_RendersHelpTemplates(t *testing.T) {
+	engine := &mockEvalPolicyEngine{
+		decision: policy.Decision{
+			Allowed:  false,
+			RuleID:   "block-exfil",
+			RuleName: "block-exfil",
+			Reason:   "matched rule block-exfil",
+			HelpText: "{{.ToolName}} may not reach {{.Destination.Domain}} as {{.Identity}}. Request access at {{.ApprovalURL}}",
+			HelpURL:  "https://wiki.example/egress?host={{.Destination.Domain}}",
+		},
+	}
+
+	svc := NewPolicyEvaluationService(engine, nil, nil, testEvalLogger())
+
+	resp, err := svc.Evaluate(context.Background(), PolicyEvaluateRequest{
+		ActionType:   "tool_call",
+		ActionName:   "fetch_url",
+		Protocol:     "mcp",
+		IdentityName: "alice",
+		Destination:  &DestinationRequest{Domain: "evil.example&x=1"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantText := "fetch_url may not reach evil.example&x=1 as alice. Request access at " + policy.ApprovalsURL
+	if resp.HelpText != wantText {
+		t.Errorf("HelpText = %q, want %q", resp.HelpText, wantText)
+	}
+	if want := "https://wiki.example/egress?host=evil.example%26x%3D1"; resp.HelpURL != want {
+		t.Errorf("HelpURL = %q, want %q", resp.HelpURL, want)
+	}
+}
+
 func TestPolicyEvaluationService_StatusTracking(t *testing.T) {
 	engine := &mockEvalPolicyEngine{
 		decision: policy.Decision{
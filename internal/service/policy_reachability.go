@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/policy"
+)
+
+// Reachability verdicts returned by CheckReachability.
+const (
+	// ReachabilityUnreachable means no combination of runtime inputs lets the call through.
+	ReachabilityUnreachable = "unreachable"
+	// ReachabilityReachable means the call is let through regardless of runtime inputs.
+	ReachabilityReachable = "reachable"
+	// ReachabilityConditional means the call is let through for some runtime inputs.
+	ReachabilityConditional = "conditional"
+)
+
+// Condition outcomes for a rule under a reachability query.
+const (
+	ConditionAlways  = "always"  // condition holds for every runtime input
+	ConditionNever   = "never"   // condition fails for every runtime input
+	ConditionDepends = "depends" // condition depends on inputs not fixed by the query
+)
+
+// ReachabilityQuery fixes the inputs a reachability check is performed for.
+// Inputs not fixed here (arguments, session state, destinations, ...) are
+// treated as unknown and may take any value.
+type ReachabilityQuery struct {
+	ToolName     string   `json:"tool_name"`
+	Roles        []string `json:"roles"`
+	IdentityID   string   `json:"identity_id,omitempty"`
+	IdentityName string   `json:"identity_name,omitempty"`
+}
+
+// ReachabilityRule is a rule that applies to the queried tool, with the
+// outcome of its condition under the query.
+type ReachabilityRule struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Priority  int    `json:"priority"`
+	ToolMatch string `json:"tool_match"`
+	Condition string `json:"condition"`
+	Action    string `json:"action"`
+	Outcome   string `json:"outcome"`
+}
+
+// ReachabilityPath is one rule chain that can let the call through.
+// The call follows this path when every rule in MustNotMatch evaluates to
+// false and Rule (if any) evaluates to true.
+type ReachabilityPath struct {
+	// Decision is "allow" or "approval_required".
+	Decision string `json:"decision"`
+	// Rule is the rule that lets the call through; nil for the default allow.
+	Rule *ReachabilityRule `json:"rule,omitempty"`
+	// DefaultAllow is true when no rule matches and the default allow applies.
+	DefaultAllow bool `json:"default_allow,omitempty"`
+	// MustNotMatch lists higher-priority blocking rules that must not fire.
+	MustNotMatch []ReachabilityRule `json:"must_not_match"`
+}
+
+// ReachabilityResult is the outcome of a reachability check.
+type ReachabilityResult struct {
+	ToolName string   `json:"tool_name"`
+	Roles    []string `json:"roles"`
+	Verdict  string   `json:"verdict"`
+	// Paths lists every rule chain that can let the call through, in priority order.
+	Paths []ReachabilityPath `json:"paths"`
+	// BlockedBy is the rule that denies the call for every remaining input, if any.
+	BlockedBy *ReachabilityRule `json:"blocked_by,omitempty"`
+	// Rules lists every rule matching the tool, in evaluation order.
+	Rules []ReachabilityRule `json:"rules"`
+}
+
+// CheckReachability determines whether a call to q.ToolName can ever be let
+// through by the current policy set for the given roles and identity.
+//
+// Rules are walked in evaluation order. Each condition is partially evaluated
+// with the query inputs bound and all other variables unknown, so a condition
+// is only treated as always/never true when that holds for every possible
+// runtime input. Conditions that cannot be decided are assumed to go either
+// way, which makes an "unreachable" verdict a proof while "conditional" may
+// include paths that no real input can take.
+func (s *PolicyService) CheckReachability(ctx context.Context, q ReachabilityQuery) (*ReachabilityResult, error) {
+	if q.ToolName == "" {
+		return nil, errors.New("tool name is required")
+	}
+	if s.loadSnapshot() == nil {
+		return nil, errors.New("policy engine not ready")
+	}
+
+	roles := q.Roles
+	if roles == nil {
+		roles = []string{}
+	}
+	known := map[string]any{
+		"tool_name":      q.ToolName,
+		"action_name":    q.ToolName,
+		"user_roles":     roles,
+		"identity_roles": roles,
+	}
+	if q.IdentityID != "" {
+		known["identity_id"] = q.IdentityID
+	}
+	if q.IdentityName != "" {
+		known["identity_name"] = q.IdentityName
+	}
+
+	result := &ReachabilityResult{
+		ToolName: q.ToolName,
+		Roles:    roles,
+		Paths:    []ReachabilityPath{},
+		Rules:    []ReachabilityRule{},
+	}
+
+	// Blocking rules that may or may not fire; every later path requires
+	// all of them to evaluate to false.
+	var guards []ReachabilityRule
+	terminated := false
+
+	for _, rule := range s.GetMatchingRules(q.ToolName) {
+		outcome, err := s.conditionOutcome(ctx, rule.Condition, known)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", rule.ID, err)
+		}
+		rr := ReachabilityRule{
+			ID:        rule.ID,
+			Name:      rule.Name,
+			Priority:  rule.Priority,
+			ToolMatch: rule.ToolMatch,
+			Condition: rule.Condition,
+			Action:    string(rule.Action),
+			Outcome:   outcome,
+		}
+		result.Rules = append(result.Rules, rr)
+
+		if terminated || outcome == ConditionNever {
+			continue
+		}
+
+		switch rule.Action {
+		case policy.ActionAllow, policy.ActionApprovalRequired:
+			ruleCopy := rr
+			result.Paths = append(result.Paths, ReachabilityPath{
+				Decision:     string(rule.Action),
+				Rule:         &ruleCopy,
+				MustNotMatch: cloneRules(guards),
+			})
+		default:
+			if outcome == ConditionDepends {
+				guards = append(guards, rr)
+			} else {
+				blocked := rr
+				result.BlockedBy = &blocked
+			}
+		}
+		if outcome == ConditionAlways {
+			terminated = true
+		}
+	}
+
+	if !terminated {
+		result.Paths = append(result.Paths, ReachabilityPath{
+			Decision:     string(policy.ActionAllow),
+			DefaultAllow: true,
+			MustNotMatch: cloneRules(guards),
+		})
+	}
+
+	result.Verdict = reachabilityVerdict(result.Paths)
+	return result, nil
+}
+
+// conditionOutcome classifies a rule condition under the known inputs.
+func (s *PolicyService) conditionOutcome(ctx context.Context, condition string, known map[string]any) (string, error) {
+	if condition == "" || condition == "true" {
+		return ConditionAlways, nil
+	}
+	value, decided, err := s.evaluator.PartialEvaluate(ctx, condition, known)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case !decided:
+		return ConditionDepends, nil
+	case value:
+		return ConditionAlways, nil
+	default:
+		return ConditionNever, nil
+	}
+}
+
+// reachabilityVerdict derives the verdict from the allowing paths: a path
+// that needs no undecided condition to hold makes the call always reachable.
+func reachabilityVerdict(paths []ReachabilityPath) string {
+	if len(paths) == 0 {
+		return ReachabilityUnreachable
+	}
+	for _, p := range paths {
+		if len(p.MustNotMatch) == 0 && (p.DefaultAllow || p.Rule.Outcome == ConditionAlways) {
+			return ReachabilityReachable
+		}
+	}
+	return ReachabilityConditional
+}
+
+// cloneRules returns a non-nil copy of rules.
+func cloneRules(rules []ReachabilityRule) []ReachabilityRule {
+	out := make([]ReachabilityRule, len(rules))
+	copy(out, rules)
+	return out
+}
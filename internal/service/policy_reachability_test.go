@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/policy"
+)
+
+func newReachabilityTestService(t *testing.T, rules ...policy.Rule) *PolicyService {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	store := newMockPolicyStore(policy.Policy{
+		ID:      "reach-policy",
+		Name:    "Reachability",
+		Enabled: true,
+		Rules:   rules,
+	})
+	svc, err := NewPolicyService(context.Background(), store, logger)
+	if err != nil {
+		t.Fatalf("failed to create policy service: %v", err)
+	}
+	return svc
+}
+
+func TestCheckReachability_UnreachableForRole(t *testing.T) {
+	svc := newReachabilityTestService(t,
+		policy.Rule{ID: "admin-delete", Name: "Admins may delete", Priority: 100, ToolMatch: "delete_*",
+			Condition: `"admin" in user_roles`, Action: policy.ActionAllow},
+		policy.Rule{ID: "deny-delete", Name: "Deny deletes", Priority: 50, ToolMatch: "delete_*",
+			Condition: "true", Action: policy.ActionDeny},
+	)
+
+	res, err := svc.CheckReachability(context.Background(), ReachabilityQuery{
+		ToolName: "delete_database",
+		Roles:    []string{"contractor"},
+	})
+	if err != nil {
+		t.Fatalf("CheckReachability() error: %v", err)
+	}
+	if res.Verdict != ReachabilityUnreachable {
+		t.Fatalf("Verdict = %q, want %q (paths: %+v)", res.Verdict, ReachabilityUnreachable, res.Paths)
+	}
+	if res.BlockedBy == nil || res.BlockedBy.ID != "deny-delete" {
+		t.Errorf("BlockedBy = %+v, want deny-delete", res.BlockedBy)
+	}
+	if len(res.Rules) != 2 || res.Rules[0].Outcome != ConditionNever {
+		t.Errorf("Rules = %+v, want admin-delete classified as never", res.Rules)
+	}
+
+	res, err = svc.CheckReachability(context.Background(), ReachabilityQuery{
+		ToolName: "delete_database",
+		Roles:    []string{"admin"},
+	})
+	if err != nil {
+		t.Fatalf("CheckReachability() error: %v", err)
+	}
+	if res.Verdict != ReachabilityReachable {
+		t.Fatalf("Verdict = %q, want %q", res.Verdict, ReachabilityReachable)
+	}
+	if len(res.Paths) != 1 || res.Paths[0].Rule == nil || res.Paths[0].Rule.ID != "admin-delete" {
+		t.Errorf("Paths = %+v, want single path through admin-delete", res.Paths)
+	}
+}
+
+func TestCheckReachability_ConditionalReportsChain(t *testing.T) {
+	svc := newReachabilityTestService(t,
+		policy.Rule{ID: "deny-prod", Name: "Deny prod", Priority: 200, ToolMatch: "*",
+			Condition: `tool_args.env == "prod"`, Action: policy.ActionDeny},
+		policy.Rule{ID: "approve-delete", Name: "Approve deletes", Priority: 100, ToolMatch: "delete_database",
+			Condition: `"contractor" in user_roles && tool_args.dry_run == true`, Action: policy.ActionApprovalRequired},
+		policy.Rule{ID: "deny-all", Name: "Deny all", Priority: 0, ToolMatch: "*",
+			Condition: "true", Action: policy.ActionDeny},
+	)
+
+	res, err := svc.CheckReachability(context.Background(), ReachabilityQuery{
+		ToolName: "delete_database",
+		Roles:    []string{"contractor"},
+	})
+	if err != nil {
+		t.Fatalf("CheckReachability() error: %v", err)
+	}
+	if res.Verdict != ReachabilityConditional {
+		t.Fatalf("Verdict = %q, want %q", res.Verdict, ReachabilityConditional)
+	}
+	if len(res.Paths) != 1 {
+		t.Fatalf("len(Paths) = %d, want 1", len(res.Paths))
+	}
+	path := res.Paths[0]
+	if path.Decision != string(policy.ActionApprovalRequired) || path.Rule.ID != "approve-delete" {
+		t.Errorf("path = %+v, want approval_required via approve-delete", path)
+	}
+	if len(path.MustNotMatch) != 1 || path.MustNotMatch[0].ID != "deny-prod" {
+		t.Errorf("MustNotMatch = %+v, want [deny-prod]", path.MustNotMatch)
+	}
+}
+
+func TestCheckReachability_DefaultAllow(t *testing.T) {
+	svc := newReachabilityTestService(t,
+		policy.Rule{ID: "deny-write", Name: "Deny writes", Priority: 10, ToolMatch: "write_*",
+			Condition: "true", Action: policy.ActionDeny},
+	)
+
+	res, err := svc.CheckReachability(context.Background(), ReachabilityQuery{ToolName: "delete_database"})
+	if err != nil {
+		t.Fatalf("CheckReachability() error: %v", err)
+	}
+	if res.Verdict != ReachabilityReachable {
+		t.Fatalf("Verdict = %q, want %q", res.Verdict, ReachabilityReachable)
+	}
+	if len(res.Paths) != 1 || !res.Paths[0].DefaultAllow {
+		t.Errorf("Paths = %+v, want default allow", res.Paths)
+	}
+}
+
+func TestCheckReachability_RequiresToolName(t *testing.T) {
+	svc := newReachabilityTestService(t)
+	if _, err := svc.CheckReachability(context.Background(), ReachabilityQuery{}); err == nil {
+		t.Fatal("expected error for empty tool name")
+	}
+}
@@ -0,0 +1,444 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"time"
+
+	celeval "github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/cel"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/memory"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/policy"
+)
+
+const (
+	// defaultReplayWindow is the history replayed when no start time is given.
+	defaultReplayWindow = 24 * time.Hour
+	// maxReplayRecords caps the audit records replayed by one request.
+	maxReplayRecords = 10000
+	// replayPageSize is the number of records read per audit query.
+	replayPageSize = 1000
+	// maxReplayDetails caps the changed records listed in a replay result.
+	maxReplayDetails = 100
+)
+
+// Replay change kinds.
+const (
+	ReplayNewlyDenied  = "newly_denied"
+	ReplayNewlyAllowed = "newly_allowed"
+	ReplayChanged      = "changed"
+)
+
+var (
+	// ErrInvalidCandidatePolicy is returned when a candidate policy set cannot be compiled.
+	ErrInvalidCandidatePolicy = errors.New("invalid candidate policy")
+	// ErrInvalidReplayWindow is returned when the replay start is not before its end.
+	ErrInvalidReplayWindow = errors.New("start_time must be before end_time")
+	// ErrReplayUnavailable is returned when no audit history reader is set.
+	ErrReplayUnavailable = errors.New("audit history not available")
+)
+
+// SimulationAuditQuery reads audit records in a time window.
+type SimulationAuditQuery interface {
+	Query(ctx context.Context, filter audit.AuditFilter) ([]audit.AuditRecord, string, error)
+}
+
+// CandidatePolicy is a policy of a candidate set. It has the JSON layout of
+// GET /admin/api/policies, so a listing can be edited and replayed as is.
+type CandidatePolicy struct {
+	Name     string `json:"name"`
+	Priority int    `json:"priority"`
+	// Enabled defaults to true; disabled policies are not evaluated.
+	Enabled *bool                 `json:"enabled,omitempty"`
+	Rules   []CandidatePolicyRule `json:"rules"`
+}
+
+// CandidatePolicyRule is a rule of a CandidatePolicy.
+type CandidatePolicyRule struct {
+	ID              string `json:"id,omitempty"`
+	Name            string `json:"name"`
+	Priority        int    `json:"priority"`
+	ToolMatch       string `json:"tool_match"`
+	Condition       string `json:"condition"`
+	Action          string `json:"action"`
+	ApprovalTimeout string `json:"approval_timeout,omitempty"`
+	TimeoutAction   string `json:"timeout_action,omitempty"`
+}
+
+// ReplayRequest selects the audit history to replay and the candidate policy
+// set to replay it through. The candidate set replaces the active policies
+// for the replay; it is never activated.
+type ReplayRequest struct {
+	Policies []CandidatePolicy `json:"policies"`
+	// StartTime defaults to 24 hours before EndTime.
+	StartTime time.Time `json:"start_time"`
+	// EndTime defaults to now.
+	EndTime time.Time `json:"end_time"`
+	// MaxRecords limits the replayed records, newest first (default and max 10000).
+	MaxRecords int `json:"max_records"`
+	// ToolMatch restricts the replay to tools matching this glob pattern.
+	ToolMatch string `json:"tool_match,omitempty"`
+	// IdentityID restricts the replay to one identity.
+	IdentityID string `json:"identity_id,omitempty"`
+}
+
+// ReplayResult reports how the candidate policy set would have decided the
+// replayed calls compared to what actually happened.
+type ReplayResult struct {
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	// TotalProcessed is the number of audit records read from the window.
+	TotalProcessed int `json:"total_processed"`
+	// TotalAnalyzed is the number of records replayed. Records that were not
+	// policy decisions (e.g. quota blocks) are skipped.
+	TotalAnalyzed int `json:"total_analyzed"`
+	Skipped       int `json:"skipped"`
+	// Truncated is true when the window held more than MaxRecords records;
+	// the newest ones were replayed.
+	Truncated    bool `json:"truncated"`
+	Unchanged    int  `json:"unchanged"`
+	NewlyDenied  int  `json:"newly_denied"`
+	NewlyAllowed int  `json:"newly_allowed"`
+	// Changed counts every changed decision, including changes between deny
+	// and approval_required.
+	Changed int `json:"changed"`
+	// Rules attributes the replayed decisions to the candidate rule that made
+	// them, most changes first. Calls no rule matched are listed with an
+	// empty rule ID.
+	Rules []ReplayRuleImpact `json:"rules"`
+	// Details lists the first 100 changed calls.
+	Details    []ReplayDetail `json:"details"`
+	DurationMs int64          `json:"duration_ms"`
+}
+
+// ReplayRuleImpact counts the replayed decisions made by one candidate rule.
+type ReplayRuleImpact struct {
+	RuleID       string `json:"rule_id"`
+	RuleName     string `json:"rule_name"`
+	PolicyName   string `json:"policy_name,omitempty"`
+	Action       string `json:"action"`
+	Decisions    int    `json:"decisions"`
+	Unchanged    int    `json:"unchanged"`
+	NewlyDenied  int    `json:"newly_denied"`
+	NewlyAllowed int    `json:"newly_allowed"`
+	Changed      int    `json:"changed"`
+}
+
+// ReplayDetail describes one replayed call whose decision would change.
+type ReplayDetail struct {
+	Timestamp        string `json:"timestamp"`
+	ToolName         string `json:"tool_name"`
+	IdentityID       string `json:"identity_id"`
+	IdentityName     string `json:"identity_name,omitempty"`
+	OriginalDecision string `json:"original_decision"`
+	OriginalRuleID   string `json:"original_rule_id,omitempty"`
+	NewDecision      string `json:"new_decision"`
+	NewRuleID        string `json:"new_rule_id,omitempty"`
+	NewRuleName      string `json:"new_rule_name,omitempty"`
+	Change           string `json:"change"`
+}
+
+// SetAuditQuery sets the audit reader used by Replay.
+func (s *SimulationService) SetAuditQuery(q SimulationAuditQuery) {
+	s.auditQuery = q
+}
+
+// Replay evaluates the audit records of a time window with a candidate policy
+// set and compares the outcome with the recorded decisions. The active
+// policies are not involved.
+func (s *SimulationService) Replay(ctx context.Context, req ReplayRequest) (*ReplayResult, error) {
+	if s.auditQuery == nil {
+		return nil, ErrReplayUnavailable
+	}
+	start := time.Now()
+
+	// Candidate conditions are untrusted input: hold them to the limits of
+	// the active policies.
+	limits := celeval.DefaultLimits()
+	if s.policyService != nil {
+		limits = s.policyService.CELLimits()
+	}
+	candidate, ruleInfo, err := buildCandidatePolicyService(ctx, req.Policies, limits)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.EndTime.IsZero() {
+		req.EndTime = start.UTC()
+	}
+	if req.StartTime.IsZero() {
+		req.StartTime = req.EndTime.Add(-defaultReplayWindow)
+	}
+	if !req.StartTime.Before(req.EndTime) {
+		return nil, ErrInvalidReplayWindow
+	}
+	maxRecords := req.MaxRecords
+	if maxRecords <= 0 || maxRecords > maxReplayRecords {
+		maxRecords = maxReplayRecords
+	}
+
+	records, truncated, err := s.readReplayWindow(ctx, req, maxRecords)
+	if err != nil {
+		return nil, fmt.Errorf("read audit history: %w", err)
+	}
+
+	result := &ReplayResult{
+		StartTime:      req.StartTime,
+		EndTime:        req.EndTime,
+		TotalProcessed: len(records),
+		Truncated:      truncated,
+		Details:        []ReplayDetail{},
+	}
+	impacts := make(map[string]*ReplayRuleImpact)
+
+	for _, rec := range records {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		// A quota block says nothing about the policy decision, so unlike
+		// Simulate it is not counted as a deny.
+		original := rec.Decision
+		if original == audit.DecisionWarn {
+			original = audit.DecisionAllow
+		}
+		if rec.ToolName == "" || !isPolicyDecision(original) {
+			result.Skipped++
+			continue
+		}
+		if req.ToolMatch != "" && req.ToolMatch != "*" {
+			if m, _ := filepath.Match(req.ToolMatch, rec.ToolName); !m {
+				result.Skipped++
+				continue
+			}
+		}
+
+		decision, err := candidate.Evaluate(ctx, simulationEvalContext(rec))
+		if err != nil {
+			s.logger.Debug("replay eval error", "tool", rec.ToolName, "error", err)
+			result.Skipped++
+			continue
+		}
+		newDecision := decisionString(decision)
+		result.TotalAnalyzed++
+
+		impact, ok := impacts[decision.RuleID]
+		if !ok {
+			impact = &ReplayRuleImpact{RuleID: decision.RuleID, RuleName: "(no matching rule)", Action: string(policy.ActionAllow)}
+			if info, found := ruleInfo[decision.RuleID]; found {
+				impact.RuleName, impact.PolicyName, impact.Action = info.ruleName, info.policyName, info.action
+			}
+			impacts[decision.RuleID] = impact
+		}
+		impact.Decisions++
+
+		change := replayChange(original, newDecision)
+		switch change {
+		case "":
+			result.Unchanged++
+			impact.Unchanged++
+			continue
+		case ReplayNewlyDenied:
+			result.NewlyDenied++
+			impact.NewlyDenied++
+		case ReplayNewlyAllowed:
+			result.NewlyAllowed++
+			impact.NewlyAllowed++
+		}
+		result.Changed++
+		impact.Changed++
+
+		if len(result.Details) < maxReplayDetails {
+			result.Details = append(result.Details, ReplayDetail{
+				Timestamp:        rec.Timestamp.Format(time.RFC3339),
+				ToolName:         rec.ToolName,
+				IdentityID:       rec.IdentityID,
+				IdentityName:     rec.IdentityName,
+				OriginalDecision: original,
+				OriginalRuleID:   rec.RuleID,
+				NewDecision:      newDecision,
+				NewRuleID:        decision.RuleID,
+				NewRuleName:      decision.RuleName,
+				Change:           change,
+			})
+		}
+	}
+
+	result.Rules = make([]ReplayRuleImpact, 0, len(impacts))
+	for _, impact := range impacts {
+		result.Rules = append(result.Rules, *impact)
+	}
+	sort.Slice(result.Rules, func(i, j int) bool {
+		a, b := result.Rules[i], result.Rules[j]
+		if a.Changed != b.Changed {
+			return a.Changed > b.Changed
+		}
+		if a.Decisions != b.Decisions {
+			return a.Decisions > b.Decisions
+		}
+		return a.RuleID < b.RuleID
+	})
+	result.DurationMs = time.Since(start).Milliseconds()
+	return result, nil
+}
+
+// readReplayWindow returns up to limit records of the request window, newest
+// first, paging backwards through the audit reader. truncated reports that
+// older records were left out.
+func (s *SimulationService) readReplayWindow(ctx context.Context, req ReplayRequest, limit int) ([]audit.AuditRecord, bool, error) {
+	filter := audit.AuditFilter{
+		StartTime:     req.StartTime,
+		EndTime:       req.EndTime,
+		UserID:        req.IdentityID,
+		LimitExplicit: true,
+	}
+	var records []audit.AuditRecord
+	for len(records) < limit {
+		filter.Limit = min(replayPageSize, limit-len(records))
+		page, _, err := s.auditQuery.Query(ctx, filter)
+		if err != nil {
+			return nil, false, err
+		}
+		records = append(records, page...)
+		if len(page) < filter.Limit {
+			return records, false, nil
+		}
+		// Continue just before the oldest record of the page. Records sharing
+		// that exact timestamp on the other side of the page boundary are lost,
+		// which is acceptable for an impact estimate.
+		filter.EndTime = page[len(page)-1].Timestamp.Add(-time.Nanosecond)
+		if filter.EndTime.Before(filter.StartTime) {
+			return records, false, nil
+		}
+	}
+	return records, true, nil
+}
+
+// candidateRuleInfo describes a compiled candidate rule for attribution.
+type candidateRuleInfo struct {
+	ruleName   string
+	policyName string
+	action     string
+}
+
+// buildCandidatePolicyService compiles a candidate policy set into a policy
+// service of its own, validating every condition against limits.
+func buildCandidatePolicyService(ctx context.Context, policies []CandidatePolicy, limits celeval.Limits) (*PolicyService, map[string]candidateRuleInfo, error) {
+	if len(policies) == 0 {
+		return nil, nil, fmt.Errorf("%w: no policies", ErrInvalidCandidatePolicy)
+	}
+	evaluator, err := celeval.NewEvaluator(celeval.WithLimits(limits))
+	if err != nil {
+		return nil, nil, err
+	}
+	store := memory.NewPolicyStore()
+	info := make(map[string]candidateRuleInfo)
+	now := time.Now().UTC()
+	for i, cp := range policies {
+		if cp.Name == "" {
+			return nil, nil, fmt.Errorf("%w: policies[%d]: name is required", ErrInvalidCandidatePolicy, i)
+		}
+		p := &policy.Policy{
+			ID:        fmt.Sprintf("candidate-%d", i),
+			Name:      cp.Name,
+			Priority:  cp.Priority,
+			Enabled:   cp.Enabled == nil || *cp.Enabled,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		for j, cr := range cp.Rules {
+			rule, err := cr.toRule()
+			if err != nil {
+				return nil, nil, fmt.Errorf("%w: policy %q rule %d: %w", ErrInvalidCandidatePolicy, cp.Name, j, err)
+			}
+			if rule.Condition != "" {
+				if err := evaluator.ValidateExpression(rule.Condition); err != nil {
+					return nil, nil, fmt.Errorf("%w: policy %q rule %d: %w", ErrInvalidCandidatePolicy, cp.Name, j, err)
+				}
+			}
+			if rule.ID == "" {
+				rule.ID = fmt.Sprintf("%s-rule-%d", cp.Name, j)
+			}
+			if _, dup := info[rule.ID]; dup {
+				return nil, nil, fmt.Errorf("%w: duplicate rule id %q", ErrInvalidCandidatePolicy, rule.ID)
+			}
+			info[rule.ID] = candidateRuleInfo{ruleName: rule.Name, policyName: cp.Name, action: string(rule.Action)}
+			p.Rules = append(p.Rules, rule)
+		}
+		if err := store.SavePolicy(ctx, p); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	svc, err := NewPolicyService(ctx, store, slog.New(slog.NewTextHandler(io.Discard, nil)), WithCELLimits(limits))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidCandidatePolicy, err)
+	}
+	return svc, info, nil
+}
+
+// toRule converts a candidate rule to a domain rule, applying the defaults
+// of the policy admin API.
+func (cr CandidatePolicyRule) toRule() (policy.Rule, error) {
+	rule := policy.Rule{
+		ID:        cr.ID,
+		Name:      cr.Name,
+		Priority:  cr.Priority,
+		ToolMatch: cr.ToolMatch,
+		Condition: cr.Condition,
+		Action:    policy.Action(cr.Action),
+	}
+	if rule.ToolMatch == "" {
+		rule.ToolMatch = "*"
+	}
+	if rule.Condition == "" {
+		rule.Condition = "true"
+	}
+	switch rule.Action {
+	case policy.ActionAllow, policy.ActionDeny, policy.ActionApprovalRequired, policy.ActionExemptRateLimit:
+	default:
+		return rule, fmt.Errorf("invalid action %q", cr.Action)
+	}
+	if cr.ApprovalTimeout != "" {
+		d, err := time.ParseDuration(cr.ApprovalTimeout)
+		if err != nil {
+			return rule, fmt.Errorf("invalid approval_timeout %q", cr.ApprovalTimeout)
+		}
+		rule.ApprovalTimeout = d
+	}
+	if cr.TimeoutAction != "" {
+		rule.TimeoutAction = policy.Action(cr.TimeoutAction)
+	}
+	return rule, nil
+}
+
+// isPolicyDecision reports whether an audit decision can come from policy
+// evaluation.
+func isPolicyDecision(decision string) bool {
+	switch decision {
+	case "allow", "deny", "approval_required":
+		return true
+	}
+	return false
+}
+
+// replayChange classifies a decision change; "" means unchanged.
+func replayChange(original, replayed string) string {
+	if original == replayed {
+		return ""
+	}
+	wasBlocked := original != "allow"
+	isBlocked := replayed != "allow"
+	switch {
+	case !wasBlocked && isBlocked:
+		return ReplayNewlyDenied
+	case wasBlocked && !isBlocked:
+		return ReplayNewlyAllowed
+	default:
+		return ReplayChanged
+	}
+}
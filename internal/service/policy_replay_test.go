@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+)
+
+// sliceAuditQuery serves audit queries from records sorted oldest first.
+type sliceAuditQuery struct {
+	records []audit.AuditRecord
+	queries int
+}
+
+func (q *sliceAuditQuery) Query(_ context.Context, filter audit.AuditFilter) ([]audit.AuditRecord, string, error) {
+	q.queries++
+	var out []audit.AuditRecord
+	for i := len(q.records) - 1; i >= 0 && len(out) < filter.Limit; i-- {
+		rec := q.records[i]
+		if rec.Timestamp.Before(filter.StartTime) || rec.Timestamp.After(filter.EndTime) {
+			continue
+		}
+		if filter.UserID != "" && rec.IdentityID != filter.UserID {
+			continue
+		}
+		out = append(out, rec)
+	}
+	return out, "", nil
+}
+
+func TestReplay_AttributesChangesToCandidateRules(t *testing.T) {
+	now := time.Now().UTC()
+	q := &sliceAuditQuery{records: []audit.AuditRecord{
+		{Timestamp: now.Add(-48 * time.Hour), ToolName: "write_file", Decision: "allow", IdentityID: "old"},
+		{Timestamp: now.Add(-3 * time.Hour), ToolName: "read_file", Decision: "allow", IdentityID: "a", Roles: []string{"dev"}},
+		{Timestamp: now.Add(-2 * time.Hour), ToolName: "write_file", Decision: "allow", IdentityID: "a", Roles: []string{"dev"}, RuleID: "old-allow"},
+		{Timestamp: now.Add(-90 * time.Minute), ToolName: "write_file", Decision: "allow", IdentityID: "b", Roles: []string{"admin"}},
+		{Timestamp: now.Add(-1 * time.Hour), ToolName: "exec", Decision: "deny", IdentityID: "b", Roles: []string{"admin"}},
+		{Timestamp: now.Add(-30 * time.Minute), ToolName: "exec", Decision: "blocked", IdentityID: "a"},
+		{Timestamp: now.Add(-10 * time.Minute), ToolName: "", Decision: "allow"},
+	}}
+	svc := newSimulationTestService(t, nil, nil)
+	svc.SetAuditQuery(q)
+
+	result, err := svc.Replay(context.Background(), ReplayRequest{
+		Policies: []CandidatePolicy{{
+			Name: "candidate",
+			Rules: []CandidatePolicyRule{
+				{ID: "deny-dev-writes", Name: "Deny dev writes", Priority: 100, ToolMatch: "write_*",
+					Condition: `"dev" in user_roles`, Action: "deny"},
+				{Name: "Admins run anything", Priority: 50, Condition: `"admin" in identity_roles`, Action: "allow"},
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+
+	if result.TotalProcessed != 6 || result.Skipped != 2 || result.TotalAnalyzed != 4 {
+		t.Errorf("processed=%d skipped=%d analyzed=%d, want 6/2/4", result.TotalProcessed, result.Skipped, result.TotalAnalyzed)
+	}
+	// The quota-blocked exec and the record without a tool are skipped.
+	if result.NewlyDenied != 1 || result.NewlyAllowed != 1 || result.Unchanged != 2 || result.Changed != 2 {
+		t.Errorf("result = %+v", result)
+	}
+
+	impacts := make(map[string]ReplayRuleImpact)
+	for _, r := range result.Rules {
+		impacts[r.RuleID] = r
+	}
+	if r := impacts["deny-dev-writes"]; r.NewlyDenied != 1 || r.Decisions != 1 || r.PolicyName != "candidate" {
+		t.Errorf("deny-dev-writes impact = %+v", r)
+	}
+	if r := impacts["candidate-rule-1"]; r.NewlyAllowed != 1 || r.Decisions != 2 || r.Action != "allow" {
+		t.Errorf("generated rule impact = %+v", r)
+	}
+	if r := impacts[""]; r.Decisions != 1 || r.Unchanged != 1 || r.RuleName != "(no matching rule)" {
+		t.Errorf("default impact = %+v", r)
+	}
+	if result.Rules[0].Changed != 1 {
+		t.Errorf("rules should be sorted by changes, got %+v", result.Rules)
+	}
+
+	if len(result.Details) != 2 {
+		t.Fatalf("details = %+v", result.Details)
+	}
+	for _, d := range result.Details {
+		if d.ToolName == "write_file" && (d.Change != ReplayNewlyDenied || d.OriginalRuleID != "old-allow") {
+			t.Errorf("write detail = %+v", d)
+		}
+	}
+}
+
+func TestReplay_PagesAndTruncates(t *testing.T) {
+	now := time.Now().UTC()
+	q := &sliceAuditQuery{}
+	for i := 2500; i > 0; i-- {
+		q.records = append(q.records, audit.AuditRecord{
+			Timestamp: now.Add(-time.Duration(i) * time.Second), ToolName: "t", Decision: "allow", IdentityID: "a",
+		})
+	}
+	svc := newSimulationTestService(t, nil, nil)
+	svc.SetAuditQuery(q)
+	allowAll := []CandidatePolicy{{Name: "p", Rules: []CandidatePolicyRule{{Action: "allow"}}}}
+
+	result, err := svc.Replay(context.Background(), ReplayRequest{Policies: allowAll})
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if result.TotalProcessed != 2500 || result.Truncated || q.queries != 3 {
+		t.Errorf("processed=%d truncated=%v queries=%d", result.TotalProcessed, result.Truncated, q.queries)
+	}
+
+	result, err = svc.Replay(context.Background(), ReplayRequest{Policies: allowAll, MaxRecords: 1200})
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if result.TotalProcessed != 1200 || !result.Truncated {
+		t.Errorf("processed=%d truncated=%v", result.TotalProcessed, result.Truncated)
+	}
+}
+
+func TestReplay_RejectsInvalidCandidates(t *testing.T) {
+	svc := newSimulationTestService(t, nil, nil)
+	svc.SetAuditQuery(&sliceAuditQuery{})
+
+	tests := []struct {
+		name     string
+		policies []CandidatePolicy
+	}{
+		{"empty set", nil},
+		{"no name", []CandidatePolicy{{Rules: []CandidatePolicyRule{{Action: "allow"}}}}},
+		{"bad action", []CandidatePolicy{{Name: "p", Rules: []CandidatePolicyRule{{Action: "maybe"}}}}},
+		{"bad CEL", []CandidatePolicy{{Name: "p", Rules: []CandidatePolicyRule{{Action: "deny", Condition: "tool_name =="}}}}},
+		{"duplicate ids", []CandidatePolicy{{Name: "p", Rules: []CandidatePolicyRule{{ID: "x", Action: "deny"}, {ID: "x", Action: "allow"}}}}},
+		{"too complex", []CandidatePolicy{{Name: "p", Rules: []CandidatePolicyRule{{Action: "deny", Condition: `user_roles.all(a, user_roles.all(b, user_roles.all(c, a + b + c != "")))`}}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.Replay(context.Background(), ReplayRequest{Policies: tt.policies}); !errors.Is(err, ErrInvalidCandidatePolicy) {
+				t.Errorf("Replay() error = %v, want ErrInvalidCandidatePolicy", err)
+			}
+		})
+	}
+}
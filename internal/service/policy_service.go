@@ -29,8 +29,13 @@ type CompiledRule struct {
 	Condition       string      // Original CEL condition text (empty means unconditional)
 	Program         cel.Program // Pre-compiled CEL program
 	Action          policy.Action
-	ApprovalTimeout time.Duration // How long to wait for approval (0 = default 5m)
-	TimeoutAction   policy.Action // What to do when approval times out (deny/allow)
+	ApprovalTimeout time.Duration      // How long to wait for approval (0 = default 5m)
+	TimeoutAction   policy.Action      // What to do when approval times out (deny/allow)
+	ExemptScope     policy.ExemptScope // What an exempt_rate_limit rule exempts
+	ExemptDuration  time.Duration      // How long a session/identity exemption lasts
+	HelpText        string             // Help text template shown on denial (rendered at denial time)
+	HelpURL         string             // Help URL template shown on denial (rendered at denial time)
+	UsesRequestTime bool               // Condition reads request_time, e.g. through withinHours
 }
 
 // RuleIndex provides O(1) lookup for exact tool matches.
@@ -43,6 +48,12 @@ type RuleIndex struct {
 type CompiledRulesSnapshot struct {
 	Rules []CompiledRule // All rules sorted by priority (kept for compatibility)
 	Index *RuleIndex     // Index for fast lookup
+	// Exemptions are the exempt_rate_limit rules sorted by priority. They
+	// are not in Index: they never take part in a policy decision.
+	Exemptions []CompiledRule
+	// UsesRequestTime is set when a rule condition reads request_time. The
+	// decisions then depend on the clock and are not cached.
+	UsesRequestTime bool
 }
 
 // lruEntry is a doubly-linked list node for the LRU cache.
@@ -183,9 +194,11 @@ func computeCacheKey(evalCtx policy.EvaluationContext) (uint64, bool) {
 	_, _ = h.WriteString(strings.Join(sortedRoles, ","))
 	_, _ = h.Write([]byte{0})
 
-	// Identity name (policies can condition on identity)
+	// Identity name and ID (policies can condition on either)
 	_, _ = h.WriteString(evalCtx.IdentityName)
 	_, _ = h.Write([]byte{0})
+	_, _ = h.WriteString(evalCtx.IdentityID)
+	_, _ = h.Write([]byte{0})
 
 	// Action type, protocol, and framework (policies can condition on these)
 	_, _ = h.WriteString(evalCtx.ActionType)
@@ -210,11 +223,31 @@ func computeCacheKey(evalCtx policy.EvaluationContext) (uint64, bool) {
 	_, _ = h.Write([]byte{0})
 	_, _ = h.WriteString(evalCtx.DestCommand)
 	_, _ = h.Write([]byte{0})
+	for _, u := range evalCtx.DestURLs {
+		_, _ = h.WriteString(u)
+		_, _ = h.Write([]byte{1})
+	}
+	_, _ = h.Write([]byte{0})
+	for _, d := range evalCtx.DestDomains {
+		_, _ = h.WriteString(d)
+		_, _ = h.Write([]byte{1})
+	}
+	_, _ = h.Write([]byte{0})
+	_, _ = h.WriteString(evalCtx.DestCountry)
+	_, _ = h.Write([]byte{0})
+	_, _ = fmt.Fprintf(h, "%d", evalCtx.DestASN)
+	_, _ = h.Write([]byte{0})
+	_, _ = h.WriteString(evalCtx.DestASNOrg)
+	_, _ = h.Write([]byte{0})
 
 	// Gateway
 	_, _ = h.WriteString(evalCtx.Gateway)
 	_, _ = h.Write([]byte{0})
 
+	// Payload size (rules can deny oversized requests)
+	_, _ = fmt.Fprintf(h, "%d", evalCtx.PayloadBytes)
+	_, _ = h.Write([]byte{0})
+
 	// H-2: FrameworkAttrs (sorted keys + values for determinism)
 	if len(evalCtx.FrameworkAttrs) > 0 {
 		fKeys := make([]string, 0, len(evalCtx.FrameworkAttrs))
@@ -239,6 +272,16 @@ func computeCacheKey(evalCtx policy.EvaluationContext) (uint64, bool) {
 		}
 		_, _ = h.Write(argsJSON)
 	}
+	_, _ = h.Write([]byte{0})
+
+	// Enrichment attributes (JSON for determinism)
+	if len(evalCtx.Enrichment) > 0 {
+		enrichmentJSON, err := json.Marshal(evalCtx.Enrichment)
+		if err != nil {
+			return 0, false
+		}
+		_, _ = h.Write(enrichmentJSON)
+	}
 
 	return h.Sum64(), true
 }
@@ -250,7 +293,9 @@ func computeCacheKey(evalCtx policy.EvaluationContext) (uint64, bool) {
 type PolicyService struct {
 	store     policy.PolicyStore
 	evaluator *celeval.Evaluator
+	celLimits celeval.Limits
 	snapshot  atomic.Value // stores *CompiledRulesSnapshot
+	variables atomic.Value // stores map[string]interface{}
 	mu        sync.Mutex   // Only for Reload() writes
 	cache     *ResultCache // CEL result cache
 	logger    *slog.Logger
@@ -266,17 +311,20 @@ func WithCacheSize(size int) PolicyServiceOption {
 	}
 }
 
+// WithCELLimits sets the cost limit, evaluation timeout and restrictions
+// applied to rule conditions.
+func WithCELLimits(limits celeval.Limits) PolicyServiceOption {
+	return func(s *PolicyService) {
+		s.celLimits = limits
+	}
+}
+
 // NewPolicyService creates a new PolicyService that loads and compiles rules from the store.
 // The ctx parameter is used for the initial policy loading and can be cancelled to abort startup.
 func NewPolicyService(ctx context.Context, store policy.PolicyStore, logger *slog.Logger, opts ...PolicyServiceOption) (*PolicyService, error) {
-	evaluator, err := celeval.NewEvaluator()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create CEL evaluator: %w", err)
-	}
-
 	s := &PolicyService{
 		store:     store,
-		evaluator: evaluator,
+		celLimits: celeval.DefaultLimits(),
 		cache:     NewResultCache(1000), // Default 1000 entries
 		logger:    logger,
 	}
@@ -286,6 +334,12 @@ func NewPolicyService(ctx context.Context, store policy.PolicyStore, logger *slo
 		opt(s)
 	}
 
+	evaluator, err := celeval.NewEvaluator(celeval.WithLimits(s.celLimits))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CEL evaluator: %w", err)
+	}
+	s.evaluator = evaluator
+
 	// Load and compile all policies
 	policies, err := store.GetAllPolicies(ctx)
 	if err != nil {
@@ -306,8 +360,10 @@ func NewPolicyService(ctx context.Context, store policy.PolicyStore, logger *slo
 
 	// Build index and store initial snapshot
 	snapshot := &CompiledRulesSnapshot{
-		Rules: compiled,
-		Index: s.buildIndex(compiled),
+		Rules:           compiled,
+		Index:           s.buildIndex(compiled),
+		Exemptions:      exemptionRules(compiled),
+		UsesRequestTime: usesRequestTime(compiled),
 	}
 	s.snapshot.Store(snapshot)
 
@@ -326,11 +382,26 @@ func (s *PolicyService) CELEvaluator() *celeval.Evaluator {
 	return s.evaluator
 }
 
-// ValidateRules checks that all CEL conditions in the given rules are valid.
-// This should be called before persisting policies to prevent invalid CEL from
-// poisoning the policy store. Returns an error describing the first invalid rule.
+// CELLimits returns the limits applied to rule conditions.
+func (s *PolicyService) CELLimits() celeval.Limits {
+	return s.evaluator.Limits()
+}
+
+// ValidateRules checks that all CEL conditions and help templates in the given
+// rules are valid. This should be called before persisting policies to prevent
+// invalid CEL from poisoning the policy store. Returns an error describing the
+// first invalid rule.
 func (s *PolicyService) ValidateRules(rules []policy.Rule) error {
 	for _, rule := range rules {
+		if err := policy.ValidateHelpTemplate(rule.HelpText); err != nil {
+			return fmt.Errorf("rule %q help_text: %w", rule.Name, err)
+		}
+		if err := policy.ValidateHelpTemplate(rule.HelpURL); err != nil {
+			return fmt.Errorf("rule %q help_url: %w", rule.Name, err)
+		}
+		if err := policy.ValidateExemption(rule.Action, rule.ExemptScope, rule.ExemptDuration); err != nil {
+			return fmt.Errorf("rule %q: %w", rule.Name, err)
+		}
 		if rule.Condition == "" {
 			continue // empty condition defaults to "true" at compile time
 		}
@@ -371,6 +442,11 @@ func (s *PolicyService) compileRules(rules []policy.Rule) ([]CompiledRule, error
 			Action:          rule.Action,
 			ApprovalTimeout: rule.ApprovalTimeout,
 			TimeoutAction:   rule.TimeoutAction,
+			ExemptScope:     rule.ExemptScope,
+			ExemptDuration:  rule.ExemptDuration,
+			HelpText:        rule.HelpText,
+			HelpURL:         rule.HelpURL,
+			UsesRequestTime: s.evaluator.UsesRequestTime(cond),
 		})
 	}
 
@@ -382,12 +458,25 @@ func (s *PolicyService) compileRules(rules []policy.Rule) ([]CompiledRule, error
 	return compiled, nil
 }
 
+// usesRequestTime reports whether a condition of rules reads request_time.
+func usesRequestTime(rules []CompiledRule) bool {
+	for _, r := range rules {
+		if r.UsesRequestTime {
+			return true
+		}
+	}
+	return false
+}
+
 // buildIndex creates a RuleIndex from compiled rules for O(1) exact match lookup.
 func (s *PolicyService) buildIndex(rules []CompiledRule) *RuleIndex {
 	idx := &RuleIndex{
 		Exact: make(map[string][]CompiledRule),
 	}
 	for _, rule := range rules {
+		if rule.Action == policy.ActionExemptRateLimit {
+			continue // evaluated by MatchExemption only
+		}
 		// Check if pattern contains wildcards
 		if strings.ContainsAny(rule.ToolMatch, "*?[") {
 			idx.Wildcard = append(idx.Wildcard, rule)
@@ -418,6 +507,24 @@ func (s *PolicyService) loadSnapshot() *CompiledRulesSnapshot {
 	return snap
 }
 
+// SetVariables replaces the policy variables that rule conditions see as
+// vars. Cached decisions are dropped, so the new values apply to the next
+// evaluation without recompiling any rule.
+func (s *PolicyService) SetVariables(vars map[string]interface{}) {
+	if vars == nil {
+		vars = map[string]interface{}{}
+	}
+	s.variables.Store(vars)
+	s.cache.Clear()
+}
+
+// Variables returns the current policy variables. The map must not be
+// modified.
+func (s *PolicyService) Variables() map[string]interface{} {
+	vars, _ := s.variables.Load().(map[string]interface{})
+	return vars
+}
+
 // getCandidateRules returns rules that might match the given tool name,
 // merging exact matches with wildcards in priority order.
 // For namespaced tools (e.g. "desktop/read_file"), it also includes rules
@@ -483,6 +590,10 @@ func (s *PolicyService) getCandidateRules(idx *RuleIndex, toolName string) []Com
 // Uses lock-free atomic.Value read for high performance on the hot path.
 // Results are cached by tool name, roles, arguments, identity, action type, and protocol.
 func (s *PolicyService) Evaluate(ctx context.Context, evalCtx policy.EvaluationContext) (policy.Decision, error) {
+	if evalCtx.Variables == nil {
+		evalCtx.Variables = s.Variables()
+	}
+
 	// Compute cache key from evaluation context
 	cacheKey, cacheKeyValid := computeCacheKey(evalCtx)
 
@@ -493,12 +604,10 @@ func (s *PolicyService) Evaluate(ctx context.Context, evalCtx policy.EvaluationC
 	// M-12: Also skip cache when session usage counters are non-zero,
 	// since CEL rules like "session_call_count > 100" depend on dynamic state.
 	hasSessionCounters := evalCtx.SessionCallCount > 0 || evalCtx.SessionWriteCount > 0
-	useCache := !evalCtx.SkipCache && cacheKeyValid && len(evalCtx.SessionActionHistory) == 0 && !hasSessionCounters
-	if useCache {
-		if decision, ok := s.cache.Get(cacheKey); ok {
-			return decision, nil
-		}
-	}
+	// Tainted actions are never cached either: the same arguments are clean
+	// in a session without the flagged result. Nor are anomalous ones, whose
+	// score changes as the tool's profile learns.
+	useCache := !evalCtx.SkipCache && cacheKeyValid && len(evalCtx.SessionActionHistory) == 0 && !hasSessionCounters && !evalCtx.ActionTainted && evalCtx.AnomalyScore == 0
 
 	// Lock-free read - no mutex needed
 	snapshot := s.loadSnapshot()
@@ -506,6 +615,14 @@ func (s *PolicyService) Evaluate(ctx context.Context, evalCtx policy.EvaluationC
 		// M-7: Fail-closed when policy engine is not ready (startup race / load failure).
 		return policy.Decision{Allowed: false, Reason: "policy engine not ready"}, nil
 	}
+	// Rules reading request_time (withinHours, isWeekend) decide
+	// differently as the clock moves, so nothing is cached while one is loaded.
+	useCache = useCache && !snapshot.UsesRequestTime
+	if useCache {
+		if decision, ok := s.cache.Get(cacheKey); ok {
+			return decision, nil
+		}
+	}
 
 	// Get candidate rules from index
 	candidates := s.getCandidateRules(snapshot.Index, evalCtx.ToolName)
@@ -513,38 +630,8 @@ func (s *PolicyService) Evaluate(ctx context.Context, evalCtx policy.EvaluationC
 	// Evaluate candidates in priority order
 	for _, rule := range candidates {
 		// Check glob pattern match (exact matches already filtered by index)
-		if strings.ContainsAny(rule.ToolMatch, "*?[") {
-			// Special case: lone "*" matches everything (including paths with /).
-			// filepath.Match("*", ...) does not match "/" separators, but for
-			// policy rules "*" means "match any tool/action name".
-			if rule.ToolMatch != "*" {
-				matched, err := filepath.Match(rule.ToolMatch, evalCtx.ToolName)
-				if err != nil {
-					s.logger.Warn("invalid glob pattern", "rule", rule.ID, "pattern", rule.ToolMatch, "error", err)
-					continue
-				}
-				// M-11: filepath.Match "*" does not cross "." separators in tool names
-				// (e.g., "mcp.*" won't match "mcp.server.read"). As a workaround,
-				// also try matching with "/" as separator since filepath.Match handles "/".
-				if !matched && strings.Contains(evalCtx.ToolName, ".") {
-					matched, _ = filepath.Match(
-						strings.ReplaceAll(rule.ToolMatch, ".", "/"),
-						strings.ReplaceAll(evalCtx.ToolName, ".", "/"),
-					)
-				}
-				// Backward compat: if the pattern has no "/" (bare pattern like "read_*"),
-				// also try matching against just the bare part of a namespaced tool name.
-				// This ensures "read_*" matches "desktop/read_file".
-				if !matched && !strings.Contains(rule.ToolMatch, "/") {
-					if slashIdx := strings.Index(evalCtx.ToolName, "/"); slashIdx >= 0 {
-						barePart := evalCtx.ToolName[slashIdx+1:]
-						matched, _ = filepath.Match(rule.ToolMatch, barePart)
-					}
-				}
-				if !matched {
-					continue
-				}
-			}
+		if !s.toolMatches(rule, evalCtx.ToolName) {
+			continue
 		}
 
 		// Evaluate CEL condition
@@ -559,6 +646,8 @@ func (s *PolicyService) Evaluate(ctx context.Context, evalCtx policy.EvaluationC
 				RuleName: rule.Name,
 				Priority: rule.Priority,
 				Reason:   fmt.Sprintf("matched rule %s", rule.Name),
+				HelpText: rule.HelpText,
+				HelpURL:  rule.HelpURL,
 			}
 
 			switch rule.Action {
@@ -596,6 +685,96 @@ func (s *PolicyService) Evaluate(ctx context.Context, evalCtx policy.EvaluationC
 	return decision, nil
 }
 
+// toolMatches reports whether the tool_match pattern of rule matches
+// toolName. An exact pattern also matches the bare name of a namespaced
+// tool, like the index does.
+func (s *PolicyService) toolMatches(rule CompiledRule, toolName string) bool {
+	if !strings.ContainsAny(rule.ToolMatch, "*?[") {
+		if rule.ToolMatch == toolName {
+			return true
+		}
+		slashIdx := strings.Index(toolName, "/")
+		return slashIdx >= 0 && rule.ToolMatch == toolName[slashIdx+1:]
+	}
+	// Special case: lone "*" matches everything (including paths with /).
+	// filepath.Match("*", ...) does not match "/" separators, but for
+	// policy rules "*" means "match any tool/action name".
+	if rule.ToolMatch == "*" {
+		return true
+	}
+	matched, err := filepath.Match(rule.ToolMatch, toolName)
+	if err != nil {
+		s.logger.Warn("invalid glob pattern", "rule", rule.ID, "pattern", rule.ToolMatch, "error", err)
+		return false
+	}
+	// M-11: filepath.Match "*" does not cross "." separators in tool names
+	// (e.g., "mcp.*" won't match "mcp.server.read"). As a workaround,
+	// also try matching with "/" as separator since filepath.Match handles "/".
+	if !matched && strings.Contains(toolName, ".") {
+		matched, _ = filepath.Match(
+			strings.ReplaceAll(rule.ToolMatch, ".", "/"),
+			strings.ReplaceAll(toolName, ".", "/"),
+		)
+	}
+	// Backward compat: if the pattern has no "/" (bare pattern like "read_*"),
+	// also try matching against just the bare part of a namespaced tool name.
+	// This ensures "read_*" matches "desktop/read_file".
+	if !matched && !strings.Contains(rule.ToolMatch, "/") {
+		if slashIdx := strings.Index(toolName, "/"); slashIdx >= 0 {
+			matched, _ = filepath.Match(rule.ToolMatch, toolName[slashIdx+1:])
+		}
+	}
+	return matched
+}
+
+// exemptionRules returns the exempt_rate_limit rules of compiled, which is
+// sorted by priority.
+func exemptionRules(compiled []CompiledRule) []CompiledRule {
+	var rules []CompiledRule
+	for _, rule := range compiled {
+		if rule.Action == policy.ActionExemptRateLimit {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// MatchExemption returns the highest priority exempt_rate_limit rule
+// matching evalCtx, or nil when none does. Results are never cached.
+func (s *PolicyService) MatchExemption(ctx context.Context, evalCtx policy.EvaluationContext) (*CompiledRule, error) {
+	snapshot := s.loadSnapshot()
+	if snapshot == nil || len(snapshot.Exemptions) == 0 {
+		return nil, nil
+	}
+	if evalCtx.Variables == nil {
+		evalCtx.Variables = s.Variables()
+	}
+	for i := range snapshot.Exemptions {
+		rule := &snapshot.Exemptions[i]
+		if !s.toolMatches(*rule, evalCtx.ToolName) {
+			continue
+		}
+		result, err := s.evaluator.Evaluate(ctx, rule.Program, evalCtx)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s evaluation failed: %w", rule.ID, err)
+		}
+		if result {
+			return rule, nil
+		}
+	}
+	return nil, nil
+}
+
+// ExemptionRules returns the loaded exempt_rate_limit rules, highest
+// priority first.
+func (s *PolicyService) ExemptionRules() []CompiledRule {
+	snapshot := s.loadSnapshot()
+	if snapshot == nil {
+		return nil
+	}
+	return snapshot.Exemptions
+}
+
 // GetMatchingRules returns all compiled rules whose tool_match pattern matches
 // the given tool name, without evaluating CEL conditions.
 // Used to determine if a tool has mixed-action rules (conditional status).
@@ -672,8 +851,10 @@ func (s *PolicyService) Reload(ctx context.Context) error {
 	// Atomic swap (very brief mutex for Store)
 	s.mu.Lock()
 	s.snapshot.Store(&CompiledRulesSnapshot{
-		Rules: compiled,
-		Index: idx,
+		Rules:           compiled,
+		Index:           idx,
+		Exemptions:      exemptionRules(compiled),
+		UsesRequestTime: usesRequestTime(compiled),
 	})
 	s.mu.Unlock()
 
@@ -1033,6 +1033,14 @@ func TestComputeCacheKey_Deterministic(t *testing.T) {
 		t.Error("different identity_name should produce different cache keys")
 	}
 
+	// Different identity ID should produce different keys
+	ctx4f := base
+	ctx4f.IdentityID = "id-other"
+	key4f, _ := computeCacheKey(ctx4f)
+	if key1 == key4f {
+		t.Error("different identity_id should produce different cache keys")
+	}
+
 	// Different framework should produce different keys
 	ctx4c := base
 	ctx4c.Framework = "crewai"
@@ -1048,6 +1056,12 @@ func TestComputeCacheKey_Deterministic(t *testing.T) {
 	if key1 == key4d {
 		t.Error("different dest_domain should produce different cache keys")
 	}
+	ctx4g := base
+	ctx4g.DestDomains = []string{"evil.com"}
+	key4g, _ := computeCacheKey(ctx4g)
+	if key1 == key4g {
+		t.Error("different dest_domains should produce different cache keys")
+	}
 
 	// Different gateway should produce different keys
 	ctx4e := base
@@ -1414,3 +1428,75 @@ func TestPolicyService_EmptyConditionDefaultsToTrue(t *testing.T) {
 		}
 	})
 }
+
+// TestPolicyService_ScopedEgressRules checks egress rules scoped by role and
+// by schedule, and that decisions of rules reading request_time are not
+// served from the cache once the clock leaves the window.
+func TestPolicyService_ScopedEgressRules(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	store := newMockPolicyStore(policy.Policy{
+		ID:      "egress",
+		Name:    "Egress",
+		Enabled: true,
+		Rules: []policy.Rule{
+			{
+				ID:        "no-tunnels-for-contractors",
+				Name:      "No tunnels for contractors",
+				Priority:  100,
+				ToolMatch: "*",
+				Condition: `"contractor" in identity_roles && dest_domains.exists(d, dest_domain_matches(d, "*.ngrok.io"))`,
+				Action:    policy.ActionDeny,
+			},
+			{
+				ID:        "s3-office-hours",
+				Name:      "S3 only during office hours",
+				Priority:  90,
+				ToolMatch: "*",
+				Condition: `dest_domains.exists(d, d == "s3.amazonaws.com") && (request_time.getDayOfWeek("UTC") in [0, 6] || !withinHours("09:00", "18:00", "UTC"))`,
+				Action:    policy.ActionDeny,
+			},
+		},
+	})
+	svc, err := NewPolicyService(context.Background(), store, logger)
+	if err != nil {
+		t.Fatalf("failed to create policy service: %v", err)
+	}
+
+	office := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	evening := time.Date(2026, 10, 16, 20, 0, 0, 0, time.UTC)
+	saturday := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		roles     []string
+		domains   []string
+		at        time.Time
+		wantAllow bool
+	}{
+		{"contractor to ngrok", []string{"contractor"}, []string{"abc.ngrok.io"}, office, false},
+		{"developer to ngrok", []string{"developer"}, []string{"abc.ngrok.io"}, office, true},
+		{"s3 during office hours", []string{"developer"}, []string{"s3.amazonaws.com"}, office, true},
+		{"s3 after hours", []string{"developer"}, []string{"s3.amazonaws.com"}, evening, false},
+		{"s3 on saturday", []string{"developer"}, []string{"s3.amazonaws.com"}, saturday, false},
+		{"other destination after hours", []string{"developer"}, []string{"example.org"}, evening, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, err := svc.Evaluate(context.Background(), policy.EvaluationContext{
+				ToolName:      "fetch",
+				ToolArguments: map[string]interface{}{},
+				UserRoles:     tt.roles,
+				IdentityID:    "id1",
+				RequestTime:   tt.at,
+				ActionType:    "tool_call",
+				DestDomain:    tt.domains[0],
+				DestDomains:   tt.domains,
+			})
+			if err != nil {
+				t.Fatalf("Evaluate failed: %v", err)
+			}
+			if decision.Allowed != tt.wantAllow {
+				t.Errorf("Allowed = %v, want %v (rule=%s)", decision.Allowed, tt.wantAllow, decision.RuleID)
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/event"
+)
+
+// Policy variable change events. The "config." prefix files them under the
+// admin event category.
+const (
+	EventPolicyVariableSet     = "config.policy_variable_set"
+	EventPolicyVariableDeleted = "config.policy_variable_deleted"
+)
+
+// maxPolicyVariableSize bounds the JSON size of one variable value.
+const maxPolicyVariableSize = 64 * 1024
+
+var (
+	// ErrPolicyVariableNotFound is returned for an unknown variable name.
+	ErrPolicyVariableNotFound = errors.New("policy variable not found")
+	// ErrInvalidPolicyVariable is returned for a bad name or value.
+	ErrInvalidPolicyVariable = errors.New("invalid policy variable")
+)
+
+// policyVariableName keeps names valid CEL field selectors, so rules can
+// write vars.name.
+var policyVariableName = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,63}$`)
+
+// PolicyVariable is a named value that rule conditions read as vars.<name>.
+type PolicyVariable struct {
+	Name        string      `json:"name"`
+	Value       interface{} `json:"value"`
+	Description string      `json:"description,omitempty"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+// PolicyVariableService manages the policy variables set from the admin
+// API. Every change is persisted to state.json, swapped into the
+// PolicyService at once and published as an admin event with the old and
+// new value.
+type PolicyVariableService struct {
+	policyService *PolicyService
+	stateStore    state.Store
+	logger        *slog.Logger
+	bus           event.Bus
+
+	mu   sync.Mutex
+	vars map[string]PolicyVariable
+}
+
+// NewPolicyVariableService creates a PolicyVariableService feeding
+// policyService. stateStore may be nil, in which case variables last until
+// restart.
+func NewPolicyVariableService(policyService *PolicyService, stateStore state.Store, logger *slog.Logger) *PolicyVariableService {
+	return &PolicyVariableService{
+		policyService: policyService,
+		stateStore:    stateStore,
+		logger:        logger,
+		vars:          make(map[string]PolicyVariable),
+	}
+}
+
+// SetEventBus publishes an event for every change.
+func (s *PolicyVariableService) SetEventBus(bus event.Bus) {
+	s.bus = bus
+}
+
+// Load replaces the variables with the persisted entries. Entries that no
+// longer decode are skipped with a warning.
+func (s *PolicyVariableService) Load(entries map[string]state.PolicyVariableEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.vars = make(map[string]PolicyVariable, len(entries))
+	for name, entry := range entries {
+		value, err := decodePolicyVariable(name, entry.Value)
+		if err != nil {
+			s.logger.Warn("skipping invalid policy variable from state", "name", name, "error", err)
+			continue
+		}
+		s.vars[name] = PolicyVariable{Name: name, Value: value, Description: entry.Description, UpdatedAt: entry.UpdatedAt}
+	}
+	s.publishLocked()
+}
+
+// List returns every variable, sorted by name.
+func (s *PolicyVariableService) List() []PolicyVariable {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]PolicyVariable, 0, len(s.vars))
+	for _, v := range s.vars {
+		out = append(out, v)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Get returns one variable.
+func (s *PolicyVariableService) Get(name string) (PolicyVariable, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.vars[name]
+	if !ok {
+		return PolicyVariable{}, ErrPolicyVariableNotFound
+	}
+	return v, nil
+}
+
+// Set creates or replaces a variable. raw is its JSON value: a boolean,
+// number, string, list or object.
+func (s *PolicyVariableService) Set(ctx context.Context, name string, raw json.RawMessage, description string) (PolicyVariable, error) {
+	value, err := decodePolicyVariable(name, raw)
+	if err != nil {
+		return PolicyVariable{}, err
+	}
+	v := PolicyVariable{Name: name, Value: value, Description: description, UpdatedAt: time.Now().UTC()}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, existed := s.vars[name]
+	if s.stateStore != nil {
+		if err := s.stateStore.Mutate(func(appState *state.AppState) error {
+			if appState.PolicyVariables == nil {
+				appState.PolicyVariables = make(map[string]state.PolicyVariableEntry)
+			}
+			appState.PolicyVariables[name] = state.PolicyVariableEntry{
+				Value:       compactJSON(raw),
+				Description: description,
+				UpdatedAt:   v.UpdatedAt,
+			}
+			return nil
+		}); err != nil {
+			return PolicyVariable{}, fmt.Errorf("persist policy variable: %w", err)
+		}
+	}
+	s.vars[name] = v
+	s.publishLocked()
+
+	payload := map[string]interface{}{"name": name, "value": value}
+	if existed {
+		payload["old_value"] = old.Value
+	}
+	s.emit(ctx, EventPolicyVariableSet, payload)
+	s.logger.Info("policy variable set", "name", name, "created", !existed)
+	return v, nil
+}
+
+// Delete removes a variable. Rules still reading it see it as absent.
+func (s *PolicyVariableService) Delete(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, ok := s.vars[name]
+	if !ok {
+		return ErrPolicyVariableNotFound
+	}
+	if s.stateStore != nil {
+		if err := s.stateStore.Mutate(func(appState *state.AppState) error {
+			delete(appState.PolicyVariables, name)
+			return nil
+		}); err != nil {
+			return fmt.Errorf("persist policy variable: %w", err)
+		}
+	}
+	delete(s.vars, name)
+	s.publishLocked()
+
+	s.emit(ctx, EventPolicyVariableDeleted, map[string]interface{}{"name": name, "old_value": old.Value})
+	s.logger.Info("policy variable deleted", "name", name)
+	return nil
+}
+
+// Reset drops every variable from memory. Used by factory reset, which
+// clears them from state.json itself.
+func (s *PolicyVariableService) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.vars = make(map[string]PolicyVariable)
+	s.publishLocked()
+}
+
+// publishLocked hands a fresh copy of the values to the PolicyService.
+func (s *PolicyVariableService) publishLocked() {
+	values := make(map[string]interface{}, len(s.vars))
+	for name, v := range s.vars {
+		values[name] = v.Value
+	}
+	s.policyService.SetVariables(values)
+}
+
+func (s *PolicyVariableService) emit(ctx context.Context, typ string, payload map[string]interface{}) {
+	if s.bus == nil {
+		return
+	}
+	s.bus.Publish(ctx, event.Event{
+		Type:      typ,
+		Source:    "policy-variables",
+		Severity:  event.SeverityInfo,
+		Payload:   payload,
+		Timestamp: time.Now().UTC(),
+	})
+}
+
+// decodePolicyVariable validates a variable and decodes its value. Whole
+// numbers become int64 so rules can compare them with int literals.
+func decodePolicyVariable(name string, raw json.RawMessage) (interface{}, error) {
+	if !policyVariableName.MatchString(name) {
+		return nil, fmt.Errorf("%w: name %q must be lowercase letters, digits and underscores, starting with a letter or underscore", ErrInvalidPolicyVariable, name)
+	}
+	if len(raw) > maxPolicyVariableSize {
+		return nil, fmt.Errorf("%w: value exceeds %d bytes", ErrInvalidPolicyVariable, maxPolicyVariableSize)
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return nil, fmt.Errorf("%w: value is not valid JSON: %v", ErrInvalidPolicyVariable, err)
+	}
+	if value == nil {
+		return nil, fmt.Errorf("%w: value must not be null", ErrInvalidPolicyVariable)
+	}
+	return normalizePolicyValue(value), nil
+}
+
+func normalizePolicyValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i
+		}
+		f, _ := val.Float64()
+		return f
+	case []interface{}:
+		for i := range val {
+			val[i] = normalizePolicyValue(val[i])
+		}
+	case map[string]interface{}:
+		for k := range val {
+			val[k] = normalizePolicyValue(val[k])
+		}
+	}
+	return v
+}
+
+func compactJSON(raw json.RawMessage) json.RawMessage {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return raw
+	}
+	return buf.Bytes()
+}
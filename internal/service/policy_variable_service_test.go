@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/event"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/policy"
+)
+
+func TestPolicyVariableService(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	stateStore := state.NewFileStateStore(filepath.Join(t.TempDir(), "state.json"), logger)
+	if err := stateStore.Save(stateStore.DefaultState()); err != nil {
+		t.Fatalf("save default state: %v", err)
+	}
+
+	store := newMockPolicyStore(policy.Policy{
+		ID:      "freeze",
+		Name:    "freeze",
+		Enabled: true,
+		Rules: []policy.Rule{
+			{ID: "deploy-freeze", Name: "deploy-freeze", Priority: 10, ToolMatch: "deploy",
+				Condition: `has(vars.prod_deploy_freeze) && vars.prod_deploy_freeze`, Action: policy.ActionDeny},
+			{ID: "bucket", Name: "bucket", Priority: 5, ToolMatch: "upload",
+				Condition: `!has(vars.allowed_buckets) || !(tool_args.bucket in vars.allowed_buckets)`, Action: policy.ActionDeny},
+		},
+	})
+	policySvc, err := NewPolicyService(context.Background(), store, logger)
+	if err != nil {
+		t.Fatalf("NewPolicyService: %v", err)
+	}
+
+	bus := event.NewBus(10)
+	bus.Start()
+	defer bus.Stop()
+	events := make(chan event.Event, 10)
+	bus.SubscribeAll(func(_ context.Context, e event.Event) { events <- e })
+
+	svc := NewPolicyVariableService(policySvc, stateStore, logger)
+	svc.SetEventBus(bus)
+	ctx := context.Background()
+
+	allowed := func(tool string, args map[string]interface{}) bool {
+		t.Helper()
+		d, err := policySvc.Evaluate(ctx, policy.EvaluationContext{ToolName: tool, ToolArguments: args})
+		if err != nil {
+			t.Fatalf("Evaluate(%s): %v", tool, err)
+		}
+		return d.Allowed
+	}
+
+	// Cache the decision before the variable exists.
+	if !allowed("deploy", nil) {
+		t.Fatal("deploy denied before the freeze is set")
+	}
+	if _, err := svc.Set(ctx, "prod_deploy_freeze", json.RawMessage(`true`), "Freeze production deploys"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if allowed("deploy", nil) {
+		t.Error("deploy allowed after the freeze is set")
+	}
+	if _, err := svc.Set(ctx, "prod_deploy_freeze", json.RawMessage(`false`), ""); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if !allowed("deploy", nil) {
+		t.Error("deploy denied after the freeze is lifted")
+	}
+
+	if _, err := svc.Set(ctx, "allowed_buckets", json.RawMessage(`["a", "b"]`), ""); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if !allowed("upload", map[string]interface{}{"bucket": "b"}) || allowed("upload", map[string]interface{}{"bucket": "c"}) {
+		t.Error("allowed_buckets not applied")
+	}
+
+	for _, name := range []string{"Bad", "1st", "with-dash"} {
+		if _, err := svc.Set(ctx, name, json.RawMessage(`1`), ""); !errors.Is(err, ErrInvalidPolicyVariable) {
+			t.Errorf("Set(%q) error = %v, want ErrInvalidPolicyVariable", name, err)
+		}
+	}
+	if _, err := svc.Set(ctx, "empty", json.RawMessage(`null`), ""); !errors.Is(err, ErrInvalidPolicyVariable) {
+		t.Errorf("Set(null) error = %v, want ErrInvalidPolicyVariable", err)
+	}
+
+	if err := svc.Delete(ctx, "allowed_buckets"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := svc.Delete(ctx, "allowed_buckets"); !errors.Is(err, ErrPolicyVariableNotFound) {
+		t.Errorf("second Delete error = %v, want ErrPolicyVariableNotFound", err)
+	}
+
+	// The first change creates the variable; later ones carry the old value.
+	var got []event.Event
+	for len(got) < 4 {
+		select {
+		case e := <-events:
+			got = append(got, e)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("got %d events, want 4", len(got))
+		}
+	}
+	if got[0].Type != EventPolicyVariableSet || got[0].Category != event.CategoryAdmin {
+		t.Errorf("first event = %s/%s", got[0].Type, got[0].Category)
+	}
+	if p := got[0].Payload.(map[string]interface{}); p["value"] != true || p["old_value"] != nil {
+		t.Errorf("first event payload = %v", p)
+	}
+	if p := got[1].Payload.(map[string]interface{}); p["value"] != false || p["old_value"] != true {
+		t.Errorf("second event payload = %v", p)
+	}
+	if got[3].Type != EventPolicyVariableDeleted {
+		t.Errorf("last event = %s, want %s", got[3].Type, EventPolicyVariableDeleted)
+	}
+
+	// A fresh service restores the persisted variables.
+	appState, err := stateStore.Load()
+	if err != nil {
+		t.Fatalf("Load state: %v", err)
+	}
+	restored := NewPolicyVariableService(policySvc, stateStore, logger)
+	restored.Load(appState.PolicyVariables)
+	list := restored.List()
+	if len(list) != 1 || list[0].Name != "prod_deploy_freeze" || list[0].Value != false {
+		t.Errorf("restored variables = %+v", list)
+	}
+}
+
+func TestDecodePolicyVariable_Numbers(t *testing.T) {
+	v, err := decodePolicyVariable("limits", json.RawMessage(`{"rows": 100, "ratio": 0.5, "tiers": [1, 2]}`))
+	if err != nil {
+		t.Fatalf("decodePolicyVariable: %v", err)
+	}
+	m := v.(map[string]interface{})
+	if m["rows"] != int64(100) || m["ratio"] != 0.5 || m["tiers"].([]interface{})[1] != int64(2) {
+		t.Errorf("decoded = %#v", m)
+	}
+}
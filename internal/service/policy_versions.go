@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/memory"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/policy"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/proxy"
+)
+
+// ErrPolicyVersionNotFound is returned when rolling back to a version that
+// is not in the policy's history.
+var ErrPolicyVersionNotFound = errors.New("policy version not found")
+
+// maxPolicyVersions bounds the history kept per policy. The oldest versions
+// are dropped first.
+const maxPolicyVersions = 50
+
+// Kinds of policy change recorded in the history.
+const (
+	PolicyChangeInitial  = "initial"
+	PolicyChangeCreate   = "create"
+	PolicyChangeUpdate   = "update"
+	PolicyChangeDelete   = "delete"
+	PolicyChangeRollback = "rollback"
+)
+
+// PolicyVersion is one version of a policy in its change history.
+type PolicyVersion struct {
+	Version         int
+	Change          string
+	RestoredVersion int
+	ChangedBy       string
+	ChangedAt       time.Time
+	Policy          policy.Policy
+}
+
+// actorContextKey carries who makes a change in the request context.
+type actorContextKey struct{}
+
+// WithActor returns a context recording actor as the author of the changes
+// made with it, e.g. the admin API caller.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// ActorFromContext returns the actor set by WithActor, or "".
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorContextKey{}).(string)
+	return actor
+}
+
+// SetAuditRecorder sets the recorder for policy changes. Every change
+// recorded in the history is also written to the audit log.
+func (s *PolicyAdminService) SetAuditRecorder(r proxy.AuditRecorder) {
+	s.recorder = r
+}
+
+// Versions returns the change history of a policy, oldest first. A policy
+// that was never changed since versioning started has an empty history.
+// Returns ErrPolicyNotFound if the policy neither exists nor has a history.
+func (s *PolicyAdminService) Versions(ctx context.Context, id string) ([]PolicyVersion, error) {
+	s.mu.Lock()
+	history := s.versions[id]
+	s.mu.Unlock()
+	if len(history) == 0 {
+		if _, err := s.Get(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	out := make([]PolicyVersion, len(history))
+	for i, v := range history {
+		out[i] = PolicyVersion{
+			Version:         v.Version,
+			Change:          v.Change,
+			RestoredVersion: v.RestoredVersion,
+			ChangedBy:       v.ChangedBy,
+			ChangedAt:       v.ChangedAt,
+			Policy:          *policyFromVersion(id, v),
+		}
+	}
+	return out, nil
+}
+
+// Rollback restores a policy as it was at version, recording the restore as
+// a new version. A deleted policy can be restored from its history.
+// Returns ErrPolicyVersionNotFound if the version is not in the history.
+func (s *PolicyAdminService) Rollback(ctx context.Context, id string, version int) (*policy.Policy, error) {
+	existing, err := s.store.GetPolicyWithRules(ctx, id)
+	if err != nil && !errors.Is(err, memory.ErrPolicyNotFound) {
+		return nil, fmt.Errorf("get existing policy: %w", err)
+	}
+	if existing != nil && IsFilePolicy(existing) {
+		return nil, ErrFilePolicy
+	}
+
+	s.mu.Lock()
+	var target *state.PolicyVersionEntry
+	for i, v := range s.versions[id] {
+		if v.Version == version {
+			target = &s.versions[id][i]
+			break
+		}
+	}
+	if target == nil {
+		s.mu.Unlock()
+		return nil, ErrPolicyVersionNotFound
+	}
+	p := policyFromVersion(id, *target)
+	s.mu.Unlock()
+
+	p.UpdatedAt = time.Now().UTC()
+	if existing != nil {
+		p.CreatedAt = existing.CreatedAt
+	} else {
+		p.CreatedAt = p.UpdatedAt
+	}
+	if err := s.policyService.ValidateRules(p.Rules); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPolicy, err)
+	}
+
+	s.mu.Lock()
+	newVersion, undo := s.recordVersionLocked(ctx, existing, p, PolicyChangeRollback, version)
+	if err := s.store.SavePolicy(ctx, p); err != nil {
+		undo()
+		s.mu.Unlock()
+		return nil, fmt.Errorf("save policy: %w", err)
+	}
+	if err := s.persistStateLocked(ctx); err != nil {
+		s.logger.Error("policy persistence failed, rolling back in-memory rollback", "policy_id", id, "error", err)
+		undo()
+		var rbErr error
+		if existing != nil {
+			rbErr = s.store.SavePolicy(ctx, existing)
+		} else {
+			rbErr = s.store.DeletePolicy(ctx, id)
+		}
+		if rbErr != nil {
+			s.logger.Error("CRITICAL: rollback failed after persist error, in-memory state may be inconsistent", "policy_id", id, "rollback_error", rbErr)
+		}
+		s.mu.Unlock()
+		return nil, fmt.Errorf("persist policy: %w", err)
+	}
+	s.mu.Unlock()
+
+	if err := s.policyService.Reload(ctx); err != nil {
+		s.logger.Error("failed to reload policies after rollback", "policy_id", id, "error", err)
+		return nil, fmt.Errorf("reload policies: %w", err)
+	}
+
+	s.logger.Info("policy rolled back", "id", id, "name", p.Name, "restored_version", version, "version", newVersion)
+	s.auditChange(ctx, p, PolicyChangeRollback, newVersion, version)
+
+	return s.store.GetPolicyWithRules(ctx, id)
+}
+
+// loadVersions restores the change history persisted in state.json.
+func (s *PolicyAdminService) loadVersions(versions map[string][]state.PolicyVersionEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.versions = make(map[string][]state.PolicyVersionEntry, len(versions))
+	for id, history := range versions {
+		s.versions[id] = history
+	}
+}
+
+// recordVersionLocked appends after to the history of its policy as a new
+// version and returns the version with a function undoing the append, for
+// when persisting fails. before is the policy being replaced, recorded
+// first as the initial version when the policy has no history yet.
+// Caller must hold s.mu.
+func (s *PolicyAdminService) recordVersionLocked(ctx context.Context, before, after *policy.Policy, change string, restored int) (int, func()) {
+	id := after.ID
+	prev, hadHistory := s.versions[id]
+	undo := func() {
+		if hadHistory {
+			s.versions[id] = prev
+		} else {
+			delete(s.versions, id)
+		}
+	}
+
+	history := prev
+	now := time.Now().UTC()
+	if len(history) == 0 && before != nil {
+		history = append(history, versionEntry(before, 1, PolicyChangeInitial, "", before.UpdatedAt))
+	}
+	version := 1
+	if len(history) > 0 {
+		version = history[len(history)-1].Version + 1
+	}
+	entry := versionEntry(after, version, change, ActorFromContext(ctx), now)
+	entry.RestoredVersion = restored
+	// Copy on append so the undo keeps the previous history intact.
+	next := make([]state.PolicyVersionEntry, 0, len(history)+1)
+	next = append(next, history...)
+	next = append(next, entry)
+	if len(next) > maxPolicyVersions {
+		next = next[len(next)-maxPolicyVersions:]
+	}
+	s.versions[id] = next
+	return version, undo
+}
+
+// versionEntry converts p to a history entry.
+func versionEntry(p *policy.Policy, version int, change, actor string, at time.Time) state.PolicyVersionEntry {
+	rules := make([]state.PolicyEntry, len(p.Rules))
+	for i, r := range p.Rules {
+		rules[i] = ruleEntry(p, r)
+	}
+	return state.PolicyVersionEntry{
+		Version:     version,
+		Change:      change,
+		ChangedBy:   actor,
+		ChangedAt:   at,
+		Name:        p.Name,
+		Description: p.Description,
+		Priority:    p.Priority,
+		Enabled:     p.Enabled,
+		Rules:       rules,
+	}
+}
+
+// policyFromVersion converts a history entry back to the policy id.
+func policyFromVersion(id string, v state.PolicyVersionEntry) *policy.Policy {
+	rules := make([]policy.Rule, len(v.Rules))
+	for i, e := range v.Rules {
+		rules[i] = ruleFromEntry(e)
+	}
+	return &policy.Policy{
+		ID:          id,
+		Name:        v.Name,
+		Description: v.Description,
+		Priority:    v.Priority,
+		Enabled:     v.Enabled,
+		Rules:       rules,
+		UpdatedAt:   v.ChangedAt,
+	}
+}
+
+// auditChange writes a policy change to the audit log.
+func (s *PolicyAdminService) auditChange(ctx context.Context, p *policy.Policy, change string, version, restored int) {
+	if s.recorder == nil {
+		return
+	}
+	args := map[string]interface{}{
+		"policy_id":   p.ID,
+		"policy_name": p.Name,
+		"version":     version,
+	}
+	if restored > 0 {
+		args["restored_version"] = restored
+	}
+	actor := ActorFromContext(ctx)
+	if actor == "" {
+		actor = "system"
+	}
+	s.recorder.Record(audit.AuditRecord{
+		Timestamp:     time.Now().UTC(),
+		IdentityID:    actor,
+		IdentityName:  actor,
+		ToolName:      "policy." + change,
+		ToolArguments: args,
+		Decision:      audit.DecisionAllow,
+		Reason:        fmt.Sprintf("policy %q: %s, version %d", p.Name, change, version),
+		Protocol:      "admin",
+		Source:        "admin_policy",
+	})
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/policy"
+)
+
+func TestPolicyAdminService_VersionsAndRollback(t *testing.T) {
+	svc, _, _, statePath := testPolicyAdminEnv(t)
+	recorder := &noticeAuditRecorder{}
+	svc.SetAuditRecorder(recorder)
+	ctx := WithActor(context.Background(), "admin@127.0.0.1")
+
+	created, err := svc.Create(ctx, &policy.Policy{
+		Name:    "Versioned",
+		Enabled: true,
+		Rules: []policy.Rule{
+			{Name: "allow-read", Priority: 100, ToolMatch: "read_*", Condition: "true", Action: policy.ActionAllow},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Create(): %v", err)
+	}
+	if _, err := svc.Update(ctx, created.ID, &policy.Policy{
+		Name:    "Versioned",
+		Enabled: true,
+		Rules: []policy.Rule{
+			{Name: "deny-read", Priority: 100, ToolMatch: "read_*", Condition: "true", Action: policy.ActionDeny},
+		},
+	}); err != nil {
+		t.Fatalf("Update(): %v", err)
+	}
+
+	versions, err := svc.Versions(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("Versions(): %v", err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("Versions() = %d entries, want 2", len(versions))
+	}
+	if versions[0].Change != PolicyChangeCreate || versions[1].Change != PolicyChangeUpdate {
+		t.Errorf("changes = %q, %q", versions[0].Change, versions[1].Change)
+	}
+	if versions[1].ChangedBy != "admin@127.0.0.1" {
+		t.Errorf("ChangedBy = %q", versions[1].ChangedBy)
+	}
+
+	restored, err := svc.Rollback(ctx, created.ID, 1)
+	if err != nil {
+		t.Fatalf("Rollback(): %v", err)
+	}
+	if len(restored.Rules) != 1 || restored.Rules[0].Action != policy.ActionAllow {
+		t.Errorf("restored rules = %+v, want the allow rule of version 1", restored.Rules)
+	}
+	versions, _ = svc.Versions(ctx, created.ID)
+	if last := versions[len(versions)-1]; last.Version != 3 || last.Change != PolicyChangeRollback || last.RestoredVersion != 1 {
+		t.Errorf("last version = %+v, want version 3 restoring 1", last)
+	}
+
+	if _, err := svc.Rollback(ctx, created.ID, 42); !errors.Is(err, ErrPolicyVersionNotFound) {
+		t.Errorf("Rollback() unknown version error = %v, want ErrPolicyVersionNotFound", err)
+	}
+
+	if len(recorder.records) != 3 {
+		t.Fatalf("audit records = %d, want 3", len(recorder.records))
+	}
+	rec := recorder.records[2]
+	if rec.ToolName != "policy.rollback" || rec.IdentityID != "admin@127.0.0.1" || rec.Source != "admin_policy" {
+		t.Errorf("audit record = %+v", rec)
+	}
+
+	// The history survives a restart.
+	appState, err := state.NewFileStateStore(statePath, slog.Default()).Load()
+	if err != nil {
+		t.Fatalf("Load state: %v", err)
+	}
+	if len(appState.PolicyVersions[created.ID]) != 3 {
+		t.Fatalf("persisted versions = %d, want 3", len(appState.PolicyVersions[created.ID]))
+	}
+	reloaded, _, _, _ := testPolicyAdminEnv(t)
+	if err := reloaded.LoadPoliciesFromState(ctx, appState); err != nil {
+		t.Fatalf("LoadPoliciesFromState(): %v", err)
+	}
+	if versions, err := reloaded.Versions(ctx, created.ID); err != nil || len(versions) != 3 {
+		t.Errorf("reloaded Versions() = %d entries, %v; want 3", len(versions), err)
+	}
+}
+
+func TestPolicyAdminService_RollbackDeleted(t *testing.T) {
+	svc, _, _, _ := testPolicyAdminEnv(t)
+	ctx := context.Background()
+
+	created, err := svc.Create(ctx, &policy.Policy{
+		Name:    "Short-lived",
+		Enabled: true,
+		Rules: []policy.Rule{
+			{Name: "allow-read", Priority: 100, ToolMatch: "read_*", Condition: "true", Action: policy.ActionAllow},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Create(): %v", err)
+	}
+	if err := svc.Delete(ctx, created.ID); err != nil {
+		t.Fatalf("Delete(): %v", err)
+	}
+
+	versions, err := svc.Versions(ctx, created.ID)
+	if err != nil || len(versions) != 2 || versions[1].Change != PolicyChangeDelete {
+		t.Fatalf("Versions() after delete = %+v, %v", versions, err)
+	}
+	if _, err := svc.Rollback(ctx, created.ID, 1); err != nil {
+		t.Fatalf("Rollback(): %v", err)
+	}
+	if p, err := svc.Get(ctx, created.ID); err != nil || p.Name != "Short-lived" {
+		t.Errorf("Get() after rollback = %v, %v", p, err)
+	}
+}
+
+func TestPolicyAdminService_VersionsRecordInitial(t *testing.T) {
+	svc, _, _, _ := testPolicyAdminEnv(t)
+	ctx := context.Background()
+
+	// The seeded default policy has no history until it is first changed.
+	versions, err := svc.Versions(ctx, "default-policy-id")
+	if err != nil || len(versions) != 0 {
+		t.Fatalf("Versions() = %+v, %v; want an empty history", versions, err)
+	}
+	if _, err := svc.Versions(ctx, "missing"); !errors.Is(err, ErrPolicyNotFound) {
+		t.Errorf("Versions() missing policy error = %v, want ErrPolicyNotFound", err)
+	}
+
+	if _, err := svc.Update(ctx, "default-policy-id", &policy.Policy{
+		Name:    "Default RBAC Policy",
+		Enabled: true,
+		Rules: []policy.Rule{
+			{Name: "deny-all", Priority: 0, ToolMatch: "*", Condition: "true", Action: policy.ActionDeny},
+		},
+	}); err != nil {
+		t.Fatalf("Update(): %v", err)
+	}
+	versions, _ = svc.Versions(ctx, "default-policy-id")
+	if len(versions) != 2 || versions[0].Change != PolicyChangeInitial || versions[0].ChangedBy != "" {
+		t.Fatalf("Versions() = %+v, want the initial version then the update", versions)
+	}
+	if len(versions[0].Policy.Rules) == len(versions[1].Policy.Rules) && versions[0].Policy.Rules[0].Name == "deny-all" {
+		t.Error("initial version recorded the updated rules")
+	}
+}
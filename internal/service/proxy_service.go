@@ -264,13 +264,14 @@ func (p *ProxyService) copyMessages(ctx context.Context, src io.Reader, dst io.W
 			writeTo = clientOut
 		}
 
-		// Write message followed by newline
-		if _, err := writeTo.Write(processedMsg.Raw); err != nil {
+		// Write message followed by newline in a single call, so a concurrent
+		// writer on the same stream (e.g. a stdio notification) cannot land
+		// between them.
+		line := make([]byte, 0, len(processedMsg.Raw)+1)
+		line = append(append(line, processedMsg.Raw...), '\n')
+		if _, err := writeTo.Write(line); err != nil {
 			return fmt.Errorf("write failed: %w", err)
 		}
-		if _, err := writeTo.Write([]byte("\n")); err != nil {
-			return fmt.Errorf("write newline failed: %w", err)
-		}
 
 		// Log latency
 		latency := time.Since(startTime)
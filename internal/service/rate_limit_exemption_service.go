@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/action"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/policy"
+)
+
+// RateLimitExemption is an exempt_rate_limit rule as listed by the admin API,
+// with its usage and the session and identity exemptions it granted.
+type RateLimitExemption struct {
+	RuleID   string             `json:"rule_id"`
+	RuleName string             `json:"rule_name"`
+	Priority int                `json:"priority"`
+	Scope    policy.ExemptScope `json:"scope"`
+	// Duration is "" for call exemptions.
+	Duration       string     `json:"duration,omitempty"`
+	ExemptedCalls  int64      `json:"exempted_calls"`
+	LastExemptedAt *time.Time `json:"last_exempted_at,omitempty"`
+	// Active are the unexpired session or identity exemptions.
+	Active []RateLimitExemptionGrant `json:"active"`
+}
+
+// RateLimitExemptionGrant is a session or identity exempted from the user
+// rate limit until ExpiresAt.
+type RateLimitExemptionGrant struct {
+	Scope         policy.ExemptScope `json:"scope"`
+	IdentityID    string             `json:"identity_id"`
+	IdentityName  string             `json:"identity_name,omitempty"`
+	SessionID     string             `json:"session_id,omitempty"`
+	GrantedAt     time.Time          `json:"granted_at"`
+	ExpiresAt     time.Time          `json:"expires_at"`
+	ExemptedCalls int64              `json:"exempted_calls"`
+}
+
+// rateLimitExemptionGrant is a grant with the rule that made it.
+type rateLimitExemptionGrant struct {
+	RateLimitExemptionGrant
+	ruleID string
+}
+
+// exemptionUsage counts the calls a rule exempted.
+type exemptionUsage struct {
+	calls int64
+	last  time.Time
+}
+
+// RateLimitExemptionService decides which calls bypass the user rate limit,
+// from the exempt_rate_limit policy rules. A rule with scope "call" exempts
+// the matching call only; one with scope "session" or "identity" exempts
+// every later call of the session or identity until its duration is over,
+// without evaluating the rules again. Grants are kept in memory only.
+type RateLimitExemptionService struct {
+	policies *PolicyService
+	logger   *slog.Logger
+	now      func() time.Time
+
+	mu     sync.Mutex
+	grants map[string]*rateLimitExemptionGrant // by grantKey
+	usage  map[string]*exemptionUsage          // by rule ID
+}
+
+// Compile-time check that RateLimitExemptionService implements action.RateLimitExempter.
+var _ action.RateLimitExempter = (*RateLimitExemptionService)(nil)
+
+// NewRateLimitExemptionService creates a RateLimitExemptionService
+// evaluating the exempt_rate_limit rules loaded in policies.
+func NewRateLimitExemptionService(policies *PolicyService, logger *slog.Logger) *RateLimitExemptionService {
+	return &RateLimitExemptionService{
+		policies: policies,
+		logger:   logger,
+		now:      time.Now,
+		grants:   make(map[string]*rateLimitExemptionGrant),
+		usage:    make(map[string]*exemptionUsage),
+	}
+}
+
+// Exempt reports whether act bypasses the user rate limit. Calls of an
+// exempted session or identity are exempt while the rule that granted it is
+// still loaded; other calls are evaluated against the exempt_rate_limit
+// rules. A rule evaluation error never exempts the call.
+func (s *RateLimitExemptionService) Exempt(ctx context.Context, act *action.CanonicalAction) bool {
+	rules := s.policies.ExemptionRules()
+	if len(rules) == 0 || act.Identity.ID == "" {
+		return false
+	}
+	now := s.now()
+
+	s.mu.Lock()
+	for _, key := range []string{
+		grantKey(policy.ExemptScopeIdentity, act.Identity.ID),
+		grantKey(policy.ExemptScopeSession, act.Identity.SessionID),
+	} {
+		g := s.grants[key]
+		if g == nil {
+			continue
+		}
+		if !now.Before(g.ExpiresAt) || findCompiledRule(rules, g.ruleID) == nil {
+			delete(s.grants, key)
+			continue
+		}
+		g.ExemptedCalls++
+		s.countLocked(g.ruleID, now)
+		s.mu.Unlock()
+		return true
+	}
+	s.mu.Unlock()
+
+	rule, err := s.policies.MatchExemption(ctx, exemptionEvalContext(act))
+	if err != nil {
+		s.logger.Warn("rate limit exemption evaluation failed",
+			"tool", act.Name,
+			"identity_id", act.Identity.ID,
+			"error", err,
+		)
+		return false
+	}
+	if rule == nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.countLocked(rule.ID, now)
+	scope := rule.ExemptScope
+	if scope != policy.ExemptScopeSession && scope != policy.ExemptScopeIdentity {
+		return true
+	}
+	if scope == policy.ExemptScopeSession && act.Identity.SessionID == "" {
+		return true
+	}
+	duration := rule.ExemptDuration
+	if duration <= 0 {
+		duration = policy.DefaultExemptDuration
+	}
+	g := &rateLimitExemptionGrant{
+		RateLimitExemptionGrant: RateLimitExemptionGrant{
+			Scope:         scope,
+			IdentityID:    act.Identity.ID,
+			IdentityName:  act.Identity.Name,
+			GrantedAt:     now,
+			ExpiresAt:     now.Add(duration),
+			ExemptedCalls: 1,
+		},
+		ruleID: rule.ID,
+	}
+	subject := act.Identity.ID
+	if scope == policy.ExemptScopeSession {
+		g.SessionID = act.Identity.SessionID
+		subject = act.Identity.SessionID
+	}
+	s.pruneLocked(now)
+	s.grants[grantKey(scope, subject)] = g
+	s.logger.Info("rate limit exemption granted",
+		"rule", rule.Name,
+		"scope", scope,
+		"identity_id", act.Identity.ID,
+		"session_id", g.SessionID,
+		"expires_at", g.ExpiresAt,
+	)
+	return true
+}
+
+// List returns every loaded exempt_rate_limit rule, highest priority first,
+// with its active grants sorted by expiry.
+func (s *RateLimitExemptionService) List() []RateLimitExemption {
+	rules := s.policies.ExemptionRules()
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneLocked(now)
+	list := make([]RateLimitExemption, 0, len(rules))
+	for _, rule := range rules {
+		e := RateLimitExemption{
+			RuleID:   rule.ID,
+			RuleName: rule.Name,
+			Priority: rule.Priority,
+			Scope:    rule.ExemptScope,
+			Active:   []RateLimitExemptionGrant{},
+		}
+		if e.Scope == "" {
+			e.Scope = policy.ExemptScopeCall
+		}
+		if e.Scope != policy.ExemptScopeCall {
+			d := rule.ExemptDuration
+			if d <= 0 {
+				d = policy.DefaultExemptDuration
+			}
+			e.Duration = d.String()
+		}
+		if u := s.usage[rule.ID]; u != nil {
+			e.ExemptedCalls = u.calls
+			last := u.last
+			e.LastExemptedAt = &last
+		}
+		for _, g := range s.grants {
+			if g.ruleID == rule.ID {
+				e.Active = append(e.Active, g.RateLimitExemptionGrant)
+			}
+		}
+		sort.Slice(e.Active, func(i, j int) bool {
+			return e.Active[i].ExpiresAt.Before(e.Active[j].ExpiresAt)
+		})
+		list = append(list, e)
+	}
+	return list
+}
+
+// Reset drops every grant and usage counter (factory reset).
+func (s *RateLimitExemptionService) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.grants = make(map[string]*rateLimitExemptionGrant)
+	s.usage = make(map[string]*exemptionUsage)
+}
+
+func (s *RateLimitExemptionService) countLocked(ruleID string, now time.Time) {
+	u := s.usage[ruleID]
+	if u == nil {
+		u = &exemptionUsage{}
+		s.usage[ruleID] = u
+	}
+	u.calls++
+	u.last = now
+}
+
+// pruneLocked drops the expired grants.
+func (s *RateLimitExemptionService) pruneLocked(now time.Time) {
+	for key, g := range s.grants {
+		if !now.Before(g.ExpiresAt) {
+			delete(s.grants, key)
+		}
+	}
+}
+
+func grantKey(scope policy.ExemptScope, subject string) string {
+	if subject == "" {
+		return ""
+	}
+	return string(scope) + ":" + subject
+}
+
+func findCompiledRule(rules []CompiledRule, id string) *CompiledRule {
+	for i := range rules {
+		if rules[i].ID == id {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// exemptionEvalContext builds the context exempt_rate_limit rules are
+// evaluated in. The rate limit runs before policy evaluation, so session
+// usage, history and health variables are not populated.
+func exemptionEvalContext(act *action.CanonicalAction) policy.EvaluationContext {
+	return policy.EvaluationContext{
+		ToolName:      act.Name,
+		ToolArguments: act.Arguments,
+		UserRoles:     act.Identity.Roles,
+		SessionID:     act.Identity.SessionID,
+		IdentityID:    act.Identity.ID,
+		IdentityName:  act.Identity.Name,
+		RequestTime:   act.RequestTime,
+		ActionType:    string(act.Type),
+		ActionName:    act.Name,
+		Protocol:      act.Protocol,
+		Gateway:       act.Gateway,
+		Framework:     act.Framework,
+		DestURL:       act.Destination.URL,
+		DestDomain:    act.Destination.Domain,
+		DestIP:        act.Destination.IP,
+		DestPort:      act.Destination.Port,
+		DestScheme:    act.Destination.Scheme,
+		DestPath:      act.Destination.Path,
+		DestCommand:   act.Destination.Command,
+		DestURLs:      act.Destination.URLs,
+		DestDomains:   act.Destination.Domains,
+	}
+}
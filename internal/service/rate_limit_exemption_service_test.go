@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/action"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/policy"
+)
+
+func TestRateLimitExemptionService(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	store := newMockPolicyStore(policy.Policy{
+		ID:      "backup",
+		Name:    "backup",
+		Enabled: true,
+		Rules: []policy.Rule{
+			{ID: "backup-reads", Name: "backup-reads", Priority: 20, ToolMatch: "read_*",
+				Condition: `identity_name == "backup-agent"`, Action: policy.ActionExemptRateLimit},
+			{ID: "nightly", Name: "nightly", Priority: 10, ToolMatch: "start_backup",
+				Condition: "true", Action: policy.ActionExemptRateLimit,
+				ExemptScope: policy.ExemptScopeIdentity, ExemptDuration: 2 * time.Hour},
+			{ID: "no-reads", Name: "no-reads", Priority: 5, ToolMatch: "read_*",
+				Condition: "true", Action: policy.ActionDeny},
+		},
+	})
+	policySvc, err := NewPolicyService(context.Background(), store, logger)
+	if err != nil {
+		t.Fatalf("NewPolicyService: %v", err)
+	}
+	svc := NewRateLimitExemptionService(policySvc, logger)
+	now := time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	call := func(identity, tool string) *action.CanonicalAction {
+		return &action.CanonicalAction{
+			Type:     action.ActionToolCall,
+			Name:     tool,
+			Identity: action.ActionIdentity{ID: "id-" + identity, Name: identity, SessionID: "sess-" + identity},
+		}
+	}
+
+	// Exempt rules never decide a call: the deny rule still matches.
+	d, err := policySvc.Evaluate(ctx, policy.EvaluationContext{ToolName: "read_file", IdentityName: "backup-agent"})
+	if err != nil || d.Allowed || d.RuleID != "no-reads" {
+		t.Fatalf("Evaluate() = %+v, %v; want a denial by no-reads", d, err)
+	}
+
+	if !svc.Exempt(ctx, call("backup-agent", "read_file")) {
+		t.Error("backup-agent read_file should be exempt")
+	}
+	if svc.Exempt(ctx, call("alice", "read_file")) {
+		t.Error("alice read_file should not be exempt")
+	}
+	// A call exemption covers the matching call only.
+	if svc.Exempt(ctx, call("backup-agent", "write_file")) {
+		t.Error("backup-agent write_file should not be exempt")
+	}
+
+	// An identity exemption covers every call of the identity until it expires.
+	if !svc.Exempt(ctx, call("bob", "start_backup")) {
+		t.Fatal("bob start_backup should be exempt")
+	}
+	now = now.Add(time.Hour)
+	if !svc.Exempt(ctx, call("bob", "write_file")) {
+		t.Error("bob write_file should be exempt during the grant")
+	}
+
+	list := svc.List()
+	if len(list) != 2 || list[0].RuleID != "backup-reads" || list[1].RuleID != "nightly" {
+		t.Fatalf("List() = %+v", list)
+	}
+	if list[0].Scope != policy.ExemptScopeCall || list[0].ExemptedCalls != 1 || len(list[0].Active) != 0 {
+		t.Errorf("backup-reads = %+v", list[0])
+	}
+	nightly := list[1]
+	if nightly.Duration != "2h0m0s" || nightly.ExemptedCalls != 2 || len(nightly.Active) != 1 {
+		t.Fatalf("nightly = %+v", nightly)
+	}
+	if g := nightly.Active[0]; g.IdentityID != "id-bob" || g.ExemptedCalls != 2 || !g.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("grant = %+v", g)
+	}
+
+	now = now.Add(time.Hour)
+	if svc.Exempt(ctx, call("bob", "write_file")) {
+		t.Error("bob write_file should not be exempt after the grant expired")
+	}
+	if got := svc.List()[1].Active; len(got) != 0 {
+		t.Errorf("Active after expiry = %+v", got)
+	}
+
+	// Removing the rule ends its grants at once.
+	if !svc.Exempt(ctx, call("bob", "start_backup")) {
+		t.Fatal("bob start_backup should be exempt")
+	}
+	store.mu.Lock()
+	store.policies[0].Rules = store.policies[0].Rules[:1]
+	store.mu.Unlock()
+	if err := policySvc.Reload(ctx); err != nil {
+		t.Fatalf("Reload(): %v", err)
+	}
+	if svc.Exempt(ctx, call("bob", "write_file")) {
+		t.Error("bob write_file should not be exempt once the rule is removed")
+	}
+
+	svc.Reset()
+	if list := svc.List(); len(list) != 1 || list[0].ExemptedCalls != 0 {
+		t.Errorf("List() after Reset() = %+v", list)
+	}
+}
+
+func TestValidateExemption(t *testing.T) {
+	tests := []struct {
+		action   policy.Action
+		scope    policy.ExemptScope
+		duration time.Duration
+		wantErr  bool
+	}{
+		{policy.ActionExemptRateLimit, "", 0, false},
+		{policy.ActionExemptRateLimit, policy.ExemptScopeSession, 0, false},
+		{policy.ActionExemptRateLimit, policy.ExemptScopeIdentity, 8 * time.Hour, false},
+		{policy.ActionExemptRateLimit, policy.ExemptScopeCall, time.Hour, true},
+		{policy.ActionExemptRateLimit, "tenant", 0, true},
+		{policy.ActionAllow, policy.ExemptScopeSession, 0, true},
+		{policy.ActionDeny, "", time.Hour, true},
+	}
+	for _, tt := range tests {
+		err := policy.ValidateExemption(tt.action, tt.scope, tt.duration)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateExemption(%s, %q, %s) error = %v, wantErr %v", tt.action, tt.scope, tt.duration, err, tt.wantErr)
+		}
+	}
+}
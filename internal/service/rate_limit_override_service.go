@@ -0,0 +1,333 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/event"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/ratelimit"
+	"github.com/google/uuid"
+)
+
+// Rate limit override change events. The "config." prefix files them under
+// the admin event category.
+const (
+	EventRateLimitOverrideSet     = "config.rate_limit_override_set"
+	EventRateLimitOverrideDeleted = "config.rate_limit_override_deleted"
+)
+
+// maxRateLimitOverrides bounds the overrides created from the admin API.
+const maxRateLimitOverrides = 1000
+
+var (
+	// ErrRateLimitOverrideNotFound is returned for an unknown override ID.
+	ErrRateLimitOverrideNotFound = errors.New("rate limit override not found")
+	// ErrRateLimitOverrideConflict is returned when another override already
+	// covers the same identity and tool.
+	ErrRateLimitOverrideConflict = errors.New("rate limit override already exists for this identity and tool")
+)
+
+// RateLimitOverride is an override as listed by the admin API.
+type RateLimitOverride struct {
+	ratelimit.Override
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// RateLimitOverrideService manages the per-identity and per-tool rate limit
+// overrides. Overrides from the YAML config are read-only; those created
+// from the admin API are persisted to state.json. Every change hands the
+// merged set to the user rate limit interceptor at once.
+type RateLimitOverrideService struct {
+	stateStore state.Store
+	logger     *slog.Logger
+	bus        event.Bus
+
+	mu       sync.Mutex
+	config   []RateLimitOverride // from YAML, read-only
+	managed  []RateLimitOverride // from the admin API, in creation order
+	onChange func(*ratelimit.Overrides)
+}
+
+// NewRateLimitOverrideService creates a RateLimitOverrideService.
+// stateStore may be nil, in which case admin overrides last until restart.
+func NewRateLimitOverrideService(stateStore state.Store, logger *slog.Logger) *RateLimitOverrideService {
+	return &RateLimitOverrideService{stateStore: stateStore, logger: logger}
+}
+
+// SetEventBus publishes an event for every change.
+func (s *RateLimitOverrideService) SetEventBus(bus event.Bus) {
+	s.bus = bus
+}
+
+// OnChange registers the consumer of the merged overrides and hands it the
+// current set.
+func (s *RateLimitOverrideService) OnChange(fn func(*ratelimit.Overrides)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onChange = fn
+	s.publishLocked()
+}
+
+// SetConfigOverrides sets the read-only overrides from the YAML config.
+// Their IDs are derived from their position, so they stay stable across
+// restarts with the same config.
+func (s *RateLimitOverrideService) SetConfigOverrides(list []ratelimit.Override) error {
+	config := make([]RateLimitOverride, 0, len(list))
+	for i, o := range list {
+		o.ID = fmt.Sprintf("config-%d", i+1)
+		o.ReadOnly = true
+		if err := o.Validate(); err != nil {
+			return fmt.Errorf("rate_limit.overrides[%d]: %w", i, err)
+		}
+		config = append(config, RateLimitOverride{Override: o})
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config = config
+	s.publishLocked()
+	return nil
+}
+
+// Load replaces the admin overrides with the persisted entries. Entries
+// that no longer validate are skipped with a warning.
+func (s *RateLimitOverrideService) Load(entries []state.RateLimitOverrideEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.managed = make([]RateLimitOverride, 0, len(entries))
+	for _, e := range entries {
+		o := RateLimitOverride{
+			Override:  ratelimit.Override{ID: e.ID, Identity: e.Identity, Tool: e.Tool, Rate: e.Rate, Burst: e.Burst},
+			CreatedAt: e.CreatedAt,
+			UpdatedAt: e.UpdatedAt,
+		}
+		if err := o.Validate(); err != nil || o.ID == "" {
+			s.logger.Warn("skipping invalid rate limit override from state", "id", e.ID, "error", err)
+			continue
+		}
+		s.managed = append(s.managed, o)
+	}
+	s.publishLocked()
+}
+
+// List returns the config overrides followed by the admin overrides.
+func (s *RateLimitOverrideService) List() []RateLimitOverride {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]RateLimitOverride, 0, len(s.config)+len(s.managed))
+	out = append(out, s.config...)
+	return append(out, s.managed...)
+}
+
+// Get returns one override.
+func (s *RateLimitOverrideService) Get(id string) (RateLimitOverride, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i := indexOverride(s.config, id); i >= 0 {
+		return s.config[i], nil
+	}
+	if i := indexOverride(s.managed, id); i >= 0 {
+		return s.managed[i], nil
+	}
+	return RateLimitOverride{}, ErrRateLimitOverrideNotFound
+}
+
+// Create adds an override. At most one override may cover the same
+// identity and tool.
+func (s *RateLimitOverrideService) Create(ctx context.Context, o ratelimit.Override) (RateLimitOverride, error) {
+	o = normalizeOverride(o)
+	if err := o.Validate(); err != nil {
+		return RateLimitOverride{}, err
+	}
+	now := time.Now().UTC()
+	o.ID = uuid.New().String()
+	created := RateLimitOverride{Override: o, CreatedAt: now, UpdatedAt: now}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.managed) >= maxRateLimitOverrides {
+		return RateLimitOverride{}, fmt.Errorf("%w: at most %d overrides", ratelimit.ErrInvalidOverride, maxRateLimitOverrides)
+	}
+	if s.conflictLocked(o, "") {
+		return RateLimitOverride{}, ErrRateLimitOverrideConflict
+	}
+	managed := append(append([]RateLimitOverride(nil), s.managed...), created)
+	if err := s.persistLocked(managed); err != nil {
+		return RateLimitOverride{}, err
+	}
+	s.managed = managed
+	s.publishLocked()
+
+	s.emit(ctx, EventRateLimitOverrideSet, overridePayload(o))
+	s.logger.Info("rate limit override created", "id", o.ID, "identity", o.Identity, "tool", o.Tool, "rate", o.Rate)
+	return created, nil
+}
+
+// Update replaces the identity, tool, rate and burst of an admin override.
+func (s *RateLimitOverrideService) Update(ctx context.Context, id string, o ratelimit.Override) (RateLimitOverride, error) {
+	o = normalizeOverride(o)
+	if err := o.Validate(); err != nil {
+		return RateLimitOverride{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if indexOverride(s.config, id) >= 0 {
+		return RateLimitOverride{}, ErrReadOnly
+	}
+	i := indexOverride(s.managed, id)
+	if i < 0 {
+		return RateLimitOverride{}, ErrRateLimitOverrideNotFound
+	}
+	if s.conflictLocked(o, id) {
+		return RateLimitOverride{}, ErrRateLimitOverrideConflict
+	}
+	old := s.managed[i]
+	o.ID = id
+	updated := RateLimitOverride{Override: o, CreatedAt: old.CreatedAt, UpdatedAt: time.Now().UTC()}
+	managed := append([]RateLimitOverride(nil), s.managed...)
+	managed[i] = updated
+	if err := s.persistLocked(managed); err != nil {
+		return RateLimitOverride{}, err
+	}
+	s.managed = managed
+	s.publishLocked()
+
+	payload := overridePayload(o)
+	payload["old_rate"] = old.Rate
+	s.emit(ctx, EventRateLimitOverrideSet, payload)
+	s.logger.Info("rate limit override updated", "id", id, "identity", o.Identity, "tool", o.Tool, "rate", o.Rate)
+	return updated, nil
+}
+
+// Delete removes an admin override.
+func (s *RateLimitOverrideService) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if indexOverride(s.config, id) >= 0 {
+		return ErrReadOnly
+	}
+	i := indexOverride(s.managed, id)
+	if i < 0 {
+		return ErrRateLimitOverrideNotFound
+	}
+	old := s.managed[i]
+	managed := append(append([]RateLimitOverride(nil), s.managed[:i]...), s.managed[i+1:]...)
+	if err := s.persistLocked(managed); err != nil {
+		return err
+	}
+	s.managed = managed
+	s.publishLocked()
+
+	s.emit(ctx, EventRateLimitOverrideDeleted, overridePayload(old.Override))
+	s.logger.Info("rate limit override deleted", "id", id)
+	return nil
+}
+
+// Reset drops the admin overrides from memory. Used by factory reset,
+// which clears them from state.json itself. Config overrides stay.
+func (s *RateLimitOverrideService) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.managed = nil
+	s.publishLocked()
+}
+
+// conflictLocked reports whether another override covers the same
+// identity and tool as o.
+func (s *RateLimitOverrideService) conflictLocked(o ratelimit.Override, exceptID string) bool {
+	for _, list := range [][]RateLimitOverride{s.config, s.managed} {
+		for _, other := range list {
+			if other.ID != exceptID && other.Identity == o.Identity && other.Tool == o.Tool {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *RateLimitOverrideService) persistLocked(managed []RateLimitOverride) error {
+	if s.stateStore == nil {
+		return nil
+	}
+	entries := make([]state.RateLimitOverrideEntry, 0, len(managed))
+	for _, o := range managed {
+		entries = append(entries, state.RateLimitOverrideEntry{
+			ID:        o.ID,
+			Identity:  o.Identity,
+			Tool:      o.Tool,
+			Rate:      o.Rate,
+			Burst:     o.Burst,
+			CreatedAt: o.CreatedAt,
+			UpdatedAt: o.UpdatedAt,
+		})
+	}
+	if err := s.stateStore.Mutate(func(appState *state.AppState) error {
+		appState.RateLimitOverrides = entries
+		return nil
+	}); err != nil {
+		return fmt.Errorf("persist rate limit overrides: %w", err)
+	}
+	return nil
+}
+
+// publishLocked hands the merged set to the consumer. Config overrides come
+// first, so they win over admin overrides for the same identity.
+func (s *RateLimitOverrideService) publishLocked() {
+	if s.onChange == nil {
+		return
+	}
+	list := make([]ratelimit.Override, 0, len(s.config)+len(s.managed))
+	for _, o := range s.config {
+		list = append(list, o.Override)
+	}
+	for _, o := range s.managed {
+		list = append(list, o.Override)
+	}
+	s.onChange(ratelimit.NewOverrides(list))
+}
+
+func (s *RateLimitOverrideService) emit(ctx context.Context, typ string, payload map[string]interface{}) {
+	if s.bus == nil {
+		return
+	}
+	s.bus.Publish(ctx, event.Event{
+		Type:      typ,
+		Source:    "rate-limit",
+		Severity:  event.SeverityInfo,
+		Payload:   payload,
+		Timestamp: time.Now().UTC(),
+	})
+}
+
+func normalizeOverride(o ratelimit.Override) ratelimit.Override {
+	o.Identity = strings.TrimSpace(o.Identity)
+	o.Tool = strings.TrimSpace(o.Tool)
+	o.ReadOnly = false
+	return o
+}
+
+func indexOverride(list []RateLimitOverride, id string) int {
+	for i, o := range list {
+		if o.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func overridePayload(o ratelimit.Override) map[string]interface{} {
+	return map[string]interface{}{
+		"id":       o.ID,
+		"identity": o.Identity,
+		"tool":     o.Tool,
+		"rate":     o.Rate,
+		"burst":    o.Burst,
+	}
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/ratelimit"
+)
+
+func TestRateLimitOverrideService(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	stateStore := state.NewFileStateStore(filepath.Join(t.TempDir(), "state.json"), logger)
+	if err := stateStore.Save(stateStore.DefaultState()); err != nil {
+		t.Fatalf("save default state: %v", err)
+	}
+
+	svc := NewRateLimitOverrideService(stateStore, logger)
+	if err := svc.SetConfigOverrides([]ratelimit.Override{{Identity: "ci-bot", Rate: 1000}}); err != nil {
+		t.Fatalf("SetConfigOverrides: %v", err)
+	}
+	var current *ratelimit.Overrides
+	svc.OnChange(func(o *ratelimit.Overrides) { current = o })
+	if _, ok := current.ForIdentity("id-1", "ci-bot"); !ok {
+		t.Fatal("OnChange must hand over the config overrides at once")
+	}
+
+	ctx := context.Background()
+	created, err := svc.Create(ctx, ratelimit.Override{Tool: " send_email ", Rate: 10})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if created.ID == "" || created.Tool != "send_email" || created.ReadOnly {
+		t.Errorf("created = %+v", created)
+	}
+	if o, ok := current.ForTool("id-1", "alice", "send_email"); !ok || o.Rate != 10 {
+		t.Errorf("ForTool after create = %+v, %v", o, ok)
+	}
+
+	if _, err := svc.Create(ctx, ratelimit.Override{Tool: "send_email", Rate: 20}); !errors.Is(err, ErrRateLimitOverrideConflict) {
+		t.Errorf("duplicate Create error = %v, want ErrRateLimitOverrideConflict", err)
+	}
+	if _, err := svc.Create(ctx, ratelimit.Override{Rate: 20}); !errors.Is(err, ratelimit.ErrInvalidOverride) {
+		t.Errorf("invalid Create error = %v, want ErrInvalidOverride", err)
+	}
+	if _, err := svc.Update(ctx, "config-1", ratelimit.Override{Identity: "ci-bot", Rate: 1}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Update of config override error = %v, want ErrReadOnly", err)
+	}
+	if err := svc.Delete(ctx, "config-1"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Delete of config override error = %v, want ErrReadOnly", err)
+	}
+
+	if _, err := svc.Update(ctx, created.ID, ratelimit.Override{Tool: "send_email", Rate: 5}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if o, _ := current.ForTool("id-1", "alice", "send_email"); o.Rate != 5 {
+		t.Errorf("rate after update = %d, want 5", o.Rate)
+	}
+
+	// Admin overrides survive a restart; config overrides are not persisted.
+	appState, err := stateStore.Load()
+	if err != nil {
+		t.Fatalf("Load state: %v", err)
+	}
+	if len(appState.RateLimitOverrides) != 1 || appState.RateLimitOverrides[0].Rate != 5 {
+		t.Fatalf("persisted = %+v", appState.RateLimitOverrides)
+	}
+	reloaded := NewRateLimitOverrideService(stateStore, logger)
+	reloaded.Load(appState.RateLimitOverrides)
+	if list := reloaded.List(); len(list) != 1 || list[0].ID != created.ID {
+		t.Errorf("reloaded list = %+v", list)
+	}
+
+	if err := svc.Delete(ctx, created.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := svc.Delete(ctx, created.ID); !errors.Is(err, ErrRateLimitOverrideNotFound) {
+		t.Errorf("second Delete error = %v, want ErrRateLimitOverrideNotFound", err)
+	}
+	if _, ok := current.ForTool("id-1", "alice", "send_email"); ok {
+		t.Error("deleted override still applies")
+	}
+}
@@ -0,0 +1,514 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/event"
+	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
+)
+
+// Response guard comparison modes.
+const (
+	// ResponseCompareStructure compares the JSON paths and value types of a result.
+	ResponseCompareStructure = "structure"
+	// ResponseCompareHash compares the exact result content.
+	ResponseCompareHash = "hash"
+)
+
+// Response guard outcomes of the last canary call.
+const (
+	ResponseGuardBaseline = "baseline"
+	ResponseGuardMatch    = "match"
+	ResponseGuardChanged  = "changed"
+	ResponseGuardError    = "error"
+)
+
+// EventToolResponseChanged fires when a guarded tool's canary result differs
+// from its baseline for threshold consecutive runs.
+const EventToolResponseChanged = "tool.response_changed"
+
+// responseGuardCallTimeout bounds one canary call.
+const responseGuardCallTimeout = 30 * time.Second
+
+// maxResponseShape caps the paths kept for a structure baseline. Larger
+// results are still compared by fingerprint, without a path-level diff.
+const maxResponseShape = 500
+
+var (
+	// ErrResponseGuardUnknownTool is returned for tools the guard does not watch.
+	ErrResponseGuardUnknownTool = errors.New("tool is not guarded")
+	// ErrResponseGuardNoResult is returned when accepting a tool that has no
+	// successful canary result yet.
+	ErrResponseGuardNoResult = errors.New("no canary result to accept")
+)
+
+// ToolCaller calls a tool outside any client session. Implemented by
+// proxy.UpstreamRouter.
+type ToolCaller interface {
+	CallTool(ctx context.Context, toolName string, arguments map[string]interface{}) (*mcp.Message, error)
+}
+
+// ToolQuarantiner quarantines tools. Implemented by ToolSecurityService.
+type ToolQuarantiner interface {
+	Quarantine(toolName string) error
+}
+
+// ResponseCanary configures the canary call of one guarded tool.
+type ResponseCanary struct {
+	Tool       string
+	Arguments  map[string]interface{}
+	Compare    string // ResponseCompareStructure or ResponseCompareHash
+	Threshold  int    // consecutive changed results before alerting (min 1)
+	Quarantine bool   // quarantine the tool when alerting
+}
+
+// ResponseGuardStatus reports the state of one guarded tool.
+type ResponseGuardStatus struct {
+	Tool            string     `json:"tool"`
+	Compare         string     `json:"compare"`
+	Threshold       int        `json:"threshold"`
+	Quarantine      bool       `json:"quarantine"`
+	BaselineAt      *time.Time `json:"baseline_at,omitempty"`
+	LastRunAt       *time.Time `json:"last_run_at,omitempty"`
+	LastOutcome     string     `json:"last_outcome,omitempty"`
+	LastError       string     `json:"last_error,omitempty"`
+	LastFingerprint string     `json:"last_fingerprint,omitempty"`
+	Consecutive     int        `json:"consecutive_changes"`
+	Alerted         bool       `json:"alerted"`
+	AddedPaths      []string   `json:"added_paths,omitempty"`
+	RemovedPaths    []string   `json:"removed_paths,omitempty"`
+}
+
+// responseObservation is the fingerprint of one canary result.
+type responseObservation struct {
+	fingerprint string
+	shape       []string
+}
+
+// responseGuardEntry is the runtime state of one guarded tool.
+type responseGuardEntry struct {
+	canary   ResponseCanary
+	argsHash string
+	status   ResponseGuardStatus
+	last     *responseObservation // last successful result, for Accept
+}
+
+// ResponseGuardService issues canary calls to idempotent read-only tools and
+// compares each result with a persisted baseline. Changes that persist for
+// a tool's threshold raise an EventToolResponseChanged alert and, when
+// configured, quarantine the tool: an upstream returning differently shaped
+// data for the same query may be compromised or tampered with.
+type ResponseGuardService struct {
+	caller      ToolCaller
+	stateStore  state.Store
+	logger      *slog.Logger
+	mu          sync.Mutex
+	entries     []*responseGuardEntry
+	baselines   map[string]state.ResponseBaselineEntry
+	eventBus    event.Bus
+	quarantiner ToolQuarantiner
+}
+
+// NewResponseGuardService creates a ResponseGuardService for the given canaries.
+func NewResponseGuardService(canaries []ResponseCanary, caller ToolCaller, stateStore state.Store, logger *slog.Logger) *ResponseGuardService {
+	s := &ResponseGuardService{
+		caller:     caller,
+		stateStore: stateStore,
+		logger:     logger,
+		baselines:  make(map[string]state.ResponseBaselineEntry),
+	}
+	for _, c := range canaries {
+		if c.Compare == "" {
+			c.Compare = ResponseCompareStructure
+		}
+		if c.Threshold < 1 {
+			c.Threshold = 1
+		}
+		if c.Arguments == nil {
+			c.Arguments = map[string]interface{}{}
+		}
+		s.entries = append(s.entries, &responseGuardEntry{
+			canary:   c,
+			argsHash: hashJSON(c.Arguments),
+			status: ResponseGuardStatus{
+				Tool:       c.Tool,
+				Compare:    c.Compare,
+				Threshold:  c.Threshold,
+				Quarantine: c.Quarantine,
+			},
+		})
+	}
+	return s
+}
+
+// SetEventBus sets the event bus for response change alerts.
+func (s *ResponseGuardService) SetEventBus(bus event.Bus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.eventBus = bus
+}
+
+// SetQuarantiner sets the service that quarantines tools on alert.
+func (s *ResponseGuardService) SetQuarantiner(q ToolQuarantiner) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quarantiner = q
+}
+
+// LoadFromState restores baselines from a previously loaded AppState.
+// Baselines of tools that are no longer guarded are dropped on the next save.
+func (s *ResponseGuardService) LoadFromState(appState *state.AppState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.entries {
+		if b, ok := appState.ResponseBaselines[e.canary.Tool]; ok {
+			s.baselines[e.canary.Tool] = b
+			at := b.CapturedAt
+			e.status.BaselineAt = &at
+		}
+	}
+}
+
+// RunAll issues one canary call per guarded tool and compares the results.
+// It returns the number of tools checked and changed, and the errors of
+// failed calls.
+func (s *ResponseGuardService) RunAll(ctx context.Context) (checked, changed int, err error) {
+	var errs []error
+	for _, e := range s.entries {
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+			break
+		}
+		outcome, runErr := s.run(ctx, e)
+		if runErr != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", e.canary.Tool, runErr))
+			continue
+		}
+		checked++
+		if outcome == ResponseGuardChanged {
+			changed++
+		}
+	}
+	return checked, changed, errors.Join(errs...)
+}
+
+// run checks one tool and returns the outcome.
+func (s *ResponseGuardService) run(ctx context.Context, e *responseGuardEntry) (string, error) {
+	callCtx, cancel := context.WithTimeout(ctx, responseGuardCallTimeout)
+	obs, callErr := s.observe(callCtx, e.canary)
+	cancel()
+
+	now := time.Now().UTC()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e.status.LastRunAt = &now
+	if callErr != nil {
+		e.status.LastOutcome = ResponseGuardError
+		e.status.LastError = callErr.Error()
+		return ResponseGuardError, callErr
+	}
+	e.status.LastError = ""
+	e.last = obs
+	e.status.LastFingerprint = obs.fingerprint
+
+	base, ok := s.baselines[e.canary.Tool]
+	if !ok || base.Compare != e.canary.Compare || base.ArgumentsHash != e.argsHash {
+		if err := s.setBaselineLocked(e, obs, now); err != nil {
+			return ResponseGuardError, err
+		}
+		e.status.LastOutcome = ResponseGuardBaseline
+		s.logger.Info("response guard baseline captured", "tool", e.canary.Tool, "compare", e.canary.Compare)
+		return ResponseGuardBaseline, nil
+	}
+
+	if base.Fingerprint == obs.fingerprint {
+		e.status.LastOutcome = ResponseGuardMatch
+		e.status.Consecutive = 0
+		e.status.Alerted = false
+		e.status.AddedPaths, e.status.RemovedPaths = nil, nil
+		return ResponseGuardMatch, nil
+	}
+
+	e.status.LastOutcome = ResponseGuardChanged
+	e.status.Consecutive++
+	e.status.AddedPaths, e.status.RemovedPaths = diffShapes(base.Shape, obs.shape)
+	s.logger.Warn("response guard detected a changed result", "tool", e.canary.Tool,
+		"compare", e.canary.Compare, "consecutive", e.status.Consecutive, "threshold", e.canary.Threshold)
+	if e.status.Consecutive >= e.canary.Threshold && !e.status.Alerted {
+		e.status.Alerted = true
+		s.alertLocked(ctx, e)
+	}
+	return ResponseGuardChanged, nil
+}
+
+// observe issues the canary call and fingerprints its result.
+func (s *ResponseGuardService) observe(ctx context.Context, c ResponseCanary) (*responseObservation, error) {
+	resp, err := s.caller.CallTool(ctx, c.Tool, c.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	var envelope struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(resp.Raw, &envelope); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	if envelope.Error != nil {
+		return nil, fmt.Errorf("upstream error %d: %s", envelope.Error.Code, envelope.Error.Message)
+	}
+	dec := json.NewDecoder(bytes.NewReader(envelope.Result))
+	dec.UseNumber()
+	var result interface{}
+	if err := dec.Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode result: %w", err)
+	}
+	if m, ok := result.(map[string]interface{}); ok && m["isError"] == true {
+		return nil, errors.New("tool returned an error result")
+	}
+	return fingerprintResult(result, c.Compare), nil
+}
+
+// setBaselineLocked stores obs as the tool's baseline and persists it.
+// Caller must hold s.mu.
+func (s *ResponseGuardService) setBaselineLocked(e *responseGuardEntry, obs *responseObservation, at time.Time) error {
+	entry := state.ResponseBaselineEntry{
+		Compare:       e.canary.Compare,
+		ArgumentsHash: e.argsHash,
+		Fingerprint:   obs.fingerprint,
+		Shape:         obs.shape,
+		CapturedAt:    at,
+	}
+	prev, hadPrev := s.baselines[e.canary.Tool]
+	s.baselines[e.canary.Tool] = entry
+	if err := s.persistLocked(); err != nil {
+		if hadPrev {
+			s.baselines[e.canary.Tool] = prev
+		} else {
+			delete(s.baselines, e.canary.Tool)
+		}
+		return fmt.Errorf("failed to persist response baseline: %w", err)
+	}
+	e.status.BaselineAt = &at
+	e.status.Consecutive = 0
+	e.status.Alerted = false
+	e.status.AddedPaths, e.status.RemovedPaths = nil, nil
+	return nil
+}
+
+// persistLocked saves the baselines of guarded tools to state.json.
+// Caller must hold s.mu.
+func (s *ResponseGuardService) persistLocked() error {
+	if s.stateStore == nil {
+		return nil
+	}
+	baselines := make(map[string]state.ResponseBaselineEntry, len(s.baselines))
+	for _, e := range s.entries {
+		if b, ok := s.baselines[e.canary.Tool]; ok {
+			baselines[e.canary.Tool] = b
+		}
+	}
+	return s.stateStore.Mutate(func(appState *state.AppState) error {
+		appState.ResponseBaselines = baselines
+		return nil
+	})
+}
+
+// alertLocked publishes the change alert and quarantines the tool if
+// configured. Caller must hold s.mu.
+func (s *ResponseGuardService) alertLocked(ctx context.Context, e *responseGuardEntry) {
+	quarantined := false
+	if e.canary.Quarantine && s.quarantiner != nil {
+		if err := s.quarantiner.Quarantine(e.canary.Tool); err != nil {
+			s.logger.Warn("response guard quarantine failed", "tool", e.canary.Tool, "error", err)
+		} else {
+			quarantined = true
+			s.logger.Warn("tool quarantined after its response changed", "tool", e.canary.Tool)
+		}
+	}
+	if s.eventBus == nil {
+		return
+	}
+	s.eventBus.Publish(ctx, event.Event{
+		Type:           EventToolResponseChanged,
+		Source:         "response-guard",
+		Severity:       event.SeverityCritical,
+		RequiresAction: true,
+		Payload: map[string]interface{}{
+			"tool_name":     e.canary.Tool,
+			"compare":       e.canary.Compare,
+			"consecutive":   e.status.Consecutive,
+			"added_paths":   e.status.AddedPaths,
+			"removed_paths": e.status.RemovedPaths,
+			"quarantined":   quarantined,
+		},
+	})
+}
+
+// Accept makes the tool's last successful canary result its new baseline,
+// e.g. after a legitimate upstream change. It does not lift a quarantine.
+func (s *ResponseGuardService) Accept(toolName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.entries {
+		if e.canary.Tool != toolName {
+			continue
+		}
+		if e.last == nil {
+			return ErrResponseGuardNoResult
+		}
+		if err := s.setBaselineLocked(e, e.last, time.Now().UTC()); err != nil {
+			return err
+		}
+		s.logger.Info("response guard change accepted", "tool", toolName)
+		return nil
+	}
+	return ErrResponseGuardUnknownTool
+}
+
+// Status returns the state of every guarded tool in configuration order.
+func (s *ResponseGuardService) Status() []ResponseGuardStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]ResponseGuardStatus, 0, len(s.entries))
+	for _, e := range s.entries {
+		st := e.status
+		st.AddedPaths = append([]string(nil), e.status.AddedPaths...)
+		st.RemovedPaths = append([]string(nil), e.status.RemovedPaths...)
+		out = append(out, st)
+	}
+	return out
+}
+
+// fingerprintResult fingerprints a tools/call result in the given mode.
+// "_meta" members are ignored in both modes: they carry per-call data such
+// as timings and request IDs.
+func fingerprintResult(result interface{}, compare string) *responseObservation {
+	if compare == ResponseCompareHash {
+		return &responseObservation{fingerprint: hashJSON(stripMeta(result))}
+	}
+	paths := make(map[string]struct{})
+	collectShape("$", result, paths)
+	shape := make([]string, 0, len(paths))
+	for p := range paths {
+		shape = append(shape, p)
+	}
+	sort.Strings(shape)
+	obs := &responseObservation{fingerprint: hashJSON(shape)}
+	if len(shape) <= maxResponseShape {
+		obs.shape = shape
+	}
+	return obs
+}
+
+// collectShape records "path:type" for every value under v. Array elements
+// share the path "[]", so the shape does not depend on the number of items.
+// Text content holding a JSON object or array is descended into, as MCP
+// servers commonly return JSON that way.
+func collectShape(path string, v interface{}, out map[string]struct{}) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out[path+":object"] = struct{}{}
+		for k, child := range val {
+			if k == "_meta" {
+				continue
+			}
+			collectShape(path+"."+k, child, out)
+		}
+	case []interface{}:
+		out[path+":array"] = struct{}{}
+		for _, child := range val {
+			collectShape(path+"[]", child, out)
+		}
+	case string:
+		trimmed := strings.TrimSpace(val)
+		if strings.HasSuffix(path, ".text") && (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) {
+			dec := json.NewDecoder(strings.NewReader(trimmed))
+			dec.UseNumber()
+			var inner interface{}
+			if dec.Decode(&inner) == nil && !dec.More() {
+				collectShape(path+"<json>", inner, out)
+				return
+			}
+		}
+		out[path+":string"] = struct{}{}
+	case json.Number:
+		out[path+":number"] = struct{}{}
+	case bool:
+		out[path+":boolean"] = struct{}{}
+	case nil:
+		out[path+":null"] = struct{}{}
+	}
+}
+
+// stripMeta returns v without "_meta" members at any depth.
+func stripMeta(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, child := range val {
+			if k != "_meta" {
+				out[k] = stripMeta(child)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, child := range val {
+			out[i] = stripMeta(child)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// hashJSON returns the hex SHA-256 of v's JSON encoding. Map keys are
+// encoded in sorted order, so equal values hash equally.
+func hashJSON(v interface{}) string {
+	data, _ := json.Marshal(v)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// diffShapes returns the paths only in cur (added) and only in base (removed).
+// Both are nil when either shape was not kept.
+func diffShapes(base, cur []string) (added, removed []string) {
+	if base == nil || cur == nil {
+		return nil, nil
+	}
+	inBase := make(map[string]struct{}, len(base))
+	for _, p := range base {
+		inBase[p] = struct{}{}
+	}
+	inCur := make(map[string]struct{}, len(cur))
+	for _, p := range cur {
+		inCur[p] = struct{}{}
+		if _, ok := inBase[p]; !ok {
+			added = append(added, p)
+		}
+	}
+	for _, p := range base {
+		if _, ok := inCur[p]; !ok {
+			removed = append(removed, p)
+		}
+	}
+	return added, removed
+}
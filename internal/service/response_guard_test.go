@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+	"github.com/Sentinel-Gate/Sentinelgate/pkg/mcp"
+)
+
+// fakeToolCaller returns the queued raw responses in order.
+type fakeToolCaller struct {
+	mu        sync.Mutex
+	responses []string
+	calls     int
+}
+
+func (c *fakeToolCaller) CallTool(_ context.Context, _ string, _ map[string]interface{}) (*mcp.Message, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	if len(c.responses) == 0 {
+		return nil, errors.New("no response queued")
+	}
+	raw := c.responses[0]
+	c.responses = c.responses[1:]
+	return &mcp.Message{Raw: []byte(raw)}, nil
+}
+
+type fakeQuarantiner struct{ tools []string }
+
+func (q *fakeQuarantiner) Quarantine(toolName string) error {
+	q.tools = append(q.tools, toolName)
+	return nil
+}
+
+func textResult(text string) string {
+	quoted, _ := json.Marshal(text)
+	return `{"jsonrpc":"2.0","id":1,"result":{"content":[{"type":"text","text":` + string(quoted) + `}],"_meta":{"took_ms":12}}}`
+}
+
+func newTestResponseGuard(t *testing.T, canary ResponseCanary, caller ToolCaller) (*ResponseGuardService, *state.FileStateStore) {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	stateStore := state.NewFileStateStore(filepath.Join(t.TempDir(), "state.json"), logger)
+	if err := stateStore.Save(stateStore.DefaultState()); err != nil {
+		t.Fatalf("save default state: %v", err)
+	}
+	return NewResponseGuardService([]ResponseCanary{canary}, caller, stateStore, logger), stateStore
+}
+
+func TestResponseGuard_StructureChangeAlertsAndQuarantines(t *testing.T) {
+	caller := &fakeToolCaller{responses: []string{
+		textResult(`{"rates":[{"currency":"EUR","rate":1.08}]}`),
+		textResult(`{"rates":[{"currency":"GBP","rate":0.86},{"currency":"EUR","rate":1.07}]}`),
+		textResult(`{"rates":[{"currency":"EUR","rate":1.08,"pay_to":"attacker"}]}`),
+		textResult(`{"rates":[{"currency":"EUR","rate":1.08,"pay_to":"attacker"}]}`),
+	}}
+	guard, _ := newTestResponseGuard(t, ResponseCanary{Tool: "get_rates", Threshold: 2, Quarantine: true}, caller)
+	bus := &mockDriftEventBus{}
+	guard.SetEventBus(bus)
+	q := &fakeQuarantiner{}
+	guard.SetQuarantiner(q)
+	ctx := context.Background()
+
+	for i, want := range []string{ResponseGuardBaseline, ResponseGuardMatch, ResponseGuardChanged, ResponseGuardChanged} {
+		if _, _, err := guard.RunAll(ctx); err != nil {
+			t.Fatalf("run %d: %v", i, err)
+		}
+		if got := guard.Status()[0].LastOutcome; got != want {
+			t.Fatalf("run %d outcome = %q, want %q", i, got, want)
+		}
+		// Alert only once the threshold is reached.
+		wantAlerts := 0
+		if i == 3 {
+			wantAlerts = 1
+		}
+		if len(bus.EventsByType(EventToolResponseChanged)) != wantAlerts {
+			t.Fatalf("run %d: %d alerts, want %d", i, len(bus.EventsByType(EventToolResponseChanged)), wantAlerts)
+		}
+	}
+
+	st := guard.Status()[0]
+	if len(st.AddedPaths) != 1 || st.AddedPaths[0] != "$.content[].text<json>.rates[].pay_to:string" {
+		t.Errorf("added paths = %v", st.AddedPaths)
+	}
+	if len(q.tools) != 1 || q.tools[0] != "get_rates" {
+		t.Errorf("quarantined = %v", q.tools)
+	}
+
+	// Accepting the change makes the current result the baseline.
+	if err := guard.Accept("get_rates"); err != nil {
+		t.Fatalf("Accept: %v", err)
+	}
+	caller.responses = append(caller.responses, textResult(`{"rates":[{"currency":"EUR","rate":1.09,"pay_to":"x"}]}`))
+	if _, changed, _ := guard.RunAll(ctx); changed != 0 {
+		t.Errorf("changed = %d after accepting, want 0", changed)
+	}
+}
+
+func TestResponseGuard_HashMode(t *testing.T) {
+	caller := &fakeToolCaller{responses: []string{
+		textResult("v1"), textResult("v1"), textResult("v2"),
+	}}
+	guard, _ := newTestResponseGuard(t, ResponseCanary{Tool: "readme", Compare: ResponseCompareHash}, caller)
+	ctx := context.Background()
+
+	changed := 0
+	for i := 0; i < 3; i++ {
+		_, c, err := guard.RunAll(ctx)
+		if err != nil {
+			t.Fatalf("run %d: %v", i, err)
+		}
+		changed += c
+	}
+	if changed != 1 || !guard.Status()[0].Alerted {
+		t.Errorf("changed = %d, status = %+v", changed, guard.Status()[0])
+	}
+}
+
+func TestResponseGuard_ErrorsAreNotChanges(t *testing.T) {
+	caller := &fakeToolCaller{responses: []string{
+		textResult("ok"),
+		`{"jsonrpc":"2.0","id":1,"result":{"content":[{"type":"text","text":"boom"}],"isError":true}}`,
+		`{"jsonrpc":"2.0","id":1,"error":{"code":-32603,"message":"Upstream unavailable"}}`,
+	}}
+	guard, _ := newTestResponseGuard(t, ResponseCanary{Tool: "status"}, caller)
+	ctx := context.Background()
+
+	if _, _, err := guard.RunAll(ctx); err != nil {
+		t.Fatalf("baseline run: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		checked, changed, err := guard.RunAll(ctx)
+		if err == nil || checked != 0 || changed != 0 {
+			t.Errorf("error run %d: checked=%d changed=%d err=%v", i, checked, changed, err)
+		}
+	}
+	if st := guard.Status()[0]; st.LastOutcome != ResponseGuardError || st.Consecutive != 0 {
+		t.Errorf("status = %+v", st)
+	}
+	if err := guard.Accept("other"); !errors.Is(err, ErrResponseGuardUnknownTool) {
+		t.Errorf("Accept(other) = %v", err)
+	}
+}
+
+func TestResponseGuard_BaselinePersists(t *testing.T) {
+	caller := &fakeToolCaller{responses: []string{textResult(`{"a":1}`)}}
+	guard, stateStore := newTestResponseGuard(t, ResponseCanary{Tool: "t"}, caller)
+	if _, _, err := guard.RunAll(context.Background()); err != nil {
+		t.Fatalf("RunAll: %v", err)
+	}
+
+	appState, err := stateStore.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if b, ok := appState.ResponseBaselines["t"]; !ok || b.Compare != ResponseCompareStructure || b.Fingerprint == "" {
+		t.Fatalf("persisted baselines = %+v", appState.ResponseBaselines)
+	}
+
+	// A restarted guard compares against the saved baseline.
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	restarted := NewResponseGuardService([]ResponseCanary{{Tool: "t"}},
+		&fakeToolCaller{responses: []string{textResult(`{"a":"1"}`)}}, stateStore, logger)
+	restarted.LoadFromState(appState)
+	if _, changed, err := restarted.RunAll(context.Background()); err != nil || changed != 1 {
+		t.Errorf("after restart: changed=%d err=%v", changed, err)
+	}
+
+	// Changing the canary arguments takes a new baseline instead.
+	other := NewResponseGuardService([]ResponseCanary{{Tool: "t", Arguments: map[string]interface{}{"x": 1}}},
+		&fakeToolCaller{responses: []string{textResult(`{"a":"1"}`)}}, stateStore, logger)
+	other.LoadFromState(appState)
+	if _, _, err := other.RunAll(context.Background()); err != nil || other.Status()[0].LastOutcome != ResponseGuardBaseline {
+		t.Errorf("new arguments: status=%+v err=%v", other.Status()[0], err)
+	}
+}
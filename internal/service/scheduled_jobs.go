@@ -0,0 +1,308 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/event"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/scheduler"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/upstream"
+)
+
+// Built-in recurring job names.
+const (
+	JobAuditArchival       = "audit-archival"
+	JobKeyExpiry           = "key-expiry-notify"
+	JobComplianceReport    = "compliance-report"
+	JobStateSnapshot       = "state-snapshot"
+	JobUpstreamConformance = "upstream-conformance"
+	JobResponseGuard       = "response-guard"
+)
+
+const (
+	// EventAPIKeyExpiring fires for each API key that expires within the
+	// configured warning period.
+	EventAPIKeyExpiring = "identity.key_expiring"
+	// EventUpstreamConformanceFailed fires when the scheduled conformance
+	// check finds failing checks on an upstream.
+	EventUpstreamConformanceFailed = "upstream.conformance_failed"
+)
+
+// complianceReportPeriod is the audit window covered by scheduled reports.
+const complianceReportPeriod = 7 * 24 * time.Hour
+
+// AuditArchiver runs audit file maintenance. Implemented by FileAuditStore.
+type AuditArchiver interface {
+	Archive(ctx context.Context) (compressed, deleted int)
+}
+
+// APIKeyLister lists API keys. Implemented by IdentityService.
+type APIKeyLister interface {
+	ListAllKeys(ctx context.Context) ([]state.APIKeyEntry, error)
+}
+
+// AuditArchivalJob compresses rotated audit files and deletes those past
+// retention, on top of the hourly pass the audit store runs by itself.
+func AuditArchivalJob(archiver AuditArchiver) scheduler.Job {
+	return scheduler.Job{
+		Name:        JobAuditArchival,
+		Description: "Compress rotated audit files and delete files past retention or over the size cap",
+		Schedule:    "0 3 * * *",
+		Enabled:     true,
+		Timeout:     30 * time.Minute,
+		Run: func(ctx context.Context) (string, error) {
+			compressed, deleted := archiver.Archive(ctx)
+			return fmt.Sprintf("%d files compressed, %d deleted", compressed, deleted), ctx.Err()
+		},
+	}
+}
+
+// KeyExpiryJob publishes an EventAPIKeyExpiring event for every active API
+// key that expires within warning, which the notification center and
+// webhooks pick up.
+func KeyExpiryJob(keys APIKeyLister, bus event.Bus, warning time.Duration) scheduler.Job {
+	return scheduler.Job{
+		Name:        JobKeyExpiry,
+		Description: fmt.Sprintf("Notify about API keys expiring within %s", warning),
+		Schedule:    "0 8 * * *",
+		Enabled:     true,
+		Timeout:     time.Minute,
+		Run: func(ctx context.Context) (string, error) {
+			all, err := keys.ListAllKeys(ctx)
+			if err != nil {
+				return "", fmt.Errorf("list API keys: %w", err)
+			}
+			now := time.Now().UTC()
+			expiring := 0
+			for _, k := range all {
+				if k.Revoked || k.ExpiresAt == nil || !k.ExpiresAt.After(now) || k.ExpiresAt.Sub(now) > warning {
+					continue
+				}
+				expiring++
+				if bus == nil {
+					continue
+				}
+				bus.Publish(ctx, event.Event{
+					Type:     EventAPIKeyExpiring,
+					Source:   "scheduler",
+					Severity: event.SeverityWarning,
+					Payload: map[string]interface{}{
+						"key_id":      k.ID,
+						"key_name":    k.Name,
+						"identity_id": k.IdentityID,
+						"expires_at":  *k.ExpiresAt,
+						"expires_in":  k.ExpiresAt.Sub(now).Round(time.Minute).String(),
+					},
+					Timestamp:      now,
+					RequiresAction: true,
+				})
+			}
+			return fmt.Sprintf("%d of %d keys expire within %s", expiring, len(all), warning), nil
+		},
+	}
+}
+
+// StateSnapshotJob copies the state, in the state.json format, into dir,
+// keeping the newest keep copies.
+func StateSnapshotJob(store state.Store, dir string, keep int) scheduler.Job {
+	return scheduler.Job{
+		Name:        JobStateSnapshot,
+		Description: fmt.Sprintf("Copy state.json to %s, keeping %d snapshots", dir, keep),
+		Schedule:    "30 2 * * *",
+		Enabled:     true,
+		Timeout:     time.Minute,
+		Run: func(ctx context.Context) (string, error) {
+			path, err := store.Snapshot(dir, keep)
+			if err != nil {
+				return "", err
+			}
+			return "wrote " + filepath.Base(path), nil
+		},
+	}
+}
+
+// ComplianceReportJob generates an evidence bundle for every compliance pack
+// over the last seven days and writes each to dir as
+// compliance-<pack>-<date>.json, keeping the newest keep reports per pack.
+// Disabled by default.
+func ComplianceReportJob(svc *ComplianceService, sysCtx func() ComplianceContext, instanceID, dir string, keep int) scheduler.Job {
+	return scheduler.Job{
+		Name:        JobComplianceReport,
+		Description: fmt.Sprintf("Write weekly compliance evidence bundles to %s", dir),
+		Schedule:    "0 6 * * 1",
+		Timeout:     10 * time.Minute,
+		Run: func(ctx context.Context) (string, error) {
+			if err := os.MkdirAll(dir, 0700); err != nil {
+				return "", fmt.Errorf("create report directory: %w", err)
+			}
+			end := time.Now().UTC()
+			start := end.Add(-complianceReportPeriod)
+			var sc ComplianceContext
+			if sysCtx != nil {
+				sc = sysCtx()
+			}
+
+			written := 0
+			var errs []error
+			for _, pack := range svc.ListPacks() {
+				if ctx.Err() != nil {
+					errs = append(errs, ctx.Err())
+					break
+				}
+				bundle, err := svc.GenerateBundle(ctx, pack.ID, start, end, sc, instanceID)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", pack.ID, err))
+					continue
+				}
+				data, err := json.MarshalIndent(bundle, "", "  ")
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", pack.ID, err))
+					continue
+				}
+				prefix := "compliance-" + pack.ID + "-"
+				name := prefix + end.Format(reportTimeFormat) + ".json"
+				if err := os.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", pack.ID, err))
+					continue
+				}
+				written++
+				if err := pruneReports(dir, prefix, keep); err != nil {
+					errs = append(errs, fmt.Errorf("prune %s reports: %w", pack.ID, err))
+				}
+			}
+			return fmt.Sprintf("%d reports written", written), errors.Join(errs...)
+		},
+	}
+}
+
+// ResponseGuardJob issues the response guard's canary calls and compares the
+// results with their baselines.
+func ResponseGuardJob(guard *ResponseGuardService) scheduler.Job {
+	return scheduler.Job{
+		Name:        JobResponseGuard,
+		Description: "Call guarded read-only tools and compare the results with their baselines",
+		Schedule:    "*/15 * * * *",
+		Enabled:     true,
+		Timeout:     10 * time.Minute,
+		Run: func(ctx context.Context) (string, error) {
+			checked, changed, err := guard.RunAll(ctx)
+			return fmt.Sprintf("%d tools checked, %d changed", checked, changed), err
+		},
+	}
+}
+
+// reportTimeFormat is the timestamp in report file names; it sorts lexically
+// in time order.
+const reportTimeFormat = "20060102T150405Z"
+
+// pruneReports deletes all but the newest keep reports named
+// <prefix><timestamp>.json in dir. The exact length check keeps the reports
+// of a pack whose ID extends another's ("soc2" and "soc2-type2") apart.
+func pruneReports(dir, prefix string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() && strings.HasPrefix(name, prefix) && strings.HasSuffix(name, ".json") &&
+			len(name) == len(prefix)+len(reportTimeFormat)+len(".json") {
+			names = append(names, name)
+		}
+	}
+	if len(names) <= keep {
+		return nil
+	}
+	sort.Strings(names)
+	var errs []error
+	for _, name := range names[:len(names)-keep] {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// UpstreamConformanceJob runs the conformance suite against every enabled
+// upstream through a fresh client and publishes
+// EventUpstreamConformanceFailed for those with failing checks. Stdio
+// upstreams are launched once more for the check, so the job is disabled
+// by default.
+func UpstreamConformanceJob(upstreams UpstreamLister, factory ClientFactory, bus event.Bus) scheduler.Job {
+	return scheduler.Job{
+		Name:        JobUpstreamConformance,
+		Description: "Run the protocol conformance suite against every enabled upstream",
+		Schedule:    "0 4 * * *",
+		Timeout:     30 * time.Minute,
+		Run: func(ctx context.Context) (string, error) {
+			list, err := upstreams.List(ctx)
+			if err != nil {
+				return "", fmt.Errorf("list upstreams: %w", err)
+			}
+			checked, failing := 0, 0
+			for i := range list {
+				u := &list[i]
+				if !u.Enabled {
+					continue
+				}
+				if ctx.Err() != nil {
+					return fmt.Sprintf("%d upstreams checked, %d failing", checked, failing), ctx.Err()
+				}
+				client, err := factory(u)
+				if err != nil {
+					failing++
+					publishConformanceFailure(ctx, bus, u, nil, err.Error())
+					continue
+				}
+				report := RunUpstreamConformance(ctx, client, u.Name, ConformanceOptions{})
+				checked++
+				if !report.OK() {
+					failing++
+					publishConformanceFailure(ctx, bus, u, report, "")
+				}
+			}
+			return fmt.Sprintf("%d upstreams checked, %d failing", checked, failing), nil
+		},
+	}
+}
+
+func publishConformanceFailure(ctx context.Context, bus event.Bus, u *upstream.Upstream, report *ConformanceReport, errMsg string) {
+	if bus == nil {
+		return
+	}
+	payload := map[string]interface{}{
+		"upstream_id":   u.ID,
+		"upstream_name": u.Name,
+	}
+	if report != nil {
+		var failed []string
+		for _, c := range report.Checks {
+			if c.Status == ConformanceFail {
+				failed = append(failed, c.Name)
+			}
+		}
+		payload["failed_checks"] = failed
+	}
+	if errMsg != "" {
+		payload["error"] = errMsg
+	}
+	bus.Publish(ctx, event.Event{
+		Type:      EventUpstreamConformanceFailed,
+		Source:    "scheduler",
+		Severity:  event.SeverityWarning,
+		Payload:   payload,
+		Timestamp: time.Now().UTC(),
+	})
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/state"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/scheduler"
+)
+
+type fakeKeyLister []state.APIKeyEntry
+
+func (f fakeKeyLister) ListAllKeys(context.Context) ([]state.APIKeyEntry, error) {
+	return f, nil
+}
+
+func TestKeyExpiryJob_PublishesExpiringKeys(t *testing.T) {
+	now := time.Now().UTC()
+	at := func(d time.Duration) *time.Time { ts := now.Add(d); return &ts }
+	keys := fakeKeyLister{
+		{ID: "soon", Name: "ci", IdentityID: "id-1", ExpiresAt: at(48 * time.Hour)},
+		{ID: "later", ExpiresAt: at(30 * 24 * time.Hour)},
+		{ID: "expired", ExpiresAt: at(-time.Hour)},
+		{ID: "revoked", Revoked: true, ExpiresAt: at(time.Hour)},
+		{ID: "forever"},
+	}
+	bus := &mockDriftEventBus{}
+
+	msg, err := KeyExpiryJob(keys, bus, 7*24*time.Hour).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if msg != "1 of 5 keys expire within 168h0m0s" {
+		t.Errorf("message = %q", msg)
+	}
+	events := bus.EventsByType(EventAPIKeyExpiring)
+	if len(events) != 1 {
+		t.Fatalf("events = %d, want 1", len(events))
+	}
+	if payload, _ := events[0].Payload.(map[string]interface{}); payload["key_id"] != "soon" {
+		t.Errorf("payload = %+v, want key soon", events[0].Payload)
+	}
+}
+
+func TestPruneReports_KeepsNewestPerPack(t *testing.T) {
+	dir := t.TempDir()
+	names := []string{
+		"compliance-soc2-20260101T000000Z.json",
+		"compliance-soc2-20260108T000000Z.json",
+		"compliance-soc2-20260115T000000Z.json",
+		"compliance-soc2-type2-20260101T000000Z.json",
+		"notes.json",
+	}
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := pruneReports(dir, "compliance-soc2-", 2); err != nil {
+		t.Fatalf("pruneReports: %v", err)
+	}
+	for i, name := range names {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if exists, want := err == nil, i != 0; exists != want {
+			t.Errorf("%s exists = %v, want %v", name, exists, want)
+		}
+	}
+}
+
+func TestJobService_UpdateSchedulePersists(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	stateStore := state.NewFileStateStore(filepath.Join(t.TempDir(), "state.json"), logger)
+	if err := stateStore.Save(stateStore.DefaultState()); err != nil {
+		t.Fatalf("save default state: %v", err)
+	}
+	sched := scheduler.New(logger)
+	if err := sched.Register(scheduler.Job{
+		Name:     JobStateSnapshot,
+		Schedule: "30 2 * * *",
+		Enabled:  true,
+		Run:      func(context.Context) (string, error) { return "", nil },
+	}); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewJobService(sched, stateStore, logger)
+
+	if _, err := svc.UpdateSchedule(context.Background(), JobStateSnapshot, "bogus", true); err == nil {
+		t.Error("invalid schedule accepted")
+	}
+	status, err := svc.UpdateSchedule(context.Background(), JobStateSnapshot, "@hourly", false)
+	if err != nil {
+		t.Fatalf("UpdateSchedule: %v", err)
+	}
+	if status.Schedule != "@hourly" || status.Enabled {
+		t.Errorf("status = %+v, want disabled @hourly", status)
+	}
+
+	appState, err := stateStore.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, ok := appState.JobSchedules[JobStateSnapshot]
+	if !ok || saved.Schedule != "@hourly" || saved.Enabled {
+		t.Errorf("saved = %+v (found=%v), want disabled @hourly", saved, ok)
+	}
+}
@@ -79,6 +79,7 @@ type SimulationDetail struct {
 type SimulationService struct {
 	policyService *PolicyService
 	auditReader   func(n int) []audit.AuditRecord
+	auditQuery    SimulationAuditQuery
 	logger        *slog.Logger
 }
 
@@ -156,18 +157,8 @@ func (s *SimulationService) Simulate(ctx context.Context, req SimulationRequest)
 		}
 
 		// Build evaluation context from the audit record.
-		evalCtx := policy.EvaluationContext{
-			ToolName:     rec.ToolName,
-			IdentityID:   rec.IdentityID,
-			IdentityName: rec.IdentityName,
-			Protocol:     rec.Protocol,
-			SessionID:    rec.SessionID,
-			RequestTime:  rec.Timestamp,
-			SkipCache:    true,
-		}
-		if rec.ToolArguments != nil {
-			evalCtx.ToolArguments = rec.ToolArguments
-		}
+		evalCtx := simulationEvalContext(rec)
+		evalCtx.Variables = s.policyService.Variables()
 
 		// Evaluate against the current policy rules.
 		newDecision, err := s.policyService.Evaluate(ctx, evalCtx)
@@ -176,12 +167,7 @@ func (s *SimulationService) Simulate(ctx context.Context, req SimulationRequest)
 			continue
 		}
 
-		newDecisionStr := "allow"
-		if newDecision.RequiresApproval {
-			newDecisionStr = "approval_required"
-		} else if !newDecision.Allowed {
-			newDecisionStr = "deny"
-		}
+		newDecisionStr := decisionString(newDecision)
 		newRuleID := newDecision.RuleID
 		newRuleName := newDecision.RuleName
 		newReason := newDecision.Reason
@@ -237,14 +223,7 @@ func (s *SimulationService) Simulate(ctx context.Context, req SimulationRequest)
 			}
 		}
 
-		// Normalize original decision: "blocked" (quota deny) → "deny", "warn" (quota pass) → "allow"
-		originalDecision := rec.Decision
-		switch originalDecision {
-		case "blocked":
-			originalDecision = "deny"
-		case "warn":
-			originalDecision = "allow"
-		}
+		originalDecision := normalizeAuditDecision(rec.Decision)
 
 		if newDecisionStr == originalDecision {
 			unchanged++
@@ -307,3 +286,42 @@ func (s *SimulationService) Simulate(ctx context.Context, req SimulationRequest)
 		DurationMs:     time.Since(start).Milliseconds(),
 	}, nil
 }
+
+// simulationEvalContext builds the policy evaluation context of a recorded call.
+func simulationEvalContext(rec audit.AuditRecord) policy.EvaluationContext {
+	return policy.EvaluationContext{
+		ToolName:      rec.ToolName,
+		ToolArguments: rec.ToolArguments,
+		UserRoles:     rec.Roles,
+		IdentityID:    rec.IdentityID,
+		IdentityName:  rec.IdentityName,
+		Protocol:      rec.Protocol,
+		SessionID:     rec.SessionID,
+		RequestTime:   rec.Timestamp,
+		SkipCache:     true,
+	}
+}
+
+// decisionString returns the audit decision name of a policy decision.
+func decisionString(d policy.Decision) string {
+	switch {
+	case d.RequiresApproval:
+		return "approval_required"
+	case !d.Allowed:
+		return "deny"
+	default:
+		return "allow"
+	}
+}
+
+// normalizeAuditDecision maps quota outcomes onto policy decisions:
+// "blocked" (quota deny) becomes "deny" and "warn" (quota pass) "allow".
+func normalizeAuditDecision(decision string) string {
+	switch decision {
+	case audit.DecisionBlocked:
+		return "deny"
+	case audit.DecisionWarn:
+		return "allow"
+	}
+	return decision
+}
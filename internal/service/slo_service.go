@@ -0,0 +1,385 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/event"
+)
+
+// SLO types.
+const (
+	// SLOTypeAvailability counts a request as good when it did not fail.
+	SLOTypeAvailability = "availability"
+	// SLOTypeLatency counts a request as good when it succeeded within the latency threshold.
+	SLOTypeLatency = "latency"
+)
+
+// SLOTargetGateway is the SLO target for requests handled by the gateway
+// itself. Any other target is the name of an upstream.
+const SLOTargetGateway = "gateway"
+
+// SLO alert states.
+const (
+	// SLOAlertFastBurn fires when the burn rate exceeds the fast threshold
+	// over both the 1h and 5m windows (page-worthy).
+	SLOAlertFastBurn = "fast_burn"
+	// SLOAlertSlowBurn fires when the burn rate exceeds the slow threshold
+	// over both the 6h and 30m windows (ticket-worthy).
+	SLOAlertSlowBurn = "slow_burn"
+)
+
+// Default multiwindow burn-rate thresholds for a 30-day error budget: a fast
+// burn spends 2% of the budget in one hour, a slow burn 5% in six hours.
+const (
+	DefaultSLOFastBurnThreshold  = 14.4
+	DefaultSLOSlowBurnThreshold  = 6.0
+	DefaultSLOEvaluationInterval = 30 * time.Second
+)
+
+// sloBucketCount is the number of one-minute buckets kept per SLO (6 hours,
+// the longest burn-rate window).
+const sloBucketCount = 360
+
+// sloWindows are the burn-rate windows, shortest first.
+var sloWindows = []struct {
+	name string
+	dur  time.Duration
+}{
+	{"5m", 5 * time.Minute},
+	{"30m", 30 * time.Minute},
+	{"1h", time.Hour},
+	{"6h", 6 * time.Hour},
+}
+
+// SLODefinition describes one SLO.
+type SLODefinition struct {
+	Name string
+	// Target is SLOTargetGateway or an upstream name.
+	Target string
+	// Type is SLOTypeAvailability or SLOTypeLatency.
+	Type string
+	// Objective is the target percentage of good requests (e.g., 99.9).
+	Objective float64
+	// LatencyThreshold bounds a good request for latency SLOs.
+	LatencyThreshold time.Duration
+}
+
+// SLOWindowStatus is the state of one SLO over one burn-rate window.
+type SLOWindowStatus struct {
+	Window string `json:"window"`
+	Total  uint64 `json:"total"`
+	Good   uint64 `json:"good"`
+	// SLI is the percentage of good requests; 100 when there were none.
+	SLI float64 `json:"sli"`
+	// BurnRate is how fast the error budget is spent: 1 consumes exactly
+	// the budget over the SLO period, 0 when there were no requests.
+	BurnRate float64 `json:"burn_rate"`
+}
+
+// SLOStatus is the current state of one SLO.
+type SLOStatus struct {
+	Name               string            `json:"name"`
+	Target             string            `json:"target"`
+	Type               string            `json:"type"`
+	Objective          float64           `json:"objective"`
+	LatencyThresholdMs int64             `json:"latency_threshold_ms,omitempty"`
+	Windows            []SLOWindowStatus `json:"windows"`
+	// Alert is SLOAlertFastBurn, SLOAlertSlowBurn or empty.
+	Alert string `json:"alert,omitempty"`
+}
+
+// BurnRate returns the burn rate over the named window, or 0 if unknown.
+func (s SLOStatus) BurnRate(window string) float64 {
+	for _, w := range s.Windows {
+		if w.Window == window {
+			return w.BurnRate
+		}
+	}
+	return 0
+}
+
+// sloBucket counts requests in one minute.
+type sloBucket struct {
+	minute int64
+	good   uint64
+	total  uint64
+}
+
+// sloState is the rolling window of one SLO.
+type sloState struct {
+	def     SLODefinition
+	buckets [sloBucketCount]sloBucket
+	alert   string
+}
+
+// SLOService tracks SLOs for the gateway and its upstreams, computes
+// multiwindow burn rates and publishes "slo.burn_rate" events when an
+// error budget is burning too fast.
+type SLOService struct {
+	mu       sync.Mutex
+	slos     []*sloState
+	fastBurn float64
+	slowBurn float64
+	eventBus event.Bus
+	logger   *slog.Logger
+	now      func() time.Time
+}
+
+// NewSLOService creates an SLOService. Zero thresholds use the defaults.
+func NewSLOService(defs []SLODefinition, fastBurn, slowBurn float64, logger *slog.Logger) *SLOService {
+	if fastBurn <= 0 {
+		fastBurn = DefaultSLOFastBurnThreshold
+	}
+	if slowBurn <= 0 {
+		slowBurn = DefaultSLOSlowBurnThreshold
+	}
+	s := &SLOService{
+		fastBurn: fastBurn,
+		slowBurn: slowBurn,
+		logger:   logger,
+		now:      time.Now,
+	}
+	for _, def := range defs {
+		s.slos = append(s.slos, &sloState{def: def})
+	}
+	return s
+}
+
+// SetEventBus sets the event bus used to publish burn-rate events.
+func (s *SLOService) SetEventBus(bus event.Bus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.eventBus = bus
+}
+
+// Thresholds returns the fast and slow burn-rate thresholds.
+func (s *SLOService) Thresholds() (fast, slow float64) {
+	return s.fastBurn, s.slowBurn
+}
+
+// Observe records one request against every SLO of target.
+func (s *SLOService) Observe(target string, latency time.Duration, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	minute := s.now().Unix() / 60
+	for _, st := range s.slos {
+		if st.def.Target != target {
+			continue
+		}
+		good := !failed
+		if st.def.Type == SLOTypeLatency && latency > st.def.LatencyThreshold {
+			good = false
+		}
+		b := &st.buckets[minute%sloBucketCount]
+		if b.minute != minute {
+			*b = sloBucket{minute: minute}
+		}
+		b.total++
+		if good {
+			b.good++
+		}
+	}
+}
+
+// ObserveUpstreamCall records a tool call forwarded to the named upstream.
+func (s *SLOService) ObserveUpstreamCall(upstreamName string, latency time.Duration, failed bool) {
+	s.Observe(upstreamName, latency, failed)
+}
+
+// ObserveGatewayCall records a request handled by the gateway.
+func (s *SLOService) ObserveGatewayCall(latency time.Duration, failed bool) {
+	s.Observe(SLOTargetGateway, latency, failed)
+}
+
+// Status returns the current state of every SLO.
+func (s *SLOService) Status() []SLOStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	minute := s.now().Unix() / 60
+	out := make([]SLOStatus, 0, len(s.slos))
+	for _, st := range s.slos {
+		out = append(out, s.statusLocked(st, minute))
+	}
+	return out
+}
+
+// statusLocked computes the status of st. The current minute counts toward
+// every window. Caller must hold s.mu.
+func (s *SLOService) statusLocked(st *sloState, minute int64) SLOStatus {
+	status := SLOStatus{
+		Name:      st.def.Name,
+		Target:    st.def.Target,
+		Type:      st.def.Type,
+		Objective: st.def.Objective,
+		Alert:     st.alert,
+	}
+	if st.def.Type == SLOTypeLatency {
+		status.LatencyThresholdMs = st.def.LatencyThreshold.Milliseconds()
+	}
+	budget := 1 - st.def.Objective/100
+	for _, w := range sloWindows {
+		minutes := int64(w.dur / time.Minute)
+		var good, total uint64
+		for _, b := range st.buckets {
+			if b.total > 0 && b.minute > minute-minutes && b.minute <= minute {
+				good += b.good
+				total += b.total
+			}
+		}
+		ws := SLOWindowStatus{Window: w.name, Total: total, Good: good, SLI: 100}
+		if total > 0 {
+			ws.SLI = float64(good) / float64(total) * 100
+			if budget > 0 {
+				ws.BurnRate = (1 - float64(good)/float64(total)) / budget
+			}
+		}
+		status.Windows = append(status.Windows, ws)
+	}
+	return status
+}
+
+// Evaluate checks every SLO against the burn-rate thresholds and publishes
+// an event when its alert state changes: "slo.burn_rate" when a fast or slow
+// burn starts (or escalates), "slo.burn_rate_resolved" when it ends.
+func (s *SLOService) Evaluate(ctx context.Context) []SLOStatus {
+	s.mu.Lock()
+	minute := s.now().Unix() / 60
+	statuses := make([]SLOStatus, 0, len(s.slos))
+	var changed []SLOStatus
+	for _, st := range s.slos {
+		status := s.statusLocked(st, minute)
+		alert := ""
+		switch {
+		case status.BurnRate("1h") >= s.fastBurn && status.BurnRate("5m") >= s.fastBurn:
+			alert = SLOAlertFastBurn
+		case status.BurnRate("6h") >= s.slowBurn && status.BurnRate("30m") >= s.slowBurn:
+			alert = SLOAlertSlowBurn
+		}
+		if alert != st.alert {
+			st.alert = alert
+			status.Alert = alert
+			changed = append(changed, status)
+		}
+		statuses = append(statuses, status)
+	}
+	bus := s.eventBus
+	s.mu.Unlock()
+
+	for _, status := range changed {
+		s.publish(ctx, bus, status)
+	}
+	return statuses
+}
+
+// publish logs an alert state change and publishes it on bus.
+func (s *SLOService) publish(ctx context.Context, bus event.Bus, status SLOStatus) {
+	payload := map[string]interface{}{
+		"slo":            status.Name,
+		"target":         status.Target,
+		"type":           status.Type,
+		"objective":      status.Objective,
+		"burn_rate_5m":   status.BurnRate("5m"),
+		"burn_rate_30m":  status.BurnRate("30m"),
+		"burn_rate_1h":   status.BurnRate("1h"),
+		"burn_rate_6h":   status.BurnRate("6h"),
+		"fast_threshold": s.fastBurn,
+		"slow_threshold": s.slowBurn,
+	}
+	evt := event.Event{Source: "slo", Payload: payload}
+	if status.Alert == "" {
+		s.logger.Info("SLO burn rate back to normal", "slo", status.Name, "target", status.Target)
+		evt.Type = "slo.burn_rate_resolved"
+		evt.Severity = event.SeverityInfo
+	} else {
+		s.logger.Warn("SLO error budget burning too fast",
+			"slo", status.Name, "target", status.Target, "alert", status.Alert,
+			"burn_rate_1h", status.BurnRate("1h"), "burn_rate_6h", status.BurnRate("6h"))
+		payload["alert"] = status.Alert
+		evt.Type = "slo.burn_rate"
+		evt.Severity = event.SeverityWarning
+		if status.Alert == SLOAlertFastBurn {
+			evt.Severity = event.SeverityCritical
+		}
+	}
+	if bus != nil {
+		bus.Publish(ctx, evt)
+	}
+}
+
+// Run evaluates burn rates every interval until ctx is done.
+func (s *SLOService) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultSLOEvaluationInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Evaluate(ctx)
+		}
+	}
+}
+
+// prometheusRuleGroups is the layout of a Prometheus rules file.
+type prometheusRuleGroups struct {
+	Groups []prometheusRuleGroup `yaml:"groups"`
+}
+
+type prometheusRuleGroup struct {
+	Name  string           `yaml:"name"`
+	Rules []prometheusRule `yaml:"rules"`
+}
+
+type prometheusRule struct {
+	Alert       string            `yaml:"alert"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for"`
+	Labels      map[string]string `yaml:"labels"`
+	Annotations map[string]string `yaml:"annotations"`
+}
+
+// PrometheusAlertRules returns a Prometheus alerting rules file with a fast
+// and a slow burn-rate alert per SLO, built on the sentinelgate_slo_burn_rate
+// metric and the configured thresholds. Teams that already run Prometheus can
+// load it instead of relying on the built-in evaluation.
+func (s *SLOService) PrometheusAlertRules() ([]byte, error) {
+	group := prometheusRuleGroup{Name: "sentinelgate-slo", Rules: []prometheusRule{}}
+	for _, st := range s.slos {
+		def := st.def
+		for _, a := range []struct {
+			name, severity, long, short, forDur string
+			threshold                           float64
+		}{
+			{"SentinelGateSLOFastBurn", "critical", "1h", "5m", "2m", s.fastBurn},
+			{"SentinelGateSLOSlowBurn", "warning", "6h", "30m", "15m", s.slowBurn},
+		} {
+			group.Rules = append(group.Rules, prometheusRule{
+				Alert: a.name,
+				Expr: fmt.Sprintf(
+					"sentinelgate_slo_burn_rate{slo=%q,window=%q} > %g\nand ignoring(window)\nsentinelgate_slo_burn_rate{slo=%q,window=%q} > %g",
+					def.Name, a.long, a.threshold, def.Name, a.short, a.threshold),
+				For: a.forDur,
+				Labels: map[string]string{
+					"severity": a.severity,
+					"slo":      def.Name,
+					"target":   def.Target,
+				},
+				Annotations: map[string]string{
+					"summary": fmt.Sprintf("SLO %s (%s %g%%) is burning its error budget", def.Name, def.Type, def.Objective),
+					"description": fmt.Sprintf("Burn rate over %s and %s is above %g for %s target %q.",
+						a.long, a.short, a.threshold, def.Type, def.Target),
+				},
+			})
+		}
+	}
+	return yaml.Marshal(prometheusRuleGroups{Groups: []prometheusRuleGroup{group}})
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/event"
+)
+
+func newTestSLOService(defs ...SLODefinition) (*SLOService, *time.Time) {
+	clock := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	svc := NewSLOService(defs, 0, 0, slog.Default())
+	svc.now = func() time.Time { return clock }
+	return svc, &clock
+}
+
+func TestSLOService_AvailabilityBurnRate(t *testing.T) {
+	svc, _ := newTestSLOService(SLODefinition{
+		Name: "gw", Target: SLOTargetGateway, Type: SLOTypeAvailability, Objective: 99,
+	})
+
+	for i := 0; i < 98; i++ {
+		svc.ObserveGatewayCall(10*time.Millisecond, false)
+	}
+	svc.ObserveGatewayCall(10*time.Millisecond, true)
+	svc.ObserveGatewayCall(10*time.Millisecond, true)
+	// Other targets are not counted.
+	svc.ObserveUpstreamCall("github", time.Millisecond, true)
+
+	status := svc.Status()
+	if len(status) != 1 {
+		t.Fatalf("Status() len = %d, want 1", len(status))
+	}
+	for _, w := range status[0].Windows {
+		if w.Total != 100 || w.Good != 98 {
+			t.Errorf("window %s: total=%d good=%d, want 100/98", w.Window, w.Total, w.Good)
+		}
+		// 2% errors against a 1% budget.
+		if w.BurnRate < 1.99 || w.BurnRate > 2.01 {
+			t.Errorf("window %s: burn rate = %v, want 2", w.Window, w.BurnRate)
+		}
+	}
+}
+
+func TestSLOService_LatencySLO(t *testing.T) {
+	svc, _ := newTestSLOService(SLODefinition{
+		Name: "gh-latency", Target: "github", Type: SLOTypeLatency, Objective: 90,
+		LatencyThreshold: 100 * time.Millisecond,
+	})
+
+	svc.ObserveUpstreamCall("github", 50*time.Millisecond, false)
+	svc.ObserveUpstreamCall("github", 150*time.Millisecond, false) // too slow
+	svc.ObserveUpstreamCall("github", 10*time.Millisecond, true)   // failed
+
+	w := svc.Status()[0].Windows[0]
+	if w.Total != 3 || w.Good != 1 {
+		t.Errorf("total=%d good=%d, want 3/1", w.Total, w.Good)
+	}
+	if got := svc.Status()[0].LatencyThresholdMs; got != 100 {
+		t.Errorf("LatencyThresholdMs = %d, want 100", got)
+	}
+}
+
+func TestSLOService_WindowsExpire(t *testing.T) {
+	svc, clock := newTestSLOService(SLODefinition{
+		Name: "gw", Target: SLOTargetGateway, Type: SLOTypeAvailability, Objective: 99.9,
+	})
+
+	svc.ObserveGatewayCall(time.Millisecond, true)
+	*clock = clock.Add(10 * time.Minute)
+	svc.ObserveGatewayCall(time.Millisecond, false)
+
+	totals := map[string]uint64{}
+	for _, w := range svc.Status()[0].Windows {
+		totals[w.Window] = w.Total
+	}
+	if totals["5m"] != 1 || totals["30m"] != 2 || totals["6h"] != 2 {
+		t.Errorf("window totals = %v, want 5m=1 30m=2 6h=2", totals)
+	}
+
+	// After six hours everything has aged out and the ring slots are reused.
+	*clock = clock.Add(6 * time.Hour)
+	for _, w := range svc.Status()[0].Windows {
+		if w.Total != 0 || w.SLI != 100 || w.BurnRate != 0 {
+			t.Errorf("window %s after 6h: %+v, want empty", w.Window, w)
+		}
+	}
+}
+
+func TestSLOService_EvaluatePublishesTransitions(t *testing.T) {
+	svc, clock := newTestSLOService(SLODefinition{
+		Name: "gw", Target: SLOTargetGateway, Type: SLOTypeAvailability, Objective: 99,
+	})
+	bus := &mockDriftEventBus{}
+	svc.SetEventBus(bus)
+	ctx := context.Background()
+
+	// Healthy traffic: no alert, no event.
+	for i := 0; i < 100; i++ {
+		svc.ObserveGatewayCall(time.Millisecond, false)
+	}
+	svc.Evaluate(ctx)
+	if len(bus.events) != 0 {
+		t.Fatalf("healthy traffic published %d events, want 0", len(bus.events))
+	}
+
+	// 50% errors burns 50x the budget: fast burn.
+	for i := 0; i < 100; i++ {
+		svc.ObserveGatewayCall(time.Millisecond, true)
+	}
+	status := svc.Evaluate(ctx)
+	if status[0].Alert != SLOAlertFastBurn {
+		t.Fatalf("Alert = %q, want %q", status[0].Alert, SLOAlertFastBurn)
+	}
+	fired := bus.EventsByType("slo.burn_rate")
+	if len(fired) != 1 || fired[0].Severity != event.SeverityCritical {
+		t.Fatalf("slo.burn_rate events = %+v, want one critical", fired)
+	}
+	if fired[0].Payload.(map[string]interface{})["slo"] != "gw" {
+		t.Errorf("payload = %v, want slo=gw", fired[0].Payload)
+	}
+
+	// Unchanged state does not publish again.
+	svc.Evaluate(ctx)
+	if got := len(bus.EventsByType("slo.burn_rate")); got != 1 {
+		t.Errorf("repeated evaluation published %d burn events, want 1", got)
+	}
+
+	// Once the errors leave every window the alert resolves.
+	*clock = clock.Add(7 * time.Hour)
+	svc.Evaluate(ctx)
+	if got := len(bus.EventsByType("slo.burn_rate_resolved")); got != 1 {
+		t.Errorf("slo.burn_rate_resolved events = %d, want 1", got)
+	}
+}
+
+func TestSLOService_PrometheusAlertRules(t *testing.T) {
+	svc, _ := newTestSLOService(SLODefinition{
+		Name: "gw", Target: SLOTargetGateway, Type: SLOTypeAvailability, Objective: 99.9,
+	})
+
+	out, err := svc.PrometheusAlertRules()
+	if err != nil {
+		t.Fatalf("PrometheusAlertRules() error: %v", err)
+	}
+	var rules prometheusRuleGroups
+	if err := yaml.Unmarshal(out, &rules); err != nil {
+		t.Fatalf("rules are not valid YAML: %v", err)
+	}
+	if len(rules.Groups) != 1 || len(rules.Groups[0].Rules) != 2 {
+		t.Fatalf("rules = %+v, want one group with two rules", rules)
+	}
+	fast := rules.Groups[0].Rules[0]
+	if fast.Labels["severity"] != "critical" || fast.Labels["slo"] != "gw" {
+		t.Errorf("fast burn labels = %v", fast.Labels)
+	}
+	for _, want := range []string{`slo="gw",window="1h"} > 14.4`, "and ignoring(window)", `window="5m"} > 14.4`} {
+		if !strings.Contains(fast.Expr, want) {
+			t.Errorf("fast burn expr %q missing %q", fast.Expr, want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/proxy"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/tokenexchange"
+)
+
+// TokenPassthroughService attaches end-user OAuth tokens to requests forwarded
+// to HTTP upstreams. Each bound upstream either receives the validated user
+// token unchanged or an upstream-audience token obtained via RFC 8693 exchange.
+// It implements proxy.UpstreamCredentialInjector.
+type TokenPassthroughService struct {
+	broker    *tokenexchange.Broker
+	bindings  map[string]tokenexchange.Binding // upstream name or ID -> binding
+	upstreams *UpstreamService
+	logger    *slog.Logger
+}
+
+// Compile-time check that TokenPassthroughService implements UpstreamCredentialInjector.
+var _ proxy.UpstreamCredentialInjector = (*TokenPassthroughService)(nil)
+
+// NewTokenPassthroughService creates a TokenPassthroughService. Bindings are
+// matched against the upstream name first, then the upstream ID.
+func NewTokenPassthroughService(
+	broker *tokenexchange.Broker,
+	bindings []tokenexchange.Binding,
+	upstreams *UpstreamService,
+	logger *slog.Logger,
+) *TokenPassthroughService {
+	byKey := make(map[string]tokenexchange.Binding, len(bindings))
+	for _, b := range bindings {
+		byKey[b.Upstream] = b
+	}
+	return &TokenPassthroughService{
+		broker:    broker,
+		bindings:  byKey,
+		upstreams: upstreams,
+		logger:    logger,
+	}
+}
+
+// UpstreamHeaders returns the Authorization header for upstreamID, or nil if
+// the upstream has no token binding. A bound upstream without a valid user
+// token yields an error so the call fails closed instead of reaching the
+// upstream unauthenticated.
+func (s *TokenPassthroughService) UpstreamHeaders(ctx context.Context, upstreamID string) (map[string]string, error) {
+	binding, name, ok := s.lookup(ctx, upstreamID)
+	if !ok {
+		return nil, nil
+	}
+
+	res, err := s.broker.Resolve(ctx, binding, tokenexchange.SubjectTokenFromContext(ctx))
+	if err != nil {
+		s.logger.Warn("upstream token resolution failed",
+			"upstream", name,
+			"mode", binding.Mode,
+			"error", err,
+		)
+		return nil, fmt.Errorf("upstream %s: %w", name, err)
+	}
+
+	use := &audit.UpstreamTokenUse{
+		Upstream:    name,
+		Mode:        string(res.Mode),
+		Audience:    res.Audience,
+		Subject:     res.Subject,
+		Fingerprint: tokenexchange.Fingerprint(res.Token),
+		Cached:      res.Cached,
+	}
+	if holder := audit.UpstreamTokenFromContext(ctx); holder != nil {
+		holder.Use = use
+	}
+
+	// SECURITY: never log the token itself, only its fingerprint.
+	s.logger.Info("user token attached to upstream request",
+		"upstream", name,
+		"mode", use.Mode,
+		"audience", use.Audience,
+		"subject", use.Subject,
+		"fingerprint", use.Fingerprint,
+		"cached", use.Cached,
+	)
+
+	return map[string]string{"Authorization": "Bearer " + res.Token}, nil
+}
+
+// lookup resolves the binding for an upstream by name, falling back to ID.
+func (s *TokenPassthroughService) lookup(ctx context.Context, upstreamID string) (tokenexchange.Binding, string, bool) {
+	name := upstreamID
+	if s.upstreams != nil {
+		if u, err := s.upstreams.Get(ctx, upstreamID); err == nil {
+			name = u.Name
+		}
+	}
+	if b, ok := s.bindings[name]; ok {
+		return b, name, true
+	}
+	if b, ok := s.bindings[upstreamID]; ok {
+		return b, name, true
+	}
+	return tokenexchange.Binding{}, name, false
+}
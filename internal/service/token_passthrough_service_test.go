@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/audit"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/tokenexchange"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/upstream"
+)
+
+// acceptAllTokenVerifier accepts every user token as subject "alice".
+type acceptAllTokenVerifier struct{}
+
+func (acceptAllTokenVerifier) VerifySubject(context.Context, string) (string, time.Time, error) {
+	return "alice", time.Now().Add(time.Hour), nil
+}
+
+func newTestTokenPassthroughService(t *testing.T, bindings ...tokenexchange.Binding) *TokenPassthroughService {
+	t.Helper()
+	store := newMgrMockUpstreamStore()
+	_ = store.Add(context.Background(), &upstream.Upstream{ID: "up-1", Name: "github", Type: upstream.UpstreamTypeHTTP, URL: "https://example.com/mcp"})
+	_ = store.Add(context.Background(), &upstream.Upstream{ID: "up-2", Name: "files", Type: upstream.UpstreamTypeStdio, Command: "files"})
+	svc := NewUpstreamService(store, nil, testManagerLogger())
+	return NewTokenPassthroughService(tokenexchange.NewBroker(tokenexchange.Config{Verifier: acceptAllTokenVerifier{}}), bindings, svc, testManagerLogger())
+}
+
+func TestTokenPassthroughService_UnboundUpstream(t *testing.T) {
+	s := newTestTokenPassthroughService(t, tokenexchange.Binding{Upstream: "github", Mode: tokenexchange.ModePassthrough})
+
+	headers, err := s.UpstreamHeaders(tokenexchange.WithSubjectToken(context.Background(), "tok"), "up-2")
+	if err != nil {
+		t.Fatalf("UpstreamHeaders() error = %v", err)
+	}
+	if headers != nil {
+		t.Errorf("unbound upstream must not receive headers, got %v", headers)
+	}
+}
+
+func TestTokenPassthroughService_PassthroughByName(t *testing.T) {
+	s := newTestTokenPassthroughService(t, tokenexchange.Binding{Upstream: "github", Mode: tokenexchange.ModePassthrough})
+
+	ctx, holder := audit.NewUpstreamTokenContext(context.Background())
+	ctx = tokenexchange.WithSubjectToken(ctx, "user-token")
+
+	headers, err := s.UpstreamHeaders(ctx, "up-1")
+	if err != nil {
+		t.Fatalf("UpstreamHeaders() error = %v", err)
+	}
+	if got := headers["Authorization"]; got != "Bearer user-token" {
+		t.Errorf("Authorization = %q, want %q", got, "Bearer user-token")
+	}
+	if holder.Use == nil {
+		t.Fatal("expected token use to be recorded for audit")
+	}
+	if holder.Use.Upstream != "github" || holder.Use.Mode != "passthrough" {
+		t.Errorf("audit use = %+v", holder.Use)
+	}
+	if holder.Use.Fingerprint != tokenexchange.Fingerprint("user-token") {
+		t.Errorf("Fingerprint = %q", holder.Use.Fingerprint)
+	}
+}
+
+func TestTokenPassthroughService_MissingTokenFailsClosed(t *testing.T) {
+	s := newTestTokenPassthroughService(t, tokenexchange.Binding{Upstream: "up-1", Mode: tokenexchange.ModePassthrough})
+
+	_, err := s.UpstreamHeaders(context.Background(), "up-1")
+	if !errors.Is(err, tokenexchange.ErrNoToken) {
+		t.Fatalf("UpstreamHeaders() error = %v, want ErrNoToken", err)
+	}
+}
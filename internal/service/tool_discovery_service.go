@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
@@ -18,6 +19,31 @@ type UpstreamLister interface {
 	Get(ctx context.Context, id string) (*upstream.Upstream, error)
 }
 
+// DefaultDiscoveryMaxPages bounds the tools/list pages fetched from one
+// upstream, so a server that keeps returning a cursor cannot stall discovery.
+const DefaultDiscoveryMaxPages = 100
+
+// DiscoveryStatus reports the last tool discovery of an upstream.
+type DiscoveryStatus struct {
+	UpstreamID string    `json:"upstream_id"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMS int64     `json:"duration_ms"`
+	// Pages is the number of tools/list pages fetched successfully.
+	Pages int `json:"pages"`
+	// PageDurationsMS is the duration of each tools/list request, including
+	// a failed last one.
+	PageDurationsMS []int64 `json:"page_durations_ms,omitempty"`
+	Tools           int     `json:"tools"`
+	// Filtered is the number of listed tools hidden by the upstream's
+	// tool_include and tool_exclude patterns.
+	Filtered int `json:"filtered,omitempty"`
+	// Incomplete is set when a page after the first failed or the page
+	// limit was reached. The tools fetched so far are kept, together with
+	// the previously discovered tools that were not seen again.
+	Incomplete bool   `json:"incomplete"`
+	Error      string `json:"error,omitempty"`
+}
+
 // ToolDiscoveryService discovers tools from connected upstream MCP servers
 // and maintains a shared ToolCache for routing and tools/list aggregation.
 type ToolDiscoveryService struct {
@@ -34,6 +60,8 @@ type ToolDiscoveryService struct {
 	wg                     sync.WaitGroup
 	notifier               ToolChangeNotifier
 	toolSecurityService    *ToolSecurityService
+	maxPages               int
+	statuses               map[string]DiscoveryStatus
 }
 
 // NewToolDiscoveryService creates a new ToolDiscoveryService.
@@ -53,6 +81,8 @@ func NewToolDiscoveryService(
 		fullRediscoveryInterval: 5 * time.Minute,
 		ctx:                    ctx,
 		cancel:                 cancel,
+		maxPages:               DefaultDiscoveryMaxPages,
+		statuses:               make(map[string]DiscoveryStatus),
 	}
 }
 
@@ -106,11 +136,35 @@ func (s *ToolDiscoveryService) DiscoverAll(ctx context.Context) error {
 
 // DiscoverFromUpstream discovers tools from a single upstream by ID.
 // It creates a temporary MCP client, performs the full MCP handshake
-// (initialize → notifications/initialized → tools/list), parses the response,
-// and stores all tools in the cache. The ToolCache handles namespacing
-// automatically when tool names conflict across upstreams.
+// (initialize → notifications/initialized → tools/list, following
+// nextCursor across pages), parses the responses, and stores all tools in
+// the cache. The ToolCache handles namespacing automatically when tool names
+// conflict across upstreams. A failure after the first page is not an
+// error: the discovery is recorded as incomplete in DiscoveryStatus.
 // Returns the number of tools stored.
 func (s *ToolDiscoveryService) DiscoverFromUpstream(ctx context.Context, upstreamID string) (int, error) {
+	status := DiscoveryStatus{UpstreamID: upstreamID, StartedAt: time.Now().UTC()}
+	count, err := s.discover(ctx, upstreamID, &status)
+	if errors.Is(err, upstream.ErrUpstreamNotFound) {
+		s.mu.Lock()
+		delete(s.statuses, upstreamID)
+		s.mu.Unlock()
+		return count, err
+	}
+	status.DurationMS = time.Since(status.StartedAt).Milliseconds()
+	status.Tools = count
+	if err != nil {
+		status.Error = err.Error()
+	}
+	s.mu.Lock()
+	s.statuses[upstreamID] = status
+	s.mu.Unlock()
+	return count, err
+}
+
+// discover runs the discovery of DiscoverFromUpstream and fills in the
+// page counts of status.
+func (s *ToolDiscoveryService) discover(ctx context.Context, upstreamID string, status *DiscoveryStatus) (int, error) {
 	// Get upstream config.
 	u, err := s.upstreamService.Get(ctx, upstreamID)
 	if err != nil {
@@ -238,46 +292,94 @@ func (s *ToolDiscoveryService) DiscoverFromUpstream(ctx context.Context, upstrea
 		return 0, fmt.Errorf("write notifications/initialized to %s: %w", upstreamID, err)
 	}
 
-	// --- Step 3: Send tools/list ---
-	reqID := fmt.Sprintf("discovery-%s", upstreamID)
-	toolsReq := fmt.Sprintf(`{"jsonrpc":"2.0","id":%q,"method":"tools/list"}`, reqID)
-	if _, err := fmt.Fprintln(stdin, toolsReq); err != nil {
-		return 0, fmt.Errorf("write tools/list to %s: %w", upstreamID, err)
+	// --- Step 3: Send tools/list, following nextCursor until exhausted ---
+	type listedTool struct {
+		Name        string          `json:"name"`
+		Description string          `json:"description"`
+		InputSchema json.RawMessage `json:"inputSchema"`
 	}
+	listPage := func(page int, cursor string) ([]listedTool, string, error) {
+		reqID := fmt.Sprintf("discovery-%s", upstreamID)
+		if page > 1 {
+			reqID = fmt.Sprintf("discovery-%s-%d", upstreamID, page)
+		}
+		req := map[string]interface{}{"jsonrpc": "2.0", "id": reqID, "method": "tools/list"}
+		if cursor != "" {
+			req["params"] = map[string]string{"cursor": cursor}
+		}
+		toolsReq, _ := json.Marshal(req)
+		if _, err := fmt.Fprintln(stdin, string(toolsReq)); err != nil {
+			return nil, "", fmt.Errorf("write tools/list to %s: %w", upstreamID, err)
+		}
 
-	responseLine, err := readResponse("tools/list")
-	if err != nil {
-		return 0, err
-	}
+		responseLine, err := readResponse("tools/list")
+		if err != nil {
+			return nil, "", err
+		}
 
-	// Parse JSON-RPC response.
-	var resp struct {
-		JSONRPC string `json:"jsonrpc"`
-		ID      string `json:"id"`
-		Result  struct {
-			Tools []struct {
-				Name        string          `json:"name"`
-				Description string          `json:"description"`
-				InputSchema json.RawMessage `json:"inputSchema"`
-			} `json:"tools"`
-		} `json:"result"`
-		Error *struct {
-			Code    int    `json:"code"`
-			Message string `json:"message"`
-		} `json:"error"`
-	}
+		// Parse JSON-RPC response.
+		var resp struct {
+			JSONRPC string `json:"jsonrpc"`
+			ID      string `json:"id"`
+			Result  struct {
+				Tools      []listedTool `json:"tools"`
+				NextCursor string       `json:"nextCursor"`
+			} `json:"result"`
+			Error *struct {
+				Code    int    `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
 
-	if err := json.Unmarshal([]byte(responseLine), &resp); err != nil {
-		return 0, fmt.Errorf("parse response from %s: %w", upstreamID, err)
-	}
+		if err := json.Unmarshal([]byte(responseLine), &resp); err != nil {
+			return nil, "", fmt.Errorf("parse response from %s: %w", upstreamID, err)
+		}
+
+		if resp.ID != reqID {
+			return nil, "", fmt.Errorf("tools/list response ID mismatch from %s: got %q, want %q", upstreamID, resp.ID, reqID)
+		}
 
-	if resp.ID != reqID {
-		return 0, fmt.Errorf("tools/list response ID mismatch from %s: got %q, want %q", upstreamID, resp.ID, reqID)
+		if resp.Error != nil {
+			return nil, "", fmt.Errorf("tools/list error from %s: %s (code %d)",
+				upstreamID, resp.Error.Message, resp.Error.Code)
+		}
+		return resp.Result.Tools, resp.Result.NextCursor, nil
 	}
 
-	if resp.Error != nil {
-		return 0, fmt.Errorf("tools/list error from %s: %s (code %d)",
-			upstreamID, resp.Error.Message, resp.Error.Code)
+	s.mu.Lock()
+	maxPages := s.maxPages
+	s.mu.Unlock()
+
+	var listed []listedTool
+	var pageErr error
+	seenCursors := make(map[string]bool)
+	cursor := ""
+	for page := 1; ; page++ {
+		if page > maxPages {
+			pageErr = fmt.Errorf("tools/list from %s: stopped after %d pages", upstreamID, maxPages)
+			break
+		}
+		pageStart := time.Now()
+		tools, next, err := listPage(page, cursor)
+		status.PageDurationsMS = append(status.PageDurationsMS, time.Since(pageStart).Milliseconds())
+		if err != nil {
+			pageErr = err
+			break
+		}
+		status.Pages++
+		listed = append(listed, tools...)
+		if next == "" {
+			break
+		}
+		if seenCursors[next] {
+			pageErr = fmt.Errorf("tools/list from %s: cursor %q repeated", upstreamID, next)
+			break
+		}
+		seenCursors[next] = true
+		cursor = next
+	}
+	if pageErr != nil && status.Pages == 0 {
+		return 0, pageErr
 	}
 
 	// Build DiscoveredTool entries. With namespacing, all tools are stored
@@ -285,25 +387,55 @@ func (s *ToolDiscoveryService) DiscoverFromUpstream(ctx context.Context, upstrea
 	// multiple upstreams share the same tool name.
 	now := time.Now()
 	var allTools []*upstream.DiscoveredTool
+	seen := make(map[string]bool, len(listed))
 
-	for _, t := range resp.Result.Tools {
+	for _, t := range listed {
+		if seen[t.Name] {
+			continue // repeated on a later page
+		}
+		seen[t.Name] = true
+		if !u.ExposesTool(t.Name) {
+			status.Filtered++
+			continue
+		}
 		allTools = append(allTools, &upstream.DiscoveredTool{
 			Name:         t.Name,
 			Description:  t.Description,
 			InputSchema:  t.InputSchema,
 			UpstreamID:   upstreamID,
 			UpstreamName: u.Name,
+			ToolPrefix:   u.ToolPrefix,
 			DiscoveredAt: now,
 		})
 	}
 
+	if pageErr != nil {
+		// The missing pages most likely hold tools found before: keep them
+		// rather than dropping them from clients until the next discovery.
+		status.Incomplete = true
+		status.Error = pageErr.Error()
+		for _, t := range s.cache.GetToolsByUpstream(upstreamID) {
+			if !seen[t.Name] && u.ExposesTool(t.Name) {
+				t.ToolPrefix = u.ToolPrefix
+				allTools = append(allTools, t)
+			}
+		}
+		s.logger.Warn("tool discovery incomplete, keeping the tools fetched so far",
+			"upstream_id", upstreamID,
+			"upstream_name", u.Name,
+			"pages", status.Pages,
+			"error", pageErr)
+	}
+
 	s.cache.SetToolsForUpstream(upstreamID, allTools)
 
 	count := len(allTools)
 	s.logger.Info("discovered tools",
 		"upstream_id", upstreamID,
 		"upstream_name", u.Name,
-		"tools", count)
+		"tools", count,
+		"filtered", status.Filtered,
+		"pages", status.Pages)
 
 	// Notify connected clients about tool list change.
 	s.notifyToolsChanged()
@@ -389,7 +521,8 @@ func (s *ToolDiscoveryService) SetFullRediscoveryInterval(d time.Duration) {
 	s.fullRediscoveryInterval = d
 }
 
-// retryEmptyUpstreams retries discovery for upstreams that have 0 tools cached.
+// retryEmptyUpstreams retries discovery for upstreams that have 0 tools
+// cached or whose last discovery was incomplete.
 func (s *ToolDiscoveryService) retryEmptyUpstreams(ctx context.Context) {
 	upstreams, err := s.upstreamService.List(ctx)
 	if err != nil {
@@ -403,13 +536,14 @@ func (s *ToolDiscoveryService) retryEmptyUpstreams(ctx context.Context) {
 			continue
 		}
 
-		// Only retry upstreams with 0 tools.
+		// Only retry upstreams with 0 tools or an incomplete discovery.
 		tools := s.cache.GetToolsByUpstream(u.ID)
-		if len(tools) > 0 {
+		st, _ := s.DiscoveryStatus(u.ID)
+		if len(tools) > 0 && !st.Incomplete {
 			continue
 		}
 
-		s.logger.Info("retrying discovery for upstream with 0 tools",
+		s.logger.Info("retrying discovery for upstream with 0 tools or incomplete discovery",
 			"upstream_id", u.ID, "upstream_name", u.Name)
 
 		count, err := s.DiscoverFromUpstream(ctx, u.ID)
@@ -452,6 +586,29 @@ func (s *ToolDiscoveryService) SetRetryInterval(d time.Duration) {
 	s.retryInterval = d
 }
 
+// SetMaxPages sets the most tools/list pages fetched from one upstream.
+// Values below 1 restore DefaultDiscoveryMaxPages.
+func (s *ToolDiscoveryService) SetMaxPages(n int) {
+	if n < 1 {
+		n = DefaultDiscoveryMaxPages
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxPages = n
+}
+
+// DiscoveryStatus returns the last discovery of an upstream, and false when
+// it was never discovered.
+func (s *ToolDiscoveryService) DiscoveryStatus(upstreamID string) (DiscoveryStatus, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.statuses[upstreamID]
+	if ok {
+		st.PageDurationsMS = append([]int64(nil), st.PageDurationsMS...)
+	}
+	return st, ok
+}
+
 // SetNotifier sets the tool change notifier for broadcasting list_changed notifications.
 func (s *ToolDiscoveryService) SetNotifier(n ToolChangeNotifier) {
 	s.mu.Lock()
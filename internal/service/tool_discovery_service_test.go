@@ -296,6 +296,9 @@ type discoveryMockClient struct {
 	startErr    error
 	closeErr    error
 	delay       time.Duration // simulate slow response
+	pageSize    int           // tools per tools/list page; 0 returns all in one
+	failPage    int           // 1-based tools/list page answered with an error
+	loopCursor  bool          // always return the same nextCursor
 	stdinRead   *io.PipeReader
 	stdinWrite  *io.PipeWriter
 	stdoutRead  *io.PipeReader
@@ -347,6 +350,9 @@ func (m *discoveryMockClient) handleRequests() {
 			JSONRPC string `json:"jsonrpc"`
 			ID      string `json:"id"`
 			Method  string `json:"method"`
+			Params  struct {
+				Cursor string `json:"cursor"`
+			} `json:"params"`
 		}
 		if err := json.Unmarshal([]byte(line), &req); err != nil {
 			continue
@@ -384,15 +390,37 @@ func (m *discoveryMockClient) handleRequests() {
 				req.ID,
 			)
 		default:
-			// tools/list and other methods: return tools.
-			toolsJSON, _ := json.Marshal(m.tools)
-			resp = fmt.Sprintf(`{"jsonrpc":"2.0","id":%q,"result":{"tools":%s}}`, req.ID, string(toolsJSON))
+			// tools/list and other methods: return tools, one page at a
+			// time when pageSize is set. The cursor is the offset.
+			resp = m.toolsPage(req.ID, req.Params.Cursor)
 		}
 
 		_, _ = m.stdoutWrite.Write([]byte(resp + "\n"))
 	}
 }
 
+// toolsPage answers a tools/list request for the page starting at cursor.
+func (m *discoveryMockClient) toolsPage(id, cursor string) string {
+	if m.pageSize == 0 {
+		toolsJSON, _ := json.Marshal(m.tools)
+		return fmt.Sprintf(`{"jsonrpc":"2.0","id":%q,"result":{"tools":%s}}`, id, string(toolsJSON))
+	}
+	offset := 0
+	_, _ = fmt.Sscan(cursor, &offset)
+	if m.failPage > 0 && offset/m.pageSize+1 == m.failPage {
+		return fmt.Sprintf(`{"jsonrpc":"2.0","id":%q,"error":{"code":-32603,"message":"backend unavailable"}}`, id)
+	}
+	end := min(offset+m.pageSize, len(m.tools))
+	toolsJSON, _ := json.Marshal(m.tools[offset:end])
+	next := ""
+	if m.loopCursor {
+		next = "0"
+	} else if end < len(m.tools) {
+		next = fmt.Sprint(end)
+	}
+	return fmt.Sprintf(`{"jsonrpc":"2.0","id":%q,"result":{"tools":%s,"nextCursor":%q}}`, id, string(toolsJSON), next)
+}
+
 func (m *discoveryMockClient) Wait() error {
 	<-m.waitCh
 	return nil
@@ -496,6 +524,55 @@ func TestToolDiscoveryService_DiscoverFromUpstream(t *testing.T) {
 	}
 }
 
+func TestToolDiscoveryService_ToolFilters(t *testing.T) {
+	cache := upstream.NewToolCache()
+	lister := &discoveryMockUpstreamLister{
+		upstreams: []upstream.Upstream{
+			{
+				ID:          "upstream-1",
+				Name:        "filesystem",
+				Type:        upstream.UpstreamTypeStdio,
+				Enabled:     true,
+				Command:     "/usr/bin/echo",
+				Status:      upstream.StatusConnected,
+				ToolInclude: []string{"read_*"},
+				ToolExclude: []string{"read_secret"},
+			},
+		},
+	}
+
+	mockTools := []discoveryMockTool{
+		{Name: "read_file", Description: "Read a file"},
+		{Name: "read_secret", Description: "Read a secret"},
+		{Name: "write_file", Description: "Write a file"},
+	}
+	factory := func(u *upstream.Upstream) (outbound.MCPClient, error) {
+		return newDiscoveryMockClient(mockTools), nil
+	}
+
+	svc := NewToolDiscoveryService(lister, cache, factory, slog.Default())
+	defer svc.Stop()
+
+	count, err := svc.DiscoverFromUpstream(context.Background(), "upstream-1")
+	if err != nil {
+		t.Fatalf("DiscoverFromUpstream error: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("count = %d, want 1", count)
+	}
+	if _, ok := cache.GetTool("read_file"); !ok {
+		t.Error("read_file should be exposed")
+	}
+	for _, name := range []string{"read_secret", "write_file"} {
+		if _, ok := cache.GetTool(name); ok {
+			t.Errorf("%s should be filtered out", name)
+		}
+	}
+	if status, _ := svc.DiscoveryStatus("upstream-1"); status.Filtered != 2 {
+		t.Errorf("status.Filtered = %d, want 2", status.Filtered)
+	}
+}
+
 func TestToolDiscoveryService_DiscoverAll(t *testing.T) {
 	cache := upstream.NewToolCache()
 	lister := &discoveryMockUpstreamLister{
@@ -900,3 +977,107 @@ func TestToolDiscoveryService_DiscoverFromUpstream_Timeout(t *testing.T) {
 		t.Fatal("expected timeout error")
 	}
 }
+
+func TestToolDiscoveryService_DiscoverFromUpstream_Pagination(t *testing.T) {
+	var mockTools []discoveryMockTool
+	for i := 0; i < 7; i++ {
+		mockTools = append(mockTools, discoveryMockTool{Name: fmt.Sprintf("tool_%d", i)})
+	}
+	lister := &discoveryMockUpstreamLister{
+		upstreams: []upstream.Upstream{{ID: "upstream-1", Name: "big", Type: upstream.UpstreamTypeStdio, Enabled: true}},
+	}
+
+	tests := []struct {
+		name           string
+		pageSize       int
+		failPage       int
+		loopCursor     bool
+		maxPages       int
+		wantTools      int
+		wantPages      int
+		wantIncomplete bool
+		wantErr        bool
+	}{
+		{name: "all pages", pageSize: 3, wantTools: 7, wantPages: 3},
+		{name: "later page fails", pageSize: 3, failPage: 3, wantTools: 6, wantPages: 2, wantIncomplete: true},
+		{name: "first page fails", pageSize: 3, failPage: 1, wantErr: true},
+		{name: "page limit", pageSize: 2, maxPages: 2, wantTools: 4, wantPages: 2, wantIncomplete: true},
+		{name: "repeated cursor", pageSize: 2, loopCursor: true, wantTools: 2, wantPages: 2, wantIncomplete: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := upstream.NewToolCache()
+			factory := func(u *upstream.Upstream) (outbound.MCPClient, error) {
+				c := newDiscoveryMockClient(mockTools)
+				c.pageSize, c.failPage, c.loopCursor = tt.pageSize, tt.failPage, tt.loopCursor
+				return c, nil
+			}
+			svc := NewToolDiscoveryService(lister, cache, factory, slog.Default())
+			defer svc.Stop()
+			svc.SetMaxPages(tt.maxPages)
+
+			count, err := svc.DiscoverFromUpstream(context.Background(), "upstream-1")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DiscoverFromUpstream error = %v, wantErr %v", err, tt.wantErr)
+			}
+			st, ok := svc.DiscoveryStatus("upstream-1")
+			if !ok {
+				t.Fatal("no discovery status recorded")
+			}
+			if tt.wantErr {
+				if st.Error == "" || st.Pages != 0 {
+					t.Errorf("status = %+v, want the error and no pages", st)
+				}
+				return
+			}
+			if count != tt.wantTools || len(cache.GetToolsByUpstream("upstream-1")) != tt.wantTools {
+				t.Errorf("count = %d, want %d", count, tt.wantTools)
+			}
+			if st.Pages != tt.wantPages || st.Tools != tt.wantTools || st.Incomplete != tt.wantIncomplete {
+				t.Errorf("status = %+v, want %d pages, %d tools, incomplete %v", st, tt.wantPages, tt.wantTools, tt.wantIncomplete)
+			}
+			if tt.wantIncomplete && st.Error == "" {
+				t.Error("incomplete status should carry the error")
+			}
+			if len(st.PageDurationsMS) < st.Pages {
+				t.Errorf("page durations = %v, want one per page", st.PageDurationsMS)
+			}
+		})
+	}
+}
+
+func TestToolDiscoveryService_IncompleteKeepsPreviousTools(t *testing.T) {
+	mockTools := []discoveryMockTool{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}}
+	lister := &discoveryMockUpstreamLister{
+		upstreams: []upstream.Upstream{{ID: "upstream-1", Name: "big", Type: upstream.UpstreamTypeStdio, Enabled: true}},
+	}
+	failPage := 0
+	factory := func(u *upstream.Upstream) (outbound.MCPClient, error) {
+		c := newDiscoveryMockClient(mockTools)
+		c.pageSize, c.failPage = 2, failPage
+		return c, nil
+	}
+	cache := upstream.NewToolCache()
+	svc := NewToolDiscoveryService(lister, cache, factory, slog.Default())
+	defer svc.Stop()
+
+	if _, err := svc.DiscoverFromUpstream(context.Background(), "upstream-1"); err != nil {
+		t.Fatalf("first discovery: %v", err)
+	}
+
+	// The second page now fails: c and d come from the previous discovery.
+	failPage = 2
+	count, err := svc.DiscoverFromUpstream(context.Background(), "upstream-1")
+	if err != nil {
+		t.Fatalf("second discovery: %v", err)
+	}
+	if count != 4 {
+		t.Errorf("count = %d, want 4 (2 fetched + 2 kept)", count)
+	}
+	if _, ok := cache.GetTool("d"); !ok {
+		t.Error("tool d from the failed page should be kept")
+	}
+	if st, _ := svc.DiscoveryStatus("upstream-1"); !st.Incomplete {
+		t.Errorf("status = %+v, want incomplete", st)
+	}
+}
@@ -40,7 +40,7 @@ type DriftReport struct {
 // ToolSecurityService manages tool baseline capture, drift detection, and quarantine.
 type ToolSecurityService struct {
 	toolCache   *upstream.ToolCache
-	stateStore  *state.FileStateStore
+	stateStore  state.Store
 	logger      *slog.Logger
 	mu          sync.RWMutex
 	baseline    map[string]ToolBaselineEntry
@@ -49,7 +49,7 @@ type ToolSecurityService struct {
 }
 
 // NewToolSecurityService creates a new ToolSecurityService.
-func NewToolSecurityService(toolCache *upstream.ToolCache, stateStore *state.FileStateStore, logger *slog.Logger) *ToolSecurityService {
+func NewToolSecurityService(toolCache *upstream.ToolCache, stateStore state.Store, logger *slog.Logger) *ToolSecurityService {
 	return &ToolSecurityService{
 		toolCache:   toolCache,
 		stateStore:  stateStore,
@@ -364,6 +364,7 @@ func (s *ToolSecurityService) CheckIntegrityAndEmit(ctx context.Context) {
 	for _, t := range currentTools {
 		upstreamByTool[t.Name] = t.UpstreamName
 	}
+	var quarantined []string
 
 	for _, d := range drifts {
 		var evtType string
@@ -380,6 +381,7 @@ func (s *ToolSecurityService) CheckIntegrityAndEmit(ctx context.Context) {
 				s.logger.Warn("auto-quarantine failed for new tool", "tool", d.ToolName, "error", err)
 			} else {
 				s.logger.Warn("new tool auto-quarantined until admin review", "tool", d.ToolName)
+				quarantined = append(quarantined, d.ToolName)
 			}
 		case "removed":
 			evtType = "tool.removed"
@@ -392,6 +394,7 @@ func (s *ToolSecurityService) CheckIntegrityAndEmit(ctx context.Context) {
 				s.logger.Warn("auto-quarantine failed", "tool", d.ToolName, "error", err)
 			} else {
 				s.logger.Warn("tool auto-quarantined due to schema change", "tool", d.ToolName)
+				quarantined = append(quarantined, d.ToolName)
 			}
 		default:
 			continue
@@ -409,6 +412,24 @@ func (s *ToolSecurityService) CheckIntegrityAndEmit(ctx context.Context) {
 			},
 		})
 	}
+
+	// One upstream-level event per affected upstream, so upstream webhooks
+	// see quarantines without subscribing to every tool event.
+	byUpstream := make(map[string][]string)
+	for _, name := range quarantined {
+		byUpstream[upstreamByTool[name]] = append(byUpstream[upstreamByTool[name]], name)
+	}
+	for upstreamName, tools := range byUpstream {
+		bus.Publish(ctx, event.Event{
+			Type:     EventUpstreamToolsQuarantined,
+			Source:   "tool-integrity",
+			Severity: event.SeverityWarning,
+			Payload: map[string]interface{}{
+				"upstream_name": upstreamName,
+				"tools":         tools,
+			},
+		})
+	}
 }
 
 // AcceptChange updates the baseline for a single tool to accept its current definition.
@@ -0,0 +1,341 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/storage"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/toolstats"
+)
+
+// toolStatsSeries is the time series holding per-tool rollups. Each data
+// point is one tool's rollup for one flush interval (tag "tool").
+const toolStatsSeries = "tools:stats"
+
+// DefaultToolStatsFlushInterval is how often per-tool rollups are persisted.
+const DefaultToolStatsFlushInterval = 5 * time.Minute
+
+// Sort keys for ToolStatsService.Top.
+const (
+	ToolStatsByCalls     = "calls"
+	ToolStatsByErrors    = "errors"
+	ToolStatsByErrorRate = "error_rate"
+	ToolStatsByP50       = "p50"
+	ToolStatsByP99       = "p99"
+)
+
+// ToolStatsSummary is the statistics of one tool over a window.
+type ToolStatsSummary struct {
+	Tool      string             `json:"tool"`
+	Calls     int64              `json:"calls"`
+	Errors    int64              `json:"errors"`
+	Denied    int64              `json:"denied"`
+	ErrorRate float64            `json:"error_rate"`
+	Latency   ToolLatencySummary `json:"latency"`
+	// Histogram is only filled for single-tool queries.
+	Histogram []ToolLatencyBucket `json:"histogram,omitempty"`
+}
+
+// ToolLatencySummary holds latency percentiles in milliseconds.
+type ToolLatencySummary struct {
+	P50  float64 `json:"p50_ms"`
+	P90  float64 `json:"p90_ms"`
+	P95  float64 `json:"p95_ms"`
+	P99  float64 `json:"p99_ms"`
+	Max  float64 `json:"max_ms"`
+	Mean float64 `json:"mean_ms"`
+}
+
+// ToolLatencyBucket is one non-empty latency histogram bucket.
+type ToolLatencyBucket struct {
+	LeMs  float64 `json:"le_ms"`
+	Count uint64  `json:"count"`
+}
+
+// ToolStatsService tracks per-tool invocation counts, error rates and
+// latency histograms. Calls are aggregated in memory and flushed as
+// per-interval rollups to the time series store, so windowed queries
+// survive restarts. Without a store, queries cover the time since start.
+type ToolStatsService struct {
+	mu      sync.Mutex
+	pending map[string]*toolstats.Rollup // since the last flush
+	total   map[string]*toolstats.Rollup // since start (or the last Reset)
+	since   time.Time
+	capWarn bool
+
+	tsStore storage.TimeSeriesStore
+	logger  *slog.Logger
+}
+
+// NewToolStatsService creates a ToolStatsService.
+func NewToolStatsService(logger *slog.Logger) *ToolStatsService {
+	return &ToolStatsService{
+		pending: make(map[string]*toolstats.Rollup),
+		total:   make(map[string]*toolstats.Rollup),
+		since:   time.Now().UTC(),
+		logger:  logger,
+	}
+}
+
+// SetTimeSeriesStore wires the store used to persist rollups.
+func (s *ToolStatsService) SetTimeSeriesStore(ts storage.TimeSeriesStore) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tsStore = ts
+}
+
+// RecordToolCall records one call of tool.
+func (s *ToolStatsService) RecordToolCall(tool string, outcome toolstats.Outcome, latency time.Duration) {
+	if tool == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.total[tool]; !exists && len(s.total) >= maxMapEntries {
+		if !s.capWarn {
+			s.capWarn = true
+			s.logger.Warn("tool stats: tool map reached size cap, new tools will not be tracked", "cap", maxMapEntries)
+		}
+		return
+	}
+	for _, m := range []map[string]*toolstats.Rollup{s.pending, s.total} {
+		r, ok := m[tool]
+		if !ok {
+			r = &toolstats.Rollup{}
+			m[tool] = r
+		}
+		r.Record(outcome, latency)
+	}
+}
+
+// Flush persists the rollups collected since the last flush. Without a
+// store it is a no-op.
+func (s *ToolStatsService) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	ts := s.tsStore
+	if ts == nil || len(s.pending) == 0 {
+		s.mu.Unlock()
+		return nil
+	}
+	pending := s.pending
+	s.pending = make(map[string]*toolstats.Rollup)
+	s.mu.Unlock()
+
+	now := time.Now().UTC()
+	var firstErr error
+	for tool, r := range pending {
+		payload, err := json.Marshal(r)
+		if err != nil {
+			continue
+		}
+		err = ts.Append(ctx, toolStatsSeries, storage.DataPoint{
+			Timestamp: now,
+			Value:     float64(r.Calls),
+			Tags:      map[string]string{"tool": tool},
+			Payload:   payload,
+		})
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("persist tool stats for %s: %w", tool, err)
+		}
+	}
+	return firstErr
+}
+
+// Run flushes rollups every interval until ctx is cancelled. Callers flush
+// once more on shutdown so calls recorded since the last tick are kept.
+func (s *ToolStatsService) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultToolStatsFlushInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Flush(ctx); err != nil {
+				s.logger.Warn("failed to flush tool stats", "error", err)
+			}
+		}
+	}
+}
+
+// rollups returns the merged rollups of every tool over the last window
+// (persisted rollups plus calls not yet flushed). tool limits the result to
+// one tool when non-empty. Without a store, the window is ignored and the
+// rollups since start are returned.
+func (s *ToolStatsService) rollups(ctx context.Context, tool string, window time.Duration) (map[string]*toolstats.Rollup, error) {
+	s.mu.Lock()
+	ts := s.tsStore
+	out := make(map[string]*toolstats.Rollup)
+	src := s.total
+	if ts != nil {
+		src = s.pending
+	}
+	for name, r := range src {
+		if tool != "" && name != tool {
+			continue
+		}
+		merged := &toolstats.Rollup{}
+		merged.Merge(r)
+		out[name] = merged
+	}
+	s.mu.Unlock()
+
+	if ts == nil {
+		return out, nil
+	}
+
+	now := time.Now().UTC()
+	points, err := ts.Query(ctx, toolStatsSeries, now.Add(-window), now)
+	if err != nil {
+		return nil, fmt.Errorf("query tool stats: %w", err)
+	}
+	for _, p := range points {
+		name := p.Tags["tool"]
+		if name == "" || (tool != "" && name != tool) {
+			continue
+		}
+		var r toolstats.Rollup
+		if err := json.Unmarshal(p.Payload, &r); err != nil {
+			continue
+		}
+		merged, ok := out[name]
+		if !ok {
+			if len(out) >= maxMapEntries {
+				continue
+			}
+			merged = &toolstats.Rollup{}
+			out[name] = merged
+		}
+		merged.Merge(&r)
+	}
+	return out, nil
+}
+
+// ToolStats returns the statistics of one tool over the last window,
+// including its latency histogram. found is false when the tool has no
+// recorded calls in the window.
+func (s *ToolStatsService) ToolStats(ctx context.Context, tool string, window time.Duration) (summary ToolStatsSummary, found bool, err error) {
+	all, err := s.rollups(ctx, tool, window)
+	if err != nil {
+		return ToolStatsSummary{}, false, err
+	}
+	r, ok := all[tool]
+	if !ok {
+		return ToolStatsSummary{Tool: tool, Histogram: []ToolLatencyBucket{}}, false, nil
+	}
+	summary = summarizeToolRollup(tool, r)
+	summary.Histogram = make([]ToolLatencyBucket, 0)
+	for _, b := range r.Latency.Buckets() {
+		summary.Histogram = append(summary.Histogram, ToolLatencyBucket{LeMs: durationMs(b.UpperBound), Count: b.Count})
+	}
+	return summary, true, nil
+}
+
+// Top returns the limit tools with the highest value of by over the last
+// window. Ties are broken by call count, then name.
+func (s *ToolStatsService) Top(ctx context.Context, by string, limit int, window time.Duration) ([]ToolStatsSummary, error) {
+	var key func(ToolStatsSummary) float64
+	switch by {
+	case ToolStatsByCalls, "":
+		key = func(t ToolStatsSummary) float64 { return float64(t.Calls) }
+	case ToolStatsByErrors:
+		key = func(t ToolStatsSummary) float64 { return float64(t.Errors) }
+	case ToolStatsByErrorRate:
+		key = func(t ToolStatsSummary) float64 { return t.ErrorRate }
+	case ToolStatsByP50:
+		key = func(t ToolStatsSummary) float64 { return t.Latency.P50 }
+	case ToolStatsByP99:
+		key = func(t ToolStatsSummary) float64 { return t.Latency.P99 }
+	default:
+		return nil, fmt.Errorf("unknown sort key %q", by)
+	}
+
+	all, err := s.rollups(ctx, "", window)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]ToolStatsSummary, 0, len(all))
+	for name, r := range all {
+		result = append(result, summarizeToolRollup(name, r))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		ki, kj := key(result[i]), key(result[j])
+		if ki != kj {
+			return ki > kj
+		}
+		if result[i].Calls != result[j].Calls {
+			return result[i].Calls > result[j].Calls
+		}
+		return result[i].Tool < result[j].Tool
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// Persistent reports whether rollups are persisted. When false, queries
+// cover the time since Since and ignore the window.
+func (s *ToolStatsService) Persistent() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tsStore != nil
+}
+
+// Since returns when in-memory tracking started (or was last reset).
+func (s *ToolStatsService) Since() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.since
+}
+
+// Reset clears all statistics, including persisted rollups.
+func (s *ToolStatsService) Reset(ctx context.Context) error {
+	s.mu.Lock()
+	s.pending = make(map[string]*toolstats.Rollup)
+	s.total = make(map[string]*toolstats.Rollup)
+	s.since = time.Now().UTC()
+	s.capWarn = false
+	ts := s.tsStore
+	s.mu.Unlock()
+
+	if ts == nil {
+		return nil
+	}
+	if _, err := ts.DeleteSeries(ctx, toolStatsSeries); err != nil {
+		return fmt.Errorf("delete tool stats: %w", err)
+	}
+	return nil
+}
+
+// summarizeToolRollup converts a rollup to its API summary (without histogram).
+func summarizeToolRollup(tool string, r *toolstats.Rollup) ToolStatsSummary {
+	return ToolStatsSummary{
+		Tool:      tool,
+		Calls:     r.Calls,
+		Errors:    r.Errors,
+		Denied:    r.Denied,
+		ErrorRate: r.ErrorRate(),
+		Latency: ToolLatencySummary{
+			P50:  durationMs(r.Latency.Quantile(0.50)),
+			P90:  durationMs(r.Latency.Quantile(0.90)),
+			P95:  durationMs(r.Latency.Quantile(0.95)),
+			P99:  durationMs(r.Latency.Quantile(0.99)),
+			Max:  durationMs(r.Latency.Max()),
+			Mean: durationMs(r.Latency.Mean()),
+		},
+	}
+}
+
+// durationMs converts d to fractional milliseconds.
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
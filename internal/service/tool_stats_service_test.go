@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	storageAdapter "github.com/Sentinel-Gate/Sentinelgate/internal/adapter/outbound/storage"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/toolstats"
+)
+
+func TestToolStatsService_InMemory(t *testing.T) {
+	svc := NewToolStatsService(slog.Default())
+	for i := 1; i <= 100; i++ {
+		svc.RecordToolCall("read_file", toolstats.OutcomeOK, time.Duration(i)*time.Millisecond)
+	}
+	svc.RecordToolCall("write_file", toolstats.OutcomeError, 500*time.Millisecond)
+	svc.RecordToolCall("write_file", toolstats.OutcomeDenied, 0)
+
+	got, found, err := svc.ToolStats(context.Background(), "read_file", time.Hour)
+	if err != nil || !found {
+		t.Fatalf("ToolStats: found=%v err=%v", found, err)
+	}
+	if got.Calls != 100 || got.Errors != 0 {
+		t.Errorf("calls=%d errors=%d, want 100 and 0", got.Calls, got.Errors)
+	}
+	if got.Latency.P50 < 50 || got.Latency.P50 > 54 || got.Latency.Max != 100 {
+		t.Errorf("latency = %+v, want p50 ~50ms and max 100ms", got.Latency)
+	}
+	if len(got.Histogram) == 0 {
+		t.Error("expected histogram buckets")
+	}
+
+	top, err := svc.Top(context.Background(), ToolStatsByErrorRate, 1, time.Hour)
+	if err != nil {
+		t.Fatalf("Top: %v", err)
+	}
+	if len(top) != 1 || top[0].Tool != "write_file" || top[0].ErrorRate != 1 || top[0].Denied != 1 {
+		t.Errorf("Top(error_rate) = %+v, want write_file with error rate 1", top)
+	}
+	if _, err := svc.Top(context.Background(), "bogus", 10, time.Hour); err == nil {
+		t.Error("expected error for unknown sort key")
+	}
+
+	if err := svc.Reset(context.Background()); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if _, found, _ := svc.ToolStats(context.Background(), "read_file", time.Hour); found {
+		t.Error("expected no stats after Reset")
+	}
+}
+
+func TestToolStatsService_PersistsRollups(t *testing.T) {
+	ctx := context.Background()
+	ts, err := storageAdapter.NewSQLiteTimeSeriesStore(filepath.Join(t.TempDir(), "ts.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = ts.Close() }()
+
+	svc := NewToolStatsService(slog.Default())
+	svc.SetTimeSeriesStore(ts)
+	svc.RecordToolCall("search", toolstats.OutcomeOK, 10*time.Millisecond)
+	svc.RecordToolCall("search", toolstats.OutcomeOK, 20*time.Millisecond)
+	if err := svc.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	// Not yet flushed: still included in queries.
+	svc.RecordToolCall("search", toolstats.OutcomeError, 30*time.Millisecond)
+
+	got, found, err := svc.ToolStats(ctx, "search", time.Hour)
+	if err != nil || !found {
+		t.Fatalf("ToolStats: found=%v err=%v", found, err)
+	}
+	if got.Calls != 3 || got.Errors != 1 {
+		t.Errorf("calls=%d errors=%d, want 3 and 1", got.Calls, got.Errors)
+	}
+
+	// A new service (restart) sees the flushed rollup only.
+	restarted := NewToolStatsService(slog.Default())
+	restarted.SetTimeSeriesStore(ts)
+	got, found, err = restarted.ToolStats(ctx, "search", time.Hour)
+	if err != nil || !found {
+		t.Fatalf("ToolStats after restart: found=%v err=%v", found, err)
+	}
+	if got.Calls != 2 || got.Latency.Max != 20 {
+		t.Errorf("after restart calls=%d max=%v, want 2 and 20ms", got.Calls, got.Latency.Max)
+	}
+
+	if err := restarted.Reset(ctx); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if _, found, _ := restarted.ToolStats(ctx, "search", time.Hour); found {
+		t.Error("expected persisted rollups to be deleted by Reset")
+	}
+}
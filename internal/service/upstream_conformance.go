@@ -0,0 +1,679 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/port/outbound"
+)
+
+// Conformance check statuses.
+const (
+	ConformancePass = "pass"
+	ConformanceWarn = "warn"
+	ConformanceFail = "fail"
+	ConformanceSkip = "skip"
+)
+
+// JSON-RPC error codes the conformance suite expects.
+const (
+	jsonRPCParseError     = -32700
+	jsonRPCMethodNotFound = -32601
+	jsonRPCInvalidParams  = -32602
+)
+
+// knownProtocolVersions lists the MCP protocol revisions the gateway understands.
+var knownProtocolVersions = map[string]struct{}{
+	"2024-11-05": {}, "2025-03-26": {}, "2025-06-18": {}, "2025-11-25": {},
+}
+
+// toolNamePattern is the tool name format recommended by the MCP spec.
+var toolNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.\-]{1,128}$`)
+
+const (
+	defaultConformanceTimeout   = 10 * time.Second
+	defaultConformanceLatency   = time.Second
+	defaultConformanceSamples   = 5
+	maxConformanceToolPages     = 50
+	maxConformanceDetails       = 5
+	conformanceParseErrorWait   = 2 * time.Second
+	conformanceMissingToolName  = "sentinelgate_conformance_missing_tool"
+	conformanceUnknownMethod    = "sentinelgate/conformance_unknown_method"
+	conformanceMaxResponseBytes = 16 << 20
+)
+
+// ConformanceOptions tunes an upstream conformance run.
+type ConformanceOptions struct {
+	// RequestTimeout bounds each request (default 10s).
+	RequestTimeout time.Duration
+	// LatencyThreshold is the response time above which the latency check warns (default 1s).
+	LatencyThreshold time.Duration
+	// LatencySamples is the number of ping round trips measured (default 5).
+	LatencySamples int
+}
+
+// ConformanceCheck is the outcome of one conformance check.
+type ConformanceCheck struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	LatencyMs int64  `json:"latency_ms,omitempty"`
+}
+
+// ConformanceReport is the result of running the conformance suite against an upstream.
+type ConformanceReport struct {
+	Target          string             `json:"target"`
+	ServerName      string             `json:"server_name,omitempty"`
+	ServerVersion   string             `json:"server_version,omitempty"`
+	ProtocolVersion string             `json:"protocol_version,omitempty"`
+	ToolCount       int                `json:"tool_count"`
+	Checks          []ConformanceCheck `json:"checks"`
+	Passed          int                `json:"passed"`
+	Warnings        int                `json:"warnings"`
+	Failed          int                `json:"failed"`
+	DurationMs      int64              `json:"duration_ms"`
+}
+
+// OK reports whether no check failed.
+func (r *ConformanceReport) OK() bool {
+	return r.Failed == 0
+}
+
+func (r *ConformanceReport) add(name, status, detail string, latency time.Duration) {
+	r.Checks = append(r.Checks, ConformanceCheck{
+		Name:      name,
+		Status:    status,
+		Detail:    detail,
+		LatencyMs: latency.Milliseconds(),
+	})
+	switch status {
+	case ConformancePass:
+		r.Passed++
+	case ConformanceWarn:
+		r.Warnings++
+	case ConformanceFail:
+		r.Failed++
+	}
+}
+
+// RunUpstreamConformance connects to an upstream through client and runs the
+// conformance suite: initialize handshake, tools/list, tool schema validity,
+// ping latency and error behavior on bad input. It never calls a real tool.
+// The client is closed before returning.
+func RunUpstreamConformance(ctx context.Context, client outbound.MCPClient, target string, opts ConformanceOptions) *ConformanceReport {
+	if opts.RequestTimeout <= 0 {
+		opts.RequestTimeout = defaultConformanceTimeout
+	}
+	if opts.LatencyThreshold <= 0 {
+		opts.LatencyThreshold = defaultConformanceLatency
+	}
+	if opts.LatencySamples <= 0 {
+		opts.LatencySamples = defaultConformanceSamples
+	}
+
+	start := time.Now()
+	report := &ConformanceReport{Target: target, Checks: []ConformanceCheck{}}
+	defer func() { report.DurationMs = time.Since(start).Milliseconds() }()
+
+	stdin, stdout, err := client.Start(ctx)
+	if err != nil {
+		_ = client.Close()
+		report.add("connect", ConformanceFail, err.Error(), 0)
+		return report
+	}
+	sess := newConformanceSession(stdin, stdout, opts.RequestTimeout)
+	// Close the client first so the reader goroutine unblocks.
+	defer func() {
+		_ = client.Close()
+		sess.wait()
+	}()
+
+	info, ok := checkInitialize(ctx, sess, report)
+	if !ok {
+		return report
+	}
+
+	tools := checkToolsList(ctx, sess, report, info.hasTools)
+	report.ToolCount = len(tools)
+	checkToolSchemas(report, tools, info.hasTools)
+	checkPingLatency(ctx, sess, report, opts)
+	checkUnknownMethod(ctx, sess, report)
+	checkInvalidParams(ctx, sess, report)
+	checkUnknownTool(ctx, sess, report)
+	checkMalformedInput(ctx, sess, report, opts.RequestTimeout)
+	return report
+}
+
+// initializeInfo is what the rest of the suite needs from initialize.
+type initializeInfo struct {
+	hasTools bool
+}
+
+func checkInitialize(ctx context.Context, sess *conformanceSession, report *ConformanceReport) (initializeInfo, bool) {
+	const name = "initialize"
+	resp, latency, err := sess.call(ctx, "initialize", map[string]interface{}{
+		"protocolVersion": "2025-11-25",
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      map[string]interface{}{"name": "sentinel-gate-conformance", "version": "1.0.0"},
+	})
+	if err != nil {
+		report.add(name, ConformanceFail, err.Error(), latency)
+		return initializeInfo{}, false
+	}
+	if resp.Error != nil {
+		report.add(name, ConformanceFail, "error response: "+resp.Error.String(), latency)
+		return initializeInfo{}, false
+	}
+
+	var result struct {
+		ProtocolVersion string                     `json:"protocolVersion"`
+		Capabilities    map[string]json.RawMessage `json:"capabilities"`
+		ServerInfo      *struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"serverInfo"`
+	}
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		report.add(name, ConformanceFail, "result is not an InitializeResult: "+err.Error(), latency)
+		return initializeInfo{}, false
+	}
+	report.ProtocolVersion = result.ProtocolVersion
+	if result.ServerInfo != nil {
+		report.ServerName = result.ServerInfo.Name
+		report.ServerVersion = result.ServerInfo.Version
+	}
+
+	var problems, warnings []string
+	if resp.JSONRPC != "2.0" {
+		problems = append(problems, fmt.Sprintf("jsonrpc is %q, want \"2.0\"", resp.JSONRPC))
+	}
+	if result.ProtocolVersion == "" {
+		problems = append(problems, "missing protocolVersion")
+	} else if _, known := knownProtocolVersions[result.ProtocolVersion]; !known {
+		warnings = append(warnings, fmt.Sprintf("unknown protocolVersion %q", result.ProtocolVersion))
+	}
+	if result.Capabilities == nil {
+		warnings = append(warnings, "missing capabilities")
+	}
+	if result.ServerInfo == nil || result.ServerInfo.Name == "" {
+		warnings = append(warnings, "missing serverInfo.name")
+	}
+	_, hasTools := result.Capabilities["tools"]
+
+	if len(problems) > 0 {
+		report.add(name, ConformanceFail, joinDetails(append(problems, warnings...)), latency)
+		return initializeInfo{}, false
+	}
+	if err := sess.notify("notifications/initialized"); err != nil {
+		report.add(name, ConformanceFail, "send notifications/initialized: "+err.Error(), latency)
+		return initializeInfo{}, false
+	}
+	if len(warnings) > 0 {
+		report.add(name, ConformanceWarn, joinDetails(warnings), latency)
+	} else {
+		report.add(name, ConformancePass, "protocol "+result.ProtocolVersion, latency)
+	}
+	return initializeInfo{hasTools: hasTools}, true
+}
+
+// conformanceTool is a tool definition as returned by tools/list.
+type conformanceTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	InputSchema json.RawMessage `json:"inputSchema"`
+}
+
+func checkToolsList(ctx context.Context, sess *conformanceSession, report *ConformanceReport, declared bool) []conformanceTool {
+	const name = "tools/list"
+	var (
+		tools   []conformanceTool
+		cursor  string
+		total   time.Duration
+		pages   int
+		details []string
+	)
+	for {
+		var params interface{}
+		if cursor != "" {
+			params = map[string]string{"cursor": cursor}
+		}
+		resp, latency, err := sess.call(ctx, "tools/list", params)
+		total += latency
+		if err != nil {
+			report.add(name, ConformanceFail, err.Error(), total)
+			return tools
+		}
+		if resp.Error != nil {
+			if !declared && resp.Error.Code == jsonRPCMethodNotFound {
+				report.add(name, ConformanceSkip, "server does not declare the tools capability", total)
+				return nil
+			}
+			report.add(name, ConformanceFail, "error response: "+resp.Error.String(), total)
+			return tools
+		}
+		var result struct {
+			Tools      *[]conformanceTool `json:"tools"`
+			NextCursor string             `json:"nextCursor"`
+		}
+		if err := json.Unmarshal(resp.Result, &result); err != nil || result.Tools == nil {
+			report.add(name, ConformanceFail, "result has no tools array", total)
+			return tools
+		}
+		tools = append(tools, *result.Tools...)
+		pages++
+		if result.NextCursor == "" {
+			break
+		}
+		if result.NextCursor == cursor || pages >= maxConformanceToolPages {
+			details = append(details, fmt.Sprintf("pagination did not terminate after %d pages", pages))
+			break
+		}
+		cursor = result.NextCursor
+	}
+
+	if !declared {
+		details = append(details, "tools listed but the tools capability was not declared in initialize")
+	}
+	if len(details) > 0 {
+		report.add(name, ConformanceWarn, joinDetails(details), total)
+		return tools
+	}
+	detail := fmt.Sprintf("%d tools", len(tools))
+	if pages > 1 {
+		detail += fmt.Sprintf(" in %d pages", pages)
+	}
+	report.add(name, ConformancePass, detail, total)
+	return tools
+}
+
+func checkToolSchemas(report *ConformanceReport, tools []conformanceTool, declared bool) {
+	const name = "tool schemas"
+	if len(tools) == 0 {
+		if declared {
+			report.add(name, ConformanceWarn, "no tools to validate", 0)
+		} else {
+			report.add(name, ConformanceSkip, "no tools", 0)
+		}
+		return
+	}
+
+	var problems, warnings []string
+	seen := make(map[string]struct{}, len(tools))
+	for i, t := range tools {
+		label := t.Name
+		if label == "" {
+			label = fmt.Sprintf("tools[%d]", i)
+		}
+		if t.Name == "" {
+			problems = append(problems, label+": missing name")
+		} else {
+			if _, dup := seen[t.Name]; dup {
+				problems = append(problems, label+": duplicate name")
+			}
+			seen[t.Name] = struct{}{}
+			if !toolNamePattern.MatchString(t.Name) {
+				warnings = append(warnings, label+": name should match "+toolNamePattern.String())
+			}
+		}
+		if t.Description == "" {
+			warnings = append(warnings, label+": missing description")
+		}
+		p, w := validateInputSchema(t.InputSchema)
+		for _, msg := range p {
+			problems = append(problems, label+": "+msg)
+		}
+		for _, msg := range w {
+			warnings = append(warnings, label+": "+msg)
+		}
+	}
+
+	switch {
+	case len(problems) > 0:
+		report.add(name, ConformanceFail, joinDetails(problems), 0)
+	case len(warnings) > 0:
+		report.add(name, ConformanceWarn, joinDetails(warnings), 0)
+	default:
+		report.add(name, ConformancePass, fmt.Sprintf("%d tools valid", len(tools)), 0)
+	}
+}
+
+// validateInputSchema checks the structural rules MCP places on a tool's
+// inputSchema: a JSON Schema object of type "object" whose properties and
+// required fields are well-formed.
+func validateInputSchema(raw json.RawMessage) (problems, warnings []string) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return []string{"missing inputSchema"}, nil
+	}
+	var schema map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return []string{"inputSchema is not a JSON object"}, nil
+	}
+	var typ string
+	if err := json.Unmarshal(schema["type"], &typ); err != nil || typ != "object" {
+		problems = append(problems, `inputSchema.type must be "object"`)
+	}
+
+	var properties map[string]json.RawMessage
+	if rawProps, ok := schema["properties"]; ok {
+		if err := json.Unmarshal(rawProps, &properties); err != nil {
+			problems = append(problems, "inputSchema.properties is not an object")
+		}
+		for prop, def := range properties {
+			var obj map[string]json.RawMessage
+			if json.Unmarshal(def, &obj) != nil {
+				var b bool
+				if json.Unmarshal(def, &b) != nil {
+					problems = append(problems, fmt.Sprintf("inputSchema.properties.%s is not a schema", prop))
+				}
+			}
+		}
+	}
+	if rawReq, ok := schema["required"]; ok {
+		var required []string
+		if err := json.Unmarshal(rawReq, &required); err != nil {
+			problems = append(problems, "inputSchema.required is not an array of strings")
+		}
+		for _, r := range required {
+			if _, ok := properties[r]; !ok {
+				warnings = append(warnings, fmt.Sprintf("required field %q is not in properties", r))
+			}
+		}
+	}
+	return problems, warnings
+}
+
+func checkPingLatency(ctx context.Context, sess *conformanceSession, report *ConformanceReport, opts ConformanceOptions) {
+	resp, latency, err := sess.call(ctx, "ping", nil)
+	method := "ping"
+	switch {
+	case err != nil:
+		report.add("ping", ConformanceFail, err.Error(), latency)
+		report.add("latency", ConformanceSkip, "ping failed", 0)
+		return
+	case resp.Error != nil && resp.Error.Code == jsonRPCMethodNotFound:
+		// Servers must answer ping, but measure latency with tools/list instead.
+		report.add("ping", ConformanceWarn, "ping is not implemented (required by the MCP base protocol)", latency)
+		method = "tools/list"
+	case resp.Error != nil:
+		report.add("ping", ConformanceFail, "error response: "+resp.Error.String(), latency)
+		report.add("latency", ConformanceSkip, "ping failed", 0)
+		return
+	default:
+		report.add("ping", ConformancePass, "", latency)
+	}
+
+	samples := make([]time.Duration, 0, opts.LatencySamples)
+	for i := 0; i < opts.LatencySamples; i++ {
+		resp, latency, err := sess.call(ctx, method, nil)
+		if err != nil {
+			report.add("latency", ConformanceFail, fmt.Sprintf("%s round trip %d: %v", method, i+1, err), latency)
+			return
+		}
+		if resp.Error != nil {
+			report.add("latency", ConformanceFail, fmt.Sprintf("%s round trip %d: %s", method, i+1, resp.Error), latency)
+			return
+		}
+		samples = append(samples, latency)
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	p50 := samples[len(samples)/2]
+	maxLatency := samples[len(samples)-1]
+	detail := fmt.Sprintf("%s p50 %s, max %s over %d requests (threshold %s)",
+		method, p50.Round(time.Microsecond), maxLatency.Round(time.Microsecond), len(samples), opts.LatencyThreshold)
+	if maxLatency > opts.LatencyThreshold {
+		report.add("latency", ConformanceWarn, detail, p50)
+		return
+	}
+	report.add("latency", ConformancePass, detail, p50)
+}
+
+func checkUnknownMethod(ctx context.Context, sess *conformanceSession, report *ConformanceReport) {
+	const name = "unknown method"
+	resp, latency, err := sess.call(ctx, conformanceUnknownMethod, nil)
+	switch {
+	case err != nil:
+		report.add(name, ConformanceFail, err.Error(), latency)
+	case resp.Error == nil:
+		report.add(name, ConformanceFail, "returned a result for an unknown method", latency)
+	case resp.Error.Code != jsonRPCMethodNotFound:
+		report.add(name, ConformanceWarn, fmt.Sprintf("error code %d, want %d (method not found)", resp.Error.Code, jsonRPCMethodNotFound), latency)
+	default:
+		report.add(name, ConformancePass, "", latency)
+	}
+}
+
+func checkInvalidParams(ctx context.Context, sess *conformanceSession, report *ConformanceReport) {
+	const name = "invalid params"
+	// tools/call without a tool name.
+	resp, latency, err := sess.call(ctx, "tools/call", map[string]interface{}{})
+	switch {
+	case err != nil:
+		report.add(name, ConformanceFail, err.Error(), latency)
+	case resp.Error == nil && resultIsError(resp.Result):
+		report.add(name, ConformanceWarn, "tools/call without a name returned a tool error instead of a protocol error", latency)
+	case resp.Error == nil:
+		report.add(name, ConformanceFail, "tools/call without a name succeeded", latency)
+	case resp.Error.Code != jsonRPCInvalidParams:
+		report.add(name, ConformanceWarn, fmt.Sprintf("error code %d, want %d (invalid params)", resp.Error.Code, jsonRPCInvalidParams), latency)
+	default:
+		report.add(name, ConformancePass, "", latency)
+	}
+}
+
+func checkUnknownTool(ctx context.Context, sess *conformanceSession, report *ConformanceReport) {
+	const name = "unknown tool"
+	resp, latency, err := sess.call(ctx, "tools/call", map[string]interface{}{
+		"name":      conformanceMissingToolName,
+		"arguments": map[string]interface{}{},
+	})
+	switch {
+	case err != nil:
+		report.add(name, ConformanceFail, err.Error(), latency)
+	case resp.Error == nil && !resultIsError(resp.Result):
+		report.add(name, ConformanceFail, "call to a nonexistent tool succeeded", latency)
+	default:
+		report.add(name, ConformancePass, "", latency)
+	}
+}
+
+func checkMalformedInput(ctx context.Context, sess *conformanceSession, report *ConformanceReport, timeout time.Duration) {
+	const name = "malformed input"
+	if err := sess.writeRaw(`{"jsonrpc":"2.0","id":`); err != nil {
+		report.add(name, ConformanceFail, "write: "+err.Error(), 0)
+		return
+	}
+	wait := conformanceParseErrorWait
+	if timeout < wait {
+		wait = timeout
+	}
+	start := time.Now()
+	msg, err := sess.await(ctx, wait, func(m *conformanceMessage) bool {
+		return m.Error != nil && (len(m.ID) == 0 || string(m.ID) == "null")
+	})
+	latency := time.Since(start)
+
+	// The server must keep serving after bad input.
+	if resp, _, pingErr := sess.call(ctx, "tools/list", nil); pingErr != nil || resp.Error != nil {
+		detail := "server stopped responding after malformed JSON"
+		if pingErr != nil {
+			detail += ": " + pingErr.Error()
+		}
+		report.add(name, ConformanceFail, detail, 0)
+		return
+	}
+	switch {
+	case err != nil:
+		report.add(name, ConformanceWarn, "no parse error returned for malformed JSON", 0)
+	case msg.Error.Code != jsonRPCParseError:
+		report.add(name, ConformanceWarn, fmt.Sprintf("error code %d, want %d (parse error)", msg.Error.Code, jsonRPCParseError), latency)
+	default:
+		report.add(name, ConformancePass, "", latency)
+	}
+}
+
+// resultIsError reports whether a tools/call result is a tool error (isError: true).
+func resultIsError(raw json.RawMessage) bool {
+	var result struct {
+		IsError bool `json:"isError"`
+	}
+	return json.Unmarshal(raw, &result) == nil && result.IsError
+}
+
+// joinDetails joins up to maxConformanceDetails messages.
+func joinDetails(msgs []string) string {
+	if len(msgs) <= maxConformanceDetails {
+		return strings.Join(msgs, "; ")
+	}
+	return fmt.Sprintf("%s; and %d more", strings.Join(msgs[:maxConformanceDetails], "; "), len(msgs)-maxConformanceDetails)
+}
+
+// conformanceRPCError is a JSON-RPC error object.
+type conformanceRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *conformanceRPCError) String() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// conformanceMessage is any JSON-RPC message received from the upstream.
+type conformanceMessage struct {
+	JSONRPC string               `json:"jsonrpc"`
+	ID      json.RawMessage      `json:"id"`
+	Method  string               `json:"method"`
+	Result  json.RawMessage      `json:"result"`
+	Error   *conformanceRPCError `json:"error"`
+}
+
+// errConformanceClosed is returned when the upstream closes its output.
+var errConformanceClosed = errors.New("upstream closed the connection")
+
+// conformanceSession is a minimal line-delimited JSON-RPC client.
+type conformanceSession struct {
+	stdin   io.Writer
+	lines   chan []byte
+	readErr error
+	timeout time.Duration
+	nextID  int
+	wg      sync.WaitGroup
+}
+
+func newConformanceSession(stdin io.Writer, stdout io.Reader, timeout time.Duration) *conformanceSession {
+	s := &conformanceSession{
+		stdin:   stdin,
+		lines:   make(chan []byte, 16),
+		timeout: timeout,
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer close(s.lines)
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), conformanceMaxResponseBytes)
+		for scanner.Scan() {
+			s.lines <- append([]byte(nil), scanner.Bytes()...)
+		}
+		s.readErr = scanner.Err()
+	}()
+	return s
+}
+
+// wait blocks until the reader goroutine exits; the client must be closed first.
+func (s *conformanceSession) wait() {
+	go func() {
+		for range s.lines {
+		}
+	}()
+	s.wg.Wait()
+}
+
+// writeRaw writes one line. A stdio upstream that stopped reading would block
+// the write forever, so it is bounded by the request timeout; the pending
+// write is released when the client is closed.
+func (s *conformanceSession) writeRaw(line string) error {
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := io.WriteString(s.stdin, line+"\n")
+		errCh <- err
+	}()
+	timer := time.NewTimer(s.timeout)
+	defer timer.Stop()
+	select {
+	case err := <-errCh:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("upstream did not read input within %s", s.timeout)
+	}
+}
+
+func (s *conformanceSession) notify(method string) error {
+	b, _ := json.Marshal(map[string]string{"jsonrpc": "2.0", "method": method})
+	return s.writeRaw(string(b))
+}
+
+// call sends a request and waits for its response, returning the round trip time.
+func (s *conformanceSession) call(ctx context.Context, method string, params interface{}) (*conformanceMessage, time.Duration, error) {
+	s.nextID++
+	id := fmt.Sprintf("conformance-%d", s.nextID)
+	req := map[string]interface{}{"jsonrpc": "2.0", "id": id, "method": method}
+	if params != nil {
+		req["params"] = params
+	}
+	b, err := json.Marshal(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	wantID, _ := json.Marshal(id)
+
+	start := time.Now()
+	if err := s.writeRaw(string(b)); err != nil {
+		return nil, 0, fmt.Errorf("write %s: %w", method, err)
+	}
+	msg, err := s.await(ctx, s.timeout, func(m *conformanceMessage) bool {
+		return m.Method == "" && string(m.ID) == string(wantID)
+	})
+	latency := time.Since(start)
+	if err != nil {
+		return nil, latency, fmt.Errorf("%s: %w", method, err)
+	}
+	return msg, latency, nil
+}
+
+// await reads messages until match returns true, skipping notifications,
+// server-initiated requests and unrelated responses.
+func (s *conformanceSession) await(ctx context.Context, timeout time.Duration, match func(*conformanceMessage) bool) (*conformanceMessage, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case line, ok := <-s.lines:
+			if !ok {
+				if s.readErr != nil {
+					return nil, fmt.Errorf("%w: %v", errConformanceClosed, s.readErr)
+				}
+				return nil, errConformanceClosed
+			}
+			var msg conformanceMessage
+			if err := json.Unmarshal(line, &msg); err != nil {
+				return nil, fmt.Errorf("invalid JSON from upstream: %w", err)
+			}
+			if match(&msg) {
+				return &msg, nil
+			}
+		case <-timer.C:
+			return nil, fmt.Errorf("no response within %s", timeout)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+)
+
+// conformanceMockClient implements outbound.MCPClient with a scripted MCP
+// server. Behaviour flags make it deviate from the spec.
+type conformanceMockClient struct {
+	toolsJSON       string
+	noPing          bool
+	acceptUnknown   bool
+	crashOnBadJSON  bool
+	startErr        error
+	stdinR, stdoutR *io.PipeReader
+	stdinW, stdoutW *io.PipeWriter
+	done            chan struct{}
+}
+
+func newConformanceMockClient(toolsJSON string) *conformanceMockClient {
+	return &conformanceMockClient{toolsJSON: toolsJSON, done: make(chan struct{})}
+}
+
+func (m *conformanceMockClient) Start(_ context.Context) (io.WriteCloser, io.ReadCloser, error) {
+	if m.startErr != nil {
+		return nil, nil, m.startErr
+	}
+	m.stdinR, m.stdinW = io.Pipe()
+	m.stdoutR, m.stdoutW = io.Pipe()
+	go m.serve()
+	return m.stdinW, m.stdoutR, nil
+}
+
+func (m *conformanceMockClient) serve() {
+	defer m.stdoutW.Close()
+	scanner := bufio.NewScanner(m.stdinR)
+	for scanner.Scan() {
+		var req struct {
+			ID     json.RawMessage        `json:"id"`
+			Method string                 `json:"method"`
+			Params map[string]interface{} `json:"params"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			if m.crashOnBadJSON {
+				_ = m.stdinR.Close() // the process exited
+				return
+			}
+			m.reply(`{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"parse error"}}`)
+			continue
+		}
+		if len(req.ID) == 0 {
+			continue // notification
+		}
+		id := string(req.ID)
+		switch {
+		case req.Method == "initialize":
+			m.reply(fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":{"protocolVersion":"2025-06-18","capabilities":{"tools":{}},"serverInfo":{"name":"mock","version":"1.2.3"}}}`, id))
+		case req.Method == "tools/list":
+			m.reply(fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":{"tools":%s}}`, id, m.toolsJSON))
+		case req.Method == "ping" && m.noPing:
+			m.reply(fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"error":{"code":-32601,"message":"method not found"}}`, id))
+		case req.Method == "ping":
+			m.reply(fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":{}}`, id))
+		case req.Method == "tools/call" && req.Params["name"] == nil:
+			m.reply(fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"error":{"code":-32602,"message":"missing name"}}`, id))
+		case req.Method == "tools/call":
+			m.reply(fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":{"content":[{"type":"text","text":"unknown tool"}],"isError":true}}`, id))
+		case m.acceptUnknown:
+			m.reply(fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":{}}`, id))
+		default:
+			m.reply(fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"error":{"code":-32601,"message":"method not found"}}`, id))
+		}
+	}
+}
+
+func (m *conformanceMockClient) reply(line string) {
+	_, _ = io.WriteString(m.stdoutW, line+"\n")
+}
+
+func (m *conformanceMockClient) Wait() error {
+	<-m.done
+	return nil
+}
+
+func (m *conformanceMockClient) Close() error {
+	select {
+	case <-m.done:
+	default:
+		close(m.done)
+	}
+	if m.stdinR != nil {
+		_ = m.stdinR.Close()
+		_ = m.stdoutR.Close()
+	}
+	return nil
+}
+
+const conformanceGoodTools = `[{"name":"read_file","description":"Read a file","inputSchema":{"type":"object","properties":{"path":{"type":"string"}},"required":["path"]}}]`
+
+func checkStatuses(r *ConformanceReport) map[string]string {
+	statuses := make(map[string]string, len(r.Checks))
+	for _, c := range r.Checks {
+		statuses[c.Name] = c.Status
+	}
+	return statuses
+}
+
+func TestRunUpstreamConformance_CompliantServer(t *testing.T) {
+	client := newConformanceMockClient(conformanceGoodTools)
+	report := RunUpstreamConformance(context.Background(), client, "mock", ConformanceOptions{
+		RequestTimeout: 2 * time.Second,
+		LatencySamples: 2,
+	})
+
+	if !report.OK() || report.Warnings != 0 {
+		t.Fatalf("report = %+v, want all checks passing", report.Checks)
+	}
+	for _, name := range []string{"initialize", "tools/list", "tool schemas", "ping", "latency", "unknown method", "invalid params", "unknown tool", "malformed input"} {
+		if got := checkStatuses(report)[name]; got != ConformancePass {
+			t.Errorf("check %q = %q, want pass", name, got)
+		}
+	}
+	if report.ServerName != "mock" || report.ServerVersion != "1.2.3" || report.ProtocolVersion != "2025-06-18" || report.ToolCount != 1 {
+		t.Errorf("report header = %+v", report)
+	}
+}
+
+func TestRunUpstreamConformance_NonCompliantServer(t *testing.T) {
+	client := newConformanceMockClient(`[{"name":"bad tool","inputSchema":{"type":"string"}},{"name":"x","inputSchema":{"type":"object"}},{"name":"x"}]`)
+	client.noPing = true
+	client.acceptUnknown = true
+	client.crashOnBadJSON = true
+
+	report := RunUpstreamConformance(context.Background(), client, "mock", ConformanceOptions{
+		RequestTimeout: time.Second,
+		LatencySamples: 1,
+	})
+
+	want := map[string]string{
+		"initialize":      ConformancePass,
+		"tools/list":      ConformancePass,
+		"tool schemas":    ConformanceFail,
+		"ping":            ConformanceWarn,
+		"latency":         ConformancePass,
+		"unknown method":  ConformanceFail,
+		"invalid params":  ConformancePass,
+		"unknown tool":    ConformancePass,
+		"malformed input": ConformanceFail,
+	}
+	got := checkStatuses(report)
+	for name, status := range want {
+		if got[name] != status {
+			t.Errorf("check %q = %q, want %q", name, got[name], status)
+		}
+	}
+	if report.OK() {
+		t.Error("OK() = true, want false")
+	}
+}
+
+func TestRunUpstreamConformance_StartError(t *testing.T) {
+	client := newConformanceMockClient("[]")
+	client.startErr = errors.New("exec: not found")
+
+	report := RunUpstreamConformance(context.Background(), client, "missing", ConformanceOptions{})
+	if len(report.Checks) != 1 || report.Checks[0].Name != "connect" || report.Checks[0].Status != ConformanceFail {
+		t.Errorf("checks = %+v, want a single failed connect check", report.Checks)
+	}
+}
+
+func TestValidateInputSchema(t *testing.T) {
+	tests := []struct {
+		schema       string
+		wantProblems int
+		wantWarnings int
+	}{
+		{`{"type":"object"}`, 0, 0},
+		{`{"type":"object","properties":{"a":{"type":"string"},"b":true},"required":["a"]}`, 0, 0},
+		{`{"type":"object","required":["missing"]}`, 0, 1},
+		{``, 1, 0},
+		{`[]`, 1, 0},
+		{`{"type":"array"}`, 1, 0},
+		{`{"type":"object","properties":[]}`, 1, 0},
+		{`{"type":"object","properties":{"a":1}}`, 1, 0},
+		{`{"type":"object","required":"a"}`, 1, 0},
+	}
+	for _, tt := range tests {
+		problems, warnings := validateInputSchema(json.RawMessage(tt.schema))
+		if len(problems) != tt.wantProblems || len(warnings) != tt.wantWarnings {
+			t.Errorf("validateInputSchema(%s) = %v / %v, want %d problems and %d warnings",
+				tt.schema, problems, warnings, tt.wantProblems, tt.wantWarnings)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrUpstreamDraining is returned when a drain is requested for an upstream
+// that is already draining.
+var ErrUpstreamDraining = errors.New("upstream is already draining")
+
+// DrainStatus describes an upstream drain in progress.
+type DrainStatus struct {
+	// InFlight is the number of calls the drain is waiting for.
+	InFlight int `json:"in_flight"`
+	// StartedAt is when new calls stopped being routed to the upstream.
+	StartedAt time.Time `json:"started_at"`
+	// Deadline is when the upstream restarts even with calls in flight.
+	Deadline time.Time `json:"deadline"`
+}
+
+// drainState is the runtime state of a drain.
+type drainState struct {
+	// conn is the connection being drained; nil when none was running.
+	conn      *upstreamConnection
+	startedAt time.Time
+	deadline  time.Time
+	// idle is closed when the last in-flight call of conn ends; nil when
+	// there is nothing to wait for. Guarded by conn.mu.
+	idle chan struct{}
+	// done is closed when the upstream has restarted, releasing the calls
+	// held by BeginCall.
+	done chan struct{}
+}
+
+// BeginCall registers a call to an upstream, so that a drain waits for it.
+// While the upstream drains, BeginCall holds the call until the restart
+// finishes. The returned function ends the call and must be called once the
+// response has been read.
+func (m *UpstreamManager) BeginCall(ctx context.Context, upstreamID string) (func(), error) {
+	for {
+		m.mu.RLock()
+		d := m.drains[upstreamID]
+		conn, ok := m.connections[upstreamID]
+		if d == nil && ok {
+			// Registered under m.mu so that a drain starting now counts it.
+			conn.mu.Lock()
+			conn.inflight++
+			conn.mu.Unlock()
+		}
+		m.mu.RUnlock()
+
+		if d != nil {
+			select {
+			case <-d.done:
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-m.ctx.Done():
+				return nil, fmt.Errorf("upstream %s: %w", upstreamID, m.ctx.Err())
+			}
+		}
+		if !ok {
+			// GetConnection reports the unmanaged upstream.
+			return func() {}, nil
+		}
+
+		var once sync.Once
+		return func() { once.Do(func() { m.endCall(upstreamID, conn) }) }, nil
+	}
+}
+
+// endCall unregisters a call, counts it as activity for the idle shutdown of
+// lazy upstreams and wakes up a drain waiting for the last one.
+func (m *UpstreamManager) endCall(upstreamID string, conn *upstreamConnection) {
+	m.mu.RLock()
+	d := m.drains[upstreamID]
+	m.mu.RUnlock()
+
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	conn.lastActivity.Store(time.Now().UnixNano())
+	conn.inflight--
+	if conn.inflight == 0 && d != nil && d.conn == conn && d.idle != nil {
+		close(d.idle)
+		d.idle = nil
+	}
+}
+
+// DrainAndRestart stops routing new calls to an upstream, waits up to
+// timeout for its in-flight calls to finish, then restarts it so that a new
+// configuration takes effect. Calls arriving meanwhile are held and sent to
+// the restarted upstream; calls still running at the deadline are cut off.
+//
+// The drain starts before DrainAndRestart returns and runs in the
+// background; the returned channel receives the restart result.
+func (m *UpstreamManager) DrainAndRestart(upstreamID string, timeout time.Duration) (<-chan error, error) {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("upstream manager closed")
+	}
+	if m.drains[upstreamID] != nil {
+		m.mu.Unlock()
+		return nil, ErrUpstreamDraining
+	}
+	now := time.Now()
+	d := &drainState{startedAt: now, deadline: now.Add(timeout), done: make(chan struct{})}
+	inflight := 0
+	if conn, ok := m.connections[upstreamID]; ok {
+		d.conn = conn
+		conn.mu.Lock()
+		inflight = conn.inflight
+		if inflight > 0 {
+			d.idle = make(chan struct{})
+		}
+		conn.mu.Unlock()
+	}
+	if m.drains == nil {
+		m.drains = make(map[string]*drainState)
+	}
+	m.drains[upstreamID] = d
+	idle := d.idle
+	m.wg.Add(1)
+	m.mu.Unlock()
+
+	m.logger.Info("draining upstream", "id", upstreamID, "in_flight", inflight, "timeout", timeout)
+
+	result := make(chan error, 1)
+	go func() {
+		defer m.wg.Done()
+		defer close(d.done)
+		defer func() {
+			m.mu.Lock()
+			delete(m.drains, upstreamID)
+			m.mu.Unlock()
+		}()
+
+		if idle != nil {
+			timer := time.NewTimer(timeout)
+			select {
+			case <-idle:
+			case <-timer.C:
+				d.conn.mu.Lock()
+				remaining := d.conn.inflight
+				d.conn.mu.Unlock()
+				m.logger.Warn("upstream drain deadline reached, restarting with calls in flight",
+					"id", upstreamID, "in_flight", remaining)
+			case <-m.ctx.Done():
+				timer.Stop()
+				result <- m.ctx.Err()
+				return
+			}
+			timer.Stop()
+		}
+
+		if err := m.ctx.Err(); err != nil {
+			result <- err
+			return
+		}
+		err := m.Restart(m.ctx, upstreamID)
+		if err == nil {
+			m.logger.Info("upstream drained and restarted", "id", upstreamID, "duration", time.Since(d.startedAt))
+		}
+		result <- err
+	}()
+	return result, nil
+}
+
+// DrainStatus returns the progress of the drain of an upstream, if one is
+// running.
+func (m *UpstreamManager) DrainStatus(upstreamID string) (DrainStatus, bool) {
+	m.mu.RLock()
+	d := m.drains[upstreamID]
+	m.mu.RUnlock()
+	if d == nil {
+		return DrainStatus{}, false
+	}
+
+	status := DrainStatus{StartedAt: d.startedAt, Deadline: d.deadline}
+	if d.conn != nil {
+		d.conn.mu.Lock()
+		status.InFlight = d.conn.inflight
+		d.conn.mu.Unlock()
+	}
+	return status, true
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/upstream"
+)
+
+func drainTestUpstream() *upstream.Upstream {
+	return &upstream.Upstream{
+		ID:      "up-1",
+		Name:    "server-1",
+		Type:    upstream.UpstreamTypeStdio,
+		Enabled: true,
+		Command: "/usr/bin/echo",
+	}
+}
+
+func TestUpstreamManager_DrainAndRestart_WaitsForInFlight(t *testing.T) {
+	mgr, clients := testManagerEnv(t, drainTestUpstream())
+	defer func() { _ = mgr.Close() }()
+	ctx := context.Background()
+
+	if err := mgr.Start(ctx, "up-1"); err != nil {
+		t.Fatalf("Start(): %v", err)
+	}
+	first := clients["up-1"]
+
+	end, err := mgr.BeginCall(ctx, "up-1")
+	if err != nil {
+		t.Fatalf("BeginCall(): %v", err)
+	}
+
+	drained, err := mgr.DrainAndRestart("up-1", 5*time.Second)
+	if err != nil {
+		t.Fatalf("DrainAndRestart(): %v", err)
+	}
+	st, ok := mgr.DrainStatus("up-1")
+	if !ok || st.InFlight != 1 || !st.Deadline.After(st.StartedAt) {
+		t.Fatalf("DrainStatus() = %+v, %v; want one call in flight", st, ok)
+	}
+	if _, err := mgr.DrainAndRestart("up-1", time.Second); !errors.Is(err, ErrUpstreamDraining) {
+		t.Errorf("second DrainAndRestart() error = %v, want ErrUpstreamDraining", err)
+	}
+
+	// A new call is held until the restart.
+	held := make(chan struct{})
+	go func() {
+		end, err := mgr.BeginCall(ctx, "up-1")
+		if err == nil {
+			end()
+		}
+		close(held)
+	}()
+	select {
+	case <-held:
+		t.Fatal("BeginCall() returned while the upstream was draining")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if first.isClosed() {
+		t.Fatal("upstream restarted with a call in flight")
+	}
+
+	end()
+	select {
+	case err := <-drained:
+		if err != nil {
+			t.Fatalf("drain result: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("upstream not restarted after the last call ended")
+	}
+	select {
+	case <-held:
+	case <-time.After(2 * time.Second):
+		t.Fatal("held call not released after the restart")
+	}
+
+	if !first.isClosed() {
+		t.Error("old client not closed by the restart")
+	}
+	if _, ok := mgr.DrainStatus("up-1"); ok {
+		t.Error("DrainStatus() still reports a drain after the restart")
+	}
+	if status, _ := mgr.Status("up-1"); status != upstream.StatusConnected {
+		t.Errorf("Status() = %q, want connected", status)
+	}
+}
+
+func TestUpstreamManager_DrainAndRestart_Deadline(t *testing.T) {
+	mgr, clients := testManagerEnv(t, drainTestUpstream())
+	defer func() { _ = mgr.Close() }()
+	ctx := context.Background()
+
+	if err := mgr.Start(ctx, "up-1"); err != nil {
+		t.Fatalf("Start(): %v", err)
+	}
+	first := clients["up-1"]
+
+	end, err := mgr.BeginCall(ctx, "up-1")
+	if err != nil {
+		t.Fatalf("BeginCall(): %v", err)
+	}
+	defer end()
+
+	start := time.Now()
+	drained, err := mgr.DrainAndRestart("up-1", 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("DrainAndRestart(): %v", err)
+	}
+	select {
+	case err := <-drained:
+		if err != nil {
+			t.Fatalf("drain result: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("upstream not restarted at the drain deadline")
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("restarted after %v, before the deadline", elapsed)
+	}
+	if !first.isClosed() {
+		t.Error("old client not closed at the deadline")
+	}
+}
+
+func TestUpstreamManager_DrainAndRestart_Idle(t *testing.T) {
+	mgr, _ := testManagerEnv(t, drainTestUpstream())
+	defer func() { _ = mgr.Close() }()
+
+	if err := mgr.Start(context.Background(), "up-1"); err != nil {
+		t.Fatalf("Start(): %v", err)
+	}
+	drained, err := mgr.DrainAndRestart("up-1", time.Minute)
+	if err != nil {
+		t.Fatalf("DrainAndRestart(): %v", err)
+	}
+	select {
+	case err := <-drained:
+		if err != nil {
+			t.Fatalf("drain result: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("upstream without calls in flight not restarted right away")
+	}
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/event"
+)
+
+// Upstream lifecycle event types published on the event bus. Webhooks can
+// subscribe to all of them with the "upstream.*" filter.
+const (
+	// EventUpstreamConnected fires when an upstream finishes its MCP handshake.
+	EventUpstreamConnected = "upstream.connected"
+	// EventUpstreamDisconnected fires when a connected upstream exits or drops.
+	EventUpstreamDisconnected = "upstream.disconnected"
+	// EventUpstreamRestarting fires when a reconnect attempt is scheduled
+	// after a crash or a failed start.
+	EventUpstreamRestarting = "upstream.restarting"
+	// EventUpstreamRetriesExhausted fires when an upstream has used all of
+	// its reconnect attempts and stays down until restarted by hand.
+	EventUpstreamRetriesExhausted = "upstream.retries_exhausted"
+	// EventUpstreamToolsQuarantined fires when the integrity check
+	// quarantines tools of an upstream (new or changed definitions).
+	EventUpstreamToolsQuarantined = "upstream.tools_quarantined"
+	// EventUpstreamTLSVerificationFailed fires when the certificate of an
+	// HTTP or OpenAPI upstream fails verification or its pins, which may
+	// mean its traffic is being intercepted.
+	EventUpstreamTLSVerificationFailed = "upstream.tls_verification_failed"
+)
+
+// SetEventBus sets the bus that receives upstream lifecycle events.
+// When nil (default), no events are published.
+func (m *UpstreamManager) SetEventBus(bus event.Bus) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.eventBus = bus
+}
+
+// publishUpstreamEvent publishes a lifecycle event for conn. extra is merged
+// into the payload, which always carries the upstream ID, name and type.
+// Must be called without conn.mu held.
+func (m *UpstreamManager) publishUpstreamEvent(conn *upstreamConnection, eventType string, severity event.Severity, extra map[string]interface{}) {
+	m.mu.RLock()
+	bus := m.eventBus
+	m.mu.RUnlock()
+	if bus == nil {
+		return
+	}
+
+	conn.mu.Lock()
+	payload := map[string]interface{}{
+		"upstream_id":   conn.upstream.ID,
+		"upstream_name": conn.upstream.Name,
+		"upstream_type": string(conn.upstream.Type),
+		"status":        string(conn.status),
+	}
+	conn.mu.Unlock()
+	for k, v := range extra {
+		payload[k] = v
+	}
+
+	bus.Publish(context.Background(), event.Event{
+		Type:           eventType,
+		Source:         "upstream-manager",
+		Severity:       severity,
+		RequiresAction: eventType == EventUpstreamRetriesExhausted,
+		Payload:        payload,
+	})
+}
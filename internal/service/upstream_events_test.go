@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/event"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/upstream"
+	"github.com/Sentinel-Gate/Sentinelgate/internal/port/outbound"
+	"go.uber.org/goleak"
+)
+
+func upstreamEventTypes(bus *mockDriftEventBus) []string {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	types := make([]string, len(bus.events))
+	for i, e := range bus.events {
+		types[i] = e.Type
+	}
+	return types
+}
+
+func TestUpstreamManager_Events_CrashAndReconnect(t *testing.T) {
+	u := &upstream.Upstream{ID: "up-1", Name: "github", Type: upstream.UpstreamTypeStdio, Enabled: true, Command: "/usr/bin/echo"}
+	store := newMgrMockUpstreamStore()
+	_ = store.Add(context.Background(), u)
+	logger := testManagerLogger()
+
+	var clientsMu sync.Mutex
+	var clients []*mgrMockMCPClient
+	factory := func(u *upstream.Upstream) (outbound.MCPClient, error) {
+		mc := newMgrMockMCPClient()
+		clientsMu.Lock()
+		clients = append(clients, mc)
+		clientsMu.Unlock()
+		return mc, nil
+	}
+
+	mgr := NewUpstreamManager(NewUpstreamService(store, nil, logger), factory, logger)
+	mgr.backoffBase = 10 * time.Millisecond
+	bus := &mockDriftEventBus{}
+	mgr.SetEventBus(bus)
+	defer goleak.VerifyNone(t)
+	defer func() { _ = mgr.Close() }()
+
+	if err := mgr.Start(context.Background(), "up-1"); err != nil {
+		t.Fatalf("Start(): %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	clientsMu.Lock()
+	clients[0].simulateCrash()
+	clientsMu.Unlock()
+	time.Sleep(200 * time.Millisecond)
+
+	got := upstreamEventTypes(bus)
+	want := []string{EventUpstreamConnected, EventUpstreamDisconnected, EventUpstreamRestarting, EventUpstreamConnected}
+	if len(got) != len(want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("events = %v, want %v", got, want)
+		}
+	}
+
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	disc := bus.events[1]
+	p, ok := disc.Payload.(map[string]interface{})
+	if !ok || p["upstream_name"] != "github" || p["upstream_id"] != "up-1" || p["upstream_type"] != "stdio" {
+		t.Errorf("disconnected payload = %#v", disc.Payload)
+	}
+	if disc.Severity != event.SeverityWarning || disc.Source != "upstream-manager" {
+		t.Errorf("disconnected event = %+v", disc)
+	}
+	if p := bus.events[3].Payload.(map[string]interface{}); p["retry_count"] != 1 {
+		t.Errorf("reconnect retry_count = %v, want 1", p["retry_count"])
+	}
+}
+
+func TestUpstreamManager_Events_RetriesExhausted(t *testing.T) {
+	u := &upstream.Upstream{ID: "up-1", Name: "flaky", Type: upstream.UpstreamTypeHTTP, Enabled: true, URL: "http://example.com"}
+	store := newMgrMockUpstreamStore()
+	_ = store.Add(context.Background(), u)
+	logger := testManagerLogger()
+
+	factory := func(u *upstream.Upstream) (outbound.MCPClient, error) {
+		mc := newMgrMockMCPClient()
+		mc.startErr = errors.New("connection refused")
+		return mc, nil
+	}
+
+	mgr := NewUpstreamManager(NewUpstreamService(store, nil, logger), factory, logger)
+	mgr.backoffBase = time.Millisecond
+	mgr.backoffCap = 2 * time.Millisecond
+	mgr.maxRetries = 2
+	bus := &mockDriftEventBus{}
+	mgr.SetEventBus(bus)
+	defer goleak.VerifyNone(t)
+	defer func() { _ = mgr.Close() }()
+
+	_ = mgr.Start(context.Background(), "up-1")
+	time.Sleep(100 * time.Millisecond)
+
+	got := upstreamEventTypes(bus)
+	want := []string{EventUpstreamRestarting, EventUpstreamRestarting, EventUpstreamRetriesExhausted}
+	if len(got) != len(want) || got[2] != want[2] {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	last := bus.events[2]
+	if last.Severity != event.SeverityCritical || !last.RequiresAction {
+		t.Errorf("retries exhausted event = %+v, want critical and requiring action", last)
+	}
+	p := last.Payload.(map[string]interface{})
+	if p["retries"] != 2 || p["last_error"] != "start client: connection refused" {
+		t.Errorf("retries exhausted payload = %#v", p)
+	}
+	if p := bus.events[0].Payload.(map[string]interface{}); p["attempt"] != 1 || p["max_retries"] != 2 {
+		t.Errorf("restarting payload = %#v", p)
+	}
+}
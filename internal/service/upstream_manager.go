@@ -9,8 +9,10 @@ import (
 	"io"
 	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/event"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/upstream"
 	"github.com/Sentinel-Gate/Sentinelgate/internal/port/outbound"
 )
@@ -32,7 +34,14 @@ type upstreamConnection struct {
 	retryCount     int
 	connectedSince time.Time
 	cancelRetry    context.CancelFunc // cancels pending retry goroutine
-	mu             sync.Mutex
+	// transition is closed when an in-progress lazy cold start or idle
+	// shutdown finishes; nil when no transition is running.
+	transition chan struct{}
+	// lastActivity is the UnixNano time of the last request or response.
+	lastActivity atomic.Int64
+	// inflight counts the calls registered with BeginCall and not ended.
+	inflight int
+	mu           sync.Mutex
 }
 
 // UpstreamManager handles lifecycle management of multiple MCP server connections.
@@ -55,10 +64,21 @@ type UpstreamManager struct {
 	stabilityDuration      time.Duration
 	stabilityCheckInterval time.Duration
 
+	// Lazy upstream lifecycle.
+	lazyStartTimeout  time.Duration
+	lazyIdleTimeout   time.Duration
+	idleCheckInterval time.Duration
+
 	// onStopCallback is called with the upstream ID after Stop() removes a connection.
 	// Used to clean up external resources (e.g., per-upstream I/O mutexes in the router).
 	onStopCallback func(upstreamID string)
 
+	// eventBus receives upstream lifecycle events (nil = none).
+	eventBus event.Bus
+
+	// drains holds the upstreams draining before a restart, by ID.
+	drains map[string]*drainState
+
 	// ready is closed after construction to signal goroutines they can read config.
 	ready chan struct{}
 }
@@ -87,12 +107,16 @@ func NewUpstreamManager(upstreamService *UpstreamService, clientFactory ClientFa
 		maxRetries:             10,
 		stabilityDuration:      5 * time.Minute,
 		stabilityCheckInterval: 1 * time.Minute,
+		lazyStartTimeout:       30 * time.Second,
+		lazyIdleTimeout:        10 * time.Minute,
+		idleCheckInterval:      30 * time.Second,
 		ready:                  make(chan struct{}),
 	}
 
-	// Start stability reset checker.
-	mgr.wg.Add(1)
+	// Start stability reset checker and lazy idle checker.
+	mgr.wg.Add(2)
 	go mgr.stabilityChecker()
+	go mgr.idleChecker()
 
 	// Signal that configuration is set and background goroutines may read it.
 	// Tests that need to override config should call Init() manually instead.
@@ -131,12 +155,16 @@ func NewUpstreamManagerUnstarted(upstreamService *UpstreamService, clientFactory
 		maxRetries:             10,
 		stabilityDuration:      5 * time.Minute,
 		stabilityCheckInterval: 1 * time.Minute,
+		lazyStartTimeout:       30 * time.Second,
+		lazyIdleTimeout:        10 * time.Minute,
+		idleCheckInterval:      30 * time.Second,
 		ready:                  make(chan struct{}),
 	}
 
-	// Start stability reset checker (will block on ready channel).
-	mgr.wg.Add(1)
+	// Start stability reset and idle checkers (will block on ready channel).
+	mgr.wg.Add(2)
 	go mgr.stabilityChecker()
+	go mgr.idleChecker()
 
 	return mgr
 }
@@ -181,6 +209,8 @@ func (m *UpstreamManager) StartAll(ctx context.Context) error {
 
 // Start starts an individual upstream by ID.
 // If the connection fails, it schedules a retry with exponential backoff.
+// Lazy upstreams are only registered as idle; they are spawned by the first
+// GetConnection call.
 func (m *UpstreamManager) Start(ctx context.Context, upstreamID string) error {
 	// Get upstream config.
 	u, err := m.upstreamService.Get(ctx, upstreamID)
@@ -193,11 +223,19 @@ func (m *UpstreamManager) Start(ctx context.Context, upstreamID string) error {
 		upstream: u,
 		status:   upstream.StatusConnecting,
 	}
+	if isLazy(u) {
+		conn.status = upstream.StatusIdle
+	}
 
 	m.mu.Lock()
 	m.connections[upstreamID] = conn
 	m.mu.Unlock()
 
+	if isLazy(u) {
+		m.logger.Info("lazy upstream registered, will start on first request", "id", u.ID, "name", u.Name)
+		return nil
+	}
+
 	// Attempt connection.
 	m.attemptConnect(conn)
 
@@ -267,6 +305,7 @@ func (m *UpstreamManager) attemptConnect(conn *upstreamConnection) {
 				}
 				return
 			}
+			conn.lastActivity.Store(time.Now().UnixNano())
 			select {
 			case lineCh <- []byte(raw):
 			case <-m.ctx.Done():
@@ -288,9 +327,14 @@ func (m *UpstreamManager) attemptConnect(conn *upstreamConnection) {
 	conn.status = upstream.StatusConnected
 	conn.lastError = ""
 	conn.connectedSince = time.Now()
+	conn.lastActivity.Store(conn.connectedSince.UnixNano())
+	retryCount := conn.retryCount
 	conn.mu.Unlock()
 
 	m.logger.Info("upstream connected", "id", u.ID, "name", u.Name)
+	m.publishUpstreamEvent(conn, EventUpstreamConnected, event.SeverityInfo, map[string]interface{}{
+		"retry_count": retryCount,
+	})
 
 	// Start health monitor goroutine.
 	m.wg.Add(1)
@@ -320,6 +364,16 @@ func (m *UpstreamManager) Stop(upstreamID string) error {
 
 // stopConnection shuts down a connection, cancels retries, and closes the client.
 func (m *UpstreamManager) stopConnection(conn *upstreamConnection) {
+	m.closeConnection(conn)
+
+	conn.mu.Lock()
+	conn.status = upstream.StatusDisconnected
+	conn.mu.Unlock()
+}
+
+// closeConnection cancels retries, closes the client and pipes, and waits for
+// the reader goroutine to exit. It leaves conn.status to the caller.
+func (m *UpstreamManager) closeConnection(conn *upstreamConnection) {
 	conn.mu.Lock()
 
 	// Cancel any pending retry.
@@ -374,10 +428,6 @@ func (m *UpstreamManager) stopConnection(conn *upstreamConnection) {
 			}
 		}
 	}
-
-	conn.mu.Lock()
-	conn.status = upstream.StatusDisconnected
-	conn.mu.Unlock()
 }
 
 // Restart stops and then starts an upstream.
@@ -389,22 +439,75 @@ func (m *UpstreamManager) Restart(ctx context.Context, upstreamID string) error
 }
 
 // GetConnection returns the stdin/stdout for a connected upstream.
+// An idle lazy upstream is started first; the call waits up to the lazy
+// start timeout for it to connect.
 func (m *UpstreamManager) GetConnection(upstreamID string) (io.WriteCloser, <-chan []byte, error) {
 	m.mu.RLock()
 	conn, ok := m.connections[upstreamID]
+	startTimeout := m.lazyStartTimeout
 	m.mu.RUnlock()
 
 	if !ok {
 		return nil, nil, fmt.Errorf("upstream %s not connected", upstreamID)
 	}
 
+	var deadline <-chan time.Time
+	attempted := false
 	conn.mu.Lock()
+	for conn.transition != nil || (conn.status == upstream.StatusIdle && !attempted) {
+		done := conn.transition
+		if done == nil {
+			done = m.beginColdStartLocked(conn)
+		}
+		if conn.status == upstream.StatusConnecting {
+			attempted = true
+		}
+		conn.mu.Unlock()
+
+		if deadline == nil {
+			timer := time.NewTimer(startTimeout)
+			defer timer.Stop()
+			deadline = timer.C
+		}
+		select {
+		case <-done:
+		case <-deadline:
+			return nil, nil, fmt.Errorf("upstream %s did not start within %s", upstreamID, startTimeout)
+		case <-m.ctx.Done():
+			return nil, nil, fmt.Errorf("upstream %s: %w", upstreamID, m.ctx.Err())
+		}
+		conn.mu.Lock()
+	}
 	defer conn.mu.Unlock()
 
+	if conn.status == upstream.StatusIdle && conn.lastError != "" {
+		return nil, nil, fmt.Errorf("upstream %s failed to start: %s", upstreamID, conn.lastError)
+	}
 	if conn.status != upstream.StatusConnected {
 		return nil, nil, fmt.Errorf("upstream %s status is %s, not connected", upstreamID, conn.status)
 	}
 
+	conn.lastActivity.Store(time.Now().UnixNano())
+	return conn.stdin, conn.lineCh, nil
+}
+
+// GetActiveConnection returns the stdin/stdout for an upstream only if it is
+// already connected. Unlike GetConnection it never starts an idle lazy upstream.
+func (m *UpstreamManager) GetActiveConnection(upstreamID string) (io.WriteCloser, <-chan []byte, error) {
+	m.mu.RLock()
+	conn, ok := m.connections[upstreamID]
+	m.mu.RUnlock()
+
+	if !ok {
+		return nil, nil, fmt.Errorf("upstream %s not connected", upstreamID)
+	}
+
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	if conn.transition != nil || conn.status != upstream.StatusConnected {
+		return nil, nil, fmt.Errorf("upstream %s status is %s, not connected", upstreamID, conn.status)
+	}
 	return conn.stdin, conn.lineCh, nil
 }
 
@@ -427,6 +530,7 @@ func (m *UpstreamManager) Status(upstreamID string) (upstream.ConnectionStatus,
 // Despite its name, this checks for "any connected" — used for availability
 // gating (503 status check). Renamed semantics: returns true when at least one
 // upstream is reachable, false only when all are disconnected.
+// Idle lazy upstreams count as reachable since they start on demand.
 func (m *UpstreamManager) AllConnected() bool {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -435,7 +539,7 @@ func (m *UpstreamManager) AllConnected() bool {
 		conn.mu.Lock()
 		status := conn.status
 		conn.mu.Unlock()
-		if status == upstream.StatusConnected {
+		if status == upstream.StatusConnected || status == upstream.StatusIdle {
 			return true
 		}
 	}
@@ -521,6 +625,18 @@ func (m *UpstreamManager) SetGlobalRetryConfig(maxRetries int, backoffCap time.D
 	m.backoffCap = backoffCap
 }
 
+// SetLazyConfig sets how long a request waits for a lazy upstream to start
+// and how long a lazy upstream may sit idle before it is stopped.
+// A non-positive idleTimeout disables idle shutdown.
+func (m *UpstreamManager) SetLazyConfig(startTimeout, idleTimeout time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if startTimeout > 0 {
+		m.lazyStartTimeout = startTimeout
+	}
+	m.lazyIdleTimeout = idleTimeout
+}
+
 // ResetRetryCount resets the retry counter for a specific upstream to zero.
 // This is useful after manual intervention that resolves the underlying issue,
 // allowing the manager to attempt reconnection as if the upstream was fresh.
@@ -572,17 +688,41 @@ func (m *UpstreamManager) scheduleRetry(conn *upstreamConnection) {
 
 	conn.mu.Lock()
 
+	// Lazy upstreams are not retried in the background: they go back to
+	// idle and the next routed request attempts a fresh start.
+	if isLazy(conn.upstream) {
+		conn.status = upstream.StatusIdle
+		conn.mu.Unlock()
+		m.logger.Info("lazy upstream returned to idle", "id", conn.upstream.ID)
+		return
+	}
+
+	// Reverse upstreams cannot be dialed: they wait disconnected until the
+	// server registers again, which restarts them.
+	if conn.upstream.Type == upstream.UpstreamTypeReverse {
+		conn.status = upstream.StatusDisconnected
+		conn.mu.Unlock()
+		m.logger.Info("reverse upstream waiting for the server to register", "id", conn.upstream.ID)
+		return
+	}
+
 	if conn.retryCount >= maxRetries {
+		lastError := conn.lastError
 		conn.status = upstream.StatusError
 		conn.lastError = fmt.Sprintf("max retries (%d) exceeded", maxRetries)
 		conn.mu.Unlock()
 		m.logger.Error("max retries exceeded", "id", conn.upstream.ID, "retries", maxRetries)
+		m.publishUpstreamEvent(conn, EventUpstreamRetriesExhausted, event.SeverityCritical, map[string]interface{}{
+			"retries":    maxRetries,
+			"last_error": lastError,
+		})
 		return
 	}
 
 	delay := calcBackoffDelay(conn.retryCount, backoffBase, backoffCap)
 	conn.retryCount++
 	attempt := conn.retryCount
+	lastError := conn.lastError
 	conn.status = upstream.StatusConnecting
 
 	// Create a cancellable context for this retry.
@@ -592,6 +732,12 @@ func (m *UpstreamManager) scheduleRetry(conn *upstreamConnection) {
 	conn.mu.Unlock()
 
 	m.logger.Info("scheduling retry", "id", upstreamID, "attempt", attempt, "delay", delay)
+	m.publishUpstreamEvent(conn, EventUpstreamRestarting, event.SeverityWarning, map[string]interface{}{
+		"attempt":       attempt,
+		"max_retries":   maxRetries,
+		"retry_in_secs": delay.Seconds(),
+		"last_error":    lastError,
+	})
 
 	// M-2: Track retry goroutine in WaitGroup to prevent it from surviving Close().
 	m.wg.Add(1)
@@ -636,7 +782,8 @@ func (m *UpstreamManager) monitorHealth(conn *upstreamConnection) {
 	}
 
 	// Wait blocks until the process exits or connection drops.
-	if waitErr := client.Wait(); waitErr != nil {
+	waitErr := client.Wait()
+	if waitErr != nil {
 		m.logger.Debug("upstream client.Wait returned error", "id", upstreamID, "error", waitErr)
 	}
 
@@ -656,8 +803,19 @@ func (m *UpstreamManager) monitorHealth(conn *upstreamConnection) {
 	}
 
 	conn.mu.Lock()
+	if conn.client != client {
+		// Client was closed on purpose (idle shutdown); nothing to reconnect.
+		conn.mu.Unlock()
+		return
+	}
 	conn.status = upstream.StatusDisconnected
 	conn.client = nil
+	connectedFor := time.Since(conn.connectedSince)
+	exitReason := "process exited"
+	if waitErr != nil {
+		exitReason = waitErr.Error()
+	}
+	conn.lastError = exitReason
 	// Close stdout to unblock the scanner goroutine, which will in turn
 	// close lineCh. This ensures pending readers on lineCh are unblocked
 	// promptly rather than waiting for an indeterminate delay.
@@ -670,6 +828,10 @@ func (m *UpstreamManager) monitorHealth(conn *upstreamConnection) {
 	conn.mu.Unlock()
 
 	m.logger.Warn("upstream disconnected, scheduling reconnect", "id", upstreamID)
+	m.publishUpstreamEvent(conn, EventUpstreamDisconnected, event.SeverityWarning, map[string]interface{}{
+		"error":              exitReason,
+		"connected_for_secs": int64(connectedFor.Seconds()),
+	})
 	m.scheduleRetry(conn)
 }
 
@@ -722,6 +884,102 @@ func (m *UpstreamManager) checkStability() {
 	}
 }
 
+// --- Lazy lifecycle ---
+
+// isLazy reports whether u is started on demand rather than at boot.
+func isLazy(u *upstream.Upstream) bool {
+	return u.Lazy && u.Type == upstream.UpstreamTypeStdio
+}
+
+// beginColdStartLocked starts connecting an idle lazy upstream in the
+// background and returns a channel closed when the attempt finishes.
+// Caller must hold conn.mu.
+func (m *UpstreamManager) beginColdStartLocked(conn *upstreamConnection) chan struct{} {
+	done := make(chan struct{})
+	conn.transition = done
+	conn.status = upstream.StatusConnecting
+	conn.lastError = ""
+
+	m.logger.Info("starting lazy upstream on demand", "id", conn.upstream.ID, "name", conn.upstream.Name)
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		m.attemptConnect(conn)
+		conn.mu.Lock()
+		conn.transition = nil
+		conn.mu.Unlock()
+		close(done)
+	}()
+	return done
+}
+
+// idleChecker periodically stops lazy upstreams that have been idle longer
+// than the configured idle timeout.
+func (m *UpstreamManager) idleChecker() {
+	defer m.wg.Done()
+	select {
+	case <-m.ready:
+	case <-m.ctx.Done():
+		return
+	}
+
+	ticker := time.NewTicker(m.idleCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.checkIdle()
+		case <-m.ctx.Done():
+			return
+		}
+	}
+}
+
+// checkIdle stops connected lazy upstreams with no call in flight and no
+// request or response activity for at least the idle timeout. Stopped
+// upstreams go back to idle.
+func (m *UpstreamManager) checkIdle() {
+	m.mu.RLock()
+	idleTimeout := m.lazyIdleTimeout
+	conns := make([]*upstreamConnection, 0, len(m.connections))
+	for _, conn := range m.connections {
+		conns = append(conns, conn)
+	}
+	m.mu.RUnlock()
+
+	if idleTimeout <= 0 {
+		return
+	}
+
+	now := time.Now()
+	for _, conn := range conns {
+		conn.mu.Lock()
+		idleFor := now.Sub(time.Unix(0, conn.lastActivity.Load()))
+		if !isLazy(conn.upstream) || conn.status != upstream.StatusConnected ||
+			conn.transition != nil || conn.inflight > 0 || idleFor < idleTimeout {
+			conn.mu.Unlock()
+			continue
+		}
+		done := make(chan struct{})
+		conn.transition = done
+		conn.mu.Unlock()
+
+		m.closeConnection(conn)
+
+		conn.mu.Lock()
+		conn.status = upstream.StatusIdle
+		conn.connectedSince = time.Time{}
+		conn.transition = nil
+		conn.mu.Unlock()
+		close(done)
+
+		m.logger.Info("lazy upstream stopped after idle period",
+			"id", conn.upstream.ID, "name", conn.upstream.Name, "idle", idleFor.Round(time.Second))
+	}
+}
+
 // performInitHandshake sends MCP initialize + notifications/initialized through
 // the given pipes. This is required for HTTP Streamable transports where the
 // upstream server creates a session on initialize and requires subsequent
@@ -1102,3 +1102,159 @@ func TestResetRetryCount_NotManaged(t *testing.T) {
 		t.Fatal("ResetRetryCount() for unmanaged upstream should return error")
 	}
 }
+
+// --- Lazy lifecycle Tests ---
+
+func lazyTestUpstream() *upstream.Upstream {
+	return &upstream.Upstream{
+		ID:      "lazy-1",
+		Name:    "lazy-server",
+		Type:    upstream.UpstreamTypeStdio,
+		Enabled: true,
+		Command: "/usr/bin/echo",
+		Lazy:    true,
+	}
+}
+
+func TestUpstreamManager_Lazy_NotSpawnedAtStart(t *testing.T) {
+	mgr, clients := testManagerEnv(t, lazyTestUpstream())
+	defer goleak.VerifyNone(t)
+	defer func() { _ = mgr.Close() }()
+
+	if err := mgr.StartAll(context.Background()); err != nil {
+		t.Fatalf("StartAll() unexpected error: %v", err)
+	}
+
+	if status, _ := mgr.Status("lazy-1"); status != upstream.StatusIdle {
+		t.Errorf("status = %q, want %q", status, upstream.StatusIdle)
+	}
+	if _, ok := clients["lazy-1"]; ok {
+		t.Error("lazy upstream should not be spawned at start")
+	}
+	if !mgr.AllConnected() {
+		t.Error("AllConnected() = false, want true for idle lazy upstream")
+	}
+	if _, _, err := mgr.GetActiveConnection("lazy-1"); err == nil {
+		t.Error("GetActiveConnection() should fail for idle upstream")
+	}
+	if _, ok := clients["lazy-1"]; ok {
+		t.Error("GetActiveConnection() must not start a lazy upstream")
+	}
+}
+
+func TestUpstreamManager_Lazy_ColdStartAndIdleShutdown(t *testing.T) {
+	mgr, clients := testManagerEnv(t, lazyTestUpstream())
+	defer goleak.VerifyNone(t)
+	defer func() { _ = mgr.Close() }()
+
+	if err := mgr.StartAll(context.Background()); err != nil {
+		t.Fatalf("StartAll() unexpected error: %v", err)
+	}
+
+	writer, lineCh, err := mgr.GetConnection("lazy-1")
+	if err != nil {
+		t.Fatalf("GetConnection() cold start error: %v", err)
+	}
+	if writer == nil || lineCh == nil {
+		t.Fatal("GetConnection() returned nil connection after cold start")
+	}
+	if status, _ := mgr.Status("lazy-1"); status != upstream.StatusConnected {
+		t.Fatalf("status = %q, want %q", status, upstream.StatusConnected)
+	}
+	first := clients["lazy-1"]
+
+	// Not idle long enough: stays connected.
+	mgr.SetLazyConfig(time.Second, time.Hour)
+	mgr.checkIdle()
+	if status, _ := mgr.Status("lazy-1"); status != upstream.StatusConnected {
+		t.Fatalf("status after recent use = %q, want %q", status, upstream.StatusConnected)
+	}
+
+	mgr.SetLazyConfig(time.Second, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	mgr.checkIdle()
+	if status, _ := mgr.Status("lazy-1"); status != upstream.StatusIdle {
+		t.Fatalf("status after idle = %q, want %q", status, upstream.StatusIdle)
+	}
+	if !first.isClosed() {
+		t.Error("idle shutdown should close the upstream client")
+	}
+
+	// Next request starts a fresh process.
+	if _, _, err := mgr.GetConnection("lazy-1"); err != nil {
+		t.Fatalf("GetConnection() restart error: %v", err)
+	}
+	if clients["lazy-1"] == first {
+		t.Error("expected a new client after idle restart")
+	}
+}
+
+func TestUpstreamManager_Lazy_NoIdleShutdownDuringCall(t *testing.T) {
+	mgr, clients := testManagerEnv(t, lazyTestUpstream())
+	defer goleak.VerifyNone(t)
+	defer func() { _ = mgr.Close() }()
+
+	if err := mgr.StartAll(context.Background()); err != nil {
+		t.Fatalf("StartAll() unexpected error: %v", err)
+	}
+	if _, _, err := mgr.GetConnection("lazy-1"); err != nil {
+		t.Fatalf("GetConnection() cold start error: %v", err)
+	}
+	end, err := mgr.BeginCall(context.Background(), "lazy-1")
+	if err != nil {
+		t.Fatalf("BeginCall() error: %v", err)
+	}
+
+	// A long call is idle by timestamp but still in flight.
+	mgr.SetLazyConfig(time.Second, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	mgr.checkIdle()
+	if status, _ := mgr.Status("lazy-1"); status != upstream.StatusConnected {
+		t.Fatalf("status during call = %q, want %q", status, upstream.StatusConnected)
+	}
+
+	if clients["lazy-1"].isClosed() {
+		t.Error("upstream client closed during a call")
+	}
+
+	// Ending the call counts as activity.
+	mgr.mu.RLock()
+	conn := mgr.connections["lazy-1"]
+	mgr.mu.RUnlock()
+	before := time.Now().UnixNano()
+	end()
+	if last := conn.lastActivity.Load(); last < before {
+		t.Errorf("lastActivity = %d, want at least %d after the call ended", last, before)
+	}
+}
+
+func TestUpstreamManager_Lazy_StartFailureReturnsToIdle(t *testing.T) {
+	store := newMgrMockUpstreamStore()
+	_ = store.Add(context.Background(), lazyTestUpstream())
+	logger := testManagerLogger()
+	svc := NewUpstreamService(store, nil, logger)
+
+	var attempts atomic.Int32
+	factory := func(u *upstream.Upstream) (outbound.MCPClient, error) {
+		attempts.Add(1)
+		return nil, errors.New("spawn failed")
+	}
+	mgr := NewUpstreamManager(svc, factory, logger)
+	defer goleak.VerifyNone(t)
+	defer func() { _ = mgr.Close() }()
+
+	if err := mgr.Start(context.Background(), "lazy-1"); err != nil {
+		t.Fatalf("Start() unexpected error: %v", err)
+	}
+
+	_, _, err := mgr.GetConnection("lazy-1")
+	if err == nil || !strings.Contains(err.Error(), "spawn failed") {
+		t.Fatalf("GetConnection() error = %v, want start failure", err)
+	}
+	if status, _ := mgr.Status("lazy-1"); status != upstream.StatusIdle {
+		t.Errorf("status = %q, want %q", status, upstream.StatusIdle)
+	}
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("attempts = %d, want 1 (no background retries for lazy upstreams)", got)
+	}
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"crypto/subtle"
 	"fmt"
 	"log/slog"
 	"sync"
@@ -17,13 +18,13 @@ import (
 // with validation and persistence to state.json.
 type UpstreamService struct {
 	store      upstream.UpstreamStore
-	stateStore *state.FileStateStore
+	stateStore state.Store
 	logger     *slog.Logger
 	mu         sync.Mutex // serializes mutations (check + modify + persist atomically)
 }
 
 // NewUpstreamService creates a new UpstreamService.
-func NewUpstreamService(store upstream.UpstreamStore, stateStore *state.FileStateStore, logger *slog.Logger) *UpstreamService {
+func NewUpstreamService(store upstream.UpstreamStore, stateStore state.Store, logger *slog.Logger) *UpstreamService {
 	return &UpstreamService{
 		store:      store,
 		stateStore: stateStore,
@@ -42,6 +43,29 @@ func (s *UpstreamService) Get(ctx context.Context, id string) (*upstream.Upstrea
 	return s.store.Get(ctx, id)
 }
 
+// UpstreamIDByName returns the ID of the upstream with the given name.
+func (s *UpstreamService) UpstreamIDByName(ctx context.Context, name string) (string, bool) {
+	all, err := s.store.List(ctx)
+	if err != nil {
+		return "", false
+	}
+	for _, u := range all {
+		if u.Name == name {
+			return u.ID, true
+		}
+	}
+	return "", false
+}
+
+// UpstreamNameByID returns the name of the upstream with the given ID.
+func (s *UpstreamService) UpstreamNameByID(ctx context.Context, id string) (string, bool) {
+	u, err := s.store.Get(ctx, id)
+	if err != nil {
+		return "", false
+	}
+	return u.Name, true
+}
+
 // Add validates and creates a new upstream, persisting the change to state.json.
 // Generates a UUID, sets timestamps, checks name uniqueness, and validates configuration.
 // Holds mu across the entire check-modify-persist sequence to prevent TOCTOU races
@@ -202,6 +226,51 @@ func (s *UpstreamService) SetEnabled(ctx context.Context, id string, enabled boo
 	return s.store.Get(ctx, id)
 }
 
+// VerifyRegistrationToken returns the ID of the enabled reverse upstream
+// the registration token belongs to, or upstream.ErrInvalidRegistrationToken.
+func (s *UpstreamService) VerifyRegistrationToken(ctx context.Context, token string) (string, error) {
+	if token == "" {
+		return "", upstream.ErrInvalidRegistrationToken
+	}
+	hash := upstream.HashRegistrationToken(token)
+	upstreams, err := s.store.List(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, u := range upstreams {
+		if u.Type != upstream.UpstreamTypeReverse || !u.Enabled || u.RegistrationTokenHash == "" {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(hash), []byte(u.RegistrationTokenHash)) == 1 {
+			return u.ID, nil
+		}
+	}
+	return "", upstream.ErrInvalidRegistrationToken
+}
+
+// RotateRegistrationToken replaces the registration token of a reverse
+// upstream and returns the new token. The old token stops working at once;
+// connections already registered with it are not closed here.
+func (s *UpstreamService) RotateRegistrationToken(ctx context.Context, id string) (string, error) {
+	existing, err := s.Get(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if existing.Type != upstream.UpstreamTypeReverse {
+		return "", fmt.Errorf("validation failed: upstream %q is not a reverse upstream", existing.Name)
+	}
+	token, hash, err := upstream.NewRegistrationToken()
+	if err != nil {
+		return "", err
+	}
+	existing.RegistrationTokenHash = hash
+	if _, err := s.Update(ctx, id, existing); err != nil {
+		return "", err
+	}
+	s.logger.Info("upstream registration token rotated", "id", id)
+	return token, nil
+}
+
 // LoadFromState populates the in-memory store from the given AppState.
 // Called at boot to restore persisted upstream configuration.
 // The ctx parameter enables cancellation during startup.
@@ -211,17 +280,26 @@ func (s *UpstreamService) LoadFromState(ctx context.Context, appState *state.App
 	for i := range appState.Upstreams {
 		entry := &appState.Upstreams[i]
 		u := &upstream.Upstream{
-			ID:        entry.ID,
-			Name:      entry.Name,
-			Type:      upstream.UpstreamType(entry.Type),
-			Enabled:   entry.Enabled,
-			Command:   entry.Command,
-			Args:      entry.Args,
-			URL:       entry.URL,
-			Env:       entry.Env,
-			Status:    upstream.StatusDisconnected,
-			CreatedAt: entry.CreatedAt,
-			UpdatedAt: entry.UpdatedAt,
+			ID:                    entry.ID,
+			Name:                  entry.Name,
+			Type:                  upstream.UpstreamType(entry.Type),
+			Enabled:               entry.Enabled,
+			Command:               entry.Command,
+			Args:                  entry.Args,
+			URL:                   entry.URL,
+			Spec:                  entry.Spec,
+			Headers:               entry.Headers,
+			Env:                   entry.Env,
+			Lazy:                  entry.Lazy,
+			Framing:               entry.Framing,
+			Status:                upstream.StatusDisconnected,
+			RegistrationTokenHash: entry.RegistrationTokenHash,
+			Credentials:           UpstreamCredentialsFromEntry(entry.Credentials),
+			ToolPrefix:            entry.ToolPrefix,
+			ToolInclude:           entry.ToolInclude,
+			ToolExclude:           entry.ToolExclude,
+			CreatedAt:             entry.CreatedAt,
+			UpdatedAt:             entry.UpdatedAt,
 		}
 
 		// M-25: Validate required fields before loading; skip invalid entries
@@ -273,16 +351,25 @@ func (s *UpstreamService) persistStateLocked(ctx context.Context) error {
 	entries := make([]state.UpstreamEntry, len(upstreams))
 	for i, u := range upstreams {
 		entries[i] = state.UpstreamEntry{
-			ID:        u.ID,
-			Name:      u.Name,
-			Type:      string(u.Type),
-			Enabled:   u.Enabled,
-			Command:   u.Command,
-			Args:      u.Args,
-			URL:       u.URL,
-			Env:       u.Env,
-			CreatedAt: u.CreatedAt,
-			UpdatedAt: u.UpdatedAt,
+			ID:                    u.ID,
+			Name:                  u.Name,
+			Type:                  string(u.Type),
+			Enabled:               u.Enabled,
+			Command:               u.Command,
+			Args:                  u.Args,
+			URL:                   u.URL,
+			Spec:                  u.Spec,
+			Headers:               u.Headers,
+			Env:                   u.Env,
+			Lazy:                  u.Lazy,
+			Framing:               u.Framing,
+			CreatedAt:             u.CreatedAt,
+			UpdatedAt:             u.UpdatedAt,
+			RegistrationTokenHash: u.RegistrationTokenHash,
+			Credentials:           credentialsToEntry(u.Credentials),
+			ToolPrefix:            u.ToolPrefix,
+			ToolInclude:           u.ToolInclude,
+			ToolExclude:           u.ToolExclude,
 		}
 	}
 
@@ -291,3 +378,43 @@ func (s *UpstreamService) persistStateLocked(ctx context.Context) error {
 		return nil
 	})
 }
+
+// UpstreamCredentialsFromEntry converts persisted upstream credentials to the
+// domain type. Returns nil for nil.
+func UpstreamCredentialsFromEntry(e *state.UpstreamCredentialsEntry) *upstream.Credentials {
+	if e == nil {
+		return nil
+	}
+	return &upstream.Credentials{
+		Type:         upstream.CredentialType(e.Type),
+		Token:        e.Token,
+		Username:     e.Username,
+		Password:     e.Password,
+		HeaderName:   e.HeaderName,
+		HeaderValue:  e.HeaderValue,
+		TokenURL:     e.TokenURL,
+		ClientID:     e.ClientID,
+		ClientSecret: e.ClientSecret,
+		Scopes:       e.Scopes,
+	}
+}
+
+// credentialsToEntry converts upstream credentials to their persisted form.
+// Returns nil for nil.
+func credentialsToEntry(c *upstream.Credentials) *state.UpstreamCredentialsEntry {
+	if c == nil {
+		return nil
+	}
+	return &state.UpstreamCredentialsEntry{
+		Type:         string(c.Type),
+		Token:        c.Token,
+		Username:     c.Username,
+		Password:     c.Password,
+		HeaderName:   c.HeaderName,
+		HeaderValue:  c.HeaderValue,
+		TokenURL:     c.TokenURL,
+		ClientID:     c.ClientID,
+		ClientSecret: c.ClientSecret,
+		Scopes:       c.Scopes,
+	}
+}
@@ -13,31 +13,93 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/Sentinel-Gate/Sentinelgate/internal/domain/event"
+	"github.com/google/uuid"
 )
 
-// WebhookService sends event notifications to a configured HTTP endpoint.
-// It subscribes to the Event Bus and POSTs JSON payloads for matching events.
+// WebhookService sends event notifications to one or more HTTP endpoints.
+// It is an event.Sink: it POSTs JSON payloads for the events matching each
+// endpoint's filter, retrying failed deliveries and keeping a per-endpoint
+// delivery log.
 type WebhookService struct {
-	url        string
-	secret     string
-	events     map[string]bool // empty = all events
-	client     *http.Client
-	logger     *slog.Logger
-	mu          sync.Mutex
-	unsubscribe func()
-	wg          sync.WaitGroup    // H-4/M-29: tracks in-flight sends
-	sendSem     chan struct{}      // H-4: bounded concurrency semaphore
-	stopCh      chan struct{}      // H-9: signals goroutines to abort semaphore wait
+	endpoints    []*webhookEndpoint
+	retries      int
+	retryBackoff time.Duration
+	client       *http.Client
+	logger       *slog.Logger
+	mu           sync.Mutex
+	unsubscribe  func()
+	wg           sync.WaitGroup // H-4/M-29: tracks in-flight sends
+	sendSem      chan struct{}  // H-4: bounded concurrency semaphore
+	stopCh       chan struct{}  // H-9: signals goroutines to abort semaphore wait
+}
+
+// WebhookEndpoint configures one webhook target.
+type WebhookEndpoint struct {
+	// Name identifies the endpoint in the delivery log.
+	Name string
+	// URL is the HTTP endpoint to POST events to.
+	URL string
+	// Secret is an optional HMAC-SHA256 secret for signing payloads.
+	Secret string
+	// Events filters which event types are sent (empty = all). A trailing
+	// "*" matches a prefix, e.g. "upstream.*".
+	Events []string
+	// Categories filters which event categories are sent (empty = all).
+	Categories []event.Category
+	// MinSeverity drops events below this severity.
+	MinSeverity event.Severity
+}
+
+// webhookDeliveryLogSize is the number of deliveries kept per endpoint.
+const webhookDeliveryLogSize = 100
+
+// webhookEndpoint is a configured endpoint with its delivery log.
+type webhookEndpoint struct {
+	WebhookEndpoint
+	mu        sync.Mutex
+	log       []WebhookDelivery // ring buffer, oldest overwritten first
+	next      int
+	delivered uint64
+	failed    uint64
+}
+
+// WebhookDelivery records the outcome of one event delivery to one endpoint.
+type WebhookDelivery struct {
+	ID         string    `json:"id"`
+	Endpoint   string    `json:"endpoint"`
+	EventType  string    `json:"event_type"`
+	Timestamp  time.Time `json:"timestamp"`
+	Attempts   int       `json:"attempts"`
+	Success    bool      `json:"success"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+// WebhookEndpointStatus summarizes an endpoint for the admin API. Secrets
+// are never included and URL credentials are redacted.
+type WebhookEndpointStatus struct {
+	Name         string           `json:"name"`
+	URL          string           `json:"url"`
+	Events       []string         `json:"events"`
+	Categories   []event.Category `json:"categories"`
+	MinSeverity  string           `json:"min_severity"`
+	Signed       bool             `json:"signed"`
+	Delivered    uint64           `json:"delivered"`
+	Failed       uint64           `json:"failed"`
+	LastDelivery *WebhookDelivery `json:"last_delivery,omitempty"`
 }
 
 // WebhookPayload is the JSON body sent to the webhook endpoint.
 type WebhookPayload struct {
 	Type           string    `json:"type"`
+	Category       string    `json:"category"`
 	Source         string    `json:"source"`
 	Severity       string    `json:"severity"`
 	Timestamp      time.Time `json:"timestamp"`
@@ -45,17 +107,12 @@ type WebhookPayload struct {
 	Payload        any       `json:"payload,omitempty"`
 }
 
-// NewWebhookService creates a webhook notification service.
+// NewWebhookService creates a webhook notification service with a single
+// endpoint named "default". Further endpoints are added with AddEndpoint.
+// Retries are off until SetRetryPolicy is called.
 // H-1: Uses SSRF-safe dialer to prevent DNS rebinding attacks at TCP connect time.
 func NewWebhookService(url, secret string, eventFilter []string, logger *slog.Logger) *WebhookService {
-	events := make(map[string]bool)
-	for _, e := range eventFilter {
-		events[e] = true
-	}
-	return &WebhookService{
-		url:    url,
-		secret: secret,
-		events: events,
+	s := &WebhookService{
 		client: &http.Client{
 			Timeout: 10 * time.Second,
 			Transport: &http.Transport{
@@ -69,6 +126,28 @@ func NewWebhookService(url, secret string, eventFilter []string, logger *slog.Lo
 		sendSem: make(chan struct{}, 10), // H-4: max 10 concurrent sends
 		stopCh:  make(chan struct{}),     // H-9: stop channel for graceful shutdown
 	}
+	if url != "" {
+		s.AddEndpoint(WebhookEndpoint{Name: "default", URL: url, Secret: secret, Events: eventFilter})
+	}
+	return s
+}
+
+// AddEndpoint registers an additional endpoint. It must be called before
+// SubscribeToBus.
+func (s *WebhookService) AddEndpoint(ep WebhookEndpoint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.endpoints = append(s.endpoints, &webhookEndpoint{WebhookEndpoint: ep})
+}
+
+// SetRetryPolicy sets how many times a failed delivery is retried and the
+// delay before the first retry; the delay doubles for each further attempt.
+// Network errors, 429 and 5xx responses are retried; other 4xx are not.
+func (s *WebhookService) SetRetryPolicy(retries int, backoff time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retries = retries
+	s.retryBackoff = backoff
 }
 
 // SetHTTPClient overrides the default SSRF-safe HTTP client (for testing only).
@@ -114,12 +193,28 @@ func webhookSSRFSafeDialer() *net.Dialer {
 }
 
 // SubscribeToBus registers the webhook as a consumer of events on the bus.
-// H-4: send() is dispatched asynchronously with bounded concurrency to avoid
-// blocking the event bus dispatch loop.
+// Use it when the webhook is not registered with an event.Dispatcher.
 func (s *WebhookService) SubscribeToBus(bus event.Bus) {
 	unsub := bus.SubscribeAll(func(ctx context.Context, evt event.Event) {
-		if len(s.events) > 0 && !s.events[evt.Type] {
-			return
+		_ = s.Deliver(ctx, evt)
+	})
+	s.mu.Lock()
+	s.unsubscribe = unsub
+	s.mu.Unlock()
+}
+
+// Name implements event.Sink.
+func (s *WebhookService) Name() string { return "webhook" }
+
+// Deliver implements event.Sink. It starts one delivery per matching
+// endpoint and returns at once; retries are per endpoint, so the sink is
+// registered without dispatcher retries.
+// H-4: deliveries are dispatched asynchronously with bounded concurrency to
+// avoid blocking the event bus dispatch loop.
+func (s *WebhookService) Deliver(ctx context.Context, evt event.Event) error {
+	for _, ep := range s.endpoints {
+		if !ep.matches(evt) {
+			continue
 		}
 		s.wg.Add(1)
 		go func() {
@@ -129,15 +224,79 @@ func (s *WebhookService) SubscribeToBus(bus event.Bus) {
 			select {
 			case s.sendSem <- struct{}{}:
 				defer func() { <-s.sendSem }()
-				s.send(evt)
+				s.deliver(ep, evt)
 			case <-s.stopCh:
 				return
 			}
 		}()
-	})
-	s.mu.Lock()
-	s.unsubscribe = unsub
-	s.mu.Unlock()
+	}
+	return nil
+}
+
+// matches reports whether the endpoint's filter accepts evt.
+func (ep *webhookEndpoint) matches(evt event.Event) bool {
+	return event.Filter{Types: ep.Events, Categories: ep.Categories, MinSeverity: ep.MinSeverity}.Matches(evt)
+}
+
+// Endpoints returns the configured endpoints with delivery counters.
+func (s *WebhookService) Endpoints() []WebhookEndpointStatus {
+	out := make([]WebhookEndpointStatus, 0, len(s.endpoints))
+	for _, ep := range s.endpoints {
+		ep.mu.Lock()
+		st := WebhookEndpointStatus{
+			Name:        ep.Name,
+			URL:         redactURL(ep.URL),
+			Events:      append([]string{}, ep.Events...),
+			Categories:  append([]event.Category{}, ep.Categories...),
+			MinSeverity: ep.MinSeverity.String(),
+			Signed:      ep.Secret != "",
+			Delivered:   ep.delivered,
+			Failed:      ep.failed,
+		}
+		if n := len(ep.log); n > 0 {
+			last := ep.log[(ep.next+n-1)%n]
+			st.LastDelivery = &last
+		}
+		ep.mu.Unlock()
+		out = append(out, st)
+	}
+	return out
+}
+
+// Deliveries returns the delivery log of the named endpoint, newest first.
+// The second result is false when no endpoint has that name.
+func (s *WebhookService) Deliveries(name string) ([]WebhookDelivery, bool) {
+	for _, ep := range s.endpoints {
+		if ep.Name != name {
+			continue
+		}
+		ep.mu.Lock()
+		defer ep.mu.Unlock()
+		n := len(ep.log)
+		out := make([]WebhookDelivery, 0, n)
+		for i := 1; i <= n; i++ {
+			out = append(out, ep.log[(ep.next-i+n)%n])
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+func (ep *webhookEndpoint) record(d WebhookDelivery) {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	if d.Success {
+		ep.delivered++
+	} else {
+		ep.failed++
+	}
+	if len(ep.log) < webhookDeliveryLogSize {
+		ep.log = append(ep.log, d)
+		ep.next = len(ep.log) % webhookDeliveryLogSize
+		return
+	}
+	ep.log[ep.next] = d
+	ep.next = (ep.next + 1) % webhookDeliveryLogSize
 }
 
 // Stop unsubscribes from the event bus and waits for in-flight deliveries.
@@ -168,9 +327,23 @@ func (s *WebhookService) Stop() {
 	}
 }
 
+// send delivers evt to every endpoint, ignoring event filters.
 func (s *WebhookService) send(evt event.Event) {
+	for _, ep := range s.endpoints {
+		s.deliver(ep, evt)
+	}
+}
+
+// deliver POSTs evt to one endpoint, retrying per the retry policy, and
+// records the outcome in the endpoint's delivery log.
+func (s *WebhookService) deliver(ep *webhookEndpoint, evt event.Event) {
+	category := evt.Category
+	if category == "" {
+		category = event.CategoryOf(evt.Type)
+	}
 	payload := WebhookPayload{
 		Type:           evt.Type,
+		Category:       string(category),
 		Source:         evt.Source,
 		Severity:       evt.Severity.String(),
 		Timestamp:      evt.Timestamp,
@@ -184,42 +357,91 @@ func (s *WebhookService) send(evt event.Event) {
 		return
 	}
 
-	req, err := http.NewRequestWithContext(context.Background(), "POST", s.url, bytes.NewReader(body))
-	if err != nil {
-		s.logger.Warn("webhook: failed to create request", "error", err)
-		return
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "SentinelGate-Webhook/1.0")
-
-	if s.secret != "" {
-		mac := hmac.New(sha256.New, []byte(s.secret))
+	var signature string
+	if ep.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(ep.Secret))
 		if _, err := mac.Write(body); err != nil {
 			s.logger.Warn("webhook: hmac write failed", "error", err)
 			return
 		}
-		sig := hex.EncodeToString(mac.Sum(nil))
-		req.Header.Set("X-Signature-256", "sha256="+sig)
+		signature = "sha256=" + hex.EncodeToString(mac.Sum(nil))
 	}
 
-	// Read client under lock to avoid race with SetHTTPClient.
+	// Read client and retry policy under lock to avoid races with setters.
 	s.mu.Lock()
 	client := s.client
+	retries := s.retries
+	backoff := s.retryBackoff
 	s.mu.Unlock()
 
+	d := WebhookDelivery{
+		ID:        uuid.New().String(),
+		Endpoint:  ep.Name,
+		EventType: evt.Type,
+		Timestamp: time.Now(),
+	}
+	for {
+		d.Attempts++
+		status, err := s.post(client, ep.URL, body, signature, d.ID, d.Attempts)
+		d.StatusCode = status
+		d.Error = ""
+		if err != nil {
+			d.Error = err.Error()
+		} else if status >= 400 {
+			d.Error = http.StatusText(status)
+		} else {
+			d.Success = true
+			break
+		}
+		retryable := err != nil || status == http.StatusTooManyRequests || status >= 500
+		if !retryable || d.Attempts > retries {
+			break
+		}
+		delay := backoff << (d.Attempts - 1)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-s.stopCh:
+			timer.Stop()
+			d.Error += " (retries aborted at shutdown)"
+			d.DurationMs = time.Since(d.Timestamp).Milliseconds()
+			ep.record(d)
+			return
+		}
+	}
+	d.DurationMs = time.Since(d.Timestamp).Milliseconds()
+	ep.record(d)
+
+	if !d.Success {
+		s.logger.Warn("webhook: delivery failed", "endpoint", ep.Name, "url", redactURL(ep.URL),
+			"event", evt.Type, "attempts", d.Attempts, "status", d.StatusCode, "error", d.Error)
+	}
+}
+
+// post sends one delivery attempt and returns the response status.
+func (s *WebhookService) post(client *http.Client, target string, body []byte, signature, deliveryID string, attempt int) (int, error) {
+	req, err := http.NewRequestWithContext(context.Background(), "POST", target, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "SentinelGate-Webhook/1.0")
+	// The delivery ID is stable across retries so receivers can deduplicate.
+	req.Header.Set("X-Webhook-Delivery", deliveryID)
+	req.Header.Set("X-Webhook-Attempt", strconv.Itoa(attempt))
+	if signature != "" {
+		req.Header.Set("X-Signature-256", signature)
+	}
+
 	resp, err := client.Do(req)
 	if err != nil {
-		s.logger.Warn("webhook: delivery failed", "url", redactURL(s.url), "event", evt.Type, "error", err)
-		return
+		return 0, err
 	}
 	defer func() {
 		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
 		resp.Body.Close()
 	}()
-
-	if resp.StatusCode >= 400 {
-		s.logger.Warn("webhook: endpoint returned error", "url", redactURL(s.url), "event", evt.Type, "status", resp.StatusCode)
-	}
+	return resp.StatusCode, nil
 }
 
 // redactURL removes userinfo (credentials) from a URL for safe logging (L-65).
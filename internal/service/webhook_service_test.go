@@ -10,6 +10,7 @@ import (
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
@@ -186,3 +187,163 @@ func TestWebhookService_ContentType(t *testing.T) {
 		t.Errorf("expected Content-Type application/json, got %s", contentType)
 	}
 }
+
+func TestWebhookService_RetryAndDeliveryLog(t *testing.T) {
+	var mu sync.Mutex
+	var calls int
+	var deliveryIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		deliveryIDs = append(deliveryIDs, r.Header.Get("X-Webhook-Delivery"))
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	svc := newTestWebhookService(server.URL, "", nil)
+	svc.SetRetryPolicy(3, time.Millisecond)
+	svc.send(event.Event{Type: "upstream.disconnected", Source: "test"})
+
+	mu.Lock()
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3 (two 503s, then success)", calls)
+	}
+	if deliveryIDs[0] == "" || deliveryIDs[0] != deliveryIDs[2] {
+		t.Errorf("delivery IDs = %v, want one stable ID across retries", deliveryIDs)
+	}
+	mu.Unlock()
+
+	log, ok := svc.Deliveries("default")
+	if !ok || len(log) != 1 {
+		t.Fatalf("Deliveries() = %v, %v", log, ok)
+	}
+	if d := log[0]; !d.Success || d.Attempts != 3 || d.StatusCode != 200 || d.EventType != "upstream.disconnected" {
+		t.Errorf("delivery = %+v", d)
+	}
+	if _, ok := svc.Deliveries("missing"); ok {
+		t.Error("Deliveries(missing) reported an endpoint")
+	}
+}
+
+func TestWebhookService_NoRetryOnClientError(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	svc := newTestWebhookService(server.URL, "", nil)
+	svc.SetRetryPolicy(3, time.Millisecond)
+	svc.send(event.Event{Type: "test", Source: "test"})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1 (4xx is not retried)", calls)
+	}
+	st := svc.Endpoints()
+	if len(st) != 1 || st[0].Failed != 1 || st[0].LastDelivery == nil || st[0].LastDelivery.StatusCode != 400 {
+		t.Errorf("Endpoints() = %+v", st)
+	}
+}
+
+func TestWebhookService_EndpointFilters(t *testing.T) {
+	var mu sync.Mutex
+	received := map[string][]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p WebhookPayload
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &p)
+		mu.Lock()
+		received[r.URL.Path] = append(received[r.URL.Path], p.Type)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	svc := newTestWebhookService("", "", nil)
+	svc.AddEndpoint(WebhookEndpoint{Name: "pager", URL: server.URL + "/pager", Events: []string{"upstream.*"}})
+	svc.AddEndpoint(WebhookEndpoint{Name: "all", URL: server.URL + "/all"})
+
+	bus := event.NewBus(100)
+	bus.Start()
+	defer bus.Stop()
+	svc.SubscribeToBus(bus)
+	defer svc.Stop()
+
+	bus.Publish(context.TODO(), event.Event{Type: "drift.anomaly", Source: "test"})
+	bus.Publish(context.TODO(), event.Event{Type: "upstream.retries_exhausted", Source: "test"})
+	time.Sleep(150 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got := received["/pager"]; len(got) != 1 || got[0] != "upstream.retries_exhausted" {
+		t.Errorf("pager received %v", got)
+	}
+	if got := received["/all"]; len(got) != 2 {
+		t.Errorf("all received %v", got)
+	}
+	if st := svc.Endpoints(); len(st) != 2 || st[0].Name != "pager" || st[0].Delivered != 1 {
+		t.Errorf("Endpoints() = %+v", st)
+	}
+}
+
+func TestWebhookService_CategoryAndSeverityFilter(t *testing.T) {
+	var mu sync.Mutex
+	var received []WebhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p WebhookPayload
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &p)
+		mu.Lock()
+		received = append(received, p)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	svc := newTestWebhookService("", "", nil)
+	svc.AddEndpoint(WebhookEndpoint{Name: "security", URL: server.URL,
+		Categories: []event.Category{event.CategorySecurity}, MinSeverity: event.SeverityWarning})
+	defer svc.Stop()
+
+	ctx := context.TODO()
+	_ = svc.Deliver(ctx, event.Event{Type: "upstream.disconnected", Severity: event.SeverityCritical})
+	_ = svc.Deliver(ctx, event.Event{Type: "tool.new", Severity: event.SeverityInfo})
+	_ = svc.Deliver(ctx, event.Event{Type: "tool.changed", Severity: event.SeverityWarning})
+	time.Sleep(150 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 1 || received[0].Type != "tool.changed" || received[0].Category != "security" {
+		t.Errorf("received %+v", received)
+	}
+	if st := svc.Endpoints()[0]; st.MinSeverity != "warning" || len(st.Categories) != 1 {
+		t.Errorf("Endpoints() = %+v", st)
+	}
+}
+
+func TestWebhookEndpoint_DeliveryLogWraps(t *testing.T) {
+	ep := &webhookEndpoint{}
+	for i := 0; i < webhookDeliveryLogSize+5; i++ {
+		ep.record(WebhookDelivery{ID: strconv.Itoa(i), Success: true})
+	}
+	svc := &WebhookService{endpoints: []*webhookEndpoint{ep}}
+	log, _ := svc.Deliveries("")
+	if len(log) != webhookDeliveryLogSize {
+		t.Fatalf("len = %d, want %d", len(log), webhookDeliveryLogSize)
+	}
+	if log[0].ID != strconv.Itoa(webhookDeliveryLogSize+4) || log[len(log)-1].ID != "5" {
+		t.Errorf("newest = %s, oldest = %s", log[0].ID, log[len(log)-1].ID)
+	}
+	if ep.delivered != webhookDeliveryLogSize+5 {
+		t.Errorf("delivered = %d", ep.delivered)
+	}
+}
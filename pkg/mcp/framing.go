@@ -0,0 +1,303 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Framing is how JSON-RPC messages are delimited on a stdio stream.
+type Framing string
+
+const (
+	// FramingAuto detects the framing from the first message read and
+	// answers in kind. Until something has been read, messages are written
+	// newline-delimited.
+	FramingAuto Framing = "auto"
+	// FramingNewline is newline-delimited JSON, the MCP stdio default.
+	FramingNewline Framing = "newline"
+	// FramingContentLength is LSP-style framing: "Content-Length: N" headers,
+	// a blank line, then exactly N bytes of JSON.
+	FramingContentLength Framing = "content-length"
+)
+
+// MaxFrameSize bounds the body of a Content-Length framed message.
+const MaxFrameSize = 10 * 1024 * 1024
+
+// maxFrameHeaderBytes bounds the header block of a framed message.
+const maxFrameHeaderBytes = 8 * 1024
+
+// ParseFraming parses a framing name. The empty string means FramingAuto.
+func ParseFraming(s string) (Framing, error) {
+	switch f := Framing(strings.ToLower(strings.TrimSpace(s))); f {
+	case "":
+		return FramingAuto, nil
+	case FramingAuto, FramingNewline, FramingContentLength:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown framing %q (want auto, newline or content-length)", s)
+	}
+}
+
+// FrameReader reads messages in either framing and yields them as
+// newline-delimited JSON, so code that reads stdio streams line by line works
+// unchanged. Newline-delimited input passes through untouched; each framed
+// body is compacted onto a single line.
+type FrameReader struct {
+	br      *bufio.Reader
+	mu      sync.Mutex
+	framing Framing
+	pending []byte
+}
+
+// NewFrameReader returns a FrameReader over r. With FramingAuto the framing is
+// detected from the first message and kept for the rest of the stream.
+func NewFrameReader(r io.Reader, framing Framing) *FrameReader {
+	return &FrameReader{br: bufio.NewReaderSize(r, 64*1024), framing: framing}
+}
+
+// Detected returns the framing in use, or FramingAuto while nothing has been
+// read yet.
+func (r *FrameReader) Detected() Framing {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.framing
+}
+
+// Read implements io.Reader.
+func (r *FrameReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	framing := r.Detected()
+	if framing == FramingAuto {
+		detected, err := r.detect()
+		if err != nil {
+			return 0, err
+		}
+		r.mu.Lock()
+		r.framing = detected
+		r.mu.Unlock()
+		framing = detected
+	}
+	if framing == FramingNewline {
+		return r.br.Read(p)
+	}
+
+	for len(r.pending) == 0 {
+		body, err := r.readFrame()
+		if err != nil {
+			return 0, err
+		}
+		r.pending = singleLine(body)
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// detect skips leading whitespace and picks the framing from what follows:
+// a "Content-" header (Content-Length or Content-Type) starts a framed
+// message; anything else, including malformed input, is treated as
+// newline-delimited so it gets the usual parse error.
+func (r *FrameReader) detect() (Framing, error) {
+	for {
+		b, err := r.br.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		_ = r.br.UnreadByte()
+		if b != 'C' && b != 'c' {
+			return FramingNewline, nil
+		}
+		const prefix = "content-"
+		head, _ := r.br.Peek(len(prefix))
+		if strings.EqualFold(string(head), prefix) {
+			return FramingContentLength, nil
+		}
+		return FramingNewline, nil
+	}
+}
+
+// readFrame reads one header block and the body it announces.
+func (r *FrameReader) readFrame() ([]byte, error) {
+	length := -1
+	headerBytes := 0
+	sawHeader := false
+	for {
+		line, err := r.br.ReadString('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) && !sawHeader && strings.TrimSpace(line) == "" {
+				return nil, io.EOF
+			}
+			if errors.Is(err, io.EOF) {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		headerBytes += len(line)
+		if headerBytes > maxFrameHeaderBytes {
+			return nil, errors.New("frame header too large")
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if !sawHeader {
+				continue // stray blank line between frames
+			}
+			break
+		}
+		sawHeader = true
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("malformed frame header %q", line)
+		}
+		if strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid Content-Length %q", strings.TrimSpace(value))
+			}
+			length = n
+		}
+	}
+	if length < 0 {
+		return nil, errors.New("frame without Content-Length header")
+	}
+	if length > MaxFrameSize {
+		return nil, fmt.Errorf("frame of %d bytes exceeds limit of %d", length, MaxFrameSize)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r.br, body); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return body, nil
+}
+
+// singleLine returns body as one newline-terminated line. Valid JSON is
+// compacted; anything else has its line breaks replaced so it still reaches
+// the reader as a single (malformed) message.
+func singleLine(body []byte) []byte {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, body); err != nil {
+		buf.Reset()
+		buf.Write(bytes.Map(func(r rune) rune {
+			if r == '\n' || r == '\r' {
+				return ' '
+			}
+			return r
+		}, body))
+	}
+	if buf.Len() == 0 {
+		return nil
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+// FrameWriter accepts newline-delimited JSON and writes each line in the
+// configured framing. Lines may arrive split across several Write calls.
+type FrameWriter struct {
+	w       io.Writer
+	framing Framing
+	peer    *FrameReader
+	mu      sync.Mutex
+	buf     []byte
+}
+
+// NewFrameWriter returns a FrameWriter over w. With FramingAuto, messages are
+// written in the framing peer has detected on the other direction of the
+// stream (newline-delimited while unknown or when peer is nil).
+func NewFrameWriter(w io.Writer, framing Framing, peer *FrameReader) *FrameWriter {
+	return &FrameWriter{w: w, framing: framing, peer: peer}
+}
+
+// Write implements io.Writer. The returned count covers all of p once the
+// complete lines in it have been written.
+func (fw *FrameWriter) Write(p []byte) (int, error) {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	if fw.framing == FramingNewline {
+		return fw.w.Write(p)
+	}
+
+	fw.buf = append(fw.buf, p...)
+	for {
+		i := bytes.IndexByte(fw.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		line := fw.buf[:i+1]
+		if err := fw.writeLine(line); err != nil {
+			fw.buf = fw.buf[i+1:]
+			return 0, err
+		}
+		fw.buf = fw.buf[i+1:]
+		if len(fw.buf) == 0 {
+			fw.buf = nil
+		}
+	}
+}
+
+// writeLine writes one newline-terminated message. Must be called with fw.mu held.
+func (fw *FrameWriter) writeLine(line []byte) error {
+	if fw.currentFraming() != FramingContentLength {
+		_, err := fw.w.Write(line)
+		return err
+	}
+	body := bytes.TrimRight(line, "\r\n")
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	// Header and body go out in a single Write so concurrent writers to the
+	// same pipe cannot interleave inside a frame.
+	frame := make([]byte, 0, len(body)+32)
+	frame = append(frame, "Content-Length: "...)
+	frame = strconv.AppendInt(frame, int64(len(body)), 10)
+	frame = append(frame, "\r\n\r\n"...)
+	frame = append(frame, body...)
+	_, err := fw.w.Write(frame)
+	return err
+}
+
+func (fw *FrameWriter) currentFraming() Framing {
+	if fw.framing != FramingAuto {
+		return fw.framing
+	}
+	if fw.peer != nil {
+		if f := fw.peer.Detected(); f == FramingContentLength {
+			return f
+		}
+	}
+	return FramingNewline
+}
+
+// Close closes the underlying writer if it is an io.Closer. A trailing
+// message without a newline is flushed first.
+func (fw *FrameWriter) Close() error {
+	fw.mu.Lock()
+	var err error
+	if len(bytes.TrimSpace(fw.buf)) > 0 {
+		err = fw.writeLine(append(fw.buf, '\n'))
+	}
+	fw.buf = nil
+	fw.mu.Unlock()
+	if c, ok := fw.w.(io.Closer); ok {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
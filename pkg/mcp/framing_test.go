@@ -0,0 +1,144 @@
+package mcp
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestParseFraming(t *testing.T) {
+	for in, want := range map[string]Framing{"": FramingAuto, "auto": FramingAuto, "Newline": FramingNewline, " content-length ": FramingContentLength} {
+		got, err := ParseFraming(in)
+		if err != nil || got != want {
+			t.Errorf("ParseFraming(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseFraming("lsp"); err == nil {
+		t.Error("ParseFraming(lsp) should fail")
+	}
+}
+
+func TestFrameReader(t *testing.T) {
+	framed := "Content-Length: 30\r\nContent-Type: application/vscode-jsonrpc; charset=utf-8\r\n\r\n" +
+		"{\n  \"id\": 1,\n  \"method\": \"a\"\n}" +
+		"\r\n" + // stray line break between frames
+		"content-length: 21\r\n\r\n{\"id\":2,\"method\":\"b\"}\n\n"
+
+	tests := []struct {
+		name    string
+		framing Framing
+		input   string
+		want    string
+		detect  Framing
+	}{
+		{"auto detects framed", FramingAuto, framed, "{\"id\":1,\"method\":\"a\"}\n{\"id\":2,\"method\":\"b\"}\n", FramingContentLength},
+		{"auto passes newline through", FramingAuto, "\n{\"id\":1}\n{\"id\":2}\n", "{\"id\":1}\n{\"id\":2}\n", FramingNewline},
+		{"auto treats garbage as newline", FramingAuto, "hello\n", "hello\n", FramingNewline},
+		{"explicit newline", FramingNewline, "{}\n", "{}\n", FramingNewline},
+		{"explicit framed", FramingContentLength, "Content-Length: 2\r\n\r\n{}", "{}\n", FramingContentLength},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewFrameReader(strings.NewReader(tt.input), tt.framing)
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("ReadAll: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if r.Detected() != tt.detect {
+				t.Errorf("Detected() = %q, want %q", r.Detected(), tt.detect)
+			}
+		})
+	}
+}
+
+func TestFrameReader_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"missing length", "Content-Type: x\r\n\r\n{}"},
+		{"bad length", "Content-Length: -4\r\n\r\n{}"},
+		{"too large", "Content-Length: 99999999999\r\n\r\n"},
+		{"truncated body", "Content-Length: 10\r\n\r\n{}"},
+		{"malformed header", "Content-Length: 2\r\nbogus\r\n\r\n{}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := io.ReadAll(NewFrameReader(strings.NewReader(tt.input), FramingContentLength))
+			if err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	_, err := io.ReadAll(NewFrameReader(strings.NewReader("Content-Length: 10\r\n\r\n{}"), FramingContentLength))
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("truncated body error = %v, want io.ErrUnexpectedEOF", err)
+	}
+}
+
+func TestFrameWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := NewFrameWriter(&out, FramingContentLength, nil)
+	// A message split over two writes, as ProxyService error paths do.
+	_, _ = w.Write([]byte(`{"id":1}`))
+	if out.Len() != 0 {
+		t.Fatalf("partial line written early: %q", out.String())
+	}
+	_, _ = w.Write([]byte("\n{\"id\":2}\n\n"))
+	want := "Content-Length: 8\r\n\r\n{\"id\":1}Content-Length: 8\r\n\r\n{\"id\":2}"
+	if out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+
+	// Round trip through a reader.
+	got, err := io.ReadAll(NewFrameReader(&out, FramingAuto))
+	if err != nil || string(got) != "{\"id\":1}\n{\"id\":2}\n" {
+		t.Errorf("round trip = %q, %v", got, err)
+	}
+}
+
+func TestFrameWriter_AutoFollowsPeer(t *testing.T) {
+	var out bytes.Buffer
+	peer := NewFrameReader(strings.NewReader("Content-Length: 2\r\n\r\n{}"), FramingAuto)
+	w := NewFrameWriter(&out, FramingAuto, peer)
+
+	_, _ = w.Write([]byte("{\"early\":true}\n"))
+	if out.String() != "{\"early\":true}\n" {
+		t.Fatalf("before detection got %q, want newline-delimited", out.String())
+	}
+
+	_, _ = io.ReadAll(peer)
+	out.Reset()
+	_, _ = w.Write([]byte("{}\n"))
+	if out.String() != "Content-Length: 2\r\n\r\n{}" {
+		t.Errorf("after detection got %q, want a frame", out.String())
+	}
+}
+
+type closeRecorder struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestFrameWriter_CloseFlushes(t *testing.T) {
+	out := &closeRecorder{}
+	w := NewFrameWriter(out, FramingContentLength, nil)
+	_, _ = w.Write([]byte(`{"id":3}`))
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if out.String() != "Content-Length: 8\r\n\r\n{\"id\":3}" || !out.closed {
+		t.Errorf("after Close got %q closed=%v", out.String(), out.closed)
+	}
+}
@@ -198,6 +198,31 @@ func (m *Message) ExtractAPIKey() string {
 	return ""
 }
 
+// ExtractLabels returns the session labels a client sent in
+// params._meta.labels (e.g., on initialize). Non-string values are skipped;
+// returns nil when there are none.
+func (m *Message) ExtractLabels() map[string]string {
+	params := m.ParsedParams
+	if params == nil {
+		params = m.ParseParams()
+	}
+	meta, ok := params["_meta"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	raw, ok := meta["labels"].(map[string]interface{})
+	if !ok || len(raw) == 0 {
+		return nil
+	}
+	labels := make(map[string]string, len(raw))
+	for k, v := range raw {
+		if s, ok := v.(string); ok {
+			labels[k] = s
+		}
+	}
+	return labels
+}
+
 // HasFrameworkContext returns true if the message has framework context.
 func (m *Message) HasFrameworkContext() bool {
 	return m.FrameworkContext != nil